@@ -0,0 +1,677 @@
+// Tempest HomeKit bridge gRPC API.
+//
+// Regenerate the Go bindings after editing this file:
+//
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//          --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//          api/proto/tempest.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: api/proto/tempest.proto
+
+package tempestpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Observation mirrors the JSON observation used by the REST API. Values are in
+// WeatherFlow base units (°C, m/s, mb, mm).
+type Observation struct {
+	state                      protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp                  int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	WindLull                   float64                `protobuf:"fixed64,2,opt,name=wind_lull,json=windLull,proto3" json:"wind_lull,omitempty"`
+	WindAvg                    float64                `protobuf:"fixed64,3,opt,name=wind_avg,json=windAvg,proto3" json:"wind_avg,omitempty"`
+	WindGust                   float64                `protobuf:"fixed64,4,opt,name=wind_gust,json=windGust,proto3" json:"wind_gust,omitempty"`
+	WindDirection              float64                `protobuf:"fixed64,5,opt,name=wind_direction,json=windDirection,proto3" json:"wind_direction,omitempty"`
+	StationPressure            float64                `protobuf:"fixed64,6,opt,name=station_pressure,json=stationPressure,proto3" json:"station_pressure,omitempty"`
+	AirTemperature             float64                `protobuf:"fixed64,7,opt,name=air_temperature,json=airTemperature,proto3" json:"air_temperature,omitempty"`
+	RelativeHumidity           float64                `protobuf:"fixed64,8,opt,name=relative_humidity,json=relativeHumidity,proto3" json:"relative_humidity,omitempty"`
+	Illuminance                float64                `protobuf:"fixed64,9,opt,name=illuminance,proto3" json:"illuminance,omitempty"`
+	Uv                         int32                  `protobuf:"varint,10,opt,name=uv,proto3" json:"uv,omitempty"`
+	SolarRadiation             float64                `protobuf:"fixed64,11,opt,name=solar_radiation,json=solarRadiation,proto3" json:"solar_radiation,omitempty"`
+	RainAccumulated            float64                `protobuf:"fixed64,12,opt,name=rain_accumulated,json=rainAccumulated,proto3" json:"rain_accumulated,omitempty"`
+	RainDailyTotal             float64                `protobuf:"fixed64,13,opt,name=rain_daily_total,json=rainDailyTotal,proto3" json:"rain_daily_total,omitempty"`
+	PrecipitationType          int32                  `protobuf:"varint,14,opt,name=precipitation_type,json=precipitationType,proto3" json:"precipitation_type,omitempty"`
+	LightningStrikeAvgDistance float64                `protobuf:"fixed64,15,opt,name=lightning_strike_avg_distance,json=lightningStrikeAvgDistance,proto3" json:"lightning_strike_avg_distance,omitempty"`
+	LightningStrikeCount       int32                  `protobuf:"varint,16,opt,name=lightning_strike_count,json=lightningStrikeCount,proto3" json:"lightning_strike_count,omitempty"`
+	Battery                    float64                `protobuf:"fixed64,17,opt,name=battery,proto3" json:"battery,omitempty"`
+	ReportInterval             int32                  `protobuf:"varint,18,opt,name=report_interval,json=reportInterval,proto3" json:"report_interval,omitempty"`
+	unknownFields              protoimpl.UnknownFields
+	sizeCache                  protoimpl.SizeCache
+}
+
+func (x *Observation) Reset() {
+	*x = Observation{}
+	mi := &file_api_proto_tempest_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Observation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Observation) ProtoMessage() {}
+
+func (x *Observation) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_tempest_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Observation.ProtoReflect.Descriptor instead.
+func (*Observation) Descriptor() ([]byte, []int) {
+	return file_api_proto_tempest_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Observation) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Observation) GetWindLull() float64 {
+	if x != nil {
+		return x.WindLull
+	}
+	return 0
+}
+
+func (x *Observation) GetWindAvg() float64 {
+	if x != nil {
+		return x.WindAvg
+	}
+	return 0
+}
+
+func (x *Observation) GetWindGust() float64 {
+	if x != nil {
+		return x.WindGust
+	}
+	return 0
+}
+
+func (x *Observation) GetWindDirection() float64 {
+	if x != nil {
+		return x.WindDirection
+	}
+	return 0
+}
+
+func (x *Observation) GetStationPressure() float64 {
+	if x != nil {
+		return x.StationPressure
+	}
+	return 0
+}
+
+func (x *Observation) GetAirTemperature() float64 {
+	if x != nil {
+		return x.AirTemperature
+	}
+	return 0
+}
+
+func (x *Observation) GetRelativeHumidity() float64 {
+	if x != nil {
+		return x.RelativeHumidity
+	}
+	return 0
+}
+
+func (x *Observation) GetIlluminance() float64 {
+	if x != nil {
+		return x.Illuminance
+	}
+	return 0
+}
+
+func (x *Observation) GetUv() int32 {
+	if x != nil {
+		return x.Uv
+	}
+	return 0
+}
+
+func (x *Observation) GetSolarRadiation() float64 {
+	if x != nil {
+		return x.SolarRadiation
+	}
+	return 0
+}
+
+func (x *Observation) GetRainAccumulated() float64 {
+	if x != nil {
+		return x.RainAccumulated
+	}
+	return 0
+}
+
+func (x *Observation) GetRainDailyTotal() float64 {
+	if x != nil {
+		return x.RainDailyTotal
+	}
+	return 0
+}
+
+func (x *Observation) GetPrecipitationType() int32 {
+	if x != nil {
+		return x.PrecipitationType
+	}
+	return 0
+}
+
+func (x *Observation) GetLightningStrikeAvgDistance() float64 {
+	if x != nil {
+		return x.LightningStrikeAvgDistance
+	}
+	return 0
+}
+
+func (x *Observation) GetLightningStrikeCount() int32 {
+	if x != nil {
+		return x.LightningStrikeCount
+	}
+	return 0
+}
+
+func (x *Observation) GetBattery() float64 {
+	if x != nil {
+		return x.Battery
+	}
+	return 0
+}
+
+func (x *Observation) GetReportInterval() int32 {
+	if x != nil {
+		return x.ReportInterval
+	}
+	return 0
+}
+
+type GetCurrentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCurrentRequest) Reset() {
+	*x = GetCurrentRequest{}
+	mi := &file_api_proto_tempest_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCurrentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCurrentRequest) ProtoMessage() {}
+
+func (x *GetCurrentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_tempest_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCurrentRequest.ProtoReflect.Descriptor instead.
+func (*GetCurrentRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_tempest_proto_rawDescGZIP(), []int{1}
+}
+
+type StreamObservationsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamObservationsRequest) Reset() {
+	*x = StreamObservationsRequest{}
+	mi := &file_api_proto_tempest_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamObservationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamObservationsRequest) ProtoMessage() {}
+
+func (x *StreamObservationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_tempest_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamObservationsRequest.ProtoReflect.Descriptor instead.
+func (*StreamObservationsRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_tempest_proto_rawDescGZIP(), []int{2}
+}
+
+type Alarm struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Name            string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description     string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Condition       string                 `protobuf:"bytes,3,opt,name=condition,proto3" json:"condition,omitempty"`
+	Enabled         bool                   `protobuf:"varint,4,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Tags            []string               `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
+	CooldownSeconds int32                  `protobuf:"varint,6,opt,name=cooldown_seconds,json=cooldownSeconds,proto3" json:"cooldown_seconds,omitempty"`
+	Channels        []string               `protobuf:"bytes,7,rep,name=channels,proto3" json:"channels,omitempty"` // channel types, e.g. "console", "email"
+	TriggeredCount  int32                  `protobuf:"varint,8,opt,name=triggered_count,json=triggeredCount,proto3" json:"triggered_count,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Alarm) Reset() {
+	*x = Alarm{}
+	mi := &file_api_proto_tempest_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Alarm) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Alarm) ProtoMessage() {}
+
+func (x *Alarm) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_tempest_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Alarm.ProtoReflect.Descriptor instead.
+func (*Alarm) Descriptor() ([]byte, []int) {
+	return file_api_proto_tempest_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Alarm) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Alarm) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Alarm) GetCondition() string {
+	if x != nil {
+		return x.Condition
+	}
+	return ""
+}
+
+func (x *Alarm) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *Alarm) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Alarm) GetCooldownSeconds() int32 {
+	if x != nil {
+		return x.CooldownSeconds
+	}
+	return 0
+}
+
+func (x *Alarm) GetChannels() []string {
+	if x != nil {
+		return x.Channels
+	}
+	return nil
+}
+
+func (x *Alarm) GetTriggeredCount() int32 {
+	if x != nil {
+		return x.TriggeredCount
+	}
+	return 0
+}
+
+type ListAlarmsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAlarmsRequest) Reset() {
+	*x = ListAlarmsRequest{}
+	mi := &file_api_proto_tempest_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAlarmsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAlarmsRequest) ProtoMessage() {}
+
+func (x *ListAlarmsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_tempest_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAlarmsRequest.ProtoReflect.Descriptor instead.
+func (*ListAlarmsRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_tempest_proto_rawDescGZIP(), []int{4}
+}
+
+type ListAlarmsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alarms        []*Alarm               `protobuf:"bytes,1,rep,name=alarms,proto3" json:"alarms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAlarmsResponse) Reset() {
+	*x = ListAlarmsResponse{}
+	mi := &file_api_proto_tempest_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAlarmsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAlarmsResponse) ProtoMessage() {}
+
+func (x *ListAlarmsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_tempest_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAlarmsResponse.ProtoReflect.Descriptor instead.
+func (*ListAlarmsResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_tempest_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListAlarmsResponse) GetAlarms() []*Alarm {
+	if x != nil {
+		return x.Alarms
+	}
+	return nil
+}
+
+type TriggerTestRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerTestRequest) Reset() {
+	*x = TriggerTestRequest{}
+	mi := &file_api_proto_tempest_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerTestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerTestRequest) ProtoMessage() {}
+
+func (x *TriggerTestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_tempest_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerTestRequest.ProtoReflect.Descriptor instead.
+func (*TriggerTestRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_tempest_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TriggerTestRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type TriggerTestResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channels      int32                  `protobuf:"varint,1,opt,name=channels,proto3" json:"channels,omitempty"` // number of channels a notification was attempted on
+	Errors        []string               `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty"`      // per-channel delivery failures
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerTestResponse) Reset() {
+	*x = TriggerTestResponse{}
+	mi := &file_api_proto_tempest_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerTestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerTestResponse) ProtoMessage() {}
+
+func (x *TriggerTestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_tempest_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerTestResponse.ProtoReflect.Descriptor instead.
+func (*TriggerTestResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_tempest_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TriggerTestResponse) GetChannels() int32 {
+	if x != nil {
+		return x.Channels
+	}
+	return 0
+}
+
+func (x *TriggerTestResponse) GetErrors() []string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+var File_api_proto_tempest_proto protoreflect.FileDescriptor
+
+const file_api_proto_tempest_proto_rawDesc = "" +
+	"\n" +
+	"\x17api/proto/tempest.proto\x12\n" +
+	"tempest.v1\"\xc3\x05\n" +
+	"\vObservation\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\x12\x1b\n" +
+	"\twind_lull\x18\x02 \x01(\x01R\bwindLull\x12\x19\n" +
+	"\bwind_avg\x18\x03 \x01(\x01R\awindAvg\x12\x1b\n" +
+	"\twind_gust\x18\x04 \x01(\x01R\bwindGust\x12%\n" +
+	"\x0ewind_direction\x18\x05 \x01(\x01R\rwindDirection\x12)\n" +
+	"\x10station_pressure\x18\x06 \x01(\x01R\x0fstationPressure\x12'\n" +
+	"\x0fair_temperature\x18\a \x01(\x01R\x0eairTemperature\x12+\n" +
+	"\x11relative_humidity\x18\b \x01(\x01R\x10relativeHumidity\x12 \n" +
+	"\villuminance\x18\t \x01(\x01R\villuminance\x12\x0e\n" +
+	"\x02uv\x18\n" +
+	" \x01(\x05R\x02uv\x12'\n" +
+	"\x0fsolar_radiation\x18\v \x01(\x01R\x0esolarRadiation\x12)\n" +
+	"\x10rain_accumulated\x18\f \x01(\x01R\x0frainAccumulated\x12(\n" +
+	"\x10rain_daily_total\x18\r \x01(\x01R\x0erainDailyTotal\x12-\n" +
+	"\x12precipitation_type\x18\x0e \x01(\x05R\x11precipitationType\x12A\n" +
+	"\x1dlightning_strike_avg_distance\x18\x0f \x01(\x01R\x1alightningStrikeAvgDistance\x124\n" +
+	"\x16lightning_strike_count\x18\x10 \x01(\x05R\x14lightningStrikeCount\x12\x18\n" +
+	"\abattery\x18\x11 \x01(\x01R\abattery\x12'\n" +
+	"\x0freport_interval\x18\x12 \x01(\x05R\x0ereportInterval\"\x13\n" +
+	"\x11GetCurrentRequest\"\x1b\n" +
+	"\x19StreamObservationsRequest\"\xf9\x01\n" +
+	"\x05Alarm\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x1c\n" +
+	"\tcondition\x18\x03 \x01(\tR\tcondition\x12\x18\n" +
+	"\aenabled\x18\x04 \x01(\bR\aenabled\x12\x12\n" +
+	"\x04tags\x18\x05 \x03(\tR\x04tags\x12)\n" +
+	"\x10cooldown_seconds\x18\x06 \x01(\x05R\x0fcooldownSeconds\x12\x1a\n" +
+	"\bchannels\x18\a \x03(\tR\bchannels\x12'\n" +
+	"\x0ftriggered_count\x18\b \x01(\x05R\x0etriggeredCount\"\x13\n" +
+	"\x11ListAlarmsRequest\"?\n" +
+	"\x12ListAlarmsResponse\x12)\n" +
+	"\x06alarms\x18\x01 \x03(\v2\x11.tempest.v1.AlarmR\x06alarms\"(\n" +
+	"\x12TriggerTestRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"I\n" +
+	"\x13TriggerTestResponse\x12\x1a\n" +
+	"\bchannels\x18\x01 \x01(\x05R\bchannels\x12\x16\n" +
+	"\x06errors\x18\x02 \x03(\tR\x06errors2\xc4\x02\n" +
+	"\aTempest\x12D\n" +
+	"\n" +
+	"GetCurrent\x12\x1d.tempest.v1.GetCurrentRequest\x1a\x17.tempest.v1.Observation\x12V\n" +
+	"\x12StreamObservations\x12%.tempest.v1.StreamObservationsRequest\x1a\x17.tempest.v1.Observation0\x01\x12K\n" +
+	"\n" +
+	"ListAlarms\x12\x1d.tempest.v1.ListAlarmsRequest\x1a\x1e.tempest.v1.ListAlarmsResponse\x12N\n" +
+	"\vTriggerTest\x12\x1e.tempest.v1.TriggerTestRequest\x1a\x1f.tempest.v1.TriggerTestResponseB(Z&tempest-homekit-go/api/proto;tempestpbb\x06proto3"
+
+var (
+	file_api_proto_tempest_proto_rawDescOnce sync.Once
+	file_api_proto_tempest_proto_rawDescData []byte
+)
+
+func file_api_proto_tempest_proto_rawDescGZIP() []byte {
+	file_api_proto_tempest_proto_rawDescOnce.Do(func() {
+		file_api_proto_tempest_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_proto_tempest_proto_rawDesc), len(file_api_proto_tempest_proto_rawDesc)))
+	})
+	return file_api_proto_tempest_proto_rawDescData
+}
+
+var file_api_proto_tempest_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_api_proto_tempest_proto_goTypes = []any{
+	(*Observation)(nil),               // 0: tempest.v1.Observation
+	(*GetCurrentRequest)(nil),         // 1: tempest.v1.GetCurrentRequest
+	(*StreamObservationsRequest)(nil), // 2: tempest.v1.StreamObservationsRequest
+	(*Alarm)(nil),                     // 3: tempest.v1.Alarm
+	(*ListAlarmsRequest)(nil),         // 4: tempest.v1.ListAlarmsRequest
+	(*ListAlarmsResponse)(nil),        // 5: tempest.v1.ListAlarmsResponse
+	(*TriggerTestRequest)(nil),        // 6: tempest.v1.TriggerTestRequest
+	(*TriggerTestResponse)(nil),       // 7: tempest.v1.TriggerTestResponse
+}
+var file_api_proto_tempest_proto_depIdxs = []int32{
+	3, // 0: tempest.v1.ListAlarmsResponse.alarms:type_name -> tempest.v1.Alarm
+	1, // 1: tempest.v1.Tempest.GetCurrent:input_type -> tempest.v1.GetCurrentRequest
+	2, // 2: tempest.v1.Tempest.StreamObservations:input_type -> tempest.v1.StreamObservationsRequest
+	4, // 3: tempest.v1.Tempest.ListAlarms:input_type -> tempest.v1.ListAlarmsRequest
+	6, // 4: tempest.v1.Tempest.TriggerTest:input_type -> tempest.v1.TriggerTestRequest
+	0, // 5: tempest.v1.Tempest.GetCurrent:output_type -> tempest.v1.Observation
+	0, // 6: tempest.v1.Tempest.StreamObservations:output_type -> tempest.v1.Observation
+	5, // 7: tempest.v1.Tempest.ListAlarms:output_type -> tempest.v1.ListAlarmsResponse
+	7, // 8: tempest.v1.Tempest.TriggerTest:output_type -> tempest.v1.TriggerTestResponse
+	5, // [5:9] is the sub-list for method output_type
+	1, // [1:5] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_api_proto_tempest_proto_init() }
+func file_api_proto_tempest_proto_init() {
+	if File_api_proto_tempest_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_tempest_proto_rawDesc), len(file_api_proto_tempest_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_proto_tempest_proto_goTypes,
+		DependencyIndexes: file_api_proto_tempest_proto_depIdxs,
+		MessageInfos:      file_api_proto_tempest_proto_msgTypes,
+	}.Build()
+	File_api_proto_tempest_proto = out.File
+	file_api_proto_tempest_proto_goTypes = nil
+	file_api_proto_tempest_proto_depIdxs = nil
+}
@@ -0,0 +1,263 @@
+// Tempest HomeKit bridge gRPC API.
+//
+// Regenerate the Go bindings after editing this file:
+//
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//          --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//          api/proto/tempest.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             v5.29.3
+// source: api/proto/tempest.proto
+
+package tempestpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Tempest_GetCurrent_FullMethodName         = "/tempest.v1.Tempest/GetCurrent"
+	Tempest_StreamObservations_FullMethodName = "/tempest.v1.Tempest/StreamObservations"
+	Tempest_ListAlarms_FullMethodName         = "/tempest.v1.Tempest/ListAlarms"
+	Tempest_TriggerTest_FullMethodName        = "/tempest.v1.Tempest/TriggerTest"
+)
+
+// TempestClient is the client API for Tempest service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Tempest exposes live observations and alarm control.
+type TempestClient interface {
+	// GetCurrent returns the most recent observation.
+	GetCurrent(ctx context.Context, in *GetCurrentRequest, opts ...grpc.CallOption) (*Observation, error)
+	// StreamObservations sends the most recent observation followed by every
+	// new observation until the client cancels.
+	StreamObservations(ctx context.Context, in *StreamObservationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Observation], error)
+	// ListAlarms returns the configured alarms.
+	ListAlarms(ctx context.Context, in *ListAlarmsRequest, opts ...grpc.CallOption) (*ListAlarmsResponse, error)
+	// TriggerTest sends a test notification for one alarm through all of its
+	// channels, regardless of its condition and cooldown.
+	TriggerTest(ctx context.Context, in *TriggerTestRequest, opts ...grpc.CallOption) (*TriggerTestResponse, error)
+}
+
+type tempestClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTempestClient(cc grpc.ClientConnInterface) TempestClient {
+	return &tempestClient{cc}
+}
+
+func (c *tempestClient) GetCurrent(ctx context.Context, in *GetCurrentRequest, opts ...grpc.CallOption) (*Observation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Observation)
+	err := c.cc.Invoke(ctx, Tempest_GetCurrent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tempestClient) StreamObservations(ctx context.Context, in *StreamObservationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Observation], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Tempest_ServiceDesc.Streams[0], Tempest_StreamObservations_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamObservationsRequest, Observation]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Tempest_StreamObservationsClient = grpc.ServerStreamingClient[Observation]
+
+func (c *tempestClient) ListAlarms(ctx context.Context, in *ListAlarmsRequest, opts ...grpc.CallOption) (*ListAlarmsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAlarmsResponse)
+	err := c.cc.Invoke(ctx, Tempest_ListAlarms_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tempestClient) TriggerTest(ctx context.Context, in *TriggerTestRequest, opts ...grpc.CallOption) (*TriggerTestResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TriggerTestResponse)
+	err := c.cc.Invoke(ctx, Tempest_TriggerTest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TempestServer is the server API for Tempest service.
+// All implementations must embed UnimplementedTempestServer
+// for forward compatibility.
+//
+// Tempest exposes live observations and alarm control.
+type TempestServer interface {
+	// GetCurrent returns the most recent observation.
+	GetCurrent(context.Context, *GetCurrentRequest) (*Observation, error)
+	// StreamObservations sends the most recent observation followed by every
+	// new observation until the client cancels.
+	StreamObservations(*StreamObservationsRequest, grpc.ServerStreamingServer[Observation]) error
+	// ListAlarms returns the configured alarms.
+	ListAlarms(context.Context, *ListAlarmsRequest) (*ListAlarmsResponse, error)
+	// TriggerTest sends a test notification for one alarm through all of its
+	// channels, regardless of its condition and cooldown.
+	TriggerTest(context.Context, *TriggerTestRequest) (*TriggerTestResponse, error)
+	mustEmbedUnimplementedTempestServer()
+}
+
+// UnimplementedTempestServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTempestServer struct{}
+
+func (UnimplementedTempestServer) GetCurrent(context.Context, *GetCurrentRequest) (*Observation, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetCurrent not implemented")
+}
+func (UnimplementedTempestServer) StreamObservations(*StreamObservationsRequest, grpc.ServerStreamingServer[Observation]) error {
+	return status.Error(codes.Unimplemented, "method StreamObservations not implemented")
+}
+func (UnimplementedTempestServer) ListAlarms(context.Context, *ListAlarmsRequest) (*ListAlarmsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListAlarms not implemented")
+}
+func (UnimplementedTempestServer) TriggerTest(context.Context, *TriggerTestRequest) (*TriggerTestResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method TriggerTest not implemented")
+}
+func (UnimplementedTempestServer) mustEmbedUnimplementedTempestServer() {}
+func (UnimplementedTempestServer) testEmbeddedByValue()                 {}
+
+// UnsafeTempestServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TempestServer will
+// result in compilation errors.
+type UnsafeTempestServer interface {
+	mustEmbedUnimplementedTempestServer()
+}
+
+func RegisterTempestServer(s grpc.ServiceRegistrar, srv TempestServer) {
+	// If the following call panics, it indicates UnimplementedTempestServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Tempest_ServiceDesc, srv)
+}
+
+func _Tempest_GetCurrent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCurrentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TempestServer).GetCurrent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Tempest_GetCurrent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TempestServer).GetCurrent(ctx, req.(*GetCurrentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Tempest_StreamObservations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamObservationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TempestServer).StreamObservations(m, &grpc.GenericServerStream[StreamObservationsRequest, Observation]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Tempest_StreamObservationsServer = grpc.ServerStreamingServer[Observation]
+
+func _Tempest_ListAlarms_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAlarmsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TempestServer).ListAlarms(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Tempest_ListAlarms_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TempestServer).ListAlarms(ctx, req.(*ListAlarmsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Tempest_TriggerTest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TriggerTestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TempestServer).TriggerTest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Tempest_TriggerTest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TempestServer).TriggerTest(ctx, req.(*TriggerTestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Tempest_ServiceDesc is the grpc.ServiceDesc for Tempest service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Tempest_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "tempest.v1.Tempest",
+	HandlerType: (*TempestServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCurrent",
+			Handler:    _Tempest_GetCurrent_Handler,
+		},
+		{
+			MethodName: "ListAlarms",
+			Handler:    _Tempest_ListAlarms_Handler,
+		},
+		{
+			MethodName: "TriggerTest",
+			Handler:    _Tempest_TriggerTest_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamObservations",
+			Handler:       _Tempest_StreamObservations_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/proto/tempest.proto",
+}
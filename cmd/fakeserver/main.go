@@ -0,0 +1,47 @@
+// Command fakeserver serves recorded WeatherFlow REST API responses for
+// integration tests and demos. Run it and start the app with
+// --weatherflow-api-url http://localhost:8090/swd/rest and any token.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"tempest-homekit-go/pkg/fakeapi"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "Address to listen on")
+	latency := flag.Duration("latency", 0, "Delay added to every API response (e.g., 500ms)")
+	errorRate := flag.Float64("error-rate", 0, "Share of API requests answered with -error-status, 0 to 1")
+	errorStatus := flag.Int("error-status", http.StatusServiceUnavailable, "HTTP status of injected errors (429 adds Retry-After: 1)")
+	recordings := flag.String("recordings", "", "Directory of recordings replacing the built-in ones (stations.json, observations_station.json, observations_device.json, better_forecast.json)")
+	seed := flag.Int64("seed", 0, "Seed for error injection (0 uses the clock)")
+	flag.Parse()
+
+	if *errorRate < 0 || *errorRate > 1 {
+		fmt.Fprintf(os.Stderr, "invalid error rate %v. Use a value from 0 to 1\n", *errorRate)
+		os.Exit(2)
+	}
+
+	server, err := fakeapi.New(fakeapi.Options{
+		Dir:         *recordings,
+		Latency:     *latency,
+		ErrorRate:   *errorRate,
+		ErrorStatus: *errorStatus,
+		Seed:        *seed,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load recordings: %v", err)
+	}
+
+	log.Printf("Fake WeatherFlow API listening on %s%s (latency %v, error rate %.2f)", *addr, fakeapi.BasePath, *latency, *errorRate)
+	log.Printf("Use --weatherflow-api-url http://localhost%s%s --token fake --station \"Fake Station\"", *addr, fakeapi.BasePath)
+	log.Printf("Change faults at runtime with POST %s/fake/control", *addr)
+	srv := &http.Server{Addr: *addr, Handler: server, ReadHeaderTimeout: 10 * time.Second}
+	log.Fatal(srv.ListenAndServe())
+}
@@ -10,26 +10,47 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"tempest-homekit-go/pkg/alarm"
 	"tempest-homekit-go/pkg/alarm/editor"
+	"tempest-homekit-go/pkg/annotations"
+	"tempest-homekit-go/pkg/backup"
 	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/corrections"
+	"tempest-homekit-go/pkg/diagnostics"
+	"tempest-homekit-go/pkg/homekit"
 	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/maintenance"
+	"tempest-homekit-go/pkg/secrets"
 	"tempest-homekit-go/pkg/service"
+	"tempest-homekit-go/pkg/stats"
 	"tempest-homekit-go/pkg/status"
+	"tempest-homekit-go/pkg/tenant"
 	"tempest-homekit-go/pkg/udp"
 	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/web"
+	"tempest-homekit-go/pkg/webhooklistener"
 
 	"github.com/joho/godotenv"
 )
 
 func main() {
+	// Windows services start in the system directory; run from the binary's
+	// directory so .env and the HomeKit db are found
+	if err := service.PrepareWindowsService(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
 	// Parse --env flag early to determine which environment file to load
 	envFile := ".env"
 	for i, arg := range os.Args {
@@ -47,13 +68,77 @@ func main() {
 
 	cfg := config.LoadConfig()
 
+	// Check the environment before the data directory check below can stop
+	// at the first problem
+	if cfg.Doctor {
+		runDoctor(cfg)
+		return
+	}
+
+	// Move all state under the data directory, failing early when it is not
+	// writable (e.g. a Docker volume owned by another user)
+	dataPaths, err := config.PrepareDataDir(config.ResolveDataDir(cfg.DataDir))
+	if err != nil {
+		log.Fatalf("Data directory check failed: %v", err)
+	}
+	homekit.StoreDir = dataPaths.HomeKitDB
+	stats.StateFile = dataPaths.StatsFile
+	maintenance.StateFile = dataPaths.Maintenance
+	annotations.StateFile = dataPaths.Annotations
+	corrections.StateFile = dataPaths.Corrections
+	alarm.SetStateFile(dataPaths.AlarmState)
+	alarm.SetUnits(cfg.Units)
+	alarm.SetPressureUnits(cfg.UnitsPressureNotify, cfg.UnitsPressureExport)
+	alarm.SetAckBaseURL(config.PublicURL(cfg))
+	if dataPaths.AlarmVersions != "" {
+		alarm.SetVersionsDir(dataPaths.AlarmVersions)
+	}
+
+	// Serve assets from a checkout while developing them
+	if cfg.StaticDir != "" {
+		web.SetStaticDir(filepath.Join(cfg.StaticDir, "pkg", "web", "static"))
+		editor.SetStaticDir(filepath.Join(cfg.StaticDir, "pkg", "alarm", "editor", "static"))
+	}
+
 	// Set up logging first (before any other operations that might log)
 	logger.SetLogLevel(cfg.LogLevel)
+	if cfg.LogFile != "" {
+		if err := logger.SetLogFile(dataPaths.LogFilePath(cfg.LogFile)); err != nil {
+			log.Fatalf("Failed to set up log file: %v", err)
+		}
+	}
 	if cfg.LogFilter != "" {
 		logger.SetLogFilter(cfg.LogFilter)
 		logger.Info("Log filter enabled: only messages containing '%s' will be shown", cfg.LogFilter)
 	}
 
+	// Point the WeatherFlow client elsewhere, e.g. at cmd/fakeserver, before
+	// anything calls the API
+	if cfg.WeatherFlowAPIURL != "" {
+		weather.BaseURL = strings.TrimRight(cfg.WeatherFlowAPIURL, "/")
+		logger.Info("Using WeatherFlow API at %s", weather.BaseURL)
+	}
+
+	// Chaos testing: degrade the WeatherFlow client (values already validated)
+	if cfg.InjectAPIErrors != "" || cfg.InjectLatency != "" {
+		var faults weather.FaultInjection
+		faults.ErrorRate, _ = config.ParsePercent(cfg.InjectAPIErrors)
+		if cfg.InjectLatency != "" {
+			faults.Latency, _ = time.ParseDuration(cfg.InjectLatency)
+		}
+		logger.Warn("Failure injection: %g%% of WeatherFlow API requests fail, %v added latency", faults.ErrorRate*100, faults.Latency)
+		weather.SetFaultInjection(faults)
+	}
+
+	// Register notification channel plugins before any alarm config is parsed
+	if cfg.AlarmPluginsDir != "" {
+		if names, err := alarm.LoadPlugins(cfg.AlarmPluginsDir); err != nil {
+			logger.Warn("Failed to load alarm plugins: %v", err)
+		} else if len(names) > 0 {
+			logger.Info("Loaded %d alarm plugin(s) from %s: %s", len(names), cfg.AlarmPluginsDir, strings.Join(names, ", "))
+		}
+	}
+
 	// Note: For generated weather, elevation will be logged by the service once location is selected
 
 	// Handle version flag
@@ -64,6 +149,14 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Check the alarm configuration now; the service would otherwise log the
+	// error and run without alarms
+	if cfg.Alarms != "" && !cfg.DisableAlarms {
+		if _, err := alarm.LoadAlarmConfig(cfg.Alarms); err != nil {
+			log.Fatalf("ERROR: --alarms: %v", err)
+		}
+	}
+
 	// Handle status theme list flag
 	if cfg.StatusThemeList {
 		status.ListThemes()
@@ -95,6 +188,7 @@ func main() {
 		if err != nil {
 			log.Fatalf("Failed to create alarm editor: %v", err)
 		}
+		editorServer.SetSecurityPolicy(config.SecurityPolicy(cfg))
 		if err := editorServer.Start(); err != nil {
 			log.Fatalf("Failed to start alarm editor: %v", err)
 		}
@@ -180,6 +274,13 @@ func main() {
 		return
 	}
 
+	// Handle the combined diagnostic run if requested
+	if cfg.TestAll {
+		logger.Info("TestAll flag detected, running every configured test...")
+		runTestAll(cfg)
+		return
+	}
+
 	// Handle web status testing if requested
 	if cfg.TestWebStatus {
 		logger.Info("TestWebStatus flag detected, testing web status scraping...")
@@ -194,6 +295,13 @@ func main() {
 		return
 	}
 
+	// Handle condition dry-run if requested
+	if cfg.EvalCondition != "" {
+		logger.Info("EvalCondition flag detected, evaluating '%s'...", cfg.EvalCondition)
+		runEvalCondition(cfg)
+		return
+	}
+
 	// Handle API testing if requested
 	if cfg.TestAPI {
 		logger.Info("TestAPI flag detected, running API endpoint tests...")
@@ -217,10 +325,38 @@ func main() {
 		return
 	}
 
-	// Handle database clearing if requested
+	// Handle web server benchmark if requested
+	if cfg.BenchWeb {
+		logger.Info("BenchWeb flag detected, load testing the web server...")
+		runWebBenchmark(cfg)
+		return
+	}
+
+	// Handle systemd unit installation if requested
+	if cfg.InstallService {
+		runInstallService(cfg)
+		return
+	}
+
+	// Encrypt the credentials stored in the alarm file
+	if cfg.EncryptAlarms {
+		runEncryptAlarms(cfg)
+		return
+	}
+
+	// Handle backup and restore of the stored state
+	if cfg.Backup != "" {
+		runBackup(cfg, stateItems(cfg, dataPaths, envFile))
+		return
+	}
+	if cfg.Restore != "" {
+		runRestore(cfg, stateItems(cfg, dataPaths, envFile))
+		return
+	}
+
 	if cfg.ClearDB {
 		logger.Info("ClearDB flag detected, clearing HomeKit database...")
-		if err := config.ClearDatabase("./db"); err != nil {
+		if err := config.ClearDatabase(homekit.StoreDir); err != nil {
 			log.Fatalf("Failed to clear database: %v", err)
 		}
 		logger.Info("Database cleared successfully. Please restart the application without --cleardb flag.")
@@ -234,10 +370,27 @@ func main() {
 			port = "8082" // Default to 8082
 		}
 		logger.Info("WebhookListen flag detected, starting webhook listener on port %s...", port)
-		runWebhookListener(port)
+		runWebhookListener(cfg, port, dataPaths.WebhookAlarms)
 		return
 	}
 
+	// Handle the UDP relay: forward broadcasts to another network and nothing else
+	if cfg.RelayOnly() {
+		runUDPRelay(cfg)
+		return
+	}
+
+	// Handle multi-station hosting: one process per station behind /station/{name}/
+	if cfg.Tenants != "" {
+		runTenantHost(cfg, dataPaths.Root)
+		return
+	}
+
+	// Handle first-run setup; the service starts with the saved settings below
+	if cfg.SetupWizard {
+		runSetupWizard(cfg)
+	}
+
 	// Handle status console mode
 	if cfg.Status {
 		// Launch status console first (it will handle output redirection and start service)
@@ -248,7 +401,13 @@ func main() {
 	}
 
 	logger.Info("Starting service with config: WebPort=%s, LogLevel=%s", cfg.WebPort, cfg.LogLevel)
-	err := service.StartService(cfg, "1.11.0")
+	if service.IsWindowsService() {
+		if err := service.RunWindowsService(func() error { return service.StartService(cfg, "1.11.0") }); err != nil {
+			log.Fatalf("Windows service failed: %v", err)
+		}
+		return
+	}
+	err = service.StartService(cfg, "1.11.0")
 	if err != nil {
 		log.Fatalf("Service failed: %v", err)
 	}
@@ -260,6 +419,54 @@ func main() {
 	logger.Info("Received signal %v, shutting down...", sig)
 }
 
+// runSetupWizard serves the first-run setup wizard on the web console's
+// address and blocks until a token and station have been saved to the env file
+func runSetupWizard(cfg *config.Config) {
+	addr := net.JoinHostPort(cfg.WebAddress, cfg.WebPort)
+	defaults := web.SetupChoices{
+		Token:         cfg.Token,
+		StationName:   cfg.StationName,
+		Sensors:       cfg.Sensors,
+		Units:         cfg.Units,
+		UnitsPressure: cfg.UnitsPressure,
+	}
+	wizard := web.NewSetupWizard(addr, defaults, func(c web.SetupChoices) error {
+		return config.CompleteSetup(cfg, c.Token, c.StationName, c.Sensors, c.Units, c.UnitsPressure)
+	})
+
+	host := cfg.WebAddress
+	if host == "" {
+		host = "localhost"
+	}
+	logger.Info("No WeatherFlow token or station configured. Open http://%s to finish setup", net.JoinHostPort(host, cfg.WebPort))
+	if _, err := wizard.Run(); err != nil {
+		log.Fatalf("Setup failed: %v", err)
+	}
+	logger.Info("Setup saved to %s for station '%s'", cfg.EnvFile, cfg.StationName)
+}
+
+// runInstallService registers this binary with the OS service manager,
+// running with the flags given on the command line
+func runInstallService(cfg *config.Config) {
+	args := os.Args[1:]
+	result, err := service.InstallService(cfg.ServiceUnit, args)
+	if err != nil {
+		log.Fatalf("Failed to install service: %v", err)
+	}
+	fmt.Printf("Installed %s\n", result.Location)
+	fmt.Printf("Logs: %s\n", result.Logs)
+	for _, arg := range args {
+		if name := strings.TrimLeft(arg, "-"); strings.HasPrefix(name, "token") || strings.Contains(name, "password") {
+			fmt.Println("Warning: the service definition contains secrets from the command line; consider moving them to an --env file")
+			break
+		}
+	}
+	fmt.Println("Start it with:")
+	for _, cmd := range result.Next {
+		fmt.Printf("  %s\n", cmd)
+	}
+}
+
 // runEmailTest sends a test email using the configured email settings
 func runEmailTest(cfg *config.Config) {
 	fmt.Println("=== Email Configuration Test ===")
@@ -361,17 +568,22 @@ func runEventLogTest(cfg *config.Config) {
 }
 
 // runUDPTest listens for UDP broadcasts from a local Tempest station
-func runUDPTest(_ *config.Config, seconds int) {
+func runUDPTest(cfg *config.Config, seconds int) {
 	fmt.Printf("=== UDP Broadcast Listener Test (%d seconds) ===\n\n", seconds)
 
 	udpListener := udp.NewUDPListener(100)
+	service.ConfigureUDPListener(cfg, udpListener, nil)
+	port := udp.UDPPort
+	if p, err := strconv.Atoi(cfg.UDPPort); err == nil {
+		port = p
+	}
 
 	// Set up packet callback for real-time pretty printing
 	udpListener.SetPacketCallback(func(data []byte) {
 		fmt.Println(udp.PrettyPrintMessage(data))
 	})
 
-	fmt.Println("Starting UDP listener on port 50222...")
+	fmt.Printf("Starting UDP listener on port %d...\n", port)
 	if err := udpListener.Start(); err != nil {
 		log.Fatalf("Failed to start UDP listener: %v", err)
 	}
@@ -413,6 +625,32 @@ func runUDPTest(_ *config.Config, seconds int) {
 				fmt.Printf("Serial Number: %s\n", serialNumber)
 				fmt.Printf("Last packet: %v\n", lastPacket.Format("2006-01-02 15:04:05"))
 
+				fmt.Println("\n=== Devices ===")
+				for _, d := range udpListener.GetDevices() {
+					name, used := d.Serial, "used"
+					if d.Name != "" {
+						name += " (" + d.Name + ")"
+					}
+					if d.Ignored {
+						used = "ignored"
+					}
+					fmt.Printf("%-30s %-8s %s  %d packets, %d observations\n", name, d.Type, used, d.Packets, d.Observations)
+				}
+
+				// Anything the decoders did not understand is worth reporting
+				if parser := udpListener.GetParserStats(); parser.Errors > 0 || len(parser.UnknownTypes) > 0 || len(parser.UnknownFields) > 0 || len(parser.InvalidValues) > 0 {
+					fmt.Printf("\n=== Not Understood (schema v%d) ===\n", parser.SchemaVersion)
+					fmt.Printf("Messages that failed to decode: %d\n", parser.Errors)
+					for _, group := range []struct {
+						title  string
+						counts map[string]int64
+					}{{"Unknown message types", parser.UnknownTypes}, {"Unknown fields", parser.UnknownFields}, {"Values that were not numbers", parser.InvalidValues}} {
+						for name, count := range group.counts {
+							fmt.Printf("%s: %s (%d)\n", group.title, name, count)
+						}
+					}
+				}
+
 				// Get latest observation
 				if obs := udpListener.GetLatestObservation(); obs != nil {
 					fmt.Println("\n=== Latest Observation ===")
@@ -434,7 +672,7 @@ func runUDPTest(_ *config.Config, seconds int) {
 			} else {
 				fmt.Println("\nNo packets received. Possible issues:")
 				fmt.Println("  - Tempest station not on same network")
-				fmt.Println("  - Firewall blocking UDP port 50222")
+				fmt.Printf("  - Firewall blocking UDP port %d\n", port)
 				fmt.Println("  - Station not broadcasting (check station settings)")
 			}
 			os.Exit(0)
@@ -480,23 +718,67 @@ func runHomeKitTest(cfg *config.Config) {
 	fmt.Printf("  Lightning: %v\n", sensorConfig.Lightning)
 	fmt.Println()
 
-	fmt.Println("HomeKit Bridge would be created with:")
-	fmt.Printf("  Name: Tempest - %s\n", cfg.StationName)
-	fmt.Printf("  Manufacturer: WeatherFlow\n")
-	fmt.Printf("  Model: Tempest Weather System\n")
-	fmt.Printf("  Serial: Tempest-%s\n", cfg.StationName)
+	// Pair with a throwaway bridge built from this configuration, over
+	// loopback and with an in-memory store, like the Home app would
+	names, _ := config.ParseHomeKitNames(cfg.HomeKitNames) // validated with the rest of the config
+	report := homekit.PairingSelfTest(cfg.Pin, &sensorConfig, homekit.Options{
+		Mode: cfg.HomeKitMode,
+		Naming: homekit.AccessoryNaming{
+			Names:         names,
+			NamePattern:   cfg.HomeKitNamePattern,
+			SerialPattern: cfg.HomeKitSerialPattern,
+			Station:       cfg.StationName,
+		},
+		PressureUnit: cfg.UnitsPressureHomeKit,
+	})
+	fmt.Printf("Pairing test (test bridge on %s, PIN %s):\n", report.Addr, report.PIN)
+	for _, step := range report.Steps {
+		result := "PASS"
+		if step.Err != nil {
+			result = "FAIL"
+		}
+		line := fmt.Sprintf("  [%s] %s", result, step.Name)
+		if step.Detail != "" {
+			line += " - " + step.Detail
+		}
+		if step.Err != nil {
+			line += ": " + step.Err.Error()
+		}
+		fmt.Println(line)
+	}
 	fmt.Println()
 
-	fmt.Println("To pair with HomeKit:")
-	fmt.Println("  1. Open Home app on iOS/macOS")
-	fmt.Println("  2. Tap '+' to add accessory")
-	fmt.Println("  3. Select 'More Options'")
-	fmt.Printf("  4. Select 'Tempest - %s'\n", cfg.StationName)
-	fmt.Printf("  5. Enter PIN: %s\n", cfg.Pin)
+	if !report.Passed() {
+		fmt.Println("HomeKit pairing test FAILED")
+		os.Exit(1)
+	}
+	fmt.Println("HomeKit pairing test passed: the bridge paired, verified and served every sensor value")
+	fmt.Println("   (The test bridge used an in-memory store; existing pairings were not touched)")
+	os.Exit(0)
+}
+
+// runTestAll runs every configured check and prints one consolidated report
+func runTestAll(cfg *config.Config) {
+	fmt.Println("=== Diagnostics (--test-all) ===")
+	fmt.Println("This takes about 30 seconds while the UDP listener waits for broadcasts.")
 	fmt.Println()
 
-	fmt.Println("HomeKit configuration test completed successfully!")
-	fmt.Println("   (Bridge was not actually started - this is a dry run)")
+	report := diagnostics.Run(cfg, diagnostics.Options{Version: "1.11.0", Progress: os.Stdout})
+	fmt.Println()
+	_ = report.WriteText(os.Stdout)
+	if report.Failed() {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// runDoctor checks the host environment and prints what to fix
+func runDoctor(cfg *config.Config) {
+	report := diagnostics.Doctor(cfg, "1.11.0")
+	_ = report.WriteText(os.Stdout)
+	if report.Failed() {
+		os.Exit(1)
+	}
 	os.Exit(0)
 }
 
@@ -582,7 +864,8 @@ func runAlarmTest(cfg *config.Config) {
 		LightningStrikeAvg:   0.0,
 	}
 
-	// Create alarm manager
+	// Create alarm manager, leaving the saved alarm state (cooldowns) alone
+	alarm.SetStateFile("")
 	manager, err := alarm.NewManager(cfg.Alarms, cfg.StationName)
 	if err != nil {
 		log.Fatalf("Failed to create alarm manager: %v", err)
@@ -608,413 +891,281 @@ func runAlarmTest(cfg *config.Config) {
 	os.Exit(0)
 }
 
-// contains checks if a string slice contains a specific string
-func contains(slice []string, str string) bool {
-	for _, s := range slice {
-		if s == str {
-			return true
-		}
+// runEvalCondition evaluates a condition against the current observation and
+// prints the parse tree with resolved field values, then exits.
+func runEvalCondition(cfg *config.Config) {
+	fmt.Println("=== Condition Dry Run ===")
+	fmt.Printf("Condition: %s\n\n", cfg.EvalCondition)
+
+	obs, source, err := fetchCurrentObservation(cfg)
+	if err != nil {
+		log.Fatalf("Failed to get current observation: %v", err)
 	}
-	return false
-}
+	fmt.Printf("Observation source: %s\n", source)
+	fmt.Printf("Observation time: %s\n\n", time.Unix(obs.Timestamp, 0).Format("2006-01-02 15:04:05"))
 
-// runWebhookListener starts an HTTP server to listen for incoming webhook requests
-func runWebhookListener(port string) {
-	logger.Info("Starting webhook listener server on port %s", port)
-	logger.Info("Webhook endpoints: POST /webhook, GET /health, GET /")
+	evaluator := alarm.NewEvaluator()
+	tree, err := evaluator.Explain(cfg.EvalCondition, obs)
+	if err != nil {
+		log.Fatalf("Failed to parse condition: %v", err)
+	}
 
-	// Create HTTP server
-	mux := http.NewServeMux()
+	fmt.Println("Parsed condition:")
+	fmt.Print(tree.Format())
+	fmt.Println()
+	fmt.Printf("Paraphrase: %s\n", evaluator.Paraphrase(cfg.EvalCondition))
+	if tree.Error != "" {
+		fmt.Printf("Result: ERROR (%s)\n", tree.Error)
+		os.Exit(1)
+	}
+	fmt.Printf("Result: %v\n", tree.Result)
+	os.Exit(0)
+}
 
-	// Webhook endpoint
-	mux.HandleFunc("/webhook", func(w http.ResponseWriter, r *http.Request) {
-		// Only accept POST requests
-		if r.Method != http.MethodPost {
-			logger.Error("Webhook endpoint received invalid method: %s from %s", r.Method, r.RemoteAddr)
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
+// fetchCurrentObservation loads the latest observation from the configured
+// data source: a custom station URL, a UDP broadcast, or the WeatherFlow API.
+func fetchCurrentObservation(cfg *config.Config) (*weather.Observation, string, error) {
+	switch {
+	case cfg.StationURL != "":
+		obs, err := weather.GetObservationFromURL(cfg.StationURL)
+		return obs, cfg.StationURL, err
+
+	case cfg.UDPStream:
+		listener := udp.NewUDPListener(10)
+		if err := listener.Start(); err != nil {
+			return nil, "", fmt.Errorf("failed to start UDP listener: %w", err)
 		}
-
-		// Read the request body
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			logger.Error("Failed to read webhook request body from %s: %v", r.RemoteAddr, err)
-			http.Error(w, "Failed to read request body", http.StatusBadRequest)
-			return
+		defer func() {
+			if err := listener.Stop(); err != nil {
+				log.Printf("udp listener stop error: %v", err)
+			}
+		}()
+		fmt.Println("Waiting up to 90 seconds for a UDP observation...")
+		select {
+		case obs := <-listener.ObservationChannel():
+			return &obs, "UDP broadcast", nil
+		case <-time.After(90 * time.Second):
+			return nil, "", fmt.Errorf("no UDP observation received within 90 seconds")
 		}
 
-		// Log webhook reception at INFO level
-		logger.Info("Webhook received from %s (%d bytes)", r.RemoteAddr, len(body))
+	case cfg.UseGeneratedWeather:
+		return nil, "", fmt.Errorf("--eval-condition does not support --use-generated-weather; use --station-url with a running generator instead")
 
-		// Try to parse and format alarm data like console notifications
-		if formattedMessage := formatWebhookAlarmMessage(body); formattedMessage != "" {
-			logger.Alarm("%s", formattedMessage)
+	default:
+		stations, err := weather.GetStations(cfg.Token)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get stations: %w", err)
 		}
-
-		// Log detailed information at DEBUG level
-		logger.Debug("Webhook details - Method: %s, URL: %s, Content-Type: %s",
-			r.Method, r.URL.String(), r.Header.Get("Content-Type"))
-
-		// Log headers at DEBUG level
-		if len(r.Header) > 0 {
-			headers := make([]string, 0, len(r.Header))
-			for key, values := range r.Header {
-				headers = append(headers, fmt.Sprintf("%s=%s", key, strings.Join(values, ",")))
-			}
-			logger.Debug("Webhook headers: %s", strings.Join(headers, "; "))
+		station := weather.FindStationByName(stations, cfg.StationName)
+		if station == nil {
+			return nil, "", fmt.Errorf("station '%s' not found", cfg.StationName)
 		}
+		obs, err := weather.GetObservation(station.StationID, cfg.Token)
+		return obs, fmt.Sprintf("WeatherFlow API (%s)", station.Name), err
+	}
+}
 
-		// Log body content at DEBUG level
-		if len(body) > 0 {
-			// Try to parse and pretty print as JSON
-			var jsonData interface{}
-			if err := json.Unmarshal(body, &jsonData); err == nil {
-				// Pretty print JSON
-				prettyJSON, _ := json.MarshalIndent(jsonData, "", "  ")
-				logger.Debug("Webhook body (JSON):\n%s", string(prettyJSON))
-			} else {
-				// Not JSON, log as string
-				logger.Debug("Webhook body (text):\n%s", string(body))
-			}
-		} else {
-			logger.Debug("Webhook body: (empty)")
+// contains checks if a string slice contains a specific string
+func contains(slice []string, str string) bool {
+	for _, s := range slice {
+		if s == str {
+			return true
 		}
+	}
+	return false
+}
 
-		// Send success response
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(`{"status":"ok","message":"Webhook received successfully"}`))
-	})
-
-	// Health check endpoint
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		logger.Debug("Health check request from %s", r.RemoteAddr)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(`{"status":"ok","service":"webhook-listener"}`))
-	})
-
-	// Root endpoint with instructions
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		logger.Debug("Root endpoint request from %s", r.RemoteAddr)
-		w.Header().Set("Content-Type", "text/plain")
-		w.WriteHeader(http.StatusOK)
-		response := fmt.Sprintf(`Webhook Listener Server
-
-This server listens for incoming webhook requests.
-
-Endpoints:
-  POST /webhook  - Receive webhook payloads (logs to console)
-  GET  /health   - Health check endpoint
-
-Send webhooks to: http://localhost:%s/webhook
-
-Server started at: %s
-`, port, time.Now().Format("2006-01-02 15:04:05"))
-		_, _ = w.Write([]byte(response))
+// runWebhookListener receives webhooks until interrupted, see pkg/webhooklistener
+func runWebhookListener(cfg *config.Config, port, storeFile string) {
+	sources, err := webhooklistener.ParseSources(cfg.WebhookListenerAllow)
+	if err != nil {
+		log.Fatalf("Invalid --webhook-listener-allow: %v", err)
+	}
+	listener, err := webhooklistener.New(webhooklistener.Config{
+		Port:           port,
+		Secret:         cfg.WebhookListenerSecret,
+		AllowedSources: sources,
+		StoreFile:      storeFile,
+		ForwardURL:     cfg.WebhookListenerForward,
+		Limits:         config.WebLimits(cfg),
 	})
-
-	// Start the server
-	server := &http.Server{
-		Addr:    ":" + port,
-		Handler: mux,
+	if err != nil {
+		log.Fatalf("Failed to start webhook listener: %v", err)
+	}
+	logger.Info("Starting webhook listener server on port %s", port)
+	logger.Info("Webhook endpoints: POST /webhook, GET /alarms, GET /health, GET /")
+	if cfg.WebhookListenerSecret == "" {
+		logger.Warn("Webhook listener accepts unsigned webhooks; set --webhook-listener-secret to require an HMAC signature")
 	}
 
-	// Channel to listen for interrupt signal
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-
-	// Start server in a goroutine
-	go func() {
-		logger.Info("Webhook listener server started successfully on http://localhost:%s", port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("Failed to start webhook listener server: %v", err)
-		}
-	}()
-
-	// Wait for interrupt signal
-	sig := <-c
-	logger.Info("Received signal %v, shutting down webhook listener server", sig)
+	// Stop on interrupt signal
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := server.Shutdown(ctx); err != nil {
-		logger.Error("Webhook listener server forced to shutdown: %v", err)
+	logger.Info("Webhook listener server started successfully on http://localhost:%s", port)
+	if err := listener.ListenAndServe(ctx); err != nil && err != http.ErrServerClosed {
+		logger.Error("Webhook listener server failed: %v", err)
 	} else {
 		logger.Info("Webhook listener server shut down gracefully")
 	}
-
+	if err := listener.Close(); err != nil {
+		logger.Error("Failed to close webhook store: %v", err)
+	}
 	os.Exit(0)
 }
 
-// WebhookAlarmPayload represents the structure of incoming webhook payloads
-type WebhookAlarmPayload struct {
-	Alarm struct {
-		Name        string `json:"name"`
-		Description string `json:"description"`
-		Condition   string `json:"condition"`
-		Tags        string `json:"tags"`
-	} `json:"alarm"`
-	Station   string                 `json:"station"`
-	Timestamp string                 `json:"timestamp"`
-	Sensors   map[string]interface{} `json:"sensors"`
-	AppInfo   string                 `json:"app_info"`
-}
-
-// formatWebhookAlarmMessage parses webhook payload and formats it like console notifications
-func formatWebhookAlarmMessage(body []byte) string {
-	var payload WebhookAlarmPayload
-	if err := json.Unmarshal(body, &payload); err != nil {
-		// Not a valid alarm webhook payload, return empty string
-		return ""
-	}
-
-	// Check if this looks like an alarm webhook (has alarm and sensors fields)
-	if payload.Alarm.Name == "" || len(payload.Sensors) == 0 {
-		return ""
+// runUDPRelay listens for UDP broadcasts and sends every packet on to the
+// --udp-relay targets until interrupted, for a station and a bridge on
+// different networks
+func runUDPRelay(cfg *config.Config) {
+	targets, err := udp.ParseRelayTargets(cfg.UDPRelay)
+	if err != nil {
+		log.Fatalf("Invalid --udp-relay: %v", err)
 	}
-
-	// Create alarm struct from payload
-	alarm := &alarm.Alarm{
-		Name:        payload.Alarm.Name,
-		Description: payload.Alarm.Description,
-		Condition:   payload.Alarm.Condition,
-		Enabled:     true, // Assume enabled if we're receiving it
+	relay, err := udp.NewRelay(targets)
+	if err != nil {
+		log.Fatalf("Failed to start UDP relay: %v", err)
 	}
+	defer func() { _ = relay.Close() }()
 
-	// Parse tags if present
-	if payload.Alarm.Tags != "" {
-		alarm.Tags = strings.Split(payload.Alarm.Tags, ",")
-		for i, tag := range alarm.Tags {
-			alarm.Tags[i] = strings.TrimSpace(tag)
-		}
+	listener := udp.NewUDPListener(10)
+	service.ConfigureUDPListener(cfg, listener, relay)
+	if err := listener.Start(); err != nil {
+		log.Fatalf("Failed to start UDP listener: %v", err)
 	}
+	logger.Info("Relaying UDP broadcasts to %v", relay.Targets())
 
-	// Create observation from sensors data
-	obs := &weather.Observation{}
-
-	// Parse timestamp
-	if payload.Timestamp != "" {
-		if t, err := time.Parse("2006-01-02 15:04:05 MST", payload.Timestamp); err == nil {
-			obs.Timestamp = t.Unix()
-		} else if t, err := time.Parse(time.RFC3339, payload.Timestamp); err == nil {
-			obs.Timestamp = t.Unix()
-		} else {
-			// Use current time if parsing fails
-			obs.Timestamp = time.Now().Unix()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			_ = listener.Stop()
+			sent, failed := relay.Stats()
+			logger.Info("UDP relay stopped: %d packets relayed, %d failed", sent, failed)
+			return
+		case <-ticker.C:
+			received, _, _, _ := listener.GetStats()
+			sent, failed := relay.Stats()
+			logger.Info("UDP relay: %d packets received, %d relayed, %d failed", received, sent, failed)
 		}
-	} else {
-		obs.Timestamp = time.Now().Unix()
 	}
+}
 
-	// Parse sensor values
-	if val, ok := payload.Sensors["temperature_c"].(float64); ok {
-		obs.AirTemperature = val
-	}
-	if val, ok := payload.Sensors["humidity"].(float64); ok {
-		obs.RelativeHumidity = val
-	}
-	if val, ok := payload.Sensors["pressure_mb"].(float64); ok {
-		obs.StationPressure = val
-	}
-	if val, ok := payload.Sensors["wind_speed_ms"].(float64); ok {
-		obs.WindAvg = val
-	}
-	if val, ok := payload.Sensors["wind_gust_ms"].(float64); ok {
-		obs.WindGust = val
-	}
-	if val, ok := payload.Sensors["wind_direction_deg"].(float64); ok {
-		obs.WindDirection = val
-	}
-	if val, ok := payload.Sensors["illuminance_lux"].(float64); ok {
-		obs.Illuminance = val
-	}
-	if val, ok := payload.Sensors["uv_index"].(float64); ok {
-		obs.UV = int(val)
-	}
-	if val, ok := payload.Sensors["rain_rate_mmh"].(float64); ok {
-		obs.RainAccumulated = val
-	}
-	if val, ok := payload.Sensors["rain_daily_mm"].(float64); ok {
-		obs.RainDailyTotal = val
-	}
-	if val, ok := payload.Sensors["lightning_count"].(float64); ok {
-		obs.LightningStrikeCount = int(val)
+// runTenantHost starts a process per station in the tenants file and serves
+// them under /station/{name}/ until interrupted, see pkg/tenant
+func runTenantHost(cfg *config.Config, dataDir string) {
+	file, err := tenant.Load(cfg.Tenants)
+	if err != nil {
+		log.Fatalf("Invalid tenants file: %v", err)
 	}
-	if val, ok := payload.Sensors["lightning_distance_km"].(float64); ok {
-		obs.LightningStrikeAvg = val
+	executable, err := os.Executable()
+	if err != nil {
+		log.Fatalf("Failed to locate the executable for station processes: %v", err)
+	}
+	host, err := tenant.NewHost(tenant.HostConfig{
+		File:       file,
+		Executable: executable,
+		Env:        os.Environ(),
+		DataDir:    dataDir,
+		Address:    cfg.WebAddress,
+		Port:       cfg.WebPort,
+		Limits:     config.WebLimits(cfg),
+	})
+	if err != nil {
+		log.Fatalf("Failed to start tenants host: %v", err)
 	}
 
-	// Format the message like console notifications
-	alarmInfo := formatAlarmInfo(alarm, false)
-	sensorInfo := formatSensorInfoWithAlarm(obs, alarm, false)
-
-	message := fmt.Sprintf("WEBHOOK ALARM: %s\n%s\n\nCurrent Conditions:\n%s",
-		payload.Alarm.Name, alarmInfo, sensorInfo)
+	// Stop the station processes on interrupt signal
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	return message
+	logger.Info("Serving %d station(s) on port %s:", len(file.Tenants), cfg.WebPort)
+	for _, t := range file.Tenants {
+		logger.Info("  %s/ -> station process on 127.0.0.1:%d, data in %s", t.Path(), t.WebPort, t.DataDir(dataDir))
+	}
+	if err := host.Run(ctx); err != nil && err != http.ErrServerClosed {
+		logger.Error("Tenants host failed: %v", err)
+		os.Exit(1)
+	}
+	logger.Info("Tenants host shut down gracefully")
 }
 
-// formatAlarmInfo returns formatted alarm information
-func formatAlarmInfo(alarm *alarm.Alarm, isHTML bool) string {
-	enabledStr := "enabled"
-	if !alarm.Enabled {
-		enabledStr = "disabled"
+// stateItems lists the state --backup saves and --restore replaces. History
+// is kept in memory and the dashboard layout and units in the browser, so
+// neither is part of it.
+func stateItems(cfg *config.Config, dataPaths config.DataPaths, envFile string) []backup.Item {
+	items := []backup.Item{
+		{Name: "db", Path: dataPaths.HomeKitDB},
+		{Name: "stats.json", Path: dataPaths.StatsFile},
+		{Name: "alarm-state.json", Path: dataPaths.AlarmState},
+		{Name: "webhook-alarms.jsonl", Path: dataPaths.WebhookAlarms},
+		{Name: "maintenance.json", Path: dataPaths.Maintenance},
+		{Name: "annotations.json", Path: dataPaths.Annotations},
+		{Name: "corrections.jsonl", Path: dataPaths.Corrections},
+		{Name: "env", Path: envFile},
+	}
+	if alarmFile, ok := strings.CutPrefix(cfg.Alarms, "@"); ok {
+		items = append(items, backup.Item{Name: "alarms.json", Path: alarmFile})
+		if dataPaths.AlarmVersions == "" {
+			items = append(items, backup.Item{Name: "alarm-versions", Path: filepath.Join(filepath.Dir(alarmFile), ".versions")})
+		}
 	}
-
-	cooldownStr := fmt.Sprintf("%d seconds", alarm.Cooldown)
-	if alarm.Cooldown >= 3600 {
-		cooldownStr = fmt.Sprintf("%d hours", alarm.Cooldown/3600)
-	} else if alarm.Cooldown >= 60 {
-		cooldownStr = fmt.Sprintf("%d minutes", alarm.Cooldown/60)
+	if dataPaths.Root != "" {
+		items = append(items,
+			backup.Item{Name: "alarm-versions", Path: dataPaths.AlarmVersions},
+			backup.Item{Name: "tenants", Path: filepath.Join(dataPaths.Root, "tenants")})
 	}
+	return items
+}
 
-	tagsStr := "none"
-	if len(alarm.Tags) > 0 {
-		tagsStr = strings.Join(alarm.Tags, ", ")
+// runEncryptAlarms encrypts the plaintext webhook credentials of the --alarms
+// file with TEMPEST_MASTER_KEY
+func runEncryptAlarms(cfg *config.Config) {
+	n, err := alarm.EncryptConfigFile(cfg.Alarms)
+	if err != nil {
+		log.Fatalf("ERROR: --encrypt-alarms: %v", err)
 	}
-
-	if isHTML {
-		return fmt.Sprintf(`<table style="border-collapse: collapse; width: 100%%;">
-			<tr><td style="padding: 5px; border: 1px solid #ddd; font-weight: bold;">Alarm:</td><td style="padding: 5px; border: 1px solid #ddd;">%s</td></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd; font-weight: bold;">Description:</td><td style="padding: 5px; border: 1px solid #ddd;">%s</td></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd; font-weight: bold;">Condition:</td><td style="padding: 5px; border: 1px solid #ddd;">%s</td></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd; font-weight: bold;">Status:</td><td style="padding: 5px; border: 1px solid #ddd;">%s</td></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd; font-weight: bold;">Cooldown:</td><td style="padding: 5px; border: 1px solid #ddd;">%s</td></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd; font-weight: bold;">Tags:</td><td style="padding: 5px; border: 1px solid #ddd;">%s</td></tr>
-		</table>`,
-			alarm.Name, alarm.Description, alarm.Condition, enabledStr, cooldownStr, tagsStr)
+	if n == 0 {
+		fmt.Printf("No plaintext credentials to encrypt in %s\n", strings.TrimPrefix(cfg.Alarms, "@"))
+		return
 	}
-
-	return fmt.Sprintf("Alarm: %s\nDescription: %s\nCondition: %s\nStatus: %s\nCooldown: %s\nTags: %s",
-		alarm.Name, alarm.Description, alarm.Condition, enabledStr, cooldownStr, tagsStr)
+	fmt.Printf("Encrypted %d credential(s) in %s. Keep %s set to send notifications.\n", n, strings.TrimPrefix(cfg.Alarms, "@"), secrets.MasterKeyEnv)
 }
 
-// formatSensorInfoWithAlarm returns formatted sensor information with alarm context
-func formatSensorInfoWithAlarm(obs *weather.Observation, alarm *alarm.Alarm, isHTML bool) string {
-	tempF := obs.AirTemperature*9/5 + 32
-	windSpeedMph := obs.WindAvg * 2.23694
-	windGustMph := obs.WindGust * 2.23694
-	rainDaily := obs.RainDailyTotal / 25.4 // Convert mm to inches
-
-	// Wind direction cardinal
-	dir := obs.WindDirection
-	cardinal := "N"
-	switch {
-	case dir >= 337.5 || dir < 22.5:
-		cardinal = "N"
-	case dir >= 22.5 && dir < 67.5:
-		cardinal = "NE"
-	case dir >= 67.5 && dir < 112.5:
-		cardinal = "E"
-	case dir >= 112.5 && dir < 157.5:
-		cardinal = "SE"
-	case dir >= 157.5 && dir < 202.5:
-		cardinal = "S"
-	case dir >= 202.5 && dir < 247.5:
-		cardinal = "SW"
-	case dir >= 247.5 && dir < 292.5:
-		cardinal = "W"
-	case dir >= 292.5 && dir < 337.5:
-		cardinal = "NW"
-	}
-
-	// Helper to get previous value with proper formatting
-	getPrevValue := func(key string, _ /* current */ float64, format string) string {
-		if alarm == nil {
-			return "N/A"
-		}
-		if prev, ok := alarm.GetTriggerValue(key); ok {
-			return fmt.Sprintf(format, prev)
-		}
-		if prev, ok := alarm.GetPreviousValue(key); ok {
-			return fmt.Sprintf(format, prev)
-		}
-		return "N/A"
+// runBackup writes the state to the --backup archive
+func runBackup(cfg *config.Config, items []backup.Item) {
+	manifest, err := backup.Create(cfg.Backup, items, "1.11.0")
+	if err != nil {
+		log.Fatalf("Backup failed: %v", err)
 	}
-
-	// Special handler for illuminance which needs number formatting
-	getPrevLux := func() string {
-		if alarm == nil {
-			return "N/A"
-		}
-		if prev, ok := alarm.GetTriggerValue("lux"); ok {
-			return formatNumber(prev)
-		}
-		if prev, ok := alarm.GetPreviousValue("lux"); ok {
-			return formatNumber(prev)
-		}
-		return "N/A"
-	}
-
-	if isHTML {
-		return fmt.Sprintf(`<table style="border-collapse: collapse; width: 100%%;">
-			<tr style="background: #f0f0f0;"><th style="padding: 5px; border: 1px solid #ddd;">Sensor</th><th style="padding: 5px; border: 1px solid #ddd;">Current</th><th style="padding: 5px; border: 1px solid #ddd;">Last</th></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd;"><strong>Temperature:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.1f°F (%.1f°C)</td><td style="padding: 5px; border: 1px solid #ddd;">%s°C</td></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd;"><strong>Humidity:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.0f%%</td><td style="padding: 5px; border: 1px solid #ddd;">%s%%</td></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd;"><strong>Pressure:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.2f mb</td><td style="padding: 5px; border: 1px solid #ddd;">%s mb</td></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd;"><strong>Wind Speed:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.1f mph (%.1f m/s)</td><td style="padding: 5px; border: 1px solid #ddd;">%s m/s</td></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd;"><strong>Wind Gust:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.1f mph (%.1f m/s)</td><td style="padding: 5px; border: 1px solid #ddd;">%s m/s</td></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd;"><strong>Wind Direction:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.0f° (%s)</td><td style="padding: 5px; border: 1px solid #ddd;">%s°</td></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd;"><strong>UV Index:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%d</td><td style="padding: 5px; border: 1px solid #ddd;">%s</td></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd;"><strong>Illuminance:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%s lux</td><td style="padding: 5px; border: 1px solid #ddd;">%s lux</td></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd;"><strong>Rain Rate:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.2f mm/hr</td><td style="padding: 5px; border: 1px solid #ddd;">%s mm/hr</td></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd;"><strong>Daily Rain:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.2f in (%.1f mm)</td><td style="padding: 5px; border: 1px solid #ddd;">%s mm</td></tr>
-			<tr><td style="padding: 5px; border: 1px solid #ddd;"><strong>Lightning:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%d strikes</td><td style="padding: 5px; border: 1px solid #ddd;">%s strikes</td></tr>
-		</table>`,
-			tempF, obs.AirTemperature, getPrevValue("temperature", obs.AirTemperature, "%.1f"),
-			obs.RelativeHumidity, getPrevValue("humidity", obs.RelativeHumidity, "%.0f"),
-			obs.StationPressure, getPrevValue("pressure", obs.StationPressure, "%.2f"),
-			windSpeedMph, obs.WindAvg, getPrevValue("wind_speed", obs.WindAvg, "%.1f"),
-			windGustMph, obs.WindGust, getPrevValue("wind_gust", obs.WindGust, "%.1f"),
-			obs.WindDirection, cardinal, getPrevValue("wind_direction", obs.WindDirection, "%.0f"),
-			obs.UV, getPrevValue("uv", float64(obs.UV), "%.0f"),
-			formatNumber(obs.Illuminance), getPrevLux(),
-			obs.RainAccumulated, getPrevValue("rain_rate", obs.RainAccumulated, "%.2f"),
-			rainDaily, obs.RainDailyTotal, getPrevValue("rain_daily", obs.RainDailyTotal, "%.1f"),
-			obs.LightningStrikeCount, getPrevValue("lightning_count", float64(obs.LightningStrikeCount), "%.0f"))
-	}
-
-	return fmt.Sprintf(`Temperature: %.1f°F (%.1f°C) [Last: %s°C]
-Humidity: %.0f%% [Last: %s%%]
-Pressure: %.2f mb [Last: %s mb]
-Wind Speed: %.1f mph (%.1f m/s) [Last: %s m/s]
-Wind Gust: %.1f mph (%.1f m/s) [Last: %s m/s]
-Wind Direction: %.0f° (%s) [Last: %s°]
-UV Index: %d [Last: %s]
-Illuminance: %s lux [Last: %s lux]
-Rain Rate: %.2f mm/hr [Last: %s mm/hr]
-Daily Rain: %.2f in (%.1f mm) [Last: %s mm]
-Lightning: %d strikes [Last: %s strikes]`,
-		tempF, obs.AirTemperature, getPrevValue("temperature", obs.AirTemperature, "%.1f"),
-		obs.RelativeHumidity, getPrevValue("humidity", obs.RelativeHumidity, "%.0f"),
-		obs.StationPressure, getPrevValue("pressure", obs.StationPressure, "%.2f"),
-		windSpeedMph, obs.WindAvg, getPrevValue("wind_speed", obs.WindAvg, "%.1f"),
-		windGustMph, obs.WindGust, getPrevValue("wind_gust", obs.WindGust, "%.1f"),
-		obs.WindDirection, cardinal, getPrevValue("wind_direction", obs.WindDirection, "%.0f"),
-		obs.UV, getPrevValue("uv", float64(obs.UV), "%.0f"),
-		formatNumber(obs.Illuminance), getPrevLux(),
-		obs.RainAccumulated, getPrevValue("rain_rate", obs.RainAccumulated, "%.2f"),
-		rainDaily, obs.RainDailyTotal, getPrevValue("rain_daily", obs.RainDailyTotal, "%.1f"),
-		obs.LightningStrikeCount, getPrevValue("lightning_count", float64(obs.LightningStrikeCount), "%.0f"))
+	var size int64
+	for _, f := range manifest.Files {
+		size += f.Size
+	}
+	fmt.Printf("Backed up %s (%d files, %d bytes) to %s\n", strings.Join(manifest.Items, ", "), len(manifest.Files), size, cfg.Backup)
+	fmt.Println("The archive contains the HomeKit keys and may contain tokens; keep it private")
 }
 
-// formatNumber formats a number with thousands separator
-func formatNumber(n float64) string {
-	s := fmt.Sprintf("%.0f", n)
-	if len(s) <= 3 {
-		return s
+// runRestore verifies the --restore archive and puts its state in place
+func runRestore(cfg *config.Config, items []backup.Item) {
+	result, err := backup.Restore(cfg.Restore, items)
+	if err != nil {
+		log.Fatalf("Restore failed: %v", err)
 	}
-
-	var result strings.Builder
-	for i, c := range s {
-		if i > 0 && (len(s)-i)%3 == 0 {
-			result.WriteString(",")
-		}
-		result.WriteRune(c)
+	fmt.Printf("Verified %d files of a backup taken %s on %s\n", len(result.Manifest.Files), result.Manifest.Created.Local().Format(time.RFC1123), result.Manifest.Host)
+	for _, name := range result.Restored {
+		fmt.Printf("  restored %s\n", name)
 	}
-	return result.String()
+	for _, name := range result.Skipped {
+		fmt.Printf("  skipped %s (not configured on this host, e.g. --alarms @file or --data-dir)\n", name)
+	}
+	for _, previous := range result.Previous {
+		fmt.Printf("  previous state kept at %s\n", previous)
+	}
+	fmt.Println("Start the bridge again to use the restored state")
 }
 
 // runAPITests performs comprehensive testing of all WeatherFlow API endpoints
@@ -1370,6 +1521,62 @@ func runLocalAPITests(cfg *config.Config) {
 	time.Sleep(1 * time.Second)
 }
 
+// runWebBenchmark replays the --bench-web request mix against a web server
+// holding --history synthetic observations and prints latency and allocations
+func runWebBenchmark(cfg *config.Config) {
+	mix, err := config.ParseRequestMix(cfg.BenchWebMix)
+	if err != nil {
+		log.Fatalf("Invalid request mix: %v", err)
+	}
+
+	fmt.Println("=== Web Server Benchmark ===")
+	fmt.Printf("History points: %d, requests: %d, concurrency: %d, mix: %s\n\n",
+		cfg.HistoryPoints, cfg.BenchWebRequests, cfg.BenchWebConcurrency, cfg.BenchWebMix)
+
+	report, err := web.Benchmark(web.BenchOptions{
+		HistoryPoints: cfg.HistoryPoints,
+		Requests:      cfg.BenchWebRequests,
+		Concurrency:   cfg.BenchWebConcurrency,
+		Mix:           mix,
+	})
+	if err != nil {
+		log.Fatalf("Benchmark failed: %v", err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENDPOINT\tREQS\tERRS\tP50\tP95\tP99\tMAX\tBODY\tALLOCS/OP\tBYTES/OP")
+	for _, ep := range report.Endpoints {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%v\t%v\t%v\t%v\t%s\t%d\t%s\n", ep.Path, ep.Requests, ep.Errors,
+			ep.P50.Round(time.Microsecond), ep.P95.Round(time.Microsecond), ep.P99.Round(time.Microsecond),
+			ep.Max.Round(time.Microsecond), formatBytes(ep.Bytes), ep.AllocsPerOp, formatBytes(ep.BytesPerOp))
+	}
+	_ = tw.Flush()
+
+	fmt.Printf("\n%d requests in %v (%.0f req/s) with %d history points\n",
+		report.Requests, report.Elapsed.Round(time.Millisecond), report.RequestsPerSecond(), report.HistoryPoints)
+	fmt.Printf("Process during load: %s allocated in %d allocations, %d GC cycles, %s heap in use\n",
+		formatBytes(int64(report.TotalAlloc)), report.Mallocs, report.NumGC, formatBytes(int64(report.HeapInuse)))
+	fmt.Println("ALLOCS/OP and BYTES/OP are measured per handler call, without HTTP client or transport overhead.")
+
+	for _, ep := range report.Endpoints {
+		if ep.Errors > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+// formatBytes renders a byte count with a binary unit
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
 func testEndpoint(client *http.Client, url, name string, debug bool) {
 	resp, err := client.Get(url)
 	if err != nil {
@@ -0,0 +1,211 @@
+package alarm
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+)
+
+// Every time an alarm fires, its notifications carry a new acknowledgement
+// token. {{ack_url}} links to the dashboard's acknowledgement page for it,
+// and {{ack_code}} is the short code an SMS reply ("ACK <code>") quotes.
+// Acknowledging silences the alarm until its condition clears, like
+// Acknowledge, and records who saw it for the dashboard.
+
+// ackCodeLength is the length of {{ack_code}}, the start of the token
+const ackCodeLength = 6
+
+// ackBaseURL is the dashboard URL acknowledgement links start with
+var ackBaseURL = "http://localhost:8080"
+
+// SetAckBaseURL sets the URL recipients reach the dashboard at, such as
+// https://weather.example.com, for the {{ack_url}} links. main sets it from
+// --public-url.
+func SetAckBaseURL(base string) {
+	ackBaseURL = strings.TrimRight(base, "/")
+}
+
+// ackEncoding writes tokens in lower case letters and digits 2-7, which
+// survive SMS and mail clients
+var ackEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// newAckToken returns a random acknowledgement token
+func newAckToken() string {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return ackEncoding.EncodeToString(b)
+}
+
+// ackURL is the acknowledgement link of token; without one (test
+// notifications and previews) it is the dashboard. The token is the fragment,
+// which browsers do not send, so it never reaches request or proxy logs.
+func ackURL(token string) string {
+	if token == "" {
+		return ackBaseURL + "/"
+	}
+	return ackBaseURL + "/api/alarms/ack#" + token
+}
+
+// ackCode is the SMS reply code of token
+func ackCode(token string) string {
+	if len(token) < ackCodeLength {
+		return ""
+	}
+	return strings.ToUpper(token[:ackCodeLength])
+}
+
+// AckStatus reports whether the latest notification of the alarm is waiting
+// for an acknowledgement, and who acknowledged it when
+func (a *Alarm) AckStatus() (pending bool, by string, at time.Time) {
+	return a.ackToken != "" && a.ackedAt.IsZero(), a.ackedBy, a.ackedAt
+}
+
+// newFiring gives the alarm a new acknowledgement token for the
+// notifications it is about to send
+func (a *Alarm) newFiring() {
+	a.ackToken = newAckToken()
+	a.ackedBy, a.ackedAt = "", time.Time{}
+}
+
+// acknowledgeLocked marks the alarm as seen by by; the caller holds m.mu
+func (m *Manager) acknowledgeLocked(a *Alarm, by string) {
+	a.acknowledged = true
+	if a.ackedAt.IsZero() {
+		a.ackedBy, a.ackedAt = by, time.Now()
+		logger.Info("Alarm %s acknowledged by %s", a.Name, by)
+	}
+	m.saveStateLocked()
+}
+
+// AcknowledgeToken acknowledges the notification holding token, from an
+// {{ack_url}} link, on behalf of by, and returns the alarm's name. Only the
+// latest notification of an alarm can be acknowledged; acknowledging it
+// again changes nothing.
+func (m *Manager) AcknowledgeToken(token, by string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.config.Alarms {
+		a := &m.config.Alarms[i]
+		if a.ackToken != "" && subtle.ConstantTimeCompare([]byte(a.ackToken), []byte(token)) == 1 {
+			m.acknowledgeLocked(a, by)
+			return a.Name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown or expired acknowledgement")
+}
+
+// AcknowledgeReply acknowledges a notification from an SMS reply: "ACK
+// <code>" with the {{ack_code}} of the notification. A trusted reply, whose
+// sender was verified, may leave out the code to acknowledge the latest
+// notification sent to from. It returns the alarm's name.
+func (m *Manager) AcknowledgeReply(from, body string, trusted bool) (string, error) {
+	words := strings.Fields(strings.ToUpper(body))
+	if len(words) == 0 || (words[0] != "ACK" && words[0] != "OK") {
+		return "", fmt.Errorf("reply ACK and the code from the alert to acknowledge it")
+	}
+	by := "SMS"
+	if from != "" {
+		by = "SMS from " + from
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var latest *Alarm
+	for i := range m.config.Alarms {
+		a := &m.config.Alarms[i]
+		if a.ackToken == "" {
+			continue
+		}
+		if len(words) > 1 {
+			if subtle.ConstantTimeCompare([]byte(ackCode(a.ackToken)), []byte(words[1])) == 1 {
+				m.acknowledgeLocked(a, by)
+				return a.Name, nil
+			}
+			continue
+		}
+		if trusted && a.sentTo(from) && (latest == nil || a.lastFired.After(latest.lastFired)) {
+			latest = a
+		}
+	}
+	if latest == nil {
+		if len(words) > 1 {
+			return "", fmt.Errorf("unknown or expired code %s", words[1])
+		}
+		return "", fmt.Errorf("reply ACK and the code from the alert to acknowledge it")
+	}
+	m.acknowledgeLocked(latest, by)
+	return latest.Name, nil
+}
+
+// sentTo reports whether an SMS channel of the alarm sends to number
+func (a *Alarm) sentTo(number string) bool {
+	digits := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, s)
+	}
+	want := digits(number)
+	for _, ch := range a.Channels {
+		if ch.SMS == nil || want == "" {
+			continue
+		}
+		for _, to := range ch.SMS.To {
+			if digits(to) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// VerifySMSReply checks the X-Twilio-Signature of a reply Twilio posted to
+// replyURL with params, using TWILIO_AUTH_TOKEN. It is false when Twilio is
+// not configured.
+func (m *Manager) VerifySMSReply(replyURL string, params url.Values, signature string) bool {
+	m.mu.RLock()
+	sms := m.config.SMS
+	m.mu.RUnlock()
+	if sms == nil || sms.Provider != "twilio" || signature == "" {
+		return false
+	}
+	authToken := os.ExpandEnv(sms.AuthToken)
+	if authToken == "" {
+		return false
+	}
+
+	// Twilio signs the URL followed by every parameter name and value,
+	// sorted by name
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(replyURL)
+	for _, k := range keys {
+		for _, v := range params[k] {
+			b.WriteString(k)
+			b.WriteString(v)
+		}
+	}
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,137 @@
+package alarm
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// firedManager returns a manager whose alarm Hot has fired once, and the
+// webhook body it sent
+func firedManager(t *testing.T) (*Manager, string) {
+	t.Helper()
+	bodies := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- string(b)
+	}))
+	t.Cleanup(srv.Close)
+
+	config := `{"alarms": [{
+		"name": "Hot",
+		"condition": "temperature > 25",
+		"enabled": true,
+		"channels": [
+			{"type": "webhook", "webhook": {"url": "` + srv.URL + `", "body": "{{ack_url}} {{ack_code}}", "content_type": "text/plain"}},
+			{"type": "sms", "sms": {"to": ["+1 (555) 123-4567"], "message": "Hot"}}
+		]
+	}]}`
+	manager, err := NewManager(config, "TestStation")
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	t.Cleanup(manager.Stop)
+	manager.ProcessObservation(&weather.Observation{Timestamp: 1700000000, AirTemperature: 30})
+	return manager, <-bodies
+}
+
+func TestAcknowledgeToken(t *testing.T) {
+	SetAckBaseURL("https://weather.example.com/")
+	defer SetAckBaseURL("http://localhost:8080")
+	manager, body := firedManager(t)
+
+	link, code, _ := strings.Cut(body, " ")
+	token, ok := strings.CutPrefix(link, "https://weather.example.com/api/alarms/ack#")
+	if !ok || len(token) != 32 || code != strings.ToUpper(token[:ackCodeLength]) {
+		t.Fatalf("notification = %q, want an ack link and code", body)
+	}
+	alarm := &manager.config.Alarms[0]
+	if pending, _, _ := alarm.AckStatus(); !pending {
+		t.Error("a fired alarm should wait for an acknowledgement")
+	}
+
+	if _, err := manager.AcknowledgeToken("wrong", "Sam"); err == nil {
+		t.Error("unknown token: want an error")
+	}
+	if name, err := manager.AcknowledgeToken(token, "Sam"); err != nil || name != "Hot" {
+		t.Fatalf("AcknowledgeToken = %q, %v", name, err)
+	}
+	pending, by, at := alarm.AckStatus()
+	if pending || by != "Sam" || at.IsZero() || !alarm.IsAcknowledged() {
+		t.Errorf("after acknowledging: pending %v, by %q, at %v, acknowledged %v", pending, by, at, alarm.IsAcknowledged())
+	}
+
+	// Acknowledging again keeps the first acknowledgement
+	if _, err := manager.AcknowledgeToken(token, "Alex"); err != nil {
+		t.Errorf("second acknowledgement: %v", err)
+	}
+	if _, by, _ := alarm.AckStatus(); by != "Sam" {
+		t.Errorf("acknowledged by %q, want Sam", by)
+	}
+
+	// The state file keeps the acknowledgement
+	restored := []Alarm{{Name: "Hot"}}
+	applyState(restored, captureState(manager.config.Alarms))
+	if pending, by, _ := restored[0].AckStatus(); pending || by != "Sam" || restored[0].ackToken != token {
+		t.Errorf("restored ack state: pending %v, by %q", pending, by)
+	}
+}
+
+func TestAcknowledgeReply(t *testing.T) {
+	manager, body := firedManager(t)
+	_, code, _ := strings.Cut(body, " ")
+
+	if _, err := manager.AcknowledgeReply("+15551234567", "thanks", true); err == nil {
+		t.Error("a reply without ACK: want an error")
+	}
+	if _, err := manager.AcknowledgeReply("+15551234567", "ACK", false); err == nil {
+		t.Error("an unverified bare ACK: want an error")
+	}
+	if _, err := manager.AcknowledgeReply("+15559999999", "ACK", true); err == nil {
+		t.Error("a bare ACK from a number the alert was not sent to: want an error")
+	}
+	if _, err := manager.AcknowledgeReply("+15551234567", "ACK ZZZZZZ", false); err == nil {
+		t.Error("a wrong code: want an error")
+	}
+	if name, err := manager.AcknowledgeReply("+15551234567", "ack "+strings.ToLower(code), false); err != nil || name != "Hot" {
+		t.Fatalf("ACK <code> = %q, %v", name, err)
+	}
+	if _, by, _ := manager.config.Alarms[0].AckStatus(); by != "SMS from +15551234567" {
+		t.Errorf("acknowledged by %q", by)
+	}
+
+	// A verified sender may leave out the code
+	manager2, _ := firedManager(t)
+	if name, err := manager2.AcknowledgeReply("+15551234567", " ACK ", true); err != nil || name != "Hot" {
+		t.Errorf("trusted bare ACK = %q, %v", name, err)
+	}
+}
+
+func TestVerifySMSReply(t *testing.T) {
+	manager, _ := firedManager(t)
+	params := url.Values{"From": {"+15551234567"}, "Body": {"ACK"}, "To": {"+15557654321"}}
+	replyURL := "https://weather.example.com/api/alarms/ack/sms"
+	mac := hmac.New(sha1.New, []byte("token123"))
+	mac.Write([]byte(replyURL + "BodyACK" + "From+15551234567" + "To+15557654321"))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	if manager.VerifySMSReply(replyURL, params, signature) {
+		t.Error("verified without Twilio configured")
+	}
+	manager.config.SMS = &SMSGlobalConfig{Provider: "twilio", AuthToken: "token123"}
+	if !manager.VerifySMSReply(replyURL, params, signature) {
+		t.Error("valid signature rejected")
+	}
+	params.Set("Body", "ACK ABCDEF")
+	if manager.VerifySMSReply(replyURL, params, signature) {
+		t.Error("signature of other parameters accepted")
+	}
+}
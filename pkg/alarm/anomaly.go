@@ -0,0 +1,50 @@
+package alarm
+
+import (
+	"fmt"
+	"strings"
+
+	"tempest-homekit-go/pkg/stats"
+)
+
+// AnomalyProvider returns how unusual field's latest reading is, as an
+// absolute z-score, and false while the field has no baseline yet
+type AnomalyProvider func(field string) (float64, bool)
+
+// SetAnomalyProvider supplies the anomaly(field) condition fields. Without a
+// provider they cannot be evaluated.
+func (e *Evaluator) SetAnomalyProvider(fn AnomalyProvider) {
+	e.anomalies = fn
+}
+
+// anomalyField returns the sensor field of an "anomaly(field)" condition field
+func anomalyField(field string) (string, bool) {
+	if !strings.HasPrefix(field, "anomaly(") || !strings.HasSuffix(field, ")") {
+		return "", false
+	}
+	inner := strings.TrimSpace(field[len("anomaly(") : len(field)-1])
+	switch inner {
+	case "temp":
+		inner = "temperature"
+	case "wind":
+		inner = "wind_speed"
+	}
+	return inner, true
+}
+
+// getAnomalyValue resolves anomaly(field). A field still building its
+// baseline reads as 0, so conditions stay quiet after a restart.
+func (e *Evaluator) getAnomalyValue(field string) (float64, error) {
+	known := false
+	for _, f := range stats.AnomalyFields() {
+		known = known || f == field
+	}
+	if !known {
+		return 0, fmt.Errorf("unknown anomaly field: %s (use %s)", field, strings.Join(stats.AnomalyFields(), ", "))
+	}
+	if e.anomalies == nil {
+		return 0, fmt.Errorf("anomaly(%s) unavailable: anomaly detection is not running", field)
+	}
+	z, _ := e.anomalies(field)
+	return z, nil
+}
@@ -0,0 +1,47 @@
+package alarm
+
+import (
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestEvaluator_AnomalyFields(t *testing.T) {
+	e := NewEvaluator()
+	obs := &weather.Observation{AirTemperature: 31}
+	if _, err := e.Evaluate("anomaly(temperature) > 4", obs); err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Errorf("anomaly without a detector: err = %v, want unavailable", err)
+	}
+
+	e.SetAnomalyProvider(func(field string) (float64, bool) {
+		switch field {
+		case "temperature":
+			return 5.2, true
+		case "wind_speed":
+			return 1.1, true
+		}
+		return 0, false
+	})
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"anomaly(temperature) > 4", true},
+		{"anomaly( temp ) >= 5 && temperature > 30", true},
+		{"anomaly(wind) > 4", false},
+		{"anomaly(humidity) > 0", false}, // no baseline yet
+	}
+	for _, tt := range tests {
+		got, err := e.Evaluate(tt.condition, obs)
+		if err != nil || got != tt.want {
+			t.Errorf("%s = %v, %v; want %v", tt.condition, got, err, tt.want)
+		}
+	}
+	if _, err := e.Evaluate("anomaly(lux) > 4", obs); err == nil || !strings.Contains(err.Error(), "unknown anomaly field") {
+		t.Errorf("anomaly(lux): err = %v, want unknown anomaly field", err)
+	}
+	if got := e.Paraphrase("anomaly(wind_gust) > 4"); got != "When wind gust anomaly score exceeds 4" {
+		t.Errorf("paraphrase = %q", got)
+	}
+}
@@ -2,6 +2,7 @@ package alarm
 
 import (
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -255,3 +256,34 @@ func TestLoadConfigFromEnv(t *testing.T) {
 		}
 	})
 }
+
+func TestLoadConfigFromEnvSecretReferences(t *testing.T) {
+	for _, k := range []string{"MS365_CLIENT_ID", "TWILIO_ACCOUNT_SID"} {
+		t.Setenv(k, "")
+	}
+	secretFile := t.TempDir() + "/smtp-password"
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_PASSWORD", "token_file:"+secretFile)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIA")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env:TEST_AWS_SECRET")
+	t.Setenv("TEST_AWS_SECRET", "aws-secret")
+
+	config, err := LoadConfigFromEnv()
+	if err != nil {
+		t.Fatalf("LoadConfigFromEnv failed: %v", err)
+	}
+	if config.Email.Password != "from-file" {
+		t.Errorf("SMTP password = %q, want from-file", config.Email.Password)
+	}
+	if config.SMS.AWSSecretKey != "aws-secret" {
+		t.Errorf("AWS secret = %q, want aws-secret", config.SMS.AWSSecretKey)
+	}
+
+	t.Setenv("SMTP_PASSWORD", "env:TEST_SMTP_UNSET")
+	if _, err := LoadConfigFromEnv(); err == nil || !strings.Contains(err.Error(), "SMTP_PASSWORD") {
+		t.Errorf("LoadConfigFromEnv() error = %v, want it to name SMTP_PASSWORD", err)
+	}
+}
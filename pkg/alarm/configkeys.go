@@ -0,0 +1,113 @@
+package alarm
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// unknownKeys lists the keys in an alarm configuration that no field of
+// AlarmConfig reads, with their path (e.g. "alarms[0].cooldwon"). Keys
+// starting with "_" are comments by convention and are skipped, as is the
+// inside of any value with its own UnmarshalJSON.
+func unknownKeys(data []byte) []string {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+	var unknown []string
+	walkKeys(doc, reflect.TypeOf(AlarmConfig{}), "", &unknown)
+	return unknown
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func walkKeys(value interface{}, t reflect.Type, path string, unknown *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, item := range v {
+				walkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), unknown)
+			}
+		}
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Map:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walkKeys(v[k], t.Elem(), joinKey(path, k), unknown)
+			}
+		case reflect.Struct:
+			fields := jsonFields(t)
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if strings.HasPrefix(k, "_") {
+					continue
+				}
+				field, ok := fields[strings.ToLower(k)]
+				if !ok {
+					*unknown = append(*unknown, joinKey(path, k))
+					continue
+				}
+				walkKeys(v[k], field, joinKey(path, k), unknown)
+			}
+		}
+	}
+}
+
+// jsonFields maps the lower-cased JSON names of a struct's fields to their
+// types, matching keys case-insensitively like encoding/json
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if embedded := f.Type; f.Anonymous && name == "" {
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			for k, v := range jsonFields(embedded) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Type
+	}
+	return fields
+}
+
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
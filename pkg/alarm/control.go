@@ -0,0 +1,118 @@
+package alarm
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// findAlarmLocked returns the named alarm; the caller holds m.mu
+func (m *Manager) findAlarmLocked(name string) (*Alarm, error) {
+	for i := range m.config.Alarms {
+		if m.config.Alarms[i].Name == name {
+			return &m.config.Alarms[i], nil
+		}
+	}
+	return nil, fmt.Errorf("alarm %q not found", name)
+}
+
+// Snooze silences the named alarm's notifications for d and returns when the
+// snooze ends. A d of zero or less ends a snooze early. Like cooldowns,
+// snoozes survive restarts through the state file.
+func (m *Manager) Snooze(name string, d time.Duration) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, err := m.findAlarmLocked(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if d <= 0 {
+		a.snoozedUntil = time.Time{}
+		logger.Info("Alarm %s snooze cancelled", name)
+	} else {
+		a.snoozedUntil = time.Now().Add(d)
+		logger.Info("Alarm %s snoozed until %s", name, a.snoozedUntil.Format("15:04:05"))
+	}
+	m.saveStateLocked()
+	return a.snoozedUntil, nil
+}
+
+// Acknowledge silences the named alarm until its condition stops holding,
+// after which it notifies again the next time the condition is met. It also
+// acknowledges the alarm's latest notifications, on behalf of the dashboard.
+func (m *Manager) Acknowledge(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, err := m.findAlarmLocked(name)
+	if err != nil {
+		return err
+	}
+	m.acknowledgeLocked(a, "dashboard")
+	return nil
+}
+
+// SetEnabled enables or disables the named alarm. When the configuration
+// came from a file, the file is saved (with a backup, as the alarm editor
+// does) so the change outlasts a reload or restart.
+func (m *Manager) SetEnabled(name string, enabled bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, err := m.findAlarmLocked(name)
+	if err != nil {
+		return err
+	}
+	if a.Enabled == enabled {
+		return nil
+	}
+	a.Enabled = enabled
+	if enabled {
+		logger.Info("Alarm %s enabled", name)
+	} else {
+		logger.Info("Alarm %s disabled", name)
+	}
+	if m.configPath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(m.config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if _, err := BackupConfigFile(m.configPath); err != nil {
+		logger.Warn("Failed to back up alarm configuration: %v", err)
+	}
+	if err := writeFileSync(m.configPath, data); err != nil {
+		return fmt.Errorf("failed to save %s: %w", m.configPath, err)
+	}
+	return nil
+}
+
+// TriggerChannelTest sends a test notification for the named alarm through
+// its channel at index, like TriggerTest does for every channel
+func (m *Manager) TriggerChannelTest(name string, index int, obs *weather.Observation) error {
+	if obs == nil {
+		return fmt.Errorf("no observation available")
+	}
+	m.mu.RLock()
+	a, err := m.findAlarmLocked(name)
+	var target Alarm
+	if err == nil {
+		target = *a
+	}
+	m.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(target.Channels) {
+		return fmt.Errorf("alarm %q has no channel %d", name, index)
+	}
+	target.Channels = target.Channels[index : index+1]
+	logger.Info("Sending test notification for alarm %s through its %s channel", name, target.Channels[0].Type)
+	if errs := m.sendNotifications(&target, obs, false); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package alarm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+const controlTestConfig = `{"alarms": [
+	{"name": "Hot", "condition": "temperature > 30", "enabled": true,
+	 "channels": [{"type": "console", "template": "hot"}, {"type": "console", "template": "hot again"}]}
+]}`
+
+func TestSnoozeAndAcknowledge(t *testing.T) {
+	m, err := NewManager(controlTestConfig, "TestStation")
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer m.Stop()
+	fires := func(temp float64) bool {
+		obs := &weather.Observation{Timestamp: time.Now().Unix(), AirTemperature: temp}
+		return len(m.processObservationLocked(obs, nil)) > 0
+	}
+
+	if _, err := m.Snooze("Missing", time.Hour); err == nil {
+		t.Error("expected an error snoozing an unknown alarm")
+	}
+	until, err := m.Snooze("Hot", time.Hour)
+	if err != nil || time.Until(until) < 59*time.Minute {
+		t.Fatalf("Snooze = %v, %v", until, err)
+	}
+	if fires(35) {
+		t.Error("snoozed alarm fired")
+	}
+	if until, _ := m.Snooze("Hot", 0); !until.IsZero() || !stateAlarm(m, "Hot").GetSnoozedUntil().IsZero() {
+		t.Error("a zero duration did not end the snooze")
+	}
+	if !fires(35) {
+		t.Error("alarm did not fire after its snooze ended")
+	}
+
+	// An acknowledged alarm stays quiet while its condition holds and
+	// notifies again once the condition has cleared and recurs
+	if err := m.Acknowledge("Hot"); err != nil {
+		t.Fatalf("Acknowledge: %v", err)
+	}
+	if fires(36) || !stateAlarm(m, "Hot").IsAcknowledged() {
+		t.Error("acknowledged alarm fired while its condition held")
+	}
+	if fires(20) || stateAlarm(m, "Hot").IsAcknowledged() {
+		t.Error("acknowledgment did not clear with the condition")
+	}
+	if !fires(35) {
+		t.Error("alarm did not fire after its acknowledgment cleared")
+	}
+}
+
+func TestSnoozeSurvivesRestart(t *testing.T) {
+	SetStateFile(filepath.Join(t.TempDir(), "alarm-state.json"))
+	defer SetStateFile("")
+
+	first, err := NewManager(controlTestConfig, "TestStation")
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	until, _ := first.Snooze("Hot", time.Hour)
+	_ = first.Acknowledge("Hot")
+	first.Stop()
+
+	second, err := NewManager(controlTestConfig, "TestStation")
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer second.Stop()
+	a := stateAlarm(second, "Hot")
+	if !a.GetSnoozedUntil().Equal(until) {
+		t.Errorf("snooze not restored: got %v, want %v", a.GetSnoozedUntil(), until)
+	}
+	if !a.IsAcknowledged() {
+		t.Error("acknowledgment not restored")
+	}
+}
+
+func TestSetEnabledSavesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alarms.json")
+	if err := os.WriteFile(path, []byte(controlTestConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManager("@"+path, "TestStation")
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer m.Stop()
+
+	if err := m.SetEnabled("Missing", false); err == nil {
+		t.Error("expected an error for an unknown alarm")
+	}
+	if err := m.SetEnabled("Hot", false); err != nil {
+		t.Fatalf("SetEnabled: %v", err)
+	}
+	if stateAlarm(m, "Hot").Enabled {
+		t.Error("alarm still enabled")
+	}
+	cfg, err := LoadAlarmConfig("@" + path)
+	if err != nil {
+		t.Fatalf("LoadAlarmConfig: %v", err)
+	}
+	if cfg.Alarms[0].Enabled {
+		t.Error("disabled state was not saved to the alarm file")
+	}
+	if versions, _ := ListConfigVersions(path); len(versions) == 0 {
+		t.Error("expected a backup of the previous alarm file")
+	}
+}
+
+func TestTriggerChannelTest(t *testing.T) {
+	m, err := NewManager(controlTestConfig, "TestStation")
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer m.Stop()
+	obs := &weather.Observation{Timestamp: time.Now().Unix(), AirTemperature: 35}
+
+	if err := m.TriggerChannelTest("Hot", 1, obs); err != nil {
+		t.Errorf("TriggerChannelTest: %v", err)
+	}
+	if err := m.TriggerChannelTest("Hot", 2, obs); err == nil || !strings.Contains(err.Error(), "no channel 2") {
+		t.Errorf("expected a missing channel error, got %v", err)
+	}
+	if err := m.TriggerChannelTest("Hot", 0, nil); err == nil {
+		t.Error("expected an error without an observation")
+	}
+}
@@ -0,0 +1,247 @@
+package alarm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/stats"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// firedRetention is how long alarm firings are kept for digest counts
+const firedRetention = 8 * 24 * time.Hour
+
+// Digest is a scheduled summary of the station's weather and alarms, sent
+// through the same channels as alarm notifications
+type Digest struct {
+	Name     string    `json:"name"`
+	Period   string    `json:"period"`            // "daily" or "weekly"
+	Time     string    `json:"time,omitempty"`    // HH:MM to send at, default 07:00
+	Weekday  int       `json:"weekday,omitempty"` // weekly: 0=Sunday ... 6=Saturday
+	Timezone string    `json:"timezone,omitempty"`
+	Enabled  bool      `json:"enabled"`
+	Channels []Channel `json:"channels"`
+}
+
+// Validate checks the digest schedule and channels
+func (d *Digest) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if d.Period != "daily" && d.Period != "weekly" {
+		return fmt.Errorf("period must be daily or weekly")
+	}
+	if d.Time != "" {
+		if _, err := parseTimeOfDay(d.Time); err != nil {
+			return fmt.Errorf("invalid time: %w", err)
+		}
+	}
+	if d.Weekday < 0 || d.Weekday > 6 {
+		return fmt.Errorf("weekday must be 0 (Sunday) to 6 (Saturday)")
+	}
+	if d.Timezone != "" {
+		if _, err := time.LoadLocation(d.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", d.Timezone, err)
+		}
+	}
+	if len(d.Channels) == 0 {
+		return fmt.Errorf("at least one channel is required")
+	}
+	for j := range d.Channels {
+		if err := d.Channels[j].Validate(); err != nil {
+			return fmt.Errorf("channel %d: %w", j, err)
+		}
+	}
+	return nil
+}
+
+// days is the number of days a digest covers
+func (d *Digest) days() int {
+	if d.Period == "weekly" {
+		return 7
+	}
+	return 1
+}
+
+// due returns the most recent scheduled send time at or before now
+func (d *Digest) due(now time.Time) time.Time {
+	if d.Timezone != "" {
+		if loc, err := time.LoadLocation(d.Timezone); err == nil {
+			now = now.In(loc)
+		}
+	}
+	minutes := 7 * 60
+	if d.Time != "" {
+		minutes, _ = parseTimeOfDay(d.Time)
+	}
+	at := time.Date(now.Year(), now.Month(), now.Day(), minutes/60, minutes%60, 0, 0, now.Location())
+	if at.After(now) {
+		at = at.AddDate(0, 0, -1)
+	}
+	if d.Period == "weekly" {
+		at = at.AddDate(0, 0, -((int(at.Weekday()) - d.Weekday + 7) % 7))
+	}
+	return at
+}
+
+// DigestSummary is the content of a digest, also sent as "summary" in JSON
+// webhook and log documents
+type DigestSummary struct {
+	Name       string         `json:"name"`
+	Period     string         `json:"period"`
+	From       string         `json:"from"` // first day covered, YYYY-MM-DD
+	To         string         `json:"to"`   // last day covered
+	TempMin    float64        `json:"tempMin"`
+	TempMax    float64        `json:"tempMax"`
+	Rain       float64        `json:"rain"`    // mm
+	GustMax    float64        `json:"gustMax"` // m/s
+	BatteryMin float64        `json:"batteryMin,omitempty"`
+	Battery    []float64      `json:"battery,omitempty"` // per day, oldest first, volts
+	Alarms     map[string]int `json:"alarms"`            // firings by alarm name
+}
+
+// summarize builds the digest content from the completed days in s and the
+// alarm firings since since
+func summarize(d *Digest, s stats.Summary, fired []FiredEvent, since time.Time) (DigestSummary, bool) {
+	out := DigestSummary{Name: d.Name, Period: d.Period, Alarms: map[string]int{}}
+	days := s.Days
+	if len(days) > d.days() {
+		days = days[:d.days()]
+	}
+	if len(days) == 0 {
+		return out, false
+	}
+	out.From, out.To = days[len(days)-1].Date, days[0].Date
+	out.TempMin, out.TempMax = days[0].TempMin, days[0].TempMax
+	for i := len(days) - 1; i >= 0; i-- {
+		day := days[i]
+		out.TempMin = min(out.TempMin, day.TempMin)
+		out.TempMax = max(out.TempMax, day.TempMax)
+		out.Rain += day.Rain
+		out.GustMax = max(out.GustMax, day.GustMax)
+		if day.Battery > 0 {
+			out.Battery = append(out.Battery, day.Battery)
+			if out.BatteryMin == 0 || day.Battery < out.BatteryMin {
+				out.BatteryMin = day.Battery
+			}
+		}
+	}
+	for _, ev := range fired {
+		if !ev.Time.Before(since) {
+			out.Alarms[ev.Name]++
+		}
+	}
+	return out, true
+}
+
+// text formats the summary for message channels
+func (s DigestSummary) text() (subject, body string) {
+	period := s.From
+	if s.From != s.To {
+		period = s.From + " to " + s.To
+	}
+	subject = fmt.Sprintf("%s: %s", s.Name, period)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s)\n", s.Name, period)
+	fmt.Fprintf(&b, "Temperature: low %.1f°C (%.1f°F), high %.1f°C (%.1f°F)\n",
+		s.TempMin, s.TempMin*9/5+32, s.TempMax, s.TempMax*9/5+32)
+	fmt.Fprintf(&b, "Rain: %.1f mm (%.2f in)\n", s.Rain, s.Rain/25.4)
+	fmt.Fprintf(&b, "Peak gust: %.1f m/s (%.1f mph)\n", s.GustMax, s.GustMax*2.23694)
+	if n := len(s.Battery); n > 0 {
+		trend := "steady"
+		if change := s.Battery[n-1] - s.Battery[0]; change <= -0.05 {
+			trend = fmt.Sprintf("falling %.2f V", -change)
+		} else if change >= 0.05 {
+			trend = fmt.Sprintf("rising %.2f V", change)
+		}
+		fmt.Fprintf(&b, "Battery: %.2f V, %s (low %.2f V)\n", s.Battery[n-1], trend, s.BatteryMin)
+	}
+	if len(s.Alarms) == 0 {
+		b.WriteString("Alarms: none triggered")
+	} else {
+		names := make([]string, 0, len(s.Alarms))
+		for name := range s.Alarms {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		b.WriteString("Alarms:")
+		for _, name := range names {
+			fmt.Fprintf(&b, "\n- %s: %d", name, s.Alarms[name])
+		}
+	}
+	return subject, b.String()
+}
+
+// ProcessDigests sends the digests whose scheduled time has passed since the
+// last call. A digest seen for the first time is not sent for a time before
+// it was loaded, so restarts do not repeat the last digest. Call it
+// periodically, at least once a minute.
+func (m *Manager) ProcessDigests(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.digestsSent == nil {
+		m.digestsSent = make(map[string]time.Time)
+	}
+	for i := range m.config.Digests {
+		d := &m.config.Digests[i]
+		if !d.Enabled {
+			continue
+		}
+		due := d.due(now)
+		last, seen := m.digestsSent[d.Name]
+		m.digestsSent[d.Name] = due
+		if !seen || !due.After(last) {
+			continue
+		}
+		m.sendDigest(d, now)
+	}
+}
+
+// sendDigest delivers a digest through its channels. Callers hold m.mu.
+func (m *Manager) sendDigest(d *Digest, now time.Time) {
+	if m.stats == nil {
+		logger.Warn("Skipping digest %s: daily statistics are not running", d.Name)
+		return
+	}
+	summary, ok := summarize(d, m.stats(), m.fired, now.Add(-time.Duration(d.days())*24*time.Hour))
+	if !ok {
+		logger.Info("Skipping digest %s: no completed days yet", d.Name)
+		return
+	}
+	subject, text := summary.text()
+	doc := map[string]interface{}{"digest": true, "subject": subject, "message": text, "summary": summary}
+
+	obs := m.lastObs
+	if obs == nil {
+		obs = &weather.Observation{Timestamp: now.Unix()}
+	}
+	alarm := &Alarm{Name: d.Name, Description: subject, Enabled: true}
+	for i := range d.Channels {
+		channel := &d.Channels[i]
+		notifier, err := m.notifierFactory.GetNotifier(channel.Type)
+		if err == nil {
+			err = notifier.Send(alarm, digestChannel(channel, subject, text, doc), obs, m.stationName)
+		}
+		if err != nil {
+			logger.Error("Failed to send %s digest %s: %v", channel.Type, d.Name, err)
+			continue
+		}
+		logger.Info("Sent %s digest %s", channel.Type, d.Name)
+	}
+}
+
+// recordFired keeps an alarm firing for digest counts. Callers hold m.mu.
+func (m *Manager) recordFired(ev FiredEvent) {
+	cutoff := ev.Time.Add(-firedRetention)
+	keep := m.fired[:0]
+	for _, f := range m.fired {
+		if f.Time.After(cutoff) {
+			keep = append(keep, f)
+		}
+	}
+	m.fired = append(keep, FiredEvent{Name: ev.Name, Time: ev.Time})
+}
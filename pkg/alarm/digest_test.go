@@ -0,0 +1,132 @@
+package alarm
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/stats"
+)
+
+func TestDigestDue(t *testing.T) {
+	daily := &Digest{Period: "daily", Time: "07:30", Timezone: "UTC"}
+	weekly := &Digest{Period: "weekly", Weekday: 1, Timezone: "UTC"} // Mondays 07:00
+	tests := []struct {
+		d    *Digest
+		now  string
+		want string
+	}{
+		{daily, "2026-05-06 08:00", "2026-05-06 07:30"},
+		{daily, "2026-05-06 07:29", "2026-05-05 07:30"},
+		{weekly, "2026-05-06 08:00", "2026-05-04 07:00"}, // Wednesday
+		{weekly, "2026-05-04 06:59", "2026-04-27 07:00"}, // Monday before the time
+		{weekly, "2026-05-04 07:00", "2026-05-04 07:00"},
+	}
+	for _, tt := range tests {
+		now, _ := time.Parse("2006-01-02 15:04", tt.now)
+		if got := tt.d.due(now).Format("2006-01-02 15:04"); got != tt.want {
+			t.Errorf("%s due(%s) = %s, want %s", tt.d.Period, tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestDigestValidate(t *testing.T) {
+	console := []Channel{{Type: "console", Template: "x"}}
+	tests := []struct {
+		name    string
+		d       Digest
+		wantErr bool
+	}{
+		{"valid", Digest{Name: "Daily", Period: "daily", Time: "06:00", Channels: console}, false},
+		{"bad period", Digest{Name: "Daily", Period: "hourly", Channels: console}, true},
+		{"bad time", Digest{Name: "Daily", Period: "daily", Time: "6am", Channels: console}, true},
+		{"bad weekday", Digest{Name: "Weekly", Period: "weekly", Weekday: 7, Channels: console}, true},
+		{"no channels", Digest{Name: "Daily", Period: "daily"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.d.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSummarizeWeekly(t *testing.T) {
+	s := stats.Summary{Days: []stats.Day{ // newest first
+		{Date: "2026-05-03", TempMin: 8, TempMax: 21, Rain: 2.5, GustMax: 9, Battery: 2.55},
+		{Date: "2026-05-02", TempMin: 5, TempMax: 18, Rain: 10, GustMax: 15.2, Battery: 2.62},
+		{Date: "2026-05-01", TempMin: 6, TempMax: 24, Rain: 0, GustMax: 4, Battery: 2.66},
+	}}
+	now := time.Date(2026, 5, 4, 7, 0, 0, 0, time.UTC)
+	fired := []FiredEvent{
+		{Name: "High Wind", Time: now.Add(-48 * time.Hour)},
+		{Name: "High Wind", Time: now.Add(-24 * time.Hour)},
+		{Name: "Frost", Time: now.Add(-8 * 24 * time.Hour)}, // before the period
+	}
+	sum, ok := summarize(&Digest{Name: "Weekly", Period: "weekly"}, s, fired, now.Add(-7*24*time.Hour))
+	if !ok {
+		t.Fatal("summarize found no days")
+	}
+	if sum.From != "2026-05-01" || sum.To != "2026-05-03" || sum.TempMin != 5 || sum.TempMax != 24 ||
+		sum.Rain != 12.5 || sum.GustMax != 15.2 || sum.BatteryMin != 2.55 {
+		t.Errorf("summary = %+v", sum)
+	}
+	if len(sum.Alarms) != 1 || sum.Alarms["High Wind"] != 2 {
+		t.Errorf("alarms = %v, want High Wind: 2", sum.Alarms)
+	}
+	_, text := sum.text()
+	for _, want := range []string{"Weekly (2026-05-01 to 2026-05-03)", "Rain: 12.5 mm", "Peak gust: 15.2 m/s", "falling 0.11 V", "- High Wind: 2"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("digest text missing %q:\n%s", want, text)
+		}
+	}
+
+	if _, ok := summarize(&Digest{Period: "daily"}, stats.Summary{}, nil, now); ok {
+		t.Error("summarize without completed days should report nothing to send")
+	}
+}
+
+func TestManagerProcessDigests(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	config := `{"alarms": [], "digests": [{"name": "Morning", "period": "daily", "time": "07:00", "timezone": "UTC", "enabled": true,
+		"channels": [{"type": "webhook", "webhook": {"url": "` + srv.URL + `", "body": "unused"}}]}]}`
+	manager, err := NewManager(config, "TestStation")
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer manager.Stop()
+	manager.SetStatsProvider(func() stats.Summary {
+		return stats.Summary{Days: []stats.Day{{Date: "2026-05-05", TempMin: 3, TempMax: 17, Rain: 1, GustMax: 7}}}
+	})
+
+	day := time.Date(2026, 5, 6, 0, 0, 0, 0, time.UTC)
+	manager.ProcessDigests(day.Add(6 * time.Hour)) // first call only notes the schedule
+	manager.ProcessDigests(day.Add(6*time.Hour + 59*time.Minute))
+	manager.ProcessDigests(day.Add(7 * time.Hour))
+	manager.ProcessDigests(day.Add(7*time.Hour + time.Minute))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 {
+		t.Fatalf("sent %d digests, want 1: %v", len(bodies), bodies)
+	}
+	for _, want := range []string{`"digest":true`, `"subject":"Morning: 2026-05-05"`, `"tempMax":17`} {
+		if !strings.Contains(bodies[0], want) {
+			t.Errorf("digest %s missing %s", bodies[0], want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package editor
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+)
+
+//go:embed static/*.css static/*.js
+var embeddedStatic embed.FS
+
+// staticFiles serves /alarm-editor/static/: the embedded copy unless
+// SetStaticDir pointed it at a directory
+var staticFiles fs.FS = func() fs.FS {
+	sub, err := fs.Sub(embeddedStatic, "static")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}()
+
+// SetStaticDir serves the editor's CSS and JavaScript from dir instead of the
+// embedded copy. Call it before starting the editor.
+func SetStaticDir(dir string) {
+	staticFiles = os.DirFS(dir)
+}
@@ -0,0 +1,154 @@
+package editor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/alarm"
+)
+
+type fakeReloader struct {
+	calls int
+}
+
+func (f *fakeReloader) Reload() error {
+	f.calls++
+	return nil
+}
+
+func TestHandlerWithBasePathAndReloader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alarms.json")
+	if err := os.WriteFile(path, []byte(`{"alarms":[]}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	server, err := NewServer("@"+path, "8080", "test", "")
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	reloader := &fakeReloader{}
+	server.SetReloader(reloader)
+	h := server.Handler("/alarm-editor")
+
+	// Index page must expose the base path to the editor script
+	req := httptest.NewRequest(http.MethodGet, "/alarm-editor/", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for index, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `window.ALARM_EDITOR_BASE = "`) || !strings.Contains(w.Body.String(), `alarm-editor";</script>`) {
+		t.Errorf("index page missing base path")
+	}
+
+	// Assets come from the binary, not the working directory
+	req = httptest.NewRequest(http.MethodGet, "/alarm-editor/static/script.js", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.Len() == 0 || w.Header().Get("Content-Type") != "application/javascript" {
+		t.Errorf("embedded script.js: status %d, %d bytes, type %q", w.Code, w.Body.Len(), w.Header().Get("Content-Type"))
+	}
+
+	// API routes live under the base path
+	body := `{"name":"hot","condition":"temperature > 30","enabled":true,"channels":[{"type":"console","template":"hot"}]}`
+	req = httptest.NewRequest(http.MethodPost, "/alarm-editor/api/alarms/create", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create failed: %d %s", w.Code, w.Body.String())
+	}
+	if reloader.calls != 1 {
+		t.Errorf("expected reloader to be called once after save, got %d", reloader.calls)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read saved config: %v", err)
+	}
+	var saved alarm.AlarmConfig
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("saved config is not valid JSON: %v", err)
+	}
+	if len(saved.Alarms) != 1 || saved.Alarms[0].Name != "hot" {
+		t.Errorf("unexpected saved alarms: %+v", saved.Alarms)
+	}
+}
+
+func TestVersionEndpoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alarms.json")
+	if err := os.WriteFile(path, []byte(`{"alarms":[]}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	server, err := NewServer("@"+path, "8081", "test", "")
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	h := server.Handler("")
+
+	// Saving creates a backup of the previous file
+	body := `{"name":"hot","condition":"temperature > 30","enabled":true,"channels":[{"type":"console","template":"hot"}]}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/alarms/create", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("create failed: %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/versions", nil))
+	var versions []alarm.ConfigVersion
+	if err := json.Unmarshal(w.Body.Bytes(), &versions); err != nil || len(versions) != 1 {
+		t.Fatalf("expected one version, got %s (err=%v)", w.Body.String(), err)
+	}
+	id := versions[0].ID
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/versions/diff?id="+id, nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"op":"+"`) {
+		t.Errorf("expected diff with additions, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/versions/rollback?id="+id, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("rollback failed: %d %s", w.Code, w.Body.String())
+	}
+	if len(server.config.Alarms) != 0 {
+		t.Errorf("expected in-memory config to reflect rollback, got %d alarms", len(server.config.Alarms))
+	}
+}
+
+func TestSaveEncryptsCredentials(t *testing.T) {
+	t.Setenv("TEMPEST_MASTER_KEY", "editor-test-key")
+	path := filepath.Join(t.TempDir(), "alarms.json")
+	if err := os.WriteFile(path, []byte(`{"alarms":[]}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	server, err := NewServer("@"+path, "8081", "test", "")
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	h := server.Handler("")
+
+	body := `{"name":"hot","condition":"temperature > 30","enabled":true,"channels":[{"type":"webhook","webhook":{"url":"http://127.0.0.1:1/hook","body":"{}","hmac_secret":"s3cret"}}]}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/alarms/create", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("create failed: %d %s", w.Code, w.Body.String())
+	}
+	saved, _ := os.ReadFile(path)
+	if strings.Contains(string(saved), "s3cret") || !strings.Contains(string(saved), "enc:v1:") {
+		t.Errorf("saved file should hold the encrypted secret:\n%s", saved)
+	}
+	cfg, err := alarm.LoadAlarmConfig("@" + path)
+	if err != nil {
+		t.Fatalf("LoadAlarmConfig failed: %v", err)
+	}
+	if len(cfg.Alarms) != 1 {
+		t.Errorf("got %d alarms, want 1", len(cfg.Alarms))
+	}
+}
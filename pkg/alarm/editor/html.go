@@ -31,6 +31,7 @@ const indexHTML = `<!DOCTYPE html>
             <button class="btn btn-info" onclick="showFullJSON()">📄 View Full JSON</button>
             <button class="btn btn-warning" onclick="showEditContactsModal()">👥 Edit Contacts</button>
             <button class="btn btn-warning" onclick="showEditTagsModal()">🏷️ Edit Tags</button>
+            <button class="btn btn-info" onclick="showVersionsModal()">🕘 Versions</button>
             <button class="btn btn-success" onclick="saveAll()">💾 Save All</button>
         </div>
         
@@ -54,6 +55,20 @@ const indexHTML = `<!DOCTYPE html>
         </div>
     </div>
     
+    <div id="versionsModal" class="modal">
+        <div class="modal-content wide">
+            <div class="modal-header">🕘 Configuration Versions</div>
+            <div class="versions-layout">
+                <div class="versions-list" id="versionsList"></div>
+                <div class="json-viewer versions-diff" id="versionDiff">Select a version to compare it with the current configuration</div>
+            </div>
+            <div class="modal-actions">
+                <button type="button" class="btn btn-secondary" onclick="closeVersionsModal()">Close</button>
+                <button type="button" class="btn btn-danger" id="rollbackBtn" onclick="rollbackSelectedVersion()" disabled>↩️ Roll Back to Selected</button>
+            </div>
+        </div>
+    </div>
+
     <div id="editModal" class="modal">
         <div class="modal-content">
             <div class="modal-header">Edit Alarm</div>
@@ -74,6 +89,8 @@ const indexHTML = `<!DOCTYPE html>
                         <button type="button" class="sensor-field-btn" onclick="insertField('humidity')">humidity</button>
                         <button type="button" class="sensor-field-btn" onclick="insertField('lightning_count')">lightning_count</button>
                         <button type="button" class="sensor-field-btn" onclick="insertField('lightning_distance')">lightning_distance</button>
+                        <button type="button" class="sensor-field-btn" onclick="insertField('lightning_nearest')">lightning_nearest</button>
+                        <button type="button" class="sensor-field-btn" onclick="insertField('lightning_rate')">lightning_rate</button>
                         <button type="button" class="sensor-field-btn" onclick="insertField('lux')">lux</button>
                         <button type="button" class="sensor-field-btn" onclick="insertField('pressure')">pressure</button>
                         <button type="button" class="sensor-field-btn" onclick="insertField('rain_daily')">rain_daily</button>
@@ -87,7 +104,7 @@ const indexHTML = `<!DOCTYPE html>
                     <textarea id="alarmCondition" required></textarea>
                     <button type="button" class="btn btn-info" onclick="validateCondition()" style="margin-top: 8px;">✓ Validate Condition</button>
                     <div id="validationResult" style="margin-top: 8px; padding: 8px; border-radius: 4px; display: none;"></div>
-                    <small>Click sensor names above to insert into condition. Supports units: 80F or 26.7C (temp), 25mph or 11.2m/s (wind). Change detection: *field (any change), &gt;field (increase), &lt;field (decrease). Examples: temperature &gt; 85F, *lightning_count (any strike), &gt;rain_rate (rain increasing), &lt;lightning_distance (lightning closer)</small>
+                    <small>Click sensor names above to insert into condition. Supports units: 80F or 26.7C (temp), 25mph or 11.2m/s (wind), 10mi or 16km (lightning). Change detection: *field (any change), &gt;field (increase), &lt;field (decrease). Examples: temperature &gt; 85F, *lightning_count (any strike), &gt;rain_rate (rain increasing), &lt;lightning_distance (lightning closer)</small>
                 </div>
                 
                 <div class="form-group">
@@ -152,6 +169,7 @@ const indexHTML = `<!DOCTYPE html>
                                     <option value="{{ "{{" }}temperature_f}}">{{ "{{" }}temperature_f}} - Temperature °F (current)</option>
                                     <option value="{{ "{{" }}humidity}}">{{ "{{" }}humidity}} - Humidity % (current)</option>
                                     <option value="{{ "{{" }}pressure}}">{{ "{{" }}pressure}} - Pressure mb (current)</option>
+                                    <option value="{{ "{{" }}pressure_local}}">{{ "{{" }}pressure_local}} - Pressure with unit, inHg or mb (current)</option>
                                     <option value="{{ "{{" }}wind_speed}}">{{ "{{" }}wind_speed}} - Wind Speed m/s (current)</option>
                                     <option value="{{ "{{" }}wind_gust}}">{{ "{{" }}wind_gust}} - Wind Gust m/s (current)</option>
                                     <option value="{{ "{{" }}wind_direction}}">{{ "{{" }}wind_direction}} - Wind Direction° (current)</option>
@@ -161,6 +179,9 @@ const indexHTML = `<!DOCTYPE html>
                                     <option value="{{ "{{" }}rain_daily}}">{{ "{{" }}rain_daily}} - Daily Rain mm (current)</option>
                                     <option value="{{ "{{" }}lightning_count}}">{{ "{{" }}lightning_count}} - Lightning Strikes (current)</option>
                                     <option value="{{ "{{" }}lightning_distance}}">{{ "{{" }}lightning_distance}} - Lightning Distance km (current)</option>
+                                    <option value="{{ "{{" }}lightning_nearest}}">{{ "{{" }}lightning_nearest}} - Nearest Strike km (storm)</option>
+                                    <option value="{{ "{{" }}lightning_nearest_local}}">{{ "{{" }}lightning_nearest_local}} - Nearest Strike in --units (storm)</option>
+                                    <option value="{{ "{{" }}lightning_rate}}">{{ "{{" }}lightning_rate}} - Strikes per Minute</option>
                                 </select>
                                 <button type="button" class="btn btn-secondary" onclick="showEmojiPicker('consoleMessage')" title="Insert Emoji">😀</button>
                             </div>
@@ -186,6 +207,7 @@ const indexHTML = `<!DOCTYPE html>
                                     <option value="{{ "{{" }}temperature_f}}">{{ "{{" }}temperature_f}} - Temperature °F (current)</option>
                                     <option value="{{ "{{" }}humidity}}">{{ "{{" }}humidity}} - Humidity % (current)</option>
                                     <option value="{{ "{{" }}pressure}}">{{ "{{" }}pressure}} - Pressure mb (current)</option>
+                                    <option value="{{ "{{" }}pressure_local}}">{{ "{{" }}pressure_local}} - Pressure with unit, inHg or mb (current)</option>
                                     <option value="{{ "{{" }}wind_speed}}">{{ "{{" }}wind_speed}} - Wind Speed m/s (current)</option>
                                 </select>
                                 <button type="button" class="btn btn-secondary" onclick="showEmojiPicker('syslogMessage')" title="Insert Emoji">😀</button>
@@ -243,6 +265,8 @@ const indexHTML = `<!DOCTYPE html>
                                     <option value="{{ "{{" }}alarm_description}}">{{ "{{" }}alarm_description}} - Alarm description</option>
                                     <option value="{{ "{{" }}station}}">{{ "{{" }}station}} - Station name</option>
                                     <option value="{{ "{{" }}timestamp}}">{{ "{{" }}timestamp}} - Current time</option>
+                                    <option value="{{ "{{" }}ack_url}}">{{ "{{" }}ack_url}} - Acknowledgement link</option>
+                                    <option value="{{ "{{" }}ack_code}}">{{ "{{" }}ack_code}} - Code for an SMS reply (ACK code)</option>
                                 </select>
                                 <button type="button" class="btn btn-secondary" onclick="showEmojiPicker('emailBody')" title="Insert Emoji">😀</button>
                             </div>
@@ -285,6 +309,8 @@ const indexHTML = `<!DOCTYPE html>
                                     <option value="{{ "{{" }}alarm_name}}">{{ "{{" }}alarm_name}} - Alarm name</option>
                                     <option value="{{ "{{" }}station}}">{{ "{{" }}station}} - Station name</option>
                                     <option value="{{ "{{" }}timestamp}}">{{ "{{" }}timestamp}} - Current time</option>
+                                    <option value="{{ "{{" }}ack_url}}">{{ "{{" }}ack_url}} - Acknowledgement link</option>
+                                    <option value="{{ "{{" }}ack_code}}">{{ "{{" }}ack_code}} - Code for an SMS reply (ACK code)</option>
                                 </select>
                                 <button type="button" class="btn btn-secondary" onclick="showEmojiPicker('smsMessage')" title="Insert Emoji">😀</button>
                             </div>
@@ -326,6 +352,7 @@ const indexHTML = `<!DOCTYPE html>
                                     <option value="{{ "{{" }}temperature_f}}">{{ "{{" }}temperature_f}} - Temperature °F (current)</option>
                                     <option value="{{ "{{" }}humidity}}">{{ "{{" }}humidity}} - Humidity % (current)</option>
                                     <option value="{{ "{{" }}pressure}}">{{ "{{" }}pressure}} - Pressure mb (current)</option>
+                                    <option value="{{ "{{" }}pressure_local}}">{{ "{{" }}pressure_local}} - Pressure with unit, inHg or mb (current)</option>
                                     <option value="{{ "{{" }}wind_speed}}">{{ "{{" }}wind_speed}} - Wind Speed m/s (current)</option>
                                     <option value="{{ "{{" }}wind_gust}}">{{ "{{" }}wind_gust}} - Wind Gust m/s (current)</option>
                                     <option value="{{ "{{" }}wind_direction}}">{{ "{{" }}wind_direction}} - Wind Direction° (current)</option>
@@ -335,6 +362,11 @@ const indexHTML = `<!DOCTYPE html>
                                     <option value="{{ "{{" }}rain_daily}}">{{ "{{" }}rain_daily}} - Daily Rain mm (current)</option>
                                     <option value="{{ "{{" }}lightning_count}}">{{ "{{" }}lightning_count}} - Lightning Strikes (current)</option>
                                     <option value="{{ "{{" }}lightning_distance}}">{{ "{{" }}lightning_distance}} - Lightning Distance km (current)</option>
+                                    <option value="{{ "{{" }}lightning_nearest}}">{{ "{{" }}lightning_nearest}} - Nearest Strike km (storm)</option>
+                                    <option value="{{ "{{" }}lightning_nearest_local}}">{{ "{{" }}lightning_nearest_local}} - Nearest Strike in --units (storm)</option>
+                                    <option value="{{ "{{" }}lightning_rate}}">{{ "{{" }}lightning_rate}} - Strikes per Minute</option>
+                                    <option value="{{ "{{" }}ack_url}}">{{ "{{" }}ack_url}} - Acknowledgement link</option>
+                                    <option value="{{ "{{" }}ack_code}}">{{ "{{" }}ack_code}} - Code for an SMS reply (ACK code)</option>
                                 </select>
                                 <button type="button" class="btn btn-secondary" onclick="showEmojiPicker('webhookBody')" title="Insert Emoji">😀</button>
                             </div>
@@ -374,6 +406,7 @@ const indexHTML = `<!DOCTYPE html>
                                     <option value="{{ "{{" }}temperature_f}}">{{ "{{" }}temperature_f}} - Temperature °F (current)</option>
                                     <option value="{{ "{{" }}humidity}}">{{ "{{" }}humidity}} - Humidity % (current)</option>
                                     <option value="{{ "{{" }}pressure}}">{{ "{{" }}pressure}} - Pressure mb (current)</option>
+                                    <option value="{{ "{{" }}pressure_local}}">{{ "{{" }}pressure_local}} - Pressure with unit, inHg or mb (current)</option>
                                     <option value="{{ "{{" }}wind_speed}}">{{ "{{" }}wind_speed}} - Wind Speed m/s (current)</option>
                                     <option value="{{ "{{" }}wind_gust}}">{{ "{{" }}wind_gust}} - Wind Gust m/s (current)</option>
                                     <option value="{{ "{{" }}wind_direction}}">{{ "{{" }}wind_direction}} - Wind Direction° (current)</option>
@@ -383,6 +416,9 @@ const indexHTML = `<!DOCTYPE html>
                                     <option value="{{ "{{" }}rain_daily}}">{{ "{{" }}rain_daily}} - Daily Rain mm (current)</option>
                                     <option value="{{ "{{" }}lightning_count}}">{{ "{{" }}lightning_count}} - Lightning Strikes (current)</option>
                                     <option value="{{ "{{" }}lightning_distance}}">{{ "{{" }}lightning_distance}} - Lightning Distance km (current)</option>
+                                    <option value="{{ "{{" }}lightning_nearest}}">{{ "{{" }}lightning_nearest}} - Nearest Strike km (storm)</option>
+                                    <option value="{{ "{{" }}lightning_nearest_local}}">{{ "{{" }}lightning_nearest_local}} - Nearest Strike in --units (storm)</option>
+                                    <option value="{{ "{{" }}lightning_rate}}">{{ "{{" }}lightning_rate}} - Strikes per Minute</option>
                                 </select>
                                 <button type="button" class="btn btn-secondary" onclick="showEmojiPicker('csvMessage')" title="Insert Emoji">😀</button>
                             </div>
@@ -414,6 +450,7 @@ const indexHTML = `<!DOCTYPE html>
                                     <option value="{{ "{{" }}temperature_f}}">{{ "{{" }}temperature_f}} - Temperature °F (current)</option>
                                     <option value="{{ "{{" }}humidity}}">{{ "{{" }}humidity}} - Humidity % (current)</option>
                                     <option value="{{ "{{" }}pressure}}">{{ "{{" }}pressure}} - Pressure mb (current)</option>
+                                    <option value="{{ "{{" }}pressure_local}}">{{ "{{" }}pressure_local}} - Pressure with unit, inHg or mb (current)</option>
                                     <option value="{{ "{{" }}wind_speed}}">{{ "{{" }}wind_speed}} - Wind Speed m/s (current)</option>
                                     <option value="{{ "{{" }}wind_gust}}">{{ "{{" }}wind_gust}} - Wind Gust m/s (current)</option>
                                     <option value="{{ "{{" }}wind_direction}}">{{ "{{" }}wind_direction}} - Wind Direction° (current)</option>
@@ -423,6 +460,9 @@ const indexHTML = `<!DOCTYPE html>
                                     <option value="{{ "{{" }}rain_daily}}">{{ "{{" }}rain_daily}} - Daily Rain mm (current)</option>
                                     <option value="{{ "{{" }}lightning_count}}">{{ "{{" }}lightning_count}} - Lightning Strikes (current)</option>
                                     <option value="{{ "{{" }}lightning_distance}}">{{ "{{" }}lightning_distance}} - Lightning Distance km (current)</option>
+                                    <option value="{{ "{{" }}lightning_nearest}}">{{ "{{" }}lightning_nearest}} - Nearest Strike km (storm)</option>
+                                    <option value="{{ "{{" }}lightning_nearest_local}}">{{ "{{" }}lightning_nearest_local}} - Nearest Strike in --units (storm)</option>
+                                    <option value="{{ "{{" }}lightning_rate}}">{{ "{{" }}lightning_rate}} - Strikes per Minute</option>
                                 </select>
                                 <button type="button" class="btn btn-secondary" onclick="showEmojiPicker('jsonMessage')" title="Insert Emoji">😀</button>
                             </div>
@@ -582,6 +622,12 @@ const indexHTML = `<!DOCTYPE html>
                     <input type="number" id="alarmCooldown" value="1800" min="0" />
                     <small>Minimum time between consecutive alarm triggers</small>
                 </div>
+
+                <div class="form-group">
+                    <label>Sustained For</label>
+                    <input type="text" id="alarmSustainedFor" placeholder="e.g. 10m or 3 obs" />
+                    <small>Fire only after the condition holds this long or for this many observations (empty fires at once)</small>
+                </div>
                 
                 <div class="form-group">
                     <label>
@@ -590,6 +636,11 @@ const indexHTML = `<!DOCTYPE html>
                     </label>
                 </div>
                 
+                <div class="form-group">
+                    <button type="button" class="btn btn-info" onclick="previewNotifications()">👁️ Preview Notifications</button>
+                    <div id="previewResult" style="margin-top: 8px; padding: 8px; border-radius: 4px; background: #eef4fb; display: none;"></div>
+                </div>
+                
                 <div class="modal-actions">
                     <button type="button" class="btn btn-secondary" onclick="closeModal()">Cancel</button>
                     <button type="button" class="btn btn-danger" onclick="deleteAlarm()" id="deleteBtn" style="display:none;">Delete</button>
@@ -671,6 +722,7 @@ const indexHTML = `<!DOCTYPE html>
         </div>
     </div>
     
+    <script>window.ALARM_EDITOR_BASE = {{.BasePath}};</script>
     <script src="/alarm-editor/static/script.js"></script>
     
     <div class="footer">
@@ -8,11 +8,14 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"tempest-homekit-go/pkg/alarm"
 	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/secrets"
 	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/websec"
 )
 
 // Server represents the alarm editor web server
@@ -24,6 +27,24 @@ type Server struct {
 	config       *alarm.AlarmConfig
 	lastLoadTime time.Time
 	contacts     []Contact
+	basePath     string         // URL prefix when mounted inside another server (e.g. /alarm-editor)
+	reloader     ConfigReloader // running alarm manager to notify after saves (optional)
+	previewer    Previewer      // running alarm manager rendering previews with live data (optional)
+	mu           sync.Mutex     // serializes API handlers when shared with the main web server
+	security     websec.Policy  // headers of the standalone server; the main web server applies its own
+}
+
+// ConfigReloader is implemented by the running alarm manager so edits made
+// through an embedded editor take effect immediately.
+type ConfigReloader interface {
+	Reload() error
+}
+
+// Previewer is implemented by the running alarm manager so previews use the
+// latest observation and the station name.
+type Previewer interface {
+	GetLastObservation() *weather.Observation
+	Preview(a *alarm.Alarm, obs *weather.Observation) []alarm.SimulatedMessage
 }
 
 // Contact represents a contact entry for alarm notifications
@@ -84,21 +105,59 @@ func (s *Server) loadConfig() error {
 	return nil
 }
 
-// saveConfig saves the alarm configuration to file
+// saveConfig saves the alarm configuration to file. With TEMPEST_MASTER_KEY
+// set, plaintext webhook credentials are encrypted first.
 func (s *Server) saveConfig() error {
+	key, err := secrets.MasterKey()
+	if err != nil {
+		return err
+	}
+	if key != nil {
+		if n, err := alarm.EncryptSecrets(s.config, key); err != nil {
+			return fmt.Errorf("failed to encrypt credentials: %w", err)
+		} else if n > 0 {
+			logger.Info("Encrypted %d credential(s) in the alarm configuration", n)
+		}
+	}
+
 	data, err := json.MarshalIndent(s.config, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
+	// Keep a timestamped copy of the previous file so bad edits can be rolled back
+	if id, err := alarm.BackupConfigFile(s.configPath); err != nil {
+		logger.Warn("Failed to back up alarm configuration: %v", err)
+	} else if id != "" {
+		logger.Debug("Backed up previous alarm configuration as version %s", id)
+	}
+
 	if err := os.WriteFile(s.configPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
 	logger.Info("Saved alarm configuration to: %s", s.configPath)
+
+	if s.reloader != nil {
+		if err := s.reloader.Reload(); err != nil {
+			logger.Error("Alarm manager failed to reload saved configuration: %v", err)
+		}
+	}
 	return nil
 }
 
+// SetReloader connects the editor to a running alarm manager so that every
+// successful save is applied to the live service.
+func (s *Server) SetReloader(r ConfigReloader) {
+	s.reloader = r
+}
+
+// SetPreviewer connects the editor to a running alarm manager so that
+// notification previews use the latest observation.
+func (s *Server) SetPreviewer(p Previewer) {
+	s.previewer = p
+}
+
 // loadContacts loads the contact list from the CONTACT_LIST environment variable or from the env file
 func (s *Server) loadContacts() error {
 	var contactListJSON string
@@ -176,36 +235,63 @@ func (s *Server) loadContacts() error {
 
 // Start starts the alarm editor web server
 func (s *Server) Start() error {
+	addr := ":" + s.port
+	logger.Info("Starting Alarm Editor on http://localhost%s", addr)
+	logger.Info("Editing: %s", s.configPath)
+	logger.Info("Press Ctrl+C to stop")
+
+	return http.ListenAndServe(addr, websec.Wrap(s.security, s.Handler("")))
+}
+
+// SetSecurityPolicy sets the CORS origins, Content-Security-Policy and
+// framing rules of the standalone editor started by Start
+func (s *Server) SetSecurityPolicy(policy websec.Policy) {
+	s.security = policy
+}
+
+// Handler returns the editor UI and API routes. basePath is the URL prefix the
+// handler is mounted under: "" when running standalone, or e.g. "/alarm-editor"
+// when served from the main web console.
+func (s *Server) Handler(basePath string) http.Handler {
+	s.basePath = strings.TrimSuffix(basePath, "/")
+
 	mux := http.NewServeMux()
 
 	// Main editor page
-	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc(s.basePath+"/", s.handleIndex)
 
 	// Static files
 	mux.HandleFunc("/alarm-editor/static/", s.handleStaticFiles)
 
-	// API endpoints
-	mux.HandleFunc("/api/config", s.handleGetConfig)
-	mux.HandleFunc("/api/config/save", s.handleSaveConfig)
-	mux.HandleFunc("/api/alarms", s.handleListAlarms)
-	mux.HandleFunc("/api/alarms/create", s.handleCreateAlarm)
-	mux.HandleFunc("/api/alarms/update", s.handleUpdateAlarm)
-	mux.HandleFunc("/api/alarms/delete", s.handleDeleteAlarm)
-	mux.HandleFunc("/api/tags", s.handleGetTags)
-	mux.HandleFunc("/api/tags/save", s.handleSaveTags)
-	mux.HandleFunc("/api/validate", s.handleValidate)
-	mux.HandleFunc("/api/validate-json", s.handleValidateJSON)
-	mux.HandleFunc("/api/fields", s.handleGetFields)
-	mux.HandleFunc("/api/env-defaults", s.handleGetEnvDefaults)
-	mux.HandleFunc("/api/contacts", s.handleGetContacts)
-	mux.HandleFunc("/api/contacts/save", s.handleSaveContacts)
-
-	addr := ":" + s.port
-	logger.Info("Starting Alarm Editor on http://localhost%s", addr)
-	logger.Info("Editing: %s", s.configPath)
-	logger.Info("Press Ctrl+C to stop")
-
-	return http.ListenAndServe(addr, mux)
+	// API endpoints (serialized, since the config is shared across requests)
+	api := func(path string, h http.HandlerFunc) {
+		mux.HandleFunc(s.basePath+path, func(w http.ResponseWriter, r *http.Request) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			h(w, r)
+		})
+	}
+	api("/api/config", s.handleGetConfig)
+	api("/api/config/save", s.handleSaveConfig)
+	api("/api/alarms", s.handleListAlarms)
+	api("/api/alarms/create", s.handleCreateAlarm)
+	api("/api/alarms/update", s.handleUpdateAlarm)
+	api("/api/alarms/delete", s.handleDeleteAlarm)
+	api("/api/alarms/validate", s.handleValidate)
+	api("/api/alarms/preview", s.handlePreview)
+	api("/api/tags", s.handleGetTags)
+	api("/api/tags/save", s.handleSaveTags)
+	api("/api/validate", s.handleValidate)
+	api("/api/validate-json", s.handleValidateJSON)
+	api("/api/fields", s.handleGetFields)
+	api("/api/env-defaults", s.handleGetEnvDefaults)
+	api("/api/contacts", s.handleGetContacts)
+	api("/api/contacts/save", s.handleSaveContacts)
+	api("/api/versions", s.handleListVersions)
+	api("/api/versions/diff", s.handleVersionDiff)
+	api("/api/versions/rollback", s.handleRollbackVersion)
+
+	return mux
 }
 
 // handleStaticFiles serves static CSS and JS files
@@ -215,9 +301,6 @@ func (s *Server) handleStaticFiles(w http.ResponseWriter, r *http.Request) {
 
 	logger.Debug("Static file request: %s (path: %s)", filename, r.URL.Path)
 
-	// Serve the file from the physical directory
-	filePath := "./pkg/alarm/editor/static/" + filename
-
 	// Set appropriate content type
 	switch {
 	case strings.HasSuffix(filename, ".css"):
@@ -228,7 +311,7 @@ func (s *Server) handleStaticFiles(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
 	}
 
-	http.ServeFile(w, r, filePath)
+	http.ServeFileFS(w, r, staticFiles, filename)
 }
 
 // handleIndex serves the main editor HTML page
@@ -250,6 +333,7 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		"Version":    s.version,
 		"EnvFile":    s.envFile,
 		"LastLoad":   lastLoad,
+		"BasePath":   s.basePath,
 	}
 	if err := tmpl.Execute(w, data); err != nil {
 		logger.Error("Failed to execute template: %v", err)
@@ -282,6 +366,10 @@ func (s *Server) handleSaveConfig(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Invalid configuration: %v", err), http.StatusBadRequest)
 		return
 	}
+	if err := config.ValidateConditions(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid configuration: %v", err), http.StatusBadRequest)
+		return
+	}
 
 	s.config = &config
 	if err := s.saveConfig(); err != nil {
@@ -349,6 +437,11 @@ func (s *Server) handleCreateAlarm(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if errs := alarm.ValidateCondition(newAlarm.Condition); len(errs) > 0 {
+		http.Error(w, fmt.Sprintf("Invalid condition: %v", errs[0]), http.StatusBadRequest)
+		return
+	}
+
 	// Validate channels
 	for i, ch := range newAlarm.Channels {
 		if err := ch.Validate(); err != nil {
@@ -397,6 +490,11 @@ func (s *Server) handleUpdateAlarm(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if errs := alarm.ValidateCondition(updatedAlarm.Condition); len(errs) > 0 {
+		http.Error(w, fmt.Sprintf("Invalid condition: %v", errs[0]), http.StatusBadRequest)
+		return
+	}
+
 	// Find and update the alarm by old name
 	found := false
 	for i, a := range s.config.Alarms {
@@ -556,7 +654,9 @@ func (s *Server) handleGetTags(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(tags)
 }
 
-// handleValidate validates an alarm condition
+// handleValidate validates an alarm condition: its syntax, fields and the
+// units of its values. Every problem is returned with its position, so the
+// editor can point at the offending text.
 func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -572,71 +672,104 @@ func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create a test observation with reasonable values
-	testObs := &weather.Observation{
-		AirTemperature:   20.0,
-		RelativeHumidity: 50.0,
-		StationPressure:  1013.25,
-		WindAvg:          5.0,
-		Illuminance:      10000,
-		UV:               3,
+	errs := alarm.ValidateCondition(req.Condition)
+	response := map[string]interface{}{
+		"valid":  len(errs) == 0,
+		"errors": errs,
+	}
+	if len(errs) > 0 {
+		response["error"] = errs[0].Error()
+	} else {
+		response["errors"] = []alarm.ConditionError{}
+		response["paraphrase"] = alarm.NewEvaluator().Paraphrase(strings.TrimSpace(req.Condition))
 	}
 
-	// Validate condition format before evaluation
-	condition := strings.TrimSpace(req.Condition)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
 
-	var validationErr error
+// previewResponse is returned by /api/alarms/preview
+type previewResponse struct {
+	Source      string                   `json:"source"` // "supplied", "current" or "sample"
+	Observation weather.Observation      `json:"observation"`
+	Messages    []alarm.SimulatedMessage `json:"messages"`
+}
 
-	if condition == "" {
-		validationErr = fmt.Errorf("condition cannot be empty")
-	} else if strings.HasSuffix(condition, "&&") || strings.HasSuffix(condition, "||") {
-		validationErr = fmt.Errorf("condition ends with incomplete operator (&&/||)")
-	} else if strings.HasPrefix(condition, "&&") || strings.HasPrefix(condition, "||") {
-		validationErr = fmt.Errorf("condition starts with incomplete operator (&&/||)")
-	} else if strings.Contains(condition, "&&") || strings.Contains(condition, "||") {
-		// Check for empty parts in compound conditions
-		// Split on the actual operators, not individual characters
-		var parts []string
-		if strings.Contains(condition, "&&") {
-			parts = strings.Split(condition, "&&")
-		} else {
-			parts = strings.Split(condition, "||")
-		}
+// handlePreview renders the message every channel of an alarm would send:
+// the alarm in the request (e.g. the unsaved editor form) or a saved alarm
+// by name, with the supplied observation, else the latest observation of
+// the running service, else sample data. Nothing is sent.
+func (s *Server) handlePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-		for i, part := range parts {
-			if strings.TrimSpace(part) == "" {
-				validationErr = fmt.Errorf("logical operator (&&/||) requires expressions on both sides (missing expression at position %d)", i+1)
+	var req struct {
+		Name        string               `json:"name"`
+		Alarm       *alarm.Alarm         `json:"alarm"`
+		Observation *weather.Observation `json:"observation"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 256<<10)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	target := req.Alarm
+	if target == nil {
+		for i := range s.config.Alarms {
+			if s.config.Alarms[i].Name == req.Name {
+				target = &s.config.Alarms[i]
 				break
 			}
 		}
+		if target == nil {
+			http.Error(w, fmt.Sprintf("Alarm '%s' not found", req.Name), http.StatusNotFound)
+			return
+		}
 	}
 
-	response := map[string]interface{}{}
+	resp := previewResponse{Source: "supplied"}
+	obs := req.Observation
+	if obs == nil && s.previewer != nil {
+		obs, resp.Source = s.previewer.GetLastObservation(), "current"
+	}
+	if obs == nil {
+		obs, resp.Source = sampleObservation(), "sample"
+	}
+	if obs.Timestamp == 0 {
+		obs.Timestamp = time.Now().Unix()
+	}
+	resp.Observation = *obs
 
-	if validationErr != nil {
-		response["valid"] = false
-		response["error"] = validationErr.Error()
+	if s.previewer != nil {
+		resp.Messages = s.previewer.Preview(target, obs)
 	} else {
-		// Create a dummy alarm context for change detection validation
-		dummyAlarm := &alarm.Alarm{
-			Name: "validation-test",
-		}
-
-		evaluator := alarm.NewEvaluator()
-		_, err := evaluator.EvaluateWithAlarm(condition, testObs, dummyAlarm)
-
-		response["valid"] = err == nil
-
-		if err != nil {
-			response["error"] = err.Error()
-		} else {
-			// Add paraphrase for valid conditions
-			response["paraphrase"] = evaluator.Paraphrase(condition)
-		}
+		resp.Messages = alarm.PreviewMessages(target, obs, "Test Station")
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(response)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// sampleObservation is the observation previews use when the editor runs
+// without a live service
+func sampleObservation() *weather.Observation {
+	return &weather.Observation{
+		Timestamp:            time.Now().Unix(),
+		AirTemperature:       25.5,
+		RelativeHumidity:     65.0,
+		StationPressure:      1013.25,
+		WindAvg:              8.5,
+		WindGust:             12.3,
+		WindDirection:        180.0,
+		Illuminance:          50000,
+		UV:                   6,
+		RainAccumulated:      2.5,
+		RainDailyTotal:       15.2,
+		LightningStrikeCount: 3,
+		LightningStrikeAvg:   8,
+	}
 }
 
 // handleValidateJSON validates a JSON message template
@@ -656,21 +789,7 @@ func (s *Server) handleValidateJSON(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create test data for template expansion
-	testObs := &weather.Observation{
-		Timestamp:            time.Now().Unix(),
-		AirTemperature:       25.5,
-		RelativeHumidity:     65.0,
-		StationPressure:      1013.25,
-		WindAvg:              8.5,
-		WindGust:             12.3,
-		WindDirection:        180.0,
-		Illuminance:          50000,
-		UV:                   6,
-		RainAccumulated:      2.5,
-		RainDailyTotal:       15.2,
-		LightningStrikeCount: 3,
-		// LightningStrikeAvg is not used in template validation
-	}
+	testObs := sampleObservation()
 
 	testAlarm := &alarm.Alarm{
 		Name:           "test-alarm",
@@ -1001,3 +1120,75 @@ func (s *Server) handleSaveTags(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
 }
+
+// handleListVersions returns the available backups of the config file, newest first
+func (s *Server) handleListVersions(w http.ResponseWriter, r *http.Request) {
+	versions, err := alarm.ListConfigVersions(s.configPath)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list versions: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(versions)
+}
+
+// handleVersionDiff returns a line diff from a backup to the current config file
+func (s *Server) handleVersionDiff(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "id parameter required", http.StatusBadRequest)
+		return
+	}
+
+	old, err := alarm.ReadConfigVersion(s.configPath, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	current, err := os.ReadFile(s.configPath)
+	if err != nil && !os.IsNotExist(err) {
+		http.Error(w, fmt.Sprintf("Failed to read current config: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":   id,
+		"diff": alarm.DiffConfigLines(string(old), string(current)),
+	})
+}
+
+// handleRollbackVersion restores a backup as the current configuration
+func (s *Server) handleRollbackVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "id parameter required", http.StatusBadRequest)
+		return
+	}
+
+	if err := alarm.RollbackConfigVersion(s.configPath, id); err != nil {
+		http.Error(w, fmt.Sprintf("Rollback failed: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.loadConfig(); err != nil {
+		http.Error(w, fmt.Sprintf("Rolled back but failed to reload: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if s.reloader != nil {
+		if err := s.reloader.Reload(); err != nil {
+			logger.Error("Alarm manager failed to reload rolled back configuration: %v", err)
+		}
+	}
+	logger.Info("Rolled back alarm configuration %s to version %s", s.configPath, id)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "success", "version": id})
+}
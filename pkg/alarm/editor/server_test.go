@@ -5,8 +5,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/weather"
 	"testing"
 )
 
@@ -355,6 +357,90 @@ func TestHandleValidate(t *testing.T) {
 	}
 }
 
+func TestHandleValidateErrorPositions(t *testing.T) {
+	server := &Server{configPath: "test.json", config: &alarm.AlarmConfig{}}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/alarms/validate", strings.NewReader(`{"condition":"temperature > 90mb"}`))
+	w := httptest.NewRecorder()
+	server.handleValidate(w, req)
+
+	var result struct {
+		Valid  bool                   `json:"valid"`
+		Error  string                 `json:"error"`
+		Errors []alarm.ConditionError `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode validate response: %v", err)
+	}
+	if result.Valid || len(result.Errors) != 1 {
+		t.Fatalf("valid=%v errors=%v, want one error", result.Valid, result.Errors)
+	}
+	if got := result.Errors[0]; got.Start != 14 || got.End != 18 || got.Text != "90mb" {
+		t.Errorf("error position = %+v, want 90mb at 14-18", got)
+	}
+	if !strings.Contains(result.Error, `unit "mb" does not apply to temperature`) {
+		t.Errorf("error = %q", result.Error)
+	}
+}
+
+func TestCreateAlarmRejectsInvalidUnits(t *testing.T) {
+	server := &Server{configPath: filepath.Join(t.TempDir(), "alarms.json"), config: &alarm.AlarmConfig{}}
+
+	body := `{"name":"Heat","condition":"temperature > 90mb","enabled":true,"channels":[{"type":"console"}]}`
+	w := httptest.NewRecorder()
+	server.handleCreateAlarm(w, httptest.NewRequest(http.MethodPost, "/api/alarms/create", strings.NewReader(body)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Invalid condition") {
+		t.Errorf("status %d body %q, want 400 Invalid condition", w.Code, w.Body.String())
+	}
+	if len(server.config.Alarms) != 0 {
+		t.Errorf("alarm with an invalid condition was saved")
+	}
+}
+
+type fakePreviewer struct{ obs *weather.Observation }
+
+func (f fakePreviewer) GetLastObservation() *weather.Observation { return f.obs }
+
+func (f fakePreviewer) Preview(a *alarm.Alarm, obs *weather.Observation) []alarm.SimulatedMessage {
+	return alarm.PreviewMessages(a, obs, "Live Station")
+}
+
+func TestHandlePreview(t *testing.T) {
+	server := &Server{configPath: "test.json", config: &alarm.AlarmConfig{Alarms: []alarm.Alarm{
+		{Name: "Saved", Condition: "uv > 8", Channels: []alarm.Channel{{Type: "console", Template: "UV {{uv}}"}}},
+	}}}
+	preview := func(body string) (int, previewResponse) {
+		w := httptest.NewRecorder()
+		server.handlePreview(w, httptest.NewRequest(http.MethodPost, "/api/alarms/preview", strings.NewReader(body)))
+		var resp previewResponse
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode preview response: %v", err)
+			}
+		}
+		return w.Code, resp
+	}
+
+	unsaved := `{"alarm": {"name": "Hot", "condition": "temperature > 40", "channels": [{"type": "console", "template": "{{station}} {{temperature_c}}"}]}`
+	if code, resp := preview(unsaved + `}`); code != http.StatusOK || resp.Source != "sample" || resp.Messages[0].Message != "Test Station 25.5" {
+		t.Errorf("sample preview = %d %+v", code, resp)
+	}
+	if code, resp := preview(unsaved + `, "observation": {"air_temperature": 41}}`); code != http.StatusOK || resp.Source != "supplied" || resp.Messages[0].Message != "Test Station 41.0" {
+		t.Errorf("supplied preview = %d %+v", code, resp)
+	}
+	if code, resp := preview(`{"name": "Saved"}`); code != http.StatusOK || resp.Messages[0].Message != "UV 6" {
+		t.Errorf("saved alarm preview = %d %+v", code, resp)
+	}
+	if code, _ := preview(`{"name": "Missing"}`); code != http.StatusNotFound {
+		t.Errorf("missing alarm status = %d, want 404", code)
+	}
+
+	server.SetPreviewer(fakePreviewer{obs: &weather.Observation{Timestamp: 1700000000, AirTemperature: 30}})
+	if code, resp := preview(unsaved + `}`); code != http.StatusOK || resp.Source != "current" || resp.Messages[0].Message != "Live Station 30.0" {
+		t.Errorf("live preview = %d %+v", code, resp)
+	}
+}
+
 func TestHandleGetFields(t *testing.T) {
 	server := &Server{
 		configPath: "test.json",
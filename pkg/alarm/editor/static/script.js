@@ -6,6 +6,9 @@ let contacts = [];
 let selectedEmailContacts = [];
 let selectedSMSContacts = [];
 
+// URL prefix for API calls; set to /alarm-editor when served from the main web console
+const API_BASE = window.ALARM_EDITOR_BASE || '';
+
 // ============================================
 // Theme Switching System
 // ============================================
@@ -56,21 +59,21 @@ async function init() {
 }
 
 async function loadAlarms() {
-    const response = await fetch('/api/config?_=' + Date.now());
+    const response = await fetch(API_BASE + '/api/config?_=' + Date.now());
     const config = await response.json();
     alarms = config.alarms || [];
     renderAlarms();
 }
 
 async function loadTags() {
-    const response = await fetch('/api/tags');
+    const response = await fetch(API_BASE + '/api/tags');
     allTags = await response.json();
     updateTagFilter();
 }
 
 async function loadContacts() {
     try {
-        const response = await fetch('/api/contacts');
+        const response = await fetch(API_BASE + '/api/contacts');
         contacts = await response.json();
     } catch (error) {
         console.warn('Failed to load contacts:', error);
@@ -683,6 +686,13 @@ function loadScheduleIntoForm(schedule) {
     toggleCustomLocation();
 }
 
+// Settings of the alarm being edited that the form does not show (e.g. CSV
+// columns, JSON fields), kept when the channel is saved
+function existingChannelConfig(type) {
+    const channel = currentAlarm && (currentAlarm.channels || []).find(c => c.type === type);
+    return (channel && channel[type]) || {};
+}
+
 function serializeScheduleFromForm() {
     const scheduleType = document.getElementById('scheduleType').value;
     
@@ -750,6 +760,7 @@ function showCreateModal() {
     document.getElementById('alarmDescription').value = '';
     document.getElementById('alarmCondition').value = '';
     document.getElementById('alarmCooldown').value = '1800';
+    document.getElementById('alarmSustainedFor').value = '';
     document.getElementById('alarmEnabled').checked = true;
     
     // Reset validation result
@@ -758,6 +769,11 @@ function showCreateModal() {
         validationResult.style.display = 'none';
         validationResult.innerHTML = '';
     }
+    const previewResult = document.getElementById('previewResult');
+    if (previewResult) {
+        previewResult.style.display = 'none';
+        previewResult.innerHTML = '';
+    }
     
     // Reset delivery methods to console only
     document.getElementById('deliveryConsole').checked = true;
@@ -915,7 +931,7 @@ Sensor Data:
 
 async function loadEnvDefaults() {
     try {
-        const response = await fetch('/api/env-defaults');
+        const response = await fetch(API_BASE + '/api/env-defaults');
         const defaults = await response.json();
         
         // Only set defaults if contact arrays are empty (don't override user selections)
@@ -946,6 +962,7 @@ function editAlarm(name) {
     document.getElementById('alarmDescription').value = '';
     document.getElementById('alarmCondition').value = '';
     document.getElementById('alarmCooldown').value = '1800';
+    document.getElementById('alarmSustainedFor').value = '';
     document.getElementById('alarmEnabled').checked = true;
     
     // Reset validation result
@@ -954,6 +971,11 @@ function editAlarm(name) {
         validationResult.style.display = 'none';
         validationResult.innerHTML = '';
     }
+    const previewResult = document.getElementById('previewResult');
+    if (previewResult) {
+        previewResult.style.display = 'none';
+        previewResult.innerHTML = '';
+    }
     
     // Clear all delivery method checkboxes
     document.getElementById('deliveryConsole').checked = false;
@@ -1009,6 +1031,7 @@ function editAlarm(name) {
     updateTagDropdown('');
     
     document.getElementById('alarmCooldown').value = currentAlarm.cooldown || 1800;
+    document.getElementById('alarmSustainedFor').value = currentAlarm.sustained_for || '';
     document.getElementById('alarmEnabled').checked = currentAlarm.enabled;
     
     // Load delivery methods and messages from channels
@@ -1096,7 +1119,7 @@ async function validateCondition() {
     }
     
     try {
-        const response = await fetch('/api/validate', {
+        const response = await fetch(API_BASE + '/api/alarms/validate', {
             method: 'POST',
             headers: {'Content-Type': 'application/json'},
             body: JSON.stringify({ condition: condition })
@@ -1113,18 +1136,49 @@ async function validateCondition() {
         } else {
             resultDiv.style.backgroundColor = '#f8d7da';
             resultDiv.style.color = '#721c24';
-            resultDiv.innerHTML = `✗ Invalid condition: ${result.error}`;
+            const errors = result.errors || [];
+            if (errors.length === 0) {
+                resultDiv.innerHTML = `✗ Invalid condition: ${escapeHTML(result.error)}`;
+                return false;
+            }
+            // Underline the offending text and select the first problem
+            const chars = Array.from(condition);
+            const items = errors.map(e => {
+                const before = escapeHTML(chars.slice(Math.max(0, e.start - 10), e.start).join(''));
+                const text = escapeHTML(chars.slice(e.start, e.end).join('') || ' ');
+                const after = escapeHTML(chars.slice(e.end, e.end + 10).join(''));
+                return `<li>${escapeHTML(e.message)} (character ${e.start + 1}): <code>${before}<mark style="text-decoration: underline wavy;">${text}</mark>${after}</code></li>`;
+            });
+            resultDiv.innerHTML = `✗ Invalid condition:<ul style="margin: 4px 0 0 16px; padding: 0;">${items.join('')}</ul>`;
+            selectConditionError(errors[0]);
             return false;
         }
     } catch (error) {
         resultDiv.style.display = 'block';
         resultDiv.style.backgroundColor = '#f8d7da';
         resultDiv.style.color = '#721c24';
-        resultDiv.innerHTML = `✗ Validation error: ${error.message}`;
+        resultDiv.innerHTML = `✗ Validation error: ${escapeHTML(error.message)}`;
         return false;
     }
 }
 
+// selectConditionError selects the text of a validation error in the
+// condition box; positions are in characters, the textarea counts UTF-16 units
+function selectConditionError(error) {
+    const textarea = document.getElementById('alarmCondition');
+    const chars = Array.from(textarea.value);
+    const start = chars.slice(0, error.start).join('').length;
+    const end = chars.slice(0, error.end).join('').length;
+    textarea.focus();
+    textarea.setSelectionRange(start, end);
+}
+
+function escapeHTML(text) {
+    const div = document.createElement('div');
+    div.textContent = text == null ? '' : String(text);
+    return div.innerHTML;
+}
+
 async function validateJSONMessage() {
     const template = document.getElementById('jsonMessage').value;
     const resultDiv = document.getElementById('jsonValidationResult');
@@ -1138,7 +1192,7 @@ async function validateJSONMessage() {
     }
     
     try {
-        const response = await fetch('/api/validate-json', {
+        const response = await fetch(API_BASE + '/api/validate-json', {
             method: 'POST',
             headers: {'Content-Type': 'application/json'},
             body: JSON.stringify({ template: template })
@@ -1186,7 +1240,7 @@ async function showSampleJSON() {
     }
     
     try {
-        const response = await fetch('/api/validate-json', {
+        const response = await fetch(API_BASE + '/api/validate-json', {
             method: 'POST',
             headers: {'Content-Type': 'application/json'},
             body: JSON.stringify({ template: template })
@@ -1266,25 +1320,9 @@ async function copyJSON() {
     }
 }
 
-async function handleSubmit(e) {
-    e.preventDefault();
-    
-    // Validate condition before saving
-    const isValid = await validateCondition();
-    if (!isValid) {
-        showNotification('Please fix the condition before saving', 'error');
-        return;
-    }
-    
-    // Validate JSON template if JSON delivery is selected
-    if (document.getElementById('deliveryJSON').checked) {
-        const jsonValid = await validateJSONMessage();
-        if (!jsonValid) {
-            showNotification('Please fix the JSON template before saving', 'error');
-            return;
-        }
-    }
-    
+// buildAlarmFromForm returns the alarm described by the edit form, or null
+// when a field cannot be read
+function buildAlarmFromForm() {
     // Build channels array from selected delivery methods
     const channels = [];
     
@@ -1355,7 +1393,7 @@ async function handleSubmit(e) {
                 webhookHeaders = JSON.parse(webhookHeadersStr);
             } catch (e) {
                 showNotification('Invalid JSON in webhook headers', 'error');
-                return;
+                return null;
             }
         }
         
@@ -1379,6 +1417,7 @@ async function handleSubmit(e) {
         channels.push({ 
             type: 'csv',
             csv: {
+                ...existingChannelConfig('csv'),
                 path: csvPath,
                 max_days: csvMaxDays,
                 message: csvMessage
@@ -1394,6 +1433,7 @@ async function handleSubmit(e) {
         channels.push({ 
             type: 'json',
             json: {
+                ...existingChannelConfig('json'),
                 path: jsonPath,
                 max_days: jsonMaxDays,
                 message: jsonMessage
@@ -1418,10 +1458,108 @@ async function handleSubmit(e) {
     if (schedule !== null) {
         alarmData.schedule = schedule;
     }
+    const sustainedFor = document.getElementById('alarmSustainedFor').value.trim();
+    if (sustainedFor) {
+        alarmData.sustained_for = sustainedFor;
+    }
+    return alarmData;
+}
+
+// previewNotifications renders what every selected channel would send, with
+// the service's latest observation (sample data in the standalone editor)
+async function previewNotifications() {
+    const resultDiv = document.getElementById('previewResult');
+    const alarmData = buildAlarmFromForm();
+    if (!alarmData) {
+        return;
+    }
+    resultDiv.style.display = 'block';
+    if (alarmData.channels.length === 0) {
+        resultDiv.innerHTML = '⚠️ Select a delivery method to preview';
+        return;
+    }
+
+    try {
+        const response = await fetch(API_BASE + '/api/alarms/preview', {
+            method: 'POST',
+            headers: {'Content-Type': 'application/json'},
+            body: JSON.stringify({ alarm: alarmData })
+        });
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        const result = await response.json();
+        const source = {
+            current: 'the latest observation',
+            supplied: 'the supplied observation',
+            sample: 'sample data'
+        }[result.source] || result.source;
+
+        resultDiv.innerHTML = `<small>Rendered with ${escapeHTML(source)}</small>`;
+        result.messages.forEach(msg => {
+            const section = document.createElement('div');
+            section.style.marginTop = '8px';
+            let header = `<strong>${escapeHTML(msg.channel)}</strong>`;
+            if (msg.to && msg.to.length > 0) {
+                header += ` → ${escapeHTML(msg.to.join(', '))}`;
+            }
+            if (msg.subject) {
+                header += `<br><em>${escapeHTML(msg.subject)}</em>`;
+            }
+            if (msg.attachments) {
+                header += `<br><small>📎 ${escapeHTML(msg.attachments.join(', '))}</small>`;
+            }
+            if (msg.held) {
+                header += `<br><small>${escapeHTML(msg.held)}</small>`;
+            }
+            section.innerHTML = header;
+            if (msg.html) {
+                // HTML messages render in a sandboxed frame, without scripts
+                const frame = document.createElement('iframe');
+                frame.setAttribute('sandbox', '');
+                frame.srcdoc = msg.message;
+                frame.style.cssText = 'width: 100%; height: 240px; border: 1px solid #ddd; background: #fff;';
+                section.appendChild(frame);
+            } else {
+                const pre = document.createElement('pre');
+                pre.textContent = msg.message;
+                pre.style.cssText = 'white-space: pre-wrap; margin: 4px 0 0; padding: 6px; background: #f8f9fa; border-radius: 4px;';
+                section.appendChild(pre);
+            }
+            resultDiv.appendChild(section);
+        });
+    } catch (error) {
+        resultDiv.innerHTML = `✗ Preview failed: ${escapeHTML(error.message)}`;
+    }
+}
+
+async function handleSubmit(e) {
+    e.preventDefault();
+    
+    // Validate condition before saving
+    const isValid = await validateCondition();
+    if (!isValid) {
+        showNotification('Please fix the condition before saving', 'error');
+        return;
+    }
+    
+    // Validate JSON template if JSON delivery is selected
+    if (document.getElementById('deliveryJSON').checked) {
+        const jsonValid = await validateJSONMessage();
+        if (!jsonValid) {
+            showNotification('Please fix the JSON template before saving', 'error');
+            return;
+        }
+    }
+    
+    const alarmData = buildAlarmFromForm();
+    if (!alarmData) {
+        return;
+    }
     
     // Track original name for updates (in case name changed)
     const originalName = currentAlarm ? currentAlarm.name : null;
-    const endpoint = currentAlarm ? `/api/alarms/update?oldName=${encodeURIComponent(originalName)}` : '/api/alarms/create';
+    const endpoint = currentAlarm ? `${API_BASE}/api/alarms/update?oldName=${encodeURIComponent(originalName)}` : API_BASE + '/api/alarms/create';
     
     try {
         const response = await fetch(endpoint, {
@@ -1448,7 +1586,7 @@ async function deleteAlarm(name) {
     if (!confirm('Are you sure you want to delete alarm "' + name + '"?')) return;
     
     try {
-        const response = await fetch('/api/alarms/delete?name=' + encodeURIComponent(name), {
+        const response = await fetch(API_BASE + '/api/alarms/delete?name=' + encodeURIComponent(name), {
             method: 'POST'
         });
         
@@ -1480,7 +1618,7 @@ async function deleteAlarm(name) {
 }
 
 async function saveAll() {
-    const response = await fetch('/api/config');
+    const response = await fetch(API_BASE + '/api/config');
     const config = await response.json();
     
     const blob = new Blob([JSON.stringify(config, null, 2)], {type: 'application/json'});
@@ -1493,6 +1631,105 @@ async function saveAll() {
     showNotification('Configuration saved', 'success');
 }
 
+// ============================================
+// Configuration Versions
+// ============================================
+
+let selectedVersionId = null;
+
+async function showVersionsModal() {
+    selectedVersionId = null;
+    document.getElementById('rollbackBtn').disabled = true;
+    document.getElementById('versionDiff').textContent = 'Select a version to compare it with the current configuration';
+
+    const listEl = document.getElementById('versionsList');
+    listEl.innerHTML = '';
+    try {
+        const response = await fetch(API_BASE + '/api/versions');
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        const versions = await response.json();
+        if (versions.length === 0) {
+            listEl.textContent = 'No previous versions yet. A backup is taken every time the configuration is saved.';
+        }
+        versions.forEach(v => {
+            const item = document.createElement('div');
+            item.className = 'version-item';
+            item.dataset.id = v.id;
+            const alarmsLabel = v.alarms >= 0 ? `${v.alarms} alarm${v.alarms === 1 ? '' : 's'}` : 'unreadable';
+            item.textContent = `${new Date(v.timestamp).toLocaleString()} (${alarmsLabel})`;
+            item.addEventListener('click', () => selectVersion(v.id));
+            listEl.appendChild(item);
+        });
+    } catch (error) {
+        showNotification('Error loading versions: ' + error.message, 'error');
+    }
+    document.getElementById('versionsModal').classList.add('active');
+}
+
+function closeVersionsModal() {
+    document.getElementById('versionsModal').classList.remove('active');
+}
+
+async function selectVersion(id) {
+    selectedVersionId = id;
+    document.querySelectorAll('#versionsList .version-item').forEach(el => {
+        el.classList.toggle('selected', el.dataset.id === id);
+    });
+    document.getElementById('rollbackBtn').disabled = false;
+
+    const diffEl = document.getElementById('versionDiff');
+    diffEl.textContent = 'Loading diff...';
+    try {
+        const response = await fetch(API_BASE + '/api/versions/diff?id=' + encodeURIComponent(id));
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        const result = await response.json();
+        diffEl.innerHTML = '';
+        let changes = 0;
+        result.diff.forEach(line => {
+            const span = document.createElement('span');
+            if (line.op === '+') {
+                span.className = 'diff-add';
+                changes++;
+            } else if (line.op === '-') {
+                span.className = 'diff-del';
+                changes++;
+            }
+            span.textContent = line.op + ' ' + line.text + '\n';
+            diffEl.appendChild(span);
+        });
+        if (changes === 0) {
+            diffEl.textContent = 'This version is identical to the current configuration';
+        }
+    } catch (error) {
+        diffEl.textContent = 'Error loading diff: ' + error.message;
+    }
+}
+
+async function rollbackSelectedVersion() {
+    if (!selectedVersionId) return;
+    if (!confirm('Roll back the alarm configuration to this version? The current configuration will be kept as a new version.')) {
+        return;
+    }
+    try {
+        const response = await fetch(API_BASE + '/api/versions/rollback?id=' + encodeURIComponent(selectedVersionId), {
+            method: 'POST'
+        });
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        showNotification('Configuration rolled back', 'success');
+        closeVersionsModal();
+        await loadAlarms();
+        await loadTags();
+    } catch (error) {
+        showNotification('Rollback failed: ' + error.message, 'error');
+    }
+}
+
 function showNotification(message, type) {
     const notification = document.getElementById('notification');
     notification.textContent = message;
@@ -1542,7 +1779,7 @@ function closeEditTagsModal() {
 
 async function loadContactsForEditor() {
     try {
-        const response = await fetch('/api/contacts');
+        const response = await fetch(API_BASE + '/api/contacts');
         const contacts = await response.json();
         renderContactsEditor(contacts);
     } catch (error) {
@@ -1554,7 +1791,7 @@ async function loadContactsForEditor() {
 async function loadTagsForEditor() {
     try {
         // Get all tags from the server (this includes both alarm tags and predefined tags)
-        const response = await fetch('/api/tags');
+        const response = await fetch(API_BASE + '/api/tags');
         const tags = await response.json();
         renderTagsEditor(tags);
     } catch (error) {
@@ -1682,7 +1919,7 @@ async function saveContacts(saveType) {
     const contacts = collectContactsFromEditor();
 
     try {
-        const response = await fetch('/api/contacts/save', {
+        const response = await fetch(API_BASE + '/api/contacts/save', {
             method: 'POST',
             headers: {'Content-Type': 'application/json'},
             body: JSON.stringify({
@@ -1711,7 +1948,7 @@ async function saveTags(saveType) {
     const tags = collectTagsFromEditor();
 
     try {
-        const response = await fetch('/api/tags/save', {
+        const response = await fetch(API_BASE + '/api/tags/save', {
             method: 'POST',
             headers: {'Content-Type': 'application/json'},
             body: JSON.stringify({
@@ -0,0 +1,221 @@
+package alarm
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template/parse"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// HTML alarm emails can be wrapped in a branded layout. EMAIL_TEMPLATES_DIR
+// names a directory holding theme.json (logo, colors, footer) and wrapper
+// templates: wrapper.html, or <name>.html for channels with "wrapper":
+// "<name>". Wrappers are html/template documents executed with
+// emailWrapperData; a wrapper holding only {{define}} blocks restyles the
+// built-in layout instead of replacing it. The directory is read for every
+// email, so edits apply without a restart.
+
+// EmailTheme is theme.json in the email templates directory
+type EmailTheme struct {
+	LogoURL         string `json:"logo_url,omitempty"`         // https image shown in the header
+	PrimaryColor    string `json:"primary_color,omitempty"`    // header background, #rgb or #rrggbb
+	BackgroundColor string `json:"background_color,omitempty"` // page background
+	TextColor       string `json:"text_color,omitempty"`
+	Footer          string `json:"footer,omitempty"` // plain text under the message
+}
+
+// defaultEmailTheme fills in what theme.json leaves out
+var defaultEmailTheme = EmailTheme{
+	PrimaryColor:    "#1e88e5",
+	BackgroundColor: "#f4f4f4",
+	TextColor:       "#333333",
+	Footer:          "Sent by Tempest HomeKit Bridge",
+}
+
+// emailWrapperData is the dot of email wrapper templates
+type emailWrapperData struct {
+	Subject    string
+	Station    string
+	Alarm      *Alarm
+	Obs        *weather.Observation
+	Time       time.Time
+	Theme      EmailTheme
+	Content    template.HTML // the channel's rendered body
+	AlarmInfo  template.HTML // the {{alarm_info}} table, also the "alarm_info" block
+	SensorInfo template.HTML // the {{sensor_info}} table, also the "sensor_info" block
+	AppInfo    template.HTML // the {{app_info}} line, also the "app_info" block
+}
+
+// emailLayout is the built-in wrapper: a 600px table layout with inline
+// styles, which mail clients that drop <style> and <head> still render
+const emailLayout = `<!DOCTYPE html>
+<html>
+<head>
+<meta http-equiv="Content-Type" content="text/html; charset=UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>{{.Subject}}</title>
+</head>
+<body style="margin: 0; padding: 0; background-color: {{.Theme.BackgroundColor}};">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0" style="background-color: {{.Theme.BackgroundColor}};">
+<tr><td align="center" style="padding: 16px;">
+<table role="presentation" width="600" cellpadding="0" cellspacing="0" border="0" style="max-width: 600px; width: 100%; background-color: #ffffff; font-family: Arial, Helvetica, sans-serif; color: {{.Theme.TextColor}};">
+<tr><td style="padding: 16px; background-color: {{.Theme.PrimaryColor}}; color: #ffffff;">{{block "header" .}}{{if .Theme.LogoURL}}<img src="{{.Theme.LogoURL}}" alt="{{.Station}}" height="40" style="display: block; height: 40px; border: 0; margin-bottom: 8px;">{{end}}<h1 style="margin: 0; font-size: 20px; font-weight: bold;">{{.Subject}}</h1>{{end}}</td></tr>
+<tr><td style="padding: 16px; font-size: 14px; line-height: 1.5;">{{block "content" .}}{{.Content}}{{end}}</td></tr>
+<tr><td style="padding: 16px; border-top: 1px solid #dddddd; font-size: 12px; color: #777777;">{{block "footer" .}}{{if .Theme.Footer}}<p style="margin: 0 0 8px;">{{.Theme.Footer}}</p>{{end}}{{template "app_info" .}}{{end}}</td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>
+{{define "alarm_info"}}{{.AlarmInfo}}{{end}}{{define "sensor_info"}}{{.SensorInfo}}{{end}}{{define "app_info"}}{{.AppInfo}}{{end}}`
+
+// themeColor matches the CSS colors theme.json accepts
+var themeColor = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// wrapperName matches the wrapper names a channel can choose
+var wrapperName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// loadEmailTheme reads theme.json from dir; a missing file is the default
+// theme
+func loadEmailTheme(dir string) (EmailTheme, error) {
+	theme := defaultEmailTheme
+	data, err := os.ReadFile(filepath.Join(dir, "theme.json"))
+	if os.IsNotExist(err) {
+		return theme, nil
+	} else if err != nil {
+		return theme, err
+	}
+	if err := json.Unmarshal(data, &theme); err != nil {
+		return theme, fmt.Errorf("theme.json: %w", err)
+	}
+	for name, color := range map[string]string{
+		"primary_color": theme.PrimaryColor, "background_color": theme.BackgroundColor, "text_color": theme.TextColor,
+	} {
+		if !themeColor.MatchString(color) {
+			return theme, fmt.Errorf("theme.json: %s %q must be a color like #1e88e5", name, color)
+		}
+	}
+	if theme.LogoURL != "" && !strings.HasPrefix(theme.LogoURL, "https://") && !strings.HasPrefix(theme.LogoURL, "http://") {
+		return theme, fmt.Errorf("theme.json: logo_url must be an http or https URL")
+	}
+	return theme, nil
+}
+
+// loadEmailWrapper returns the wrapper template a channel uses: the built-in
+// layout, restyled or replaced by <name>.html in dir when it exists
+func loadEmailWrapper(dir, name string) (*template.Template, error) {
+	if name == "" {
+		name = "wrapper"
+	}
+	if !wrapperName.MatchString(name) {
+		return nil, fmt.Errorf("invalid email wrapper name %q", name)
+	}
+	layout := template.Must(template.New("layout").Parse(emailLayout))
+
+	data, err := os.ReadFile(filepath.Join(dir, name+".html"))
+	if os.IsNotExist(err) && name == "wrapper" {
+		return layout, nil
+	} else if err != nil {
+		return nil, err
+	}
+	custom, err := layout.New(name).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s.html: %w", name, err)
+	}
+	if custom.Tree == nil || parse.IsEmptyTree(custom.Tree.Root) {
+		return layout, nil // only {{define}} blocks: restyle the layout
+	}
+	return custom, nil
+}
+
+// checkEmailTemplates reports problems with the templates directory: a
+// missing directory, an invalid theme.json or a wrapper that does not parse
+func checkEmailTemplates(dir string) error {
+	if info, err := os.Stat(dir); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("not a directory")
+	}
+	if _, err := loadEmailTheme(dir); err != nil {
+		return err
+	}
+	wrappers, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return err
+	}
+	for _, path := range wrappers {
+		name := strings.TrimSuffix(filepath.Base(path), ".html")
+		if _, err := loadEmailWrapper(dir, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// themeEmail renders the HTML body of an email channel inside its wrapper
+// from dir. It returns "" for bodies that are complete HTML documents and
+// channels with "wrapper": "none", which are sent as they are.
+func themeEmail(dir string, email *EmailConfig, subject, bodyTemplate string, alarm *Alarm, obs *weather.Observation, stationName string) (string, error) {
+	if email.Wrapper == "none" || strings.Contains(strings.ToLower(bodyTemplate), "<html") {
+		return "", nil
+	}
+	theme, err := loadEmailTheme(dir)
+	if err != nil {
+		return "", err
+	}
+	wrapper, err := loadEmailWrapper(dir, email.Wrapper)
+	if err != nil {
+		return "", err
+	}
+
+	// Plain text bodies keep their line breaks; the blocks render as tables
+	if !isHTMLTemplate(bodyTemplate) {
+		bodyTemplate = strings.ReplaceAll(bodyTemplate, "\n", "<br>\n")
+	}
+	unit := pressureUnits.notifications
+	data := emailWrapperData{
+		Subject:    subject,
+		Station:    stationName,
+		Alarm:      alarm,
+		Obs:        obs,
+		Time:       time.Unix(obs.Timestamp, 0),
+		Theme:      theme,
+		Content:    template.HTML(renderNotification(bodyTemplate, alarm, obs, stationName, unit, true)),
+		AlarmInfo:  template.HTML(formatAlarmInfo(alarm, true)),
+		SensorInfo: template.HTML(formatSensorInfoWithAlarm(obs, alarm, true, unit)),
+		AppInfo:    template.HTML(formatAppInfo(true)),
+	}
+	var b strings.Builder
+	if err := wrapper.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// htmlTags matches the markup htmlToText drops
+var htmlTags = regexp.MustCompile(`(?s)<(?:style|script)[^>]*>.*?</(?:style|script)>|<[^>]+>`)
+
+// blankLines matches the runs of empty lines htmlToText collapses
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// htmlToText is the plain text alternative of an HTML email body
+func htmlToText(body string) string {
+	text := strings.NewReplacer(
+		"<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n\n", "</h1>", "\n", "</h2>", "\n",
+		"</div>", "\n", "</tr>", "\n", "</td>", "  ", "</th>", "  ",
+	).Replace(body)
+	text = html.UnescapeString(htmlTags.ReplaceAllString(text, ""))
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
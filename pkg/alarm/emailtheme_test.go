@@ -0,0 +1,110 @@
+package alarm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestThemeEmail(t *testing.T) {
+	dir := t.TempDir()
+	alarm := &Alarm{Name: "Hot", Condition: "temperature > 30"}
+	obs := &weather.Observation{Timestamp: 1700000000, AirTemperature: 35}
+	email := &EmailConfig{Html: true}
+
+	// Built-in layout with the default theme
+	got, err := themeEmail(dir, email, "Hot at Home", "It is {{temperature_c}}°C\n{{sensor_info}}", alarm, obs, "Home")
+	if err != nil {
+		t.Fatalf("themeEmail: %v", err)
+	}
+	for _, want := range []string{"<!DOCTYPE html>", "background-color: #1e88e5", "Hot at Home</h1>", "It is 35.0°C<br>", "<table", "Sent by Tempest HomeKit Bridge"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("themed email missing %q:\n%s", want, got)
+		}
+	}
+
+	// theme.json brands the layout; a wrapper of blocks restyles it
+	theme := `{"logo_url": "https://example.com/logo.png", "primary_color": "#003366", "footer": "Acme <Weather>"}`
+	if err := os.WriteFile(filepath.Join(dir, "theme.json"), []byte(theme), 0644); err != nil {
+		t.Fatal(err)
+	}
+	blocks := `{{define "content"}}<div class="acme">{{.Content}}</div>{{template "alarm_info" .}}{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "wrapper.html"), []byte(blocks), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = themeEmail(dir, email, "Hot", "<p>{{alarm_name}}</p>", alarm, obs, "Home")
+	if err != nil {
+		t.Fatalf("themeEmail: %v", err)
+	}
+	for _, want := range []string{`<img src="https://example.com/logo.png"`, "background-color: #003366", `<div class="acme"><p>Hot</p></div>`, "Condition:", "Acme &lt;Weather&gt;"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("branded email missing %q:\n%s", want, got)
+		}
+	}
+
+	// A named wrapper replaces the layout
+	if err := os.WriteFile(filepath.Join(dir, "plain.html"), []byte(`<html><body>{{.Station}}: {{.Content}}</body></html>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = themeEmail(dir, &EmailConfig{Html: true, Wrapper: "plain"}, "Hot", "<p>hot</p>", alarm, obs, "Home")
+	if err != nil || got != "<html><body>Home: <p>hot</p></body></html>" {
+		t.Errorf("plain wrapper = %q, %v", got, err)
+	}
+
+	// Complete documents and "wrapper": "none" are not wrapped
+	if got, err := themeEmail(dir, email, "Hot", "<html><body>mine</body></html>", alarm, obs, "Home"); got != "" || err != nil {
+		t.Errorf("complete document wrapped: %q, %v", got, err)
+	}
+	if got, err := themeEmail(dir, &EmailConfig{Html: true, Wrapper: "none"}, "Hot", "<p>hot</p>", alarm, obs, "Home"); got != "" || err != nil {
+		t.Errorf("wrapper none wrapped: %q, %v", got, err)
+	}
+	if _, err := themeEmail(dir, &EmailConfig{Html: true, Wrapper: "missing"}, "Hot", "<p>hot</p>", alarm, obs, "Home"); err == nil {
+		t.Error("missing wrapper: want an error")
+	}
+}
+
+func TestCheckEmailTemplates(t *testing.T) {
+	dir := t.TempDir()
+	if err := checkEmailTemplates(dir); err != nil {
+		t.Errorf("empty directory: %v", err)
+	}
+	if err := checkEmailTemplates("../../examples/email-templates"); err != nil {
+		t.Errorf("example templates: %v", err)
+	}
+	if err := checkEmailTemplates(filepath.Join(dir, "missing")); err == nil {
+		t.Error("missing directory: want an error")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "theme.json"), []byte(`{"primary_color": "red; background: url(x)"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkEmailTemplates(dir); err == nil || !strings.Contains(err.Error(), "primary_color") {
+		t.Errorf("invalid color error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "theme.json"), []byte(`{"logo_url": "javascript:alert(1)"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkEmailTemplates(dir); err == nil || !strings.Contains(err.Error(), "logo_url") {
+		t.Errorf("invalid logo error = %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "theme.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.html"), []byte(`{{if}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkEmailTemplates(dir); err == nil || !strings.Contains(err.Error(), "broken.html") {
+		t.Errorf("broken wrapper error = %v", err)
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	got := htmlToText("<h1>Hot</h1><p>It is <b>35&deg;C</b></p><table><tr><td>Temp</td><td>35</td></tr></table>")
+	if got != "Hot\nIt is 35°C\n\nTemp  35" {
+		t.Errorf("htmlToText = %q", got)
+	}
+}
@@ -0,0 +1,129 @@
+package alarm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"tempest-homekit-go/pkg/secrets"
+)
+
+// secretField is a credential stored in the alarm file
+type secretField struct {
+	path  string // e.g. "alarms[0].channels[1].webhook.hmac_secret"
+	value string
+	set   func(string)
+}
+
+// credentialHeader reports whether a webhook header carries a credential
+func credentialHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range []string{"authorization", "token", "key", "secret", "password", "auth"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretFields lists the webhook HMAC secrets, OAuth2 client secrets and
+// credential headers of every alarm and digest channel
+func secretFields(cfg *AlarmConfig) []secretField {
+	var fields []secretField
+	addChannels := func(prefix string, channels []Channel) {
+		for j := range channels {
+			wh := channels[j].Webhook
+			if wh == nil {
+				continue
+			}
+			path := fmt.Sprintf("%s.channels[%d].webhook", prefix, j)
+			fields = append(fields, secretField{path + ".hmac_secret", wh.HMACSecret, func(v string) { wh.HMACSecret = v }})
+			if o := wh.OAuth2; o != nil {
+				fields = append(fields, secretField{path + ".oauth2.client_secret", o.ClientSecret, func(v string) { o.ClientSecret = v }})
+			}
+			for name, value := range wh.Headers {
+				if credentialHeader(name) {
+					name := name
+					fields = append(fields, secretField{path + ".headers." + name, value, func(v string) { wh.Headers[name] = v }})
+				}
+			}
+		}
+	}
+	for i := range cfg.Alarms {
+		addChannels(fmt.Sprintf("alarms[%d]", i), cfg.Alarms[i].Channels)
+	}
+	for i := range cfg.Digests {
+		addChannels(fmt.Sprintf("digests[%d]", i), cfg.Digests[i].Channels)
+	}
+	return fields
+}
+
+// EncryptSecrets encrypts the plaintext credentials in cfg with key and
+// returns how many it changed. Empty values, ${ENV_VAR} and env:, file:,
+// keychain: or enc: references are left alone.
+func EncryptSecrets(cfg *AlarmConfig, key []byte) (int, error) {
+	n := 0
+	for _, f := range secretFields(cfg) {
+		if f.value == "" || secrets.IsReference(f.value) || strings.HasPrefix(f.value, "${") {
+			continue
+		}
+		sealed, err := secrets.Encrypt(f.value, key)
+		if err != nil {
+			return n, fmt.Errorf("%s: %w", f.path, err)
+		}
+		f.set(sealed)
+		n++
+	}
+	return n, nil
+}
+
+// checkEncryptedSecrets makes sure every encrypted credential in cfg can be
+// decrypted, so a missing or wrong TEMPEST_MASTER_KEY is reported when the
+// file is loaded rather than when an alarm fires. The values stay encrypted
+// in memory and are decrypted as each notification is sent.
+func checkEncryptedSecrets(cfg *AlarmConfig) error {
+	for _, f := range secretFields(cfg) {
+		if !secrets.IsEncrypted(f.value) {
+			continue
+		}
+		if _, err := secrets.Resolve(f.value); err != nil {
+			return fmt.Errorf("%s: %w", f.path, err)
+		}
+	}
+	return nil
+}
+
+// EncryptConfigFile encrypts the plaintext credentials of the alarm file at
+// path with TEMPEST_MASTER_KEY. No backup is kept, since it would hold the
+// plaintext; versions saved earlier by the editor still do.
+func EncryptConfigFile(path string) (int, error) {
+	path = strings.TrimPrefix(path, "@")
+	key, err := secrets.MasterKey()
+	if err != nil {
+		return 0, err
+	}
+	if key == nil {
+		return 0, fmt.Errorf("%s is not set", secrets.MasterKeyEnv)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var cfg AlarmConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	n, err := EncryptSecrets(&cfg, key)
+	if err != nil || n == 0 {
+		return n, err
+	}
+	out, err := json.MarshalIndent(&cfg, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := writeFileSync(path, out); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
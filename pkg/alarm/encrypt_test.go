@@ -0,0 +1,62 @@
+package alarm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/secrets"
+)
+
+func TestEncryptConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alarms.json")
+	data := `{"alarms":[{"name":"Hot","enabled":true,"condition":"temperature > 30","channels":[{"type":"webhook","webhook":{
+		"url":"http://127.0.0.1:1/hook","body":"{}","hmac_secret":"s3cret",
+		"headers":{"Authorization":"Bearer abc","X-Api-Key":"${API_KEY}","Accept":"text/plain"},
+		"oauth2":{"token_url":"http://127.0.0.1:1/token","client_id":"id","client_secret":"shh"}}}]}]}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(secrets.MasterKeyEnv, "")
+	if _, err := EncryptConfigFile("@" + path); err == nil {
+		t.Fatal("expected an error without a master key")
+	}
+
+	t.Setenv(secrets.MasterKeyEnv, "test-master-key")
+	n, err := EncryptConfigFile("@" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("encrypted %d credentials, want 3 (hmac_secret, client_secret, Authorization)", n)
+	}
+	saved, _ := os.ReadFile(path)
+	for _, plain := range []string{"s3cret", "Bearer abc", `"shh"`} {
+		if strings.Contains(string(saved), plain) {
+			t.Errorf("file still contains %s", plain)
+		}
+	}
+	if !strings.Contains(string(saved), "${API_KEY}") || !strings.Contains(string(saved), "text/plain") {
+		t.Errorf("references and ordinary headers should be left alone:\n%s", saved)
+	}
+
+	// Values stay encrypted in memory and decrypt when sent
+	cfg, err := LoadAlarmConfig("@" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wh := cfg.Alarms[0].Channels[0].Webhook
+	if !secrets.IsEncrypted(wh.HMACSecret) {
+		t.Errorf("hmac_secret = %q, want it to stay encrypted", wh.HMACSecret)
+	}
+	if got, err := resolveSecret(wh.HMACSecret); err != nil || got != "s3cret" {
+		t.Errorf("resolveSecret() = %q, %v", got, err)
+	}
+
+	t.Setenv(secrets.MasterKeyEnv, "wrong-key")
+	if _, err := LoadAlarmConfig("@" + path); err == nil || !strings.Contains(err.Error(), "webhook.hmac_secret") {
+		t.Errorf("LoadAlarmConfig() with the wrong key error = %v", err)
+	}
+}
@@ -10,7 +10,16 @@ import (
 )
 
 // Evaluator evaluates alarm conditions against weather observations
-type Evaluator struct{}
+type Evaluator struct {
+	metrics   MetricsProvider  // optional source for bridge health fields
+	stats     StatsProvider    // optional source for growing degree day and chill hour fields
+	anomalies AnomalyProvider  // optional source for anomaly(field) scores
+	history   HistoryProvider  // optional source for delta() and rate()
+	sites     SitesProvider    // optional source for any_site., all_sites. and site.<name>.
+	forecast  ForecastProvider // optional source for forecast_max() and forecast_min()
+	latitude  float64          // station location for the sun fields
+	longitude float64
+}
 
 // NewEvaluator creates a new alarm evaluator
 func NewEvaluator() *Evaluator {
@@ -35,8 +44,9 @@ func (e *Evaluator) EvaluateWithAlarm(condition string, obs *weather.Observation
 	//   "*lightning_count" (triggers on any change)
 	//   ">rain_rate" (triggers when rain increases)
 	//   "<lightning_distance" (triggers when lightning gets closer)
+	// The words AND / OR (any case) are accepted as aliases for && and ||.
 
-	condition = strings.TrimSpace(condition)
+	condition = normalizeLogicalKeywords(strings.TrimSpace(condition))
 
 	// Debug log the evaluation attempt
 	logger.Debug("Evaluating condition: %s (temp=%.1f, humidity=%.0f, pressure=%.2f)",
@@ -117,9 +127,30 @@ func (e *Evaluator) evaluateSimpleWithAlarm(condition string, obs *weather.Obser
 	}
 
 	if operator == "" {
+		// Boolean fields may be tested bare ("is_daytime") or negated ("!is_daytime")
+		name, negated := strings.TrimSpace(condition), false
+		if strings.HasPrefix(name, "!") {
+			name, negated = strings.TrimSpace(name[1:]), true
+		}
+		if booleanFields[strings.ToLower(name)] {
+			value, err := e.getFieldValue(name, obs)
+			if err != nil {
+				return false, err
+			}
+			return (value != 0) != negated, nil
+		}
 		return false, fmt.Errorf("invalid condition format: %s (expected 'field operator value')", condition)
 	}
 
+	// Fields of other sites are compared per site
+	if scope, site, inner, ok := siteField(field); ok {
+		compareValue, err := e.parseValueWithUnits(valueStr, inner)
+		if err != nil {
+			return false, fmt.Errorf("invalid comparison value %s: %w", valueStr, err)
+		}
+		return e.evaluateSiteCondition(scope, site, inner, operator, compareValue, obs)
+	}
+
 	// Get the field value from observation
 	fieldValue, err := e.getFieldValue(field, obs)
 	if err != nil {
@@ -138,6 +169,21 @@ func (e *Evaluator) evaluateSimpleWithAlarm(condition string, obs *weather.Obser
 
 // getFieldValue extracts a field value from the weather observation
 func (e *Evaluator) getFieldValue(field string, obs *weather.Observation) (float64, error) {
+	if inner, ok := anomalyField(strings.ToLower(strings.TrimSpace(field))); ok {
+		return e.getAnomalyValue(inner)
+	}
+	if fn, inner, window, ok, err := trendField(strings.ToLower(strings.TrimSpace(field))); ok {
+		if err != nil {
+			return 0, err
+		}
+		return e.getTrendValue(fn, inner, window, obs)
+	}
+	if fn, inner, window, ok, err := forecastField(strings.ToLower(strings.TrimSpace(field))); ok {
+		if err != nil {
+			return 0, err
+		}
+		return e.getForecastValue(fn, inner, window, obs)
+	}
 	field = strings.ToLower(strings.ReplaceAll(field, " ", "_"))
 
 	switch field {
@@ -161,12 +207,38 @@ func (e *Evaluator) getFieldValue(field string, obs *weather.Observation) (float
 		return obs.RainAccumulated, nil
 	case "rain_daily", "rain_accumulation":
 		return obs.RainAccumulated, nil
+	case "rain_rate_raw":
+		raw, _ := weather.RawRain(obs)
+		return raw, nil
+	case "rain_daily_raw":
+		_, raw := weather.RawRain(obs)
+		return raw, nil
+	case "rain_rate_nc":
+		return obs.NCRainAccumulated, nil
+	case "rain_daily_nc":
+		return obs.NCRainDailyTotal, nil
+	case "snow_rate":
+		return obs.SnowRate, nil
+	case "snow_daily":
+		return obs.SnowDailyTotal, nil
 	case "lightning_count":
 		return float64(obs.LightningStrikeCount), nil
 	case "lightning_distance":
 		return obs.LightningStrikeAvg, nil
+	case "lightning_nearest":
+		return obs.LightningNearest, nil
+	case "lightning_rate":
+		return obs.LightningRate, nil
 	case "precipitation_type":
 		return float64(obs.PrecipitationType), nil
+	case "battery":
+		return obs.Battery, nil
+	case "data_age", "udp_silence", "api_errors_rate", "token_invalid", "token_rate_limited":
+		return e.getMetricValue(field, obs)
+	case "gdd_today", "gdd_season", "chill_hours_today", "chill_hours_season", "solar_daily", "uv_dose":
+		return e.getStatsValue(field)
+	case "is_daytime", "sun_elevation", "minutes_to_sunset", "minutes_to_sunrise":
+		return e.getSunValue(field, obs)
 	default:
 		return 0, fmt.Errorf("unknown field: %s", field)
 	}
@@ -174,19 +246,24 @@ func (e *Evaluator) getFieldValue(field string, obs *weather.Observation) (float
 
 // parseValueWithUnits parses a value string with optional unit suffix and converts to base units
 // Supports:
-//   - Temperature: 80F or 80f -> Celsius, 32C or 32c -> Celsius (no conversion)
-//   - Wind: 25mph -> m/s, 10m/s or 10 -> m/s (no conversion)
-//   - Humidity: 80% -> 80 (no conversion, just strip %)
+//   - Temperature: 80F, 80f or 80°F -> Celsius, 32C or 32°C -> Celsius (no conversion)
+//   - Wind: 25mph, 40km/h (kph) or 20kt (knots) -> m/s, 10m/s or 10 -> m/s (no conversion)
+//   - Humidity and percent: 80% -> 80 (no conversion, just strip %)
+//   - Pressure: 29.8inHg -> mb, 1010mb or 1010hPa -> mb (no conversion)
 func (e *Evaluator) parseValueWithUnits(valueStr string, field string) (float64, error) {
 	valueStr = strings.TrimSpace(valueStr)
 	field = strings.ToLower(field)
+	// forecast_min(temperature, 12h) takes the units of temperature
+	if _, inner, _, ok, err := forecastField(strings.TrimSpace(field)); ok && err == nil {
+		field = forecastValues[inner].units
+	}
 
 	// Check for temperature fields (stored in Celsius)
 	if field == "temperature" || field == "temp" {
 		// Check for Fahrenheit suffix
 		if strings.HasSuffix(strings.ToLower(valueStr), "f") {
 			valueStr = strings.TrimSuffix(strings.TrimSuffix(valueStr, "f"), "F")
-			fahrenheit, err := strconv.ParseFloat(strings.TrimSpace(valueStr), 64)
+			fahrenheit, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(valueStr, "°")), 64)
 			if err != nil {
 				return 0, err
 			}
@@ -196,7 +273,7 @@ func (e *Evaluator) parseValueWithUnits(valueStr string, field string) (float64,
 		}
 		// Check for explicit Celsius suffix (optional, already in Celsius)
 		if strings.HasSuffix(strings.ToLower(valueStr), "c") {
-			valueStr = strings.TrimSuffix(strings.TrimSuffix(valueStr, "c"), "C")
+			valueStr = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(valueStr, "c"), "C"), "°")
 		}
 	}
 
@@ -213,6 +290,20 @@ func (e *Evaluator) parseValueWithUnits(valueStr string, field string) (float64,
 			ms := mph * 0.44704
 			return ms, nil
 		}
+		// km/h and knots, as other weather services report wind
+		lower := strings.ToLower(valueStr)
+		for _, unit := range []struct {
+			suffix string
+			ms     float64
+		}{{"km/h", 1 / 3.6}, {"kph", 1 / 3.6}, {"knots", 0.514444}, {"kt", 0.514444}} {
+			if strings.HasSuffix(lower, unit.suffix) {
+				v, err := strconv.ParseFloat(strings.TrimSpace(valueStr[:len(valueStr)-len(unit.suffix)]), 64)
+				if err != nil {
+					return 0, err
+				}
+				return v * unit.ms, nil
+			}
+		}
 		// Check for explicit m/s suffix (optional, already in m/s)
 		if strings.HasSuffix(strings.ToLower(valueStr), "m/s") {
 			valueStr = strings.TrimSuffix(valueStr, "m/s")
@@ -222,13 +313,86 @@ func (e *Evaluator) parseValueWithUnits(valueStr string, field string) (float64,
 		}
 	}
 
-	// Check for humidity fields (stored as percentage, strip % if present)
-	if field == "humidity" {
+	// Lightning distances are stored in km
+	if field == "lightning_distance" || field == "lightning_nearest" {
+		if strings.HasSuffix(strings.ToLower(valueStr), "mi") {
+			miles, err := strconv.ParseFloat(strings.TrimSpace(valueStr[:len(valueStr)-2]), 64)
+			if err != nil {
+				return 0, err
+			}
+			return miles * 1.609344, nil
+		}
+		if strings.HasSuffix(strings.ToLower(valueStr), "km") {
+			valueStr = valueStr[:len(valueStr)-2]
+		}
+	}
+	// Pressure is stored in mb; inHg thresholds are converted so conditions
+	// can be written in the unit the dashboard shows
+	if field == "pressure" {
+		lower := strings.ToLower(valueStr)
+		for _, unit := range []string{"inhg", "hpa", "mb"} {
+			if strings.HasSuffix(lower, unit) {
+				v, err := strconv.ParseFloat(strings.TrimSpace(valueStr[:len(valueStr)-len(unit)]), 64)
+				if err != nil {
+					return 0, err
+				}
+				return weather.PressureToMB(v, unit)
+			}
+		}
+	}
+	if field == "lightning_rate" {
+		valueStr = strings.TrimSuffix(valueStr, "/min")
+	}
+
+	// Solar energy is stored in Wh/m²; kWh is what panel owners think in
+	if field == "solar_daily" {
+		lower := strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(valueStr), "/m²"), "/m2")
+		multiplier := 1.0
+		if strings.HasSuffix(lower, "kwh") {
+			lower, multiplier = strings.TrimSuffix(lower, "kwh"), 1000
+		} else {
+			lower = strings.TrimSuffix(lower, "wh")
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(lower), 64)
+		if err != nil {
+			return 0, unitError(valueStr, field)
+		}
+		return v * multiplier, nil
+	}
+	if field == "uv_dose" {
+		valueStr = strings.TrimSuffix(strings.TrimSuffix(valueStr, "SED"), "sed")
+	}
+
+	if durationFields[field] {
+		return parseDurationSeconds(valueStr)
+	}
+	if booleanFields[field] {
+		switch strings.ToLower(valueStr) {
+		case "true":
+			return 1, nil
+		case "false":
+			return 0, nil
+		}
+	}
+	if field == "battery" {
+		valueStr = strings.TrimSuffix(strings.TrimSuffix(valueStr, "v"), "V")
+	}
+	if field == "api_errors_rate" {
+		valueStr = strings.TrimSuffix(valueStr, "/h")
+	}
+
+	// Humidity and percentages such as the chance of precipitation are stored
+	// as 0-100, strip % if present
+	if field == "humidity" || field == "percent" {
 		valueStr = strings.TrimSuffix(valueStr, "%")
 	}
 
-	// Parse as plain number
-	return strconv.ParseFloat(strings.TrimSpace(valueStr), 64)
+	// Parse as plain number; anything else is a unit the field does not take
+	value, err := strconv.ParseFloat(strings.TrimSpace(valueStr), 64)
+	if err != nil {
+		return 0, unitError(valueStr, field)
+	}
+	return value, nil
 }
 
 // evaluateChangeDetection evaluates unary change-detection operators
@@ -341,15 +505,53 @@ func (e *Evaluator) GetAvailableFields() []string {
 		"uv", "uv_index",
 		"rain_rate",
 		"rain_daily",
+		"rain_rate_raw",
+		"rain_daily_raw",
+		"rain_rate_nc",
+		"rain_daily_nc",
+		"snow_rate",
+		"snow_daily",
+		"gdd_today",
+		"gdd_season",
+		"chill_hours_today",
+		"chill_hours_season",
+		"solar_daily",
+		"uv_dose",
+		"is_daytime",
+		"sun_elevation",
+		"minutes_to_sunset",
+		"minutes_to_sunrise",
+		"anomaly(temperature)",
+		"anomaly(humidity)",
+		"anomaly(pressure)",
+		"anomaly(wind_speed)",
+		"anomaly(wind_gust)",
+		"delta(pressure, 3h)",
+		"rate(temperature, 1h)",
+		"forecast_max(temperature, 24h)",
+		"forecast_min(temperature, 12h)",
+		"forecast_max(precip_probability, 6h)",
+		"forecast_max(wind_gust, 24h)",
 		"lightning_count",
 		"lightning_distance",
+		"lightning_nearest",
+		"lightning_rate",
 		"precipitation_type",
+		"battery",
+		"data_age",
+		"udp_silence",
+		"api_errors_rate",
+		"token_invalid",
+		"token_rate_limited",
+		"any_site.wind_gust",
+		"all_sites.temperature",
+		"site.<name>.temperature",
 	}
 }
 
 // Paraphrase converts a condition into human-readable text
 func (e *Evaluator) Paraphrase(condition string) string {
-	condition = strings.TrimSpace(condition)
+	condition = normalizeLogicalKeywords(strings.TrimSpace(condition))
 	if condition == "" {
 		return "No condition specified"
 	}
@@ -396,6 +598,14 @@ func (e *Evaluator) paraphraseSimple(condition string) string {
 		}
 	}
 
+	// Handle bare boolean fields
+	if name := strings.TrimPrefix(condition, "!"); booleanFields[strings.ToLower(strings.TrimSpace(name))] {
+		if name != condition {
+			return "it is not " + e.formatFieldName(name)
+		}
+		return "it is " + e.formatFieldName(name)
+	}
+
 	// Handle binary comparisons
 	for _, op := range []string{">=", "<=", "!=", "==", ">", "<"} {
 		if strings.Contains(condition, op) {
@@ -422,6 +632,21 @@ func (e *Evaluator) paraphraseSimple(condition string) string {
 // formatFieldName converts a field name into human-readable text
 func (e *Evaluator) formatFieldName(field string) string {
 	field = strings.ToLower(strings.TrimSpace(field))
+	if inner, ok := anomalyField(field); ok {
+		return e.formatFieldName(inner) + " anomaly score"
+	}
+	if fn, inner, window, ok, err := trendField(field); ok && err == nil {
+		if fn == "rate" {
+			return e.formatFieldName(inner) + " change per hour over " + formatWindow(window)
+		}
+		return e.formatFieldName(inner) + " change over " + formatWindow(window)
+	}
+	if fn, inner, window, ok, err := forecastField(field); ok && err == nil {
+		if fn == "forecast_min" {
+			return "lowest forecast " + forecastValues[inner].name + " in the next " + formatWindow(window)
+		}
+		return "highest forecast " + forecastValues[inner].name + " in the next " + formatWindow(window)
+	}
 	fieldNames := map[string]string{
 		"temperature":        "temperature",
 		"temp":               "temperature",
@@ -437,9 +662,33 @@ func (e *Evaluator) formatFieldName(field string) string {
 		"uv_index":           "UV index",
 		"rain_rate":          "rain rate",
 		"rain_daily":         "daily rainfall",
+		"rain_rate_raw":      "uncorrected rain rate",
+		"rain_daily_raw":     "uncorrected daily rainfall",
+		"rain_rate_nc":       "Rain Check rain rate",
+		"rain_daily_nc":      "Rain Check daily rainfall",
+		"snow_rate":          "snowfall rate",
+		"snow_daily":         "daily snowfall",
+		"gdd_today":          "growing degree days today",
+		"gdd_season":         "season growing degree days",
+		"chill_hours_today":  "chill hours today",
+		"chill_hours_season": "season chill hours",
+		"solar_daily":        "solar energy today",
+		"uv_dose":            "UV dose today",
+		"is_daytime":         "daytime",
+		"sun_elevation":      "sun elevation",
+		"minutes_to_sunset":  "minutes to sunset",
+		"minutes_to_sunrise": "minutes to sunrise",
 		"lightning_count":    "lightning strike count",
 		"lightning_distance": "lightning distance",
+		"lightning_nearest":  "nearest lightning strike",
+		"lightning_rate":     "lightning strikes per minute",
 		"precipitation_type": "precipitation type",
+		"battery":            "battery voltage",
+		"data_age":           "time since last observation",
+		"udp_silence":        "time since last UDP packet",
+		"api_errors_rate":    "API errors per hour",
+		"token_invalid":      "API token rejected",
+		"token_rate_limited": "API rate limited",
 	}
 	if name, ok := fieldNames[field]; ok {
 		return name
@@ -464,6 +713,13 @@ func (e *Evaluator) formatValue(value string) string {
 	if strings.HasSuffix(value, "m/s") {
 		return value[:len(value)-3] + " m/s"
 	}
+	// Check for pressure units
+	if strings.HasSuffix(strings.ToLower(value), "inhg") {
+		return value[:len(value)-4] + " inHg"
+	}
+	if strings.HasSuffix(value, "mb") {
+		return value[:len(value)-2] + " mb"
+	}
 	// Check for humidity percentage
 	if strings.HasSuffix(value, "%") {
 		return value
@@ -309,6 +309,43 @@ func TestEvaluatorGetAvailableFields(t *testing.T) {
 	}
 }
 
+func TestEvaluatorRainSources(t *testing.T) {
+	obs := &weather.Observation{RainAccumulated: 0.5, RainDailyTotal: 6, NCRainAccumulated: 0.7, NCRainDailyTotal: 8}
+	weather.ApplyRainCorrection(obs, 1.5)
+
+	evaluator := NewEvaluator()
+	for field, want := range map[string]float64{
+		"rain_rate":      0.75,
+		"rain_rate_raw":  0.5,
+		"rain_daily_raw": 6,
+		"rain_rate_nc":   0.7,
+		"rain_daily_nc":  8,
+	} {
+		got, err := evaluator.getFieldValue(field, obs)
+		if err != nil || got != want {
+			t.Errorf("%s = %v, %v; want %v", field, got, err, want)
+		}
+	}
+
+	// Without a correction the raw fields read the reported values
+	if got, _ := evaluator.getFieldValue("rain_daily_raw", &weather.Observation{RainDailyTotal: 4}); got != 4 {
+		t.Errorf("uncorrected rain_daily_raw = %v, want 4", got)
+	}
+}
+
+func TestEvaluatorSnowFields(t *testing.T) {
+	obs := &weather.Observation{SnowRate: 30, SnowDailyTotal: 45}
+	evaluator := NewEvaluator()
+
+	result, err := evaluator.Evaluate("snow_daily > 40 && snow_rate >= 30", obs)
+	if err != nil || !result {
+		t.Errorf("snow condition = %v, %v; want true", result, err)
+	}
+	if result, _ := evaluator.Evaluate("snow_daily > 50", obs); result {
+		t.Error("snow_daily > 50 should be false for 45 mm")
+	}
+}
+
 func TestEvaluatorEdgeCases(t *testing.T) {
 	obs := &weather.Observation{
 		AirTemperature: 0.0,
@@ -0,0 +1,158 @@
+package alarm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// ConditionNode is one node of a parsed condition, annotated with the values
+// used to evaluate it. It mirrors how EvaluateWithAlarm reads a condition and
+// is intended for debugging alarm syntax (see --eval-condition).
+type ConditionNode struct {
+	Type         string           `json:"type"` // "and", "or", "compare", or "change"
+	Expression   string           `json:"expression"`
+	Field        string           `json:"field,omitempty"`
+	Operator     string           `json:"operator,omitempty"`
+	Value        string           `json:"value,omitempty"`        // comparison value as written (with units)
+	FieldValue   float64          `json:"fieldValue"`             // resolved observation value (base units)
+	CompareValue float64          `json:"compareValue,omitempty"` // comparison value converted to base units
+	Result       bool             `json:"result"`
+	Error        string           `json:"error,omitempty"`
+	Children     []*ConditionNode `json:"children,omitempty"`
+}
+
+var (
+	andKeyword = regexp.MustCompile(`(?i)\s+and\s+`)
+	orKeyword  = regexp.MustCompile(`(?i)\s+or\s+`)
+)
+
+// normalizeLogicalKeywords rewrites the word forms AND/OR into && and ||
+func normalizeLogicalKeywords(condition string) string {
+	condition = andKeyword.ReplaceAllString(condition, " && ")
+	return orKeyword.ReplaceAllString(condition, " || ")
+}
+
+// Explain parses condition and evaluates every part against obs without
+// short-circuiting, returning the annotated tree. The root Result matches what
+// Evaluate would return. Change-detection parts are reported but never fire,
+// since there is no previous value outside a running alarm.
+func (e *Evaluator) Explain(condition string, obs *weather.Observation) (*ConditionNode, error) {
+	if obs == nil {
+		return nil, fmt.Errorf("no observation available")
+	}
+	condition = normalizeLogicalKeywords(strings.TrimSpace(condition))
+	if condition == "" {
+		return nil, fmt.Errorf("condition cannot be empty")
+	}
+
+	for _, logical := range []struct{ sep, kind string }{{"&&", "and"}, {"||", "or"}} {
+		if !strings.Contains(condition, logical.sep) {
+			continue
+		}
+		root := &ConditionNode{Type: logical.kind, Expression: condition, Result: logical.kind == "and"}
+		for i, part := range strings.Split(condition, logical.sep) {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				return nil, fmt.Errorf("%s operator (%s) requires expressions on both sides (missing expression at position %d)",
+					strings.ToUpper(logical.kind), logical.sep, i+1)
+			}
+			child := e.explainSimple(part, obs)
+			root.Children = append(root.Children, child)
+			if child.Error != "" {
+				root.Error = child.Error
+			}
+			if logical.kind == "and" {
+				root.Result = root.Result && child.Result
+			} else {
+				root.Result = root.Result || child.Result
+			}
+		}
+		if root.Error != "" {
+			root.Result = false
+		}
+		return root, nil
+	}
+
+	return e.explainSimple(condition, obs), nil
+}
+
+// explainSimple annotates a single comparison or change-detection expression
+func (e *Evaluator) explainSimple(condition string, obs *weather.Observation) *ConditionNode {
+	node := &ConditionNode{Type: "compare", Expression: condition}
+
+	if first := condition[0]; first == '*' || first == '>' || first == '<' {
+		node.Type = "change"
+		node.Operator = string(first)
+		node.Field = strings.TrimSpace(condition[1:])
+		value, err := e.getFieldValue(node.Field, obs)
+		if err != nil {
+			node.Error = err.Error()
+			return node
+		}
+		node.FieldValue = value
+		return node
+	}
+
+	for _, op := range []string{">=", "<=", "!=", "==", ">", "<"} {
+		if idx := strings.Index(condition, op); idx > 0 {
+			node.Field = strings.TrimSpace(condition[:idx])
+			node.Operator = op
+			node.Value = strings.TrimSpace(condition[idx+len(op):])
+			break
+		}
+	}
+	if node.Operator == "" {
+		node.Error = fmt.Sprintf("invalid condition format: %s (expected 'field operator value')", condition)
+		return node
+	}
+
+	fieldValue, err := e.getFieldValue(node.Field, obs)
+	if err != nil {
+		node.Error = err.Error()
+		return node
+	}
+	node.FieldValue = fieldValue
+
+	compareValue, err := e.parseValueWithUnits(node.Value, node.Field)
+	if err != nil {
+		node.Error = fmt.Sprintf("invalid comparison value %s: %v", node.Value, err)
+		return node
+	}
+	node.CompareValue = compareValue
+	node.Result = e.compare(fieldValue, node.Operator, compareValue)
+	return node
+}
+
+// Format renders the node tree as indented text for terminal output
+func (n *ConditionNode) Format() string {
+	var b strings.Builder
+	n.format(&b, 0)
+	return b.String()
+}
+
+func (n *ConditionNode) format(b *strings.Builder, depth int) {
+	indent := strings.Repeat("  ", depth)
+	status := "false"
+	if n.Result {
+		status = "TRUE"
+	}
+	switch n.Type {
+	case "and", "or":
+		fmt.Fprintf(b, "%s%s => %s\n", indent, strings.ToUpper(n.Type), status)
+		for _, c := range n.Children {
+			c.format(b, depth+1)
+		}
+		return
+	case "change":
+		fmt.Fprintf(b, "%sCHANGE %s%s  [%s = %.2f]  (needs previous value; not evaluated)\n", indent, n.Operator, n.Field, n.Field, n.FieldValue)
+	default:
+		fmt.Fprintf(b, "%sCOMPARE %s %s %s  [%s = %.2f, value = %.2f] => %s\n",
+			indent, n.Field, n.Operator, n.Value, n.Field, n.FieldValue, n.CompareValue, status)
+	}
+	if n.Error != "" {
+		fmt.Fprintf(b, "%s  error: %s\n", indent, n.Error)
+	}
+}
@@ -0,0 +1,71 @@
+package alarm
+
+import (
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestExplainCompoundCondition(t *testing.T) {
+	e := NewEvaluator()
+	obs := &weather.Observation{AirTemperature: 2.0, WindGust: 15.0}
+
+	tree, err := e.Explain("wind_gust > 25mph AND temperature < 40F", obs)
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if tree.Type != "and" || len(tree.Children) != 2 {
+		t.Fatalf("expected AND node with two children, got %+v", tree)
+	}
+	gust := tree.Children[0]
+	if gust.Field != "wind_gust" || gust.Operator != ">" || gust.Value != "25mph" || gust.FieldValue != 15.0 {
+		t.Errorf("unexpected gust node: %+v", gust)
+	}
+	if !gust.Result || !tree.Children[1].Result || !tree.Result {
+		t.Errorf("expected all parts true, got %+v", tree)
+	}
+
+	// Explain must agree with Evaluate
+	want, err := e.Evaluate("wind_gust > 25mph AND temperature < 40F", obs)
+	if err != nil || want != tree.Result {
+		t.Errorf("Evaluate=%v (err %v) disagrees with Explain=%v", want, err, tree.Result)
+	}
+
+	if out := tree.Format(); !strings.Contains(out, "COMPARE wind_gust > 25mph") {
+		t.Errorf("unexpected formatted output:\n%s", out)
+	}
+}
+
+func TestExplainReportsErrors(t *testing.T) {
+	e := NewEvaluator()
+	obs := &weather.Observation{}
+
+	tree, err := e.Explain("bogus > 1 || temperature > 0", obs)
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if tree.Error == "" || tree.Result {
+		t.Errorf("expected unknown field error to propagate, got %+v", tree)
+	}
+
+	if _, err := e.Explain("temperature > 1 &&", obs); err == nil {
+		t.Error("expected error for dangling operator")
+	}
+	if _, err := e.Explain("temperature > 1", nil); err == nil {
+		t.Error("expected error for missing observation")
+	}
+}
+
+func TestEvaluateAcceptsWordOperators(t *testing.T) {
+	e := NewEvaluator()
+	obs := &weather.Observation{AirTemperature: 30, RelativeHumidity: 20}
+
+	got, err := e.Evaluate("temperature > 25 or humidity > 90", obs)
+	if err != nil || !got {
+		t.Errorf("expected OR keyword to be accepted, got %v err=%v", got, err)
+	}
+	if p := e.Paraphrase("temperature > 25 AND humidity > 90"); !strings.Contains(p, " AND ") {
+		t.Errorf("unexpected paraphrase: %s", p)
+	}
+}
@@ -0,0 +1,186 @@
+package alarm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// legacyFileTimeLayout is the record timestamp layout when no time_format is set
+const legacyFileTimeLayout = "2006-01-02 15:04:05"
+
+// FileColumn maps a CSV column or JSON field of a file channel record to a
+// template, e.g. {"name": "temp_c", "value": "{{temperature}}"}
+type FileColumn struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// validateFileColumns checks that every column has a unique name
+func validateFileColumns(columns []FileColumn, kind string) error {
+	seen := make(map[string]bool, len(columns))
+	for i, col := range columns {
+		if strings.TrimSpace(col.Name) == "" {
+			return fmt.Errorf("%s %d needs a name", kind, i)
+		}
+		if seen[col.Name] {
+			return fmt.Errorf("duplicate %s %q", kind, col.Name)
+		}
+		seen[col.Name] = true
+	}
+	return nil
+}
+
+// renderFileColumns expands the template of each column, with pressures in
+// the export pressure unit
+func renderFileColumns(columns []FileColumn, alarm *Alarm, obs *weather.Observation, stationName string) (names, values []string) {
+	for _, col := range columns {
+		names = append(names, col.Name)
+		values = append(values, expandExportTemplate(col.Value, alarm, obs, stationName))
+	}
+	return names, values
+}
+
+// formatFileTime formats a record timestamp: "iso8601" (or "rfc3339") for
+// RFC 3339 with the zone offset, "unix" for epoch seconds, any other value as
+// a Go time layout, and the legacy "2006-01-02 15:04:05" when empty
+func formatFileTime(t time.Time, format string) string {
+	switch strings.ToLower(format) {
+	case "":
+		return t.Format(legacyFileTimeLayout)
+	case "iso8601", "rfc3339":
+		return t.Format(time.RFC3339)
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	return t.Format(format)
+}
+
+// jsonFieldValue keeps a rendered value that is already JSON (numbers,
+// booleans, objects) as is, so analytics tools see typed values, and quotes
+// anything else as a string
+func jsonFieldValue(value string) json.RawMessage {
+	if trimmed := strings.TrimSpace(value); trimmed != "" && json.Valid([]byte(trimmed)) {
+		return json.RawMessage(trimmed)
+	}
+	quoted, _ := json.Marshal(value)
+	return quoted
+}
+
+// fieldsRecord builds a JSON object with the fields in configured order
+func fieldsRecord(names, values []string) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(jsonFieldValue(values[i]))
+	}
+	b.WriteByte('}')
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, b.Bytes(), "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to build JSON record: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// readCSVHeader returns the first row of an existing CSV file, or nil when
+// the file is missing or empty
+func readCSVHeader(filePath string) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	return header, err
+}
+
+// rotateFile moves a file aside as <path>.<time>.bak, gzipped to
+// <path>.<time>.bak.gz when compress is set, and returns the new path
+func rotateFile(filePath string, compress bool) (string, error) {
+	backupPath := filePath + "." + time.Now().Format("2006-01-02_15-04-05") + ".bak"
+	if err := os.Rename(filePath, backupPath); err != nil {
+		return "", fmt.Errorf("failed to rotate file: %w", err)
+	}
+	if !compress {
+		return backupPath, nil
+	}
+	gzPath, err := gzipFile(backupPath)
+	if err != nil {
+		logger.Warn("Failed to compress rotated file %s: %v", backupPath, err)
+		return backupPath, nil
+	}
+	return gzPath, nil
+}
+
+// gzipFile compresses a file to <path>.gz and removes the original
+func gzipFile(filePath string) (string, error) {
+	in, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = in.Close() }()
+
+	gzPath := filePath + ".gz"
+	out, err := os.OpenFile(gzPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err = io.Copy(zw, in); err == nil {
+		err = zw.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(gzPath)
+		return "", err
+	}
+	_ = in.Close()
+	if err := os.Remove(filePath); err != nil {
+		return "", err
+	}
+	return gzPath, nil
+}
+
+// rotateOnHeaderChange starts a new CSV file when the configured columns no
+// longer match the header of the existing one, so a file never mixes schemas
+func rotateOnHeaderChange(cfg *CSVConfig, header []string) {
+	existing, err := readCSVHeader(cfg.Path)
+	if err != nil || existing == nil || slices.Equal(existing, header) {
+		return
+	}
+	rotated, err := rotateFile(cfg.Path, cfg.Compress)
+	if err != nil {
+		logger.Warn("Failed to rotate CSV file %s after a column change: %v", cfg.Path, err)
+		return
+	}
+	logger.Info("CSV columns changed, rotated %s to %s", cfg.Path, rotated)
+}
@@ -0,0 +1,177 @@
+package alarm
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestCSVNotifierColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alarms.csv")
+	channel := &Channel{Type: "csv", CSV: &CSVConfig{Path: path, Columns: []FileColumn{
+		{Name: "time", Value: "{{timestamp_iso}}"},
+		{Name: "alarm", Value: "{{alarm_name}}"},
+		{Name: "temp_c", Value: "{{temperature}}"},
+	}}}
+	alarm := &Alarm{Name: "Hot, dry"}
+	obs := &weather.Observation{Timestamp: time.Date(2026, 7, 1, 15, 0, 0, 0, time.UTC).Unix(), AirTemperature: 31.5}
+
+	notifier := &CSVNotifier{}
+	for i := 0; i < 2; i++ {
+		if err := notifier.Send(alarm, channel, obs, "Station"); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	records := readCSV(t, path)
+	if len(records) != 3 || strings.Join(records[0], "|") != "time|alarm|temp_c" {
+		t.Fatalf("records = %q, want header and 2 rows", records)
+	}
+	when, err := time.Parse(time.RFC3339, records[1][0])
+	if err != nil || when.Unix() != obs.Timestamp {
+		t.Errorf("time column = %q, want ISO-8601 observation time", records[1][0])
+	}
+	if records[1][1] != "Hot, dry" || records[1][2] != "31.5" {
+		t.Errorf("row = %q", records[1])
+	}
+
+	// Changing the columns starts a new file rather than mixing schemas
+	channel.CSV.Columns = channel.CSV.Columns[:2]
+	channel.CSV.Compress = true
+	if err := notifier.Send(alarm, channel, obs, "Station"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if records := readCSV(t, path); len(records) != 2 || len(records[0]) != 2 {
+		t.Errorf("records after column change = %q, want new header and 1 row", records)
+	}
+	rotated, _ := filepath.Glob(path + ".*.bak.gz")
+	if len(rotated) != 1 {
+		t.Fatalf("rotated files = %v, want one gzipped backup", rotated)
+	}
+	if content := readGzip(t, rotated[0]); !strings.HasPrefix(content, "time,alarm,temp_c\n") {
+		t.Errorf("rotated content = %q", content)
+	}
+}
+
+func TestCSVNotifierNoHeaderAndTimeFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alarms.csv")
+	cfg := &CSVConfig{Path: path, NoHeader: true, TimeFormat: "unix"}
+	if err := (&CSVNotifier{}).appendToCSVFile(cfg, "hello"); err != nil {
+		t.Fatalf("appendToCSVFile: %v", err)
+	}
+	records := readCSV(t, path)
+	if len(records) != 1 || records[0][1] != "hello" {
+		t.Fatalf("records = %q, want one row without a header", records)
+	}
+	if _, err := strconv.ParseInt(records[0][0], 10, 64); err != nil {
+		t.Errorf("timestamp = %q, want epoch seconds", records[0][0])
+	}
+}
+
+func TestJSONNotifierFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alarms.json")
+	channel := &Channel{Type: "json", JSON: &JSONConfig{Path: path, Fields: []FileColumn{
+		{Name: "time", Value: "{{timestamp_iso}}"},
+		{Name: "alarm", Value: "{{alarm_name}}"},
+		{Name: "humidity", Value: "{{humidity}}"},
+		{Name: "last_temp", Value: "{{last_temperature}}"},
+	}}}
+	obs := &weather.Observation{Timestamp: time.Now().Unix(), RelativeHumidity: 88}
+	for i := 0; i < 2; i++ {
+		if err := (&JSONNotifier{}).Send(&Alarm{Name: "Humid"}, channel, obs, "Station"); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatalf("file is not a JSON array: %v\n%s", err, data)
+	}
+	if len(records) != 2 {
+		t.Fatalf("records = %d, want 2", len(records))
+	}
+	if h, ok := records[0]["humidity"].(float64); !ok || h != 88 {
+		t.Errorf("humidity = %#v, want the number 88", records[0]["humidity"])
+	}
+	if records[0]["last_temp"] != "N/A" || records[0]["alarm"] != "Humid" {
+		t.Errorf("record = %v", records[0])
+	}
+	// Fields keep their configured order
+	if strings.Index(string(data), `"time"`) > strings.Index(string(data), `"alarm"`) {
+		t.Errorf("fields out of order:\n%s", data)
+	}
+}
+
+func TestValidateFileColumns(t *testing.T) {
+	if err := validateFileColumns([]FileColumn{{Name: "a"}, {Name: "b"}}, "column"); err != nil {
+		t.Errorf("valid columns: %v", err)
+	}
+	if err := validateFileColumns([]FileColumn{{Name: "a"}, {Name: "a"}}, "column"); err == nil {
+		t.Error("expected error for duplicate column")
+	}
+	if err := validateFileColumns([]FileColumn{{Value: "{{lux}}"}}, "field"); err == nil {
+		t.Error("expected error for unnamed field")
+	}
+}
+
+func TestFormatFileTime(t *testing.T) {
+	ts := time.Date(2026, 3, 4, 5, 6, 7, 0, time.FixedZone("X", -7*3600))
+	tests := map[string]string{
+		"":           "2026-03-04 05:06:07",
+		"iso8601":    "2026-03-04T05:06:07-07:00",
+		"RFC3339":    "2026-03-04T05:06:07-07:00",
+		"unix":       "1772625967",
+		"2006/01/02": "2026/03/04",
+	}
+	for format, want := range tests {
+		if got := formatFileTime(ts, format); got != want {
+			t.Errorf("formatFileTime(%q) = %q, want %q", format, got, want)
+		}
+	}
+}
+
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
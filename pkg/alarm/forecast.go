@@ -0,0 +1,117 @@
+package alarm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// ForecastProvider returns the hourly forecast periods that have not ended,
+// oldest first
+type ForecastProvider func() []weather.ForecastPeriod
+
+// forecastValue is a forecast quantity conditions can read
+type forecastValue struct {
+	name  string // human-readable name used when paraphrasing conditions
+	units string // unit class of thresholds, as in parseValueWithUnits
+	value func(p weather.ForecastPeriod) float64
+}
+
+// forecastValues are the hourly forecast quantities of forecast_max() and
+// forecast_min(), by the name used in conditions. Values are metric like the
+// observation fields; pressure is at sea level.
+var forecastValues = map[string]forecastValue{
+	"temperature":        {"temperature", "temperature", func(p weather.ForecastPeriod) float64 { return p.AirTemperature }},
+	"feels_like":         {"feels-like temperature", "temperature", func(p weather.ForecastPeriod) float64 { return p.FeelsLike }},
+	"humidity":           {"humidity", "humidity", func(p weather.ForecastPeriod) float64 { return float64(p.RelativeHumidity) }},
+	"sea_level_pressure": {"sea level pressure", "pressure", func(p weather.ForecastPeriod) float64 { return p.SeaLevelPressure }},
+	"wind_speed":         {"wind speed", "wind_speed", func(p weather.ForecastPeriod) float64 { return p.WindAvg }},
+	"wind_gust":          {"wind gust", "wind_gust", func(p weather.ForecastPeriod) float64 { return p.WindGust }},
+	"uv":                 {"UV index", "uv", func(p weather.ForecastPeriod) float64 { return float64(p.UV) }},
+	"precip_probability": {"chance of precipitation", "percent", func(p weather.ForecastPeriod) float64 { return float64(p.PrecipProbability) }},
+	"precip":             {"hourly precipitation", "rain_rate", func(p weather.ForecastPeriod) float64 { return p.Precip }},
+}
+
+// SetForecastProvider supplies the hourly forecast behind the
+// forecast_max(field, window) and forecast_min(field, window) fields.
+// Without a provider they cannot be evaluated.
+func (e *Evaluator) SetForecastProvider(fn ForecastProvider) {
+	e.forecast = fn
+}
+
+// forecastField splits a "forecast_max(field, window)" or
+// "forecast_min(field, window)" condition field into the function, forecast
+// quantity and window
+func forecastField(field string) (fn, inner string, window time.Duration, ok bool, err error) {
+	for _, name := range []string{"forecast_max", "forecast_min"} {
+		if strings.HasPrefix(field, name+"(") && strings.HasSuffix(field, ")") {
+			fn = name
+			break
+		}
+	}
+	if fn == "" {
+		return "", "", 0, false, nil
+	}
+	args := strings.Split(field[len(fn)+1:len(field)-1], ",")
+	if len(args) != 2 {
+		return fn, "", 0, true, fmt.Errorf("%s needs a field and a window, e.g. %s(temperature, 12h)", fn, fn)
+	}
+	inner = strings.ReplaceAll(strings.TrimSpace(args[0]), " ", "_")
+	switch inner {
+	case "temp":
+		inner = "temperature"
+	case "wind":
+		inner = "wind_speed"
+	}
+	if _, known := forecastValues[inner]; !known {
+		names := make([]string, 0, len(forecastValues))
+		for name := range forecastValues {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fn, inner, 0, true, fmt.Errorf("unknown forecast field: %s (use %s)", inner, strings.Join(names, ", "))
+	}
+	seconds, err := parseDurationSeconds(args[1])
+	if err != nil || seconds <= 0 {
+		return fn, inner, 0, true, fmt.Errorf("invalid %s window %q (use e.g. 6h or 24h)", fn, strings.TrimSpace(args[1]))
+	}
+	return fn, inner, time.Duration(seconds * float64(time.Second)), true, nil
+}
+
+// getForecastValue resolves the highest (forecast_max) or lowest
+// (forecast_min) forecast value of the hours from the observation's hour to
+// the end of the window. Without forecast hours in the window there is no
+// value, so the condition cannot fire on a missing forecast.
+func (e *Evaluator) getForecastValue(fn, field string, window time.Duration, obs *weather.Observation) (float64, error) {
+	if e.forecast == nil {
+		return 0, fmt.Errorf("%s(%s) unavailable: no forecast source", fn, field)
+	}
+	now := time.Now()
+	if obs.Timestamp > 0 {
+		now = time.Unix(obs.Timestamp, 0)
+	}
+	hours := weather.UpcomingHours(e.forecast(), now, window)
+	if len(hours) == 0 {
+		return 0, fmt.Errorf("%s(%s) unavailable: no hourly forecast for the next %s", fn, field, formatWindow(window))
+	}
+	value := forecastValues[field].value
+	result := value(hours[0])
+	for _, p := range hours[1:] {
+		v := value(p)
+		if (fn == "forecast_max" && v > result) || (fn == "forecast_min" && v < result) {
+			result = v
+		}
+	}
+	return result, nil
+}
+
+// SetForecastProvider supplies the hourly forecast to forecast_max() and
+// forecast_min() conditions
+func (m *Manager) SetForecastProvider(fn ForecastProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.evaluator.SetForecastProvider(fn)
+}
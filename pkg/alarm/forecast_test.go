@@ -0,0 +1,87 @@
+package alarm
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestEvaluateForecastFields(t *testing.T) {
+	start := time.Date(2026, 11, 3, 18, 0, 0, 0, time.UTC)
+	var hourly []weather.ForecastPeriod
+	for h := 0; h < 24; h++ {
+		// Cooling 1 °C per hour from 6 °C, rain likely from the fourth hour
+		p := weather.ForecastPeriod{Time: start.Add(time.Duration(h) * time.Hour).Unix(), AirTemperature: 6 - float64(h), WindGust: 8}
+		if h >= 3 {
+			p.PrecipProbability = 80
+		}
+		hourly = append(hourly, p)
+	}
+	now := weather.Observation{Timestamp: start.Add(20 * time.Minute).Unix()}
+
+	e := NewEvaluator()
+	if _, err := e.Evaluate("forecast_min(temperature, 12h) < 0", &now); err == nil {
+		t.Error("expected an error without a forecast provider")
+	}
+	e.SetForecastProvider(func() []weather.ForecastPeriod { return hourly })
+
+	if v, err := e.getFieldValue("forecast_min(temperature, 12h)", &now); err != nil || v != -6 {
+		t.Errorf("forecast_min(temperature, 12h) = %v, %v; want -6", v, err)
+	}
+	if v, err := e.getFieldValue("forecast_max(precip_probability, 3h)", &now); err != nil || v != 80 {
+		t.Errorf("forecast_max(precip_probability, 3h) = %v, %v; want 80 (the hour starting at 21:00 begins within 3h of 18:20)", v, err)
+	}
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"forecast_min(temperature, 12h) < 0", true},
+		{"forecast_min(temp, 4h) < 0", false},
+		{"forecast_min(temperature, 12h) < 32F", true},
+		{"forecast_max(wind_gust, 24h) > 15mph", true},
+		{"forecast_max(wind_gust, 24h) > 20mph", false},
+		{"forecast_max(precip_probability, 2h) >= 70%", false},
+		{"forecast_max(precip_probability, 6h) >= 70% && temperature > 5", true},
+	}
+	now.AirTemperature = 7
+	for _, tt := range tests {
+		got, err := e.Evaluate(tt.condition, &now)
+		if err != nil || got != tt.want {
+			t.Errorf("Evaluate(%q) = %v, %v; want %v", tt.condition, got, err, tt.want)
+		}
+	}
+
+	// Past the end of the forecast there is nothing to compare
+	later := weather.Observation{Timestamp: start.Add(30 * time.Hour).Unix()}
+	if _, err := e.Evaluate("forecast_min(temperature, 12h) < 0", &later); err == nil {
+		t.Error("expected an error without forecast hours in the window")
+	}
+	for _, bad := range []string{"forecast_max(temperature) > 1", "forecast_max(temperature, soon) > 1", "forecast_min(lux, 1h) < 1"} {
+		if _, err := e.Evaluate(bad, &now); err == nil {
+			t.Errorf("Evaluate(%q) should fail", bad)
+		}
+	}
+
+	if errs := ValidateCondition("forecast_min(temperature, 12h) < 28F && forecast_max(precip_probability, 6h) > 50%"); len(errs) != 0 {
+		t.Errorf("ValidateCondition: unexpected errors %v", errs)
+	}
+	if errs := ValidateCondition("forecast_max(wind_gust, 6h) > 90mb"); len(errs) != 1 {
+		t.Errorf("ValidateCondition: expected a unit error, got %v", errs)
+	}
+	if got := e.Paraphrase("forecast_min(temperature, 12h) < 0"); !strings.Contains(got, "lowest forecast temperature in the next 12h is below 0") {
+		t.Errorf("Paraphrase = %q", got)
+	}
+	if v, err := e.parseValueWithUnits("32F", "forecast_min(feels_like, 6h)"); err != nil || math.Abs(v) > 1e-9 {
+		t.Errorf("32F for feels_like = %v, %v; want 0 °C", v, err)
+	}
+	if v, err := e.parseValueWithUnits("50%", "forecast_max(precip_probability, 6h)"); err != nil || v != 50 {
+		t.Errorf("50%% for precip_probability = %v, %v; want 50", v, err)
+	}
+	if _, err := e.parseValueWithUnits("50F", "forecast_max(precip_probability, 6h)"); err == nil {
+		t.Error("50F for precip_probability should fail")
+	}
+}
@@ -0,0 +1,199 @@
+package alarm
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// maxHistoryMinutes caps the window a channel can include (one day)
+const maxHistoryMinutes = 1440
+
+// HistoryAttachment includes the observations that led up to an alarm in an
+// email or webhook notification: as an attached file for email, and as a
+// field of the JSON body for webhooks
+type HistoryAttachment struct {
+	Minutes int    `json:"minutes"`          // observations up to this many minutes before the trigger
+	Format  string `json:"format,omitempty"` // "csv" (default) or "json"
+	Field   string `json:"field,omitempty"`  // webhook body field, default "history"
+}
+
+// historyField matches the webhook body fields history can be added as
+var historyField = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Validate checks the window, format and webhook field
+func (h *HistoryAttachment) Validate() error {
+	if h.Minutes < 1 || h.Minutes > maxHistoryMinutes {
+		return fmt.Errorf("history minutes must be between 1 and %d", maxHistoryMinutes)
+	}
+	if h.Format != "" && h.Format != "csv" && h.Format != "json" {
+		return fmt.Errorf("invalid history format %q (must be csv or json)", h.Format)
+	}
+	if h.Field != "" && !historyField.MatchString(h.Field) {
+		return fmt.Errorf("invalid history field %q (letters, digits, - and _ only)", h.Field)
+	}
+	return nil
+}
+
+// format returns the history format, csv unless json was chosen
+func (h *HistoryAttachment) format() string {
+	if h.Format == "json" {
+		return "json"
+	}
+	return "csv"
+}
+
+// field returns the webhook body field holding the history
+func (h *HistoryAttachment) field() string {
+	if h.Field == "" {
+		return "history"
+	}
+	return h.Field
+}
+
+// recentHistory returns the observations of history in the minutes up to
+// obs, oldest first and ending with obs
+func recentHistory(history []weather.Observation, obs *weather.Observation, minutes int) []weather.Observation {
+	since := obs.Timestamp - int64(minutes)*60
+	var recent []weather.Observation
+	for _, o := range history {
+		if o.Timestamp >= since && o.Timestamp < obs.Timestamp {
+			recent = append(recent, o)
+		}
+	}
+	return append(recent, *obs)
+}
+
+// historyColumns are the observation fields of history CSV files, in
+// station units (°C, %, mb, m/s, lux, mm, km, V)
+var historyColumns = []struct {
+	name  string
+	value func(o *weather.Observation) float64
+}{
+	{"air_temperature", func(o *weather.Observation) float64 { return o.AirTemperature }},
+	{"relative_humidity", func(o *weather.Observation) float64 { return o.RelativeHumidity }},
+	{"station_pressure", func(o *weather.Observation) float64 { return o.StationPressure }},
+	{"wind_avg", func(o *weather.Observation) float64 { return o.WindAvg }},
+	{"wind_gust", func(o *weather.Observation) float64 { return o.WindGust }},
+	{"wind_lull", func(o *weather.Observation) float64 { return o.WindLull }},
+	{"wind_direction", func(o *weather.Observation) float64 { return o.WindDirection }},
+	{"illuminance", func(o *weather.Observation) float64 { return o.Illuminance }},
+	{"uv", func(o *weather.Observation) float64 { return float64(o.UV) }},
+	{"solar_radiation", func(o *weather.Observation) float64 { return o.SolarRadiation }},
+	{"rain_accumulated", func(o *weather.Observation) float64 { return o.RainAccumulated }},
+	{"rain_daily_total", func(o *weather.Observation) float64 { return o.RainDailyTotal }},
+	{"lightning_strike_count", func(o *weather.Observation) float64 { return float64(o.LightningStrikeCount) }},
+	{"lightning_strike_avg_distance", func(o *weather.Observation) float64 { return o.LightningStrikeAvg }},
+	{"battery", func(o *weather.Observation) float64 { return o.Battery }},
+}
+
+// historyCSV renders observations as CSV with a header row
+func historyCSV(observations []weather.Observation) []byte {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	header := []string{"timestamp"}
+	for _, column := range historyColumns {
+		header = append(header, column.name)
+	}
+	_ = w.Write(header)
+	for i := range observations {
+		o := &observations[i]
+		row := []string{time.Unix(o.Timestamp, 0).UTC().Format(time.RFC3339)}
+		for _, column := range historyColumns {
+			row = append(row, strconv.FormatFloat(column.value(o), 'f', -1, 64))
+		}
+		_ = w.Write(row)
+	}
+	w.Flush()
+	return b.Bytes()
+}
+
+// encode renders observations in the history format
+func (h *HistoryAttachment) encode(observations []weather.Observation) ([]byte, error) {
+	if h.format() == "json" {
+		if observations == nil {
+			observations = []weather.Observation{}
+		}
+		return json.MarshalIndent(observations, "", "  ")
+	}
+	return historyCSV(observations), nil
+}
+
+// historyFileName matches the characters replaced in attachment names
+var historyFileName = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// attachment returns the history of an alarm as an email attachment named
+// after the alarm and the trigger time
+func (h *HistoryAttachment) attachment(alarm *Alarm, obs *weather.Observation, observations []weather.Observation) (*emailAttachment, error) {
+	data, err := h.encode(observations)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.Trim(historyFileName.ReplaceAllString(alarm.Name, "-"), "-")
+	if name == "" {
+		name = "alarm"
+	}
+	contentType := "text/csv; charset=UTF-8"
+	if h.format() == "json" {
+		contentType = "application/json"
+	}
+	return &emailAttachment{
+		Name:        fmt.Sprintf("%s-history-%s.%s", name, time.Unix(obs.Timestamp, 0).UTC().Format("20060102-1504"), h.format()),
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
+
+// addToPayload adds the history to a webhook body that is a JSON object, as
+// an array of observations or a CSV string. Other bodies are returned
+// unchanged with an error.
+func (h *HistoryAttachment) addToPayload(body string, observations []weather.Observation) (string, error) {
+	trimmed := strings.TrimSpace(body)
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(trimmed), &fields); err != nil || fields == nil {
+		return body, fmt.Errorf("history needs a JSON object webhook body")
+	}
+	if _, ok := fields[h.field()]; ok {
+		return body, fmt.Errorf("webhook body already has a %q field", h.field())
+	}
+	data, err := h.encode(observations)
+	if err != nil {
+		return body, err
+	}
+	if h.format() == "csv" {
+		if data, err = json.Marshal(string(data)); err != nil {
+			return body, err
+		}
+	} else {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, data); err != nil {
+			return body, err
+		}
+		data = compact.Bytes()
+	}
+	key, _ := json.Marshal(h.field())
+
+	// Append the field before the closing brace, keeping the body as written
+	inner := strings.TrimSpace(strings.TrimSuffix(trimmed, "}"))
+	separator := ","
+	if inner == "{" {
+		separator = ""
+	}
+	return fmt.Sprintf("%s%s%s:%s}", inner, separator, key, data), nil
+}
+
+// historyFor returns the observations a channel includes with a
+// notification: those collected for it at trigger time, or obs alone
+func (c *Channel) historyFor(obs *weather.Observation) []weather.Observation {
+	if len(c.recent) > 0 {
+		return c.recent
+	}
+	return []weather.Observation{*obs}
+}
@@ -0,0 +1,161 @@
+package alarm
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestHistoryAttachmentValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		history HistoryAttachment
+		wantErr string
+	}{
+		{"csv", HistoryAttachment{Minutes: 30}, ""},
+		{"json field", HistoryAttachment{Minutes: 1440, Format: "json", Field: "recent_obs"}, ""},
+		{"no minutes", HistoryAttachment{}, "between 1 and 1440"},
+		{"too long", HistoryAttachment{Minutes: 1441}, "between 1 and 1440"},
+		{"bad format", HistoryAttachment{Minutes: 5, Format: "xml"}, "must be csv or json"},
+		{"bad field", HistoryAttachment{Minutes: 5, Field: "a.b"}, "invalid history field"},
+	}
+	for _, tt := range tests {
+		err := tt.history.Validate()
+		if (tt.wantErr == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	console := Channel{Type: "console", Template: "x", History: &HistoryAttachment{Minutes: 5}}
+	if err := console.Validate(); err == nil || !strings.Contains(err.Error(), "only supported by email and webhook") {
+		t.Errorf("console history error = %v", err)
+	}
+}
+
+func TestRecentHistory(t *testing.T) {
+	history := []weather.Observation{{Timestamp: 1000}, {Timestamp: 1500}, {Timestamp: 1900}, {Timestamp: 2000}}
+	obs := &weather.Observation{Timestamp: 2000, AirTemperature: 30}
+	got := recentHistory(history, obs, 10)
+	if len(got) != 3 || got[0].Timestamp != 1500 || got[1].Timestamp != 1900 || got[2].AirTemperature != 30 {
+		t.Errorf("recentHistory = %+v, want 1500, 1900 and obs", got)
+	}
+	if got := recentHistory(nil, obs, 10); len(got) != 1 || got[0].Timestamp != 2000 {
+		t.Errorf("recentHistory without history = %+v, want obs", got)
+	}
+}
+
+func TestHistoryEncode(t *testing.T) {
+	observations := []weather.Observation{
+		{Timestamp: 1700000000, AirTemperature: 20.5, RelativeHumidity: 60},
+		{Timestamp: 1700000060, AirTemperature: 21, UV: 3},
+	}
+	csvData, err := (&HistoryAttachment{Minutes: 5}).encode(observations)
+	if err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(csvData)), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "timestamp,air_temperature,relative_humidity,") ||
+		!strings.HasPrefix(lines[1], "2023-11-14T22:13:20Z,20.5,60,") {
+		t.Errorf("csv = %q", lines)
+	}
+
+	jsonData, err := (&HistoryAttachment{Minutes: 5, Format: "json"}).encode(observations)
+	if err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	var decoded []weather.Observation
+	if err := json.Unmarshal(jsonData, &decoded); err != nil || len(decoded) != 2 || decoded[1].UV != 3 {
+		t.Errorf("json = %s, %v", jsonData, err)
+	}
+
+	attachment, err := (&HistoryAttachment{Minutes: 5}).attachment(&Alarm{Name: "High Wind!"}, &observations[1], observations)
+	if err != nil || attachment.Name != "High-Wind-history-20231114-2214.csv" || attachment.ContentType != "text/csv; charset=UTF-8" {
+		t.Errorf("attachment = %+v, %v", attachment, err)
+	}
+}
+
+func TestHistoryAddToPayload(t *testing.T) {
+	observations := []weather.Observation{{Timestamp: 1700000000, AirTemperature: 20}}
+	h := &HistoryAttachment{Minutes: 5, Format: "json"}
+
+	got, err := h.addToPayload(` {"text": "Hot"} `, observations)
+	if err != nil {
+		t.Fatalf("addToPayload: %v", err)
+	}
+	var payload struct {
+		Text    string                `json:"text"`
+		History []weather.Observation `json:"history"`
+	}
+	if err := json.Unmarshal([]byte(got), &payload); err != nil || payload.Text != "Hot" || len(payload.History) != 1 {
+		t.Errorf("payload = %s, %v", got, err)
+	}
+
+	// CSV goes in a string field; an empty object takes no comma
+	got, err = (&HistoryAttachment{Minutes: 5, Field: "csv"}).addToPayload("{}", observations)
+	var csvPayload map[string]string
+	if err != nil || json.Unmarshal([]byte(got), &csvPayload) != nil || !strings.HasPrefix(csvPayload["csv"], "timestamp,") {
+		t.Errorf("csv payload = %s, %v", got, err)
+	}
+
+	for _, body := range []string{"text=Hot", `["Hot"]`, `{"history": 1}`} {
+		if got, err := h.addToPayload(body, observations); err == nil || got != body {
+			t.Errorf("%s: got %q, %v; want it unchanged with an error", body, got, err)
+		}
+	}
+}
+
+func TestManagerWebhookHistory(t *testing.T) {
+	bodies := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- string(b)
+	}))
+	defer srv.Close()
+
+	config := `{"alarms": [{
+		"name": "Hot",
+		"condition": "temperature > 25",
+		"enabled": true,
+		"channels": [{"type": "webhook", "history": {"minutes": 10, "format": "json"},
+			"webhook": {"url": "` + srv.URL + `", "body": "{\"text\": \"{{alarm_name}}\"}"}}]
+	}]}`
+	manager, err := NewManager(config, "TestStation")
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer manager.Stop()
+	manager.SetHistoryProvider(func() []weather.Observation {
+		return []weather.Observation{
+			{Timestamp: 1700000000 - 3600, AirTemperature: 10}, // outside the window
+			{Timestamp: 1700000000 - 300, AirTemperature: 20},
+		}
+	})
+
+	manager.ProcessObservation(&weather.Observation{Timestamp: 1700000000, AirTemperature: 30})
+	var payload struct {
+		Text    string                `json:"text"`
+		History []weather.Observation `json:"history"`
+	}
+	if err := json.Unmarshal([]byte(<-bodies), &payload); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if payload.Text != "Hot" || len(payload.History) != 2 || payload.History[0].AirTemperature != 20 || payload.History[1].AirTemperature != 30 {
+		t.Errorf("payload = %+v, want the last 10 minutes", payload)
+	}
+
+	// Previews name the email attachment
+	alarm := &Alarm{Name: "Hot", Channels: []Channel{{Type: "email", History: &HistoryAttachment{Minutes: 10},
+		Email: &EmailConfig{To: []string{"me@example.com"}, Subject: "Hot", Body: "Hot"}}}}
+	messages := manager.Preview(alarm, &weather.Observation{Timestamp: 1700000000})
+	if len(messages) != 1 || len(messages[0].Attachments) != 1 || messages[0].Attachments[0] != "Hot-history-20231114-2213.csv" {
+		t.Errorf("preview = %+v", messages)
+	}
+	if alarm.Channels[0].recent != nil {
+		t.Error("Preview changed the alarm's channel")
+	}
+}
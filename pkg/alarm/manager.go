@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -28,6 +29,25 @@ type Manager struct {
 	longitude       float64 // Station longitude for sun calculations
 	mu              sync.RWMutex
 	stopChan        chan struct{}
+	firedHandler    func(FiredEvent)            // optional hook invoked after an alarm fires
+	lastObs         *weather.Observation        // most recent observation, reused by ProcessMetrics
+	throttles       map[string]*channelThrottle // quiet hours and rate limit state by alarm channel
+	stats           StatsProvider               // daily statistics for digests
+	fired           []FiredEvent                // recent firings (name and time) for digest counts
+	digestsSent     map[string]time.Time        // last scheduled time handled, by digest name
+	uploadMu        sync.Mutex                  // serializes ProcessUploads
+	uploads         map[string]*uploadState     // upload progress by upload name, guarded by uploadMu
+	suspended       func() bool                 // reports maintenance, during which alarms and uploads do not run
+}
+
+// FiredEvent describes an alarm that fired for an observation
+type FiredEvent struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Condition   string              `json:"condition"`
+	Tags        []string            `json:"tags,omitempty"`
+	Time        time.Time           `json:"time"`
+	Observation weather.Observation `json:"observation"`
 }
 
 // NewManager creates a new alarm manager
@@ -47,6 +67,7 @@ func NewManager(configInput string, stationName string) (*Manager, error) {
 		stopChan:        make(chan struct{}),
 		lastLoadTime:    time.Now(),
 	}
+	applyState(config.Alarms, loadState())
 
 	// If config is from file, set up file watching
 	if strings.HasPrefix(configInput, "@") {
@@ -92,17 +113,23 @@ func validateConfigProviders(config *AlarmConfig) {
 	usesEmail := false
 	usesSMS := false
 
+	var channels []Channel
 	for _, alarm := range config.Alarms {
-		if !alarm.Enabled {
-			continue
+		if alarm.Enabled {
+			channels = append(channels, alarm.Channels...)
 		}
-		for _, channel := range alarm.Channels {
-			switch channel.Type {
-			case "email":
-				usesEmail = true
-			case "sms":
-				usesSMS = true
-			}
+	}
+	for _, digest := range config.Digests {
+		if digest.Enabled {
+			channels = append(channels, digest.Channels...)
+		}
+	}
+	for _, channel := range channels {
+		switch channel.Type {
+		case "email":
+			usesEmail = true
+		case "sms":
+			usesSMS = true
 		}
 	}
 
@@ -151,6 +178,11 @@ func validateConfigProviders(config *AlarmConfig) {
 					logger.Info("⚠️  Microsoft 365 email is configured but missing required environment variables: %s", strings.Join(missing, ", "))
 				}
 			}
+			if dir := config.Email.TemplatesDir; dir != "" {
+				if err := checkEmailTemplates(dir); err != nil {
+					logger.Info("⚠️  EMAIL_TEMPLATES_DIR %s: %v; HTML emails are sent without the theme", dir, err)
+				}
+			}
 		}
 	}
 
@@ -270,7 +302,10 @@ func (m *Manager) reloadConfig() error {
 	}
 
 	// Load credentials from environment variables (ONLY source)
-	envConfig, _ := LoadConfigFromEnv()
+	envConfig, err := LoadConfigFromEnv()
+	if err != nil {
+		return fmt.Errorf("alarm credentials: %w", err)
+	}
 	newConfig.Email = envConfig.Email
 	newConfig.SMS = envConfig.SMS
 	newConfig.Syslog = envConfig.Syslog
@@ -278,8 +313,13 @@ func (m *Manager) reloadConfig() error {
 	if err := newConfig.Validate(); err != nil {
 		return fmt.Errorf("invalid config: %w", err)
 	}
+	if err := checkEncryptedSecrets(&newConfig); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
 
 	m.mu.Lock()
+	// Keep cooldowns and change detection of alarms that are still configured
+	applyState(newConfig.Alarms, captureState(m.config.Alarms))
 	m.config = &newConfig
 	m.notifierFactory = NewNotifierFactory(&newConfig)
 	m.lastLoadTime = time.Now()
@@ -314,20 +354,127 @@ func (m *Manager) reloadConfig() error {
 	return nil
 }
 
+// Reload re-reads the alarm configuration file immediately. It is used by the
+// integrated alarm editor so saved edits take effect without waiting for the
+// file watcher. Inline configurations cannot be reloaded.
+func (m *Manager) Reload() error {
+	m.mu.RLock()
+	path := m.configPath
+	m.mu.RUnlock()
+	if path == "" {
+		return fmt.Errorf("alarm configuration is inline and cannot be reloaded")
+	}
+	return m.reloadConfig()
+}
+
 // ProcessObservation evaluates all alarms against a new weather observation
 func (m *Manager) ProcessObservation(obs *weather.Observation) {
 	if obs == nil {
 		return
 	}
 
+	m.mu.Lock()
+	copied := *obs
+	m.lastObs = &copied
+	m.mu.Unlock()
+
+	m.dispatchFired(m.processObservationLocked(obs, nil))
+}
+
+// ProcessMetrics re-evaluates alarms that reference bridge health fields
+// (data_age, udp_silence, api_errors_rate) against the last observation. Call
+// it periodically so these alarms fire even when no observations arrive.
+func (m *Manager) ProcessMetrics() {
+	m.mu.RLock()
+	obs := m.lastObs
+	m.mu.RUnlock()
+	if obs == nil {
+		obs = &weather.Observation{}
+	}
+
+	onlyMetrics := func(a *Alarm) bool { return usesMetricFields(a.Condition) }
+	m.dispatchFired(m.processObservationLocked(obs, onlyMetrics))
+}
+
+// SetMetricsProvider supplies bridge health values to alarm conditions
+func (m *Manager) SetMetricsProvider(fn MetricsProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.evaluator.SetMetricsProvider(fn)
+}
+
+// SetStatsProvider supplies the daily statistics (GDD, chill hours) to alarm
+// conditions and digests
+func (m *Manager) SetStatsProvider(fn StatsProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = fn
+	m.evaluator.SetStatsProvider(fn)
+}
+
+// SetHistoryProvider supplies the observation history to the delta() and
+// rate() condition fields and the channels that include history
+func (m *Manager) SetHistoryProvider(fn HistoryProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.evaluator.SetHistoryProvider(fn)
+}
+
+// SetSuspended supplies the maintenance check: while fn returns true no alarm
+// fires and no upload runs. They resume on their own when it returns false.
+func (m *Manager) SetSuspended(fn func() bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.suspended = fn
+}
+
+// isSuspended reports maintenance; the caller holds m.mu
+func (m *Manager) isSuspended() bool {
+	return m.suspended != nil && m.suspended()
+}
+
+// SetAnomalyProvider supplies the anomaly(field) scores to alarm conditions
+func (m *Manager) SetAnomalyProvider(fn AnomalyProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.evaluator.SetAnomalyProvider(fn)
+}
+
+func (m *Manager) dispatchFired(fired []FiredEvent) {
+	m.mu.RLock()
+	handler := m.firedHandler
+	m.mu.RUnlock()
+	if handler != nil {
+		for _, ev := range fired {
+			handler(ev)
+		}
+	}
+}
+
+// processObservationLocked evaluates alarms under the manager lock and returns
+// the alarms that fired. A nil filter evaluates every alarm.
+func (m *Manager) processObservationLocked(obs *weather.Observation, filter func(*Alarm) bool) []FiredEvent {
+	var fired []FiredEvent
+
 	// Work with the original alarms directly to preserve state (previousValue map)
 	// We lock for the entire duration to ensure consistent state
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.flushDigests(obs, time.Now())
+
+	if m.isSuspended() {
+		logger.Debug("Maintenance in progress, not evaluating alarms")
+		return nil
+	}
+
 	for i := range m.config.Alarms {
 		alarm := &m.config.Alarms[i]
 
+		if filter != nil && !filter(alarm) {
+			continue
+		}
+
 		if !alarm.Enabled {
 			logger.Debug("Skipping disabled alarm: %s", alarm.Name)
 			continue
@@ -347,6 +494,11 @@ func (m *Manager) ProcessObservation(obs *weather.Observation) {
 			continue
 		}
 
+		if until := alarm.GetSnoozedUntil(); !until.IsZero() {
+			logger.Debug("Alarm %s snoozed until %s, skipping", alarm.Name, until.Format(time.RFC3339))
+			continue
+		}
+
 		logger.Debug("Evaluating alarm: '%s'", alarm.Name)
 		logger.Debug("  Condition: %s", alarm.Condition)
 		logger.Debug("  Current observation: temp=%.1f°C, humidity=%.0f%%, pressure=%.2f, wind=%.1fm/s, lux=%.0f, uv=%d",
@@ -360,13 +512,44 @@ func (m *Manager) ProcessObservation(obs *weather.Observation) {
 		}
 
 		logger.Debug("  Result: %v", triggered)
+		conditionMet := triggered
+
+		// With sustained_for the condition must keep holding before the alarm fires
+		if !alarm.sustained(triggered, obs) {
+			if triggered {
+				logger.Debug("Alarm %s condition met, waiting for sustained_for %s", alarm.Name, alarm.SustainedFor)
+			}
+			triggered = false
+		}
+
+		// An acknowledged alarm stays quiet until its condition clears
+		if alarm.acknowledged {
+			if conditionMet {
+				logger.Debug("Alarm %s acknowledged, not notifying", alarm.Name)
+				triggered = false
+			} else {
+				alarm.acknowledged = false
+			}
+		}
 
 		if triggered {
 			logger.Info("🚨 Alarm triggered: %s (condition: %s)", alarm.Name, alarm.Condition)
-			m.sendNotifications(alarm, obs)
+			alarm.newFiring()
+			m.sendNotifications(alarm, obs, true)
 			// Increment triggered count and mark as fired
 			alarm.TriggeredCount++
 			alarm.MarkFired()
+			alarm.resetSustained()
+			ev := FiredEvent{
+				Name:        alarm.Name,
+				Description: alarm.Description,
+				Condition:   alarm.Condition,
+				Tags:        alarm.Tags,
+				Time:        time.Now(),
+				Observation: *obs,
+			}
+			m.recordFired(ev)
+			fired = append(fired, ev)
 		}
 
 		// Store all sensor values for next evaluation
@@ -383,35 +566,107 @@ func (m *Manager) ProcessObservation(obs *weather.Observation) {
 		alarm.SetPreviousValue("rain_daily", obs.RainDailyTotal)
 		alarm.SetPreviousValue("lightning_count", float64(obs.LightningStrikeCount))
 	}
+	m.saveStateLocked()
+	return fired
+}
+
+// SetFiredHandler registers a function called (outside the manager lock) for
+// every alarm that fires. Pass nil to remove it.
+func (m *Manager) SetFiredHandler(fn func(FiredEvent)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.firedHandler = fn
 }
 
-// sendNotifications sends notifications through all configured channels for an alarm
-func (m *Manager) sendNotifications(alarm *Alarm, obs *weather.Observation) {
+// sendNotifications sends notifications through all configured channels for an
+// alarm and returns the delivery errors, which are also logged. With throttle
+// set (callers hold m.mu), channels in quiet hours or over their hourly limit
+// hold the notification for a later digest instead.
+func (m *Manager) sendNotifications(alarm *Alarm, obs *weather.Observation, throttle bool) []error {
+	var errs []error
 	logger.Debug("Sending notifications for alarm '%s' through %d channels", alarm.Name, len(alarm.Channels))
 	for i := range alarm.Channels {
 		channel := &alarm.Channels[i]
 		logger.Debug("Processing channel %d: type=%s", i, channel.Type)
 
+		if throttle && channel.throttled() {
+			now := time.Now()
+			t := m.throttleFor(throttleKey(alarm, i))
+			if reason := t.holdReason(channel, now); reason != "" {
+				logger.Info("Holding %s notification for alarm %s (%s)", channel.Type, alarm.Name, reason)
+				t.hold(digestLine(alarm, obs, now), reason)
+				continue
+			}
+			t.sent = append(t.sent, now)
+		}
+
 		notifier, err := m.notifierFactory.GetNotifier(channel.Type)
 		if err != nil {
 			logger.Error("Failed to get notifier for %s: %v", channel.Type, err)
+			errs = append(errs, fmt.Errorf("%s: %w", channel.Type, err))
 			continue
 		}
 
+		if channel.History != nil {
+			channel = m.withHistory(channel, obs)
+		}
+
 		logger.Debug("Attempting to send %s notification for alarm %s", channel.Type, alarm.Name)
 		if err := notifier.Send(alarm, channel, obs, m.stationName); err != nil {
 			logger.Error("Failed to send %s notification for alarm %s: %v",
 				channel.Type, alarm.Name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", channel.Type, err))
 		} else {
 			logger.Info("Sent %s notification for alarm %s", channel.Type, alarm.Name)
 		}
 	}
 	logger.Debug("Finished sending notifications for alarm '%s'", alarm.Name)
+	return errs
 }
 
-// Stop stops the alarm manager and file watcher
+// withHistory returns a copy of channel holding the observations its
+// History includes with a notification for obs
+func (m *Manager) withHistory(channel *Channel, obs *weather.Observation) *Channel {
+	var history []weather.Observation
+	if m.evaluator.history != nil {
+		history = m.evaluator.history()
+	}
+	withHistory := *channel
+	withHistory.recent = recentHistory(history, obs, channel.History.Minutes)
+	return &withHistory
+}
+
+// TriggerTest sends notifications for the named alarm through all of its
+// channels using obs, ignoring the alarm's condition, schedule, and cooldown.
+// It returns the number of channels attempted and any delivery errors.
+func (m *Manager) TriggerTest(name string, obs *weather.Observation) (int, []error, error) {
+	if obs == nil {
+		return 0, nil, fmt.Errorf("no observation available")
+	}
+	m.mu.RLock()
+	var target *Alarm
+	for i := range m.config.Alarms {
+		if m.config.Alarms[i].Name == name {
+			a := m.config.Alarms[i]
+			target = &a
+			break
+		}
+	}
+	m.mu.RUnlock()
+	if target == nil {
+		return 0, nil, fmt.Errorf("alarm %q not found", name)
+	}
+
+	logger.Info("Sending test notification for alarm %s", name)
+	return len(target.Channels), m.sendNotifications(target, obs, false), nil
+}
+
+// Stop stops the alarm manager and file watcher and saves the alarm state
 func (m *Manager) Stop() {
 	close(m.stopChan)
+	m.mu.Lock()
+	m.saveStateLocked()
+	m.mu.Unlock()
 	if m.watcher != nil {
 		if err := m.watcher.Close(); err != nil {
 			logger.Debug("failed to close watcher: %v", err)
@@ -430,6 +685,34 @@ func (m *Manager) GetConfig() *AlarmConfig {
 	return &configCopy
 }
 
+// GetLastObservation returns a copy of the most recent observation, or nil
+// before the first one arrives
+func (m *Manager) GetLastObservation() *weather.Observation {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.lastObs == nil {
+		return nil
+	}
+	copied := *m.lastObs
+	return &copied
+}
+
+// Preview renders the message every channel of alarm would send for obs,
+// with this station's name and email theme; see PreviewMessages
+func (m *Manager) Preview(alarm *Alarm, obs *weather.Observation) []SimulatedMessage {
+	m.mu.RLock()
+	email := m.config.Email
+	m.mu.RUnlock()
+	a := *alarm
+	a.Channels = slices.Clone(alarm.Channels)
+	for i := range a.Channels {
+		if a.Channels[i].History != nil {
+			a.Channels[i] = *m.withHistory(&a.Channels[i], obs)
+		}
+	}
+	return previewMessages(&a, obs, m.stationName, email)
+}
+
 // GetAlarmCount returns the number of configured alarms
 func (m *Manager) GetAlarmCount() int {
 	m.mu.RLock()
@@ -468,12 +751,14 @@ func (m *Manager) GetLastLoadTime() time.Time {
 	return m.lastLoadTime
 }
 
-// SetLocation sets the geographic location for sunrise/sunset calculations in schedules
+// SetLocation sets the geographic location for sunrise/sunset calculations in
+// schedules and the sun condition fields
 func (m *Manager) SetLocation(latitude, longitude float64) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.latitude = latitude
 	m.longitude = longitude
+	m.evaluator.SetLocation(latitude, longitude)
 	logger.Debug("Alarm manager location set to: lat=%.4f, lon=%.4f", latitude, longitude)
 }
 
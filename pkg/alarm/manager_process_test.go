@@ -286,3 +286,80 @@ func TestManager_GetConfigPath_Inline(t *testing.T) {
 		t.Errorf("Expected 'Inline configuration', got '%s'", path)
 	}
 }
+
+func TestManager_ProcessObservation_FiredHandler(t *testing.T) {
+	config := `{"alarms": [{"name": "Hot", "condition": "temperature > 25", "enabled": true,
+		"channels": [{"type": "console", "template": "hot"}]}]}`
+
+	manager, err := NewManager(config, "TestStation")
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer manager.Stop()
+
+	var fired []FiredEvent
+	manager.SetFiredHandler(func(ev FiredEvent) {
+		// Handler runs outside the manager lock, so reading state must not deadlock
+		_ = manager.GetAlarmCount()
+		fired = append(fired, ev)
+	})
+
+	manager.ProcessObservation(&weather.Observation{AirTemperature: 20})
+	if len(fired) != 0 {
+		t.Fatalf("expected no events below threshold, got %d", len(fired))
+	}
+
+	manager.ProcessObservation(&weather.Observation{AirTemperature: 30})
+	if len(fired) != 1 || fired[0].Name != "Hot" || fired[0].Observation.AirTemperature != 30 {
+		t.Errorf("unexpected fired events: %+v", fired)
+	}
+}
+
+func TestManager_ProcessObservation_Suspended(t *testing.T) {
+	config := `{"alarms": [{"name": "Hot", "condition": "temperature > 25", "enabled": true,
+		"channels": [{"type": "console", "template": "hot"}]}]}`
+
+	manager, err := NewManager(config, "TestStation")
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer manager.Stop()
+
+	var fired []FiredEvent
+	manager.SetFiredHandler(func(ev FiredEvent) { fired = append(fired, ev) })
+	maintenance := true
+	manager.SetSuspended(func() bool { return maintenance })
+
+	manager.ProcessObservation(&weather.Observation{AirTemperature: 30})
+	if len(fired) != 0 {
+		t.Fatalf("expected no events during maintenance, got %d", len(fired))
+	}
+
+	// Alarms resume when maintenance ends
+	maintenance = false
+	manager.ProcessObservation(&weather.Observation{AirTemperature: 30})
+	if len(fired) != 1 {
+		t.Errorf("expected the alarm to fire after maintenance, got %d events", len(fired))
+	}
+}
+
+func TestManager_TriggerTest(t *testing.T) {
+	cfg := `{"alarms":[{"name":"hot","condition":"temperature > 100","enabled":true,"channels":[{"type":"console","template":"test {{alarm_name}}"}]}]}`
+	m, err := NewManager(cfg, "Test")
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	defer m.Stop()
+
+	obs := &weather.Observation{AirTemperature: 20}
+	attempted, errs, err := m.TriggerTest("hot", obs)
+	if err != nil || attempted != 1 || len(errs) != 0 {
+		t.Errorf("expected one successful channel, got %d %v %v", attempted, errs, err)
+	}
+	if _, _, err := m.TriggerTest("missing", obs); err == nil {
+		t.Error("expected error for unknown alarm")
+	}
+	if m.GetConfig().Alarms[0].TriggeredCount != 0 {
+		t.Error("test notifications must not count as triggers")
+	}
+}
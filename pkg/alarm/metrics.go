@@ -0,0 +1,116 @@
+package alarm
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// BridgeMetrics are internal health values that alarm conditions can reference
+// alongside weather fields, so an alarm can report the station going offline or
+// the API token expiring.
+type BridgeMetrics struct {
+	LastObservation   time.Time // when the bridge last received an observation
+	LastUDPPacket     time.Time // zero when not listening for UDP broadcasts
+	APIErrorsLastHour int       // failed WeatherFlow API requests in the last hour
+	TokenInvalid      bool      // the last token check was rejected by the API
+	TokenRateLimited  bool      // the last token check was rate limited
+}
+
+// MetricsProvider returns the current bridge metrics
+type MetricsProvider func() BridgeMetrics
+
+// metricFields are the condition fields computed from BridgeMetrics rather
+// than the observation. Alarms using them are also evaluated on a timer (see
+// Manager.ProcessMetrics) because observations stop arriving when the station
+// goes offline.
+var metricFields = map[string]bool{
+	"data_age":           true,
+	"udp_silence":        true,
+	"api_errors_rate":    true,
+	"token_invalid":      true,
+	"token_rate_limited": true,
+}
+
+// durationFields take a duration value ("10m", "90s", "1h"); plain numbers are seconds
+var durationFields = map[string]bool{
+	"data_age":    true,
+	"udp_silence": true,
+}
+
+var conditionFieldPattern = regexp.MustCompile(`[a-zA-Z_]+`)
+
+// usesMetricFields reports whether a condition references any metric field
+func usesMetricFields(condition string) bool {
+	for _, word := range conditionFieldPattern.FindAllString(condition, -1) {
+		if metricFields[strings.ToLower(word)] {
+			return true
+		}
+	}
+	return false
+}
+
+// SetMetricsProvider supplies the bridge metrics used by data_age, udp_silence
+// and api_errors_rate. Without a provider data_age is derived from the
+// observation timestamp and the other metrics read as 0.
+func (e *Evaluator) SetMetricsProvider(fn MetricsProvider) {
+	e.metrics = fn
+}
+
+// getMetricValue resolves a metric field in its base unit (seconds or errors/hour)
+func (e *Evaluator) getMetricValue(field string, obs *weather.Observation) (float64, error) {
+	var m BridgeMetrics
+	if e.metrics != nil {
+		m = e.metrics()
+	}
+	now := time.Now()
+
+	switch field {
+	case "data_age":
+		last := m.LastObservation
+		if last.IsZero() && obs != nil && obs.Timestamp > 0 {
+			last = time.Unix(obs.Timestamp, 0)
+		}
+		if last.IsZero() {
+			return 0, fmt.Errorf("data_age unavailable: no observation time")
+		}
+		return now.Sub(last).Seconds(), nil
+	case "udp_silence":
+		if m.LastUDPPacket.IsZero() {
+			return 0, nil
+		}
+		return now.Sub(m.LastUDPPacket).Seconds(), nil
+	case "api_errors_rate":
+		return float64(m.APIErrorsLastHour), nil
+	case "token_invalid":
+		return boolMetric(m.TokenInvalid), nil
+	case "token_rate_limited":
+		return boolMetric(m.TokenRateLimited), nil
+	}
+	return 0, fmt.Errorf("unknown field: %s", field)
+}
+
+// boolMetric maps a flag to 1 or 0 so conditions can test it, e.g. token_invalid == 1
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// parseDurationSeconds parses "10m", "90s", "1h30m" or a plain number of seconds
+func parseDurationSeconds(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f, nil
+	}
+	d, err := time.ParseDuration(strings.ToLower(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q (use e.g. 90s, 10m, 1h)", value)
+	}
+	return d.Seconds(), nil
+}
@@ -0,0 +1,139 @@
+package alarm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestEvaluator_MetricFields(t *testing.T) {
+	now := time.Now()
+	e := NewEvaluator()
+	e.SetMetricsProvider(func() BridgeMetrics {
+		return BridgeMetrics{
+			LastObservation:   now.Add(-11 * time.Minute),
+			LastUDPPacket:     now.Add(-2 * time.Minute),
+			APIErrorsLastHour: 4,
+			TokenInvalid:      true,
+		}
+	})
+	obs := &weather.Observation{Timestamp: now.Unix(), Battery: 2.35}
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"data_age > 10m", true},
+		{"data_age > 15m", false},
+		{"data_age > 600", true},
+		{"udp_silence > 5m", false},
+		{"udp_silence > 90s", true},
+		{"api_errors_rate >= 3", true},
+		{"api_errors_rate > 3/h", true},
+		{"api_errors_rate > 10", false},
+		{"battery < 2.4", true},
+		{"battery < 2.4V", true},
+		{"battery < 2.3V", false},
+		{"data_age > 10m || temperature > 40", true},
+		{"token_invalid == 1", true},
+		{"token_rate_limited == 1", false},
+	}
+	for _, tt := range tests {
+		got, err := e.Evaluate(tt.condition, obs)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.condition, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.condition, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluator_MetricFieldsWithoutProvider(t *testing.T) {
+	e := NewEvaluator()
+	obs := &weather.Observation{Timestamp: time.Now().Add(-20 * time.Minute).Unix()}
+
+	got, err := e.Evaluate("data_age > 10m", obs)
+	if err != nil || !got {
+		t.Errorf("data_age from observation timestamp: got %v, %v; want true", got, err)
+	}
+	got, err = e.Evaluate("udp_silence > 1m", obs)
+	if err != nil || got {
+		t.Errorf("udp_silence without UDP: got %v, %v; want false", got, err)
+	}
+	if _, err := e.Evaluate("data_age > 10m", &weather.Observation{}); err == nil {
+		t.Error("expected error for data_age with no observation time")
+	}
+	if _, err := e.Evaluate("data_age > soon", obs); err == nil {
+		t.Error("expected error for invalid duration")
+	}
+}
+
+func TestUsesMetricFields(t *testing.T) {
+	cases := map[string]bool{
+		"data_age > 10m":                       true,
+		"temperature > 30 && udp_silence > 5m": true,
+		"API_ERRORS_RATE > 3":                  true,
+		"temperature > 30":                     false,
+		"battery < 2.4":                        false,
+	}
+	for cond, want := range cases {
+		if got := usesMetricFields(cond); got != want {
+			t.Errorf("usesMetricFields(%q) = %v, want %v", cond, got, want)
+		}
+	}
+}
+
+func TestManager_ProcessMetrics(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "alarms.json")
+	config := `{
+		"alarms": [
+			{
+				"name": "Station offline",
+				"condition": "data_age > 10m",
+				"enabled": true,
+				"channels": [{"type": "console", "template": "offline"}]
+			},
+			{
+				"name": "Hot",
+				"condition": "temperature > 30",
+				"enabled": true,
+				"channels": [{"type": "console", "template": "hot"}]
+			}
+		]
+	}`
+	if err := os.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	manager, err := NewManager("@"+configFile, "TestStation")
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer manager.Stop()
+
+	var fired []string
+	manager.SetFiredHandler(func(ev FiredEvent) { fired = append(fired, ev.Name) })
+
+	last := time.Now()
+	manager.SetMetricsProvider(func() BridgeMetrics { return BridgeMetrics{LastObservation: last} })
+
+	// A hot observation fires only the weather alarm
+	manager.ProcessObservation(&weather.Observation{Timestamp: last.Unix(), AirTemperature: 35})
+	if len(fired) != 1 || fired[0] != "Hot" {
+		t.Fatalf("after observation fired %v, want [Hot]", fired)
+	}
+
+	// Observations stop; the timer tick fires the offline alarm without
+	// re-evaluating the weather alarm
+	last = time.Now().Add(-15 * time.Minute)
+	fired = nil
+	manager.ProcessMetrics()
+	if len(fired) != 1 || fired[0] != "Station offline" {
+		t.Fatalf("after metrics tick fired %v, want [Station offline]", fired)
+	}
+}
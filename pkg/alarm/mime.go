@@ -0,0 +1,130 @@
+package alarm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+)
+
+// emailAttachment is a file attached to an alarm email
+type emailAttachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// mimePart is one part of an email body. Text is quoted-printable and
+// attachments base64, so no line exceeds the 998 characters RFC 5322 allows;
+// multipart content is already encoded.
+type mimePart struct {
+	contentType string
+	encoding    string // Content-Transfer-Encoding, "" for multipart content
+	disposition string // Content-Disposition, "" for inline parts
+	content     []byte
+}
+
+// textPart is a quoted-printable text part
+func textPart(contentType, text string) mimePart {
+	return mimePart{contentType: contentType, encoding: "quoted-printable", content: []byte(text)}
+}
+
+// attachmentPart is a base64 part holding the file a
+func attachmentPart(a *emailAttachment) mimePart {
+	return mimePart{
+		contentType: a.ContentType,
+		encoding:    "base64",
+		disposition: mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}),
+		content:     a.Data,
+	}
+}
+
+// multipartOf combines parts into a multipart/<subtype> part
+func multipartOf(subtype string, parts ...mimePart) (mimePart, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range parts {
+		header := textproto.MIMEHeader{"Content-Type": {part.contentType}}
+		if part.encoding != "" {
+			header.Set("Content-Transfer-Encoding", part.encoding)
+		}
+		if part.disposition != "" {
+			header.Set("Content-Disposition", part.disposition)
+		}
+		w, err := mw.CreatePart(header)
+		if err != nil {
+			return mimePart{}, err
+		}
+		if err := part.encode(w); err != nil {
+			return mimePart{}, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return mimePart{}, err
+	}
+	return mimePart{
+		contentType: fmt.Sprintf("multipart/%s; boundary=%q", subtype, mw.Boundary()),
+		content:     body.Bytes(),
+	}, nil
+}
+
+// encode writes the content of p in its transfer encoding
+func (p mimePart) encode(w io.Writer) error {
+	switch p.encoding {
+	case "quoted-printable":
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write(p.content); err != nil {
+			return err
+		}
+		return qp.Close()
+	case "base64":
+		encoded := base64.StdEncoding.EncodeToString(p.content)
+		for len(encoded) > 76 {
+			if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+				return err
+			}
+			encoded = encoded[76:]
+		}
+		_, err := io.WriteString(w, encoded)
+		return err
+	default:
+		_, err := w.Write(p.content)
+		return err
+	}
+}
+
+// writeMIME ends the message headers with the MIME headers of body and
+// writes it, in multipart/mixed with attachment when there is one
+func writeMIME(msg *strings.Builder, body mimePart, attachment *emailAttachment) error {
+	if attachment != nil {
+		var err error
+		if body, err = multipartOf("mixed", body, attachmentPart(attachment)); err != nil {
+			return err
+		}
+	}
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString(fmt.Sprintf("Content-Type: %s\r\n", body.contentType))
+	if body.encoding != "" {
+		msg.WriteString(fmt.Sprintf("Content-Transfer-Encoding: %s\r\n", body.encoding))
+	}
+	msg.WriteString("\r\n")
+	var encoded strings.Builder
+	if err := body.encode(&encoded); err != nil {
+		return err
+	}
+	msg.WriteString(encoded.String())
+	return nil
+}
+
+// alternativePart is a multipart/alternative part with a plain text and an
+// HTML version of a message
+func alternativePart(text, htmlBody string) (mimePart, error) {
+	return multipartOf("alternative",
+		textPart("text/plain; charset=UTF-8", text),
+		textPart("text/html; charset=UTF-8", htmlBody))
+}
@@ -0,0 +1,92 @@
+package alarm
+
+import (
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// readParts returns "Content-Type: content" for every part of a multipart
+// body, descending into nested multiparts
+func readParts(t *testing.T, contentType string, body io.Reader) []string {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		t.Fatalf("Content-Type = %q, %v", contentType, err)
+	}
+	var parts []string
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextPart() // decodes quoted-printable
+		if err == io.EOF {
+			return parts
+		} else if err != nil {
+			t.Fatalf("NextPart: %v", err)
+		}
+		partType := part.Header.Get("Content-Type")
+		if strings.HasPrefix(partType, "multipart/") {
+			parts = append(parts, readParts(t, partType, part)...)
+			continue
+		}
+		content, _ := io.ReadAll(part)
+		if part.Header.Get("Content-Transfer-Encoding") == "base64" {
+			content, _ = io.ReadAll(base64.NewDecoder(base64.StdEncoding, strings.NewReader(string(content))))
+			partType += "; " + part.Header.Get("Content-Disposition")
+		}
+		parts = append(parts, partType+": "+string(content))
+	}
+}
+
+func TestWriteMIME(t *testing.T) {
+	long := "<p>" + strings.Repeat("é", 1200) + "</p>"
+	csv := strings.Repeat("2024-01-01T00:00:00Z,35\n", 100)
+	tests := []struct {
+		name       string
+		attachment *emailAttachment
+		mediaType  string
+		want       []string
+	}{
+		{"alternative", nil, "multipart/alternative", []string{
+			"text/plain; charset=UTF-8: It is hot",
+			"text/html; charset=UTF-8: " + long,
+		}},
+		{"with attachment", &emailAttachment{Name: "Hot-history.csv", ContentType: "text/csv; charset=UTF-8", Data: []byte(csv)}, "multipart/mixed", []string{
+			"text/plain; charset=UTF-8: It is hot",
+			"text/html; charset=UTF-8: " + long,
+			"text/csv; charset=UTF-8; attachment; filename=Hot-history.csv: " + csv,
+		}},
+	}
+	for _, tt := range tests {
+		alternative, err := alternativePart("It is hot", long)
+		if err != nil {
+			t.Fatalf("%s: alternativePart: %v", tt.name, err)
+		}
+		var msg strings.Builder
+		msg.WriteString("Subject: Hot\r\n")
+		if err := writeMIME(&msg, alternative, tt.attachment); err != nil {
+			t.Fatalf("%s: writeMIME: %v", tt.name, err)
+		}
+		for _, line := range strings.Split(msg.String(), "\r\n") {
+			if len(line) > 998 {
+				t.Fatalf("%s: line of %d characters", tt.name, len(line))
+			}
+		}
+
+		parsed, err := mail.ReadMessage(strings.NewReader(msg.String()))
+		if err != nil {
+			t.Fatalf("%s: ReadMessage: %v", tt.name, err)
+		}
+		contentType := parsed.Header.Get("Content-Type")
+		if !strings.HasPrefix(contentType, tt.mediaType+";") {
+			t.Errorf("%s: Content-Type = %q", tt.name, contentType)
+		}
+		parts := readParts(t, contentType, parsed.Body)
+		if strings.Join(parts, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: parts = %q", tt.name, parts)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+//go:build !windows
+// +build !windows
+
+package alarm
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func (n *SyslogNotifier) Send(alarm *Alarm, channel *Channel, obs *weather.Observation, stationName string) error {
+	message := expandTemplate(channel.Template, alarm, obs, stationName)
+
+	var priority syslog.Priority
+	if n.config != nil {
+		switch strings.ToLower(n.config.Priority) {
+		case "error":
+			priority = syslog.LOG_ERR
+		case "warning":
+			priority = syslog.LOG_WARNING
+		case "info":
+			priority = syslog.LOG_INFO
+		default:
+			priority = syslog.LOG_WARNING
+		}
+	} else {
+		priority = syslog.LOG_WARNING
+	}
+
+	var writer *syslog.Writer
+	var err error
+
+	if n.config != nil && n.config.Network != "" && n.config.Address != "" {
+		writer, err = syslog.Dial(n.config.Network, n.config.Address, priority, n.config.Tag)
+	} else {
+		writer, err = syslog.New(priority, "tempest-weather")
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	return writer.Warning(message)
+}
+
+func (n *EventLogNotifier) Send(alarm *Alarm, channel *Channel, obs *weather.Observation, stationName string) error {
+	message := expandTemplate(channel.Template, alarm, obs, stationName)
+
+	// Outside Windows the system log is syslog
+	writer, err := syslog.New(syslog.LOG_WARNING, "tempest-weather")
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	return writer.Warning(message)
+}
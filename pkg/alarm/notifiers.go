@@ -7,7 +7,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log/syslog"
 	"net/http"
 	"net/smtp"
 	"net/url"
@@ -71,6 +70,9 @@ func (f *NotifierFactory) GetNotifier(channelType string) (Notifier, error) {
 	case "json":
 		return &JSONNotifier{}, nil
 	default:
+		if path, ok := lookupPlugin(channelType); ok {
+			return &PluginNotifier{Name: channelType, Path: path}, nil
+		}
 		return nil, fmt.Errorf("unsupported notifier type: %s", channelType)
 	}
 }
@@ -84,69 +86,15 @@ func (n *ConsoleNotifier) Send(alarm *Alarm, channel *Channel, obs *weather.Obse
 	return nil
 }
 
-// SyslogNotifier sends notifications to syslog
+// SyslogNotifier sends notifications to syslog. Windows has no syslog, so
+// there it reports an error (see notifier_syslog_*.go).
 type SyslogNotifier struct {
 	config *SyslogConfig
 }
 
-func (n *SyslogNotifier) Send(alarm *Alarm, channel *Channel, obs *weather.Observation, stationName string) error {
-	message := expandTemplate(channel.Template, alarm, obs, stationName)
-
-	var priority syslog.Priority
-	if n.config != nil {
-		switch strings.ToLower(n.config.Priority) {
-		case "error":
-			priority = syslog.LOG_ERR
-		case "warning":
-			priority = syslog.LOG_WARNING
-		case "info":
-			priority = syslog.LOG_INFO
-		default:
-			priority = syslog.LOG_WARNING
-		}
-	} else {
-		priority = syslog.LOG_WARNING
-	}
-
-	var writer *syslog.Writer
-	var err error
-
-	if n.config != nil && n.config.Network != "" && n.config.Address != "" {
-		writer, err = syslog.Dial(n.config.Network, n.config.Address, priority, n.config.Tag)
-	} else {
-		writer, err = syslog.New(priority, "tempest-weather")
-	}
-
-	if err != nil {
-		return fmt.Errorf("failed to connect to syslog: %w", err)
-	}
-	defer func() { _ = writer.Close() }()
-
-	return writer.Warning(message)
-}
-
 // EventLogNotifier sends notifications to system event log (Windows) or syslog (Unix)
 type EventLogNotifier struct{}
 
-func (n *EventLogNotifier) Send(alarm *Alarm, channel *Channel, obs *weather.Observation, stationName string) error {
-	message := expandTemplate(channel.Template, alarm, obs, stationName)
-
-	if runtime.GOOS == "windows" {
-		// On Windows, use event log (simplified - would need golang.org/x/sys/windows for full implementation)
-		logger.Info("[EventLog] %s", message)
-		return nil
-	}
-
-	// On Unix systems, fall back to syslog
-	writer, err := syslog.New(syslog.LOG_WARNING, "tempest-weather")
-	if err != nil {
-		return fmt.Errorf("failed to connect to syslog: %w", err)
-	}
-	defer func() { _ = writer.Close() }()
-
-	return writer.Warning(message)
-}
-
 // EmailNotifier sends email notifications
 type EmailNotifier struct {
 	config *EmailGlobalConfig
@@ -169,9 +117,20 @@ func (n *EmailNotifier) Send(alarm *Alarm, channel *Channel, obs *weather.Observ
 	}
 	body := expandTemplate(bodyTemplate, alarm, obs, stationName)
 
-	// Prepend recipient information to body for better context
-	toList := strings.Join(channel.Email.To, ", ")
-	body = fmt.Sprintf("To: %s\n\n%s", toList, body)
+	// HTML emails take the branded layout of EMAIL_TEMPLATES_DIR
+	themed := ""
+	if channel.Email.Html && n.config.TemplatesDir != "" {
+		var err error
+		if themed, err = themeEmail(n.config.TemplatesDir, channel.Email, subject, bodyTemplate, alarm, obs, stationName); err != nil {
+			logger.Warn("Email theme not applied to alarm %s: %v", alarm.Name, err)
+		}
+	}
+
+	if themed == "" {
+		// Prepend recipient information to body for better context
+		toList := strings.Join(channel.Email.To, ", ")
+		body = fmt.Sprintf("To: %s\n\n%s", toList, body)
+	}
 
 	// Build email message
 	from := n.config.FromAddress
@@ -187,16 +146,48 @@ func (n *EmailNotifier) Send(alarm *Alarm, channel *Channel, obs *weather.Observ
 	}
 	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
 
+	// The observations leading up to the alarm are attached as a file
+	var attachment *emailAttachment
+	if channel.History != nil {
+		var err error
+		if attachment, err = channel.History.attachment(alarm, obs, channel.historyFor(obs)); err != nil {
+			return fmt.Errorf("failed to build history attachment: %w", err)
+		}
+	}
+
 	// Set content type based on Html flag
-	if channel.Email.Html {
-		msg.WriteString("MIME-Version: 1.0\r\n")
-		msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	if themed != "" {
+		text := body
+		if isHTMLTemplate(bodyTemplate) {
+			text = htmlToText(body)
+		}
+		alternative, err := alternativePart(text, themed)
+		if err == nil {
+			err = writeMIME(&msg, alternative, attachment)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to build email: %w", err)
+		}
+		body = themed
+	} else if attachment != nil {
+		contentType := "text/plain; charset=UTF-8"
+		if channel.Email.Html {
+			contentType = "text/html; charset=UTF-8"
+		}
+		if err := writeMIME(&msg, textPart(contentType, body), attachment); err != nil {
+			return fmt.Errorf("failed to build email: %w", err)
+		}
 	} else {
-		msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
-	}
+		if channel.Email.Html {
+			msg.WriteString("MIME-Version: 1.0\r\n")
+			msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+		} else {
+			msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		}
 
-	msg.WriteString("\r\n")
-	msg.WriteString(body)
+		msg.WriteString("\r\n")
+		msg.WriteString(body)
+	}
 
 	// Prepare recipients list
 	recipients := append([]string{}, channel.Email.To...)
@@ -209,7 +200,7 @@ func (n *EmailNotifier) Send(alarm *Alarm, channel *Channel, obs *weather.Observ
 		return n.sendSMTP(recipients, []byte(msg.String()))
 	case "microsoft365", "o365", "exchange":
 		if n.config.UseOAuth2 {
-			return n.sendMicrosoft365(channel.Email, subject, body)
+			return n.sendMicrosoft365(channel.Email, subject, body, attachment)
 		}
 		// Fall back to SMTP for M365 without OAuth2
 		logger.Info("Microsoft 365 OAuth2 not configured, using SMTP for Exchange")
@@ -330,7 +321,7 @@ func (n *EmailNotifier) sendSMTP(to []string, msg []byte) error {
 	return smtp.SendMail(addr, auth, n.config.FromAddress, to, msg)
 }
 
-func (n *EmailNotifier) sendMicrosoft365(emailConfig *EmailConfig, subject, body string) error {
+func (n *EmailNotifier) sendMicrosoft365(emailConfig *EmailConfig, subject, body string, attachment *emailAttachment) error {
 	// Get credentials from environment (expand environment variables)
 	clientID := os.ExpandEnv(n.config.ClientID)
 	clientSecret := os.ExpandEnv(n.config.ClientSecret)
@@ -377,6 +368,15 @@ func (n *EmailNotifier) sendMicrosoft365(emailConfig *EmailConfig, subject, body
 	bodyContent.SetContent(&body)
 	message.SetBody(bodyContent)
 
+	// Attach the alarm history
+	if attachment != nil {
+		file := models.NewFileAttachment()
+		file.SetName(&attachment.Name)
+		file.SetContentType(&attachment.ContentType)
+		file.SetContentBytes(attachment.Data)
+		message.SetAttachments([]models.Attachmentable{file})
+	}
+
 	// Set recipients
 	toRecipients := make([]models.Recipientable, 0, len(emailConfig.To))
 	for _, addr := range emailConfig.To {
@@ -635,26 +635,17 @@ func (n *WebhookNotifier) Send(alarm *Alarm, channel *Channel, obs *weather.Obse
 
 	// Expand the body template
 	body := expandTemplate(channel.Webhook.Body, alarm, obs, stationName)
-
-	// Create HTTP request
-	req, err := http.NewRequest(channel.Webhook.Method, channel.Webhook.URL, strings.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("failed to create webhook request: %w", err)
-	}
-
-	// Set content type
-	req.Header.Set("Content-Type", channel.Webhook.ContentType)
-
-	// Set custom headers
-	for key, value := range channel.Webhook.Headers {
-		req.Header.Set(key, value)
+	if channel.History != nil {
+		var err error
+		if body, err = channel.History.addToPayload(body, channel.historyFor(obs)); err != nil {
+			logger.Warn("History not added to webhook for alarm %s: %v", alarm.Name, err)
+		}
 	}
 
-	// Send request
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	// Send with the channel's signature, token and client certificate
+	resp, err := sendWebhook(channel.Webhook, []byte(body))
 	if err != nil {
-		return fmt.Errorf("failed to send webhook request: %w", err)
+		return err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -676,10 +667,19 @@ func (n *CSVNotifier) Send(alarm *Alarm, channel *Channel, obs *weather.Observat
 		return fmt.Errorf("CSV configuration missing for channel")
 	}
 
+	// Configured columns are written as they are, with a header that must match
+	if len(channel.CSV.Columns) > 0 {
+		header, row := renderFileColumns(channel.CSV.Columns, alarm, obs, stationName)
+		if !channel.CSV.NoHeader {
+			rotateOnHeaderChange(channel.CSV, header)
+		}
+		return n.appendRow(channel.CSV, header, row)
+	}
+
 	// Expand the message template
-	message := expandTemplate(channel.CSV.Message, alarm, obs, stationName)
+	message := expandExportTemplate(channel.CSV.Message, alarm, obs, stationName)
 
-	return n.appendToCSVFile(channel.CSV.Path, message, channel.CSV.MaxDays)
+	return n.appendToCSVFile(channel.CSV, message)
 }
 
 // JSONNotifier writes alarm notifications to JSON files
@@ -690,17 +690,50 @@ func (n *JSONNotifier) Send(alarm *Alarm, channel *Channel, obs *weather.Observa
 		return fmt.Errorf("JSON configuration missing for channel")
 	}
 
+	// Configured fields make up the whole record
+	if len(channel.JSON.Fields) > 0 {
+		record, err := fieldsRecord(renderFileColumns(channel.JSON.Fields, alarm, obs, stationName))
+		if err != nil {
+			return err
+		}
+		return n.appendRecord(channel.JSON, record)
+	}
+
 	// Expand the message template
-	message := expandTemplate(channel.JSON.Message, alarm, obs, stationName)
+	message := expandExportTemplate(channel.JSON.Message, alarm, obs, stationName)
 
-	return n.appendToJSONFile(channel.JSON.Path, message, channel.JSON.MaxDays)
+	return n.appendToJSONFile(channel.JSON, message)
+}
+
+// appendToCSVFile appends a message to a CSV file with rotation, prefixed
+// with the write time
+func (n *CSVNotifier) appendToCSVFile(cfg *CSVConfig, message string) error {
+	timestamp := formatFileTime(time.Now(), cfg.TimeFormat)
+
+	// Check if message contains commas (multi-column format)
+	if strings.Contains(message, ",") {
+		headers := []string{"timestamp", "alarm_name", "alarm_description", "temperature", "humidity", "pressure", "wind_speed", "lux", "uv", "rain_daily", "message"}
+		// Parse the message as CSV to handle quoted fields properly
+		reader := csv.NewReader(strings.NewReader(message))
+		fields, err := reader.Read()
+		if err != nil {
+			// If parsing fails, treat as single field
+			return n.appendRow(cfg, headers, []string{timestamp, message})
+		}
+		// Prepend timestamp to the fields
+		return n.appendRow(cfg, headers, append([]string{timestamp}, fields...))
+	}
+	return n.appendRow(cfg, []string{"timestamp", "message"}, []string{timestamp, message})
 }
 
-// appendToCSVFile appends a message to a CSV file with rotation
-func (n *CSVNotifier) appendToCSVFile(filePath string, message string, maxDays int) error {
+// appendRow appends a row to a CSV file with rotation, writing the header
+// first when the file is new
+func (n *CSVNotifier) appendRow(cfg *CSVConfig, headers, row []string) error {
+	filePath := cfg.Path
+
 	// Check if file needs rotation
-	if maxDays > 0 {
-		if err := rotateFileIfNeeded(filePath, maxDays); err != nil {
+	if cfg.MaxDays > 0 {
+		if err := rotateFileIfNeeded(filePath, cfg.MaxDays, cfg.Compress); err != nil {
 			logger.Warn("Failed to rotate CSV file %s: %v", filePath, err)
 		}
 	}
@@ -725,96 +758,64 @@ func (n *CSVNotifier) appendToCSVFile(filePath string, message string, maxDays i
 		return fmt.Errorf("failed to stat file: %w", err)
 	}
 
-	isEmpty := fileInfo.Size() == 0
-
 	// Prepare CSV writer
 	writer := csv.NewWriter(file)
-	defer writer.Flush()
 
 	// Write headers if file is empty
-	if isEmpty {
-		// Check if message contains commas (multi-column format)
-		if strings.Contains(message, ",") {
-			headers := []string{"timestamp", "alarm_name", "alarm_description", "temperature", "humidity", "pressure", "wind_speed", "lux", "uv", "rain_daily", "message"}
-			if err := writer.Write(headers); err != nil {
-				return fmt.Errorf("failed to write CSV headers: %w", err)
-			}
-		} else {
-			headers := []string{"timestamp", "message"}
-			if err := writer.Write(headers); err != nil {
-				return fmt.Errorf("failed to write CSV headers: %w", err)
-			}
+	if fileInfo.Size() == 0 && !cfg.NoHeader {
+		if err := writer.Write(headers); err != nil {
+			return fmt.Errorf("failed to write CSV headers: %w", err)
 		}
 	}
-
-	// Write record with current timestamp
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-
-	// Check if message contains commas (multi-column format)
-	if strings.Contains(message, ",") {
-		// Parse the message as CSV to handle quoted fields properly
-		reader := csv.NewReader(strings.NewReader(message))
-		fields, err := reader.Read()
-		if err != nil {
-			// If parsing fails, treat as single field
-			row := []string{timestamp, message}
-			if err := writer.Write(row); err != nil {
-				return fmt.Errorf("failed to write CSV record: %w", err)
-			}
-		} else {
-			// Prepend timestamp to the fields
-			row := append([]string{timestamp}, fields...)
-			if err := writer.Write(row); err != nil {
-				return fmt.Errorf("failed to write CSV record: %w", err)
-			}
-		}
-	} else {
-		row := []string{timestamp, message}
-		if err := writer.Write(row); err != nil {
-			return fmt.Errorf("failed to write CSV record: %w", err)
-		}
+	if err := writer.Write(row); err != nil {
+		return fmt.Errorf("failed to write CSV record: %w", err)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV record: %w", err)
 	}
 
 	logger.Info("Alarm message written to CSV file %s", filePath)
 	return nil
 }
 
-// appendToJSONFile appends a message to a JSON file with rotation
-func (n *JSONNotifier) appendToJSONFile(filePath string, message string, maxDays int) error {
-	// Check if file needs rotation
-	if maxDays > 0 {
-		if err := rotateFileIfNeeded(filePath, maxDays); err != nil {
-			logger.Warn("Failed to rotate JSON file %s: %v", filePath, err)
-		}
-	}
-
-	// Read existing content
-	var existingContent []byte
-	file, err := os.Open(filePath)
-	if err != nil {
-		if !os.IsNotExist(err) {
-			return fmt.Errorf("failed to open JSON file for reading: %w", err)
-		}
-		// File doesn't exist, existingContent remains empty
-	} else {
-		defer func() { _ = file.Close() }()
-		existingContent, err = io.ReadAll(file)
-		if err != nil {
-			return fmt.Errorf("failed to read JSON file: %w", err)
-		}
-	}
-
+// appendToJSONFile appends a message to a JSON file with rotation, as a
+// record with the write time
+func (n *JSONNotifier) appendToJSONFile(cfg *JSONConfig, message string) error {
 	// Create JSON record with timestamp and message
+	now := time.Now()
 	record := map[string]interface{}{
-		"timestamp": time.Now().Format("2006-01-02 15:04:05"),
+		"timestamp": formatFileTime(now, cfg.TimeFormat),
 		"message":   message,
 	}
+	if strings.EqualFold(cfg.TimeFormat, "unix") {
+		record["timestamp"] = now.Unix()
+	}
 
 	// Marshal record to JSON
 	jsonData, err := json.MarshalIndent(record, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON record: %w", err)
 	}
+	return n.appendRecord(cfg, jsonData)
+}
+
+// appendRecord adds an encoded record to the array in a JSON file
+func (n *JSONNotifier) appendRecord(cfg *JSONConfig, jsonData []byte) error {
+	filePath := cfg.Path
+
+	// Check if file needs rotation
+	if cfg.MaxDays > 0 {
+		if err := rotateFileIfNeeded(filePath, cfg.MaxDays, cfg.Compress); err != nil {
+			logger.Warn("Failed to rotate JSON file %s: %v", filePath, err)
+		}
+	}
+
+	// Read existing content
+	existingContent, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read JSON file: %w", err)
+	}
 
 	// Prepare new content
 	var newContent []byte
@@ -844,8 +845,9 @@ func (n *JSONNotifier) appendToJSONFile(filePath string, message string, maxDays
 	return nil
 }
 
-// rotateFileIfNeeded rotates a file if it's older than maxDays
-func rotateFileIfNeeded(filePath string, maxDays int) error {
+// rotateFileIfNeeded rotates a file if it's older than maxDays, gzipping the
+// rotated copy when compress is set
+func rotateFileIfNeeded(filePath string, maxDays int, compress bool) error {
 	if maxDays <= 0 {
 		return nil // No rotation needed
 	}
@@ -863,12 +865,9 @@ func rotateFileIfNeeded(filePath string, maxDays int) error {
 	maxAge := time.Duration(maxDays) * 24 * time.Hour
 
 	if fileAge > maxAge {
-		// Create backup with timestamp
-		timestamp := time.Now().Format("2006-01-02_15-04-05")
-		backupPath := filePath + "." + timestamp + ".bak"
-
-		if err := os.Rename(filePath, backupPath); err != nil {
-			return fmt.Errorf("failed to rotate file: %w", err)
+		backupPath, err := rotateFile(filePath, compress)
+		if err != nil {
+			return err
 		}
 
 		logger.Info("Rotated file %s to %s (age: %v)", filePath, backupPath, fileAge)
@@ -920,6 +919,13 @@ func formatAppInfo(isHTML bool) string {
 		appVersion, uptimeStr, runtime.Version())
 }
 
+// FormatAlarmText returns the plain-text alarm details and current
+// conditions console notifications show, for alarms received from another
+// bridge by the webhook listener
+func FormatAlarmText(alarm *Alarm, obs *weather.Observation) string {
+	return formatAlarmInfo(alarm, false) + "\n\nCurrent Conditions:\n" + formatSensorInfoWithAlarm(obs, alarm, false, pressureUnits.notifications)
+}
+
 // formatAlarmInfo returns formatted alarm information
 func formatAlarmInfo(alarm *Alarm, isHTML bool) string {
 	enabledStr := "enabled"
@@ -958,15 +964,30 @@ func formatAlarmInfo(alarm *Alarm, isHTML bool) string {
 
 // formatSensorInfo returns formatted sensor information
 func formatSensorInfo(obs *weather.Observation, isHTML bool) string {
-	return formatSensorInfoWithAlarm(obs, nil, isHTML)
+	return formatSensorInfoWithAlarm(obs, nil, isHTML, pressureUnits.notifications)
 }
 
-func formatSensorInfoWithAlarm(obs *weather.Observation, alarm *Alarm, isHTML bool) string {
+// formatSensorInfoWithAlarm returns formatted sensor information with the
+// values the alarm last compared against, pressures in pressureUnit
+func formatSensorInfoWithAlarm(obs *weather.Observation, alarm *Alarm, isHTML bool, pressureUnit string) string {
 	tempF := obs.AirTemperature*9/5 + 32
 	windSpeedMph := obs.WindAvg * 2.23694
 	windGustMph := obs.WindGust * 2.23694
 	rainDaily := obs.RainDailyTotal / 25.4 // Convert mm to inches
 
+	// Strike distances follow --units, like the dashboard
+	lightning := ""
+	if obs.LightningStrikeCount > 0 && obs.LightningStrikeAvg > 0 {
+		lightning = ", avg " + formatDistance(obs.LightningStrikeAvg)
+	}
+	if obs.LightningNearest > 0 {
+		lightning += fmt.Sprintf(", nearest %s, %.1f/min", formatDistance(obs.LightningNearest), obs.LightningRate)
+	}
+
+	// Pressures follow the notification or export pressure unit
+	pressureUnit = pressureLabel(pressureUnit)
+	pressure := weather.ConvertPressure(obs.StationPressure, pressureUnit)
+
 	// Wind direction cardinal
 	dir := obs.WindDirection
 	cardinal := "N"
@@ -1002,6 +1023,18 @@ func formatSensorInfoWithAlarm(obs *weather.Observation, alarm *Alarm, isHTML bo
 		}
 		return "N/A"
 	}
+	getPrevPressure := func() string {
+		if alarm == nil {
+			return "N/A"
+		}
+		if prev, ok := alarm.GetTriggerValue("pressure"); ok {
+			return fmt.Sprintf("%.2f", weather.ConvertPressure(prev, pressureUnit))
+		}
+		if prev, ok := alarm.GetPreviousValue("pressure"); ok {
+			return fmt.Sprintf("%.2f", weather.ConvertPressure(prev, pressureUnit))
+		}
+		return "N/A"
+	}
 
 	// Special handler for illuminance which needs number formatting
 	getPrevLux := func() string {
@@ -1050,7 +1083,7 @@ func formatSensorInfoWithAlarm(obs *weather.Observation, alarm *Alarm, isHTML bo
 			<tr style="background: #f0f0f0;"><th style="padding: 5px; border: 1px solid #ddd;">Sensor</th><th style="padding: 5px; border: 1px solid #ddd;">Current</th><th style="padding: 5px; border: 1px solid #ddd;">Last</th></tr>
 			<tr%s><td style="padding: 5px; border: 1px solid #ddd;"><strong>Temperature:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.1f°F (%.1f°C)</td><td style="padding: 5px; border: 1px solid #ddd;">%s°C</td></tr>
 			<tr%s><td style="padding: 5px; border: 1px solid #ddd;"><strong>Humidity:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.0f%%</td><td style="padding: 5px; border: 1px solid #ddd;">%s%%</td></tr>
-			<tr%s><td style="padding: 5px; border: 1px solid #ddd;"><strong>Pressure:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.2f mb</td><td style="padding: 5px; border: 1px solid #ddd;">%s mb</td></tr>
+			<tr%s><td style="padding: 5px; border: 1px solid #ddd;"><strong>Pressure:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.2f %s</td><td style="padding: 5px; border: 1px solid #ddd;">%s %s</td></tr>
 			<tr%s><td style="padding: 5px; border: 1px solid #ddd;"><strong>Wind Speed:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.1f mph (%.1f m/s)</td><td style="padding: 5px; border: 1px solid #ddd;">%s m/s</td></tr>
 			<tr%s><td style="padding: 5px; border: 1px solid #ddd;"><strong>Wind Gust:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.1f mph (%.1f m/s)</td><td style="padding: 5px; border: 1px solid #ddd;">%s m/s</td></tr>
 			<tr%s><td style="padding: 5px; border: 1px solid #ddd;"><strong>Wind Direction:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.0f° (%s)</td><td style="padding: 5px; border: 1px solid #ddd;">%s°</td></tr>
@@ -1058,7 +1091,7 @@ func formatSensorInfoWithAlarm(obs *weather.Observation, alarm *Alarm, isHTML bo
 			<tr%s><td style="padding: 5px; border: 1px solid #ddd;"><strong>Illuminance:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%s lux</td><td style="padding: 5px; border: 1px solid #ddd;">%s lux</td></tr>
 			<tr%s><td style="padding: 5px; border: 1px solid #ddd;"><strong>Rain Rate:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.2f mm/hr</td><td style="padding: 5px; border: 1px solid #ddd;">%s mm/hr</td></tr>
 			<tr%s><td style="padding: 5px; border: 1px solid #ddd;"><strong>Daily Rain:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%.2f in (%.1f mm)</td><td style="padding: 5px; border: 1px solid #ddd;">%s mm</td></tr>
-			<tr%s><td style="padding: 5px; border: 1px solid #ddd;"><strong>Lightning:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%d strikes</td><td style="padding: 5px; border: 1px solid #ddd;">%s strikes</td></tr>
+			<tr%s><td style="padding: 5px; border: 1px solid #ddd;"><strong>Lightning:</strong></td><td style="padding: 5px; border: 1px solid #ddd;">%d strikes%s</td><td style="padding: 5px; border: 1px solid #ddd;">%s strikes</td></tr>
 		</table>`
 		return fmt.Sprintf(htmlTemplate,
 			getRowStyle(hasChanged("temperature", obs.AirTemperature, 0.1)),
@@ -1066,7 +1099,7 @@ func formatSensorInfoWithAlarm(obs *weather.Observation, alarm *Alarm, isHTML bo
 			getRowStyle(hasChanged("humidity", obs.RelativeHumidity, 1.0)),
 			obs.RelativeHumidity, getPrevValue("humidity", obs.RelativeHumidity, "%.0f"),
 			getRowStyle(hasChanged("pressure", obs.StationPressure, 0.1)),
-			obs.StationPressure, getPrevValue("pressure", obs.StationPressure, "%.2f"),
+			pressure, pressureUnit, getPrevPressure(), pressureUnit,
 			getRowStyle(hasChanged("wind_speed", obs.WindAvg, 0.1)),
 			windSpeedMph, obs.WindAvg, getPrevValue("wind_speed", obs.WindAvg, "%.1f"),
 			getRowStyle(hasChanged("wind_gust", obs.WindGust, 0.1)),
@@ -1082,12 +1115,12 @@ func formatSensorInfoWithAlarm(obs *weather.Observation, alarm *Alarm, isHTML bo
 			getRowStyle(hasChanged("rain_daily", obs.RainDailyTotal, 0.1)),
 			rainDaily, obs.RainDailyTotal, getPrevValue("rain_daily", obs.RainDailyTotal, "%.1f"),
 			getRowStyle(hasChanged("lightning_count", float64(obs.LightningStrikeCount), 0.5)),
-			obs.LightningStrikeCount, getPrevValue("lightning_count", float64(obs.LightningStrikeCount), "%.0f"))
+			obs.LightningStrikeCount, lightning, getPrevValue("lightning_count", float64(obs.LightningStrikeCount), "%.0f"))
 	}
 
 	return fmt.Sprintf(`Temperature: %.1f°F (%.1f°C) [Last: %s°C]
 Humidity: %.0f%% [Last: %s%%]
-Pressure: %.2f mb [Last: %s mb]
+Pressure: %.2f %s [Last: %s %s]
 Wind Speed: %.1f mph (%.1f m/s) [Last: %s m/s]
 Wind Gust: %.1f mph (%.1f m/s) [Last: %s m/s]
 Wind Direction: %.0f° (%s) [Last: %s°]
@@ -1095,10 +1128,10 @@ UV Index: %d [Last: %s]
 Illuminance: %s lux [Last: %s lux]
 Rain Rate: %.2f mm/hr [Last: %s mm/hr]
 Daily Rain: %.2f in (%.1f mm) [Last: %s mm]
-Lightning: %d strikes [Last: %s strikes]`,
+Lightning: %d strikes%s [Last: %s strikes]`,
 		tempF, obs.AirTemperature, getPrevValue("temperature", obs.AirTemperature, "%.1f"),
 		obs.RelativeHumidity, getPrevValue("humidity", obs.RelativeHumidity, "%.0f"),
-		obs.StationPressure, getPrevValue("pressure", obs.StationPressure, "%.2f"),
+		pressure, pressureUnit, getPrevPressure(), pressureUnit,
 		windSpeedMph, obs.WindAvg, getPrevValue("wind_speed", obs.WindAvg, "%.1f"),
 		windGustMph, obs.WindGust, getPrevValue("wind_gust", obs.WindGust, "%.1f"),
 		obs.WindDirection, cardinal, getPrevValue("wind_direction", obs.WindDirection, "%.0f"),
@@ -1106,19 +1139,40 @@ Lightning: %d strikes [Last: %s strikes]`,
 		formatNumber(obs.Illuminance), getPrevLux(),
 		obs.RainAccumulated, getPrevValue("rain_rate", obs.RainAccumulated, "%.2f"),
 		rainDaily, obs.RainDailyTotal, getPrevValue("rain_daily", obs.RainDailyTotal, "%.1f"),
-		obs.LightningStrikeCount, getPrevValue("lightning_count", float64(obs.LightningStrikeCount), "%.0f"))
+		obs.LightningStrikeCount, lightning, getPrevValue("lightning_count", float64(obs.LightningStrikeCount), "%.0f"))
 }
 
-// expandTemplate replaces template variables with actual values
+// expandTemplate renders a notification template (see renderTemplate) with
+// the observation and alarm values
 func expandTemplate(template string, alarm *Alarm, obs *weather.Observation, stationName string) string {
-	result := template
+	return expandTemplateIn(template, alarm, obs, stationName, pressureUnits.notifications)
+}
 
-	// Detect if this is an HTML template
-	isHTML := strings.Contains(template, "<html>") || strings.Contains(template, "<table>") ||
+// expandExportTemplate renders the template of a CSV or JSON file channel
+// record, with pressures in the export pressure unit
+func expandExportTemplate(template string, alarm *Alarm, obs *weather.Observation, stationName string) string {
+	return expandTemplateIn(template, alarm, obs, stationName, pressureUnits.export)
+}
+
+// isHTMLTemplate reports whether a template is HTML, in which case
+// {{sensor_info}} and the other blocks are rendered as HTML
+func isHTMLTemplate(template string) bool {
+	return strings.Contains(template, "<html>") || strings.Contains(template, "<table>") ||
 		strings.Contains(template, "<div") || strings.Contains(template, "<h1>") ||
 		strings.Contains(template, "<h2>") || strings.Contains(template, "<p>")
+}
+
+// expandTemplateIn renders a template with {{pressure_local}} and
+// {{sensor_info}} in pressureUnit
+func expandTemplateIn(template string, alarm *Alarm, obs *weather.Observation, stationName string, pressureUnit string) string {
+	return renderNotification(template, alarm, obs, stationName, pressureUnit, isHTMLTemplate(template))
+}
 
+// renderNotification renders a template, with the {{app_info}},
+// {{alarm_info}} and {{sensor_info}} blocks as HTML when isHTML is set
+func renderNotification(template string, alarm *Alarm, obs *weather.Observation, stationName string, pressureUnit string, isHTML bool) string {
 	// Replace observation values (current)
+	_, rawDaily := weather.RawRain(obs)
 	replacements := map[string]string{
 		"{{temperature}}":        fmt.Sprintf("%.1f", obs.AirTemperature),
 		"{{temperature_f}}":      fmt.Sprintf("%.1f", obs.AirTemperature*9/5+32),
@@ -1132,20 +1186,35 @@ func expandTemplate(template string, alarm *Alarm, obs *weather.Observation, sta
 		"{{uv}}":                 fmt.Sprintf("%d", obs.UV),
 		"{{rain_rate}}":          fmt.Sprintf("%.2f", obs.RainAccumulated),
 		"{{rain_daily}}":         fmt.Sprintf("%.2f", obs.RainAccumulated),
+		"{{rain_daily_raw}}":     fmt.Sprintf("%.2f", rawDaily),
+		"{{rain_daily_nc}}":      fmt.Sprintf("%.2f", obs.NCRainDailyTotal),
+		"{{snow_rate}}":          fmt.Sprintf("%.1f", obs.SnowRate),
+		"{{snow_daily}}":         fmt.Sprintf("%.1f", obs.SnowDailyTotal),
 		"{{lightning_count}}":    fmt.Sprintf("%d", obs.LightningStrikeCount),
 		"{{lightning_distance}}": fmt.Sprintf("%.1f", obs.LightningStrikeAvg),
+		"{{lightning_nearest}}":  fmt.Sprintf("%.1f", obs.LightningNearest),
+		"{{lightning_rate}}":     fmt.Sprintf("%.1f", obs.LightningRate),
 		"{{timestamp}}":          time.Unix(obs.Timestamp, 0).Format("2006-01-02 15:04:05 MST"),
+		"{{timestamp_iso}}":      time.Unix(obs.Timestamp, 0).Format(time.RFC3339),
+		"{{timestamp_unix}}":     fmt.Sprintf("%d", obs.Timestamp),
 		"{{station}}":            stationName,
 		"{{alarm_name}}":         alarm.Name,
 		"{{alarm_description}}":  alarm.Description,
 		"{{alarm_condition}}":    alarm.Condition,
 		"{{message}}":            fmt.Sprintf("ALARM: %s triggered", alarm.Name),
+		"{{ack_url}}":            ackURL(alarm.ackToken),
+		"{{ack_code}}":           ackCode(alarm.ackToken),
 		// New composite variables
 		"{{app_info}}":    formatAppInfo(isHTML),
 		"{{alarm_info}}":  formatAlarmInfo(alarm, isHTML),
-		"{{sensor_info}}": formatSensorInfoWithAlarm(obs, alarm, isHTML),
+		"{{sensor_info}}": formatSensorInfoWithAlarm(obs, alarm, isHTML, pressureUnit),
 	}
 
+	// Distances in the configured unit system (--units), with their unit
+	replacements["{{lightning_distance_local}}"] = formatDistance(obs.LightningStrikeAvg)
+	replacements["{{lightning_nearest_local}}"] = formatDistance(obs.LightningNearest)
+	replacements["{{pressure_local}}"] = weather.FormatPressure(obs.StationPressure, pressureLabel(pressureUnit))
+
 	// Add previous values for change detection comparisons
 	// These show the value that was compared against to trigger the alarm
 	// Use trigger context if available (more accurate), otherwise fall back to previousValue
@@ -1234,9 +1303,5 @@ func expandTemplate(template string, alarm *Alarm, obs *weather.Observation, sta
 		replacements["{{last_lightning_distance}}"] = "N/A"
 	}
 
-	for placeholder, value := range replacements {
-		result = strings.ReplaceAll(result, placeholder, value)
-	}
-
-	return result
+	return renderTemplate(template, replacements, newTemplateData(alarm, obs, stationName, isHTML, pressureLabel(pressureUnit)))
 }
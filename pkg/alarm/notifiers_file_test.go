@@ -51,7 +51,7 @@ func TestCSVNotifier_appendToCSVFile(t *testing.T) {
 			filePath := filepath.Join(tempDir, fmt.Sprintf("test_%s.csv", tt.name))
 
 			// First write - should create file with headers
-			err := notifier.appendToCSVFile(filePath, tt.message, 30)
+			err := notifier.appendToCSVFile(&CSVConfig{Path: filePath, MaxDays: 30}, tt.message)
 			if err != nil {
 				t.Fatalf("Failed to write CSV file: %v", err)
 			}
@@ -97,7 +97,7 @@ func TestCSVNotifier_appendToCSVFile(t *testing.T) {
 			}
 
 			// Second write - should append without headers
-			err = notifier.appendToCSVFile(filePath, tt.message, 30)
+			err = notifier.appendToCSVFile(&CSVConfig{Path: filePath, MaxDays: 30}, tt.message)
 			if err != nil {
 				t.Fatalf("Failed to append to CSV file: %v", err)
 			}
@@ -133,7 +133,7 @@ func TestCSVNotifier_appendToCSVFile_Rotation(t *testing.T) {
 	filePath := filepath.Join(tempDir, "test.csv")
 
 	// Create file with old timestamp
-	err = notifier.appendToCSVFile(filePath, "test message", 1) // 1 day max
+	err = notifier.appendToCSVFile(&CSVConfig{Path: filePath, MaxDays: 1}, "test message") // 1 day max
 	if err != nil {
 		t.Fatalf("Failed to create CSV file: %v", err)
 	}
@@ -146,7 +146,7 @@ func TestCSVNotifier_appendToCSVFile_Rotation(t *testing.T) {
 	}
 
 	// Write again - should rotate the file
-	err = notifier.appendToCSVFile(filePath, "new message", 1)
+	err = notifier.appendToCSVFile(&CSVConfig{Path: filePath, MaxDays: 1}, "new message")
 	if err != nil {
 		t.Fatalf("Failed to write after rotation: %v", err)
 	}
@@ -200,7 +200,7 @@ func TestJSONNotifier_appendToJSONFile(t *testing.T) {
 			filePath := filepath.Join(tempDir, fmt.Sprintf("test_%s.json", tt.name))
 
 			// First write - should create file with array
-			err := notifier.appendToJSONFile(filePath, tt.message, 30)
+			err := notifier.appendToJSONFile(&JSONConfig{Path: filePath, MaxDays: 30}, tt.message)
 			if err != nil {
 				t.Fatalf("Failed to write JSON file: %v", err)
 			}
@@ -233,7 +233,7 @@ func TestJSONNotifier_appendToJSONFile(t *testing.T) {
 			}
 
 			// Second write - should append to array
-			err = notifier.appendToJSONFile(filePath, tt.message, 30)
+			err = notifier.appendToJSONFile(&JSONConfig{Path: filePath, MaxDays: 30}, tt.message)
 			if err != nil {
 				t.Fatalf("Failed to append to JSON file: %v", err)
 			}
@@ -268,7 +268,7 @@ func TestJSONNotifier_appendToJSONFile_Rotation(t *testing.T) {
 	filePath := filepath.Join(tempDir, "test.json")
 
 	// Create file with old timestamp
-	err = notifier.appendToJSONFile(filePath, `{"test": "data"}`, 1) // 1 day max
+	err = notifier.appendToJSONFile(&JSONConfig{Path: filePath, MaxDays: 1}, `{"test": "data"}`) // 1 day max
 	if err != nil {
 		t.Fatalf("Failed to create JSON file: %v", err)
 	}
@@ -281,7 +281,7 @@ func TestJSONNotifier_appendToJSONFile_Rotation(t *testing.T) {
 	}
 
 	// Write again - should rotate the file
-	err = notifier.appendToJSONFile(filePath, `{"new": "data"}`, 1)
+	err = notifier.appendToJSONFile(&JSONConfig{Path: filePath, MaxDays: 1}, `{"new": "data"}`)
 	if err != nil {
 		t.Fatalf("Failed to write after rotation: %v", err)
 	}
@@ -0,0 +1,250 @@
+package alarm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// PluginProtocolVersion is sent to plugins so they can reject payloads they
+// do not understand.
+const PluginProtocolVersion = 1
+
+// DefaultPluginTimeout bounds how long a plugin may take to deliver a notification
+const DefaultPluginTimeout = 30 * time.Second
+
+// PluginRequest is the JSON document written to a plugin's stdin for each
+// notification. The plugin is started once per notification.
+type PluginRequest struct {
+	Version     int                    `json:"version"`
+	Channel     string                 `json:"channel"`
+	Message     string                 `json:"message"` // channel template expanded against the observation
+	Station     string                 `json:"station"`
+	Alarm       PluginAlarm            `json:"alarm"`
+	Options     map[string]interface{} `json:"options,omitempty"` // the channel's "plugin" object, passed through untouched
+	Observation *weather.Observation   `json:"observation,omitempty"`
+	Timestamp   time.Time              `json:"timestamp"`
+}
+
+// PluginAlarm is the subset of an alarm exposed to plugins
+type PluginAlarm struct {
+	Name           string   `json:"name"`
+	Description    string   `json:"description,omitempty"`
+	Condition      string   `json:"condition"`
+	Tags           []string `json:"tags,omitempty"`
+	TriggeredCount int      `json:"triggered_count"`
+}
+
+// PluginResponse is the optional JSON document a plugin writes to stdout.
+// A plugin that exits 0 without output is treated as successful.
+type PluginResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// pluginNamePattern restricts plugin names to something usable as a channel type
+var pluginNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   = map[string]string{} // channel type -> executable path
+)
+
+// LoadPlugins registers every executable in dir as a notification channel
+// named after the file (without extension), so "plugins/teams.sh" handles
+// channels of type "teams". Built-in channel types cannot be overridden.
+// A missing directory is not an error. It returns the registered names.
+func LoadPlugins(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read plugins directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path) // follow symlinks
+		if err != nil || !isExecutable(info) {
+			logger.Debug("Skipping non-executable plugin candidate %s", path)
+			continue
+		}
+
+		name := strings.ToLower(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+		if !pluginNamePattern.MatchString(name) {
+			logger.Warn("Skipping plugin %s: name must match %s", path, pluginNamePattern.String())
+			continue
+		}
+		if builtinChannelTypes[name] {
+			logger.Warn("Skipping plugin %s: %q is a built-in channel type", path, name)
+			continue
+		}
+
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			abs = path
+		}
+		if err := RegisterPlugin(name, abs); err != nil {
+			logger.Warn("Skipping plugin %s: %v", path, err)
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// RegisterPlugin registers an executable as the handler for a channel type
+func RegisterPlugin(name, path string) error {
+	if builtinChannelTypes[name] {
+		return fmt.Errorf("cannot override built-in channel type %q", name)
+	}
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if existing, ok := plugins[name]; ok && existing != path {
+		return fmt.Errorf("channel type %q is already provided by %s", name, existing)
+	}
+	plugins[name] = path
+	logger.Debug("Registered notification plugin %q (%s)", name, path)
+	return nil
+}
+
+// UnregisterPlugin removes a plugin channel type
+func UnregisterPlugin(name string) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	delete(plugins, name)
+}
+
+// PluginNames returns the registered plugin channel types, sorted
+func PluginNames() []string {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupPlugin(name string) (string, bool) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	path, ok := plugins[name]
+	return path, ok
+}
+
+func isExecutable(info os.FileInfo) bool {
+	if !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(info.Name())) {
+		case ".exe", ".bat", ".cmd":
+			return true
+		}
+		return false
+	}
+	return info.Mode().Perm()&0111 != 0
+}
+
+// PluginNotifier delivers notifications by running an external executable
+// that speaks the JSON stdin/stdout protocol described by PluginRequest and
+// PluginResponse.
+type PluginNotifier struct {
+	Name    string
+	Path    string
+	Timeout time.Duration
+}
+
+func (n *PluginNotifier) Send(alarm *Alarm, channel *Channel, obs *weather.Observation, stationName string) error {
+	req := PluginRequest{
+		Version: PluginProtocolVersion,
+		Channel: n.Name,
+		Station: stationName,
+		Alarm: PluginAlarm{
+			Name:           alarm.Name,
+			Description:    alarm.Description,
+			Condition:      alarm.Condition,
+			Tags:           alarm.Tags,
+			TriggeredCount: alarm.TriggeredCount,
+		},
+		Options:     channel.Plugin,
+		Observation: obs,
+		Timestamp:   time.Now(),
+	}
+	if channel.Template != "" {
+		req.Message = expandTemplate(channel.Template, alarm, obs, stationName)
+	}
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode plugin request: %w", err)
+	}
+
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = DefaultPluginTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, n.Path)
+	cmd.Stdin = bytes.NewReader(payload)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	runErr := cmd.Run()
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		logger.Debug("Plugin %s stderr: %s", n.Name, msg)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("plugin %s timed out after %v", n.Name, timeout)
+	}
+
+	out := bytes.TrimSpace(stdout.Bytes())
+	if len(out) > 0 {
+		var resp PluginResponse
+		if err := json.Unmarshal(out, &resp); err != nil {
+			if runErr != nil {
+				return fmt.Errorf("plugin %s failed: %w", n.Name, runErr)
+			}
+			return fmt.Errorf("plugin %s returned invalid response: %w", n.Name, err)
+		}
+		if !resp.OK {
+			if resp.Error == "" {
+				resp.Error = "plugin reported failure"
+			}
+			return fmt.Errorf("plugin %s: %s", n.Name, resp.Error)
+		}
+	}
+	if runErr != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("plugin %s failed: %w: %s", n.Name, runErr, msg)
+		}
+		return fmt.Errorf("plugin %s failed: %w", n.Name, runErr)
+	}
+
+	logger.Info("Plugin %s delivered alarm %s", n.Name, alarm.Name)
+	return nil
+}
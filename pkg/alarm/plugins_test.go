@@ -0,0 +1,90 @@
+package alarm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func writePlugin(t *testing.T, dir, name, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadPluginsAndSend(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell plugins are not supported on windows")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "request.json")
+	writePlugin(t, dir, "chatops.sh", "cat > "+out+"\necho '{\"ok\": true}'\n")
+	writePlugin(t, dir, "failing", "echo '{\"ok\": false, \"error\": \"channel closed\"}'\n")
+	writePlugin(t, dir, "console", "exit 0\n") // built-in names are skipped
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a plugin"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		UnregisterPlugin("chatops")
+		UnregisterPlugin("failing")
+	})
+
+	names, err := LoadPlugins(dir)
+	if err != nil {
+		t.Fatalf("LoadPlugins failed: %v", err)
+	}
+	if strings.Join(names, ",") != "chatops,failing" {
+		t.Fatalf("unexpected plugins: %v", names)
+	}
+
+	ch := Channel{Type: "chatops", Template: "{{alarm_name}} fired", Plugin: map[string]interface{}{"room": "ops"}}
+	if err := ch.Validate(); err != nil {
+		t.Fatalf("expected plugin channel to validate: %v", err)
+	}
+
+	notifier, err := NewNotifierFactory(&AlarmConfig{}).GetNotifier("chatops")
+	if err != nil {
+		t.Fatalf("GetNotifier failed: %v", err)
+	}
+	a := &Alarm{Name: "hot", Condition: "temperature > 30"}
+	if err := notifier.Send(a, &ch, &weather.Observation{AirTemperature: 31}, "Backyard"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req PluginRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("plugin received invalid JSON: %v", err)
+	}
+	if req.Version != PluginProtocolVersion || req.Message != "hot fired" || req.Station != "Backyard" ||
+		req.Options["room"] != "ops" || req.Observation == nil || req.Observation.AirTemperature != 31 {
+		t.Errorf("unexpected plugin request: %+v", req)
+	}
+
+	failing, _ := NewNotifierFactory(&AlarmConfig{}).GetNotifier("failing")
+	if err := failing.Send(a, &Channel{Type: "failing"}, nil, ""); err == nil || !strings.Contains(err.Error(), "channel closed") {
+		t.Errorf("expected plugin error to surface, got %v", err)
+	}
+}
+
+func TestLoadPluginsMissingDirAndUnknownType(t *testing.T) {
+	names, err := LoadPlugins(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || len(names) != 0 {
+		t.Errorf("expected missing dir to be ignored, got %v, %v", names, err)
+	}
+	if err := (&Channel{Type: "nosuchplugin"}).Validate(); err == nil {
+		t.Error("expected unknown channel type to be rejected")
+	}
+	if err := RegisterPlugin("email", "/bin/true"); err == nil {
+		t.Error("expected built-in channel types to be protected")
+	}
+}
@@ -0,0 +1,174 @@
+package alarm
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// SimulatedMessage is the notification a channel would send
+type SimulatedMessage struct {
+	Channel string   `json:"channel"` // channel type, e.g. "email"
+	To      []string `json:"to,omitempty"`
+	Subject string   `json:"subject,omitempty"`
+	Message string   `json:"message"`
+	HTML    bool     `json:"html,omitempty"` // Message is HTML (an HTML email or template)
+	Held    string   `json:"held,omitempty"` // why the notification would go to a digest instead
+	// Attachments names the files attached to an email
+	Attachments []string `json:"attachments,omitempty"`
+}
+
+// SimulatedAlarm is the outcome of one alarm in Simulate
+type SimulatedAlarm struct {
+	Name      string             `json:"name"`
+	Condition string             `json:"condition"`
+	Matched   bool               `json:"matched"`           // the condition holds for the observation
+	Fires     bool               `json:"fires"`             // the alarm would fire now
+	Blocked   string             `json:"blocked,omitempty"` // why a matching alarm would not fire
+	Error     string             `json:"error,omitempty"`
+	Messages  []SimulatedMessage `json:"messages,omitempty"` // rendered for every matching alarm
+}
+
+// Simulate evaluates every alarm against obs the way ProcessObservation
+// would, using copies of the alarms so change detection, sustained_for,
+// cooldowns, counters and the saved state are left as they are. Nothing is
+// sent and obs is not recorded as the last observation.
+func (m *Manager) Simulate(obs *weather.Observation) []SimulatedAlarm {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	results := make([]SimulatedAlarm, 0, len(m.config.Alarms))
+	for i := range m.config.Alarms {
+		a := m.config.Alarms[i]
+		a.previousValue = maps.Clone(a.previousValue)
+		a.triggerContext = maps.Clone(a.triggerContext)
+		result := SimulatedAlarm{Name: a.Name, Condition: a.Condition}
+
+		matched, err := m.evaluator.EvaluateWithAlarm(a.Condition, obs, &a)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Matched = matched
+		switch {
+		case !matched:
+		case !a.Enabled:
+			result.Blocked = "disabled"
+		case a.Schedule != nil && !a.Schedule.IsActive(now, m.latitude, m.longitude):
+			result.Blocked = "outside schedule " + a.Schedule.String()
+		case !a.CanFire():
+			result.Blocked = "in cooldown"
+		case !a.sustained(true, obs):
+			result.Blocked = "waiting for sustained_for " + a.SustainedFor
+		default:
+			result.Fires = true
+		}
+		if matched {
+			for j := range a.Channels {
+				channel := &a.Channels[j]
+				if channel.History != nil {
+					channel = m.withHistory(channel, obs)
+				}
+				msg := simulateChannel(&a, channel, obs, m.stationName, m.config.Email)
+				if result.Fires && a.Channels[j].throttled() {
+					t := channelThrottle{}
+					if existing := m.throttles[throttleKey(&a, j)]; existing != nil {
+						t.sent = slices.Clone(existing.sent)
+					}
+					msg.Held = t.holdReason(&a.Channels[j], now)
+				}
+				result.Messages = append(result.Messages, msg)
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// PreviewMessages renders the message every channel of alarm would send for
+// obs, whether or not the condition holds. The alarm is copied, so its change
+// detection state is left as it is.
+func PreviewMessages(alarm *Alarm, obs *weather.Observation, stationName string) []SimulatedMessage {
+	return previewMessages(alarm, obs, stationName, nil)
+}
+
+// previewMessages is PreviewMessages with the email settings whose theme
+// HTML emails are rendered in, if any
+func previewMessages(alarm *Alarm, obs *weather.Observation, stationName string, email *EmailGlobalConfig) []SimulatedMessage {
+	a := *alarm
+	a.previousValue = maps.Clone(a.previousValue)
+	a.triggerContext = maps.Clone(a.triggerContext)
+	messages := make([]SimulatedMessage, 0, len(a.Channels))
+	for i := range a.Channels {
+		messages = append(messages, simulateChannel(&a, &a.Channels[i], obs, stationName, email))
+	}
+	return messages
+}
+
+// simulateChannel renders the message a channel's notifier would send; HTML
+// emails take the theme of email when it has a templates directory
+func simulateChannel(alarm *Alarm, channel *Channel, obs *weather.Observation, stationName string, email *EmailGlobalConfig) SimulatedMessage {
+	msg := SimulatedMessage{Channel: channel.Type}
+	switch {
+	case channel.Type == "email" && channel.Email != nil:
+		body := channel.Email.Body
+		if body == "" {
+			body = channel.Template
+		}
+		msg.To = channel.Email.To
+		msg.Subject = expandTemplate(channel.Email.Subject, alarm, obs, stationName)
+		msg.Message = expandTemplate(body, alarm, obs, stationName)
+		msg.HTML = channel.Email.Html || isHTMLTemplate(body)
+		if channel.Email.Html && email != nil && email.TemplatesDir != "" {
+			if themed, err := themeEmail(email.TemplatesDir, channel.Email, msg.Subject, body, alarm, obs, stationName); err != nil {
+				logger.Warn("Email theme not applied to alarm %s: %v", alarm.Name, err)
+			} else if themed != "" {
+				msg.Message = themed
+			}
+		}
+		if channel.History != nil {
+			if attachment, err := channel.History.attachment(alarm, obs, channel.historyFor(obs)); err == nil {
+				msg.Attachments = []string{attachment.Name}
+			}
+		}
+	case channel.Type == "sms" && channel.SMS != nil:
+		msg.To = channel.SMS.To
+		msg.Message = expandTemplate(channel.SMS.Message, alarm, obs, stationName)
+	case channel.Type == "webhook" && channel.Webhook != nil:
+		msg.To = []string{channel.Webhook.URL}
+		msg.Message = expandTemplate(channel.Webhook.Body, alarm, obs, stationName)
+		if channel.History != nil {
+			if body, err := channel.History.addToPayload(msg.Message, channel.historyFor(obs)); err != nil {
+				logger.Warn("History not added to webhook for alarm %s: %v", alarm.Name, err)
+			} else {
+				msg.Message = body
+			}
+		}
+	case channel.Type == "csv" && channel.CSV != nil:
+		msg.To = []string{channel.CSV.Path}
+		if len(channel.CSV.Columns) > 0 {
+			_, row := renderFileColumns(channel.CSV.Columns, alarm, obs, stationName)
+			msg.Message = strings.Join(row, ",")
+		} else {
+			msg.Message = expandExportTemplate(channel.CSV.Message, alarm, obs, stationName)
+		}
+	case channel.Type == "json" && channel.JSON != nil:
+		msg.To = []string{channel.JSON.Path}
+		if len(channel.JSON.Fields) > 0 {
+			record, _ := fieldsRecord(renderFileColumns(channel.JSON.Fields, alarm, obs, stationName))
+			msg.Message = string(record)
+		} else {
+			msg.Message = expandExportTemplate(channel.JSON.Message, alarm, obs, stationName)
+		}
+	default:
+		msg.Message = expandTemplate(channel.Template, alarm, obs, stationName)
+		msg.HTML = isHTMLTemplate(channel.Template)
+	}
+	return msg
+}
@@ -0,0 +1,105 @@
+package alarm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestSimulate(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "alarms.json")
+	config := `{
+		"alarms": [
+			{
+				"name": "Hot",
+				"condition": "temperature > 30",
+				"enabled": true,
+				"cooldown": 3600,
+				"channels": [
+					{"type": "console", "template": "Hot at {{station}}: {{temperature_c}}°C"},
+					{"type": "email", "email": {"to": ["me@example.com"], "subject": "Hot {{temperature_c}}", "body": "It is hot"}}
+				]
+			},
+			{"name": "Cold", "condition": "temperature < 0", "enabled": true, "channels": [{"type": "console", "template": "Cold"}]},
+			{"name": "Off", "condition": "temperature > 30", "enabled": false, "channels": [{"type": "console", "template": "Off"}]},
+			{"name": "Held", "condition": "temperature > 30", "enabled": true, "sustained_for": "3 obs", "channels": [{"type": "console", "template": "Held"}]}
+		]
+	}`
+	if err := os.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	manager, err := NewManager("@"+configFile, "TestStation")
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer manager.Stop()
+
+	obs := &weather.Observation{Timestamp: 1700000000, AirTemperature: 35}
+	results := manager.Simulate(obs)
+	byName := map[string]SimulatedAlarm{}
+	for _, r := range results {
+		byName[r.Name] = r
+	}
+
+	hot := byName["Hot"]
+	if !hot.Matched || !hot.Fires || len(hot.Messages) != 2 {
+		t.Fatalf("Hot = %+v, want it to fire through two channels", hot)
+	}
+	if !strings.Contains(hot.Messages[0].Message, "Hot at TestStation: 35") {
+		t.Errorf("console message = %q", hot.Messages[0].Message)
+	}
+	if email := hot.Messages[1]; email.Subject != "Hot 35.0" || email.Message != "It is hot" || email.To[0] != "me@example.com" {
+		t.Errorf("email message = %+v", email)
+	}
+	if cold := byName["Cold"]; cold.Matched || cold.Fires || len(cold.Messages) != 0 {
+		t.Errorf("Cold = %+v, want no match", cold)
+	}
+	if off := byName["Off"]; !off.Matched || off.Fires || off.Blocked != "disabled" {
+		t.Errorf("Off = %+v, want blocked as disabled", off)
+	}
+	if held := byName["Held"]; held.Fires || !strings.Contains(held.Blocked, "sustained_for") {
+		t.Errorf("Held = %+v, want blocked by sustained_for", held)
+	}
+
+	// The live alarms are untouched: nothing fired, no cooldown started and
+	// no last observation recorded
+	cfg := manager.GetConfig()
+	for _, a := range cfg.Alarms {
+		if a.TriggeredCount != 0 || !a.lastFired.IsZero() || a.heldCount != 0 {
+			t.Errorf("alarm %s changed by Simulate: count %d, last fired %v, held %d", a.Name, a.TriggeredCount, a.lastFired, a.heldCount)
+		}
+	}
+	if manager.lastObs != nil {
+		t.Error("Simulate recorded the observation")
+	}
+	if again := manager.Simulate(obs); !again[0].Fires {
+		t.Error("second simulation blocked; Simulate must not start the cooldown")
+	}
+}
+
+func TestPreviewMessages(t *testing.T) {
+	alarm := &Alarm{
+		Name:      "Hot",
+		Condition: "temperature > 40",
+		Channels: []Channel{
+			{Type: "console", Template: "Hot at {{station}}: {{temperature_c}}°C"},
+			{Type: "email", Email: &EmailConfig{To: []string{"me@example.com"}, Subject: "{{alarm_name}}", Body: "<p>{{temperature_c}}</p>", Html: true}},
+		},
+	}
+	obs := &weather.Observation{Timestamp: 1700000000, AirTemperature: 20}
+
+	// Rendered although the condition does not hold
+	messages := PreviewMessages(alarm, obs, "Home")
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(messages))
+	}
+	if messages[0].Message != "Hot at Home: 20.0°C" || messages[0].HTML {
+		t.Errorf("console = %+v", messages[0])
+	}
+	if email := messages[1]; email.Subject != "Hot" || email.Message != "<p>20.0</p>" || !email.HTML {
+		t.Errorf("email = %+v", email)
+	}
+}
@@ -0,0 +1,137 @@
+package alarm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// SitesProvider returns the current observations of federated sites, by
+// site name (see pkg/federation)
+type SitesProvider func() map[string]weather.Observation
+
+// localSite is the name of the local station among the sites
+const localSite = "local"
+
+// siteValueFields are the observation fields cross-site conditions can read;
+// bridge metrics, statistics and trends are only known for the local station
+var siteValueFields = map[string]bool{
+	"temperature": true, "temp": true,
+	"humidity":   true,
+	"pressure":   true,
+	"wind_speed": true, "wind": true,
+	"wind_gust":      true,
+	"wind_direction": true,
+	"lux":            true, "light": true,
+	"uv": true, "uv_index": true,
+	"rain_rate": true, "rain_daily": true,
+	"lightning_count":    true,
+	"lightning_distance": true,
+	"lightning_nearest":  true,
+	"lightning_rate":     true,
+	"precipitation_type": true,
+	"battery":            true,
+}
+
+// siteFieldPattern finds cross-site fields in a condition
+var siteFieldPattern = regexp.MustCompile(`(?i)\b(any_site|all_sites|site\.[a-z0-9_-]+)\.`)
+
+// SetSitesProvider supplies the federated sites to the any_site., all_sites.
+// and site.<name>. condition fields. Without a provider only the local
+// station is known.
+func (e *Evaluator) SetSitesProvider(fn SitesProvider) {
+	e.sites = fn
+}
+
+// usesSiteFields reports whether a condition references other sites
+func usesSiteFields(condition string) bool {
+	return siteFieldPattern.MatchString(condition)
+}
+
+// siteField splits a cross-site field: "any_site.wind_gust" is scope any,
+// "all_sites.temperature" scope all and "site.cabin.uv" scope site for cabin
+func siteField(field string) (scope, site, inner string, ok bool) {
+	field = strings.ToLower(strings.TrimSpace(field))
+	switch {
+	case strings.HasPrefix(field, "any_site."):
+		return "any", "", strings.TrimPrefix(field, "any_site."), true
+	case strings.HasPrefix(field, "all_sites."):
+		return "all", "", strings.TrimPrefix(field, "all_sites."), true
+	case strings.HasPrefix(field, "site."):
+		site, inner, found := strings.Cut(strings.TrimPrefix(field, "site."), ".")
+		return "site", site, inner, found && site != ""
+	}
+	return "", "", "", false
+}
+
+// evaluateSiteCondition compares field at the sites in scope: any_site holds
+// when one site matches, all_sites when every reporting site does, and
+// site.<name> when that site matches. The local station takes part as
+// "local" once it has reported. Sites without a current observation are
+// left out, so an offline site never fires an alarm.
+func (e *Evaluator) evaluateSiteCondition(scope, site, field, operator string, value float64, local *weather.Observation) (bool, error) {
+	if !siteValueFields[field] {
+		return false, fmt.Errorf("field %s is not available for other sites", field)
+	}
+	observations := map[string]weather.Observation{}
+	if e.sites != nil {
+		observations = e.sites()
+	}
+	if local != nil && local.Timestamp != 0 {
+		observations[localSite] = *local
+	}
+
+	if scope == "site" {
+		obs, ok := observations[site]
+		if !ok {
+			logger.Debug("Site %s has no current observation", site)
+			return false, nil
+		}
+		fieldValue, err := e.getFieldValue(field, &obs)
+		if err != nil {
+			return false, err
+		}
+		return e.compare(fieldValue, operator, value), nil
+	}
+
+	matched := 0
+	for name, obs := range observations {
+		fieldValue, err := e.getFieldValue(field, &obs)
+		if err != nil {
+			return false, err
+		}
+		if e.compare(fieldValue, operator, value) {
+			logger.Debug("Site %s matches %s %s %v", name, field, operator, value)
+			matched++
+		}
+	}
+	if scope == "any" {
+		return matched > 0, nil
+	}
+	return len(observations) > 0 && matched == len(observations), nil
+}
+
+// ProcessSites re-evaluates alarms that reference other sites against the
+// last local observation. Call it after every federation poll so cross-site
+// alarms fire without waiting for the local station.
+func (m *Manager) ProcessSites() {
+	m.mu.RLock()
+	obs := m.lastObs
+	m.mu.RUnlock()
+	if obs == nil {
+		obs = &weather.Observation{}
+	}
+
+	onlySites := func(a *Alarm) bool { return usesSiteFields(a.Condition) }
+	m.dispatchFired(m.processObservationLocked(obs, onlySites))
+}
+
+// SetSitesProvider supplies the federated sites to cross-site conditions
+func (m *Manager) SetSitesProvider(fn SitesProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.evaluator.SetSitesProvider(fn)
+}
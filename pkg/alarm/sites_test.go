@@ -0,0 +1,124 @@
+package alarm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestEvaluator_SiteFields(t *testing.T) {
+	now := time.Now().Unix()
+	e := NewEvaluator()
+	e.SetSitesProvider(func() map[string]weather.Observation {
+		return map[string]weather.Observation{
+			"cabin":   {Timestamp: now, WindGust: 25, AirTemperature: 2},
+			"beach-2": {Timestamp: now, WindGust: 8, AirTemperature: 18},
+		}
+	})
+	local := &weather.Observation{Timestamp: now, WindGust: 3, AirTemperature: 12}
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"any_site.wind_gust > 50mph", true}, // 25 m/s is 56 mph
+		{"any_site.wind_gust > 60mph", false},
+		{"all_sites.temperature > 1", true},
+		{"all_sites.temperature > 5", false},
+		{"site.cabin.temperature < 3", true},
+		{"site.beach-2.temperature < 3", false},
+		{"site.local.wind_gust == 3", true},
+		{"site.unknown.temperature < 100", false},
+		{"ANY_SITE.temperature > 15 && temperature < 15", true},
+	}
+	for _, tt := range tests {
+		got, err := e.Evaluate(tt.condition, local)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.condition, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.condition, got, tt.want)
+		}
+	}
+
+	if _, err := e.Evaluate("any_site.data_age > 10m", local); err == nil {
+		t.Error("expected an error for a bridge metric of other sites")
+	}
+}
+
+func TestEvaluator_SiteFieldsWithoutProvider(t *testing.T) {
+	e := NewEvaluator()
+	got, err := e.Evaluate("any_site.temperature > 10", &weather.Observation{Timestamp: time.Now().Unix(), AirTemperature: 12})
+	if err != nil || !got {
+		t.Errorf("local station only: got %v, %v; want true", got, err)
+	}
+	got, err = e.Evaluate("all_sites.temperature > 10", &weather.Observation{AirTemperature: 12})
+	if err != nil || got {
+		t.Errorf("no site reporting: got %v, %v; want false", got, err)
+	}
+}
+
+func TestUsesSiteFields(t *testing.T) {
+	cases := map[string]bool{
+		"any_site.wind_gust > 50mph":            true,
+		"temperature > 30 && site.cabin.uv > 6": true,
+		"ALL_SITES.temperature < 0":             true,
+		"temperature > 30":                      false,
+		"wind_gust > 20":                        false,
+	}
+	for cond, want := range cases {
+		if got := usesSiteFields(cond); got != want {
+			t.Errorf("usesSiteFields(%q) = %v, want %v", cond, got, want)
+		}
+	}
+}
+
+func TestManager_ProcessSites(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "alarms.json")
+	config := `{
+		"alarms": [
+			{
+				"name": "Gale somewhere",
+				"condition": "any_site.wind_gust > 50mph",
+				"enabled": true,
+				"channels": [{"type": "console", "template": "gale"}]
+			},
+			{
+				"name": "Hot",
+				"condition": "temperature > 30",
+				"enabled": true,
+				"channels": [{"type": "console", "template": "hot"}]
+			}
+		]
+	}`
+	if err := os.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	manager, err := NewManager("@"+configFile, "TestStation")
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer manager.Stop()
+
+	var fired []string
+	manager.SetFiredHandler(func(ev FiredEvent) { fired = append(fired, ev.Name) })
+	gust := 10.0
+	manager.SetSitesProvider(func() map[string]weather.Observation {
+		return map[string]weather.Observation{"cabin": {Timestamp: time.Now().Unix(), WindGust: gust, AirTemperature: 35}}
+	})
+
+	manager.ProcessSites()
+	if len(fired) != 0 {
+		t.Fatalf("fired %v before the gale", fired)
+	}
+	gust = 30
+	manager.ProcessSites()
+	if len(fired) != 1 || fired[0] != "Gale somewhere" {
+		t.Errorf("fired %v, want only the cross-site alarm", fired)
+	}
+}
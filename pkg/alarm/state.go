@@ -0,0 +1,153 @@
+package alarm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+)
+
+// stateFile holds the alarm runtime state across restarts; empty keeps it in
+// memory only
+var stateFile string
+
+// SetStateFile sets where the alarm runtime state (last fired, trigger
+// counts, previous values and sustained_for progress) is kept, so a restart
+// does not reset cooldowns and re-fire alarms. main sets it from the data
+// directory.
+func SetStateFile(path string) {
+	stateFile = path
+}
+
+// alarmState is the runtime state of one alarm. The cooldown deadline is
+// LastFired plus the alarm's cooldown, so a changed cooldown applies at once.
+type alarmState struct {
+	LastFired      time.Time          `json:"lastFired,omitempty"`
+	TriggeredCount int                `json:"triggeredCount,omitempty"`
+	PreviousValues map[string]float64 `json:"previousValues,omitempty"`
+	HeldSince      time.Time          `json:"heldSince,omitempty"`
+	HeldCount      int                `json:"heldCount,omitempty"`
+	HeldLast       int64              `json:"heldLast,omitempty"`
+	SnoozedUntil   time.Time          `json:"snoozedUntil,omitempty"`
+	Acknowledged   bool               `json:"acknowledged,omitempty"`
+	AckToken       string             `json:"ackToken,omitempty"`
+	AckedBy        string             `json:"ackedBy,omitempty"`
+	AckedAt        time.Time          `json:"ackedAt,omitempty"`
+}
+
+// runtimeState is what the state file holds, by alarm name
+type runtimeState struct {
+	Saved  time.Time             `json:"saved"`
+	Alarms map[string]alarmState `json:"alarms"`
+}
+
+// captureState returns the runtime state of every alarm
+func captureState(alarms []Alarm) map[string]alarmState {
+	states := make(map[string]alarmState, len(alarms))
+	for i := range alarms {
+		a := &alarms[i]
+		st := alarmState{
+			LastFired:      a.lastFired,
+			TriggeredCount: a.TriggeredCount,
+			HeldSince:      a.heldSince,
+			HeldCount:      a.heldCount,
+			HeldLast:       a.heldLast,
+			SnoozedUntil:   a.snoozedUntil,
+			Acknowledged:   a.acknowledged,
+			AckToken:       a.ackToken,
+			AckedBy:        a.ackedBy,
+			AckedAt:        a.ackedAt,
+		}
+		if len(a.previousValue) > 0 {
+			st.PreviousValues = make(map[string]float64, len(a.previousValue))
+			for k, v := range a.previousValue {
+				st.PreviousValues[k] = v
+			}
+		}
+		states[a.Name] = st
+	}
+	return states
+}
+
+// applyState restores runtime state onto the alarms with the same name;
+// alarms without saved state start fresh
+func applyState(alarms []Alarm, states map[string]alarmState) {
+	for i := range alarms {
+		a := &alarms[i]
+		st, ok := states[a.Name]
+		if !ok {
+			continue
+		}
+		a.lastFired = st.LastFired
+		a.TriggeredCount = st.TriggeredCount
+		a.heldSince, a.heldCount, a.heldLast = st.HeldSince, st.HeldCount, st.HeldLast
+		a.snoozedUntil, a.acknowledged = st.SnoozedUntil, st.Acknowledged
+		a.ackToken, a.ackedBy, a.ackedAt = st.AckToken, st.AckedBy, st.AckedAt
+		a.previousValue = nil
+		for k, v := range st.PreviousValues {
+			a.SetPreviousValue(k, v)
+		}
+	}
+}
+
+// saveStateLocked writes the runtime state of all alarms to the state file.
+// The file is written to a temporary name, synced and renamed, so a crash
+// leaves either the old or the new state. The caller holds m.mu.
+func (m *Manager) saveStateLocked() {
+	if stateFile == "" || m.config == nil {
+		return
+	}
+	data, err := json.MarshalIndent(runtimeState{Saved: time.Now(), Alarms: captureState(m.config.Alarms)}, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := writeFileSync(stateFile, data); err != nil {
+		logger.Warn("Failed to save alarm state: %v", err)
+	}
+}
+
+// writeFileSync replaces path with data atomically
+func writeFileSync(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadState reads the state file; a missing or corrupt file gives no state
+func loadState() map[string]alarmState {
+	if stateFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("Failed to read alarm state from %s: %v", stateFile, err)
+		}
+		return nil
+	}
+	var st runtimeState
+	if err := json.Unmarshal(data, &st); err != nil {
+		logger.Warn("Ignoring corrupt alarm state file %s: %v", stateFile, err)
+		return nil
+	}
+	return st.Alarms
+}
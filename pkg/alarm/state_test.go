@@ -0,0 +1,112 @@
+package alarm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+const stateTestConfig = `{"alarms": [
+	{"name": "Hot", "condition": "temperature > 30", "enabled": true, "cooldown": 3600,
+	 "channels": [{"type": "console", "template": "hot"}]},
+	{"name": "Pressure change", "condition": "*pressure", "enabled": true,
+	 "channels": [{"type": "console", "template": "pressure"}]},
+	{"name": "Humid", "condition": "humidity > 90", "enabled": true, "sustained_for": "3 obs",
+	 "channels": [{"type": "console", "template": "humid"}]}
+]}`
+
+// stateAlarm returns the manager's alarm with its runtime state
+func stateAlarm(m *Manager, name string) *Alarm {
+	for i := range m.config.Alarms {
+		if m.config.Alarms[i].Name == name {
+			return &m.config.Alarms[i]
+		}
+	}
+	return nil
+}
+
+func TestManagerStateSurvivesRestart(t *testing.T) {
+	SetStateFile(filepath.Join(t.TempDir(), "alarm-state.json"))
+	defer SetStateFile("")
+
+	start := time.Now().Add(-10 * time.Minute)
+	first, err := NewManager(stateTestConfig, "TestStation")
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	for i, humidity := range []float64{95, 95} {
+		obs := &weather.Observation{Timestamp: start.Add(time.Duration(i) * time.Minute).Unix(),
+			AirTemperature: 35, StationPressure: 1010, RelativeHumidity: humidity}
+		first.processObservationLocked(obs, nil)
+	}
+	first.Stop()
+
+	// A restarted manager is still in cooldown, remembers the pressure and
+	// continues the sustained_for count
+	second, err := NewManager(stateTestConfig, "TestStation")
+	if err != nil {
+		t.Fatalf("NewManager after restart: %v", err)
+	}
+	defer second.Stop()
+	hot := stateAlarm(second, "Hot")
+	if hot.TriggeredCount != 1 || !hot.IsInCooldown() {
+		t.Errorf("Hot after restart: count %d, in cooldown %v", hot.TriggeredCount, hot.IsInCooldown())
+	}
+
+	obs := &weather.Observation{Timestamp: start.Add(2 * time.Minute).Unix(),
+		AirTemperature: 35, StationPressure: 1010, RelativeHumidity: 95}
+	fired := map[string]bool{}
+	for _, ev := range second.processObservationLocked(obs, nil) {
+		fired[ev.Name] = true
+	}
+	if fired["Hot"] {
+		t.Error("Hot fired again during its cooldown after a restart")
+	}
+	if fired["Pressure change"] {
+		t.Error("Pressure change fired for an unchanged pressure after a restart")
+	}
+	if !fired["Humid"] {
+		t.Error("Humid did not fire on its third observation across the restart")
+	}
+}
+
+func TestManagerStateIgnoresCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alarm-state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	SetStateFile(path)
+	defer SetStateFile("")
+
+	manager, err := NewManager(stateTestConfig, "TestStation")
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer manager.Stop()
+	if hot := stateAlarm(manager, "Hot"); hot.TriggeredCount != 0 || !hot.CanFire() {
+		t.Errorf("Hot should start fresh, got count %d", hot.TriggeredCount)
+	}
+}
+
+func TestReloadKeepsState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alarms.json")
+	if err := os.WriteFile(path, []byte(stateTestConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manager, err := NewManager("@"+path, "TestStation")
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer manager.Stop()
+	manager.processObservationLocked(&weather.Observation{Timestamp: time.Now().Unix(), AirTemperature: 35}, nil)
+
+	if err := manager.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if hot := stateAlarm(manager, "Hot"); hot.TriggeredCount != 1 || !hot.IsInCooldown() {
+		t.Errorf("reload reset Hot: count %d", hot.TriggeredCount)
+	}
+}
@@ -0,0 +1,41 @@
+package alarm
+
+import (
+	"fmt"
+
+	"tempest-homekit-go/pkg/stats"
+)
+
+// StatsProvider returns the daily statistics summary
+type StatsProvider func() stats.Summary
+
+// SetStatsProvider supplies the growing degree day, chill hour, solar
+// energy and UV dose fields.
+// Without a provider those fields cannot be evaluated.
+func (e *Evaluator) SetStatsProvider(fn StatsProvider) {
+	e.stats = fn
+}
+
+// getStatsValue resolves a field from the daily statistics
+func (e *Evaluator) getStatsValue(field string) (float64, error) {
+	if e.stats == nil {
+		return 0, fmt.Errorf("%s unavailable: daily statistics are not running", field)
+	}
+	s := e.stats()
+	switch field {
+	case "gdd_today":
+		return s.GDD.Today, nil
+	case "gdd_season":
+		return s.GDD.Season, nil
+	case "chill_hours_today":
+		return s.ChillHours.Today, nil
+	case "chill_hours_season":
+		return s.ChillHours.Season, nil
+	case "solar_daily":
+		return s.Solar.Today, nil
+	case "uv_dose":
+		return s.UV.Today, nil
+	default:
+		return 0, fmt.Errorf("unknown field: %s", field)
+	}
+}
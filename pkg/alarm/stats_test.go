@@ -0,0 +1,46 @@
+package alarm
+
+import (
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/stats"
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestEvaluator_StatsFields(t *testing.T) {
+	e := NewEvaluator()
+	obs := &weather.Observation{AirTemperature: 25}
+	if _, err := e.Evaluate("gdd_season > 1200", obs); err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Errorf("gdd_season without statistics: err = %v, want unavailable", err)
+	}
+
+	e.SetStatsProvider(func() stats.Summary {
+		return stats.Summary{
+			GDD:        stats.GDDSummary{Today: 8.5, Season: 1250},
+			ChillHours: stats.ChillSummary{Today: 3, Season: 640},
+			Solar:      stats.DoseSummary{Unit: "Wh/m²", Today: 6400},
+			UV:         stats.DoseSummary{Unit: "SED", Today: 4.5},
+		}
+	})
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"gdd_season > 1200", true},
+		{"gdd_today >= 10", false},
+		{"chill_hours_season >= 600 && temperature > 20", true},
+		{"chill_hours_today > 5", false},
+		{"solar_daily > 6kWh", true},
+		{"solar_daily > 6.5kWh/m²", false},
+		{"solar_daily >= 6400Wh", true},
+		{"uv_dose > 4SED", true},
+		{"uv_dose > 5", false},
+	}
+	for _, tt := range tests {
+		got, err := e.Evaluate(tt.condition, obs)
+		if err != nil || got != tt.want {
+			t.Errorf("%s = %v, %v; want %v", tt.condition, got, err, tt.want)
+		}
+	}
+}
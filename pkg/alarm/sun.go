@@ -0,0 +1,112 @@
+package alarm
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// sunFields are the condition fields derived from the sun's position at the
+// station, so conditions such as "lux < 500 && is_daytime" need no schedule
+var sunFields = map[string]bool{
+	"is_daytime":         true,
+	"sun_elevation":      true,
+	"minutes_to_sunset":  true,
+	"minutes_to_sunrise": true,
+}
+
+// booleanFields read as 1 or 0 and may be used bare ("is_daytime") or
+// negated ("!is_daytime") in conditions
+var booleanFields = map[string]bool{
+	"is_daytime":         true,
+	"token_invalid":      true,
+	"token_rate_limited": true,
+}
+
+// horizonElevation is the sun's elevation at sunrise and sunset in degrees,
+// allowing for refraction and the size of the disc
+const horizonElevation = -0.833
+
+// SetLocation sets the station coordinates used by the sun fields
+func (e *Evaluator) SetLocation(latitude, longitude float64) {
+	e.latitude, e.longitude = latitude, longitude
+}
+
+// getSunValue resolves a sun field at the observation time
+func (e *Evaluator) getSunValue(field string, obs *weather.Observation) (float64, error) {
+	if e.latitude == 0 && e.longitude == 0 {
+		return 0, fmt.Errorf("%s unavailable: station location unknown", field)
+	}
+	now := time.Now()
+	if obs != nil && obs.Timestamp > 0 {
+		now = time.Unix(obs.Timestamp, 0)
+	}
+
+	switch field {
+	case "sun_elevation":
+		return sunElevation(now, e.latitude, e.longitude), nil
+	case "is_daytime":
+		return boolMetric(sunElevation(now, e.latitude, e.longitude) > horizonElevation), nil
+	case "minutes_to_sunset", "minutes_to_sunrise":
+		next, ok := nextSunEvent(now, e.latitude, e.longitude, field == "minutes_to_sunset")
+		if !ok {
+			return 0, fmt.Errorf("%s unavailable: no sunrise or sunset at this latitude today", field)
+		}
+		return next.Sub(now).Minutes(), nil
+	}
+	return 0, fmt.Errorf("unknown field: %s", field)
+}
+
+// solarParams returns the sun's declination (radians) and the equation of
+// time (minutes) at t, using the NOAA approximations
+func solarParams(t time.Time) (declination, eqTime float64) {
+	t = t.UTC()
+	g := 2 * math.Pi / 365 * (float64(t.YearDay()-1) + (float64(t.Hour())-12)/24)
+	eqTime = 229.18 * (0.000075 + 0.001868*math.Cos(g) - 0.032077*math.Sin(g) -
+		0.014615*math.Cos(2*g) - 0.040849*math.Sin(2*g))
+	declination = 0.006918 - 0.399912*math.Cos(g) + 0.070257*math.Sin(g) -
+		0.006758*math.Cos(2*g) + 0.000907*math.Sin(2*g) -
+		0.002697*math.Cos(3*g) + 0.00148*math.Sin(3*g)
+	return declination, eqTime
+}
+
+// sunElevation returns the sun's elevation above the horizon in degrees
+func sunElevation(t time.Time, latitude, longitude float64) float64 {
+	decl, eqTime := solarParams(t)
+	u := t.UTC()
+	minutes := float64(u.Hour()*60+u.Minute()) + float64(u.Second())/60
+	hourAngle := ((minutes+eqTime+4*longitude)/4 - 180) * math.Pi / 180
+	lat := latitude * math.Pi / 180
+	cosZenith := math.Sin(lat)*math.Sin(decl) + math.Cos(lat)*math.Cos(decl)*math.Cos(hourAngle)
+	return 90 - math.Acos(math.Max(-1, math.Min(1, cosZenith)))*180/math.Pi
+}
+
+// sunEvent returns sunrise or sunset on the UTC day of t, and false when the
+// sun stays above or below the horizon all day
+func sunEvent(t time.Time, latitude, longitude float64, sunset bool) (time.Time, bool) {
+	day := time.Date(t.UTC().Year(), t.UTC().Month(), t.UTC().Day(), 12, 0, 0, 0, time.UTC)
+	decl, eqTime := solarParams(day)
+	lat := latitude * math.Pi / 180
+	cosHA := math.Cos((90-horizonElevation)*math.Pi/180)/(math.Cos(lat)*math.Cos(decl)) - math.Tan(lat)*math.Tan(decl)
+	if cosHA < -1 || cosHA > 1 {
+		return time.Time{}, false
+	}
+	ha := math.Acos(cosHA) * 180 / math.Pi
+	if !sunset {
+		ha = -ha
+	}
+	minutes := 720 - 4*(longitude-ha) - eqTime
+	return day.Add(time.Duration((minutes - 720) * float64(time.Minute))), true
+}
+
+// nextSunEvent returns the first sunset (or sunrise) after now
+func nextSunEvent(now time.Time, latitude, longitude float64, sunset bool) (time.Time, bool) {
+	for d := -1; d <= 2; d++ {
+		if at, ok := sunEvent(now.AddDate(0, 0, d), latitude, longitude, sunset); ok && at.After(now) {
+			return at, true
+		}
+	}
+	return time.Time{}, false
+}
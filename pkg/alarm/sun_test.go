@@ -0,0 +1,79 @@
+package alarm
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// Denver on the June solstice: sunrise 05:32 MDT, sunset 20:31 MDT, solar
+// noon near 13:01 MDT with the sun about 73.7° high
+const denverLat, denverLon = 39.74, -104.99
+
+func TestSunElevationAndEvents(t *testing.T) {
+	noon := time.Date(2026, 6, 21, 19, 1, 0, 0, time.UTC)
+	if el := sunElevation(noon, denverLat, denverLon); math.Abs(el-73.7) > 0.5 {
+		t.Errorf("solar noon elevation = %.2f°, want about 73.7°", el)
+	}
+	if el := sunElevation(noon.Add(12*time.Hour), denverLat, denverLon); el > -20 {
+		t.Errorf("midnight elevation = %.2f°, want well below the horizon", el)
+	}
+
+	sunset, ok := nextSunEvent(noon, denverLat, denverLon, true)
+	want := time.Date(2026, 6, 22, 2, 31, 0, 0, time.UTC)
+	if !ok || sunset.Sub(want).Abs() > 3*time.Minute {
+		t.Errorf("sunset = %v, want about %v", sunset, want)
+	}
+	sunrise, ok := nextSunEvent(noon, denverLat, denverLon, false)
+	want = time.Date(2026, 6, 22, 11, 32, 0, 0, time.UTC)
+	if !ok || sunrise.Sub(want).Abs() > 3*time.Minute {
+		t.Errorf("sunrise = %v, want about %v", sunrise, want)
+	}
+
+	if _, ok := sunEvent(noon, 80, 0, true); ok {
+		t.Error("expected no sunset during the polar day")
+	}
+}
+
+func TestEvaluateSunFields(t *testing.T) {
+	e := NewEvaluator()
+	afternoon := &weather.Observation{Timestamp: time.Date(2026, 6, 21, 23, 0, 0, 0, time.UTC).Unix(), Illuminance: 300}
+	night := &weather.Observation{Timestamp: time.Date(2026, 6, 22, 6, 0, 0, 0, time.UTC).Unix(), Illuminance: 0}
+
+	if _, err := e.Evaluate("is_daytime", afternoon); err == nil {
+		t.Error("expected an error without a station location")
+	}
+	e.SetLocation(denverLat, denverLon)
+
+	tests := []struct {
+		condition string
+		obs       *weather.Observation
+		want      bool
+	}{
+		{"lux < 500 && is_daytime", afternoon, true},
+		{"lux < 500 AND is_daytime", night, false},
+		{"!is_daytime", night, true},
+		{"is_daytime == true", afternoon, true},
+		{"is_daytime == 0", night, true},
+		{"sun_elevation > 20", afternoon, true},
+		{"minutes_to_sunset < 240", afternoon, true}, // about 211 minutes
+		{"minutes_to_sunset < 180", afternoon, false},
+		{"minutes_to_sunrise < 360", night, true}, // about 332 minutes
+	}
+	for _, tt := range tests {
+		got, err := e.Evaluate(tt.condition, tt.obs)
+		if err != nil {
+			t.Errorf("Evaluate(%q) error: %v", tt.condition, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tt.condition, got, tt.want)
+		}
+	}
+
+	if got := e.Paraphrase("lux < 500 && is_daytime"); got != "When light level is below 500 AND it is daytime" {
+		t.Errorf("Paraphrase = %q", got)
+	}
+}
@@ -0,0 +1,72 @@
+package alarm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// parseSustainedFor parses an alarm's sustained_for: a duration ("10m",
+// "90s") or a number of consecutive observations ("3 obs", "3 observations")
+func parseSustainedFor(value string) (d time.Duration, observations int, err error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return 0, 0, nil
+	}
+	for _, suffix := range []string{"observations", "observation", "obs"} {
+		if n, found := strings.CutSuffix(value, suffix); found {
+			observations, err = strconv.Atoi(strings.TrimSpace(n))
+			if err != nil || observations < 1 {
+				return 0, 0, fmt.Errorf("invalid sustained_for %q (use e.g. 3 obs)", value)
+			}
+			return 0, observations, nil
+		}
+	}
+	d, err = time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, 0, fmt.Errorf("invalid sustained_for %q (use a duration such as 10m, or a count such as 3 obs)", value)
+	}
+	return d, 0, nil
+}
+
+// sustained records whether the condition held for obs and reports whether
+// it has now held for the alarm's sustained_for. Alarms without
+// sustained_for fire on the first observation that meets the condition.
+// Re-evaluating the same observation (for bridge health fields) does not
+// count as another observation.
+func (a *Alarm) sustained(held bool, obs *weather.Observation) bool {
+	d, count, err := parseSustainedFor(a.SustainedFor)
+	if err != nil || (d == 0 && count == 0) {
+		return held
+	}
+	if !held {
+		a.heldSince, a.heldCount, a.heldLast = time.Time{}, 0, 0
+		return false
+	}
+
+	at := time.Now()
+	if obs != nil && obs.Timestamp > 0 {
+		at = time.Unix(obs.Timestamp, 0)
+	}
+	if a.heldCount == 0 {
+		a.heldSince = at
+	}
+	if a.heldCount == 0 || obs == nil || obs.Timestamp != a.heldLast {
+		a.heldCount++
+	}
+	if obs != nil {
+		a.heldLast = obs.Timestamp
+	}
+	if count > 0 {
+		return a.heldCount >= count
+	}
+	return at.Sub(a.heldSince) >= d
+}
+
+// resetSustained starts a new sustained_for period after the alarm fires
+func (a *Alarm) resetSustained() {
+	a.heldSince, a.heldCount, a.heldLast = time.Time{}, 0, 0
+}
@@ -0,0 +1,72 @@
+package alarm
+
+import (
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestParseSustainedFor(t *testing.T) {
+	tests := []struct {
+		in      string
+		d       time.Duration
+		count   int
+		wantErr bool
+	}{
+		{"", 0, 0, false},
+		{"10m", 10 * time.Minute, 0, false},
+		{"3 obs", 0, 3, false},
+		{"2observations", 0, 2, false},
+		{"0 obs", 0, 0, true},
+		{"-5m", 0, 0, true},
+		{"soon", 0, 0, true},
+	}
+	for _, tt := range tests {
+		d, count, err := parseSustainedFor(tt.in)
+		if (err != nil) != tt.wantErr || d != tt.d || count != tt.count {
+			t.Errorf("parseSustainedFor(%q) = %v, %d, %v", tt.in, d, count, err)
+		}
+	}
+}
+
+func TestManagerSustainedFor(t *testing.T) {
+	config := `{"alarms": [
+		{"name": "Windy", "condition": "wind_gust > 10", "enabled": true, "sustained_for": "10m",
+		 "channels": [{"type": "console", "template": "windy"}]},
+		{"name": "Humid", "condition": "humidity > 90", "enabled": true, "sustained_for": "3 obs",
+		 "channels": [{"type": "console", "template": "humid"}]}
+	]}`
+	manager, err := NewManager(config, "TestStation")
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer manager.Stop()
+
+	start := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	fired := map[string][]int{}
+	readings := []struct{ gust, humidity float64 }{
+		{12, 95}, // 0: both start holding
+		{12, 95}, // 5 min
+		{8, 80},  // 10 min: a lull resets both
+		{12, 95}, // 15 min
+		{12, 95}, // 20 min
+		{12, 95}, // 25 min: Humid held 3 observations
+		{12, 95}, // 30 min: both start over after firing
+		{12, 95}, // 35 min
+	}
+	for i, r := range readings {
+		obs := &weather.Observation{Timestamp: start.Add(time.Duration(i*5) * time.Minute).Unix(), WindGust: r.gust, RelativeHumidity: r.humidity}
+		for _, ev := range manager.processObservationLocked(obs, nil) {
+			fired[ev.Name] = append(fired[ev.Name], i)
+		}
+		// Metric re-evaluation of the same observation does not count again
+		manager.processObservationLocked(obs, func(*Alarm) bool { return false })
+	}
+	if got := fired["Humid"]; len(got) != 1 || got[0] != 5 {
+		t.Errorf("Humid fired at %v, want once at 5 (third observation after the lull)", got)
+	}
+	if got := fired["Windy"]; len(got) != 1 || got[0] != 5 {
+		t.Errorf("Windy fired at %v, want once at 5 (10 minutes after 15)", got)
+	}
+}
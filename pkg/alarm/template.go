@@ -0,0 +1,254 @@
+package alarm
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// Notification templates are Go text/template documents. Every legacy
+// {{variable}} placeholder is registered as a function returning the
+// formatted string it used to be replaced with, so existing templates render
+// unchanged, while new templates can use the raw values on the dot
+// (templateData) with actions, pipelines and the helpers in templateHelpers:
+//
+//	{{if gt .Temperature 30.0}}Hot: {{.Temperature | cToF | round 0}}°F{{end}}
+
+// templateData is the dot value of notification templates
+type templateData struct {
+	Alarm             *Alarm
+	Obs               *weather.Observation
+	Station           string
+	Time              time.Time
+	Temperature       float64 // °C
+	Humidity          float64 // %
+	Pressure          float64 // station pressure, mb
+	PressureLocal     float64 // station pressure in PressureUnit
+	PressureUnit      string  // notification or export pressure unit, inHg or mb
+	WindSpeed         float64 // m/s
+	WindGust          float64 // m/s
+	WindDirection     float64 // degrees
+	Lux               float64
+	UV                int
+	RainRate          float64 // mm/hr
+	RainDaily         float64 // mm
+	SnowRate          float64 // mm/hr
+	SnowDaily         float64 // mm
+	LightningCount    int
+	LightningDistance float64 // km
+	LightningNearest  float64 // km, nearest strike of the current storm
+	LightningRate     float64 // strikes per minute
+	// Last holds the values the alarm compared against, by condition field
+	// name (temperature, humidity, wind_speed...), when known
+	Last map[string]float64
+	HTML bool
+}
+
+// templateKeywords are text/template words that look like legacy
+// placeholders but must stay actions
+var templateKeywords = map[string]bool{
+	"end": true, "else": true, "break": true, "continue": true, "nil": true, "true": true, "false": true,
+}
+
+// placeholderPattern matches a bare {{name}} action
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// templateFields are the condition fields exposed in templateData.Last
+var templateFields = []string{
+	"temperature", "humidity", "pressure", "wind_speed", "wind_gust", "wind_direction",
+	"lux", "uv", "rain_rate", "rain_daily", "lightning_count", "lightning_distance",
+	"lightning_nearest", "lightning_rate",
+}
+
+// newTemplateData collects the raw values of a notification
+func newTemplateData(alarm *Alarm, obs *weather.Observation, stationName string, isHTML bool, pressureUnit string) templateData {
+	data := templateData{
+		Alarm:             alarm,
+		Obs:               obs,
+		Station:           stationName,
+		Time:              time.Unix(obs.Timestamp, 0),
+		Temperature:       obs.AirTemperature,
+		Humidity:          obs.RelativeHumidity,
+		Pressure:          obs.StationPressure,
+		PressureLocal:     weather.ConvertPressure(obs.StationPressure, pressureUnit),
+		PressureUnit:      pressureUnit,
+		WindSpeed:         obs.WindAvg,
+		WindGust:          obs.WindGust,
+		WindDirection:     obs.WindDirection,
+		Lux:               obs.Illuminance,
+		UV:                obs.UV,
+		RainRate:          obs.RainAccumulated,
+		RainDaily:         obs.RainDailyTotal,
+		SnowRate:          obs.SnowRate,
+		SnowDaily:         obs.SnowDailyTotal,
+		LightningCount:    obs.LightningStrikeCount,
+		LightningDistance: obs.LightningStrikeAvg,
+		LightningNearest:  obs.LightningNearest,
+		LightningRate:     obs.LightningRate,
+		Last:              map[string]float64{},
+		HTML:              isHTML,
+	}
+	for _, field := range templateFields {
+		if v, ok := alarm.GetTriggerValue(field); ok {
+			data.Last[field] = v
+		} else if v, ok := alarm.GetPreviousValue(field); ok {
+			data.Last[field] = v
+		}
+	}
+	return data
+}
+
+// renderTemplate executes text as a Go template. replacements maps legacy
+// "{{name}}" placeholders to their formatted values; each becomes a
+// function. Placeholders that are neither a legacy variable nor a helper are
+// kept literally, as the string substitution did. Templates that are not
+// valid Go templates fall back to plain substitution.
+func renderTemplate(text string, replacements map[string]string, data templateData) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+
+	funcs := templateHelpers()
+	for placeholder, value := range replacements {
+		value := value
+		funcs[strings.TrimSuffix(strings.TrimPrefix(placeholder, "{{"), "}}")] = func() string { return value }
+	}
+	escaped := placeholderPattern.ReplaceAllStringFunc(text, func(action string) string {
+		name := placeholderPattern.FindStringSubmatch(action)[1]
+		if _, ok := funcs[name]; ok || templateKeywords[name] {
+			return action
+		}
+		return `{{"` + action + `"}}`
+	})
+
+	tmpl, err := template.New("notification").Funcs(funcs).Parse(escaped)
+	if err == nil {
+		var b strings.Builder
+		if err = tmpl.Execute(&b, data); err == nil {
+			return b.String()
+		}
+	}
+	logger.Debug("Template is not a valid Go template (%v), using plain substitution", err)
+	result := text
+	for placeholder, value := range replacements {
+		result = strings.ReplaceAll(result, placeholder, value)
+	}
+	return result
+}
+
+// templateHelpers are the functions available to notification templates in
+// addition to the text/template builtins
+func templateHelpers() template.FuncMap {
+	return template.FuncMap{
+		// Numbers
+		"round": func(places int, v interface{}) float64 {
+			p := math.Pow(10, float64(places))
+			return math.Round(toFloat(v)*p) / p
+		},
+		"floor": func(v interface{}) float64 { return math.Floor(toFloat(v)) },
+		"ceil":  func(v interface{}) float64 { return math.Ceil(toFloat(v)) },
+		"abs":   func(v interface{}) float64 { return math.Abs(toFloat(v)) },
+		"add":   func(a, b interface{}) float64 { return toFloat(a) + toFloat(b) },
+		"sub":   func(a, b interface{}) float64 { return toFloat(a) - toFloat(b) },
+		"mul":   func(a, b interface{}) float64 { return toFloat(a) * toFloat(b) },
+		"div": func(a, b interface{}) float64 {
+			if toFloat(b) == 0 {
+				return 0
+			}
+			return toFloat(a) / toFloat(b)
+		},
+		"min": func(a, b interface{}) float64 { return math.Min(toFloat(a), toFloat(b)) },
+		"max": func(a, b interface{}) float64 { return math.Max(toFloat(a), toFloat(b)) },
+		"number": func(decimals int, v interface{}) string {
+			return strconv.FormatFloat(toFloat(v), 'f', decimals, 64)
+		},
+
+		// Units
+		"cToF":      func(v interface{}) float64 { return toFloat(v)*9/5 + 32 },
+		"fToC":      func(v interface{}) float64 { return (toFloat(v) - 32) * 5 / 9 },
+		"msToMph":   func(v interface{}) float64 { return toFloat(v) * 2.23694 },
+		"msToKmh":   func(v interface{}) float64 { return toFloat(v) * 3.6 },
+		"msToKnots": func(v interface{}) float64 { return toFloat(v) * 1.94384 },
+		"mmToIn":    func(v interface{}) float64 { return toFloat(v) / 25.4 },
+		"mbToInHg":  func(v interface{}) float64 { return toFloat(v) * 0.02953 },
+		"kmToMi":    func(v interface{}) float64 { return toFloat(v) * 0.621371 },
+		"distance":  func(v interface{}) string { return formatDistance(toFloat(v)) },
+		"compass":   compassPoint,
+
+		// Conditionals
+		"default": func(def, v interface{}) interface{} {
+			if isEmpty(v) {
+				return def
+			}
+			return v
+		},
+		"ternary": func(yes, no interface{}, cond bool) interface{} {
+			if cond {
+				return yes
+			}
+			return no
+		},
+		"empty": isEmpty,
+
+		// Strings and time
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"trim":     strings.TrimSpace,
+		"contains": func(substr, s string) bool { return strings.Contains(s, substr) },
+		"replace":  func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"join":     func(sep string, items []string) string { return strings.Join(items, sep) },
+		"date":     func(layout string, t time.Time) string { return t.Format(layout) },
+		"now":      time.Now,
+	}
+}
+
+// toFloat converts template numbers (and numeric strings) to float64
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f
+	}
+	return 0
+}
+
+// isEmpty reports whether v is a zero number, an empty string or nil
+func isEmpty(v interface{}) bool {
+	switch n := v.(type) {
+	case nil:
+		return true
+	case string:
+		return n == ""
+	case bool:
+		return !n
+	case []string:
+		return len(n) == 0
+	case int, int64, float32, float64:
+		return toFloat(n) == 0
+	}
+	return false
+}
+
+// compassPoint names a wind direction in degrees with one of 16 points
+func compassPoint(v interface{}) string {
+	points := []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+	deg := math.Mod(toFloat(v), 360)
+	if deg < 0 {
+		deg += 360
+	}
+	return points[int(math.Round(deg/22.5))%16]
+}
@@ -0,0 +1,59 @@
+package alarm
+
+import (
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestExpandTemplate_GoTemplate(t *testing.T) {
+	alarm := &Alarm{Name: "High Wind", Condition: "wind_gust > 10"}
+	alarm.SetTriggerContext(map[string]float64{"wind_gust": 9.5})
+	obs := &weather.Observation{
+		Timestamp:        1697234567,
+		AirTemperature:   31.26,
+		RelativeHumidity: 40,
+		WindGust:         12.5,
+		WindDirection:    225,
+		RainDailyTotal:   25.4,
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"legacy variables", "{{alarm_name}} at {{temperature}}°C", "High Wind at 31.3°C"},
+		{"legacy with spaces", "{{ alarm_name }}", "High Wind"},
+		{"unknown placeholder kept", "{{alarm_name}} {{unknown}}", "High Wind {{unknown}}"},
+		{"raw field", "{{.Temperature}}", "31.26"},
+		{"round", "{{round 1 .Temperature}}", "31.3"},
+		{"unit pipeline", "{{.Temperature | cToF | round 0}}°F", "88°F"},
+		{"number", "{{.WindGust | msToMph | number 1}} mph", "28.0 mph"},
+		{"compass", "from the {{compass .WindDirection}}", "from the SW"},
+		{"rain inches", "{{.RainDaily | mmToIn | number 2}}", "1.00"},
+		{"conditional", "{{if gt .Temperature 30.0}}hot{{else}}ok{{end}}", "hot"},
+		{"ternary", `{{ternary "windy" "calm" (gt .WindGust 10.0)}}`, "windy"},
+		{"default", `{{.Station | default "unknown"}}`, "unknown"},
+		{"last value", `{{index .Last "wind_gust"}}`, "9.5"},
+		{"strings", `{{alarm_name | upper}}`, "HIGH WIND"},
+		{"arithmetic", "{{sub .WindGust 2.5}}", "10"},
+		{"invalid template falls back", "{{alarm_name}} {{if}}", "High Wind {{if}}"},
+		{"missing field falls back", "{{alarm_name}} {{.Nope}}", "High Wind {{.Nope}}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandTemplate(tt.template, alarm, obs, ""); got != tt.want {
+				t.Errorf("expandTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompassPoint(t *testing.T) {
+	for deg, want := range map[float64]string{0: "N", 11: "N", 12: "NNE", 90: "E", 180: "S", 350: "N", 360: "N", -90: "W"} {
+		if got := compassPoint(deg); got != want {
+			t.Errorf("compassPoint(%v) = %q, want %q", deg, got, want)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package alarm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// maxHeldNotifications caps the notifications kept for one channel's digest;
+// older ones are only counted
+const maxHeldNotifications = 50
+
+// reasonQuietHours is the hold reason for notifications in quiet hours
+const reasonQuietHours = "quiet hours"
+
+// QuietHours is a daily time range (24-hour "HH:MM", end exclusive) in which
+// a channel holds its notifications. Ranges may wrap midnight.
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone,omitempty"` // IANA name, system timezone if empty
+}
+
+// Active reports whether now falls inside the quiet hours
+func (q *QuietHours) Active(now time.Time) bool {
+	if q == nil {
+		return false
+	}
+	start, err := parseTimeOfDay(q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseTimeOfDay(q.End)
+	if err != nil {
+		return false
+	}
+	if q.Timezone != "" {
+		if loc, err := time.LoadLocation(q.Timezone); err == nil {
+			now = now.In(loc)
+		}
+	}
+	minutes := now.Hour()*60 + now.Minute()
+	if end < start {
+		return minutes >= start || minutes < end
+	}
+	return minutes >= start && minutes < end
+}
+
+// Validate checks the quiet hours times and timezone
+func (q *QuietHours) Validate() error {
+	if _, err := parseTimeOfDay(q.Start); err != nil {
+		return fmt.Errorf("invalid quiet_hours start: %w", err)
+	}
+	if _, err := parseTimeOfDay(q.End); err != nil {
+		return fmt.Errorf("invalid quiet_hours end: %w", err)
+	}
+	if q.Start == q.End {
+		return fmt.Errorf("quiet_hours start and end must differ")
+	}
+	if q.Timezone != "" {
+		if _, err := time.LoadLocation(q.Timezone); err != nil {
+			return fmt.Errorf("invalid quiet_hours timezone %q: %w", q.Timezone, err)
+		}
+	}
+	return nil
+}
+
+// throttled reports whether the channel limits its notifications
+func (c *Channel) throttled() bool {
+	return c.QuietHours != nil || c.MaxPerHour > 0
+}
+
+// channelThrottle is the delivery state of one alarm channel
+type channelThrottle struct {
+	sent    []time.Time // sends in the last hour
+	held    []string    // digest lines of held notifications
+	dropped int         // held notifications beyond maxHeldNotifications
+	quiet   bool        // some were held for quiet hours
+	limited bool        // some were held by max_per_hour
+}
+
+// holdReason returns why a notification cannot be sent now, or "" if it can
+func (t *channelThrottle) holdReason(c *Channel, now time.Time) string {
+	if c.QuietHours.Active(now) {
+		return reasonQuietHours
+	}
+	if c.MaxPerHour > 0 {
+		cutoff := now.Add(-time.Hour)
+		recent := t.sent[:0]
+		for _, ts := range t.sent {
+			if ts.After(cutoff) {
+				recent = append(recent, ts)
+			}
+		}
+		t.sent = recent
+		if len(t.sent) >= c.MaxPerHour {
+			return fmt.Sprintf("limit of %d per hour", c.MaxPerHour)
+		}
+	}
+	return ""
+}
+
+// hold adds a notification to the next digest
+func (t *channelThrottle) hold(line, reason string) {
+	if len(t.held) >= maxHeldNotifications {
+		t.dropped++
+	} else {
+		t.held = append(t.held, line)
+	}
+	if reason == reasonQuietHours {
+		t.quiet = true
+	} else {
+		t.limited = true
+	}
+}
+
+// digest returns the subject and text summarizing the held notifications
+func (t *channelThrottle) digest(alarm *Alarm) (string, string) {
+	count := len(t.held) + t.dropped
+	var reasons []string
+	if t.quiet {
+		reasons = append(reasons, "quiet hours")
+	}
+	if t.limited {
+		reasons = append(reasons, "rate limit")
+	}
+	subject := fmt.Sprintf("Tempest alarm digest: %s (%d held)", alarm.Name, count)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d notification(s) for alarm %s were held (%s):\n", count, alarm.Name, strings.Join(reasons, ", "))
+	for _, line := range t.held {
+		b.WriteString("- " + line + "\n")
+	}
+	if t.dropped > 0 {
+		fmt.Fprintf(&b, "- ... and %d more\n", t.dropped)
+	}
+	return subject, strings.TrimSuffix(b.String(), "\n")
+}
+
+// digestLine summarizes a held notification
+func digestLine(alarm *Alarm, obs *weather.Observation, now time.Time) string {
+	return fmt.Sprintf("%s %s: %.1f°C, %.0f%% RH, wind %.1f m/s gusting %.1f m/s",
+		now.Format("2006-01-02 15:04"), alarm.Condition, obs.AirTemperature, obs.RelativeHumidity, obs.WindAvg, obs.WindGust)
+}
+
+// throttleKey identifies a channel of an alarm across reloads
+func throttleKey(alarm *Alarm, index int) string {
+	return fmt.Sprintf("%s\x00%d", alarm.Name, index)
+}
+
+// throttleFor returns the state of a channel, creating it. Callers hold m.mu.
+func (m *Manager) throttleFor(key string) *channelThrottle {
+	if m.throttles == nil {
+		m.throttles = make(map[string]*channelThrottle)
+	}
+	t, ok := m.throttles[key]
+	if !ok {
+		t = &channelThrottle{}
+		m.throttles[key] = t
+	}
+	return t
+}
+
+// flushDigests sends a digest through every channel with held notifications
+// whose quiet hours or rate limit window has reopened. Callers hold m.mu.
+func (m *Manager) flushDigests(obs *weather.Observation, now time.Time) {
+	for key, t := range m.throttles {
+		if len(t.held) == 0 {
+			continue
+		}
+		alarm, channel := m.findChannel(key)
+		if channel == nil || !channel.throttled() {
+			delete(m.throttles, key) // removed by a reload
+			continue
+		}
+		if t.holdReason(channel, now) != "" {
+			continue
+		}
+
+		subject, text := t.digest(alarm)
+		t.held, t.dropped, t.quiet, t.limited = nil, 0, false, false
+		t.sent = append(t.sent, now)
+
+		notifier, err := m.notifierFactory.GetNotifier(channel.Type)
+		if err != nil {
+			logger.Error("Failed to get notifier for %s: %v", channel.Type, err)
+			continue
+		}
+		doc := map[string]interface{}{"digest": true, "alarm": alarm.Name, "subject": subject, "message": text}
+		if err := notifier.Send(alarm, digestChannel(channel, subject, text, doc), obs, m.stationName); err != nil {
+			logger.Error("Failed to send %s digest for alarm %s: %v", channel.Type, alarm.Name, err)
+		} else {
+			logger.Info("Sent %s digest for alarm %s", channel.Type, alarm.Name)
+		}
+	}
+}
+
+// findChannel looks up the alarm channel a throttle key refers to
+func (m *Manager) findChannel(key string) (*Alarm, *Channel) {
+	for i := range m.config.Alarms {
+		alarm := &m.config.Alarms[i]
+		for j := range alarm.Channels {
+			if throttleKey(alarm, j) == key {
+				return alarm, &alarm.Channels[j]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// digestChannel returns a copy of c whose templates render a digest: text
+// for message channels, doc as JSON for JSON webhooks and the JSON log
+func digestChannel(c *Channel, subject, text string, doc map[string]interface{}) *Channel {
+	d := *c
+	literal := escapeTemplate(text)
+	d.Template = literal
+	if c.Email != nil {
+		email := *c.Email
+		email.Subject = escapeTemplate(subject)
+		email.Body = literal
+		email.Html = false
+		d.Email = &email
+	}
+	if c.SMS != nil {
+		sms := *c.SMS
+		sms.Message = literal
+		d.SMS = &sms
+	}
+	if c.Webhook != nil {
+		webhook := *c.Webhook
+		webhook.Body = literal
+		if strings.Contains(webhook.ContentType, "json") {
+			webhook.Body = escapeTemplate(marshalDigest(doc))
+		}
+		d.Webhook = &webhook
+	}
+	if c.CSV != nil {
+		csv := *c.CSV
+		csv.Message = `{{timestamp}},{{alarm_name}},` + escapeTemplate(strings.ReplaceAll(subject, ",", ";"))
+		d.CSV = &csv
+	}
+	if c.JSON != nil {
+		js := *c.JSON
+		js.Message = escapeTemplate(marshalDigest(doc))
+		d.JSON = &js
+	}
+	return &d
+}
+
+// marshalDigest encodes a digest document without HTML escaping, so
+// conditions such as "temperature > 25" stay readable
+func marshalDigest(doc map[string]interface{}) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(doc)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// escapeTemplate makes text render literally as a notification template
+func escapeTemplate(text string) string {
+	return strings.ReplaceAll(text, "{{", `{{"{{"}}`)
+}
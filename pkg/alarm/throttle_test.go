@@ -0,0 +1,127 @@
+package alarm
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestQuietHoursActive(t *testing.T) {
+	overnight := &QuietHours{Start: "22:00", End: "07:00", Timezone: "UTC"}
+	daytime := &QuietHours{Start: "12:00", End: "13:30", Timezone: "UTC"}
+	tests := []struct {
+		q    *QuietHours
+		at   string
+		want bool
+	}{
+		{overnight, "23:15", true},
+		{overnight, "03:00", true},
+		{overnight, "07:00", false},
+		{overnight, "21:59", false},
+		{daytime, "12:00", true},
+		{daytime, "13:29", true},
+		{daytime, "13:30", false},
+		{nil, "12:00", false},
+	}
+	for _, tt := range tests {
+		now, _ := time.Parse("15:04", tt.at)
+		if got := tt.q.Active(now); got != tt.want {
+			t.Errorf("%+v Active(%s) = %v, want %v", tt.q, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestChannelValidateThrottle(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel Channel
+		wantErr bool
+	}{
+		{"valid", Channel{Type: "console", Template: "x", MaxPerHour: 3, QuietHours: &QuietHours{Start: "22:00", End: "06:00"}}, false},
+		{"negative limit", Channel{Type: "console", Template: "x", MaxPerHour: -1}, true},
+		{"bad start", Channel{Type: "console", Template: "x", QuietHours: &QuietHours{Start: "25:00", End: "06:00"}}, true},
+		{"empty range", Channel{Type: "console", Template: "x", QuietHours: &QuietHours{Start: "06:00", End: "06:00"}}, true},
+		{"bad timezone", Channel{Type: "console", Template: "x", QuietHours: &QuietHours{Start: "22:00", End: "06:00", Timezone: "Mars/Olympus"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.channel.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestManagerRateLimitDigest(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	config := `{"alarms": [{
+		"name": "Hot",
+		"condition": "temperature > 25",
+		"enabled": true,
+		"channels": [{"type": "webhook", "max_per_hour": 2,
+			"webhook": {"url": "` + srv.URL + `", "body": "{\"text\": \"{{alarm_name}} {{temperature}}\"}"}}]
+	}]}`
+	manager, err := NewManager(config, "TestStation")
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer manager.Stop()
+
+	for i := 0; i < 5; i++ {
+		manager.ProcessObservation(&weather.Observation{AirTemperature: 30 + float64(i)})
+	}
+	mu.Lock()
+	if len(bodies) != 2 {
+		t.Fatalf("sent %d notifications over a limit of 2: %v", len(bodies), bodies)
+	}
+	mu.Unlock()
+
+	// Age the sends out of the window; the next observation sends the digest
+	manager.mu.Lock()
+	for _, th := range manager.throttles {
+		for i := range th.sent {
+			th.sent[i] = th.sent[i].Add(-2 * time.Hour)
+		}
+	}
+	manager.mu.Unlock()
+	manager.ProcessObservation(&weather.Observation{AirTemperature: 10})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 3 {
+		t.Fatalf("expected a digest after the window reopened, got %d bodies", len(bodies))
+	}
+	digest := bodies[2]
+	for _, want := range []string{`"digest":true`, "3 notification(s) for alarm Hot were held (rate limit)", "temperature > 25: 34.0°C"} {
+		if !strings.Contains(digest, want) {
+			t.Errorf("digest %s missing %q", digest, want)
+		}
+	}
+}
+
+func TestDigestChannelEscapesTemplates(t *testing.T) {
+	alarm := &Alarm{Name: "Hot"}
+	th := &channelThrottle{}
+	th.hold("{{temperature}} literal", reasonQuietHours)
+	subject, text := th.digest(alarm)
+	c := digestChannel(&Channel{Type: "console", Template: "{{alarm_name}}"}, subject, text, nil)
+	got := expandTemplate(c.Template, alarm, &weather.Observation{AirTemperature: 20}, "")
+	if !strings.Contains(got, "- {{temperature}} literal") || !strings.Contains(got, "(quiet hours)") {
+		t.Errorf("digest rendered as %q", got)
+	}
+}
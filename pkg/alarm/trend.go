@@ -0,0 +1,98 @@
+package alarm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// HistoryProvider returns the stored observations, oldest first. The slice
+// is only read.
+type HistoryProvider func() []weather.Observation
+
+// maxTrendGap is how far before the start of a trend window the reference
+// observation may be; with a larger gap the trend reads 0
+const maxTrendGap = 15 * time.Minute
+
+// SetHistoryProvider supplies the observation history behind the
+// delta(field, window) and rate(field, window) fields. Without a provider
+// they cannot be evaluated.
+func (e *Evaluator) SetHistoryProvider(fn HistoryProvider) {
+	e.history = fn
+}
+
+// trendField splits a "delta(field, window)" or "rate(field, window)"
+// condition field into the function, sensor field and window
+func trendField(field string) (fn, inner string, window time.Duration, ok bool, err error) {
+	for _, name := range []string{"delta", "rate"} {
+		if strings.HasPrefix(field, name+"(") && strings.HasSuffix(field, ")") {
+			fn = name
+			break
+		}
+	}
+	if fn == "" {
+		return "", "", 0, false, nil
+	}
+	args := strings.Split(field[len(fn)+1:len(field)-1], ",")
+	if len(args) != 2 {
+		return fn, "", 0, true, fmt.Errorf("%s needs a field and a window, e.g. %s(pressure, 3h)", fn, fn)
+	}
+	inner = strings.ReplaceAll(strings.TrimSpace(args[0]), " ", "_")
+	seconds, err := parseDurationSeconds(args[1])
+	if err != nil || seconds <= 0 {
+		return fn, inner, 0, true, fmt.Errorf("invalid %s window %q (use e.g. 30m or 3h)", fn, strings.TrimSpace(args[1]))
+	}
+	if metricFields[inner] || sunFields[inner] || strings.Contains(inner, "(") || strings.HasPrefix(inner, "gdd_") || strings.HasPrefix(inner, "chill_hours_") {
+		return fn, inner, 0, true, fmt.Errorf("%s only applies to observation fields, not %s", fn, inner)
+	}
+	return fn, inner, time.Duration(seconds * float64(time.Second)), true, nil
+}
+
+// getTrendValue resolves delta (change over the window) or rate (average
+// change per hour over the window) for an observation field. Without history
+// reaching back to the start of the window it reads 0, so conditions stay
+// quiet after a restart.
+func (e *Evaluator) getTrendValue(fn, field string, window time.Duration, obs *weather.Observation) (float64, error) {
+	current, err := e.getFieldValue(field, obs)
+	if err != nil {
+		return 0, err
+	}
+	if e.history == nil {
+		return 0, fmt.Errorf("%s(%s) unavailable: no observation history", fn, field)
+	}
+	now := time.Now()
+	if obs.Timestamp > 0 {
+		now = time.Unix(obs.Timestamp, 0)
+	}
+	start := now.Add(-window).Unix()
+
+	history := e.history()
+	// Newest observation at or before the start of the window
+	i := sort.Search(len(history), func(i int) bool { return history[i].Timestamp > start }) - 1
+	if i < 0 || start-history[i].Timestamp > int64(maxTrendGap/time.Second) {
+		return 0, nil
+	}
+	past, err := e.getFieldValue(field, &history[i])
+	if err != nil {
+		return 0, err
+	}
+	delta := current - past
+	if fn == "rate" {
+		return delta / now.Sub(time.Unix(history[i].Timestamp, 0)).Hours(), nil
+	}
+	return delta, nil
+}
+
+// formatWindow writes a trend window the way it is usually typed, e.g. 3h or 90m
+func formatWindow(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return d.String()
+}
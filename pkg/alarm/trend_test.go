@@ -0,0 +1,63 @@
+package alarm
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestEvaluateTrendFields(t *testing.T) {
+	start := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	var history []weather.Observation
+	for m := 0; m <= 180; m += 5 {
+		// Pressure falls 1.5 mb per hour, temperature rises 2 °C per hour
+		history = append(history, weather.Observation{
+			Timestamp:       start.Add(time.Duration(m) * time.Minute).Unix(),
+			StationPressure: 1010 - 1.5*float64(m)/60,
+			AirTemperature:  10 + 2*float64(m)/60,
+		})
+	}
+	now := history[len(history)-1]
+
+	e := NewEvaluator()
+	if _, err := e.Evaluate("delta(pressure, 3h) < -4", &now); err == nil {
+		t.Error("expected an error without a history provider")
+	}
+	e.SetHistoryProvider(func() []weather.Observation { return history })
+
+	if v, err := e.getFieldValue("delta(pressure, 3h)", &now); err != nil || math.Abs(v+4.5) > 1e-9 {
+		t.Errorf("delta(pressure, 3h) = %v, %v; want -4.5", v, err)
+	}
+	if v, err := e.getFieldValue("rate(temperature, 90m)", &now); err != nil || math.Abs(v-2) > 1e-9 {
+		t.Errorf("rate(temperature, 90m) = %v, %v; want 2", v, err)
+	}
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"delta(pressure, 3h) < -4", true},
+		{"delta(pressure, 1h) < -4", false},
+		{"delta(pressure, 3h) < -4 && rate(temperature, 1h) > 1.5", true},
+		{"delta(pressure, 6h) < -4", false}, // history does not reach back 6 hours
+	}
+	for _, tt := range tests {
+		got, err := e.Evaluate(tt.condition, &now)
+		if err != nil || got != tt.want {
+			t.Errorf("Evaluate(%q) = %v, %v; want %v", tt.condition, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"delta(pressure) < 1", "delta(pressure, soon) < 1", "rate(data_age, 1h) > 1", "delta(nope, 1h) > 1"} {
+		if _, err := e.Evaluate(bad, &now); err == nil {
+			t.Errorf("Evaluate(%q) should fail", bad)
+		}
+	}
+
+	if got := e.Paraphrase("delta(pressure, 3h) < -4"); !strings.Contains(got, "pressure change over 3h is below -4") {
+		t.Errorf("Paraphrase = %q", got)
+	}
+}
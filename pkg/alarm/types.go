@@ -1,12 +1,18 @@
 package alarm
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/secrets"
+	"tempest-homekit-go/pkg/weather"
 )
 
 // AlarmConfig represents the alarm system configuration.
@@ -16,6 +22,10 @@ import (
 type AlarmConfig struct {
 	// List of alarm rules
 	Alarms []Alarm `json:"alarms"`
+	// Scheduled daily or weekly summaries
+	Digests []Digest `json:"digests,omitempty"`
+	// Periodic uploads of file channel exports to object storage
+	Uploads []Upload `json:"uploads,omitempty"`
 
 	// Internal: Global email settings (loaded from .env, not JSON)
 	Email *EmailGlobalConfig `json:"-"`
@@ -39,6 +49,8 @@ type EmailGlobalConfig struct {
 	ClientID     string `json:"client_id,omitempty"`
 	ClientSecret string `json:"client_secret,omitempty"`
 	TenantID     string `json:"tenant_id,omitempty"`
+	// Directory of the theme and wrapper templates of HTML emails (EMAIL_TEMPLATES_DIR, see emailtheme.go)
+	TemplatesDir string `json:"templates_dir,omitempty"`
 }
 
 // SMSGlobalConfig contains global SMS configuration
@@ -70,12 +82,37 @@ type Alarm struct {
 	Condition   string    `json:"condition"`          // e.g., "temperature > 85", "humidity > 80 && temperature > 35", "*lightning_count"
 	Cooldown    int       `json:"cooldown,omitempty"` // Seconds between repeated notifications
 	Schedule    *Schedule `json:"schedule,omitempty"` // Optional schedule defining when alarm is active
-	Channels    []Channel `json:"channels"`
+	// SustainedFor requires the condition to hold continuously for a duration
+	// ("10m") or a number of observations ("3 obs") before the alarm fires
+	SustainedFor string    `json:"sustained_for,omitempty"`
+	Channels     []Channel `json:"channels"`
 	// TriggeredCount tracks how many times this alarm has been triggered since process start
 	TriggeredCount int                `json:"triggered_count,omitempty"`
 	lastFired      time.Time          // Internal: last trigger time
 	previousValue  map[string]float64 // Internal: previous field values for change detection
 	triggerContext map[string]float64 // Internal: field values at time of trigger (for notification display)
+	heldSince      time.Time          // Internal: when the condition started holding (sustained_for)
+	heldCount      int                // Internal: consecutive observations meeting the condition
+	heldLast       int64              // Internal: timestamp of the last observation counted
+	snoozedUntil   time.Time          // Internal: notifications are silenced until then (Snooze)
+	acknowledged   bool               // Internal: silenced until the condition clears (Acknowledge)
+	ackToken       string             // Internal: acknowledgement token of the latest notifications (see ack.go)
+	ackedBy        string             // Internal: who acknowledged them
+	ackedAt        time.Time          // Internal: when they were acknowledged
+}
+
+// builtinChannelTypes lists the channel types handled in-process. Any other
+// type must be provided by a plugin (see LoadPlugins).
+var builtinChannelTypes = map[string]bool{
+	"console":  true,
+	"email":    true,
+	"sms":      true,
+	"syslog":   true,
+	"oslog":    true,
+	"eventlog": true,
+	"webhook":  true,
+	"csv":      true,
+	"json":     true,
 }
 
 // Channel represents a notification channel
@@ -87,6 +124,21 @@ type Channel struct {
 	Webhook  *WebhookConfig `json:"webhook,omitempty"`
 	CSV      *CSVConfig     `json:"csv,omitempty"`
 	JSON     *JSONConfig    `json:"json,omitempty"`
+	// Plugin holds free-form options passed to a plugin channel handler
+	Plugin map[string]interface{} `json:"plugin,omitempty"`
+	// QuietHours holds this channel's notifications during a daily time
+	// range; they are sent as one digest when it ends
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+	// MaxPerHour limits the notifications sent through this channel in any
+	// rolling hour, independent of the alarm cooldown (0 = unlimited).
+	// Notifications over the limit are sent as a digest later.
+	MaxPerHour int `json:"max_per_hour,omitempty"`
+	// History includes the observations before the trigger with email
+	// (attached file) and webhook (JSON body field) notifications
+	History *HistoryAttachment `json:"history,omitempty"`
+
+	// recent holds the History observations collected when the alarm fired
+	recent []weather.Observation
 }
 
 // EmailConfig holds email-specific configuration for a channel
@@ -97,6 +149,9 @@ type EmailConfig struct {
 	CC      []string `json:"cc,omitempty"`
 	BCC     []string `json:"bcc,omitempty"`
 	Html    bool     `json:"html,omitempty"`
+	// Wrapper names the HTML wrapper (<name>.html in EMAIL_TEMPLATES_DIR);
+	// empty uses wrapper.html or the built-in layout, "none" sends the body as is
+	Wrapper string `json:"wrapper,omitempty"`
 }
 
 // SMSConfig holds SMS-specific configuration for a channel
@@ -112,28 +167,69 @@ type WebhookConfig struct {
 	Headers     map[string]string `json:"headers,omitempty"`
 	Body        string            `json:"body,omitempty"`
 	ContentType string            `json:"content_type,omitempty"`
+	// HMACSecret signs each request with X-Tempest-Signature and
+	// X-Tempest-Timestamp headers (HMAC-SHA256, see pkg/websec). Secrets may
+	// be given as ${ENV_VAR}, a pkg/secrets reference or an enc:v1: value.
+	HMACSecret string          `json:"hmac_secret,omitempty"`
+	OAuth2     *WebhookOAuth2  `json:"oauth2,omitempty"`
+	TLS        *WebhookTLSAuth `json:"tls,omitempty"`
+}
+
+// WebhookOAuth2 fetches a bearer token with the OAuth2 client credentials
+// grant and sends it in the Authorization header
+type WebhookOAuth2 struct {
+	TokenURL     string   `json:"token_url"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"` // may be ${ENV_VAR}
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+// WebhookTLSAuth holds a client certificate for mutual TLS and an optional
+// CA bundle that replaces the system roots for the webhook server
+type WebhookTLSAuth struct {
+	CertFile string `json:"cert_file,omitempty"` // PEM client certificate
+	KeyFile  string `json:"key_file,omitempty"`  // PEM private key
+	CAFile   string `json:"ca_file,omitempty"`   // PEM CA certificates
 }
 
 // CSVConfig holds CSV file-specific configuration for a channel
 type CSVConfig struct {
-	Path    string `json:"path,omitempty"`
-	MaxDays int    `json:"max_days,omitempty"`
-	Message string `json:"message,omitempty"`
+	Path       string       `json:"path,omitempty"`
+	MaxDays    int          `json:"max_days,omitempty"`
+	Message    string       `json:"message,omitempty"`
+	Columns    []FileColumn `json:"columns,omitempty"`     // replaces message with one templated value per column
+	NoHeader   bool         `json:"no_header,omitempty"`   // omit the header row
+	Compress   bool         `json:"compress,omitempty"`    // gzip rotated files
+	TimeFormat string       `json:"time_format,omitempty"` // "iso8601", "unix" or a Go layout for the timestamp column
 }
 
 // JSONConfig holds JSON file-specific configuration for a channel
 type JSONConfig struct {
-	Path    string `json:"path,omitempty"`
-	MaxDays int    `json:"max_days,omitempty"`
-	Message string `json:"message,omitempty"`
+	Path       string       `json:"path,omitempty"`
+	MaxDays    int          `json:"max_days,omitempty"`
+	Message    string       `json:"message,omitempty"`
+	Fields     []FileColumn `json:"fields,omitempty"`      // replaces message with one templated value per field
+	Compress   bool         `json:"compress,omitempty"`    // gzip rotated files
+	TimeFormat string       `json:"time_format,omitempty"` // "iso8601", "unix" or a Go layout for the timestamp field
 }
 
 // LoadConfigFromEnv loads email/SMS configuration from environment variables.
 // All credentials must be explicitly set in .env file - no fallback to OS credentials.
 // For AWS SNS: Requires AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_REGION in .env
 // (Does NOT use ~/.aws/credentials or IAM roles)
+//
+// Secret variables (passwords, client secrets, auth tokens and AWS keys) may
+// hold an env:, file:, token_file: or keychain: reference instead of the value.
 func LoadConfigFromEnv() (*AlarmConfig, error) {
 	config := &AlarmConfig{}
+	var secretErr error
+	secret := func(name string) string {
+		value, err := secrets.ResolveEnv(name)
+		if err != nil && secretErr == nil {
+			secretErr = err
+		}
+		return value
+	}
 
 	// Load email configuration from environment
 	if clientID := os.Getenv("MS365_CLIENT_ID"); clientID != "" {
@@ -142,10 +238,11 @@ func LoadConfigFromEnv() (*AlarmConfig, error) {
 			Provider:     "microsoft365",
 			UseOAuth2:    true,
 			ClientID:     clientID,
-			ClientSecret: os.Getenv("MS365_CLIENT_SECRET"),
+			ClientSecret: secret("MS365_CLIENT_SECRET"),
 			TenantID:     os.Getenv("MS365_TENANT_ID"),
 			FromAddress:  os.Getenv("MS365_FROM_ADDRESS"),
 			FromName:     os.Getenv("SMTP_FROM_NAME"), // Can use same FROM_NAME
+			TemplatesDir: os.Getenv("EMAIL_TEMPLATES_DIR"),
 		}
 	} else if smtpHost := os.Getenv("SMTP_HOST"); smtpHost != "" {
 		// Generic SMTP
@@ -160,14 +257,15 @@ func LoadConfigFromEnv() (*AlarmConfig, error) {
 			useTLS = false
 		}
 		config.Email = &EmailGlobalConfig{
-			Provider:    "smtp",
-			SMTPHost:    smtpHost,
-			SMTPPort:    smtpPort,
-			Username:    os.Getenv("SMTP_USERNAME"),
-			Password:    os.Getenv("SMTP_PASSWORD"),
-			FromAddress: os.Getenv("SMTP_FROM_ADDRESS"),
-			FromName:    os.Getenv("SMTP_FROM_NAME"),
-			UseTLS:      useTLS,
+			Provider:     "smtp",
+			SMTPHost:     smtpHost,
+			SMTPPort:     smtpPort,
+			Username:     os.Getenv("SMTP_USERNAME"),
+			Password:     secret("SMTP_PASSWORD"),
+			FromAddress:  os.Getenv("SMTP_FROM_ADDRESS"),
+			FromName:     os.Getenv("SMTP_FROM_NAME"),
+			UseTLS:       useTLS,
+			TemplatesDir: os.Getenv("EMAIL_TEMPLATES_DIR"),
 		}
 	}
 
@@ -177,7 +275,7 @@ func LoadConfigFromEnv() (*AlarmConfig, error) {
 		config.SMS = &SMSGlobalConfig{
 			Provider:   "twilio",
 			AccountSID: twilioSID,
-			AuthToken:  os.Getenv("TWILIO_AUTH_TOKEN"),
+			AuthToken:  secret("TWILIO_AUTH_TOKEN"),
 			FromNumber: os.Getenv("TWILIO_FROM_NUMBER"),
 		}
 	} else if awsKey := os.Getenv("AWS_ACCESS_KEY_ID"); awsKey != "" {
@@ -185,7 +283,7 @@ func LoadConfigFromEnv() (*AlarmConfig, error) {
 		config.SMS = &SMSGlobalConfig{
 			Provider:       "aws_sns",
 			AWSAccessKey:   awsKey,
-			AWSSecretKey:   os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			AWSSecretKey:   secret("AWS_SECRET_ACCESS_KEY"),
 			AWSRegion:      os.Getenv("AWS_REGION"),
 			AWSSNSTopicARN: os.Getenv("AWS_SNS_TOPIC_ARN"),
 		}
@@ -207,7 +305,7 @@ func LoadConfigFromEnv() (*AlarmConfig, error) {
 		}
 	}
 
-	return config, nil
+	return config, secretErr
 }
 
 // LoadAlarmConfig loads alarm configuration from file or JSON string
@@ -238,27 +336,7 @@ func LoadAlarmConfig(input string) (*AlarmConfig, error) {
 
 			// Provide detailed error for invalid JSON
 			if syntaxErr, ok := err.(*json.SyntaxError); ok {
-				// Calculate line and column
-				lines := strings.Split(input, "\n")
-				var line, col int
-				offset := syntaxErr.Offset
-				currentOffset := int64(0)
-
-				for i, l := range lines {
-					lineLen := int64(len(l) + 1) // +1 for newline
-					if currentOffset+lineLen > offset {
-						line = i + 1
-						col = int(offset - currentOffset + 1)
-						break
-					}
-					currentOffset += lineLen
-				}
-
-				if line == 0 {
-					line = 1
-					col = int(offset)
-				}
-
+				line, col := jsonPosition(data, syntaxErr.Offset)
 				return nil, fmt.Errorf("invalid JSON syntax at line %d, column %d: %v\nProvide valid JSON string or use @filename.json to load from file", line, col, syntaxErr)
 			}
 
@@ -269,14 +347,32 @@ func LoadAlarmConfig(input string) (*AlarmConfig, error) {
 	var config AlarmConfig
 	if err := json.Unmarshal(data, &config); err != nil {
 		if isFile {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			switch {
+			case errors.As(err, &syntaxErr):
+				line, col := jsonPosition(data, syntaxErr.Offset)
+				return nil, fmt.Errorf("invalid JSON syntax in %s at line %d, column %d: %v", strings.TrimPrefix(input, "@"), line, col, syntaxErr)
+			case errors.As(err, &typeErr):
+				line, col := jsonPosition(data, typeErr.Offset)
+				return nil, fmt.Errorf("invalid value in %s at line %d, column %d: %q must be %s, not %s", strings.TrimPrefix(input, "@"), line, col, typeErr.Field, jsonTypeName(typeErr.Type.Kind().String()), typeErr.Value)
+			}
 			return nil, fmt.Errorf("failed to parse alarm config from file: %w", err)
 		}
 		return nil, fmt.Errorf("failed to parse alarm config from JSON string: %w\nEnsure your JSON matches the AlarmConfig structure", err)
 	}
 
+	// Misspelled keys are otherwise ignored silently, e.g. "cooldwon"
+	if unknown := unknownKeys(data); len(unknown) > 0 {
+		logger.Warn("Alarm config: ignoring unknown setting(s) %s (check the spelling; keys starting with _ are comments)", strings.Join(unknown, ", "))
+	}
+
 	// Load credentials from environment variables (ONLY source for Email/SMS/Syslog config)
 	// JSON files should contain ONLY alarm rules, never credentials
-	envConfig, _ := LoadConfigFromEnv()
+	envConfig, err := LoadConfigFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("alarm credentials: %w", err)
+	}
 	config.Email = envConfig.Email
 	config.SMS = envConfig.SMS
 	config.Syslog = envConfig.Syslog
@@ -285,12 +381,69 @@ func LoadAlarmConfig(input string) (*AlarmConfig, error) {
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid alarm config: %w", err)
 	}
+	if err := checkEncryptedSecrets(&config); err != nil {
+		return nil, fmt.Errorf("invalid alarm config: %w", err)
+	}
 
 	return &config, nil
 }
 
+// jsonPosition converts a byte offset in data to a 1-based line and column
+func jsonPosition(data []byte, offset int64) (line, col int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = int(offset) - (bytes.LastIndexByte(before, '\n') + 1)
+	if col < 1 {
+		col = 1
+	}
+	return line, col
+}
+
+// jsonTypeName names a Go kind the way the JSON in the file spells it
+func jsonTypeName(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "a number"
+	case kind == "bool":
+		return "true or false"
+	case kind == "string":
+		return "a string"
+	case kind == "slice", kind == "array":
+		return "a list"
+	default:
+		return "an object"
+	}
+}
+
 // Validate checks if the alarm configuration is valid
 func (c *AlarmConfig) Validate() error {
+	digestNames := make(map[string]bool)
+	for i := range c.Digests {
+		d := &c.Digests[i]
+		if err := d.Validate(); err != nil {
+			return fmt.Errorf("digest at index %d: %w", i, err)
+		}
+		if digestNames[d.Name] {
+			return fmt.Errorf("duplicate digest name: %s", d.Name)
+		}
+		digestNames[d.Name] = true
+	}
+
+	uploadNames := make(map[string]bool)
+	for i := range c.Uploads {
+		u := &c.Uploads[i]
+		if err := u.Validate(); err != nil {
+			return fmt.Errorf("upload at index %d: %w", i, err)
+		}
+		if uploadNames[u.Name] {
+			return fmt.Errorf("duplicate upload name: %s", u.Name)
+		}
+		uploadNames[u.Name] = true
+	}
+
 	// Allow empty alarm list - manager can start and watch for file changes
 	if len(c.Alarms) == 0 {
 		return nil
@@ -310,6 +463,10 @@ func (c *AlarmConfig) Validate() error {
 			return fmt.Errorf("alarm %s: condition is required", alarm.Name)
 		}
 
+		if _, _, err := parseSustainedFor(alarm.SustainedFor); err != nil {
+			return fmt.Errorf("alarm %s: %w", alarm.Name, err)
+		}
+
 		// Validate schedule if present
 		if alarm.Schedule != nil {
 			if err := alarm.Schedule.Validate(); err != nil {
@@ -333,20 +490,28 @@ func (c *AlarmConfig) Validate() error {
 
 // Validate checks if a channel configuration is valid
 func (c *Channel) Validate() error {
-	validTypes := map[string]bool{
-		"console":  true,
-		"email":    true,
-		"sms":      true,
-		"syslog":   true,
-		"oslog":    true,
-		"eventlog": true,
-		"webhook":  true,
-		"csv":      true,
-		"json":     true,
+	if c.MaxPerHour < 0 {
+		return fmt.Errorf("max_per_hour must be 0 (unlimited) or positive")
+	}
+	if c.QuietHours != nil {
+		if err := c.QuietHours.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.History != nil {
+		if c.Type != "email" && c.Type != "webhook" {
+			return fmt.Errorf("history is only supported by email and webhook channels")
+		}
+		if err := c.History.Validate(); err != nil {
+			return err
+		}
 	}
 
-	if !validTypes[c.Type] {
-		return fmt.Errorf("invalid channel type: %s (must be console, email, sms, syslog, oslog, eventlog, or webhook)", c.Type)
+	if !builtinChannelTypes[c.Type] {
+		if _, ok := lookupPlugin(c.Type); ok {
+			return nil // plugins validate their own options
+		}
+		return fmt.Errorf("invalid channel type: %s (must be console, email, sms, syslog, oslog, eventlog, webhook, or a loaded plugin)", c.Type)
 	}
 
 	switch c.Type {
@@ -367,6 +532,9 @@ func (c *Channel) Validate() error {
 		if c.Email.Body == "" {
 			return fmt.Errorf("body template is required for email channel")
 		}
+		if w := c.Email.Wrapper; w != "" && w != "none" && !wrapperName.MatchString(w) {
+			return fmt.Errorf("invalid email wrapper %q (letters, digits, - and _ only)", w)
+		}
 	case "sms":
 		if c.SMS == nil {
 			return fmt.Errorf("sms configuration is required for sms channel")
@@ -393,6 +561,12 @@ func (c *Channel) Validate() error {
 		if c.Webhook.ContentType == "" {
 			c.Webhook.ContentType = "application/json" // Default content type
 		}
+		if o := c.Webhook.OAuth2; o != nil && (o.TokenURL == "" || o.ClientID == "") {
+			return fmt.Errorf("token_url and client_id are required for webhook oauth2")
+		}
+		if t := c.Webhook.TLS; t != nil && (t.CertFile == "") != (t.KeyFile == "") {
+			return fmt.Errorf("webhook tls needs both cert_file and key_file")
+		}
 	case "csv":
 		if c.CSV == nil {
 			return fmt.Errorf("csv configuration is required for csv channel")
@@ -403,6 +577,9 @@ func (c *Channel) Validate() error {
 		if c.CSV.MaxDays < 0 {
 			return fmt.Errorf("max_days must be 0 (unlimited) or positive for csv channel")
 		}
+		if err := validateFileColumns(c.CSV.Columns, "column"); err != nil {
+			return fmt.Errorf("csv channel: %w", err)
+		}
 		if c.CSV.Message == "" {
 			c.CSV.Message = `{{timestamp}},{{alarm_name}},{{alarm_description}},{{temperature}},{{humidity}},{{pressure}},{{wind_speed}},{{lux}},{{uv}},{{rain_daily}}`
 		}
@@ -416,6 +593,9 @@ func (c *Channel) Validate() error {
 		if c.JSON.MaxDays < 0 {
 			return fmt.Errorf("max_days must be 0 (unlimited) or positive for json channel")
 		}
+		if err := validateFileColumns(c.JSON.Fields, "field"); err != nil {
+			return fmt.Errorf("json channel: %w", err)
+		}
 		if c.JSON.Message == "" {
 			c.JSON.Message = `{"timestamp": "{{timestamp}}", "message": "ALARM: {{alarm_name}} triggered", "alarm": {{alarm_info}}, "sensors": {{sensor_info}}}`
 		}
@@ -445,6 +625,21 @@ func (a *Alarm) GetLastFired() time.Time {
 	return a.lastFired
 }
 
+// GetSnoozedUntil returns when a snooze ends, or the zero time when the
+// alarm is not snoozed
+func (a *Alarm) GetSnoozedUntil() time.Time {
+	if time.Now().After(a.snoozedUntil) {
+		return time.Time{}
+	}
+	return a.snoozedUntil
+}
+
+// IsAcknowledged reports whether the alarm is silenced until its condition
+// stops holding
+func (a *Alarm) IsAcknowledged() bool {
+	return a.acknowledged
+}
+
 // GetCooldownRemaining returns the remaining cooldown time in seconds (0 if can fire)
 func (a *Alarm) GetCooldownRemaining() int {
 	if !a.Enabled || a.Cooldown == 0 {
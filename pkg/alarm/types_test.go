@@ -3,6 +3,8 @@ package alarm
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -510,6 +512,38 @@ func TestLoadAlarmConfig(t *testing.T) {
 	}
 }
 
+func TestLoadAlarmConfigFileErrors(t *testing.T) {
+	for _, tt := range []struct{ body, want string }{
+		{"{\"alarms\": [\n  {\"name\": \"a\",}\n]}", "at line 2, column 16"},
+		{"{\"alarms\": [\n  {\"name\": \"a\", \"cooldown\": \"5m\"}\n]}", "at line 2, column 32: \"alarms.0.cooldown\" must be a number, not string"},
+	} {
+		path := filepath.Join(t.TempDir(), "alarms.json")
+		if err := os.WriteFile(path, []byte(tt.body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadAlarmConfig("@" + path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("expected %q, got %v", tt.want, err)
+		}
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	data := []byte(`{"_comment": "skipped", "alarms": [{"name": "a", "condtion": "x", "Cooldown": 5,
+		"schedule": {"type": "daily", "whatever": 1},
+		"channels": [{"type": "email", "email": {"to": ["a@b"], "subjct": "s"}}]}], "digest": []}`)
+	got := unknownKeys(data)
+	want := []string{"alarms[0].channels[0].email.subjct", "alarms[0].condtion", "digest"}
+	if len(got) != len(want) {
+		t.Fatalf("unknownKeys = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("unknownKeys = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
 func TestAlarmJSON(t *testing.T) {
 	alarm := Alarm{
 		Name:        "test-alarm",
@@ -0,0 +1,43 @@
+package alarm
+
+import (
+	"fmt"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// unitSystem is the unit system (--units) notifications show lightning
+// distances in
+var unitSystem = "imperial"
+
+// SetUnits sets the unit system (imperial, metric or sae) used for the
+// lightning distances of notifications, so they match the dashboard. main
+// sets it from --units.
+func SetUnits(units string) {
+	unitSystem = units
+}
+
+// formatDistance formats a distance in km in the configured unit system
+func formatDistance(km float64) string {
+	return fmt.Sprintf("%.1f %s", weather.ConvertDistance(km, unitSystem), weather.DistanceUnit(unitSystem))
+}
+
+// pressureUnits are the pressure units of notifications
+// (--units-pressure-notifications) and of the CSV and JSON file channel
+// exports (--units-pressure-export). Conditions and {{pressure}} stay in mb.
+var pressureUnits = struct{ notifications, export string }{weather.PressureMB, weather.PressureMB}
+
+// SetPressureUnits sets the pressure units (inHg or mb) of notifications and
+// of file channel exports. main sets them from the configuration.
+func SetPressureUnits(notifications, export string) {
+	pressureUnits.notifications, pressureUnits.export = notifications, export
+}
+
+// pressureLabel returns the unit pressures are shown in for unit, mb unless
+// it is inHg
+func pressureLabel(unit string) string {
+	if unit == weather.PressureInHg {
+		return weather.PressureInHg
+	}
+	return weather.PressureMB
+}
@@ -0,0 +1,98 @@
+package alarm
+
+import (
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestEvaluator_LightningStormFields(t *testing.T) {
+	e := NewEvaluator()
+	obs := &weather.Observation{LightningStrikeCount: 4, LightningStrikeAvg: 12, LightningNearest: 6, LightningRate: 2.5}
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"lightning_nearest < 8", true},
+		{"lightning_nearest < 5km", false},
+		{"lightning_nearest < 5mi", true}, // 5 mi is 8 km
+		{"lightning_distance > 8mi", false},
+		{"lightning_rate >= 2/min", true},
+		{"lightning_nearest > 0 && lightning_rate > 3", false},
+	}
+	for _, tt := range tests {
+		got, err := e.Evaluate(tt.condition, obs)
+		if err != nil || got != tt.want {
+			t.Errorf("%s = %v, %v; want %v", tt.condition, got, err, tt.want)
+		}
+	}
+}
+
+func TestExpandTemplate_LightningUnits(t *testing.T) {
+	defer SetUnits("imperial")
+	alarm := &Alarm{Name: "Lightning"}
+	obs := &weather.Observation{LightningStrikeCount: 4, LightningStrikeAvg: 16.09344, LightningNearest: 8.04672, LightningRate: 1.5}
+
+	SetUnits("imperial")
+	template := "{{lightning_nearest}} km|{{lightning_nearest_local}}|{{lightning_distance_local}}|{{lightning_rate}}/min|{{distance .LightningNearest}}"
+	if got, want := expandTemplate(template, alarm, obs, "Test"), "8.0 km|5.0 mi|10.0 mi|1.5/min|5.0 mi"; got != want {
+		t.Errorf("imperial = %q, want %q", got, want)
+	}
+	if info := expandTemplate("{{sensor_info}}", alarm, obs, "Test"); !strings.Contains(info, "Lightning: 4 strikes, avg 10.0 mi, nearest 5.0 mi, 1.5/min") {
+		t.Errorf("imperial sensor_info lightning row missing:\n%s", info)
+	}
+
+	SetUnits("metric")
+	if got, want := expandTemplate("{{lightning_nearest_local}}", alarm, obs, "Test"), "8.0 km"; got != want {
+		t.Errorf("metric = %q, want %q", got, want)
+	}
+}
+
+func TestEvaluator_PressureUnits(t *testing.T) {
+	e := NewEvaluator()
+	obs := &weather.Observation{StationPressure: 1005}
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"pressure < 1010", true},
+		{"pressure < 1010mb", true},
+		{"pressure < 1000hPa", false},
+		{"pressure < 29.8inHg", true}, // 1009.1 mb
+		{"pressure < 29.6inHg", false},
+	}
+	for _, tt := range tests {
+		got, err := e.Evaluate(tt.condition, obs)
+		if err != nil || got != tt.want {
+			t.Errorf("%s = %v, %v; want %v", tt.condition, got, err, tt.want)
+		}
+	}
+}
+
+func TestExpandTemplate_PressureUnits(t *testing.T) {
+	defer SetPressureUnits("mb", "mb")
+	alarm := &Alarm{Name: "Pressure"}
+	obs := &weather.Observation{StationPressure: 1013.25}
+	template := "{{pressure}}|{{pressure_local}}|{{.PressureLocal | round 2}} {{.PressureUnit}}"
+
+	SetPressureUnits("inHg", "mb")
+	if got, want := expandTemplate(template, alarm, obs, "Test"), "1013.25|29.92 inHg|29.92 inHg"; got != want {
+		t.Errorf("notification = %q, want %q", got, want)
+	}
+	if info := expandTemplate("{{sensor_info}}", alarm, obs, "Test"); !strings.Contains(info, "Pressure: 29.92 inHg") {
+		t.Errorf("notification sensor_info pressure row not in inHg:\n%s", info)
+	}
+	_, row := renderFileColumns([]FileColumn{{Name: "pressure", Value: "{{pressure_local}}"}}, alarm, obs, "Test")
+	if row[0] != "1013.2 mb" {
+		t.Errorf("export column = %q, want the export unit (mb)", row[0])
+	}
+
+	SetPressureUnits("mb", "inHg")
+	if got := expandExportTemplate("{{pressure_local}}", alarm, obs, "Test"); got != "29.92 inHg" {
+		t.Errorf("export = %q, want 29.92 inHg", got)
+	}
+	if got := expandTemplate("{{pressure_local}}", alarm, obs, "Test"); got != "1013.2 mb" {
+		t.Errorf("notification = %q, want 1013.2 mb", got)
+	}
+}
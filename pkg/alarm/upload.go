@@ -0,0 +1,329 @@
+package alarm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/secrets"
+)
+
+// defaultUploadInterval is how often an upload runs without an interval
+const defaultUploadInterval = time.Hour
+
+// uploadTimeout bounds a single object upload
+const uploadTimeout = 5 * time.Minute
+
+// Upload periodically copies the rotated CSV/JSON channel files (and any
+// other matching files) to S3-compatible object storage: AWS S3, Google
+// Cloud Storage in interoperability mode, MinIO, Cloudflare R2 and the like.
+// Credentials are read from the environment, never from the alarm file.
+type Upload struct {
+	Name      string   `json:"name"`
+	Endpoint  string   `json:"endpoint,omitempty"`   // default https://s3.<region>.amazonaws.com
+	Region    string   `json:"region,omitempty"`     // default us-east-1 ("auto" for GCS and R2)
+	Bucket    string   `json:"bucket"`               // bucket name
+	Prefix    string   `json:"prefix,omitempty"`     // object key prefix, e.g. "tempest/backyard/"
+	PathStyle bool     `json:"path_style,omitempty"` // <endpoint>/<bucket>/<key> instead of <bucket>.<host>/<key>
+	Interval  string   `json:"interval,omitempty"`   // how often to upload, default 1h
+	Files     []string `json:"files,omitempty"`      // extra glob patterns, uploaded again when they change
+	Current   bool     `json:"current,omitempty"`    // also upload the live channel files when they change
+	Delete    bool     `json:"delete_after_upload,omitempty"`
+	AccessKey string   `json:"access_key_env,omitempty"` // environment variable holding the access key, default AWS_ACCESS_KEY_ID
+	SecretKey string   `json:"secret_key_env,omitempty"` // environment variable holding the secret, default AWS_SECRET_ACCESS_KEY
+	Enabled   bool     `json:"enabled"`
+}
+
+// Validate checks the upload target and schedule
+func (u *Upload) Validate() error {
+	if u.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if u.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	if u.Endpoint != "" {
+		parsed, err := url.Parse(u.Endpoint)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("endpoint must be an http or https URL")
+		}
+	}
+	if u.Interval != "" {
+		d, err := time.ParseDuration(u.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid interval: %w", err)
+		}
+		if d < time.Minute {
+			return fmt.Errorf("interval must be at least 1m")
+		}
+	}
+	for _, pattern := range u.Files {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid files pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// interval returns how often the upload runs
+func (u *Upload) interval() time.Duration {
+	if d, err := time.ParseDuration(u.Interval); err == nil && d > 0 {
+		return d
+	}
+	return defaultUploadInterval
+}
+
+// region returns the signing region
+func (u *Upload) region() string {
+	if u.Region == "" {
+		return "us-east-1"
+	}
+	return u.Region
+}
+
+// credentials reads the access key and secret from the environment; the
+// secret may be an env:, file:, token_file: or keychain: reference
+func (u *Upload) credentials() (aws.Credentials, error) {
+	accessEnv, secretEnv := u.AccessKey, u.SecretKey
+	if accessEnv == "" {
+		accessEnv = "AWS_ACCESS_KEY_ID"
+	}
+	if secretEnv == "" {
+		secretEnv = "AWS_SECRET_ACCESS_KEY"
+	}
+	secretKey, err := secrets.ResolveEnv(secretEnv)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+	creds := aws.Credentials{AccessKeyID: os.Getenv(accessEnv), SecretAccessKey: secretKey}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return creds, fmt.Errorf("credentials missing (%s, %s)", accessEnv, secretEnv)
+	}
+	return creds, nil
+}
+
+// objectURL returns the URL of an object key in the bucket
+func (u *Upload) objectURL(key string) (string, error) {
+	endpoint := u.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + u.region() + ".amazonaws.com"
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	escaped := strings.Join(segments, "/")
+	if u.PathStyle {
+		return strings.TrimSuffix(base.String(), "/") + "/" + url.PathEscape(u.Bucket) + "/" + escaped, nil
+	}
+	base.Host = u.Bucket + "." + base.Host
+	return strings.TrimSuffix(base.String(), "/") + "/" + escaped, nil
+}
+
+// put uploads one object with an AWS Signature Version 4 signed request
+func (u *Upload) put(ctx context.Context, client *http.Client, key string, body []byte, now time.Time) error {
+	creds, err := u.credentials()
+	if err != nil {
+		return err
+	}
+	target, err := u.objectURL(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("Content-Type", contentTypeFor(key))
+	req.ContentLength = int64(len(body))
+
+	signer := v4.NewSigner(func(o *v4.SignerOptions) { o.DisableURIPathEscaping = true })
+	if err := signer.SignHTTP(ctx, creds, req, payloadHash, "s3", u.region(), now); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("upload of %s failed: %s %s", key, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// contentTypeFor picks the object content type from the file name
+func contentTypeFor(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".gz"):
+		return "application/gzip"
+	case strings.Contains(name, ".csv"):
+		return "text/csv"
+	case strings.Contains(name, ".json"):
+		return "application/json"
+	}
+	return "application/octet-stream"
+}
+
+// uploadFile is a local file due for upload
+type uploadFile struct {
+	path    string
+	rotated bool // immutable rotated copy, may be deleted after upload
+}
+
+// uploadState remembers what each upload last sent
+type uploadState struct {
+	lastRun time.Time
+	sent    map[string]time.Time // modification time uploaded, by path
+}
+
+// uploadSources lists the files an upload covers: the rotated copies of the
+// CSV and JSON channel files, the live files when current is set, and the
+// files matching the extra patterns
+func uploadSources(u *Upload, channelFiles []string) []uploadFile {
+	seen := map[string]bool{}
+	var files []uploadFile
+	add := func(p string, rotated bool) {
+		if !seen[p] {
+			seen[p] = true
+			files = append(files, uploadFile{path: p, rotated: rotated})
+		}
+	}
+	for _, live := range channelFiles {
+		matches, _ := filepath.Glob(live + ".*.bak*")
+		for _, p := range matches {
+			if strings.HasSuffix(p, ".bak") || strings.HasSuffix(p, ".bak.gz") {
+				add(p, true)
+			}
+		}
+		if u.Current {
+			add(live, false)
+		}
+	}
+	for _, pattern := range u.Files {
+		matches, _ := filepath.Glob(pattern)
+		for _, p := range matches {
+			add(p, false)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return files
+}
+
+// channelFiles returns the paths of the CSV and JSON channels in the alarm
+// configuration. Callers hold m.mu.
+func (m *Manager) channelFiles() []string {
+	var paths []string
+	for i := range m.config.Alarms {
+		for _, c := range m.config.Alarms[i].Channels {
+			if c.CSV != nil && c.CSV.Path != "" {
+				paths = append(paths, c.CSV.Path)
+			}
+			if c.JSON != nil && c.JSON.Path != "" {
+				paths = append(paths, c.JSON.Path)
+			}
+		}
+	}
+	return paths
+}
+
+// ProcessUploads runs the uploads whose interval has passed. The first call
+// runs every enabled upload. Call it periodically, at least once a minute;
+// uploads run on the calling goroutine without holding the configuration lock.
+// Nothing runs during maintenance; files changed meanwhile go with the next
+// run after it.
+func (m *Manager) ProcessUploads(now time.Time) {
+	m.uploadMu.Lock()
+	defer m.uploadMu.Unlock()
+
+	m.mu.RLock()
+	if m.isSuspended() {
+		m.mu.RUnlock()
+		return
+	}
+	uploads := append([]Upload(nil), m.config.Uploads...)
+	channelFiles := m.channelFiles()
+	m.mu.RUnlock()
+
+	if m.uploads == nil {
+		m.uploads = make(map[string]*uploadState)
+	}
+	for i := range uploads {
+		u := &uploads[i]
+		if !u.Enabled {
+			continue
+		}
+		state, ok := m.uploads[u.Name]
+		if !ok {
+			state = &uploadState{sent: map[string]time.Time{}}
+			m.uploads[u.Name] = state
+		}
+		if !state.lastRun.IsZero() && now.Sub(state.lastRun) < u.interval() {
+			continue
+		}
+		state.lastRun = now
+		m.runUpload(u, state, uploadSources(u, channelFiles), now)
+	}
+}
+
+// runUpload sends the new or changed files of one upload
+func (m *Manager) runUpload(u *Upload, state *uploadState, files []uploadFile, now time.Time) {
+	client := &http.Client{Timeout: uploadTimeout}
+	uploaded := 0
+	for _, f := range files {
+		info, err := os.Stat(f.path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if sent, ok := state.sent[f.path]; ok && sent.Equal(info.ModTime()) {
+			continue
+		}
+		body, err := os.ReadFile(f.path)
+		if err != nil {
+			logger.Warn("Upload %s: failed to read %s: %v", u.Name, f.path, err)
+			continue
+		}
+		key := path.Join(u.Prefix, filepath.Base(f.path))
+		ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+		err = u.put(ctx, client, key, body, now)
+		cancel()
+		if err != nil {
+			logger.Error("Upload %s to bucket %s failed: %v", u.Name, u.Bucket, err)
+			return // retried at the next interval
+		}
+		uploaded++
+		state.sent[f.path] = info.ModTime()
+		if f.rotated && u.Delete {
+			if err := os.Remove(f.path); err != nil {
+				logger.Warn("Upload %s: failed to remove %s: %v", u.Name, f.path, err)
+			}
+			delete(state.sent, f.path)
+		}
+	}
+	if uploaded > 0 {
+		logger.Info("Upload %s: sent %d file(s) to bucket %s", u.Name, uploaded, u.Bucket)
+	}
+}
@@ -0,0 +1,124 @@
+package alarm
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUploadValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		upload  Upload
+		wantErr bool
+	}{
+		{"valid", Upload{Name: "a", Bucket: "b", Interval: "6h"}, false},
+		{"missing bucket", Upload{Name: "a"}, true},
+		{"bad endpoint", Upload{Name: "a", Bucket: "b", Endpoint: "s3.example.com"}, true},
+		{"short interval", Upload{Name: "a", Bucket: "b", Interval: "10s"}, true},
+		{"bad pattern", Upload{Name: "a", Bucket: "b", Files: []string{"[x"}}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.upload.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestUploadObjectURL(t *testing.T) {
+	u := &Upload{Bucket: "wx", Region: "eu-west-1"}
+	if got, _ := u.objectURL("tempest/a b.csv"); got != "https://wx.s3.eu-west-1.amazonaws.com/tempest/a%20b.csv" {
+		t.Errorf("virtual-hosted URL = %s", got)
+	}
+	u = &Upload{Bucket: "wx", Endpoint: "http://minio:9000", PathStyle: true}
+	if got, _ := u.objectURL("x.json"); got != "http://minio:9000/wx/x.json" {
+		t.Errorf("path-style URL = %s", got)
+	}
+}
+
+func TestManagerProcessUploads(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDTEST/") {
+			http.Error(w, "unsigned", http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received[r.URL.Path] = string(body)
+		mu.Unlock()
+	}))
+	defer server.Close()
+	t.Setenv("TEST_S3_KEY", "AKIDTEST")
+	t.Setenv("TEST_S3_SECRET", "secret")
+
+	dir := t.TempDir()
+	live := filepath.Join(dir, "alarms.csv")
+	rotated := live + ".2026-10-01_00-00-00.bak.gz"
+	for path, content := range map[string]string{live: "live", rotated: "old"} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := `{"alarms": [{"name": "Log", "condition": "temperature > 0", "enabled": true,
+		"channels": [{"type": "csv", "csv": {"path": "` + live + `"}}]}],
+		"uploads": [{"name": "archive", "endpoint": "` + server.URL + `", "bucket": "wx", "path_style": true,
+		"prefix": "tempest/backyard", "interval": "1h", "delete_after_upload": true,
+		"access_key_env": "TEST_S3_KEY", "secret_key_env": "TEST_S3_SECRET", "enabled": true}]}`
+	manager, err := NewManager(config, "TestStation")
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer manager.Stop()
+
+	// Nothing is uploaded during maintenance
+	maintenance := true
+	manager.SetSuspended(func() bool { return maintenance })
+	manager.ProcessUploads(time.Now())
+	if len(received) != 0 {
+		t.Fatalf("uploaded during maintenance: %v", received)
+	}
+	maintenance = false
+
+	now := time.Now()
+	manager.ProcessUploads(now)
+	if got := received["/wx/tempest/backyard/"+filepath.Base(rotated)]; got != "old" {
+		t.Fatalf("rotated file not uploaded, received %v", received)
+	}
+	if _, ok := received["/wx/tempest/backyard/alarms.csv"]; ok {
+		t.Error("live file uploaded without current")
+	}
+	if _, err := os.Stat(rotated); !os.IsNotExist(err) {
+		t.Error("rotated file should be deleted after upload")
+	}
+
+	// A new rotated file waits for the interval
+	second := live + ".2026-10-02_00-00-00.bak"
+	if err := os.WriteFile(second, []byte("newer"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manager.ProcessUploads(now.Add(30 * time.Minute))
+	if len(received) != 1 {
+		t.Errorf("uploaded before the interval: %v", received)
+	}
+	manager.ProcessUploads(now.Add(time.Hour))
+	if received["/wx/tempest/backyard/"+filepath.Base(second)] != "newer" {
+		t.Errorf("second rotated file not uploaded: %v", received)
+	}
+}
+
+func TestUploadMissingCredentials(t *testing.T) {
+	t.Setenv("NO_SUCH_KEY", "")
+	u := &Upload{Name: "a", Bucket: "b", AccessKey: "NO_SUCH_KEY"}
+	if _, err := u.credentials(); err == nil || !strings.Contains(err.Error(), "NO_SUCH_KEY") {
+		t.Errorf("credentials() error = %v, want it to name the variable", err)
+	}
+}
@@ -0,0 +1,238 @@
+package alarm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tempest-homekit-go/pkg/stats"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// ConditionError is a problem found in a condition by ValidateCondition.
+// Start and End are character offsets into the condition as written, so an
+// editor can underline Text.
+type ConditionError struct {
+	Message string `json:"message"`
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	Text    string `json:"text"`
+}
+
+func (e ConditionError) Error() string {
+	return fmt.Sprintf("%s (at character %d)", e.Message, e.Start+1)
+}
+
+// fieldUnits are the unit suffixes the comparison values of a field accept,
+// for the error when a value carries another unit
+var fieldUnits = map[string]string{
+	"temperature":        "F or C",
+	"temp":               "F or C",
+	"humidity":           "%",
+	"percent":            "%",
+	"pressure":           "inHg, mb or hPa",
+	"wind_speed":         "mph, km/h, kt or m/s",
+	"wind":               "mph, km/h, kt or m/s",
+	"wind_gust":          "mph, km/h, kt or m/s",
+	"lightning_distance": "mi or km",
+	"lightning_nearest":  "mi or km",
+	"lightning_rate":     "/min",
+	"solar_daily":        "kWh or Wh",
+	"uv_dose":            "SED",
+	"battery":            "V",
+	"api_errors_rate":    "/h",
+}
+
+// numberWithUnit splits a comparison value such as "90mb" into its number
+// and unit
+var numberWithUnit = regexp.MustCompile(`^([-+]?(?:\d+\.?\d*|\.\d+))\s*(\S.*)$`)
+
+// unitError explains why valueStr is not a valid comparison value for field
+func unitError(valueStr, field string) error {
+	m := numberWithUnit.FindStringSubmatch(strings.TrimSpace(valueStr))
+	if m == nil {
+		return fmt.Errorf("%q is not a number", valueStr)
+	}
+	if units, ok := fieldUnits[field]; ok {
+		return fmt.Errorf("unit %q does not apply to %s (use %s, or a plain number)", m[2], field, units)
+	}
+	return fmt.Errorf("unit %q does not apply to %s (use a plain number)", m[2], field)
+}
+
+// groupingParen matches an opening parenthesis that does not follow a
+// function name
+var groupingParen = regexp.MustCompile(`(?i)(?:^|[^\w\s]|\b(?:and|or))\s*\(`)
+
+// logicalOperator matches the operators joining the parts of a condition
+var logicalOperator = regexp.MustCompile(`&&|\|\||(?i)\s+(?:and|or)\s+`)
+
+// ValidateCondition checks a condition without a live observation: its
+// syntax, the field names and the units of the comparison values, so
+// "temperature > 90mb" is rejected when the alarm is saved rather than
+// failing at every evaluation. It returns every problem found, in order.
+func ValidateCondition(condition string) []ConditionError {
+	v := &conditionValidator{condition: condition, evaluator: validationEvaluator()}
+	if strings.TrimSpace(condition) == "" {
+		v.fail(0, len(condition), "condition cannot be empty")
+		return v.errors
+	}
+
+	// Parentheses only belong to functions such as delta(pressure, 3h);
+	// EvaluateWithAlarm has no grouping
+	if loc := groupingParen.FindStringIndex(condition); loc != nil {
+		v.fail(loc[1]-1, loc[1], "parentheses cannot group expressions; split the condition into separate alarms")
+		return v.errors
+	}
+
+	// Conditions are either all AND or all OR, as EvaluateWithAlarm reads them
+	kind, start, last := "", 0, []int(nil)
+	for _, loc := range logicalOperator.FindAllStringIndex(condition, -1) {
+		op := logicalKind(condition[loc[0]:loc[1]])
+		if kind == "" {
+			kind = op
+		} else if op != kind {
+			v.fail(loc[0], loc[1], "cannot mix AND (&&) and OR (||) in one condition")
+		}
+		v.part(start, loc[0], loc)
+		start, last = loc[1], loc
+	}
+	v.part(start, len(condition), last)
+	return v.errors
+}
+
+// ValidateConditions checks the condition of every alarm with
+// ValidateCondition and returns the first problem
+func (c *AlarmConfig) ValidateConditions() error {
+	for _, alarm := range c.Alarms {
+		if errs := ValidateCondition(alarm.Condition); len(errs) > 0 {
+			return fmt.Errorf("alarm %s: invalid condition: %w", alarm.Name, errs[0])
+		}
+	}
+	return nil
+}
+
+// logicalKind returns "and" or "or" for a logical operator
+func logicalKind(op string) string {
+	op = strings.ToLower(strings.TrimSpace(op))
+	if op == "&&" || op == "and" {
+		return "and"
+	}
+	return "or"
+}
+
+// validationEvaluator resolves every field from empty providers, so only
+// problems with the condition itself are errors
+func validationEvaluator() *Evaluator {
+	e := NewEvaluator()
+	e.SetLocation(40, -105)
+	e.SetMetricsProvider(func() BridgeMetrics { return BridgeMetrics{LastObservation: time.Now()} })
+	e.SetStatsProvider(func() stats.Summary { return stats.Summary{} })
+	e.SetAnomalyProvider(func(string) (float64, bool) { return 0, false })
+	e.SetHistoryProvider(func() []weather.Observation { return nil })
+	e.SetSitesProvider(func() map[string]weather.Observation { return nil })
+	e.SetForecastProvider(func() []weather.ForecastPeriod { return []weather.ForecastPeriod{{Time: time.Now().Unix()}} })
+	return e
+}
+
+type conditionValidator struct {
+	condition string
+	evaluator *Evaluator
+	errors    []ConditionError
+}
+
+// fail records a problem with condition[start:end] (byte offsets)
+func (v *conditionValidator) fail(start, end int, format string, args ...interface{}) {
+	v.errors = append(v.errors, ConditionError{
+		Message: fmt.Sprintf(format, args...),
+		Start:   utf8.RuneCountInString(v.condition[:start]),
+		End:     utf8.RuneCountInString(v.condition[:end]),
+		Text:    v.condition[start:end],
+	})
+}
+
+// span returns the byte offsets of condition[start:end] without the
+// surrounding spaces
+func (v *conditionValidator) span(start, end int) (int, int) {
+	text := v.condition[start:end]
+	trimmed := strings.TrimLeft(text, " \t\r\n")
+	start += len(text) - len(trimmed)
+	return start, start + len(strings.TrimRight(trimmed, " \t\r\n"))
+}
+
+// part checks the expression in condition[start:end]; logical is the logical
+// operator next to it, blamed when the expression is missing
+func (v *conditionValidator) part(start, end int, logical []int) {
+	start, end = v.span(start, end)
+	if start == end {
+		opStart, opEnd := v.span(logical[0], logical[1])
+		v.fail(opStart, opEnd, "logical operator needs an expression on both sides")
+		return
+	}
+	expr := v.condition[start:end]
+	obs := &weather.Observation{Timestamp: time.Now().Unix()}
+
+	// Change detection: *field, >field, <field
+	if first := expr[0]; first == '*' || first == '>' || first == '<' {
+		fieldStart, fieldEnd := v.span(start+1, end)
+		if fieldStart == fieldEnd {
+			v.fail(start, end, "change detection %c needs a field, e.g. %clightning_count", first, first)
+			return
+		}
+		if _, err := v.evaluator.getFieldValue(v.condition[fieldStart:fieldEnd], obs); err != nil {
+			v.fail(fieldStart, fieldEnd, "%v", err)
+		}
+		return
+	}
+
+	var op string
+	var idx int
+	for _, candidate := range []string{">=", "<=", "!=", "==", ">", "<"} {
+		if i := strings.Index(expr, candidate); i > 0 {
+			op, idx = candidate, i
+			break
+		}
+	}
+	if op == "" {
+		name := strings.TrimSpace(strings.TrimPrefix(expr, "!"))
+		switch {
+		case booleanFields[strings.ToLower(name)]:
+		case v.knownField(name):
+			v.fail(start, end, "%s needs a comparison, e.g. %s > 10", name, name)
+		default:
+			v.fail(start, end, "expected 'field operator value', e.g. temperature > 85F")
+		}
+		return
+	}
+
+	fieldStart, fieldEnd := v.span(start, start+idx)
+	valueStart, valueEnd := v.span(start+idx+len(op), end)
+	field := v.condition[fieldStart:fieldEnd]
+	if valueStart == valueEnd {
+		v.fail(start+idx, start+idx+len(op), "%s %s needs a value", field, op)
+		return
+	}
+	valueStr := v.condition[valueStart:valueEnd]
+
+	unitField := field
+	if _, _, inner, ok := siteField(field); ok {
+		if !siteValueFields[inner] {
+			v.fail(fieldStart, fieldEnd, "field %s is not available for other sites", inner)
+			return
+		}
+		unitField = inner
+	} else if _, err := v.evaluator.getFieldValue(field, obs); err != nil {
+		v.fail(fieldStart, fieldEnd, "%v", err)
+		return
+	}
+	if _, err := v.evaluator.parseValueWithUnits(valueStr, unitField); err != nil {
+		v.fail(valueStart, valueEnd, "%v", err)
+	}
+}
+
+// knownField reports whether name is a field conditions can read
+func (v *conditionValidator) knownField(name string) bool {
+	_, err := v.evaluator.getFieldValue(name, &weather.Observation{Timestamp: time.Now().Unix()})
+	return err == nil
+}
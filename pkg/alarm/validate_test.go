@@ -0,0 +1,98 @@
+package alarm
+
+import (
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestValidateCondition(t *testing.T) {
+	valid := []string{
+		"temperature > 85",
+		"temperature > 85F && humidity > 80%",
+		"temperature > 29.5°C or wind_gust > 40km/h",
+		"pressure < 29.5inHg",
+		"wind_speed >= 20kt",
+		"*lightning_count",
+		"is_daytime AND !token_invalid",
+		"data_age > 10m",
+		"delta(pressure, 3h) < -3",
+		"anomaly(temperature) > 4",
+		"any_site.temperature > 35C",
+		"solar_daily > 5kWh",
+		"lightning_nearest < 10mi",
+	}
+	for _, condition := range valid {
+		if errs := ValidateCondition(condition); len(errs) > 0 {
+			t.Errorf("%q: unexpected errors %v", condition, errs)
+		}
+	}
+
+	tests := []struct {
+		condition string
+		text      string // the offending text
+		start     int
+		message   string
+	}{
+		{"temperature > 90mb", "90mb", 14, `unit "mb" does not apply to temperature (use F or C`},
+		{"humidity > 50 && pressure < 30F", "30F", 28, `unit "F" does not apply to pressure`},
+		{"fake_field > 100", "fake_field", 0, "unknown field: fake_field"},
+		{"temperature >> 85", "> 85", 13, "is not a number"},
+		{"temperature > 85 &&", "&&", 17, "needs an expression on both sides"},
+		{"temperature > 85 && humidity > 80 || uv > 6", "||", 34, "cannot mix AND"},
+		{"temperature >", ">", 12, "needs a value"},
+		{"temperature", "temperature", 0, "needs a comparison"},
+		{"any_site.data_age > 2m", "any_site.data_age", 0, "not available for other sites"},
+		{"(*lightning_count && uv > 3) || wind_gust > 15", "(", 0, "parentheses cannot group"},
+		{"uv > 3 && (wind_gust > 15)", "(", 10, "parentheses cannot group"},
+		{"uv > 3 and (wind_gust > 15)", "(", 11, "parentheses cannot group"},
+		{"délai > 5 && temperature > 5mph", "5mph", 27, `unit "mph" does not apply to temperature`},
+	}
+	for _, tt := range tests {
+		errs := ValidateCondition(tt.condition)
+		if len(errs) == 0 {
+			t.Errorf("%q: no error, want %q", tt.condition, tt.message)
+			continue
+		}
+		got := errs[len(errs)-1]
+		if got.Text != tt.text || got.Start != tt.start || got.End != tt.start+len([]rune(tt.text)) || !strings.Contains(got.Message, tt.message) {
+			t.Errorf("%q: error %+v, want %q at %d: %q", tt.condition, got, tt.text, tt.start, tt.message)
+		}
+	}
+}
+
+func TestEvaluatorUnitInference(t *testing.T) {
+	e := NewEvaluator()
+	obs := &weather.Observation{AirTemperature: 30, WindAvg: 10}
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"temperature > 85°F", true},
+		{"temperature > 31°C", false},
+		{"wind_speed > 35km/h", true}, // 9.7 m/s
+		{"wind_speed > 20kt", false},  // 10.3 m/s
+		{"wind_speed > 19 knots", true},
+	}
+	for _, tt := range tests {
+		got, err := e.Evaluate(tt.condition, obs)
+		if err != nil || got != tt.want {
+			t.Errorf("%s = %v, %v; want %v", tt.condition, got, err, tt.want)
+		}
+	}
+	if _, err := e.Evaluate("temperature > 90mb", obs); err == nil || !strings.Contains(err.Error(), `unit "mb" does not apply to temperature`) {
+		t.Errorf("temperature > 90mb error = %v", err)
+	}
+}
+
+func TestAlarmConfigValidateConditions(t *testing.T) {
+	config := &AlarmConfig{Alarms: []Alarm{{Name: "Heat", Condition: "temperature > 90F"}}}
+	if err := config.ValidateConditions(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config.Alarms = append(config.Alarms, Alarm{Name: "Storm", Condition: "pressure < 29.5mph"})
+	if err := config.ValidateConditions(); err == nil || !strings.Contains(err.Error(), "alarm Storm: invalid condition") {
+		t.Errorf("error = %v, want the Storm alarm rejected", err)
+	}
+}
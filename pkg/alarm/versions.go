@@ -0,0 +1,242 @@
+package alarm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxConfigVersions is the number of alarm config backups kept per file.
+// Older backups are pruned whenever a new one is written.
+const MaxConfigVersions = 50
+
+// versionTimeFormat is used in backup file names so they sort chronologically
+const versionTimeFormat = "20060102-150405.000"
+
+// ConfigVersion describes a timestamped backup of an alarm configuration file
+type ConfigVersion struct {
+	ID        string    `json:"id"`        // Backup identifier (timestamp portion of the file name)
+	Timestamp time.Time `json:"timestamp"` // When the backup was taken
+	Size      int64     `json:"size"`      // Backup size in bytes
+	Alarms    int       `json:"alarms"`    // Number of alarms in the backup (-1 if unparseable)
+}
+
+// DiffLine is a single line of a line-based diff between two configs
+type DiffLine struct {
+	Op   string `json:"op"` // " " unchanged, "+" added, "-" removed
+	Text string `json:"text"`
+}
+
+// versionsRoot holds the backups of all config files when set (--data-dir);
+// otherwise they live in a .versions directory next to each config file
+var versionsRoot string
+
+// SetVersionsDir keeps alarm config backups under dir instead of next to the
+// config files
+func SetVersionsDir(dir string) {
+	versionsRoot = dir
+}
+
+// versionsDir returns the directory holding backups for the given config file
+func versionsDir(configPath string) string {
+	if versionsRoot != "" {
+		return filepath.Join(versionsRoot, filepath.Base(configPath))
+	}
+	return filepath.Join(filepath.Dir(configPath), ".versions", filepath.Base(configPath))
+}
+
+// BackupConfigFile copies the current contents of configPath into the versions
+// directory before it is overwritten. A missing config file is not an error
+// (there is nothing to back up yet). Returns the new version ID, or "" when no
+// backup was written.
+func BackupConfigFile(configPath string) (string, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read config for backup: %w", err)
+	}
+
+	dir := versionsDir(configPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create versions directory: %w", err)
+	}
+
+	id := time.Now().Format(versionTimeFormat)
+	// Avoid clobbering a backup taken within the same millisecond
+	for i := 1; fileExists(filepath.Join(dir, id+".json")); i++ {
+		id = fmt.Sprintf("%s-%d", time.Now().Format(versionTimeFormat), i)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, id+".json"), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write config backup: %w", err)
+	}
+
+	pruneConfigVersions(dir, MaxConfigVersions)
+	return id, nil
+}
+
+// ListConfigVersions returns the available backups for configPath, newest first
+func ListConfigVersions(configPath string) ([]ConfigVersion, error) {
+	entries, err := os.ReadDir(versionsDir(configPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []ConfigVersion{}, nil
+		}
+		return nil, fmt.Errorf("failed to read versions directory: %w", err)
+	}
+
+	versions := []ConfigVersion{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		id := strings.TrimSuffix(e.Name(), ".json")
+		ts, err := time.ParseInLocation(versionTimeFormat, versionTimestampPart(id), time.Local)
+		if err != nil {
+			continue
+		}
+		v := ConfigVersion{ID: id, Timestamp: ts, Alarms: -1}
+		if info, err := e.Info(); err == nil {
+			v.Size = info.Size()
+		}
+		if data, err := ReadConfigVersion(configPath, id); err == nil {
+			var cfg AlarmConfig
+			if json.Unmarshal(data, &cfg) == nil {
+				v.Alarms = len(cfg.Alarms)
+			}
+		}
+		versions = append(versions, v)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].ID > versions[j].ID
+	})
+	return versions, nil
+}
+
+// ReadConfigVersion returns the raw contents of a backup
+func ReadConfigVersion(configPath, id string) ([]byte, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return nil, fmt.Errorf("invalid version id: %q", id)
+	}
+	data, err := os.ReadFile(filepath.Join(versionsDir(configPath), id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("version %s not found", id)
+		}
+		return nil, fmt.Errorf("failed to read version %s: %w", id, err)
+	}
+	return data, nil
+}
+
+// RollbackConfigVersion restores a backup over configPath. The current file is
+// backed up first so a rollback can itself be undone. The restored content is
+// validated before anything is written.
+func RollbackConfigVersion(configPath, id string) error {
+	data, err := ReadConfigVersion(configPath, id)
+	if err != nil {
+		return err
+	}
+	var cfg AlarmConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("version %s is not valid JSON: %w", id, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("version %s is not a valid alarm config: %w", id, err)
+	}
+	if _, err := BackupConfigFile(configPath); err != nil {
+		return err
+	}
+	if err := os.WriteFile(configPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to restore version %s: %w", id, err)
+	}
+	return nil
+}
+
+// DiffConfigLines computes a line-based diff from old to new using the
+// longest common subsequence of lines.
+func DiffConfigLines(oldText, newText string) []DiffLine {
+	a := strings.Split(strings.TrimRight(oldText, "\n"), "\n")
+	b := strings.Split(strings.TrimRight(newText, "\n"), "\n")
+
+	// lcs[i][j] = length of LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	diff := make([]DiffLine, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, DiffLine{Op: " ", Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: "-", Text: a[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: "+", Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, DiffLine{Op: "-", Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, DiffLine{Op: "+", Text: b[j]})
+	}
+	return diff
+}
+
+// pruneConfigVersions removes the oldest backups beyond keep
+func pruneConfigVersions(dir string, keep int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	names := []string{}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	if len(names) <= keep {
+		return
+	}
+	sort.Strings(names)
+	for _, name := range names[:len(names)-keep] {
+		_ = os.Remove(filepath.Join(dir, name))
+	}
+}
+
+// versionTimestampPart strips any collision suffix ("-1") from a version ID
+func versionTimestampPart(id string) string {
+	if len(id) > len(versionTimeFormat) {
+		return id[:len(versionTimeFormat)]
+	}
+	return id
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
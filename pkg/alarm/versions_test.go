@@ -0,0 +1,109 @@
+package alarm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupAndRollbackConfigVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alarms.json")
+
+	// Nothing to back up before the file exists
+	id, err := BackupConfigFile(path)
+	if err != nil || id != "" {
+		t.Fatalf("expected no backup for missing file, got id=%q err=%v", id, err)
+	}
+
+	v1 := `{"alarms":[{"name":"a","condition":"temperature > 30","enabled":true,"channels":[{"type":"console","template":"x"}]}]}`
+	if err := os.WriteFile(path, []byte(v1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	id, err = BackupConfigFile(path)
+	if err != nil || id == "" {
+		t.Fatalf("expected backup, got id=%q err=%v", id, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"alarms":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := ListConfigVersions(path)
+	if err != nil {
+		t.Fatalf("ListConfigVersions failed: %v", err)
+	}
+	if len(versions) != 1 || versions[0].ID != id || versions[0].Alarms != 1 {
+		t.Fatalf("unexpected versions: %+v", versions)
+	}
+
+	if err := RollbackConfigVersion(path, id); err != nil {
+		t.Fatalf("rollback failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != v1 {
+		t.Errorf("rollback did not restore content, got %s", data)
+	}
+
+	// The rollback itself must be undoable
+	versions, _ = ListConfigVersions(path)
+	if len(versions) != 2 {
+		t.Errorf("expected rollback to back up current file, got %d versions", len(versions))
+	}
+}
+
+func TestReadConfigVersionRejectsTraversal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alarms.json")
+	for _, id := range []string{"", "../alarms", "a/b"} {
+		if _, err := ReadConfigVersion(path, id); err == nil {
+			t.Errorf("expected error for id %q", id)
+		}
+	}
+}
+
+func TestPruneConfigVersions(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"1.json", "2.json", "3.json", "4.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pruneConfigVersions(dir, 2)
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 || entries[0].Name() != "3.json" {
+		t.Errorf("expected two newest backups kept, got %v", entries)
+	}
+}
+
+func TestDiffConfigLines(t *testing.T) {
+	diff := DiffConfigLines("a\nb\nc\n", "a\nc\nd\n")
+	want := []DiffLine{{" ", "a"}, {"-", "b"}, {" ", "c"}, {"+", "d"}}
+	if len(diff) != len(want) {
+		t.Fatalf("expected %d lines, got %+v", len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("line %d: expected %+v, got %+v", i, want[i], diff[i])
+		}
+	}
+}
+
+func TestSetVersionsDir(t *testing.T) {
+	root := t.TempDir()
+	SetVersionsDir(filepath.Join(root, "alarm-versions"))
+	defer SetVersionsDir("")
+
+	path := filepath.Join(t.TempDir(), "alarms.json")
+	if err := os.WriteFile(path, []byte(`{"alarms":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	id, err := BackupConfigFile(path)
+	if err != nil || id == "" {
+		t.Fatalf("expected backup, got id=%q err=%v", id, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "alarm-versions", "alarms.json", id+".json")); err != nil {
+		t.Errorf("backup not written under the versions directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), ".versions")); !os.IsNotExist(err) {
+		t.Errorf("expected no .versions directory next to the config, got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package alarm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/secrets"
+	"tempest-homekit-go/pkg/websec"
+)
+
+// webhookTimeout bounds a webhook or token request
+const webhookTimeout = 10 * time.Second
+
+// tokenExpiryMargin renews OAuth2 tokens this long before they expire
+const tokenExpiryMargin = 30 * time.Second
+
+var (
+	webhookClients   sync.Map // WebhookTLSAuth -> *http.Client
+	defaultWebhookCl = &http.Client{Timeout: webhookTimeout}
+
+	oauth2Mu     sync.Mutex
+	oauth2Tokens = map[string]oauth2Token{}
+)
+
+type oauth2Token struct {
+	value   string
+	expires time.Time
+}
+
+// resolveSecret reads secrets given as ${ENV_VAR} from the environment, or
+// as an env:, file:, token_file: or keychain: reference, so alarm files can
+// be shared without them
+func resolveSecret(value string) (string, error) {
+	if strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}") {
+		return os.Getenv(value[2 : len(value)-1]), nil
+	}
+	return secrets.Resolve(value)
+}
+
+// webhookClient returns an HTTP client presenting the channel's client
+// certificate, reused across sends so connections are kept alive
+func webhookClient(auth *WebhookTLSAuth) (*http.Client, error) {
+	if auth == nil {
+		return defaultWebhookCl, nil
+	}
+	if client, ok := webhookClients.Load(*auth); ok {
+		return client.(*http.Client), nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if auth.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(auth.CertFile, auth.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load webhook client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if auth.CAFile != "" {
+		pem, err := os.ReadFile(auth.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read webhook CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in webhook CA file %s", auth.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	client, _ := webhookClients.LoadOrStore(*auth, &http.Client{Timeout: webhookTimeout, Transport: transport})
+	return client.(*http.Client), nil
+}
+
+// oauth2Key identifies a cached token
+func (o *WebhookOAuth2) key() string {
+	return o.TokenURL + "\x00" + o.ClientID + "\x00" + strings.Join(o.Scopes, " ")
+}
+
+// token returns a cached access token, fetching a new one with the client
+// credentials grant when there is none or it is about to expire
+func (o *WebhookOAuth2) token(client *http.Client) (string, error) {
+	oauth2Mu.Lock()
+	defer oauth2Mu.Unlock()
+	if t, ok := oauth2Tokens[o.key()]; ok && time.Now().Before(t.expires) {
+		return t.value, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(o.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.Scopes, " "))
+	}
+	req, err := http.NewRequest(http.MethodPost, o.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	clientSecret, err := resolveSecret(o.ClientSecret)
+	if err != nil {
+		return "", fmt.Errorf("client_secret: %w", err)
+	}
+	req.SetBasicAuth(url.QueryEscape(o.ClientID), url.QueryEscape(clientSecret))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	var tr struct {
+		AccessToken string      `json:"access_token"`
+		TokenType   string      `json:"token_type"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tr); err != nil || tr.AccessToken == "" {
+		return "", fmt.Errorf("token response has no access_token")
+	}
+	if tr.TokenType != "" && !strings.EqualFold(tr.TokenType, "bearer") {
+		return "", fmt.Errorf("unsupported token type %q", tr.TokenType)
+	}
+
+	expires := time.Now().Add(time.Hour)
+	if seconds, err := strconv.Atoi(tr.ExpiresIn.String()); err == nil && seconds > 0 {
+		expires = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	oauth2Tokens[o.key()] = oauth2Token{value: tr.AccessToken, expires: expires.Add(-tokenExpiryMargin)}
+	return tr.AccessToken, nil
+}
+
+// forget drops a cached token the webhook server rejected
+func (o *WebhookOAuth2) forget() {
+	oauth2Mu.Lock()
+	defer oauth2Mu.Unlock()
+	delete(oauth2Tokens, o.key())
+}
+
+// sendWebhook posts body with the channel's headers, signature and OAuth2
+// token. A 401 with a cached token fetches a new token and retries once.
+func sendWebhook(cfg *WebhookConfig, body []byte) (*http.Response, error) {
+	client, err := webhookClient(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(cfg.Method, cfg.URL, strings.NewReader(string(body)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create webhook request: %w", err)
+		}
+		req.Header.Set("Content-Type", cfg.ContentType)
+		for key, value := range cfg.Headers {
+			value, err := resolveSecret(value)
+			if err != nil {
+				return nil, fmt.Errorf("header %s: %w", key, err)
+			}
+			req.Header.Set(key, value)
+		}
+		secret, err := resolveSecret(cfg.HMACSecret)
+		if err != nil {
+			return nil, fmt.Errorf("hmac_secret: %w", err)
+		}
+		if secret != "" {
+			ts := time.Now().Unix()
+			req.Header.Set(websec.TimestampHeader, strconv.FormatInt(ts, 10))
+			req.Header.Set(websec.SignatureHeader, websec.SignBody(secret, ts, body))
+		}
+		if cfg.OAuth2 != nil {
+			token, err := cfg.OAuth2.token(client)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get OAuth2 token: %w", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send webhook request: %w", err)
+		}
+		if resp.StatusCode == http.StatusUnauthorized && cfg.OAuth2 != nil && attempt == 0 {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+			cfg.OAuth2.forget()
+			continue
+		}
+		return resp, nil
+	}
+}
@@ -0,0 +1,163 @@
+package alarm
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/websec"
+)
+
+func sendTestWebhook(t *testing.T, cfg *WebhookConfig) error {
+	t.Helper()
+	ch := &Channel{Type: "webhook", Webhook: cfg}
+	if err := ch.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return (&WebhookNotifier{}).Send(&Alarm{Name: "Hot"}, ch, &weather.Observation{AirTemperature: 31}, "Backyard")
+}
+
+func TestWebhookHMACSigning(t *testing.T) {
+	t.Setenv("TEST_WEBHOOK_SECRET", "s3cret")
+	var verifyErr error
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verifyErr = websec.VerifySignature("s3cret", r.Header.Get(websec.SignatureHeader), r.Header.Get(websec.TimestampHeader), body, time.Now())
+	}))
+	defer srv.Close()
+
+	if err := sendTestWebhook(t, &WebhookConfig{URL: srv.URL, Body: `{"alarm":"{{alarm_name}}"}`, HMACSecret: "${TEST_WEBHOOK_SECRET}"}); err != nil {
+		t.Fatal(err)
+	}
+	if verifyErr != nil {
+		t.Errorf("signature rejected: %v", verifyErr)
+	}
+}
+
+func TestWebhookSecretReferences(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "hmac")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_WEBHOOK_API_KEY", "key-123")
+	var verifyErr error
+	var apiKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		apiKey = r.Header.Get("X-Api-Key")
+		verifyErr = websec.VerifySignature("from-file", r.Header.Get(websec.SignatureHeader), r.Header.Get(websec.TimestampHeader), body, time.Now())
+	}))
+	defer srv.Close()
+
+	cfg := &WebhookConfig{
+		URL:        srv.URL,
+		Body:       `{"alarm":"{{alarm_name}}"}`,
+		Headers:    map[string]string{"X-Api-Key": "env:TEST_WEBHOOK_API_KEY"},
+		HMACSecret: "file:" + secretFile,
+	}
+	if err := sendTestWebhook(t, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if verifyErr != nil {
+		t.Errorf("signature rejected: %v", verifyErr)
+	}
+	if apiKey != "key-123" {
+		t.Errorf("X-Api-Key = %q, want key-123", apiKey)
+	}
+
+	cfg.HMACSecret = "env:TEST_WEBHOOK_UNSET"
+	if err := sendTestWebhook(t, cfg); err == nil {
+		t.Error("expected an error for an unset secret reference")
+	}
+}
+
+func TestWebhookOAuth2ClientCredentials(t *testing.T) {
+	var issued, rejected atomic.Int32
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if r.FormValue("grant_type") != "client_credentials" || id != "bridge" || secret != "pw" || r.FormValue("scope") != "alerts.write" {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		n := issued.Add(1)
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+	defer tokens.Close()
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first token is revoked server-side
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			rejected.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer hook.Close()
+
+	cfg := &WebhookConfig{URL: hook.URL, Body: "{}", OAuth2: &WebhookOAuth2{TokenURL: tokens.URL, ClientID: "bridge", ClientSecret: "pw", Scopes: []string{"alerts.write"}}}
+	defer cfg.OAuth2.forget()
+	if err := sendTestWebhook(t, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := sendTestWebhook(t, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if issued.Load() != 2 || rejected.Load() != 1 {
+		t.Errorf("issued %d tokens, %d rejected; want a refresh after the 401 and the new token cached", issued.Load(), rejected.Load())
+	}
+
+	cfg.OAuth2.forget()
+	cfg.OAuth2.ClientSecret = "wrong"
+	if err := sendTestWebhook(t, cfg); err == nil {
+		t.Error("webhook sent without a token")
+	}
+}
+
+func TestWebhookMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "bridge"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	certFile, keyFile, caFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key"), filepath.Join(dir, "ca.pem")
+	_ = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	_ = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+
+	clientCert, _ := x509.ParseCertificate(der)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+	_ = os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600)
+
+	if err := sendTestWebhook(t, &WebhookConfig{URL: srv.URL, Body: "{}", TLS: &WebhookTLSAuth{CertFile: certFile, KeyFile: keyFile, CAFile: caFile}}); err != nil {
+		t.Fatalf("mTLS webhook: %v", err)
+	}
+	if err := sendTestWebhook(t, &WebhookConfig{URL: srv.URL, Body: "{}", TLS: &WebhookTLSAuth{CAFile: caFile}}); err == nil {
+		t.Error("webhook accepted without a client certificate")
+	}
+}
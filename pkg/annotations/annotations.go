@@ -0,0 +1,221 @@
+// Package annotations keeps notes users attach to time ranges of the
+// observation history ("moved station", "sprinkler hit sensor"), so charts
+// and exports can explain readings that would otherwise look like anomalies.
+package annotations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"tempest-homekit-go/pkg/logger"
+)
+
+// StateFile is where annotations are kept across restarts; empty keeps them
+// in memory only. main sets it from the data directory.
+var StateFile string
+
+const (
+	// MaxAnnotations caps the stored annotations
+	MaxAnnotations = 1000
+
+	// MaxTextLength is the longest note, in characters
+	MaxTextLength = 500
+)
+
+// ErrNotFound is returned by Update and Delete for an unknown annotation ID
+var ErrNotFound = errors.New("annotation not found")
+
+// Annotation is a note on a time range. A note on a moment has End equal to
+// Start.
+type Annotation struct {
+	ID      int       `json:"id"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Text    string    `json:"text"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated,omitempty"`
+}
+
+// overlaps reports whether the annotation and [from, to] share any time
+func (a Annotation) overlaps(from, to time.Time) bool {
+	return !a.End.Before(from) && !a.Start.After(to)
+}
+
+// state is what StateFile holds
+type state struct {
+	NextID      int          `json:"nextId"`
+	Annotations []Annotation `json:"annotations"`
+}
+
+// Store holds the annotations, oldest start first
+type Store struct {
+	mu          sync.RWMutex
+	annotations []Annotation
+	nextID      int
+	now         func() time.Time
+}
+
+// New returns a store with the annotations saved in StateFile
+func New() *Store {
+	s := &Store{nextID: 1, now: time.Now}
+	s.load()
+	return s
+}
+
+// validate checks and normalizes a note and its range
+func validate(start, end time.Time, text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("annotation text is required")
+	}
+	if utf8.RuneCountInString(text) > MaxTextLength {
+		return "", fmt.Errorf("annotation text is limited to %d characters", MaxTextLength)
+	}
+	if start.IsZero() {
+		return "", fmt.Errorf("annotation start is required")
+	}
+	if end.Before(start) {
+		return "", fmt.Errorf("annotation must end at or after its start")
+	}
+	return text, nil
+}
+
+// Add stores a note on [start, end]; a zero end marks the moment start
+func (s *Store) Add(start, end time.Time, text string) (Annotation, error) {
+	if end.IsZero() {
+		end = start
+	}
+	text, err := validate(start, end, text)
+	if err != nil {
+		return Annotation{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.annotations) >= MaxAnnotations {
+		return Annotation{}, fmt.Errorf("annotations are limited to %d; delete old ones first", MaxAnnotations)
+	}
+	a := Annotation{ID: s.nextID, Start: start.UTC().Truncate(time.Second), End: end.UTC().Truncate(time.Second), Text: text, Created: s.now().UTC().Truncate(time.Second)}
+	s.nextID++
+	s.annotations = append(s.annotations, a)
+	s.sortLocked()
+	s.saveLocked()
+	logger.Info("Annotation %d added for %s: %s", a.ID, a.Start.Format(time.RFC3339), a.Text)
+	return a, nil
+}
+
+// Update replaces the range and text of annotation id
+func (s *Store) Update(id int, start, end time.Time, text string) (Annotation, error) {
+	if end.IsZero() {
+		end = start
+	}
+	text, err := validate(start, end, text)
+	if err != nil {
+		return Annotation{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.annotations {
+		a := &s.annotations[i]
+		if a.ID != id {
+			continue
+		}
+		a.Start, a.End, a.Text = start.UTC().Truncate(time.Second), end.UTC().Truncate(time.Second), text
+		a.Updated = s.now().UTC().Truncate(time.Second)
+		updated := *a
+		s.sortLocked()
+		s.saveLocked()
+		return updated, nil
+	}
+	return Annotation{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+}
+
+// Delete removes annotation id
+func (s *Store) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, a := range s.annotations {
+		if a.ID == id {
+			s.annotations = append(s.annotations[:i], s.annotations[i+1:]...)
+			s.saveLocked()
+			logger.Info("Annotation %d deleted", id)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %d", ErrNotFound, id)
+}
+
+// List returns the annotations overlapping [from, to], oldest start first. A
+// zero from or to leaves that side open.
+func (s *Store) List(from, to time.Time) []Annotation {
+	if to.IsZero() {
+		to = time.Unix(1<<62, 0)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := []Annotation{}
+	for _, a := range s.annotations {
+		if a.overlaps(from, to) {
+			list = append(list, a)
+		}
+	}
+	return list
+}
+
+// sortLocked orders the annotations by start; the caller holds s.mu
+func (s *Store) sortLocked() {
+	sort.SliceStable(s.annotations, func(i, j int) bool { return s.annotations[i].Start.Before(s.annotations[j].Start) })
+}
+
+// saveLocked writes the annotations to StateFile; the caller holds s.mu
+func (s *Store) saveLocked() {
+	if StateFile == "" {
+		return
+	}
+	data, err := json.MarshalIndent(state{NextID: s.nextID, Annotations: s.annotations}, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(StateFile), 0755); err != nil {
+		logger.Warn("Failed to save annotations: %v", err)
+		return
+	}
+	tmp := StateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		logger.Warn("Failed to save annotations: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, StateFile); err != nil {
+		logger.Warn("Failed to save annotations: %v", err)
+	}
+}
+
+func (s *Store) load() {
+	if StateFile == "" {
+		return
+	}
+	data, err := os.ReadFile(StateFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("Failed to read annotations: %v", err)
+		}
+		return
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		logger.Warn("Ignoring invalid annotations file %s: %v", StateFile, err)
+		return
+	}
+	s.annotations = st.Annotations
+	s.nextID = max(st.NextID, 1)
+	s.sortLocked()
+}
@@ -0,0 +1,73 @@
+package annotations
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testStore returns a store saving to a temporary state file
+func testStore(t *testing.T) *Store {
+	t.Helper()
+	StateFile = filepath.Join(t.TempDir(), "annotations.json")
+	t.Cleanup(func() { StateFile = "" })
+	return New()
+}
+
+func TestStore(t *testing.T) {
+	s := testStore(t)
+	base := time.Date(2025, 6, 1, 14, 0, 0, 0, time.UTC)
+
+	sprinkler, err := s.Add(base, base.Add(90*time.Minute), " sprinkler hit sensor ")
+	if err != nil || sprinkler.ID != 1 || sprinkler.Text != "sprinkler hit sensor" {
+		t.Fatalf("Add = %+v, %v", sprinkler, err)
+	}
+	moved, err := s.Add(base.Add(-24*time.Hour), time.Time{}, "moved station")
+	if err != nil || !moved.End.Equal(moved.Start) {
+		t.Fatalf("Add moment = %+v, %v", moved, err)
+	}
+	for name, a := range map[string]Annotation{
+		"no text":   {Start: base, Text: "  "},
+		"no start":  {Text: "x"},
+		"backwards": {Start: base, End: base.Add(-time.Hour), Text: "x"},
+		"too long":  {Start: base, Text: strings.Repeat("é", MaxTextLength+1)},
+	} {
+		if _, err := s.Add(a.Start, a.End, a.Text); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+
+	if list := s.List(time.Time{}, time.Time{}); len(list) != 2 || list[0].ID != moved.ID {
+		t.Errorf("List = %+v, want both, oldest start first", list)
+	}
+	if list := s.List(base.Add(time.Hour), base.Add(2*time.Hour)); len(list) != 1 || list[0].ID != sprinkler.ID {
+		t.Errorf("List overlapping = %+v, want the sprinkler note", list)
+	}
+	if list := s.List(base.Add(2*time.Hour), time.Time{}); len(list) != 0 {
+		t.Errorf("List after = %+v, want none", list)
+	}
+
+	if updated, err := s.Update(sprinkler.ID, base, base.Add(time.Hour), "sprinkler"); err != nil || updated.Text != "sprinkler" || updated.Updated.IsZero() {
+		t.Errorf("Update = %+v, %v", updated, err)
+	}
+	if _, err := s.Update(99, base, base, "x"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update(99) = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(moved.ID); err != nil {
+		t.Errorf("Delete: %v", err)
+	}
+	if err := s.Delete(moved.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete again = %v, want ErrNotFound", err)
+	}
+
+	// The state file keeps the notes and the ID sequence
+	restored := New()
+	if list := restored.List(time.Time{}, time.Time{}); len(list) != 1 || list[0].Text != "sprinkler" {
+		t.Errorf("restored = %+v", list)
+	}
+	if a, _ := restored.Add(base, time.Time{}, "x"); a.ID != 3 {
+		t.Errorf("restored next ID = %d, want 3", a.ID)
+	}
+}
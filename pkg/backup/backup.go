@@ -0,0 +1,392 @@
+// Package backup writes the bridge's state (HomeKit db, statistics, alarm
+// configuration and its backups) to one tar.gz archive and restores it, so a
+// bridge moves to another host without pairing again. Every archive carries a
+// manifest with the SHA-256 of each file, and nothing is restored from an
+// archive that does not match it.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ManifestName is the archive entry listing the items and file checksums
+const ManifestName = "manifest.json"
+
+// formatVersion is bumped when the archive layout changes incompatibly
+const formatVersion = 1
+
+// Item is a file or directory of state, stored in the archive under Name
+type Item struct {
+	Name string // archive path, e.g. "db" or "alarms.json"
+	Path string // location on this host
+}
+
+// FileEntry is a file in the archive
+type FileEntry struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Mode   uint32 `json:"mode"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes an archive
+type Manifest struct {
+	Version    int         `json:"version"`
+	Created    time.Time   `json:"created"`
+	Host       string      `json:"host,omitempty"`
+	AppVersion string      `json:"appVersion,omitempty"`
+	Items      []string    `json:"items"` // names of the items that existed when the backup was taken
+	Files      []FileEntry `json:"files"`
+}
+
+// Result reports what Restore did
+type Result struct {
+	Manifest *Manifest
+	Restored []string // items written to this host
+	Skipped  []string // items in the archive this host has no location for
+	Previous []string // existing state moved aside to <path>.pre-restore
+}
+
+// Create writes items that exist to a gzip-compressed tar archive, then
+// reads the archive back to verify it. Missing items are left out. The
+// archive holds secrets (HomeKit keys, tokens in the environment file) and is
+// only readable by its owner.
+func Create(archive string, items []Item, appVersion string) (*Manifest, error) {
+	manifest := &Manifest{Version: formatVersion, Created: time.Now().UTC(), AppVersion: appVersion}
+	manifest.Host, _ = os.Hostname()
+
+	tmp := archive + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(tmp) // no-op after the rename below
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, item := range items {
+		if item.Path == "" {
+			continue
+		}
+		info, err := os.Stat(item.Path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		manifest.Items = append(manifest.Items, item.Name)
+		if !info.IsDir() {
+			err = addFile(tw, manifest, item.Name, item.Path, info)
+		} else {
+			err = filepath.WalkDir(item.Path, func(p string, d fs.DirEntry, err error) error {
+				if err != nil || !d.Type().IsRegular() {
+					return err // directories are implied by their files; links and devices are not state
+				}
+				rel, err := filepath.Rel(item.Path, p)
+				if err != nil {
+					return err
+				}
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				return addFile(tw, manifest, path.Join(item.Name, filepath.ToSlash(rel)), p, info)
+			})
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to add %s: %w", item.Name, err)
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = tw.WriteHeader(&tar.Header{Name: ManifestName, Mode: 0600, Size: int64(len(data)), ModTime: manifest.Created})
+	}
+	if err == nil {
+		_, err = tw.Write(data)
+	}
+	for _, closer := range []io.Closer{tw, gz, f} {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	if _, err := Verify(tmp); err != nil {
+		return nil, fmt.Errorf("archive failed verification: %w", err)
+	}
+	if err := os.Rename(tmp, archive); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// addFile writes one file and records its checksum in the manifest
+func addFile(tw *tar.Writer, manifest *Manifest, name, filePath string, info fs.FileInfo) error {
+	src, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: int64(info.Mode().Perm()), Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return err
+	}
+	sum := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tw, sum), src)
+	if err != nil {
+		return err
+	}
+	if n != info.Size() {
+		return fmt.Errorf("%s changed while it was read", filePath)
+	}
+	manifest.Files = append(manifest.Files, FileEntry{Name: name, Size: n, Mode: uint32(info.Mode().Perm()), SHA256: hex.EncodeToString(sum.Sum(nil))})
+	return nil
+}
+
+// Verify reads the whole archive and checks every file against the manifest
+func Verify(archive string) (*Manifest, error) {
+	return walk(archive, nil)
+}
+
+// walk reads the archive, calling extract (when set) with each file's
+// contents, and checks the files against the manifest
+func walk(archive string, extract func(name string, mode fs.FileMode, r io.Reader) error) (*Manifest, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	var manifest *Manifest
+	seen := map[string]FileEntry{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corrupt archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected entry %s in archive", hdr.Name)
+		}
+		if hdr.Name == ManifestName {
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("invalid manifest: %w", err)
+			}
+			continue
+		}
+		if !validName(hdr.Name) {
+			return nil, fmt.Errorf("unsafe path %q in archive", hdr.Name)
+		}
+		if _, dup := seen[hdr.Name]; dup {
+			return nil, fmt.Errorf("%s is in the archive twice", hdr.Name)
+		}
+		sum := sha256.New()
+		var r io.Reader = io.TeeReader(tr, sum)
+		var n int64
+		if extract != nil {
+			counter := &countingReader{r: r}
+			err = extract(hdr.Name, fs.FileMode(hdr.Mode).Perm(), counter)
+			n = counter.n
+		} else {
+			n, err = io.Copy(io.Discard, r)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", hdr.Name, err)
+		}
+		seen[hdr.Name] = FileEntry{Name: hdr.Name, Size: n, SHA256: hex.EncodeToString(sum.Sum(nil))}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("archive has no %s; it was not written by --backup", ManifestName)
+	}
+	if manifest.Version != formatVersion {
+		return nil, fmt.Errorf("archive format %d is not supported (expected %d)", manifest.Version, formatVersion)
+	}
+	for _, want := range manifest.Files {
+		got, ok := seen[want.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s is missing from the archive", want.Name)
+		}
+		if got.Size != want.Size || got.SHA256 != want.SHA256 {
+			return nil, fmt.Errorf("%s does not match its checksum", want.Name)
+		}
+		delete(seen, want.Name)
+	}
+	for name := range seen {
+		return nil, fmt.Errorf("%s is not listed in the manifest", name)
+	}
+	return manifest, nil
+}
+
+// validName rejects absolute paths and paths leaving the item directory
+func validName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, "/") && !strings.Contains(name, "\\") &&
+		path.Clean(name) == name && name != ".." && !strings.HasPrefix(name, "../")
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Restore verifies the archive and replaces the state of every item in it
+// that items gives a location for. The archive is unpacked to a staging
+// directory first, so a corrupt archive changes nothing. Existing state is
+// kept at <path>.pre-restore.
+func Restore(archive string, items []Item) (*Result, error) {
+	staging, err := os.MkdirTemp("", "tempest-restore-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	manifest, err := walk(archive, func(name string, mode fs.FileMode, r io.Reader) error {
+		target := filepath.Join(staging, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return err
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode|0600)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, r); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make(map[string]string, len(items))
+	for _, item := range items {
+		if item.Path != "" {
+			locations[item.Name] = item.Path
+		}
+	}
+	result := &Result{Manifest: manifest}
+	for _, name := range manifest.Items {
+		dest, ok := locations[name]
+		if !ok {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+		previous, err := replace(filepath.Join(staging, filepath.FromSlash(name)), dest)
+		if err != nil {
+			return result, fmt.Errorf("failed to restore %s to %s: %w", name, dest, err)
+		}
+		if previous != "" {
+			result.Previous = append(result.Previous, previous)
+		}
+		result.Restored = append(result.Restored, name)
+	}
+	sort.Strings(result.Skipped)
+	return result, nil
+}
+
+// replace copies src (a file or directory) next to dest, moves dest aside
+// and renames the copy into place. An empty directory at dest, as created by
+// --data-dir on startup, is removed instead of kept. A directory item with no
+// files has nothing in the staging directory and restores as empty.
+func replace(src, dest string) (previous string, err error) {
+	incoming := dest + ".restoring"
+	if err := os.RemoveAll(incoming); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		err = os.MkdirAll(incoming, 0755)
+		if err != nil {
+			return "", err
+		}
+	} else if err := copyTree(src, incoming); err != nil {
+		os.RemoveAll(incoming)
+		return "", err
+	}
+
+	if entries, err := os.ReadDir(dest); err == nil && len(entries) == 0 {
+		if err := os.Remove(dest); err != nil {
+			return "", err
+		}
+	} else if _, err := os.Lstat(dest); err == nil {
+		previous = dest + ".pre-restore"
+		if err := os.RemoveAll(previous); err != nil {
+			return "", err
+		}
+		if err := os.Rename(dest, previous); err != nil {
+			return "", err
+		}
+	}
+	return previous, os.Rename(incoming, dest)
+}
+
+// copyTree copies a file, or a directory with its files, keeping permissions
+func copyTree(src, dest string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		in, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
@@ -0,0 +1,188 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "db", "keypair"), "keys")
+	writeFile(t, filepath.Join(src, "db", "pairings", "ios"), "paired")
+	writeFile(t, filepath.Join(src, "alarms.json"), `{"alarms": []}`)
+	items := []Item{
+		{Name: "db", Path: filepath.Join(src, "db")},
+		{Name: "alarms.json", Path: filepath.Join(src, "alarms.json")},
+		{Name: "stats.json", Path: filepath.Join(src, "stats.json")}, // missing: left out
+	}
+
+	archive := filepath.Join(t.TempDir(), "state.tar.gz")
+	manifest, err := Create(archive, items, "1.0.0")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if strings.Join(manifest.Items, ",") != "db,alarms.json" || len(manifest.Files) != 3 {
+		t.Errorf("manifest = %+v", manifest)
+	}
+	if info, err := os.Stat(archive); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("archive mode = %v, %v; want 0600", info, err)
+	}
+
+	dest := t.TempDir()
+	writeFile(t, filepath.Join(dest, "db", "keypair"), "other host")
+	if err := os.MkdirAll(filepath.Join(dest, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	result, err := Restore(archive, []Item{
+		{Name: "db", Path: filepath.Join(dest, "db")},
+		{Name: "stats.json", Path: filepath.Join(dest, "stats.json")},
+	})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if strings.Join(result.Restored, ",") != "db" || strings.Join(result.Skipped, ",") != "alarms.json" {
+		t.Errorf("restored %v, skipped %v", result.Restored, result.Skipped)
+	}
+	if got := readFile(t, filepath.Join(dest, "db", "pairings", "ios")); got != "paired" {
+		t.Errorf("restored pairing = %q", got)
+	}
+	if got := readFile(t, filepath.Join(dest, "db.pre-restore", "keypair")); got != "other host" {
+		t.Errorf("previous keypair = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "stats.json")); !os.IsNotExist(err) {
+		t.Error("an item missing from the backup was created")
+	}
+}
+
+func TestRestoreReplacesEmptyDirectory(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "db", "keypair"), "keys")
+	archive := filepath.Join(t.TempDir(), "state.tar.gz")
+	if _, err := Create(archive, []Item{{Name: "db", Path: filepath.Join(src, "db")}}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "db")
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	result, err := Restore(archive, []Item{{Name: "db", Path: dest}})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if len(result.Previous) != 0 {
+		t.Errorf("empty directory kept as %v", result.Previous)
+	}
+	if got := readFile(t, filepath.Join(dest, "keypair")); got != "keys" {
+		t.Errorf("keypair = %q", got)
+	}
+}
+
+// rewrite copies an archive, changing the contents of one entry
+func rewrite(t *testing.T, archive, name string, change func([]byte) []byte) string {
+	t.Helper()
+	f, err := os.Open(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	gw := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gw)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		if hdr.Name == name {
+			data = change(data)
+			hdr.Size = int64(len(data))
+		}
+		_ = tw.WriteHeader(hdr)
+		_, _ = tw.Write(data)
+	}
+	_ = tw.Close()
+	_ = gw.Close()
+	tampered := archive + ".tampered"
+	if err := os.WriteFile(tampered, out.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return tampered
+}
+
+func TestVerifyRejectsDamage(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "alarms.json"), `{"alarms": []}`)
+	archive := filepath.Join(t.TempDir(), "state.tar.gz")
+	if _, err := Create(archive, []Item{{Name: "alarms.json", Path: filepath.Join(src, "alarms.json")}}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(archive); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	tampered := rewrite(t, archive, "alarms.json", func(b []byte) []byte { return bytes.ToUpper(b) })
+	if _, err := Verify(tampered); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("tampered file: %v, want a checksum error", err)
+	}
+	dest := filepath.Join(t.TempDir(), "alarms.json")
+	writeFile(t, dest, "current")
+	if _, err := Restore(tampered, []Item{{Name: "alarms.json", Path: dest}}); err == nil {
+		t.Error("restore of a tampered archive succeeded")
+	}
+	if got := readFile(t, dest); got != "current" {
+		t.Errorf("failed restore changed the state to %q", got)
+	}
+
+	data, _ := os.ReadFile(archive)
+	truncated := archive + ".truncated"
+	_ = os.WriteFile(truncated, data[:len(data)/2], 0600)
+	if _, err := Verify(truncated); err == nil {
+		t.Error("truncated archive verified")
+	}
+}
+
+func TestValidName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"db/keypair": true, "alarms.json": true,
+		"": false, "/etc/passwd": false, "../x": false, "db/../../x": false, "db//x": false, `db\x`: false,
+	} {
+		if got := validName(name); got != want {
+			t.Errorf("validName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
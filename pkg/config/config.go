@@ -8,13 +8,23 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
+
+	"tempest-homekit-go/pkg/federation"
+	"tempest-homekit-go/pkg/maintenance"
+	"tempest-homekit-go/pkg/udp"
+	"tempest-homekit-go/pkg/webhooklistener"
+	"tempest-homekit-go/pkg/websec"
 )
 
 // Config holds all configuration parameters for the Tempest HomeKit service.
@@ -22,9 +32,38 @@ type Config struct {
 	Token                  string
 	StationName            string
 	Pin                    string
+	HomeKitMode            string // "split" (one accessory per sensor) or "combined" (one station accessory)
+	HomeKitNames           string // Accessory name overrides: "temperature=Backyard Temperature,humidity=Backyard Humidity"
+	HomeKitNamePattern     string // Accessory name pattern, e.g. "{station} {name}"
+	HomeKitSerialPattern   string // Accessory serial number pattern, e.g. "{serial}-{station}"
+	HomeKitAddress         string // IP the HomeKit (HAP) server binds to; empty binds to all addresses
+	HomeKitPort            string // Port of the HomeKit (HAP) server; empty picks a free port
+	HomeKitInterfaces      string // Comma-separated interfaces the bridge is announced on over mDNS
+	GustHold               string // Keep reporting a gust to HomeKit for this long ("0" reports the peak since the last update)
+	LuxSmoothing           string // Time constant of the moving average of the illuminance reported to HomeKit ("0" reports it raw)
 	LogLevel               string
 	LogFilter              string // Filter log messages to only show those containing this string
 	WebPort                string
+	WebAddress             string // IP the web server binds to; empty binds to all addresses
+	WebBasePath            string // URL prefix a reverse proxy serves the web console under, e.g. /station/alice
+	PublicURL              string // URL alarm recipients reach the dashboard at, for acknowledgement links, e.g. https://weather.example.com
+	HiddenCards            string // Comma-separated dashboard cards not rendered, e.g. uv,forecast
+	Tenants                string // JSON file of stations served under /station/{name}/ (empty: single station)
+	Federate               string // Other instances to poll for the combined /sites page and cross-site alarms: "cabin=http://cabin.local:8080,..."
+	FederateInterval       string // Time between polls of the federated instances, e.g. 1m
+	GRPCPort               string // Port for the gRPC API (empty disables it)
+	MQTTBroker             string // MQTT broker for derived statistics such as ET0 (empty disables publishing)
+	MQTTTopic              string // Topic prefix for MQTT messages
+	MQTTUsername           string // MQTT broker user name
+	MQTTPassword           string // MQTT broker password
+	AdminPassword          string // Password for admin endpoints such as HomeKit pairing management (HTTP basic auth)
+	CORSOrigins            string // Comma-separated origins allowed to call the web API cross-site; "*" allows any
+	ContentSecurityPolicy  string // Content-Security-Policy replacing the built-in one (empty keeps the default)
+	FrameAncestors         string // CSP frame-ancestors sources allowed to embed the web pages (empty forbids framing)
+	WebReadHeaderTimeout   string // How long web and webhook listener clients may take to send request headers ("0" disables)
+	WebWriteTimeout        string // How long writing a web response may take ("0" disables)
+	WebIdleTimeout         string // How long idle keep-alive connections are kept open ("0" disables)
+	WebMaxConns            int    // Concurrent connections accepted by the web server and webhook listener (0: unlimited)
 	ClearDB                bool
 	DisableHomeKit         bool // Disable HomeKit services and run web console only
 	DisableWebConsole      bool // Disable web server (HomeKit only mode)
@@ -43,8 +82,11 @@ type Config struct {
 	TestEventLog           bool    // Send test eventlog notification and exit
 	TestUDP                int     // Listen for UDP broadcasts for N seconds and display received data (default: 120)
 	TestHomeKit            bool    // Test HomeKit bridge setup and pairing without starting service
+	TestAll                bool    // Run every configured self-test, print a pass/fail report and exit
+	Doctor                 bool    // Check ports, firewall, clock, directories, DNS and Chrome, suggest fixes and exit
 	TestWebStatus          bool    // Test web status scraping and exit
 	TestAlarm              string  // Trigger a specific alarm by name for testing
+	EvalCondition          string  // Evaluate an alarm condition against the current observation and exit
 	UseWebStatus           bool    // Enable headless browser scraping of TempestWX status
 	UseGeneratedWeather    bool    // Use generated weather data for testing instead of Tempest API
 	TestSensorRain         bool    // Test rain sensor with cycling pattern (requires --use-generated-weather)
@@ -55,12 +97,50 @@ type Config struct {
 	TestSensorLux          bool    // Test lux sensor with cycling pattern (requires --use-generated-weather)
 	TestSensorUV           bool    // Test UV sensor with cycling pattern (requires --use-generated-weather)
 	TestSensorLightning    bool    // Test lightning sensor with cycling pattern (requires --use-generated-weather)
+	InjectAPIErrors        string  // Percentage of WeatherFlow API requests answered with a synthetic 503 (chaos testing)
+	InjectUDPDrop          string  // Percentage of received UDP packets discarded (chaos testing)
+	InjectLatency          string  // Delay added to every WeatherFlow API request (chaos testing)
+	BenchWeb               bool    // Load test the web server against a synthetic history and exit
+	BenchWebMix            string  // Request mix for --bench-web, e.g. "status=3,history=1"
+	BenchWebRequests       int     // Total requests replayed by --bench-web
+	BenchWebConcurrency    int     // Parallel clients used by --bench-web
 	UDPStream              bool    // Listen for UDP broadcasts from local Tempest station
+	UDPMergeAPI            bool    // With UDPStream, also poll the REST API and merge its observations
+	UDPPort                string  // Port the UDP listener binds (default 50222)
+	UDPNetwork             string  // UDP listener socket: "udp" (IPv4 and IPv6), "udp4" or "udp6"
+	UDPInterface           string  // Network interface the UDP listener is bound to (empty: all)
+	UDPMulticast           string  // Multicast groups the UDP listener joins: "239.0.0.222,ff02::1"
+	UDPRelay               string  // Hosts received UDP packets are sent on to: "192.168.20.255:50222,..." (empty: no relay)
+	UDPSerial              string  // Devices used from UDP broadcasts: "ST-00012345=Backyard,..." (empty: the first Tempest heard)
+	UDPRawPackets          int     // Raw UDP packets kept for /api/udp/raw with --loglevel debug (default: 100, 0 disables)
+	Ingest                 bool    // Read observations pushed to /api/ingest instead of a Tempest station
+	IngestToken            string  // Bearer token required by /api/ingest
+	EcowittPort            string  // Port of the Ecowitt/Wunderground upload listener ("" disables)
+	EcowittPasskey         string  // PASSKEY or PASSWORD uploads must carry ("" accepts any)
 	DisableInternet        bool    // Disable all internet access (no API, no status scraping)
 	StationURL             string  // Custom station URL for weather data (overrides Tempest API)
+	WeatherFlowAPIURL      string  // WeatherFlow REST API base URL, e.g. a cmd/fakeserver ("" uses the real API)
+	WatchdogTimeout        string  // Restart the data source after this long without observations ("0" disables)
+	TokenCheckInterval     string  // Re-check the WeatherFlow token this often ("0" checks only at startup)
+	PollObs                string  // WeatherFlow observation polling interval
+	PollForecast           string  // Forecast polling interval
+	PollStatus             string  // Station status page scraping interval (--use-web-status)
+	BackfillGap            string  // Fetch missing history when observations are further apart than this ("0" disables)
+	Maintenance            string  // Maintenance windows suspending alarms and uploads: "2h" from startup or START/END, comma-separated
+	RainCorrection         string  // Multiplier applied to gauge rain values, from a check gauge ("1" leaves them as reported)
+	SnowRatio              string  // Snow-to-liquid ratio for snowfall estimates ("10" means 10 mm of snow per mm of water)
+	GDDBase                string  // Growing degree day base temperature, °C unless suffixed with F ("50F")
+	GDDSeasonStart         string  // MM-DD the growing degree day season starts on
+	ChillSeasonStart       string  // MM-DD the chill hour season starts on
+	AnomalyThreshold       string  // z-score from which a reading counts as an anomaly
+	ComfortRange           string  // Indoor comfort temperature range for the comfort advisor, °C unless suffixed with F ("68-75F")
+	ComfortHumidity        string  // Highest comfortable indoor relative humidity (%) for the comfort advisor
 	Elevation              float64 // elevation in meters
 	Units                  string  // Units system: imperial, metric, or sae
 	UnitsPressure          string  // Pressure units: inHg or mb
+	UnitsPressureHomeKit   string  // Pressure units of the HomeKit pressure sensor: inHg or mb
+	UnitsPressureNotify    string  // Pressure units of notifications ({{pressure_local}}, {{sensor_info}}): inHg or mb
+	UnitsPressureExport    string  // Pressure units of file channel exports: inHg or mb
 	HistoryPoints          int     // Number of data points to store in history (default: 1000, min: 10)
 	ChartHistoryHours      int     // Number of hours of history to display in charts (default: 24, 0 = all)
 	HistoryReduce          int     // Reduction factor for historical data (average N points into 1)
@@ -68,6 +148,13 @@ type Config struct {
 	HistoryBinMinutes      int     // Bin size in minutes for timebin reduction
 	HistoryKeepRecentHours int     // Keep recent N hours at full resolution when reducing history
 	Version                bool    // Show version and exit
+	InstallService         bool    // Install an OS service (systemd, launchd, Windows) running the bridge with the other flags, then exit
+	ServiceUnit            string  // Path of the unit file or plist written by --install-service; empty uses the platform default
+	Backup                 string  // Write the HomeKit db, statistics and alarm configuration to this tar.gz archive, then exit
+	Restore                string  // Verify and restore a --backup archive into the data directory, then exit
+	LogFile                string  // Append log output to this file instead of the console
+	DataDir                string  // Directory holding all state (HomeKit db, logs, alarm backups)
+	StaticDir              string  // Source checkout to serve web assets from instead of the embedded copies (development)
 	// GeneratedWeatherPath is the URL path portion used for the built-in generated
 	// weather endpoint. Default: "/api/generate-weather". This can be overridden
 	// via the GENERATE_WEATHER_PATH environment variable or the --generate-path flag.
@@ -77,15 +164,26 @@ type Config struct {
 	Alarms         string // Alarm configuration: @filename.json or inline JSON
 	AlarmsEdit     string // Alarm editor mode: @filename.json to edit
 	AlarmsEditPort string // Port for alarm editor (default: 8081)
+	// AlarmsWebEditor mounts the alarm editor at /alarm-editor on the main web
+	// server, sharing the running alarm manager. Access is protected by HTTP
+	// basic auth using AlarmsEditorPassword.
+	AlarmsWebEditor      bool
+	AlarmsEditorPassword string // Password required for the /alarm-editor route
+	AlarmPluginsDir      string // Directory scanned at startup for notification channel plugins
+	EncryptAlarms        bool   // Encrypt the webhook credentials in the --alarms file with TEMPEST_MASTER_KEY, then exit
 
 	// Webhook listener
-	WebhookListener    bool   // Enable webhook listener server (default port: 8082)
-	WebhookListenPort  string // Port for webhook listener server (default: 8082)
-	WebhookListenerSet bool   // Track if webhook-listener flag was explicitly set
-	WebhookPortSet     bool   // Track if webhook-listener-port flag was explicitly set
+	WebhookListener        bool   // Enable webhook listener server (default port: 8082)
+	WebhookListenPort      string // Port for webhook listener server (default: 8082)
+	WebhookListenerSet     bool   // Track if webhook-listener flag was explicitly set
+	WebhookPortSet         bool   // Track if webhook-listener-port flag was explicitly set
+	WebhookListenerSecret  string // Shared secret webhooks must be HMAC-signed with (empty accepts unsigned webhooks)
+	WebhookListenerAllow   string // Comma-separated IPs and CIDR networks webhooks are accepted from (empty: any)
+	WebhookListenerForward string // URL received webhooks are forwarded to (empty: no forwarding)
 
 	// Environment file
-	EnvFile string // Custom environment file (default: .env)
+	EnvFile     string // Custom environment file (default: .env)
+	SetupWizard bool   // No token or station configured yet; serve the first-run setup wizard
 
 	// Status console options
 	Status          bool   // Enable curses-based status console
@@ -116,17 +214,55 @@ func customUsage() {
 	safeFprintln(w, "  --token <string>\tWeatherFlow API token (required for API mode)\tEnv: TEMPEST_TOKEN")
 	safeFprintln(w, "  --station <string>\tTempest station name (required for API mode)\tEnv: TEMPEST_STATION_NAME")
 	safeFprintln(w, "  --station-url <url>\tCustom station URL (overrides Tempest API)\tEnv: STATION_URL")
+	safeFprintln(w, "  --weatherflow-api-url <url>\tWeatherFlow REST API base URL, e.g. a cmd/fakeserver (default: real API)\tEnv: WEATHERFLOW_API_URL")
 	safeFprintln(w, "  --use-generated-weather\tUse simulated weather data for testing (sets generate-path internally)\t")
-	safeFprintln(w, "  --udp-stream\tListen for UDP broadcasts from local station (port 50222)\tEnv: UDP_STREAM=true")
+	safeFprintln(w, "  --udp-stream\tListen for UDP broadcasts from local station (port 50222, see --udp-port)\tEnv: UDP_STREAM=true")
+	safeFprintln(w, "  --udp-merge-api\tWith --udp-stream, also poll the WeatherFlow API and merge its observations\tEnv: UDP_MERGE_API=true")
+	safeFprintln(w, "  --udp-port <port>\tPort the UDP listener binds (default: 50222)\tEnv: UDP_PORT")
+	safeFprintln(w, "  --udp-network <net>\tUDP listener socket: udp (IPv4 and IPv6), udp4 or udp6 (default: udp)\tEnv: UDP_NETWORK")
+	safeFprintln(w, "  --udp-interface <name>\tReceive UDP broadcasts only on this interface (Linux, macOS)\tEnv: UDP_INTERFACE")
+	safeFprintln(w, "  --udp-multicast <list>\tMulticast groups the UDP listener joins, e.g. 239.0.0.222\tEnv: UDP_MULTICAST")
+	safeFprintln(w, "  --udp-relay <list>\tSend received UDP packets on to host:port,... (alone: run only as a relay)\tEnv: UDP_RELAY")
+	safeFprintln(w, "  --udp-serial <list>\tUse only these devices from UDP broadcasts: serial[=name],...\tEnv: UDP_SERIAL")
+	safeFprintln(w, "  --udp-raw-packets <n>\tRaw UDP packets kept for /api/udp/raw with --loglevel debug (default: 100)\tEnv: UDP_RAW_PACKETS")
+	safeFprintln(w, "  --ingest\tRead observations pushed to POST /api/ingest (WeatherFlow or generic JSON) instead of a Tempest station\tEnv: INGEST=true")
+	safeFprintln(w, "  --ingest-token <token>\tBearer token /api/ingest requires (at least 16 characters)\tEnv: INGEST_TOKEN")
+	safeFprintln(w, "  --ecowitt-port <port>\tWith --ingest, receive Ecowitt/Ambient Weather/Wunderground uploads on this port\tEnv: ECOWITT_PORT")
+	safeFprintln(w, "  --ecowitt-passkey <key>\tPASSKEY (or Wunderground PASSWORD) uploads must carry\tEnv: ECOWITT_PASSKEY")
 	safeFprintln(w, "  --disable-internet\tDisable all internet access (offline mode)\tEnv: DISABLE_INTERNET=true")
+	safeFprintln(w, "  --watchdog-timeout <duration>\tRestart the data source after this long without observations (default: 5m, 0=off)\tEnv: WATCHDOG_TIMEOUT")
+	safeFprintln(w, "  --poll-obs <duration>\tObservation polling interval (default: 60s)\tEnv: POLL_OBS")
+	safeFprintln(w, "  --poll-forecast <duration>\tForecast polling interval (default: 30m)\tEnv: POLL_FORECAST")
+	safeFprintln(w, "  --poll-status <duration>\tStation status scraping interval (default: 15m)\tEnv: POLL_STATUS")
+	safeFprintln(w, "  --backfill-gap <duration>\tBackfill history gaps longer than this from the API (default: 5m, 0=off)\tEnv: BACKFILL_GAP")
+	safeFprintln(w, "  --maintenance <windows>\tSuspend alarms and uploads: 2h from startup or START/END, e.g. 2025-06-01T09:00/4h\tEnv: MAINTENANCE")
+	safeFprintln(w, "  --rain-correction <factor>\tMultiply gauge rain values, e.g. 1.15 to match a check gauge (default: 1)\tEnv: RAIN_CORRECTION")
+	safeFprintln(w, "  --snow-ratio <ratio>\tSnow-to-liquid ratio for snowfall estimates (default: 10)\tEnv: SNOW_RATIO")
+	safeFprintln(w, "  --gdd-base <temp>\tGrowing degree day base temperature, e.g. 10 or 50F (default: 10)\tEnv: GDD_BASE")
+	safeFprintln(w, "  --gdd-season-start <MM-DD>\tDate the growing degree day season starts (default: 01-01)\tEnv: GDD_SEASON_START")
+	safeFprintln(w, "  --chill-season-start <MM-DD>\tDate the chill hour season starts (default: 10-01)\tEnv: CHILL_SEASON_START")
+	safeFprintln(w, "  --anomaly-threshold <z>\tFlag readings this many standard deviations from the 2h baseline (default: 4)\tEnv: ANOMALY_THRESHOLD")
+	safeFprintln(w, "  --comfort-range <min-max>\tIndoor comfort temperature range for /api/comfort, e.g. 20-24 or 68-75F (default: 20-24)\tEnv: COMFORT_RANGE")
+	safeFprintln(w, "  --comfort-humidity <percent>\tHighest comfortable indoor humidity for /api/comfort (default: 60)\tEnv: COMFORT_HUMIDITY")
+	safeFprintln(w, "  --token-check-interval <duration>\tRe-check the WeatherFlow token this often (default: 1h, 0=startup only)\tEnv: TOKEN_CHECK_INTERVAL")
 	safeFprintln(w, "  --env <file>\tCustom environment file to load (default: .env)\t")
 	safeFprintln(w, "  --elevation <value>\tStation elevation (e.g., 903ft, 275m) - auto-detected if omitted\t")
 	safeFprintln(w)
 
 	// HomeKit options
 	safeFprintln(w, "HOMEKIT OPTIONS:")
-	safeFprintln(w, "  --pin <string>\tHomeKit PIN for device pairing (default: \"00102003\")\tEnv: HOMEKIT_PIN")
-	safeFprintln(w, "  --sensors <list>\tSensors to enable (default: \"temp,lux,humidity,uv\")\tEnv: SENSORS")
+	safeFprintln(w, "  --pin <string>\tHomeKit PIN for device pairing (default: random PIN generated on first run)\tEnv: HOMEKIT_PIN")
+	safeFprintln(w, "  --sensors <list>\tSensors to enable, plus \"diagnostics\" for a bridge health accessory and \"comfort\" for the Open Windows switch (default: \"temp,lux,humidity,uv\")\tEnv: SENSORS")
+	safeFprintln(w, "  --homekit-mode <mode>\tsplit (one accessory per sensor) or combined (one station accessory) (default: split)\tEnv: HOMEKIT_MODE")
+	safeFprintln(w, "  --homekit-names <list>\tRename accessories: temperature=Backyard Temperature,humidity=...\tEnv: HOMEKIT_NAMES")
+	safeFprintln(w, "  --homekit-name-pattern <str>\tAccessory name pattern using {name}, {sensor}, {station} (default: {name})\tEnv: HOMEKIT_NAME_PATTERN")
+	safeFprintln(w, "  --homekit-serial-pattern <str>\tAccessory serial pattern using {serial}, {sensor}, {station} (default: {serial})\tEnv: HOMEKIT_SERIAL_PATTERN")
+	safeFprintln(w, "  --homekit-address <ip>\tBind the HomeKit server to this IP and advertise only it (default: all addresses)\tEnv: HOMEKIT_ADDRESS")
+	safeFprintln(w, "  --homekit-port <port>\tHomeKit server port (default: a free port)\tEnv: HOMEKIT_PORT")
+	safeFprintln(w, "  --homekit-interfaces <list>\tAnnounce the bridge over mDNS only on these interfaces, e.g. eth0 (default: all)\tEnv: HOMEKIT_INTERFACES")
+	safeFprintln(w, "  --gust-hold <duration>\tKeep reporting the strongest gust to HomeKit this long (default: 0, peak since last update)\tEnv: GUST_HOLD")
+	safeFprintln(w, "  --units-pressure-homekit <unit>\tPressure units of the HomeKit pressure sensor: mb or inHg (default: mb)\tEnv: UNITS_PRESSURE_HOMEKIT")
+	safeFprintln(w, "  --lux-smoothing <duration>\tMoving average of the illuminance reported to HomeKit, e.g. 5m (default: 0, raw)\tEnv: LUX_SMOOTHING")
 	safeFprintln(w, "  --disable-homekit\tRun web console only (no HomeKit services)\t")
 	safeFprintln(w, "  --disable-alarms\tDisable alarm initialization and processing\t")
 	safeFprintln(w, "  --cleardb\tClear HomeKit database and reset device pairing\t")
@@ -134,7 +270,7 @@ func customUsage() {
 
 	// HISTORY section (dedicated)
 	safeFprintln(w, "HISTORY OPTIONS:")
-	safeFprintln(w, "  --history <points>\tNumber of data points to store in history (default: 1000, min: 10)\tEnv: HISTORY_POINTS")
+	safeFprintln(w, "  --history <points>\tNumber of data points to store in history (default: 1000, min: 10, max: 1000000)\tEnv: HISTORY_POINTS")
 	safeFprintln(w, "  --history-read\tPreload historical observations from Tempest API up to HISTORY_POINTS\tEnv: READ_HISTORY")
 	safeFprintln(w, "  --history-reduce <factor>\tReduce historical data by averaging N points into 1 (default: 1 = no reduction)\tEnv: HISTORY_REDUCE")
 	safeFprintln(w, "  --history-reduce-method <str>\tMethod to reduce historical data: timebin (default), factor, lttb\tEnv: HISTORY_REDUCE_METHOD")
@@ -148,7 +284,27 @@ func customUsage() {
 	// Web console and others (shortened for readability)
 	safeFprintln(w, "WEB CONSOLE OPTIONS:")
 	safeFprintln(w, "  --web-port <port>\tWeb dashboard port (default: \"8080\")\tEnv: WEB_PORT")
+	safeFprintln(w, "  --web-address <ip>\tBind the web dashboard to this IP (default: all addresses)\tEnv: WEB_ADDRESS")
+	safeFprintln(w, "  --web-base-path <path>\tURL prefix a reverse proxy serves the dashboard under, e.g. /station/alice\tEnv: WEB_BASE_PATH")
+	safeFprintln(w, "  --public-url <url>\tURL alarm recipients reach the dashboard at, for {{ack_url}} links (default: http://<hostname>:<web-port>)\tEnv: PUBLIC_URL")
+	safeFprintln(w, "  --hidden-cards <list>\tDashboard cards to hide, e.g. uv,forecast (see /api/sensors)\tEnv: HIDDEN_CARDS")
+	safeFprintln(w, "  --tenants <file>\tServe several stations under /station/{name}/, one isolated process each\tEnv: TENANTS")
+	safeFprintln(w, "  --federate <list>\tPoll other instances for the /sites page and any_site. alarms: name=url,...\tEnv: FEDERATE")
+	safeFprintln(w, "  --federate-interval <dur>\tTime between polls of the federated instances (default: 1m)\tEnv: FEDERATE_INTERVAL")
 	safeFprintln(w, "  --disable-webconsole\tDisable web server (HomeKit only mode)\t")
+	safeFprintln(w, "  --admin-password <str>\tPassword protecting admin endpoints such as HomeKit pairing management (HTTP basic auth)\tEnv: ADMIN_PASSWORD")
+	safeFprintln(w, "  --cors-origins <list>\tComma-separated origins allowed to call the web API from other sites, or * for any (default: same origin only)\tEnv: CORS_ORIGINS")
+	safeFprintln(w, "  --csp <policy>\tContent-Security-Policy sent with web pages (default: built-in policy allowing only this server)\tEnv: CONTENT_SECURITY_POLICY")
+	safeFprintln(w, "  --frame-ancestors <sources>\tSites allowed to embed the web pages in a frame, e.g. https://ha.local:8123 (default: none)\tEnv: FRAME_ANCESTORS")
+	safeFprintln(w, "  --web-read-header-timeout <dur>\tTime allowed to send request headers (default: 10s, 0 disables)\tEnv: WEB_READ_HEADER_TIMEOUT")
+	safeFprintln(w, "  --web-write-timeout <dur>\tTime allowed to write a response; /api/stream is exempt (default: 60s, 0 disables)\tEnv: WEB_WRITE_TIMEOUT")
+	safeFprintln(w, "  --web-idle-timeout <dur>\tClose keep-alive connections idle this long (default: 120s, 0 disables)\tEnv: WEB_IDLE_TIMEOUT")
+	safeFprintln(w, "  --web-max-conns <n>\tConcurrent connections accepted by the web server and webhook listener (default: 256, 0 = unlimited)\tEnv: WEB_MAX_CONNS")
+	safeFprintln(w, "  --grpc-port <port>\tServe the gRPC API on this port (default: disabled)\tEnv: GRPC_PORT")
+	safeFprintln(w, "  --mqtt-broker <url>\tPublish daily statistics (ET0) to this MQTT broker, e.g. tcp://host:1883 (default: disabled)\tEnv: MQTT_BROKER")
+	safeFprintln(w, "  --mqtt-topic <prefix>\tTopic prefix for MQTT messages (default: tempest)\tEnv: MQTT_TOPIC")
+	safeFprintln(w, "  --mqtt-username <str>\tMQTT broker user name\tEnv: MQTT_USERNAME")
+	safeFprintln(w, "  --mqtt-password <str>\tMQTT broker password\tEnv: MQTT_PASSWORD")
 	safeFprintln(w, "  --use-web-status\tEnable Chrome-based scraping of TempestWX status page\t")
 	safeFprintln(w)
 
@@ -156,8 +312,18 @@ func customUsage() {
 	safeFprintln(w, "  --alarms <file|json>\tAlarm configuration: @filename.json or inline JSON string\tEnv: ALARMS")
 	safeFprintln(w, "  --alarms-edit <file>\tRun alarm editor for specified config file: @filename.json\tEnv: ALARMS_EDIT")
 	safeFprintln(w, "  --alarms-edit-port <port>\tPort for alarm editor web UI (default: 8081)\tEnv: ALARMS_EDIT_PORT")
+	safeFprintln(w, "  --encrypt-alarms\tEncrypt the webhook secrets and credential headers in the --alarms file with TEMPEST_MASTER_KEY and exit\t")
+	safeFprintln(w, "  --alarms-web-editor\tServe the alarm editor at /alarm-editor on the web console\tEnv: ALARMS_WEB_EDITOR=true")
+	safeFprintln(w, "  --alarms-editor-password <str>\tPassword protecting /alarm-editor (HTTP basic auth)\tEnv: ALARMS_EDITOR_PASSWORD")
+	safeFprintln(w, "  --alarm-plugins-dir <dir>\tDirectory of notification channel plugins (default: plugins)\tEnv: ALARM_PLUGINS_DIR")
+	safeFprintln(w, "  --units-pressure-notifications <unit>\tPressure units of {{pressure_local}} and {{sensor_info}}: mb or inHg (default: mb)\tEnv: UNITS_PRESSURE_NOTIFICATIONS")
+	safeFprintln(w, "  --units-pressure-export <unit>\tPressure units of {{pressure_local}} in CSV and JSON file channels: mb or inHg (default: mb)\tEnv: UNITS_PRESSURE_EXPORT")
+	safeFprintln(w, "  --eval-condition <expr>\tEvaluate a condition against the current observation, print the parse tree and exit\t")
 	safeFprintln(w, "  --webhook-listener\tStart webhook listener server (default port: 8082)\tEnv: WEBHOOK_LISTENER")
 	safeFprintln(w, "  --webhook-listener-port <port>\tPort for webhook listener server (default: 8082)\tEnv: WEBHOOK_LISTEN_PORT")
+	safeFprintln(w, "  --webhook-listener-secret <secret>\tRequire webhooks signed with this HMAC-SHA256 secret\tEnv: WEBHOOK_LISTENER_SECRET")
+	safeFprintln(w, "  --webhook-listener-allow <list>\tAccept webhooks only from these IPs/CIDR networks\tEnv: WEBHOOK_LISTENER_ALLOW")
+	safeFprintln(w, "  --webhook-listener-forward <url>\tForward received webhooks to this URL\tEnv: WEBHOOK_LISTENER_FORWARD")
 	safeFprintln(w)
 
 	safeFprintln(w, "STATUS OPTIONS:")
@@ -171,6 +337,7 @@ func customUsage() {
 	safeFprintln(w, "LOGGING & DEBUG OPTIONS:")
 	safeFprintln(w, "  --loglevel <level>\tLog level: error (default), warn/warning, info, debug\tEnv: LOG_LEVEL")
 	safeFprintln(w, "  --logfilter <string>\tFilter log messages (case-insensitive substring match)\tEnv: LOG_FILTER")
+	safeFprintln(w, "  --log-file <path>\tAppend log output to a file instead of the console\tEnv: LOG_FILE")
 	safeFprintln(w)
 
 	safeFprintln(w, "TESTING OPTIONS:")
@@ -185,7 +352,9 @@ func customUsage() {
 	safeFprintln(w, "  --test-oslog\tSend test oslog notification and exit (macOS only)\t")
 	safeFprintln(w, "  --test-eventlog\tSend test eventlog notification and exit (Windows only)\t")
 	safeFprintln(w, "  --test-udp [seconds]\tListen for UDP broadcasts for N seconds (default: 120) and exit\t")
-	safeFprintln(w, "  --test-homekit\tTest HomeKit bridge setup and pairing info, then exit\t")
+	safeFprintln(w, "  --test-homekit\tPair with a test bridge over HAP, read every sensor back, report pass/fail and exit\t")
+	safeFprintln(w, "  --test-all\tRun the API, UDP (30s), HomeKit, notification channel and web checks, print a report and exit\t")
+	safeFprintln(w, "  --doctor\tCheck ports, firewall, clock, data directories, DNS and Chrome, print fixes and exit\t")
 	safeFprintln(w, "  --test-web-status\tTest web status scraping from TempestWX and exit\t")
 	safeFprintln(w, "  --test-alarm <name>\tTrigger a specific alarm by name for testing and exit\t")
 	safeFprintln(w, "  --test-sensor-rain\tRun rain sensor cycling pattern (requires --use-generated-weather)\t")
@@ -196,9 +365,22 @@ func customUsage() {
 	safeFprintln(w, "  --test-sensor-lux\tRun lux sensor cycling pattern (requires --use-generated-weather)\t")
 	safeFprintln(w, "  --test-sensor-uv\tRun UV sensor cycling pattern (requires --use-generated-weather)\t")
 	safeFprintln(w, "  --test-sensor-lightning\tRun lightning sensor cycling pattern (requires --use-generated-weather)\t")
+	safeFprintln(w, "  --inject-api-errors <pct>\tAnswer this share of WeatherFlow API requests with a synthetic 503 (e.g., 10%)\t")
+	safeFprintln(w, "  --inject-udp-drop <pct>\tDiscard this share of received UDP packets (e.g., 20%)\t")
+	safeFprintln(w, "  --inject-latency <duration>\tDelay every WeatherFlow API request (e.g., 2s)\t")
+	safeFprintln(w, "  --bench-web\tLoad test the web server with --history synthetic points, report latency and allocations, and exit\t")
+	safeFprintln(w, "  --bench-web-mix <mix>\tWeighted requests for --bench-web (default: status=3,history=1)\t")
+	safeFprintln(w, "  --bench-web-requests <n>\tRequests replayed by --bench-web (default: 2000)\t")
+	safeFprintln(w, "  --bench-web-concurrency <n>\tParallel clients for --bench-web (default: 8)\t")
 	safeFprintln(w)
 
 	safeFprintln(w, "OTHER OPTIONS:")
+	safeFprintln(w, "  --data-dir <dir>\tKeep all state (HomeKit db, logs, alarm backups) in one directory (default: /data when it exists)\tEnv: DATA_DIR")
+	safeFprintln(w, "  --static-dir <dir>\tServe dashboard and alarm editor assets from this source checkout instead of the binary (development)\tEnv: STATIC_DIR")
+	safeFprintln(w, "  --install-service\tInstall a systemd unit, launchd job or Windows service that runs the bridge with the other flags given, then exit\t")
+	safeFprintln(w, "  --service-unit <path>\tUnit file or plist written by --install-service (default: platform location)\t")
+	safeFprintln(w, "  --backup <file>\tWrite all state (HomeKit db, statistics, alarm config and backups, .env) to a tar.gz archive and exit\t")
+	safeFprintln(w, "  --restore <file>\tVerify a --backup archive and restore it into the data directory, then exit (stop the bridge first)\t")
 	safeFprintln(w, "  --version\tShow version information and exit\t")
 	safeFprintln(w, "  --help\tShow this help message\t")
 
@@ -219,18 +401,81 @@ func LoadConfig() *Config {
 	cfg := &Config{
 		Token:                  getEnvOrDefault("TEMPEST_TOKEN", ""),
 		StationName:            getEnvOrDefault("TEMPEST_STATION_NAME", ""),
-		Pin:                    getEnvOrDefault("HOMEKIT_PIN", "00102003"),
+		Pin:                    getEnvOrDefault("HOMEKIT_PIN", ""),
+		HomeKitMode:            getEnvOrDefault("HOMEKIT_MODE", "split"),
+		HomeKitNames:           getEnvOrDefault("HOMEKIT_NAMES", ""),
+		HomeKitNamePattern:     getEnvOrDefault("HOMEKIT_NAME_PATTERN", ""),
+		HomeKitSerialPattern:   getEnvOrDefault("HOMEKIT_SERIAL_PATTERN", ""),
+		HomeKitAddress:         getEnvOrDefault("HOMEKIT_ADDRESS", ""),
+		HomeKitPort:            getEnvOrDefault("HOMEKIT_PORT", ""),
+		HomeKitInterfaces:      getEnvOrDefault("HOMEKIT_INTERFACES", ""),
+		GustHold:               getEnvOrDefault("GUST_HOLD", "0"),
+		LuxSmoothing:           getEnvOrDefault("LUX_SMOOTHING", "0"),
 		LogLevel:               getEnvOrDefault("LOG_LEVEL", "error"),
 		LogFilter:              getEnvOrDefault("LOG_FILTER", ""),
+		LogFile:                getEnvOrDefault("LOG_FILE", ""),
+		DataDir:                getEnvOrDefault("DATA_DIR", ""),
+		StaticDir:              getEnvOrDefault("STATIC_DIR", ""),
 		WebPort:                getEnvOrDefault("WEB_PORT", "8080"),
+		WebAddress:             getEnvOrDefault("WEB_ADDRESS", ""),
+		WebBasePath:            getEnvOrDefault("WEB_BASE_PATH", ""),
+		PublicURL:              getEnvOrDefault("PUBLIC_URL", ""),
+		HiddenCards:            getEnvOrDefault("HIDDEN_CARDS", ""),
+		Tenants:                getEnvOrDefault("TENANTS", ""),
+		Federate:               getEnvOrDefault("FEDERATE", ""),
+		FederateInterval:       getEnvOrDefault("FEDERATE_INTERVAL", "1m"),
+		GRPCPort:               getEnvOrDefault("GRPC_PORT", ""),
+		MQTTBroker:             getEnvOrDefault("MQTT_BROKER", ""),
+		MQTTTopic:              getEnvOrDefault("MQTT_TOPIC", "tempest"),
+		MQTTUsername:           getEnvOrDefault("MQTT_USERNAME", ""),
+		MQTTPassword:           getEnvOrDefault("MQTT_PASSWORD", ""),
+		AdminPassword:          getEnvOrDefault("ADMIN_PASSWORD", ""),
+		CORSOrigins:            getEnvOrDefault("CORS_ORIGINS", ""),
+		ContentSecurityPolicy:  getEnvOrDefault("CONTENT_SECURITY_POLICY", ""),
+		FrameAncestors:         getEnvOrDefault("FRAME_ANCESTORS", ""),
+		WebReadHeaderTimeout:   getEnvOrDefault("WEB_READ_HEADER_TIMEOUT", "10s"),
+		WebWriteTimeout:        getEnvOrDefault("WEB_WRITE_TIMEOUT", "60s"),
+		WebIdleTimeout:         getEnvOrDefault("WEB_IDLE_TIMEOUT", "120s"),
+		WebMaxConns:            parseIntEnv("WEB_MAX_CONNS", 256),
 		Sensors:                getEnvOrDefault("SENSORS", "temp,lux,humidity,uv"),
 		HistoryRead:            getEnvOrDefault("READ_HISTORY", "") == "true",
 		StationURL:             getEnvOrDefault("STATION_URL", ""),
+		WeatherFlowAPIURL:      getEnvOrDefault("WEATHERFLOW_API_URL", ""),
 		UDPStream:              getEnvOrDefault("UDP_STREAM", "") == "true",
+		UDPMergeAPI:            getEnvOrDefault("UDP_MERGE_API", "") == "true",
+		UDPPort:                getEnvOrDefault("UDP_PORT", "50222"),
+		UDPNetwork:             getEnvOrDefault("UDP_NETWORK", "udp"),
+		UDPInterface:           getEnvOrDefault("UDP_INTERFACE", ""),
+		UDPMulticast:           getEnvOrDefault("UDP_MULTICAST", ""),
+		UDPRelay:               getEnvOrDefault("UDP_RELAY", ""),
+		UDPSerial:              getEnvOrDefault("UDP_SERIAL", ""),
+		UDPRawPackets:          parseIntEnv("UDP_RAW_PACKETS", 100),
+		Ingest:                 getEnvOrDefault("INGEST", "") == "true",
+		IngestToken:            getEnvOrDefault("INGEST_TOKEN", ""),
+		EcowittPort:            getEnvOrDefault("ECOWITT_PORT", ""),
+		EcowittPasskey:         getEnvOrDefault("ECOWITT_PASSKEY", ""),
 		DisableInternet:        getEnvOrDefault("DISABLE_INTERNET", "") == "true",
+		WatchdogTimeout:        getEnvOrDefault("WATCHDOG_TIMEOUT", "5m"),
+		TokenCheckInterval:     getEnvOrDefault("TOKEN_CHECK_INTERVAL", "1h"),
+		PollObs:                getEnvOrDefault("POLL_OBS", "60s"),
+		PollForecast:           getEnvOrDefault("POLL_FORECAST", "30m"),
+		PollStatus:             getEnvOrDefault("POLL_STATUS", "15m"),
+		BackfillGap:            getEnvOrDefault("BACKFILL_GAP", "5m"),
+		Maintenance:            getEnvOrDefault("MAINTENANCE", ""),
+		RainCorrection:         getEnvOrDefault("RAIN_CORRECTION", "1"),
+		SnowRatio:              getEnvOrDefault("SNOW_RATIO", "10"),
+		GDDBase:                getEnvOrDefault("GDD_BASE", "10"),
+		GDDSeasonStart:         getEnvOrDefault("GDD_SEASON_START", "01-01"),
+		ChillSeasonStart:       getEnvOrDefault("CHILL_SEASON_START", "10-01"),
+		AnomalyThreshold:       getEnvOrDefault("ANOMALY_THRESHOLD", "4"),
+		ComfortRange:           getEnvOrDefault("COMFORT_RANGE", "20-24"),
+		ComfortHumidity:        getEnvOrDefault("COMFORT_HUMIDITY", "60"),
 		Elevation:              275.2, // 903ft default elevation in meters
 		Units:                  getEnvOrDefault("UNITS", "imperial"),
 		UnitsPressure:          getEnvOrDefault("UNITS_PRESSURE", "inHg"),
+		UnitsPressureHomeKit:   getEnvOrDefault("UNITS_PRESSURE_HOMEKIT", "mb"),
+		UnitsPressureNotify:    getEnvOrDefault("UNITS_PRESSURE_NOTIFICATIONS", "mb"),
+		UnitsPressureExport:    getEnvOrDefault("UNITS_PRESSURE_EXPORT", "mb"),
 		HistoryPoints:          parseIntEnv("HISTORY_POINTS", 1000),
 		ChartHistoryHours:      parseIntEnv("CHART_HISTORY_HOURS", 24),
 		HistoryReduce:          parseIntEnv("HISTORY_REDUCE", 1),
@@ -241,8 +486,14 @@ func LoadConfig() *Config {
 		Alarms:                 getEnvOrDefault("ALARMS", ""),
 		AlarmsEdit:             getEnvOrDefault("ALARMS_EDIT", ""),
 		AlarmsEditPort:         getEnvOrDefault("ALARMS_EDIT_PORT", "8081"),
+		AlarmsWebEditor:        getEnvOrDefault("ALARMS_WEB_EDITOR", "") == "true",
+		AlarmsEditorPassword:   getEnvOrDefault("ALARMS_EDITOR_PASSWORD", ""),
+		AlarmPluginsDir:        getEnvOrDefault("ALARM_PLUGINS_DIR", "plugins"),
 		WebhookListener:        getEnvOrDefault("WEBHOOK_LISTENER", "") == "true",
 		WebhookListenPort:      getEnvOrDefault("WEBHOOK_LISTEN_PORT", "8082"),
+		WebhookListenerSecret:  getEnvOrDefault("WEBHOOK_LISTENER_SECRET", ""),
+		WebhookListenerAllow:   getEnvOrDefault("WEBHOOK_LISTENER_ALLOW", ""),
+		WebhookListenerForward: getEnvOrDefault("WEBHOOK_LISTENER_FORWARD", ""),
 		EnvFile:                getEnvOrDefault("ENV_FILE", ".env"),
 		Status:                 getEnvOrDefault("STATUS", "") == "true",
 		StatusRefresh:          parseIntEnv("STATUS_REFRESH", 5),
@@ -257,10 +508,42 @@ func LoadConfig() *Config {
 	var elevationProvided bool
 	flag.StringVar(&cfg.Token, "token", cfg.Token, "WeatherFlow API token")
 	flag.StringVar(&cfg.StationName, "station", cfg.StationName, "Tempest station name")
-	flag.StringVar(&cfg.Pin, "pin", cfg.Pin, "HomeKit PIN")
+	flag.StringVar(&cfg.Pin, "pin", cfg.Pin, "HomeKit PIN (empty generates a random PIN on first run)")
+	flag.StringVar(&cfg.HomeKitMode, "homekit-mode", cfg.HomeKitMode, "HomeKit accessory layout: split (one accessory per sensor) or combined (one station accessory)")
+	flag.StringVar(&cfg.HomeKitNames, "homekit-names", cfg.HomeKitNames, "Comma-separated accessory=name overrides (bridge, station, temperature, humidity, light, uv, pressure, diagnostics, comfort)")
+	flag.StringVar(&cfg.HomeKitNamePattern, "homekit-name-pattern", cfg.HomeKitNamePattern, "Accessory name pattern using {name}, {sensor} and {station}")
+	flag.StringVar(&cfg.HomeKitSerialPattern, "homekit-serial-pattern", cfg.HomeKitSerialPattern, "Accessory serial number pattern using {serial}, {sensor} and {station}")
+	flag.StringVar(&cfg.HomeKitAddress, "homekit-address", cfg.HomeKitAddress, "IP address the HomeKit server binds to and advertises (empty: all addresses)")
+	flag.StringVar(&cfg.HomeKitPort, "homekit-port", cfg.HomeKitPort, "Port the HomeKit server listens on (empty: a free port)")
+	flag.StringVar(&cfg.HomeKitInterfaces, "homekit-interfaces", cfg.HomeKitInterfaces, "Comma-separated network interfaces to announce the HomeKit bridge on over mDNS (empty: all)")
+	flag.StringVar(&cfg.GustHold, "gust-hold", cfg.GustHold, "Keep reporting the strongest gust to HomeKit for this long, e.g. 10m (0 reports the peak since the previous update)")
+	flag.StringVar(&cfg.LuxSmoothing, "lux-smoothing", cfg.LuxSmoothing, "Smooth the illuminance reported to HomeKit with a moving average over about this long, e.g. 5m (0 reports it raw)")
 	flag.StringVar(&cfg.LogLevel, "loglevel", cfg.LogLevel, "Log level (debug, info, error)")
 	flag.StringVar(&cfg.LogFilter, "logfilter", cfg.LogFilter, "Filter log messages to only show those containing this string (case-insensitive)")
+	flag.StringVar(&cfg.LogFile, "log-file", cfg.LogFile, "Append log output to this file instead of the console (relative paths go under the data directory's logs)")
+	flag.StringVar(&cfg.DataDir, "data-dir", cfg.DataDir, "Directory for all state: HomeKit db, logs and alarm backups (default: /data when it exists)")
+	flag.StringVar(&cfg.StaticDir, "static-dir", cfg.StaticDir, "Serve pkg/web/static and pkg/alarm/editor/static from this source checkout instead of the embedded assets")
 	flag.StringVar(&cfg.WebPort, "web-port", cfg.WebPort, "Web dashboard port")
+	flag.StringVar(&cfg.WebAddress, "web-address", cfg.WebAddress, "IP address the web dashboard binds to (empty: all addresses)")
+	flag.StringVar(&cfg.WebBasePath, "web-base-path", cfg.WebBasePath, "URL prefix a reverse proxy serves the web dashboard under, e.g. /station/alice (empty: the root)")
+	flag.StringVar(&cfg.PublicURL, "public-url", cfg.PublicURL, "URL alarm recipients reach the web dashboard at, including any base path, for the {{ack_url}} acknowledgement links, e.g. https://weather.example.com (empty: http://<hostname>:<web-port><web-base-path>)")
+	flag.StringVar(&cfg.HiddenCards, "hidden-cards", cfg.HiddenCards, "Comma-separated dashboard cards to hide: temperature, humidity, wind, rain, pressure, light, uv, forecast, tempest or alarm. Can also be set via HIDDEN_CARDS environment variable")
+	flag.StringVar(&cfg.Tenants, "tenants", cfg.Tenants, "JSON file of stations to serve under /station/{name}/ on the web port, each in its own process with separate history, alarms and HomeKit bridge")
+	flag.StringVar(&cfg.Federate, "federate", cfg.Federate, "Other instances to poll for the combined /sites page and cross-site alarms, as name=url pairs: cabin=http://cabin.local:8080,beach=http://10.0.0.5:8080")
+	flag.StringVar(&cfg.FederateInterval, "federate-interval", cfg.FederateInterval, "Time between polls of the federated instances")
+	flag.StringVar(&cfg.AdminPassword, "admin-password", cfg.AdminPassword, "Password protecting admin endpoints such as HomeKit pairing management (HTTP basic auth)")
+	flag.StringVar(&cfg.CORSOrigins, "cors-origins", cfg.CORSOrigins, "Comma-separated origins allowed to call the web API from other sites, or * for any (empty: same origin only)")
+	flag.StringVar(&cfg.ContentSecurityPolicy, "csp", cfg.ContentSecurityPolicy, "Content-Security-Policy sent with web pages (empty: built-in policy)")
+	flag.StringVar(&cfg.FrameAncestors, "frame-ancestors", cfg.FrameAncestors, "CSP frame-ancestors sources allowed to embed the web pages (empty: no framing)")
+	flag.StringVar(&cfg.WebReadHeaderTimeout, "web-read-header-timeout", cfg.WebReadHeaderTimeout, "Time web clients may take to send request headers (0 disables)")
+	flag.StringVar(&cfg.WebWriteTimeout, "web-write-timeout", cfg.WebWriteTimeout, "Time allowed to write a web response, except /api/stream (0 disables)")
+	flag.StringVar(&cfg.WebIdleTimeout, "web-idle-timeout", cfg.WebIdleTimeout, "Close idle keep-alive web connections after this long (0 disables)")
+	flag.IntVar(&cfg.WebMaxConns, "web-max-conns", cfg.WebMaxConns, "Concurrent connections accepted by the web server and webhook listener (0: unlimited)")
+	flag.StringVar(&cfg.GRPCPort, "grpc-port", cfg.GRPCPort, "Serve the gRPC API on this port (empty disables it)")
+	flag.StringVar(&cfg.MQTTBroker, "mqtt-broker", cfg.MQTTBroker, "Publish daily statistics such as ET0 to this MQTT broker (host[:port] or tcp://, mqtts:// URL)")
+	flag.StringVar(&cfg.MQTTTopic, "mqtt-topic", cfg.MQTTTopic, "Topic prefix for MQTT messages")
+	flag.StringVar(&cfg.MQTTUsername, "mqtt-username", cfg.MQTTUsername, "MQTT broker user name")
+	flag.StringVar(&cfg.MQTTPassword, "mqtt-password", cfg.MQTTPassword, "MQTT broker password")
 	flag.StringVar(&cfg.Sensors, "sensors", cfg.Sensors, "Sensors to enable: 'all', 'min' (temp,humidity,lux), or comma-delimited list (temp/temperature,humidity,lux/light,wind,rain,pressure,uv/uvi,lightning)")
 	flag.StringVar(&elevationStr, "elevation", "", "Station elevation (e.g., 903ft, 275m). If not provided, elevation will be auto-detected from coordinates")
 	flag.BoolVar(&cfg.ClearDB, "cleardb", false, "Clear HomeKit database and reset device pairing")
@@ -278,18 +561,52 @@ func LoadConfig() *Config {
 	flag.BoolVar(&cfg.TestOSLog, "test-oslog", false, "Send a test oslog notification and exit (macOS only)")
 	flag.BoolVar(&cfg.TestEventLog, "test-eventlog", false, "Send a test eventlog notification and exit (Windows only)")
 	flag.IntVar(&cfg.TestUDP, "test-udp", 0, "Listen for UDP broadcasts for N seconds (default: 120) and exit")
-	flag.BoolVar(&cfg.TestHomeKit, "test-homekit", false, "Test HomeKit bridge setup and pairing info, then exit")
+	flag.BoolVar(&cfg.TestHomeKit, "test-homekit", false, "Start a test bridge on an ephemeral loopback port, pair with it over HAP, read every sensor back and report pass/fail (exit status 1 on failure)")
+	flag.BoolVar(&cfg.TestAll, "test-all", false, "Run every configured self-test (WeatherFlow API, 30s UDP listen, HomeKit pairing, a test notification per alarm channel type, web endpoints) and print a pass/fail report for bug reports (exit status 1 on failure)")
+	flag.BoolVar(&cfg.Doctor, "doctor", false, "Check the environment (web, HomeKit and UDP ports, firewall, clock skew, data directory permissions, DNS for the WeatherFlow API, Chrome for --use-web-status), print remediation steps and exit (exit status 1 on failure)")
 	flag.BoolVar(&cfg.TestWebStatus, "test-web-status", false, "Test web status scraping from TempestWX and exit")
 	flag.StringVar(&cfg.TestAlarm, "test-alarm", "", "Trigger a specific alarm by name for testing and exit")
-	flag.BoolVar(&cfg.UseWebStatus, "use-web-status", false, "Enable headless browser scraping of TempestWX status page every 15 minutes")
+	flag.StringVar(&cfg.EvalCondition, "eval-condition", "", "Evaluate an alarm condition against the current observation (API, UDP or --station-url), print the parsed tree and field values, then exit")
+	flag.BoolVar(&cfg.UseWebStatus, "use-web-status", false, "Enable headless browser scraping of TempestWX status page (every 15 minutes, see --poll-status)")
 	flag.StringVar(&cfg.StationURL, "station-url", cfg.StationURL, "Custom station URL for weather data (e.g., http://localhost:8080/api/generate-weather). Overrides Tempest API. Can also be set via STATION_URL environment variable")
+	flag.StringVar(&cfg.WeatherFlowAPIURL, "weatherflow-api-url", cfg.WeatherFlowAPIURL, "WeatherFlow REST API base URL (e.g., http://localhost:8090/swd/rest for cmd/fakeserver). Can also be set via WEATHERFLOW_API_URL environment variable")
 	flag.BoolVar(&cfg.UseGeneratedWeather, "use-generated-weather", false, "Use generated weather data for UI testing instead of Tempest API")
 	flag.BoolVar(&cfg.UDPStream, "udp-stream", cfg.UDPStream, "Listen for UDP broadcasts from local Tempest station (port 50222) for offline operation. Can also be set via UDP_STREAM environment variable")
+	flag.StringVar(&cfg.WatchdogTimeout, "watchdog-timeout", cfg.WatchdogTimeout, "Restart the data source after this long without observations, with exponential backoff (0 disables the watchdog)")
+	flag.StringVar(&cfg.PollObs, "poll-obs", cfg.PollObs, "How often to poll the WeatherFlow API or --station-url for observations (jittered by 10%)")
+	flag.StringVar(&cfg.PollForecast, "poll-forecast", cfg.PollForecast, "How often to fetch the forecast (jittered by 10%)")
+	flag.StringVar(&cfg.PollStatus, "poll-status", cfg.PollStatus, "How often to scrape the station status page with --use-web-status (jittered by 10%)")
+	flag.StringVar(&cfg.RainCorrection, "rain-correction", cfg.RainCorrection, "Multiply the gauge's rain values by this factor to match a check gauge (raw values stay available in the API and to alarms)")
+	flag.StringVar(&cfg.SnowRatio, "snow-ratio", cfg.SnowRatio, "Snow-to-liquid ratio used to estimate snowfall from below-freezing precipitation (wet snow ~5, powder ~20)")
+	flag.StringVar(&cfg.GDDBase, "gdd-base", cfg.GDDBase, "Growing degree day base temperature in °C, or °F with an F suffix (degree days are then in °F)")
+	flag.StringVar(&cfg.GDDSeasonStart, "gdd-season-start", cfg.GDDSeasonStart, "Date (MM-DD) the growing degree day season starts each year")
+	flag.StringVar(&cfg.ChillSeasonStart, "chill-season-start", cfg.ChillSeasonStart, "Date (MM-DD) the chill hour season starts each year")
+	flag.StringVar(&cfg.ComfortRange, "comfort-range", cfg.ComfortRange, "Indoor comfort temperature range (min-max) the comfort advisor aims for, in °C or °F with an F suffix (68-75F)")
+	flag.StringVar(&cfg.ComfortHumidity, "comfort-humidity", cfg.ComfortHumidity, "Highest comfortable indoor relative humidity (%) for the comfort advisor")
+	flag.StringVar(&cfg.AnomalyThreshold, "anomaly-threshold", cfg.AnomalyThreshold, "Flag temperature, humidity, pressure and wind readings this many standard deviations (z-score) away from the mean of the previous two hours")
+	flag.StringVar(&cfg.BackfillGap, "backfill-gap", cfg.BackfillGap, "Fetch missing observations from the WeatherFlow API when consecutive observations are further apart than this (0 disables backfilling)")
+	flag.StringVar(&cfg.Maintenance, "maintenance", cfg.Maintenance, "Maintenance windows during which alarms and uploads are suspended, comma-separated: a duration from startup (2h) or START/END and START/DURATION ranges in local time or RFC 3339 (2025-06-01T09:00/2025-06-01T13:00, 2025-06-01T09:00/4h)")
+	flag.StringVar(&cfg.TokenCheckInterval, "token-check-interval", cfg.TokenCheckInterval, "How often to re-check that the WeatherFlow token is accepted (0 checks only at startup)")
+	flag.BoolVar(&cfg.UDPMergeAPI, "udp-merge-api", cfg.UDPMergeAPI, "With --udp-stream, also poll the WeatherFlow API and merge its observations into the UDP stream (UDP values win, missing fields such as the daily rain total come from the API). Requires --token and --station. Can also be set via UDP_MERGE_API environment variable")
+	flag.StringVar(&cfg.UDPPort, "udp-port", cfg.UDPPort, "Port the UDP listener binds, e.g. when a relay sends the broadcasts to another port (default: 50222). Can also be set via UDP_PORT environment variable")
+	flag.StringVar(&cfg.UDPNetwork, "udp-network", cfg.UDPNetwork, "UDP listener socket: udp (IPv4 and IPv6 where the system supports dual-stack sockets), udp4 or udp6. Can also be set via UDP_NETWORK environment variable")
+	flag.StringVar(&cfg.UDPInterface, "udp-interface", cfg.UDPInterface, "Receive UDP broadcasts only on this network interface, e.g. eth0 (Linux and macOS); also used for --udp-multicast. Can also be set via UDP_INTERFACE environment variable")
+	flag.StringVar(&cfg.UDPMulticast, "udp-multicast", cfg.UDPMulticast, "Multicast groups the UDP listener joins, comma separated, e.g. 239.0.0.222 for a relay sending to a group. Can also be set via UDP_MULTICAST environment variable")
+	flag.StringVar(&cfg.UDPRelay, "udp-relay", cfg.UDPRelay, "Send every received UDP packet on to these host:port destinations, unicast or to a broadcast address (192.168.20.255:50222). Without --udp-stream the process runs only as a relay. Can also be set via UDP_RELAY environment variable")
+	flag.StringVar(&cfg.UDPSerial, "udp-serial", cfg.UDPSerial, "Serial numbers of the devices to use from UDP broadcasts, each optionally named: ST-00012345=Backyard,ST-00054321=Roof. Other devices are counted but ignored; list an AIR and a SKY together to combine them. Default: the first Tempest heard. Can also be set via UDP_SERIAL environment variable")
+	flag.IntVar(&cfg.UDPRawPackets, "udp-raw-packets", cfg.UDPRawPackets, "With --loglevel debug, keep this many received UDP packets exactly as sent for /api/udp/raw and the dashboard developer panel (default: 100, 0 disables). Can also be set via UDP_RAW_PACKETS environment variable")
+	flag.BoolVar(&cfg.Ingest, "ingest", cfg.Ingest, "Read observations pushed to POST /api/ingest by other hardware (WeatherFlow obs_st/API JSON or the generic format) or to --ecowitt-port instead of a Tempest station. Requires --ingest-token or --ecowitt-port. Can also be set via INGEST environment variable")
+	flag.StringVar(&cfg.IngestToken, "ingest-token", cfg.IngestToken, "Bearer token required by /api/ingest, at least 16 characters. Can also be set via INGEST_TOKEN environment variable")
+	flag.StringVar(&cfg.EcowittPort, "ecowitt-port", cfg.EcowittPort, "With --ingest, listen on this port for uploads in the Ecowitt or Weather Underground protocol (Ecowitt, Ambient Weather and similar stations). Can also be set via ECOWITT_PORT environment variable")
+	flag.StringVar(&cfg.EcowittPasskey, "ecowitt-passkey", cfg.EcowittPasskey, "PASSKEY (Ecowitt, Ambient Weather) or PASSWORD (Weather Underground) uploads must carry; empty accepts any station. Can also be set via ECOWITT_PASSKEY environment variable")
 	flag.BoolVar(&cfg.DisableInternet, "disable-internet", cfg.DisableInternet, "Disable all internet access (no WeatherFlow API calls, no status scraping). Requires --udp-stream or --use-generated-weather. Can also be set via DISABLE_INTERNET environment variable")
 	flag.BoolVar(&cfg.DisableWebConsole, "disable-webconsole", false, "Disable web server (HomeKit only mode)")
 	flag.StringVar(&cfg.Units, "units", cfg.Units, "Units system: imperial (default), metric, or sae. Can also be set via UNITS environment variable")
 	flag.StringVar(&cfg.UnitsPressure, "units-pressure", cfg.UnitsPressure, "Pressure units: inHg (default) or mb. Can also be set via UNITS_PRESSURE environment variable")
-	flag.IntVar(&cfg.HistoryPoints, "history", cfg.HistoryPoints, "Number of data points to store in history (default: 1000, min: 10). Can also be set via HISTORY_POINTS environment variable")
+	flag.StringVar(&cfg.UnitsPressureHomeKit, "units-pressure-homekit", cfg.UnitsPressureHomeKit, "Pressure units of the HomeKit pressure sensor: mb (default) or inHg. Can also be set via UNITS_PRESSURE_HOMEKIT environment variable")
+	flag.StringVar(&cfg.UnitsPressureNotify, "units-pressure-notifications", cfg.UnitsPressureNotify, "Pressure units of notifications: mb (default) or inHg. Can also be set via UNITS_PRESSURE_NOTIFICATIONS environment variable")
+	flag.StringVar(&cfg.UnitsPressureExport, "units-pressure-export", cfg.UnitsPressureExport, "Pressure units of alarm file channel exports: mb (default) or inHg. Can also be set via UNITS_PRESSURE_EXPORT environment variable")
+	flag.IntVar(&cfg.HistoryPoints, "history", cfg.HistoryPoints, "Number of data points to store in history (default: 1000, min: 10, max: 1000000). Can also be set via HISTORY_POINTS environment variable")
 	flag.IntVar(&cfg.HistoryReduce, "history-reduce", cfg.HistoryReduce, "Reduce historical data by averaging N points into 1 (default: 1 = no reduction)")
 	flag.StringVar(&cfg.HistoryReduceMethod, "history-reduce-method", cfg.HistoryReduceMethod, "Method to reduce historical data: timebin (default), factor, lttb")
 	flag.IntVar(&cfg.HistoryBinMinutes, "history-bin-size", cfg.HistoryBinMinutes, "Bin size in minutes for timebin reduction (default: 10)")
@@ -299,8 +616,15 @@ func LoadConfig() *Config {
 	flag.StringVar(&cfg.Alarms, "alarms", cfg.Alarms, "Alarm configuration: @filename.json or inline JSON string")
 	flag.StringVar(&cfg.AlarmsEdit, "alarms-edit", cfg.AlarmsEdit, "Run alarm editor for specified config file: @filename.json")
 	flag.StringVar(&cfg.AlarmsEditPort, "alarms-edit-port", cfg.AlarmsEditPort, "Port for alarm editor web UI (default: 8081)")
+	flag.BoolVar(&cfg.EncryptAlarms, "encrypt-alarms", false, "Encrypt the webhook HMAC secrets, OAuth2 client secrets and credential headers in the --alarms file with the TEMPEST_MASTER_KEY environment variable, then exit")
+	flag.BoolVar(&cfg.AlarmsWebEditor, "alarms-web-editor", cfg.AlarmsWebEditor, "Serve the alarm editor at /alarm-editor on the main web console (requires --alarms @file and --alarms-editor-password)")
+	flag.StringVar(&cfg.AlarmsEditorPassword, "alarms-editor-password", cfg.AlarmsEditorPassword, "Password protecting the /alarm-editor route (HTTP basic auth)")
+	flag.StringVar(&cfg.AlarmPluginsDir, "alarm-plugins-dir", cfg.AlarmPluginsDir, "Directory scanned at startup for notification channel plugins")
 	flag.BoolVar(&cfg.WebhookListener, "webhook-listener", cfg.WebhookListener, "Start webhook listener server (default port: 8082)")
 	flag.StringVar(&cfg.WebhookListenPort, "webhook-listener-port", cfg.WebhookListenPort, "Port for webhook listener server (default: 8082)")
+	flag.StringVar(&cfg.WebhookListenerSecret, "webhook-listener-secret", cfg.WebhookListenerSecret, "Require webhooks HMAC-SHA256 signed with this shared secret (X-Tempest-Signature)")
+	flag.StringVar(&cfg.WebhookListenerAllow, "webhook-listener-allow", cfg.WebhookListenerAllow, "Comma-separated IPs and CIDR networks webhooks are accepted from (empty: any)")
+	flag.StringVar(&cfg.WebhookListenerForward, "webhook-listener-forward", cfg.WebhookListenerForward, "Forward received webhooks to this URL")
 	flag.StringVar(&cfg.EnvFile, "env", cfg.EnvFile, "Custom environment file to load (default: .env)")
 	flag.BoolVar(&cfg.Status, "status", cfg.Status, "Enable curses-based status console (TUI mode)")
 	flag.IntVar(&cfg.StatusRefresh, "status-refresh", cfg.StatusRefresh, "Status refresh interval in seconds (default: 5)")
@@ -308,6 +632,10 @@ func LoadConfig() *Config {
 	flag.StringVar(&cfg.StatusTheme, "status-theme", cfg.StatusTheme, "Color theme for status console (default: dark-ocean)")
 	flag.BoolVar(&cfg.StatusThemeList, "status-theme-list", false, "List all available color themes and exit")
 	flag.BoolVar(&cfg.Version, "version", false, "Show version information and exit")
+	flag.StringVar(&cfg.Backup, "backup", "", "Write all state (HomeKit db, statistics, alarm config and its backups, environment file) to this tar.gz archive, verify it and exit")
+	flag.StringVar(&cfg.Restore, "restore", "", "Verify a --backup archive and restore it into the data directory, keeping replaced state as *.pre-restore, then exit")
+	flag.BoolVar(&cfg.InstallService, "install-service", false, "Install a systemd unit (Linux), launchd job (macOS) or Windows service that runs the bridge with the other flags given, then exit")
+	flag.StringVar(&cfg.ServiceUnit, "service-unit", "", "Unit file or plist path written by --install-service (default: platform location)")
 	flag.BoolVar(&cfg.TestSensorRain, "test-sensor-rain", false, "Test rain sensor with cycling pattern")
 	flag.BoolVar(&cfg.TestSensorWind, "test-sensor-wind", false, "Test wind sensor with cycling pattern")
 	flag.BoolVar(&cfg.TestSensorTemp, "test-sensor-temp", false, "Test temperature sensor with cycling pattern")
@@ -316,6 +644,13 @@ func LoadConfig() *Config {
 	flag.BoolVar(&cfg.TestSensorLux, "test-sensor-lux", false, "Test lux sensor with cycling pattern")
 	flag.BoolVar(&cfg.TestSensorUV, "test-sensor-uv", false, "Test UV sensor with cycling pattern")
 	flag.BoolVar(&cfg.TestSensorLightning, "test-sensor-lightning", false, "Test lightning sensor with cycling pattern")
+	flag.StringVar(&cfg.InjectAPIErrors, "inject-api-errors", "", "Chaos testing: answer this percentage of WeatherFlow API requests with a synthetic 503 (e.g., 10%), exercising retries and failover")
+	flag.StringVar(&cfg.InjectUDPDrop, "inject-udp-drop", "", "Chaos testing: discard this percentage of received UDP packets (e.g., 20%)")
+	flag.StringVar(&cfg.InjectLatency, "inject-latency", "", "Chaos testing: delay every WeatherFlow API request by this duration (e.g., 2s)")
+	flag.BoolVar(&cfg.BenchWeb, "bench-web", false, "Load test the web server with --history synthetic observations, report p50/p95 latency and allocations per endpoint, and exit")
+	flag.StringVar(&cfg.BenchWebMix, "bench-web-mix", "status=3,history=1", "Request mix for --bench-web as endpoint=weight pairs; names map to /api/<name>, or give a path such as /api/history")
+	flag.IntVar(&cfg.BenchWebRequests, "bench-web-requests", 2000, "Total requests replayed by --bench-web")
+	flag.IntVar(&cfg.BenchWebConcurrency, "bench-web-concurrency", 8, "Parallel clients used by --bench-web")
 
 	// Parse flags but check if elevation was actually provided
 	flag.Parse()
@@ -325,6 +660,17 @@ func LoadConfig() *Config {
 	// StationURL when using --use-generated-weather so the generated data
 	// source is used instead of an HTTP API.
 
+	// Read secrets given as env:, file:, token_file: or keychain: references
+	if err := resolveSecrets(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Without a token or station there is nothing to connect to yet, so serve
+	// the setup wizard instead of failing (unless there is no web server to
+	// serve it from)
+	cfg.SetupWizard = NeedsSetup(cfg) && !cfg.DisableWebConsole
+
 	// Validate command line arguments
 	if err := validateConfig(cfg); err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: %v\n\n", err)
@@ -348,7 +694,7 @@ func LoadConfig() *Config {
 	// Handle elevation configuration - auto lookup by default
 	if !elevationProvided || strings.ToLower(elevationStr) == "auto" {
 		// Skip station elevation lookup if using generated weather - elevation will be set later from generated location
-		if !cfg.UseGeneratedWeather {
+		if !cfg.UseGeneratedWeather && !cfg.SetupWizard {
 			if elevation, err := lookupStationElevation(cfg.Token, cfg.StationName); err != nil {
 				log.Printf("Warning: Failed to lookup elevation automatically: %v", err)
 				log.Printf("INFO: Using fallback elevation 903ft (275.2m)")
@@ -371,6 +717,99 @@ func LoadConfig() *Config {
 	return cfg
 }
 
+// ParsePercent parses a failure injection rate such as "10%" or "10" into a
+// fraction from 0 to 1. An empty value is 0.
+func ParsePercent(value string) (float64, error) {
+	v := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+	if v == "" {
+		return 0, nil
+	}
+	pct, err := strconv.ParseFloat(v, 64)
+	if err != nil || pct < 0 || pct > 100 {
+		return 0, fmt.Errorf("invalid percentage '%s'. Use a value from 0%% to 100%% such as 10%%", value)
+	}
+	return pct / 100, nil
+}
+
+// ParseRequestMix parses a weighted request mix such as "status=3,history=1"
+// into request paths and weights. Bare names map to /api/<name>; a weight
+// may be omitted and defaults to 1.
+func ParseRequestMix(value string) (map[string]int, error) {
+	mix := make(map[string]int)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, weight := part, 1
+		if i := strings.LastIndex(part, "="); i >= 0 {
+			w, err := strconv.Atoi(strings.TrimSpace(part[i+1:]))
+			if err != nil || w < 1 {
+				return nil, fmt.Errorf("invalid weight in '%s'. Use endpoint=weight pairs such as status=3,history=1", part)
+			}
+			name, weight = strings.TrimSpace(part[:i]), w
+		}
+		if name == "" {
+			return nil, fmt.Errorf("missing endpoint in '%s'", part)
+		}
+		if !strings.HasPrefix(name, "/") {
+			name = "/api/" + name
+		}
+		mix[name] += weight
+	}
+	if len(mix) == 0 {
+		return nil, fmt.Errorf("empty request mix. Use endpoint=weight pairs such as status=3,history=1")
+	}
+	return mix, nil
+}
+
+// ParseGDDBase parses a growing degree day base temperature: a number in °C,
+// or in °F when suffixed with F ("50F")
+func ParseGDDBase(value string) (base float64, fahrenheit bool, err error) {
+	v := strings.TrimSpace(value)
+	switch {
+	case strings.HasSuffix(v, "F"), strings.HasSuffix(v, "f"):
+		v, fahrenheit = v[:len(v)-1], true
+	case strings.HasSuffix(v, "C"), strings.HasSuffix(v, "c"):
+		v = v[:len(v)-1]
+	}
+	base, err = strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid GDD base '%s'. Use a temperature such as 10 or 50F", value)
+	}
+	return base, fahrenheit, nil
+}
+
+// ParseComfortRange parses the comfort advisor's indoor temperature range,
+// "20-24" in °C or "68-75F" in °F, and returns it in °C
+func ParseComfortRange(value string) (low, high float64, err error) {
+	v := strings.TrimSpace(value)
+	fahrenheit := false
+	switch {
+	case strings.HasSuffix(v, "F"), strings.HasSuffix(v, "f"):
+		v, fahrenheit = v[:len(v)-1], true
+	case strings.HasSuffix(v, "C"), strings.HasSuffix(v, "c"):
+		v = v[:len(v)-1]
+	}
+	lowStr, highStr, ok := strings.Cut(v, "-")
+	if ok {
+		low, err = strconv.ParseFloat(strings.TrimSpace(lowStr), 64)
+		if err == nil {
+			high, err = strconv.ParseFloat(strings.TrimSpace(highStr), 64)
+		}
+	}
+	if !ok || err != nil || low >= high {
+		return 0, 0, fmt.Errorf("invalid comfort range '%s'. Use min-max such as 20-24 or 68-75F", value)
+	}
+	if fahrenheit {
+		low, high = (low-32)*5/9, (high-32)*5/9
+	}
+	return low, high, nil
+}
+
+// MaxHistoryPoints caps --history, since the history is kept in memory
+const MaxHistoryPoints = 1000000
+
 // validateConfig validates command line arguments and returns an error if invalid
 func validateConfig(cfg *Config) error {
 	// Ensure sensible defaults for fields when Config structs are created programmatically
@@ -381,6 +820,11 @@ func validateConfig(cfg *Config) error {
 	if strings.TrimSpace(cfg.UnitsPressure) == "" {
 		cfg.UnitsPressure = "inHg"
 	}
+	for _, unit := range []*string{&cfg.UnitsPressureHomeKit, &cfg.UnitsPressureNotify, &cfg.UnitsPressureExport} {
+		if strings.TrimSpace(*unit) == "" {
+			*unit = "mb"
+		}
+	}
 	// Default history/chart values when zero-valued Config is used in tests or programmatically
 	if cfg.HistoryPoints == 0 {
 		cfg.HistoryPoints = 1000
@@ -398,14 +842,14 @@ func validateConfig(cfg *Config) error {
 		}
 	}
 	if !validLevel {
-		return fmt.Errorf("invalid log level '%s'. Valid options: debug, info, warn/warning, error", cfg.LogLevel)
+		return fmt.Errorf("invalid log level '%s'.%s Valid options: debug, info, warn/warning, error", cfg.LogLevel, didYouMean(cfg.LogLevel, validLogLevels))
 	}
 
 	// Validate sensor configuration by testing parsing
 	if cfg.Sensors != "" {
 		// Test if sensor config is valid by attempting to parse it
 		// This will help catch invalid sensor names early
-		validSensorNames := []string{"temp", "temperature", "humidity", "lux", "light", "wind", "rain", "pressure", "uv", "uvi", "lightning"}
+		validSensorNames := []string{"temp", "temperature", "humidity", "lux", "light", "wind", "rain", "pressure", "uv", "uvi", "lightning", "diagnostics", "comfort"}
 		validPresets := []string{"all", "min"}
 
 		// Check if it's a preset
@@ -433,56 +877,403 @@ func validateConfig(cfg *Config) error {
 					}
 				}
 				if !valid {
-					return fmt.Errorf("invalid sensor '%s'. Valid sensors: %s. Valid presets: %s",
-						sensor, strings.Join(validSensorNames, ", "), strings.Join(validPresets, ", "))
+					return fmt.Errorf("invalid sensor '%s'.%s Valid sensors: %s. Valid presets: %s",
+						sensor, didYouMean(sensor, validSensorNames), strings.Join(validSensorNames, ", "), strings.Join(validPresets, ", "))
 				}
 			}
 		}
 	}
 
-	// Validate web port is numeric
-	if _, err := strconv.Atoi(cfg.WebPort); err != nil {
-		return fmt.Errorf("invalid web port '%s'. Port must be a number", cfg.WebPort)
+	// Validate HomeKit accessory mode
+	switch cfg.HomeKitMode {
+	case "", "split", "combined":
+	default:
+		return fmt.Errorf("invalid HomeKit mode '%s'.%s Valid modes: split, combined", cfg.HomeKitMode, didYouMean(cfg.HomeKitMode, []string{"split", "combined"}))
+	}
+
+	// Validate HomeKit accessory names
+	if _, err := ParseHomeKitNames(cfg.HomeKitNames); err != nil {
+		return err
+	}
+
+	// Validate bind addresses; interfaces are checked when HomeKit starts
+	if cfg.HomeKitAddress != "" && net.ParseIP(cfg.HomeKitAddress) == nil {
+		return fmt.Errorf("invalid HomeKit address '%s'. Must be an IP address", cfg.HomeKitAddress)
+	}
+	if cfg.WebAddress != "" && net.ParseIP(cfg.WebAddress) == nil {
+		return fmt.Errorf("invalid web address '%s'. Must be an IP address", cfg.WebAddress)
+	}
+	if cfg.HomeKitPort != "" {
+		if err := checkPort("HomeKit port", cfg.HomeKitPort); err != nil {
+			return err
+		}
+	}
+	if _, err := ParseHiddenCards(cfg.HiddenCards); err != nil {
+		return err
+	}
+	if cfg.WebBasePath != "" && !basePathPattern.MatchString(cfg.WebBasePath) {
+		return fmt.Errorf("invalid web base path '%s'. Use a path such as /station/alice without a trailing slash", cfg.WebBasePath)
+	}
+	if cfg.PublicURL != "" {
+		if u, err := url.Parse(cfg.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("invalid public URL '%s'. Use scheme://host[:port][/path], e.g. https://weather.example.com", cfg.PublicURL)
+		}
+	}
+
+	// Origins are compared with the browser's Origin header, which has no path
+	for _, origin := range websec.ParseOrigins(cfg.CORSOrigins) {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+			return fmt.Errorf("invalid CORS origin '%s'. Use scheme://host[:port], e.g. https://ha.local:8123, or *", origin)
+		}
+	}
+
+	// Validate web server timeouts and connection limit
+	for _, timeout := range []struct{ name, value string }{
+		{"web read header timeout", cfg.WebReadHeaderTimeout},
+		{"web write timeout", cfg.WebWriteTimeout},
+		{"web idle timeout", cfg.WebIdleTimeout},
+	} {
+		if timeout.value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(timeout.value); err != nil || (d != 0 && d < time.Second) {
+			return fmt.Errorf("invalid %s '%s'. Use a duration of at least 1s such as 30s, or 0 to disable", timeout.name, timeout.value)
+		}
+	}
+	if cfg.WebMaxConns < 0 {
+		return fmt.Errorf("invalid web max conns %d. Use a positive number, or 0 for no limit", cfg.WebMaxConns)
+	}
+
+	// Validate the web and gRPC ports; conflicts are checked once all ports are
+	if err := checkPort("web port", cfg.WebPort); err != nil {
+		return err
+	}
+	if cfg.GRPCPort != "" {
+		if err := checkPort("gRPC port", cfg.GRPCPort); err != nil {
+			return err
+		}
+	}
+
+	// Validate MQTT topic prefix; wildcards are only valid in subscriptions
+	if cfg.MQTTBroker != "" {
+		if cfg.MQTTTopic == "" || strings.ContainsAny(cfg.MQTTTopic, "+#") {
+			return fmt.Errorf("invalid MQTT topic '%s'. Use a prefix without wildcards, such as tempest", cfg.MQTTTopic)
+		}
+	}
+
+	// Validate watchdog timeout
+	if cfg.WatchdogTimeout != "" && cfg.WatchdogTimeout != "0" {
+		d, err := time.ParseDuration(cfg.WatchdogTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid watchdog timeout '%s'. Use a duration such as 5m or 90s", cfg.WatchdogTimeout)
+		}
+		if d < 2*time.Minute { // observations arrive once a minute
+			return fmt.Errorf("watchdog timeout %s is too short (minimum 2m)", cfg.WatchdogTimeout)
+		}
+	}
+
+	// Validate polling intervals; the minimums keep a single bridge well
+	// inside the WeatherFlow rate limits
+	for _, poll := range []struct {
+		name, value string
+		min         time.Duration
+	}{
+		{"observation", cfg.PollObs, 15 * time.Second},
+		{"forecast", cfg.PollForecast, 5 * time.Minute},
+		{"status", cfg.PollStatus, 5 * time.Minute},
+	} {
+		if poll.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(poll.value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid %s polling interval '%s'. Use a duration such as 60s or 30m", poll.name, poll.value)
+		}
+		if d < poll.min {
+			return fmt.Errorf("%s polling interval %s is too short (minimum %v)", poll.name, poll.value, poll.min)
+		}
+	}
+
+	// Validate backfill gap
+	if cfg.BackfillGap != "" && cfg.BackfillGap != "0" {
+		d, err := time.ParseDuration(cfg.BackfillGap)
+		if err != nil {
+			return fmt.Errorf("invalid backfill gap '%s'. Use a duration such as 5m or 10m", cfg.BackfillGap)
+		}
+		if d < 2*time.Minute { // observations arrive once a minute
+			return fmt.Errorf("backfill gap %s is too short (minimum 2m)", cfg.BackfillGap)
+		}
+	}
+
+	if cfg.Maintenance != "" {
+		if _, err := maintenance.ParseSpec(cfg.Maintenance, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	// Validate gust hold
+	if cfg.GustHold != "" && cfg.GustHold != "0" {
+		d, err := time.ParseDuration(cfg.GustHold)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid gust hold '%s'. Use a duration such as 5m or 10m", cfg.GustHold)
+		}
+		if d > time.Hour {
+			return fmt.Errorf("gust hold %s is too long (maximum 1h)", cfg.GustHold)
+		}
+	}
+
+	// Validate lux smoothing
+	if cfg.LuxSmoothing != "" && cfg.LuxSmoothing != "0" {
+		d, err := time.ParseDuration(cfg.LuxSmoothing)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid lux smoothing '%s'. Use a duration such as 5m", cfg.LuxSmoothing)
+		}
+		if d > time.Hour {
+			return fmt.Errorf("lux smoothing %s is too long (maximum 1h)", cfg.LuxSmoothing)
+		}
+	}
+
+	// Validate rain correction
+	if cfg.RainCorrection != "" {
+		factor, err := strconv.ParseFloat(cfg.RainCorrection, 64)
+		if err != nil {
+			return fmt.Errorf("invalid rain correction '%s'. Use a multiplier such as 1.1", cfg.RainCorrection)
+		}
+		if factor < 0.2 || factor > 5 {
+			return fmt.Errorf("rain correction %s is out of range (0.2 to 5)", cfg.RainCorrection)
+		}
+	}
+
+	// Validate snow ratio
+	if cfg.SnowRatio != "" {
+		ratio, err := strconv.ParseFloat(cfg.SnowRatio, 64)
+		if err != nil {
+			return fmt.Errorf("invalid snow ratio '%s'. Use a ratio such as 10", cfg.SnowRatio)
+		}
+		if ratio < 3 || ratio > 30 {
+			return fmt.Errorf("snow ratio %s is out of range (3 to 30)", cfg.SnowRatio)
+		}
+	}
+
+	// Validate the comfort advisor targets
+	if cfg.ComfortRange != "" {
+		if _, _, err := ParseComfortRange(cfg.ComfortRange); err != nil {
+			return err
+		}
+	}
+	if cfg.ComfortHumidity != "" {
+		if h, err := strconv.ParseFloat(cfg.ComfortHumidity, 64); err != nil || h <= 0 || h > 100 {
+			return fmt.Errorf("invalid comfort humidity '%s'. Use a percentage such as 60", cfg.ComfortHumidity)
+		}
+	}
+
+	// Validate growing degree day base and season starts
+	if cfg.GDDBase != "" {
+		if _, _, err := ParseGDDBase(cfg.GDDBase); err != nil {
+			return err
+		}
+	}
+	for _, season := range []struct{ name, start string }{
+		{"GDD season start", cfg.GDDSeasonStart},
+		{"chill season start", cfg.ChillSeasonStart},
+	} {
+		if season.start == "" {
+			continue
+		}
+		if _, err := time.Parse("01-02", season.start); err != nil {
+			return fmt.Errorf("invalid %s '%s'. Use MM-DD, such as 03-01", season.name, season.start)
+		}
+	}
+
+	// Validate anomaly threshold
+	if cfg.AnomalyThreshold != "" {
+		z, err := strconv.ParseFloat(cfg.AnomalyThreshold, 64)
+		if err != nil {
+			return fmt.Errorf("invalid anomaly threshold '%s'. Use a z-score such as 4", cfg.AnomalyThreshold)
+		}
+		if z < 2 || z > 10 {
+			return fmt.Errorf("anomaly threshold %s is out of range (2 to 10)", cfg.AnomalyThreshold)
+		}
+	}
+
+	// Validate token check interval
+	if cfg.TokenCheckInterval != "" && cfg.TokenCheckInterval != "0" {
+		d, err := time.ParseDuration(cfg.TokenCheckInterval)
+		if err != nil {
+			return fmt.Errorf("invalid token check interval '%s'. Use a duration such as 1h or 30m", cfg.TokenCheckInterval)
+		}
+		if d < time.Minute { // stay well inside the API rate limit
+			return fmt.Errorf("token check interval %s is too short (minimum 1m)", cfg.TokenCheckInterval)
+		}
 	}
 
 	// Validate webhook listen port is numeric
 	if cfg.WebhookListenPort != "" {
-		if _, err := strconv.Atoi(cfg.WebhookListenPort); err != nil {
-			return fmt.Errorf("invalid webhook listen port '%s'. Port must be a number", cfg.WebhookListenPort)
+		if err := checkPort("webhook listen port", cfg.WebhookListenPort); err != nil {
+			return err
+		}
+	}
+	if cfg.AlarmsEditPort != "" {
+		if err := checkPort("alarm editor port", cfg.AlarmsEditPort); err != nil {
+			return err
 		}
 	}
 
-	// Validate HomeKit PIN format (8 digits)
-	if len(cfg.Pin) != 8 {
-		return fmt.Errorf("invalid HomeKit PIN '%s'. PIN must be exactly 8 digits", cfg.Pin)
+	// Validate webhook listener sources and forwarding URL
+	if _, err := webhooklistener.ParseSources(cfg.WebhookListenerAllow); err != nil {
+		return fmt.Errorf("invalid webhook listener allow list: %v. Use IPs or CIDR networks such as 192.168.1.0/24", err)
 	}
-	if _, err := strconv.Atoi(cfg.Pin); err != nil {
-		return fmt.Errorf("invalid HomeKit PIN '%s'. PIN must contain only digits", cfg.Pin)
+	if cfg.WebhookListenerForward != "" {
+		if u, err := url.Parse(cfg.WebhookListenerForward); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook listener forward URL '%s'. Use an http:// or https:// URL", cfg.WebhookListenerForward)
+		}
+	}
+
+	// Validate HomeKit PIN format (8 digits); an empty PIN is generated by the
+	// HomeKit package and kept in its store
+	if cfg.Pin != "" {
+		if len(cfg.Pin) != 8 {
+			return fmt.Errorf("invalid HomeKit PIN '%s'. PIN must be exactly 8 digits", cfg.Pin)
+		}
+		if _, err := strconv.Atoi(cfg.Pin); err != nil {
+			return fmt.Errorf("invalid HomeKit PIN '%s'. PIN must contain only digits", cfg.Pin)
+		}
 	}
 
 	// Validate required fields for WeatherFlow API mode
 	// The WeatherFlow API token is required only when using the WeatherFlow API as the
 	// data source. If a custom station URL is provided via --station-url, the
-	// --use-generated-weather flag is set, or --udp-stream is enabled, a WeatherFlow token is not necessary.
+	// --use-generated-weather flag is set, or --udp-stream or --ingest is enabled, a WeatherFlow token is not necessary.
 	// Also skip token requirement for alarm editor mode.
-	usingWeatherFlowAPI := cfg.StationURL == "" && !cfg.UseGeneratedWeather && !cfg.UDPStream && cfg.AlarmsEdit == ""
+	usingWeatherFlowAPI := UsesWeatherFlowAPI(cfg)
 
-	if usingWeatherFlowAPI {
+	if usingWeatherFlowAPI && !cfg.SetupWizard && !cfg.Doctor {
 		if cfg.Token == "" {
-			return fmt.Errorf("WeatherFlow API token is required when using the WeatherFlow API as the data source. Set via --token flag or TEMPEST_TOKEN environment variable, or use --station-url/--use-generated-weather/--udp-stream for token-less modes")
+			return fmt.Errorf("WeatherFlow API token is required when using the WeatherFlow API as the data source. Set via --token flag or TEMPEST_TOKEN environment variable, or use --station-url/--use-generated-weather/--udp-stream/--ingest for token-less modes")
 		}
 		if cfg.StationName == "" {
 			return fmt.Errorf("both --token and --station are required when using the WeatherFlow API. Set station via --station flag or TEMPEST_STATION_NAME environment variable")
 		}
 	}
 
-	// Validate DisableInternet mode requires a local data source (UDP or Generated Weather)
-	if cfg.DisableInternet && !cfg.UDPStream && !cfg.UseGeneratedWeather {
-		return fmt.Errorf("--disable-internet mode requires --udp-stream or --use-generated-weather (need a local data source)")
+	if cfg.WeatherFlowAPIURL != "" {
+		u, err := url.Parse(cfg.WeatherFlowAPIURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid WeatherFlow API URL '%s'. Use an http:// or https:// URL such as http://localhost:8090/swd/rest", cfg.WeatherFlowAPIURL)
+		}
+	}
+
+	// Validate DisableInternet mode requires a local data source (UDP, ingest or Generated Weather)
+	if cfg.DisableInternet && !cfg.UDPStream && !cfg.UseGeneratedWeather && !cfg.Ingest {
+		return fmt.Errorf("--disable-internet mode requires --udp-stream or --use-generated-weather (need a local data source), or --ingest")
+	}
+
+	if cfg.Ingest {
+		if cfg.UDPStream || cfg.StationURL != "" || cfg.UseGeneratedWeather {
+			return fmt.Errorf("--ingest cannot be combined with --udp-stream, --station-url or --use-generated-weather")
+		}
+		if cfg.DisableWebConsole {
+			return fmt.Errorf("--ingest needs the web console to receive observations")
+		}
+		if cfg.IngestToken == "" && cfg.EcowittPort == "" {
+			return fmt.Errorf("--ingest requires --ingest-token (or INGEST_TOKEN) for /api/ingest, or --ecowitt-port")
+		}
+		if cfg.IngestToken != "" && len(cfg.IngestToken) < 16 {
+			return fmt.Errorf("--ingest-token must be at least 16 characters")
+		}
+	}
+	if cfg.Tenants != "" {
+		if cfg.UDPStream || cfg.StationURL != "" || cfg.UseGeneratedWeather || cfg.Ingest {
+			return fmt.Errorf("--tenants cannot be combined with a data source; set one per station in the tenants file")
+		}
+		if cfg.DisableWebConsole || cfg.WebBasePath != "" {
+			return fmt.Errorf("--tenants serves the stations on the web port and cannot be combined with --disable-webconsole or --web-base-path")
+		}
+		if _, err := os.Stat(cfg.Tenants); err != nil {
+			return fmt.Errorf("tenants file: %v", err)
+		}
+	}
+	if cfg.Federate != "" {
+		if _, err := federation.ParseSites(cfg.Federate); err != nil {
+			return fmt.Errorf("invalid --federate: %v", err)
+		}
+		if cfg.DisableWebConsole {
+			return fmt.Errorf("--federate shows the sites in the web console and cannot be combined with --disable-webconsole")
+		}
+		if cfg.Tenants != "" {
+			return fmt.Errorf("--federate cannot be combined with --tenants; set it per station in the tenants file")
+		}
+		d, err := time.ParseDuration(cfg.FederateInterval)
+		if err != nil {
+			return fmt.Errorf("invalid federate interval '%s'. Use a duration such as 1m or 30s", cfg.FederateInterval)
+		}
+		if d < 10*time.Second {
+			return fmt.Errorf("federate interval %s is too short (minimum 10s)", cfg.FederateInterval)
+		}
+	}
+	if cfg.EcowittPort != "" {
+		if !cfg.Ingest {
+			return fmt.Errorf("--ecowitt-port requires --ingest")
+		}
+		if err := checkPort("Ecowitt listener port", cfg.EcowittPort); err != nil {
+			return err
+		}
+	}
+	if err := checkPortConflicts(cfg); err != nil {
+		return err
+	}
+
+	if port, err := strconv.Atoi(cfg.UDPPort); cfg.UDPPort != "" && (err != nil || port < 1 || port > 65535) {
+		return fmt.Errorf("invalid UDP port '%s'. Port must be a number between 1 and 65535", cfg.UDPPort)
+	}
+	network, err := udp.ParseNetwork(cfg.UDPNetwork)
+	if err != nil {
+		return fmt.Errorf("--udp-network: %v", err)
+	}
+	groups, err := udp.ParseMulticastGroups(cfg.UDPMulticast)
+	if err != nil {
+		return fmt.Errorf("--udp-multicast: %v", err)
+	}
+	for _, group := range groups {
+		if (network == "udp4" && group.To4() == nil) || (network == "udp6" && group.To4() != nil) {
+			return fmt.Errorf("--udp-multicast: group %s cannot be joined on a %s socket", group, network)
+		}
+	}
+	if cfg.UDPRawPackets < 0 || cfg.UDPRawPackets > 10000 {
+		return fmt.Errorf("--udp-raw-packets must be between 0 and 10000 (got %d)", cfg.UDPRawPackets)
+	}
+	if cfg.UDPRelay != "" {
+		if _, err := udp.ParseRelayTargets(cfg.UDPRelay); err != nil {
+			return fmt.Errorf("--udp-relay: %v", err)
+		}
+	}
+
+	if cfg.UDPSerial != "" {
+		if !cfg.UDPStream && cfg.TestUDP == 0 {
+			return fmt.Errorf("--udp-serial requires --udp-stream or --test-udp")
+		}
+		if _, err := udp.ParseStations(cfg.UDPSerial); err != nil {
+			return fmt.Errorf("--udp-serial: %v", err)
+		}
+	}
+
+	if cfg.UDPMergeAPI {
+		if !cfg.UDPStream {
+			return fmt.Errorf("--udp-merge-api requires --udp-stream")
+		}
+		if cfg.Token == "" || cfg.StationName == "" {
+			return fmt.Errorf("--udp-merge-api requires --token and --station to poll the WeatherFlow API")
+		}
 	}
 
 	// Validate DisableInternet mode is incompatible with internet-dependent features
 	if cfg.DisableInternet {
+		if cfg.UDPMergeAPI {
+			return fmt.Errorf("--udp-merge-api cannot be used with --disable-internet (requires WeatherFlow API access)")
+		}
 		if cfg.UseWebStatus {
 			return fmt.Errorf("--use-web-status cannot be used with --disable-internet (requires internet access)")
 		}
@@ -496,6 +1287,41 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("--disable-homekit and --disable-webconsole cannot be used together (would disable everything)")
 	}
 
+	// Failure injection
+	if _, err := ParsePercent(cfg.InjectAPIErrors); err != nil {
+		return fmt.Errorf("--inject-api-errors: %v", err)
+	}
+	if _, err := ParsePercent(cfg.InjectUDPDrop); err != nil {
+		return fmt.Errorf("--inject-udp-drop: %v", err)
+	}
+	if cfg.InjectLatency != "" {
+		if d, err := time.ParseDuration(cfg.InjectLatency); err != nil || d < 0 {
+			return fmt.Errorf("invalid injected latency '%s'. Use a duration such as 2s or 500ms", cfg.InjectLatency)
+		}
+	}
+
+	// Backup and restore run instead of the bridge
+	if cfg.Backup != "" && cfg.Restore != "" {
+		return fmt.Errorf("--backup and --restore cannot be combined")
+	}
+	if cfg.EncryptAlarms && !strings.HasPrefix(cfg.Alarms, "@") {
+		return fmt.Errorf("--encrypt-alarms requires --alarms @file.json")
+	}
+	if cfg.Restore != "" {
+		if _, err := os.Stat(cfg.Restore); err != nil {
+			return fmt.Errorf("restore archive: %v", err)
+		}
+	}
+
+	if cfg.BenchWeb {
+		if _, err := ParseRequestMix(cfg.BenchWebMix); err != nil {
+			return fmt.Errorf("--bench-web-mix: %v", err)
+		}
+		if cfg.BenchWebRequests < 1 || cfg.BenchWebConcurrency < 1 {
+			return fmt.Errorf("--bench-web-requests and --bench-web-concurrency must be at least 1")
+		}
+	}
+
 	// Test sensor flags require --use-generated-weather
 	if (cfg.TestSensorRain || cfg.TestSensorWind || cfg.TestSensorTemp || cfg.TestSensorHumidity ||
 		cfg.TestSensorPressure || cfg.TestSensorLux || cfg.TestSensorUV || cfg.TestSensorLightning) &&
@@ -503,11 +1329,25 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("test sensor flags require --use-generated-weather")
 	}
 
-	// Station name is required for non-alarm-editor modes (already checked above for API mode)
-	if cfg.StationName == "" && cfg.AlarmsEdit == "" && !usingWeatherFlowAPI {
+	// Station name is required for non-alarm-editor modes (already checked above for API mode);
+	// a tenants host names its stations in the tenants file
+	if cfg.StationName == "" && cfg.AlarmsEdit == "" && cfg.Tenants == "" && !cfg.maintenance() && !cfg.RelayOnly() && !usingWeatherFlowAPI {
 		return fmt.Errorf("station name is required. Set via --station flag or TEMPEST_STATION_NAME environment variable")
 	}
 
+	// The integrated alarm editor writes back to the alarm file and must be protected
+	if cfg.AlarmsWebEditor {
+		if !strings.HasPrefix(cfg.Alarms, "@") {
+			return fmt.Errorf("--alarms-web-editor requires a file-based alarm configuration (--alarms @filename.json)")
+		}
+		if cfg.AlarmsEditorPassword == "" {
+			return fmt.Errorf("--alarms-web-editor requires --alarms-editor-password (or ALARMS_EDITOR_PASSWORD)")
+		}
+		if cfg.DisableWebConsole || cfg.DisableAlarms {
+			return fmt.Errorf("--alarms-web-editor cannot be used with --disable-webconsole or --disable-alarms")
+		}
+	}
+
 	// Validate units
 	validUnits := []string{"imperial", "metric", "sae"}
 	validUnit := false
@@ -518,7 +1358,7 @@ func validateConfig(cfg *Config) error {
 		}
 	}
 	if !validUnit {
-		return fmt.Errorf("invalid units '%s'. Valid options: imperial, metric, sae", cfg.Units)
+		return fmt.Errorf("invalid units '%s'.%s Valid options: imperial, metric, sae", cfg.Units, didYouMean(cfg.Units, validUnits))
 	}
 
 	// Validate pressure units
@@ -531,13 +1371,25 @@ func validateConfig(cfg *Config) error {
 		}
 	}
 	if !validPressureUnit {
-		return fmt.Errorf("invalid pressure units '%s'. Valid options: inHg, mb", cfg.UnitsPressure)
+		return fmt.Errorf("invalid pressure units '%s'.%s Valid options: inHg, mb", cfg.UnitsPressure, didYouMean(cfg.UnitsPressure, validPressureUnits))
+	}
+	for _, context := range []struct{ name, unit string }{
+		{"HomeKit", cfg.UnitsPressureHomeKit},
+		{"notification", cfg.UnitsPressureNotify},
+		{"export", cfg.UnitsPressureExport},
+	} {
+		if !slices.Contains(validPressureUnits, context.unit) {
+			return fmt.Errorf("invalid %s pressure units '%s'.%s Valid options: inHg, mb", context.name, context.unit, didYouMean(context.unit, validPressureUnits))
+		}
 	}
 
 	// Validate history points
 	if cfg.HistoryPoints < 10 {
 		return fmt.Errorf("history points must be at least 10 (got %d)", cfg.HistoryPoints)
 	}
+	if cfg.HistoryPoints > MaxHistoryPoints {
+		return fmt.Errorf("history points must be at most %d (got %d); the history is kept in memory and %d covers about two years of one-minute observations", MaxHistoryPoints, cfg.HistoryPoints, MaxHistoryPoints)
+	}
 	// Validate chart history hours (0 means all, so only check if positive)
 	if cfg.ChartHistoryHours < 0 {
 		return fmt.Errorf("chart history hours must be 0 (all data) or positive (got %d)", cfg.ChartHistoryHours)
@@ -584,6 +1436,8 @@ type SensorConfig struct {
 	Pressure    bool
 	UV          bool
 	Lightning   bool
+	Diagnostics bool // bridge diagnostics accessory (data age, data source, uptime); never part of a preset
+	Comfort     bool // comfort advisor "Open Windows" switch fed by /api/indoor; never part of a preset
 }
 
 // ParseSensorConfig parses the sensor configuration string and returns a SensorConfig
@@ -632,12 +1486,157 @@ func ParseSensorConfig(sensorsFlag string) SensorConfig {
 				config.UV = true
 			case "lightning":
 				config.Lightning = true
+			case "diagnostics":
+				config.Diagnostics = true
+			case "comfort":
+				config.Comfort = true
 			}
 		}
 		return config
 	}
 }
 
+// SensorNames are the canonical --sensors names, in the order String lists
+// them
+var SensorNames = []string{"temp", "humidity", "lux", "wind", "rain", "pressure", "uv", "lightning", "diagnostics", "comfort"}
+
+// Names returns the canonical names of the enabled sensors
+func (c SensorConfig) Names() []string {
+	enabled := []bool{c.Temperature, c.Humidity, c.Light, c.Wind, c.Rain, c.Pressure, c.UV, c.Lightning, c.Diagnostics, c.Comfort}
+	names := []string{}
+	for i, on := range enabled {
+		if on {
+			names = append(names, SensorNames[i])
+		}
+	}
+	return names
+}
+
+// String returns the configuration as a --sensors list that
+// ParseSensorConfig reads back
+func (c SensorConfig) String() string {
+	return strings.Join(c.Names(), ",")
+}
+
+// HideableCards are the dashboard cards --hidden-cards accepts. The HomeKit
+// card holds the settings that show cards again and is always rendered.
+var HideableCards = []string{"temperature", "humidity", "wind", "rain", "pressure", "light", "uv", "forecast", "tempest", "alarm"}
+
+// ParseHiddenCards parses a --hidden-cards list such as "uv,forecast"
+func ParseHiddenCards(list string) ([]string, error) {
+	cards := []string{}
+	for _, card := range strings.Split(strings.ToLower(list), ",") {
+		card = strings.TrimSpace(card)
+		if card == "" {
+			continue
+		}
+		if !slices.Contains(HideableCards, card) {
+			return nil, fmt.Errorf("invalid dashboard card '%s'. Valid cards: %s", card, strings.Join(HideableCards, ", "))
+		}
+		if !slices.Contains(cards, card) {
+			cards = append(cards, card)
+		}
+	}
+	return cards, nil
+}
+
+// basePathPattern matches a --web-base-path: path segments without a
+// trailing slash, the same rule web.SetBasePath applies
+var basePathPattern = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// homekitAccessoryKeys maps the names accepted by --homekit-names, including
+// the --sensors aliases, to HomeKit accessory keys
+var homekitAccessoryKeys = map[string]string{
+	"bridge":      "bridge",
+	"station":     "station",
+	"temp":        "temperature",
+	"temperature": "temperature",
+	"humidity":    "humidity",
+	"lux":         "light",
+	"light":       "light",
+	"uv":          "uv",
+	"uvi":         "uv",
+	"pressure":    "pressure",
+	"diagnostics": "diagnostics",
+	"comfort":     "comfort",
+}
+
+// ParseHomeKitNames parses accessory name overrides such as
+// "temp=Backyard Temperature,humidity=Backyard Humidity" into a map keyed by
+// accessory (bridge, temperature, humidity, light, uv, pressure, diagnostics, comfort).
+func ParseHomeKitNames(spec string) (map[string]string, error) {
+	names := map[string]string{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, name, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid HomeKit name '%s'. Use accessory=Name, e.g. temperature=Backyard Temperature", entry)
+		}
+		accessory, known := homekitAccessoryKeys[strings.ToLower(strings.TrimSpace(key))]
+		if !known {
+			return nil, fmt.Errorf("invalid HomeKit accessory '%s'. Valid accessories: bridge, station, temperature, humidity, light, uv, pressure, diagnostics, comfort", strings.TrimSpace(key))
+		}
+		if len(name) > 64 { // HAP limit for the Name characteristic
+			return nil, fmt.Errorf("HomeKit name for %s is longer than 64 characters", accessory)
+		}
+		names[accessory] = name
+	}
+	return names, nil
+}
+
+// SecurityPolicy returns the CORS and browser security header settings of
+// the web console and alarm editor
+func SecurityPolicy(cfg *Config) websec.Policy {
+	return websec.Policy{
+		CORSOrigins:           websec.ParseOrigins(cfg.CORSOrigins),
+		ContentSecurityPolicy: cfg.ContentSecurityPolicy,
+		FrameAncestors:        cfg.FrameAncestors,
+	}
+}
+
+// PublicURL returns the URL alarm recipients reach the web console at: the
+// --public-url, or this host's name with the web port and base path
+func PublicURL(cfg *Config) string {
+	if cfg.PublicURL != "" {
+		return strings.TrimRight(cfg.PublicURL, "/")
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "localhost"
+	}
+	return fmt.Sprintf("http://%s%s", net.JoinHostPort(host, cfg.WebPort), cfg.WebBasePath)
+}
+
+// WebLimits returns the timeouts and connection cap of the web server and
+// webhook listener. Values are validated by LoadConfig; "0" disables a timeout.
+func WebLimits(cfg *Config) websec.Limits {
+	timeout := func(value string) time.Duration {
+		d, _ := time.ParseDuration(value)
+		return d
+	}
+	return websec.Limits{
+		ReadHeaderTimeout: timeout(cfg.WebReadHeaderTimeout),
+		WriteTimeout:      timeout(cfg.WebWriteTimeout),
+		IdleTimeout:       timeout(cfg.WebIdleTimeout),
+		MaxConns:          cfg.WebMaxConns,
+	}
+}
+
+// ParseInterfaces splits a comma-separated list of network interface names
+func ParseInterfaces(spec string) []string {
+	var ifaces []string
+	for _, name := range strings.Split(spec, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			ifaces = append(ifaces, name)
+		}
+	}
+	return ifaces
+}
+
 // StationLocation represents station coordinates from WeatherFlow API
 type StationLocation struct {
 	StationID int     `json:"station_id"`
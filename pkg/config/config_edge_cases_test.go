@@ -134,6 +134,14 @@ func TestParseSensorConfigEdgeCases(t *testing.T) {
 				Humidity:    true,
 			},
 		},
+		{
+			name:  "diagnostics accessory",
+			input: "temp,diagnostics",
+			expected: SensorConfig{
+				Temperature: true,
+				Diagnostics: true,
+			},
+		},
 		{
 			name:  "unknown sensor ignored in fallback",
 			input: "temp,unknown,humidity",
@@ -270,7 +278,7 @@ func TestLoadConfigEdgeCases(t *testing.T) {
 	cfg := &Config{
 		Token:       getEnvOrDefault("TEMPEST_TOKEN", ""),
 		StationName: getEnvOrDefault("TEMPEST_STATION_NAME", ""),
-		Pin:         getEnvOrDefault("HOMEKIT_PIN", "00102003"),
+		Pin:         getEnvOrDefault("HOMEKIT_PIN", ""),
 		LogLevel:    getEnvOrDefault("LOG_LEVEL", "error"),
 		WebPort:     getEnvOrDefault("WEB_PORT", "8080"),
 		Sensors:     getEnvOrDefault("SENSORS", "temp,lux,humidity,uv"),
@@ -283,8 +291,8 @@ func TestLoadConfigEdgeCases(t *testing.T) {
 	if cfg.StationName != "" {
 		t.Errorf("Expected empty default station name, got %s", cfg.StationName)
 	}
-	if cfg.Pin != "00102003" {
-		t.Errorf("Expected default PIN, got %s", cfg.Pin)
+	if cfg.Pin != "" {
+		t.Errorf("Expected empty default PIN (generated on first run), got %s", cfg.Pin)
 	}
 	if cfg.LogLevel != "error" {
 		t.Errorf("Expected default log level, got %s", cfg.LogLevel)
@@ -359,3 +367,47 @@ func absFloat(x float64) float64 {
 	}
 	return x
 }
+
+func TestParseHomeKitNames(t *testing.T) {
+	names, err := ParseHomeKitNames("temp=Backyard Temperature, lux = Garden Light,bridge=Weather")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"temperature": "Backyard Temperature", "light": "Garden Light", "bridge": "Weather"}
+	if len(names) != len(want) {
+		t.Fatalf("got %v, want %v", names, want)
+	}
+	for k, v := range want {
+		if names[k] != v {
+			t.Errorf("names[%s] = %q, want %q", k, names[k], v)
+		}
+	}
+
+	for _, bad := range []string{"wind=Wind", "temperature", "humidity="} {
+		if _, err := ParseHomeKitNames(bad); err == nil {
+			t.Errorf("ParseHomeKitNames(%q) should fail", bad)
+		}
+	}
+	if names, err := ParseHomeKitNames(""); err != nil || len(names) != 0 {
+		t.Errorf("empty spec: got %v, %v", names, err)
+	}
+}
+
+func TestParseComfortRange(t *testing.T) {
+	low, high, err := ParseComfortRange("20-24")
+	if err != nil || low != 20 || high != 24 {
+		t.Errorf("20-24: got %v-%v, %v", low, high, err)
+	}
+	low, high, err = ParseComfortRange("68-75F")
+	if err != nil || low != 20 || high < 23.8 || high > 23.9 {
+		t.Errorf("68-75F: got %v-%v, %v", low, high, err)
+	}
+	for _, bad := range []string{"", "24-20", "warm", "20"} {
+		if _, _, err := ParseComfortRange(bad); err == nil {
+			t.Errorf("ParseComfortRange(%q) should fail", bad)
+		}
+	}
+	if cfg := ParseSensorConfig("temp,comfort"); !cfg.Comfort || !cfg.Temperature {
+		t.Errorf("ParseSensorConfig(temp,comfort) = %+v", cfg)
+	}
+}
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestValidateConfigValid tests that valid configurations pass validation
@@ -152,7 +155,6 @@ func TestValidateConfigInvalidWebPort(t *testing.T) {
 		webPort string
 	}{
 		{"non-numeric", "not-a-number"},
-		{"empty", ""},
 		{"decimal", "8080.5"},
 		{"letters mixed", "80a80"},
 	}
@@ -209,7 +211,6 @@ func TestValidateConfigInvalidPin(t *testing.T) {
 	}{
 		{"too short", "123"},
 		{"too long", "123456789"},
-		{"empty", ""},
 		{"contains letters", "1234567a"},
 		{"contains symbols", "1234567!"},
 		{"seven digits", "1234567"},
@@ -240,7 +241,7 @@ func TestValidateConfigInvalidPin(t *testing.T) {
 
 // TestValidateConfigValidPins tests valid PIN formats
 func TestValidateConfigValidPins(t *testing.T) {
-	validPins := []string{"12345678", "00000000", "99999999", "12340000"}
+	validPins := []string{"12345678", "00000000", "99999999", "12340000", ""} // empty generates a PIN
 
 	for _, pin := range validPins {
 		t.Run(pin, func(t *testing.T) {
@@ -466,3 +467,899 @@ func TestValidateConfigDisableInternetRequiresDataSource(t *testing.T) {
 		t.Errorf("Expected data source requirement error, got: %v", err)
 	}
 }
+
+// TestValidateConfigAlarmsWebEditor tests requirements for the integrated alarm editor
+func TestValidateConfigAlarmsWebEditor(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			Token:                "valid-token",
+			StationName:          "Test Station",
+			Pin:                  "12345678",
+			LogLevel:             "error",
+			WebPort:              "8080",
+			AlarmsWebEditor:      true,
+			Alarms:               "@alarms.json",
+			AlarmsEditorPassword: "secret",
+		}
+	}
+
+	if err := validateConfig(base()); err != nil {
+		t.Fatalf("expected valid web editor config, got: %v", err)
+	}
+
+	inline := base()
+	inline.Alarms = `{"alarms":[]}`
+	if err := validateConfig(inline); err == nil || !strings.Contains(err.Error(), "file-based") {
+		t.Errorf("expected file-based error for inline alarms, got: %v", err)
+	}
+
+	noPassword := base()
+	noPassword.AlarmsEditorPassword = ""
+	if err := validateConfig(noPassword); err == nil || !strings.Contains(err.Error(), "password") {
+		t.Errorf("expected password error, got: %v", err)
+	}
+
+	noWeb := base()
+	noWeb.DisableWebConsole = true
+	if err := validateConfig(noWeb); err == nil {
+		t.Error("expected error when web console is disabled")
+	}
+}
+
+// TestValidateConfigHomeKitMode tests HomeKit accessory mode validation
+func TestValidateConfigHomeKitMode(t *testing.T) {
+	for mode, valid := range map[string]bool{"": true, "split": true, "combined": true, "single": false, "Combined": false} {
+		cfg := &Config{
+			Token:       "valid-token",
+			StationName: "Test Station",
+			Pin:         "12345678",
+			LogLevel:    "info",
+			WebPort:     "8080",
+			Sensors:     "temp",
+			HomeKitMode: mode,
+		}
+		err := validateConfig(cfg)
+		if valid && err != nil {
+			t.Errorf("mode %q: unexpected error: %v", mode, err)
+		}
+		if !valid && (err == nil || !strings.Contains(err.Error(), "invalid HomeKit mode")) {
+			t.Errorf("mode %q: expected HomeKit mode error, got %v", mode, err)
+		}
+	}
+}
+
+func TestValidateConfigBindAddresses(t *testing.T) {
+	tests := []struct {
+		homekit, web string
+		wantErr      string
+	}{
+		{"", "", ""},
+		{"192.168.1.20", "::1", ""},
+		{"eth0", "", "invalid HomeKit address"},
+		{"", "localhost", "invalid web address"},
+	}
+	for _, tt := range tests {
+		cfg := &Config{
+			Token:          "valid-token",
+			StationName:    "Test Station",
+			LogLevel:       "info",
+			WebPort:        "8080",
+			Sensors:        "temp",
+			HomeKitAddress: tt.homekit,
+			WebAddress:     tt.web,
+		}
+		err := validateConfig(cfg)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%q/%q: unexpected error: %v", tt.homekit, tt.web, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%q/%q: expected %q error, got %v", tt.homekit, tt.web, tt.wantErr, err)
+		}
+	}
+}
+
+func TestValidateConfigCORSOrigins(t *testing.T) {
+	for origins, valid := range map[string]bool{
+		"":  true,
+		"*": true,
+		"https://ha.local:8123, http://10.0.0.5/": true,
+		"ha.local":                   false,
+		"https://ha.local/dashboard": false,
+		"ftp://ha.local":             false,
+	} {
+		cfg := &Config{
+			Token:       "valid-token",
+			StationName: "Test Station",
+			LogLevel:    "info",
+			WebPort:     "8080",
+			Sensors:     "temp",
+			CORSOrigins: origins,
+		}
+		err := validateConfig(cfg)
+		if valid && err != nil {
+			t.Errorf("%q: unexpected error: %v", origins, err)
+		}
+		if !valid && (err == nil || !strings.Contains(err.Error(), "invalid CORS origin")) {
+			t.Errorf("%q: expected invalid CORS origin error, got %v", origins, err)
+		}
+	}
+}
+
+func TestValidateConfigWebLimits(t *testing.T) {
+	base := func() *Config {
+		return &Config{Token: "valid-token", StationName: "Test Station", LogLevel: "info", WebPort: "8080", Sensors: "temp",
+			WebReadHeaderTimeout: "10s", WebWriteTimeout: "60s", WebIdleTimeout: "2m", WebMaxConns: 256}
+	}
+	if err := validateConfig(base()); err != nil {
+		t.Fatalf("defaults rejected: %v", err)
+	}
+	cfg := base()
+	cfg.WebWriteTimeout = "0"
+	if err := validateConfig(cfg); err != nil {
+		t.Errorf("0 should disable the write timeout: %v", err)
+	}
+	if limits := WebLimits(cfg); limits.WriteTimeout != 0 || limits.IdleTimeout != 2*time.Minute || limits.MaxConns != 256 {
+		t.Errorf("WebLimits = %+v", limits)
+	}
+	for _, mutate := range []func(*Config){
+		func(c *Config) { c.WebReadHeaderTimeout = "soon" },
+		func(c *Config) { c.WebIdleTimeout = "10ms" },
+		func(c *Config) { c.WebMaxConns = -1 },
+	} {
+		cfg := base()
+		mutate(cfg)
+		if err := validateConfig(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestParseInterfaces(t *testing.T) {
+	got := ParseInterfaces(" eth0, ,macvlan0 ")
+	if len(got) != 2 || got[0] != "eth0" || got[1] != "macvlan0" {
+		t.Errorf("ParseInterfaces = %q", got)
+	}
+	if got := ParseInterfaces(""); got != nil {
+		t.Errorf("ParseInterfaces(\"\") = %q, want nil", got)
+	}
+}
+
+func TestValidateConfigTokenCheckInterval(t *testing.T) {
+	tests := []struct {
+		interval string
+		wantErr  string
+	}{
+		{"", ""},
+		{"0", ""},
+		{"1h", ""},
+		{"90s", ""},
+		{"hourly", "invalid token check interval"},
+		{"30s", "too short"},
+	}
+	for _, tt := range tests {
+		cfg := &Config{
+			Token:              "valid-token",
+			StationName:        "Test Station",
+			LogLevel:           "info",
+			WebPort:            "8080",
+			Sensors:            "temp",
+			TokenCheckInterval: tt.interval,
+		}
+		err := validateConfig(cfg)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.interval, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%q: expected %q error, got %v", tt.interval, tt.wantErr, err)
+		}
+	}
+}
+
+func TestValidateConfigPollIntervals(t *testing.T) {
+	tests := []struct {
+		obs, forecast, status string
+		wantErr               string
+	}{
+		{"60s", "30m", "15m", ""},
+		{"", "", "", ""},
+		{"15s", "5m", "5m", ""},
+		{"often", "30m", "15m", "invalid observation polling interval"},
+		{"5s", "30m", "15m", "observation polling interval 5s is too short"},
+		{"60s", "1m", "15m", "forecast polling interval 1m is too short"},
+		{"60s", "30m", "-1m", "invalid status polling interval"},
+	}
+	for _, tt := range tests {
+		cfg := &Config{
+			Token:        "valid-token",
+			StationName:  "Test Station",
+			LogLevel:     "info",
+			WebPort:      "8080",
+			Sensors:      "temp",
+			PollObs:      tt.obs,
+			PollForecast: tt.forecast,
+			PollStatus:   tt.status,
+		}
+		err := validateConfig(cfg)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tt, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%+v: expected %q error, got %v", tt, tt.wantErr, err)
+		}
+	}
+}
+
+func TestValidateConfigBackfillGap(t *testing.T) {
+	for gap, wantErr := range map[string]string{
+		"":     "",
+		"0":    "",
+		"5m":   "",
+		"soon": "invalid backfill gap",
+		"90s":  "too short",
+	} {
+		cfg := &Config{
+			Token:       "valid-token",
+			StationName: "Test Station",
+			LogLevel:    "info",
+			WebPort:     "8080",
+			Sensors:     "temp",
+			BackfillGap: gap,
+		}
+		err := validateConfig(cfg)
+		if wantErr == "" && err != nil {
+			t.Errorf("%q: unexpected error: %v", gap, err)
+		}
+		if wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)) {
+			t.Errorf("%q: expected %q error, got %v", gap, wantErr, err)
+		}
+	}
+}
+
+func TestValidateConfigGustHold(t *testing.T) {
+	for hold, wantErr := range map[string]string{
+		"":    "",
+		"0":   "",
+		"10m": "",
+		"-1m": "invalid gust hold",
+		"2h":  "too long",
+	} {
+		cfg := &Config{
+			Token:       "valid-token",
+			StationName: "Test Station",
+			LogLevel:    "info",
+			WebPort:     "8080",
+			Sensors:     "temp",
+			GustHold:    hold,
+		}
+		err := validateConfig(cfg)
+		if wantErr == "" && err != nil {
+			t.Errorf("%q: unexpected error: %v", hold, err)
+		}
+		if wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)) {
+			t.Errorf("%q: expected %q error, got %v", hold, wantErr, err)
+		}
+	}
+}
+
+func TestValidateConfigLuxSmoothing(t *testing.T) {
+	for window, wantErr := range map[string]string{
+		"":     "",
+		"0":    "",
+		"5m":   "",
+		"soon": "invalid lux smoothing",
+		"90m":  "too long",
+	} {
+		cfg := &Config{
+			Token:        "valid-token",
+			StationName:  "Test Station",
+			LogLevel:     "info",
+			WebPort:      "8080",
+			Sensors:      "temp",
+			LuxSmoothing: window,
+		}
+		err := validateConfig(cfg)
+		if wantErr == "" && err != nil {
+			t.Errorf("%q: unexpected error: %v", window, err)
+		}
+		if wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)) {
+			t.Errorf("%q: expected %q error, got %v", window, wantErr, err)
+		}
+	}
+}
+
+func TestValidateConfigPressureContexts(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{"defaults", Config{}, ""},
+		{"inHg everywhere", Config{UnitsPressureHomeKit: "inHg", UnitsPressureNotify: "inHg", UnitsPressureExport: "inHg"}, ""},
+		{"homekit", Config{UnitsPressureHomeKit: "psi"}, "invalid HomeKit pressure units"},
+		{"notifications", Config{UnitsPressureNotify: "inhg"}, "invalid notification pressure units"},
+		{"export", Config{UnitsPressureExport: "hPa"}, "invalid export pressure units"},
+	}
+	for _, tt := range tests {
+		cfg := tt.cfg
+		cfg.Token, cfg.StationName, cfg.LogLevel, cfg.WebPort, cfg.Sensors = "valid-token", "Test Station", "info", "8080", "temp"
+		err := validateConfig(&cfg)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: expected %q error, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestValidateConfigUDPMergeAPI(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{"udp with token and station", Config{UDPStream: true, Token: "t", StationName: "s"}, ""},
+		{"without udp stream", Config{Token: "t", StationName: "s"}, "requires --udp-stream"},
+		{"without token", Config{UDPStream: true, StationName: "s"}, "requires --token and --station"},
+		{"offline", Config{UDPStream: true, Token: "t", StationName: "s", DisableInternet: true}, "cannot be used with --disable-internet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.UDPMergeAPI = true
+			cfg.LogLevel, cfg.WebPort, cfg.Sensors = "info", "8080", "temp"
+			err := validateConfig(&cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigRainCorrection(t *testing.T) {
+	for factor, wantErr := range map[string]string{
+		"":     "",
+		"1":    "",
+		"1.15": "",
+		"0.8":  "",
+		"lots": "invalid rain correction",
+		"0":    "out of range",
+		"12":   "out of range",
+	} {
+		cfg := &Config{
+			Token:          "valid-token",
+			StationName:    "Test Station",
+			LogLevel:       "info",
+			WebPort:        "8080",
+			Sensors:        "temp",
+			RainCorrection: factor,
+		}
+		err := validateConfig(cfg)
+		if wantErr == "" && err != nil {
+			t.Errorf("%q: unexpected error: %v", factor, err)
+		}
+		if wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)) {
+			t.Errorf("%q: expected %q error, got %v", factor, wantErr, err)
+		}
+	}
+}
+
+func TestValidateConfigSnowRatio(t *testing.T) {
+	for ratio, wantErr := range map[string]string{
+		"":     "",
+		"10":   "",
+		"12.5": "",
+		"deep": "invalid snow ratio",
+		"1":    "out of range",
+		"50":   "out of range",
+	} {
+		cfg := &Config{
+			Token:       "valid-token",
+			StationName: "Test Station",
+			LogLevel:    "info",
+			WebPort:     "8080",
+			Sensors:     "temp",
+			SnowRatio:   ratio,
+		}
+		err := validateConfig(cfg)
+		if wantErr == "" && err != nil {
+			t.Errorf("%q: unexpected error: %v", ratio, err)
+		}
+		if wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)) {
+			t.Errorf("%q: expected %q error, got %v", ratio, wantErr, err)
+		}
+	}
+}
+
+func TestValidateConfigMQTTTopic(t *testing.T) {
+	for topic, wantErr := range map[string]string{
+		"tempest":         "",
+		"home/backyard":   "",
+		"":                "invalid MQTT topic",
+		"tempest/#":       "invalid MQTT topic",
+		"tempest/+/stats": "invalid MQTT topic",
+	} {
+		cfg := &Config{
+			Token:       "valid-token",
+			StationName: "Test Station",
+			LogLevel:    "info",
+			WebPort:     "8080",
+			Sensors:     "temp",
+			MQTTBroker:  "tcp://localhost:1883",
+			MQTTTopic:   topic,
+		}
+		err := validateConfig(cfg)
+		if wantErr == "" && err != nil {
+			t.Errorf("%q: unexpected error: %v", topic, err)
+		}
+		if wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)) {
+			t.Errorf("%q: expected %q error, got %v", topic, wantErr, err)
+		}
+	}
+}
+
+func TestValidateConfigGrowingSeason(t *testing.T) {
+	tests := []struct {
+		base, gddStart, chillStart string
+		wantErr                    string
+	}{
+		{"10", "01-01", "10-01", ""},
+		{"50F", "03-15", "11-01", ""},
+		{"4.5c", "", "", ""},
+		{"warm", "01-01", "10-01", "invalid GDD base"},
+		{"10", "13-01", "10-01", "invalid GDD season start"},
+		{"10", "01-01", "Oct 1", "invalid chill season start"},
+	}
+	for _, tt := range tests {
+		cfg := &Config{
+			Token:            "valid-token",
+			StationName:      "Test Station",
+			LogLevel:         "info",
+			WebPort:          "8080",
+			Sensors:          "temp",
+			GDDBase:          tt.base,
+			GDDSeasonStart:   tt.gddStart,
+			ChillSeasonStart: tt.chillStart,
+		}
+		err := validateConfig(cfg)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tt, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%+v: expected %q error, got %v", tt, tt.wantErr, err)
+		}
+	}
+
+	if base, fahrenheit, err := ParseGDDBase("50F"); err != nil || base != 50 || !fahrenheit {
+		t.Errorf("ParseGDDBase(50F) = %v, %v, %v", base, fahrenheit, err)
+	}
+}
+
+func TestValidateConfigAnomalyThreshold(t *testing.T) {
+	for threshold, wantErr := range map[string]string{
+		"":     "",
+		"4":    "",
+		"3.5":  "",
+		"high": "invalid anomaly threshold",
+		"1":    "out of range",
+		"12":   "out of range",
+	} {
+		cfg := &Config{
+			Token:            "valid-token",
+			StationName:      "Test Station",
+			LogLevel:         "info",
+			WebPort:          "8080",
+			Sensors:          "temp",
+			AnomalyThreshold: threshold,
+		}
+		err := validateConfig(cfg)
+		if wantErr == "" && err != nil {
+			t.Errorf("%q: unexpected error: %v", threshold, err)
+		}
+		if wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)) {
+			t.Errorf("%q: expected %q error, got %v", threshold, wantErr, err)
+		}
+	}
+}
+
+func TestValidateConfigWeatherFlowAPIURL(t *testing.T) {
+	for apiURL, wantErr := range map[string]string{
+		"":                                     "",
+		"http://localhost:8090/swd/rest":       "",
+		"https://swd.weatherflow.com/swd/rest": "",
+		"localhost:8090":                       "invalid WeatherFlow API URL",
+		"ftp://example.com/swd/rest":           "invalid WeatherFlow API URL",
+		"http:///swd/rest":                     "invalid WeatherFlow API URL",
+	} {
+		cfg := &Config{
+			Token:             "valid-token",
+			StationName:       "Test Station",
+			LogLevel:          "info",
+			WebPort:           "8080",
+			Sensors:           "temp",
+			WeatherFlowAPIURL: apiURL,
+		}
+		err := validateConfig(cfg)
+		if wantErr == "" && err != nil {
+			t.Errorf("%q: unexpected error: %v", apiURL, err)
+		}
+		if wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)) {
+			t.Errorf("%q: expected %q error, got %v", apiURL, wantErr, err)
+		}
+	}
+}
+
+func TestValidateConfigFailureInjection(t *testing.T) {
+	tests := []struct {
+		apiErrors, udpDrop, latency string
+		wantErr                     string
+	}{
+		{"", "", "", ""},
+		{"10%", "20", "2s", ""},
+		{"0.5%", "100%", "0", ""},
+		{"ten", "", "", "--inject-api-errors"},
+		{"", "150%", "", "--inject-udp-drop"},
+		{"", "-5", "", "--inject-udp-drop"},
+		{"", "", "2", "invalid injected latency"},
+		{"", "", "-1s", "invalid injected latency"},
+	}
+	for _, tt := range tests {
+		cfg := &Config{
+			Token:           "valid-token",
+			StationName:     "Test Station",
+			LogLevel:        "info",
+			WebPort:         "8080",
+			Sensors:         "temp",
+			InjectAPIErrors: tt.apiErrors,
+			InjectUDPDrop:   tt.udpDrop,
+			InjectLatency:   tt.latency,
+		}
+		err := validateConfig(cfg)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tt, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%+v: expected %q error, got %v", tt, tt.wantErr, err)
+		}
+	}
+
+	if rate, err := ParsePercent(" 12.5% "); err != nil || rate != 0.125 {
+		t.Errorf("ParsePercent(12.5%%) = %v, %v", rate, err)
+	}
+}
+
+func TestValidateConfigBenchWeb(t *testing.T) {
+	tests := []struct {
+		mix                   string
+		requests, concurrency int
+		wantErr               string
+	}{
+		{"status=3,history=1", 2000, 8, ""},
+		{"status, /api/history=2", 10, 1, ""},
+		{"", 10, 1, "--bench-web-mix"},
+		{"status=0", 10, 1, "--bench-web-mix"},
+		{"=2", 10, 1, "--bench-web-mix"},
+		{"status", 0, 1, "--bench-web-requests"},
+		{"status", 10, 0, "--bench-web-concurrency"},
+	}
+	for _, tt := range tests {
+		cfg := &Config{
+			Token:               "valid-token",
+			StationName:         "Test Station",
+			LogLevel:            "info",
+			WebPort:             "8080",
+			Sensors:             "temp",
+			BenchWeb:            true,
+			BenchWebMix:         tt.mix,
+			BenchWebRequests:    tt.requests,
+			BenchWebConcurrency: tt.concurrency,
+		}
+		err := validateConfig(cfg)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tt, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%+v: expected %q error, got %v", tt, tt.wantErr, err)
+		}
+	}
+
+	mix, err := ParseRequestMix("status, /api/history=2, status=2")
+	if err != nil || mix["/api/status"] != 3 || mix["/api/history"] != 2 || len(mix) != 2 {
+		t.Errorf("ParseRequestMix = %v, %v", mix, err)
+	}
+}
+
+// TestValidateConfigIngest tests the push ingestion mode requirements
+func TestValidateConfigIngest(t *testing.T) {
+	base := func() *Config {
+		return &Config{Ingest: true, IngestToken: "0123456789abcdef", StationName: "Garden", LogLevel: "info", WebPort: "8080", Sensors: "temp"}
+	}
+	if err := validateConfig(base()); err != nil {
+		t.Fatalf("ingest without a WeatherFlow token should be valid, got %v", err)
+	}
+	if cfg := base(); UsesWeatherFlowAPI(cfg) {
+		t.Error("ingest mode should not use the WeatherFlow API")
+	}
+
+	tests := map[string]func(*Config){
+		"short token":    func(c *Config) { c.IngestToken = "short" },
+		"with udp":       func(c *Config) { c.UDPStream = true },
+		"with url":       func(c *Config) { c.StationURL = "http://localhost/station/1" },
+		"without web":    func(c *Config) { c.DisableWebConsole = true },
+		"with generated": func(c *Config) { c.UseGeneratedWeather = true },
+	}
+	for name, mutate := range tests {
+		cfg := base()
+		mutate(cfg)
+		if err := validateConfig(cfg); err == nil || !strings.Contains(err.Error(), "ingest") {
+			t.Errorf("%s: expected an ingest error, got %v", name, err)
+		}
+	}
+}
+
+// TestValidateConfigEcowitt tests the Ecowitt listener requirements
+func TestValidateConfigEcowitt(t *testing.T) {
+	cfg := &Config{Ingest: true, EcowittPort: "8089", StationName: "Garden", LogLevel: "info", WebPort: "8080", Sensors: "temp"}
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("the Ecowitt listener should not need an ingest token, got %v", err)
+	}
+	cfg.EcowittPort = "http"
+	if err := validateConfig(cfg); err == nil {
+		t.Error("expected an invalid port error")
+	}
+	cfg.EcowittPort, cfg.Ingest, cfg.UDPStream = "8089", false, true
+	if err := validateConfig(cfg); err == nil || !strings.Contains(err.Error(), "requires --ingest") {
+		t.Errorf("expected --ingest to be required, got %v", err)
+	}
+}
+
+func TestValidateConfigTenants(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tenants.json")
+	if err := os.WriteFile(file, []byte(`{"tenants": []}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{Tenants: file, LogLevel: "info", WebPort: "8080", Sensors: "temp"}
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("a tenants host should not need a token or station, got %v", err)
+	}
+	cfg.UDPStream = true
+	if err := validateConfig(cfg); err == nil {
+		t.Error("expected a data source to be rejected with --tenants")
+	}
+	cfg.UDPStream, cfg.Tenants = false, filepath.Join(t.TempDir(), "missing.json")
+	if err := validateConfig(cfg); err == nil {
+		t.Error("expected a missing tenants file to be rejected")
+	}
+
+	single := &Config{UDPStream: true, StationName: "Garden", LogLevel: "info", WebPort: "8080", Sensors: "temp", WebBasePath: "/station/mom", HomeKitPort: "51830"}
+	if err := validateConfig(single); err != nil {
+		t.Fatalf("valid base path and HomeKit port rejected: %v", err)
+	}
+	single.WebBasePath = "/station/mom/"
+	if err := validateConfig(single); err == nil {
+		t.Error("expected a trailing slash in the base path to be rejected")
+	}
+	single.WebBasePath, single.HomeKitPort = "", "70000"
+	if err := validateConfig(single); err == nil {
+		t.Error("expected an out of range HomeKit port to be rejected")
+	}
+}
+
+func TestValidateConfigFederate(t *testing.T) {
+	cfg := &Config{UDPStream: true, StationName: "Home", LogLevel: "info", WebPort: "8080", Sensors: "temp",
+		Federate: "cabin=http://cabin.local:8080, beach=https://beach.example.com/", FederateInterval: "1m"}
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("valid federation rejected: %v", err)
+	}
+	for _, bad := range []string{"cabin", "Cabin House=http://x", "local=http://x", "a=http://x,a=http://y", "cabin=ftp://x"} {
+		cfg.Federate = bad
+		if err := validateConfig(cfg); err == nil {
+			t.Errorf("--federate %q accepted", bad)
+		}
+	}
+	cfg.Federate, cfg.FederateInterval = "cabin=http://cabin.local:8080", "5s"
+	if err := validateConfig(cfg); err == nil {
+		t.Error("expected a federate interval below 10s to be rejected")
+	}
+	cfg.FederateInterval, cfg.DisableWebConsole = "1m", true
+	if err := validateConfig(cfg); err == nil {
+		t.Error("expected --federate to be rejected without the web console")
+	}
+}
+
+func TestValidateConfigUDPSerial(t *testing.T) {
+	cfg := &Config{UDPStream: true, StationName: "Home", LogLevel: "info", WebPort: "8080", Sensors: "temp",
+		UDPSerial: "ST-00012345=Backyard, st-00054321"}
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("valid --udp-serial rejected: %v", err)
+	}
+	for _, bad := range []string{",", "ST-1,st-1", "ST 1=Roof", "=Roof"} {
+		cfg.UDPSerial = bad
+		if err := validateConfig(cfg); err == nil {
+			t.Errorf("--udp-serial %q accepted", bad)
+		}
+	}
+	cfg.UDPSerial, cfg.UDPStream, cfg.UseGeneratedWeather = "ST-00012345", false, true
+	if err := validateConfig(cfg); err == nil {
+		t.Error("expected --udp-serial to be rejected without --udp-stream")
+	}
+}
+
+func TestValidateConfigUDPRelay(t *testing.T) {
+	cfg := &Config{UDPRelay: "192.168.20.255:50222,10.0.0.5:50223", UDPPort: "50223", LogLevel: "info", WebPort: "8080", Sensors: "temp"}
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("a relay should not need a token or station, got %v", err)
+	}
+	if !cfg.RelayOnly() || UsesWeatherFlowAPI(cfg) {
+		t.Error("--udp-relay without --udp-stream should run only the relay")
+	}
+	for _, bad := range []string{"192.168.20.255", "host:0", ","} {
+		cfg.UDPRelay = bad
+		if err := validateConfig(cfg); err == nil {
+			t.Errorf("--udp-relay %q accepted", bad)
+		}
+	}
+	cfg.UDPRelay, cfg.UDPPort = "10.0.0.5:50222", "70000"
+	if err := validateConfig(cfg); err == nil {
+		t.Error("expected --udp-port 70000 to be rejected")
+	}
+}
+
+func TestValidateConfigUDPSocket(t *testing.T) {
+	cfg := &Config{UDPStream: true, StationName: "Home", LogLevel: "info", WebPort: "8080", Sensors: "temp",
+		UDPNetwork: "udp", UDPInterface: "eth0", UDPMulticast: "239.0.0.222, ff02::1"}
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("valid UDP socket options rejected: %v", err)
+	}
+	for _, tc := range []struct{ network, groups string }{
+		{"tcp", ""},
+		{"udp", "192.168.1.255"},
+		{"udp4", "ff02::1"},
+		{"udp6", "239.0.0.222"},
+	} {
+		cfg.UDPNetwork, cfg.UDPMulticast = tc.network, tc.groups
+		if err := validateConfig(cfg); err == nil {
+			t.Errorf("--udp-network %q --udp-multicast %q accepted", tc.network, tc.groups)
+		}
+	}
+}
+
+func TestValidateConfigBackupRestore(t *testing.T) {
+	cfg := &Config{Backup: filepath.Join(t.TempDir(), "state.tar.gz"), LogLevel: "info", WebPort: "8080", Sensors: "temp"}
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("--backup should not need a token or station, got %v", err)
+	}
+	cfg.Restore = cfg.Backup
+	if err := validateConfig(cfg); err == nil {
+		t.Error("expected --backup with --restore to be rejected")
+	}
+	cfg.Backup = ""
+	if err := validateConfig(cfg); err == nil {
+		t.Error("expected a missing restore archive to be rejected")
+	}
+}
+
+func TestValidateConfigUDPRawPackets(t *testing.T) {
+	cfg := &Config{UDPStream: true, StationName: "Home", LogLevel: "debug", WebPort: "8080", Sensors: "temp", UDPRawPackets: 100}
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("--udp-raw-packets 100 rejected: %v", err)
+	}
+	for _, bad := range []int{-1, 10001} {
+		cfg.UDPRawPackets = bad
+		if err := validateConfig(cfg); err == nil {
+			t.Errorf("--udp-raw-packets %d accepted", bad)
+		}
+	}
+}
+
+func TestValidateConfigHiddenCards(t *testing.T) {
+	cfg := &Config{Token: "t", StationName: "Home", LogLevel: "info", WebPort: "8080", Sensors: "temp", HiddenCards: "uv, Forecast"}
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("--hidden-cards uv,forecast rejected: %v", err)
+	}
+	for _, bad := range []string{"homekit", "dewpoint"} {
+		cfg.HiddenCards = bad
+		if err := validateConfig(cfg); err == nil {
+			t.Errorf("--hidden-cards %s accepted", bad)
+		}
+	}
+}
+
+func TestSensorConfigStringRoundTrip(t *testing.T) {
+	for _, list := range []string{"all", "min", "temp,wind,comfort", "uvi,lux,diagnostics"} {
+		sensors := ParseSensorConfig(list)
+		if got := ParseSensorConfig(sensors.String()); got != sensors {
+			t.Errorf("%q: String() = %q parses back to %v", list, sensors.String(), got)
+		}
+	}
+	if got := ParseSensorConfig("uvi,temperature").String(); got != "temp,uv" {
+		t.Errorf("String() = %q, want temp,uv", got)
+	}
+}
+
+func TestValidateConfigSuggestions(t *testing.T) {
+	for _, tt := range []struct {
+		set  func(*Config)
+		want string
+	}{
+		{func(c *Config) { c.Units = "metrc" }, "Did you mean 'metric'?"},
+		{func(c *Config) { c.UnitsPressure = "mbar" }, "Did you mean 'mb'?"},
+		{func(c *Config) { c.LogLevel = "inf" }, "Did you mean 'info'?"},
+		{func(c *Config) { c.Sensors = "temp,humdity" }, "invalid sensor 'humdity'. Did you mean 'humidity'?"},
+		{func(c *Config) { c.HomeKitMode = "combine" }, "Did you mean 'combined'?"},
+	} {
+		cfg := &Config{Token: "t", StationName: "Home", LogLevel: "info", WebPort: "8080", Sensors: "temp"}
+		tt.set(cfg)
+		if err := validateConfig(cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("expected %q, got %v", tt.want, err)
+		}
+	}
+
+	// Nothing close enough: no suggestion
+	cfg := &Config{Token: "t", StationName: "Home", LogLevel: "info", WebPort: "8080", Sensors: "temp", Units: "kelvin"}
+	if err := validateConfig(cfg); err == nil || strings.Contains(err.Error(), "Did you mean") {
+		t.Errorf("unexpected suggestion: %v", err)
+	}
+}
+
+func TestValidateConfigPorts(t *testing.T) {
+	base := func() *Config {
+		return &Config{Token: "t", StationName: "Home", LogLevel: "info", WebPort: "8080", Sensors: "temp"}
+	}
+	for _, tt := range []struct {
+		set  func(*Config)
+		want string
+	}{
+		{func(c *Config) { c.WebPort = "0" }, "invalid web port"},
+		{func(c *Config) { c.WebPort = "70000" }, "invalid web port"},
+		{func(c *Config) { c.GRPCPort = "-1" }, "invalid gRPC port"},
+		{func(c *Config) { c.WebhookListenPort = "99999" }, "invalid webhook listen port"},
+		{func(c *Config) { c.AlarmsEditPort = "x" }, "invalid alarm editor port"},
+		{func(c *Config) { c.GRPCPort = "8080" }, "gRPC port 8080 conflicts with the web port"},
+		{func(c *Config) { c.HomeKitPort = "8080" }, "HomeKit port 8080 conflicts with the web port"},
+	} {
+		cfg := base()
+		tt.set(cfg)
+		if err := validateConfig(cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("expected %q, got %v", tt.want, err)
+		}
+	}
+
+	// The HomeKit port is free to match the web port when HomeKit is off
+	cfg := base()
+	cfg.HomeKitPort = "8080"
+	cfg.DisableHomeKit = true
+	if err := validateConfig(cfg); err != nil {
+		t.Errorf("HomeKit port ignored with --disable-homekit: %v", err)
+	}
+}
+
+func TestValidateConfigHistoryPointsMax(t *testing.T) {
+	cfg := &Config{Token: "t", StationName: "Home", LogLevel: "info", WebPort: "8080", Sensors: "temp", HistoryPoints: MaxHistoryPoints}
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("--history %d rejected: %v", MaxHistoryPoints, err)
+	}
+	cfg.HistoryPoints = MaxHistoryPoints + 1
+	if err := validateConfig(cfg); err == nil || !strings.Contains(err.Error(), "at most") {
+		t.Errorf("--history %d accepted: %v", cfg.HistoryPoints, err)
+	}
+}
+
+func TestValidateConfigPublicURL(t *testing.T) {
+	cfg := &Config{Token: "t", StationName: "Home", LogLevel: "info", WebPort: "8080", Sensors: "temp", PublicURL: "https://weather.example.com/tempest/"}
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("--public-url rejected: %v", err)
+	}
+	if got := PublicURL(cfg); got != "https://weather.example.com/tempest" {
+		t.Errorf("PublicURL = %q", got)
+	}
+	for _, bad := range []string{"weather.example.com", "ftp://weather.example.com", "https://", "https://weather.example.com/?x=1"} {
+		cfg.PublicURL = bad
+		if err := validateConfig(cfg); err == nil {
+			t.Errorf("--public-url %s accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ContainerDataDir is the data directory used when --data-dir is not set and
+// the directory exists, typically a mounted Docker volume
+const ContainerDataDir = "/data"
+
+// DataPaths are the state locations under a data directory
+type DataPaths struct {
+	Root          string // the data directory; empty keeps the legacy layout
+	HomeKitDB     string // HAP keys, pairings, accessory IDs and setup PIN
+	Logs          string // default directory for --log-file
+	AlarmVersions string // alarm configuration backups
+	StatsFile     string // daily statistics and seasonal totals (GDD, chill hours)
+	AlarmState    string // alarm cooldowns, trigger counts and change detection values
+	WebhookAlarms string // webhooks received by --webhook-listener, one JSON object per line
+	Maintenance   string // scheduled maintenance windows and past gaps
+	Annotations   string // notes on time ranges of the history
+	Corrections   string // audit trail of deleted and overwritten observations, one JSON object per line
+}
+
+// ResolveDataDir returns the data directory: --data-dir / DATA_DIR when set,
+// otherwise /data when it exists. Without either the legacy layout relative
+// to the working directory is kept (./db, backups next to the alarm file).
+func ResolveDataDir(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	if info, err := os.Stat(ContainerDataDir); err == nil && info.IsDir() {
+		return ContainerDataDir
+	}
+	return ""
+}
+
+// PrepareDataDir creates the state directories under root and checks that the
+// process can write to each of them, so a volume with the wrong owner fails
+// at startup instead of losing pairings later. An empty root returns the
+// legacy layout without checks.
+func PrepareDataDir(root string) (DataPaths, error) {
+	if root == "" {
+		return DataPaths{HomeKitDB: "./db", StatsFile: "stats.json", AlarmState: "alarm-state.json", WebhookAlarms: "webhook-alarms.jsonl", Maintenance: "maintenance.json", Annotations: "annotations.json", Corrections: "corrections.jsonl"}, nil
+	}
+	paths := DataPaths{
+		Root:          root,
+		HomeKitDB:     filepath.Join(root, "db"),
+		Logs:          filepath.Join(root, "logs"),
+		AlarmVersions: filepath.Join(root, "alarm-versions"),
+		StatsFile:     filepath.Join(root, "stats.json"),
+		AlarmState:    filepath.Join(root, "alarm-state.json"),
+		WebhookAlarms: filepath.Join(root, "webhook-alarms.jsonl"),
+		Maintenance:   filepath.Join(root, "maintenance.json"),
+		Annotations:   filepath.Join(root, "annotations.json"),
+		Corrections:   filepath.Join(root, "corrections.jsonl"),
+	}
+	for _, dir := range []string{paths.Root, paths.HomeKitDB, paths.Logs, paths.AlarmVersions} {
+		if err := checkWritableDir(dir); err != nil {
+			return DataPaths{}, err
+		}
+	}
+	return paths, nil
+}
+
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create data directory %s (running as uid %d): %w", dir, os.Getuid(), err)
+	}
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("data directory %s is not writable by uid %d, fix the volume owner or permissions: %w", dir, os.Getuid(), err)
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
+}
+
+// LogFilePath places a relative --log-file inside the logs directory of the
+// data directory; absolute paths and the legacy layout are left unchanged
+func (p DataPaths) LogFilePath(logFile string) string {
+	if logFile == "" || p.Root == "" || filepath.IsAbs(logFile) {
+		return logFile
+	}
+	return filepath.Join(p.Logs, logFile)
+}
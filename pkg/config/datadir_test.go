@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPrepareDataDir(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "data")
+	paths, err := PrepareDataDir(root)
+	if err != nil {
+		t.Fatalf("PrepareDataDir returned error: %v", err)
+	}
+	for _, dir := range []string{paths.HomeKitDB, paths.Logs, paths.AlarmVersions} {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			t.Errorf("expected directory %s to exist: %v", dir, err)
+		}
+	}
+	if paths.HomeKitDB != filepath.Join(root, "db") {
+		t.Errorf("HomeKitDB = %q", paths.HomeKitDB)
+	}
+	if paths.StatsFile != filepath.Join(root, "stats.json") {
+		t.Errorf("StatsFile = %q", paths.StatsFile)
+	}
+	if paths.AlarmState != filepath.Join(root, "alarm-state.json") {
+		t.Errorf("AlarmState = %q", paths.AlarmState)
+	}
+	if got := paths.LogFilePath("bridge.log"); got != filepath.Join(root, "logs", "bridge.log") {
+		t.Errorf("relative log file resolved to %q", got)
+	}
+	if got := paths.LogFilePath("/var/log/bridge.log"); got != "/var/log/bridge.log" {
+		t.Errorf("absolute log file changed to %q", got)
+	}
+}
+
+func TestPrepareDataDirLegacyLayout(t *testing.T) {
+	paths, err := PrepareDataDir("")
+	if err != nil || paths.HomeKitDB != "./db" || paths.AlarmVersions != "" {
+		t.Errorf("legacy layout = %+v, %v", paths, err)
+	}
+	if got := paths.LogFilePath("bridge.log"); got != "bridge.log" {
+		t.Errorf("legacy log file changed to %q", got)
+	}
+}
+
+func TestPrepareDataDirNotWritable(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+	root := t.TempDir()
+	if err := os.Chmod(root, 0555); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chmod(root, 0755) }()
+	if _, err := PrepareDataDir(root); err == nil {
+		t.Error("expected an error for a read-only data directory")
+	}
+}
+
+func TestResolveDataDir(t *testing.T) {
+	if got := ResolveDataDir("/srv/tempest"); got != "/srv/tempest" {
+		t.Errorf("ResolveDataDir kept %q", got)
+	}
+}
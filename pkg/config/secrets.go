@@ -0,0 +1,31 @@
+package config
+
+import (
+	"fmt"
+
+	"tempest-homekit-go/pkg/secrets"
+)
+
+// resolveSecrets replaces secret settings given as env:, file:, token_file:
+// or keychain: references with the values they point to
+func resolveSecrets(cfg *Config) error {
+	fields := []struct {
+		flag  string
+		value *string
+	}{
+		{"token", &cfg.Token},
+		{"mqtt-password", &cfg.MQTTPassword},
+		{"admin-password", &cfg.AdminPassword},
+		{"ingest-token", &cfg.IngestToken},
+		{"alarms-editor-password", &cfg.AlarmsEditorPassword},
+		{"webhook-listener-secret", &cfg.WebhookListenerSecret},
+	}
+	for _, f := range fields {
+		value, err := secrets.Resolve(*f.value)
+		if err != nil {
+			return fmt.Errorf("--%s: %w", f.flag, err)
+		}
+		*f.value = value
+	}
+	return nil
+}
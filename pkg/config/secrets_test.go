@@ -0,0 +1,32 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestResolveSecrets tests that secret settings can point at files and
+// environment variables
+func TestResolveSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_TEST_MQTT_PASSWORD", "mqtt-secret")
+
+	cfg := &Config{Token: "token_file:" + path, MQTTPassword: "env:CONFIG_TEST_MQTT_PASSWORD", AdminPassword: "plain"}
+	if err := resolveSecrets(cfg); err != nil {
+		t.Fatalf("resolveSecrets() error = %v", err)
+	}
+	if cfg.Token != "file-token" || cfg.MQTTPassword != "mqtt-secret" || cfg.AdminPassword != "plain" {
+		t.Errorf("resolved Token=%q MQTTPassword=%q AdminPassword=%q", cfg.Token, cfg.MQTTPassword, cfg.AdminPassword)
+	}
+
+	cfg = &Config{IngestToken: "env:CONFIG_TEST_UNSET_SECRET"}
+	err := resolveSecrets(cfg)
+	if err == nil || !strings.Contains(err.Error(), "--ingest-token") || !strings.Contains(err.Error(), "CONFIG_TEST_UNSET_SECRET") {
+		t.Errorf("resolveSecrets() error = %v, want it to name the flag and variable", err)
+	}
+}
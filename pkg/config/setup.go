@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// UsesWeatherFlowAPI reports whether observations come from the WeatherFlow
+// REST API rather than UDP, pushed observations, a custom station URL or
+// generated weather. A tenants host reads no observations itself.
+func UsesWeatherFlowAPI(cfg *Config) bool {
+	return cfg.StationURL == "" && !cfg.UseGeneratedWeather && !cfg.UDPStream && !cfg.Ingest && cfg.AlarmsEdit == "" && cfg.Tenants == "" && !cfg.maintenance() && !cfg.RelayOnly()
+}
+
+// RelayOnly reports whether the process only relays UDP broadcasts
+// (--udp-relay without --udp-stream) instead of running the bridge
+func (cfg *Config) RelayOnly() bool {
+	return cfg.UDPRelay != "" && !cfg.UDPStream
+}
+
+// maintenance reports whether a command that works on the stored state
+// (--backup, --restore, --encrypt-alarms) runs instead of the bridge
+func (cfg *Config) maintenance() bool {
+	return cfg.Backup != "" || cfg.Restore != "" || cfg.EncryptAlarms
+}
+
+// NeedsSetup reports whether the WeatherFlow API is the data source but the
+// token or station has not been configured yet
+func NeedsSetup(cfg *Config) bool {
+	return UsesWeatherFlowAPI(cfg) && (cfg.Token == "" || cfg.StationName == "")
+}
+
+// CompleteSetup validates the token, station, sensors and units chosen in the
+// setup wizard, looks up the station elevation and saves the choices to the
+// environment file so the next start skips the wizard. cfg is only changed
+// when everything succeeds.
+func CompleteSetup(cfg *Config, token, station, sensors, units, unitsPressure string) error {
+	next := *cfg
+	next.Token = strings.TrimSpace(token)
+	next.StationName = strings.TrimSpace(station)
+	next.Sensors = strings.TrimSpace(sensors)
+	next.Units = strings.TrimSpace(units)
+	next.UnitsPressure = strings.TrimSpace(unitsPressure)
+	next.SetupWizard = false
+	if err := validateConfig(&next); err != nil {
+		return err
+	}
+
+	envFile := next.EnvFile
+	if envFile == "" {
+		envFile = ".env"
+	}
+	if err := UpdateEnvFile(envFile, map[string]string{
+		"TEMPEST_TOKEN":        next.Token,
+		"TEMPEST_STATION_NAME": next.StationName,
+		"SENSORS":              next.Sensors,
+		"UNITS":                next.Units,
+		"UNITS_PRESSURE":       next.UnitsPressure,
+	}); err != nil {
+		return err
+	}
+
+	if elevation, err := lookupStationElevation(next.Token, next.StationName); err == nil {
+		next.Elevation = elevation
+	}
+	*cfg = next
+	return nil
+}
+
+// UpdateEnvFile sets KEY=VALUE lines in an environment file, replacing
+// existing assignments and appending new keys in sorted order. Comments and
+// other keys are kept. A missing file is created readable only by the owner
+// since it holds the API token.
+func UpdateEnvFile(path string, values map[string]string) error {
+	mode := os.FileMode(0600)
+	var lines []string
+	content, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		lines = strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+		if info, statErr := os.Stat(path); statErr == nil {
+			mode = info.Mode().Perm()
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("failed to read env file '%s': %w", path, err)
+	}
+
+	written := make(map[string]bool)
+	for i, line := range lines {
+		key, _, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "export "), "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if value, set := values[key]; set {
+			lines[i] = key + "=" + envQuote(value)
+			written[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if !written[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, key+"="+envQuote(values[key]))
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), mode); err != nil {
+		return fmt.Errorf("failed to write env file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// envQuote quotes values that godotenv would otherwise split or expand.
+// Single quotes are literal; values containing one fall back to escaped
+// double quotes.
+func envQuote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t#\"'$\\") {
+		return value
+	}
+	if !strings.Contains(value, "'") {
+		return "'" + value + "'"
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`).Replace(value) + `"`
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joho/godotenv"
+)
+
+func TestNeedsSetup(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want bool
+	}{
+		{"no token", Config{StationName: "Home"}, true},
+		{"no station", Config{Token: "abc"}, true},
+		{"configured", Config{Token: "abc", StationName: "Home"}, false},
+		{"udp stream", Config{UDPStream: true}, false},
+		{"generated weather", Config{UseGeneratedWeather: true}, false},
+		{"station url", Config{StationURL: "http://localhost/api"}, false},
+	}
+	for _, tt := range tests {
+		if got := NeedsSetup(&tt.cfg); got != tt.want {
+			t.Errorf("%s: NeedsSetup = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	original := "# Tempest settings\nTEMPEST_TOKEN=old\nLOG_LEVEL=debug\n"
+	if err := os.WriteFile(path, []byte(original), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	err := UpdateEnvFile(path, map[string]string{
+		"TEMPEST_TOKEN":        "new-token",
+		"TEMPEST_STATION_NAME": "Joe's Station #2",
+		"UNITS":                "metric",
+	})
+	if err != nil {
+		t.Fatalf("UpdateEnvFile returned error: %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(content), "# Tempest settings\nTEMPEST_TOKEN=new-token\nLOG_LEVEL=debug\n") {
+		t.Errorf("existing lines not updated in place:\n%s", content)
+	}
+	env, err := godotenv.Read(path)
+	if err != nil {
+		t.Fatalf("godotenv could not read the result: %v", err)
+	}
+	if env["TEMPEST_STATION_NAME"] != "Joe's Station #2" || env["UNITS"] != "metric" || env["LOG_LEVEL"] != "debug" {
+		t.Errorf("unexpected env after update: %v", env)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+		t.Errorf("file mode changed to %v", info.Mode().Perm())
+	}
+}
+
+func TestUpdateEnvFileCreatesPrivateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := UpdateEnvFile(path, map[string]string{"TEMPEST_TOKEN": "abc"}); err != nil {
+		t.Fatalf("UpdateEnvFile returned error: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("new env file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+}
+
+func TestCompleteSetupRejectsInvalidChoices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	cfg := &Config{LogLevel: "info", EnvFile: path, SetupWizard: true}
+
+	if err := CompleteSetup(cfg, "abc", "Home", "temp,sprinkler", "imperial", "inHg"); err == nil {
+		t.Fatal("expected an error for an unknown sensor")
+	}
+	if cfg.Token != "" || !cfg.SetupWizard {
+		t.Errorf("config changed after a failed setup: %+v", cfg)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("env file written after a failed setup")
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// didYouMean suggests the option closest to a mistyped value, such as
+// "metrc" for "metric", or returns "" when none is within two edits
+func didYouMean(value string, options []string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	best, bestDistance := "", 3
+	for _, option := range options {
+		if d := editDistance(value, strings.ToLower(option)); d < bestDistance {
+			best, bestDistance = option, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" Did you mean '%s'?", best)
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// checkPort validates a port flag
+func checkPort(name, value string) error {
+	if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("invalid %s '%s'. Port must be a number between 1 and 65535", name, value)
+	}
+	return nil
+}
+
+// checkPortConflicts reports two listeners of the same process configured on
+// one TCP port, which would otherwise fail when the second one starts
+func checkPortConflicts(cfg *Config) error {
+	type listener struct{ name, port string }
+	var listeners []listener
+	if !cfg.DisableWebConsole {
+		listeners = append(listeners, listener{"web", cfg.WebPort})
+	}
+	if !cfg.DisableHomeKit && cfg.HomeKitPort != "" {
+		listeners = append(listeners, listener{"HomeKit", cfg.HomeKitPort})
+	}
+	if cfg.GRPCPort != "" {
+		listeners = append(listeners, listener{"gRPC", cfg.GRPCPort})
+	}
+	if cfg.EcowittPort != "" {
+		listeners = append(listeners, listener{"Ecowitt listener", cfg.EcowittPort})
+	}
+	for i, a := range listeners {
+		for _, b := range listeners[:i] {
+			if a.port == b.port {
+				return fmt.Errorf("%s port %s conflicts with the %s port. Give each listener its own port", a.name, a.port, b.name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+// Package corrections records deletions and overwrites of stored observations,
+// such as the 60 °C spike of a station left in a car, as an audit trail. The
+// trail is replayed on history that is loaded again (preload, outage
+// backfill), so a corrected reading does not come back.
+package corrections
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// StateFile is the audit trail, one JSON object per line; empty keeps it in
+// memory only. main sets it from the data directory.
+var StateFile string
+
+// MaxReasonLength is the longest reason kept with a correction, in bytes
+const MaxReasonLength = 500
+
+// Actions of an Entry
+const (
+	ActionDelete    = "delete"
+	ActionOverwrite = "overwrite"
+)
+
+// Entry is one correction in the audit trail
+type Entry struct {
+	Time      time.Time            `json:"time"`
+	Action    string               `json:"action"`    // delete or overwrite
+	Timestamp int64                `json:"timestamp"` // of the corrected observation, Unix seconds
+	Fields    map[string]float64   `json:"fields,omitempty"`
+	Before    weather.Observation  `json:"before"`
+	After     *weather.Observation `json:"after,omitempty"` // nil for a deletion
+	Reason    string               `json:"reason,omitempty"`
+	By        string               `json:"by,omitempty"` // who made the correction, e.g. the request's address
+}
+
+// Log is the audit trail of corrections, oldest first
+type Log struct {
+	mu      sync.RWMutex
+	entries []Entry
+	latest  map[int64]int // index of the newest entry per corrected timestamp
+	now     func() time.Time
+}
+
+// New returns the log with the corrections saved in StateFile
+func New() *Log {
+	l := &Log{latest: map[int64]int{}, now: time.Now}
+	l.load()
+	return l
+}
+
+// Overwrite returns obs with fields replaced. Field names are the
+// observation's JSON names (air_temperature, wind_gust, ...); the timestamp
+// cannot be changed.
+func Overwrite(obs weather.Observation, fields map[string]float64) (weather.Observation, error) {
+	if len(fields) == 0 {
+		return obs, fmt.Errorf("give at least one field to overwrite")
+	}
+	data, err := json.Marshal(obs)
+	if err != nil {
+		return obs, err
+	}
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return obs, err
+	}
+	for name, value := range fields {
+		if name == "timestamp" || !observationFields[name] {
+			return obs, fmt.Errorf("unknown observation field '%s'", name)
+		}
+		values[name] = value
+	}
+	data, _ = json.Marshal(values)
+	var out weather.Observation
+	if err := json.Unmarshal(data, &out); err != nil {
+		return obs, fmt.Errorf("invalid field value: %v", err)
+	}
+	return out, nil
+}
+
+// observationFields holds the JSON names of the observation fields. The
+// omitempty ones are set so they are listed too.
+var observationFields = func() map[string]bool {
+	data, _ := json.Marshal(weather.Observation{
+		RainAccumulatedRaw: 1, RainDailyTotalRaw: 1, NCRainAccumulated: 1, NCRainDailyTotal: 1,
+		SnowRate: 1, SnowDailyTotal: 1, LightningNearest: 1, LightningRate: 1,
+	})
+	values := map[string]interface{}{}
+	_ = json.Unmarshal(data, &values)
+	names := map[string]bool{}
+	for name := range values {
+		names[name] = true
+	}
+	return names
+}()
+
+// Record adds a correction of before to the trail: a deletion when after is
+// nil, otherwise an overwrite with fields
+func (l *Log) Record(before weather.Observation, after *weather.Observation, fields map[string]float64, reason, by string) Entry {
+	reason = strings.TrimSpace(reason)
+	if len(reason) > MaxReasonLength {
+		reason = reason[:MaxReasonLength]
+	}
+	e := Entry{Action: ActionDelete, Timestamp: before.Timestamp, Before: before, Reason: reason, By: by}
+	if after != nil {
+		e.Action, e.Fields, e.After = ActionOverwrite, fields, after
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e.Time = l.now().UTC().Truncate(time.Second)
+	l.latest[e.Timestamp] = len(l.entries)
+	l.entries = append(l.entries, e)
+	l.appendLocked(e)
+	logger.Info("Correction: %s of the observation at %s by %s (reason: %q)", e.Action, time.Unix(e.Timestamp, 0).UTC().Format(time.RFC3339), by, reason)
+	return e
+}
+
+// Apply returns obs as corrected: nil when its reading was deleted, obs with
+// the overwritten fields replaced, or obs itself when it was not corrected
+func (l *Log) Apply(obs *weather.Observation) *weather.Observation {
+	if l == nil || obs == nil {
+		return obs
+	}
+	l.mu.RLock()
+	i, ok := l.latest[obs.Timestamp]
+	var e Entry
+	if ok {
+		e = l.entries[i]
+	}
+	l.mu.RUnlock()
+	if !ok {
+		return obs
+	}
+	if e.Action == ActionDelete {
+		return nil
+	}
+	corrected, err := Overwrite(*obs, e.Fields)
+	if err != nil {
+		return obs
+	}
+	return &corrected
+}
+
+// Entries returns up to limit corrections, newest first; limit 0 returns all
+func (l *Log) Entries(limit int) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	n := len(l.entries)
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	out := make([]Entry, 0, n)
+	for i := len(l.entries) - 1; i >= 0 && len(out) < n; i-- {
+		out = append(out, l.entries[i])
+	}
+	return out
+}
+
+// appendLocked writes e to StateFile; the caller holds l.mu
+func (l *Log) appendLocked(e Entry) {
+	if StateFile == "" {
+		return
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(StateFile), 0755); err != nil {
+		logger.Warn("Failed to save correction: %v", err)
+		return
+	}
+	f, err := os.OpenFile(StateFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Warn("Failed to save correction: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		logger.Warn("Failed to save correction: %v", err)
+	}
+}
+
+func (l *Log) load() {
+	if StateFile == "" {
+		return
+	}
+	data, err := os.ReadFile(StateFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("Failed to read corrections: %v", err)
+		}
+		return
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	lines := 0
+	for scanner.Scan() {
+		lines++
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			logger.Warn("Skipping unreadable line %d of %s: %v", lines, StateFile, err)
+			continue
+		}
+		l.latest[e.Timestamp] = len(l.entries)
+		l.entries = append(l.entries, e)
+	}
+}
@@ -0,0 +1,64 @@
+package corrections
+
+import (
+	"path/filepath"
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestOverwrite(t *testing.T) {
+	obs := weather.Observation{Timestamp: 1748880000, AirTemperature: 60, UV: 3}
+	out, err := Overwrite(obs, map[string]float64{"air_temperature": 24.1, "snow_rate": 0.5})
+	if err != nil || out.AirTemperature != 24.1 || out.SnowRate != 0.5 || out.UV != 3 || out.Timestamp != obs.Timestamp {
+		t.Errorf("Overwrite = %+v, %v", out, err)
+	}
+	for name, fields := range map[string]map[string]float64{
+		"none":      {},
+		"unknown":   {"temperature": 1},
+		"timestamp": {"timestamp": 1},
+		"not int":   {"uv": 2.5},
+	} {
+		if _, err := Overwrite(obs, fields); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
+
+func TestLog(t *testing.T) {
+	StateFile = filepath.Join(t.TempDir(), "corrections.jsonl")
+	t.Cleanup(func() { StateFile = "" })
+	l := New()
+
+	spike := weather.Observation{Timestamp: 100, AirTemperature: 60}
+	gust := weather.Observation{Timestamp: 200, WindGust: 90}
+	l.Record(spike, nil, nil, " station sat in a car ", "192.0.2.1:5000")
+	fixed, _ := Overwrite(gust, map[string]float64{"wind_gust": 9})
+	if e := l.Record(gust, &fixed, map[string]float64{"wind_gust": 9}, "", "192.0.2.1:5000"); e.Action != ActionOverwrite || e.After.WindGust != 9 {
+		t.Errorf("Record overwrite = %+v", e)
+	}
+
+	// Both survive a restart and apply to the readings loaded again
+	restored := New()
+	entries := restored.Entries(0)
+	if len(entries) != 2 || entries[0].Timestamp != 200 || entries[1].Reason != "station sat in a car" || entries[1].Before.AirTemperature != 60 {
+		t.Fatalf("Entries = %+v", entries)
+	}
+	if got := restored.Entries(1); len(got) != 1 {
+		t.Errorf("Entries(1) = %d entries", len(got))
+	}
+	if restored.Apply(&spike) != nil {
+		t.Error("deleted reading kept")
+	}
+	if got := restored.Apply(&gust); got == nil || got.WindGust != 9 {
+		t.Errorf("overwritten reading = %+v", got)
+	}
+	other := &weather.Observation{Timestamp: 300}
+	if restored.Apply(other) != other {
+		t.Error("uncorrected reading changed")
+	}
+	var none *Log
+	if none.Apply(other) != other {
+		t.Error("nil log changed a reading")
+	}
+}
@@ -0,0 +1,285 @@
+package diagnostics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/homekit"
+	"tempest-homekit-go/pkg/service"
+	"tempest-homekit-go/pkg/udp"
+	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/web"
+)
+
+// Options tunes Run
+type Options struct {
+	Version    string
+	UDPListen  time.Duration // how long to wait for UDP broadcasts (default 30s)
+	Progress   io.Writer     // receives a line as each check starts; may be nil
+	WebPaths   []string      // endpoints for the web check (default web.SelfTestPaths)
+	SkipUDP    bool
+	SkipAPI    bool
+	SkipAlarms bool // do not send test notifications
+}
+
+// Run runs every check that applies to cfg. Checks that are not configured
+// are reported as skipped rather than failed, so the report shows what was
+// and was not exercised.
+func Run(cfg *config.Config, opts Options) *Report {
+	if opts.UDPListen <= 0 {
+		opts.UDPListen = 30 * time.Second
+	}
+	if opts.WebPaths == nil {
+		opts.WebPaths = web.SelfTestPaths
+	}
+	report := &Report{Title: "diagnostics", Version: opts.Version, Started: time.Now(), Settings: settings(cfg)}
+	progress := func(name string) {
+		if opts.Progress != nil {
+			_, _ = fmt.Fprintf(opts.Progress, "Running %s check...\n", name)
+		}
+	}
+
+	progress("WeatherFlow API")
+	checkAPI(report, cfg, opts.SkipAPI)
+	progress("UDP")
+	checkUDP(report, cfg, opts.UDPListen, opts.SkipUDP)
+	progress("HomeKit")
+	checkHomeKit(report, cfg)
+	progress("notification")
+	checkNotifications(report, cfg, opts.SkipAlarms)
+	progress("web server")
+	checkWeb(report, opts.WebPaths)
+	return report
+}
+
+// settings summarizes the configuration without the token or passwords
+func settings(cfg *config.Config) []string {
+	source := "WeatherFlow API"
+	switch {
+	case cfg.UseGeneratedWeather:
+		source = "generated weather"
+	case cfg.StationURL != "":
+		source = "station URL"
+	case cfg.UDPStream:
+		source = "UDP broadcasts"
+	}
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+	alarms := "none"
+	if cfg.Alarms != "" && !cfg.DisableAlarms {
+		alarms = "configured"
+	}
+	return []string{
+		"Station:          " + cfg.StationName,
+		"Data source:      " + source,
+		"Token set:        " + yesNo(cfg.Token != ""),
+		"Internet:         " + yesNo(!cfg.DisableInternet),
+		"HomeKit:          " + yesNo(!cfg.DisableHomeKit) + " (sensors " + cfg.Sensors + ")",
+		"Web console:      " + yesNo(!cfg.DisableWebConsole) + " (port " + cfg.WebPort + ")",
+		"Alarms:           " + alarms,
+	}
+}
+
+// redact removes the API token from error text
+func redact(cfg *config.Config, err error) string {
+	if cfg.Token == "" {
+		return err.Error()
+	}
+	return strings.ReplaceAll(err.Error(), cfg.Token, "<token>")
+}
+
+// checkAPI looks up the configured station and fetches its latest observation
+func checkAPI(report *Report, cfg *config.Config, skip bool) {
+	const name = "WeatherFlow API"
+	start := time.Now()
+	switch {
+	case skip:
+		report.add(name, Skip, start, "skipped")
+		return
+	case cfg.DisableInternet:
+		report.add(name, Skip, start, "--disable-internet is set")
+		return
+	case cfg.Token == "":
+		report.add(name, Skip, start, "no token configured")
+		return
+	}
+
+	stations, err := weather.GetStations(cfg.Token)
+	if err != nil {
+		report.add(name, Fail, start, "listing stations: %s", redact(cfg, err))
+		return
+	}
+	station := weather.FindStationByName(stations, cfg.StationName)
+	if station == nil {
+		report.add(name, Fail, start, "station %q not found among %d station(s) for this token", cfg.StationName, len(stations))
+		return
+	}
+	obs, err := weather.GetObservation(station.StationID, cfg.Token)
+	if err != nil {
+		report.add(name, Fail, start, "observation for station %d: %s", station.StationID, redact(cfg, err))
+		return
+	}
+	age := time.Since(time.Unix(obs.Timestamp, 0)).Round(time.Second)
+	if age > 15*time.Minute {
+		report.add(name, Warn, start, "station %d (%s): latest observation is %v old", station.StationID, station.Name, age)
+		return
+	}
+	report.add(name, Pass, start, "station %d (%s): observation %v old, %.1f°C", station.StationID, station.Name, age, obs.AirTemperature)
+}
+
+// checkUDP listens for broadcasts. Hearing nothing fails only when the
+// configuration depends on UDP.
+func checkUDP(report *Report, cfg *config.Config, listen time.Duration, skip bool) {
+	const name = "UDP broadcasts"
+	start := time.Now()
+	if skip {
+		report.add(name, Skip, start, "skipped")
+		return
+	}
+	port := udp.UDPPort
+	if p, err := strconv.Atoi(cfg.UDPPort); err == nil {
+		port = p
+	}
+
+	listener := udp.NewUDPListener(100)
+	service.ConfigureUDPListener(cfg, listener, nil)
+	if err := listener.Start(); err != nil {
+		report.add(name, Fail, start, "listening on port %d: %v", port, err)
+		return
+	}
+	time.Sleep(listen)
+	packets, _, stationIP, serial := listener.GetStats()
+	parser := listener.GetParserStats()
+	_ = listener.Stop()
+
+	switch {
+	case packets == 0 && cfg.UDPStream:
+		report.add(name, Fail, start, "no packets on port %d in %v (check the network and firewall)", port, listen)
+	case packets == 0:
+		report.add(name, Warn, start, "no packets on port %d in %v (not needed unless --udp-stream is used)", port, listen)
+	case parser.Errors > 0 || len(parser.UnknownTypes) > 0:
+		report.add(name, Warn, start, "%d packets from %s (%s), %d failed to decode, %d unknown message type(s)", packets, stationIP, serial, parser.Errors, len(parser.UnknownTypes))
+	default:
+		report.add(name, Pass, start, "%d packets from %s (%s) on port %d", packets, stationIP, serial, port)
+	}
+}
+
+// checkHomeKit pairs with a throwaway bridge, as --test-homekit does
+func checkHomeKit(report *Report, cfg *config.Config) {
+	const name = "HomeKit pairing"
+	start := time.Now()
+	if cfg.DisableHomeKit {
+		report.add(name, Skip, start, "--disable-homekit is set")
+		return
+	}
+	sensorConfig := config.ParseSensorConfig(cfg.Sensors)
+	names, _ := config.ParseHomeKitNames(cfg.HomeKitNames) // validated with the rest of the config
+	result := homekit.PairingSelfTest(cfg.Pin, &sensorConfig, homekit.Options{
+		Mode: cfg.HomeKitMode,
+		Naming: homekit.AccessoryNaming{
+			Names:         names,
+			NamePattern:   cfg.HomeKitNamePattern,
+			SerialPattern: cfg.HomeKitSerialPattern,
+			Station:       cfg.StationName,
+		},
+		PressureUnit: cfg.UnitsPressureHomeKit,
+	})
+	if !result.Passed() {
+		for _, step := range result.Steps {
+			if step.Err != nil {
+				report.add(name, Fail, start, "%s: %v", step.Name, step.Err)
+				return
+			}
+		}
+	}
+	report.add(name, Pass, start, "%d steps passed on a test bridge (existing pairings untouched)", len(result.Steps))
+}
+
+// checkNotifications sends one test notification through the first channel
+// of each type in the alarm configuration, to that channel's recipients
+func checkNotifications(report *Report, cfg *config.Config, skip bool) {
+	start := time.Now()
+	switch {
+	case skip:
+		report.add("Notifications", Skip, start, "skipped")
+		return
+	case cfg.Alarms == "" || cfg.DisableAlarms:
+		report.add("Notifications", Skip, start, "no alarms configured")
+		return
+	}
+	alarmConfig, err := alarm.LoadAlarmConfig(cfg.Alarms)
+	if err != nil {
+		report.add("Notifications", Fail, start, "loading alarms: %v", err)
+		return
+	}
+
+	channels := map[string]alarm.Channel{}
+	for _, a := range alarmConfig.Alarms {
+		for _, ch := range a.Channels {
+			if _, seen := channels[ch.Type]; !seen {
+				channels[ch.Type] = ch
+			}
+		}
+	}
+	if len(channels) == 0 {
+		report.add("Notifications", Skip, start, "no channels in %d alarm(s)", len(alarmConfig.Alarms))
+		return
+	}
+	types := make([]string, 0, len(channels))
+	for t := range channels {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	factory := alarm.NewNotifierFactory(alarmConfig)
+	testAlarm := &alarm.Alarm{Name: "Diagnostics Test", Description: "Test notification from --test-all", Enabled: true}
+	obs := &weather.Observation{Timestamp: time.Now().Unix(), AirTemperature: 20, RelativeHumidity: 50, WindAvg: 5, StationPressure: 1013.25}
+	for _, t := range types {
+		name := "Notification: " + t
+		start := time.Now()
+		channel := channels[t]
+		channel.Template = "🔔 TEST NOTIFICATION from Tempest HomeKit Go ({{station}}, {{timestamp}}). Sent by --test-all; no action is needed."
+		notifier, err := factory.GetNotifier(t)
+		if err != nil {
+			report.add(name, Fail, start, "%v", err)
+			continue
+		}
+		if err := notifier.Send(testAlarm, &channel, obs, cfg.StationName); err != nil {
+			report.add(name, Fail, start, "%v", err)
+			continue
+		}
+		report.add(name, Pass, start, "test notification sent")
+	}
+}
+
+// checkWeb serves the dashboard on a loopback port and requests each path
+func checkWeb(report *Report, paths []string) {
+	const name = "Web server"
+	start := time.Now()
+	checks, err := web.SelfTest(paths)
+	if err != nil {
+		report.add(name, Fail, start, "%v", err)
+		return
+	}
+	var failed []string
+	for _, c := range checks {
+		if c.Err != nil {
+			failed = append(failed, c.Path+": "+c.Err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		report.add(name, Fail, start, "%d of %d endpoints failed: %s", len(failed), len(checks), strings.Join(failed, "; "))
+		return
+	}
+	report.add(name, Pass, start, "%d endpoints returned 200", len(checks))
+}
@@ -0,0 +1,103 @@
+// Package diagnostics runs every configured self-test (WeatherFlow API, UDP,
+// HomeKit, notification channels and the web server) and collects the
+// results into one report for --test-all. Doctor checks the host
+// environment the same way for --doctor.
+package diagnostics
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	Pass Status = "PASS"
+	Fail Status = "FAIL"
+	Warn Status = "WARN" // worked, but something looks wrong
+	Skip Status = "SKIP" // not configured
+)
+
+// Check is one line of the report
+type Check struct {
+	Name     string
+	Status   Status
+	Detail   string
+	Fix      string // what to do about a failure or warning
+	Duration time.Duration
+}
+
+// Report is the result of Run or Doctor
+type Report struct {
+	Title    string // "diagnostics" or "doctor"
+	Version  string
+	Started  time.Time
+	Settings []string // configuration summary, printed above the checks
+	Checks   []Check
+}
+
+// add records a check
+func (r *Report) add(name string, status Status, start time.Time, format string, args ...interface{}) {
+	r.Checks = append(r.Checks, Check{Name: name, Status: status, Detail: fmt.Sprintf(format, args...), Duration: time.Since(start)})
+}
+
+// fix attaches a remediation step to the last check
+func (r *Report) fix(format string, args ...interface{}) {
+	r.Checks[len(r.Checks)-1].Fix = fmt.Sprintf(format, args...)
+}
+
+// Count returns how many checks ended with status
+func (r *Report) Count(status Status) int {
+	n := 0
+	for _, c := range r.Checks {
+		if c.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed reports whether any check failed
+func (r *Report) Failed() bool {
+	return r.Count(Fail) > 0
+}
+
+// WriteText writes the report as plain text that can be pasted into a bug
+// report; it contains no secrets
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "=== tempest-homekit-go %s ===\n", r.Title)
+	fmt.Fprintf(&b, "Version: %s (%s, %s/%s)\n", r.Version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "Run at:  %s\n", r.Started.Format(time.RFC3339))
+	for _, s := range r.Settings {
+		fmt.Fprintf(&b, "  %s\n", s)
+	}
+	b.WriteString("\n")
+
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for _, c := range r.Checks {
+		fmt.Fprintf(tw, "[%s]\t%s\t%v\t%s\n", c.Status, c.Name, c.Duration.Round(time.Millisecond), c.Detail)
+	}
+	_ = tw.Flush()
+
+	first := true
+	for _, c := range r.Checks {
+		if c.Fix == "" || c.Status == Pass || c.Status == Skip {
+			continue
+		}
+		if first {
+			b.WriteString("\nHow to fix:\n")
+			first = false
+		}
+		fmt.Fprintf(&b, "- %s: %s\n", c.Name, c.Fix)
+	}
+
+	fmt.Fprintf(&b, "\n%d passed, %d failed, %d warnings, %d skipped\n", r.Count(Pass), r.Count(Fail), r.Count(Warn), r.Count(Skip))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,73 @@
+package diagnostics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/config"
+)
+
+func TestRunReport(t *testing.T) {
+	alarms := filepath.Join(t.TempDir(), "alarms.json")
+	csvPath := filepath.Join(t.TempDir(), "alarms.csv")
+	if err := os.WriteFile(alarms, []byte(`{"alarms":[{"name":"Hot","condition":"temperature > 30","enabled":true,
+		"channels":[{"type":"csv","csv":{"path":"`+csvPath+`"}},{"type":"webhook","webhook":{"url":"http://127.0.0.1:1/hook","body":"{}"}}]}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Token:          "secret-token",
+		StationName:    "Backyard",
+		Alarms:         "@" + alarms,
+		DisableHomeKit: true,
+		Sensors:        "temp",
+		WebPort:        "8080",
+	}
+	report := Run(cfg, Options{Version: "test", SkipAPI: true, SkipUDP: true})
+
+	status := map[string]Status{}
+	for _, c := range report.Checks {
+		status[c.Name] = c.Status
+	}
+	want := map[string]Status{
+		"WeatherFlow API":       Skip,
+		"UDP broadcasts":        Skip,
+		"HomeKit pairing":       Skip,
+		"Notification: csv":     Pass,
+		"Notification: webhook": Fail,
+		"Web server":            Pass,
+	}
+	for name, s := range want {
+		if status[name] != s {
+			t.Errorf("%s: got %q, want %q", name, status[name], s)
+		}
+	}
+	if !report.Failed() {
+		t.Error("report with a failed channel should fail")
+	}
+	if _, err := os.Stat(csvPath); err != nil {
+		t.Errorf("csv test notification not written: %v", err)
+	}
+
+	var out strings.Builder
+	if err := report.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	if strings.Contains(text, "secret-token") {
+		t.Error("report contains the API token")
+	}
+	for _, s := range []string{"Version: test", "[FAIL]  Notification: webhook", "Station:          Backyard", "2 passed, 1 failed, 0 warnings, 3 skipped"} {
+		if !strings.Contains(text, s) {
+			t.Errorf("report missing %q:\n%s", s, text)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	cfg := &config.Config{Token: "abc123"}
+	if got := redact(cfg, &os.PathError{Op: "get", Path: "https://x/?token=abc123", Err: os.ErrNotExist}); strings.Contains(got, "abc123") {
+		t.Errorf("token not redacted: %s", got)
+	}
+}
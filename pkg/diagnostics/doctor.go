@@ -0,0 +1,355 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/udp"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// chromeNames are the executables chromedp looks for on the PATH
+var chromeNames = []string{"google-chrome", "google-chrome-stable", "chromium", "chromium-browser", "chrome", "headless-shell", "chrome.exe"}
+
+// chromePaths are the usual install locations outside the PATH
+var chromePaths = []string{
+	"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+	"/Applications/Chromium.app/Contents/MacOS/Chromium",
+	`C:\Program Files\Google\Chrome\Application\chrome.exe`,
+	`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
+}
+
+// Doctor checks the host rather than the configuration: that the ports are
+// free, the state directories writable, the clock right and the WeatherFlow
+// API reachable. Every failure or warning carries a remediation step.
+func Doctor(cfg *config.Config, version string) *Report {
+	report := &Report{Title: "doctor", Version: version, Started: time.Now(), Settings: settings(cfg)}
+
+	if cfg.DisableWebConsole {
+		report.add("Web port", Skip, time.Now(), "--disable-webconsole is set")
+	} else {
+		checkTCPPort(report, "Web port", cfg.WebPort, "--web-port")
+	}
+	switch {
+	case cfg.DisableHomeKit:
+		report.add("HomeKit port", Skip, time.Now(), "--disable-homekit is set")
+	case cfg.HomeKitPort == "":
+		report.add("HomeKit port", Pass, time.Now(), "a free port is picked at startup")
+	default:
+		checkTCPPort(report, "HomeKit port", cfg.HomeKitPort, "--homekit-port")
+	}
+	checkUDPPort(report, cfg)
+	checkFirewall(report, cfg)
+	checkDataDirs(report, cfg)
+	checkDNS(report, cfg)
+	checkClock(report, cfg)
+	checkChrome(report, cfg)
+	return report
+}
+
+// checkTCPPort binds the port the way the server would
+func checkTCPPort(report *Report, name, port, flag string) {
+	start := time.Now()
+	ln, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		report.add(name, Fail, start, "cannot listen on TCP port %s: %v", port, err)
+		if errors.Is(err, os.ErrPermission) || strings.Contains(err.Error(), "permission denied") {
+			report.fix("ports below 1024 need root or CAP_NET_BIND_SERVICE; choose a higher port with %s", flag)
+		} else {
+			report.fix("another process holds the port (an already running bridge counts); find it with %s, stop it or choose another port with %s", portOwnerCommand(port, "tcp"), flag)
+		}
+		return
+	}
+	_ = ln.Close()
+	report.add(name, Pass, start, "TCP port %s is free", port)
+}
+
+// checkUDPPort opens the socket the UDP listener opens, with the same
+// network, interface and multicast options
+func checkUDPPort(report *Report, cfg *config.Config) {
+	const name = "UDP port"
+	start := time.Now()
+	port := udp.UDPPort
+	if p, err := strconv.Atoi(cfg.UDPPort); err == nil {
+		port = p
+	}
+	network, _ := udp.ParseNetwork(cfg.UDPNetwork)
+	groups, _ := udp.ParseMulticastGroups(cfg.UDPMulticast)
+	if err := udp.CheckPort(port, udp.SocketOptions{Network: network, Interface: cfg.UDPInterface, Groups: groups}); err != nil {
+		report.add(name, Fail, start, "cannot listen on UDP port %d: %v", port, err)
+		if cfg.UDPInterface != "" {
+			report.fix("check that interface %q exists (ip link, ifconfig) or remove --udp-interface", cfg.UDPInterface)
+		} else {
+			report.fix("a program that does not set SO_REUSEADDR holds the port; find it with %s and stop it, or change --udp-port", portOwnerCommand(strconv.Itoa(port), "udp"))
+		}
+		return
+	}
+	report.add(name, Pass, start, "UDP port %d can be shared with the WeatherFlow apps", port)
+}
+
+// portOwnerCommand names the command that shows which process uses a port
+func portOwnerCommand(port, proto string) string {
+	switch runtime.GOOS {
+	case "windows":
+		return "'netstat -ano | findstr :" + port + "'"
+	case "linux":
+		flag := "-ltnp"
+		if proto == "udp" {
+			flag = "-lunp"
+		}
+		return "'sudo ss " + flag + " sport = :" + port + "'"
+	default:
+		return "'sudo lsof -i " + strings.ToUpper(proto) + ":" + port + "'"
+	}
+}
+
+// checkFirewall looks for a host firewall and says which ports to open;
+// it cannot tell whether the rules already allow them
+func checkFirewall(report *Report, cfg *config.Config) {
+	const name = "Firewall"
+	start := time.Now()
+	udpPort := cfg.UDPPort
+	if udpPort == "" {
+		udpPort = strconv.Itoa(udp.UDPPort)
+	}
+	var tcpPorts []string
+	if !cfg.DisableWebConsole {
+		tcpPorts = append(tcpPorts, cfg.WebPort)
+	}
+	if !cfg.DisableHomeKit && cfg.HomeKitPort != "" {
+		tcpPorts = append(tcpPorts, cfg.HomeKitPort)
+	}
+	udpPorts := []string{udpPort}
+	if !cfg.DisableHomeKit {
+		udpPorts = append(udpPorts, "5353") // mDNS, for the Home app to find the bridge
+	}
+
+	switch runtime.GOOS {
+	case "linux":
+		var rules []string
+		if _, err := exec.LookPath("ufw"); err == nil {
+			for _, p := range tcpPorts {
+				rules = append(rules, "sudo ufw allow "+p+"/tcp")
+			}
+			for _, p := range udpPorts {
+				rules = append(rules, "sudo ufw allow "+p+"/udp")
+			}
+		} else if _, err := exec.LookPath("firewall-cmd"); err == nil {
+			for _, p := range tcpPorts {
+				rules = append(rules, "sudo firewall-cmd --permanent --add-port="+p+"/tcp")
+			}
+			for _, p := range udpPorts {
+				rules = append(rules, "sudo firewall-cmd --permanent --add-port="+p+"/udp")
+			}
+			rules = append(rules, "sudo firewall-cmd --reload")
+		}
+		if rules == nil {
+			report.add(name, Pass, start, "neither ufw nor firewalld is installed")
+			return
+		}
+		report.add(name, Warn, start, "a host firewall is installed; TCP %s and UDP %s must be allowed", strings.Join(tcpPorts, ", "), strings.Join(udpPorts, ", "))
+		report.fix("if it is active, run: %s", strings.Join(rules, "; "))
+	case "darwin":
+		out, err := exec.Command("/usr/libexec/ApplicationFirewall/socketfilterfw", "--getglobalstate").Output()
+		if err != nil || !strings.Contains(string(out), "enabled") {
+			report.add(name, Pass, start, "the macOS application firewall is off")
+			return
+		}
+		report.add(name, Warn, start, "the macOS application firewall is on")
+		report.fix("allow incoming connections for tempest-homekit-go in System Settings > Network > Firewall > Options, or run: sudo /usr/libexec/ApplicationFirewall/socketfilterfw --add %s", executable())
+	case "windows":
+		report.add(name, Warn, start, "Windows Defender Firewall may block TCP %s and UDP %s", strings.Join(tcpPorts, ", "), strings.Join(udpPorts, ", "))
+		report.fix("allow the program from an administrator prompt: netsh advfirewall firewall add rule name=\"tempest-homekit-go\" dir=in action=allow program=\"%s\"", executable())
+	default:
+		report.add(name, Skip, start, "no firewall hints for %s", runtime.GOOS)
+	}
+}
+
+// executable is the path of the running binary, for firewall rules
+func executable() string {
+	if path, err := os.Executable(); err == nil {
+		return path
+	}
+	return "tempest-homekit-go"
+}
+
+// checkDataDirs checks that the HomeKit database and the other state can be
+// written, without creating anything that does not exist yet
+func checkDataDirs(report *Report, cfg *config.Config) {
+	const name = "Data directories"
+	start := time.Now()
+	root := config.ResolveDataDir(cfg.DataDir)
+	dirs := []string{".", "db"}
+	if root != "" {
+		dirs = []string{root, filepath.Join(root, "db"), filepath.Join(root, "logs"), filepath.Join(root, "alarm-versions")}
+	}
+	for _, dir := range dirs {
+		if err := checkWritable(dir); err != nil {
+			report.add(name, Fail, start, "%v", err)
+			report.fix("give uid %d write access, e.g. 'sudo chown -R %d %s', or point --data-dir at a writable directory", os.Getuid(), os.Getuid(), dir)
+			return
+		}
+	}
+	report.add(name, Pass, start, "writable: %s", strings.Join(dirs, ", "))
+}
+
+// checkWritable creates and removes a file in dir, or in the closest
+// existing parent when dir would be created at startup
+func checkWritable(dir string) error {
+	existing := dir
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", existing)
+			}
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return fmt.Errorf("%s: %v", dir, err)
+		}
+		existing = parent
+	}
+	f, err := os.CreateTemp(existing, ".doctor-check-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable by uid %d: %v", existing, os.Getuid(), err)
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
+}
+
+// apiURL is the configured WeatherFlow API; Doctor runs before main applies
+// --weatherflow-api-url to the client
+func apiURL(cfg *config.Config) string {
+	if cfg.WeatherFlowAPIURL != "" {
+		return cfg.WeatherFlowAPIURL
+	}
+	return weather.BaseURL
+}
+
+// apiHost is the host of the configured WeatherFlow API
+func apiHost(cfg *config.Config) string {
+	u, err := url.Parse(apiURL(cfg))
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// checkDNS resolves the WeatherFlow API host
+func checkDNS(report *Report, cfg *config.Config) {
+	const name = "DNS"
+	start := time.Now()
+	host := apiHost(cfg)
+	if cfg.DisableInternet {
+		report.add(name, Skip, start, "--disable-internet is set")
+		return
+	}
+	if host == "" || net.ParseIP(host) != nil {
+		report.add(name, Skip, start, "the API URL has no host name to resolve")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		report.add(name, Fail, start, "cannot resolve %s: %v", host, err)
+		report.fix("check the DNS servers in /etc/resolv.conf or the network settings (a container may need --dns), or use --udp-stream with --disable-internet")
+		return
+	}
+	report.add(name, Pass, start, "%s resolves to %s", host, strings.Join(addrs, ", "))
+}
+
+// checkClock compares the local clock with the Date header of the API server.
+// Large skew breaks TLS; smaller skew shifts day boundaries and schedules.
+func checkClock(report *Report, cfg *config.Config) {
+	const name = "Clock"
+	start := time.Now()
+	if cfg.DisableInternet {
+		report.add(name, Skip, start, "--disable-internet is set")
+		return
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Head(apiURL(cfg))
+	if err != nil {
+		report.add(name, Warn, start, "could not reach %s to compare clocks: %v", apiHost(cfg), err)
+		report.fix("check the network connection; a clock far off also makes TLS fail with certificate errors")
+		return
+	}
+	_ = resp.Body.Close()
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		report.add(name, Skip, start, "%s sent no Date header", apiHost(cfg))
+		return
+	}
+	// The header has one-second resolution; compare against the middle of the request
+	skew := time.Since(start)/2 + start.Sub(serverTime)
+	skew = skew.Round(time.Second)
+	abs := skew
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs > 5*time.Minute:
+		report.add(name, Fail, start, "the local clock is %v off %s", skew, apiHost(cfg))
+	case abs > 5*time.Second:
+		report.add(name, Warn, start, "the local clock is %v off %s", skew, apiHost(cfg))
+	default:
+		report.add(name, Pass, start, "within %v of %s", abs, apiHost(cfg))
+		return
+	}
+	switch runtime.GOOS {
+	case "linux":
+		report.fix("enable time sync: 'sudo timedatectl set-ntp true' (or install chrony)")
+	case "darwin":
+		report.fix("enable 'Set time and date automatically' in System Settings > General > Date & Time, or run 'sudo sntp -sS time.apple.com'")
+	case "windows":
+		report.fix("run 'w32tm /resync' from an administrator prompt")
+	default:
+		report.fix("synchronize the clock with NTP")
+	}
+}
+
+// checkChrome looks for the browser --use-web-status drives
+func checkChrome(report *Report, cfg *config.Config) {
+	const name = "Chrome"
+	start := time.Now()
+	found := ""
+	for _, n := range chromeNames {
+		if path, err := exec.LookPath(n); err == nil {
+			found = path
+			break
+		}
+	}
+	for _, p := range chromePaths {
+		if found != "" {
+			break
+		}
+		if _, err := os.Stat(p); err == nil {
+			found = p
+		}
+	}
+	switch {
+	case found != "":
+		report.add(name, Pass, start, "found %s", found)
+	case !cfg.UseWebStatus:
+		report.add(name, Skip, start, "not found; only --use-web-status needs it")
+	default:
+		report.add(name, Fail, start, "--use-web-status needs Chrome or Chromium and neither was found")
+		report.fix("install Chromium (e.g. 'sudo apt install chromium') or Google Chrome, or drop --use-web-status")
+	}
+}
@@ -0,0 +1,68 @@
+package diagnostics
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/config"
+)
+
+func TestDoctorPortsAndDataDir(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	busyPort := strconv.Itoa(busy.Addr().(*net.TCPAddr).Port)
+
+	dataDir := filepath.Join(t.TempDir(), "state")
+	cfg := &config.Config{
+		WebPort:         busyPort,
+		HomeKitPort:     "",
+		UDPPort:         "0",
+		DataDir:         dataDir,
+		DisableInternet: true,
+	}
+	report := Doctor(cfg, "test")
+	status := map[string]Check{}
+	for _, c := range report.Checks {
+		status[c.Name] = c
+	}
+	if c := status["Web port"]; c.Status != Fail || !strings.Contains(c.Fix, "--web-port") {
+		t.Errorf("busy web port: %+v", c)
+	}
+	for name, want := range map[string]Status{"HomeKit port": Pass, "UDP port": Pass, "Data directories": Pass, "DNS": Skip, "Clock": Skip} {
+		if status[name].Status != want {
+			t.Errorf("%s: got %+v, want %s", name, status[name], want)
+		}
+	}
+	if _, err := os.Stat(dataDir); !os.IsNotExist(err) {
+		t.Error("doctor created the data directory")
+	}
+
+	var out strings.Builder
+	if err := report.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "How to fix:\n- Web port: ") {
+		t.Errorf("remediation missing:\n%s", out.String())
+	}
+}
+
+func TestCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+	if err := checkWritable(filepath.Join(dir, "a", "b")); err != nil {
+		t.Errorf("missing directory under a writable parent: %v", err)
+	}
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkWritable(file); err == nil {
+		t.Error("a file accepted as a directory")
+	}
+}
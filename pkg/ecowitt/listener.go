@@ -0,0 +1,258 @@
+// Package ecowitt receives uploads from Ecowitt, Ambient Weather and other
+// stations that speak the Ecowitt or Weather Underground upload protocol
+// (form or query fields such as tempf, humidity and baromabsin), normalizes
+// them to metric weather.Observation values and hands them to the ingest
+// data source, so non-Tempest stations drive HomeKit, alarms and the
+// dashboard.
+package ecowitt
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/websec"
+)
+
+// maxBodySize caps an upload; Ecowitt posts a few hundred bytes
+const maxBodySize = 64 << 10
+
+// luxPerWattM2 converts solar radiation to an approximate illuminance for
+// stations without a lux sensor (the factor Ecowitt's own apps use)
+const luxPerWattM2 = 126.7
+
+// Pusher receives the converted observations
+type Pusher interface {
+	Push(obs weather.Observation) error
+}
+
+// Config configures the listener
+type Config struct {
+	Port string
+	// Passkey, when set, must match the PASSKEY (Ecowitt, Ambient Weather) or
+	// PASSWORD (Weather Underground) field of every upload
+	Passkey string
+	Limits  websec.Limits
+}
+
+// Server is an upload listener
+type Server struct {
+	cfg Config
+	now func() time.Time
+
+	mu     sync.Mutex
+	target Pusher
+	last   *counters // daily totals of the previous upload
+}
+
+// counters are the daily totals uploads carry, from which the amounts since
+// the previous upload are derived
+type counters struct {
+	rain      float64 // mm
+	lightning int
+}
+
+// New creates a listener
+func New(cfg Config) *Server {
+	return &Server{cfg: cfg, now: time.Now}
+}
+
+// SetTarget sets where converted observations go. The service calls it
+// whenever it creates a new ingest data source.
+func (s *Server) SetTarget(target Pusher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.target = target
+}
+
+// ListenAndServe serves the listener until ctx is cancelled, then shuts
+// down gracefully
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{Addr: ":" + s.cfg.Port, Handler: s.Handler()}
+	errc := make(chan error, 1)
+	go func() { errc <- websec.ListenAndServe(srv, s.cfg.Limits) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// Handler accepts uploads on any path: stations let users pick the path
+// (Ecowitt defaults to /data/report/, Weather Underground clients use
+// /weatherstation/updateweatherstation.php)
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(s.handleUpload)
+}
+
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid upload", http.StatusBadRequest)
+		return
+	}
+	values := r.Form
+	if !s.authorized(values) {
+		logger.Warn("Rejected Ecowitt upload from %s: passkey does not match", r.RemoteAddr)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.target == nil {
+		http.Error(w, "Not ready", http.StatusServiceUnavailable)
+		return
+	}
+	obs, next, err := parseUpload(values, s.last, s.now())
+	if err != nil {
+		logger.Debug("Invalid Ecowitt upload from %s: %v", r.RemoteAddr, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.target.Push(obs); err != nil {
+		logger.Debug("Ecowitt upload from %s not queued: %v", r.RemoteAddr, err)
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	s.last = next
+	logger.Debug("Ecowitt upload from %s (%s): %.1f°C, %.0f%%", r.RemoteAddr, values.Get("stationtype"), obs.AirTemperature, obs.RelativeHumidity)
+	// Weather Underground clients look for "success"
+	_, _ = w.Write([]byte("success\n"))
+}
+
+// authorized checks the passkey, when one is configured
+func (s *Server) authorized(values url.Values) bool {
+	if s.cfg.Passkey == "" {
+		return true
+	}
+	supplied := values.Get("PASSKEY")
+	if supplied == "" {
+		supplied = values.Get("PASSWORD")
+	}
+	return subtle.ConstantTimeCompare([]byte(supplied), []byte(s.cfg.Passkey)) == 1
+}
+
+// parseUpload converts the fields of one upload, which are always imperial,
+// to a metric observation. Rain and lightning arrive as daily totals; the
+// amounts since the previous upload are derived from last, and the returned
+// counters are passed to the next call. Outdoor temperature and humidity are
+// required.
+func parseUpload(values url.Values, last *counters, now time.Time) (weather.Observation, *counters, error) {
+	number := func(keys ...string) (float64, bool) {
+		for _, key := range keys {
+			if v := strings.TrimSpace(values.Get(key)); v != "" {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					return f, true
+				}
+			}
+		}
+		return 0, false
+	}
+
+	tempF, okTemp := number("tempf")
+	humidity, okHumidity := number("humidity")
+	if !okTemp || !okHumidity {
+		return weather.Observation{}, nil, fmt.Errorf("tempf and humidity are required")
+	}
+	if humidity < 0 || humidity > 100 {
+		return weather.Observation{}, nil, fmt.Errorf("humidity must be between 0 and 100")
+	}
+	timestamp, err := parseDateUTC(values.Get("dateutc"), now)
+	if err != nil {
+		return weather.Observation{}, nil, err
+	}
+
+	obs := weather.Observation{
+		Timestamp:        timestamp.Unix(),
+		AirTemperature:   (tempF - 32) * 5 / 9,
+		RelativeHumidity: humidity,
+		ReportInterval:   1,
+	}
+	// Station pressure; the relative (sea level) reading only when the
+	// station sends nothing else, as Weather Underground clients do
+	if inHg, ok := number("baromabsin", "baromrelin", "baromin"); ok {
+		obs.StationPressure = inHg * 33.8639
+	}
+	if mph, ok := number("windspeedmph"); ok {
+		obs.WindAvg = mph * 0.44704
+	}
+	if mph, ok := number("windgustmph"); ok {
+		obs.WindGust = mph * 0.44704
+	}
+	if deg, ok := number("winddir"); ok {
+		obs.WindDirection = deg
+	}
+	if wm2, ok := number("solarradiation"); ok {
+		obs.SolarRadiation = wm2
+		obs.Illuminance = wm2 * luxPerWattM2
+	}
+	if uv, ok := number("uv", "UV"); ok {
+		obs.UV = int(uv + 0.5)
+	}
+	if km, ok := number("lightning"); ok {
+		obs.LightningStrikeAvg = km
+	}
+
+	// A total below the previous one was reset at the station's midnight
+	next := &counters{}
+	if in, ok := number("dailyrainin"); ok {
+		next.rain = in * 25.4
+		obs.RainDailyTotal = next.rain
+		switch {
+		case last == nil:
+			// first upload: the amount since the previous report is unknown
+		case next.rain < last.rain:
+			obs.RainAccumulated = next.rain
+		default:
+			obs.RainAccumulated = next.rain - last.rain
+		}
+	}
+	if count, ok := number("lightning_num"); ok {
+		next.lightning = int(count)
+		switch {
+		case last == nil:
+		case next.lightning < last.lightning:
+			obs.LightningStrikeCount = next.lightning
+		default:
+			obs.LightningStrikeCount = next.lightning - last.lightning
+		}
+	}
+	if rate, ok := number("rainratein"); (ok && rate > 0) || obs.RainAccumulated > 0 {
+		obs.PrecipitationType = 1 // rain
+	}
+	return obs, next, nil
+}
+
+// parseDateUTC reads the dateutc field: "now" or "2006-01-02 15:04:05" in
+// UTC (Ecowitt, Ambient Weather), missing meaning now
+func parseDateUTC(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "now") {
+		return now, nil
+	}
+	t, err := time.Parse("2006-01-02 15:04:05", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("dateutc must be \"now\" or YYYY-MM-DD hh:mm:ss")
+	}
+	if t.After(now.Add(5 * time.Minute)) {
+		return time.Time{}, fmt.Errorf("dateutc %s is in the future", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,119 @@
+package ecowitt
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+type recorder struct{ pushed []weather.Observation }
+
+func (r *recorder) Push(obs weather.Observation) error {
+	r.pushed = append(r.pushed, obs)
+	return nil
+}
+
+func TestParseUpload(t *testing.T) {
+	now := time.Date(2026, 7, 1, 12, 0, 30, 0, time.UTC)
+	values := url.Values{
+		"PASSKEY":        {"ABCDEF"},
+		"stationtype":    {"GW1100A_V2.3.1"},
+		"dateutc":        {"2026-07-01 12:00:00"},
+		"tempf":          {"77.0"},
+		"humidity":       {"55"},
+		"baromabsin":     {"29.530"},
+		"baromrelin":     {"29.921"},
+		"windspeedmph":   {"10.0"},
+		"windgustmph":    {"15.0"},
+		"winddir":        {"270"},
+		"solarradiation": {"500.0"},
+		"uv":             {"4"},
+		"rainratein":     {"0.000"},
+		"dailyrainin":    {"0.100"},
+	}
+	obs, next, err := parseUpload(values, nil, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if obs.Timestamp != now.Add(-30*time.Second).Unix() || obs.AirTemperature != 25 || obs.RelativeHumidity != 55 {
+		t.Errorf("time/temperature/humidity: %+v", obs)
+	}
+	if math.Abs(obs.StationPressure-1000.0) > 0.1 || math.Abs(obs.WindAvg-4.47) > 0.01 || math.Abs(obs.WindGust-6.71) > 0.01 || obs.WindDirection != 270 {
+		t.Errorf("pressure/wind: %+v", obs)
+	}
+	if obs.UV != 4 || obs.SolarRadiation != 500 || obs.Illuminance != 500*luxPerWattM2 {
+		t.Errorf("light: %+v", obs)
+	}
+	if math.Abs(obs.RainDailyTotal-2.54) > 0.001 || obs.RainAccumulated != 0 || obs.PrecipitationType != 0 {
+		t.Errorf("first upload rain: %+v", obs)
+	}
+
+	values.Set("dateutc", "2026-07-01 12:01:00")
+	values.Set("dailyrainin", "0.150")
+	obs, next, err = parseUpload(values, next, now.Add(time.Minute))
+	if err != nil || math.Abs(obs.RainAccumulated-1.27) > 0.001 || obs.PrecipitationType != 1 {
+		t.Errorf("rain since the previous upload: %+v, %v", obs, err)
+	}
+
+	// The daily total resets at the station's midnight
+	values.Set("dailyrainin", "0.010")
+	obs, _, err = parseUpload(values, next, now.Add(time.Minute))
+	if err != nil || math.Abs(obs.RainAccumulated-0.254) > 0.001 {
+		t.Errorf("rain after a reset: %+v, %v", obs, err)
+	}
+
+	for name, bad := range map[string]url.Values{
+		"missing humidity": {"tempf": {"70"}},
+		"bad humidity":     {"tempf": {"70"}, "humidity": {"120"}},
+		"bad date":         {"tempf": {"70"}, "humidity": {"50"}, "dateutc": {"yesterday"}},
+		"future":           {"tempf": {"70"}, "humidity": {"50"}, "dateutc": {"2026-07-01 13:00:00"}},
+	} {
+		if _, _, err := parseUpload(bad, nil, now); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	s := New(Config{Passkey: "ABCDEF"})
+	target := &recorder{}
+	do := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+	post := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/data/report/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req
+	}
+
+	if rec := do(post("PASSKEY=ABCDEF&tempf=70&humidity=50")); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("before a target: status %d, want 503", rec.Code)
+	}
+	s.SetTarget(target)
+	if rec := do(post("PASSKEY=WRONG&tempf=70&humidity=50")); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong passkey: status %d, want 401", rec.Code)
+	}
+	if rec := do(post("PASSKEY=ABCDEF&tempf=70")); rec.Code != http.StatusBadRequest {
+		t.Errorf("incomplete upload: status %d, want 400", rec.Code)
+	}
+	if rec := do(post("PASSKEY=ABCDEF&dateutc=now&tempf=70&humidity=50")); rec.Code != http.StatusOK {
+		t.Errorf("Ecowitt upload: status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Weather Underground protocol: GET with ID/PASSWORD and sea level pressure
+	wu := httptest.NewRequest(http.MethodGet, "/weatherstation/updateweatherstation.php?ID=KXX1&PASSWORD=ABCDEF&dateutc=now&tempf=50&humidity=80&baromin=30.00", nil)
+	if rec := do(wu); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "success") {
+		t.Errorf("Wunderground upload: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(target.pushed) != 2 || math.Abs(target.pushed[1].AirTemperature-10) > 0.001 || math.Abs(target.pushed[1].StationPressure-1015.9) > 0.1 {
+		t.Errorf("pushed %+v", target.pushed)
+	}
+}
@@ -0,0 +1,459 @@
+// Package fakeapi serves recorded WeatherFlow REST API responses so
+// integration tests and demos can run without a token or network access.
+// Timestamps in the recordings are shifted to the time of the request, and
+// latency and errors can be injected, at start-up or at runtime through
+// /fake/control.
+package fakeapi
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// BasePath is where the API is served; point the client at
+// http://<host>:<port>/swd/rest like the real API
+const BasePath = "/swd/rest"
+
+//go:embed recordings/*.json
+var builtinRecordings embed.FS
+
+// recordingFiles are the responses a recordings directory can replace
+var recordingFiles = []string{"stations.json", "observations_station.json", "observations_device.json", "better_forecast.json"}
+
+// Options configure the recordings and the injected faults
+type Options struct {
+	Dir         string        // directory of recordings replacing the built-in ones; missing files keep the built-in
+	Latency     time.Duration // added to every API response
+	ErrorRate   float64       // share of API requests answered with ErrorStatus, 0 to 1
+	ErrorStatus int           // status of injected errors, default 503
+	Seed        int64         // seeds the error injection; 0 uses the clock
+}
+
+// Faults is the injected behavior, as read and changed at /fake/control
+type Faults struct {
+	LatencyMs   int64   `json:"latencyMs"`
+	ErrorRate   float64 `json:"errorRate"`
+	ErrorStatus int     `json:"errorStatus"`
+	FailNext    int     `json:"failNext"` // the next n API requests fail regardless of errorRate
+}
+
+// Server is an http.Handler imitating the WeatherFlow REST API
+type Server struct {
+	mux *http.ServeMux
+
+	stations    stationsRecording
+	stationObs  map[string]interface{}
+	deviceObs   deviceRecording
+	forecast    map[string]interface{}
+	recordedDay time.Time // local midnight of the forecast's first day
+
+	mu       sync.Mutex
+	faults   Faults
+	rand     *rand.Rand
+	requests map[string]int
+}
+
+type stationsRecording struct {
+	Stations []struct {
+		StationID int    `json:"station_id"`
+		Timezone  string `json:"timezone"`
+		Devices   []struct {
+			DeviceID   int    `json:"device_id"`
+			DeviceType string `json:"device_type"`
+		} `json:"devices"`
+	} `json:"stations"`
+	raw map[string]interface{}
+}
+
+type deviceRecording struct {
+	raw map[string]interface{}
+	obs [][]interface{}
+}
+
+// New loads the recordings and returns the server
+func New(opts Options) (*Server, error) {
+	s := &Server{requests: make(map[string]int)}
+	load := func(name string, v interface{}) error {
+		data, err := builtinRecordings.ReadFile("recordings/" + name)
+		if err != nil {
+			return err
+		}
+		if opts.Dir != "" {
+			if custom, err := os.ReadFile(filepath.Join(opts.Dir, name)); err == nil {
+				data = custom
+			} else if !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			return fmt.Errorf("invalid recording %s: %w", name, err)
+		}
+		return nil
+	}
+	if err := load("stations.json", &s.stations); err != nil {
+		return nil, err
+	}
+	if err := load("stations.json", &s.stations.raw); err != nil {
+		return nil, err
+	}
+	if len(s.stations.Stations) == 0 {
+		return nil, fmt.Errorf("invalid recording stations.json: no stations")
+	}
+	if err := load("observations_station.json", &s.stationObs); err != nil {
+		return nil, err
+	}
+	if err := load("observations_device.json", &s.deviceObs.raw); err != nil {
+		return nil, err
+	}
+	var device struct {
+		Obs [][]interface{} `json:"obs"`
+	}
+	if err := load("observations_device.json", &device); err != nil {
+		return nil, err
+	}
+	if len(device.Obs) == 0 {
+		return nil, fmt.Errorf("invalid recording observations_device.json: no obs")
+	}
+	s.deviceObs.obs = device.Obs
+	if err := load("better_forecast.json", &s.forecast); err != nil {
+		return nil, err
+	}
+	if daily := forecastList(s.forecast, "daily"); len(daily) > 0 {
+		s.recordedDay = time.Unix(int64(number(daily[0]["day_start_local"])), 0)
+	}
+
+	if opts.ErrorStatus == 0 {
+		opts.ErrorStatus = http.StatusServiceUnavailable
+	}
+	s.faults = Faults{LatencyMs: opts.Latency.Milliseconds(), ErrorRate: opts.ErrorRate, ErrorStatus: opts.ErrorStatus}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	s.rand = rand.New(rand.NewSource(seed))
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET "+BasePath+"/stations", s.api("stations", s.handleStations))
+	s.mux.HandleFunc("GET "+BasePath+"/stations/{id}", s.api("stations/{id}", s.handleStation))
+	s.mux.HandleFunc("GET "+BasePath+"/observations/station/{id}", s.api("observations/station/{id}", s.handleStationObservation))
+	s.mux.HandleFunc("GET "+BasePath+"/observations/device/{id}", s.api("observations/device/{id}", s.handleDeviceObservations))
+	s.mux.HandleFunc("GET "+BasePath+"/better_forecast", s.api("better_forecast", s.handleForecast))
+	s.mux.HandleFunc("/fake/control", s.handleControl)
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Faults returns the injected behavior
+func (s *Server) Faults() Faults {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.faults
+}
+
+// SetFaults replaces the injected behavior
+func (s *Server) SetFaults(f Faults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f.ErrorStatus == 0 {
+		f.ErrorStatus = http.StatusServiceUnavailable
+	}
+	s.faults = f
+}
+
+// Requests returns the number of API requests served per endpoint,
+// including failed ones
+func (s *Server) Requests() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]int, len(s.requests))
+	for k, v := range s.requests {
+		out[k] = v
+	}
+	return out
+}
+
+// api wraps an endpoint with the request counter, token check and faults
+func (s *Server) api(endpoint string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[endpoint]++
+		f := s.faults
+		fail := f.FailNext > 0 || (f.ErrorRate > 0 && s.rand.Float64() < f.ErrorRate)
+		if f.FailNext > 0 {
+			s.faults.FailNext--
+		}
+		s.mu.Unlock()
+
+		if f.LatencyMs > 0 {
+			select {
+			case <-time.After(time.Duration(f.LatencyMs) * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if fail {
+			if f.ErrorStatus == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "1")
+			}
+			writeStatus(w, f.ErrorStatus, "INJECTED FAILURE")
+			return
+		}
+		if r.URL.Query().Get("token") == "" {
+			writeStatus(w, http.StatusUnauthorized, "UNAUTHORIZED")
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// writeStatus answers with the API's error body
+func writeStatus(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": map[string]interface{}{"status_code": code, "status_message": message},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// station returns the index of the recorded station with the given ID, or
+// the station owning the given Tempest device when device is true
+func (s *Server) station(id string, device bool) (int, bool) {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, false
+	}
+	for i, st := range s.stations.Stations {
+		if !device && st.StationID == n {
+			return i, true
+		}
+		for _, d := range st.Devices {
+			if device && d.DeviceID == n && d.DeviceType == "ST" {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// location returns the time zone of a recorded station
+func (s *Server) location(station int) *time.Location {
+	if loc, err := time.LoadLocation(s.stations.Stations[station].Timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+func (s *Server) handleStations(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.stations.raw)
+}
+
+func (s *Server) handleStation(w http.ResponseWriter, r *http.Request) {
+	i, ok := s.station(r.PathValue("id"), false)
+	if !ok {
+		writeStatus(w, http.StatusNotFound, "NOT FOUND")
+		return
+	}
+	resp := copyMap(s.stations.raw)
+	resp["stations"] = []interface{}{s.stations.raw["stations"].([]interface{})[i]}
+	writeJSON(w, resp)
+}
+
+// handleStationObservation returns the recorded latest observation as of now
+func (s *Server) handleStationObservation(w http.ResponseWriter, r *http.Request) {
+	i, ok := s.station(r.PathValue("id"), false)
+	if !ok {
+		writeStatus(w, http.StatusNotFound, "NOT FOUND")
+		return
+	}
+	resp := copyMap(s.stationObs)
+	resp["station_id"] = s.stations.Stations[i].StationID
+	if obs, ok := resp["obs"].([]interface{}); ok && len(obs) > 0 {
+		if latest, ok := obs[0].(map[string]interface{}); ok {
+			latest = copyMap(latest)
+			latest["timestamp"] = time.Now().Truncate(time.Minute).Unix()
+			resp["obs"] = []interface{}{latest}
+		}
+	}
+	writeJSON(w, resp)
+}
+
+// handleDeviceObservations replays the recorded device observations over the
+// requested range: time_start/time_end, a local day by day_offset, or the
+// last hour. Like the real API, long ranges come back in coarser buckets.
+func (s *Server) handleDeviceObservations(w http.ResponseWriter, r *http.Request) {
+	i, ok := s.station(r.PathValue("id"), true)
+	if !ok {
+		writeStatus(w, http.StatusNotFound, "NOT FOUND")
+		return
+	}
+	now := time.Now()
+	q := r.URL.Query()
+	var start, end time.Time
+	switch {
+	case q.Get("time_start") != "" || q.Get("time_end") != "":
+		from, err1 := strconv.ParseInt(q.Get("time_start"), 10, 64)
+		to, err2 := strconv.ParseInt(q.Get("time_end"), 10, 64)
+		if err1 != nil || err2 != nil || to < from {
+			writeStatus(w, http.StatusBadRequest, "INVALID TIME RANGE")
+			return
+		}
+		start, end = time.Unix(from, 0), time.Unix(to, 0)
+	case q.Get("day_offset") != "":
+		offset, err := strconv.Atoi(q.Get("day_offset"))
+		if err != nil || offset < 0 {
+			writeStatus(w, http.StatusBadRequest, "INVALID DAY OFFSET")
+			return
+		}
+		local := now.In(s.location(i))
+		start = time.Date(local.Year(), local.Month(), local.Day()-offset, 0, 0, 0, 0, local.Location())
+		end = start.AddDate(0, 0, 1)
+	default:
+		start, end = now.Add(-time.Hour), now
+	}
+	if end.After(now) {
+		end = now
+	}
+
+	step := time.Minute
+	if span := end.Sub(start); span > 7*24*time.Hour {
+		step = 30 * time.Minute
+	} else if span > 24*time.Hour {
+		step = 5 * time.Minute
+	}
+	obs := [][]interface{}{}
+	for t := start.Truncate(step); !t.After(end); t = t.Add(step) {
+		if t.Before(start) {
+			continue
+		}
+		// The same minute always replays the same recorded row
+		row := append([]interface{}{}, s.deviceObs.obs[(t.Unix()/60)%int64(len(s.deviceObs.obs))]...)
+		row[0] = t.Unix()
+		obs = append(obs, row)
+	}
+	resp := copyMap(s.deviceObs.raw)
+	resp["device_id"] = r.PathValue("id")
+	resp["bucket_step_minutes"] = int(step / time.Minute)
+	resp["obs"] = obs
+	writeJSON(w, resp)
+}
+
+// handleForecast returns the recorded forecast with its days starting today
+// and its hours starting at the next full hour
+func (s *Server) handleForecast(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("station_id")
+	i, ok := s.station(id, false)
+	if !ok {
+		writeStatus(w, http.StatusNotFound, "NOT FOUND")
+		return
+	}
+	loc := s.location(i)
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	shift := int64(0)
+	if !s.recordedDay.IsZero() {
+		shift = today.Unix() - s.recordedDay.Unix()
+	}
+
+	resp := copyMap(s.forecast)
+	resp["station_id"] = s.stations.Stations[i].StationID
+	if current, ok := resp["current_conditions"].(map[string]interface{}); ok {
+		current = copyMap(current)
+		current["time"] = now.Truncate(time.Minute).Unix()
+		resp["current_conditions"] = current
+	}
+	forecast := copyMap(s.forecast["forecast"].(map[string]interface{}))
+	daily := forecastList(s.forecast, "daily")
+	for d, day := range daily {
+		day = copyMap(day)
+		for _, key := range []string{"day_start_local", "sunrise", "sunset"} {
+			if v, ok := day[key]; ok {
+				day[key] = int64(number(v)) + shift
+			}
+		}
+		date := today.AddDate(0, 0, d)
+		day["day_num"], day["month_num"] = date.Day(), int(date.Month())
+		daily[d] = day
+	}
+	forecast["daily"] = daily
+	hourly := forecastList(s.forecast, "hourly")
+	next := now.Truncate(time.Hour).Add(time.Hour)
+	for h, hour := range hourly {
+		hour = copyMap(hour)
+		t := next.Add(time.Duration(h) * time.Hour)
+		hour["time"] = t.Unix()
+		hour["local_hour"], hour["local_day"] = t.Hour(), t.Day()
+		hourly[h] = hour
+	}
+	forecast["hourly"] = hourly
+	resp["forecast"] = forecast
+	writeJSON(w, resp)
+}
+
+// handleControl reads (GET) or changes (POST, any subset of the fields) the
+// injected faults; the response includes the request counters
+func (s *Server) handleControl(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		f := s.Faults()
+		if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+			http.Error(w, fmt.Sprintf("invalid faults: %v", err), http.StatusBadRequest)
+			return
+		}
+		if f.ErrorRate < 0 || f.ErrorRate > 1 || f.LatencyMs < 0 || f.FailNext < 0 {
+			http.Error(w, "errorRate must be 0 to 1; latencyMs and failNext must not be negative", http.StatusBadRequest)
+			return
+		}
+		s.SetFaults(f)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, struct {
+		Faults
+		Requests map[string]int `json:"requests"`
+	}{s.Faults(), s.Requests()})
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// forecastList returns a copy of forecast.<key> of a better_forecast response
+func forecastList(resp map[string]interface{}, key string) []map[string]interface{} {
+	forecast, _ := resp["forecast"].(map[string]interface{})
+	list, _ := forecast[key].([]interface{})
+	out := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		if m, ok := item.(map[string]interface{}); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func number(v interface{}) float64 {
+	f, _ := v.(float64)
+	return f
+}
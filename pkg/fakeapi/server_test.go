@@ -0,0 +1,152 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// newTestServer starts the fake API and points the weather client at it
+func newTestServer(t *testing.T, opts Options) *Server {
+	t.Helper()
+	s, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s)
+	prev := weather.BaseURL
+	weather.BaseURL = srv.URL + BasePath
+	t.Cleanup(func() {
+		weather.BaseURL = prev
+		srv.Close()
+	})
+	return s
+}
+
+func TestWeatherClient(t *testing.T) {
+	s := newTestServer(t, Options{})
+
+	stations, err := weather.GetStations("fake")
+	if err != nil || len(stations) != 1 {
+		t.Fatalf("GetStations = %v, %v", stations, err)
+	}
+	station := weather.FindStationByName(stations, "Fake Station")
+	if station == nil {
+		t.Fatal("Fake Station not found")
+	}
+
+	obs, err := weather.GetObservation(station.StationID, "fake")
+	if err != nil {
+		t.Fatalf("GetObservation: %v", err)
+	}
+	if age := time.Since(time.Unix(obs.Timestamp, 0)); age < 0 || age > 2*time.Minute {
+		t.Errorf("observation is %v old, want current", age)
+	}
+
+	end := time.Now()
+	start := end.Add(-30 * time.Minute)
+	history, err := weather.GetObservationsRange(station.StationID, "fake", start, end)
+	if err != nil {
+		t.Fatalf("GetObservationsRange: %v", err)
+	}
+	if len(history) < 28 || len(history) > 30 {
+		t.Errorf("got %d observations for 30 minutes, want one a minute", len(history))
+	}
+	for _, o := range history {
+		if o.Timestamp <= start.Unix() || o.Timestamp >= end.Unix() || o.AirTemperature == 0 {
+			t.Errorf("observation %+v outside the range or empty", o)
+			break
+		}
+	}
+
+	forecast, err := weather.GetForecast(station.StationID, "fake")
+	if err != nil {
+		t.Fatalf("GetForecast: %v", err)
+	}
+	if len(forecast.Forecast.Daily) != 10 {
+		t.Errorf("got %d forecast days, want 10", len(forecast.Forecast.Daily))
+	}
+	if age := time.Since(time.Unix(forecast.CurrentConditions.Time, 0)); age < 0 || age > 2*time.Minute {
+		t.Errorf("current conditions are %v old, want current", age)
+	}
+
+	if n := s.Requests()["observations/device/{id}"]; n != 1 {
+		t.Errorf("device observation requests = %d, want 1", n)
+	}
+}
+
+func TestDeviceObservationBuckets(t *testing.T) {
+	s, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for span, step := range map[time.Duration]int{
+		2 * time.Hour:       1,
+		3 * 24 * time.Hour:  5,
+		10 * 24 * time.Hour: 30,
+	} {
+		url := BasePath + "/observations/device/67890?token=x&time_start=" + strconv.FormatInt(now.Add(-span).Unix(), 10) + "&time_end=" + strconv.FormatInt(now.Unix(), 10)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		var resp struct {
+			Step int             `json:"bucket_step_minutes"`
+			Obs  [][]interface{} `json:"obs"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%v: %v", span, err)
+		}
+		if resp.Step != step || len(resp.Obs) < int(span/time.Minute)/step-1 {
+			t.Errorf("%v: step %d with %d rows, want step %d", span, resp.Step, len(resp.Obs), step)
+		}
+	}
+}
+
+func TestFaults(t *testing.T) {
+	s, err := New(Options{ErrorRate: 1, ErrorStatus: http.StatusTooManyRequests})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	if rec := get(BasePath + "/stations?token=x"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("with error rate 1: status %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/fake/control", strings.NewReader(`{"errorRate":0,"failNext":1,"errorStatus":500}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("control: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := get(BasePath + "/stations?token=x"); rec.Code != http.StatusInternalServerError {
+		t.Errorf("failNext: status %d, want 500", rec.Code)
+	}
+	if rec := get(BasePath + "/stations?token=x"); rec.Code != http.StatusOK {
+		t.Errorf("after failNext: status %d, want 200", rec.Code)
+	}
+	if rec := get(BasePath + "/stations"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without token: status %d, want 401", rec.Code)
+	}
+	if rec := get(BasePath + "/stations/1?token=x"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown station: status %d, want 404", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/fake/control", strings.NewReader(`{"errorRate":2}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid error rate: status %d, want 400", rec.Code)
+	}
+	if got := s.Requests()["stations"]; got != 4 {
+		t.Errorf("stations requests = %d, want 4", got)
+	}
+}
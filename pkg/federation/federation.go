@@ -0,0 +1,291 @@
+// Package federation polls other tempest-homekit-go instances' /api/status
+// endpoints so one instance can show every site on a combined page and
+// evaluate cross-site alarms such as any_site.wind_gust > 50mph.
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// LocalSite is the name the local station goes by in cross-site alarms
+const LocalSite = "local"
+
+// namePattern keeps site names usable in alarm conditions (site.<name>.field)
+var namePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Site is a remote instance
+type Site struct {
+	Name string `json:"name"`
+	URL  string `json:"url"` // base URL of the web console, e.g. http://cabin.local:8080
+}
+
+// ParseSites parses --federate: comma-separated name=url pairs
+func ParseSites(list string) ([]Site, error) {
+	var sites []Site
+	seen := map[string]bool{LocalSite: true}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, rawURL, ok := strings.Cut(entry, "=")
+		name, rawURL = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(rawURL)
+		if !ok || rawURL == "" {
+			return nil, fmt.Errorf("site %q: use name=url", entry)
+		}
+		if !namePattern.MatchString(name) {
+			return nil, fmt.Errorf("site name %q must be 1-32 lowercase letters, digits, _ or -", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("site name %q is used twice (%q is the local station)", name, LocalSite)
+		}
+		seen[name] = true
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("site %s: invalid URL %q, use http://host:port", name, rawURL)
+		}
+		sites = append(sites, Site{Name: name, URL: strings.TrimRight(rawURL, "/")})
+	}
+	if len(sites) == 0 {
+		return nil, fmt.Errorf("no sites listed")
+	}
+	return sites, nil
+}
+
+// Reading is the latest observation of a site, in the units of /api/weather
+// (°C, %, mb, m/s, mm, lux)
+type Reading struct {
+	Temperature          float64 `json:"temperature"`
+	Humidity             float64 `json:"humidity"`
+	WindSpeed            float64 `json:"windSpeed"`
+	WindGust             float64 `json:"windGust"`
+	WindDirection        float64 `json:"windDirection"`
+	RainAccum            float64 `json:"rainAccum"`
+	RainRate             float64 `json:"rainRate"`
+	RainDailyTotal       float64 `json:"rainDailyTotal"`
+	PrecipitationType    int     `json:"precipitationType"`
+	Pressure             float64 `json:"pressure"`
+	Illuminance          float64 `json:"illuminance"`
+	UV                   int     `json:"uv"`
+	Battery              float64 `json:"battery"`
+	LightningStrikeAvg   float64 `json:"lightningStrikeAvg"`
+	LightningStrikeCount int     `json:"lightningStrikeCount"`
+	LastUpdate           string  `json:"lastUpdate"`
+}
+
+// Observation converts the reading for alarm evaluation
+func (r Reading) Observation() weather.Observation {
+	obs := weather.Observation{
+		AirTemperature:       r.Temperature,
+		RelativeHumidity:     r.Humidity,
+		WindAvg:              r.WindSpeed,
+		WindGust:             r.WindGust,
+		WindDirection:        r.WindDirection,
+		RainAccumulated:      r.RainAccum,
+		RainDailyTotal:       r.RainDailyTotal,
+		PrecipitationType:    r.PrecipitationType,
+		StationPressure:      r.Pressure,
+		Illuminance:          r.Illuminance,
+		UV:                   r.UV,
+		Battery:              r.Battery,
+		LightningStrikeAvg:   r.LightningStrikeAvg,
+		LightningStrikeCount: r.LightningStrikeCount,
+	}
+	if t, err := time.Parse(time.RFC3339, r.LastUpdate); err == nil {
+		obs.Timestamp = t.Unix()
+	}
+	return obs
+}
+
+// SiteStatus is the state of a remote site, as served by /api/federation
+type SiteStatus struct {
+	Name        string    `json:"name"`
+	URL         string    `json:"url"`
+	StationName string    `json:"stationName,omitempty"`
+	Online      bool      `json:"online"` // the last poll succeeded and the reading is fresh
+	LastPoll    time.Time `json:"lastPoll,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Reading     *Reading  `json:"reading,omitempty"`
+}
+
+// Options configures a Federation
+type Options struct {
+	Interval time.Duration // between polls, default 1m
+	MaxAge   time.Duration // readings older than this are not used, default 15m
+	Client   *http.Client  // default: 10s timeout
+}
+
+// Federation polls the remote sites
+type Federation struct {
+	sites []Site
+	opts  Options
+	now   func() time.Time
+
+	mu       sync.RWMutex
+	statuses map[string]*SiteStatus
+	onPoll   func()
+}
+
+// New creates a poller for sites
+func New(sites []Site, opts Options) *Federation {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = 15 * time.Minute
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	f := &Federation{sites: sites, opts: opts, now: time.Now, statuses: make(map[string]*SiteStatus)}
+	for _, s := range sites {
+		f.statuses[s.Name] = &SiteStatus{Name: s.Name, URL: s.URL}
+	}
+	return f
+}
+
+// OnPoll sets a function called after every round of polls, e.g. to
+// re-evaluate cross-site alarms
+func (f *Federation) OnPoll(fn func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onPoll = fn
+}
+
+// Run polls every site immediately and then every interval until ctx is
+// cancelled
+func (f *Federation) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.opts.Interval)
+	defer ticker.Stop()
+	for {
+		f.Poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll fetches every site once, concurrently
+func (f *Federation) Poll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, s := range f.sites {
+		wg.Add(1)
+		go func(s Site) {
+			defer wg.Done()
+			station, reading, err := f.fetch(ctx, s)
+			f.record(s, station, reading, err)
+		}(s)
+	}
+	wg.Wait()
+
+	f.mu.RLock()
+	onPoll := f.onPoll
+	f.mu.RUnlock()
+	if onPoll != nil {
+		onPoll()
+	}
+}
+
+// fetch reads the newest point of a site's /api/status. Only points of the
+// last MaxAge are requested, so the response stays small.
+func (f *Federation) fetch(ctx context.Context, s Site) (string, *Reading, error) {
+	since := f.now().Add(-f.opts.MaxAge).Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL+"/api/status?since="+strconv.FormatInt(since, 10), nil)
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := f.opts.Client.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("/api/status returned %s", resp.Status)
+	}
+	var status struct {
+		StationName string    `json:"stationName"`
+		DataHistory []Reading `json:"dataHistory"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return "", nil, fmt.Errorf("invalid /api/status response: %w", err)
+	}
+	if len(status.DataHistory) == 0 {
+		return status.StationName, nil, fmt.Errorf("no observation in the last %v", f.opts.MaxAge)
+	}
+	latest := status.DataHistory[len(status.DataHistory)-1]
+	return status.StationName, &latest, nil
+}
+
+// record stores the result of a poll; a failed poll keeps the last reading
+// for display but marks the site offline
+func (f *Federation) record(s Site, station string, reading *Reading, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status := f.statuses[s.Name]
+	status.LastPoll = f.now()
+	if station != "" {
+		status.StationName = station
+	}
+	if err != nil {
+		if status.Online || status.Error != err.Error() {
+			logger.Warn("Federation: site %s unavailable: %v", s.Name, err)
+		}
+		status.Online, status.Error = false, err.Error()
+		return
+	}
+	if !status.Online {
+		logger.Info("Federation: site %s online (%s)", s.Name, status.StationName)
+	}
+	status.Online, status.Error, status.Reading = true, "", reading
+}
+
+// Sites returns the state of every site in configuration order
+func (f *Federation) Sites() []SiteStatus {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	statuses := make([]SiteStatus, 0, len(f.sites))
+	for _, s := range f.sites {
+		status := *f.statuses[s.Name]
+		if status.Reading != nil {
+			reading := *status.Reading
+			status.Reading = &reading
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Observations returns the latest reading of every online site whose reading
+// is no older than MaxAge, by site name
+func (f *Federation) Observations() map[string]weather.Observation {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	oldest := f.now().Add(-f.opts.MaxAge).Unix()
+	observations := make(map[string]weather.Observation)
+	for name, status := range f.statuses {
+		if !status.Online || status.Reading == nil {
+			continue
+		}
+		obs := status.Reading.Observation()
+		if obs.Timestamp < oldest {
+			continue
+		}
+		observations[name] = obs
+	}
+	return observations
+}
@@ -0,0 +1,83 @@
+package federation
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSites(t *testing.T) {
+	sites, err := ParseSites(" Cabin=http://cabin.local:8080/ , beach_2=https://beach.example.com")
+	if err != nil {
+		t.Fatalf("ParseSites: %v", err)
+	}
+	if len(sites) != 2 || sites[0] != (Site{Name: "cabin", URL: "http://cabin.local:8080"}) || sites[1].Name != "beach_2" {
+		t.Errorf("sites = %+v", sites)
+	}
+
+	for _, bad := range []string{"", "cabin", "cabin=", "my cabin=http://x", "local=http://x", "a=http://x,a=http://y", "a=ftp://x", "a=http://"} {
+		if _, err := ParseSites(bad); err == nil {
+			t.Errorf("ParseSites(%q) accepted", bad)
+		}
+	}
+}
+
+func TestPoll(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	var gotSince string
+	fresh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSince = r.URL.Query().Get("since")
+		_, _ = io.WriteString(w, `{"stationName": "Cabin", "dataHistory": [
+			{"temperature": 5, "lastUpdate": "2026-10-17T11:50:00Z"},
+			{"temperature": 6, "windGust": 30, "uv": 3, "lastUpdate": "2026-10-17T11:58:00Z"}
+		]}`)
+	}))
+	defer fresh.Close()
+	stale := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"stationName": "Beach", "dataHistory": [{"temperature": 20, "lastUpdate": "2026-10-17T10:00:00Z"}]}`)
+	}))
+	defer stale.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer down.Close()
+
+	f := New([]Site{{Name: "cabin", URL: fresh.URL}, {Name: "beach", URL: stale.URL}, {Name: "barn", URL: down.URL}}, Options{})
+	f.now = func() time.Time { return now }
+	polled := 0
+	f.OnPoll(func() { polled++ })
+	f.Poll(context.Background())
+
+	if polled != 1 {
+		t.Errorf("OnPoll called %d times", polled)
+	}
+	if want := strconv.FormatInt(now.Add(-15*time.Minute).Unix(), 10); gotSince != want {
+		t.Errorf("since = %s, want %s", gotSince, want)
+	}
+
+	sites := f.Sites()
+	if len(sites) != 3 || sites[0].Name != "cabin" || !sites[0].Online || sites[0].StationName != "Cabin" || sites[0].Reading.Temperature != 6 {
+		t.Errorf("cabin = %+v", sites[0])
+	}
+	if sites[2].Online || !strings.Contains(sites[2].Error, "500") {
+		t.Errorf("barn = %+v, want offline with the status", sites[2])
+	}
+
+	obs := f.Observations()
+	if len(obs) != 1 {
+		t.Fatalf("observations = %+v, want only the fresh site", obs)
+	}
+	if o := obs["cabin"]; o.WindGust != 30 || o.UV != 3 || o.Timestamp != now.Add(-2*time.Minute).Unix() {
+		t.Errorf("cabin observation = %+v", o)
+	}
+
+	f.now = func() time.Time { return now.Add(20 * time.Minute) }
+	if obs := f.Observations(); len(obs) != 0 {
+		t.Errorf("observations after 20m = %+v, want none", obs)
+	}
+}
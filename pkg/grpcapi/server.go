@@ -0,0 +1,200 @@
+// Package grpcapi serves the Tempest gRPC API defined in api/proto/tempest.proto.
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "tempest-homekit-go/api/proto"
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// streamBuffer is the number of observations queued per streaming client
+const streamBuffer = 32
+
+// ObservationSource provides live observations to streaming clients. The
+// service event bus observation topic satisfies it.
+type ObservationSource interface {
+	Subscribe(name string, buffer int) (<-chan weather.Observation, func())
+}
+
+// AlarmSource is the subset of the alarm manager used by the API
+type AlarmSource interface {
+	GetConfig() *alarm.AlarmConfig
+	TriggerTest(name string, obs *weather.Observation) (int, []error, error)
+}
+
+// Server implements the Tempest gRPC service
+type Server struct {
+	pb.UnimplementedTempestServer
+
+	observations ObservationSource
+	alarms       AlarmSource // nil when alarms are disabled
+	grpcServer   *grpc.Server
+
+	mu     sync.RWMutex
+	latest *weather.Observation
+}
+
+// NewServer creates a gRPC API server. alarms may be nil.
+func NewServer(observations ObservationSource, alarms AlarmSource) *Server {
+	s := &Server{observations: observations, alarms: alarms}
+	s.grpcServer = grpc.NewServer()
+	pb.RegisterTempestServer(s.grpcServer, s)
+	return s
+}
+
+// UpdateObservation records the latest observation returned by GetCurrent
+func (s *Server) UpdateObservation(obs weather.Observation) {
+	s.mu.Lock()
+	s.latest = &obs
+	s.mu.Unlock()
+}
+
+func (s *Server) current() *weather.Observation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.latest
+}
+
+// Start listens on the given port and serves until Stop is called
+func (s *Server) Start(port string) error {
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return fmt.Errorf("failed to listen on gRPC port %s: %w", port, err)
+	}
+	logger.Info("gRPC API listening on :%s", port)
+	return s.Serve(lis)
+}
+
+// Serve serves on an existing listener
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpcServer.Serve(lis)
+}
+
+// Stop closes open streams and stops the server
+func (s *Server) Stop() {
+	s.grpcServer.Stop()
+}
+
+// GetCurrent returns the most recent observation
+func (s *Server) GetCurrent(ctx context.Context, _ *pb.GetCurrentRequest) (*pb.Observation, error) {
+	obs := s.current()
+	if obs == nil {
+		return nil, status.Error(codes.Unavailable, "no observation received yet")
+	}
+	return toProtoObservation(obs), nil
+}
+
+// StreamObservations sends the latest observation, then every new one until
+// the client disconnects. Slow clients miss observations rather than blocking
+// the service.
+func (s *Server) StreamObservations(_ *pb.StreamObservationsRequest, stream pb.Tempest_StreamObservationsServer) error {
+	if s.observations == nil {
+		return status.Error(codes.Unavailable, "observation stream not available")
+	}
+	ch, unsubscribe := s.observations.Subscribe("grpc-stream", streamBuffer)
+	defer unsubscribe()
+
+	if obs := s.current(); obs != nil {
+		if err := stream.Send(toProtoObservation(obs)); err != nil {
+			return err
+		}
+	}
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case obs, ok := <-ch:
+			if !ok {
+				return status.Error(codes.Unavailable, "observation stream closed")
+			}
+			if err := stream.Send(toProtoObservation(&obs)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ListAlarms returns the configured alarms
+func (s *Server) ListAlarms(ctx context.Context, _ *pb.ListAlarmsRequest) (*pb.ListAlarmsResponse, error) {
+	if s.alarms == nil {
+		return nil, status.Error(codes.FailedPrecondition, "alarms are not configured")
+	}
+	cfg := s.alarms.GetConfig()
+	resp := &pb.ListAlarmsResponse{}
+	for _, a := range cfg.Alarms {
+		channels := make([]string, 0, len(a.Channels))
+		for _, ch := range a.Channels {
+			channels = append(channels, ch.Type)
+		}
+		resp.Alarms = append(resp.Alarms, &pb.Alarm{
+			Name:            a.Name,
+			Description:     a.Description,
+			Condition:       a.Condition,
+			Enabled:         a.Enabled,
+			Tags:            a.Tags,
+			CooldownSeconds: int32(a.Cooldown),
+			Channels:        channels,
+			TriggeredCount:  int32(a.TriggeredCount),
+		})
+	}
+	return resp, nil
+}
+
+// TriggerTest sends a test notification for one alarm using the latest observation
+func (s *Server) TriggerTest(ctx context.Context, req *pb.TriggerTestRequest) (*pb.TriggerTestResponse, error) {
+	if s.alarms == nil {
+		return nil, status.Error(codes.FailedPrecondition, "alarms are not configured")
+	}
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "alarm name is required")
+	}
+	obs := s.current()
+	if obs == nil {
+		return nil, status.Error(codes.Unavailable, "no observation received yet")
+	}
+
+	attempted, errs, err := s.alarms.TriggerTest(req.GetName(), obs)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	resp := &pb.TriggerTestResponse{Channels: int32(attempted)}
+	for _, e := range errs {
+		resp.Errors = append(resp.Errors, e.Error())
+	}
+	return resp, nil
+}
+
+func toProtoObservation(obs *weather.Observation) *pb.Observation {
+	return &pb.Observation{
+		Timestamp:                  obs.Timestamp,
+		WindLull:                   obs.WindLull,
+		WindAvg:                    obs.WindAvg,
+		WindGust:                   obs.WindGust,
+		WindDirection:              obs.WindDirection,
+		StationPressure:            obs.StationPressure,
+		AirTemperature:             obs.AirTemperature,
+		RelativeHumidity:           obs.RelativeHumidity,
+		Illuminance:                obs.Illuminance,
+		Uv:                         int32(obs.UV),
+		SolarRadiation:             obs.SolarRadiation,
+		RainAccumulated:            obs.RainAccumulated,
+		RainDailyTotal:             obs.RainDailyTotal,
+		PrecipitationType:          int32(obs.PrecipitationType),
+		LightningStrikeAvgDistance: obs.LightningStrikeAvg,
+		LightningStrikeCount:       int32(obs.LightningStrikeCount),
+		Battery:                    obs.Battery,
+		ReportInterval:             int32(obs.ReportInterval),
+	}
+}
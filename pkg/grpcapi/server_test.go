@@ -0,0 +1,126 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "tempest-homekit-go/api/proto"
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/weather"
+)
+
+type fakeObservations struct {
+	subscribed chan chan weather.Observation
+}
+
+func (f *fakeObservations) Subscribe(name string, buffer int) (<-chan weather.Observation, func()) {
+	ch := make(chan weather.Observation, buffer)
+	f.subscribed <- ch
+	return ch, func() {}
+}
+
+type fakeAlarms struct {
+	triggered string
+}
+
+func (f *fakeAlarms) GetConfig() *alarm.AlarmConfig {
+	return &alarm.AlarmConfig{Alarms: []alarm.Alarm{{
+		Name:      "hot",
+		Condition: "temperature > 30",
+		Enabled:   true,
+		Channels:  []alarm.Channel{{Type: "console"}, {Type: "email"}},
+	}}}
+}
+
+func (f *fakeAlarms) TriggerTest(name string, obs *weather.Observation) (int, []error, error) {
+	if name != "hot" {
+		return 0, nil, errors.New("alarm not found")
+	}
+	f.triggered = name
+	return 2, []error{errors.New("email: smtp down")}, nil
+}
+
+func newTestClient(t *testing.T, srv *Server) pb.TempestClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return pb.NewTempestClient(conn)
+}
+
+func TestGetCurrentAndStream(t *testing.T) {
+	source := &fakeObservations{subscribed: make(chan chan weather.Observation, 1)}
+	srv := NewServer(source, nil)
+	client := newTestClient(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.GetCurrent(ctx, &pb.GetCurrentRequest{}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable before first observation, got %v", err)
+	}
+
+	srv.UpdateObservation(weather.Observation{Timestamp: 10, AirTemperature: 21.5, UV: 3})
+	obs, err := client.GetCurrent(ctx, &pb.GetCurrentRequest{})
+	if err != nil || obs.Timestamp != 10 || obs.AirTemperature != 21.5 || obs.Uv != 3 {
+		t.Fatalf("unexpected current observation %v, err %v", obs, err)
+	}
+
+	stream, err := client.StreamObservations(ctx, &pb.StreamObservationsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first, err := stream.Recv(); err != nil || first.Timestamp != 10 {
+		t.Fatalf("expected latest observation first, got %v err %v", first, err)
+	}
+	ch := <-source.subscribed
+	ch <- weather.Observation{Timestamp: 20}
+	if next, err := stream.Recv(); err != nil || next.Timestamp != 20 {
+		t.Fatalf("expected streamed observation, got %v err %v", next, err)
+	}
+
+	if _, err := client.ListAlarms(ctx, &pb.ListAlarmsRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition without alarms, got %v", err)
+	}
+}
+
+func TestListAlarmsAndTriggerTest(t *testing.T) {
+	alarms := &fakeAlarms{}
+	srv := NewServer(nil, alarms)
+	client := newTestClient(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	list, err := client.ListAlarms(ctx, &pb.ListAlarmsRequest{})
+	if err != nil || len(list.Alarms) != 1 || list.Alarms[0].Name != "hot" || len(list.Alarms[0].Channels) != 2 {
+		t.Fatalf("unexpected alarms %v, err %v", list, err)
+	}
+
+	srv.UpdateObservation(weather.Observation{Timestamp: 1})
+	resp, err := client.TriggerTest(ctx, &pb.TriggerTestRequest{Name: "hot"})
+	if err != nil || resp.Channels != 2 || len(resp.Errors) != 1 || alarms.triggered != "hot" {
+		t.Fatalf("unexpected trigger response %v, err %v", resp, err)
+	}
+	if _, err := client.TriggerTest(ctx, &pb.TriggerTestRequest{Name: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+	if _, err := client.TriggerTest(ctx, &pb.TriggerTestRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", err)
+	}
+}
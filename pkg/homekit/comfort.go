@@ -0,0 +1,81 @@
+package homekit
+
+import (
+	"sync"
+
+	"tempest-homekit-go/pkg/logger"
+
+	"github.com/brutella/hap/accessory"
+	"github.com/brutella/hap/characteristic"
+	"github.com/brutella/hap/service"
+)
+
+// comfortAccessory exposes the comfort advisor as an "Open Windows" switch
+// that is on while ventilating with outdoor air is recommended, so Home
+// automations can react to it. Switching it by hand is undone, since the
+// switch only reports the advice.
+type comfortAccessory struct {
+	accessory *accessory.A // nil in combined mode, where the switch joins the station accessory
+	service   *service.Switch
+
+	mu   sync.Mutex
+	open bool
+}
+
+// comfortInfo describes the split-mode comfort advisor accessory
+var comfortInfo = accessory.Info{
+	Name:         "Comfort Advisor",
+	SerialNumber: "TWB-COMFORT-001",
+	Manufacturer: "WeatherFlow",
+	Model:        "Tempest Comfort Advisor",
+	Firmware:     "1.0.0",
+}
+
+// newComfortAccessory creates the switch service; the caller places it on an
+// accessory
+func newComfortAccessory() *comfortAccessory {
+	c := &comfortAccessory{service: service.NewSwitch()}
+	name := characteristic.NewName()
+	name.SetValue("Open Windows")
+	c.service.AddC(name.C)
+	c.service.On.SetValue(false)
+	c.service.On.OnValueRemoteUpdate(func(on bool) {
+		c.mu.Lock()
+		open := c.open
+		c.mu.Unlock()
+		if on != open {
+			logger.Info("Open Windows switch is set by the comfort advisor; restoring it")
+			c.service.On.SetValue(open)
+		}
+	})
+	return c
+}
+
+func (c *comfortAccessory) update(open bool) {
+	c.mu.Lock()
+	c.open = open
+	c.mu.Unlock()
+	if c.service.On.Value() != open {
+		c.service.On.SetValue(open)
+	}
+}
+
+// HasComfortAdvisor reports whether the comfort advisor switch is enabled
+func (ws *WeatherSystemModern) HasComfortAdvisor() bool {
+	return ws.comfort != nil
+}
+
+// UpdateComfort sets the Open Windows switch. It is a no-op when the comfort
+// advisor is not enabled.
+func (ws *WeatherSystemModern) UpdateComfort(openWindows bool) {
+	ws.stateMu.Lock()
+	c := ws.comfort // replaced by Reset
+	ws.stateMu.Unlock()
+	if c == nil {
+		return
+	}
+	if ws.LogLevel == "debug" {
+		logger.Debug("Updating comfort advisor: open windows %v", openWindows)
+	}
+	c.update(openWindows)
+}
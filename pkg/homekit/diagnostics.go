@@ -0,0 +1,154 @@
+package homekit
+
+import (
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+
+	"github.com/brutella/hap/accessory"
+	"github.com/brutella/hap/characteristic"
+	"github.com/brutella/hap/service"
+)
+
+// Custom service and characteristics for bridge diagnostics. Apps that show
+// custom characteristics (Eve, Controller for HomeKit) display them directly;
+// the Home app only shows the standard services mapped below.
+const (
+	TypeBridgeDiagnostics = "F010-0001-1000-8000-0026BB765291"
+	TypeDataAge           = "F011-0001-1000-8000-0026BB765291"
+	TypeDataSource        = "F012-0001-1000-8000-0026BB765291"
+	TypeUptime            = "F013-0001-1000-8000-0026BB765291"
+)
+
+// DiagnosticsStaleAfter is the data age at which the diagnostics accessory
+// reports the data feed as interrupted
+const DiagnosticsStaleAfter = 10 * time.Minute
+
+// Diagnostics is the bridge state shown by the diagnostics accessory
+type Diagnostics struct {
+	DataAge    time.Duration // time since the last observation
+	DataSource string        // udp, api, generated, custom-url or ingest
+	Uptime     time.Duration
+}
+
+// diagnosticsAccessory exposes bridge health in the Home app:
+//   - a contact sensor that is "closed" while observations are arriving and
+//     "open" (with a fault) once they are older than DiagnosticsStaleAfter, so
+//     it can drive Home automations and notifications
+//   - a light sensor whose reading is the data age in minutes
+//   - a custom service with data age (seconds), data source and uptime (seconds)
+type diagnosticsAccessory struct {
+	accessory   *accessory.A // nil in combined mode, where services join the station accessory
+	services    []*service.S
+	dataFlowing *characteristic.ContactSensorState
+	fault       *characteristic.StatusFault
+	ageMinutes  *characteristic.Float
+	dataAge     *characteristic.Float
+	dataSource  *characteristic.String
+	uptime      *characteristic.Float
+}
+
+// diagnosticsInfo describes the split-mode diagnostics accessory
+var diagnosticsInfo = accessory.Info{
+	Name:         "Bridge Diagnostics",
+	SerialNumber: "TWB-DIAG-001",
+	Manufacturer: "WeatherFlow",
+	Model:        "Tempest Bridge Diagnostics",
+	Firmware:     "1.0.0",
+}
+
+// newDiagnosticsAccessory creates the diagnostics services; the caller places
+// them on an accessory
+func newDiagnosticsAccessory() *diagnosticsAccessory {
+	d := &diagnosticsAccessory{}
+
+	contact := service.NewContactSensor()
+	d.dataFlowing = contact.ContactSensorState
+	_ = d.dataFlowing.SetValue(characteristic.ContactSensorStateContactNotDetected) // no data yet
+	d.fault = characteristic.NewStatusFault()
+	contact.AddC(d.fault.C)
+	contactName := characteristic.NewName()
+	contactName.SetValue("Data Flowing")
+	contact.AddC(contactName.C)
+	d.services = append(d.services, contact.S)
+
+	// Data age in minutes, using the light sensor service like the pressure
+	// and UV accessories so the value is visible in the Home app
+	age := service.NewLightSensor()
+	age.CurrentAmbientLightLevel.Description = "Data Age (minutes)"
+	age.CurrentAmbientLightLevel.Unit = "min"
+	age.CurrentAmbientLightLevel.SetMinValue(0.0)
+	age.CurrentAmbientLightLevel.SetMaxValue(100000.0)
+	age.CurrentAmbientLightLevel.SetStepValue(0.1)
+	age.CurrentAmbientLightLevel.SetValue(0.0)
+	d.ageMinutes = age.CurrentAmbientLightLevel.Float
+	ageName := characteristic.NewName()
+	ageName.SetValue("Data Age")
+	age.AddC(ageName.C)
+	d.services = append(d.services, age.S)
+
+	diag := service.New(TypeBridgeDiagnostics)
+	d.dataAge = newReadOnlyFloat(TypeDataAge, "Data Age", "seconds")
+	d.uptime = newReadOnlyFloat(TypeUptime, "Uptime", "seconds")
+	d.dataSource = characteristic.NewString(TypeDataSource)
+	d.dataSource.Description = "Data Source"
+	d.dataSource.Permissions = []string{characteristic.PermissionRead, characteristic.PermissionEvents}
+	d.dataSource.SetValue("")
+	diag.AddC(d.dataAge.C)
+	diag.AddC(d.dataSource.C)
+	diag.AddC(d.uptime.C)
+	d.services = append(d.services, diag)
+
+	return d
+}
+
+func newReadOnlyFloat(typ, description, unit string) *characteristic.Float {
+	c := characteristic.NewFloat(typ)
+	c.Format = characteristic.FormatFloat
+	c.Description = description
+	c.Unit = unit
+	c.Permissions = []string{characteristic.PermissionRead, characteristic.PermissionEvents}
+	c.SetMinValue(0.0)
+	c.SetMaxValue(1e9)
+	c.SetStepValue(1.0)
+	c.SetValue(0.0)
+	return c
+}
+
+func (d *diagnosticsAccessory) update(diag Diagnostics) {
+	stale := diag.DataAge > DiagnosticsStaleAfter
+	if stale {
+		_ = d.dataFlowing.SetValue(characteristic.ContactSensorStateContactNotDetected)
+		_ = d.fault.SetValue(characteristic.StatusFaultGeneralFault)
+	} else {
+		_ = d.dataFlowing.SetValue(characteristic.ContactSensorStateContactDetected)
+		_ = d.fault.SetValue(characteristic.StatusFaultNoFault)
+	}
+	d.ageMinutes.SetValue(diag.DataAge.Minutes())
+	d.dataAge.SetValue(diag.DataAge.Seconds())
+	d.uptime.SetValue(diag.Uptime.Seconds())
+	if d.dataSource.Value() != diag.DataSource {
+		d.dataSource.SetValue(diag.DataSource)
+	}
+}
+
+// HasDiagnostics reports whether the diagnostics accessory is enabled
+func (ws *WeatherSystemModern) HasDiagnostics() bool {
+	return ws.diagnostics != nil
+}
+
+// UpdateDiagnostics refreshes the diagnostics accessory. It is a no-op when
+// the accessory is not enabled.
+func (ws *WeatherSystemModern) UpdateDiagnostics(diag Diagnostics) {
+	ws.stateMu.Lock()
+	d := ws.diagnostics // replaced by Reset
+	ws.stateMu.Unlock()
+	if d == nil {
+		return
+	}
+	if ws.LogLevel == "debug" {
+		logger.Debug("Updating bridge diagnostics: data age %v, source %s, uptime %v",
+			diag.DataAge.Round(time.Second), diag.DataSource, diag.Uptime.Round(time.Second))
+	}
+	d.update(diag)
+}
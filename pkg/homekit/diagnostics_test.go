@@ -0,0 +1,58 @@
+package homekit
+
+import (
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/config"
+
+	"github.com/brutella/hap"
+	"github.com/brutella/hap/characteristic"
+)
+
+func TestDiagnosticsAccessory(t *testing.T) {
+	cfg := config.SensorConfig{Temperature: true, Diagnostics: true}
+	ws, err := NewWeatherSystemModern("00102003", &cfg, "info", Options{Store: hap.NewMemStore()})
+	if err != nil {
+		t.Fatalf("NewWeatherSystemModern returned error: %v", err)
+	}
+	if !ws.HasDiagnostics() {
+		t.Fatal("expected diagnostics accessory to be enabled")
+	}
+	d := ws.diagnostics
+
+	ws.UpdateDiagnostics(Diagnostics{DataAge: 90 * time.Second, DataSource: "udp", Uptime: time.Hour})
+	if got := d.dataFlowing.Value(); got != characteristic.ContactSensorStateContactDetected {
+		t.Errorf("fresh data: contact state = %d, want detected", got)
+	}
+	if got := d.ageMinutes.Value(); got != 1.5 {
+		t.Errorf("age in minutes = %v, want 1.5", got)
+	}
+	if got := d.dataSource.Value(); got != "udp" {
+		t.Errorf("data source = %q, want udp", got)
+	}
+	if got := d.uptime.Value(); got != 3600 {
+		t.Errorf("uptime = %v, want 3600", got)
+	}
+
+	ws.UpdateDiagnostics(Diagnostics{DataAge: DiagnosticsStaleAfter + time.Minute, DataSource: "api"})
+	if got := d.dataFlowing.Value(); got != characteristic.ContactSensorStateContactNotDetected {
+		t.Errorf("stale data: contact state = %d, want not detected", got)
+	}
+	if got := d.fault.Value(); got != characteristic.StatusFaultGeneralFault {
+		t.Errorf("stale data: status fault = %d, want general fault", got)
+	}
+}
+
+func TestDiagnosticsDisabled(t *testing.T) {
+	cfg := config.SensorConfig{Temperature: true}
+	ws, err := NewWeatherSystemModern("00102003", &cfg, "info", Options{Store: hap.NewMemStore()})
+	if err != nil {
+		t.Fatalf("NewWeatherSystemModern returned error: %v", err)
+	}
+	if ws.HasDiagnostics() {
+		t.Fatal("diagnostics accessory should be off by default")
+	}
+	// Must not panic
+	ws.UpdateDiagnostics(Diagnostics{DataAge: time.Minute})
+}
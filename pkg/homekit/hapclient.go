@@ -0,0 +1,373 @@
+package homekit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/brutella/hap/chacha20poly1305"
+	"github.com/brutella/hap/curve25519"
+	hapEd25519 "github.com/brutella/hap/ed25519"
+	"github.com/brutella/hap/hkdf"
+	"github.com/brutella/hap/tlv8"
+	"github.com/tadglines/go-pkgs/crypto/srp"
+)
+
+// hapController is a minimal HomeKit controller, enough to pair with a
+// bridge, verify the pairing and read over the encrypted session, the way the
+// Home app does. It is used by the pairing self test.
+type hapController struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	id         string
+	publicKey  ed25519.PublicKey
+	privateKey ed25519.PrivateKey
+
+	accessoryID  string
+	accessoryKey []byte
+
+	// Session keys after pair verify; nil before
+	writeKey, readKey     []byte
+	writeCount, readCount uint64
+}
+
+// hapPayload decodes the TLV8 answers of pair setup and pair verify. Requests
+// use structs without optional items, which tlv8.Marshal does not encode
+// correctly.
+type hapPayload struct {
+	Method        byte   `tlv8:"0,optional"`
+	Identifier    string `tlv8:"1,optional"`
+	Salt          []byte `tlv8:"2,optional"`
+	PublicKey     []byte `tlv8:"3,optional"`
+	Proof         []byte `tlv8:"4,optional"`
+	EncryptedData []byte `tlv8:"5,optional"`
+	State         byte   `tlv8:"6,optional"`
+	Error         byte   `tlv8:"7,optional"`
+	Signature     []byte `tlv8:"10,optional"`
+}
+
+// hapEncrypted is the M5 / M3 request carrying an encrypted sub-TLV
+type hapEncrypted struct {
+	State         byte   `tlv8:"6"`
+	EncryptedData []byte `tlv8:"5"`
+}
+
+// hapFrameMax is the largest plaintext in one encrypted frame
+const hapFrameMax = 1024
+
+// dialHAP connects to the bridge at addr with a new controller identity
+func dialHAP(addr string) (*hapController, error) {
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &hapController{
+		conn:       conn,
+		r:          bufio.NewReader(conn),
+		id:         "tempest-selftest",
+		publicKey:  public,
+		privateKey: private,
+	}, nil
+}
+
+func (c *hapController) Close() error {
+	return c.conn.Close()
+}
+
+// pairSetup runs pair setup M1-M6 with the given 8-digit PIN
+func (c *hapController) pairSetup(pin string) error {
+	if len(pin) != 8 {
+		return fmt.Errorf("invalid PIN %q", pin)
+	}
+	m2, err := c.exchange("/pair-setup", struct {
+		State  byte `tlv8:"6"`
+		Method byte `tlv8:"0"`
+	}{State: 1})
+	if err != nil {
+		return fmt.Errorf("M1: %w", err)
+	}
+
+	// SRP-6a with SHA-512, the 3072-bit group and the formatted PIN
+	username := []byte("Pair-Setup")
+	s, err := srp.NewSRP("rfc5054.3072", sha512.New, func(salt, password []byte) []byte {
+		h := sha512.New()
+		h.Write(username)
+		h.Write([]byte(":"))
+		h.Write(password)
+		inner := h.Sum(nil)
+		h.Reset()
+		h.Write(salt)
+		h.Write(inner)
+		return h.Sum(nil)
+	})
+	if err != nil {
+		return err
+	}
+	session := s.NewClientSession(username, []byte(pin[:3]+"-"+pin[3:5]+"-"+pin[5:]))
+	secret, err := session.ComputeKey(m2.Salt, m2.PublicKey)
+	if err != nil {
+		return fmt.Errorf("M2: %w", err)
+	}
+
+	m4, err := c.exchange("/pair-setup", struct {
+		State     byte   `tlv8:"6"`
+		PublicKey []byte `tlv8:"3"`
+		Proof     []byte `tlv8:"4"`
+	}{3, session.GetA(), session.ComputeAuthenticator()})
+	if err != nil {
+		return fmt.Errorf("M3: %w (wrong PIN?)", err)
+	}
+	if !session.VerifyServerAuthenticator(m4.Proof) {
+		return fmt.Errorf("M4: accessory proof is invalid")
+	}
+
+	// M5: our long-term key, signed, encrypted with the SRP session key
+	encKey, err := hkdf.Sha512(secret, []byte("Pair-Setup-Encrypt-Salt"), []byte("Pair-Setup-Encrypt-Info"))
+	if err != nil {
+		return err
+	}
+	signKey, err := hkdf.Sha512(secret, []byte("Pair-Setup-Controller-Sign-Salt"), []byte("Pair-Setup-Controller-Sign-Info"))
+	if err != nil {
+		return err
+	}
+	signed := append(append(signKey[:], c.id...), c.publicKey...)
+	sub, err := tlv8.Marshal(struct {
+		Identifier string `tlv8:"1"`
+		PublicKey  []byte `tlv8:"3"`
+		Signature  []byte `tlv8:"10"`
+	}{c.id, c.publicKey, ed25519.Sign(c.privateKey, signed)})
+	if err != nil {
+		return err
+	}
+	m6, err := c.exchange("/pair-setup", hapEncrypted{5, seal(encKey[:], "PS-Msg05", sub)})
+	if err != nil {
+		return fmt.Errorf("M5: %w", err)
+	}
+
+	// M6: the accessory's long-term key, signed the same way
+	plain, err := open(encKey[:], "PS-Msg06", m6.EncryptedData)
+	if err != nil {
+		return fmt.Errorf("M6: %w", err)
+	}
+	var accessory hapPayload
+	if err := tlv8.Unmarshal(plain, &accessory); err != nil {
+		return fmt.Errorf("M6: %w", err)
+	}
+	signKey, err = hkdf.Sha512(secret, []byte("Pair-Setup-Accessory-Sign-Salt"), []byte("Pair-Setup-Accessory-Sign-Info"))
+	if err != nil {
+		return err
+	}
+	signed = append(append(signKey[:], accessory.Identifier...), accessory.PublicKey...)
+	if !hapEd25519.ValidateSignature(accessory.PublicKey, signed, accessory.Signature) {
+		return fmt.Errorf("M6: accessory signature is invalid")
+	}
+	c.accessoryID, c.accessoryKey = accessory.Identifier, accessory.PublicKey
+	return nil
+}
+
+// pairVerify runs pair verify M1-M4 and switches the connection to the
+// encrypted session
+func (c *hapController) pairVerify() error {
+	public, private := curve25519.GenerateKeyPair()
+	m2, err := c.exchange("/pair-verify", struct {
+		State     byte   `tlv8:"6"`
+		PublicKey []byte `tlv8:"3"`
+	}{1, public[:]})
+	if err != nil {
+		return fmt.Errorf("M1: %w", err)
+	}
+	var accessoryPublic [32]byte
+	copy(accessoryPublic[:], m2.PublicKey)
+	shared := curve25519.SharedSecret(private, accessoryPublic)
+	encKey, err := hkdf.Sha512(shared[:], []byte("Pair-Verify-Encrypt-Salt"), []byte("Pair-Verify-Encrypt-Info"))
+	if err != nil {
+		return err
+	}
+
+	plain, err := open(encKey[:], "PV-Msg02", m2.EncryptedData)
+	if err != nil {
+		return fmt.Errorf("M2: %w", err)
+	}
+	var accessory hapPayload
+	if err := tlv8.Unmarshal(plain, &accessory); err != nil {
+		return fmt.Errorf("M2: %w", err)
+	}
+	signed := append(append(append([]byte{}, m2.PublicKey...), accessory.Identifier...), public[:]...)
+	if accessory.Identifier != c.accessoryID || !hapEd25519.ValidateSignature(c.accessoryKey, signed, accessory.Signature) {
+		return fmt.Errorf("M2: accessory %q is not the one paired with", accessory.Identifier)
+	}
+
+	signed = append(append(append([]byte{}, public[:]...), c.id...), m2.PublicKey...)
+	sub, err := tlv8.Marshal(struct {
+		Identifier string `tlv8:"1"`
+		Signature  []byte `tlv8:"10"`
+	}{c.id, ed25519.Sign(c.privateKey, signed)})
+	if err != nil {
+		return err
+	}
+	if _, err := c.exchange("/pair-verify", hapEncrypted{3, seal(encKey[:], "PV-Msg03", sub)}); err != nil {
+		return fmt.Errorf("M3: %w", err)
+	}
+
+	write, err := hkdf.Sha512(shared[:], []byte("Control-Salt"), []byte("Control-Write-Encryption-Key"))
+	if err != nil {
+		return err
+	}
+	read, err := hkdf.Sha512(shared[:], []byte("Control-Salt"), []byte("Control-Read-Encryption-Key"))
+	if err != nil {
+		return err
+	}
+	c.writeKey, c.readKey = write[:], read[:]
+	c.r = bufio.NewReader(&hapSecureReader{c: c, raw: c.r})
+	return nil
+}
+
+// exchange posts a TLV8 payload and decodes the TLV8 answer, failing on an
+// HTTP or TLV error
+func (c *hapController) exchange(path string, payload interface{}) (hapPayload, error) {
+	body, err := tlv8.Marshal(payload)
+	if err != nil {
+		return hapPayload{}, err
+	}
+	status, data, err := c.do(http.MethodPost, path, "application/pairing+tlv8", body)
+	if err != nil {
+		return hapPayload{}, err
+	}
+	var resp hapPayload
+	if err := tlv8.Unmarshal(data, &resp); err != nil {
+		return hapPayload{}, fmt.Errorf("HTTP %d: %w", status, err)
+	}
+	if resp.Error != 0 {
+		return hapPayload{}, fmt.Errorf("TLV error %d", resp.Error)
+	}
+	if status != http.StatusOK {
+		return hapPayload{}, fmt.Errorf("HTTP %d", status)
+	}
+	return resp, nil
+}
+
+// get requests path over the encrypted session
+func (c *hapController) get(path string) (int, []byte, error) {
+	if c.writeKey == nil {
+		return 0, nil, fmt.Errorf("not verified")
+	}
+	return c.do(http.MethodGet, path, "", nil)
+}
+
+// do sends one HTTP/1.1 request on the connection and reads the response
+func (c *hapController) do(method, path, contentType string, body []byte) (int, []byte, error) {
+	var req bytes.Buffer
+	fmt.Fprintf(&req, "%s %s HTTP/1.1\r\nHost: %s\r\nContent-Length: %d\r\n", method, path, c.conn.RemoteAddr(), len(body))
+	if contentType != "" {
+		fmt.Fprintf(&req, "Content-Type: %s\r\n", contentType)
+	}
+	req.WriteString("\r\n")
+	req.Write(body)
+
+	_ = c.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if err := c.write(req.Bytes()); err != nil {
+		return 0, nil, err
+	}
+	resp, err := http.ReadResponse(c.r, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, data, err
+}
+
+// write sends data, in encrypted frames once the session is verified
+func (c *hapController) write(data []byte) error {
+	if c.writeKey == nil {
+		_, err := c.conn.Write(data)
+		return err
+	}
+	var out bytes.Buffer
+	for len(data) > 0 {
+		n := len(data)
+		if n > hapFrameMax {
+			n = hapFrameMax
+		}
+		length := make([]byte, 2)
+		binary.LittleEndian.PutUint16(length, uint16(n))
+		sealed, mac, err := chacha20poly1305.EncryptAndSeal(c.writeKey, counterNonce(c.writeCount), data[:n], length)
+		if err != nil {
+			return err
+		}
+		c.writeCount++
+		out.Write(length)
+		out.Write(sealed)
+		out.Write(mac[:])
+		data = data[n:]
+	}
+	_, err := c.conn.Write(out.Bytes())
+	return err
+}
+
+// hapSecureReader decrypts the accessory's frames
+type hapSecureReader struct {
+	c   *hapController
+	raw *bufio.Reader // the connection's reader from before pair verify
+	buf bytes.Buffer
+}
+
+func (s *hapSecureReader) Read(p []byte) (int, error) {
+	for s.buf.Len() == 0 {
+		var length uint16
+		if err := binary.Read(s.raw, binary.LittleEndian, &length); err != nil {
+			return 0, err
+		}
+		frame := make([]byte, int(length)+16)
+		if _, err := io.ReadFull(s.raw, frame); err != nil {
+			return 0, err
+		}
+		var mac [16]byte
+		copy(mac[:], frame[length:])
+		aad := make([]byte, 2)
+		binary.LittleEndian.PutUint16(aad, length)
+		plain, err := chacha20poly1305.DecryptAndVerify(s.c.readKey, counterNonce(s.c.readCount), frame[:length], mac, aad)
+		if err != nil {
+			return 0, fmt.Errorf("decrypting frame %d: %w", s.c.readCount, err)
+		}
+		s.c.readCount++
+		s.buf.Write(plain)
+	}
+	return s.buf.Read(p)
+}
+
+func counterNonce(n uint64) []byte {
+	nonce := make([]byte, 8)
+	binary.LittleEndian.PutUint64(nonce, n)
+	return nonce
+}
+
+// seal encrypts a pairing sub-TLV and appends its tag
+func seal(key []byte, nonce string, data []byte) []byte {
+	sealed, mac, _ := chacha20poly1305.EncryptAndSeal(key, []byte(nonce), data, nil)
+	return append(sealed, mac[:]...)
+}
+
+// open decrypts a pairing sub-TLV sealed with seal
+func open(key []byte, nonce string, data []byte) ([]byte, error) {
+	if len(data) < 16 {
+		return nil, fmt.Errorf("encrypted data too short")
+	}
+	var mac [16]byte
+	copy(mac[:], data[len(data)-16:])
+	return chacha20poly1305.DecryptAndVerify(key, []byte(nonce), data[:len(data)-16], mac, nil)
+}
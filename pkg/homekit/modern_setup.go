@@ -4,8 +4,11 @@ package homekit
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"strings"
+	"sync"
 
 	"tempest-homekit-go/pkg/config"
 	"tempest-homekit-go/pkg/logger"
@@ -13,9 +16,12 @@ import (
 	"github.com/brutella/hap"
 	"github.com/brutella/hap/accessory"
 	"github.com/brutella/hap/characteristic"
-	"github.com/brutella/hap/service"
 )
 
+// StoreDir is where the HAP server keeps its keys and pairings. main moves it
+// under --data-dir.
+var StoreDir = "./db"
+
 // Custom service for weather sensors that don't interfere with temperature conversion
 const TypeWeatherSensor = "F000-0001-1000-8000-0026BB765291"
 
@@ -23,6 +29,7 @@ const TypeWeatherSensor = "F000-0001-1000-8000-0026BB765291"
 type WeatherAccessoryModern struct {
 	AccessoryPtr *accessory.A
 	WeatherValue interface{} // Changed to interface{} to support custom characteristics
+	convert      func(float64) float64
 }
 
 // WeatherSystemModern - New implementation using hap library and custom services
@@ -32,11 +39,30 @@ type WeatherSystemModern struct {
 	Accessories map[string]*WeatherAccessoryModern
 	LogLevel    string
 	cancel      context.CancelFunc
+	diagnostics *diagnosticsAccessory // nil unless the diagnostics accessory is enabled
+	comfort     *comfortAccessory     // nil unless the comfort advisor is enabled
+	named       []namedAccessory      // enabled accessories by key
+	setup       SetupInfo
+
+	// Construction settings, kept so the bridge can be rebuilt by
+	// RemovePairing and Reset
+	store        hap.Store
+	pin          string // configured PIN, empty when generated
+	sensorConfig *config.SensorConfig
+	opts         Options
+	adminMu      sync.Mutex // serializes pairing management
+
+	stateMu  sync.Mutex
+	running  bool          // true while ListenAndServe is running
+	serveErr error         // error returned by ListenAndServe, if it stopped
+	served   chan struct{} // closed when ListenAndServe returns
 }
 
 // NewWeatherSystemModern creates a new weather system using the modern hap library.
 // It initializes HomeKit accessories based on the sensor configuration and starts the HAP server.
-func NewWeatherSystemModern(pin string, sensorConfig *config.SensorConfig, logLevel string) (*WeatherSystemModern, error) {
+// Accessory names, serial numbers and the key store can be customized with opts.
+// An empty pin generates a random PIN on the first run, see loadSetup.
+func NewWeatherSystemModern(pin string, sensorConfig *config.SensorConfig, logLevel string, opts Options) (*WeatherSystemModern, error) {
 	if logLevel == "debug" {
 		logger.Debug("Creating new weather system with hap library")
 		logger.Debug("Sensor configuration: Temp=%v, Humidity=%v, Light=%v, Wind=%v, Rain=%v, Pressure=%v, UV=%v, Lightning=%v",
@@ -45,7 +71,10 @@ func NewWeatherSystemModern(pin string, sensorConfig *config.SensorConfig, logLe
 	}
 
 	// Create file storage for HomeKit data
-	fs := hap.NewFsStore("./db")
+	fs := opts.Store
+	if fs == nil {
+		fs = hap.NewFsStore(StoreDir)
+	}
 
 	// Create bridge accessory - this is the main hub
 	bridgeInfo := accessory.Info{
@@ -62,146 +91,82 @@ func NewWeatherSystemModern(pin string, sensorConfig *config.SensorConfig, logLe
 
 	accessories := make(map[string]*WeatherAccessoryModern)
 	var hapAccessories []*accessory.A
+	var named []namedAccessory // enabled accessories by key, for naming and stable IDs
 
-	// Create standard HomeKit accessories based on sensor configuration
-	var accessoryCount int
-
-	// Create separate accessories for each sensor type (more compliant approach)
-
-	// Temperature Sensor Accessory
-	if sensorConfig.Temperature {
-		tempInfo := accessory.Info{
-			Name:         "Temperature Sensor",
-			SerialNumber: "TWS-TEMP-001",
-			Manufacturer: "WeatherFlow",
-			Model:        "Tempest Temperature",
-			Firmware:     "1.0.0",
-		}
-		tempAccessory := accessory.New(tempInfo, accessory.TypeSensor)
-		tempService := service.NewTemperatureSensor()
-		tempAccessory.AddS(tempService.S)
-
-		hapAccessories = append(hapAccessories, tempAccessory)
-		accessories["Air Temperature"] = &WeatherAccessoryModern{
-			AccessoryPtr: tempAccessory,
-			WeatherValue: tempService.CurrentTemperature.Float,
-		}
-		accessoryCount++
-		if logLevel == "debug" {
-			logger.Debug("Created temperature sensor accessory")
-		}
+	// Create standard HomeKit services based on sensor configuration
+	sensors := newSensorServices(sensorConfig, opts.PressureUnit)
+	var diagnostics *diagnosticsAccessory
+	if sensorConfig.Diagnostics {
+		diagnostics = newDiagnosticsAccessory()
 	}
-
-	// Humidity Sensor Accessory
-	if sensorConfig.Humidity {
-		humidityInfo := accessory.Info{
-			Name:         "Humidity Sensor",
-			SerialNumber: "TWS-HUM-001",
-			Manufacturer: "WeatherFlow",
-			Model:        "Tempest Humidity",
-			Firmware:     "1.0.0",
-		}
-		humidityAccessory := accessory.New(humidityInfo, accessory.TypeSensor)
-		humidityService := service.NewHumiditySensor()
-		humidityAccessory.AddS(humidityService.S)
-
-		hapAccessories = append(hapAccessories, humidityAccessory)
-		accessories["Relative Humidity"] = &WeatherAccessoryModern{
-			AccessoryPtr: humidityAccessory,
-			WeatherValue: humidityService.CurrentRelativeHumidity.Float,
-		}
-		accessoryCount++
-		if logLevel == "debug" {
-			logger.Debug("Created humidity sensor accessory")
-		}
+	var comfort *comfortAccessory
+	if sensorConfig.Comfort {
+		comfort = newComfortAccessory()
 	}
 
-	// Light Sensor Accessory (Lux)
-	if sensorConfig.Light {
-		lightInfo := accessory.Info{
-			Name:         "Light Sensor",
-			SerialNumber: "TWS-LUX-001",
-			Manufacturer: "WeatherFlow",
-			Model:        "Tempest Light",
-			Firmware:     "1.0.0",
-		}
-		lightAccessory := accessory.New(lightInfo, accessory.TypeSensor)
-		lightService := service.NewLightSensor()
-		lightAccessory.AddS(lightService.S)
-
-		hapAccessories = append(hapAccessories, lightAccessory)
-		accessories["Ambient Light"] = &WeatherAccessoryModern{
-			AccessoryPtr: lightAccessory,
-			WeatherValue: lightService.CurrentAmbientLightLevel.Float,
-		}
-		accessoryCount++
-		if logLevel == "debug" {
-			logger.Debug("Created light sensor accessory")
+	var accessoryCount int
+	switch opts.Mode {
+	case ModeCombined:
+		// One accessory carrying every service, shown as a single station tile
+		station := accessory.New(stationInfo, accessory.TypeSensor)
+		for _, spec := range sensors {
+			addServiceName(spec.service, opts.Naming.name(spec.key, spec.info.Name, spec.info.SerialNumber))
+			station.AddS(spec.service)
+			accessories[spec.sensor] = &WeatherAccessoryModern{AccessoryPtr: station, WeatherValue: spec.value, convert: spec.convert}
 		}
-	}
-
-	// UV Sensor Accessory
-	if sensorConfig.UV {
-		uvInfo := accessory.Info{
-			Name:         "UV Index Sensor",
-			SerialNumber: "TWS-UV-001",
-			Manufacturer: "WeatherFlow",
-			Model:        "Tempest UV",
-			Firmware:     "1.0.0",
+		if diagnostics != nil {
+			for _, svc := range diagnostics.services {
+				station.AddS(svc)
+			}
 		}
-		uvAccessory := accessory.New(uvInfo, accessory.TypeSensor)
-
-		// Use Light Sensor service for UV with proper UV Index range
-		uvService := service.NewLightSensor()
-		uvService.CurrentAmbientLightLevel.Description = "UV Index"
-		uvService.CurrentAmbientLightLevel.Unit = "UV Index"
-		uvService.CurrentAmbientLightLevel.SetMinValue(0.0)
-		uvService.CurrentAmbientLightLevel.SetMaxValue(15.0)
-		uvService.CurrentAmbientLightLevel.SetStepValue(0.1)
-		uvService.CurrentAmbientLightLevel.SetValue(0.0)
-		uvAccessory.AddS(uvService.S)
-
-		hapAccessories = append(hapAccessories, uvAccessory)
-		accessories["UV Index"] = &WeatherAccessoryModern{
-			AccessoryPtr: uvAccessory,
-			WeatherValue: uvService.CurrentAmbientLightLevel.Float,
+		if comfort != nil {
+			station.AddS(comfort.service.S)
 		}
+		hapAccessories = append(hapAccessories, station)
+		named = append(named, namedAccessory{AccessoryStation, station})
 		accessoryCount++
 		if logLevel == "debug" {
-			logger.Debug("Created UV Index sensor accessory using light sensor service with UV range")
+			logger.Debug("Created combined station accessory with %d sensor services", len(sensors))
 		}
-	}
-
-	// Pressure Sensor Accessory (using standard light sensor service with custom labels)
-	if sensorConfig.Pressure {
-		pressureInfo := accessory.Info{
-			Name:         "Pressure Sensor",
-			SerialNumber: "TWS-PRESS-001",
-			Manufacturer: "WeatherFlow",
-			Model:        "Tempest Pressure",
-			Firmware:     "1.0.0",
+	default:
+		// Create separate accessories for each sensor type (more compliant approach)
+		for _, spec := range sensors {
+			acc := accessory.New(spec.info, accessory.TypeSensor)
+			acc.AddS(spec.service)
+
+			hapAccessories = append(hapAccessories, acc)
+			named = append(named, namedAccessory{spec.key, acc})
+			accessories[spec.sensor] = &WeatherAccessoryModern{AccessoryPtr: acc, WeatherValue: spec.value, convert: spec.convert}
+			accessoryCount++
+			if logLevel == "debug" {
+				logger.Debug("Created %s accessory", strings.ToLower(spec.info.Name))
+			}
 		}
-		pressureAccessory := accessory.New(pressureInfo, accessory.TypeSensor)
-
-		// Use standard Light Sensor service but customize for pressure
-		pressureService := service.NewLightSensor()
-		pressureService.CurrentAmbientLightLevel.Description = "Atmospheric Pressure (mb)"
-		pressureService.CurrentAmbientLightLevel.Unit = "mb"
-		pressureService.CurrentAmbientLightLevel.SetMinValue(700.0)
-		pressureService.CurrentAmbientLightLevel.SetMaxValue(1200.0)
-		pressureService.CurrentAmbientLightLevel.SetStepValue(0.1) // Decimal precision
-		pressureService.CurrentAmbientLightLevel.SetValue(1013.25) // Standard atmospheric pressure
-
-		pressureAccessory.AddS(pressureService.S)
-
-		hapAccessories = append(hapAccessories, pressureAccessory)
-		accessories["Atmospheric Pressure"] = &WeatherAccessoryModern{
-			AccessoryPtr: pressureAccessory,
-			WeatherValue: pressureService.CurrentAmbientLightLevel.Float,
+
+		// Bridge diagnostics accessory (data age, data source, uptime)
+		if diagnostics != nil {
+			diagnostics.accessory = accessory.New(diagnosticsInfo, accessory.TypeSensor)
+			for _, svc := range diagnostics.services {
+				diagnostics.accessory.AddS(svc)
+			}
+			hapAccessories = append(hapAccessories, diagnostics.accessory)
+			named = append(named, namedAccessory{AccessoryDiagnostics, diagnostics.accessory})
+			accessoryCount++
+			if logLevel == "debug" {
+				logger.Debug("Created bridge diagnostics accessory")
+			}
 		}
-		accessoryCount++
-		if logLevel == "debug" {
-			logger.Debug("Created atmospheric pressure sensor accessory using standard light sensor service with custom labels")
+
+		// Comfort advisor accessory (open windows recommendation)
+		if comfort != nil {
+			comfort.accessory = accessory.New(comfortInfo, accessory.TypeSwitch)
+			comfort.accessory.AddS(comfort.service.S)
+			hapAccessories = append(hapAccessories, comfort.accessory)
+			named = append(named, namedAccessory{AccessoryComfort, comfort.accessory})
+			accessoryCount++
+			if logLevel == "debug" {
+				logger.Debug("Created comfort advisor accessory")
+			}
 		}
 	}
 
@@ -234,18 +199,35 @@ func NewWeatherSystemModern(pin string, sensorConfig *config.SensorConfig, logLe
 	if logLevel == "debug" {
 		logger.Debug("Creating server with %d accessories based on sensor configuration", len(hapAccessories))
 	}
+	if err := applyNaming(fs, opts.Naming, bridge.A, named); err != nil {
+		return nil, err
+	}
 	server, err := hap.NewServer(fs, bridge.A, hapAccessories...)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set the PIN for pairing
-	server.Pin = pin
+	// Set the PIN and setup ID for pairing; without a configured PIN a random
+	// one is generated on the first run and kept in the store
+	setup, err := loadSetup(fs, pin)
+	if err != nil {
+		return nil, err
+	}
+	server.Pin = setup.PIN
+	server.SetupId = setup.SetupID
+
+	server.Addr, server.Ifaces, err = configureNetwork(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(server.Ifaces) > 0 {
+		logger.Info("HomeKit bridge announced on interfaces %v", server.Ifaces)
+	}
 
 	if logLevel == "debug" {
-		logger.Debug("Weather system created successfully with PIN: %s", pin)
+		logger.Debug("Weather system created successfully with PIN: %s", setup.PIN)
 		logger.Debug("HomeKit compliance: %d accessories created based on sensor configuration", accessoryCount)
-		logger.Debug("Sensors enabled: Temp=%v, Humidity=%v, Light=%v, UV=%v, Pressure=%v", sensorConfig.Temperature, sensorConfig.Humidity, sensorConfig.Light, sensorConfig.UV, sensorConfig.Pressure)
+		logger.Debug("Sensors enabled: Temp=%v, Humidity=%v, Light=%v, UV=%v, Pressure=%v, Diagnostics=%v", sensorConfig.Temperature, sensorConfig.Humidity, sensorConfig.Light, sensorConfig.UV, sensorConfig.Pressure, sensorConfig.Diagnostics)
 	}
 
 	return &WeatherSystemModern{
@@ -253,6 +235,15 @@ func NewWeatherSystemModern(pin string, sensorConfig *config.SensorConfig, logLe
 		Server:      server,
 		Accessories: accessories,
 		LogLevel:    logLevel,
+		diagnostics: diagnostics,
+		comfort:     comfort,
+		named:       named,
+		setup:       setup,
+
+		store:        fs,
+		pin:          pin,
+		sensorConfig: sensorConfig,
+		opts:         opts,
 	}, nil
 }
 
@@ -267,18 +258,62 @@ func (ws *WeatherSystemModern) Start() error {
 	ws.cancel = cancel
 
 	// Start the server in background
+	served := make(chan struct{})
+	ws.stateMu.Lock()
+	ws.served = served
+	ws.stateMu.Unlock()
+	ws.setRunning(true, nil)
 	go func() {
+		defer close(served)
 		if ws.LogLevel == "debug" {
 			logger.Debug("HomeKit server starting with PIN: %s", ws.Server.Pin)
 		}
-		if err := ws.Server.ListenAndServe(ctx); err != nil {
+		err := ws.Server.ListenAndServe(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
 			logger.Error("HomeKit server error: %v", err)
 		}
+		ws.setRunning(false, err)
 	}()
 
 	return nil
 }
 
+func (ws *WeatherSystemModern) setRunning(running bool, err error) {
+	ws.stateMu.Lock()
+	defer ws.stateMu.Unlock()
+	ws.running = running
+	ws.serveErr = err
+}
+
+// Health reports whether the HAP server is running and published
+func (ws *WeatherSystemModern) Health() error {
+	ws.stateMu.Lock()
+	defer ws.stateMu.Unlock()
+	if ws.running {
+		return nil
+	}
+	if ws.serveErr != nil {
+		return fmt.Errorf("HomeKit server stopped: %v", ws.serveErr)
+	}
+	return fmt.Errorf("HomeKit server not running")
+}
+
+// CheckStoreWritable verifies that the HAP store directory accepts writes, so
+// pairings survive a restart
+func CheckStoreWritable() error {
+	if err := os.MkdirAll(StoreDir, 0755); err != nil {
+		return fmt.Errorf("cannot create HomeKit store %s: %w", StoreDir, err)
+	}
+	f, err := os.CreateTemp(StoreDir, ".healthcheck-*")
+	if err != nil {
+		return fmt.Errorf("HomeKit store %s is not writable: %w", StoreDir, err)
+	}
+	name := f.Name()
+	_ = f.Close()
+	_ = os.Remove(name)
+	return nil
+}
+
 // Stop the weather system gracefully
 func (ws *WeatherSystemModern) Stop() {
 	if ws.LogLevel == "debug" {
@@ -289,11 +324,18 @@ func (ws *WeatherSystemModern) Stop() {
 	}
 }
 
-// UpdateSensor updates a specific sensor value
+// UpdateSensor updates a specific sensor value, given in observation units
+// (pressure in mb)
 func (ws *WeatherSystemModern) UpdateSensor(sensorName string, value float64) {
-	if accessory, exists := ws.Accessories[sensorName]; exists {
+	ws.stateMu.Lock()
+	accessory, exists := ws.Accessories[sensorName] // replaced by Reset
+	ws.stateMu.Unlock()
+	if exists {
 		// Check if this sensor has a valid characteristic (some are intentionally nil for compatibility)
 		if accessory.WeatherValue != nil {
+			if accessory.convert != nil {
+				value = accessory.convert(value)
+			}
 			if ws.LogLevel == "debug" {
 				logger.Debug("Updating %s: %.3f", sensorName, value)
 			}
@@ -321,29 +363,6 @@ func (ws *WeatherSystemModern) GetAvailableSensors() []string {
 	return sensors
 }
 
-// countPairedDevices counts the number of paired devices by reading pairing files from the database
-func countPairedDevices() int {
-	dbDir := "./db"
-	entries, err := os.ReadDir(dbDir)
-	if err != nil {
-		logger.Warn("Failed to read database directory for paired devices count: %v", err)
-		return 0
-	}
-
-	count := 0
-	for _, entry := range entries {
-		if strings.HasSuffix(entry.Name(), ".pairing") {
-			count++
-		}
-	}
-
-	if count > 0 {
-		logger.Debug("Found %d paired device(s) in database", count)
-	}
-
-	return count
-}
-
 // GetDetailedInfo returns detailed HomeKit bridge information
 func (ws *WeatherSystemModern) GetDetailedInfo() map[string]interface{} {
 	if ws.Bridge == nil || ws.Server == nil {
@@ -358,7 +377,10 @@ func (ws *WeatherSystemModern) GetDetailedInfo() map[string]interface{} {
 		"bridgeId":       ws.Bridge.Info.SerialNumber.Value(),
 		"category":       "Bridge",
 		"pin":            ws.Server.Pin,
-		"setupCode":      "X-" + ws.Server.Pin,
+		"setupCode":      ws.setup.SetupCode,
+		"setupId":        ws.setup.SetupID,
+		"setupURI":       ws.setup.SetupURI,
+		"pinGenerated":   ws.setup.Generated,
 		"port":           "51826", // Standard HAP port
 		"hapVersion":     "1.1",   // HAP protocol version
 		"accessories":    len(ws.Accessories),
@@ -366,11 +388,10 @@ func (ws *WeatherSystemModern) GetDetailedInfo() map[string]interface{} {
 		"manufacturer":   ws.Bridge.Info.Manufacturer.Value(),
 		"model":          ws.Bridge.Info.Model.Value(),
 		"firmware":       ws.Bridge.Info.FirmwareRevision.Value(),
+		"names":          ws.AccessoryNames(),
 	}
 
-	// Get paired devices count by reading database files
-	pairedCount := countPairedDevices()
-	info["pairedDevices"] = pairedCount
+	info["pairedDevices"] = ws.pairingCount()
 	info["reachability"] = true
 	info["lastRequest"] = "Active"
 
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"tempest-homekit-go/pkg/config"
+
+	"github.com/brutella/hap"
 )
 
 func TestNewWeatherSystemModern_Basic(t *testing.T) {
@@ -15,7 +17,7 @@ func TestNewWeatherSystemModern_Basic(t *testing.T) {
 		Pressure:    true,
 	}
 
-	ws, err := NewWeatherSystemModern("00102003", &cfg, "debug")
+	ws, err := NewWeatherSystemModern("00102003", &cfg, "debug", Options{Store: hap.NewMemStore()})
 	if err != nil {
 		t.Fatalf("NewWeatherSystemModern returned error: %v", err)
 	}
@@ -0,0 +1,188 @@
+package homekit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"tempest-homekit-go/pkg/logger"
+
+	"github.com/brutella/hap"
+	"github.com/brutella/hap/accessory"
+)
+
+// accessoryRecordsKey is the HAP store key holding the persisted accessory IDs
+// and names
+const accessoryRecordsKey = "accessories.json"
+
+// Accessory keys used by AccessoryNaming.Names and the persisted records
+const (
+	AccessoryBridge      = "bridge"
+	AccessoryTemperature = "temperature"
+	AccessoryHumidity    = "humidity"
+	AccessoryLight       = "light"
+	AccessoryUV          = "uv"
+	AccessoryPressure    = "pressure"
+	AccessoryDiagnostics = "diagnostics"
+	AccessoryComfort     = "comfort"
+)
+
+// AccessoryNaming customizes accessory names and serial numbers.
+//
+// NamePattern and SerialPattern may contain {name} (the built-in name, e.g.
+// "Temperature Sensor"), {serial} (the built-in serial, e.g. "TWS-TEMP-001"),
+// {sensor} (the accessory key) and {station} (the station name). An entry in
+// Names replaces the pattern for that accessory. In combined mode sensor names
+// label the services of the station accessory instead.
+type AccessoryNaming struct {
+	Names         map[string]string // accessory key -> name, e.g. "temperature" -> "Backyard Temperature"
+	NamePattern   string            // default "{name}"
+	SerialPattern string            // default "{serial}"
+	Station       string
+}
+
+// Options are optional settings for NewWeatherSystemModern
+type Options struct {
+	Mode   string // ModeSplit (default) or ModeCombined
+	Naming AccessoryNaming
+	Store  hap.Store // HAP key and pairing store; nil uses StoreDir
+
+	Address    string   // IP the HAP server listens on; empty listens on all addresses
+	Port       int      // port the HAP server listens on; 0 picks a free port
+	Interfaces []string // interfaces the bridge is announced on over mDNS; empty announces on all
+
+	PressureUnit string // unit of the pressure sensor: weather.PressureMB (default) or weather.PressureInHg
+}
+
+// accessoryRecord is what is persisted per accessory. Keeping the accessory ID
+// stable across restarts and configuration changes lets the Home app keep
+// pairings, rooms and automations when accessories are renamed, added or removed.
+type accessoryRecord struct {
+	ID     uint64 `json:"aid"`
+	Name   string `json:"name"`
+	Serial string `json:"serial"`
+}
+
+type namedAccessory struct {
+	key string
+	acc *accessory.A
+}
+
+func (n AccessoryNaming) expand(pattern, key, name, serial string) string {
+	return strings.NewReplacer(
+		"{name}", name,
+		"{serial}", serial,
+		"{sensor}", key,
+		"{station}", n.Station,
+	).Replace(pattern)
+}
+
+func (n AccessoryNaming) name(key, builtin, serial string) string {
+	if custom := strings.TrimSpace(n.Names[key]); custom != "" {
+		return custom
+	}
+	if n.NamePattern == "" {
+		return builtin
+	}
+	if name := strings.TrimSpace(n.expand(n.NamePattern, key, builtin, serial)); name != "" {
+		return name
+	}
+	return builtin
+}
+
+func (n AccessoryNaming) serial(key, name, builtin string) string {
+	if n.SerialPattern == "" {
+		return builtin
+	}
+	if serial := strings.TrimSpace(n.expand(n.SerialPattern, key, name, builtin)); serial != "" {
+		return serial
+	}
+	return builtin
+}
+
+// applyNaming sets names and serial numbers and assigns accessory IDs, reusing
+// the IDs persisted in the store so existing pairings stay valid. The bridge
+// is always accessory 1. Records of accessories that are currently disabled are
+// kept so they get their old ID back when re-enabled.
+func applyNaming(store hap.Store, naming AccessoryNaming, bridge *accessory.A, items []namedAccessory) error {
+	records := map[string]accessoryRecord{}
+	if b, err := store.Get(accessoryRecordsKey); err == nil && len(b) > 0 {
+		if err := json.Unmarshal(b, &records); err != nil {
+			logger.Warn("Ignoring unreadable HomeKit accessory records: %v", err)
+			records = map[string]accessoryRecord{}
+		}
+	}
+
+	nextID := uint64(2)
+	for key, rec := range records {
+		if key != AccessoryBridge && rec.ID >= nextID {
+			nextID = rec.ID + 1
+		}
+	}
+
+	all := append([]namedAccessory{{AccessoryBridge, bridge}}, items...)
+	for _, item := range all {
+		builtinName := item.acc.Info.Name.Value()
+		builtinSerial := item.acc.Info.SerialNumber.Value()
+		name := naming.name(item.key, builtinName, builtinSerial)
+		serial := naming.serial(item.key, builtinName, builtinSerial)
+		item.acc.Info.Name.SetValue(name)
+		item.acc.Info.SerialNumber.SetValue(serial)
+
+		rec, known := records[item.key]
+		switch {
+		case item.key == AccessoryBridge:
+			rec.ID = 1
+		case !known || rec.ID < 2:
+			rec.ID = nextID
+			nextID++
+		}
+		if known && rec.Name != "" && rec.Name != name {
+			logger.Info("HomeKit accessory %s renamed from %q to %q (pairing kept)", item.key, rec.Name, name)
+		}
+		rec.Name = name
+		rec.Serial = serial
+		records[item.key] = rec
+		item.acc.Id = rec.ID
+	}
+
+	b, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode HomeKit accessory records: %w", err)
+	}
+	if err := store.Set(accessoryRecordsKey, b); err != nil {
+		return fmt.Errorf("failed to save HomeKit accessory records: %w", err)
+	}
+	return nil
+}
+
+// AccessoryNames returns the configured name of each enabled accessory by key
+func (ws *WeatherSystemModern) AccessoryNames() map[string]string {
+	names := make(map[string]string, len(ws.named)+1)
+	if ws.Bridge != nil {
+		names[AccessoryBridge] = ws.Bridge.Info.Name.Value()
+	}
+	for _, item := range ws.named {
+		names[item.key] = item.acc.Info.Name.Value()
+	}
+	return names
+}
+
+// SetStation renames the station used by {station} in name and serial
+// patterns. When a pattern uses it the bridge is rebuilt so controllers see
+// the new names and serials; accessory IDs, and therefore pairings, are kept.
+func (ws *WeatherSystemModern) SetStation(station string) error {
+	ws.adminMu.Lock()
+	defer ws.adminMu.Unlock()
+
+	naming := ws.opts.Naming
+	if naming.Station == station {
+		return nil
+	}
+	ws.opts.Naming.Station = station
+	if !strings.Contains(naming.NamePattern+naming.SerialPattern, "{station}") {
+		return nil
+	}
+	logger.Info("HomeKit station changed to %q, updating accessory names and serials", station)
+	return ws.rebuild(false)
+}
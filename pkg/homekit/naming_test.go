@@ -0,0 +1,108 @@
+package homekit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"tempest-homekit-go/pkg/config"
+
+	"github.com/brutella/hap"
+)
+
+func TestAccessoryNaming(t *testing.T) {
+	store := hap.NewMemStore()
+	cfg := config.SensorConfig{Temperature: true, Humidity: true, Light: true}
+	naming := AccessoryNaming{
+		Names:         map[string]string{AccessoryTemperature: "Backyard Temperature"},
+		NamePattern:   "{station} {name}",
+		SerialPattern: "{serial}-{station}",
+		Station:       "Home",
+	}
+	ws, err := NewWeatherSystemModern("00102003", &cfg, "info", Options{Naming: naming, Store: store})
+	if err != nil {
+		t.Fatalf("NewWeatherSystemModern returned error: %v", err)
+	}
+
+	names := ws.AccessoryNames()
+	want := map[string]string{
+		AccessoryBridge:      "Home Tempest Weather Bridge",
+		AccessoryTemperature: "Backyard Temperature",
+		AccessoryHumidity:    "Home Humidity Sensor",
+		AccessoryLight:       "Home Light Sensor",
+	}
+	for key, name := range want {
+		if names[key] != name {
+			t.Errorf("name of %s = %q, want %q", key, names[key], name)
+		}
+	}
+	if got := ws.Accessories["Air Temperature"].AccessoryPtr.Info.SerialNumber.Value(); got != "TWS-TEMP-001-Home" {
+		t.Errorf("temperature serial = %q, want TWS-TEMP-001-Home", got)
+	}
+}
+
+func TestAccessoryIDsStableAcrossConfigChanges(t *testing.T) {
+	store := hap.NewMemStore()
+	ids := func(cfg config.SensorConfig) map[string]uint64 {
+		t.Helper()
+		ws, err := NewWeatherSystemModern("00102003", &cfg, "info", Options{Store: store})
+		if err != nil {
+			t.Fatalf("NewWeatherSystemModern returned error: %v", err)
+		}
+		out := map[string]uint64{AccessoryBridge: ws.Bridge.Id}
+		for _, item := range ws.named {
+			out[item.key] = item.acc.Id
+		}
+		return out
+	}
+
+	first := ids(config.SensorConfig{Temperature: true, Humidity: true, Light: true})
+	if first[AccessoryBridge] != 1 || first[AccessoryTemperature] != 2 || first[AccessoryHumidity] != 3 || first[AccessoryLight] != 4 {
+		t.Fatalf("first run should match sequential IDs, got %v", first)
+	}
+
+	// Disabling humidity must not shift light, and a new accessory gets a fresh ID
+	second := ids(config.SensorConfig{Temperature: true, Light: true, UV: true})
+	if second[AccessoryTemperature] != 2 || second[AccessoryLight] != 4 || second[AccessoryUV] != 5 {
+		t.Fatalf("IDs changed after config change: %v", second)
+	}
+
+	// Re-enabling humidity restores its old ID
+	third := ids(config.SensorConfig{Temperature: true, Humidity: true, Light: true, UV: true})
+	if third[AccessoryHumidity] != 3 {
+		t.Fatalf("humidity should get its old ID back, got %v", third)
+	}
+
+	b, err := store.Get(accessoryRecordsKey)
+	if err != nil {
+		t.Fatalf("accessory records not persisted: %v", err)
+	}
+	var records map[string]accessoryRecord
+	if err := json.Unmarshal(b, &records); err != nil {
+		t.Fatalf("invalid accessory records: %v", err)
+	}
+	if records[AccessoryUV].Name != "UV Index Sensor" {
+		t.Errorf("unexpected persisted record: %+v", records[AccessoryUV])
+	}
+}
+
+func TestSetStation(t *testing.T) {
+	store := hap.NewMemStore()
+	cfg := config.SensorConfig{Temperature: true}
+	naming := AccessoryNaming{SerialPattern: "{serial}-{station}", Station: "Home"}
+	ws, err := NewWeatherSystemModern("00102003", &cfg, "info", Options{Naming: naming, Store: store})
+	if err != nil {
+		t.Fatalf("NewWeatherSystemModern returned error: %v", err)
+	}
+	id := ws.Accessories["Air Temperature"].AccessoryPtr.Id
+
+	if err := ws.SetStation("Cabin"); err != nil {
+		t.Fatalf("SetStation returned error: %v", err)
+	}
+	acc := ws.Accessories["Air Temperature"].AccessoryPtr
+	if got := acc.Info.SerialNumber.Value(); got != "TWS-TEMP-001-Cabin" {
+		t.Errorf("serial after station change = %q, want TWS-TEMP-001-Cabin", got)
+	}
+	if acc.Id != id {
+		t.Errorf("accessory ID changed from %d to %d", id, acc.Id)
+	}
+}
@@ -0,0 +1,61 @@
+package homekit
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// configureNetwork binds the HAP server to opts.Address and opts.Port and
+// limits the mDNS announcement to opts.Interfaces. With an address but no
+// interfaces, the interface holding the address is announced on, so
+// controllers are only told about an address the server actually listens on.
+func configureNetwork(opts Options) (addr string, ifaces []string, err error) {
+	for _, name := range opts.Interfaces {
+		if _, err := net.InterfaceByName(name); err != nil {
+			return "", nil, fmt.Errorf("HomeKit interface %q not found: %w", name, err)
+		}
+	}
+	ifaces = opts.Interfaces
+	// Port 0 keeps hap's behavior of picking a free port
+	port := strconv.Itoa(opts.Port)
+
+	if opts.Address == "" {
+		if opts.Port == 0 {
+			return "", ifaces, nil
+		}
+		return ":" + port, ifaces, nil
+	}
+	ip := net.ParseIP(opts.Address)
+	if ip == nil {
+		return "", nil, fmt.Errorf("invalid HomeKit address %q", opts.Address)
+	}
+	if len(ifaces) == 0 {
+		name, err := interfaceWithIP(ip)
+		if err != nil {
+			return "", nil, err
+		}
+		ifaces = []string{name}
+	}
+	return net.JoinHostPort(ip.String(), port), ifaces, nil
+}
+
+// interfaceWithIP returns the name of the interface that has ip assigned
+func interfaceWithIP(ip net.IP) (string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", fmt.Errorf("failed to list network interfaces: %w", err)
+	}
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipNet, ok := a.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return iface.Name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no network interface has address %s", ip)
+}
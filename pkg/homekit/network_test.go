@@ -0,0 +1,39 @@
+package homekit
+
+import (
+	"net"
+	"testing"
+)
+
+func TestConfigureNetworkDefaults(t *testing.T) {
+	addr, ifaces, err := configureNetwork(Options{})
+	if err != nil || addr != "" || ifaces != nil {
+		t.Errorf("configureNetwork(empty) = %q, %v, %v; want all addresses", addr, ifaces, err)
+	}
+	if addr, _, err := configureNetwork(Options{Port: 51830}); err != nil || addr != ":51830" {
+		t.Errorf("configureNetwork(port) = %q, %v; want :51830", addr, err)
+	}
+}
+
+func TestConfigureNetworkAddress(t *testing.T) {
+	// The loopback interface name differs between platforms, so look it up
+	lo, err := interfaceWithIP(net.ParseIP("127.0.0.1"))
+	if err != nil {
+		t.Skipf("no loopback interface: %v", err)
+	}
+
+	addr, ifaces, err := configureNetwork(Options{Address: "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("configureNetwork returned error: %v", err)
+	}
+	if addr != "127.0.0.1:0" || len(ifaces) != 1 || ifaces[0] != lo {
+		t.Errorf("got %q on %v, want 127.0.0.1:0 on [%s]", addr, ifaces, lo)
+	}
+
+	if _, _, err := configureNetwork(Options{Address: "192.0.2.123"}); err == nil {
+		t.Error("expected an error for an address no interface has")
+	}
+	if _, _, err := configureNetwork(Options{Interfaces: []string{"no-such-iface0"}}); err == nil {
+		t.Error("expected an error for an unknown interface")
+	}
+}
@@ -0,0 +1,168 @@
+package homekit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+
+	"github.com/brutella/hap"
+)
+
+// ErrPairingNotFound is returned by RemovePairing for an unknown controller
+var ErrPairingNotFound = errors.New("pairing not found")
+
+// serverStopTimeout bounds how long a restart waits for the HAP server to
+// release its port
+const serverStopTimeout = 10 * time.Second
+
+// PairingInfo is a controller (iPhone, Home hub) paired with the bridge
+type PairingInfo struct {
+	ID    string // controller pairing identifier
+	Admin bool   // admin controllers can add and remove pairings
+}
+
+// Pairings lists the paired controllers, sorted by ID
+func (ws *WeatherSystemModern) Pairings() ([]PairingInfo, error) {
+	keys, err := ws.store.KeysWithSuffix(".pairing")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list HomeKit pairings: %w", err)
+	}
+	pairings := make([]PairingInfo, 0, len(keys))
+	for _, key := range keys {
+		b, err := ws.store.Get(key)
+		if err != nil {
+			continue
+		}
+		var p hap.Pairing
+		if err := json.Unmarshal(b, &p); err != nil {
+			logger.Warn("Ignoring unreadable HomeKit pairing %s: %v", key, err)
+			continue
+		}
+		pairings = append(pairings, PairingInfo{ID: p.Name, Admin: p.Permission == hap.PermissionAdmin})
+	}
+	sort.Slice(pairings, func(i, j int) bool { return pairings[i].ID < pairings[j].ID })
+	return pairings, nil
+}
+
+// RemovePairing unpairs a controller. As in the HAP specification, removing
+// the last admin controller removes all pairings. The HAP server is restarted
+// so open sessions of the removed controller are closed and the bridge
+// advertises itself as unpaired once no pairings are left.
+func (ws *WeatherSystemModern) RemovePairing(id string) error {
+	ws.adminMu.Lock()
+	defer ws.adminMu.Unlock()
+
+	pairings, err := ws.Pairings()
+	if err != nil {
+		return err
+	}
+	found, adminLeft := false, false
+	for _, p := range pairings {
+		if p.ID == id {
+			found = true
+		} else if p.Admin {
+			adminLeft = true
+		}
+	}
+	if !found {
+		return ErrPairingNotFound
+	}
+
+	remove := []string{id}
+	if !adminLeft {
+		remove = remove[:0]
+		for _, p := range pairings {
+			remove = append(remove, p.ID)
+		}
+	}
+	for _, name := range remove {
+		if err := ws.store.Delete(pairingKey(name)); err != nil {
+			return fmt.Errorf("failed to remove HomeKit pairing %s: %w", name, err)
+		}
+		logger.Info("Removed HomeKit pairing %s", name)
+	}
+	return ws.rebuild(false)
+}
+
+// Reset removes all pairings and the bridge identity, like --cleardb, and
+// restarts the bridge so it can be added to a home again without restarting
+// the process. Accessory IDs are kept. A generated PIN is replaced by a new one.
+func (ws *WeatherSystemModern) Reset() error {
+	ws.adminMu.Lock()
+	defer ws.adminMu.Unlock()
+	logger.Info("Resetting HomeKit state")
+	return ws.rebuild(true)
+}
+
+// rebuild stops the HAP server, optionally clears the store, and recreates the
+// bridge with the original settings. hap servers cannot be restarted once
+// stopped, and accessories cannot be added to a second server.
+func (ws *WeatherSystemModern) rebuild(clear bool) error {
+	ws.stateMu.Lock()
+	wasRunning, served := ws.running, ws.served
+	ws.stateMu.Unlock()
+
+	if wasRunning {
+		ws.Stop()
+		select {
+		case <-served:
+		case <-time.After(serverStopTimeout):
+			return fmt.Errorf("HomeKit server did not stop within %v", serverStopTimeout)
+		}
+	}
+
+	if clear {
+		keys, err := ws.store.KeysWithSuffix("")
+		if err != nil {
+			return fmt.Errorf("failed to list HomeKit store: %w", err)
+		}
+		for _, key := range keys {
+			if key == accessoryRecordsKey {
+				continue
+			}
+			if err := ws.store.Delete(key); err != nil {
+				return fmt.Errorf("failed to clear HomeKit store: %w", err)
+			}
+		}
+	}
+
+	opts := ws.opts
+	opts.Store = ws.store
+	fresh, err := NewWeatherSystemModern(ws.pin, ws.sensorConfig, ws.LogLevel, opts)
+	if err != nil {
+		return err
+	}
+
+	ws.stateMu.Lock()
+	ws.Bridge = fresh.Bridge
+	ws.Server = fresh.Server
+	ws.Accessories = fresh.Accessories
+	ws.diagnostics = fresh.diagnostics
+	ws.comfort = fresh.comfort
+	ws.named = fresh.named
+	ws.setup = fresh.setup
+	ws.stateMu.Unlock()
+
+	if wasRunning {
+		return ws.Start()
+	}
+	return nil
+}
+
+func pairingKey(id string) string {
+	return fmt.Sprintf("%x.pairing", id)
+}
+
+// pairingCount returns the number of paired controllers
+func (ws *WeatherSystemModern) pairingCount() int {
+	keys, err := ws.store.KeysWithSuffix(".pairing")
+	if err != nil {
+		logger.Warn("Failed to read HomeKit pairings: %v", err)
+		return 0
+	}
+	return len(keys)
+}
@@ -0,0 +1,101 @@
+package homekit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"tempest-homekit-go/pkg/config"
+
+	"github.com/brutella/hap"
+)
+
+func addTestPairing(t *testing.T, store hap.Store, name string, permission byte) {
+	t.Helper()
+	b, err := json.Marshal(hap.Pairing{Name: name, PublicKey: []byte{1, 2, 3}, Permission: permission})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(fmt.Sprintf("%x.pairing", name), b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newPairingTestSystem(t *testing.T) (*WeatherSystemModern, hap.Store) {
+	t.Helper()
+	store := hap.NewMemStore()
+	cfg := config.SensorConfig{Temperature: true}
+	ws, err := NewWeatherSystemModern("", &cfg, "info", Options{Store: store})
+	if err != nil {
+		t.Fatalf("NewWeatherSystemModern returned error: %v", err)
+	}
+	addTestPairing(t, store, "phone", hap.PermissionAdmin)
+	addTestPairing(t, store, "hub", hap.PermissionUser)
+	return ws, store
+}
+
+func TestPairings(t *testing.T) {
+	ws, _ := newPairingTestSystem(t)
+	pairings, err := ws.Pairings()
+	if err != nil {
+		t.Fatalf("Pairings returned error: %v", err)
+	}
+	want := []PairingInfo{{ID: "hub"}, {ID: "phone", Admin: true}}
+	if len(pairings) != len(want) || pairings[0] != want[0] || pairings[1] != want[1] {
+		t.Errorf("Pairings = %+v, want %+v", pairings, want)
+	}
+	if n := ws.pairingCount(); n != 2 {
+		t.Errorf("pairingCount = %d, want 2", n)
+	}
+}
+
+func TestRemovePairing(t *testing.T) {
+	ws, _ := newPairingTestSystem(t)
+	if err := ws.RemovePairing("unknown"); !errors.Is(err, ErrPairingNotFound) {
+		t.Fatalf("expected ErrPairingNotFound, got %v", err)
+	}
+	if err := ws.RemovePairing("hub"); err != nil {
+		t.Fatalf("RemovePairing returned error: %v", err)
+	}
+	pairings, _ := ws.Pairings()
+	if len(pairings) != 1 || pairings[0].ID != "phone" {
+		t.Errorf("expected only the admin pairing to remain, got %+v", pairings)
+	}
+}
+
+func TestRemoveLastAdminRemovesAll(t *testing.T) {
+	ws, _ := newPairingTestSystem(t)
+	if err := ws.RemovePairing("phone"); err != nil {
+		t.Fatalf("RemovePairing returned error: %v", err)
+	}
+	if pairings, _ := ws.Pairings(); len(pairings) != 0 {
+		t.Errorf("expected all pairings removed with the last admin, got %+v", pairings)
+	}
+}
+
+func TestReset(t *testing.T) {
+	ws, store := newPairingTestSystem(t)
+	before := ws.Setup()
+	records, err := store.Get(accessoryRecordsKey)
+	if err != nil {
+		t.Fatalf("accessory records missing: %v", err)
+	}
+
+	if err := ws.Reset(); err != nil {
+		t.Fatalf("Reset returned error: %v", err)
+	}
+	if pairings, _ := ws.Pairings(); len(pairings) != 0 {
+		t.Errorf("expected no pairings after reset, got %+v", pairings)
+	}
+	after, err := store.Get(accessoryRecordsKey)
+	if err != nil || string(after) != string(records) {
+		t.Errorf("accessory records not kept across reset")
+	}
+	if s := ws.Setup(); s.PIN == before.PIN && s.SetupID == before.SetupID {
+		t.Errorf("expected a new generated PIN and setup ID, got %+v", s)
+	}
+	if ws.Server == nil || ws.Bridge == nil {
+		t.Error("bridge not rebuilt after reset")
+	}
+}
@@ -0,0 +1,260 @@
+package homekit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A minimal QR code encoder for the X-HM setup URI, so the terminal status
+// console can show a scannable code without a third-party library. It only
+// supports what a setup URI needs: version 2 (25x25 modules), error
+// correction level M and byte mode, which holds up to 26 bytes.
+const (
+	qrSize      = 25
+	qrDataBytes = 28
+	qrECBytes   = 16
+	qrMaxInput  = 26
+)
+
+// QRCode returns the QR code modules for text, true for dark, indexed [row][column].
+// The quiet zone is not included.
+func QRCode(text string) ([][]bool, error) {
+	if len(text) > qrMaxInput {
+		return nil, fmt.Errorf("QR code text too long: %d bytes, maximum %d", len(text), qrMaxInput)
+	}
+
+	q := &qrMatrix{}
+	q.drawFunctionPatterns()
+	codewords := qrEncodeData([]byte(text))
+	codewords = append(codewords, qrReedSolomon(codewords, qrECBytes)...)
+	q.drawCodewords(codewords)
+	q.applyMask()
+	q.drawFormatBits()
+
+	out := make([][]bool, qrSize)
+	for y := range out {
+		out[y] = make([]bool, qrSize)
+		copy(out[y], q.modules[y][:])
+	}
+	return out, nil
+}
+
+// QRCodeText renders text as a QR code using Unicode half blocks, two module
+// rows per line, with a two-module quiet zone. Dark modules are drawn with
+// foreground blocks, so it reads best as dark text on a light background.
+func QRCodeText(text string) (string, error) {
+	modules, err := QRCode(text)
+	if err != nil {
+		return "", err
+	}
+	const quiet = 2
+	dark := func(y, x int) bool {
+		y, x = y-quiet, x-quiet
+		return y >= 0 && x >= 0 && y < qrSize && x < qrSize && modules[y][x]
+	}
+
+	var b strings.Builder
+	size := qrSize + 2*quiet
+	for y := 0; y < size; y += 2 {
+		for x := 0; x < size; x++ {
+			top, bottom := dark(y, x), dark(y+1, x)
+			switch {
+			case top && bottom:
+				b.WriteRune('█')
+			case top:
+				b.WriteRune('▀')
+			case bottom:
+				b.WriteRune('▄')
+			default:
+				b.WriteRune(' ')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+type qrMatrix struct {
+	modules    [qrSize][qrSize]bool
+	isFunction [qrSize][qrSize]bool
+}
+
+func (q *qrMatrix) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.isFunction[y][x] = true
+}
+
+func (q *qrMatrix) drawFunctionPatterns() {
+	// Timing patterns
+	for i := 0; i < qrSize; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+
+	// Finder patterns with separators
+	for _, c := range [][2]int{{3, 3}, {qrSize - 4, 3}, {3, qrSize - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x < 0 || y < 0 || x >= qrSize || y >= qrSize {
+					continue
+				}
+				dist := max(abs(dx), abs(dy))
+				q.set(x, y, dist != 2 && dist != 4)
+			}
+		}
+	}
+
+	// The single version 2 alignment pattern
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			q.set(18+dx, 18+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+
+	// Reserve the format areas; drawFormatBits fills them in after masking
+	q.drawFormatBits()
+}
+
+// drawFormatBits writes error correction level M and mask pattern 0, plus the
+// always-dark module
+func (q *qrMatrix) drawFormatBits() {
+	const data = 0<<3 | 0 // level M, mask 0
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 != 0 }
+
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.set(qrSize-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, qrSize-15+i, bit(i))
+	}
+	q.set(8, qrSize-8, true)
+}
+
+// drawCodewords places the data in the zigzag order, two columns at a time
+// from the bottom right, skipping the vertical timing pattern
+func (q *qrMatrix) drawCodewords(data []byte) {
+	i := 0
+	for right := qrSize - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < qrSize; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 { // upward
+					y = qrSize - 1 - vert
+				}
+				if q.isFunction[y][x] || i >= len(data)*8 {
+					continue
+				}
+				q.modules[y][x] = (data[i>>3]>>(7-uint(i&7)))&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+// applyMask applies mask pattern 0 to the data modules
+func (q *qrMatrix) applyMask() {
+	for y := 0; y < qrSize; y++ {
+		for x := 0; x < qrSize; x++ {
+			if !q.isFunction[y][x] && (x+y)%2 == 0 {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// qrEncodeData builds the data codewords: byte mode indicator, length, data,
+// terminator and pad bytes
+func qrEncodeData(text []byte) []byte {
+	var bits []bool
+	appendBits := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, (v>>i)&1 != 0)
+		}
+	}
+	appendBits(0x4, 4)
+	appendBits(len(text), 8)
+	for _, c := range text {
+		appendBits(int(c), 8)
+	}
+	appendBits(0, min(4, qrDataBytes*8-len(bits)))
+	appendBits(0, (8-len(bits)%8)%8)
+
+	out := make([]byte, 0, qrDataBytes)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << (7 - j)
+			}
+		}
+		out = append(out, b)
+	}
+	for pad := byte(0xEC); len(out) < qrDataBytes; pad ^= 0xEC ^ 0x11 {
+		out = append(out, pad)
+	}
+	return out
+}
+
+// qrReedSolomon returns the error correction codewords for data
+func qrReedSolomon(data []byte, degree int) []byte {
+	divisor := make([]byte, degree)
+	divisor[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range divisor {
+			divisor[j] = gfMultiply(divisor[j], root)
+			if j+1 < len(divisor) {
+				divisor[j] ^= divisor[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+
+	result := make([]byte, degree)
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[degree-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,7 @@
+//go:build race
+// +build race
+
+package homekit
+
+// raceEnabled reports whether the tests run with the race detector
+const raceEnabled = true
@@ -0,0 +1,251 @@
+package homekit
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/config"
+
+	"github.com/brutella/hap"
+	"github.com/brutella/hap/characteristic"
+)
+
+// SelfTestStep is one check of the pairing self test
+type SelfTestStep struct {
+	Name   string
+	Detail string
+	Err    error
+}
+
+// SelfTestReport is the outcome of PairingSelfTest, in the order the steps ran
+type SelfTestReport struct {
+	PIN   string
+	Addr  string
+	Steps []SelfTestStep
+}
+
+// Passed reports whether every step succeeded
+func (r *SelfTestReport) Passed() bool {
+	for _, s := range r.Steps {
+		if s.Err != nil {
+			return false
+		}
+	}
+	return len(r.Steps) > 0
+}
+
+// selfTestValues are written to each enabled sensor and read back over HAP
+var selfTestValues = map[string]float64{
+	"Air Temperature":      21.5,
+	"Relative Humidity":    55,
+	"Ambient Light":        12000,
+	"UV Index":             3.2,
+	"Atmospheric Pressure": 1008.4,
+}
+
+// hapAccessoryDB is the GET /accessories answer
+type hapAccessoryDB struct {
+	Accessories []struct {
+		ID       uint64 `json:"aid"`
+		Services []struct {
+			ID              uint64 `json:"iid"`
+			Type            string `json:"type"`
+			Characteristics []struct {
+				ID     uint64      `json:"iid"`
+				Type   string      `json:"type"`
+				Perms  []string    `json:"perms"`
+				Format string      `json:"format"`
+				Value  interface{} `json:"value"`
+			} `json:"characteristics"`
+		} `json:"services"`
+	} `json:"accessories"`
+}
+
+// HAP short type UUIDs checked by the self test
+const (
+	hapTypeAccessoryInformation = "3E"
+	hapTypeName                 = "23"
+)
+
+// PairingSelfTest starts a bridge with the given sensors on an ephemeral
+// loopback port, backed by an in-memory store so real pairings are not
+// touched, then pairs with it like the Home app, validates the accessory
+// database and reads each sensor's value back. An empty pin generates one.
+func PairingSelfTest(pin string, sensorConfig *config.SensorConfig, opts Options) *SelfTestReport {
+	report := &SelfTestReport{}
+	step := func(name, detail string, err error) bool {
+		report.Steps = append(report.Steps, SelfTestStep{Name: name, Detail: detail, Err: err})
+		return err == nil
+	}
+
+	opts.Store = hap.NewMemStore()
+	opts.Address, opts.Interfaces = "", nil
+	ws, err := NewWeatherSystemModern(pin, sensorConfig, "", opts)
+	if !step("Create bridge", "", err) {
+		return report
+	}
+	report.PIN = ws.Server.Pin
+
+	// Pick a free port, since the HAP server does not report the one it got
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !step("Reserve port", "", err) {
+		return report
+	}
+	report.Addr = ln.Addr().String()
+	_ = ln.Close()
+	ws.Server.Addr = report.Addr
+
+	if err := ws.Start(); !step("Start HAP server", report.Addr, err) {
+		return report
+	}
+	defer ws.Stop()
+	if !step("Wait for HAP server", "", waitForListener(ws, report.Addr, 5*time.Second)) {
+		return report
+	}
+
+	c, err := dialHAP(report.Addr)
+	if !step("Connect", "", err) {
+		return report
+	}
+	defer func() { _ = c.Close() }()
+	if !step("Pair setup (SRP, M1-M6)", "", c.pairSetup(report.PIN)) {
+		return report
+	}
+	if !step("Pair verify (M1-M4)", "accessory "+c.accessoryID, c.pairVerify()) {
+		return report
+	}
+
+	status, body, err := c.get("/accessories")
+	if err == nil && status != 200 {
+		err = fmt.Errorf("HTTP %d", status)
+	}
+	var db hapAccessoryDB
+	if err == nil {
+		err = json.Unmarshal(body, &db)
+	}
+	if !step("Read accessory database", fmt.Sprintf("%d accessories", len(db.Accessories)), err) {
+		return report
+	}
+	step("Validate accessory definitions", "", validateAccessoryDB(db, len(ws.named)+1))
+
+	// Write known values and read them back through the encrypted session
+	names := make([]string, 0, len(selfTestValues))
+	for name := range selfTestValues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		acc, ok := ws.Accessories[name]
+		if !ok || acc.AccessoryPtr == nil {
+			continue
+		}
+		value, ok := acc.WeatherValue.(*characteristic.Float)
+		if !ok {
+			continue
+		}
+		want := selfTestValues[name]
+		ws.UpdateSensor(name, want)
+		if acc.convert != nil {
+			want = acc.convert(want)
+		}
+		got, err := readFloat(c, acc.AccessoryPtr.Id, value.Id)
+		if err == nil && math.Abs(got-want) > 0.1 {
+			err = fmt.Errorf("read %v, want %v", got, want)
+		}
+		step("Read "+name, fmt.Sprintf("%v", got), err)
+	}
+	return report
+}
+
+// waitForListener waits until the HAP server accepts connections or fails
+func waitForListener(ws *WeatherSystemModern, addr string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, 200*time.Millisecond)
+		if err == nil {
+			return conn.Close()
+		}
+		if herr := ws.Health(); herr != nil {
+			return herr
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("not listening after %v: %w", timeout, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// validateAccessoryDB checks what the Home app relies on: unique accessory and
+// instance IDs, an accessory information service with a name on every
+// accessory, and a format and permissions on every characteristic
+func validateAccessoryDB(db hapAccessoryDB, wantAccessories int) error {
+	var problems []string
+	if len(db.Accessories) != wantAccessories {
+		problems = append(problems, fmt.Sprintf("%d accessories, want %d", len(db.Accessories), wantAccessories))
+	}
+	aids := make(map[uint64]bool)
+	for _, a := range db.Accessories {
+		if aids[a.ID] {
+			problems = append(problems, fmt.Sprintf("duplicate aid %d", a.ID))
+		}
+		aids[a.ID] = true
+		iids := make(map[uint64]bool)
+		named := false
+		for _, s := range a.Services {
+			if iids[s.ID] {
+				problems = append(problems, fmt.Sprintf("aid %d: duplicate iid %d", a.ID, s.ID))
+			}
+			iids[s.ID] = true
+			for _, ch := range s.Characteristics {
+				if iids[ch.ID] {
+					problems = append(problems, fmt.Sprintf("aid %d: duplicate iid %d", a.ID, ch.ID))
+				}
+				iids[ch.ID] = true
+				if ch.Format == "" || len(ch.Perms) == 0 {
+					problems = append(problems, fmt.Sprintf("aid %d iid %d (type %s): missing format or perms", a.ID, ch.ID, ch.Type))
+				}
+				if s.Type == hapTypeAccessoryInformation && ch.Type == hapTypeName {
+					if name, _ := ch.Value.(string); name != "" {
+						named = true
+					}
+				}
+			}
+		}
+		if !named {
+			problems = append(problems, fmt.Sprintf("aid %d: no accessory information name", a.ID))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// readFloat reads one characteristic value
+func readFloat(c *hapController, aid, iid uint64) (float64, error) {
+	status, body, err := c.get(fmt.Sprintf("/characteristics?id=%d.%d", aid, iid))
+	if err != nil {
+		return 0, err
+	}
+	if status != 200 {
+		return 0, fmt.Errorf("HTTP %d: %s", status, body)
+	}
+	var resp struct {
+		Characteristics []struct {
+			Value  *float64 `json:"value"`
+			Status int      `json:"status"`
+		} `json:"characteristics"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, err
+	}
+	if len(resp.Characteristics) != 1 || resp.Characteristics[0].Value == nil {
+		return 0, fmt.Errorf("no value in %s", body)
+	}
+	return *resp.Characteristics[0].Value, nil
+}
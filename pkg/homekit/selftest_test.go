@@ -0,0 +1,43 @@
+package homekit
+
+import (
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/config"
+)
+
+func TestPairingSelfTest(t *testing.T) {
+	if raceEnabled {
+		// brutella/hap v0.0.32 reads conn.ss in Write without the lock Read
+		// sets it under, so a paired session races inside the library:
+		// https://github.com/brutella/hap/blob/v0.0.32/conn.go#L48-L83
+		t.Skip("brutella/hap v0.0.32 has a data race in conn.Write/conn.Read")
+	}
+	for _, mode := range []string{ModeSplit, ModeCombined} {
+		sensors := config.ParseSensorConfig("temp,humidity,lux,uv,pressure")
+		report := PairingSelfTest("11122333", &sensors, Options{Mode: mode})
+		for _, s := range report.Steps {
+			if s.Err != nil {
+				t.Logf("%s: %s: %v", mode, s.Name, s.Err)
+			}
+		}
+		if !report.Passed() {
+			if len(report.Steps) > 0 && strings.HasPrefix(report.Steps[len(report.Steps)-1].Name, "Wait") {
+				t.Skipf("HAP server cannot start here: %v", report.Steps[len(report.Steps)-1].Err)
+			}
+			t.Fatalf("%s: self test failed", mode)
+		}
+		if got := len(report.Steps); got != 14 {
+			t.Errorf("%s: %d steps, want 14 with five sensor reads", mode, got)
+		}
+	}
+}
+
+func TestValidateAccessoryDB(t *testing.T) {
+	var db hapAccessoryDB
+	db.Accessories = append(db.Accessories, db.Accessories...)
+	if err := validateAccessoryDB(db, 1); err == nil || !strings.Contains(err.Error(), "0 accessories, want 1") {
+		t.Errorf("empty database: %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package homekit
+
+import (
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+
+	"github.com/brutella/hap/accessory"
+	"github.com/brutella/hap/characteristic"
+	"github.com/brutella/hap/service"
+)
+
+// Accessory modes
+const (
+	// ModeSplit publishes one bridged accessory per sensor (the default)
+	ModeSplit = "split"
+	// ModeCombined publishes a single bridged "station" accessory carrying every
+	// sensor service, which the Home app shows as one tile
+	ModeCombined = "combined"
+)
+
+// AccessoryStation is the accessory key of the combined-mode station accessory
+const AccessoryStation = "station"
+
+// stationInfo describes the combined-mode accessory
+var stationInfo = accessory.Info{
+	Name:         "Tempest Weather Station",
+	SerialNumber: "TWS-STATION-001",
+	Manufacturer: "WeatherFlow",
+	Model:        "Tempest Station",
+	Firmware:     "1.0.0",
+}
+
+// sensorService is one sensor's HomeKit service before it is placed on an
+// accessory of its own (split mode) or on the station accessory (combined mode)
+type sensorService struct {
+	key     string         // accessory key, see naming.go
+	sensor  string         // name used by UpdateSensor, e.g. "Air Temperature"
+	info    accessory.Info // accessory info in split mode
+	service *service.S
+	value   *characteristic.Float
+	convert func(float64) float64 // converts UpdateSensor values to the characteristic's unit; nil keeps them
+}
+
+func sensorInfo(name, serial, model string) accessory.Info {
+	return accessory.Info{
+		Name:         name,
+		SerialNumber: serial,
+		Manufacturer: "WeatherFlow",
+		Model:        model,
+		Firmware:     "1.0.0",
+	}
+}
+
+// newSensorServices creates the services for the enabled sensors, in
+// accessory order. The pressure sensor reports pressureUnit (mb when empty).
+func newSensorServices(sensorConfig *config.SensorConfig, pressureUnit string) []sensorService {
+	var specs []sensorService
+
+	// Temperature Sensor
+	if sensorConfig.Temperature {
+		tempService := service.NewTemperatureSensor()
+		specs = append(specs, sensorService{
+			key:     AccessoryTemperature,
+			sensor:  "Air Temperature",
+			info:    sensorInfo("Temperature Sensor", "TWS-TEMP-001", "Tempest Temperature"),
+			service: tempService.S,
+			value:   tempService.CurrentTemperature.Float,
+		})
+	}
+
+	// Humidity Sensor
+	if sensorConfig.Humidity {
+		humidityService := service.NewHumiditySensor()
+		specs = append(specs, sensorService{
+			key:     AccessoryHumidity,
+			sensor:  "Relative Humidity",
+			info:    sensorInfo("Humidity Sensor", "TWS-HUM-001", "Tempest Humidity"),
+			service: humidityService.S,
+			value:   humidityService.CurrentRelativeHumidity.Float,
+		})
+	}
+
+	// Light Sensor (Lux)
+	if sensorConfig.Light {
+		lightService := service.NewLightSensor()
+		specs = append(specs, sensorService{
+			key:     AccessoryLight,
+			sensor:  "Ambient Light",
+			info:    sensorInfo("Light Sensor", "TWS-LUX-001", "Tempest Light"),
+			service: lightService.S,
+			value:   lightService.CurrentAmbientLightLevel.Float,
+		})
+	}
+
+	// UV Sensor: Light Sensor service with a UV Index range
+	if sensorConfig.UV {
+		uvService := service.NewLightSensor()
+		uvService.CurrentAmbientLightLevel.Description = "UV Index"
+		uvService.CurrentAmbientLightLevel.Unit = "UV Index"
+		uvService.CurrentAmbientLightLevel.SetMinValue(0.0)
+		uvService.CurrentAmbientLightLevel.SetMaxValue(15.0)
+		uvService.CurrentAmbientLightLevel.SetStepValue(0.1)
+		uvService.CurrentAmbientLightLevel.SetValue(0.0)
+		specs = append(specs, sensorService{
+			key:     AccessoryUV,
+			sensor:  "UV Index",
+			info:    sensorInfo("UV Index Sensor", "TWS-UV-001", "Tempest UV"),
+			service: uvService.S,
+			value:   uvService.CurrentAmbientLightLevel.Float,
+		})
+	}
+
+	// Pressure Sensor: standard light sensor service with custom labels. The
+	// characteristic range follows the unit so inHg keeps its precision.
+	if sensorConfig.Pressure {
+		if pressureUnit != weather.PressureInHg {
+			pressureUnit = weather.PressureMB
+		}
+		minValue, maxValue, step := weather.PressureRange(pressureUnit)
+		pressureService := service.NewLightSensor()
+		pressureService.CurrentAmbientLightLevel.Description = "Atmospheric Pressure (" + pressureUnit + ")"
+		pressureService.CurrentAmbientLightLevel.Unit = pressureUnit
+		pressureService.CurrentAmbientLightLevel.SetMinValue(minValue)
+		pressureService.CurrentAmbientLightLevel.SetMaxValue(maxValue)
+		pressureService.CurrentAmbientLightLevel.SetStepValue(step)
+		pressureService.CurrentAmbientLightLevel.SetValue(weather.ConvertPressure(1013.25, pressureUnit)) // Standard atmospheric pressure
+		specs = append(specs, sensorService{
+			key:     AccessoryPressure,
+			sensor:  "Atmospheric Pressure",
+			info:    sensorInfo("Pressure Sensor", "TWS-PRESS-001", "Tempest Pressure"),
+			service: pressureService.S,
+			value:   pressureService.CurrentAmbientLightLevel.Float,
+			convert: func(mb float64) float64 { return weather.ConvertPressure(mb, pressureUnit) },
+		})
+	}
+
+	return specs
+}
+
+// Sensors returns the sensors the bridge publishes
+func (ws *WeatherSystemModern) Sensors() config.SensorConfig {
+	ws.adminMu.Lock()
+	defer ws.adminMu.Unlock()
+	return *ws.sensorConfig
+}
+
+// SetSensors publishes another set of sensors without restarting the
+// process. The bridge is rebuilt; accessory IDs, and therefore pairings and
+// the rooms of sensors that stay, are kept.
+func (ws *WeatherSystemModern) SetSensors(sensors config.SensorConfig) error {
+	ws.adminMu.Lock()
+	defer ws.adminMu.Unlock()
+
+	if *ws.sensorConfig == sensors {
+		return nil
+	}
+	previous := ws.sensorConfig
+	ws.sensorConfig = &sensors
+	logger.Info("HomeKit sensors changed to %s, rebuilding the bridge", sensors.String())
+	if err := ws.rebuild(false); err != nil {
+		ws.sensorConfig = previous
+		return err
+	}
+	return nil
+}
+
+// addServiceName labels a service so several services of the same type on one
+// accessory can be told apart in the Home app
+func addServiceName(s *service.S, name string) {
+	n := characteristic.NewName()
+	n.SetValue(name)
+	s.AddC(n.C)
+}
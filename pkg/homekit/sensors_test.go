@@ -0,0 +1,128 @@
+package homekit
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/config"
+
+	"github.com/brutella/hap"
+	"github.com/brutella/hap/characteristic"
+)
+
+func TestCombinedMode(t *testing.T) {
+	cfg := config.SensorConfig{Temperature: true, Humidity: true, UV: true, Pressure: true, Diagnostics: true}
+	naming := AccessoryNaming{Names: map[string]string{AccessoryTemperature: "Backyard Temperature"}}
+	ws, err := NewWeatherSystemModern("00102003", &cfg, "info", Options{Mode: ModeCombined, Naming: naming, Store: hap.NewMemStore()})
+	if err != nil {
+		t.Fatalf("NewWeatherSystemModern returned error: %v", err)
+	}
+
+	if len(ws.named) != 1 || ws.named[0].key != AccessoryStation {
+		t.Fatalf("expected a single station accessory, got %d accessories", len(ws.named))
+	}
+	station := ws.named[0].acc
+	if station.Id != 2 {
+		t.Errorf("station accessory ID = %d, want 2", station.Id)
+	}
+	// accessory information + 4 sensors + 3 diagnostics services
+	if got := len(station.Ss); got != 8 {
+		t.Errorf("station has %d services, want 8", got)
+	}
+	for _, sensor := range []string{"Air Temperature", "Relative Humidity", "UV Index", "Atmospheric Pressure"} {
+		if acc := ws.Accessories[sensor]; acc == nil || acc.AccessoryPtr != station {
+			t.Errorf("%s is not on the station accessory", sensor)
+		}
+	}
+
+	ws.UpdateSensor("UV Index", 6.5)
+	if got := ws.Accessories["UV Index"].WeatherValue.(*characteristic.Float).Value(); got != 6.5 {
+		t.Errorf("UV Index = %v, want 6.5", got)
+	}
+	ws.UpdateDiagnostics(Diagnostics{DataAge: time.Minute, DataSource: "udp"})
+	if got := ws.diagnostics.dataSource.Value(); got != "udp" {
+		t.Errorf("diagnostics data source = %q, want udp", got)
+	}
+
+	// Services are labelled so the Home app can tell the light sensors apart
+	names := map[string]bool{}
+	for _, s := range station.Ss {
+		for _, c := range s.Cs {
+			if c.Type == characteristic.TypeName {
+				if v, ok := c.Val.(string); ok {
+					names[v] = true
+				}
+			}
+		}
+	}
+	for _, want := range []string{"Backyard Temperature", "Humidity Sensor", "UV Index Sensor", "Pressure Sensor", "Data Flowing"} {
+		if !names[want] {
+			t.Errorf("no service named %q on the station accessory", want)
+		}
+	}
+}
+
+func TestSplitModeIsDefault(t *testing.T) {
+	cfg := config.SensorConfig{Temperature: true, Humidity: true}
+	ws, err := NewWeatherSystemModern("00102003", &cfg, "info", Options{Store: hap.NewMemStore()})
+	if err != nil {
+		t.Fatalf("NewWeatherSystemModern returned error: %v", err)
+	}
+	if len(ws.named) != 2 {
+		t.Fatalf("expected one accessory per sensor, got %d", len(ws.named))
+	}
+	if ws.Accessories["Air Temperature"].AccessoryPtr == ws.Accessories["Relative Humidity"].AccessoryPtr {
+		t.Error("split mode put two sensors on the same accessory")
+	}
+}
+
+func TestPressureUnit(t *testing.T) {
+	cfg := config.SensorConfig{Pressure: true}
+	for unit, want := range map[string]float64{"": 1013.2, "mb": 1013.2, "inHg": 29.92} {
+		ws, err := NewWeatherSystemModern("00102003", &cfg, "info", Options{Store: hap.NewMemStore(), PressureUnit: unit})
+		if err != nil {
+			t.Fatalf("NewWeatherSystemModern returned error: %v", err)
+		}
+		// UpdateSensor takes mb whatever the unit HomeKit shows
+		ws.UpdateSensor("Atmospheric Pressure", 1013.2)
+		c := ws.Accessories["Atmospheric Pressure"].WeatherValue.(*characteristic.Float)
+		if got := c.Value(); math.Abs(got-want) > 0.01 {
+			t.Errorf("%q: pressure = %v, want %v", unit, got, want)
+		}
+		if wantUnit := map[string]string{"": "mb", "mb": "mb", "inHg": "inHg"}[unit]; c.Unit != wantUnit {
+			t.Errorf("%q: characteristic unit = %q, want %q", unit, c.Unit, wantUnit)
+		}
+	}
+}
+
+func TestSetSensors(t *testing.T) {
+	ws, store := newPairingTestSystem(t)
+	id := ws.Accessories["Air Temperature"].AccessoryPtr.Id
+
+	if err := ws.SetSensors(config.SensorConfig{Temperature: true, Humidity: true}); err != nil {
+		t.Fatalf("SetSensors returned error: %v", err)
+	}
+	if _, ok := ws.Accessories["Relative Humidity"]; !ok {
+		t.Error("humidity accessory not published after enabling it")
+	}
+	if got := ws.Accessories["Air Temperature"].AccessoryPtr.Id; got != id {
+		t.Errorf("temperature accessory ID changed from %d to %d", id, got)
+	}
+	if got := ws.Sensors(); got != (config.SensorConfig{Temperature: true, Humidity: true}) {
+		t.Errorf("Sensors() = %v", got)
+	}
+
+	if err := ws.SetSensors(config.SensorConfig{Humidity: true}); err != nil {
+		t.Fatalf("SetSensors returned error: %v", err)
+	}
+	if acc := ws.Accessories["Air Temperature"]; acc != nil && acc.AccessoryPtr != nil {
+		t.Error("temperature accessory still published after disabling it")
+	}
+	if pairings, _ := ws.Pairings(); len(pairings) != 2 {
+		t.Errorf("pairings after changing sensors = %d, want 2", len(pairings))
+	}
+	if _, err := store.Get(accessoryRecordsKey); err != nil {
+		t.Errorf("accessory records lost: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package homekit
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"tempest-homekit-go/pkg/logger"
+
+	"github.com/brutella/hap"
+)
+
+// setupRecordKey is the HAP store key holding the generated setup PIN and the
+// setup ID
+const setupRecordKey = "setup.json"
+
+// categoryBridge is the HAP accessory category of the bridge, encoded in the
+// setup URI so the Home app shows the right icon while pairing
+const categoryBridge = 2
+
+// setupIDChars are the characters of a setup ID
+const setupIDChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// SetupInfo is what a controller needs to pair with the bridge
+type SetupInfo struct {
+	PIN       string // 8 digits, e.g. "03145154"
+	SetupCode string // PIN as shown on HomeKit labels, e.g. "031-45-154"
+	SetupID   string // 4 characters, identifies the bridge when scanning the QR code
+	SetupURI  string // X-HM:// payload encoded in the QR code
+	Generated bool   // true when the PIN was generated rather than configured
+}
+
+type setupRecord struct {
+	PIN     string `json:"pin,omitempty"` // only set for generated PINs
+	SetupID string `json:"setup_id"`
+}
+
+// loadSetup returns the pairing setup for the bridge. A configured pin is used
+// as is. Without one, the PIN generated on the first run is read from the
+// store, or a new random PIN is generated and saved. The setup ID is generated
+// once and kept, since the bridge advertises a hash of it over mDNS.
+func loadSetup(store hap.Store, pin string) (SetupInfo, error) {
+	var rec setupRecord
+	if b, err := store.Get(setupRecordKey); err == nil && len(b) > 0 {
+		if err := json.Unmarshal(b, &rec); err != nil {
+			logger.Warn("Ignoring unreadable HomeKit setup record: %v", err)
+			rec = setupRecord{}
+		}
+	}
+
+	changed := false
+	if !validSetupID(rec.SetupID) {
+		id, err := randomSetupID()
+		if err != nil {
+			return SetupInfo{}, err
+		}
+		rec.SetupID = id
+		changed = true
+	}
+
+	generated := pin == ""
+	if generated {
+		if !validPIN(rec.PIN) {
+			p, err := randomPIN()
+			if err != nil {
+				return SetupInfo{}, err
+			}
+			rec.PIN = p
+			changed = true
+			logger.Info("Generated HomeKit setup PIN %s (saved in the HomeKit store)", formatSetupCode(p))
+		}
+		pin = rec.PIN
+	}
+
+	if changed {
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return SetupInfo{}, fmt.Errorf("failed to encode HomeKit setup record: %w", err)
+		}
+		if err := store.Set(setupRecordKey, b); err != nil {
+			return SetupInfo{}, fmt.Errorf("failed to save HomeKit setup record: %w", err)
+		}
+	}
+
+	return SetupInfo{
+		PIN:       pin,
+		SetupCode: formatSetupCode(pin),
+		SetupID:   rec.SetupID,
+		SetupURI:  SetupURI(pin, rec.SetupID, categoryBridge),
+		Generated: generated,
+	}, nil
+}
+
+// SetupURI returns the X-HM:// setup payload for an IP accessory: the setup
+// code, the "supports IP" flag and the category packed into an integer, in
+// base 36 padded to 9 characters, followed by the setup ID
+func SetupURI(pin, setupID string, category int) string {
+	code, _ := strconv.ParseUint(pin, 10, 64)
+	payload := code&(1<<27-1) | 1<<28 | uint64(category)<<31
+	encoded := strings.ToUpper(strconv.FormatUint(payload, 36))
+	if len(encoded) < 9 {
+		encoded = strings.Repeat("0", 9-len(encoded)) + encoded
+	}
+	return "X-HM://" + encoded + setupID
+}
+
+func formatSetupCode(pin string) string {
+	if len(pin) != 8 {
+		return pin
+	}
+	return pin[:3] + "-" + pin[3:5] + "-" + pin[5:]
+}
+
+func validPIN(pin string) bool {
+	if len(pin) != 8 || hap.InvalidPins[pin] {
+		return false
+	}
+	_, err := strconv.Atoi(pin)
+	return err == nil
+}
+
+func validSetupID(id string) bool {
+	if len(id) != 4 {
+		return false
+	}
+	for _, c := range id {
+		if !strings.ContainsRune(setupIDChars, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// randomPIN returns a random 8 digit PIN that hap accepts
+func randomPIN() (string, error) {
+	for {
+		n, err := rand.Int(rand.Reader, big.NewInt(100000000))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate HomeKit PIN: %w", err)
+		}
+		if pin := fmt.Sprintf("%08d", n.Int64()); validPIN(pin) {
+			return pin, nil
+		}
+	}
+}
+
+func randomSetupID() (string, error) {
+	id := make([]byte, 4)
+	for i := range id {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(setupIDChars))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate HomeKit setup ID: %w", err)
+		}
+		id[i] = setupIDChars[n.Int64()]
+	}
+	return string(id), nil
+}
+
+// Setup returns the pairing setup of the bridge
+func (ws *WeatherSystemModern) Setup() SetupInfo {
+	return ws.setup
+}
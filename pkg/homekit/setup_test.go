@@ -0,0 +1,98 @@
+package homekit
+
+import (
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/config"
+
+	"github.com/brutella/hap"
+)
+
+func TestSetupURI(t *testing.T) {
+	// Example from the HAP specification tooling: 031-45-154, bridge category
+	if got := SetupURI("03145154", "1QJ8", categoryBridge); got != "X-HM://0023ISYWY1QJ8" {
+		t.Errorf("SetupURI = %q, want X-HM://0023ISYWY1QJ8", got)
+	}
+}
+
+func TestLoadSetupGeneratesAndPersists(t *testing.T) {
+	store := hap.NewMemStore()
+	first, err := loadSetup(store, "")
+	if err != nil {
+		t.Fatalf("loadSetup returned error: %v", err)
+	}
+	if !first.Generated || !validPIN(first.PIN) || !validSetupID(first.SetupID) {
+		t.Fatalf("unexpected generated setup: %+v", first)
+	}
+	if first.SetupCode != first.PIN[:3]+"-"+first.PIN[3:5]+"-"+first.PIN[5:] {
+		t.Errorf("setup code %q does not match PIN %q", first.SetupCode, first.PIN)
+	}
+	if !strings.HasPrefix(first.SetupURI, "X-HM://") || !strings.HasSuffix(first.SetupURI, first.SetupID) {
+		t.Errorf("unexpected setup URI %q", first.SetupURI)
+	}
+
+	second, err := loadSetup(store, "")
+	if err != nil {
+		t.Fatalf("loadSetup returned error: %v", err)
+	}
+	if second != first {
+		t.Errorf("setup changed across restarts: %+v then %+v", first, second)
+	}
+
+	// A configured PIN wins but keeps the setup ID
+	configured, err := loadSetup(store, "24681357")
+	if err != nil {
+		t.Fatalf("loadSetup returned error: %v", err)
+	}
+	if configured.PIN != "24681357" || configured.Generated || configured.SetupID != first.SetupID {
+		t.Errorf("unexpected setup with configured PIN: %+v", configured)
+	}
+}
+
+func TestNewWeatherSystemModernSetup(t *testing.T) {
+	cfg := config.SensorConfig{Temperature: true}
+	ws, err := NewWeatherSystemModern("", &cfg, "info", Options{Store: hap.NewMemStore()})
+	if err != nil {
+		t.Fatalf("NewWeatherSystemModern returned error: %v", err)
+	}
+	setup := ws.Setup()
+	if ws.Server.Pin != setup.PIN || ws.Server.SetupId != setup.SetupID {
+		t.Errorf("server not configured with setup %+v", setup)
+	}
+	info := ws.GetDetailedInfo()
+	if info["setupURI"] != setup.SetupURI || info["pinGenerated"] != true {
+		t.Errorf("detailed info missing setup: %v", info)
+	}
+}
+
+func TestQRCode(t *testing.T) {
+	modules, err := QRCode("X-HM://0023ISYWY1QJ8")
+	if err != nil {
+		t.Fatalf("QRCode returned error: %v", err)
+	}
+	if len(modules) != 25 || len(modules[0]) != 25 {
+		t.Fatalf("expected a 25x25 version 2 symbol, got %dx%d", len(modules), len(modules[0]))
+	}
+	// Finder pattern corners and the always-dark module
+	for _, p := range [][2]int{{0, 0}, {0, 24}, {24, 0}, {17, 8}} {
+		if !modules[p[0]][p[1]] {
+			t.Errorf("module %v should be dark", p)
+		}
+	}
+	if modules[7][7] {
+		t.Error("finder separator should be light")
+	}
+
+	text, err := QRCodeText("X-HM://0023ISYWY1QJ8")
+	if err != nil {
+		t.Fatalf("QRCodeText returned error: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n"); len(lines) != 15 {
+		t.Errorf("expected 15 text lines, got %d", len(lines))
+	}
+
+	if _, err := QRCode(strings.Repeat("x", 27)); err == nil {
+		t.Error("expected error for text longer than version 2 capacity")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -44,6 +45,23 @@ func SetLogLevel(level string) {
 	}
 }
 
+// SetLogFile appends all log output, and anything written to stdout and
+// stderr, to path. Services without a console (Windows services) use it to
+// keep their logs.
+func SetLogFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	os.Stdout = f
+	os.Stderr = f
+	log.SetOutput(f)
+	return nil
+}
+
 // SetLogFilter configures the global log filter string
 // Only messages containing this string (case-insensitive) will be output
 func SetLogFilter(filter string) {
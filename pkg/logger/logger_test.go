@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -160,3 +161,21 @@ func TestWarnLogLevel(t *testing.T) {
 	// restore
 	SetLogLevel(LogLevelError)
 }
+
+func TestSetLogFile(t *testing.T) {
+	stdout, stderr := os.Stdout, os.Stderr
+	defer func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		log.SetOutput(os.Stderr)
+	}()
+
+	path := filepath.Join(t.TempDir(), "logs", "bridge.log")
+	if err := SetLogFile(path); err != nil {
+		t.Fatalf("SetLogFile returned error: %v", err)
+	}
+	Error("written to the log file")
+	b, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(b), "written to the log file") {
+		t.Errorf("log file content %q, %v", b, err)
+	}
+}
@@ -0,0 +1,298 @@
+// Package maintenance schedules windows during which the station is being
+// serviced or relocated. While a window is active alarms and uploads are
+// suspended; when it ends they resume on their own and the window is kept as
+// a gap in the observation history, so the readings around it are not taken
+// at face value and the gap is not backfilled.
+package maintenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+)
+
+// StateFile is where scheduled windows and past gaps are kept across
+// restarts; empty keeps them in memory only. main sets it from the data
+// directory.
+var StateFile string
+
+const (
+	// MaxDuration is the longest window, so a forgotten one cannot silence
+	// alarms for good
+	MaxDuration = 7 * 24 * time.Hour
+
+	// keepGaps is how many completed windows are kept
+	keepGaps = 100
+)
+
+// Window is a period of maintenance
+type Window struct {
+	ID     int       `json:"id"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason,omitempty"`
+	Source string    `json:"source"` // flag or api
+}
+
+// Contains reports whether t falls within the window
+func (w Window) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// overlaps reports whether the window and [start, end) share any time
+func (w Window) overlaps(start, end time.Time) bool {
+	return w.Start.Before(end) && start.Before(w.End)
+}
+
+// Status is the state /api/maintenance reports
+type Status struct {
+	Active    *Window  `json:"active,omitempty"` // the window in progress
+	Scheduled []Window `json:"scheduled"`        // windows still to come, soonest first
+	Gaps      []Window `json:"gaps"`             // completed windows, oldest first
+}
+
+// state is what StateFile holds
+type state struct {
+	NextID  int      `json:"nextId"`
+	Windows []Window `json:"windows"`
+	Gaps    []Window `json:"gaps"`
+}
+
+// Scheduler holds the maintenance windows. Windows start and end as time
+// passes; there is no timer, the next call after a boundary notices it.
+type Scheduler struct {
+	mu      sync.Mutex
+	windows []Window // active and scheduled, by start
+	gaps    []Window // completed, oldest first
+	nextID  int
+	started int // ID of the window last logged as started
+	now     func() time.Time
+}
+
+// New returns a scheduler with the windows saved in StateFile
+func New() *Scheduler {
+	s := &Scheduler{nextID: 1, now: time.Now}
+	s.load()
+	return s
+}
+
+// Add schedules a window from start to end. Windows may not overlap, end in
+// the past or last longer than MaxDuration.
+func (s *Scheduler) Add(start, end time.Time, reason, source string) (Window, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.advanceLocked(now)
+
+	if !end.After(start) {
+		return Window{}, fmt.Errorf("maintenance must end after it starts")
+	}
+	if !end.After(now) {
+		return Window{}, fmt.Errorf("maintenance window %s is already over", formatRange(start, end))
+	}
+	if end.Sub(start) > MaxDuration {
+		return Window{}, fmt.Errorf("maintenance windows are limited to %s", MaxDuration)
+	}
+	for _, w := range s.windows {
+		if w.overlaps(start, end) {
+			return Window{}, fmt.Errorf("overlaps maintenance window %d (%s)", w.ID, formatRange(w.Start, w.End))
+		}
+	}
+
+	w := Window{ID: s.nextID, Start: start.Truncate(time.Second), End: end.Truncate(time.Second), Reason: strings.TrimSpace(reason), Source: source}
+	s.nextID++
+	s.windows = append(s.windows, w)
+	sort.Slice(s.windows, func(i, j int) bool { return s.windows[i].Start.Before(s.windows[j].Start) })
+	logger.Info("Maintenance window %d scheduled: %s%s", w.ID, formatRange(w.Start, w.End), reasonSuffix(w.Reason))
+	s.advanceLocked(now)
+	s.saveLocked()
+	return w, nil
+}
+
+// End ends the window with the given ID now, or cancels it when it has not
+// started. ID 0 ends the active window. An ended window is kept as a gap.
+func (s *Scheduler) End(id int) (Window, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.advanceLocked(now)
+
+	for i, w := range s.windows {
+		if (id == 0 && w.Contains(now)) || (id != 0 && w.ID == id) {
+			s.windows = append(s.windows[:i], s.windows[i+1:]...)
+			if w.Start.After(now) {
+				logger.Info("Maintenance window %d cancelled", w.ID)
+			} else {
+				w.End = now.Truncate(time.Second)
+				s.finishLocked(w)
+			}
+			s.saveLocked()
+			return w, nil
+		}
+	}
+	if id == 0 {
+		return Window{}, fmt.Errorf("no maintenance in progress")
+	}
+	return Window{}, fmt.Errorf("no scheduled maintenance window %d", id)
+}
+
+// Active returns the window in progress, if any
+func (s *Scheduler) Active() (Window, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.advanceLocked(now)
+	for _, w := range s.windows {
+		if w.Contains(now) {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
+
+// Suspended reports whether maintenance is in progress; alarms and uploads
+// check it before running
+func (s *Scheduler) Suspended() bool {
+	_, ok := s.Active()
+	return ok
+}
+
+// Status returns the active, scheduled and completed windows
+func (s *Scheduler) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.advanceLocked(now)
+	status := Status{Scheduled: []Window{}, Gaps: append([]Window{}, s.gaps...)}
+	for _, w := range s.windows {
+		if w.Contains(now) {
+			active := w
+			status.Active = &active
+		} else {
+			status.Scheduled = append(status.Scheduled, w)
+		}
+	}
+	return status
+}
+
+// Gaps returns the completed and active windows overlapping [start, end),
+// the active one ending now
+func (s *Scheduler) Gaps(start, end time.Time) []Window {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.advanceLocked(now)
+	var gaps []Window
+	for _, w := range s.gaps {
+		if w.overlaps(start, end) {
+			gaps = append(gaps, w)
+		}
+	}
+	for _, w := range s.windows {
+		if w.Contains(now) {
+			w.End = now.Truncate(time.Second)
+			if w.overlaps(start, end) {
+				gaps = append(gaps, w)
+			}
+		}
+	}
+	return gaps
+}
+
+// advanceLocked logs windows that started and moves those that ended to the
+// gaps; the caller holds s.mu
+func (s *Scheduler) advanceLocked(now time.Time) {
+	changed := false
+	kept := s.windows[:0]
+	for _, w := range s.windows {
+		if !now.Before(w.End) {
+			s.finishLocked(w)
+			changed = true
+			continue
+		}
+		if w.Contains(now) && s.started != w.ID {
+			s.started = w.ID
+			logger.Warn("Maintenance window %d started: alarms and uploads are suspended until %s%s", w.ID, w.End.Format(time.RFC3339), reasonSuffix(w.Reason))
+		}
+		kept = append(kept, w)
+	}
+	s.windows = kept
+	if changed {
+		s.saveLocked()
+	}
+}
+
+// finishLocked records a window that ended as a gap; the caller holds s.mu
+// and saves
+func (s *Scheduler) finishLocked(w Window) {
+	if s.started == w.ID {
+		s.started = 0
+	}
+	s.gaps = append(s.gaps, w)
+	if len(s.gaps) > keepGaps {
+		s.gaps = s.gaps[len(s.gaps)-keepGaps:]
+	}
+	logger.Info("Maintenance window %d ended after %s: alarms and uploads resumed", w.ID, w.End.Sub(w.Start).Round(time.Second))
+}
+
+// saveLocked writes the windows to StateFile; the caller holds s.mu
+func (s *Scheduler) saveLocked() {
+	if StateFile == "" {
+		return
+	}
+	data, err := json.MarshalIndent(state{NextID: s.nextID, Windows: s.windows, Gaps: s.gaps}, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(StateFile), 0755); err != nil {
+		logger.Warn("Failed to save maintenance windows: %v", err)
+		return
+	}
+	tmp := StateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		logger.Warn("Failed to save maintenance windows: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, StateFile); err != nil {
+		logger.Warn("Failed to save maintenance windows: %v", err)
+	}
+}
+
+func (s *Scheduler) load() {
+	if StateFile == "" {
+		return
+	}
+	data, err := os.ReadFile(StateFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("Failed to read maintenance windows: %v", err)
+		}
+		return
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		logger.Warn("Ignoring invalid maintenance state %s: %v", StateFile, err)
+		return
+	}
+	s.windows, s.gaps = st.Windows, st.Gaps
+	s.nextID = max(st.NextID, 1)
+}
+
+// formatRange renders a window's times for logs and errors
+func formatRange(start, end time.Time) string {
+	return start.Format(time.RFC3339) + " to " + end.Format(time.RFC3339)
+}
+
+func reasonSuffix(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	return " (" + reason + ")"
+}
@@ -0,0 +1,130 @@
+package maintenance
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testScheduler returns a scheduler whose clock the test moves
+func testScheduler(t *testing.T, now *time.Time) *Scheduler {
+	t.Helper()
+	StateFile = filepath.Join(t.TempDir(), "maintenance.json")
+	t.Cleanup(func() { StateFile = "" })
+	s := New()
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestSchedulerWindows(t *testing.T) {
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	s := testScheduler(t, &now)
+
+	w, err := s.Add(now.Add(time.Hour), now.Add(3*time.Hour), " moving the mast ", "api")
+	if err != nil || w.ID != 1 || w.Reason != "moving the mast" {
+		t.Fatalf("Add = %+v, %v", w, err)
+	}
+	for name, times := range map[string][2]time.Time{
+		"overlap":   {now.Add(2 * time.Hour), now.Add(4 * time.Hour)},
+		"backwards": {now.Add(5 * time.Hour), now.Add(4 * time.Hour)},
+		"past":      {now.Add(-2 * time.Hour), now.Add(-time.Hour)},
+		"too long":  {now.Add(5 * time.Hour), now.Add(5*time.Hour + MaxDuration + time.Second)},
+	} {
+		if _, err := s.Add(times[0], times[1], "", "api"); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+	if s.Suspended() {
+		t.Error("suspended before the window starts")
+	}
+
+	now = now.Add(90 * time.Minute)
+	if active, ok := s.Active(); !ok || active.ID != 1 || !s.Suspended() {
+		t.Errorf("Active = %+v, %v; want window 1", active, ok)
+	}
+	if gaps := s.Gaps(now.Add(-time.Hour), now.Add(time.Hour)); len(gaps) != 1 || !gaps[0].End.Equal(now) {
+		t.Errorf("Gaps during maintenance = %+v, want the active window ending now", gaps)
+	}
+
+	// It resumes on its own and the window becomes a gap
+	now = now.Add(2 * time.Hour)
+	status := s.Status()
+	if s.Suspended() || status.Active != nil || len(status.Scheduled) != 0 || len(status.Gaps) != 1 {
+		t.Errorf("after the window: %+v", status)
+	}
+
+	// Ending early keeps the gap up to now; cancelling leaves no gap
+	if _, err := s.Add(now, now.Add(time.Hour), "", "api"); err != nil {
+		t.Fatal(err)
+	}
+	later, _ := s.Add(now.Add(2*time.Hour), now.Add(3*time.Hour), "", "api")
+	now = now.Add(10 * time.Minute)
+	if ended, err := s.End(0); err != nil || !ended.End.Equal(now) {
+		t.Errorf("End(0) = %+v, %v", ended, err)
+	}
+	if _, err := s.End(0); err == nil {
+		t.Error("End(0) without maintenance: want an error")
+	}
+	if _, err := s.End(later.ID); err != nil {
+		t.Errorf("cancel: %v", err)
+	}
+	if _, err := s.End(99); err == nil {
+		t.Error("End(99): want an error")
+	}
+	if status := s.Status(); len(status.Gaps) != 2 || len(status.Scheduled) != 0 {
+		t.Errorf("status = %+v, want two gaps", status)
+	}
+
+	// The state file keeps the gaps and the ID sequence
+	restored := New()
+	restored.now = s.now
+	if status := restored.Status(); len(status.Gaps) != 2 {
+		t.Errorf("restored status = %+v", status)
+	}
+	if w, _ := restored.Add(now.Add(time.Hour), now.Add(2*time.Hour), "", "api"); w.ID != 4 {
+		t.Errorf("restored next ID = %d, want 4", w.ID)
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	windows, err := ParseSpec("2h, 2025-06-02T09:00:00Z/2025-06-02T13:00:00Z,2025-06-03T09:00:00Z/30m", now)
+	if err != nil || len(windows) != 3 {
+		t.Fatalf("ParseSpec = %+v, %v", windows, err)
+	}
+	if !windows[0].Start.Equal(now) || windows[0].End.Sub(windows[0].Start) != 2*time.Hour {
+		t.Errorf("2h = %+v", windows[0])
+	}
+	if windows[1].End.Sub(windows[1].Start) != 4*time.Hour || windows[2].End.Sub(windows[2].Start) != 30*time.Minute {
+		t.Errorf("ranges = %+v", windows[1:])
+	}
+	if windows, err := ParseSpec("2025-06-02T09:00/2025-06-02 10:30", now); err != nil || windows[0].Start.Location() != time.Local {
+		t.Errorf("local times = %+v, %v", windows, err)
+	}
+	for _, bad := range []string{"soon", "-1h", "2025-06-02T09:00/2025-06-02T08:00", "tomorrow/2h", "2025-06-02T09:00/200h"} {
+		if _, err := ParseSpec(bad, now); err == nil {
+			t.Errorf("%s accepted", bad)
+		}
+	}
+}
+
+func TestAddSpec(t *testing.T) {
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	s := testScheduler(t, &now)
+	spec := "2025-06-01T07:00:00Z/2025-06-01T07:30:00Z,2025-06-01T09:00:00Z/1h"
+	if err := s.AddSpec(spec); err != nil {
+		t.Fatal(err)
+	}
+	// Restarting with the same flag adds nothing twice
+	if err := s.AddSpec(spec); err != nil {
+		t.Fatal(err)
+	}
+	status := s.Status()
+	if len(status.Scheduled) != 1 || status.Scheduled[0].Source != "flag" || len(status.Gaps) != 0 {
+		t.Errorf("status = %+v, want the future window only", status)
+	}
+	if err := s.AddSpec("2025-06-01T09:30:00Z/1h"); err == nil || !strings.Contains(err.Error(), "overlaps") {
+		t.Errorf("overlapping flag window: %v", err)
+	}
+}
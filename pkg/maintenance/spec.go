@@ -0,0 +1,107 @@
+package maintenance
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+)
+
+// localLayouts are the times a window can be given in besides RFC 3339, in
+// the server's time zone
+var localLayouts = []string{"2006-01-02T15:04", "2006-01-02 15:04"}
+
+// parseTime reads an RFC 3339 or local time
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s'. Use 2006-01-02T15:04 or RFC 3339", s)
+}
+
+// ParseSpec reads the --maintenance windows: a comma-separated list of
+// durations starting now ("2h") and START/END or START/DURATION ranges
+// ("2025-06-01T09:00/2025-06-01T13:00", "2025-06-01T09:00/4h")
+func ParseSpec(spec string, now time.Time) ([]Window, error) {
+	var windows []Window
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		var w Window
+		startText, endText, isRange := strings.Cut(item, "/")
+		if !isRange {
+			d, err := time.ParseDuration(item)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid maintenance window '%s'. Use a duration such as 2h or START/END", item)
+			}
+			w.Start, w.End = now, now.Add(d)
+		} else {
+			start, err := parseTime(strings.TrimSpace(startText))
+			if err != nil {
+				return nil, err
+			}
+			w.Start = start
+			endText = strings.TrimSpace(endText)
+			if d, err := time.ParseDuration(endText); err == nil {
+				w.End = start.Add(d)
+			} else if w.End, err = parseTime(endText); err != nil {
+				return nil, err
+			}
+		}
+		if !w.End.After(w.Start) {
+			return nil, fmt.Errorf("maintenance window '%s' must end after it starts", item)
+		}
+		if w.End.Sub(w.Start) > MaxDuration {
+			return nil, fmt.Errorf("maintenance window '%s' is longer than %s", item, MaxDuration)
+		}
+		w.Source = "flag"
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// AddSpec schedules the --maintenance windows. Windows already scheduled or
+// over are skipped, so restarting with the same flag adds nothing twice.
+func (s *Scheduler) AddSpec(spec string) error {
+	windows, err := ParseSpec(spec, s.now())
+	if err != nil {
+		return err
+	}
+	for _, w := range windows {
+		if s.known(w) {
+			continue
+		}
+		if !w.End.After(s.now()) {
+			logger.Debug("Skipping maintenance window %s, already over", formatRange(w.Start, w.End))
+			continue
+		}
+		if _, err := s.Add(w.Start, w.End, "", w.Source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// known reports whether a flag window with the same start is scheduled or
+// done; one ended early keeps its start
+func (s *Scheduler) known(w Window) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	start := w.Start.Truncate(time.Second)
+	for _, list := range [][]Window{s.windows, s.gaps} {
+		for _, k := range list {
+			if k.Source == "flag" && k.Start.Equal(start) {
+				return true
+			}
+		}
+	}
+	return false
+}
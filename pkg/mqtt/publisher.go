@@ -0,0 +1,191 @@
+// Package mqtt implements the small part of MQTT 3.1.1 the service needs:
+// publishing retained QoS 0 messages to a broker.
+package mqtt
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	packetConnect    = 0x10
+	packetConnAck    = 0x20
+	packetPublish    = 0x30
+	packetDisconnect = 0xE0
+
+	flagRetain = 0x01
+
+	dialTimeout = 10 * time.Second
+)
+
+// Message is one message to publish
+type Message struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+}
+
+// Publisher sends messages to a broker. Each Publish call opens a connection,
+// sends its messages and disconnects, which suits infrequent updates and
+// needs no keep-alive or reconnect handling.
+type Publisher struct {
+	address  string
+	useTLS   bool
+	clientID string
+	username string
+	password string
+}
+
+// NewPublisher creates a publisher for broker, given as host[:port] or as a
+// tcp://, mqtt://, ssl:// or mqtts:// URL. The port defaults to 1883, or 8883
+// with TLS.
+func NewPublisher(broker, clientID, username, password string) (*Publisher, error) {
+	p := &Publisher{clientID: clientID, username: username, password: password}
+	host := broker
+	if strings.Contains(broker, "://") {
+		u, err := url.Parse(broker)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MQTT broker %q: %w", broker, err)
+		}
+		switch u.Scheme {
+		case "tcp", "mqtt":
+		case "ssl", "tls", "mqtts":
+			p.useTLS = true
+		default:
+			return nil, fmt.Errorf("unsupported MQTT broker scheme %q", u.Scheme)
+		}
+		host = u.Host
+	}
+	if host == "" {
+		return nil, fmt.Errorf("invalid MQTT broker %q: missing host", broker)
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		port := "1883"
+		if p.useTLS {
+			port = "8883"
+		}
+		host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+	}
+	p.address = host
+	return p, nil
+}
+
+// Address returns the broker's host:port
+func (p *Publisher) Address() string {
+	return p.address
+}
+
+// Publish sends the messages over one connection
+func (p *Publisher) Publish(messages ...Message) error {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	var conn net.Conn
+	var err error
+	if p.useTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", p.address, &tls.Config{MinVersion: tls.VersionTLS12})
+	} else {
+		conn, err = dialer.Dial("tcp", p.address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to MQTT broker %s: %w", p.address, err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(dialTimeout))
+
+	w := bufio.NewWriter(conn)
+	if _, err := w.Write(p.connectPacket()); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := readConnAck(conn); err != nil {
+		return err
+	}
+	for _, m := range messages {
+		if _, err := w.Write(publishPacket(m)); err != nil {
+			return err
+		}
+	}
+	if _, err := w.Write([]byte{packetDisconnect, 0}); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func (p *Publisher) connectPacket() []byte {
+	flags := byte(0x02) // clean session
+	payload := encodeString(p.clientID)
+	if p.username != "" {
+		flags |= 0x80
+		payload = append(payload, encodeString(p.username)...)
+		if p.password != "" {
+			flags |= 0x40
+			payload = append(payload, encodeString(p.password)...)
+		}
+	}
+	body := append(encodeString("MQTT"), 4, flags, 0, 60) // protocol level 4, 60s keep-alive
+	return packet(packetConnect, append(body, payload...))
+}
+
+func publishPacket(m Message) []byte {
+	header := byte(packetPublish)
+	if m.Retain {
+		header |= flagRetain
+	}
+	return packet(header, append(encodeString(m.Topic), m.Payload...))
+}
+
+// connAckErrors are the CONNACK return codes of MQTT 3.1.1
+var connAckErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+func readConnAck(r io.Reader) error {
+	var ack [4]byte
+	if _, err := io.ReadFull(r, ack[:]); err != nil {
+		return fmt.Errorf("no CONNACK from MQTT broker: %w", err)
+	}
+	if ack[0] != packetConnAck || ack[1] != 2 {
+		return errors.New("unexpected reply from MQTT broker")
+	}
+	if ack[3] != 0 {
+		reason, ok := connAckErrors[ack[3]]
+		if !ok {
+			reason = fmt.Sprintf("return code %d", ack[3])
+		}
+		return fmt.Errorf("MQTT broker refused connection: %s", reason)
+	}
+	return nil
+}
+
+func packet(header byte, body []byte) []byte {
+	out := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(out, body...)
+}
+
+func encodeString(s string) []byte {
+	return append([]byte{byte(len(s) >> 8), byte(len(s))}, s...)
+}
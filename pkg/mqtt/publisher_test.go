@@ -0,0 +1,135 @@
+package mqtt
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeBroker accepts one connection, answers CONNACK with code and returns
+// the packets it received
+func fakeBroker(t *testing.T, code byte) (string, <-chan [][]byte) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	got := make(chan [][]byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		var packets [][]byte
+		for {
+			p, err := readPacket(r)
+			if err != nil {
+				break
+			}
+			packets = append(packets, p)
+			if p[0] == packetConnect {
+				_, _ = conn.Write([]byte{packetConnAck, 2, 0, code})
+				if code != 0 {
+					break
+				}
+			}
+			if p[0] == packetDisconnect {
+				break
+			}
+		}
+		got <- packets
+	}()
+	return ln.Addr().String(), got
+}
+
+func readPacket(r *bufio.Reader) ([]byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	length, mult := 0, 1
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		length += int(b&0x7f) * mult
+		mult *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return append([]byte{header}, body...), nil
+}
+
+func TestPublish(t *testing.T) {
+	addr, got := fakeBroker(t, 0)
+	p, err := NewPublisher("tcp://"+addr, "tempest-test", "user", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	long := strings.Repeat("x", 300) // needs a two-byte remaining length
+	if err := p.Publish(
+		Message{Topic: "tempest/et0/today", Payload: []byte("3.42"), Retain: true},
+		Message{Topic: "tempest/stats", Payload: []byte(long)},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	packets := <-got
+	if len(packets) != 4 {
+		t.Fatalf("broker received %d packets, want connect, 2 publishes, disconnect", len(packets))
+	}
+	connect := packets[0]
+	if !strings.Contains(string(connect), "MQTT") || connect[8]&0xC0 != 0xC0 {
+		t.Errorf("CONNECT without protocol name or credentials flags: %q", connect)
+	}
+	if !strings.HasSuffix(string(connect), "tempest-test\x00\x04user\x00\x06secret") {
+		t.Errorf("CONNECT payload = %q", connect[11:])
+	}
+	if packets[1][0] != packetPublish|flagRetain || string(packets[1][1:]) != "\x00\x11tempest/et0/today3.42" {
+		t.Errorf("retained PUBLISH = %q", packets[1])
+	}
+	if packets[2][0] != packetPublish || !strings.HasSuffix(string(packets[2]), long) {
+		t.Errorf("second PUBLISH = %q", packets[2][:20])
+	}
+	if packets[3][0] != packetDisconnect {
+		t.Errorf("last packet = %x, want DISCONNECT", packets[3][0])
+	}
+}
+
+func TestPublishRefused(t *testing.T) {
+	addr, _ := fakeBroker(t, 4)
+	p, _ := NewPublisher(addr, "tempest-test", "user", "wrong")
+	err := p.Publish(Message{Topic: "t", Payload: []byte("x")})
+	if err == nil || !strings.Contains(err.Error(), "bad user name or password") {
+		t.Errorf("Publish error = %v, want a refused connection", err)
+	}
+}
+
+func TestNewPublisherAddress(t *testing.T) {
+	for broker, want := range map[string]string{
+		"broker.local":             "broker.local:1883",
+		"broker.local:1884":        "broker.local:1884",
+		"mqtt://10.0.0.5":          "10.0.0.5:1883",
+		"mqtts://broker.example":   "broker.example:8883",
+		"ssl://broker.example:443": "broker.example:443",
+	} {
+		p, err := NewPublisher(broker, "id", "", "")
+		if err != nil || p.Address() != want {
+			t.Errorf("%s: address %v, %v; want %s", broker, p, err, want)
+		}
+	}
+	if _, err := NewPublisher("ws://broker", "id", "", ""); err == nil {
+		t.Error("unsupported scheme should be rejected")
+	}
+}
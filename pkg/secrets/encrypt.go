@@ -0,0 +1,101 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MasterKeyEnv names the environment variable holding the key that encrypts
+// enc: values. It may itself be a file: or keychain: reference.
+const MasterKeyEnv = "TEMPEST_MASTER_KEY"
+
+// encPrefix marks a value encrypted with AES-256-GCM under the master key
+const encPrefix = "enc:v1:"
+
+// IsEncrypted reports whether value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encPrefix)
+}
+
+// MasterKey returns the key derived from TEMPEST_MASTER_KEY, or nil when it
+// is not set. Any string works; a random one such as the output of
+// "openssl rand -base64 32" is recommended.
+func MasterKey() ([]byte, error) {
+	switch value := os.Getenv(MasterKeyEnv); {
+	case value == "":
+		return nil, nil
+	case IsEncrypted(value):
+		return nil, fmt.Errorf("%s cannot itself be encrypted", MasterKeyEnv)
+	}
+	master, err := ResolveEnv(MasterKeyEnv)
+	if err != nil {
+		return nil, err
+	}
+	key := sha256.Sum256([]byte(master))
+	return key[:], nil
+}
+
+// Encrypt seals plaintext under key as "enc:v1:<base64>"
+func Encrypt(plaintext string, key []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt
+func Decrypt(value string, key []byte) (string, error) {
+	if !IsEncrypted(value) {
+		return "", errors.New("not an enc:v1: value")
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, encPrefix))
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("malformed encrypted value: too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt with %s (wrong key?)", MasterKeyEnv)
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptWithMasterKey resolves an enc: value for Resolve
+func decryptWithMasterKey(value string) (string, error) {
+	key, err := MasterKey()
+	if err != nil {
+		return "", err
+	}
+	if key == nil {
+		return "", fmt.Errorf("encrypted value needs %s to be set", MasterKeyEnv)
+	}
+	return Decrypt(value, key)
+}
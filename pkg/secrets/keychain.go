@@ -0,0 +1,39 @@
+//go:build !windows
+
+package secrets
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// execCommand runs the credential store tool; tests replace it
+var execCommand = func(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).Output()
+}
+
+// keychainLookup asks the platform's credential store tool for a password
+func keychainLookup(service, account string) (string, error) {
+	var out []byte
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		// Add with: security add-generic-password -s service -a account -w
+		out, err = execCommand("security", "find-generic-password", "-s", service, "-a", account, "-w")
+	case "linux", "freebsd", "openbsd", "netbsd":
+		// Add with: secret-tool store --label=tempest service service account account
+		out, err = execCommand("secret-tool", "lookup", "service", service, "account", account)
+	default:
+		return "", errors.New("no credential store support on " + runtime.GOOS + "; use file: or env:")
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", errors.New("not found in the credential store")
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
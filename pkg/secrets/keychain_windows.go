@@ -0,0 +1,64 @@
+//go:build windows
+
+package secrets
+
+import (
+	"errors"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+var (
+	advapi32     = syscall.NewLazyDLL("advapi32.dll")
+	procCredRead = advapi32.NewProc("CredReadW")
+	procCredFree = advapi32.NewProc("CredFree")
+)
+
+// credential mirrors CREDENTIALW
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+const credTypeGeneric = 1
+
+// keychainLookup reads the generic credential "service/account" from
+// Windows Credential Manager, e.g. one added with
+// cmdkey /generic:service/account /user:account /pass
+func keychainLookup(service, account string) (string, error) {
+	target, err := syscall.UTF16PtrFromString(service + "/" + account)
+	if err != nil {
+		return "", err
+	}
+	var cred *credential
+	r, _, callErr := procCredRead.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if r == 0 {
+		if callErr == syscall.Errno(1168) { // ERROR_NOT_FOUND
+			return "", errors.New("not found in Credential Manager")
+		}
+		return "", callErr
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	blob := unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)
+	if len(blob)%2 != 0 {
+		return string(blob), nil
+	}
+	// cmdkey and the Credential Manager UI store the password as UTF-16
+	chars := make([]uint16, len(blob)/2)
+	for i := range chars {
+		chars[i] = uint16(blob[2*i]) | uint16(blob[2*i+1])<<8
+	}
+	return string(utf16.Decode(chars)), nil
+}
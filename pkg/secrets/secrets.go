@@ -0,0 +1,89 @@
+// Package secrets resolves settings that name where a secret is kept instead
+// of holding it, so tokens and passwords need not sit in plaintext in .env
+// files or alarm configurations:
+//
+//	env:NAME                     the environment variable NAME
+//	file:/run/secrets/token      the contents of a file, e.g. a Docker or systemd secret
+//	token_file:/run/secrets/token  the same as file:
+//	keychain:service/account     the OS credential store
+//	enc:v1:...                   a value encrypted with TEMPEST_MASTER_KEY (see Encrypt)
+//
+// The credential store is the login Keychain on macOS, the Secret Service
+// (GNOME Keyring, KWallet) through secret-tool on Linux, and Windows
+// Credential Manager, where the whole "service/account" is the target name.
+// Any other value is returned unchanged.
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// prefixes are the reference forms Resolve understands
+var prefixes = []string{"env:", "file:", "token_file:", "keychain:", encPrefix}
+
+// IsReference reports whether value names a secret rather than holding it
+func IsReference(value string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(value, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the secret value names, or value itself when it is not a
+// reference. A reference that cannot be read is an error, never "".
+func Resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "env:"):
+		name := strings.TrimPrefix(value, "env:")
+		secret, ok := os.LookupEnv(name)
+		if !ok || secret == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+
+	case strings.HasPrefix(value, "file:"), strings.HasPrefix(value, "token_file:"):
+		_, path, _ := strings.Cut(value, ":")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading secret file: %w", err)
+		}
+		secret := strings.TrimRight(string(data), "\r\n")
+		if secret == "" {
+			return "", fmt.Errorf("secret file %s is empty", path)
+		}
+		return secret, nil
+
+	case IsEncrypted(value):
+		return decryptWithMasterKey(value)
+
+	case strings.HasPrefix(value, "keychain:"):
+		ref := strings.TrimPrefix(value, "keychain:")
+		service, account, ok := strings.Cut(ref, "/")
+		if !ok || service == "" || account == "" {
+			return "", fmt.Errorf("keychain reference %q must be keychain:service/account", value)
+		}
+		secret, err := keychainLookup(service, account)
+		if err != nil {
+			return "", fmt.Errorf("keychain %s/%s: %w", service, account, err)
+		}
+		if secret == "" {
+			return "", fmt.Errorf("keychain %s/%s is empty", service, account)
+		}
+		return secret, nil
+	}
+	return value, nil
+}
+
+// ResolveEnv reads the environment variable name and resolves its value, so
+// SMTP_PASSWORD=keychain:tempest/smtp works like a plain password
+func ResolveEnv(name string) (string, error) {
+	value, err := Resolve(os.Getenv(name))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return value, nil
+}
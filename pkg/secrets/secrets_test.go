@@ -0,0 +1,138 @@
+//go:build !windows
+
+package secrets
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SECRETS_TEST_VALUE", "from-env")
+
+	tests := []struct {
+		value, want, wantErr string
+	}{
+		{value: "plain", want: "plain"},
+		{value: "", want: ""},
+		{value: "env:SECRETS_TEST_VALUE", want: "from-env"},
+		{value: "env:SECRETS_TEST_MISSING", wantErr: "SECRETS_TEST_MISSING is not set"},
+		{value: "file:" + path, want: "from-file"},
+		{value: "token_file:" + path, want: "from-file"},
+		{value: "file:" + filepath.Join(dir, "missing"), wantErr: "reading secret file"},
+		{value: "file:" + empty, wantErr: "is empty"},
+		{value: "keychain:tempest", wantErr: "must be keychain:service/account"},
+	}
+	for _, tt := range tests {
+		got, err := Resolve(tt.value)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Resolve(%q) error = %v, want %q", tt.value, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolveKeychain(t *testing.T) {
+	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
+		t.Skip("no credential store tool on " + runtime.GOOS)
+	}
+	orig := execCommand
+	defer func() { execCommand = orig }()
+
+	var gotArgs []string
+	execCommand = func(name string, args ...string) ([]byte, error) {
+		gotArgs = append([]string{name}, args...)
+		if args[len(args)-1] == "missing" || args[len(args)-2] == "missing" {
+			return nil, &exec.ExitError{}
+		}
+		return []byte("s3cret\n"), nil
+	}
+
+	got, err := Resolve("keychain:tempest/smtp")
+	if err != nil || got != "s3cret" {
+		t.Fatalf("Resolve() = %q, %v; want s3cret", got, err)
+	}
+	if !strings.Contains(strings.Join(gotArgs, " "), "tempest") || !strings.Contains(strings.Join(gotArgs, " "), "smtp") {
+		t.Errorf("credential store called with %v", gotArgs)
+	}
+
+	if _, err := Resolve("keychain:tempest/missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing entry error = %v", err)
+	}
+
+	execCommand = func(string, ...string) ([]byte, error) { return nil, errors.New("executable file not found") }
+	if _, err := Resolve("keychain:tempest/smtp"); err == nil || !strings.Contains(err.Error(), "executable file not found") {
+		t.Errorf("missing tool error = %v", err)
+	}
+}
+
+func TestResolveEnv(t *testing.T) {
+	t.Setenv("SECRETS_TEST_INNER", "inner")
+	t.Setenv("SECRETS_TEST_OUTER", "env:SECRETS_TEST_INNER")
+	if got, err := ResolveEnv("SECRETS_TEST_OUTER"); err != nil || got != "inner" {
+		t.Errorf("ResolveEnv() = %q, %v; want inner", got, err)
+	}
+	t.Setenv("SECRETS_TEST_OUTER", "env:SECRETS_TEST_NOPE")
+	if _, err := ResolveEnv("SECRETS_TEST_OUTER"); err == nil || !strings.HasPrefix(err.Error(), "SECRETS_TEST_OUTER:") {
+		t.Errorf("ResolveEnv() error = %v, want it to name the variable", err)
+	}
+	if !IsReference("keychain:a/b") || IsReference("hunter2") {
+		t.Error("IsReference misclassified a value")
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := make([]byte, 32)
+	sealed, err := Encrypt("hunter2", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(sealed) || strings.Contains(sealed, "hunter2") {
+		t.Fatalf("Encrypt() = %q", sealed)
+	}
+	if got, err := Decrypt(sealed, key); err != nil || got != "hunter2" {
+		t.Errorf("Decrypt() = %q, %v", got, err)
+	}
+	other := make([]byte, 32)
+	other[0] = 1
+	if _, err := Decrypt(sealed, other); err == nil {
+		t.Error("Decrypt() with the wrong key succeeded")
+	}
+
+	t.Setenv(MasterKeyEnv, "")
+	if _, err := Resolve(sealed); err == nil || !strings.Contains(err.Error(), MasterKeyEnv) {
+		t.Errorf("Resolve() without a master key error = %v", err)
+	}
+	t.Setenv(MasterKeyEnv, "correct horse battery staple")
+	master, err := MasterKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, _ = Encrypt("hunter2", master)
+	if got, err := Resolve(sealed); err != nil || got != "hunter2" {
+		t.Errorf("Resolve() = %q, %v", got, err)
+	}
+	t.Setenv(MasterKeyEnv, sealed)
+	if _, err := MasterKey(); err == nil {
+		t.Error("MasterKey() accepted an encrypted master key")
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"sync/atomic"
+	"time"
+
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// metricsInterval is how often alarms over bridge health fields (data_age,
+// udp_silence, api_errors_rate, token_invalid, ...) are re-evaluated between
+// observations
+var metricsInterval = 30 * time.Second
+
+// startAlarmMetrics feeds bridge health metrics to the alarm manager and
+// re-evaluates health alarms on a timer, so they can fire while the station is
+// silent. It must be called before subscribeConsumers so the observation time
+// is current when alarms evaluate. tokens may be nil when the token is not
+// checked. The returned function stops the timer.
+func startAlarmMetrics(bus *EventBus, dataSource weather.DataSource, alarmManager *alarm.Manager, tokens *tokenMonitor) (stop func()) {
+	started := time.Now()
+	var lastObs atomic.Int64
+	lastObs.Store(started.UnixNano()) // data_age counts from startup until the first observation
+	bus.Observations.Handle("alarm-metrics", func(weather.Observation) {
+		lastObs.Store(time.Now().UnixNano())
+	})
+
+	alarmManager.SetMetricsProvider(func() alarm.BridgeMetrics {
+		status := dataSource.GetStatus()
+		m := alarm.BridgeMetrics{
+			LastObservation:   time.Unix(0, lastObs.Load()),
+			APIErrorsLastHour: status.ErrorsLastHour,
+		}
+		if tokens != nil {
+			state := tokens.Last().State
+			m.TokenInvalid = state == weather.TokenInvalid
+			m.TokenRateLimited = state == weather.TokenRateLimited
+		}
+		if status.Type == weather.DataSourceUDP {
+			m.LastUDPPacket = status.LastUpdate
+			if m.LastUDPPacket.IsZero() {
+				m.LastUDPPacket = started
+			}
+		}
+		return m
+	})
+
+	ticker := time.NewTicker(metricsInterval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				alarmManager.ProcessMetrics()
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
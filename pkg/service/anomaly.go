@@ -0,0 +1,39 @@
+package service
+
+import (
+	"strconv"
+
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/stats"
+	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/web"
+)
+
+// anomalyThreshold returns the configured z-score threshold, keeping the
+// default for invalid values
+func anomalyThreshold(cfg *config.Config) float64 {
+	if cfg.AnomalyThreshold == "" {
+		return stats.DefaultAnomalyThreshold
+	}
+	z, err := strconv.ParseFloat(cfg.AnomalyThreshold, 64)
+	if err != nil || z <= 0 {
+		logger.Warn("Ignoring invalid anomaly threshold %q", cfg.AnomalyThreshold)
+		return stats.DefaultAnomalyThreshold
+	}
+	return z
+}
+
+// startAnomalyDetection scores each observation before alarms see it, so
+// anomaly(field) conditions refer to the observation being evaluated, and
+// serves the flagged readings at /api/anomalies for the dashboard charts
+func startAnomalyDetection(bus *EventBus, detector *stats.AnomalyDetector, webServer *web.WebServer, alarmManager *alarm.Manager) {
+	bus.Observations.Handle("anomalies", func(obs weather.Observation) { detector.Add(&obs) })
+	if webServer != nil {
+		webServer.SetAnomalyDetector(detector)
+	}
+	if alarmManager != nil {
+		alarmManager.SetAnomalyProvider(detector.Score)
+	}
+}
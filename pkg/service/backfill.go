@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/web"
+)
+
+// backfillMaxGap bounds the interval one backfill requests; older parts of a
+// longer outage are left empty
+const backfillMaxGap = 24 * time.Hour
+
+// gapBackfiller watches observation timestamps and, when two consecutive
+// observations are further apart than threshold (after downtime, an API
+// outage or UDP silence), fetches the missing interval from the REST API and
+// merges it into the stored history
+type gapBackfiller struct {
+	threshold time.Duration
+	stationID func() int
+	fetch     func(stationID int, start, end time.Time) ([]*weather.Observation, error)
+	store     func([]*weather.Observation) int
+	newest    func() int64                    // newest stored timestamp, seeds the first comparison
+	skip      func(start, end time.Time) bool // reports gaps left by maintenance, which are not backfilled
+
+	mu   sync.Mutex
+	last int64 // newest observation timestamp seen
+	wg   sync.WaitGroup
+}
+
+// newGapBackfiller returns a backfiller storing into the web console history,
+// or nil when backfilling is disabled or there is no WeatherFlow API to
+// backfill from
+func newGapBackfiller(cfg *config.Config, stations *stationSwitcher, webServer *web.WebServer) *gapBackfiller {
+	threshold := backfillThreshold(cfg)
+	if threshold == 0 || webServer == nil || cfg.Token == "" || cfg.DisableInternet || cfg.UseGeneratedWeather || cfg.StationURL != "" || cfg.Ingest {
+		return nil
+	}
+	return &gapBackfiller{
+		threshold: threshold,
+		stationID: func() int {
+			if st := stations.Current(); st != nil {
+				return st.StationID
+			}
+			return 0
+		},
+		fetch: func(stationID int, start, end time.Time) ([]*weather.Observation, error) {
+			return weather.GetObservationsRange(stationID, cfg.Token, start, end)
+		},
+		store: func(observations []*weather.Observation) int {
+			return webServer.BackfillHistory(correctRain(cfg, observations))
+		},
+		newest: webServer.LatestHistoryTimestamp,
+	}
+}
+
+// newHistoryFetcher returns the WeatherFlow range fetch behind
+// /api/history/compare, or nil when there is no WeatherFlow API to ask
+func newHistoryFetcher(cfg *config.Config, stations *stationSwitcher) web.HistoryFetcher {
+	if cfg.Token == "" || cfg.DisableInternet || cfg.UseGeneratedWeather || cfg.StationURL != "" || cfg.Ingest {
+		return nil
+	}
+	return func(start, end time.Time) ([]*weather.Observation, error) {
+		st := stations.Current()
+		if st == nil {
+			return nil, errors.New("no station selected")
+		}
+		observations, err := weather.GetObservationsRange(st.StationID, cfg.Token, start, end)
+		if err != nil {
+			return nil, err
+		}
+		return correctRain(cfg, observations), nil
+	}
+}
+
+// backfillThreshold returns the configured gap threshold, or 0 when
+// backfilling is disabled
+func backfillThreshold(cfg *config.Config) time.Duration {
+	if cfg.BackfillGap == "" || cfg.BackfillGap == "0" {
+		return 0
+	}
+	d, err := time.ParseDuration(cfg.BackfillGap)
+	if err != nil {
+		logger.Warn("Ignoring invalid backfill gap %q: %v", cfg.BackfillGap, err)
+		return 0
+	}
+	return d
+}
+
+// observe is an event bus handler; it only compares timestamps and leaves
+// the fetch to a goroutine
+func (b *gapBackfiller) observe(obs weather.Observation) {
+	b.mu.Lock()
+	prev := b.last
+	if prev == 0 && b.newest != nil {
+		prev = b.newest()
+	}
+	if obs.Timestamp > b.last {
+		b.last = obs.Timestamp
+	}
+	b.mu.Unlock()
+
+	if prev == 0 || time.Duration(obs.Timestamp-prev)*time.Second <= b.threshold {
+		return
+	}
+	start, end := time.Unix(prev, 0), time.Unix(obs.Timestamp, 0)
+	if b.skip != nil && b.skip(start, end) {
+		logger.Info("Observation gap of %v (%s to %s) overlaps maintenance, not backfilling",
+			end.Sub(start).Round(time.Second), start.Format(time.RFC3339), end.Format(time.RFC3339))
+		return
+	}
+	if end.Sub(start) > backfillMaxGap {
+		start = end.Add(-backfillMaxGap)
+	}
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.backfill(start, end)
+	}()
+}
+
+func (b *gapBackfiller) backfill(start, end time.Time) {
+	stationID := b.stationID()
+	if stationID == 0 {
+		return
+	}
+	logger.Info("Observation gap of %v detected (%s to %s), backfilling from the WeatherFlow API",
+		end.Sub(start).Round(time.Second), start.Format(time.RFC3339), end.Format(time.RFC3339))
+	observations, err := b.fetch(stationID, start, end)
+	if err != nil {
+		logger.Warn("Backfill of observation gap failed: %v", err)
+		return
+	}
+	added := b.store(observations)
+	logger.Info("Backfilled %d observations into history", added)
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/web"
+)
+
+type fetchCall struct {
+	stationID  int
+	start, end int64
+}
+
+func newTestBackfiller(newest int64) (*gapBackfiller, *[]fetchCall, *[]*weather.Observation) {
+	var mu sync.Mutex
+	var calls []fetchCall
+	var stored []*weather.Observation
+	b := &gapBackfiller{
+		threshold: 5 * time.Minute,
+		stationID: func() int { return 42 },
+		fetch: func(stationID int, start, end time.Time) ([]*weather.Observation, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, fetchCall{stationID, start.Unix(), end.Unix()})
+			return []*weather.Observation{{Timestamp: start.Unix() + 60}, {Timestamp: start.Unix() + 120}}, nil
+		},
+		store: func(obs []*weather.Observation) int {
+			mu.Lock()
+			defer mu.Unlock()
+			stored = append(stored, obs...)
+			return len(obs)
+		},
+		newest: func() int64 { return newest },
+	}
+	return b, &calls, &stored
+}
+
+func TestGapBackfiller(t *testing.T) {
+	b, calls, stored := newTestBackfiller(0)
+
+	b.observe(weather.Observation{Timestamp: 10_000})
+	b.observe(weather.Observation{Timestamp: 10_060})
+	b.observe(weather.Observation{Timestamp: 10_300}) // 4m gap, under the threshold
+	b.wg.Wait()
+	if len(*calls) != 0 {
+		t.Fatalf("unexpected backfill for short gaps: %+v", *calls)
+	}
+
+	b.observe(weather.Observation{Timestamp: 11_300}) // 1000s gap
+	b.wg.Wait()
+	if len(*calls) != 1 || (*calls)[0] != (fetchCall{42, 10_300, 11_300}) {
+		t.Fatalf("calls = %+v, want one fetch of 10300-11300 for station 42", *calls)
+	}
+	if len(*stored) != 2 {
+		t.Errorf("stored %d observations, want 2", len(*stored))
+	}
+
+	// An older, out-of-order observation is not a gap
+	b.observe(weather.Observation{Timestamp: 10_500})
+	b.wg.Wait()
+	if len(*calls) != 1 {
+		t.Errorf("out-of-order observation triggered a backfill: %+v", *calls)
+	}
+
+	// Long outages are clamped to backfillMaxGap
+	end := int64(11_300) + int64(3*24*time.Hour/time.Second)
+	b.observe(weather.Observation{Timestamp: end})
+	b.wg.Wait()
+	if got := (*calls)[1]; got.start != end-int64(backfillMaxGap/time.Second) || got.end != end {
+		t.Errorf("long gap fetched %+v, want the last %v", got, backfillMaxGap)
+	}
+}
+
+func TestGapBackfillerSeedsFromStoredHistory(t *testing.T) {
+	b, calls, _ := newTestBackfiller(50_000)
+	b.observe(weather.Observation{Timestamp: 50_000 + 3600})
+	b.wg.Wait()
+	if len(*calls) != 1 || (*calls)[0].start != 50_000 {
+		t.Errorf("calls = %+v, want a backfill from the newest stored observation", *calls)
+	}
+}
+
+func TestNewGapBackfiller(t *testing.T) {
+	stations := newStationSwitcher(&config.Config{}, &weather.Station{StationID: 1})
+	webServer := web.NewWebServer("0", 0, "error", 1, false, "test", "", nil, nil, "imperial", "inHg", 100, 24, "", true)
+	if b := newGapBackfiller(&config.Config{Token: "t", BackfillGap: "5m"}, stations, webServer); b == nil || b.stationID() != 1 {
+		t.Fatal("expected a backfiller for the WeatherFlow API")
+	}
+	for _, cfg := range []*config.Config{
+		{Token: "t", BackfillGap: "0"},
+		{Token: "", BackfillGap: "5m"},
+		{Token: "t", BackfillGap: "5m", DisableInternet: true},
+		{Token: "t", BackfillGap: "5m", StationURL: "http://localhost/weather"},
+	} {
+		if b := newGapBackfiller(cfg, stations, webServer); b != nil {
+			t.Errorf("expected no backfiller for %+v", cfg)
+		}
+	}
+}
+
+func TestGapBackfillerSkipsMaintenance(t *testing.T) {
+	b, calls, _ := newTestBackfiller(0)
+	b.skip = func(start, end time.Time) bool {
+		return start.Unix() < 10_600 && end.Unix() > 10_400 // maintenance from 10400 to 10600
+	}
+	b.observe(weather.Observation{Timestamp: 10_000})
+	b.observe(weather.Observation{Timestamp: 11_000})
+	b.wg.Wait()
+	if len(*calls) != 0 {
+		t.Fatalf("backfilled a gap left by maintenance: %+v", *calls)
+	}
+	b.observe(weather.Observation{Timestamp: 12_000})
+	b.wg.Wait()
+	if len(*calls) != 1 {
+		t.Errorf("calls = %+v, want the later gap backfilled", *calls)
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"strconv"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/homekit"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/web"
+)
+
+// comfortTargets returns the configured comfort range, keeping the defaults
+// for invalid values
+func comfortTargets(cfg *config.Config) weather.ComfortTargets {
+	targets := weather.DefaultComfortTargets
+	if cfg.ComfortRange != "" {
+		if low, high, err := config.ParseComfortRange(cfg.ComfortRange); err != nil {
+			logger.Warn("Ignoring invalid comfort range %q", cfg.ComfortRange)
+		} else {
+			targets.TempMin, targets.TempMax = low, high
+		}
+	}
+	if cfg.ComfortHumidity != "" {
+		if h, err := strconv.ParseFloat(cfg.ComfortHumidity, 64); err != nil || h <= 0 || h > 100 {
+			logger.Warn("Ignoring invalid comfort humidity %q", cfg.ComfortHumidity)
+		} else {
+			targets.HumidityMax = h
+		}
+	}
+	return targets
+}
+
+// startComfortAdvisor compares indoor readings pushed to /api/indoor with
+// each observation and keeps the HomeKit Open Windows switch in step with the
+// advice. It needs the web console, the only way readings arrive.
+func startComfortAdvisor(cfg *config.Config, bus *EventBus, webServer *web.WebServer, ws *homekit.WeatherSystemModern) {
+	if webServer == nil {
+		if ws != nil && ws.HasComfortAdvisor() {
+			logger.Warn("The comfort advisor switch needs the web console for /api/indoor readings")
+		}
+		return
+	}
+	advisor := weather.NewComfortAdvisor(comfortTargets(cfg), func(advice weather.ComfortAdvice) {
+		logger.Info("Comfort advisor: open windows %v (%s)", advice.OpenWindows, advice.Reason)
+		if ws != nil {
+			ws.UpdateComfort(advice.OpenWindows)
+		}
+	})
+	webServer.SetComfortAdvisor(advisor)
+	bus.Observations.Handle("comfort-advisor", func(obs weather.Observation) { advisor.SetOutdoor(obs) })
+}
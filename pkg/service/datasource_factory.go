@@ -3,6 +3,7 @@ package service
 
 import (
 	"fmt"
+	"time"
 
 	"tempest-homekit-go/pkg/config"
 	"tempest-homekit-go/pkg/generator"
@@ -25,10 +26,20 @@ var DataSourceFactory DataSourceFactoryFunc = CreateDataSource
 // For generated weather, the generator parameter can be provided as interface{} (pass nil to create new).
 func CreateDataSource(cfg *config.Config, station *weather.Station, udpListener interface{}, genParam interface{}) (weather.DataSource, error) {
 	// Priority order:
-	// 1. UDP Stream (if enabled)
-	// 2. Custom Station URL (if provided)
-	// 3. Generated Weather (if enabled)
-	// 4. WeatherFlow API (default)
+	// 1. Pushed observations (if --ingest)
+	// 2. UDP Stream (if enabled)
+	// 3. Custom Station URL (if provided)
+	// 4. Generated Weather (if enabled)
+	// 5. WeatherFlow API (default)
+
+	if cfg.Ingest {
+		var stationName string
+		if station != nil {
+			stationName = station.StationName
+		}
+		logger.Info("Creating ingest data source")
+		return weather.NewIngestDataSource(stationName), nil
+	}
 
 	if cfg.UDPStream {
 		if udpListener == nil {
@@ -53,7 +64,20 @@ func CreateDataSource(cfg *config.Config, station *weather.Station, udpListener
 			return nil, fmt.Errorf("invalid UDP listener type")
 		}
 
+		if cfg.UDPMergeAPI && !cfg.DisableInternet {
+			if stationID == 0 {
+				logger.Warn("Station ID unknown, not merging WeatherFlow API observations into the UDP stream")
+			} else {
+				// The API source fetches the forecast, so the UDP source does not need the token
+				udpSource := weather.NewUDPDataSource(listener, cfg.DisableInternet, stationID, "")
+				apiSource := weather.NewAPIDataSource(stationID, cfg.Token, station.StationName, weather.APIDataSourceOptions{GeneratedPath: cfg.GeneratedWeatherPath, Intervals: pollIntervals(cfg)})
+				logger.Info("UDP data source created (port 50222), merging WeatherFlow API observations for station %d", stationID)
+				return weather.NewMergedDataSource(udpSource, apiSource), nil
+			}
+		}
+
 		dataSource := weather.NewUDPDataSource(listener, cfg.DisableInternet, stationID, token)
+		dataSource.SetForecastInterval(pollIntervals(cfg).Forecast)
 		logger.Info("UDP data source created (port 50222)")
 		return dataSource, nil
 	}
@@ -69,7 +93,7 @@ func CreateDataSource(cfg *config.Config, station *weather.Station, udpListener
 			stationName = station.StationName
 		}
 
-		dataSource := weather.NewAPIDataSource(stationID, cfg.Token, stationName, weather.APIDataSourceOptions{CustomURL: cfg.StationURL, GeneratedPath: cfg.GeneratedWeatherPath})
+		dataSource := weather.NewAPIDataSource(stationID, cfg.Token, stationName, weather.APIDataSourceOptions{CustomURL: cfg.StationURL, GeneratedPath: cfg.GeneratedWeatherPath, Intervals: pollIntervals(cfg)})
 		logger.Info("API data source created with custom URL")
 		return dataSource, nil
 	}
@@ -110,7 +134,28 @@ func CreateDataSource(cfg *config.Config, station *weather.Station, udpListener
 	}
 
 	logger.Info("Creating API data source for station: %s (ID: %d)", station.StationName, station.StationID)
-	dataSource := weather.NewAPIDataSource(station.StationID, cfg.Token, station.StationName, weather.APIDataSourceOptions{CustomURL: "", GeneratedPath: cfg.GeneratedWeatherPath})
+	dataSource := weather.NewAPIDataSource(station.StationID, cfg.Token, station.StationName, weather.APIDataSourceOptions{CustomURL: "", GeneratedPath: cfg.GeneratedWeatherPath, Intervals: pollIntervals(cfg)})
 	logger.Info("WeatherFlow API data source created")
 	return dataSource, nil
 }
+
+// pollIntervals parses the configured polling intervals. Empty or invalid
+// values fall back to the defaults.
+func pollIntervals(cfg *config.Config) weather.PollIntervals {
+	parse := func(name, value string) time.Duration {
+		if value == "" {
+			return 0
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			logger.Warn("Ignoring invalid %s polling interval %q: %v", name, value, err)
+			return 0
+		}
+		return d
+	}
+	return weather.PollIntervals{
+		Observation: parse("observation", cfg.PollObs),
+		Forecast:    parse("forecast", cfg.PollForecast),
+		Status:      parse("status", cfg.PollStatus),
+	}
+}
@@ -478,3 +478,14 @@ func TestCreateDataSource_CustomURLWithNilStation(t *testing.T) {
 		t.Fatalf("expected custom URL in status, got %s", status.CustomURL)
 	}
 }
+
+func TestCreateDataSource_Ingest(t *testing.T) {
+	cfg := &config.Config{Ingest: true, IngestToken: "0123456789abcdef"}
+	ds, err := CreateDataSource(cfg, &weather.Station{StationName: "Garden"}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ds.GetType() != weather.DataSourceIngest || ds.GetStatus().StationName != "Garden" {
+		t.Errorf("got %s source with status %+v", ds.GetType(), ds.GetStatus())
+	}
+}
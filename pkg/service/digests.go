@@ -0,0 +1,33 @@
+package service
+
+import (
+	"time"
+
+	"tempest-homekit-go/pkg/alarm"
+)
+
+// digestInterval is how often scheduled alarm digests are checked
+const digestInterval = time.Minute
+
+// startDigests sends the alarm configuration's daily and weekly digests when
+// they are due. It must be called after startStats so digests can read the
+// daily statistics. The returned function stops the timer.
+func startDigests(alarmManager *alarm.Manager) (stop func()) {
+	alarmManager.ProcessDigests(time.Now()) // note the current schedule without sending
+	ticker := time.NewTicker(digestInterval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				alarmManager.ProcessDigests(now)
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/ecowitt"
+	"tempest-homekit-go/pkg/logger"
+)
+
+// startEcowittListener serves the Ecowitt/Weather Underground upload
+// listener of --ecowitt-port in the background. It returns a nil listener
+// when the port is not set; the data source factory points it at each new
+// ingest data source.
+func startEcowittListener(cfg *config.Config) (*ecowitt.Server, func()) {
+	if cfg.EcowittPort == "" {
+		return nil, func() {}
+	}
+	listener := ecowitt.New(ecowitt.Config{
+		Port:    cfg.EcowittPort,
+		Passkey: cfg.EcowittPasskey,
+		Limits:  config.WebLimits(cfg),
+	})
+	if cfg.EcowittPasskey == "" {
+		logger.Warn("Ecowitt listener accepts uploads from any station; set --ecowitt-passkey to require the station's PASSKEY")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		logger.Info("Ecowitt/Wunderground upload listener on port %s", cfg.EcowittPort)
+		if err := listener.ListenAndServe(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Ecowitt listener failed: %v", err)
+		}
+	}()
+	return listener, func() {
+		cancel()
+		<-done
+	}
+}
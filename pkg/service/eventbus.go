@@ -0,0 +1,153 @@
+package service
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// Topic is a typed publish/subscribe channel. Handlers registered with Handle
+// run synchronously in publish order; channel subscribers registered with
+// Subscribe receive events asynchronously and drop events when their buffer is
+// full so a slow consumer can never stall the observation loop.
+type Topic[T any] struct {
+	name   string
+	mu     sync.RWMutex
+	nextID int
+	subs   []*subscriber[T]
+}
+
+type subscriber[T any] struct {
+	id      int
+	name    string
+	handler func(T)
+	ch      chan T
+	closed  bool // set under the topic lock before ch is closed
+	dropped atomic.Int64
+}
+
+// NewTopic creates an empty topic. The name is used in log messages.
+func NewTopic[T any](name string) *Topic[T] {
+	return &Topic[T]{name: name}
+}
+
+// Handle registers a synchronous handler and returns a function that removes it.
+// Handlers should return quickly; they run on the publisher's goroutine.
+func (t *Topic[T]) Handle(name string, fn func(T)) (unsubscribe func()) {
+	return t.add(&subscriber[T]{name: name, handler: fn})
+}
+
+// Subscribe returns a buffered channel receiving every event published after
+// the call, and a function that removes the subscription and closes the channel.
+func (t *Topic[T]) Subscribe(name string, buffer int) (<-chan T, func()) {
+	if buffer < 1 {
+		buffer = 1
+	}
+	sub := &subscriber[T]{name: name, ch: make(chan T, buffer)}
+	return sub.ch, t.add(sub)
+}
+
+func (t *Topic[T]) add(sub *subscriber[T]) func() {
+	t.mu.Lock()
+	t.nextID++
+	sub.id = t.nextID
+	t.subs = append(t.subs, sub)
+	t.mu.Unlock()
+	logger.Debug("Event bus: %s subscribed to %s", sub.name, t.name)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { t.remove(sub.id) })
+	}
+}
+
+func (t *Topic[T]) remove(id int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, sub := range t.subs {
+		if sub.id == id {
+			t.subs = append(t.subs[:i:i], t.subs[i+1:]...)
+			if sub.ch != nil {
+				sub.closed = true
+				close(sub.ch)
+			}
+			logger.Debug("Event bus: %s unsubscribed from %s", sub.name, t.name)
+			return
+		}
+	}
+}
+
+// Publish delivers v to every subscriber
+func (t *Topic[T]) Publish(v T) {
+	t.mu.RLock()
+	subs := make([]*subscriber[T], len(t.subs))
+	copy(subs, t.subs)
+	t.mu.RUnlock()
+
+	for _, sub := range subs {
+		if sub.handler != nil {
+			t.invoke(sub, v)
+			continue
+		}
+		t.deliver(sub, v)
+	}
+}
+
+// deliver sends to a channel subscriber without blocking. The read lock keeps
+// the channel from being closed by a concurrent unsubscribe mid-send.
+func (t *Topic[T]) deliver(sub *subscriber[T], v T) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if sub.closed {
+		return
+	}
+	select {
+	case sub.ch <- v:
+	default:
+		if n := sub.dropped.Add(1); n == 1 || n%100 == 0 {
+			logger.Warn("Event bus: subscriber %s on %s is slow, dropped %d events", sub.name, t.name, n)
+		}
+	}
+}
+
+// invoke runs a handler, isolating the publisher from handler panics
+func (t *Topic[T]) invoke(sub *subscriber[T], v T) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Event bus: handler %s on %s panicked: %v", sub.name, t.name, r)
+		}
+	}()
+	sub.handler(v)
+}
+
+// SubscriberCount returns the number of active subscribers
+func (t *Topic[T]) SubscriberCount() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.subs)
+}
+
+// EventBus groups the topics published by the service
+type EventBus struct {
+	Observations  *Topic[weather.Observation]      // every observation from the active data source
+	StationStatus *Topic[weather.DataSourceStatus] // data source status after each observation
+	Forecasts     *Topic[*weather.ForecastResponse]
+	Alarms        *Topic[alarm.FiredEvent]        // alarms that fired
+	Wind          *Topic[weather.WindSample]      // rapid_wind samples between observations (UDP stream only)
+	Strikes       *Topic[weather.LightningStrike] // evt_strike lightning strikes (UDP stream only)
+}
+
+// NewEventBus creates an event bus with empty topics
+func NewEventBus() *EventBus {
+	return &EventBus{
+		Observations:  NewTopic[weather.Observation]("observations"),
+		StationStatus: NewTopic[weather.DataSourceStatus]("station-status"),
+		Forecasts:     NewTopic[*weather.ForecastResponse]("forecasts"),
+		Alarms:        NewTopic[alarm.FiredEvent]("alarms"),
+		Wind:          NewTopic[weather.WindSample]("wind"),
+		Strikes:       NewTopic[weather.LightningStrike]("strikes"),
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestTopicHandlersRunInOrder(t *testing.T) {
+	topic := NewTopic[int]("test")
+	var got []string
+	topic.Handle("first", func(v int) { got = append(got, "first") })
+	unsub := topic.Handle("second", func(v int) { got = append(got, "second") })
+
+	topic.Publish(1)
+	if len(got) != 2 || got[0] != "first" || got[1] != "second" {
+		t.Fatalf("unexpected handler order: %v", got)
+	}
+
+	unsub()
+	unsub() // idempotent
+	topic.Publish(2)
+	if len(got) != 3 {
+		t.Errorf("expected removed handler not to run, got %v", got)
+	}
+	if topic.SubscriberCount() != 1 {
+		t.Errorf("expected 1 subscriber, got %d", topic.SubscriberCount())
+	}
+}
+
+func TestTopicChannelSubscriberDropsWhenFull(t *testing.T) {
+	topic := NewTopic[weather.Observation]("observations")
+	ch, unsub := topic.Subscribe("slow", 1)
+
+	// Second publish must not block even though nobody is reading
+	topic.Publish(weather.Observation{Timestamp: 1})
+	topic.Publish(weather.Observation{Timestamp: 2})
+
+	obs := <-ch
+	if obs.Timestamp != 1 {
+		t.Errorf("expected first event to be buffered, got %d", obs.Timestamp)
+	}
+
+	unsub()
+	if _, ok := <-ch; ok {
+		t.Error("expected channel to be closed after unsubscribe")
+	}
+	// Publishing after unsubscribe must not panic on the closed channel
+	topic.Publish(weather.Observation{Timestamp: 3})
+}
+
+func TestTopicHandlerPanicIsIsolated(t *testing.T) {
+	topic := NewTopic[int]("test")
+	called := false
+	topic.Handle("panics", func(int) { panic("boom") })
+	topic.Handle("after", func(int) { called = true })
+
+	topic.Publish(1)
+	if !called {
+		t.Error("expected handler after a panicking handler to still run")
+	}
+}
+
+func TestSubscribeConsumersAllDisabled(t *testing.T) {
+	bus := NewEventBus()
+	subscribeConsumers(bus, nil, nil, nil, homekitOptions{})
+	if bus.Observations.SubscriberCount() != 0 {
+		t.Errorf("expected no consumers when all are disabled")
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/federation"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/web"
+)
+
+// startFederation polls the instances listed in --federate for the /sites
+// page and re-evaluates cross-site alarms after every poll. The returned
+// function stops polling.
+func startFederation(cfg *config.Config, webServer *web.WebServer, alarmManager *alarm.Manager) (stop func()) {
+	if cfg.Federate == "" || webServer == nil {
+		return func() {}
+	}
+	sites, err := federation.ParseSites(cfg.Federate)
+	if err != nil {
+		logger.Error("Federation disabled: %v", err)
+		return func() {}
+	}
+	interval, err := time.ParseDuration(cfg.FederateInterval)
+	if err != nil {
+		interval = time.Minute
+	}
+
+	f := federation.New(sites, federation.Options{Interval: interval})
+	webServer.SetFederation(f)
+	if alarmManager != nil {
+		alarmManager.SetSitesProvider(f.Observations)
+		f.OnPoll(alarmManager.ProcessSites)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.Run(ctx)
+	}()
+	logger.Info("Federation: polling %d site(s) every %v, combined view at /sites", len(sites), interval)
+	return func() {
+		cancel()
+		<-done
+	}
+}
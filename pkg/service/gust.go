@@ -0,0 +1,21 @@
+package service
+
+import (
+	"time"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/logger"
+)
+
+// gustHold returns how long HomeKit keeps reporting a gust (--gust-hold)
+func gustHold(cfg *config.Config) time.Duration {
+	if cfg.GustHold == "" || cfg.GustHold == "0" {
+		return 0
+	}
+	d, err := time.ParseDuration(cfg.GustHold)
+	if err != nil || d < 0 {
+		logger.Warn("Ignoring invalid gust hold %q", cfg.GustHold)
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"sync/atomic"
+	"time"
+
+	"tempest-homekit-go/pkg/homekit"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// diagnosticsInterval is how often the HomeKit diagnostics accessory is refreshed
+var diagnosticsInterval = 30 * time.Second
+
+// startHomeKitDiagnostics keeps the HomeKit diagnostics accessory current. It
+// refreshes on every observation and on a timer, so the data age keeps growing
+// in the Home app while the station is silent. The returned function stops the
+// timer.
+func startHomeKitDiagnostics(bus *EventBus, dataSource weather.DataSource, ws *homekit.WeatherSystemModern) (stop func()) {
+	started := time.Now()
+	var lastObs atomic.Int64
+	lastObs.Store(started.UnixNano()) // data age counts from startup until the first observation
+
+	update := func() {
+		now := time.Now()
+		ws.UpdateDiagnostics(homekit.Diagnostics{
+			DataAge:    now.Sub(time.Unix(0, lastObs.Load())),
+			DataSource: string(dataSource.GetType()),
+			Uptime:     now.Sub(started),
+		})
+	}
+	bus.Observations.Handle("homekit-diagnostics", func(weather.Observation) {
+		lastObs.Store(time.Now().UnixNano())
+		update()
+	})
+	update()
+
+	ticker := time.NewTicker(diagnosticsInterval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				update()
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"errors"
+
+	"tempest-homekit-go/pkg/homekit"
+	"tempest-homekit-go/pkg/web"
+)
+
+// homekitPairings exposes HomeKit pairing management to the web console and
+// refreshes the pairing details shown on the dashboard after changes
+type homekitPairings struct {
+	ws  *homekit.WeatherSystemModern
+	web *web.WebServer
+}
+
+func (p homekitPairings) Pairings() ([]web.HomeKitPairing, error) {
+	pairings, err := p.ws.Pairings()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]web.HomeKitPairing, len(pairings))
+	for i, pairing := range pairings {
+		out[i] = web.HomeKitPairing{ID: pairing.ID, Admin: pairing.Admin}
+	}
+	return out, nil
+}
+
+func (p homekitPairings) RemovePairing(id string) error {
+	err := p.ws.RemovePairing(id)
+	if errors.Is(err, homekit.ErrPairingNotFound) {
+		return web.ErrPairingNotFound
+	}
+	p.refresh()
+	return err
+}
+
+func (p homekitPairings) Reset() error {
+	err := p.ws.Reset()
+	p.refresh()
+	return err
+}
+
+// refresh updates the setup and pairing fields of the dashboard's HomeKit
+// status; a reset can generate a new PIN and setup ID
+func (p homekitPairings) refresh() {
+	info := p.ws.GetDetailedInfo()
+	status := make(map[string]interface{})
+	for _, key := range []string{"pin", "setupCode", "setupId", "setupURI", "pinGenerated", "pairedDevices"} {
+		if v, ok := info[key]; ok {
+			status[key] = v
+		}
+	}
+	p.web.UpdateHomeKitStatus(status)
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// InstallResult describes a service registered by InstallService
+type InstallResult struct {
+	Location string   // unit file, launchd plist or Windows service name
+	Logs     string   // where the service output goes
+	Next     []string // commands that start the service
+}
+
+// InstallService registers the bridge with the platform's service manager so
+// it runs with args at boot and is restarted when it fails: a systemd unit on
+// Linux, a launchd job on macOS and a service control manager entry on
+// Windows. path overrides the unit or plist location; empty uses the default.
+func InstallService(path string, args []string) (InstallResult, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return installSystemd(path, args)
+	case "darwin":
+		return installLaunchd(path, args)
+	case "windows":
+		return installWindowsService(args)
+	default:
+		return InstallResult{}, fmt.Errorf("--install-service is not supported on %s", runtime.GOOS)
+	}
+}
+
+// executableAndWorkDir returns the resolved path of the running binary and the
+// current directory, which installed services run from
+func executableAndWorkDir() (exe, workDir string, err error) {
+	exe, err = os.Executable()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to locate executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	workDir, err = os.Getwd()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	return exe, workDir, nil
+}
+
+// WindowsServiceName is the service control manager name of the bridge,
+// shared with scripts/install-service.sh
+const WindowsServiceName = "TempestHomeKit"
+
+// hasFlag reports whether args set the named flag, in any of the forms the
+// flag package accepts
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		flagName, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && flagName == name {
+			return true
+		}
+	}
+	return false
+}
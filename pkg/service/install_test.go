@@ -0,0 +1,33 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLaunchdPlist(t *testing.T) {
+	plist := LaunchdPlist(launchdLabel, "/usr/local/bin/tempest-homekit-go", "/Users/me/tempest", "/Users/me/Library/Logs/tempest-homekit-go",
+		[]string{"--station", "Tom & Jerry's <Station>"})
+	for _, want := range []string{
+		"<string>com.tempest.homekit</string>",
+		"<string>/usr/local/bin/tempest-homekit-go</string>\n        <string>--station</string>\n        <string>Tom &amp; Jerry&#39;s &lt;Station&gt;</string>",
+		"<key>WorkingDirectory</key>\n    <string>/Users/me/tempest</string>",
+		"<string>/Users/me/Library/Logs/tempest-homekit-go/tempest-homekit-go.log</string>",
+		"<key>SuccessfulExit</key>\n        <false/>",
+		"<key>RunAtLoad</key>\n    <true/>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("plist missing %q:\n%s", want, plist)
+		}
+	}
+}
+
+func TestHasFlag(t *testing.T) {
+	args := []string{"--token", "abc", "-log-file=/tmp/x.log"}
+	if !hasFlag(args, "log-file") || !hasFlag(args, "token") {
+		t.Error("expected log-file and token to be found")
+	}
+	if hasFlag(args, "abc") || hasFlag(args, "station") {
+		t.Error("flag values and missing flags must not match")
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// launchdLabel is the launchd job label, shared with scripts/install-service.sh
+// so both install paths manage the same job
+const launchdLabel = "com.tempest.homekit"
+
+// launchdThrottleSec is how long launchd waits before restarting the bridge
+const launchdThrottleSec = 10
+
+// LaunchdPlist returns a launchd job that runs exe with args from workDir,
+// starts at load, restarts it when it exits with an error and appends stdout
+// and stderr to files in logDir
+func LaunchdPlist(label, exe, workDir, logDir string, args []string) string {
+	esc := func(s string) string {
+		var b bytes.Buffer
+		_ = xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+	fmt.Fprintf(&b, "    <key>Label</key>\n    <string>%s</string>\n", esc(label))
+	b.WriteString("    <key>ProgramArguments</key>\n    <array>\n")
+	for _, arg := range append([]string{exe}, args...) {
+		fmt.Fprintf(&b, "        <string>%s</string>\n", esc(arg))
+	}
+	b.WriteString("    </array>\n")
+	fmt.Fprintf(&b, "    <key>WorkingDirectory</key>\n    <string>%s</string>\n", esc(workDir))
+	fmt.Fprintf(&b, "    <key>StandardOutPath</key>\n    <string>%s</string>\n", esc(filepath.Join(logDir, "tempest-homekit-go.log")))
+	fmt.Fprintf(&b, "    <key>StandardErrorPath</key>\n    <string>%s</string>\n", esc(filepath.Join(logDir, "tempest-homekit-go-error.log")))
+	b.WriteString("    <key>RunAtLoad</key>\n    <true/>\n")
+	b.WriteString("    <key>KeepAlive</key>\n    <dict>\n        <key>SuccessfulExit</key>\n        <false/>\n    </dict>\n")
+	fmt.Fprintf(&b, "    <key>ThrottleInterval</key>\n    <integer>%d</integer>\n", launchdThrottleSec)
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+// launchdPaths returns the default plist and log locations: a system daemon
+// when run as root, otherwise an agent of the current user
+func launchdPaths() (plist, logDir string, err error) {
+	if os.Geteuid() == 0 {
+		return "/Library/LaunchDaemons/" + launchdLabel + ".plist", "/Library/Logs/tempest-homekit-go", nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist"),
+		filepath.Join(home, "Library", "Logs", "tempest-homekit-go"), nil
+}
+
+func installLaunchd(path string, args []string) (InstallResult, error) {
+	exe, workDir, err := executableAndWorkDir()
+	if err != nil {
+		return InstallResult{}, err
+	}
+	defaultPath, logDir, err := launchdPaths()
+	if err != nil {
+		return InstallResult{}, err
+	}
+	if path == "" {
+		path = defaultPath
+	}
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return InstallResult{}, fmt.Errorf("failed to create log directory %s: %w", logDir, err)
+	}
+
+	plist := LaunchdPlist(launchdLabel, exe, workDir, logDir, serviceArgs(args))
+	if err := os.WriteFile(path, []byte(plist), 0644); err != nil {
+		return InstallResult{}, fmt.Errorf("failed to write launchd plist %s: %w", path, err)
+	}
+	return InstallResult{
+		Location: path,
+		Logs:     logDir,
+		Next:     []string{"launchctl load -w " + path},
+	}, nil
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"time"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/homekit"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// luxSmoothing returns the time constant of the illuminance average reported
+// to HomeKit (--lux-smoothing)
+func luxSmoothing(cfg *config.Config) time.Duration {
+	if cfg.LuxSmoothing == "" || cfg.LuxSmoothing == "0" {
+		return 0
+	}
+	d, err := time.ParseDuration(cfg.LuxSmoothing)
+	if err != nil || d < 0 {
+		logger.Warn("Ignoring invalid lux smoothing %q", cfg.LuxSmoothing)
+		return 0
+	}
+	return d
+}
+
+// updateAmbientLight reports the illuminance of obs to the HomeKit light
+// sensor, averaged by lux
+func updateAmbientLight(ws *homekit.WeatherSystemModern, lux *weather.Smoother, obs weather.Observation) {
+	ws.UpdateSensor("Ambient Light", lux.Add(time.Unix(obs.Timestamp, 0), obs.Illuminance))
+}
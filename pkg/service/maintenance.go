@@ -0,0 +1,43 @@
+package service
+
+import (
+	"time"
+
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/maintenance"
+	"tempest-homekit-go/pkg/web"
+)
+
+// startMaintenance loads the maintenance windows, adds those of
+// --maintenance and lets them suspend alarms and uploads. The web console
+// manages them through /api/maintenance.
+func startMaintenance(cfg *config.Config, webServer *web.WebServer, alarmManager *alarm.Manager) *maintenance.Scheduler {
+	scheduler := maintenance.New()
+	if cfg.Maintenance != "" {
+		if err := scheduler.AddSpec(cfg.Maintenance); err != nil {
+			logger.Warn("Ignoring --maintenance %q: %v", cfg.Maintenance, err)
+		}
+	}
+	if alarmManager != nil {
+		alarmManager.SetSuspended(scheduler.Suspended)
+	}
+	if webServer != nil {
+		webServer.SetMaintenance(scheduler)
+	}
+	if status := scheduler.Status(); status.Active != nil {
+		logger.Warn("Maintenance in progress until %s: alarms and uploads are suspended", status.Active.End.Format(time.RFC3339))
+	} else if len(status.Scheduled) > 0 {
+		logger.Info("%d maintenance window(s) scheduled, the next at %s", len(status.Scheduled), status.Scheduled[0].Start.Format(time.RFC3339))
+	}
+	return scheduler
+}
+
+// duringMaintenance reports whether a history gap overlaps maintenance, for
+// the backfiller
+func duringMaintenance(scheduler *maintenance.Scheduler) func(start, end time.Time) bool {
+	return func(start, end time.Time) bool {
+		return len(scheduler.Gaps(start, end)) > 0
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// rainCorrection returns the configured rain gauge multiplier, or 1 when it
+// is unset or invalid
+func rainCorrection(cfg *config.Config) float64 {
+	if cfg.RainCorrection == "" {
+		return 1
+	}
+	factor, err := strconv.ParseFloat(cfg.RainCorrection, 64)
+	if err != nil || factor <= 0 {
+		logger.Warn("Ignoring invalid rain correction %q", cfg.RainCorrection)
+		return 1
+	}
+	return factor
+}
+
+// snowRatio returns the configured snow-to-liquid ratio, or the default when
+// it is unset or invalid
+func snowRatio(cfg *config.Config) float64 {
+	if cfg.SnowRatio == "" {
+		return weather.DefaultSnowRatio
+	}
+	ratio, err := strconv.ParseFloat(cfg.SnowRatio, 64)
+	if err != nil || ratio <= 0 {
+		logger.Warn("Ignoring invalid snow ratio %q", cfg.SnowRatio)
+		return weather.DefaultSnowRatio
+	}
+	return ratio
+}
+
+// stationLocation loads the station's time zone, or returns nil (server local
+// time) when it is unknown
+func stationLocation(name string) *time.Location {
+	if name == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		logger.Warn("Unknown station time zone %q: %v", name, err)
+		return nil
+	}
+	return loc
+}
+
+// correctRain applies the rain correction and snow rate to observations that
+// bypass the main loop (history preload and backfill). Daily snow totals are
+// only kept for live observations.
+func correctRain(cfg *config.Config, observations []*weather.Observation) []*weather.Observation {
+	factor, ratio := rainCorrection(cfg), snowRatio(cfg)
+	for _, obs := range observations {
+		weather.ApplyRainCorrection(obs, factor)
+		weather.SetSnowRate(obs, ratio)
+	}
+	return observations
+}
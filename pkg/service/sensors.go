@@ -0,0 +1,99 @@
+package service
+
+import (
+	"strings"
+	"sync"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/homekit"
+	"tempest-homekit-go/pkg/web"
+)
+
+// homekitSensorNames lists the enabled sensors by the names the dashboard's
+// accessory list uses
+func homekitSensorNames(sensorConfig config.SensorConfig) []string {
+	var enabledSensors []string
+	if sensorConfig.Temperature {
+		enabledSensors = append(enabledSensors, "Temperature")
+	}
+	if sensorConfig.Humidity {
+		enabledSensors = append(enabledSensors, "Humidity")
+	}
+	if sensorConfig.Light {
+		enabledSensors = append(enabledSensors, "Light")
+	}
+	if sensorConfig.Wind {
+		enabledSensors = append(enabledSensors, "Wind Speed", "Wind Direction")
+	}
+	if sensorConfig.Rain {
+		enabledSensors = append(enabledSensors, "Rain")
+	}
+	if sensorConfig.Pressure {
+		enabledSensors = append(enabledSensors, "Pressure")
+	}
+	if sensorConfig.UV {
+		enabledSensors = append(enabledSensors, "UV")
+	}
+	if sensorConfig.Lightning {
+		enabledSensors = append(enabledSensors, "Lightning")
+	}
+	if sensorConfig.Diagnostics {
+		enabledSensors = append(enabledSensors, "Bridge Diagnostics")
+	}
+	return enabledSensors
+}
+
+// sensorSettings implements web.SensorManager. Sensor changes rebuild the
+// HomeKit bridge when it runs; both settings are saved to the env file as
+// SENSORS and HIDDEN_CARDS.
+type sensorSettings struct {
+	cfg *config.Config
+	ws  *homekit.WeatherSystemModern // nil when HomeKit is disabled
+	web *web.WebServer
+
+	mu sync.Mutex // guards cfg.Sensors and cfg.HiddenCards
+}
+
+func (s *sensorSettings) HomeKitSensors() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return config.ParseSensorConfig(s.cfg.Sensors).Names()
+}
+
+func (s *sensorSettings) SetHomeKitSensors(names []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sensors := config.ParseSensorConfig(strings.Join(names, ","))
+	if s.ws != nil {
+		if err := s.ws.SetSensors(sensors); err != nil {
+			return err
+		}
+	}
+	s.cfg.Sensors = sensors.String()
+
+	status := map[string]interface{}{
+		"accessoryNames": homekitSensorNames(sensors),
+		"sensorConfig":   s.cfg.Sensors,
+	}
+	if s.ws != nil {
+		status["accessories"] = s.ws.GetDetailedInfo()["accessories"]
+	}
+	s.web.UpdateHomeKitStatus(status)
+	return s.save("SENSORS", s.cfg.Sensors)
+}
+
+func (s *sensorSettings) SaveHiddenCards(cards []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg.HiddenCards = strings.Join(cards, ",")
+	return s.save("HIDDEN_CARDS", s.cfg.HiddenCards)
+}
+
+func (s *sensorSettings) save(key, value string) error {
+	envFile := s.cfg.EnvFile
+	if envFile == "" {
+		envFile = ".env"
+	}
+	return config.UpdateEnvFile(envFile, map[string]string{key: value})
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/web"
+)
+
+func TestSensorSettingsSaveToEnvFile(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envFile, []byte("SENSORS=temp\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Sensors: "temp", EnvFile: envFile}
+	webServer := web.NewWebServer("0", 0, "error", 1, false, "test", "", nil, nil, "imperial", "inHg", 100, 24, "", true)
+	settings := &sensorSettings{cfg: cfg, web: webServer}
+
+	if err := settings.SetHomeKitSensors([]string{"uv", "temp"}); err != nil {
+		t.Fatalf("SetHomeKitSensors returned error: %v", err)
+	}
+	if err := settings.SaveHiddenCards([]string{"forecast", "uv"}); err != nil {
+		t.Fatalf("SaveHiddenCards returned error: %v", err)
+	}
+	if got := settings.HomeKitSensors(); !slices.Equal(got, []string{"temp", "uv"}) {
+		t.Errorf("HomeKitSensors() = %v", got)
+	}
+
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"SENSORS=temp,uv", "HIDDEN_CARDS=forecast,uv"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("env file missing %s:\n%s", want, content)
+		}
+	}
+}
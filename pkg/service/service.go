@@ -9,10 +9,15 @@ import (
 	"time"
 
 	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/alarm/editor"
+	"tempest-homekit-go/pkg/annotations"
 	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/corrections"
 	"tempest-homekit-go/pkg/generator"
+	"tempest-homekit-go/pkg/grpcapi"
 	"tempest-homekit-go/pkg/homekit"
 	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/stats"
 	"tempest-homekit-go/pkg/udp"
 	"tempest-homekit-go/pkg/weather"
 	"tempest-homekit-go/pkg/web"
@@ -31,7 +36,15 @@ func StartService(cfg *config.Config, version string) error {
 	var station *weather.Station
 	var weatherGen *generator.WeatherGenerator
 
-	if cfg.UDPStream {
+	if cfg.Ingest {
+		// Ingest mode - observations are pushed to the web server by other hardware
+		logger.Info("Ingest mode - waiting for observations at /api/ingest")
+		station = &weather.Station{
+			StationID:   0,
+			Name:        cfg.StationName,
+			StationName: cfg.StationName,
+		}
+	} else if cfg.UDPStream {
 		// UDP mode - fetch station details if internet is available and we have credentials
 		if !cfg.DisableInternet && cfg.Token != "" && cfg.StationName != "" {
 			logger.Info("UDP stream mode - fetching station details for forecast and metadata")
@@ -157,7 +170,22 @@ func StartService(cfg *config.Config, version string) error {
 		// Setup HomeKit with sensor configuration
 		logger.Debug("Initializing HomeKit accessories with sensor config: %s", cfg.Sensors)
 		var setupErr error
-		ws, setupErr = homekit.NewWeatherSystemModern(cfg.Pin, &sensorConfig, cfg.LogLevel)
+		names, _ := config.ParseHomeKitNames(cfg.HomeKitNames) // validated with the rest of the config
+		homekitPort, _ := strconv.Atoi(cfg.HomeKitPort)        // empty picks a free port
+		naming := homekit.AccessoryNaming{
+			Names:         names,
+			NamePattern:   cfg.HomeKitNamePattern,
+			SerialPattern: cfg.HomeKitSerialPattern,
+			Station:       station.Name,
+		}
+		ws, setupErr = homekit.NewWeatherSystemModern(cfg.Pin, &sensorConfig, cfg.LogLevel, homekit.Options{
+			Mode:         cfg.HomeKitMode,
+			Naming:       naming,
+			Address:      cfg.HomeKitAddress,
+			Port:         homekitPort,
+			Interfaces:   config.ParseInterfaces(cfg.HomeKitInterfaces),
+			PressureUnit: cfg.UnitsPressureHomeKit,
+		})
 		if setupErr != nil {
 			return fmt.Errorf("failed to setup HomeKit: %v", setupErr)
 		}
@@ -170,7 +198,8 @@ func StartService(cfg *config.Config, version string) error {
 			}
 		}()
 
-		logger.Info("HomeKit server started successfully with PIN: %s", cfg.Pin)
+		setup := ws.Setup()
+		logger.Info("HomeKit server started successfully with PIN: %s (setup URI %s)", setup.SetupCode, setup.SetupURI)
 		logger.Debug("HomeKit - Bridge ready to accept connections")
 		logger.Debug("HomeKit - Listening for iOS/HomeKit client connections...")
 	}
@@ -199,6 +228,8 @@ func StartService(cfg *config.Config, version string) error {
 			}
 		}
 		logger.Info("Using generated weather - no station URL needed")
+	} else if cfg.Ingest {
+		logger.Info("Using pushed observations - no station URL needed")
 	} else if effectiveStationURL == "" {
 		// Get station information from WeatherFlow API
 		stations, err := weather.GetStations(cfg.Token)
@@ -209,7 +240,7 @@ func StartService(cfg *config.Config, version string) error {
 		if station == nil {
 			return fmt.Errorf("station '%s' not found", cfg.StationName)
 		}
-		effectiveStationURL = fmt.Sprintf("https://swd.weatherflow.com/swd/rest/observations/station/%d?token=%s", station.StationID, cfg.Token)
+		effectiveStationURL = stationObservationURL(station.StationID, cfg.Token)
 		logger.Info("Found station: %s (ID: %d)", station.Name, station.StationID)
 	} else {
 		// Station URL provided, extract station ID for web server
@@ -268,9 +299,44 @@ func StartService(cfg *config.Config, version string) error {
 	if !cfg.DisableWebConsole {
 		webServer = web.NewWebServer(cfg.WebPort, cfg.Elevation, cfg.LogLevel, station.StationID, cfg.UseWebStatus, version, effectiveStationURL, generatedWeatherInfo, weatherGen, cfg.Units, cfg.UnitsPressure, cfg.HistoryPoints, cfg.ChartHistoryHours, cfg.Alarms, cfg.DisableAlarms)
 		webServer.SetStationName(station.Name)
+		if station.Timezone != "" {
+			_ = webServer.SetTimezone(station.Timezone) // logged; the forecast's time zone is the fallback
+		}
+		webServer.SetListenAddress(cfg.WebAddress)
+		if err := webServer.SetBasePath(cfg.WebBasePath); err != nil {
+			return err
+		}
+		webServer.SetSecurityPolicy(config.SecurityPolicy(cfg))
+		webServer.SetLimits(config.WebLimits(cfg))
+		webServer.SetRainCorrection(rainCorrection(cfg))
+		webServer.GetStatusManager().SetInterval(pollIntervals(cfg).Status)
 		if alarmManager != nil {
 			webServer.SetAlarmManager(alarmManager)
+			alarmManager.SetHistoryProvider(webServer.History)
+			alarmManager.SetForecastProvider(webServer.HourlyForecast)
+
+			// Mount the alarm editor on the main web server, sharing the live alarm manager
+			if cfg.AlarmsWebEditor {
+				editorServer, err := editor.NewServer(cfg.Alarms, cfg.WebPort, version, cfg.EnvFile)
+				if err != nil {
+					logger.Error("Failed to initialize integrated alarm editor: %v", err)
+				} else {
+					editorServer.SetReloader(alarmManager)
+					editorServer.SetPreviewer(alarmManager)
+					webServer.MountAlarmEditor(editorServer.Handler(cfg.WebBasePath+"/alarm-editor"), cfg.AlarmsEditorPassword)
+				}
+			}
 		}
+		registerHealthChecks(cfg, webServer, ws, alarmManager)
+		webServer.SetAdminPassword(cfg.AdminPassword)
+		if ws != nil {
+			webServer.SetHomeKitPairingManager(homekitPairings{ws: ws, web: webServer})
+		}
+		hiddenCards, _ := config.ParseHiddenCards(cfg.HiddenCards) // validated with the rest of the config
+		webServer.SetHiddenCards(hiddenCards)
+		webServer.SetSensorManager(&sensorSettings{cfg: cfg, ws: ws, web: webServer})
+		webServer.SetAnnotations(annotations.New())
+		webServer.SetCorrections(corrections.New())
 		go func() {
 			defer func() {
 				if r := recover(); r != nil {
@@ -287,31 +353,7 @@ func StartService(cfg *config.Config, version string) error {
 	}
 
 	// Update HomeKit status in web server
-	var enabledSensors []string
-	if sensorConfig.Temperature {
-		enabledSensors = append(enabledSensors, "Temperature")
-	}
-	if sensorConfig.Humidity {
-		enabledSensors = append(enabledSensors, "Humidity")
-	}
-	if sensorConfig.Light {
-		enabledSensors = append(enabledSensors, "Light")
-	}
-	if sensorConfig.Wind {
-		enabledSensors = append(enabledSensors, "Wind Speed", "Wind Direction")
-	}
-	if sensorConfig.Rain {
-		enabledSensors = append(enabledSensors, "Rain")
-	}
-	if sensorConfig.Pressure {
-		enabledSensors = append(enabledSensors, "Pressure")
-	}
-	if sensorConfig.UV {
-		enabledSensors = append(enabledSensors, "UV")
-	}
-	if sensorConfig.Lightning {
-		enabledSensors = append(enabledSensors, "Lightning")
-	}
+	enabledSensors := homekitSensorNames(sensorConfig)
 
 	// Build complete sensor list (all possible sensors, regardless of enabled/disabled status)
 	allSensorsList := []string{
@@ -362,106 +404,116 @@ func StartService(cfg *config.Config, version string) error {
 		webServer.UpdateHomeKitStatus(homekitStatus)
 	}
 
-	// Preload historical data if requested
-	if cfg.HistoryRead {
-		var dataSourceDesc string
-		if cfg.UseGeneratedWeather {
-			dataSourceDesc = "from generated weather"
-		} else {
-			dataSourceDesc = "from Tempest API"
+	// Preload historical data if requested; pushed observations have no
+	// history to read
+	if cfg.HistoryRead && !cfg.Ingest {
+		preloadHistory(cfg, webServer, weatherGen, station.StationID)
+	}
+
+	// UNIFIED DATA SOURCE APPROACH
+	// Create appropriate data source using factory pattern. Use the
+	// injectable DataSourceFactory so tests can override behavior.
+	var generatorPtr *generator.WeatherGenerator
+	if cfg.UseGeneratedWeather && weatherGen != nil {
+		generatorPtr = weatherGen
+	}
+	// The UDP listener publishes rapid_wind samples and strikes on the bus, so
+	// it exists before the data source
+	bus := NewEventBus()
+	lightning := weather.NewLightningTracker()
+	bus.Strikes.Handle("lightning", lightning.AddStrike)
+	// newDataSource builds everything the data source owns, so the watchdog
+	// can call it again to replace a stalled source. It always uses the
+	// active station, which the dashboard can change in WeatherFlow API mode.
+	stations := newStationSwitcher(cfg, station)
+	statsEngine := stats.NewEngine(stationLocation(station.Timezone), station.Latitude, cfg.Elevation)
+	anomalies := stats.NewAnomalyDetector(anomalyThreshold(cfg))
+	ecowittListener, stopEcowitt := startEcowittListener(cfg)
+	defer stopEcowitt()
+	udpRelay, stopUDPRelay := startUDPRelay(cfg)
+	defer stopUDPRelay()
+	newDataSource := func() (weather.DataSource, error) {
+		// Create UDP listener if needed (service layer handles this to avoid import cycles)
+		var udpListener *udp.UDPListener
+		if cfg.UDPStream {
+			logger.Info("Creating UDP listener for UDP stream mode")
+			udpListener = udp.NewUDPListener(cfg.HistoryPoints)
+			ConfigureUDPListener(cfg, udpListener, udpRelay)
+			udpListener.SetRapidWindCallback(bus.Wind.Publish)
+			udpListener.SetStrikeCallback(bus.Strikes.Publish)
+			if rate, _ := config.ParsePercent(cfg.InjectUDPDrop); rate > 0 {
+				logger.Warn("Failure injection: dropping %g%% of UDP packets", rate*100)
+				udpListener.SetDropRate(rate)
+			}
 		}
 
-		if cfg.LogLevel == "info" || cfg.LogLevel == "debug" {
-			logger.Info("--history-read flag detected, preloading historical observations (up to HISTORY_POINTS points) %s...", dataSourceDesc)
+		logger.Info("Creating data source...")
+		ds, err := DataSourceFactory(cfg, stations.Current(), udpListener, generatorPtr)
+		if err != nil {
+			return nil, err
 		}
 
-		// Create a progress callback function
-		progressCallback := func(currentStep, totalSteps int, description string) {
+		// Pushed observations reach the source through the web server and
+		// the Ecowitt listener
+		if ingest, ok := ds.(*weather.IngestDataSource); ok {
 			if webServer != nil {
-				webServer.SetHistoryLoadingProgress(currentStep, totalSteps, description)
+				webServer.SetIngestor(ingest, cfg.IngestToken)
 			}
-		}
-
-		var historicalObs []*weather.Observation
-		var err error
-
-		if cfg.UseGeneratedWeather && weatherGen != nil {
-			// Generate historical data
-			logger.Info("Generating %d historical weather data points...", cfg.HistoryPoints)
-			historicalObs = weatherGen.GenerateHistoricalData(cfg.HistoryPoints)
-			logger.Debug("Successfully generated %d historical observations", len(historicalObs))
-		} else {
-			// Use real historical data from API
-			historicalObs, err = weather.GetHistoricalObservationsWithProgress(station.StationID, cfg.Token, cfg.LogLevel, progressCallback, cfg.HistoryPoints)
-			if err != nil {
-				logger.Error("Failed to fetch historical data: %v", err)
-				if webServer != nil {
-					webServer.SetHistoryLoadingComplete()
-				}
-			} else {
-				logger.Debug("Successfully fetched %d historical observations", len(historicalObs))
+			if ecowittListener != nil {
+				ecowittListener.SetTarget(ingest)
 			}
 		}
 
-		if err == nil && webServer != nil {
-			webServer.SetHistoryLoadingProgress(2, 3, "Processing historical data...")
-
-			// Send historical data to web server for charts
-			for _, obs := range historicalObs {
-				webServer.UpdateWeather(obs)
-				logger.Debug("Added historical observation from %v", time.Unix(obs.Timestamp, 0))
-			}
-
-			// Complete the loading process
-			webServer.SetHistoryLoadingComplete()
-
-			webServer.SetHistoricalDataStatus(len(historicalObs))
-
-			if cfg.LogLevel == "info" || cfg.LogLevel == "debug" {
-				logger.Info("Historical data preload completed - loaded %d observations (up to configured HISTORY_POINTS)", len(historicalObs))
+		// Wire up status manager for UDP data source if web server is enabled
+		if webServer != nil && cfg.UDPStream {
+			if udpDataSource, ok := ds.(interface{ SetStatusManager(*weather.StatusManager) }); ok {
+				statusManager := webServer.GetStatusManager()
+				if statusManager != nil {
+					udpDataSource.SetStatusManager(statusManager)
+					logger.Info("Status manager connected to UDP data source")
+				}
 			}
-
-			// NOTE: No need to reset test pattern rain anymore because we now preserve
-			// cumulativeRain during historical generation instead of restoring it
 		}
+		return ds, nil
 	}
-
-	// UNIFIED DATA SOURCE APPROACH
-	// Create UDP listener if needed (service layer handles this to avoid import cycles)
-	var udpListener *udp.UDPListener
-	if cfg.UDPStream {
-		logger.Info("Creating UDP listener for UDP stream mode")
-		udpListener = udp.NewUDPListener(cfg.HistoryPoints)
-	}
-
-	// Create appropriate data source using factory pattern. Use the
-	// injectable DataSourceFactory so tests can override behavior.
-	logger.Info("Creating data source...")
-	var generatorPtr *generator.WeatherGenerator
-	if cfg.UseGeneratedWeather && weatherGen != nil {
-		generatorPtr = weatherGen
+	// superviseDataSource adds the watchdog, if enabled, around a new source
+	superviseDataSource := func() (weather.DataSource, error) {
+		ds, err := newDataSource()
+		if err != nil {
+			return nil, err
+		}
+		timeout := watchdogTimeout(cfg)
+		if timeout <= 0 {
+			return ds, nil
+		}
+		supervised := newSupervisedSource(ds, newDataSource, timeout)
+		if webServer != nil {
+			update := func(s WatchdogStatus) { webServer.UpdateWatchdogStatus(watchdogInfo(s)) }
+			supervised.OnChange(update)
+			update(supervised.Status())
+		}
+		return supervised, nil
 	}
-	dataSource, err := DataSourceFactory(cfg, station, udpListener, generatorPtr)
+	dataSource, err := superviseDataSource()
 	if err != nil {
 		return fmt.Errorf("failed to create data source: %v", err)
 	}
+	if config.UsesWeatherFlowAPI(cfg) {
+		// Let the dashboard switch between the token's stations at runtime
+		stations.source = newSwitchableSource(dataSource, superviseDataSource)
+		stations.webServer, stations.homekit, stations.alarms = webServer, ws, alarmManager
+		stations.stats, stations.anomalies = statsEngine, anomalies
+		dataSource = stations.source
+		if webServer != nil {
+			webServer.SetStationManager(stations)
+		}
+	}
 	defer func() {
 		if err := dataSource.Stop(); err != nil {
 			logger.Error("dataSource stop error: %v", err)
 		}
 	}()
 
-	// Wire up status manager for UDP data source if web server is enabled
-	if webServer != nil && cfg.UDPStream {
-		if udpDataSource, ok := dataSource.(*weather.UDPDataSource); ok {
-			statusManager := webServer.GetStatusManager()
-			if statusManager != nil {
-				udpDataSource.SetStatusManager(statusManager)
-				logger.Info("Status manager connected to UDP data source")
-			}
-		}
-	}
-
 	// Start the data source
 	logger.Info("Starting data source: %s", dataSource.GetType())
 	obsChan, err := dataSource.Start()
@@ -476,56 +528,248 @@ func StartService(cfg *config.Config, version string) error {
 		logger.Debug("Initial data source status set: type=%s", initialStatus.Type)
 	}
 
+	// Wire consumers to the event bus. Handlers run in registration order for
+	// each observation, so HomeKit and the web server are updated before alarms
+	// are evaluated.
+	tokens, stopTokens := startTokenMonitor(cfg, webServer)
+	defer stopTokens()
+	if alarmManager != nil {
+		stopMetrics := startAlarmMetrics(bus, dataSource, alarmManager, tokens)
+		defer stopMetrics()
+	}
+	maintenanceWindows := startMaintenance(cfg, webServer, alarmManager)
+	// Registered before the web console so the first observation is compared
+	// with the history stored before it
+	if backfiller := newGapBackfiller(cfg, stations, webServer); backfiller != nil {
+		backfiller.skip = duringMaintenance(maintenanceWindows)
+		bus.Observations.Handle("backfill", backfiller.observe)
+	}
+	if fetch := newHistoryFetcher(cfg, stations); fetch != nil && webServer != nil {
+		webServer.SetHistoryFetcher(fetch)
+	}
+	// Before the consumers, so alarms see statistics that include the observation
+	stopStats := startStats(cfg, bus, statsEngine, webServer, alarmManager)
+	defer stopStats()
+	if alarmManager != nil {
+		stopDigests := startDigests(alarmManager)
+		defer stopDigests()
+		stopUploads := startUploads(alarmManager)
+		defer stopUploads()
+	}
+	startAnomalyDetection(bus, anomalies, webServer, alarmManager)
+	startComfortAdvisor(cfg, bus, webServer, ws)
+	stopFederation := startFederation(cfg, webServer, alarmManager)
+	defer stopFederation()
+	subscribeConsumers(bus, ws, webServer, alarmManager, homekitOptions{GustHold: gustHold(cfg), LuxSmoothing: luxSmoothing(cfg)})
+	if ws != nil && ws.HasDiagnostics() {
+		stopDiagnostics := startHomeKitDiagnostics(bus, dataSource, ws)
+		defer stopDiagnostics()
+	}
+
+	if cfg.GRPCPort != "" {
+		var alarms grpcapi.AlarmSource
+		if alarmManager != nil {
+			alarms = alarmManager
+		}
+		grpcServer := grpcapi.NewServer(bus.Observations, alarms)
+		bus.Observations.Handle("grpc", grpcServer.UpdateObservation)
+		go func() {
+			if err := grpcServer.Start(cfg.GRPCPort); err != nil {
+				logger.Error("gRPC API stopped: %v", err)
+			}
+		}()
+		defer grpcServer.Stop()
+	}
+
+	stopNotify := startSystemdNotify(webServer)
+	defer stopNotify()
+
 	// Main observation processing loop - unified for all data sources!
 	logger.Info("Starting unified observation processing loop")
+	var lastForecast *weather.ForecastResponse
+	rainFactor := rainCorrection(cfg)
+	if rainFactor != 1 {
+		logger.Info("Rain values are multiplied by %g (--rain-correction)", rainFactor)
+	}
+	snow := weather.NewSnowEstimator(snowRatio(cfg), stationLocation(station.Timezone))
 	for obs := range obsChan {
 		logger.Debug("Processing observation from %s data source", dataSource.GetType())
 
-		// Update HomeKit sensors (if enabled)
-		if ws != nil {
+		weather.ApplyRainCorrection(&obs, rainFactor)
+		snow.Apply(&obs)
+		lightning.Apply(&obs)
+
+		// Only publish forecasts when the data source has a new one. The
+		// forecast goes out before the observation so forecast_* alarm
+		// conditions evaluated for this observation see it.
+		if forecast := dataSource.GetForecast(); forecast != nil && forecast != lastForecast {
+			lastForecast = forecast
+			snow.SetLocation(stationLocation(forecast.Timezone))
+			bus.Forecasts.Publish(forecast)
+		}
+		bus.Observations.Publish(obs)
+
+		bus.StationStatus.Publish(dataSource.GetStatus())
+
+		// Log observation details
+		if cfg.LogLevel == "info" || cfg.LogLevel == "debug" {
+			logger.Info("%s data - Temp: %.1f°C, Humidity: %.1f%%, Wind: %.1f m/s, Lux: %.0f",
+				dataSource.GetType(), obs.AirTemperature, obs.RelativeHumidity, obs.WindAvg, obs.Illuminance)
+		}
+	}
+
+	logger.Info("Observation processing loop ended")
+	return nil
+}
+
+// registerHealthChecks adds the service-owned subsystems to /healthz and
+// /readyz. The data source and UDP listener checks are built into the web server.
+func registerHealthChecks(cfg *config.Config, webServer *web.WebServer, ws *homekit.WeatherSystemModern, alarmManager *alarm.Manager) {
+	webServer.AddHealthCheck("homekit", true, func() error {
+		if ws == nil {
+			return web.ErrSubsystemDisabled
+		}
+		return ws.Health()
+	})
+	webServer.AddHealthCheck("alarms", false, func() error {
+		if cfg.Alarms == "" || cfg.DisableAlarms {
+			return web.ErrSubsystemDisabled
+		}
+		if alarmManager == nil {
+			return fmt.Errorf("alarm manager failed to load %s", cfg.Alarms)
+		}
+		return nil
+	})
+	webServer.AddHealthCheck("store", false, func() error {
+		if ws == nil {
+			return web.ErrSubsystemDisabled
+		}
+		return homekit.CheckStoreWritable()
+	})
+}
+
+// homekitOptions are the HomeKit reporting settings of subscribeConsumers
+type homekitOptions struct {
+	GustHold     time.Duration // --gust-hold
+	LuxSmoothing time.Duration // --lux-smoothing
+}
+
+// subscribeConsumers registers the built-in consumers (HomeKit, web console and
+// alarms) on the event bus. Any of them may be nil when disabled. HomeKit gets
+// the strongest gust since its previous update, held for opts.GustHold, and
+// the illuminance averaged over opts.LuxSmoothing; the web console keeps the
+// raw readings.
+func subscribeConsumers(bus *EventBus, ws *homekit.WeatherSystemModern, webServer *web.WebServer, alarmManager *alarm.Manager, opts homekitOptions) {
+	if ws != nil {
+		gusts := weather.NewGustTracker(opts.GustHold)
+		lux := weather.NewSmoother(opts.LuxSmoothing)
+		bus.Wind.Handle("homekit", func(sample weather.WindSample) {
+			gusts.Add(time.Unix(sample.Timestamp, 0), sample.Speed)
+		})
+		bus.Observations.Handle("homekit", func(obs weather.Observation) {
+			at := time.Unix(obs.Timestamp, 0)
+			gusts.Add(at, obs.WindGust)
 			ws.UpdateSensor("Wind Speed", obs.WindAvg)
-			ws.UpdateSensor("Wind Gust", obs.WindGust)
+			ws.UpdateSensor("Wind Gust", gusts.Report(at))
 			ws.UpdateSensor("Wind Direction", obs.WindDirection)
 			ws.UpdateSensor("Air Temperature", obs.AirTemperature)
 			ws.UpdateSensor("Relative Humidity", obs.RelativeHumidity)
-			ws.UpdateSensor("Ambient Light", obs.Illuminance)
+			updateAmbientLight(ws, lux, obs)
 			ws.UpdateSensor("UV Index", float64(obs.UV))
 			ws.UpdateSensor("Rain Accumulation", obs.RainAccumulated)
 			ws.UpdateSensor("Precipitation Type", float64(obs.PrecipitationType))
 			ws.UpdateSensor("Lightning Count", float64(obs.LightningStrikeCount))
 			ws.UpdateSensor("Lightning Distance", obs.LightningStrikeAvg)
 			logger.Debug("HomeKit sensors updated")
-		}
+		})
+	}
 
-		// Update web server
-		if webServer != nil {
+	if webServer != nil {
+		bus.Observations.Handle("web", func(obs weather.Observation) {
 			webServer.UpdateWeather(&obs)
 			logger.Debug("Web server updated")
-
-			// Update forecast from data source (if available)
-			if forecast := dataSource.GetForecast(); forecast != nil {
-				webServer.UpdateForecast(forecast)
-				logger.Debug("Forecast updated")
-			}
-
-			// Update data source status in web server
-			status := dataSource.GetStatus()
+		})
+		bus.Forecasts.Handle("web", func(forecast *weather.ForecastResponse) {
+			webServer.UpdateForecast(forecast)
+			logger.Debug("Forecast updated")
+		})
+		bus.StationStatus.Handle("web", func(status weather.DataSourceStatus) {
 			webServer.UpdateDataSourceStatus(status)
 			logger.Debug("Data source status updated")
-		}
+		})
+		bus.Observations.Handle("web-stream", webServer.PublishObservationEvent)
+		bus.Alarms.Handle("web-stream", webServer.PublishAlarmEvent)
+	}
 
-		// Process alarms if alarm manager is initialized
-		if alarmManager != nil {
+	if alarmManager != nil {
+		alarmManager.SetFiredHandler(bus.Alarms.Publish)
+		bus.Observations.Handle("alarms", func(obs weather.Observation) {
 			alarmManager.ProcessObservation(&obs)
+		})
+	}
+}
+
+// preloadHistory fills the dashboard history for --history-read, from the
+// generator in generated weather mode and from the WeatherFlow API otherwise
+func preloadHistory(cfg *config.Config, webServer *web.WebServer, weatherGen *generator.WeatherGenerator, stationID int) {
+	var dataSourceDesc string
+	if cfg.UseGeneratedWeather {
+		dataSourceDesc = "from generated weather"
+	} else {
+		dataSourceDesc = "from Tempest API"
+	}
+
+	if cfg.LogLevel == "info" || cfg.LogLevel == "debug" {
+		logger.Info("--history-read flag detected, preloading historical observations (up to HISTORY_POINTS points) %s...", dataSourceDesc)
+	}
+
+	// Create a progress callback function
+	progressCallback := func(currentStep, totalSteps int, description string) {
+		if webServer != nil {
+			webServer.SetHistoryLoadingProgress(currentStep, totalSteps, description)
 		}
+	}
 
-		// Log observation details
-		if cfg.LogLevel == "info" || cfg.LogLevel == "debug" {
-			logger.Info("%s data - Temp: %.1f°C, Humidity: %.1f%%, Wind: %.1f m/s, Lux: %.0f",
-				dataSource.GetType(), obs.AirTemperature, obs.RelativeHumidity, obs.WindAvg, obs.Illuminance)
+	var historicalObs []*weather.Observation
+	var err error
+
+	if cfg.UseGeneratedWeather && weatherGen != nil {
+		// Generate historical data
+		logger.Info("Generating %d historical weather data points...", cfg.HistoryPoints)
+		historicalObs = weatherGen.GenerateHistoricalData(cfg.HistoryPoints)
+		logger.Debug("Successfully generated %d historical observations", len(historicalObs))
+	} else {
+		// Use real historical data from API
+		historicalObs, err = weather.GetHistoricalObservationsWithProgress(stationID, cfg.Token, cfg.LogLevel, progressCallback, cfg.HistoryPoints)
+		if err != nil {
+			logger.Error("Failed to fetch historical data: %v", err)
+			if webServer != nil {
+				webServer.SetHistoryLoadingComplete()
+			}
+		} else {
+			logger.Debug("Successfully fetched %d historical observations", len(historicalObs))
 		}
 	}
 
-	logger.Info("Observation processing loop ended")
-	return nil
+	if err == nil && webServer != nil {
+		webServer.SetHistoryLoadingProgress(2, 3, "Processing historical data...")
+
+		// Send historical data to web server for charts
+		for _, obs := range correctRain(cfg, historicalObs) {
+			webServer.UpdateWeather(obs)
+			logger.Debug("Added historical observation from %v", time.Unix(obs.Timestamp, 0))
+		}
+
+		// Complete the loading process
+		webServer.SetHistoryLoadingComplete()
+
+		webServer.SetHistoricalDataStatus(len(historicalObs))
+
+		if cfg.LogLevel == "info" || cfg.LogLevel == "debug" {
+			logger.Info("Historical data preload completed - loaded %d observations (up to configured HISTORY_POINTS)", len(historicalObs))
+		}
+
+		// NOTE: No need to reset test pattern rain anymore because we now preserve
+		// cumulativeRain during historical generation instead of restoring it
+	}
 }
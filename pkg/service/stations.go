@@ -0,0 +1,302 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/homekit"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/stats"
+	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/web"
+)
+
+// stationsCacheTTL is how long the station list from GetStations is reused
+const stationsCacheTTL = 10 * time.Minute
+
+// stationObservationURL is the WeatherFlow observation URL of a station, as
+// shown in the dashboard
+func stationObservationURL(stationID int, token string) string {
+	return fmt.Sprintf("https://swd.weatherflow.com/swd/rest/observations/station/%d?token=%s", stationID, token)
+}
+
+// stationSwitcher tracks the active station. In WeatherFlow API mode it also
+// implements web.StationManager: it caches the token's station list and, on a
+// switch, replaces the data source and points the dashboard, HomeKit naming
+// and alarm location at the new station.
+type stationSwitcher struct {
+	cfg          *config.Config
+	listStations func(token string) ([]weather.Station, error)
+
+	// Set once the service has built them; any may be nil when disabled
+	source    *switchableSource
+	webServer *web.WebServer
+	homekit   *homekit.WeatherSystemModern
+	alarms    *alarm.Manager
+	stats     *stats.Engine
+	anomalies *stats.AnomalyDetector
+
+	switchMu sync.Mutex // serializes SwitchStation
+
+	mu       sync.RWMutex
+	station  *weather.Station
+	cached   []weather.Station
+	cachedAt time.Time
+}
+
+func newStationSwitcher(cfg *config.Config, station *weather.Station) *stationSwitcher {
+	return &stationSwitcher{cfg: cfg, station: station, listStations: weather.GetStations}
+}
+
+// Current returns the active station
+func (s *stationSwitcher) Current() *weather.Station {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.station
+}
+
+// Stations returns the token's stations, fetching them when the cache is
+// empty, expired or refresh is set
+func (s *stationSwitcher) Stations(refresh bool) ([]web.StationInfo, time.Time, error) {
+	stations, fetched, err := s.stations(refresh)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	out := make([]web.StationInfo, 0, len(stations))
+	for _, st := range stations {
+		name := st.Name
+		if name == "" {
+			name = st.StationName
+		}
+		out = append(out, web.StationInfo{ID: st.StationID, Name: name, Latitude: st.Latitude, Longitude: st.Longitude})
+	}
+	return out, fetched, nil
+}
+
+func (s *stationSwitcher) stations(refresh bool) ([]weather.Station, time.Time, error) {
+	s.mu.RLock()
+	cached, cachedAt := s.cached, s.cachedAt
+	s.mu.RUnlock()
+	if !refresh && cached != nil && time.Since(cachedAt) < stationsCacheTTL {
+		return cached, cachedAt, nil
+	}
+
+	stations, err := s.listStations(s.cfg.Token)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	now := time.Now()
+	s.mu.Lock()
+	s.cached, s.cachedAt = stations, now
+	s.mu.Unlock()
+	return stations, now, nil
+}
+
+// SwitchStation makes the station with the given ID the active one
+func (s *stationSwitcher) SwitchStation(id int) error {
+	s.switchMu.Lock()
+	defer s.switchMu.Unlock()
+
+	previous := s.Current()
+	if previous != nil && previous.StationID == id {
+		return nil
+	}
+	next, err := s.find(id)
+	if err != nil {
+		return err
+	}
+	name := next.Name
+	if name == "" {
+		name = next.StationName
+	}
+
+	s.mu.Lock()
+	s.station = next
+	s.mu.Unlock()
+	if s.source != nil {
+		if err := s.source.Switch(); err != nil {
+			s.mu.Lock()
+			s.station = previous
+			s.mu.Unlock()
+			return fmt.Errorf("failed to start data source for station %d: %w", id, err)
+		}
+	}
+	logger.Info("Active station changed to %s (ID: %d)", name, id)
+
+	if s.webServer != nil {
+		s.webServer.SetStation(id, name, stationObservationURL(id, s.cfg.Token))
+		_ = s.webServer.SetTimezone(next.Timezone)
+	}
+	if s.homekit != nil {
+		if err := s.homekit.SetStation(name); err != nil {
+			logger.Error("Failed to update HomeKit accessories for station %s: %v", name, err)
+		}
+	}
+	if s.alarms != nil && (next.Latitude != 0 || next.Longitude != 0) {
+		s.alarms.SetLocation(next.Latitude, next.Longitude)
+	}
+	if s.stats != nil {
+		s.stats.Reset()
+		s.stats.SetLatitude(next.Latitude)
+		s.stats.SetLocation(stationLocation(next.Timezone))
+	}
+	if s.anomalies != nil {
+		s.anomalies.Reset()
+	}
+	if s.cfg.HistoryRead && s.webServer != nil {
+		go preloadHistory(s.cfg, s.webServer, nil, id)
+	}
+	return nil
+}
+
+// find returns the station with the given ID, refreshing the cached list
+// once when it is not in it
+func (s *stationSwitcher) find(id int) (*weather.Station, error) {
+	for _, refresh := range []bool{false, true} {
+		stations, _, err := s.stations(refresh)
+		if err != nil {
+			return nil, err
+		}
+		for i := range stations {
+			if stations[i].StationID == id {
+				st := stations[i]
+				return &st, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("station %d: %w", id, web.ErrStationNotFound)
+}
+
+// switchableSource is a weather.DataSource whose underlying source can be
+// replaced while running, forwarding observations from whichever source is
+// current. The stream ends when the current source closes its channel on its
+// own.
+type switchableSource struct {
+	create func() (weather.DataSource, error)
+	out    chan weather.Observation
+
+	mu        sync.RWMutex
+	current   weather.DataSource
+	stopFwd   chan struct{} // closed to detach the forwarder from current
+	fwdDone   chan struct{}
+	running   bool
+	closeOnce sync.Once
+}
+
+func newSwitchableSource(initial weather.DataSource, create func() (weather.DataSource, error)) *switchableSource {
+	return &switchableSource{create: create, current: initial, out: make(chan weather.Observation, 100)}
+}
+
+func (s *switchableSource) Start() (<-chan weather.Observation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return s.out, nil
+	}
+	ch, err := s.current.Start()
+	if err != nil {
+		return nil, err
+	}
+	s.running = true
+	s.forward(ch)
+	return s.out, nil
+}
+
+// forward starts copying ch to out; s.mu must be held
+func (s *switchableSource) forward(ch <-chan weather.Observation) {
+	stop, done := make(chan struct{}), make(chan struct{})
+	s.stopFwd, s.fwdDone = stop, done
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			case obs, ok := <-ch:
+				if !ok {
+					select {
+					case <-stop: // closed because it is being replaced
+					default:
+						s.closeOnce.Do(func() { close(s.out) })
+					}
+					return
+				}
+				select {
+				case s.out <- obs:
+				case <-stop:
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Switch starts a new source from create and stops the old one. On error the
+// old source keeps running.
+func (s *switchableSource) Switch() error {
+	ds, err := s.create()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.running {
+		s.current = ds
+		return nil
+	}
+	ch, err := ds.Start()
+	if err != nil {
+		_ = ds.Stop()
+		return err
+	}
+
+	old := s.current
+	close(s.stopFwd)
+	<-s.fwdDone
+	if err := old.Stop(); err != nil {
+		logger.Warn("Error stopping previous data source: %v", err)
+	}
+	s.current = ds
+	s.forward(ch)
+	return nil
+}
+
+func (s *switchableSource) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.running {
+		return nil
+	}
+	s.running = false
+	close(s.stopFwd)
+	<-s.fwdDone
+	err := s.current.Stop()
+	s.closeOnce.Do(func() { close(s.out) })
+	return err
+}
+
+func (s *switchableSource) currentSource() weather.DataSource {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+func (s *switchableSource) GetLatestObservation() *weather.Observation {
+	return s.currentSource().GetLatestObservation()
+}
+
+func (s *switchableSource) GetForecast() *weather.ForecastResponse {
+	return s.currentSource().GetForecast()
+}
+
+func (s *switchableSource) GetStatus() weather.DataSourceStatus {
+	return s.currentSource().GetStatus()
+}
+
+func (s *switchableSource) GetType() weather.DataSourceType {
+	return s.currentSource().GetType()
+}
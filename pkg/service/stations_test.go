@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/web"
+)
+
+func TestStationSwitcherCachesStations(t *testing.T) {
+	calls := 0
+	s := newStationSwitcher(&config.Config{Token: "tok"}, &weather.Station{StationID: 1})
+	s.listStations = func(token string) ([]weather.Station, error) {
+		calls++
+		return []weather.Station{{StationID: 1, Name: "Home"}, {StationID: 2, StationName: "Cabin"}}, nil
+	}
+
+	stations, _, err := s.Stations(false)
+	if err != nil || len(stations) != 2 || stations[1].Name != "Cabin" {
+		t.Fatalf("Stations = %+v, %v", stations, err)
+	}
+	if _, _, _ = s.Stations(false); calls != 1 {
+		t.Errorf("expected the cached list to be reused, got %d fetches", calls)
+	}
+	if _, _, _ = s.Stations(true); calls != 2 {
+		t.Errorf("expected refresh to fetch again, got %d fetches", calls)
+	}
+}
+
+func TestStationSwitcherSwitchesDataSource(t *testing.T) {
+	s := newStationSwitcher(&config.Config{Token: "tok"}, &weather.Station{StationID: 1, Name: "Home"})
+	s.listStations = func(string) ([]weather.Station, error) {
+		return []weather.Station{{StationID: 1, Name: "Home"}, {StationID: 2, Name: "Cabin"}}, nil
+	}
+
+	first, second := newStubSource(), newStubSource()
+	var createdFor []int
+	s.source = newSwitchableSource(first, func() (weather.DataSource, error) {
+		createdFor = append(createdFor, s.Current().StationID)
+		return second, nil
+	})
+	out, err := s.source.Start()
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	if err := s.SwitchStation(3); !errors.Is(err, web.ErrStationNotFound) {
+		t.Errorf("expected ErrStationNotFound for an unknown station, got %v", err)
+	}
+	if err := s.SwitchStation(2); err != nil {
+		t.Fatalf("SwitchStation returned error: %v", err)
+	}
+	if len(createdFor) != 1 || createdFor[0] != 2 {
+		t.Errorf("expected a data source for station 2, created for %v", createdFor)
+	}
+	if !first.isStopped() {
+		t.Error("previous data source was not stopped")
+	}
+
+	second.ch <- weather.Observation{Timestamp: 42}
+	select {
+	case obs := <-out:
+		if obs.Timestamp != 42 {
+			t.Errorf("unexpected observation %+v", obs)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("observation from the new data source was not forwarded")
+	}
+
+	if err := s.SwitchStation(2); err != nil || len(createdFor) != 1 {
+		t.Errorf("switching to the active station should be a no-op, got %v (%d sources)", err, len(createdFor))
+	}
+}
+
+func TestSwitchableSourceKeepsOldSourceOnError(t *testing.T) {
+	first := newStubSource()
+	failing := newStubSource()
+	failing.startErr = errors.New("boom")
+	src := newSwitchableSource(first, func() (weather.DataSource, error) { return failing, nil })
+	out, err := src.Start()
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	if err := src.Switch(); err == nil {
+		t.Fatal("expected the failed start to be reported")
+	}
+	if first.isStopped() {
+		t.Error("old data source stopped although the replacement failed")
+	}
+	first.ch <- weather.Observation{Timestamp: 7}
+	if obs := <-out; obs.Timestamp != 7 {
+		t.Errorf("unexpected observation %+v", obs)
+	}
+
+	if err := src.Stop(); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+	if _, ok := <-out; ok {
+		t.Error("expected the output channel to be closed after Stop")
+	}
+}
+
+func TestSwitchableSourceEndsWithCurrentSource(t *testing.T) {
+	first := newStubSource()
+	src := newSwitchableSource(first, nil)
+	out, _ := src.Start()
+	close(first.ch)
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Error("expected the stream to end")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream did not end when the data source closed")
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/mqtt"
+	"tempest-homekit-go/pkg/stats"
+	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/web"
+)
+
+// mqttPublishInterval is how often the statistics are published over MQTT
+const mqttPublishInterval = 5 * time.Minute
+
+// statsOptions returns the configured GDD base and season starts, keeping
+// the defaults for invalid values
+func statsOptions(cfg *config.Config) stats.Options {
+	opts := stats.DefaultOptions()
+	if cfg.GDDBase != "" {
+		if base, fahrenheit, err := config.ParseGDDBase(cfg.GDDBase); err != nil {
+			logger.Warn("Ignoring %v", err)
+		} else {
+			opts.GDDBase, opts.GDDFahrenheit = base, fahrenheit
+		}
+	}
+	if _, err := time.Parse("01-02", cfg.GDDSeasonStart); err == nil {
+		opts.GDDSeasonStart = cfg.GDDSeasonStart
+	}
+	if _, err := time.Parse("01-02", cfg.ChillSeasonStart); err == nil {
+		opts.ChillSeasonStart = cfg.ChillSeasonStart
+	}
+	return opts
+}
+
+// startStats feeds observations to the statistics engine, serves it at
+// /api/stats, makes it available to alarm conditions and, with
+// --mqtt-broker, publishes it periodically. The returned function stops
+// publishing and saves the engine's state.
+func startStats(cfg *config.Config, bus *EventBus, engine *stats.Engine, webServer *web.WebServer, alarmManager *alarm.Manager) func() {
+	engine.SetOptions(statsOptions(cfg))
+	bus.Observations.Handle("stats", func(obs weather.Observation) { engine.Add(&obs) })
+	bus.Wind.Handle("stats", engine.AddGust)
+	bus.Forecasts.Handle("stats", func(forecast *weather.ForecastResponse) {
+		engine.SetLocation(stationLocation(forecast.Timezone))
+	})
+	if webServer != nil {
+		webServer.SetStatsEngine(engine)
+	}
+	if alarmManager != nil {
+		alarmManager.SetStatsProvider(engine.Summary)
+	}
+	if cfg.MQTTBroker == "" {
+		return engine.Save
+	}
+
+	hostname, _ := os.Hostname()
+	publisher, err := mqtt.NewPublisher(cfg.MQTTBroker, "tempest-homekit-"+hostname, cfg.MQTTUsername, cfg.MQTTPassword)
+	if err != nil {
+		logger.Error("MQTT publishing disabled: %v", err)
+		return engine.Save
+	}
+	logger.Info("Publishing statistics to MQTT broker %s under %s/", publisher.Address(), cfg.MQTTTopic)
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(mqttPublishInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				summary := engine.Summary()
+				if summary.Updated == "" {
+					continue
+				}
+				if err := publisher.Publish(statsMessages(cfg.MQTTTopic, summary)...); err != nil {
+					logger.Warn("MQTT publish failed: %v", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		engine.Save()
+	}
+}
+
+// statsMessages builds the retained MQTT messages for a summary: the full
+// summary as JSON plus one plain number per value, which irrigation
+// controllers and Home Assistant sensors can read without a template
+func statsMessages(prefix string, summary stats.Summary) []mqtt.Message {
+	prefix = strings.TrimSuffix(prefix, "/")
+	number := func(v float64) []byte { return []byte(fmt.Sprintf("%.2f", v)) }
+	messages := []mqtt.Message{
+		{Topic: prefix + "/et0/today", Payload: number(summary.ET0.Today), Retain: true},
+		{Topic: prefix + "/et0/yesterday", Payload: number(summary.ET0.Yesterday), Retain: true},
+		{Topic: prefix + "/et0/week", Payload: number(summary.ET0.Week), Retain: true},
+		{Topic: prefix + "/gdd/today", Payload: number(summary.GDD.Today), Retain: true},
+		{Topic: prefix + "/gdd/season", Payload: number(summary.GDD.Season), Retain: true},
+		{Topic: prefix + "/chill_hours/today", Payload: number(summary.ChillHours.Today), Retain: true},
+		{Topic: prefix + "/chill_hours/season", Payload: number(summary.ChillHours.Season), Retain: true},
+		{Topic: prefix + "/solar_energy/today", Payload: number(summary.Solar.Today), Retain: true},
+		{Topic: prefix + "/uv_dose/today", Payload: number(summary.UV.Today), Retain: true},
+	}
+	if summary.Today != nil {
+		messages = append(messages, mqtt.Message{Topic: prefix + "/gust/peak_today", Payload: number(summary.Today.GustMax), Retain: true})
+	}
+	if data, err := json.Marshal(summary); err == nil {
+		messages = append(messages, mqtt.Message{Topic: prefix + "/stats", Payload: data, Retain: true})
+	}
+	return messages
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/stats"
+)
+
+func TestStatsMessages(t *testing.T) {
+	summary := stats.Summary{Updated: "2026-07-01T12:00:00Z", ET0: stats.ET0Summary{Today: 2.345, Yesterday: 5.1, Week: 31},
+		Solar: stats.DoseSummary{Unit: "Wh/m²", Today: 5432.1}, UV: stats.DoseSummary{Unit: "SED", Today: 3.25}}
+	messages := statsMessages("home/tempest/", summary)
+
+	want := map[string]string{
+		"home/tempest/et0/today":          "2.35",
+		"home/tempest/et0/yesterday":      "5.10",
+		"home/tempest/et0/week":           "31.00",
+		"home/tempest/gdd/today":          "0.00",
+		"home/tempest/gdd/season":         "0.00",
+		"home/tempest/chill_hours/today":  "0.00",
+		"home/tempest/chill_hours/season": "0.00",
+		"home/tempest/solar_energy/today": "5432.10",
+		"home/tempest/uv_dose/today":      "3.25",
+	}
+	if len(messages) != len(want)+1 {
+		t.Fatalf("got %d messages, want %d", len(messages), len(want)+1)
+	}
+	for _, m := range messages {
+		if !m.Retain {
+			t.Errorf("%s is not retained", m.Topic)
+		}
+		if m.Topic == "home/tempest/stats" {
+			var decoded stats.Summary
+			if err := json.Unmarshal(m.Payload, &decoded); err != nil || decoded.ET0 != summary.ET0 {
+				t.Errorf("stats payload = %s, %v", m.Payload, err)
+			}
+			continue
+		}
+		if want[m.Topic] != string(m.Payload) {
+			t.Errorf("%s = %s, want %s", m.Topic, m.Payload, want[m.Topic])
+		}
+	}
+}
+
+func TestStatsOptions(t *testing.T) {
+	opts := statsOptions(&config.Config{GDDBase: "50F", GDDSeasonStart: "03-01", ChillSeasonStart: "bad"})
+	if opts.GDDBase != 50 || !opts.GDDFahrenheit || opts.GDDSeasonStart != "03-01" || opts.ChillSeasonStart != "10-01" {
+		t.Errorf("statsOptions = %+v", opts)
+	}
+}
+
+func TestStatsMessagesPeakGust(t *testing.T) {
+	summary := stats.Summary{Today: &stats.Day{Date: "2026-07-01", GustMax: 14.25}}
+	for _, m := range statsMessages("home/tempest", summary) {
+		if m.Topic == "home/tempest/gust/peak_today" {
+			if string(m.Payload) != "14.25" {
+				t.Errorf("peak gust = %s, want 14.25", m.Payload)
+			}
+			return
+		}
+	}
+	t.Error("no home/tempest/gust/peak_today message")
+}
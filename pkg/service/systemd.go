@@ -0,0 +1,253 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/web"
+)
+
+// systemdUnitPath is where --install-service writes the unit file by default
+const systemdUnitPath = "/etc/systemd/system/tempest-homekit-go.service"
+
+// systemdWatchdogSec is the WatchdogSec written to installed unit files
+const systemdWatchdogSec = 60
+
+// sdNotify sends a state such as "READY=1" to systemd. It is a no-op when the
+// process was not started by systemd with Type=notify (NOTIFY_SOCKET unset).
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:] // abstract socket
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("failed to connect to systemd notify socket: %w", err)
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// sdWatchdogInterval returns the WatchdogSec systemd configured for this
+// process, or 0 when the watchdog is not enabled
+func sdWatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0 // meant for another process
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// startSystemdNotify tells systemd the service is ready and, when WatchdogSec
+// is set, pings the watchdog at half the interval. Pings are skipped while the
+// web console's liveness check (/healthz) fails, so systemd restarts a bridge
+// whose data feed or HomeKit server is dead rather than only a hung process.
+// The returned function reports STOPPING=1 and stops the pings.
+func startSystemdNotify(webServer *web.WebServer) func() {
+	if err := sdNotify("READY=1\nSTATUS=Receiving weather data"); err != nil {
+		logger.Warn("systemd notify failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	if interval := sdWatchdogInterval(); interval > 0 {
+		logger.Info("systemd watchdog enabled, pinging every %v", interval/2)
+		go func() {
+			ticker := time.NewTicker(interval / 2)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+				}
+				state := "WATCHDOG=1"
+				if webServer != nil {
+					if health := webServer.Liveness(); health.Status != web.HealthOK {
+						logger.Warn("Liveness check failed, skipping systemd watchdog ping")
+						state = "STATUS=Liveness check failed"
+					}
+				}
+				if err := sdNotify(state); err != nil {
+					logger.Warn("systemd watchdog ping failed: %v", err)
+				}
+			}
+		}()
+	}
+
+	return func() {
+		close(done)
+		_ = sdNotify("STOPPING=1")
+	}
+}
+
+// SystemdUnit returns a Type=notify unit file that runs exe with args from
+// workDir, so relative paths such as the HomeKit db and .env keep working.
+// runAs sets User= and envFile sets EnvironmentFile= when not empty.
+func SystemdUnit(exe, workDir, runAs, envFile string, args []string) string {
+	execStart := []string{systemdQuote(exe)}
+	for _, arg := range args {
+		execStart = append(execStart, systemdQuote(arg))
+	}
+
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Tempest HomeKit Bridge\n")
+	b.WriteString("After=network-online.target\n")
+	b.WriteString("Wants=network-online.target\n\n")
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=notify\n")
+	b.WriteString("NotifyAccess=main\n")
+	fmt.Fprintf(&b, "WatchdogSec=%d\n", systemdWatchdogSec)
+	if envFile != "" {
+		fmt.Fprintf(&b, "EnvironmentFile=%s\n", systemdQuote(envFile))
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(execStart, " "))
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", systemdQuote(workDir))
+	if runAs != "" {
+		fmt.Fprintf(&b, "User=%s\n", runAs)
+	}
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=10\n")
+	b.WriteString("SyslogIdentifier=tempest-homekit-go\n\n")
+	b.WriteString("[Install]\n")
+	b.WriteString("WantedBy=multi-user.target\n")
+	return b.String()
+}
+
+// systemdQuote quotes an ExecStart word. '%' and '$' are escaped since
+// systemd expands specifiers and environment variables in command lines.
+func systemdQuote(s string) string {
+	s = strings.NewReplacer("%", "%%", "$", "$$").Replace(s)
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\;") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// serviceArgs drops the install flags from the command line so the unit runs
+// the service with the remaining flags
+func serviceArgs(args []string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		name := strings.TrimLeft(args[i], "-")
+		name, _, hasValue := strings.Cut(name, "=")
+		switch name {
+		case "install-service":
+			continue
+		case "service-unit":
+			if !hasValue {
+				i++ // skip the value
+			}
+			continue
+		}
+		out = append(out, args[i])
+	}
+	return out
+}
+
+// secretFlags are the flags kept out of installed unit files, which are world
+// readable, by the environment variable that sets the same option
+var secretFlags = map[string]string{
+	"token":                   "TEMPEST_TOKEN",
+	"pin":                     "HOMEKIT_PIN",
+	"admin-password":          "ADMIN_PASSWORD",
+	"mqtt-password":           "MQTT_PASSWORD",
+	"ingest-token":            "INGEST_TOKEN",
+	"ecowitt-passkey":         "ECOWITT_PASSKEY",
+	"alarms-editor-password":  "ALARMS_EDITOR_PASSWORD",
+	"webhook-listener-secret": "WEBHOOK_LISTENER_SECRET",
+}
+
+// splitSecretArgs moves the secretFlags out of args into environment
+// variables. Flags override the environment, and so does EnvironmentFile=
+// over a .env in the working directory, so the service sees the same values.
+func splitSecretArgs(args []string) (rest []string, env map[string]string) {
+	env = map[string]string{}
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		key, secret := secretFlags[name]
+		if !secret || !strings.HasPrefix(args[i], "-") {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				continue
+			}
+			i++
+			value = args[i]
+		}
+		env[key] = value
+	}
+	return rest, env
+}
+
+// systemdEnvironmentFile returns an EnvironmentFile= file setting env.
+// Values are double quoted with the characters systemd treats specially
+// inside quotes escaped.
+func systemdEnvironmentFile(env map[string]string) string {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", "$", `\$`)
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s=\"%s\"\n", key, escape.Replace(env[key]))
+	}
+	return b.String()
+}
+
+// installSystemd writes a unit file for the current binary, working directory
+// and flags. Secret flags go to an EnvironmentFile= beside the unit that only
+// root can read. When run with sudo the service runs as the invoking user.
+func installSystemd(path string, args []string) (InstallResult, error) {
+	exe, workDir, err := executableAndWorkDir()
+	if err != nil {
+		return InstallResult{}, err
+	}
+	if path == "" {
+		path = systemdUnitPath
+	}
+
+	args, env := splitSecretArgs(serviceArgs(args))
+	envFile := ""
+	if len(env) > 0 {
+		envFile = strings.TrimSuffix(path, ".service") + ".env"
+		if err := os.WriteFile(envFile, []byte(systemdEnvironmentFile(env)), 0600); err != nil {
+			return InstallResult{}, fmt.Errorf("failed to write environment file %s: %w", envFile, err)
+		}
+		// WriteFile keeps the mode of a file that already exists
+		if err := os.Chmod(envFile, 0600); err != nil {
+			return InstallResult{}, fmt.Errorf("failed to restrict environment file %s: %w", envFile, err)
+		}
+	}
+
+	unit := SystemdUnit(exe, workDir, os.Getenv("SUDO_USER"), envFile, args)
+	if err := os.WriteFile(path, []byte(unit), 0644); err != nil {
+		return InstallResult{}, fmt.Errorf("failed to write unit file %s: %w", path, err)
+	}
+	return InstallResult{
+		Location: path,
+		Logs:     "journalctl -u " + filepath.Base(path),
+		Next: []string{
+			"sudo systemctl daemon-reload",
+			"sudo systemctl enable --now " + filepath.Base(path),
+		},
+	}, nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSdNotify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unix datagram sockets unavailable: %v", err)
+	}
+	defer conn.Close()
+
+	t.Setenv("NOTIFY_SOCKET", path)
+	if err := sdNotify("READY=1"); err != nil {
+		t.Fatalf("sdNotify returned error: %v", err)
+	}
+	buf := make([]byte, 64)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil || string(buf[:n]) != "READY=1" {
+		t.Errorf("received %q, %v; want READY=1", buf[:n], err)
+	}
+
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := sdNotify("READY=1"); err != nil {
+		t.Errorf("sdNotify without NOTIFY_SOCKET should be a no-op, got %v", err)
+	}
+}
+
+func TestSdWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "60000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	if got := sdWatchdogInterval(); got != time.Minute {
+		t.Errorf("interval = %v, want 1m", got)
+	}
+	t.Setenv("WATCHDOG_PID", "1")
+	if got := sdWatchdogInterval(); got != 0 {
+		t.Errorf("interval for another PID = %v, want 0", got)
+	}
+	t.Setenv("WATCHDOG_USEC", "")
+	if got := sdWatchdogInterval(); got != 0 {
+		t.Errorf("interval without WATCHDOG_USEC = %v, want 0", got)
+	}
+}
+
+func TestServiceArgs(t *testing.T) {
+	args := []string{"--install-service", "--token", "abc", "--service-unit", "/tmp/x.service", "--station=My Station", "-service-unit=/tmp/y", "--udp-stream"}
+	want := []string{"--token", "abc", "--station=My Station", "--udp-stream"}
+	if got := serviceArgs(args); !reflect.DeepEqual(got, want) {
+		t.Errorf("serviceArgs = %q, want %q", got, want)
+	}
+}
+
+func TestSystemdUnit(t *testing.T) {
+	unit := SystemdUnit("/opt/tempest/tempest-homekit-go", "/opt/tempest", "tempest", "/etc/systemd/system/tempest-homekit-go.env",
+		[]string{"--station", "My Station", "--history-points=100%", "--pin", "$HOME"})
+	for _, want := range []string{
+		"Type=notify\n",
+		"WatchdogSec=60\n",
+		`ExecStart=/opt/tempest/tempest-homekit-go --station "My Station" --history-points=100%% --pin $$HOME` + "\n",
+		"WorkingDirectory=/opt/tempest\n",
+		"User=tempest\n",
+		"EnvironmentFile=/etc/systemd/system/tempest-homekit-go.env\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit file missing %q:\n%s", want, unit)
+		}
+	}
+	if unit := SystemdUnit("/bin/x", "/", "", "", nil); strings.Contains(unit, "User=") || strings.Contains(unit, "EnvironmentFile=") {
+		t.Errorf("User= or EnvironmentFile= written without a value:\n%s", unit)
+	}
+}
+
+func TestInstallSystemdKeepsSecretsOutOfUnit(t *testing.T) {
+	args, env := splitSecretArgs([]string{"--token", "abc", "--station", "token", "-admin-password=p$ss\"word", "--udp-stream"})
+	if want := []string{"--station", "token", "--udp-stream"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %q, want %q", args, want)
+	}
+	if want := map[string]string{"TEMPEST_TOKEN": "abc", "ADMIN_PASSWORD": `p$ss"word`}; !reflect.DeepEqual(env, want) {
+		t.Errorf("env = %v, want %v", env, want)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tempest.service")
+	if _, err := installSystemd(path, []string{"--install-service", "--token", "secret-token", "--mqtt-password=hunter2"}); err != nil {
+		t.Fatalf("installSystemd: %v", err)
+	}
+	unit, _ := os.ReadFile(path)
+	if strings.Contains(string(unit), "secret-token") || strings.Contains(string(unit), "hunter2") {
+		t.Errorf("unit file contains a secret:\n%s", unit)
+	}
+	envPath := filepath.Join(dir, "tempest.env")
+	if !strings.Contains(string(unit), "EnvironmentFile="+envPath+"\n") {
+		t.Errorf("unit file missing EnvironmentFile=%s:\n%s", envPath, unit)
+	}
+	info, err := os.Stat(envPath)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("environment file: %v, %v; want mode 0600", info, err)
+	}
+	if got, _ := os.ReadFile(envPath); string(got) != "MQTT_PASSWORD=\"hunter2\"\nTEMPEST_TOKEN=\"secret-token\"\n" {
+		t.Errorf("environment file = %q", got)
+	}
+	if got := systemdEnvironmentFile(map[string]string{"PIN": `a\b"$c`}); got != `PIN="a\\b\"\$c"`+"\n" {
+		t.Errorf("systemdEnvironmentFile = %q", got)
+	}
+}
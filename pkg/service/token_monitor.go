@@ -0,0 +1,140 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/web"
+)
+
+// tokenRetryInterval is how soon a token check is repeated when the API was
+// unreachable or rate limited, unless the regular interval is shorter.
+// A variable so tests can shorten it.
+var tokenRetryInterval = 5 * time.Minute
+
+// tokenMonitor checks the WeatherFlow token at startup and then periodically,
+// logs when it stops being accepted and publishes the result to the web
+// console and the token_invalid / token_rate_limited alarm fields
+type tokenMonitor struct {
+	token     string
+	interval  time.Duration // 0 repeats only inconclusive checks
+	check     func(token string) weather.TokenCheck
+	webServer *web.WebServer
+
+	mu        sync.RWMutex
+	last      weather.TokenCheck
+	lastValid time.Time
+}
+
+// tokenCheckInterval returns the configured re-check interval, or 0 when the
+// token is only checked at startup
+func tokenCheckInterval(cfg *config.Config) time.Duration {
+	if cfg.TokenCheckInterval == "" || cfg.TokenCheckInterval == "0" {
+		return 0
+	}
+	d, err := time.ParseDuration(cfg.TokenCheckInterval)
+	if err != nil {
+		logger.Warn("Ignoring invalid token check interval %q: %v", cfg.TokenCheckInterval, err)
+		return 0
+	}
+	return d
+}
+
+// startTokenMonitor starts checking the token in the background. It returns a
+// nil monitor when there is no token, internet access is disabled or the
+// observations do not come from WeatherFlow (generated weather, --station-url,
+// --ingest).
+func startTokenMonitor(cfg *config.Config, webServer *web.WebServer) (*tokenMonitor, func()) {
+	if cfg.Token == "" || cfg.DisableInternet || cfg.UseGeneratedWeather || cfg.StationURL != "" || cfg.Ingest {
+		return nil, func() {}
+	}
+	m := &tokenMonitor{
+		token:     cfg.Token,
+		interval:  tokenCheckInterval(cfg),
+		check:     weather.CheckToken,
+		webServer: webServer,
+	}
+	done := make(chan struct{})
+	go m.run(done)
+	return m, func() { close(done) }
+}
+
+func (m *tokenMonitor) run(done <-chan struct{}) {
+	for {
+		next := m.checkNow()
+		if next == 0 {
+			return
+		}
+		timer := time.NewTimer(next)
+		select {
+		case <-done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// checkNow checks the token once, records the result and returns how long to
+// wait before the next check (0 for none)
+func (m *tokenMonitor) checkNow() time.Duration {
+	result := m.check(m.token)
+
+	m.mu.Lock()
+	previous := m.last.State
+	m.last = result
+	if result.State == weather.TokenValid {
+		m.lastValid = result.CheckedAt
+	}
+	lastValid := m.lastValid
+	m.mu.Unlock()
+
+	if result.State != previous {
+		switch result.State {
+		case weather.TokenValid:
+			if previous != "" {
+				logger.Info("WeatherFlow API token accepted again")
+			}
+		case weather.TokenInvalid:
+			logger.Error("WeatherFlow API token was rejected (%s). It may have expired or been revoked; create a new one at https://tempestwx.com/settings/tokens and update TEMPEST_TOKEN", result.Message)
+		case weather.TokenRateLimited:
+			logger.Warn("WeatherFlow API token check was rate limited (%s)", result.Message)
+		case weather.TokenUnreachable:
+			logger.Warn("Could not check the WeatherFlow API token: %s", result.Message)
+		}
+	}
+
+	if m.webServer != nil {
+		status := web.TokenStatus{
+			State:      string(result.State),
+			Message:    result.Message,
+			StatusCode: result.StatusCode,
+			CheckedAt:  result.CheckedAt.Format(time.RFC3339),
+		}
+		if !lastValid.IsZero() {
+			status.LastValid = lastValid.Format(time.RFC3339)
+		}
+		m.webServer.UpdateTokenStatus(status)
+	}
+
+	next := m.interval
+	if result.State == weather.TokenRateLimited || result.State == weather.TokenUnreachable {
+		if next == 0 || tokenRetryInterval < next {
+			next = tokenRetryInterval
+		}
+		if result.RetryAfter > next {
+			next = result.RetryAfter
+		}
+	}
+	return next
+}
+
+// Last returns the most recent check; State is empty before the first one
+func (m *tokenMonitor) Last() weather.TokenCheck {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.last
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestTokenMonitorCheckNow(t *testing.T) {
+	results := []weather.TokenCheck{
+		{State: weather.TokenValid, StatusCode: 200},
+		{State: weather.TokenRateLimited, StatusCode: 429, RetryAfter: 2 * time.Hour},
+		{State: weather.TokenInvalid, StatusCode: 401, Message: "rejected"},
+	}
+	m := &tokenMonitor{token: "t", interval: time.Hour}
+	m.check = func(string) weather.TokenCheck {
+		r := results[0]
+		results = results[1:]
+		r.CheckedAt = time.Now()
+		return r
+	}
+
+	if next := m.checkNow(); next != time.Hour {
+		t.Errorf("valid: next check in %v, want 1h", next)
+	}
+	if next := m.checkNow(); next != 2*time.Hour {
+		t.Errorf("rate limited: next check in %v, want Retry-After of 2h", next)
+	}
+	if next := m.checkNow(); next != time.Hour {
+		t.Errorf("invalid: next check in %v, want 1h", next)
+	}
+	if got := m.Last().State; got != weather.TokenInvalid {
+		t.Errorf("Last().State = %q, want invalid", got)
+	}
+
+	m.mu.RLock()
+	lastValid := m.lastValid
+	m.mu.RUnlock()
+	if lastValid.IsZero() {
+		t.Error("expected the first successful check to be remembered")
+	}
+}
+
+func TestTokenMonitorRetriesInconclusiveChecks(t *testing.T) {
+	m := &tokenMonitor{token: "t", check: func(string) weather.TokenCheck {
+		return weather.TokenCheck{State: weather.TokenUnreachable}
+	}}
+	if next := m.checkNow(); next != tokenRetryInterval {
+		t.Errorf("unreachable with startup-only checks: next check in %v, want %v", next, tokenRetryInterval)
+	}
+
+	m.check = func(string) weather.TokenCheck { return weather.TokenCheck{State: weather.TokenValid} }
+	if next := m.checkNow(); next != 0 {
+		t.Errorf("valid with startup-only checks: next check in %v, want none", next)
+	}
+}
+
+func TestStartTokenMonitorSkipped(t *testing.T) {
+	for _, cfg := range []*config.Config{
+		{},
+		{Token: "t", DisableInternet: true},
+		{Token: "t", UseGeneratedWeather: true},
+		{Token: "t", StationURL: "http://localhost/weather"},
+	} {
+		m, stop := startTokenMonitor(cfg, nil)
+		stop()
+		if m != nil {
+			t.Errorf("expected no token monitor for %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"strconv"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/udp"
+)
+
+// startUDPRelay opens the --udp-relay socket the UDP listener sends received
+// packets on through. It returns a nil relay when no targets are set; the
+// returned function closes the socket.
+func startUDPRelay(cfg *config.Config) (*udp.Relay, func()) {
+	if cfg.UDPRelay == "" {
+		return nil, func() {}
+	}
+	targets, err := udp.ParseRelayTargets(cfg.UDPRelay)
+	if err == nil {
+		var relay *udp.Relay
+		if relay, err = udp.NewRelay(targets); err == nil {
+			logger.Info("Relaying UDP broadcasts to %v", relay.Targets())
+			return relay, func() {
+				if err := relay.Close(); err != nil {
+					logger.Debug("failed to close UDP relay: %v", err)
+				}
+			}
+		}
+	}
+	logger.Error("UDP relay disabled: %v", err)
+	return nil, func() {}
+}
+
+// ConfigureUDPListener applies --udp-port, --udp-network, --udp-interface,
+// --udp-multicast, --udp-serial, --udp-raw-packets (with --loglevel debug) and
+// the relay (nil for none) to a new listener
+func ConfigureUDPListener(cfg *config.Config, listener *udp.UDPListener, relay *udp.Relay) {
+	if port, err := strconv.Atoi(cfg.UDPPort); err == nil {
+		listener.SetPort(port)
+	}
+	network, _ := udp.ParseNetwork(cfg.UDPNetwork)
+	groups, _ := udp.ParseMulticastGroups(cfg.UDPMulticast)
+	listener.SetSocketOptions(udp.SocketOptions{Network: network, Interface: cfg.UDPInterface, Groups: groups})
+	if stations, _ := udp.ParseStations(cfg.UDPSerial); len(stations) > 0 {
+		listener.SetStations(stations)
+	}
+	if relay != nil {
+		listener.SetRelay(relay)
+	}
+	if cfg.LogLevel == "debug" && cfg.UDPRawPackets > 0 {
+		listener.SetRawBufferSize(cfg.UDPRawPackets)
+	}
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"time"
+
+	"tempest-homekit-go/pkg/alarm"
+)
+
+// uploadCheckInterval is how often object storage uploads are checked; each
+// upload runs at its own interval
+const uploadCheckInterval = time.Minute
+
+// startUploads copies the alarm file channel exports to object storage in
+// the background. The returned function stops the timer.
+func startUploads(alarmManager *alarm.Manager) (stop func()) {
+	ticker := time.NewTicker(uploadCheckInterval)
+	done := make(chan struct{})
+	go func() {
+		alarmManager.ProcessUploads(time.Now())
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				alarmManager.ProcessUploads(now)
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
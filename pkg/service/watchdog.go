@@ -0,0 +1,291 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/web"
+)
+
+// Watchdog restart backoff bounds and how often the watchdog checks for a
+// stall. Variables so tests can shorten them.
+var (
+	watchdogCheckInterval = 15 * time.Second
+	watchdogMinBackoff    = 30 * time.Second
+	watchdogMaxBackoff    = 15 * time.Minute
+)
+
+// WatchdogStatus describes a supervised component for /api/status
+type WatchdogStatus struct {
+	Component   string
+	Restarts    int
+	LastRestart time.Time
+	LastReason  string
+	LastError   string // error from the most recent restart attempt, if it failed
+	Stalled     bool
+}
+
+// watchdogTimeout returns the configured stall timeout, or 0 when the watchdog
+// is disabled
+func watchdogTimeout(cfg *config.Config) time.Duration {
+	if cfg.WatchdogTimeout == "" || cfg.WatchdogTimeout == "0" {
+		return 0
+	}
+	d, err := time.ParseDuration(cfg.WatchdogTimeout)
+	if err != nil {
+		logger.Warn("Ignoring invalid watchdog timeout %q: %v", cfg.WatchdogTimeout, err)
+		return 0
+	}
+	return d
+}
+
+// watchdogInfo converts a watchdog status for /api/status
+func watchdogInfo(s WatchdogStatus) web.WatchdogInfo {
+	info := web.WatchdogInfo{
+		Component:  s.Component,
+		Restarts:   s.Restarts,
+		LastReason: s.LastReason,
+		LastError:  s.LastError,
+		Stalled:    s.Stalled,
+	}
+	if !s.LastRestart.IsZero() {
+		info.LastRestart = s.LastRestart.Format(time.RFC3339)
+	}
+	return info
+}
+
+// supervisedSource is a weather.DataSource that watches another data source and
+// replaces it with a fresh one from create when no observation arrives within
+// stallAfter, e.g. when the API polling loop hangs or the UDP listener stops
+// receiving. Consecutive restarts back off exponentially up to
+// watchdogMaxBackoff; the backoff resets once observations flow again. A closed
+// observation channel means the source shut down and ends the stream.
+type supervisedSource struct {
+	create     func() (weather.DataSource, error)
+	stallAfter time.Duration
+	onChange   func(WatchdogStatus)
+
+	out  chan weather.Observation
+	stop chan struct{}
+	done chan struct{}
+
+	mu          sync.RWMutex
+	current     weather.DataSource
+	started     time.Time // when current was started
+	lastObs     time.Time
+	backoff     time.Duration
+	nextAttempt time.Time
+	status      WatchdogStatus
+	running     bool
+}
+
+// newSupervisedSource wraps an already created data source. create builds a
+// replacement, including any resources the source owns such as a UDP listener.
+func newSupervisedSource(initial weather.DataSource, create func() (weather.DataSource, error), stallAfter time.Duration) *supervisedSource {
+	return &supervisedSource{
+		create:     create,
+		stallAfter: stallAfter,
+		current:    initial,
+		backoff:    watchdogMinBackoff,
+		out:        make(chan weather.Observation, 100),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+		status:     WatchdogStatus{Component: string(initial.GetType())},
+	}
+}
+
+// OnChange registers a callback run after every restart and recovery
+func (s *supervisedSource) OnChange(fn func(WatchdogStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = fn
+}
+
+// Start starts the wrapped data source and the watchdog
+func (s *supervisedSource) Start() (<-chan weather.Observation, error) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return s.out, nil
+	}
+	ch, err := s.current.Start()
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.running = true
+	s.started = time.Now()
+	s.mu.Unlock()
+
+	go s.run(ch)
+	logger.Info("Watchdog: supervising %s data source (restart after %v without observations)", s.status.Component, s.stallAfter)
+	return s.out, nil
+}
+
+// Stop stops the watchdog and the wrapped data source
+func (s *supervisedSource) Stop() error {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return nil
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	close(s.stop)
+	<-s.done
+	return s.currentSource().Stop()
+}
+
+func (s *supervisedSource) currentSource() weather.DataSource {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+func (s *supervisedSource) GetLatestObservation() *weather.Observation {
+	return s.currentSource().GetLatestObservation()
+}
+
+func (s *supervisedSource) GetForecast() *weather.ForecastResponse {
+	return s.currentSource().GetForecast()
+}
+
+func (s *supervisedSource) GetStatus() weather.DataSourceStatus {
+	return s.currentSource().GetStatus()
+}
+
+func (s *supervisedSource) GetType() weather.DataSourceType {
+	return s.currentSource().GetType()
+}
+
+// Status returns the watchdog state of the supervised component
+func (s *supervisedSource) Status() WatchdogStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+func (s *supervisedSource) run(ch <-chan weather.Observation) {
+	defer close(s.done)
+	defer close(s.out)
+
+	ticker := time.NewTicker(watchdogCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case obs, ok := <-ch:
+			if !ok {
+				logger.Info("Watchdog: %s data source closed its observation stream", s.status.Component)
+				return
+			}
+			s.observed()
+			select {
+			case s.out <- obs:
+			case <-s.stop:
+				return
+			}
+		case now := <-ticker.C:
+			if reason := s.stalled(now); reason != "" {
+				// nil after a failed restart: wait for the next attempt
+				ch = s.restart(reason)
+			}
+		}
+	}
+}
+
+// observed records an observation and clears the stall state after a restart
+func (s *supervisedSource) observed() {
+	s.mu.Lock()
+	s.lastObs = time.Now()
+	recovered := s.status.Stalled
+	s.status.Stalled = false
+	s.status.LastError = ""
+	s.backoff = watchdogMinBackoff
+	status, fn := s.status, s.onChange
+	s.mu.Unlock()
+
+	if recovered {
+		logger.Info("Watchdog: %s data source recovered (%d restarts total)", status.Component, status.Restarts)
+		if fn != nil {
+			fn(status)
+		}
+	}
+}
+
+// stalled returns why the source should be restarted now, or "" if it should not
+func (s *supervisedSource) stalled(now time.Time) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if now.Before(s.nextAttempt) {
+		return ""
+	}
+	last := s.started
+	if s.lastObs.After(last) {
+		last = s.lastObs
+	}
+	if idle := now.Sub(last); idle > s.stallAfter {
+		return fmt.Sprintf("no observations for %v", idle.Round(time.Second))
+	}
+	return ""
+}
+
+// restart replaces the current data source and returns the new observation
+// channel, or nil when the replacement could not be started. The stopped
+// source stays current until a replacement starts so status calls still work.
+func (s *supervisedSource) restart(reason string) <-chan weather.Observation {
+	old := s.currentSource()
+	logger.Warn("Watchdog: %s data source stalled (%s), restarting", s.status.Component, reason)
+	if err := old.Stop(); err != nil {
+		logger.Warn("Watchdog: error stopping stalled %s data source: %v", s.status.Component, err)
+	}
+
+	ds, err := s.create()
+	var ch <-chan weather.Observation
+	if err == nil {
+		if ch, err = ds.Start(); err != nil {
+			_ = ds.Stop()
+		}
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	s.status.Restarts++
+	s.status.LastRestart = now
+	s.status.LastReason = reason
+	s.status.Stalled = true
+	s.status.LastError = ""
+	if err != nil {
+		s.status.LastError = err.Error()
+	} else {
+		s.current = ds
+		s.started = now
+	}
+	s.nextAttempt = now.Add(s.backoff)
+	s.backoff *= 2
+	if s.backoff > watchdogMaxBackoff {
+		s.backoff = watchdogMaxBackoff
+	}
+	status, fn, wait := s.status, s.onChange, s.nextAttempt.Sub(now)
+	s.mu.Unlock()
+
+	if err != nil {
+		logger.Error("Watchdog: failed to restart %s data source: %v (next attempt in %v)", status.Component, err, wait)
+	} else {
+		logger.Info("Watchdog: %s data source restarted (restart #%d)", status.Component, status.Restarts)
+	}
+	if fn != nil {
+		fn(status)
+	}
+	if err != nil {
+		return nil
+	}
+	return ch
+}
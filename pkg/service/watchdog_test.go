@@ -0,0 +1,189 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// stubSource is a data source whose observations are sent by the test
+type stubSource struct {
+	ch       chan weather.Observation
+	startErr error
+	mu       sync.Mutex
+	stopped  bool
+}
+
+func newStubSource() *stubSource {
+	return &stubSource{ch: make(chan weather.Observation, 10)}
+}
+
+func (f *stubSource) Start() (<-chan weather.Observation, error) {
+	if f.startErr != nil {
+		return nil, f.startErr
+	}
+	return f.ch, nil
+}
+func (f *stubSource) Stop() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = true
+	return nil
+}
+func (f *stubSource) isStopped() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stopped
+}
+func (f *stubSource) GetLatestObservation() *weather.Observation { return nil }
+func (f *stubSource) GetForecast() *weather.ForecastResponse     { return nil }
+func (f *stubSource) GetStatus() weather.DataSourceStatus {
+	return weather.DataSourceStatus{Type: weather.DataSourceAPI}
+}
+func (f *stubSource) GetType() weather.DataSourceType { return weather.DataSourceAPI }
+
+func shortenWatchdog(t *testing.T) {
+	t.Helper()
+	origCheck, origMin, origMax := watchdogCheckInterval, watchdogMinBackoff, watchdogMaxBackoff
+	watchdogCheckInterval = 5 * time.Millisecond
+	watchdogMinBackoff = 20 * time.Millisecond
+	watchdogMaxBackoff = 80 * time.Millisecond
+	t.Cleanup(func() {
+		watchdogCheckInterval, watchdogMinBackoff, watchdogMaxBackoff = origCheck, origMin, origMax
+	})
+}
+
+func TestSupervisedSource_RestartsStalledSource(t *testing.T) {
+	shortenWatchdog(t)
+
+	stalled := newStubSource()
+	replacement := newStubSource()
+	replacement.ch <- weather.Observation{Timestamp: 42}
+
+	s := newSupervisedSource(stalled, func() (weather.DataSource, error) { return replacement, nil }, 30*time.Millisecond)
+	var mu sync.Mutex
+	var changes []WatchdogStatus
+	s.OnChange(func(st WatchdogStatus) {
+		mu.Lock()
+		changes = append(changes, st)
+		mu.Unlock()
+	})
+
+	out, err := s.Start()
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = s.Stop() }()
+
+	select {
+	case obs := <-out:
+		if obs.Timestamp != 42 {
+			t.Errorf("got observation %d, want the replacement's observation 42", obs.Timestamp)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no observation after restart")
+	}
+
+	if !stalled.isStopped() {
+		t.Error("stalled source was not stopped")
+	}
+	st := s.Status()
+	if st.Restarts != 1 || st.Stalled || st.LastReason == "" {
+		t.Errorf("unexpected status after recovery: %+v", st)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(changes) < 2 || !changes[0].Stalled || changes[len(changes)-1].Stalled {
+		t.Errorf("expected restart then recovery notifications, got %+v", changes)
+	}
+}
+
+func TestSupervisedSource_Backoff(t *testing.T) {
+	shortenWatchdog(t)
+
+	s := newSupervisedSource(newStubSource(), func() (weather.DataSource, error) { return newStubSource(), nil }, time.Millisecond)
+	for i, want := range []time.Duration{20, 40, 80, 80} {
+		before := time.Now()
+		if ch := s.restart("test"); ch == nil {
+			t.Fatalf("restart %d returned nil channel", i)
+		}
+		if wait := s.nextAttempt.Sub(before); wait < want*time.Millisecond {
+			t.Errorf("restart %d: next attempt in %v, want at least %v", i, wait, want*time.Millisecond)
+		}
+		if reason := s.stalled(time.Now()); reason != "" {
+			t.Errorf("restart %d: restart allowed again before backoff expired", i)
+		}
+	}
+
+	s.observed()
+	if s.backoff != watchdogMinBackoff {
+		t.Errorf("backoff not reset after observation: %v", s.backoff)
+	}
+}
+
+func TestSupervisedSource_FailedRestart(t *testing.T) {
+	shortenWatchdog(t)
+
+	initial := newStubSource()
+	s := newSupervisedSource(initial, func() (weather.DataSource, error) { return nil, errors.New("listen udp :50222: address in use") }, time.Millisecond)
+	if ch := s.restart("test"); ch != nil {
+		t.Fatal("expected nil channel when the replacement cannot be created")
+	}
+	st := s.Status()
+	if st.Restarts != 1 || st.LastError == "" || !st.Stalled {
+		t.Errorf("unexpected status after failed restart: %+v", st)
+	}
+	if s.currentSource() != weather.DataSource(initial) {
+		t.Error("current source replaced despite failed restart")
+	}
+}
+
+func TestSupervisedSource_ClosedStreamEnds(t *testing.T) {
+	shortenWatchdog(t)
+
+	src := newStubSource()
+	restarted := false
+	s := newSupervisedSource(src, func() (weather.DataSource, error) {
+		restarted = true
+		return newStubSource(), nil
+	}, time.Hour)
+	out, err := s.Start()
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	close(src.ch)
+
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Fatal("expected closed output channel")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("output channel not closed after source closed")
+	}
+	if restarted {
+		t.Error("closed stream should not trigger a restart")
+	}
+	if err := s.Stop(); err != nil {
+		t.Errorf("Stop failed: %v", err)
+	}
+}
+
+func TestWatchdogTimeout(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":     0,
+		"0":    0,
+		"5m":   5 * time.Minute,
+		"junk": 0,
+	}
+	for in, want := range cases {
+		if got := watchdogTimeout(&config.Config{WatchdogTimeout: in}); got != want {
+			t.Errorf("watchdogTimeout(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
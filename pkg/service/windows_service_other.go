@@ -0,0 +1,25 @@
+//go:build !windows
+
+package service
+
+import "errors"
+
+// IsWindowsService reports whether the process was started by the Windows
+// service control manager; always false on this platform
+func IsWindowsService() bool {
+	return false
+}
+
+// PrepareWindowsService is a no-op outside Windows
+func PrepareWindowsService() error {
+	return nil
+}
+
+// RunWindowsService is only supported on Windows
+func RunWindowsService(start func() error) error {
+	return errors.New("windows services are only supported on Windows")
+}
+
+func installWindowsService(args []string) (InstallResult, error) {
+	return InstallResult{}, errors.New("windows services are only supported on Windows")
+}
@@ -0,0 +1,125 @@
+//go:build windows
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// IsWindowsService reports whether the process was started by the Windows
+// service control manager
+func IsWindowsService() bool {
+	ok, err := svc.IsWindowsService()
+	return err == nil && ok
+}
+
+// PrepareWindowsService changes to the binary's directory when running as a
+// Windows service. Services start in the system directory, so .env, db and
+// logs would otherwise not be found.
+func PrepareWindowsService() error {
+	if !IsWindowsService() {
+		return nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	return os.Chdir(filepath.Dir(exe))
+}
+
+// RunWindowsService runs start under the service control manager. start is
+// expected to block while the bridge runs; a stop or shutdown request ends
+// the service.
+func RunWindowsService(start func() error) error {
+	return svc.Run(WindowsServiceName, windowsHandler{start: start})
+}
+
+type windowsHandler struct {
+	start func() error
+}
+
+func (h windowsHandler) Execute(_ []string, r <-chan svc.ChangeRequest, s chan<- svc.Status) (bool, uint32) {
+	s <- svc.Status{State: svc.StartPending}
+	done := make(chan error, 1)
+	go func() { done <- h.start() }()
+	s <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				logger.Error("Service failed: %v", err)
+				return true, 1 // non-zero exit triggers the recovery actions
+			}
+			return false, 0
+		case c := <-r:
+			switch c.Cmd {
+			case svc.Interrogate:
+				s <- c.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				logger.Info("Windows service stop requested")
+				s <- svc.Status{State: svc.StopPending}
+				return false, 0
+			}
+		}
+	}
+}
+
+// installWindowsService registers the bridge with the service control manager
+// to start automatically, restart after failures and write its output to
+// logs\tempest-homekit-go.log next to the binary
+func installWindowsService(args []string) (InstallResult, error) {
+	exe, _, err := executableAndWorkDir()
+	if err != nil {
+		return InstallResult{}, err
+	}
+	args = serviceArgs(args)
+	logFile := filepath.Join(filepath.Dir(exe), "logs", "tempest-homekit-go.log")
+	if !hasFlag(args, "log-file") {
+		args = append(args, "--log-file", logFile)
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return InstallResult{}, fmt.Errorf("failed to connect to the service control manager (run as Administrator): %w", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(WindowsServiceName); err == nil {
+		s.Close()
+		return InstallResult{}, fmt.Errorf("service %s already exists; remove it with: sc delete %s", WindowsServiceName, WindowsServiceName)
+	}
+
+	s, err := m.CreateService(WindowsServiceName, exe, mgr.Config{
+		DisplayName:      "Tempest HomeKit Bridge",
+		Description:      "HomeKit bridge for WeatherFlow Tempest weather stations",
+		StartType:        mgr.StartAutomatic,
+		DelayedAutoStart: true, // after networking is up
+	}, args...)
+	if err != nil {
+		return InstallResult{}, fmt.Errorf("failed to create service %s: %w", WindowsServiceName, err)
+	}
+	defer s.Close()
+
+	restart := mgr.RecoveryAction{Type: mgr.ServiceRestart, Delay: 10 * time.Second}
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{restart, restart, restart}, uint32((24 * time.Hour).Seconds())); err != nil {
+		return InstallResult{}, fmt.Errorf("failed to set restart on failure: %w", err)
+	}
+	if err := s.SetRecoveryActionsOnNonCrashFailures(true); err != nil {
+		return InstallResult{}, fmt.Errorf("failed to set restart on failure: %w", err)
+	}
+
+	return InstallResult{
+		Location: WindowsServiceName,
+		Logs:     logFile,
+		Next:     []string{"sc start " + WindowsServiceName},
+	}, nil
+}
@@ -0,0 +1,184 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+const (
+	// anomalyWindow is the rolling baseline each reading is compared with
+	anomalyWindow = 2 * time.Hour
+
+	// anomalyMinSamples is how many baseline readings a field needs before
+	// it is scored, about half an hour of one-minute observations
+	anomalyMinSamples = 30
+
+	// keepAnomalies bounds the flagged readings kept for the dashboard
+	keepAnomalies = 100
+
+	// DefaultAnomalyThreshold is the z-score from which a reading is flagged
+	DefaultAnomalyThreshold = 4.0
+)
+
+// anomalyField is a sensor value the detector scores. Light, UV and solar
+// radiation are left out: their rise at sunrise is an anomaly against any
+// two-hour baseline.
+type anomalyField struct {
+	name   string
+	value  func(*weather.Observation) float64
+	minStd float64 // floor for the baseline's standard deviation, so a flat baseline does not flag sensor noise
+}
+
+var anomalyFields = []anomalyField{
+	{"temperature", func(o *weather.Observation) float64 { return o.AirTemperature }, 0.5},
+	{"humidity", func(o *weather.Observation) float64 { return o.RelativeHumidity }, 2},
+	{"pressure", func(o *weather.Observation) float64 { return o.StationPressure }, 0.5},
+	{"wind_speed", func(o *weather.Observation) float64 { return o.WindAvg }, 1},
+	{"wind_gust", func(o *weather.Observation) float64 { return o.WindGust }, 1.5},
+}
+
+// AnomalyFields returns the names of the fields the detector scores
+func AnomalyFields() []string {
+	names := make([]string, len(anomalyFields))
+	for i, f := range anomalyFields {
+		names[i] = f.name
+	}
+	return names
+}
+
+// Anomaly is a flagged reading
+type Anomaly struct {
+	Timestamp int64   `json:"timestamp"`
+	Field     string  `json:"field"`
+	Value     float64 `json:"value"`    // in observation units (°C, %, mb, m/s)
+	Baseline  float64 `json:"baseline"` // mean of the rolling window
+	Score     float64 `json:"score"`    // signed z-score
+}
+
+// AnomalyReport is the detector's snapshot served at /api/anomalies
+type AnomalyReport struct {
+	Threshold float64            `json:"threshold"`
+	Window    string             `json:"window"`
+	Updated   int64              `json:"updated,omitempty"` // timestamp of the last scored observation
+	Scores    map[string]float64 `json:"scores"`            // signed z-scores of the last observation, by field
+	Recent    []Anomaly          `json:"recent"`            // flagged readings, oldest first
+}
+
+type anomalySample struct {
+	ts    int64
+	value float64
+}
+
+// AnomalyDetector flags readings that are far from the rolling mean of the
+// previous two hours, measured in standard deviations (z-score)
+type AnomalyDetector struct {
+	mu        sync.Mutex
+	threshold float64
+	samples   map[string][]anomalySample
+	scores    map[string]float64
+	last      int64
+	recent    []Anomaly
+}
+
+// NewAnomalyDetector returns a detector flagging readings with a z-score of
+// at least threshold in either direction
+func NewAnomalyDetector(threshold float64) *AnomalyDetector {
+	if threshold <= 0 {
+		threshold = DefaultAnomalyThreshold
+	}
+	return &AnomalyDetector{
+		threshold: threshold,
+		samples:   make(map[string][]anomalySample),
+		scores:    make(map[string]float64),
+	}
+}
+
+// Add scores obs against the baseline and then adds it to the baseline.
+// Observations not newer than the last one (re-sent or backfilled) are
+// ignored.
+func (d *AnomalyDetector) Add(obs *weather.Observation) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if obs.Timestamp <= d.last {
+		return
+	}
+	d.last = obs.Timestamp
+	cutoff := obs.Timestamp - int64(anomalyWindow/time.Second)
+
+	for _, f := range anomalyFields {
+		samples := d.samples[f.name]
+		i := sort.Search(len(samples), func(i int) bool { return samples[i].ts >= cutoff })
+		samples = samples[i:]
+
+		value := f.value(obs)
+		delete(d.scores, f.name)
+		if len(samples) >= anomalyMinSamples {
+			mean, std := meanStd(samples)
+			z := (value - mean) / math.Max(std, f.minStd)
+			d.scores[f.name] = z
+			if math.Abs(z) >= d.threshold {
+				d.recent = append(d.recent, Anomaly{Timestamp: obs.Timestamp, Field: f.name, Value: value, Baseline: mean, Score: z})
+				if len(d.recent) > keepAnomalies {
+					d.recent = d.recent[len(d.recent)-keepAnomalies:]
+				}
+			}
+		}
+		d.samples[f.name] = append(samples, anomalySample{obs.Timestamp, value})
+	}
+}
+
+func meanStd(samples []anomalySample) (mean, std float64) {
+	for _, s := range samples {
+		mean += s.value
+	}
+	mean /= float64(len(samples))
+	for _, s := range samples {
+		std += (s.value - mean) * (s.value - mean)
+	}
+	return mean, math.Sqrt(std / float64(len(samples)))
+}
+
+// Score returns the absolute z-score of field in the last observation, or
+// false while the field has too little baseline
+func (d *AnomalyDetector) Score(field string) (float64, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	z, ok := d.scores[field]
+	return math.Abs(z), ok
+}
+
+// Threshold returns the z-score from which readings are flagged
+func (d *AnomalyDetector) Threshold() float64 {
+	return d.threshold
+}
+
+// Report returns the current scores and the recently flagged readings
+func (d *AnomalyDetector) Report() AnomalyReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	r := AnomalyReport{
+		Threshold: d.threshold,
+		Window:    anomalyWindow.String(),
+		Updated:   d.last,
+		Scores:    make(map[string]float64, len(d.scores)),
+		Recent:    append([]Anomaly{}, d.recent...),
+	}
+	for field, z := range d.scores {
+		r.Scores[field] = z
+	}
+	return r
+}
+
+// Reset clears the baseline and flagged readings, for a station switch
+func (d *AnomalyDetector) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.samples = make(map[string][]anomalySample)
+	d.scores = make(map[string]float64)
+	d.recent = nil
+	d.last = 0
+}
@@ -0,0 +1,82 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestAnomalyDetector(t *testing.T) {
+	d := NewAnomalyDetector(4)
+	start := time.Date(2026, 8, 1, 12, 0, 0, 0, time.UTC)
+	obs := func(minute int, temp float64) *weather.Observation {
+		return &weather.Observation{
+			Timestamp:        start.Add(time.Duration(minute) * time.Minute).Unix(),
+			AirTemperature:   temp,
+			RelativeHumidity: 50,
+			StationPressure:  1010,
+			WindAvg:          2,
+			WindGust:         3,
+		}
+	}
+
+	// Temperature wanders by ±1 °C; nothing is scored before 30 samples
+	for m := 0; m < 60; m++ {
+		d.Add(obs(m, 20+math.Sin(float64(m)/5)))
+		if _, ok := d.Score("temperature"); ok != (m >= anomalyMinSamples) {
+			t.Fatalf("minute %d: scored = %v", m, ok)
+		}
+	}
+	if r := d.Report(); len(r.Recent) != 0 {
+		t.Fatalf("normal readings flagged: %+v", r.Recent)
+	}
+
+	// A 10 °C jump is flagged; flat humidity stays below the std floor
+	d.Add(obs(60, 30))
+	z, _ := d.Score("temperature")
+	if z < 4 {
+		t.Errorf("temperature score = %.1f, want at least 4", z)
+	}
+	if h, _ := d.Score("humidity"); h != 0 {
+		t.Errorf("humidity score = %v, want 0", h)
+	}
+	r := d.Report()
+	if len(r.Recent) != 1 || r.Recent[0].Field != "temperature" || r.Recent[0].Score < 4 || math.Abs(r.Recent[0].Baseline-20) > 1 {
+		t.Errorf("recent = %+v", r.Recent)
+	}
+
+	// Re-sent observations are ignored, Reset starts over
+	d.Add(obs(60, -40))
+	if len(d.Report().Recent) != 1 {
+		t.Error("re-sent observation was scored")
+	}
+	d.Reset()
+	if _, ok := d.Score("temperature"); ok || len(d.Report().Recent) != 0 {
+		t.Error("Reset kept state")
+	}
+}
+
+func TestAnomalyDetectorWindow(t *testing.T) {
+	d := NewAnomalyDetector(0)
+	if d.Threshold() != DefaultAnomalyThreshold {
+		t.Errorf("threshold = %v, want the default", d.Threshold())
+	}
+	start := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	// An hour at 10 °C, then three hours at 20 °C: once the window has
+	// moved past the step, 20 °C is the baseline again
+	for m := 0; m < 240; m++ {
+		temp := 10.0
+		if m >= 60 {
+			temp = 20
+		}
+		d.Add(&weather.Observation{Timestamp: start.Add(time.Duration(m) * time.Minute).Unix(), AirTemperature: temp})
+	}
+	if z, ok := d.Score("temperature"); !ok || z != 0 {
+		t.Errorf("score after the window passed = %v, %v", z, ok)
+	}
+	if got := d.Report().Recent; len(got) == 0 || got[0].Timestamp != start.Add(time.Hour).Unix() {
+		t.Errorf("recent = %+v, want the step flagged", got)
+	}
+}
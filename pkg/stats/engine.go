@@ -0,0 +1,542 @@
+// Package stats derives daily agronomic statistics, such as reference
+// evapotranspiration, growing degree days and chill hours, from the
+// observation stream, and flags anomalous readings in it.
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// StateFile is where completed days and seasonal totals are kept across
+// restarts; empty keeps them in memory only. main sets it from the data
+// directory.
+var StateFile string
+
+const (
+	// keepDays is how many completed days are kept
+	keepDays = 7
+
+	// maxGap is the longest interval between two observations that still
+	// counts toward the day's solar energy and chill hours; longer gaps are
+	// treated as missing
+	maxGap = 10 * time.Minute
+
+	// Chill hours count time with the air temperature between these (°C)
+	chillMin = 0.0
+	chillMax = 7.2
+
+	// uvIndexIrradiance is the erythemally weighted irradiance (W/m²) of a
+	// UV index of 1, and sedJoules the erythemal dose (J/m²) of one SED
+	uvIndexIrradiance = 0.025
+	sedJoules         = 100.0
+)
+
+// Options configures the growing degree day and chill hour accumulations
+type Options struct {
+	GDDBase          float64 // base temperature, in °F when GDDFahrenheit is set, else °C
+	GDDFahrenheit    bool    // compute degree days in °F
+	GDDSeasonStart   string  // MM-DD the GDD season starts on
+	ChillSeasonStart string  // MM-DD the chill hour season starts on
+}
+
+// DefaultOptions are a 10 °C base, GDD from January 1 and chill hours from
+// October 1 (northern hemisphere seasons)
+func DefaultOptions() Options {
+	return Options{GDDBase: 10, GDDSeasonStart: "01-01", ChillSeasonStart: "10-01"}
+}
+
+// Day aggregates one station day of observations
+type Day struct {
+	Date        string  `json:"date"` // YYYY-MM-DD in the station's time zone
+	TempMin     float64 `json:"tempMin"`
+	TempMax     float64 `json:"tempMax"`
+	HumidityMin float64 `json:"humidityMin"`
+	HumidityMax float64 `json:"humidityMax"`
+	WindAvg     float64 `json:"windAvg"`             // m/s
+	Solar       float64 `json:"solar"`               // MJ/m²
+	SolarEnergy float64 `json:"solarEnergy"`         // Wh/m², Solar in the unit solar panels are rated in
+	UVDose      float64 `json:"uvDose"`              // standard erythemal doses (SED, 100 J/m² of sunburning UV)
+	UVMax       int     `json:"uvMax"`               // highest UV index
+	Coverage    float64 `json:"coverage"`            // fraction of the day covered by observations
+	ET0         float64 `json:"et0"`                 // mm
+	GDD         float64 `json:"gdd"`                 // growing degree days
+	ChillHours  float64 `json:"chillHours"`          // hours between 0 and 7.2 °C
+	Rain        float64 `json:"rain"`                // mm, sum of the observations' accumulations
+	GustMax     float64 `json:"gustMax"`             // peak gust, m/s, including rapid_wind samples
+	GustMaxAt   string  `json:"gustMaxAt,omitempty"` // time of the peak gust, RFC 3339
+	Battery     float64 `json:"battery"`             // last battery reading, volts
+	Partial     bool    `json:"partial"`             // the day is still in progress
+
+	observations int
+	windSum      float64
+	pressureSum  float64
+	covered      time.Duration
+	chill        time.Duration
+	last         int64
+	dayOfYear    int
+}
+
+// ET0Summary is the reference evapotranspiration part of a Summary
+type ET0Summary struct {
+	Today     float64 `json:"today"`     // running estimate for the day so far, mm
+	Yesterday float64 `json:"yesterday"` // last completed day, mm
+	Week      float64 `json:"week"`      // sum of the completed days kept, mm
+}
+
+// GDDSummary is the growing degree day part of a Summary
+type GDDSummary struct {
+	Base        float64 `json:"base"`
+	Unit        string  `json:"unit"` // C or F, for the base and the degree days
+	Today       float64 `json:"today"`
+	Season      float64 `json:"season"` // since SeasonStart, including today
+	SeasonStart string  `json:"seasonStart"`
+}
+
+// ChillSummary is the chill hour part of a Summary
+type ChillSummary struct {
+	Today       float64 `json:"today"`
+	Season      float64 `json:"season"` // since SeasonStart, including today
+	SeasonStart string  `json:"seasonStart"`
+}
+
+// DoseSummary is a daily dose part of a Summary
+type DoseSummary struct {
+	Unit      string  `json:"unit"`
+	Today     float64 `json:"today"`     // received so far today
+	Yesterday float64 `json:"yesterday"` // last completed day
+}
+
+// Summary is the engine's snapshot served at /api/stats and over MQTT
+type Summary struct {
+	Updated    string       `json:"updated,omitempty"` // time of the last observation, RFC 3339
+	ET0        ET0Summary   `json:"et0"`
+	GDD        GDDSummary   `json:"gdd"`
+	ChillHours ChillSummary `json:"chillHours"`
+	Solar      DoseSummary  `json:"solarEnergy"` // Wh/m²
+	UV         DoseSummary  `json:"uvDose"`      // SED
+	Today      *Day         `json:"today,omitempty"`
+	Days       []Day        `json:"days"` // completed days, newest first
+}
+
+// season accumulates a daily value over completed days since Start
+type season struct {
+	Start   string  `json:"start"`   // YYYY-MM-DD
+	Total   float64 `json:"total"`   // sum over completed days
+	Through string  `json:"through"` // last day included in Total
+}
+
+// add adds a completed day's value, starting a new season when the day
+// belongs to a later one
+func (s *season) add(date, monthDay string, value float64) {
+	start := seasonStart(date, monthDay)
+	if s.Start != start {
+		*s = season{Start: start}
+	}
+	if date > s.Through {
+		s.Total += value
+		s.Through = date
+	}
+}
+
+// value returns the season total including today's running value
+func (s season) value(today *Day, monthDay string, todayValue float64) (float64, string) {
+	if today == nil {
+		return s.Total, s.Start
+	}
+	start := seasonStart(today.Date, monthDay)
+	if s.Start != start {
+		return todayValue, start
+	}
+	return s.Total + todayValue, start
+}
+
+// seasonStart returns the most recent MM-DD on or before date (YYYY-MM-DD)
+func seasonStart(date, monthDay string) string {
+	start := date[:4] + "-" + monthDay
+	if start > date {
+		year, _ := strconv.Atoi(date[:4])
+		start = fmt.Sprintf("%04d-%s", year-1, monthDay)
+	}
+	return start
+}
+
+// state is what StateFile holds
+type state struct {
+	Days        []Day  `json:"days"`
+	GDDSeason   season `json:"gddSeason"`
+	ChillSeason season `json:"chillSeason"`
+}
+
+// Engine accumulates observations into daily statistics. Days start at
+// midnight in the station's time zone.
+type Engine struct {
+	mu        sync.RWMutex
+	location  *time.Location
+	latitude  float64
+	elevation float64
+	options   Options
+	today     *Day
+	days      []Day // completed days, newest first
+	gdd       season
+	chill     season
+	updated   int64
+}
+
+// NewEngine creates an engine for a station at the given latitude and
+// elevation (meters), with days in loc (server local time when nil)
+func NewEngine(loc *time.Location, latitude, elevation float64) *Engine {
+	if loc == nil {
+		loc = time.Local
+	}
+	e := &Engine{location: loc, latitude: latitude, elevation: elevation, options: DefaultOptions()}
+	e.load()
+	return e
+}
+
+// SetOptions changes the GDD base and season starts. Completed days keep the
+// values they were closed with.
+func (e *Engine) SetOptions(opts Options) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.options = opts
+	if e.today != nil {
+		e.finish(e.today)
+	}
+}
+
+// SetLocation changes the time zone days start in
+func (e *Engine) SetLocation(loc *time.Location) {
+	if loc == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.location = loc
+}
+
+// SetLatitude changes the latitude used for clear-sky radiation
+func (e *Engine) SetLatitude(latitude float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.latitude = latitude
+}
+
+// Reset drops everything accumulated, including the seasonal totals, for
+// example after a station switch
+func (e *Engine) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.today, e.days, e.updated = nil, nil, 0
+	e.gdd, e.chill = season{}, season{}
+	e.saveLocked()
+}
+
+// Save writes completed days and seasonal totals to StateFile
+func (e *Engine) Save() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.saveLocked()
+}
+
+func (e *Engine) saveLocked() {
+	if StateFile == "" {
+		return
+	}
+	data, err := json.MarshalIndent(state{Days: e.days, GDDSeason: e.gdd, ChillSeason: e.chill}, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(StateFile), 0755); err != nil {
+		logger.Warn("Failed to save statistics: %v", err)
+		return
+	}
+	tmp := StateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		logger.Warn("Failed to save statistics: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, StateFile); err != nil {
+		logger.Warn("Failed to save statistics: %v", err)
+	}
+}
+
+func (e *Engine) load() {
+	if StateFile == "" {
+		return
+	}
+	data, err := os.ReadFile(StateFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("Failed to read statistics from %s: %v", StateFile, err)
+		}
+		return
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		logger.Warn("Ignoring corrupt statistics file %s: %v", StateFile, err)
+		return
+	}
+	e.days, e.gdd, e.chill = st.Days, st.GDDSeason, st.ChillSeason
+	if len(e.days) > keepDays {
+		e.days = e.days[:keepDays]
+	}
+}
+
+// Add accumulates an observation. Observations not newer than the last one
+// added are ignored.
+func (e *Engine) Add(obs *weather.Observation) {
+	if obs == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if obs.Timestamp <= e.updated {
+		return
+	}
+	if e.today == nil && len(e.days) > 0 {
+		// Days loaded from StateFile: skip observations they already cover
+		if t := time.Unix(obs.Timestamp, 0).In(e.location).Format("2006-01-02"); t <= e.days[0].Date {
+			return
+		}
+	}
+
+	t := time.Unix(obs.Timestamp, 0).In(e.location)
+	if date := t.Format("2006-01-02"); e.today == nil || e.today.Date != date {
+		e.closeDay()
+		e.today = newDay(t, obs)
+	}
+	e.accumulate(e.today, t, obs)
+	e.updated = obs.Timestamp
+}
+
+// newDay starts the day of t with obs as its first observation
+func newDay(t time.Time, obs *weather.Observation) *Day {
+	return &Day{Date: t.Format("2006-01-02"), dayOfYear: t.YearDay(), TempMin: obs.AirTemperature, TempMax: obs.AirTemperature,
+		HumidityMin: obs.RelativeHumidity, HumidityMax: obs.RelativeHumidity, Partial: true}
+}
+
+// accumulate adds obs, measured at t, to d
+func (e *Engine) accumulate(d *Day, t time.Time, obs *weather.Observation) {
+	interval := time.Duration(obs.ReportInterval) * time.Minute
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if d.last != 0 {
+		if gap := time.Duration(obs.Timestamp-d.last) * time.Second; gap <= maxGap {
+			interval = gap
+		}
+	}
+	d.Solar += obs.SolarRadiation * interval.Seconds() / 1e6
+	// A UV index of 1 is 25 mW/m² of erythemally weighted irradiance
+	d.UVDose += float64(obs.UV) * uvIndexIrradiance * interval.Seconds() / sedJoules
+	d.UVMax = max(d.UVMax, obs.UV)
+	d.covered += interval
+	if obs.AirTemperature >= chillMin && obs.AirTemperature <= chillMax {
+		d.chill += interval
+	}
+
+	d.TempMin = math.Min(d.TempMin, obs.AirTemperature)
+	d.TempMax = math.Max(d.TempMax, obs.AirTemperature)
+	d.HumidityMin = math.Min(d.HumidityMin, obs.RelativeHumidity)
+	d.HumidityMax = math.Max(d.HumidityMax, obs.RelativeHumidity)
+	d.Rain += obs.RainAccumulated
+	d.addGust(t, obs.WindGust)
+	if obs.Battery > 0 {
+		d.Battery = obs.Battery
+	}
+	d.windSum += obs.WindAvg
+	d.pressureSum += obs.StationPressure
+	d.observations++
+	d.last = obs.Timestamp
+	e.finish(d)
+}
+
+// Recompute rebuilds the day containing the Unix time at from observations
+// (oldest first; those of other days are skipped), for example after a
+// stored reading was deleted or overwritten, and returns the day's date.
+// Completed days also correct the seasonal totals they were added to. A day
+// no longer kept, or observations covering less of the day than it already
+// had, is refused, so a short history cannot truncate a day; rapid_wind gusts
+// between observations are not part of the rebuilt day.
+func (e *Engine) Recompute(at int64, observations []weather.Observation) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	date := time.Unix(at, 0).In(e.location).Format("2006-01-02")
+	var old *Day
+	if e.today != nil && e.today.Date == date {
+		old = e.today
+	} else {
+		for i := range e.days {
+			if e.days[i].Date == date {
+				old = &e.days[i]
+			}
+		}
+	}
+	if old == nil {
+		return date, fmt.Errorf("no statistics kept for %s", date)
+	}
+
+	var d *Day
+	for i := range observations {
+		obs := &observations[i]
+		t := time.Unix(obs.Timestamp, 0).In(e.location)
+		if t.Format("2006-01-02") != date {
+			continue
+		}
+		if d == nil {
+			d = newDay(t, obs)
+		}
+		e.accumulate(d, t, obs)
+	}
+	// Dropping a reading lengthens the next interval instead of losing
+	// coverage, unless it was the last one before a gap
+	if d == nil || d.Coverage < old.Coverage-maxGap.Hours()/24 {
+		return date, fmt.Errorf("the history covers less of %s than its statistics; not recomputed", date)
+	}
+
+	if old == e.today {
+		e.today = d
+		return date, nil
+	}
+	d.Partial = false
+	if e.gdd.Start == seasonStart(date, e.options.GDDSeasonStart) && date <= e.gdd.Through {
+		e.gdd.Total += d.GDD - old.GDD
+	}
+	if e.chill.Start == seasonStart(date, e.options.ChillSeasonStart) && date <= e.chill.Through {
+		e.chill.Total += d.ChillHours - old.ChillHours
+	}
+	*old = *d
+	e.saveLocked()
+	return date, nil
+}
+
+// addGust raises the day's peak gust when speed beats it
+func (d *Day) addGust(t time.Time, speed float64) {
+	if speed > d.GustMax {
+		d.GustMax, d.GustMaxAt = speed, t.Format(time.RFC3339)
+	}
+}
+
+// AddGust counts a wind sample measured between observations, such as a
+// UDP rapid_wind speed, toward today's peak gust. Samples from another day
+// than the current one are ignored.
+func (e *Engine) AddGust(sample weather.WindSample) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	t := time.Unix(sample.Timestamp, 0).In(e.location)
+	if e.today != nil && e.today.Date == t.Format("2006-01-02") {
+		e.today.addGust(t, sample.Speed)
+	}
+}
+
+// PeakGust returns today's peak gust in m/s and when it was measured, or
+// zero values before the first observation of the day
+func (e *Engine) PeakGust() (float64, time.Time) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.today == nil || e.today.GustMaxAt == "" {
+		return 0, time.Time{}
+	}
+	at, _ := time.Parse(time.RFC3339, e.today.GustMaxAt)
+	return e.today.GustMax, at
+}
+
+// LightDose returns the solar energy (Wh/m²) and UV dose (SED) received
+// today, or zeros before the first observation of the day
+func (e *Engine) LightDose() (solar, uv float64) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.today == nil {
+		return 0, 0
+	}
+	return e.today.SolarEnergy, e.today.UVDose
+}
+
+// closeDay moves today to the completed days
+func (e *Engine) closeDay() {
+	if e.today == nil {
+		return
+	}
+	d := *e.today
+	d.Partial = false
+	e.days = append([]Day{d}, e.days...)
+	if len(e.days) > keepDays {
+		e.days = e.days[:keepDays]
+	}
+	e.gdd.add(d.Date, e.options.GDDSeasonStart, d.GDD)
+	e.chill.add(d.Date, e.options.ChillSeasonStart, d.ChillHours)
+	e.saveLocked()
+}
+
+// finish recomputes the derived values of d
+func (e *Engine) finish(d *Day) {
+	d.WindAvg = d.windSum / float64(d.observations)
+	d.Coverage = math.Min(d.covered.Hours()/24, 1)
+	d.ET0 = weather.ReferenceET0(weather.ET0Inputs{
+		TempMin:     d.TempMin,
+		TempMax:     d.TempMax,
+		HumidityMin: d.HumidityMin,
+		HumidityMax: d.HumidityMax,
+		WindSpeed:   d.WindAvg,
+		Solar:       d.Solar,
+		Pressure:    d.pressureSum / float64(d.observations) / 10, // mb to kPa
+		Latitude:    e.latitude,
+		Elevation:   e.elevation,
+		DayOfYear:   d.dayOfYear,
+	})
+	d.ChillHours = d.chill.Hours()
+	d.SolarEnergy = d.Solar * 1e6 / 3600
+
+	// Average method: (max + min) / 2 above the base, never negative
+	tMin, tMax := d.TempMin, d.TempMax
+	if e.options.GDDFahrenheit {
+		tMin, tMax = tMin*9/5+32, tMax*9/5+32
+	}
+	d.GDD = math.Max((tMax+tMin)/2-e.options.GDDBase, 0)
+}
+
+// Summary returns a snapshot of the statistics
+func (e *Engine) Summary() Summary {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	s := Summary{Days: append([]Day{}, e.days...)}
+	s.GDD.Base, s.GDD.Unit = e.options.GDDBase, "C"
+	if e.options.GDDFahrenheit {
+		s.GDD.Unit = "F"
+	}
+	if e.updated != 0 {
+		s.Updated = time.Unix(e.updated, 0).Format(time.RFC3339)
+	}
+	var todayGDD, todayChill float64
+	if e.today != nil {
+		today := *e.today
+		s.Today = &today
+		s.ET0.Today = today.ET0
+		todayGDD, todayChill = today.GDD, today.ChillHours
+	}
+	s.GDD.Today, s.ChillHours.Today = todayGDD, todayChill
+	s.GDD.Season, s.GDD.SeasonStart = e.gdd.value(e.today, e.options.GDDSeasonStart, todayGDD)
+	s.ChillHours.Season, s.ChillHours.SeasonStart = e.chill.value(e.today, e.options.ChillSeasonStart, todayChill)
+	s.Solar.Unit, s.UV.Unit = "Wh/m²", "SED"
+	if e.today != nil {
+		s.Solar.Today, s.UV.Today = e.today.SolarEnergy, e.today.UVDose
+	}
+	if len(e.days) > 0 {
+		s.ET0.Yesterday = e.days[0].ET0
+		s.Solar.Yesterday, s.UV.Yesterday = e.days[0].SolarEnergy, e.days[0].UVDose
+	}
+	for _, d := range e.days {
+		s.ET0.Week += d.ET0
+	}
+	return s
+}
@@ -0,0 +1,267 @@
+package stats
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// feedDay adds a synthetic day of one-minute observations starting at start
+func feedDay(e *Engine, start time.Time) {
+	for m := 0; m < 24*60; m++ {
+		t := start.Add(time.Duration(m) * time.Minute)
+		hour := float64(m) / 60
+		solar := math.Max(0, 800*math.Sin(math.Pi*(hour-6)/12)) // sunrise 06:00, sunset 18:00
+		e.Add(&weather.Observation{
+			Timestamp:        t.Unix(),
+			AirTemperature:   15 + 8*math.Sin(math.Pi*(hour-9)/12),
+			RelativeHumidity: 70 - 20*math.Sin(math.Pi*(hour-9)/12),
+			WindAvg:          2,
+			StationPressure:  1000,
+			SolarRadiation:   solar,
+		})
+	}
+}
+
+func TestEngineDailyET0(t *testing.T) {
+	loc := time.FixedZone("station", -7*3600)
+	e := NewEngine(loc, 34, 275)
+	start := time.Date(2026, 6, 20, 0, 0, 0, 0, loc)
+	feedDay(e, start)
+
+	s := e.Summary()
+	if s.Today == nil || !s.Today.Partial || s.Today.Date != "2026-06-20" {
+		t.Fatalf("today = %+v", s.Today)
+	}
+	if s.Today.Coverage < 0.99 {
+		t.Errorf("coverage = %v, want a full day", s.Today.Coverage)
+	}
+	// 800 W/m² peak over 12 hours is about 22 MJ/m²
+	if math.Abs(s.Today.Solar-22) > 0.5 {
+		t.Errorf("solar = %.2f MJ/m², want about 22", s.Today.Solar)
+	}
+	if s.ET0.Today < 3 || s.ET0.Today > 7 {
+		t.Errorf("ET0 today = %.2f mm, want a summer value between 3 and 7", s.ET0.Today)
+	}
+
+	// The first observation after midnight closes the day
+	e.Add(&weather.Observation{Timestamp: start.Add(24 * time.Hour).Unix(), AirTemperature: 10, RelativeHumidity: 80})
+	s = e.Summary()
+	if len(s.Days) != 1 || s.Days[0].Date != "2026-06-20" || s.Days[0].Partial {
+		t.Fatalf("completed days = %+v", s.Days)
+	}
+	if s.ET0.Yesterday != s.Days[0].ET0 || s.ET0.Week != s.Days[0].ET0 {
+		t.Errorf("et0 summary = %+v", s.ET0)
+	}
+	if s.Today.Date != "2026-06-21" {
+		t.Errorf("today = %s, want 2026-06-21", s.Today.Date)
+	}
+}
+
+func TestEngineKeepsAWeek(t *testing.T) {
+	e := NewEngine(time.UTC, 45, 0)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for d := 0; d < 10; d++ {
+		e.Add(&weather.Observation{Timestamp: start.AddDate(0, 0, d).Unix(), AirTemperature: 10, RelativeHumidity: 50})
+	}
+	if s := e.Summary(); len(s.Days) != keepDays || s.Days[0].Date != "2026-03-09" {
+		t.Errorf("kept %d days, newest %s", len(s.Days), s.Days[0].Date)
+	}
+
+	// Older observations are ignored; Reset clears everything
+	e.Add(&weather.Observation{Timestamp: start.Unix(), AirTemperature: 30})
+	if s := e.Summary(); s.Today.TempMax != 10 {
+		t.Errorf("old observation changed today: %+v", s.Today)
+	}
+	e.Reset()
+	if s := e.Summary(); s.Today != nil || len(s.Days) != 0 {
+		t.Errorf("summary after Reset = %+v", s)
+	}
+}
+
+// addHours adds hourly observations at the given temperatures from start
+func addHours(e *Engine, start time.Time, temps ...float64) {
+	for i, temp := range temps {
+		e.Add(&weather.Observation{Timestamp: start.Add(time.Duration(i) * time.Hour).Unix(), AirTemperature: temp, RelativeHumidity: 60, ReportInterval: 60})
+	}
+}
+
+func TestEngineGDDAndChillHours(t *testing.T) {
+	e := NewEngine(time.UTC, 45, 0)
+	day1 := time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC)
+	addHours(e, day1, 4, 6, 14, 26) // min 4, max 26: (26+4)/2 - 10 = 5 GDD; two chill hours
+	s := e.Summary()
+	if s.GDD.Today != 5 || s.GDD.Season != 5 || s.GDD.SeasonStart != "2026-01-01" || s.GDD.Unit != "C" {
+		t.Errorf("gdd = %+v", s.GDD)
+	}
+	if s.ChillHours.Today != 2 || s.ChillHours.SeasonStart != "2025-10-01" {
+		t.Errorf("chill hours = %+v", s.ChillHours)
+	}
+
+	addHours(e, day1.AddDate(0, 0, 1), 12, 20) // 6 GDD, no chill
+	s = e.Summary()
+	if s.GDD.Today != 6 || s.GDD.Season != 11 || s.ChillHours.Season != 2 {
+		t.Errorf("second day: gdd %+v, chill %+v", s.GDD, s.ChillHours)
+	}
+
+	// °F degree days use a °F base
+	e.SetOptions(Options{GDDBase: 50, GDDFahrenheit: true, GDDSeasonStart: "01-01", ChillSeasonStart: "10-01"})
+	if s := e.Summary(); math.Abs(s.GDD.Today-10.8) > 1e-9 || s.GDD.Unit != "F" {
+		t.Errorf("°F gdd = %+v, want 10.8 °F-days for 12-20 °C", s.GDD)
+	}
+}
+
+func TestEngineSeasonRollover(t *testing.T) {
+	e := NewEngine(time.UTC, 45, 0)
+	e.SetOptions(Options{GDDBase: 10, GDDSeasonStart: "07-01", ChillSeasonStart: "10-01"})
+	addHours(e, time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC), 20, 30)
+	addHours(e, time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), 20, 20)
+	if s := e.Summary(); s.GDD.Season != 10 || s.GDD.SeasonStart != "2026-07-01" {
+		t.Errorf("gdd after the season start = %+v, want only today's 10", s.GDD)
+	}
+}
+
+func TestEnginePersistsSeasons(t *testing.T) {
+	StateFile = filepath.Join(t.TempDir(), "stats.json")
+	defer func() { StateFile = "" }()
+
+	e := NewEngine(time.UTC, 45, 0)
+	day1 := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	addHours(e, day1, 10, 30)
+	addHours(e, day1.AddDate(0, 0, 1), 15)
+
+	restarted := NewEngine(time.UTC, 45, 0)
+	s := restarted.Summary()
+	if len(s.Days) != 1 || s.GDD.Season != 10 {
+		t.Fatalf("after restart: days %d, gdd %+v", len(s.Days), s.GDD)
+	}
+	// Observations of a day already closed before the restart are skipped
+	restarted.Add(&weather.Observation{Timestamp: day1.Add(20 * time.Hour).Unix(), AirTemperature: 40})
+	if s := restarted.Summary(); s.Today != nil {
+		t.Errorf("closed day was reopened: %+v", s.Today)
+	}
+	addHours(restarted, day1.AddDate(0, 0, 1), 10, 20)
+	addHours(restarted, day1.AddDate(0, 0, 2), 0)
+	if s := restarted.Summary(); s.GDD.Season != 15 || len(s.Days) != 2 {
+		t.Errorf("season after restart = %+v, days %d", s.GDD, len(s.Days))
+	}
+}
+
+func TestEngineRainGustAndBattery(t *testing.T) {
+	e := NewEngine(time.UTC, 45, 0)
+	start := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	for i, o := range []weather.Observation{
+		{RainAccumulated: 0.5, WindGust: 4, Battery: 2.61},
+		{RainAccumulated: 1.25, WindGust: 11.5, Battery: 2.60},
+		{RainAccumulated: 0, WindGust: 6},
+	} {
+		o.Timestamp = start.Add(time.Duration(i) * time.Minute).Unix()
+		e.Add(&o)
+	}
+	d := e.Summary().Today
+	if d.Rain != 1.75 || d.GustMax != 11.5 || d.Battery != 2.60 {
+		t.Errorf("rain = %v, gust = %v, battery = %v; want 1.75, 11.5, 2.60", d.Rain, d.GustMax, d.Battery)
+	}
+}
+
+func TestEnginePeakGust(t *testing.T) {
+	e := NewEngine(time.UTC, 45, 0)
+	if peak, at := e.PeakGust(); peak != 0 || !at.IsZero() {
+		t.Errorf("peak gust before any observation = %v at %v", peak, at)
+	}
+	start := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	e.Add(&weather.Observation{Timestamp: start.Unix(), WindGust: 6})
+
+	// A rapid_wind sample between observations raises the peak; one from
+	// another day is ignored
+	e.AddGust(weather.WindSample{Timestamp: start.Add(30 * time.Second).Unix(), Speed: 13.2})
+	e.AddGust(weather.WindSample{Timestamp: start.AddDate(0, 0, 1).Unix(), Speed: 20})
+	e.Add(&weather.Observation{Timestamp: start.Add(time.Minute).Unix(), WindGust: 8})
+
+	peak, at := e.PeakGust()
+	if peak != 13.2 || !at.Equal(start.Add(30*time.Second)) {
+		t.Errorf("peak gust = %v at %v, want 13.2 at %v", peak, at, start.Add(30*time.Second))
+	}
+	if d := e.Summary().Today; d.GustMaxAt != "2026-05-01T08:00:30Z" {
+		t.Errorf("gustMaxAt = %q", d.GustMaxAt)
+	}
+}
+
+func TestEngineLightDose(t *testing.T) {
+	e := NewEngine(time.UTC, 45, 0)
+	start := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	// An hour of full sun at UV index 8
+	for i := 0; i < 60; i++ {
+		e.Add(&weather.Observation{Timestamp: start.Add(time.Duration(i) * time.Minute).Unix(), SolarRadiation: 1000, UV: 8})
+	}
+	solar, uv := e.LightDose()
+	if math.Abs(solar-1000) > 0.01 || math.Abs(uv-7.2) > 0.01 {
+		t.Errorf("light dose = %.2f Wh/m², %.2f SED; want 1000 and 7.2", solar, uv)
+	}
+	if d := e.Summary().Today; d.UVMax != 8 {
+		t.Errorf("uvMax = %d, want 8", d.UVMax)
+	}
+
+	e.Add(&weather.Observation{Timestamp: start.AddDate(0, 0, 1).Unix()})
+	s := e.Summary()
+	if math.Abs(s.Solar.Yesterday-1000) > 0.01 || math.Abs(s.UV.Yesterday-7.2) > 0.01 || s.Solar.Today != 0 {
+		t.Errorf("summary solar %+v, uv %+v; want yesterday 1000 Wh/m² and 7.2 SED", s.Solar, s.UV)
+	}
+	if s.Solar.Unit != "Wh/m²" || s.UV.Unit != "SED" {
+		t.Errorf("units = %q, %q", s.Solar.Unit, s.UV.Unit)
+	}
+}
+
+func TestEngineRecompute(t *testing.T) {
+	e := NewEngine(time.UTC, 45, 0)
+	day1 := time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC)
+	var history []weather.Observation
+	for i, temp := range []float64{4, 6, 60, 14} { // 60 °C: the station sat in a car
+		history = append(history, weather.Observation{Timestamp: day1.Add(time.Duration(i) * time.Hour).Unix(), AirTemperature: temp, RelativeHumidity: 60, ReportInterval: 60})
+	}
+	// Today, in one-minute readings with another spike
+	day2 := day1.AddDate(0, 0, 1)
+	for m, temp := range []float64{12, 13, 55, 14, 20} {
+		history = append(history, weather.Observation{Timestamp: day2.Add(time.Duration(m) * time.Minute).Unix(), AirTemperature: temp, RelativeHumidity: 60, ReportInterval: 1})
+	}
+	for i := range history {
+		e.Add(&history[i])
+	}
+	if s := e.Summary(); s.Days[0].GDD != 22 || s.GDD.Season != 22+s.GDD.Today {
+		t.Fatalf("before: day %+v, gdd %+v", s.Days[0], s.GDD)
+	}
+
+	// Overwriting the completed day's spike corrects the day and the season
+	history[2].AirTemperature = 20
+	if date, err := e.Recompute(history[2].Timestamp, history); err != nil || date != "2026-04-10" {
+		t.Fatalf("Recompute = %s, %v", date, err)
+	}
+	s := e.Summary()
+	if s.Days[0].TempMax != 20 || s.Days[0].GDD != 2 || s.Days[0].Partial {
+		t.Errorf("corrected day = %+v", s.Days[0])
+	}
+	if s.GDD.Season != 2+s.GDD.Today {
+		t.Errorf("season = %+v, want the corrected day's 2 GDD plus today", s.GDD)
+	}
+
+	// Deleting today's spike; the next reading covers its minute
+	spike := history[6]
+	history = append(history[:6], history[7:]...)
+	if _, err := e.Recompute(spike.Timestamp, history); err != nil {
+		t.Fatal(err)
+	}
+	if s := e.Summary(); s.Today.TempMax != 20 || !s.Today.Partial {
+		t.Errorf("today = %+v, want max 20", s.Today)
+	}
+
+	// Dropping an hourly reading loses an hour of the day
+	if _, err := e.Recompute(history[1].Timestamp, append(append([]weather.Observation{}, history[:1]...), history[2:]...)); err == nil {
+		t.Error("history covering less of the day: want an error")
+	}
+	if _, err := e.Recompute(day1.AddDate(-1, 0, 0).Unix(), history); err == nil {
+		t.Error("day not kept: want an error")
+	}
+}
@@ -0,0 +1,141 @@
+package status
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/web"
+)
+
+// snoozeDuration is how long the 's' key snoozes the selected alarm
+const snoozeDuration = time.Hour
+
+// alarmEntry is one alarm in the console's alarm selector
+type alarmEntry struct {
+	Name    string
+	Enabled bool
+	Snoozed bool
+	Acked   bool
+}
+
+// alarmEntries lists the alarms of an /api/alarm-status response
+func alarmEntries(alarmData map[string]interface{}) []alarmEntry {
+	list, _ := alarmData["alarms"].([]interface{})
+	entries := make([]alarmEntry, 0, len(list))
+	for _, a := range list {
+		alarm, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var e alarmEntry
+		e.Name, _ = alarm["name"].(string)
+		e.Enabled, _ = alarm["enabled"].(bool)
+		e.Acked, _ = alarm["acknowledged"].(bool)
+		until, _ := alarm["snoozedUntil"].(string)
+		e.Snoozed = until != ""
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// alarmSelectorText renders the alarms with the selected one marked, below
+// the alarm panel, so the management keys have a visible target
+func alarmSelectorText(entries []alarmEntry, selected int, style web.ConsoleStyle) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", style("label", "Manage (↑/↓, a:ack s:snooze u:unsnooze e:on/off x:test):"))
+	for i, e := range entries {
+		marker := "  "
+		if i == selected {
+			marker = "▶ "
+		}
+		state, role := "on", "success"
+		switch {
+		case !e.Enabled:
+			state, role = "off", "muted"
+		case e.Snoozed:
+			state, role = "snoozed", "warning"
+		case e.Acked:
+			state, role = "acked", "warning"
+		}
+		fmt.Fprintf(&b, "%s%s %s\n", marker, style("value", e.Name), style(role, "["+state+"]"))
+	}
+	return b.String()
+}
+
+// postAlarmControl calls one of the /api/alarms/* admin endpoints the web
+// console uses, authenticating with the admin password
+func postAlarmControl(baseURL, password, action string, req web.AlarmControlRequest) (*web.AlarmControlResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, baseURL+"/api/alarms/"+action, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.SetBasicAuth("admin", password)
+
+	client := &http.Client{Timeout: 30 * time.Second} // send-test waits for delivery
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", strings.TrimSpace(string(data)))
+	}
+	var result web.AlarmControlResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// alarmAction runs the alarm management action bound to key against the
+// selected alarm and returns a line for the console log
+func alarmAction(baseURL, password string, key rune, e alarmEntry) string {
+	req := web.AlarmControlRequest{Name: e.Name}
+	var action, done string
+	switch key {
+	case 'a':
+		action, done = "acknowledge", "acknowledged until its condition clears"
+	case 's':
+		req.Duration = snoozeDuration.String()
+		action, done = "snooze", "snoozed for "+snoozeDuration.String()
+	case 'u':
+		req.Duration = "0"
+		action, done = "snooze", "snooze cancelled"
+	case 'e':
+		enabled := !e.Enabled
+		req.Enabled = &enabled
+		action, done = "enable", "enabled"
+		if !enabled {
+			done = "disabled"
+		}
+	case 'x':
+		action = "send-test"
+	default:
+		return ""
+	}
+
+	resp, err := postAlarmControl(baseURL, password, action, req)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("Alarm %s: %s failed: %v", e.Name, action, err)
+	case action == "send-test" && len(resp.Errors) > 0:
+		return fmt.Sprintf("Alarm %s: test notification failed on %d of %d channel(s): %s", e.Name, len(resp.Errors), resp.Channels, strings.Join(resp.Errors, "; "))
+	case action == "send-test":
+		return fmt.Sprintf("Alarm %s: test notification sent through %d channel(s)", e.Name, resp.Channels)
+	}
+	return fmt.Sprintf("Alarm %s %s", e.Name, done)
+}
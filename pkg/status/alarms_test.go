@@ -0,0 +1,87 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/web"
+)
+
+func TestAlarmEntriesAndSelector(t *testing.T) {
+	var alarmData map[string]interface{}
+	_ = json.Unmarshal([]byte(`{"alarms": [
+		{"name": "Hot", "enabled": true, "acknowledged": true},
+		{"name": "Windy", "enabled": true, "snoozedUntil": "2026-10-17T12:00:00Z"},
+		{"name": "Cold", "enabled": false}
+	]}`), &alarmData)
+	entries := alarmEntries(alarmData)
+	if len(entries) != 3 || !entries[0].Acked || !entries[1].Snoozed || entries[2].Enabled {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+
+	plain := func(role, text string) string { return text }
+	text := alarmSelectorText(entries, 1, plain)
+	for _, want := range []string{"  Hot [acked]", "▶ Windy [snoozed]", "  Cold [off]"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("selector missing %q:\n%s", want, text)
+		}
+	}
+	if alarmSelectorText(nil, 0, plain) != "" {
+		t.Error("expected no selector without alarms")
+	}
+}
+
+func TestAlarmAction(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pass, _ := r.BasicAuth(); pass != "secret" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var req web.AlarmControlRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		line := r.URL.Path + " " + req.Duration
+		if req.Enabled != nil && !*req.Enabled {
+			line += "off"
+		}
+		got = append(got, strings.TrimSpace(line))
+		resp := web.AlarmControlResponse{Name: req.Name}
+		if strings.HasSuffix(r.URL.Path, "send-test") {
+			resp.Channels, resp.Errors = 2, []string{"smtp: connection refused"}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	hot := alarmEntry{Name: "Hot", Enabled: true}
+	for key, want := range map[rune]string{
+		'a': "Alarm Hot acknowledged until its condition clears",
+		's': "Alarm Hot snoozed for 1h0m0s",
+		'u': "Alarm Hot snooze cancelled",
+		'e': "Alarm Hot disabled",
+		'x': "Alarm Hot: test notification failed on 1 of 2 channel(s): smtp: connection refused",
+	} {
+		if line := alarmAction(srv.URL, "secret", key, hot); line != want {
+			t.Errorf("key %c: got %q, want %q", key, line, want)
+		}
+	}
+	for _, want := range []string{"/api/alarms/acknowledge", "/api/alarms/snooze 1h0m0s", "/api/alarms/snooze 0", "/api/alarms/enable off", "/api/alarms/send-test"} {
+		found := false
+		for _, g := range got {
+			found = found || g == want
+		}
+		if !found {
+			t.Errorf("request %q not sent; got %v", want, got)
+		}
+	}
+
+	if line := alarmAction(srv.URL, "wrong", 'a', hot); !strings.Contains(line, "acknowledge failed: Unauthorized") {
+		t.Errorf("expected an auth failure, got %q", line)
+	}
+	if line := alarmAction(srv.URL, "secret", 'z', hot); line != "" {
+		t.Errorf("unbound key produced %q", line)
+	}
+}
@@ -11,10 +11,13 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/homekit"
 	"tempest-homekit-go/pkg/service"
+	"tempest-homekit-go/pkg/web"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -25,6 +28,7 @@ type LogBuffer struct {
 	mu      sync.Mutex
 	lines   []string
 	maxSize int
+	count   int // lines written since creation
 }
 
 // NewLogBuffer creates a new log buffer with the specified maximum size
@@ -51,6 +55,7 @@ func (lb *LogBuffer) Write(p []byte) (n int, err error) {
 		// Strip ANSI color codes for cleaner display
 		line = stripANSI(line)
 		lb.lines = append(lb.lines, line)
+		lb.count++
 		if len(lb.lines) > lb.maxSize {
 			lb.lines = lb.lines[1:]
 		}
@@ -68,6 +73,14 @@ func (lb *LogBuffer) GetLines() []string {
 	return result
 }
 
+// Count returns the number of lines written since the buffer was created,
+// including those that no longer fit, so callers can tell when it changed
+func (lb *LogBuffer) Count() int {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return lb.count
+}
+
 // stripANSI removes ANSI escape sequences from a string
 func stripANSI(s string) string {
 	// Simple ANSI stripper - removes escape sequences
@@ -156,76 +169,69 @@ func RunStatusConsole(cfg *config.Config, version string) error {
 		SetTextColor(theme.TitleColor)
 	mainFlex.AddItem(title, 1, 0, false)
 
-	// Content area - split into left (logs + station) and right (alarms + homekit)
-	contentFlex := tview.NewFlex().SetDirection(tview.FlexColumn)
-
-	// Left column
-	leftFlex := tview.NewFlex().SetDirection(tview.FlexRow)
-
-	// Console Logs (top-left)
-	tvConsole := tview.NewTextView().
-		SetDynamicColors(true).
-		SetScrollable(true).
-		SetChangedFunc(func() {
-			// Queue the UI update asynchronously to avoid re-entrancy deadlocks
-			// when this callback runs on the tview event loop.
-			go func() {
-				app.QueueUpdateDraw(func() {
-					defer func() {
-						if r := recover(); r != nil {
-							log.Printf("panic in tvConsole QueueUpdateDraw: %v", r)
-						}
-					}()
-					// Keep behavior minimal: no heavy work inside the queued callback.
-				})
-			}()
-		})
-	tvConsole.SetBorder(true).SetTitle(" Console Logs ").SetBorderColor(theme.BorderColor)
-	leftFlex.AddItem(tvConsole, 0, 3, false) // 3/6 of left column
-
-	// Tempest Sensors (middle-left)
-	tvSensors := tview.NewTextView().
-		SetDynamicColors(true).
-		SetScrollable(true)
-	tvSensors.SetBorder(true).SetTitle(" Tempest Sensors ").SetBorderColor(theme.BorderColor)
-	leftFlex.AddItem(tvSensors, 0, 2, false) // 2/6 of left column
-
-	// Station Status (bottom-left)
-	tvStation := tview.NewTextView().
-		SetDynamicColors(true).
-		SetScrollable(true)
-	tvStation.SetBorder(true).SetTitle(" Station Status ").SetBorderColor(theme.BorderColor)
-	leftFlex.AddItem(tvStation, 0, 1, false) // 1/6 of left column
-
-	contentFlex.AddItem(leftFlex, 0, 1, false) // Left column is 1/2 of width
-
-	// Right column
-	rightFlex := tview.NewFlex().SetDirection(tview.FlexRow)
-
-	// Alarm Status (top-right)
-	tvAlarms := tview.NewTextView().
-		SetDynamicColors(true).
-		SetScrollable(true)
-	tvAlarms.SetBorder(true).SetTitle(" Alarm Status ").SetBorderColor(theme.BorderColor)
-	rightFlex.AddItem(tvAlarms, 0, 1, false)
-
-	// HomeKit Status (middle-right)
-	tvHomeKit := tview.NewTextView().
-		SetDynamicColors(true).
-		SetScrollable(true)
-	tvHomeKit.SetBorder(true).SetTitle(" HomeKit Status ").SetBorderColor(theme.BorderColor)
-	rightFlex.AddItem(tvHomeKit, 0, 1, false)
-
-	// System Info (bottom-right)
-	tvSystem := tview.NewTextView().
+	// Tab bar; clicking a title or pressing its number selects the tab
+	tabBar := tview.NewTextView().
 		SetDynamicColors(true).
-		SetScrollable(true)
-	tvSystem.SetBorder(true).SetTitle(" System Info ").SetBorderColor(theme.BorderColor)
-	rightFlex.AddItem(tvSystem, 0, 1, false)
-
-	contentFlex.AddItem(rightFlex, 0, 1, false) // Right column is 1/2 of width
+		SetRegions(true).
+		SetWrap(false).
+		SetText(tabBarText())
+	mainFlex.AddItem(tabBar, 1, 0, false)
+
+	newPanel := func(title string) *tview.TextView {
+		tv := tview.NewTextView().
+			SetDynamicColors(true).
+			SetScrollable(true)
+		tv.SetBorder(true).SetTitle(" " + title + " ").SetBorderColor(theme.BorderColor)
+		return tv
+	}
 
-	mainFlex.AddItem(contentFlex, 0, 1, false)
+	// Now: current readings, station status and system info
+	tvSensors := newPanel("Tempest Sensors")
+	tvStation := newPanel("Station Status")
+	tvSystem := newPanel("System Info")
+	nowFlex := tview.NewFlex().SetDirection(tview.FlexColumn).
+		AddItem(tvSensors, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(tvStation, 0, 1, false).
+			AddItem(tvSystem, 0, 1, false), 0, 1, false)
+
+	// Charts: braille sparklines of the recent history
+	tvCharts := newPanel("Charts")
+
+	// Alarms: status and the management selector
+	tvAlarms := newPanel("Alarm Status")
+
+	// HomeKit: bridge status and the pairing QR code
+	tvHomeKit := newPanel("HomeKit Status")
+
+	// Logs: the captured log output, tailed live
+	tvConsole := newPanel("Console Logs").SetMaxLines(1000)
+
+	pages := tview.NewPages().
+		AddPage("now", nowFlex, true, true).
+		AddPage("charts", tvCharts, true, false).
+		AddPage("alarms", tvAlarms, true, false).
+		AddPage("homekit", tvHomeKit, true, false).
+		AddPage("logs", tvConsole, true, false)
+	mainFlex.AddItem(pages, 0, 1, false)
+
+	// currentTab is only touched on the event loop
+	currentTab := "now"
+	tabBar.SetHighlightedFunc(func(added, removed, remaining []string) {
+		if len(added) == 0 {
+			// Clicking the current tab again keeps it selected
+			tabBar.Highlight(currentTab)
+			return
+		}
+		currentTab = added[0]
+		pages.SwitchToPage(currentTab)
+		if currentTab == "logs" {
+			app.SetFocus(tvConsole)
+		} else {
+			app.SetFocus(pages)
+		}
+	})
+	tabBar.Highlight(currentTab)
 
 	// Footer with timers
 	footer := tview.NewTextView().
@@ -243,7 +249,7 @@ func RunStatusConsole(cfg *config.Config, version string) error {
 		seconds := int(elapsed.Seconds()) % 60
 		currentTheme := GetTheme(currentThemeName) // Look up current theme
 		timerTag := colorToTviewTag(currentTheme.TimerColor)
-		footer.SetText(fmt.Sprintf(" Running: [%s]%02d:%02d:%02d[-] | Refresh: [%s]%ds[-] | Theme: [%s]%s[-] | 'q':quit 'r':refresh 't':theme ",
+		footer.SetText(fmt.Sprintf(" Running: [%s]%02d:%02d:%02d[-] | Refresh: [%s]%ds[-] | Theme: [%s]%s[-] | 1-5/Tab:tabs 'q':quit 'r':refresh 't':theme ",
 			timerTag, hours, minutes, seconds, timerTag, nextRefresh, timerTag, currentThemeName))
 	}
 	updateFooter(cfg.StatusRefresh)
@@ -252,15 +258,17 @@ func RunStatusConsole(cfg *config.Config, version string) error {
 	applyTheme := func() {
 		currentTheme := GetTheme(currentThemeName) // Look up current theme
 		title.SetTextColor(currentTheme.TitleColor)
-		tvConsole.SetBorderColor(currentTheme.BorderColor)
-		tvSensors.SetBorderColor(currentTheme.BorderColor)
-		tvStation.SetBorderColor(currentTheme.BorderColor)
-		tvAlarms.SetBorderColor(currentTheme.BorderColor)
-		tvHomeKit.SetBorderColor(currentTheme.BorderColor)
-		tvSystem.SetBorderColor(currentTheme.BorderColor)
+		for _, tv := range []*tview.TextView{tvConsole, tvSensors, tvStation, tvSystem, tvCharts, tvAlarms, tvHomeKit} {
+			tv.SetBorderColor(currentTheme.BorderColor)
+		}
 		footer.SetTextColor(currentTheme.FooterColor)
 	}
 
+	// Alarm selector shared by updateStatus and the key handler
+	var alarmMu sync.Mutex
+	var alarmList []alarmEntry
+	selectedAlarm := 0
+
 	// Function to fetch and update status data
 	baseURL := fmt.Sprintf("http://localhost:%s", cfg.WebPort)
 	updateStatus := func() {
@@ -271,10 +279,12 @@ func RunStatusConsole(cfg *config.Config, version string) error {
 		default:
 		}
 
-		// Fetch all data first (outside UI update to avoid blocking)
-		weatherData, weatherErr := fetchStatus(baseURL + "/api/weather")
-		statusData, statusErr := fetchStatus(baseURL + "/api/status")
-		alarmData, alarmErr := fetchStatus(baseURL + "/api/alarm-status")
+		// Fetch all data first (outside UI update to avoid blocking); a
+		// failed fetch returns nil and leaves its panels empty
+		weatherData, _ := fetchStatus(baseURL + "/api/weather")
+		statusData, _ := fetchStatus(baseURL + "/api/status")
+		alarmData, _ := fetchStatus(baseURL + "/api/alarm-status")
+		history, _ := fetchHistory(baseURL, time.Now())
 
 		// Check again before UI update
 		select {
@@ -290,162 +300,41 @@ func RunStatusConsole(cfg *config.Config, version string) error {
 		currentTheme := GetTheme(currentThemeName)
 		labelTag := colorToTviewTag(currentTheme.LabelColor)
 		valueTag := colorToTviewTag(currentTheme.ValueColor)
-		successTag := colorToTviewTag(currentTheme.SuccessColor)
-		dangerTag := colorToTviewTag(currentTheme.DangerColor)
-		warningTag := colorToTviewTag(currentTheme.WarningColor)
-		mutedTag := colorToTviewTag(currentTheme.MutedColor)
 
-		// Build console log text
-		var consoleBuilder strings.Builder
-		for _, line := range logBuf.GetLines() {
-			consoleBuilder.WriteString(currentTheme.ColorizeLogLine(line))
-			consoleBuilder.WriteByte('\n')
-		}
-
-		// Build sensors text
-		var sensorsBuilder strings.Builder
-		if weatherErr == nil {
-			if temp, ok := weatherData["temperature"].(float64); ok {
-				fmt.Fprintf(&sensorsBuilder, "[%s]Temperature:[-] [%s]%.1f°C[-]\n", labelTag, valueTag, temp)
-			}
-			if humidity, ok := weatherData["humidity"].(float64); ok {
-				fmt.Fprintf(&sensorsBuilder, "[%s]Humidity:[-] [%s]%.0f%%[-]\n", labelTag, valueTag, humidity)
-			}
-			if pressure, ok := weatherData["pressure"].(float64); ok {
-				fmt.Fprintf(&sensorsBuilder, "[%s]Pressure:[-] [%s]%.1f mb[-]\n", labelTag, valueTag, pressure)
-			}
-			if windSpeed, ok := weatherData["windSpeed"].(float64); ok {
-				fmt.Fprintf(&sensorsBuilder, "[%s]Wind Speed:[-] [%s]%.1f mph[-]\n", labelTag, valueTag, windSpeed)
-			}
-			if windGust, ok := weatherData["windGust"].(float64); ok {
-				fmt.Fprintf(&sensorsBuilder, "[%s]Wind Gust:[-] [%s]%.1f mph[-]\n", labelTag, valueTag, windGust)
-			}
-			if windDir, ok := weatherData["windDirection"].(float64); ok {
-				fmt.Fprintf(&sensorsBuilder, "[%s]Wind Direction:[-] [%s]%.0f°[-]\n", labelTag, valueTag, windDir)
-			}
-			if rain, ok := weatherData["rainAccum"].(float64); ok {
-				fmt.Fprintf(&sensorsBuilder, "[%s]Rain Accum:[-] [%s]%.3f in[-]\n", labelTag, valueTag, rain)
-			}
-			if illuminance, ok := weatherData["illuminance"].(float64); ok {
-				fmt.Fprintf(&sensorsBuilder, "[%s]Illuminance:[-] [%s]%.0f lux[-]\n", labelTag, valueTag, illuminance)
-			}
-			if uv, ok := weatherData["uv"].(float64); ok {
-				fmt.Fprintf(&sensorsBuilder, "[%s]UV Index:[-] [%s]%.0f[-]\n", labelTag, valueTag, uv)
-			}
-			if battery, ok := weatherData["battery"].(float64); ok {
-				fmt.Fprintf(&sensorsBuilder, "[%s]Battery:[-] [%s]%.2fV[-]\n", labelTag, valueTag, battery)
+		// Build the sensor, station, alarm and HomeKit panels; /console
+		// renders the same panels with ANSI colors
+		style := func(role, text string) string {
+			color := currentTheme.ValueColor
+			switch role {
+			case "label":
+				color = currentTheme.LabelColor
+			case "success":
+				color = currentTheme.SuccessColor
+			case "danger":
+				color = currentTheme.DangerColor
+			case "warning":
+				color = currentTheme.WarningColor
+			case "muted":
+				color = currentTheme.MutedColor
 			}
+			return fmt.Sprintf("[%s]%s[-]", colorToTviewTag(color), text)
 		}
-
-		// Build station text
-		var stationBuilder strings.Builder
-		if statusErr == nil {
-			fmt.Fprintf(&stationBuilder, "[%s]Connected:[-] [%s]%v[-]\n", labelTag, valueTag, statusData["connected"])
-			if station, ok := statusData["station"].(string); ok {
-				fmt.Fprintf(&stationBuilder, "[%s]Station:[-] [%s]%s[-]\n", labelTag, valueTag, station)
-			}
-			if dataSource, ok := statusData["dataSource"].(string); ok {
-				fmt.Fprintf(&stationBuilder, "[%s]Data Source:[-] [%s]%s[-]\n", labelTag, valueTag, dataSource)
-			}
-			if lastUpdate, ok := statusData["lastUpdate"].(string); ok {
-				fmt.Fprintf(&stationBuilder, "[%s]Last Update:[-] [%s]%s[-]\n", labelTag, valueTag, lastUpdate)
-			}
-			if history, ok := statusData["dataHistory"].([]interface{}); ok {
-				fmt.Fprintf(&stationBuilder, "[%s]History:[-] [%s]%d pts[-]\n", labelTag, valueTag, len(history))
-			}
+		panels := web.ConsolePanels(weatherData, statusData, alarmData, style)
+		alarmMu.Lock()
+		alarmList = alarmEntries(alarmData)
+		if selectedAlarm >= len(alarmList) {
+			selectedAlarm = max(len(alarmList)-1, 0)
 		}
-
-		// Build alarms text
-		var alarmsBuilder strings.Builder
-		if alarmErr == nil {
-			if enabled, ok := alarmData["enabled"].(bool); ok {
-				fmt.Fprintf(&alarmsBuilder, "[%s]Enabled:[-] [%s]%v[-]\n", labelTag, valueTag, enabled)
-			}
-			if total, ok := alarmData["totalAlarms"].(float64); ok {
-				fmt.Fprintf(&alarmsBuilder, "[%s]Total:[-] [%s]%.0f[-]\n", labelTag, valueTag, total)
-			}
-			if enabledCount, ok := alarmData["enabledAlarms"].(float64); ok {
-				fmt.Fprintf(&alarmsBuilder, "[%s]Active:[-] [%s]%.0f[-]\n\n", labelTag, valueTag, enabledCount)
-			}
-
-			if alarms, ok := alarmData["alarms"].([]interface{}); ok {
-				triggered := []string{}
-				cooling := []string{}
-				for _, a := range alarms {
-					if alarmMap, ok := a.(map[string]interface{}); ok {
-						name, _ := alarmMap["name"].(string)
-						lastTriggered, _ := alarmMap["lastTriggered"].(string)
-						inCooldown, _ := alarmMap["inCooldown"].(bool)
-						cooldownRemaining, _ := alarmMap["cooldownRemaining"].(float64)
-
-						if lastTriggered != "" && lastTriggered != "Never" {
-							triggered = append(triggered, fmt.Sprintf("%s (%s)", name, lastTriggered))
-						}
-						if inCooldown && cooldownRemaining > 0 {
-							cooling = append(cooling, fmt.Sprintf("%s (%.0fs)", name, cooldownRemaining))
-						}
-					}
-				}
-
-				if len(triggered) > 0 {
-					fmt.Fprintf(&alarmsBuilder, "[%s]Triggered:[-]\n", dangerTag)
-					for i, t := range triggered {
-						if i >= 3 {
-							break
-						}
-						fmt.Fprintf(&alarmsBuilder, "  [%s]%s[-]\n", valueTag, t)
-					}
-					if len(triggered) > 3 {
-						fmt.Fprintf(&alarmsBuilder, "  [%s]+%d more...[-]\n", valueTag, len(triggered)-3)
-					}
-					fmt.Fprintf(&alarmsBuilder, "\n")
-				}
-
-				if len(cooling) > 0 {
-					fmt.Fprintf(&alarmsBuilder, "[%s]Cooling Down:[-]\n", warningTag)
-					for i, c := range cooling {
-						if i >= 3 {
-							break
-						}
-						fmt.Fprintf(&alarmsBuilder, "  [%s]%s[-]\n", valueTag, c)
-					}
-					if len(cooling) > 3 {
-						fmt.Fprintf(&alarmsBuilder, "  [%s]+%d more...[-]\n", valueTag, len(cooling)-3)
-					}
-				}
-			}
+		alarmsText := panels[2].Text
+		if selector := alarmSelectorText(alarmList, selectedAlarm, style); selector != "" {
+			alarmsText += "\n" + selector
 		}
-
-		// Build HomeKit text
-		var homekitBuilder strings.Builder
-		if statusErr == nil {
-			if homekit, ok := statusData["homekit"].(map[string]interface{}); ok {
-				if bridge, ok := homekit["bridge"].(bool); ok {
-					if bridge {
-						fmt.Fprintf(&homekitBuilder, "[%s]Status:[-] [%s]Active[-]\n", labelTag, successTag)
-					} else {
-						fmt.Fprintf(&homekitBuilder, "[%s]Status:[-] [%s]Disabled[-]\n", labelTag, dangerTag)
-					}
-				}
-
-				if pin, ok := homekit["pin"].(string); ok {
-					fmt.Fprintf(&homekitBuilder, "[%s]PIN:[-] [%s]%s[-]\n", labelTag, valueTag, pin)
-				}
-
-				if accessories, ok := homekit["accessories"].(float64); ok {
-					fmt.Fprintf(&homekitBuilder, "[%s]Accessories:[-] [%s]%.0f[-]\n\n", labelTag, valueTag, accessories)
-				}
-
-				if accessoryNames, ok := homekit["accessoryNames"].([]interface{}); ok && len(accessoryNames) > 0 {
-					fmt.Fprintf(&homekitBuilder, "[%s]Published Sensors:[-]\n", labelTag)
-					for _, acc := range accessoryNames {
-						if name, ok := acc.(string); ok {
-							fmt.Fprintf(&homekitBuilder, "  [%s]• %s[-]\n", successTag, name)
-						}
-					}
-				} else {
-					fmt.Fprintf(&homekitBuilder, "[%s]No sensors published[-]\n", mutedTag)
-				}
+		alarmMu.Unlock()
+		homekitText := panels[3].Text
+		if homekit, ok := statusData["homekit"].(map[string]interface{}); ok {
+			setupURI, _ := homekit["setupURI"].(string)
+			if qr := setupQRText(setupURI); qr != "" {
+				homekitText += fmt.Sprintf("\n[%s]Scan to pair:[-]\n%s", labelTag, qr)
 			}
 		}
 
@@ -472,15 +361,67 @@ func RunStatusConsole(cfg *config.Config, version string) error {
 					log.Printf("panic in updateStatus QueueUpdateDraw: %v", r)
 				}
 			}()
+			// The charts fit the view's current width
+			_, _, width, _ := tvCharts.GetInnerRect()
+			tvCharts.SetText(chartsText(history, width, style))
+			tvSensors.SetText(panels[0].Text)
+			tvStation.SetText(panels[1].Text)
+			tvAlarms.SetText(alarmsText)
+			tvHomeKit.SetText(homekitText)
+			tvSystem.SetText(systemBuilder.String())
+		})
+	}
+
+	// updateLogs shows the captured log lines when new ones arrived. While
+	// following is paused the panel is left alone so it can be scrolled.
+	var followLogs atomic.Bool
+	followLogs.Store(true)
+	var logMu sync.Mutex
+	lastLogCount := -1
+	updateLogs := func(force bool) {
+		logMu.Lock()
+		defer logMu.Unlock()
+		count := logBuf.Count()
+		if !followLogs.Load() || (count == lastLogCount && !force) {
+			return
+		}
+		lastLogCount = count
+		currentTheme := GetTheme(currentThemeName)
+		var consoleBuilder strings.Builder
+		for _, line := range logBuf.GetLines() {
+			consoleBuilder.WriteString(currentTheme.ColorizeLogLine(line))
+			consoleBuilder.WriteByte('\n')
+		}
+		app.QueueUpdateDraw(func() {
+			tvConsole.SetTitle(" Console Logs ")
 			tvConsole.SetText(consoleBuilder.String())
 			tvConsole.ScrollToEnd()
-			tvSensors.SetText(sensorsBuilder.String())
-			tvStation.SetText(stationBuilder.String())
-			tvAlarms.SetText(alarmsBuilder.String())
-			tvHomeKit.SetText(homekitBuilder.String())
-			tvSystem.SetText(systemBuilder.String())
 		})
 	}
+	setFollowLogs := func(follow bool) {
+		if followLogs.Swap(follow) == follow {
+			return
+		}
+		if follow {
+			go updateLogs(true)
+		} else {
+			tvConsole.SetTitle(" Console Logs (paused, f or End to follow) ")
+		}
+	}
+
+	// Live log tailing
+	logTicker := time.NewTicker(250 * time.Millisecond)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-logTicker.C:
+				updateLogs(false)
+			}
+		}
+	}()
+	defer logTicker.Stop()
 
 	// Shared state for refresh countdown
 	var refreshMutex sync.Mutex
@@ -547,11 +488,82 @@ func RunStatusConsole(cfg *config.Config, version string) error {
 	}()
 	defer footerTicker.Stop()
 
+	// moveAlarmSelection moves the alarm selector and redraws the panel
+	moveAlarmSelection := func(delta int) {
+		alarmMu.Lock()
+		if n := len(alarmList); n > 0 {
+			selectedAlarm = (selectedAlarm + delta + n) % n
+		}
+		alarmMu.Unlock()
+		go updateStatus()
+	}
+
+	// runAlarmAction applies an alarm management key to the selected alarm
+	// through the same admin API as the web console, off the event loop
+	runAlarmAction := func(key rune) {
+		alarmMu.Lock()
+		if len(alarmList) == 0 {
+			alarmMu.Unlock()
+			log.Printf("No alarms to manage")
+			return
+		}
+		entry := alarmList[selectedAlarm]
+		alarmMu.Unlock()
+		go func() {
+			log.Print(alarmAction(baseURL, cfg.AdminPassword, key, entry))
+			updateStatus()
+		}()
+	}
+
+	// selectTab switches to the tab at index, wrapping around
+	selectTab := func(index int) {
+		n := len(consoleTabs)
+		tabBar.Highlight(consoleTabs[(index%n+n)%n].ID)
+	}
+
 	// Input handling with non-blocking approach
 	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Keys that only apply to the current tab
+		switch currentTab {
+		case "alarms":
+			switch {
+			case event.Key() == tcell.KeyUp || event.Rune() == 'k':
+				moveAlarmSelection(-1)
+				return nil
+			case event.Key() == tcell.KeyDown || event.Rune() == 'j':
+				moveAlarmSelection(1)
+				return nil
+			case event.Key() == tcell.KeyRune && strings.ContainsRune("asuex", event.Rune()):
+				runAlarmAction(event.Rune())
+				return nil
+			}
+		case "logs":
+			// Scrolling back pauses the live tail; the log view scrolls
+			switch {
+			case event.Key() == tcell.KeyUp || event.Key() == tcell.KeyPgUp || event.Key() == tcell.KeyHome || event.Rune() == 'k':
+				setFollowLogs(false)
+				return event
+			case event.Key() == tcell.KeyEnd:
+				setFollowLogs(true)
+				return nil
+			case event.Rune() == 'f':
+				setFollowLogs(!followLogs.Load())
+				return nil
+			}
+		}
+
 		switch event.Key() {
+		case tcell.KeyTab, tcell.KeyRight:
+			selectTab(tabIndex(currentTab) + 1)
+			return nil
+		case tcell.KeyBacktab, tcell.KeyLeft:
+			selectTab(tabIndex(currentTab) - 1)
+			return nil
 		case tcell.KeyRune:
 			switch event.Rune() {
+			case '1', '2', '3', '4', '5':
+				selectTab(int(event.Rune() - '1'))
+				return nil
 			case 'q', 'Q':
 				// Stop everything immediately
 				cancel()
@@ -578,6 +590,7 @@ func RunStatusConsole(cfg *config.Config, version string) error {
 				refreshMutex.Unlock()
 				updateFooter(currentNext)
 				// The next auto-refresh will pick up the new theme
+				go updateLogs(true)
 				return nil
 			}
 		case tcell.KeyEscape, tcell.KeyCtrlC:
@@ -598,7 +611,7 @@ func RunStatusConsole(cfg *config.Config, version string) error {
 	})
 
 	// Set root and run
-	app.SetRoot(mainFlex, true).SetFocus(mainFlex)
+	app.SetRoot(mainFlex, true).SetFocus(pages)
 
 	// Enable mouse support
 	app.EnableMouse(true)
@@ -632,3 +645,20 @@ func fetchStatus(url string) (map[string]interface{}, error) {
 
 	return result, nil
 }
+
+// setupQRText renders the HomeKit setup URI as a QR code for the HomeKit
+// panel, black on white regardless of theme so phones can scan it
+func setupQRText(setupURI string) string {
+	if setupURI == "" {
+		return ""
+	}
+	qr, err := homekit.QRCodeText(setupURI)
+	if err != nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(qr, "\n"), "\n") {
+		fmt.Fprintf(&b, "[black:white]%s[-:-]\n", line)
+	}
+	return b.String()
+}
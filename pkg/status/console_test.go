@@ -25,3 +25,22 @@ func TestLogBufferWrite(t *testing.T) {
 		t.Fatalf("unexpected line content: %q", lines[0])
 	}
 }
+
+func TestSetupQRText(t *testing.T) {
+	if got := setupQRText(""); got != "" {
+		t.Errorf("expected no QR code without a setup URI, got %q", got)
+	}
+	qr := setupQRText("X-HM://0023ISYWY1QJ8")
+	lines := strings.Split(strings.TrimSuffix(qr, "\n"), "\n")
+	if len(lines) != 15 || !strings.HasPrefix(lines[0], "[black:white]") {
+		t.Errorf("unexpected QR code text: %q", qr)
+	}
+}
+
+func TestLogBufferCount(t *testing.T) {
+	lb := NewLogBuffer(2)
+	_, _ = lb.Write([]byte("one\ntwo\nthree\n"))
+	if lb.Count() != 3 || len(lb.GetLines()) != 2 {
+		t.Errorf("Count = %d with %d lines kept, want 3 and 2", lb.Count(), len(lb.GetLines()))
+	}
+}
@@ -0,0 +1,69 @@
+package status
+
+import "math"
+
+// brailleDots are the dot bits of a braille cell, indexed by column (0 left,
+// 1 right) and dot row (0 top to 3 bottom)
+var brailleDots = [2][4]rune{
+	{0x01, 0x02, 0x04, 0x40},
+	{0x08, 0x10, 0x20, 0x80},
+}
+
+// resample reduces values to at most n points by averaging equal buckets,
+// so a chart shows the whole window rather than only its newest points
+func resample(values []float64, n int) []float64 {
+	if n <= 0 || len(values) <= n {
+		return values
+	}
+	out := make([]float64, n)
+	for i := range out {
+		from, to := i*len(values)/n, (i+1)*len(values)/n
+		sum := 0.0
+		for _, v := range values[from:to] {
+			sum += v
+		}
+		out[i] = sum / float64(to-from)
+	}
+	return out
+}
+
+// brailleChart draws values as a filled area chart of height rows and at
+// most width cells. Each braille cell holds two values and four levels, so
+// the chart has twice the horizontal and four times the vertical resolution
+// of block characters. Flat series sit in the middle of the chart.
+func brailleChart(values []float64, width, height int) []string {
+	if len(values) == 0 || width <= 0 || height <= 0 {
+		return nil
+	}
+	values = resample(values, width*2)
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	levels := height * 4
+
+	cells := make([][]rune, height)
+	for r := range cells {
+		cells[r] = make([]rune, (len(values)+1)/2)
+		for c := range cells[r] {
+			cells[r][c] = 0x2800
+		}
+	}
+	for i, v := range values {
+		level := levels/2 - 1
+		if hi > lo {
+			level = int(math.Round((v - lo) / (hi - lo) * float64(levels-1)))
+		}
+		// Fill from the bottom dot row up to the value's level
+		for dot := 0; dot <= level; dot++ {
+			y := levels - 1 - dot
+			cells[y/4][i/2] |= brailleDots[i%2][y%4]
+		}
+	}
+
+	lines := make([]string, height)
+	for r, row := range cells {
+		lines[r] = string(row)
+	}
+	return lines
+}
@@ -0,0 +1,81 @@
+package status
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"tempest-homekit-go/pkg/web"
+)
+
+func TestResample(t *testing.T) {
+	if got := resample([]float64{1, 2, 3}, 5); len(got) != 3 {
+		t.Errorf("short series should be kept, got %v", got)
+	}
+	got := resample([]float64{1, 3, 5, 7, 9, 11}, 3)
+	want := []float64{2, 6, 10}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("resample = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestBrailleChart(t *testing.T) {
+	if brailleChart(nil, 10, 2) != nil {
+		t.Error("expected no chart without values")
+	}
+
+	// A rising series fills the bottom left dot and the whole right column
+	lines := brailleChart([]float64{0, 7}, 10, 2)
+	if len(lines) != 2 || lines[0] != "⢸" || lines[1] != "⣸" {
+		t.Errorf("unexpected chart: %q", lines)
+	}
+
+	// Long series are averaged down to two values per cell
+	values := make([]float64, 100)
+	for i := range values {
+		values[i] = float64(i % 10)
+	}
+	for _, line := range brailleChart(values, 8, 3) {
+		if n := utf8.RuneCountInString(line); n != 8 {
+			t.Errorf("expected 8 cells, got %d in %q", n, line)
+		}
+	}
+
+	// A flat series is drawn half way up
+	flat := brailleChart([]float64{5, 5}, 4, 2)
+	if flat[0] != "⠀" || flat[1] != "⣿" {
+		t.Errorf("unexpected flat chart: %q", flat)
+	}
+}
+
+func TestChartsText(t *testing.T) {
+	plain := func(role, text string) string { return text }
+	if got := chartsText(nil, 40, plain); !strings.Contains(got, "Waiting") {
+		t.Errorf("expected a waiting message, got %q", got)
+	}
+	history := []web.HistoryResponse{
+		{Timestamp: 0, AirTemperature: 18.2, RelativeHumidity: 60, StationPressure: 1012},
+		{Timestamp: 1800, AirTemperature: 21.5, RelativeHumidity: 55, StationPressure: 1011},
+		{Timestamp: 3600, AirTemperature: 20.1, RelativeHumidity: 58, StationPressure: 1010.5},
+	}
+	text := chartsText(history, 40, plain)
+	for _, want := range []string{"Last 1h0m0s, 3 observations", "Temperature: 20.1°C (min 18.2°C, max 21.5°C)", "Humidity: 58% (min 55%, max 60%)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("charts missing %q:\n%s", want, text)
+		}
+	}
+	if n := strings.Count(text, "\n"); n != 1+len(chartSeries)*(2+chartHeight) {
+		t.Errorf("expected %d lines, got %d", 1+len(chartSeries)*(2+chartHeight), n)
+	}
+}
+
+func TestTabs(t *testing.T) {
+	if tabIndex("logs") != 4 || tabIndex("unknown") != 0 {
+		t.Error("unexpected tab index")
+	}
+	if bar := tabBarText(); !strings.Contains(bar, `["charts"] 2 Charts [""]`) {
+		t.Errorf("unexpected tab bar %q", bar)
+	}
+}
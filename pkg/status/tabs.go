@@ -0,0 +1,107 @@
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/web"
+)
+
+// consoleTabs are the pages of the status console in tab order; keys 1-5
+// select them
+var consoleTabs = []struct{ ID, Title string }{
+	{"now", "Now"},
+	{"charts", "Charts"},
+	{"alarms", "Alarms"},
+	{"homekit", "HomeKit"},
+	{"logs", "Logs"},
+}
+
+// tabIndex returns the position of the tab with id, or 0 when unknown
+func tabIndex(id string) int {
+	for i, t := range consoleTabs {
+		if t.ID == id {
+			return i
+		}
+	}
+	return 0
+}
+
+// tabBarText renders the tab bar with one tview region per tab, so a mouse
+// click on a title selects it
+func tabBarText() string {
+	var b strings.Builder
+	for i, t := range consoleTabs {
+		fmt.Fprintf(&b, ` ["%s"] %d %s [""]`, t.ID, i+1, t.Title)
+	}
+	return b.String()
+}
+
+// chartWindow is how much history the Charts tab shows
+const chartWindow = 6 * time.Hour
+
+// chartHeight is the number of braille rows of each chart
+const chartHeight = 3
+
+// chartSeries are the /api/history fields charted in the Charts tab, in the
+// native Tempest units the history uses
+var chartSeries = []struct {
+	Label, Unit, Format string
+	Value               func(web.HistoryResponse) float64
+}{
+	{"Temperature", "°C", "%.1f", func(h web.HistoryResponse) float64 { return h.AirTemperature }},
+	{"Humidity", "%", "%.0f", func(h web.HistoryResponse) float64 { return h.RelativeHumidity }},
+	{"Pressure", " mb", "%.1f", func(h web.HistoryResponse) float64 { return h.StationPressure }},
+	{"Wind", " m/s", "%.1f", func(h web.HistoryResponse) float64 { return h.WindAvg }},
+	{"Gust", " m/s", "%.1f", func(h web.HistoryResponse) float64 { return h.WindGust }},
+	{"Rain Rate", " mm/h", "%.1f", func(h web.HistoryResponse) float64 { return h.RainRate }},
+	{"Illuminance", " lux", "%.0f", func(h web.HistoryResponse) float64 { return h.Illuminance }},
+	{"UV Index", "", "%.0f", func(h web.HistoryResponse) float64 { return float64(h.UV) }},
+}
+
+// fetchHistory fetches the observations of the last chartWindow
+func fetchHistory(baseURL string, now time.Time) ([]web.HistoryResponse, error) {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("%s/api/history?since=%d", baseURL, now.Add(-chartWindow).Unix()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("history: %s", resp.Status)
+	}
+	var history []web.HistoryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// chartsText renders a braille chart of every chartSeries for a view width
+// cells wide, each headed by its latest value and range
+func chartsText(history []web.HistoryResponse, width int, style web.ConsoleStyle) string {
+	if len(history) < 2 {
+		return style("muted", "Waiting for observations to chart...") + "\n"
+	}
+	var b strings.Builder
+	span := time.Duration(history[len(history)-1].Timestamp-history[0].Timestamp) * time.Second
+	fmt.Fprintf(&b, "%s\n", style("muted", fmt.Sprintf("Last %s, %d observations", span.Round(time.Minute), len(history))))
+	values := make([]float64, len(history))
+	for _, s := range chartSeries {
+		lo, hi := s.Value(history[0]), s.Value(history[0])
+		for i, h := range history {
+			values[i] = s.Value(h)
+			lo, hi = min(lo, values[i]), max(hi, values[i])
+		}
+		value := func(v float64) string { return fmt.Sprintf(s.Format, v) + s.Unit }
+		fmt.Fprintf(&b, "\n%s %s %s\n", style("label", s.Label+":"), style("value", value(values[len(values)-1])),
+			style("muted", "(min "+value(lo)+", max "+value(hi)+")"))
+		for _, line := range brailleChart(values, width, chartHeight) {
+			fmt.Fprintf(&b, "%s\n", style("success", line))
+		}
+	}
+	return b.String()
+}
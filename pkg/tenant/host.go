@@ -0,0 +1,327 @@
+package tenant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/websec"
+)
+
+// Restart backoff of a station process that exits; a process that ran for
+// restartReset starts over at the minimum
+const (
+	restartMin   = time.Second
+	restartMax   = time.Minute
+	restartReset = 5 * time.Minute
+	stopTimeout  = 10 * time.Second
+)
+
+// HostConfig configures a Host
+type HostConfig struct {
+	File       *File
+	Executable string   // binary started for every station, normally os.Executable()
+	Env        []string // base environment of the station processes, normally os.Environ()
+	DataDir    string   // host data directory; stations use DataDir/tenants/{name}
+	Address    string   // IP the public web server binds to; empty binds to all addresses
+	Port       string   // public web port
+	Limits     websec.Limits
+}
+
+// Status describes a station process, as listed by /api/tenants
+type Status struct {
+	Name      string    `json:"name"`
+	Station   string    `json:"station"`
+	Path      string    `json:"path"`
+	Running   bool      `json:"running"`
+	PID       int       `json:"pid,omitempty"`
+	StartedAt time.Time `json:"startedAt,omitempty"`
+	Restarts  int       `json:"restarts"`
+	LastExit  string    `json:"lastExit,omitempty"`
+}
+
+// Host supervises the station processes and proxies requests to them
+type Host struct {
+	cfg     HostConfig
+	proxies map[string]http.Handler
+
+	mu       sync.Mutex
+	statuses map[string]*Status
+}
+
+// NewHost checks cfg and prepares a proxy per station
+func NewHost(cfg HostConfig) (*Host, error) {
+	if cfg.File == nil || len(cfg.File.Tenants) == 0 {
+		return nil, fmt.Errorf("no tenants defined")
+	}
+	h := &Host{cfg: cfg, proxies: make(map[string]http.Handler), statuses: make(map[string]*Status)}
+	for _, t := range cfg.File.Tenants {
+		if strconv.Itoa(t.WebPort) == cfg.Port || strconv.Itoa(t.HomeKitPort) == cfg.Port {
+			return nil, fmt.Errorf("tenant %q uses the host's web port %s", t.Name, cfg.Port)
+		}
+		target := &url.URL{Scheme: "http", Host: net.JoinHostPort("127.0.0.1", strconv.Itoa(t.WebPort))}
+		h.proxies[t.Name] = newProxy(t, target)
+		h.statuses[t.Name] = &Status{Name: t.Name, Station: t.Station, Path: t.Path() + "/"}
+	}
+	return h, nil
+}
+
+// Run starts every station process and serves the public web port until ctx
+// is cancelled, then stops the processes
+func (h *Host) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, t := range h.cfg.File.Tenants {
+		wg.Add(1)
+		go func(t Tenant) {
+			defer wg.Done()
+			h.supervise(ctx, t)
+		}(t)
+	}
+
+	srv := &http.Server{Addr: net.JoinHostPort(h.cfg.Address, h.cfg.Port), Handler: h.Handler()}
+	errc := make(chan error, 1)
+	go func() { errc <- websec.ListenAndServe(srv, h.cfg.Limits) }()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		shutdownCtx, stop := context.WithTimeout(context.Background(), 5*time.Second)
+		err = srv.Shutdown(shutdownCtx)
+		stop()
+	}
+	cancel()
+	wg.Wait()
+	return err
+}
+
+// Statuses returns the state of every station process in file order
+func (h *Host) Statuses() []Status {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	statuses := make([]Status, 0, len(h.cfg.File.Tenants))
+	for _, t := range h.cfg.File.Tenants {
+		statuses = append(statuses, *h.statuses[t.Name])
+	}
+	return statuses
+}
+
+// Handler serves the station index at /, the process states at
+// /api/tenants and each station's console under /station/{name}/
+func (h *Host) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", h.handleIndex)
+	mux.HandleFunc("GET /api/tenants", h.handleStatuses)
+	mux.HandleFunc("/station/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := h.proxies[r.PathValue("name")]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/station/{name}/", func(w http.ResponseWriter, r *http.Request) {
+		proxy, ok := h.proxies[r.PathValue("name")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	})
+	return mux
+}
+
+// newProxy forwards /station/{name}/... to the station console with the
+// prefix stripped. Redirects the console sends to its own root-relative
+// paths get the prefix back.
+func newProxy(t Tenant, target *url.URL) http.Handler {
+	prefix := t.Path()
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.Out.URL.Path = strings.TrimPrefix(r.In.URL.Path, prefix)
+			r.Out.URL.RawPath = ""
+			r.SetURL(target)
+			r.Out.Host = r.In.Host
+			r.SetXForwarded()
+			r.Out.Header.Set("X-Forwarded-Prefix", prefix)
+		},
+		// Stream /api/stream and /api/status/stream events as they arrive
+		FlushInterval: -1,
+		ModifyResponse: func(resp *http.Response) error {
+			if loc := resp.Header.Get("Location"); strings.HasPrefix(loc, "/") && !strings.HasPrefix(loc, "//") && !strings.HasPrefix(loc, prefix+"/") {
+				resp.Header.Set("Location", prefix+loc)
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Debug("Tenant %s: proxy error: %v", t.Name, err)
+			http.Error(w, fmt.Sprintf("Station %s is starting or unavailable", t.Name), http.StatusBadGateway)
+		},
+	}
+}
+
+// stationIndex lists the stations on the host's root page
+var stationIndex = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Tempest Stations</title>
+</head>
+<body>
+    <h1>Tempest Stations</h1>
+    <ul>
+    {{- range .}}
+        <li><a href="{{.Path}}">{{.Station}}</a> ({{.Name}}){{if not .Running}}: not running{{if .LastExit}}, {{.LastExit}}{{end}}{{end}}</li>
+    {{- end}}
+    </ul>
+</body>
+</html>
+`))
+
+func (h *Host) handleIndex(w http.ResponseWriter, r *http.Request) {
+	var page bytes.Buffer
+	if err := stationIndex.Execute(&page, h.Statuses()); err != nil {
+		http.Error(w, "Index unavailable", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = page.WriteTo(w)
+}
+
+func (h *Host) handleStatuses(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(h.Statuses())
+}
+
+// supervise runs the station process until ctx is cancelled, restarting it
+// with backoff whenever it exits
+func (h *Host) supervise(ctx context.Context, t Tenant) {
+	backoff := restartMin
+	for {
+		started := time.Now()
+		err := h.runOnce(ctx, t)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) >= restartReset {
+			backoff = restartMin
+		}
+		logger.Warn("Tenant %s: station process exited (%v), restarting in %v", t.Name, err, backoff)
+
+		h.mu.Lock()
+		h.statuses[t.Name].Restarts++
+		h.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, restartMax)
+	}
+}
+
+// runOnce starts the station process and waits for it to exit, interrupting
+// it when ctx is cancelled
+func (h *Host) runOnce(ctx context.Context, t Tenant) error {
+	cmd := exec.Command(h.cfg.Executable, t.Args...)
+	cmd.Env = t.Env(h.cfg.Env, h.cfg.DataDir)
+	cmd.Stdout = newPrefixWriter(os.Stdout, "["+t.Name+"] ")
+	cmd.Stderr = newPrefixWriter(os.Stderr, "["+t.Name+"] ")
+	if err := os.MkdirAll(t.DataDir(h.cfg.DataDir), 0o755); err != nil {
+		return h.exited(t, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return h.exited(t, err)
+	}
+	logger.Info("Tenant %s: started station process %d, console at %s/", t.Name, cmd.Process.Pid, t.Path())
+
+	h.mu.Lock()
+	status := h.statuses[t.Name]
+	status.Running, status.PID, status.StartedAt, status.LastExit = true, cmd.Process.Pid, time.Now(), ""
+	h.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		return h.exited(t, err)
+	case <-ctx.Done():
+	}
+
+	// Windows cannot deliver an interrupt to another process
+	if runtime.GOOS == "windows" || cmd.Process.Signal(os.Interrupt) != nil {
+		_ = cmd.Process.Kill()
+	}
+	select {
+	case err := <-done:
+		return h.exited(t, err)
+	case <-time.After(stopTimeout):
+		logger.Warn("Tenant %s: station process did not stop within %v, killing it", t.Name, stopTimeout)
+		_ = cmd.Process.Kill()
+		return h.exited(t, <-done)
+	}
+}
+
+// exited records how the station process ended
+func (h *Host) exited(t Tenant, err error) error {
+	if err == nil {
+		err = fmt.Errorf("exit status 0")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	status := h.statuses[t.Name]
+	status.Running, status.PID, status.LastExit = false, 0, err.Error()
+	return err
+}
+
+// prefixWriter prefixes every line a station process writes with its name,
+// so the interleaved logs stay readable
+type prefixWriter struct {
+	mu     sync.Mutex
+	out    io.Writer
+	prefix []byte
+	midway bool // the last write ended without a newline
+}
+
+func newPrefixWriter(out io.Writer, prefix string) *prefixWriter {
+	return &prefixWriter{out: out, prefix: []byte(prefix)}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var buf bytes.Buffer
+	for _, line := range bytes.SplitAfter(p, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if !w.midway {
+			buf.Write(w.prefix)
+		}
+		buf.Write(line)
+		w.midway = line[len(line)-1] != '\n'
+	}
+	if _, err := w.out.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,191 @@
+// Package tenant serves several independent station consoles from one host,
+// e.g. for someone looking after family members' stations. Each station in
+// the tenants file runs as its own child process with its own data
+// directory, so history, alarms and the HomeKit bridge (pairings, setup ID
+// and port) stay separate, and the host reverse-proxies /station/{name}/ on
+// the public web port to the child's loopback web console.
+package tenant
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultBasePort is the first loopback port given to station consoles that
+// do not set web_port
+const DefaultBasePort = 18080
+
+// namePattern keeps names usable as a URL segment and a directory name
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// hostFlags are set by the host for every station and cannot be overridden
+// through args
+var hostFlags = []string{"--data-dir", "--web-port", "--web-address", "--web-base-path", "--tenants", "--disable-webconsole", "--env"}
+
+// Tenant is one station served under /station/{name}/
+type Tenant struct {
+	Name        string   `json:"name"`
+	Token       string   `json:"token,omitempty"`        // WeatherFlow token, defaults to the host's TEMPEST_TOKEN
+	Station     string   `json:"station"`                // station name, the WeatherFlow one unless args pick another data source
+	Alarms      string   `json:"alarms,omitempty"`       // @file.json or inline JSON
+	Sensors     string   `json:"sensors,omitempty"`      // HomeKit sensors, defaults to the host's SENSORS
+	Pin         string   `json:"pin,omitempty"`          // HomeKit PIN, random when empty
+	HomeKitPort int      `json:"homekit_port,omitempty"` // HAP port, a free port when 0
+	WebPort     int      `json:"web_port,omitempty"`     // loopback console port, base_port + index when 0
+	Args        []string `json:"args,omitempty"`         // further flags, e.g. ["--units", "metric"]
+}
+
+// File is the tenants file
+type File struct {
+	BasePort int      `json:"base_port,omitempty"`
+	Tenants  []Tenant `json:"tenants"`
+}
+
+// Load reads and validates a tenants file
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return file, nil
+}
+
+// Parse decodes a tenants file, assigns default web ports and checks that
+// names and ports are unique
+func Parse(data []byte) (*File, error) {
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if len(file.Tenants) == 0 {
+		return nil, fmt.Errorf("no tenants defined")
+	}
+	if file.BasePort == 0 {
+		file.BasePort = DefaultBasePort
+	}
+
+	names := make(map[string]bool)
+	ports := make(map[int]string)
+	usePort := func(port int, what string) error {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("%s %d must be between 1 and 65535", what, port)
+		}
+		if other, ok := ports[port]; ok {
+			return fmt.Errorf("%s %d is already used as %s", what, port, other)
+		}
+		ports[port] = what
+		return nil
+	}
+	for i := range file.Tenants {
+		t := &file.Tenants[i]
+		if !namePattern.MatchString(t.Name) {
+			return nil, fmt.Errorf("tenant %d: name %q must be 1-32 lowercase letters, digits or dashes", i+1, t.Name)
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("tenant %q is defined twice", t.Name)
+		}
+		names[t.Name] = true
+
+		if t.Station == "" {
+			return nil, fmt.Errorf("tenant %q: station is required", t.Name)
+		}
+		if hasFlag(t.Args, hostFlags) {
+			return nil, fmt.Errorf("tenant %q: args cannot set %s, the host sets them", t.Name, strings.Join(hostFlags, ", "))
+		}
+		if t.WebPort == 0 {
+			t.WebPort = file.BasePort + i
+		}
+		if err := usePort(t.WebPort, t.Name+" web_port"); err != nil {
+			return nil, fmt.Errorf("tenant %q: %w", t.Name, err)
+		}
+		if t.HomeKitPort != 0 {
+			if err := usePort(t.HomeKitPort, t.Name+" homekit_port"); err != nil {
+				return nil, fmt.Errorf("tenant %q: %w", t.Name, err)
+			}
+		}
+	}
+	return &file, nil
+}
+
+// hasFlag reports whether args set any of flags, as --flag, --flag=value or
+// the single-dash forms the flag package also accepts
+func hasFlag(args, flags []string) bool {
+	for _, arg := range args {
+		name, _, _ := strings.Cut(arg, "=")
+		name = "--" + strings.TrimLeft(name, "-")
+		for _, flag := range flags {
+			if name == flag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Path is the URL prefix the station is served under
+func (t Tenant) Path() string {
+	return "/station/" + t.Name
+}
+
+// DataDir is the station's data directory under the host's data directory
+func (t Tenant) DataDir(root string) string {
+	if root == "" {
+		root = "."
+	}
+	return filepath.Join(root, "tenants", t.Name)
+}
+
+// Env returns the environment of the station's process: base (the host's
+// environment) with every per-station setting replaced. Settings are passed
+// as variables rather than flags so tokens do not show up in process
+// listings; empty values are set too, so the host's .env file cannot fill
+// them in and TENANTS cannot start another host.
+func (t Tenant) Env(base []string, dataRoot string) []string {
+	settings := map[string]string{
+		"TENANTS":       "",
+		"DATA_DIR":      t.DataDir(dataRoot),
+		"WEB_PORT":      strconv.Itoa(t.WebPort),
+		"WEB_ADDRESS":   "127.0.0.1",
+		"WEB_BASE_PATH": t.Path(),
+		"ALARMS":        t.Alarms,
+		"HOMEKIT_PIN":   t.Pin,
+		"HOMEKIT_PORT":  "",
+	}
+	if t.HomeKitPort != 0 {
+		settings["HOMEKIT_PORT"] = strconv.Itoa(t.HomeKitPort)
+	}
+	if t.Token != "" {
+		settings["TEMPEST_TOKEN"] = t.Token
+	}
+	settings["TEMPEST_STATION_NAME"] = t.Station
+	if t.Sensors != "" {
+		settings["SENSORS"] = t.Sensors
+	}
+
+	env := make([]string, 0, len(base)+len(settings))
+	for _, kv := range base {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, replaced := settings[key]; !replaced {
+			env = append(env, kv)
+		}
+	}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, key+"="+settings[key])
+	}
+	return env
+}
@@ -0,0 +1,176 @@
+package tenant
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	file, err := Parse([]byte(`{"tenants": [
+		{"name": "mom", "token": "t1", "station": "Mom's House", "homekit_port": 51830},
+		{"name": "dad", "station": "Dad's", "args": ["--udp-stream"], "web_port": 19000}
+	]}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if file.Tenants[0].WebPort != DefaultBasePort || file.Tenants[1].WebPort != 19000 {
+		t.Errorf("web ports = %d, %d; want %d, 19000", file.Tenants[0].WebPort, file.Tenants[1].WebPort, DefaultBasePort)
+	}
+	if got := file.Tenants[0].Path(); got != "/station/mom" {
+		t.Errorf("Path() = %q", got)
+	}
+
+	invalid := map[string]string{
+		"empty":           `{"tenants": []}`,
+		"bad name":        `{"tenants": [{"name": "Mom House", "station": "x"}]}`,
+		"duplicate name":  `{"tenants": [{"name": "a", "station": "x"}, {"name": "a", "station": "y"}]}`,
+		"no station":      `{"tenants": [{"name": "a"}]}`,
+		"host flag":       `{"tenants": [{"name": "a", "station": "x", "args": ["-data-dir=/tmp"]}]}`,
+		"port clash":      `{"tenants": [{"name": "a", "station": "x", "homekit_port": 18081}, {"name": "b", "station": "y"}]}`,
+		"port range":      `{"tenants": [{"name": "a", "station": "x", "web_port": 70000}]}`,
+		"malformed JSON":  `{"tenants": [`,
+		"generated clash": `{"base_port": 9000, "tenants": [{"name": "a", "station": "x"}, {"name": "b", "station": "y", "web_port": 9000}]}`,
+	}
+	for name, data := range invalid {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestTenantEnv(t *testing.T) {
+	tn := Tenant{Name: "mom", Token: "secret", Station: "Mom's House", WebPort: 18080, HomeKitPort: 51830}
+	base := []string{"PATH=/bin", "TENANTS=tenants.json", "TEMPEST_TOKEN=host", "ALARMS=@host.json", "SENSORS=all"}
+	env := map[string]string{}
+	for _, kv := range tn.Env(base, "/data") {
+		key, value, _ := strings.Cut(kv, "=")
+		if _, dup := env[key]; dup {
+			t.Errorf("%s set twice", key)
+		}
+		env[key] = value
+	}
+
+	want := map[string]string{
+		"PATH":                 "/bin",
+		"TENANTS":              "",
+		"TEMPEST_TOKEN":        "secret",
+		"TEMPEST_STATION_NAME": "Mom's House",
+		"ALARMS":               "",
+		"SENSORS":              "all", // the host's default
+		"DATA_DIR":             filepath.Join("/data", "tenants", "mom"),
+		"WEB_PORT":             "18080",
+		"WEB_ADDRESS":          "127.0.0.1",
+		"WEB_BASE_PATH":        "/station/mom",
+		"HOMEKIT_PORT":         "51830",
+	}
+	for key, value := range want {
+		if got, ok := env[key]; !ok || got != value {
+			t.Errorf("%s = %q (set: %v), want %q", key, got, ok, value)
+		}
+	}
+}
+
+func TestHostProxy(t *testing.T) {
+	var gotPath, gotPrefix string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotPrefix = r.URL.Path, r.Header.Get("X-Forwarded-Prefix")
+		if r.URL.Path == "/alarm-editor" {
+			http.Redirect(w, r, "/alarm-editor/", http.StatusMovedPermanently)
+			return
+		}
+		_, _ = io.WriteString(w, "console")
+	}))
+	defer backend.Close()
+	u, _ := url.Parse(backend.URL)
+	port := u.Port()
+
+	file, err := Parse([]byte(`{"tenants": [{"name": "mom", "station": "x", "web_port": ` + port + `}]}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	host, err := NewHost(HostConfig{File: file, Port: "8080"})
+	if err != nil {
+		t.Fatalf("NewHost: %v", err)
+	}
+	front := httptest.NewServer(host.Handler())
+	defer front.Close()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	resp, err := client.Get(front.URL + "/station/mom/api/weather")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "console" {
+		t.Errorf("proxied request = %d %q", resp.StatusCode, body)
+	}
+	if gotPath != "/api/weather" || gotPrefix != "/station/mom" {
+		t.Errorf("backend saw %q with prefix %q", gotPath, gotPrefix)
+	}
+
+	resp, err = client.Get(front.URL + "/station/mom/alarm-editor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if loc := resp.Header.Get("Location"); loc != "/station/mom/alarm-editor/" {
+		t.Errorf("redirect Location = %q, want the prefixed path", loc)
+	}
+
+	resp, err = client.Get(front.URL + "/station/mom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMovedPermanently || resp.Header.Get("Location") != "/station/mom/" {
+		t.Errorf("/station/mom = %d to %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	resp, err = client.Get(front.URL + "/station/dad/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown station = %d, want 404", resp.StatusCode)
+	}
+
+	resp, err = client.Get(front.URL + "/api/tenants")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statuses []Status
+	err = json.NewDecoder(resp.Body).Decode(&statuses)
+	resp.Body.Close()
+	if err != nil || len(statuses) != 1 || statuses[0].Name != "mom" || statuses[0].Path != "/station/mom/" {
+		t.Errorf("/api/tenants = %+v, %v", statuses, err)
+	}
+}
+
+func TestNewHostRejectsPortClash(t *testing.T) {
+	file, err := Parse([]byte(`{"tenants": [{"name": "mom", "station": "x", "web_port": 8080}]}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, err := NewHost(HostConfig{File: file, Port: "8080"}); err == nil {
+		t.Error("expected an error for a station on the host's web port")
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := newPrefixWriter(&out, "[mom] ")
+	_, _ = w.Write([]byte("one\ntw"))
+	_, _ = w.Write([]byte("o\nthree\n"))
+	if want := "[mom] one\n[mom] two\n[mom] three\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
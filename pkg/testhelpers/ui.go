@@ -9,7 +9,6 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
-	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -73,7 +72,7 @@ func AssertPopoutDatasetOrdering(t *testing.T, browserCtx context.Context, ts *h
 		time.Sleep(60 * time.Millisecond)
 	}
 	if !skipNav {
-		popURL := ts.URL + "/chart/" + chartType + "?config=" + url.QueryEscape(cfgJSON)
+		popURL := ts.URL + "/chart/" + chartType
 		navJS := fmt.Sprintf("location.href=%q", popURL)
 		if err := chromedp.Run(browserCtx, chromedp.EvaluateAsDevTools(navJS, nil)); err != nil {
 			t.Fatalf("failed to initiate navigation to popout url for %s: %v", chartType, err)
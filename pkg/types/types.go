@@ -15,11 +15,19 @@ type Observation struct {
 	Illuminance          float64 `json:"illuminance"`
 	UV                   int     `json:"uv"`
 	SolarRadiation       float64 `json:"solar_radiation"`
-	RainAccumulated      float64 `json:"rain_accumulated"` // Incremental rain since last obs (from "precip" field)
-	RainDailyTotal       float64 `json:"rain_daily_total"` // Total rain since midnight (from "precip_accum_local_day" field)
+	RainAccumulated      float64 `json:"rain_accumulated"`               // Incremental rain since last obs (from "precip" field)
+	RainDailyTotal       float64 `json:"rain_daily_total"`               // Total rain since midnight (from "precip_accum_local_day" field)
+	RainAccumulatedRaw   float64 `json:"rain_accumulated_raw,omitempty"` // RainAccumulated before --rain-correction
+	RainDailyTotalRaw    float64 `json:"rain_daily_total_raw,omitempty"` // RainDailyTotal before --rain-correction
+	NCRainAccumulated    float64 `json:"nc_rain_accumulated,omitempty"`  // WeatherFlow Rain Check (nearcast) rain since last obs, API only
+	NCRainDailyTotal     float64 `json:"nc_rain_daily_total,omitempty"`  // Rain Check total since midnight (from "precip_accum_local_day_final")
+	SnowRate             float64 `json:"snow_rate,omitempty"`            // Estimated snowfall in mm/hr, from precipitation and temperature
+	SnowDailyTotal       float64 `json:"snow_daily_total,omitempty"`     // Estimated snowfall since midnight (mm)
 	PrecipitationType    int     `json:"precipitation_type"`
 	LightningStrikeAvg   float64 `json:"lightning_strike_avg_distance"`
 	LightningStrikeCount int     `json:"lightning_strike_count"`
+	LightningNearest     float64 `json:"lightning_nearest,omitempty"` // Nearest strike of the current storm (km), from the lightning tracker
+	LightningRate        float64 `json:"lightning_rate,omitempty"`    // Strikes per minute over the last 10 minutes
 	Battery              float64 `json:"battery"`
 	ReportInterval       int     `json:"report_interval"`
 }
@@ -0,0 +1,217 @@
+package udp
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// SchemaVersion is the revision of the WeatherFlow UDP reference the decoders
+// follow (https://weatherflow.github.io/Tempest/api/udp/v171/)
+const SchemaVersion = 171
+
+// obsField is one position of an observation array; set is nil for values
+// the bridge has no use for
+type obsField struct {
+	name string
+	set  func(o *weather.Observation, v float64)
+}
+
+// obsSchema lays out the observation array of one message type. Arrays
+// shorter than required are rejected; positions past the known fields come
+// from newer firmware and are reported as unknown rather than failing the
+// message.
+type obsSchema struct {
+	fields   []obsField
+	required int
+}
+
+func setFloat(set func(o *weather.Observation) *float64) func(*weather.Observation, float64) {
+	return func(o *weather.Observation, v float64) { *set(o) = v }
+}
+
+func setInt(set func(o *weather.Observation) *int) func(*weather.Observation, float64) {
+	return func(o *weather.Observation, v float64) { *set(o) = int(v) }
+}
+
+var (
+	timestampField      = obsField{"timestamp", func(o *weather.Observation, v float64) { o.Timestamp = int64(v) }}
+	windLullField       = obsField{"wind_lull", setFloat(func(o *weather.Observation) *float64 { return &o.WindLull })}
+	windAvgField        = obsField{"wind_avg", setFloat(func(o *weather.Observation) *float64 { return &o.WindAvg })}
+	windGustField       = obsField{"wind_gust", setFloat(func(o *weather.Observation) *float64 { return &o.WindGust })}
+	windDirectionField  = obsField{"wind_direction", setFloat(func(o *weather.Observation) *float64 { return &o.WindDirection })}
+	windSampleField     = obsField{"wind_sample_interval", nil}
+	pressureField       = obsField{"station_pressure", setFloat(func(o *weather.Observation) *float64 { return &o.StationPressure })}
+	temperatureField    = obsField{"air_temperature", setFloat(func(o *weather.Observation) *float64 { return &o.AirTemperature })}
+	humidityField       = obsField{"relative_humidity", setFloat(func(o *weather.Observation) *float64 { return &o.RelativeHumidity })}
+	illuminanceField    = obsField{"illuminance", setFloat(func(o *weather.Observation) *float64 { return &o.Illuminance })}
+	uvField             = obsField{"uv", setInt(func(o *weather.Observation) *int { return &o.UV })}
+	solarField          = obsField{"solar_radiation", setFloat(func(o *weather.Observation) *float64 { return &o.SolarRadiation })}
+	rainField           = obsField{"rain_accumulated", setFloat(func(o *weather.Observation) *float64 { return &o.RainAccumulated })}
+	precipTypeField     = obsField{"precipitation_type", setInt(func(o *weather.Observation) *int { return &o.PrecipitationType })}
+	strikeDistanceField = obsField{"lightning_strike_avg_distance", setFloat(func(o *weather.Observation) *float64 { return &o.LightningStrikeAvg })}
+	strikeCountField    = obsField{"lightning_strike_count", setInt(func(o *weather.Observation) *int { return &o.LightningStrikeCount })}
+	batteryField        = obsField{"battery", setFloat(func(o *weather.Observation) *float64 { return &o.Battery })}
+	reportIntervalField = obsField{"report_interval", setInt(func(o *weather.Observation) *int { return &o.ReportInterval })}
+)
+
+// obsSchemas are the observation layouts of SchemaVersion
+var obsSchemas = map[MessageType]obsSchema{
+	TypeObservationST: {required: 18, fields: []obsField{
+		timestampField, windLullField, windAvgField, windGustField, windDirectionField, windSampleField, pressureField, temperatureField,
+		humidityField, illuminanceField, uvField, solarField, rainField, precipTypeField, strikeDistanceField, strikeCountField, batteryField, reportIntervalField,
+	}},
+	TypeObservationAir: {required: 8, fields: []obsField{
+		timestampField, pressureField, temperatureField, humidityField, strikeCountField, strikeDistanceField, batteryField, reportIntervalField,
+	}},
+	TypeObservationSky: {required: 14, fields: []obsField{
+		timestampField, illuminanceField, uvField, rainField, windLullField, windAvgField, windGustField, windDirectionField, batteryField,
+		reportIntervalField, solarField, {"local_day_rain_accumulation", nil}, precipTypeField, windSampleField,
+	}},
+}
+
+// decodeReport lists what a decoder could not use; the listener adds it to
+// its parser statistics
+type decodeReport struct {
+	unknown []string // fields this schema does not know, e.g. "obs_st[18]"
+	invalid []string // known fields holding something other than a number
+	nulls   int      // known fields sent as null
+}
+
+// numbers reads values positionally by names into out. Nulls and values that
+// are not numbers are left at zero and reported; values past names are
+// reported as unknown.
+func numbers(t MessageType, values []interface{}, names []string, report *decodeReport) []float64 {
+	out := make([]float64, len(names))
+	for i, v := range values {
+		if i >= len(names) {
+			report.unknown = append(report.unknown, fmt.Sprintf("%s[%d]", t, i))
+			continue
+		}
+		switch n := v.(type) {
+		case float64:
+			out[i] = n
+		case nil:
+			report.nulls++
+		default:
+			report.invalid = append(report.invalid, fmt.Sprintf("%s.%s", t, names[i]))
+		}
+	}
+	return out
+}
+
+// decodeObservation decodes the observation array of an obs_st, obs_air or
+// obs_sky message using the schema of t
+func decodeObservation(t MessageType, msg UDPMessage) (weather.Observation, decodeReport, error) {
+	var obs weather.Observation
+	var report decodeReport
+	schema, ok := obsSchemas[t]
+	if !ok {
+		return obs, report, fmt.Errorf("no observation schema for %s", t)
+	}
+	if len(msg.Obs) == 0 || len(msg.Obs[0]) < schema.required {
+		return obs, report, fmt.Errorf("%s has %d values, want at least %d", t, valueCount(msg.Obs), schema.required)
+	}
+	if msg.Obs[0][0] == nil {
+		return obs, report, fmt.Errorf("%s has no timestamp", t)
+	}
+
+	names := make([]string, len(schema.fields))
+	for i, f := range schema.fields {
+		names[i] = f.name
+	}
+	values := numbers(t, msg.Obs[0], names, &report)
+	for i, f := range schema.fields {
+		if f.set != nil {
+			f.set(&obs, values[i])
+		}
+	}
+	return obs, report, nil
+}
+
+func valueCount(obs [][]interface{}) int {
+	if len(obs) == 0 {
+		return 0
+	}
+	return len(obs[0])
+}
+
+// RapidWind is a decoded rapid_wind message, sent every 3 seconds
+type RapidWind struct {
+	Timestamp int64
+	Speed     float64 // m/s
+	Direction int     // degrees
+}
+
+// decodeRapidWind decodes a rapid_wind message
+func decodeRapidWind(msg UDPMessage) (RapidWind, decodeReport, error) {
+	var report decodeReport
+	if len(msg.Ob) < 3 {
+		return RapidWind{}, report, fmt.Errorf("rapid_wind has %d values, want 3", len(msg.Ob))
+	}
+	v := numbers(TypeRapidWind, msg.Ob, []string{"timestamp", "wind_speed", "wind_direction"}, &report)
+	return RapidWind{Timestamp: int64(v[0]), Speed: v[1], Direction: int(v[2])}, report, nil
+}
+
+// Strike is a decoded evt_strike message
+type Strike struct {
+	Timestamp int64
+	Distance  float64 // km
+	Energy    float64
+}
+
+// decodeStrike decodes an evt_strike message
+func decodeStrike(msg UDPMessage) (Strike, decodeReport, error) {
+	var report decodeReport
+	if len(msg.Evt) < 3 {
+		return Strike{}, report, fmt.Errorf("evt_strike has %d values, want 3", len(msg.Evt))
+	}
+	v := numbers(TypeLightning, msg.Evt, []string{"timestamp", "distance", "energy"}, &report)
+	return Strike{Timestamp: int64(v[0]), Distance: v[1], Energy: v[2]}, report, nil
+}
+
+// decodeRainStart decodes an evt_precip message into its timestamp
+func decodeRainStart(msg UDPMessage) (int64, decodeReport, error) {
+	var report decodeReport
+	if len(msg.Evt) < 1 {
+		return 0, report, fmt.Errorf("evt_precip has no timestamp")
+	}
+	v := numbers(TypeRainStart, msg.Evt, []string{"timestamp"}, &report)
+	return int64(v[0]), report, nil
+}
+
+// knownTypes are the message types of SchemaVersion
+var knownTypes = map[MessageType]bool{
+	TypeObservationST: true, TypeObservationAir: true, TypeObservationSky: true, TypeRapidWind: true,
+	TypeRainStart: true, TypeLightning: true, TypeDeviceStatus: true, TypeHubStatus: true,
+}
+
+// knownKeys are the top-level keys UDPMessage decodes
+var knownKeys = func() map[string]bool {
+	keys := map[string]bool{}
+	t := reflect.TypeOf(UDPMessage{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		keys[name] = true
+	}
+	return keys
+}()
+
+// unknownKeys returns the top-level keys of a message that UDPMessage does
+// not decode, e.g. "hub_status.new_key"
+func unknownKeys(t MessageType, data []byte) []string {
+	var raw map[string]json.RawMessage
+	if json.Unmarshal(data, &raw) != nil {
+		return nil
+	}
+	var unknown []string
+	for key := range raw {
+		if !knownKeys[key] {
+			unknown = append(unknown, fmt.Sprintf("%s.%s", t, key))
+		}
+	}
+	return unknown
+}
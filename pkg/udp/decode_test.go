@@ -0,0 +1,113 @@
+package udp
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestDecodeObservationToleratesNewFirmware(t *testing.T) {
+	l := NewUDPListener(10)
+
+	// A future obs_st with a 19th value, a new top-level key, a null
+	// illuminance and a string UV
+	obs := createObsST(time.Now().Unix())
+	obs[0][9] = nil
+	obs[0][10] = "3"
+	obs[0] = append(obs[0], 42.0)
+	data, _ := json.Marshal(map[string]interface{}{
+		"serial_number": "ST-1", "type": "obs_st", "hub_sn": "HB-1", "obs": obs, "firmware_revision": 200, "air_quality": 12,
+	})
+	l.processPacket(data, "")
+
+	latest := l.GetLatestObservation()
+	if latest == nil || latest.AirTemperature != 20.5 || latest.Illuminance != 0 || latest.UV != 0 {
+		t.Fatalf("observation = %+v, want it kept with the unreadable values at zero", latest)
+	}
+	stats := l.GetParserStats()
+	if stats.SchemaVersion != SchemaVersion || stats.Messages["obs_st"] != 1 || stats.NullValues != 1 {
+		t.Errorf("parser stats = %+v", stats)
+	}
+	if stats.UnknownFields["obs_st[18]"] != 1 || stats.UnknownFields["obs_st.air_quality"] != 1 || stats.InvalidValues["obs_st.uv"] != 1 {
+		t.Errorf("unknown fields %v, invalid values %v", stats.UnknownFields, stats.InvalidValues)
+	}
+}
+
+func TestDecodeErrorsAndUnknownTypes(t *testing.T) {
+	l := NewUDPListener(10)
+	for _, raw := range []string{
+		`{"serial_number":"ST-1","type":"obs_st","obs":[[1700000000,1,2]]}`,
+		`{"serial_number":"ST-1","type":"obs_st","obs":[[null,0,1,2,90,3,1012,20,50,100,3,0,0,0,5,1,2.7,1]]}`,
+		`{"serial_number":"ST-1","type":"rapid_wind","ob":[1700000000]}`,
+		`{"serial_number":"ST-1","type":"evt_strike","evt":[]}`,
+		`{"serial_number":"ST-1","type":"evt_precip","evt":[]}`,
+		`not json`,
+		`{"serial_number":"ST-1","type":"obs_aq","obs":[[1700000000,12]]}`,
+		`{"serial_number":"ST-1","type":"obs_aq","obs":[[1700000060,13]]}`,
+	} {
+		l.processPacket([]byte(raw), "")
+	}
+
+	stats := l.GetParserStats()
+	if stats.Errors != 6 || stats.UnknownTypes["obs_aq"] != 2 || len(stats.Messages) != 0 {
+		t.Errorf("parser stats = %+v", stats)
+	}
+	if len(l.GetObservations()) != 0 {
+		t.Error("a message that failed to decode was used")
+	}
+}
+
+func TestDecodeEvents(t *testing.T) {
+	l := NewUDPListener(10)
+	for _, raw := range []string{
+		`{"serial_number":"ST-1","type":"rapid_wind","ob":[1700000000,2.3,128]}`,
+		`{"serial_number":"ST-1","type":"evt_strike","evt":[1700000000,27,3848]}`,
+		`{"serial_number":"ST-1","type":"evt_precip","evt":[1700000000]}`,
+		`{"serial_number":"HB-1","type":"hub_status","firmware_revision":"177","uptime":1,"rssi":-62,"timestamp":1700000000,"reset_flags":"BOR,PIN","seq":1,"fs":[1,0,15675411,524288],"radio_stats":[25,1,0,3,2839],"mqtt_stats":[1,0]}`,
+	} {
+		l.processPacket([]byte(raw), "")
+	}
+
+	stats := l.GetParserStats()
+	for _, typ := range []string{"rapid_wind", "evt_strike", "evt_precip", "hub_status"} {
+		if stats.Messages[typ] != 1 {
+			t.Errorf("%s decoded %d times", typ, stats.Messages[typ])
+		}
+	}
+	if stats.Errors != 0 || len(stats.UnknownFields) != 0 {
+		t.Errorf("parser stats = %+v", stats)
+	}
+}
+
+func TestParserStatsAreBounded(t *testing.T) {
+	l := NewUDPListener(10)
+	for i := 0; i < maxParserKeys+10; i++ {
+		l.recordUnknownType(UDPMessage{Type: MessageType(time.Duration(i).String())})
+	}
+	stats := l.GetParserStats()
+	if len(stats.UnknownTypes) != maxParserKeys+1 || stats.UnknownTypes["other"] != 10 {
+		t.Errorf("%d unknown types, %d other", len(stats.UnknownTypes), stats.UnknownTypes["other"])
+	}
+}
+
+func TestRapidWindCallback(t *testing.T) {
+	l := NewUDPListener(10)
+	var samples []weather.WindSample
+	l.SetRapidWindCallback(func(s weather.WindSample) { samples = append(samples, s) })
+	l.processPacket([]byte(`{"serial_number":"ST-1","type":"rapid_wind","ob":[1700000000,7.4,215]}`), "")
+	if len(samples) != 1 || samples[0] != (weather.WindSample{Timestamp: 1700000000, Speed: 7.4, Direction: 215}) {
+		t.Errorf("samples = %+v", samples)
+	}
+}
+
+func TestStrikeCallback(t *testing.T) {
+	l := NewUDPListener(10)
+	var strikes []weather.LightningStrike
+	l.SetStrikeCallback(func(s weather.LightningStrike) { strikes = append(strikes, s) })
+	l.processPacket([]byte(`{"serial_number":"ST-1","type":"evt_strike","evt":[1700000000,27,3848]}`), "")
+	if len(strikes) != 1 || strikes[0] != (weather.LightningStrike{Timestamp: 1700000000, Distance: 27, Energy: 3848}) {
+		t.Errorf("strikes = %+v", strikes)
+	}
+}
@@ -0,0 +1,182 @@
+package udp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// Station selects a device by serial number for --udp-serial, optionally
+// with a display name
+type Station struct {
+	Serial string
+	Name   string
+}
+
+// ParseStations parses --udp-serial: serial numbers separated by commas, each
+// optionally followed by =name, e.g. "ST-00012345=Backyard,ST-00054321"
+func ParseStations(spec string) ([]Station, error) {
+	var stations []Station
+	seen := map[string]bool{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		serial, name, _ := strings.Cut(part, "=")
+		serial, name = strings.ToUpper(strings.TrimSpace(serial)), strings.TrimSpace(name)
+		if serial == "" || strings.ContainsAny(serial, " \t") {
+			return nil, fmt.Errorf("invalid serial number %q", part)
+		}
+		if seen[serial] {
+			return nil, fmt.Errorf("serial number %s is listed twice", serial)
+		}
+		seen[serial] = true
+		stations = append(stations, Station{Serial: serial, Name: name})
+	}
+	if len(stations) == 0 && strings.TrimSpace(spec) != "" {
+		return nil, fmt.Errorf("no serial numbers in %q", spec)
+	}
+	return stations, nil
+}
+
+// SetStations restricts the listener to the given devices: messages from
+// other serial numbers are counted in GetDevices but not used. Hub status is
+// taken only from hubs relaying a selected device. List an AIR and a SKY
+// together to combine them into one station. Without stations the first
+// Tempest heard is used and other Tempests are ignored.
+func (l *UDPListener) SetStations(stations []Station) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stations = nil
+	if len(stations) > 0 {
+		l.stations = make(map[string]string, len(stations))
+		for _, s := range stations {
+			l.stations[strings.ToUpper(s.Serial)] = s.Name
+		}
+	}
+}
+
+// deviceType names the kind of device from its serial number prefix
+func deviceType(serial string, t MessageType) string {
+	switch {
+	case t == TypeHubStatus || strings.HasPrefix(serial, "HB-"):
+		return "hub"
+	case strings.HasPrefix(serial, "AR-"):
+		return "air"
+	case strings.HasPrefix(serial, "SK-"):
+		return "sky"
+	}
+	return "tempest"
+}
+
+// track records the message in the per-device statistics and reports whether
+// its device is ignored. With --udp-serial only the listed devices (and their
+// hubs) are used; otherwise AIR and SKY devices are all used but only the
+// first Tempest, so two Tempests on one network are not interleaved.
+func (l *UDPListener) track(msg UDPMessage, ip string) (ignored bool) {
+	if msg.SerialNumber == "" {
+		return false
+	}
+	serial := strings.ToUpper(msg.SerialNumber)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.devices == nil {
+		l.devices = make(map[string]*weather.UDPDevice)
+		l.hubs = make(map[string]bool)
+	}
+	d := l.devices[serial]
+	if d == nil {
+		d = &weather.UDPDevice{Serial: serial, Type: deviceType(serial, msg.Type)}
+		l.devices[serial] = d
+	}
+	d.Name = l.stations[serial]
+	d.Packets++
+	d.LastPacket = time.Now()
+	if ip != "" {
+		d.IP = ip
+	}
+	if msg.HubSN != "" {
+		d.HubSerial = strings.ToUpper(msg.HubSN)
+	}
+	if msg.FirmwareRevision != 0 {
+		d.Firmware = int(msg.FirmwareRevision)
+	}
+	switch msg.Type {
+	case TypeDeviceStatus:
+		d.Voltage, d.RSSI = msg.Voltage, msg.RSSI
+	case TypeHubStatus:
+		d.RSSI = msg.RSSI
+	}
+
+	wasIgnored := d.Ignored
+	switch {
+	case d.Type == "hub":
+		_, listed := l.stations[serial]
+		d.Ignored = len(l.hubs) > 0 && !l.hubs[serial] && !listed
+	case l.stations != nil:
+		_, listed := l.stations[serial]
+		d.Ignored = !listed
+	case d.Type == "tempest":
+		if l.tempest == "" {
+			l.tempest = serial
+		}
+		d.Ignored = serial != l.tempest
+	default:
+		d.Ignored = false
+	}
+	if !d.Ignored && d.HubSerial != "" {
+		l.hubs[d.HubSerial] = true
+	}
+	if d.Ignored && !wasIgnored {
+		if l.stations != nil {
+			logger.Info("Ignoring UDP broadcasts from %s %s (not in --udp-serial)", d.Type, serial)
+		} else {
+			logger.Warn("Ignoring UDP broadcasts from a second Tempest %s, using %s; choose with --udp-serial", serial, l.tempest)
+		}
+	}
+	return d.Ignored
+}
+
+// observe records obs as its device's latest observation and adds it to the
+// station history unless the device is ignored
+func (l *UDPListener) observe(serial string, obs weather.Observation) {
+	l.mu.Lock()
+	serial = strings.ToUpper(serial)
+	d := l.devices[serial]
+	if d != nil {
+		d.Observations++
+		latest := obs
+		d.LastObservation = &latest
+	}
+	l.recordLink(serial, obs, time.Now())
+	ignored := d != nil && d.Ignored
+	l.mu.Unlock()
+
+	if !ignored {
+		l.addObservation(obs)
+	}
+}
+
+// GetDevices returns every device heard, sorted by serial number, with its
+// packet counts, signal and latest observation
+func (l *UDPListener) GetDevices() []weather.UDPDevice {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	devices := make([]weather.UDPDevice, 0, len(l.devices))
+	for _, d := range l.devices {
+		device := *d
+		if d.LastObservation != nil {
+			obs := *d.LastObservation
+			device.LastObservation = &obs
+		}
+		devices = append(devices, device)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Serial < devices[j].Serial })
+	return devices
+}
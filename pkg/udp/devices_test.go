@@ -0,0 +1,99 @@
+package udp
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func obsMessage(t *testing.T, serial, hub string, temp float64) []byte {
+	t.Helper()
+	obs := createObsST(time.Now().Unix())
+	obs[0][7] = temp
+	data, err := json.Marshal(UDPMessage{Type: TypeObservationST, SerialNumber: serial, HubSN: hub, Obs: obs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseStations(t *testing.T) {
+	stations, err := ParseStations("ST-00012345=Backyard, st-00054321")
+	if err != nil {
+		t.Fatalf("ParseStations: %v", err)
+	}
+	if len(stations) != 2 || stations[0] != (Station{"ST-00012345", "Backyard"}) || stations[1] != (Station{"ST-00054321", ""}) {
+		t.Errorf("ParseStations = %+v", stations)
+	}
+	if stations, err := ParseStations(""); err != nil || stations != nil {
+		t.Errorf("empty spec = %+v, %v", stations, err)
+	}
+	for _, bad := range []string{",", "ST-1,ST-1", "ST 1", "=Roof"} {
+		if _, err := ParseStations(bad); err == nil {
+			t.Errorf("ParseStations(%q) accepted", bad)
+		}
+	}
+}
+
+func TestSecondTempestIsIgnored(t *testing.T) {
+	l := NewUDPListener(10)
+	l.processPacket(obsMessage(t, "ST-A", "HB-1", 20), "192.0.2.10")
+	l.processPacket(obsMessage(t, "ST-B", "HB-2", 30), "192.0.2.20")
+	l.processPacket(obsMessage(t, "ST-A", "HB-1", 21), "192.0.2.10")
+
+	if got := len(l.GetObservations()); got != 2 {
+		t.Fatalf("history has %d observations, want 2 from ST-A", got)
+	}
+	if obs := l.GetLatestObservation(); obs.AirTemperature != 21 {
+		t.Errorf("latest temperature %.1f, want 21 from ST-A", obs.AirTemperature)
+	}
+	if _, _, ip, serial := l.GetStats(); ip != "192.0.2.10" || serial != "ST-A" {
+		t.Errorf("stats ip=%q serial=%q", ip, serial)
+	}
+
+	devices := l.GetDevices()
+	if len(devices) != 2 {
+		t.Fatalf("GetDevices = %+v", devices)
+	}
+	a, b := devices[0], devices[1]
+	if a.Serial != "ST-A" || a.Ignored || a.Packets != 2 || a.Observations != 2 || a.HubSerial != "HB-1" {
+		t.Errorf("ST-A = %+v", a)
+	}
+	if b.Serial != "ST-B" || !b.Ignored || b.IP != "192.0.2.20" || b.LastObservation == nil || b.LastObservation.AirTemperature != 30 {
+		t.Errorf("ST-B = %+v", b)
+	}
+}
+
+func TestSetStationsSelectsDevices(t *testing.T) {
+	l := NewUDPListener(10)
+	l.SetStations([]Station{{Serial: "st-b", Name: "Roof"}})
+
+	l.processPacket(obsMessage(t, "ST-A", "HB-1", 20), "192.0.2.10")
+	l.processPacket(obsMessage(t, "ST-B", "HB-2", 30), "192.0.2.20")
+	for _, hub := range []string{"HB-1", "HB-2"} {
+		data, _ := json.Marshal(UDPMessage{Type: TypeHubStatus, SerialNumber: hub, Timestamp: time.Now().Unix(), Seq: 1})
+		l.processPacket(data, "")
+	}
+	data, _ := json.Marshal(UDPMessage{Type: TypeDeviceStatus, SerialNumber: "ST-A", Voltage: 2.4})
+	l.processPacket(data, "")
+
+	if obs := l.GetLatestObservation(); obs == nil || obs.AirTemperature != 30 || len(l.GetObservations()) != 1 {
+		t.Fatalf("expected only the ST-B observation, got %+v", l.GetObservations())
+	}
+	if l.GetDeviceStatus() != nil {
+		t.Error("device status of an ignored device was used")
+	}
+	hub, ok := l.GetHubStatus().(map[string]interface{})
+	if !ok || hub["serial_number"] != "HB-2" {
+		t.Errorf("hub status = %v, want HB-2 relaying ST-B", l.GetHubStatus())
+	}
+	for _, d := range l.GetDevices() {
+		want := d.Serial == "HB-1" || d.Serial == "ST-A"
+		if d.Ignored != want {
+			t.Errorf("%s ignored = %v", d.Serial, d.Ignored)
+		}
+		if d.Serial == "ST-B" && d.Name != "Roof" {
+			t.Errorf("ST-B name = %q", d.Name)
+		}
+	}
+}
@@ -3,9 +3,13 @@
 package udp
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"net"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,7 +18,8 @@ import (
 )
 
 const (
-	// UDPPort is the port that Tempest hubs broadcast on
+	// UDPPort is the port that Tempest hubs broadcast on, and the default
+	// listening port
 	UDPPort = 50222
 )
 
@@ -86,11 +91,13 @@ type UDPMessage struct {
 	Seq        int           `json:"seq,omitempty"`
 	RadioStats []interface{} `json:"radio_stats,omitempty"`
 	MqttStats  []interface{} `json:"mqtt_stats,omitempty"`
+	FS         []interface{} `json:"fs,omitempty"` // internal file system use
 }
 
 // UDPListener listens for UDP broadcasts from a Tempest weather station
 type UDPListener struct {
 	conn            *net.UDPConn
+	port            int
 	observations    []weather.Observation
 	maxHistorySize  int
 	latestObs       *weather.Observation
@@ -104,7 +111,21 @@ type UDPListener struct {
 	observationChan chan weather.Observation
 	stopChan        chan struct{}
 	running         bool
-	packetCallback  func([]byte) // Callback for raw packet data
+	packetCallback  func([]byte)                  // Callback for raw packet data
+	windCallback    func(weather.WindSample)      // Callback for rapid_wind samples
+	strikeCallback  func(weather.LightningStrike) // Callback for evt_strike events
+	dropRate        float64                       // Share of packets discarded on arrival (--inject-udp-drop)
+	stations        map[string]string             // --udp-serial: selected serial numbers and their names; nil selects all
+	devices         map[string]*weather.UDPDevice // every device heard, by serial number
+	hubs            map[string]bool               // hubs relaying a selected device
+	tempest         string                        // Tempest used when no stations are selected
+	relay           *Relay                        // --udp-relay: where received packets are sent on
+	socket          SocketOptions                 // network, interface and multicast groups of the socket
+	links           map[string]*linkTracker       // observation loss and jitter, by serial number
+	raw             []RawPacket                   // last received packets, for debugging (ring buffer)
+	rawSize         int                           // capacity of raw; 0 keeps no packets
+	rawNext         int                           // next slot of raw to overwrite once full
+	parser          parserStats                   // decoded messages and what the decoders did not know
 }
 
 // DeviceStatus holds device status information
@@ -142,6 +163,7 @@ func NewUDPListener(maxHistorySize int) *UDPListener {
 	}
 
 	return &UDPListener{
+		port:            UDPPort,
 		observations:    make([]weather.Observation, 0, maxHistorySize),
 		maxHistorySize:  maxHistorySize,
 		observationChan: make(chan weather.Observation, 100),
@@ -157,6 +179,48 @@ func (l *UDPListener) SetPacketCallback(callback func([]byte)) {
 	l.packetCallback = callback
 }
 
+// SetRapidWindCallback sets a callback function that will be called with the
+// wind sample of each rapid_wind message from a device that is not ignored
+func (l *UDPListener) SetRapidWindCallback(callback func(weather.WindSample)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.windCallback = callback
+}
+
+// SetStrikeCallback sets a callback function that will be called with each
+// evt_strike lightning strike from a device that is not ignored
+func (l *UDPListener) SetStrikeCallback(callback func(weather.LightningStrike)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.strikeCallback = callback
+}
+
+// SetDropRate discards the given share (0 to 1) of received packets before
+// they are counted or parsed, simulating a lossy network for chaos testing
+func (l *UDPListener) SetDropRate(rate float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.dropRate = rate
+}
+
+// SetPort changes the port Start listens on, e.g. when a relay sends the
+// broadcasts to another port; call it before Start
+func (l *UDPListener) SetPort(port int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if port > 0 {
+		l.port = port
+	}
+}
+
+// SetRelay sends every received packet on through r (see Relay). Packets the
+// relay itself sent are ignored so a re-broadcast cannot loop.
+func (l *UDPListener) SetRelay(r *Relay) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.relay = r
+}
+
 // Start begins listening for UDP broadcasts
 func (l *UDPListener) Start() error {
 	l.mu.Lock()
@@ -165,23 +229,25 @@ func (l *UDPListener) Start() error {
 		return fmt.Errorf("UDP listener already running")
 	}
 	l.running = true
+	port, opts := l.port, l.socket
 	l.mu.Unlock()
 
-	addr := net.UDPAddr{
-		Port: UDPPort,
-		IP:   net.ParseIP("0.0.0.0"),
-	}
-
-	conn, err := net.ListenUDP("udp", &addr)
+	conn, err := listenUDP(port, opts)
 	if err != nil {
 		l.mu.Lock()
 		l.running = false
 		l.mu.Unlock()
-		return fmt.Errorf("failed to start UDP listener on port %d: %v", UDPPort, err)
+		return fmt.Errorf("failed to start UDP listener on port %d: %v", port, err)
 	}
 
 	l.conn = conn
-	logger.Info("UDP listener started on port %d", UDPPort)
+	logger.Info("UDP listener started on %s", conn.LocalAddr())
+	if opts.Interface != "" {
+		logger.Info("UDP listener bound to interface %s", opts.Interface)
+	}
+	for _, group := range opts.Groups {
+		logger.Info("UDP listener joined multicast group %s", group)
+	}
 
 	// Start listening in a goroutine
 	go l.listen()
@@ -189,6 +255,35 @@ func (l *UDPListener) Start() error {
 	return nil
 }
 
+// listenUDP opens the listening socket on all addresses of the network
+func listenUDP(port int, opts SocketOptions) (*net.UDPConn, error) {
+	network := opts.Network
+	if network == "" {
+		network = "udp"
+	}
+	lc := net.ListenConfig{Control: socketControl(opts.Interface)}
+	pc, err := lc.ListenPacket(context.Background(), network, net.JoinHostPort("", strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	conn := pc.(*net.UDPConn)
+	if err := joinGroups(conn, opts); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// CheckPort opens and closes the socket Start would open, so --doctor can
+// report a port it cannot bind without starting a listener
+func CheckPort(port int, opts SocketOptions) error {
+	conn, err := listenUDP(port, opts)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
 // Stop stops the UDP listener
 func (l *UDPListener) Stop() error {
 	l.mu.Lock()
@@ -229,22 +324,41 @@ func (l *UDPListener) listen() {
 				continue
 			}
 
-			// Update packet statistics
-			l.mu.Lock()
-			l.packetCount++
-			l.lastPacketTime = time.Now()
-			if l.stationIP == "" && remoteAddr != nil {
-				l.stationIP = remoteAddr.IP.String()
-				logger.Info("Detected Tempest station at IP: %s", l.stationIP)
-			}
-			l.mu.Unlock()
-
-			// Process the message
-			l.processMessage(buffer[:n])
+			l.handlePacket(buffer[:n], remoteAddr)
 		}
 	}
 }
 
+// handlePacket counts and processes one received packet
+func (l *UDPListener) handlePacket(data []byte, remoteAddr *net.UDPAddr) {
+	// Update packet statistics
+	l.mu.Lock()
+	relay := l.relay
+	if relay != nil && relay.own(remoteAddr) {
+		l.mu.Unlock()
+		return
+	}
+	if l.dropRate > 0 && rand.Float64() < l.dropRate {
+		l.mu.Unlock()
+		logger.Debug("Dropping UDP packet (injected loss)")
+		return
+	}
+	l.packetCount++
+	l.lastPacketTime = time.Now()
+	l.mu.Unlock()
+
+	if relay != nil {
+		relay.Forward(data)
+	}
+
+	// Process the message
+	ip := ""
+	if remoteAddr != nil {
+		ip = remoteAddr.IP.String()
+	}
+	l.processPacket(data, ip)
+}
+
 // PrettyPrintMessage formats a UDP message for human-readable output
 func PrettyPrintMessage(data []byte) string {
 	var msg UDPMessage
@@ -255,69 +369,35 @@ func PrettyPrintMessage(data []byte) string {
 	ts := time.Now().Format("15:04:05")
 	switch msg.Type {
 	case TypeObservationST:
-		if len(msg.Obs) > 0 && len(msg.Obs[0]) >= 18 {
-			obs := msg.Obs[0]
-			temp := obs[7].(float64)
-			humidity := obs[8].(float64)
-			pressure := obs[6].(float64)
-			windAvg := obs[2].(float64)
-			windGust := obs[3].(float64)
-			windDir := obs[4].(float64)
-			rain := obs[12].(float64)
-			uv := int(obs[10].(float64))
-			lux := obs[9].(float64)
-			lightningCount := int(obs[15].(float64))
-			lightningDist := obs[14].(float64)
-			battery := obs[16].(float64)
+		if o, _, err := decodeObservation(msg.Type, msg); err == nil {
 			return fmt.Sprintf("[%s] 🌡️  obs_st | Temp: %.1f°C | Humidity: %.0f%% | Pressure: %.1fmb | Wind: %.1f/%.1fm/s@%.0f° | UV: %d | Lux: %.0f | Rain: %.2fmm | Lightning: %d@%.0fkm | Battery: %.2fV | Serial: %s",
-				ts, temp, humidity, pressure, windAvg, windGust, windDir, uv, lux, rain, lightningCount, lightningDist, battery, msg.SerialNumber)
+				ts, o.AirTemperature, o.RelativeHumidity, o.StationPressure, o.WindAvg, o.WindGust, o.WindDirection, o.UV, o.Illuminance,
+				o.RainAccumulated, o.LightningStrikeCount, o.LightningStrikeAvg, o.Battery, msg.SerialNumber)
 		}
 	case TypeObservationAir:
-		if len(msg.Obs) > 0 && len(msg.Obs[0]) >= 8 {
-			obs := msg.Obs[0]
-			temp := obs[2].(float64)
-			humidity := obs[3].(float64)
-			pressure := obs[1].(float64)
-			lightningCount := int(obs[4].(float64))
-			lightningDist := obs[5].(float64)
-			battery := obs[6].(float64)
+		if o, _, err := decodeObservation(msg.Type, msg); err == nil {
 			return fmt.Sprintf("[%s] 🌡️  obs_air | Temp: %.1f°C | Humidity: %.0f%% | Pressure: %.1fmb | Lightning: %d@%.0fkm | Battery: %.2fV | Serial: %s",
-				ts, temp, humidity, pressure, lightningCount, lightningDist, battery, msg.SerialNumber)
+				ts, o.AirTemperature, o.RelativeHumidity, o.StationPressure, o.LightningStrikeCount, o.LightningStrikeAvg, o.Battery, msg.SerialNumber)
 		}
 	case TypeObservationSky:
-		if len(msg.Obs) > 0 && len(msg.Obs[0]) >= 14 {
-			obs := msg.Obs[0]
-			windAvg := obs[5].(float64)
-			windGust := obs[6].(float64)
-			windDir := obs[7].(float64)
-			rain := obs[3].(float64)
-			uv := int(obs[2].(float64))
-			lux := obs[1].(float64)
-			solar := obs[10].(float64)
-			battery := obs[8].(float64)
+		if o, _, err := decodeObservation(msg.Type, msg); err == nil {
 			return fmt.Sprintf("[%s] obs_sky | Wind: %.1f/%.1fm/s@%.0f° | UV: %d | Lux: %.0f | Solar: %.0fW/m² | Rain: %.2fmm | Battery: %.2fV | Serial: %s",
-				ts, windAvg, windGust, windDir, uv, lux, solar, rain, battery, msg.SerialNumber)
+				ts, o.WindAvg, o.WindGust, o.WindDirection, o.UV, o.Illuminance, o.SolarRadiation, o.RainAccumulated, o.Battery, msg.SerialNumber)
 		}
 	case TypeRapidWind:
-		if len(msg.Ob) >= 3 {
-			windSpeed := msg.Ob[1].(float64)
-			windDir := int(msg.Ob[2].(float64))
+		if wind, _, err := decodeRapidWind(msg); err == nil {
 			return fmt.Sprintf("[%s] rapid_wind | Speed: %.1fm/s | Direction: %d° | Serial: %s",
-				ts, windSpeed, windDir, msg.SerialNumber)
+				ts, wind.Speed, wind.Direction, msg.SerialNumber)
 		}
 	case TypeRainStart:
-		if len(msg.Evt) > 0 {
-			timestamp := int64(msg.Evt[0].(float64))
+		if timestamp, _, err := decodeRainStart(msg); err == nil {
 			return fmt.Sprintf("[%s] evt_precip | Rain started at %s | Serial: %s",
 				ts, time.Unix(timestamp, 0).Format("15:04:05"), msg.SerialNumber)
 		}
 	case TypeLightning:
-		if len(msg.Evt) >= 3 {
-			timestamp := int64(msg.Evt[0].(float64))
-			distance := msg.Evt[1].(float64)
-			energy := msg.Evt[2].(float64)
+		if strike, _, err := decodeStrike(msg); err == nil {
 			return fmt.Sprintf("[%s] evt_strike | Distance: %.1fkm | Energy: %.0f | Time: %s | Serial: %s",
-				ts, distance, energy, time.Unix(timestamp, 0).Format("15:04:05"), msg.SerialNumber)
+				ts, strike.Distance, strike.Energy, time.Unix(strike.Timestamp, 0).Format("15:04:05"), msg.SerialNumber)
 		}
 	case TypeDeviceStatus:
 		return fmt.Sprintf("[%s] device_status | Uptime: %ds | Battery: %.2fV | RSSI: %ddBm | Hub RSSI: %ddBm | Sensor Status: 0x%X | Serial: %s",
@@ -333,6 +413,11 @@ func PrettyPrintMessage(data []byte) string {
 
 // processMessage parses and processes a UDP message
 func (l *UDPListener) processMessage(data []byte) {
+	l.processPacket(data, "")
+}
+
+// processPacket parses and processes a UDP message received from ip
+func (l *UDPListener) processPacket(data []byte, ip string) {
 	// Call packet callback if set (for --test-udp mode)
 	l.mu.RLock()
 	callback := l.packetCallback
@@ -341,6 +426,7 @@ func (l *UDPListener) processMessage(data []byte) {
 	if callback != nil {
 		callback(data)
 	}
+	l.recordRaw(data, ip)
 
 	// Pretty print packet if debug logging is enabled
 	if logger.IsDebugEnabled() {
@@ -349,18 +435,41 @@ func (l *UDPListener) processMessage(data []byte) {
 
 	var msg UDPMessage
 	if err := json.Unmarshal(data, &msg); err != nil {
-		logger.Debug("Failed to parse UDP message: %v", err)
+		l.recordDecodeError("message", err)
 		return
 	}
 
 	logger.Debug("Parsed UDP message - Type: %s, Serial: %s, Hub: %s", msg.Type, msg.SerialNumber, msg.HubSN)
 
-	// Update serial number if not set
-	if l.serialNumber == "" && msg.SerialNumber != "" {
+	// Observations of ignored devices are still parsed for GetDevices
+	if l.track(msg, ip) {
+		switch msg.Type {
+		case TypeObservationST, TypeObservationAir, TypeObservationSky:
+		default:
+			return
+		}
+	} else {
 		l.mu.Lock()
-		l.serialNumber = msg.SerialNumber
+		if l.stationIP == "" && ip != "" {
+			l.stationIP = ip
+			logger.Info("Detected Tempest station at IP: %s", l.stationIP)
+		}
+		detected := l.serialNumber == "" && msg.SerialNumber != ""
+		if detected {
+			l.serialNumber = msg.SerialNumber
+		}
 		l.mu.Unlock()
-		logger.Info("Detected Tempest device serial: %s", msg.SerialNumber)
+		if detected {
+			logger.Info("Detected Tempest device serial: %s", msg.SerialNumber)
+		}
+	}
+
+	if !knownTypes[msg.Type] {
+		l.recordUnknownType(msg)
+		return
+	}
+	if keys := unknownKeys(msg.Type, data); len(keys) > 0 {
+		l.recordFields(msg, decodeReport{unknown: keys})
 	}
 
 	switch msg.Type {
@@ -377,143 +486,115 @@ func (l *UDPListener) processMessage(data []byte) {
 	case TypeHubStatus:
 		l.processHubStatus(msg)
 	case TypeRainStart:
-		timestamp := int64(msg.Evt[0].(float64))
+		timestamp, report, err := decodeRainStart(msg)
+		if err != nil {
+			l.recordDecodeError(msg.Type, err)
+			return
+		}
+		l.recordDecode(msg, report)
 		logger.Debug("UDP evt_precip - Rain start event at timestamp=%d (%v)", timestamp, time.Unix(timestamp, 0))
 	case TypeLightning:
-		if len(msg.Evt) >= 3 {
-			timestamp := int64(msg.Evt[0].(float64))
-			distance := msg.Evt[1].(float64)
-			energy := msg.Evt[2].(float64)
-			logger.Debug("UDP evt_strike - Lightning strike at timestamp=%d, distance=%.1fkm, energy=%.0f", timestamp, distance, energy)
+		strike, report, err := decodeStrike(msg)
+		if err != nil {
+			l.recordDecodeError(msg.Type, err)
+			return
+		}
+		l.recordDecode(msg, report)
+		logger.Debug("UDP evt_strike - Lightning strike at timestamp=%d, distance=%.1fkm, energy=%.0f", strike.Timestamp, strike.Distance, strike.Energy)
+
+		// The strike is counted by the next observation; the callback lets
+		// storm tracking see its exact distance
+		l.mu.RLock()
+		d := l.devices[strings.ToUpper(msg.SerialNumber)]
+		callback := l.strikeCallback
+		l.mu.RUnlock()
+		if callback != nil && (d == nil || !d.Ignored) {
+			callback(weather.LightningStrike{Timestamp: strike.Timestamp, Distance: strike.Distance, Energy: strike.Energy})
 		}
-	default:
-		logger.Debug("Unknown UDP message type: %s", msg.Type)
 	}
 }
 
+// decodeObservation decodes an observation message of type t and counts it,
+// returning false when it could not be decoded
+func (l *UDPListener) decodeObservation(t MessageType, msg UDPMessage) (weather.Observation, bool) {
+	msg.Type = t
+	observation, report, err := decodeObservation(t, msg)
+	if err != nil {
+		l.recordDecodeError(t, err)
+		return observation, false
+	}
+	l.recordDecode(msg, report)
+	return observation, true
+}
+
 // processObservationST processes a Tempest (ST) observation message
 func (l *UDPListener) processObservationST(msg UDPMessage) {
-	if len(msg.Obs) == 0 || len(msg.Obs[0]) < 18 {
-		logger.Debug("Invalid Tempest observation data")
+	observation, ok := l.decodeObservation(TypeObservationST, msg)
+	if !ok {
 		return
 	}
 
-	obs := msg.Obs[0]
-
-	// Parse observation according to Tempest UDP format
-	// [0]=timestamp, [1]=wind_lull, [2]=wind_avg, [3]=wind_gust, [4]=wind_dir,
-	// [5]=wind_sample_interval, [6]=pressure, [7]=temp, [8]=humidity, [9]=lux,
-	// [10]=uv, [11]=solar_rad, [12]=rain_1min, [13]=precip_type, [14]=lightning_dist,
-	// [15]=lightning_count, [16]=battery, [17]=report_interval
-
-	observation := weather.Observation{
-		Timestamp:            int64(obs[0].(float64)),
-		WindLull:             obs[1].(float64),
-		WindAvg:              obs[2].(float64),
-		WindGust:             obs[3].(float64),
-		WindDirection:        obs[4].(float64),
-		StationPressure:      obs[6].(float64),
-		AirTemperature:       obs[7].(float64),
-		RelativeHumidity:     obs[8].(float64),
-		Illuminance:          obs[9].(float64),
-		UV:                   int(obs[10].(float64)),
-		SolarRadiation:       obs[11].(float64),
-		RainAccumulated:      obs[12].(float64),
-		PrecipitationType:    int(obs[13].(float64)),
-		LightningStrikeAvg:   obs[14].(float64),
-		LightningStrikeCount: int(obs[15].(float64)),
-		Battery:              obs[16].(float64),
-		ReportInterval:       int(obs[17].(float64)),
-	}
-
 	logger.Debug("UDP obs_st - Timestamp=%d, Temp=%.1f°C, Humidity=%.0f%%, Pressure=%.1fmb, Wind=%.1f/%.1f/%.1fm/s@%.0f°, Lux=%.0f, UV=%d, Rain=%.2fmm, Lightning=%d@%.0fkm, Battery=%.2fV",
 		observation.Timestamp, observation.AirTemperature, observation.RelativeHumidity, observation.StationPressure,
 		observation.WindLull, observation.WindAvg, observation.WindGust, observation.WindDirection,
 		observation.Illuminance, observation.UV, observation.RainAccumulated,
 		observation.LightningStrikeCount, observation.LightningStrikeAvg, observation.Battery)
 
-	l.addObservation(observation)
+	l.observe(msg.SerialNumber, observation)
 }
 
 // processObservationAir processes an AIR device observation
 func (l *UDPListener) processObservationAir(msg UDPMessage) {
-	if len(msg.Obs) == 0 || len(msg.Obs[0]) < 8 {
+	observation, ok := l.decodeObservation(TypeObservationAir, msg)
+	if !ok {
 		return
 	}
 
-	obs := msg.Obs[0]
-	// AIR format: [0]=timestamp, [1]=pressure, [2]=temp, [3]=humidity,
-	// [4]=lightning_count, [5]=lightning_dist, [6]=battery, [7]=report_interval
-
-	observation := weather.Observation{
-		Timestamp:            int64(obs[0].(float64)),
-		StationPressure:      obs[1].(float64),
-		AirTemperature:       obs[2].(float64),
-		RelativeHumidity:     obs[3].(float64),
-		LightningStrikeCount: int(obs[4].(float64)),
-		LightningStrikeAvg:   obs[5].(float64),
-		Battery:              obs[6].(float64),
-		ReportInterval:       int(obs[7].(float64)),
-	}
-
 	logger.Debug("UDP obs_air - Timestamp=%d, Temp=%.1f°C, Humidity=%.0f%%, Pressure=%.1fmb, Lightning=%d@%.0fkm, Battery=%.2fV",
 		observation.Timestamp, observation.AirTemperature, observation.RelativeHumidity,
 		observation.StationPressure, observation.LightningStrikeCount, observation.LightningStrikeAvg, observation.Battery)
 
-	l.addObservation(observation)
+	l.observe(msg.SerialNumber, observation)
 }
 
 // processObservationSky processes a SKY device observation
 func (l *UDPListener) processObservationSky(msg UDPMessage) {
-	if len(msg.Obs) == 0 || len(msg.Obs[0]) < 14 {
+	observation, ok := l.decodeObservation(TypeObservationSky, msg)
+	if !ok {
 		return
 	}
 
-	obs := msg.Obs[0]
-	// SKY format: [0]=timestamp, [1]=lux, [2]=uv, [3]=rain_1min, [4]=wind_lull,
-	// [5]=wind_avg, [6]=wind_gust, [7]=wind_dir, [8]=battery, [9]=report_interval,
-	// [10]=solar_rad, [11]=local_rain (null), [12]=precip_type, [13]=wind_sample_interval
-
-	observation := weather.Observation{
-		Timestamp:         int64(obs[0].(float64)),
-		Illuminance:       obs[1].(float64),
-		UV:                int(obs[2].(float64)),
-		RainAccumulated:   obs[3].(float64),
-		WindLull:          obs[4].(float64),
-		WindAvg:           obs[5].(float64),
-		WindGust:          obs[6].(float64),
-		WindDirection:     obs[7].(float64),
-		Battery:           obs[8].(float64),
-		ReportInterval:    int(obs[9].(float64)),
-		SolarRadiation:    obs[10].(float64),
-		PrecipitationType: int(obs[12].(float64)),
-	}
-
 	logger.Debug("UDP obs_sky - Timestamp=%d, Wind=%.1f/%.1f/%.1fm/s@%.0f°, Lux=%.0f, UV=%d, Solar=%.0fW/m², Rain=%.2fmm, Battery=%.2fV",
 		observation.Timestamp, observation.WindLull, observation.WindAvg, observation.WindGust, observation.WindDirection,
 		observation.Illuminance, observation.UV, observation.SolarRadiation, observation.RainAccumulated, observation.Battery)
 
-	l.addObservation(observation)
+	l.observe(msg.SerialNumber, observation)
 }
 
 // processRapidWind processes rapid wind updates (every 3 seconds)
 func (l *UDPListener) processRapidWind(msg UDPMessage) {
-	if len(msg.Ob) < 3 {
+	wind, report, err := decodeRapidWind(msg)
+	if err != nil {
+		l.recordDecodeError(msg.Type, err)
 		return
 	}
+	l.recordDecode(msg, report)
+	logger.Debug("UDP rapid_wind - Timestamp=%d, Speed=%.1fm/s, Direction=%d°", wind.Timestamp, wind.Speed, wind.Direction)
 
-	// Rapid wind: [0]=timestamp, [1]=wind_speed, [2]=wind_direction
-	timestamp := int64(msg.Ob[0].(float64))
-	windSpeed := msg.Ob[1].(float64)
-	windDir := int(msg.Ob[2].(float64))
-	logger.Debug("UDP rapid_wind - Timestamp=%d, Speed=%.1fm/s, Direction=%d°", timestamp, windSpeed, windDir)
-
-	// We could update wind in real-time here, but for now just log it
-	// The full observation will be processed when obs_st arrives
+	// The full observation is processed when obs_st arrives; the callback
+	// lets gust tracking see the samples in between
+	l.mu.RLock()
+	d := l.devices[strings.ToUpper(msg.SerialNumber)]
+	callback := l.windCallback
+	l.mu.RUnlock()
+	if callback != nil && (d == nil || !d.Ignored) {
+		callback(weather.WindSample{Timestamp: wind.Timestamp, Speed: wind.Speed, Direction: wind.Direction})
+	}
 }
 
 // processDeviceStatus processes device status messages
 func (l *UDPListener) processDeviceStatus(msg UDPMessage) {
+	l.recordDecode(msg, decodeReport{})
 	status := &DeviceStatus{
 		Timestamp:    time.Unix(msg.Timestamp, 0),
 		Uptime:       msg.Uptime,
@@ -534,6 +615,7 @@ func (l *UDPListener) processDeviceStatus(msg UDPMessage) {
 
 // processHubStatus processes hub status messages
 func (l *UDPListener) processHubStatus(msg UDPMessage) {
+	l.recordDecode(msg, decodeReport{})
 	status := &HubStatus{
 		Timestamp:    time.Unix(msg.Timestamp, 0),
 		FirmwareRev:  fmt.Sprintf("%d", msg.FirmwareRevision),
@@ -2,6 +2,7 @@ package udp
 
 import (
 	"encoding/json"
+	"net"
 	"testing"
 	"time"
 
@@ -87,3 +88,21 @@ func TestAddObservationRollOverAndGetLatestCopy(t *testing.T) {
 func createObs(ts int64) *weather.Observation {
 	return &weather.Observation{Timestamp: ts, AirTemperature: float64(ts)}
 }
+
+func TestHandlePacketDropRate(t *testing.T) {
+	l := NewUDPListener(100)
+	msg, _ := json.Marshal(UDPMessage{SerialNumber: "HB-1", Type: TypeHubStatus, Timestamp: time.Now().Unix()})
+	addr := &net.UDPAddr{IP: net.ParseIP("192.168.1.20"), Port: UDPPort}
+
+	l.SetDropRate(1)
+	l.handlePacket(msg, addr)
+	if count, _, ip, _ := l.GetStats(); count != 0 || ip != "" || l.GetHubStatus() != nil {
+		t.Fatalf("dropped packet was counted: count=%d ip=%q", count, ip)
+	}
+
+	l.SetDropRate(0)
+	l.handlePacket(msg, addr)
+	if count, _, ip, _ := l.GetStats(); count != 1 || ip != "192.168.1.20" || l.GetHubStatus() == nil {
+		t.Errorf("packet not handled: count=%d ip=%q", count, ip)
+	}
+}
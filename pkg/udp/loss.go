@@ -0,0 +1,122 @@
+package udp
+
+import (
+	"math"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// jitterGain is the RFC 3550 smoothing factor for the jitter estimate
+const jitterGain = 1.0 / 16
+
+// linkTracker follows the observations of one device. Devices report at a
+// fixed interval, so a gap of several intervals between observation
+// timestamps means broadcasts were lost on the way.
+type linkTracker struct {
+	lastTimestamp int64     // station timestamp of the previous observation
+	lastArrival   time.Time // when the previous observation was received
+	expected      int64
+	received      int64
+	lost          int64
+	duplicates    int64
+	jitter        float64 // seconds
+	gapSum        float64 // seconds between arrivals, for the mean interval
+	gaps          int64
+	maxGap        float64
+	lastLoss      time.Time
+}
+
+// record counts an observation stamped timestamp by the station that arrived
+// at arrival; interval is the device's report interval
+func (t *linkTracker) record(timestamp int64, interval time.Duration, arrival time.Time) {
+	if t.received == 0 && t.duplicates == 0 {
+		t.lastTimestamp, t.lastArrival = timestamp, arrival
+		t.expected, t.received = 1, 1
+		return
+	}
+	if timestamp <= t.lastTimestamp {
+		// Repeated (e.g. heard on two interfaces) or out of order
+		t.duplicates++
+		return
+	}
+
+	gap := float64(timestamp - t.lastTimestamp)
+	steps := int64(math.Round(gap / interval.Seconds()))
+	if steps < 1 {
+		steps = 1
+	}
+	t.expected += steps
+	t.received++
+	if steps > 1 {
+		t.lost += steps - 1
+		t.lastLoss = arrival
+	}
+
+	// Jitter compares the spacing of arrivals with the spacing the station
+	// stamped, so it grows with variable delay on the network, not with loss
+	between := arrival.Sub(t.lastArrival).Seconds()
+	t.jitter += (math.Abs(between-gap) - t.jitter) * jitterGain
+	t.gapSum += between
+	t.gaps++
+	t.maxGap = math.Max(t.maxGap, between)
+
+	t.lastTimestamp, t.lastArrival = timestamp, arrival
+}
+
+// reportInterval is the interval a device reports at; the observation carries
+// it in minutes, older firmware leaves it out
+func reportInterval(obs weather.Observation) time.Duration {
+	if obs.ReportInterval > 0 {
+		return time.Duration(obs.ReportInterval) * time.Minute
+	}
+	return time.Minute
+}
+
+// recordLink adds obs to its device's link statistics. Must be called with
+// l.mu held.
+func (l *UDPListener) recordLink(serial string, obs weather.Observation, arrival time.Time) {
+	if l.links == nil {
+		l.links = make(map[string]*linkTracker)
+	}
+	t := l.links[serial]
+	if t == nil {
+		t = &linkTracker{}
+		l.links[serial] = t
+	}
+	t.record(obs.Timestamp, reportInterval(obs), arrival)
+}
+
+// GetLinkStats returns observation loss and jitter summed over the devices
+// in use. Jitter and the longest gap are those of the worst device.
+func (l *UDPListener) GetLinkStats() weather.UDPLinkStats {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var stats weather.UDPLinkStats
+	var gapSum float64
+	var gaps int64
+	for serial, t := range l.links {
+		if d := l.devices[serial]; d != nil && d.Ignored {
+			continue
+		}
+		stats.Expected += t.expected
+		stats.Received += t.received
+		stats.Lost += t.lost
+		stats.Duplicates += t.duplicates
+		stats.JitterSeconds = math.Max(stats.JitterSeconds, t.jitter)
+		stats.MaxGapSeconds = math.Max(stats.MaxGapSeconds, t.maxGap)
+		if t.lastLoss.After(stats.LastLoss) {
+			stats.LastLoss = t.lastLoss
+		}
+		gapSum += t.gapSum
+		gaps += t.gaps
+	}
+	if stats.Expected > 0 {
+		stats.LossPercent = float64(stats.Lost) / float64(stats.Expected) * 100
+	}
+	if gaps > 0 {
+		stats.MeanIntervalSeconds = gapSum / float64(gaps)
+	}
+	return stats
+}
@@ -0,0 +1,56 @@
+package udp
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLinkTrackerLossAndJitter(t *testing.T) {
+	var lt linkTracker
+	start := time.Unix(1700000000, 0)
+	// Minute observations; the ones at 2 and 3 minutes never arrive and the
+	// one at 5 minutes is two seconds late
+	for _, m := range []struct {
+		minute int64
+		delay  time.Duration
+	}{{0, 0}, {1, 0}, {4, 0}, {5, 2 * time.Second}, {6, 0}, {6, 0}} {
+		ts := start.Unix() + m.minute*60
+		lt.record(ts, time.Minute, time.Unix(ts, 0).Add(m.delay))
+	}
+
+	if lt.expected != 7 || lt.received != 5 || lt.lost != 2 || lt.duplicates != 1 {
+		t.Errorf("expected=%d received=%d lost=%d duplicates=%d", lt.expected, lt.received, lt.lost, lt.duplicates)
+	}
+	// The late observation moves jitter twice: 2s late, then 2s early
+	want := 2.0/16 + (2-2.0/16)/16
+	if math.Abs(lt.jitter-want) > 1e-9 {
+		t.Errorf("jitter = %v, want %v", lt.jitter, want)
+	}
+	if lt.maxGap != 180 {
+		t.Errorf("max gap = %v", lt.maxGap)
+	}
+}
+
+func TestGetLinkStatsSkipsIgnoredDevices(t *testing.T) {
+	l := NewUDPListener(10)
+	now := time.Now().Unix()
+	for _, m := range []struct {
+		serial string
+		ts     int64
+	}{{"ST-A", now - 180}, {"ST-B", now - 180}, {"ST-A", now}, {"ST-B", now - 120}} {
+		obs := createObsST(m.ts)
+		obs[0][17] = 1.0
+		data, _ := json.Marshal(UDPMessage{Type: TypeObservationST, SerialNumber: m.serial, Obs: obs})
+		l.processPacket(data, "")
+	}
+
+	stats := l.GetLinkStats()
+	if stats.Expected != 4 || stats.Received != 2 || stats.Lost != 2 || stats.LossPercent != 50 {
+		t.Errorf("link stats = %+v, want ST-A only with 2 of 4 lost", stats)
+	}
+	if stats.LastLoss.IsZero() {
+		t.Error("last loss not recorded")
+	}
+}
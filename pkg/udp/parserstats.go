@@ -0,0 +1,117 @@
+package udp
+
+import (
+	"strings"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// maxParserKeys bounds the unknown types and fields counted; names come from
+// the network, so anything past the bound is counted as "other"
+const maxParserKeys = 50
+
+// parserStats counts decoded messages and what the decoders did not know
+type parserStats struct {
+	messages      map[string]int64
+	errors        int64
+	unknownTypes  map[string]int64
+	unknownFields map[string]int64
+	invalidValues map[string]int64
+	nullValues    int64
+}
+
+// bump adds one to counts[key], creating the map, and reports whether the
+// key is new
+func bump(counts *map[string]int64, key string) bool {
+	if *counts == nil {
+		*counts = make(map[string]int64)
+	}
+	if _, ok := (*counts)[key]; !ok && len(*counts) >= maxParserKeys {
+		key = "other"
+	}
+	(*counts)[key]++
+	return (*counts)[key] == 1
+}
+
+// recordDecode counts a decoded message and what its decoder reported
+func (l *UDPListener) recordDecode(msg UDPMessage, report decodeReport) {
+	l.mu.Lock()
+	bump(&l.parser.messages, string(msg.Type))
+	l.mu.Unlock()
+	l.recordFields(msg, report)
+}
+
+// recordFields counts the fields a decoder could not use. New unknown fields
+// are logged once with the firmware revision, as they usually mean newer
+// firmware than SchemaVersion.
+func (l *UDPListener) recordFields(msg UDPMessage, report decodeReport) {
+	l.mu.Lock()
+	l.parser.nullValues += int64(report.nulls)
+	var newFields, newInvalid []string
+	for _, f := range report.unknown {
+		if bump(&l.parser.unknownFields, f) {
+			newFields = append(newFields, f)
+		}
+	}
+	for _, f := range report.invalid {
+		if bump(&l.parser.invalidValues, f) {
+			newInvalid = append(newInvalid, f)
+		}
+	}
+	l.mu.Unlock()
+
+	if len(newFields) > 0 {
+		logger.Info("UDP %s from %s (firmware %d) has fields unknown to schema v%d, ignored: %s",
+			msg.Type, msg.SerialNumber, msg.FirmwareRevision, SchemaVersion, strings.Join(newFields, ", "))
+	}
+	if len(newInvalid) > 0 {
+		logger.Warn("UDP %s from %s has values that are not numbers, read as 0: %s",
+			msg.Type, msg.SerialNumber, strings.Join(newInvalid, ", "))
+	}
+}
+
+// recordDecodeError counts a message that could not be decoded
+func (l *UDPListener) recordDecodeError(t MessageType, err error) {
+	l.mu.Lock()
+	l.parser.errors++
+	l.mu.Unlock()
+	logger.Debug("Failed to decode UDP %s: %v", t, err)
+}
+
+// recordUnknownType counts a message type SchemaVersion does not describe
+func (l *UDPListener) recordUnknownType(msg UDPMessage) {
+	l.mu.Lock()
+	isNew := bump(&l.parser.unknownTypes, string(msg.Type))
+	l.mu.Unlock()
+	if isNew {
+		logger.Info("Unknown UDP message type %q from %s (firmware %d), not in schema v%d",
+			msg.Type, msg.SerialNumber, msg.FirmwareRevision, SchemaVersion)
+	}
+}
+
+// GetParserStats returns how many messages of each type were decoded and
+// which types, fields and values the decoders did not understand
+func (l *UDPListener) GetParserStats() weather.UDPParserStats {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	clone := func(m map[string]int64) map[string]int64 {
+		if len(m) == 0 {
+			return nil
+		}
+		c := make(map[string]int64, len(m))
+		for k, v := range m {
+			c[k] = v
+		}
+		return c
+	}
+	return weather.UDPParserStats{
+		SchemaVersion: SchemaVersion,
+		Messages:      clone(l.parser.messages),
+		Errors:        l.parser.errors,
+		UnknownTypes:  clone(l.parser.unknownTypes),
+		UnknownFields: clone(l.parser.unknownFields),
+		InvalidValues: clone(l.parser.invalidValues),
+		NullValues:    l.parser.nullValues,
+	}
+}
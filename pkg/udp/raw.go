@@ -0,0 +1,73 @@
+package udp
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RawPacket is a UDP packet exactly as it was received, kept so users can
+// capture what the hub sent when reporting a parsing problem
+type RawPacket struct {
+	Time   time.Time `json:"time"`
+	From   string    `json:"from,omitempty"`
+	Type   string    `json:"type,omitempty"`   // message type, when the packet is JSON
+	Serial string    `json:"serial,omitempty"` // sender serial number, when the packet is JSON
+	Size   int       `json:"size"`
+	Data   string    `json:"data"`
+}
+
+// SetRawBufferSize keeps the last n packets for GetRawPackets; 0 (the
+// default) keeps none
+func (l *UDPListener) SetRawBufferSize(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	l.raw = make([]RawPacket, 0, n)
+	l.rawSize = n
+	l.rawNext = 0
+}
+
+// RawBufferSize returns how many packets GetRawPackets can hold
+func (l *UDPListener) RawBufferSize() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.rawSize
+}
+
+// recordRaw adds a packet to the ring buffer
+func (l *UDPListener) recordRaw(data []byte, ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rawSize == 0 {
+		return
+	}
+	p := RawPacket{Time: time.Now(), From: ip, Size: len(data), Data: string(data)}
+	var head struct {
+		Type   string `json:"type"`
+		Serial string `json:"serial_number"`
+	}
+	if json.Unmarshal(data, &head) == nil {
+		p.Type, p.Serial = head.Type, head.Serial
+	}
+	if len(l.raw) < l.rawSize {
+		l.raw = append(l.raw, p)
+	} else {
+		l.raw[l.rawNext] = p
+	}
+	l.rawNext = (l.rawNext + 1) % l.rawSize
+}
+
+// GetRawPackets returns the buffered packets, newest first
+func (l *UDPListener) GetRawPackets() []RawPacket {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	packets := make([]RawPacket, 0, len(l.raw))
+	for i := range l.raw {
+		// Walk backwards from the newest entry
+		idx := (l.rawNext - 1 - i + 2*len(l.raw)) % len(l.raw)
+		packets = append(packets, l.raw[idx])
+	}
+	return packets
+}
@@ -0,0 +1,31 @@
+package udp
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRawPacketRingBuffer(t *testing.T) {
+	l := NewUDPListener(10)
+	l.processPacket([]byte(`{"type":"hub_status","serial_number":"HB-1"}`), "192.0.2.1")
+	if got := l.GetRawPackets(); len(got) != 0 {
+		t.Fatalf("packets kept without a buffer: %+v", got)
+	}
+
+	l.SetRawBufferSize(3)
+	for i := 1; i <= 4; i++ {
+		l.processPacket([]byte(fmt.Sprintf(`{"type":"rapid_wind","serial_number":"ST-1","ob":[%d,1,90]}`, i)), "192.0.2.1")
+	}
+	l.processPacket([]byte("not json"), "")
+
+	got := l.GetRawPackets()
+	if len(got) != 3 {
+		t.Fatalf("kept %d packets, want 3", len(got))
+	}
+	if got[0].Data != "not json" || got[0].Type != "" || got[0].Size != 8 {
+		t.Errorf("newest = %+v", got[0])
+	}
+	if got[2].Type != "rapid_wind" || got[2].Serial != "ST-1" || got[2].From != "192.0.2.1" || got[2].Data != `{"type":"rapid_wind","serial_number":"ST-1","ob":[3,1,90]}` {
+		t.Errorf("oldest = %+v", got[2])
+	}
+}
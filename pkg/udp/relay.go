@@ -0,0 +1,120 @@
+package udp
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"tempest-homekit-go/pkg/logger"
+)
+
+// ParseRelayTargets parses --udp-relay: host:port destinations separated by
+// commas. A broadcast address such as 192.168.20.255:50222 re-broadcasts the
+// packets on that network, any other address receives them unicast.
+func ParseRelayTargets(spec string) ([]string, error) {
+	var targets []string
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		host, port, err := net.SplitHostPort(part)
+		if err != nil || host == "" {
+			return nil, fmt.Errorf("invalid relay target %q, use host:port", part)
+		}
+		if p, err := net.LookupPort("udp", port); err != nil || p == 0 {
+			return nil, fmt.Errorf("invalid port in relay target %q", part)
+		}
+		targets = append(targets, part)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no relay targets in %q", spec)
+	}
+	return targets, nil
+}
+
+// Relay sends received packets on to other hosts, for a station and server
+// on different networks with a small relay in between
+type Relay struct {
+	conn    *net.UDPConn
+	targets []*net.UDPAddr
+	local   map[string]bool // addresses of this host, to skip our own broadcasts
+
+	mu     sync.Mutex
+	sent   int64
+	failed int64
+}
+
+// NewRelay resolves the targets and opens the socket packets are sent from
+func NewRelay(targets []string) (*Relay, error) {
+	r := &Relay{local: map[string]bool{}}
+	for _, target := range targets {
+		addr, err := net.ResolveUDPAddr("udp", target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve relay target %s: %w", target, err)
+		}
+		r.targets = append(r.targets, addr)
+	}
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open relay socket: %w", err)
+	}
+	r.conn = conn
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, a := range addrs {
+			if ipNet, ok := a.(*net.IPNet); ok {
+				r.local[ipNet.IP.String()] = true
+			}
+		}
+	}
+	return r, nil
+}
+
+// own reports whether a packet came from this relay, e.g. a re-broadcast on
+// the network the listener is on; relaying it again would loop
+func (r *Relay) own(from *net.UDPAddr) bool {
+	if from == nil {
+		return false
+	}
+	port := r.conn.LocalAddr().(*net.UDPAddr).Port
+	return from.Port == port && (from.IP.IsLoopback() || r.local[from.IP.String()])
+}
+
+// Forward sends data to every target
+func (r *Relay) Forward(data []byte) {
+	for _, addr := range r.targets {
+		_, err := r.conn.WriteToUDP(data, addr)
+		r.mu.Lock()
+		if err != nil {
+			r.failed++
+		} else {
+			r.sent++
+		}
+		r.mu.Unlock()
+		if err != nil {
+			logger.Debug("Failed to relay UDP packet to %s: %v", addr, err)
+		}
+	}
+}
+
+// Stats returns how many packets were sent and how many sends failed
+func (r *Relay) Stats() (sent, failed int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sent, r.failed
+}
+
+// Targets returns the resolved destinations
+func (r *Relay) Targets() []string {
+	var targets []string
+	for _, addr := range r.targets {
+		targets = append(targets, addr.String())
+	}
+	return targets
+}
+
+// Close closes the relay socket
+func (r *Relay) Close() error {
+	return r.conn.Close()
+}
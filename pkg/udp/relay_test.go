@@ -0,0 +1,100 @@
+package udp
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParseRelayTargets(t *testing.T) {
+	targets, err := ParseRelayTargets("192.168.20.255:50222, bridge.local:50223")
+	if err != nil || len(targets) != 2 || targets[1] != "bridge.local:50223" {
+		t.Fatalf("ParseRelayTargets = %v, %v", targets, err)
+	}
+	for _, bad := range []string{"", ",", "192.168.20.255", ":50222", "host:0", "host:port"} {
+		if _, err := ParseRelayTargets(bad); err == nil {
+			t.Errorf("ParseRelayTargets(%q) accepted", bad)
+		}
+	}
+}
+
+// freePort returns a UDP port nothing listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestListenerOnOtherPortRelaysPackets(t *testing.T) {
+	target, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = target.Close() }()
+
+	relay, err := NewRelay([]string{target.LocalAddr().String()})
+	if err != nil {
+		t.Fatalf("NewRelay: %v", err)
+	}
+	defer func() { _ = relay.Close() }()
+
+	port := freePort(t)
+	l := NewUDPListener(10)
+	l.SetPort(port)
+	l.SetRelay(relay)
+	if err := l.Start(); err != nil {
+		t.Fatalf("Start on port %d: %v", port, err)
+	}
+	defer func() { _ = l.Stop() }()
+
+	msg, _ := json.Marshal(UDPMessage{SerialNumber: "ST-A", Type: TypeObservationST, Obs: createObsST(time.Now().Unix())})
+	sender, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = sender.Close() }()
+	if _, err := sender.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 4096)
+	_ = target.SetReadDeadline(time.Now().Add(3 * time.Second))
+	n, _, err := target.ReadFromUDP(buf)
+	if err != nil {
+		t.Fatalf("relayed packet not received: %v", err)
+	}
+	if string(buf[:n]) != string(msg) {
+		t.Errorf("relayed %q, want %q", buf[:n], msg)
+	}
+	if sent, failed := relay.Stats(); sent != 1 || failed != 0 {
+		t.Errorf("relay stats sent=%d failed=%d", sent, failed)
+	}
+	if count, _, _, _ := l.GetStats(); count != 1 {
+		t.Errorf("listener counted %d packets", count)
+	}
+}
+
+func TestRelayIgnoresItsOwnPackets(t *testing.T) {
+	relay, err := NewRelay([]string{"127.0.0.1:9"})
+	if err != nil {
+		t.Fatalf("NewRelay: %v", err)
+	}
+	defer func() { _ = relay.Close() }()
+	l := NewUDPListener(10)
+	l.SetRelay(relay)
+
+	own := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: relay.conn.LocalAddr().(*net.UDPAddr).Port}
+	msg, _ := json.Marshal(UDPMessage{SerialNumber: "HB-1", Type: TypeHubStatus})
+	l.handlePacket(msg, own)
+	if count, _, _, _ := l.GetStats(); count != 0 {
+		t.Errorf("own relayed packet counted")
+	}
+	if sent, _ := relay.Stats(); sent != 0 {
+		t.Errorf("own packet relayed again")
+	}
+}
@@ -0,0 +1,84 @@
+package udp
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// SocketOptions configures the listening socket. The port is always opened
+// with address and port reuse where the platform has it, so the listener
+// runs next to the WeatherFlow apps and other listeners on the same host.
+type SocketOptions struct {
+	Network   string   // "udp" (dual-stack where supported, the default), "udp4" or "udp6"
+	Interface string   // receive only on this network interface (Linux and macOS)
+	Groups    []net.IP // multicast groups to join, e.g. for a relay sending to 239.0.0.222
+}
+
+// ParseNetwork checks --udp-network
+func ParseNetwork(network string) (string, error) {
+	switch network = strings.ToLower(strings.TrimSpace(network)); network {
+	case "", "udp", "dual":
+		return "udp", nil
+	case "udp4", "ipv4", "4":
+		return "udp4", nil
+	case "udp6", "ipv6", "6":
+		return "udp6", nil
+	}
+	return "", fmt.Errorf("invalid UDP network %q, use udp (IPv4 and IPv6), udp4 or udp6", network)
+}
+
+// ParseMulticastGroups parses --udp-multicast: IPv4 or IPv6 multicast
+// addresses separated by commas
+func ParseMulticastGroups(spec string) ([]net.IP, error) {
+	var groups []net.IP
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		ip := net.ParseIP(part)
+		if ip == nil || !ip.IsMulticast() {
+			return nil, fmt.Errorf("%q is not a multicast address", part)
+		}
+		groups = append(groups, ip)
+	}
+	return groups, nil
+}
+
+// SetSocketOptions changes how Start opens the socket; call it before Start
+func (l *UDPListener) SetSocketOptions(opts SocketOptions) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.socket = opts
+}
+
+// joinGroups joins the multicast groups on the interface (all interfaces
+// the system chooses when none is set)
+func joinGroups(conn *net.UDPConn, opts SocketOptions) error {
+	if len(opts.Groups) == 0 {
+		return nil
+	}
+	var iface *net.Interface
+	if opts.Interface != "" {
+		var err error
+		if iface, err = net.InterfaceByName(opts.Interface); err != nil {
+			return fmt.Errorf("interface %s: %w", opts.Interface, err)
+		}
+	}
+	for _, group := range opts.Groups {
+		var err error
+		if group.To4() != nil {
+			err = ipv4.NewPacketConn(conn).JoinGroup(iface, &net.UDPAddr{IP: group})
+		} else {
+			err = ipv6.NewPacketConn(conn).JoinGroup(iface, &net.UDPAddr{IP: group})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to join multicast group %s: %w", group, err)
+		}
+	}
+	return nil
+}
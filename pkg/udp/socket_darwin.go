@@ -0,0 +1,45 @@
+//go:build darwin
+// +build darwin
+
+package udp
+
+import (
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// socketControl sets SO_REUSEADDR and SO_REUSEPORT, so several programs can
+// receive the broadcasts, and binds the socket to the interface when set
+func socketControl(iface string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		index := 0
+		if iface != "" {
+			ifi, err := net.InterfaceByName(iface)
+			if err != nil {
+				return err
+			}
+			index = ifi.Index
+		}
+		var err error
+		if ctrlErr := c.Control(func(fd uintptr) {
+			if err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1); err != nil {
+				return
+			}
+			if err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1); err != nil {
+				return
+			}
+			if index != 0 {
+				if network == "udp4" {
+					err = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_BOUND_IF, index)
+				} else {
+					err = unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_BOUND_IF, index)
+				}
+			}
+		}); ctrlErr != nil {
+			return ctrlErr
+		}
+		return err
+	}
+}
@@ -0,0 +1,32 @@
+//go:build linux
+// +build linux
+
+package udp
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// socketControl sets SO_REUSEADDR and SO_REUSEPORT, so several programs can
+// receive the broadcasts, and binds the socket to the interface when set
+func socketControl(iface string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		var err error
+		if ctrlErr := c.Control(func(fd uintptr) {
+			if err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1); err != nil {
+				return
+			}
+			if err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1); err != nil {
+				return
+			}
+			if iface != "" {
+				err = unix.BindToDevice(int(fd), iface)
+			}
+		}); ctrlErr != nil {
+			return ctrlErr
+		}
+		return err
+	}
+}
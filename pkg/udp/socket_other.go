@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package udp
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// socketControl leaves the socket options at their defaults; binding to an
+// interface is only supported on Linux and macOS
+func socketControl(iface string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		if iface != "" {
+			return fmt.Errorf("binding to interface %s is not supported on this platform", iface)
+		}
+		return nil
+	}
+}
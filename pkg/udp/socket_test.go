@@ -0,0 +1,122 @@
+package udp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseNetworkAndGroups(t *testing.T) {
+	for in, want := range map[string]string{"": "udp", "udp": "udp", "UDP4": "udp4", "ipv6": "udp6"} {
+		if got, err := ParseNetwork(in); err != nil || got != want {
+			t.Errorf("ParseNetwork(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseNetwork("tcp"); err == nil {
+		t.Error("ParseNetwork accepted tcp")
+	}
+
+	groups, err := ParseMulticastGroups("239.0.0.222, ff02::1")
+	if err != nil || len(groups) != 2 || groups[1].To4() != nil {
+		t.Fatalf("ParseMulticastGroups = %v, %v", groups, err)
+	}
+	for _, bad := range []string{"192.168.1.255", "not-an-ip"} {
+		if _, err := ParseMulticastGroups(bad); err == nil {
+			t.Errorf("ParseMulticastGroups(%q) accepted", bad)
+		}
+	}
+}
+
+// waitForPackets waits until l has counted n packets
+func waitForPackets(t *testing.T, l *UDPListener, n int64) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if count, _, _, _ := l.GetStats(); count >= n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	count, _, _, _ := l.GetStats()
+	t.Fatalf("received %d packets, want %d", count, n)
+}
+
+func send(t *testing.T, network, addr string) {
+	t.Helper()
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		t.Skipf("cannot send to %s: %v", addr, err)
+	}
+	defer func() { _ = conn.Close() }()
+	msg, _ := json.Marshal(UDPMessage{SerialNumber: "HB-1", Type: TypeHubStatus})
+	if _, err := conn.Write(msg); err != nil {
+		t.Skipf("cannot send to %s: %v", addr, err)
+	}
+}
+
+func TestListenersShareThePort(t *testing.T) {
+	port := freePort(t)
+	first, second := NewUDPListener(10), NewUDPListener(10)
+	for _, l := range []*UDPListener{first, second} {
+		l.SetPort(port)
+		l.SetSocketOptions(SocketOptions{Network: "udp4"})
+		if err := l.Start(); err != nil {
+			t.Fatalf("Start: %v (the port should be shared)", err)
+		}
+		defer func(l *UDPListener) { _ = l.Stop() }(l)
+	}
+}
+
+func TestListenerIPv6(t *testing.T) {
+	port := freePort(t)
+	l := NewUDPListener(10)
+	l.SetPort(port)
+	l.SetSocketOptions(SocketOptions{Network: "udp6"})
+	if err := l.Start(); err != nil {
+		t.Skipf("IPv6 not available: %v", err)
+	}
+	defer func() { _ = l.Stop() }()
+
+	send(t, "udp6", net.JoinHostPort("::1", fmt.Sprint(port)))
+	waitForPackets(t, l, 1)
+	if _, _, ip, _ := l.GetStats(); ip != "::1" {
+		t.Errorf("station IP = %q", ip)
+	}
+}
+
+func TestListenerMulticastGroup(t *testing.T) {
+	port := freePort(t)
+	l := NewUDPListener(10)
+	l.SetPort(port)
+	l.SetSocketOptions(SocketOptions{Network: "udp4", Groups: []net.IP{net.ParseIP("239.0.0.222")}})
+	if err := l.Start(); err != nil {
+		if strings.Contains(err.Error(), "multicast") {
+			t.Skipf("multicast not available: %v", err)
+		}
+		t.Fatalf("Start: %v", err)
+	}
+	defer func() { _ = l.Stop() }()
+
+	send(t, "udp4", net.JoinHostPort("239.0.0.222", fmt.Sprint(port)))
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if count, _, _, _ := l.GetStats(); count > 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Skip("no multicast delivery on this host")
+}
+
+func TestListenerUnknownInterface(t *testing.T) {
+	l := NewUDPListener(10)
+	l.SetPort(freePort(t))
+	l.SetSocketOptions(SocketOptions{Interface: "no-such-if0"})
+	if err := l.Start(); err == nil {
+		_ = l.Stop()
+		t.Error("Start succeeded on an unknown interface")
+	}
+}
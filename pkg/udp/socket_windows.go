@@ -0,0 +1,26 @@
+//go:build windows
+// +build windows
+
+package udp
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// socketControl sets SO_REUSEADDR, so several programs can receive the
+// broadcasts; binding to an interface is not supported on Windows
+func socketControl(iface string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		if iface != "" {
+			return fmt.Errorf("binding to interface %s is not supported on Windows", iface)
+		}
+		var err error
+		if ctrlErr := c.Control(func(fd uintptr) {
+			err = syscall.SetsockoptInt(syscall.Handle(fd), syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1)
+		}); ctrlErr != nil {
+			return ctrlErr
+		}
+		return err
+	}
+}
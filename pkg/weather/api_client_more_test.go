@@ -12,7 +12,8 @@ func TestGetForecast(t *testing.T) {
 		// simple forecast response
 		fr := ForecastResponse{
 			Forecast: struct {
-				Daily []ForecastPeriod `json:"daily"`
+				Daily  []ForecastPeriod `json:"daily"`
+				Hourly []ForecastPeriod `json:"hourly,omitempty"`
 			}{Daily: []ForecastPeriod{{Time: 1, AirTemperature: 20.0}}},
 			CurrentConditions: ForecastPeriod{Time: 1, AirTemperature: 20.0},
 		}
@@ -45,7 +45,8 @@ func TestAPIDataSource_FetchForecast_WithTransportOverride(t *testing.T) {
 	// Create a forecast response
 	fr := ForecastResponse{
 		Forecast: struct {
-			Daily []ForecastPeriod `json:"daily"`
+			Daily  []ForecastPeriod `json:"daily"`
+			Hourly []ForecastPeriod `json:"hourly,omitempty"`
 		}{Daily: []ForecastPeriod{{Time: 1, AirTemperature: 21.0}}},
 		CurrentConditions: ForecastPeriod{Time: 1, AirTemperature: 21.0},
 	}
@@ -0,0 +1,47 @@
+package weather
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// GetObservationsRange fetches the station's Tempest observations with
+// timestamps strictly between start and end, oldest first. It is used to fill
+// gaps in history after an outage.
+func GetObservationsRange(stationID int, token string, start, end time.Time) ([]*Observation, error) {
+	details, err := GetStationDetails(stationID, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get station details: %w", err)
+	}
+	deviceID, err := GetTempestDeviceID(details)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/observations/device/%d?time_start=%d&time_end=%d&token=%s",
+		BaseURL, deviceID, start.Unix(), end.Unix(), token)
+	resp, err := sharedClient.get(url, requestOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
+	}
+
+	var apiResp HistoricalResponse
+	if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse observations: %w", err)
+	}
+
+	var observations []*Observation
+	for _, obs := range parseDeviceObservations(apiResp.Obs) {
+		if obs.Timestamp > start.Unix() && obs.Timestamp < end.Unix() {
+			observations = append(observations, obs)
+		}
+	}
+	sort.Slice(observations, func(i, j int) bool { return observations[i].Timestamp < observations[j].Timestamp })
+	return observations, nil
+}
@@ -0,0 +1,46 @@
+package weather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetObservationsRange(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/swd/rest/stations/"):
+			_, _ = w.Write([]byte(`{"stations":[{"station_id":1,"devices":[{"device_id":77,"device_type":"ST"}]}]}`))
+		case r.URL.Path == "/swd/rest/observations/device/77":
+			query = r.URL.RawQuery
+			row := func(ts int) string {
+				return `[` + strconv.Itoa(ts) + `,0,1,2,90,0,1000,20,50,100,1,10,0,0,0,0,2.6,1]`
+			}
+			// Out of order, with both range boundaries included by the server
+			_, _ = w.Write([]byte(`{"obs":[` + row(1300) + `,` + row(1000) + `,` + row(1120) + `,` + row(1060) + `]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	restore := overrideTransportToTestServer(srv)
+	defer restore()
+
+	obs, err := GetObservationsRange(1, "tok", time.Unix(1000, 0), time.Unix(1300, 0))
+	if err != nil {
+		t.Fatalf("GetObservationsRange: %v", err)
+	}
+	if !strings.Contains(query, "time_start=1000") || !strings.Contains(query, "time_end=1300") {
+		t.Errorf("unexpected query %q", query)
+	}
+	if len(obs) != 2 || obs[0].Timestamp != 1060 || obs[1].Timestamp != 1120 {
+		t.Fatalf("expected the two observations inside the range, oldest first; got %d", len(obs))
+	}
+	if obs[0].AirTemperature != 20 || obs[0].WindAvg != 1 {
+		t.Errorf("fields not parsed: %+v", obs[0])
+	}
+}
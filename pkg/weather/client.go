@@ -7,12 +7,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"math/rand"
 	"net/http"
 	"regexp"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
@@ -22,9 +20,12 @@ import (
 	"github.com/chromedp/chromedp"
 )
 
-const (
-	BaseURL = "https://swd.weatherflow.com/swd/rest"
-)
+// DefaultBaseURL is the WeatherFlow REST API
+const DefaultBaseURL = "https://swd.weatherflow.com/swd/rest"
+
+// BaseURL is the REST API the client calls. --weatherflow-api-url replaces it,
+// e.g. with a cmd/fakeserver for tests and demos.
+var BaseURL = DefaultBaseURL
 
 type Device struct {
 	DeviceID     int    `json:"device_id"`
@@ -38,6 +39,7 @@ type Station struct {
 	StationName string   `json:"station_name"`
 	Latitude    float64  `json:"latitude"`
 	Longitude   float64  `json:"longitude"`
+	Timezone    string   `json:"timezone"`
 	Devices     []Device `json:"devices"`
 }
 
@@ -64,7 +66,9 @@ type HistoricalResponse struct {
 	Obs          [][]interface{}        `json:"obs"` // Device API returns array of arrays, not array of maps
 }
 
-// ForecastPeriod represents a single forecast period from the better_forecast API
+// ForecastPeriod represents a single forecast period from the better_forecast API.
+// Hourly periods cover the hour starting at Time and also carry Precip; the
+// daily ones have the high and low temperatures.
 type ForecastPeriod struct {
 	Time              int64   `json:"time"`
 	Icon              string  `json:"icon"`
@@ -78,8 +82,10 @@ type ForecastPeriod struct {
 	PrecipProbability int     `json:"precip_probability"`
 	PrecipIcon        string  `json:"precip_icon"`
 	PrecipType        string  `json:"precip_type"`
+	Precip            float64 `json:"precip,omitempty"` // hourly: forecast precipitation for the hour, mm
 	WindAvg           float64 `json:"wind_avg"`
 	WindDirection     int     `json:"wind_direction"`
+	WindDirectionCard string  `json:"wind_direction_cardinal,omitempty"`
 	WindGust          float64 `json:"wind_gust"`
 	UV                int     `json:"uv"`
 }
@@ -91,7 +97,8 @@ type ForecastResponse struct {
 	StationName string                 `json:"station_name"`
 	Timezone    string                 `json:"timezone"`
 	Forecast    struct {
-		Daily []ForecastPeriod `json:"daily"`
+		Daily  []ForecastPeriod `json:"daily"`
+		Hourly []ForecastPeriod `json:"hourly,omitempty"`
 	} `json:"forecast"`
 	CurrentConditions ForecastPeriod `json:"current_conditions"`
 }
@@ -99,23 +106,16 @@ type ForecastResponse struct {
 // GetStations retrieves all weather stations associated with the provided API token.
 func GetStations(token string) ([]Station, error) {
 	url := fmt.Sprintf("%s/stations?token=%s", BaseURL, token)
-	resp, err := http.Get(url)
+	resp, err := sharedClient.get(url, requestOptions{})
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
-
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
 	var stationsResp StationsResponse
-	err = json.Unmarshal(body, &stationsResp)
+	err = json.Unmarshal(resp.Body, &stationsResp)
 	if err != nil {
 		return nil, err
 	}
@@ -131,23 +131,16 @@ func GetObservation(stationID int, token string) (*Observation, error) {
 
 // GetObservationFromURL fetches weather data from a custom URL (e.g., generated weather endpoint)
 func GetObservationFromURL(url string) (*Observation, error) {
-	resp, err := http.Get(url)
+	resp, err := sharedClient.get(url, requestOptions{})
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
-
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
 	var obsResp ObservationResponse
-	err = json.Unmarshal(body, &obsResp)
+	err = json.Unmarshal(resp.Body, &obsResp)
 	if err != nil {
 		return nil, err
 	}
@@ -156,9 +149,13 @@ func GetObservationFromURL(url string) (*Observation, error) {
 		return nil, fmt.Errorf("no observations found")
 	}
 
-	latest := obsResp.Obs[0] // latest is first
+	return stationObservation(obsResp.Obs[0]), nil // latest is first
+}
 
-	obs := &Observation{
+// stationObservation converts an observation of the station REST API, keyed
+// by field name, to an Observation
+func stationObservation(latest map[string]interface{}) *Observation {
+	return &Observation{
 		Timestamp:            int64(getFloat64(latest["timestamp"])),
 		WindLull:             getFloat64(latest["wind_lull"]),
 		WindAvg:              getFloat64(latest["wind_avg"]),
@@ -170,16 +167,15 @@ func GetObservationFromURL(url string) (*Observation, error) {
 		Illuminance:          getFloat64(latest["brightness"]), // API uses "brightness" instead of "illuminance"
 		UV:                   getInt(latest["uv"]),
 		SolarRadiation:       getFloat64(latest["solar_radiation"]),
-		RainAccumulated:      getFloat64(latest["precip"]),                 // Incremental rain since last obs
-		RainDailyTotal:       getFloat64(latest["precip_accum_local_day"]), // Total rain since midnight (mm)
+		RainAccumulated:      getFloat64(latest["precip"]),                       // Incremental rain since last obs
+		RainDailyTotal:       getFloat64(latest["precip_accum_local_day"]),       // Total rain since midnight (mm)
+		NCRainDailyTotal:     getFloat64(latest["precip_accum_local_day_final"]), // Rain Check corrected total (mm)
 		PrecipitationType:    getInt(latest["precipitation_type"]),
 		LightningStrikeAvg:   getFloat64(latest["lightning_strike_avg"]),
 		LightningStrikeCount: getInt(latest["lightning_strike_count"]),
 		Battery:              getFloat64(latest["battery"]),
 		ReportInterval:       getInt(latest["report_interval"]),
 	}
-
-	return obs, nil
 }
 
 func getFloat64(value interface{}) float64 {
@@ -204,23 +200,16 @@ func getInt(value interface{}) int {
 
 func GetStationDetails(stationID int, token string) (*Station, error) {
 	url := fmt.Sprintf("%s/stations/%d?token=%s", BaseURL, stationID, token)
-	resp, err := http.Get(url)
+	resp, err := sharedClient.get(url, requestOptions{})
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
-
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
 	var stationResp StationDetailsResponse
-	err = json.Unmarshal(body, &stationResp)
+	err = json.Unmarshal(resp.Body, &stationResp)
 	if err != nil {
 		return nil, err
 	}
@@ -310,7 +299,7 @@ func GetHistoricalObservationsWithProgress(stationID int, token string, logLevel
 			fmt.Printf("DEBUG: Fetching observations for %s (day_offset=%d)...\n", dayName, dayOffset)
 		}
 
-		resp, err := http.Get(url)
+		resp, err := sharedClient.get(url, requestOptions{})
 		if err != nil {
 			errorCount++
 			fmt.Printf("ERROR: API call failed for %s: %v\n", dayName, err)
@@ -319,24 +308,14 @@ func GetHistoricalObservationsWithProgress(stationID int, token string, logLevel
 		}
 
 		if resp.StatusCode != http.StatusOK {
-			_ = resp.Body.Close()
 			errorCount++
 			fmt.Printf("ERROR: API call for %s returned HTTP %d\n", dayName, resp.StatusCode)
 			time.Sleep(100 * time.Millisecond)
 			continue
 		}
 
-		body, err := io.ReadAll(resp.Body)
-		_ = resp.Body.Close()
-		if err != nil {
-			errorCount++
-			fmt.Printf("ERROR: Error reading response for %s: %v\n", dayName, err)
-			time.Sleep(100 * time.Millisecond)
-			continue
-		}
-
 		var apiResp HistoricalResponse
-		if err := json.Unmarshal(body, &apiResp); err != nil {
+		if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
 			errorCount++
 			fmt.Printf("ERROR: Error parsing JSON for %s: %v\n", dayName, err)
 			time.Sleep(100 * time.Millisecond)
@@ -439,7 +418,9 @@ func GetHistoricalObservationsWithProgress(stationID int, token string, logLevel
 // [0]: timestamp, [1]: wind_lull, [2]: wind_avg, [3]: wind_gust, [4]: wind_direction, [5]: ?,
 // [6]: station_pressure, [7]: air_temperature, [8]: relative_humidity, [9]: illuminance,
 // [10]: uv, [11]: solar_radiation, [12]: rain_accumulated, [13]: precipitation_type,
-// [14]: lightning_strike_avg_distance, [15]: lightning_strike_count, [16]: battery, [17]: report_interval
+// [14]: lightning_strike_avg_distance, [15]: lightning_strike_count, [16]: battery, [17]: report_interval,
+// [18]: local_day_rain_accumulation, [19]: nc_rain_accumulation, [20]: local_day_nc_rain_accumulation
+// ([18]-[20] are only present once Rain Check has processed the observation)
 func parseDeviceObservations(obsData [][]interface{}) []*Observation {
 	var observations []*Observation
 
@@ -468,6 +449,10 @@ func parseDeviceObservations(obsData [][]interface{}) []*Observation {
 			Battery:              getFloat64(obsArray[16]),       // battery
 			ReportInterval:       getInt(obsArray[17]),           // report_interval
 		}
+		if len(obsArray) > 20 {
+			obs.NCRainAccumulated = getFloat64(obsArray[19]) // nc_rain_accumulation
+			obs.NCRainDailyTotal = getFloat64(obsArray[20])  // local_day_nc_rain_accumulation
+		}
 		observations = append(observations, obs)
 	}
 
@@ -519,23 +504,16 @@ var _ = filterToOneMinuteIncrements
 func GetForecast(stationID int, token string) (*ForecastResponse, error) {
 	url := fmt.Sprintf("%s/better_forecast?station_id=%d&token=%s", BaseURL, stationID, token)
 
-	resp, err := http.Get(url)
+	resp, err := sharedClient.get(url, requestOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch forecast data: %v", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
-
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("forecast API request failed with status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read forecast response: %v", err)
-	}
-
 	var forecastResp ForecastResponse
-	if err := json.Unmarshal(body, &forecastResp); err != nil {
+	if err := json.Unmarshal(resp.Body, &forecastResp); err != nil {
 		return nil, fmt.Errorf("failed to parse forecast JSON: %v", err)
 	}
 
@@ -573,75 +551,21 @@ func GetAllHistoricalObservations(stationID int, token string, logLevel string,
 			fmt.Printf("DEBUG: Fetching day_offset=%d from %s\n", dayOffset, url)
 		}
 
-		// Attempt request with retries for 429 (rate limit). Use Retry-After header if provided.
-		var resp *http.Response
+		// 429 and 5xx answers are retried by the shared client; network errors
+		// are retried here a few times with a short jittered pause
+		var resp *apiResponse
 		var err error
-		maxRetries := 6
-		attempt := 0
-		for {
-			resp, err = http.Get(url)
-			if err != nil {
-				// On transient error, retry a few times
-				logger.Warn("API call failed for day_offset=%d: %v", dayOffset, err)
-				attempt++
-				if attempt > maxRetries {
-					consecutiveEmpty++
-					break
-				}
-				// small backoff with jitter
-				backoffMs := 150 + rand.Intn(200)
-				time.Sleep(time.Duration(backoffMs) * time.Millisecond)
-				continue
-			}
-
-			if resp.StatusCode == http.StatusTooManyRequests {
-				// Read Retry-After header if present
-				ra := resp.Header.Get("Retry-After")
-				_ = resp.Body.Close()
-				waitSec := 0
-				if ra != "" {
-					if n, perr := strconv.Atoi(ra); perr == nil {
-						waitSec = n
-					}
-				}
-				// fallback exponential backoff with jitter
-				if waitSec <= 0 {
-					base := 1 << attempt // 1,2,4,8...
-					jitter := rand.Intn(base + 1)
-					waitSec = base + jitter
-					if waitSec > 60 {
-						waitSec = 60
-					}
-				}
-				logger.Warn("API returned 429 for day_offset=%d (attempt %d/%d) - sleeping %ds before retry", dayOffset, attempt+1, maxRetries, waitSec)
-				time.Sleep(time.Duration(waitSec) * time.Second)
-				attempt++
-				if attempt > maxRetries {
-					consecutiveEmpty++
-					break
-				}
-				continue
-			}
-
-			if resp.StatusCode != http.StatusOK {
-				_ = resp.Body.Close()
-				logger.Warn("API call for day_offset=%d returned HTTP %d", dayOffset, resp.StatusCode)
-				time.Sleep(150 * time.Millisecond)
-				consecutiveEmpty++
-				if consecutiveEmpty >= 3 {
-					break
-				}
+		for attempt := 0; attempt <= 6; attempt++ {
+			if resp, err = sharedClient.get(url, requestOptions{}); err == nil {
 				break
 			}
-
-			// success
-			break
+			logger.Warn("API call failed for day_offset=%d: %v", dayOffset, err)
+			time.Sleep(time.Duration(150+rand.Intn(200)) * time.Millisecond)
 		}
-
-		body, err := io.ReadAll(resp.Body)
-		_ = resp.Body.Close()
-		if err != nil {
-			fmt.Printf("ERROR: Error reading response for day_offset=%d: %v\n", dayOffset, err)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			if err == nil {
+				logger.Warn("API call for day_offset=%d returned HTTP %d", dayOffset, resp.StatusCode)
+			}
 			time.Sleep(150 * time.Millisecond)
 			consecutiveEmpty++
 			if consecutiveEmpty >= 3 {
@@ -651,7 +575,7 @@ func GetAllHistoricalObservations(stationID int, token string, logLevel string,
 		}
 
 		var apiResp HistoricalResponse
-		if err := json.Unmarshal(body, &apiResp); err != nil {
+		if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
 			fmt.Printf("ERROR: Error parsing JSON for day_offset=%d: %v\n", dayOffset, err)
 			time.Sleep(150 * time.Millisecond)
 			consecutiveEmpty++
@@ -936,15 +860,13 @@ func GetStationStatus(stationID int, logLevel string) (*StationStatus, error) {
 		fmt.Printf("DEBUG: Fetching station status from %s\n", url)
 	}
 
-	resp, err := http.Get(url)
+	resp, err := sharedClient.get(url, requestOptions{})
 	if err != nil {
 		if logLevel == "debug" {
 			fmt.Printf("DEBUG: HTTP request failed: %v\n", err)
 		}
 		return nil, fmt.Errorf("failed to fetch station status: %v", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
-
 	if resp.StatusCode != http.StatusOK {
 		if logLevel == "debug" {
 			fmt.Printf("DEBUG: HTTP request returned status %d\n", resp.StatusCode)
@@ -952,14 +874,7 @@ func GetStationStatus(stationID int, logLevel string) (*StationStatus, error) {
 		return nil, fmt.Errorf("station status request failed with status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		if logLevel == "debug" {
-			fmt.Printf("DEBUG: Failed to read response body: %v\n", err)
-		}
-		return nil, fmt.Errorf("failed to read station status response: %v", err)
-	}
-
+	body := resp.Body
 	if logLevel == "debug" {
 		fmt.Printf("DEBUG: Retrieved %d bytes of HTML content\n", len(body))
 	}
@@ -0,0 +1,172 @@
+package weather
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// IndoorStaleAfter is how long a pushed indoor reading is used for advice
+const IndoorStaleAfter = 30 * time.Minute
+
+// comfortMargin is how much (°C) the outdoor air must differ from indoors
+// before opening windows is worth it
+const comfortMargin = 1.0
+
+// comfortMaxGust is the gust (m/s) above which windows stay closed
+const comfortMaxGust = 10.0
+
+// IndoorReading is an indoor temperature and humidity pushed by an external
+// sensor
+type IndoorReading struct {
+	Temperature float64   `json:"temperature"` // °C
+	Humidity    float64   `json:"humidity"`    // %
+	Time        time.Time `json:"time"`
+}
+
+// ComfortTargets is the indoor comfort range the advisor aims for
+type ComfortTargets struct {
+	TempMin     float64 `json:"tempMin"`     // °C
+	TempMax     float64 `json:"tempMax"`     // °C
+	HumidityMax float64 `json:"humidityMax"` // %
+}
+
+// DefaultComfortTargets are used when no range is configured
+var DefaultComfortTargets = ComfortTargets{TempMin: 20, TempMax: 24, HumidityMax: 60}
+
+// ComfortAdvice is the recommendation for ventilating with outdoor air
+type ComfortAdvice struct {
+	OpenWindows        bool           `json:"openWindows"`
+	Reason             string         `json:"reason"`
+	Indoor             *IndoorReading `json:"indoor,omitempty"`
+	IndoorDewPoint     *float64       `json:"indoorDewPoint,omitempty"` // °C
+	OutdoorTemperature *float64       `json:"outdoorTemperature,omitempty"`
+	OutdoorHumidity    *float64       `json:"outdoorHumidity,omitempty"`
+	OutdoorDewPoint    *float64       `json:"outdoorDewPoint,omitempty"`
+	Targets            ComfortTargets `json:"targets"`
+}
+
+// DewPoint returns the dew point in °C (Magnus formula)
+func DewPoint(temperature, humidity float64) float64 {
+	if humidity <= 0 {
+		return math.Inf(-1)
+	}
+	gamma := math.Log(humidity/100) + 17.27*temperature/(237.3+temperature)
+	return 237.3 * gamma / (17.27 - gamma)
+}
+
+// AdviseComfort compares the indoor reading with the outdoor observation and
+// recommends opening the windows when outdoor air brings the indoor
+// temperature or humidity toward the targets without making the other worse.
+// Rain and strong gusts always keep the windows closed.
+func AdviseComfort(indoor *IndoorReading, outdoor *Observation, targets ComfortTargets, now time.Time) ComfortAdvice {
+	advice := ComfortAdvice{Targets: targets}
+	if indoor == nil {
+		advice.Reason = "waiting for an indoor reading"
+		return advice
+	}
+	in := *indoor
+	advice.Indoor = &in
+	inDew := DewPoint(indoor.Temperature, indoor.Humidity)
+	advice.IndoorDewPoint = &inDew
+	if now.Sub(indoor.Time) > IndoorStaleAfter {
+		advice.Reason = fmt.Sprintf("indoor reading is older than %v", IndoorStaleAfter)
+		return advice
+	}
+	if outdoor == nil {
+		advice.Reason = "waiting for an outdoor observation"
+		return advice
+	}
+	outTemp, outHumidity := outdoor.AirTemperature, outdoor.RelativeHumidity
+	outDew := DewPoint(outTemp, outHumidity)
+	advice.OutdoorTemperature, advice.OutdoorHumidity, advice.OutdoorDewPoint = &outTemp, &outHumidity, &outDew
+
+	// Outdoor air never leaves indoors wetter than this dew point
+	humidityLimit := DewPoint(math.Max(indoor.Temperature, targets.TempMin), targets.HumidityMax)
+	tooHumidOutside := outDew > math.Max(inDew, humidityLimit)
+
+	switch {
+	case outdoor.RainAccumulated > 0 || outdoor.PrecipitationType > 0:
+		advice.Reason = "it is raining"
+	case outdoor.WindGust > comfortMaxGust:
+		advice.Reason = fmt.Sprintf("gusts of %.0f m/s", outdoor.WindGust)
+	case tooHumidOutside:
+		advice.Reason = fmt.Sprintf("outdoor air is more humid (dew point %.1f°C vs %.1f°C indoors)", outDew, inDew)
+	case indoor.Temperature > targets.TempMax && outTemp <= indoor.Temperature-comfortMargin:
+		advice.OpenWindows = true
+		advice.Reason = fmt.Sprintf("cooler outside (%.1f°C vs %.1f°C indoors)", outTemp, indoor.Temperature)
+	case indoor.Temperature < targets.TempMin && outTemp >= indoor.Temperature+comfortMargin:
+		advice.OpenWindows = true
+		advice.Reason = fmt.Sprintf("warmer outside (%.1f°C vs %.1f°C indoors)", outTemp, indoor.Temperature)
+	case indoor.Humidity > targets.HumidityMax && outDew <= inDew-comfortMargin &&
+		outTemp >= targets.TempMin-comfortMargin && outTemp <= math.Max(indoor.Temperature, targets.TempMax):
+		advice.OpenWindows = true
+		advice.Reason = fmt.Sprintf("drier outside (dew point %.1f°C vs %.1f°C indoors)", outDew, inDew)
+	case indoor.Temperature >= targets.TempMin && indoor.Temperature <= targets.TempMax &&
+		outTemp >= targets.TempMin && outTemp <= targets.TempMax:
+		advice.OpenWindows = true
+		advice.Reason = "outdoor air is within the comfort range"
+	case indoor.Temperature > targets.TempMax || indoor.Temperature < targets.TempMin:
+		advice.Reason = fmt.Sprintf("outside (%.1f°C) would not help indoors (%.1f°C)", outTemp, indoor.Temperature)
+	default:
+		advice.Reason = fmt.Sprintf("outside (%.1f°C) is outside the comfort range", outTemp)
+	}
+	return advice
+}
+
+// ComfortAdvisor keeps the latest indoor reading and outdoor observation and
+// reports when the recommendation changes
+type ComfortAdvisor struct {
+	mu       sync.Mutex
+	targets  ComfortTargets
+	indoor   *IndoorReading
+	outdoor  *Observation
+	open     *bool // last reported recommendation
+	onAdvice func(ComfortAdvice)
+}
+
+// NewComfortAdvisor creates an advisor. onAdvice, if set, receives the first
+// advice and every advice that changes the recommendation; it is called with
+// the advisor locked and must not call back into it.
+func NewComfortAdvisor(targets ComfortTargets, onAdvice func(ComfortAdvice)) *ComfortAdvisor {
+	return &ComfortAdvisor{targets: targets, onAdvice: onAdvice}
+}
+
+// SetIndoor records an indoor reading and returns the new advice
+func (a *ComfortAdvisor) SetIndoor(r IndoorReading) ComfortAdvice {
+	a.mu.Lock()
+	a.indoor = &r
+	a.mu.Unlock()
+	return a.update()
+}
+
+// SetOutdoor records an outdoor observation and returns the new advice. A
+// stale indoor reading turns the recommendation off at the next observation.
+func (a *ComfortAdvisor) SetOutdoor(obs Observation) ComfortAdvice {
+	a.mu.Lock()
+	a.outdoor = &obs
+	a.mu.Unlock()
+	return a.update()
+}
+
+// Advice returns the advice for the latest readings
+func (a *ComfortAdvisor) Advice() ComfortAdvice {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return AdviseComfort(a.indoor, a.outdoor, a.targets, time.Now())
+}
+
+func (a *ComfortAdvisor) update() ComfortAdvice {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	advice := AdviseComfort(a.indoor, a.outdoor, a.targets, time.Now())
+	if a.open == nil || *a.open != advice.OpenWindows {
+		open := advice.OpenWindows
+		a.open = &open
+		if a.onAdvice != nil {
+			a.onAdvice(advice)
+		}
+	}
+	return advice
+}
@@ -0,0 +1,78 @@
+package weather
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDewPoint(t *testing.T) {
+	if dp := DewPoint(20, 100); math.Abs(dp-20) > 0.01 {
+		t.Errorf("DewPoint(20, 100) = %.2f, want 20", dp)
+	}
+	if dp := DewPoint(25, 50); math.Abs(dp-13.9) > 0.1 {
+		t.Errorf("DewPoint(25, 50) = %.2f, want about 13.9", dp)
+	}
+}
+
+func TestAdviseComfort(t *testing.T) {
+	now := time.Date(2026, 7, 1, 20, 0, 0, 0, time.UTC)
+	targets := DefaultComfortTargets
+	indoor := func(temp, humidity float64) *IndoorReading {
+		return &IndoorReading{Temperature: temp, Humidity: humidity, Time: now.Add(-time.Minute)}
+	}
+	tests := []struct {
+		name    string
+		indoor  *IndoorReading
+		outdoor *Observation
+		open    bool
+	}{
+		{"no indoor reading", nil, &Observation{AirTemperature: 18, RelativeHumidity: 50}, false},
+		{"stale indoor reading", &IndoorReading{Temperature: 27, Humidity: 50, Time: now.Add(-time.Hour)}, &Observation{AirTemperature: 18, RelativeHumidity: 50}, false},
+		{"no observation", indoor(27, 50), nil, false},
+		{"cooler outside", indoor(27, 50), &Observation{AirTemperature: 18, RelativeHumidity: 60}, true},
+		{"hotter outside", indoor(27, 50), &Observation{AirTemperature: 31, RelativeHumidity: 30}, false},
+		{"humid outside", indoor(27, 40), &Observation{AirTemperature: 22, RelativeHumidity: 95}, false},
+		{"raining", indoor(27, 50), &Observation{AirTemperature: 18, RelativeHumidity: 60, PrecipitationType: 1}, false},
+		{"gusty", indoor(27, 50), &Observation{AirTemperature: 18, RelativeHumidity: 60, WindGust: 15}, false},
+		{"warmer outside", indoor(17, 50), &Observation{AirTemperature: 21, RelativeHumidity: 40}, true},
+		{"drier outside", indoor(23, 75), &Observation{AirTemperature: 22, RelativeHumidity: 40}, true},
+		{"both comfortable", indoor(22, 50), &Observation{AirTemperature: 21, RelativeHumidity: 50}, true},
+		{"cold outside", indoor(22, 50), &Observation{AirTemperature: 5, RelativeHumidity: 50}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			advice := AdviseComfort(tc.indoor, tc.outdoor, targets, now)
+			if advice.OpenWindows != tc.open {
+				t.Errorf("OpenWindows = %v, want %v (%s)", advice.OpenWindows, tc.open, advice.Reason)
+			}
+			if advice.Reason == "" {
+				t.Error("advice has no reason")
+			}
+		})
+	}
+}
+
+func TestComfortAdvisorReportsChanges(t *testing.T) {
+	var reported []bool
+	advisor := NewComfortAdvisor(DefaultComfortTargets, func(advice ComfortAdvice) {
+		reported = append(reported, advice.OpenWindows)
+	})
+	advisor.SetOutdoor(Observation{AirTemperature: 18, RelativeHumidity: 60})
+	advisor.SetIndoor(IndoorReading{Temperature: 27, Humidity: 50, Time: time.Now()})
+	advisor.SetIndoor(IndoorReading{Temperature: 26, Humidity: 50, Time: time.Now()})
+	advisor.SetOutdoor(Observation{AirTemperature: 18, RelativeHumidity: 60, RainAccumulated: 0.2})
+
+	want := []bool{false, true, false}
+	if len(reported) != len(want) {
+		t.Fatalf("reported %v, want %v", reported, want)
+	}
+	for i := range want {
+		if reported[i] != want[i] {
+			t.Fatalf("reported %v, want %v", reported, want)
+		}
+	}
+	if advice := advisor.Advice(); advice.OpenWindows || advice.Indoor == nil || advice.Indoor.Temperature != 26 {
+		t.Errorf("Advice() = %+v", advice)
+	}
+}
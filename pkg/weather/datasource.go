@@ -43,6 +43,9 @@ const (
 
 	// DataSourceCustomURL represents a custom station URL
 	DataSourceCustomURL DataSourceType = "custom-url"
+
+	// DataSourceIngest represents observations pushed to /api/ingest
+	DataSourceIngest DataSourceType = "ingest"
 )
 
 // DataSourceStatus provides unified status information for any data source
@@ -53,12 +56,23 @@ type DataSourceStatus struct {
 	ObservationCount int64          `json:"observationCount"`
 
 	// Optional fields depending on source type
-	StationName  string `json:"stationName,omitempty"`
-	StationIP    string `json:"stationIP,omitempty"`    // For UDP
-	SerialNumber string `json:"serialNumber,omitempty"` // For UDP
-	PacketCount  int64  `json:"packetCount,omitempty"`  // For UDP
-	Location     string `json:"location,omitempty"`     // For Generated
-	Season       string `json:"season,omitempty"`       // For Generated
-	ClimateZone  string `json:"climateZone,omitempty"`  // For Generated
-	CustomURL    string `json:"customURL,omitempty"`    // For Custom URL
+	StationName  string          `json:"stationName,omitempty"`
+	StationIP    string          `json:"stationIP,omitempty"`    // For UDP
+	SerialNumber string          `json:"serialNumber,omitempty"` // For UDP
+	PacketCount  int64           `json:"packetCount,omitempty"`  // For UDP
+	Devices      []UDPDevice     `json:"devices,omitempty"`      // For UDP: every device heard, by serial
+	Link         *UDPLinkStats   `json:"link,omitempty"`         // For UDP: observation loss and jitter
+	Parser       *UDPParserStats `json:"parser,omitempty"`       // For UDP: decoded messages and unknown types and fields
+	Location     string          `json:"location,omitempty"`     // For Generated
+	Season       string          `json:"season,omitempty"`       // For Generated
+	ClimateZone  string          `json:"climateZone,omitempty"`  // For Generated
+	CustomURL    string          `json:"customURL,omitempty"`    // For Custom URL
+
+	// Request failures, for API-backed sources
+	ErrorCount     int64  `json:"errorCount,omitempty"`     // Failed requests since start
+	ErrorsLastHour int    `json:"errorsLastHour,omitempty"` // Failed requests in the last hour
+	LastError      string `json:"lastError,omitempty"`
+
+	// Merge counters when UDP and API observations are combined
+	Merge *MergeStats `json:"merge,omitempty"`
 }
@@ -17,6 +17,7 @@ type APIDataSource struct {
 	customURL     string // For custom station URLs (generated weather, etc.)
 	generated     bool   // true when this API data source is using generated weather endpoint
 	generatedPath string // configured path used to identify generated weather endpoint
+	intervals     PollIntervals
 
 	mu                sync.RWMutex
 	latestObservation *Observation
@@ -27,12 +28,16 @@ type APIDataSource struct {
 	lastUpdate        time.Time
 	running           bool
 	wg                sync.WaitGroup
+	errorCount        int64
+	errorTimes        []time.Time // failures within the last hour
+	lastError         string
 }
 
 // APIDataSourceOptions holds optional parameters for creating APIDataSource
 type APIDataSourceOptions struct {
 	CustomURL     string
 	GeneratedPath string
+	Intervals     PollIntervals // observation and forecast cadences; zero uses the defaults
 }
 
 // NewAPIDataSource creates a new API-based data source with options.
@@ -43,6 +48,7 @@ func NewAPIDataSource(stationID int, token, stationName string, opts APIDataSour
 		stationName:     stationName,
 		customURL:       opts.CustomURL,
 		generatedPath:   opts.GeneratedPath,
+		intervals:       opts.Intervals.withDefaults(),
 		observationChan: make(chan Observation, 100),
 		stopChan:        make(chan struct{}),
 	}
@@ -141,9 +147,39 @@ func (a *APIDataSource) GetStatus() DataSourceStatus {
 		ObservationCount: a.observationCount,
 		StationName:      a.stationName,
 		CustomURL:        a.customURL,
+		ErrorCount:       a.errorCount,
+		ErrorsLastHour:   countSince(a.errorTimes, time.Now().Add(-time.Hour)),
+		LastError:        a.lastError,
 	}
 }
 
+// recordError tracks a failed request for the error counters in GetStatus
+func (a *APIDataSource) recordError(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	a.errorCount++
+	a.lastError = err.Error()
+	cutoff := now.Add(-time.Hour)
+	kept := a.errorTimes[:0]
+	for _, t := range a.errorTimes {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	a.errorTimes = append(kept, now)
+}
+
+func countSince(times []time.Time, cutoff time.Time) int {
+	n := 0
+	for _, t := range times {
+		if t.After(cutoff) {
+			n++
+		}
+	}
+	return n
+}
+
 // GetType returns the data source type
 func (a *APIDataSource) GetType() DataSourceType {
 	if a.customURL != "" {
@@ -155,18 +191,19 @@ func (a *APIDataSource) GetType() DataSourceType {
 	return DataSourceAPI
 }
 
-// pollLoop is the main polling loop that fetches data every 60 seconds
+// pollLoop fetches observations and the forecast on their own jittered
+// intervals until the data source is stopped
 func (a *APIDataSource) pollLoop() {
-	logger.Info("Starting API data source polling loop (60 second interval)")
+	logger.Info("Starting API data source polling loop (observations every %v, forecast every %v)", a.intervals.Observation, a.intervals.Forecast)
 
 	// Initial fetch
 	a.fetchObservation()
 	a.fetchForecast()
 
-	ticker := time.NewTicker(60 * time.Second)
-	defer ticker.Stop()
-
-	forecastCounter := 0
+	obsTimer := time.NewTimer(jittered(a.intervals.Observation))
+	defer obsTimer.Stop()
+	forecastTimer := time.NewTimer(jittered(a.intervals.Forecast))
+	defer forecastTimer.Stop()
 
 	for {
 		select {
@@ -175,15 +212,13 @@ func (a *APIDataSource) pollLoop() {
 			a.wg.Done()
 			return
 
-		case <-ticker.C:
+		case <-obsTimer.C:
 			a.fetchObservation()
+			obsTimer.Reset(jittered(a.intervals.Observation))
 
-			// Update forecast every 30 minutes (30 ticks)
-			forecastCounter++
-			if forecastCounter >= 30 {
-				a.fetchForecast()
-				forecastCounter = 0
-			}
+		case <-forecastTimer.C:
+			a.fetchForecast()
+			forecastTimer.Reset(jittered(a.intervals.Forecast))
 		}
 	}
 }
@@ -200,6 +235,7 @@ func (a *APIDataSource) fetchObservation() {
 		obs, err = GetObservationFromURL(a.customURL)
 		if err != nil {
 			logger.Error("Error getting observation from URL %s: %v", a.customURL, err)
+			a.recordError(err)
 			return
 		}
 		logger.Debug("Successfully fetched observation from custom URL: %s", a.customURL)
@@ -208,6 +244,7 @@ func (a *APIDataSource) fetchObservation() {
 		obs, err = GetObservation(a.stationID, a.token)
 		if err != nil {
 			logger.Error("Error getting observation from API: %v", err)
+			a.recordError(err)
 			return
 		}
 		logger.Debug("Successfully fetched observation from WeatherFlow API")
@@ -248,6 +285,7 @@ func (a *APIDataSource) fetchForecast() {
 	forecast, err := GetForecast(a.stationID, a.token)
 	if err != nil {
 		logger.Error("Error getting forecast: %v", err)
+		a.recordError(err)
 		return
 	}
 
@@ -1,6 +1,7 @@
 package weather
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -92,3 +93,23 @@ func TestAPIDataSource_GeneratedDetectionAndFetch(t *testing.T) {
 		t.Fatalf("Stop error: %v", err)
 	}
 }
+
+func TestAPIDataSource_ErrorCounters(t *testing.T) {
+	ds := NewAPIDataSource(1, "token", "Test Station", APIDataSourceOptions{})
+	ds.errorTimes = []time.Time{time.Now().Add(-2 * time.Hour)}
+	ds.errorCount = 1
+
+	ds.recordError(fmt.Errorf("401 Unauthorized"))
+	ds.recordError(fmt.Errorf("timeout"))
+
+	status := ds.GetStatus()
+	if status.ErrorCount != 3 {
+		t.Errorf("ErrorCount = %d, want 3", status.ErrorCount)
+	}
+	if status.ErrorsLastHour != 2 {
+		t.Errorf("ErrorsLastHour = %d, want 2", status.ErrorsLastHour)
+	}
+	if status.LastError != "timeout" {
+		t.Errorf("LastError = %q, want timeout", status.LastError)
+	}
+}
@@ -0,0 +1,109 @@
+// Package weather provides the push data source fed through /api/ingest.
+package weather
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+)
+
+// IngestDataSource implements the DataSource interface for observations that
+// other hardware pushes to the web server, e.g. an Ecowitt gateway through a
+// converter. It has no forecast.
+type IngestDataSource struct {
+	stationName string
+
+	mu                sync.RWMutex
+	running           bool
+	latestObservation *Observation
+	lastUpdate        time.Time
+	observationCount  int64
+	rejected          int64
+	observationChan   chan Observation
+}
+
+// NewIngestDataSource creates a data source for pushed observations
+func NewIngestDataSource(stationName string) *IngestDataSource {
+	return &IngestDataSource{
+		stationName:     stationName,
+		observationChan: make(chan Observation, 100),
+	}
+}
+
+// Start makes the source accept pushed observations
+func (s *IngestDataSource) Start() (<-chan Observation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = true
+	logger.Info("Ingest data source started, waiting for observations at /api/ingest")
+	return s.observationChan, nil
+}
+
+// Stop makes the source reject pushed observations
+func (s *IngestDataSource) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+	return nil
+}
+
+// Push queues an observation for the service. Observations that are not
+// newer than the latest one are rejected, so retries and overlapping batches
+// are harmless.
+func (s *IngestDataSource) Push(obs Observation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.running {
+		return fmt.Errorf("ingest data source is not running")
+	}
+	if s.latestObservation != nil && obs.Timestamp <= s.latestObservation.Timestamp {
+		s.rejected++
+		return fmt.Errorf("observation at %d is not newer than %d", obs.Timestamp, s.latestObservation.Timestamp)
+	}
+	select {
+	case s.observationChan <- obs:
+	default:
+		s.rejected++
+		return fmt.Errorf("ingest queue is full")
+	}
+	s.latestObservation = &obs
+	s.lastUpdate = time.Now()
+	s.observationCount++
+	return nil
+}
+
+// GetLatestObservation returns the most recent pushed observation
+func (s *IngestDataSource) GetLatestObservation() *Observation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.latestObservation
+}
+
+// GetForecast returns nil; pushed data has no forecast
+func (s *IngestDataSource) GetForecast() *ForecastResponse {
+	return nil
+}
+
+// GetStatus returns the current status
+func (s *IngestDataSource) GetStatus() DataSourceStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	status := DataSourceStatus{
+		Type:             DataSourceIngest,
+		Active:           s.running,
+		LastUpdate:       s.lastUpdate,
+		ObservationCount: s.observationCount,
+		StationName:      s.stationName,
+	}
+	if s.rejected > 0 {
+		status.ErrorCount = s.rejected
+	}
+	return status
+}
+
+// GetType returns the data source type
+func (s *IngestDataSource) GetType() DataSourceType {
+	return DataSourceIngest
+}
@@ -0,0 +1,163 @@
+// Package weather provides the merged UDP + API data source implementation.
+package weather
+
+import (
+	"sync"
+
+	"tempest-homekit-go/pkg/logger"
+)
+
+// MergedDataSource reads a station over UDP and the REST API at the same time
+// and publishes one stream of observations combined by an ObservationMerger.
+// It reports itself as a UDP source; the forecast comes from the API source.
+type MergedDataSource struct {
+	udp    DataSource
+	api    DataSource
+	merger *ObservationMerger
+
+	mu                sync.RWMutex
+	latestObservation *Observation
+	observationChan   chan Observation
+	stopChan          chan struct{}
+	running           bool
+	wg                sync.WaitGroup
+}
+
+// NewMergedDataSource combines a UDP source (preferred) with an API source
+func NewMergedDataSource(udp, api DataSource) *MergedDataSource {
+	return &MergedDataSource{
+		udp:             udp,
+		api:             api,
+		merger:          NewObservationMerger(),
+		observationChan: make(chan Observation, 100),
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Start starts both sources. A failing API source only disables the merge;
+// a failing UDP source is an error.
+func (m *MergedDataSource) Start() (<-chan Observation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running {
+		return m.observationChan, nil
+	}
+
+	udpCh, err := m.udp.Start()
+	if err != nil {
+		return nil, err
+	}
+	apiCh, err := m.api.Start()
+	if err != nil {
+		logger.Warn("API data source failed to start, continuing with UDP only: %v", err)
+		apiCh = nil
+	}
+
+	m.running = true
+	m.wg.Add(1)
+	go m.mergeLoop(udpCh, apiCh)
+	logger.Info("Merged UDP + API data source started")
+	return m.observationChan, nil
+}
+
+func (m *MergedDataSource) mergeLoop(udpCh, apiCh <-chan Observation) {
+	defer m.wg.Done()
+	for udpCh != nil || apiCh != nil {
+		var src ObservationSource
+		var obs Observation
+		var ok bool
+		select {
+		case <-m.stopChan:
+			return
+		case obs, ok = <-udpCh:
+			if !ok {
+				udpCh = nil
+				continue
+			}
+			src = SourceUDP
+		case obs, ok = <-apiCh:
+			if !ok {
+				apiCh = nil
+				continue
+			}
+			src = SourceAPI
+		}
+
+		merged, publish := m.merger.Add(src, obs)
+		if !publish {
+			logger.Debug("Merger dropped %s observation at %d", src, obs.Timestamp)
+			continue
+		}
+		m.mu.Lock()
+		m.latestObservation = &merged
+		m.mu.Unlock()
+
+		select {
+		case m.observationChan <- merged:
+		case <-m.stopChan:
+			return
+		}
+	}
+}
+
+// Stop stops both sources and closes the observation channel
+func (m *MergedDataSource) Stop() error {
+	m.mu.Lock()
+	if !m.running {
+		m.mu.Unlock()
+		return nil
+	}
+	m.running = false
+	close(m.stopChan)
+	m.mu.Unlock()
+
+	m.wg.Wait()
+	if err := m.api.Stop(); err != nil {
+		logger.Warn("Error stopping API data source: %v", err)
+	}
+	err := m.udp.Stop()
+	close(m.observationChan)
+	logger.Info("Merged data source stopped")
+	return err
+}
+
+// GetLatestObservation returns the most recent merged observation
+func (m *MergedDataSource) GetLatestObservation() *Observation {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.latestObservation != nil {
+		return m.latestObservation
+	}
+	return m.udp.GetLatestObservation()
+}
+
+// GetForecast returns the API source's forecast
+func (m *MergedDataSource) GetForecast() *ForecastResponse {
+	if f := m.api.GetForecast(); f != nil {
+		return f
+	}
+	return m.udp.GetForecast()
+}
+
+// GetStatus returns the UDP source's status with the merge counters
+func (m *MergedDataSource) GetStatus() DataSourceStatus {
+	status := m.udp.GetStatus()
+	stats := m.merger.Stats()
+	status.Merge = &stats
+	if api := m.api.GetStatus(); api.LastError != "" {
+		status.ErrorCount, status.ErrorsLastHour, status.LastError = api.ErrorCount, api.ErrorsLastHour, api.LastError
+	}
+	return status
+}
+
+// GetType returns DataSourceUDP; the API only supplements the stream
+func (m *MergedDataSource) GetType() DataSourceType {
+	return DataSourceUDP
+}
+
+// SetStatusManager passes the status manager on to the UDP source
+func (m *MergedDataSource) SetStatusManager(sm *StatusManager) {
+	if u, ok := m.udp.(interface{ SetStatusManager(*StatusManager) }); ok {
+		u.SetStatusManager(sm)
+	}
+}
@@ -21,6 +21,52 @@ type UDPListener interface {
 	GetHubStatus() interface{}
 }
 
+// UDPDevice is what the UDP listener knows about one device heard on the
+// network: a Tempest, AIR, SKY or hub, by serial number
+type UDPDevice struct {
+	Serial          string       `json:"serial"`
+	Name            string       `json:"name,omitempty"` // from --udp-serial SERIAL=name
+	Type            string       `json:"type"`           // tempest, air, sky or hub
+	HubSerial       string       `json:"hubSerial,omitempty"`
+	IP              string       `json:"ip,omitempty"`
+	Firmware        int          `json:"firmware,omitempty"`
+	Packets         int64        `json:"packets"`
+	Observations    int64        `json:"observations"`
+	LastPacket      time.Time    `json:"lastPacket"`
+	Voltage         float64      `json:"voltage,omitempty"`
+	RSSI            int          `json:"rssi,omitempty"`
+	Ignored         bool         `json:"ignored"` // not used by the bridge (--udp-serial, or a second Tempest)
+	LastObservation *Observation `json:"lastObservation,omitempty"`
+}
+
+// UDPLinkStats describes how reliably observations arrive over UDP. A gap of
+// several report intervals between observations counts the missing ones as
+// lost; jitter is the smoothed variation in delay between observations.
+type UDPLinkStats struct {
+	Expected            int64     `json:"expected"`
+	Received            int64     `json:"received"`
+	Lost                int64     `json:"lost"`
+	Duplicates          int64     `json:"duplicates,omitempty"`
+	LossPercent         float64   `json:"lossPercent"`
+	JitterSeconds       float64   `json:"jitterSeconds"`
+	MeanIntervalSeconds float64   `json:"meanIntervalSeconds,omitempty"`
+	MaxGapSeconds       float64   `json:"maxGapSeconds,omitempty"`
+	LastLoss            time.Time `json:"lastLoss"`
+}
+
+// UDPParserStats counts the UDP messages decoded per type and what the
+// decoders did not understand, so fields added by new firmware show up
+// instead of being dropped silently
+type UDPParserStats struct {
+	SchemaVersion int              `json:"schemaVersion"`           // WeatherFlow UDP reference revision the decoders follow
+	Messages      map[string]int64 `json:"messages,omitempty"`      // decoded messages by type
+	Errors        int64            `json:"errors"`                  // messages that could not be decoded
+	UnknownTypes  map[string]int64 `json:"unknownTypes,omitempty"`  // message types not in the schema
+	UnknownFields map[string]int64 `json:"unknownFields,omitempty"` // e.g. "hub_status.new_key" or "obs_st[18]"
+	InvalidValues map[string]int64 `json:"invalidValues,omitempty"` // known fields that were not numbers, e.g. "obs_st.uv"
+	NullValues    int64            `json:"nullValues,omitempty"`    // known fields sent as null
+}
+
 // UDPDataSource implements DataSource for local UDP broadcast listening
 type UDPDataSource struct {
 	listener      UDPListener
@@ -28,6 +74,7 @@ type UDPDataSource struct {
 	stationID     int
 	token         string
 	statusManager *StatusManager
+	forecastEvery time.Duration
 
 	mu                sync.RWMutex
 	latestObservation *Observation
@@ -45,11 +92,20 @@ func NewUDPDataSource(listener UDPListener, noInternet bool, stationID int, toke
 		noInternet:      noInternet,
 		stationID:       stationID,
 		token:           token,
+		forecastEvery:   DefaultForecastInterval,
 		observationChan: make(chan Observation, 100),
 		stopChan:        make(chan struct{}),
 	}
 }
 
+// SetForecastInterval changes how often the forecast is fetched; call it
+// before Start. Zero keeps the default.
+func (u *UDPDataSource) SetForecastInterval(d time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.forecastEvery = PollIntervals{Forecast: d}.withDefaults().Forecast
+}
+
 // Start begins listening for UDP broadcasts
 func (u *UDPDataSource) Start() (<-chan Observation, error) {
 	u.mu.Lock()
@@ -147,6 +203,18 @@ func (u *UDPDataSource) GetStatus() DataSourceStatus {
 		// Count observations
 		obs := u.listener.GetObservations()
 		status.ObservationCount = int64(len(obs))
+
+		if d, ok := u.listener.(interface{ GetDevices() []UDPDevice }); ok {
+			status.Devices = d.GetDevices()
+		}
+		if l, ok := u.listener.(interface{ GetLinkStats() UDPLinkStats }); ok {
+			link := l.GetLinkStats()
+			status.Link = &link
+		}
+		if p, ok := u.listener.(interface{ GetParserStats() UDPParserStats }); ok {
+			parser := p.GetParserStats()
+			status.Parser = &parser
+		}
 	}
 
 	return status
@@ -212,13 +280,16 @@ func (u *UDPDataSource) forwardLoop() {
 
 // forecastLoop periodically fetches forecast data (only if internet enabled)
 func (u *UDPDataSource) forecastLoop() {
-	logger.Info("Starting forecast polling loop (30 minute interval)")
+	u.mu.RLock()
+	interval := u.forecastEvery
+	u.mu.RUnlock()
+	logger.Info("Starting forecast polling loop (%v interval)", interval)
 
 	// Initial fetch
 	u.fetchForecast()
 
-	ticker := time.NewTicker(30 * time.Minute)
-	defer ticker.Stop()
+	timer := time.NewTimer(jittered(interval))
+	defer timer.Stop()
 
 	for {
 		select {
@@ -226,8 +297,9 @@ func (u *UDPDataSource) forecastLoop() {
 			logger.Debug("Forecast polling loop stopped")
 			return
 
-		case <-ticker.C:
+		case <-timer.C:
 			u.fetchForecast()
+			timer.Reset(jittered(interval))
 		}
 	}
 }
@@ -0,0 +1,62 @@
+package weather
+
+import "math"
+
+// ET0Inputs are the daily values the FAO-56 Penman-Monteith equation needs
+type ET0Inputs struct {
+	TempMin     float64 // °C
+	TempMax     float64 // °C
+	HumidityMin float64 // %
+	HumidityMax float64 // %
+	WindSpeed   float64 // mean wind speed at 2 m, m/s
+	Solar       float64 // incoming solar radiation, MJ/m²/day
+	Pressure    float64 // station pressure, kPa (derived from Elevation when 0)
+	Latitude    float64 // degrees, north positive
+	Elevation   float64 // meters
+	DayOfYear   int     // 1-366
+}
+
+// ReferenceET0 returns the daily grass reference evapotranspiration in mm
+// using the FAO-56 Penman-Monteith equation (Allen et al. 1998, eq. 6), with
+// soil heat flux taken as zero as FAO-56 recommends for daily steps
+func ReferenceET0(in ET0Inputs) float64 {
+	tMean := (in.TempMax + in.TempMin) / 2
+	pressure := in.Pressure
+	if pressure <= 0 {
+		pressure = 101.3 * math.Pow((293-0.0065*in.Elevation)/293, 5.26)
+	}
+	gamma := 0.000665 * pressure
+	delta := 4098 * saturationVaporPressure(tMean) / math.Pow(tMean+237.3, 2)
+
+	es := (saturationVaporPressure(in.TempMax) + saturationVaporPressure(in.TempMin)) / 2
+	ea := (saturationVaporPressure(in.TempMin)*in.HumidityMax/100 + saturationVaporPressure(in.TempMax)*in.HumidityMin/100) / 2
+
+	rso := (0.75 + 2e-5*in.Elevation) * extraterrestrialRadiation(in.Latitude, in.DayOfYear)
+	cloudiness := 1.0
+	if rso > 0 {
+		cloudiness = math.Min(in.Solar/rso, 1)
+	}
+	const sigma = 4.903e-9 // Stefan-Boltzmann constant, MJ/K⁴/m²/day
+	rnl := sigma * (math.Pow(in.TempMax+273.16, 4) + math.Pow(in.TempMin+273.16, 4)) / 2 *
+		(0.34 - 0.14*math.Sqrt(ea)) * (1.35*cloudiness - 0.35)
+	rn := 0.77*in.Solar - rnl
+
+	u2 := in.WindSpeed
+	et0 := (0.408*delta*rn + gamma*900/(tMean+273)*u2*(es-ea)) / (delta + gamma*(1+0.34*u2))
+	return math.Max(et0, 0)
+}
+
+// saturationVaporPressure returns e°(T) in kPa (FAO-56 eq. 11)
+func saturationVaporPressure(t float64) float64 {
+	return 0.6108 * math.Exp(17.27*t/(t+237.3))
+}
+
+// extraterrestrialRadiation returns Ra in MJ/m²/day (FAO-56 eq. 21)
+func extraterrestrialRadiation(latitude float64, dayOfYear int) float64 {
+	phi := latitude * math.Pi / 180
+	j := 2 * math.Pi * float64(dayOfYear) / 365
+	dr := 1 + 0.033*math.Cos(j)
+	decl := 0.409 * math.Sin(j-1.39)
+	ws := math.Acos(math.Max(-1, math.Min(1, -math.Tan(phi)*math.Tan(decl))))
+	return 24 * 60 / math.Pi * 0.0820 * dr * (ws*math.Sin(phi)*math.Sin(decl) + math.Cos(phi)*math.Cos(decl)*math.Sin(ws))
+}
@@ -0,0 +1,28 @@
+package weather
+
+import (
+	"math"
+	"testing"
+)
+
+// FAO-56 example 18: Brussels, 6 July
+func TestReferenceET0FAOExample(t *testing.T) {
+	got := ReferenceET0(ET0Inputs{
+		TempMin: 12.3, TempMax: 21.5,
+		HumidityMin: 63, HumidityMax: 84,
+		WindSpeed: 2.078,
+		Solar:     22.07,
+		Latitude:  50.8, Elevation: 100,
+		DayOfYear: 187,
+	})
+	if math.Abs(got-3.9) > 0.1 {
+		t.Errorf("ReferenceET0 = %.2f mm, want 3.9", got)
+	}
+}
+
+func TestReferenceET0NeverNegative(t *testing.T) {
+	got := ReferenceET0(ET0Inputs{TempMin: -10, TempMax: -5, HumidityMin: 95, HumidityMax: 100, Latitude: 60, DayOfYear: 355})
+	if got < 0 {
+		t.Errorf("ReferenceET0 = %v, want >= 0", got)
+	}
+}
@@ -0,0 +1,21 @@
+package weather
+
+import "time"
+
+// UpcomingHours returns the hourly periods that have not ended at now and
+// begin within window of it, in forecast order. A window of 0 keeps every later
+// hour; the current hour is included while it lasts.
+func UpcomingHours(hourly []ForecastPeriod, now time.Time, window time.Duration) []ForecastPeriod {
+	hours := []ForecastPeriod{}
+	for _, p := range hourly {
+		start := time.Unix(p.Time, 0)
+		if !start.Add(time.Hour).After(now) {
+			continue
+		}
+		if window > 0 && !start.Before(now.Add(window)) {
+			continue
+		}
+		hours = append(hours, p)
+	}
+	return hours
+}
@@ -0,0 +1,32 @@
+package weather
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpcomingHours(t *testing.T) {
+	now := time.Date(2026, 1, 10, 14, 20, 0, 0, time.UTC)
+	var hourly []ForecastPeriod
+	for h := -2; h < 6; h++ {
+		hourly = append(hourly, ForecastPeriod{Time: now.Truncate(time.Hour).Add(time.Duration(h) * time.Hour).Unix(), AirTemperature: float64(h)})
+	}
+	temps := func(hours []ForecastPeriod) []float64 {
+		out := []float64{}
+		for _, p := range hours {
+			out = append(out, p.AirTemperature)
+		}
+		return out
+	}
+
+	// The current hour (14:00) lasts until 15:00; 3h from 14:20 reaches into 17:00
+	if got := temps(UpcomingHours(hourly, now, 3*time.Hour)); len(got) != 4 || got[0] != 0 || got[3] != 3 {
+		t.Errorf("expected the hours 14:00 to 17:00, got %v", got)
+	}
+	if got := temps(UpcomingHours(hourly, now, 0)); len(got) != 6 || got[5] != 5 {
+		t.Errorf("expected every hour from 14:00, got %v", got)
+	}
+	if got := UpcomingHours(hourly, now.Add(24*time.Hour), 0); len(got) != 0 {
+		t.Errorf("expected no hours after the forecast ends, got %v", got)
+	}
+}
@@ -0,0 +1,70 @@
+package weather
+
+import (
+	"sync"
+	"time"
+)
+
+// WindSample is a wind reading between observations, such as the UDP
+// rapid_wind message a Tempest sends every 3 seconds
+type WindSample struct {
+	Timestamp int64   // Unix seconds
+	Speed     float64 // m/s
+	Direction int     // degrees
+}
+
+// GustTracker reports the strongest gust since the previous report rather
+// than the latest sample, so a gust that falls between two HomeKit updates
+// is not lost. With a hold time, a gust keeps being reported for at least
+// that long after it was measured.
+type GustTracker struct {
+	mu         sync.Mutex
+	hold       time.Duration
+	samples    []gustSample
+	lastReport time.Time
+}
+
+type gustSample struct {
+	at    time.Time
+	speed float64
+}
+
+// NewGustTracker creates a tracker holding gusts for hold (0 reports only
+// the strongest gust since the previous report)
+func NewGustTracker(hold time.Duration) *GustTracker {
+	return &GustTracker{hold: hold}
+}
+
+// Add records a gust or wind speed measured at t
+func (g *GustTracker) Add(t time.Time, speed float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.samples = append(g.samples, gustSample{at: t, speed: speed})
+}
+
+// Report returns the strongest gust measured since the previous report or
+// within the hold time before now, whichever reaches further back, and
+// starts a new window
+func (g *GustTracker) Report(now time.Time) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	from := now.Add(-g.hold)
+	if g.lastReport.Before(from) {
+		from = g.lastReport
+	}
+
+	peak := 0.0
+	kept := g.samples[:0]
+	for _, s := range g.samples {
+		// Samples newer than the report belong to the next window
+		if !s.at.Before(from) && !s.at.After(now) {
+			peak = max(peak, s.speed)
+		}
+		if s.at.After(now.Add(-g.hold)) {
+			kept = append(kept, s)
+		}
+	}
+	g.samples = kept
+	g.lastReport = now
+	return peak
+}
@@ -0,0 +1,53 @@
+package weather
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGustTrackerReportsIntervalPeak(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	g := NewGustTracker(0)
+
+	// A rapid_wind gust between two observations is not lost
+	g.Add(start.Add(3*time.Second), 4)
+	g.Add(start.Add(30*time.Second), 12.5)
+	g.Add(start.Add(time.Minute), 6)
+	if got := g.Report(start.Add(time.Minute)); got != 12.5 {
+		t.Errorf("first report = %v, want 12.5", got)
+	}
+
+	// Without a hold the next window starts after the previous report
+	g.Add(start.Add(2*time.Minute), 5)
+	if got := g.Report(start.Add(2 * time.Minute)); got != 5 {
+		t.Errorf("second report = %v, want 5", got)
+	}
+
+	// Samples newer than the report are kept for the next one
+	g.Add(start.Add(4*time.Minute), 9)
+	g.Add(start.Add(3*time.Minute), 3)
+	if got := g.Report(start.Add(3 * time.Minute)); got != 3 {
+		t.Errorf("third report = %v, want 3", got)
+	}
+	if got := g.Report(start.Add(4 * time.Minute)); got != 9 {
+		t.Errorf("fourth report = %v, want 9", got)
+	}
+}
+
+func TestGustTrackerHold(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	g := NewGustTracker(10 * time.Minute)
+	g.Add(start, 15)
+	for minute, want := range []float64{15, 15, 15} {
+		at := start.Add(time.Duration(minute*4) * time.Minute)
+		g.Add(at, 4)
+		if got := g.Report(at); got != want {
+			t.Errorf("report at +%dm = %v, want %v", minute*4, got, want)
+		}
+	}
+	// Once the hold has passed the gust is no longer reported
+	g.Add(start.Add(11*time.Minute), 4)
+	if got := g.Report(start.Add(11 * time.Minute)); got != 4 {
+		t.Errorf("report after the hold = %v, want 4", got)
+	}
+}
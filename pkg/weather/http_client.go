@@ -0,0 +1,405 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+)
+
+// Retry and timeout settings of the shared API client. Variables so tests can
+// shorten them.
+var (
+	apiRequestTimeout = 30 * time.Second
+	apiMaxRetries     = 3
+	apiBaseBackoff    = time.Second
+	apiMaxBackoff     = time.Minute // a longer Retry-After is returned to the caller instead of waited out
+)
+
+// EndpointStats counts the requests made to one API endpoint
+type EndpointStats struct {
+	Endpoint    string    `json:"endpoint"` // path with numeric IDs replaced by {id}, e.g. observations/station/{id}
+	Calls       int64     `json:"calls"`    // HTTP requests sent, including retries
+	CacheHits   int64     `json:"cacheHits"`
+	NotModified int64     `json:"notModified"` // 304 answers to ETag / Last-Modified revalidation
+	Coalesced   int64     `json:"coalesced"`   // callers that shared a request already in flight
+	Retries     int64     `json:"retries"`
+	RateLimited int64     `json:"rateLimited"` // 429 answers
+	Errors      int64     `json:"errors"`      // network errors and non-2xx answers after retries
+	LastStatus  int       `json:"lastStatus,omitempty"`
+	LastCall    time.Time `json:"lastCall"`
+}
+
+// apiResponse is a fully read response from the shared client
+type apiResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// requestOptions adjust a single request of the shared client
+type requestOptions struct {
+	noCache bool // bypass the response cache, e.g. when checking a token
+	noRetry bool // return 429 and 5xx answers immediately
+}
+
+// cachedResponse is a successful response kept for reuse and revalidation
+type cachedResponse struct {
+	resp         *apiResponse
+	etag         string
+	lastModified string
+	expires      time.Time // fresh until, from Cache-Control max-age; zero means revalidate every time
+}
+
+// inflightCall is a request that concurrent callers for the same URL share
+type inflightCall struct {
+	done chan struct{}
+	resp *apiResponse
+	err  error
+}
+
+// apiClient is the HTTP client behind every WeatherFlow request. Concurrent
+// requests for the same URL are coalesced into one, successful responses are
+// cached as long as the server's Cache-Control allows and revalidated with
+// ETag / Last-Modified after that, 429 and 5xx answers are retried with
+// exponential backoff (honoring Retry-After), and calls are counted per
+// endpoint.
+type apiClient struct {
+	http  *http.Client
+	sleep func(time.Duration)
+
+	mu       sync.Mutex
+	faults   FaultInjection
+	cache    map[string]*cachedResponse
+	inflight map[string]*inflightCall
+	stats    map[string]*EndpointStats
+}
+
+func newAPIClient() *apiClient {
+	return &apiClient{
+		// A nil Transport resolves http.DefaultTransport on every request
+		http:     &http.Client{Timeout: apiRequestTimeout},
+		sleep:    time.Sleep,
+		cache:    make(map[string]*cachedResponse),
+		inflight: make(map[string]*inflightCall),
+		stats:    make(map[string]*EndpointStats),
+	}
+}
+
+// sharedClient is used by all package functions that call the API
+var sharedClient = newAPIClient()
+
+// FaultInjection degrades the shared client on purpose so retries, failover
+// and alarms can be watched under bad conditions (--inject-* flags)
+type FaultInjection struct {
+	ErrorRate float64       // share of requests answered with a synthetic 503 instead of being sent, 0 to 1
+	Latency   time.Duration // added before every request
+}
+
+// SetFaultInjection sets the faults of the shared client; the zero value
+// turns them off
+func SetFaultInjection(f FaultInjection) {
+	sharedClient.mu.Lock()
+	sharedClient.faults = f
+	sharedClient.mu.Unlock()
+}
+
+// APIStats returns the per-endpoint call counters of the shared client,
+// sorted by endpoint
+func APIStats() []EndpointStats {
+	return sharedClient.Stats()
+}
+
+// Stats returns a copy of the per-endpoint counters sorted by endpoint
+func (c *apiClient) Stats() []EndpointStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]EndpointStats, 0, len(c.stats))
+	for _, s := range c.stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Endpoint < out[j].Endpoint })
+	return out
+}
+
+// get fetches rawURL, sharing, caching and retrying as described on apiClient
+func (c *apiClient) get(rawURL string, opts requestOptions) (*apiResponse, error) {
+	endpoint := endpointName(rawURL)
+
+	c.mu.Lock()
+	if !opts.noCache {
+		if entry, ok := c.cache[rawURL]; ok && time.Now().Before(entry.expires) {
+			c.statsFor(endpoint).CacheHits++
+			c.mu.Unlock()
+			return entry.resp, nil
+		}
+	}
+	if call, ok := c.inflight[rawURL]; ok && !opts.noCache {
+		c.statsFor(endpoint).Coalesced++
+		c.mu.Unlock()
+		<-call.done
+		return call.resp, call.err
+	}
+	call := &inflightCall{done: make(chan struct{})}
+	if !opts.noCache {
+		c.inflight[rawURL] = call
+	}
+	c.mu.Unlock()
+
+	call.resp, call.err = c.fetch(rawURL, endpoint, opts)
+
+	c.mu.Lock()
+	if c.inflight[rawURL] == call {
+		delete(c.inflight, rawURL)
+	}
+	c.mu.Unlock()
+	close(call.done)
+	return call.resp, call.err
+}
+
+// fetch sends the request, revalidating a cached copy and retrying 429 and
+// 5xx answers
+func (c *apiClient) fetch(rawURL, endpoint string, opts requestOptions) (*apiResponse, error) {
+	for attempt := 0; ; attempt++ {
+		resp, cached, err := c.send(rawURL, endpoint, opts)
+		if err != nil {
+			c.record(endpoint, func(s *EndpointStats) { s.Errors++ })
+			return nil, err
+		}
+		if cached {
+			return resp, nil
+		}
+
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if retryable && !opts.noRetry && attempt < apiMaxRetries {
+			if wait, ok := retryDelay(resp, attempt); ok {
+				c.record(endpoint, func(s *EndpointStats) { s.Retries++ })
+				logger.Debug("WeatherFlow %s returned HTTP %d, retrying in %v (attempt %d/%d)", endpoint, resp.StatusCode, wait, attempt+1, apiMaxRetries)
+				c.sleep(wait)
+				continue
+			}
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			c.record(endpoint, func(s *EndpointStats) { s.Errors++ })
+		} else if !opts.noCache {
+			c.store(rawURL, resp)
+		}
+		return resp, nil
+	}
+}
+
+// send performs one HTTP request. cached is true when the server confirmed
+// the cached copy with 304 Not Modified, in which case that copy is returned.
+func (c *apiClient) send(rawURL, endpoint string, opts requestOptions) (resp *apiResponse, cached bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, false, redactURLError(err)
+	}
+	var entry *cachedResponse
+	if !opts.noCache {
+		c.mu.Lock()
+		entry = c.cache[rawURL]
+		c.mu.Unlock()
+	}
+	if entry != nil {
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	c.record(endpoint, func(s *EndpointStats) { s.Calls++; s.LastCall = time.Now() })
+	if resp := c.injectFault(endpoint); resp != nil {
+		return resp, false, nil
+	}
+	httpResp, err := c.http.Do(req)
+	if err != nil {
+		return nil, false, redactURLError(err)
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	c.record(endpoint, func(s *EndpointStats) {
+		s.LastStatus = httpResp.StatusCode
+		if httpResp.StatusCode == http.StatusTooManyRequests {
+			s.RateLimited++
+		}
+	})
+	if httpResp.StatusCode == http.StatusNotModified && entry != nil {
+		_, _ = io.Copy(io.Discard, httpResp.Body)
+		c.record(endpoint, func(s *EndpointStats) { s.NotModified++ })
+		c.store(rawURL, &apiResponse{StatusCode: entry.resp.StatusCode, Header: mergeHeaders(entry.resp.Header, httpResp.Header), Body: entry.resp.Body})
+		return entry.resp, true, nil
+	}
+
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read response from %s: %w", endpoint, err)
+	}
+	return &apiResponse{StatusCode: httpResp.StatusCode, Header: httpResp.Header, Body: body}, false, nil
+}
+
+// injectFault waits out the injected latency and returns a synthetic 503 for
+// the injected share of requests, or nil to send the request
+func (c *apiClient) injectFault(endpoint string) *apiResponse {
+	c.mu.Lock()
+	f := c.faults
+	c.mu.Unlock()
+	if f.Latency > 0 {
+		c.sleep(f.Latency)
+	}
+	if f.ErrorRate <= 0 || rand.Float64() >= f.ErrorRate {
+		return nil
+	}
+	logger.Debug("Injecting HTTP 503 for WeatherFlow %s", endpoint)
+	c.record(endpoint, func(s *EndpointStats) { s.LastStatus = http.StatusServiceUnavailable })
+	return &apiResponse{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{},
+		Body:       []byte(`{"status":{"status_code":503,"status_message":"INJECTED FAILURE"}}`),
+	}
+}
+
+// store caches a successful response that can be reused or revalidated
+func (c *apiClient) store(rawURL string, resp *apiResponse) {
+	entry := &cachedResponse{
+		resp:         resp,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+	maxAge, cacheable := cacheMaxAge(resp.Header.Get("Cache-Control"))
+	if !cacheable || (maxAge == 0 && entry.etag == "" && entry.lastModified == "") {
+		c.mu.Lock()
+		delete(c.cache, rawURL)
+		c.mu.Unlock()
+		return
+	}
+	if maxAge > 0 {
+		entry.expires = time.Now().Add(maxAge)
+	}
+	c.mu.Lock()
+	c.cache[rawURL] = entry
+	c.mu.Unlock()
+}
+
+func (c *apiClient) record(endpoint string, update func(*EndpointStats)) {
+	c.mu.Lock()
+	update(c.statsFor(endpoint))
+	c.mu.Unlock()
+}
+
+// statsFor returns the counters of an endpoint; c.mu must be held
+func (c *apiClient) statsFor(endpoint string) *EndpointStats {
+	s, ok := c.stats[endpoint]
+	if !ok {
+		s = &EndpointStats{Endpoint: endpoint}
+		c.stats[endpoint] = s
+	}
+	return s
+}
+
+// retryDelay returns how long to wait before retrying a 429 or 5xx answer:
+// the server's Retry-After when given, otherwise exponential backoff with
+// jitter. ok is false when the server asks for a longer wait than
+// apiMaxBackoff.
+func retryDelay(resp *apiResponse, attempt int) (time.Duration, bool) {
+	if ra := resp.Header.Get("Retry-After"); ra != "" {
+		if secs, err := strconv.Atoi(ra); err == nil && secs >= 0 {
+			wait := time.Duration(secs) * time.Second
+			return wait, wait <= apiMaxBackoff
+		}
+		if at, err := http.ParseTime(ra); err == nil {
+			wait := time.Until(at)
+			if wait < 0 {
+				wait = 0
+			}
+			return wait, wait <= apiMaxBackoff
+		}
+	}
+	wait := apiBaseBackoff << attempt
+	if wait > apiMaxBackoff || wait <= 0 {
+		wait = apiMaxBackoff
+	}
+	if half := int64(wait / 2); half > 0 {
+		wait = wait/2 + time.Duration(rand.Int63n(half+1))
+	}
+	return wait, true
+}
+
+// cacheMaxAge parses Cache-Control. cacheable is false for no-store; maxAge
+// is 0 when the response must be revalidated before reuse.
+func cacheMaxAge(header string) (maxAge time.Duration, cacheable bool) {
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(strings.ToLower(directive)), "=")
+		switch name {
+		case "no-store":
+			return 0, false
+		case "no-cache":
+			return 0, true
+		case "max-age":
+			if secs, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && secs > 0 {
+				maxAge = time.Duration(secs) * time.Second
+			}
+		}
+	}
+	return maxAge, true
+}
+
+// mergeHeaders returns the cached headers updated with those of a 304 answer
+func mergeHeaders(cached, fresh http.Header) http.Header {
+	out := cached.Clone()
+	for k, v := range fresh {
+		out[k] = v
+	}
+	return out
+}
+
+var numericSegment = regexp.MustCompile(`^\d+$`)
+
+// endpointName identifies the endpoint of a URL for the call counters: the
+// path below the API base with numeric IDs replaced, or host and path for
+// other URLs. The query, and with it the token, is dropped.
+func endpointName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "invalid"
+	}
+	path := u.Host + u.Path
+	if base, err := url.Parse(BaseURL); err == nil && u.Host == base.Host && strings.HasPrefix(u.Path, base.Path) {
+		path = strings.TrimPrefix(u.Path, base.Path)
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range segments {
+		if numericSegment.MatchString(seg) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// redactURLError removes the query string from the URL that net/http puts in
+// its errors, so the API token does not end up in logs
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		redacted := *urlErr
+		if u, perr := url.Parse(urlErr.URL); perr == nil {
+			u.RawQuery = ""
+			redacted.URL = u.String()
+		}
+		return &redacted
+	}
+	return err
+}
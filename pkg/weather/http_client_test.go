@@ -0,0 +1,249 @@
+package weather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the shared client's retries of 429/5xx test responses fast
+	apiBaseBackoff = time.Millisecond
+	os.Exit(m.Run())
+}
+
+func newTestAPIClient() (*apiClient, *[]time.Duration) {
+	c := newAPIClient()
+	var waits []time.Duration
+	c.sleep = func(d time.Duration) { waits = append(waits, d) }
+	return c, &waits
+}
+
+func statsOf(c *apiClient, endpoint string) EndpointStats {
+	for _, s := range c.Stats() {
+		if s.Endpoint == endpoint {
+			return s
+		}
+	}
+	return EndpointStats{}
+}
+
+func TestAPIClientRetriesRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	c, waits := newTestAPIClient()
+	resp, err := c.get(srv.URL+"/stations?token=secret", requestOptions{})
+	if err != nil || resp.StatusCode != http.StatusOK || string(resp.Body) != `{"ok":true}` {
+		t.Fatalf("get = %+v, %v", resp, err)
+	}
+	if len(*waits) != 2 || (*waits)[0] != 2*time.Second {
+		t.Errorf("waits = %v, want two Retry-After waits of 2s", *waits)
+	}
+	s := statsOf(c, srv.Listener.Addr().String()+"/stations")
+	if s.Calls != 3 || s.Retries != 2 || s.RateLimited != 2 || s.Errors != 0 || s.LastStatus != 200 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+}
+
+func TestAPIClientGivesUpAfterRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/x" {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	c, waits := newTestAPIClient()
+	resp, err := c.get(srv.URL+"/x", requestOptions{})
+	if err != nil || resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("get = %+v, %v; want the final 502", resp, err)
+	}
+	if int(calls.Load()) != apiMaxRetries+1 || len(*waits) != apiMaxRetries {
+		t.Errorf("calls = %d, waits = %v", calls.Load(), *waits)
+	}
+
+	// A Retry-After beyond apiMaxBackoff is handed back instead of waited out
+	*waits = nil
+	if resp, _ := c.get(srv.URL+"/y", requestOptions{}); resp.StatusCode != http.StatusTooManyRequests || len(*waits) != 0 {
+		t.Errorf("long Retry-After: status %d after %v", resp.StatusCode, *waits)
+	}
+
+	*waits = nil
+	if resp, _ := c.get(srv.URL+"/z", requestOptions{noRetry: true}); resp.StatusCode != http.StatusTooManyRequests || len(*waits) != 0 {
+		t.Errorf("noRetry: status %d after %v", resp.StatusCode, *waits)
+	}
+}
+
+func TestAPIClientCaching(t *testing.T) {
+	var calls, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/etag":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+		}
+		_, _ = w.Write([]byte("body " + r.URL.Path))
+	}))
+	defer srv.Close()
+
+	c, _ := newTestAPIClient()
+	for i := 0; i < 3; i++ {
+		if resp, err := c.get(srv.URL+"/fresh", requestOptions{}); err != nil || string(resp.Body) != "body /fresh" {
+			t.Fatalf("fresh get = %+v, %v", resp, err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("max-age response fetched %d times, want 1", calls.Load())
+	}
+	if resp, _ := c.get(srv.URL+"/fresh", requestOptions{noCache: true}); resp == nil || calls.Load() != 2 {
+		t.Errorf("noCache should bypass the cache, calls = %d", calls.Load())
+	}
+
+	calls.Store(0)
+	for i := 0; i < 3; i++ {
+		if resp, err := c.get(srv.URL+"/etag", requestOptions{}); err != nil || resp.StatusCode != http.StatusOK || string(resp.Body) != "body /etag" {
+			t.Fatalf("etag get = %+v, %v", resp, err)
+		}
+	}
+	if calls.Load() != 3 || notModified.Load() != 2 {
+		t.Errorf("etag: %d calls, %d revalidated; want 3 and 2", calls.Load(), notModified.Load())
+	}
+	if s := statsOf(c, srv.Listener.Addr().String()+"/etag"); s.NotModified != 2 {
+		t.Errorf("etag stats: %+v", s)
+	}
+
+	calls.Store(0)
+	for i := 0; i < 2; i++ {
+		_, _ = c.get(srv.URL+"/plain", requestOptions{})
+	}
+	if calls.Load() != 2 {
+		t.Errorf("responses without cache headers should not be reused, calls = %d", calls.Load())
+	}
+}
+
+func TestAPIClientCoalescesConcurrentRequests(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		_, _ = w.Write([]byte("shared"))
+	}))
+	defer srv.Close()
+
+	c, _ := newTestAPIClient()
+	var wg sync.WaitGroup
+	bodies := make([]string, 5)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if resp, err := c.get(srv.URL+"/obs", requestOptions{}); err == nil {
+				bodies[i] = string(resp.Body)
+			}
+		}(i)
+	}
+	// Wait until the other callers have joined the first request
+	deadline := time.Now().Add(2 * time.Second)
+	for statsOf(c, srv.Listener.Addr().String()+"/obs").Coalesced < 4 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("server saw %d requests, want 1", calls.Load())
+	}
+	for i, b := range bodies {
+		if b != "shared" {
+			t.Errorf("caller %d got %q", i, b)
+		}
+	}
+}
+
+func TestEndpointName(t *testing.T) {
+	tests := map[string]string{
+		BaseURL + "/stations?token=abc":                            "stations",
+		BaseURL + "/stations/1234?token=abc":                       "stations/{id}",
+		BaseURL + "/observations/device/99?day_offset=1&token=abc": "observations/device/{id}",
+		BaseURL + "/better_forecast?station_id=1&token=abc":        "better_forecast",
+		"https://tempestwx.com/settings/station/1234/status":       "tempestwx.com/settings/station/{id}/status",
+		"http://localhost:8080/api/generate-weather":               "localhost:8080/api/generate-weather",
+	}
+	for in, want := range tests {
+		if got := endpointName(in); got != want {
+			t.Errorf("endpointName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAPIClientErrorHidesToken(t *testing.T) {
+	c, _ := newTestAPIClient()
+	_, err := c.get("http://127.0.0.1:1/stations?token=secret-token", requestOptions{})
+	if err == nil {
+		t.Fatal("expected a connection error")
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("error leaks the token: %v", err)
+	}
+	if s := statsOf(c, "127.0.0.1:1/stations"); s.Calls != 1 || s.Errors != 1 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+}
+
+func TestAPIClientFaultInjection(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	c, waits := newTestAPIClient()
+	c.faults = FaultInjection{ErrorRate: 1, Latency: 2 * time.Second}
+	resp, err := c.get(srv.URL+"/stations?token=abc", requestOptions{noCache: true})
+	if err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("get = %+v, %v", resp, err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("server saw %d requests, want none", calls.Load())
+	}
+	s := statsOf(c, srv.Listener.Addr().String()+"/stations")
+	if s.Calls != int64(apiMaxRetries+1) || s.Retries != int64(apiMaxRetries) || s.Errors != 1 || s.LastStatus != http.StatusServiceUnavailable {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+	if len(*waits) == 0 || (*waits)[0] != 2*time.Second {
+		t.Errorf("waits = %v, want the injected latency first", *waits)
+	}
+
+	c.faults = FaultInjection{}
+	if resp, err := c.get(srv.URL+"/stations?token=abc", requestOptions{noCache: true}); err != nil || resp.StatusCode != http.StatusOK || calls.Load() != 1 {
+		t.Errorf("without faults: get = %+v, %v after %d requests", resp, err, calls.Load())
+	}
+}
@@ -68,6 +68,7 @@ func TestGetHistoricalObservationsAndForecast_WithTestServer(t *testing.T) {
 	mux.HandleFunc("/swd/rest/better_forecast", func(w http.ResponseWriter, r *http.Request) {
 		fr := ForecastResponse{StationID: 123, StationName: "X"}
 		fr.Forecast.Daily = []ForecastPeriod{{Time: 1620000000, Icon: "sunny", AirTemperature: 20.0}}
+		fr.Forecast.Hourly = []ForecastPeriod{{Time: 1620003600, AirTemperature: 18.5, PrecipProbability: 30}}
 		b, _ := json.Marshal(fr)
 		w.WriteHeader(200)
 		_, _ = w.Write(b)
@@ -105,6 +106,9 @@ func TestGetHistoricalObservationsAndForecast_WithTestServer(t *testing.T) {
 	if f == nil || len(f.Forecast.Daily) == 0 {
 		t.Fatalf("expected forecast data, got %+v", f)
 	}
+	if len(f.Forecast.Hourly) != 1 || f.Forecast.Hourly[0].PrecipProbability != 30 {
+		t.Fatalf("expected the hourly forecast, got %+v", f.Forecast.Hourly)
+	}
 }
 
 func TestParseStationStatusHTML_Simple(t *testing.T) {
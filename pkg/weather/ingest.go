@@ -0,0 +1,216 @@
+package weather
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ingestMaxFuture is how far ahead of the local clock a pushed observation
+// may be timestamped
+const ingestMaxFuture = 5 * time.Minute
+
+// IngestObservation is the generic observation format accepted by
+// /api/ingest. Temperature and humidity are required; other fields default
+// to zero. Values are metric (°C, mb, m/s, mm, km) unless Units is
+// "imperial" (°F, inHg, mph, in, mi).
+type IngestObservation struct {
+	Timestamp         json.RawMessage `json:"timestamp,omitempty"` // unix seconds or RFC 3339, default now
+	Units             string          `json:"units,omitempty"`     // "metric" (default) or "imperial"
+	Temperature       *float64        `json:"temperature"`
+	Humidity          *float64        `json:"humidity"`
+	Pressure          float64         `json:"pressure,omitempty"` // station pressure
+	WindAvg           float64         `json:"wind_avg,omitempty"`
+	WindGust          float64         `json:"wind_gust,omitempty"`
+	WindLull          float64         `json:"wind_lull,omitempty"`
+	WindDirection     float64         `json:"wind_direction,omitempty"` // degrees
+	Rain              float64         `json:"rain,omitempty"`           // since the previous observation
+	RainDaily         float64         `json:"rain_daily,omitempty"`     // since local midnight
+	Illuminance       float64         `json:"illuminance,omitempty"`    // lux
+	UV                float64         `json:"uv,omitempty"`
+	SolarRadiation    float64         `json:"solar_radiation,omitempty"` // W/m²
+	LightningCount    int             `json:"lightning_count,omitempty"`
+	LightningDistance float64         `json:"lightning_distance,omitempty"`
+	Battery           float64         `json:"battery,omitempty"`         // volts
+	ReportInterval    int             `json:"report_interval,omitempty"` // minutes
+	PrecipitationType int             `json:"precipitation_type,omitempty"`
+}
+
+// ParseIngestPayload decodes observations pushed by another station. It
+// accepts a WeatherFlow UDP obs_st message or device API response
+// ({"obs": [[...]]}), a WeatherFlow station API response ({"obs": [{...}]}),
+// and the generic IngestObservation format, alone or as an array.
+// Observations are returned oldest first.
+func ParseIngestPayload(body []byte, now time.Time) ([]Observation, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("empty payload")
+	}
+
+	var observations []Observation
+	if trimmed[0] == '[' {
+		var generic []IngestObservation
+		if err := json.Unmarshal(trimmed, &generic); err != nil {
+			return nil, fmt.Errorf("invalid observation array: %w", err)
+		}
+		for i := range generic {
+			obs, err := generic[i].Observation(now)
+			if err != nil {
+				return nil, fmt.Errorf("observation %d: %w", i, err)
+			}
+			observations = append(observations, obs)
+		}
+	} else {
+		var envelope struct {
+			Type string          `json:"type"`
+			Obs  json.RawMessage `json:"obs"`
+		}
+		if err := json.Unmarshal(trimmed, &envelope); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		switch {
+		case envelope.Obs != nil:
+			parsed, err := parseWeatherFlowObs(envelope.Type, envelope.Obs)
+			if err != nil {
+				return nil, err
+			}
+			observations = parsed
+		default:
+			var generic IngestObservation
+			if err := json.Unmarshal(trimmed, &generic); err != nil {
+				return nil, fmt.Errorf("invalid observation: %w", err)
+			}
+			obs, err := generic.Observation(now)
+			if err != nil {
+				return nil, err
+			}
+			observations = append(observations, obs)
+		}
+	}
+
+	if len(observations) == 0 {
+		return nil, fmt.Errorf("no observations found")
+	}
+	for _, obs := range observations {
+		if time.Unix(obs.Timestamp, 0).After(now.Add(ingestMaxFuture)) {
+			return nil, fmt.Errorf("observation timestamp %d is in the future", obs.Timestamp)
+		}
+	}
+	sort.SliceStable(observations, func(i, j int) bool { return observations[i].Timestamp < observations[j].Timestamp })
+	return observations, nil
+}
+
+// parseWeatherFlowObs decodes the obs member of a WeatherFlow message:
+// arrays in UDP obs_st and device API order, or station API objects
+func parseWeatherFlowObs(msgType string, raw json.RawMessage) ([]Observation, error) {
+	if msgType != "" && msgType != "obs_st" {
+		return nil, fmt.Errorf("unsupported WeatherFlow message type %q (only obs_st)", msgType)
+	}
+	var arrays [][]interface{}
+	if err := json.Unmarshal(raw, &arrays); err == nil {
+		var observations []Observation
+		for _, obs := range parseDeviceObservations(arrays) {
+			if obs.Timestamp == 0 {
+				return nil, fmt.Errorf("WeatherFlow observation without a timestamp")
+			}
+			observations = append(observations, *obs)
+		}
+		if len(observations) < len(arrays) {
+			return nil, fmt.Errorf("WeatherFlow observation arrays need 18 values")
+		}
+		return observations, nil
+	}
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(raw, &objects); err != nil {
+		return nil, fmt.Errorf("obs must be a list of WeatherFlow observation arrays or objects")
+	}
+	var observations []Observation
+	for _, fields := range objects {
+		obs := stationObservation(fields)
+		if obs.Timestamp == 0 {
+			return nil, fmt.Errorf("WeatherFlow observation without a timestamp")
+		}
+		observations = append(observations, *obs)
+	}
+	return observations, nil
+}
+
+// Observation validates the generic observation and converts it to metric
+func (in *IngestObservation) Observation(now time.Time) (Observation, error) {
+	if in.Temperature == nil || in.Humidity == nil {
+		return Observation{}, fmt.Errorf("temperature and humidity are required")
+	}
+	if *in.Humidity < 0 || *in.Humidity > 100 {
+		return Observation{}, fmt.Errorf("humidity must be between 0 and 100")
+	}
+	timestamp, err := parseIngestTimestamp(in.Timestamp, now)
+	if err != nil {
+		return Observation{}, err
+	}
+
+	obs := Observation{
+		Timestamp:            timestamp,
+		AirTemperature:       *in.Temperature,
+		RelativeHumidity:     *in.Humidity,
+		StationPressure:      in.Pressure,
+		WindAvg:              in.WindAvg,
+		WindGust:             in.WindGust,
+		WindLull:             in.WindLull,
+		WindDirection:        in.WindDirection,
+		RainAccumulated:      in.Rain,
+		RainDailyTotal:       in.RainDaily,
+		Illuminance:          in.Illuminance,
+		UV:                   int(in.UV + 0.5),
+		SolarRadiation:       in.SolarRadiation,
+		LightningStrikeCount: in.LightningCount,
+		LightningStrikeAvg:   in.LightningDistance,
+		Battery:              in.Battery,
+		ReportInterval:       in.ReportInterval,
+		PrecipitationType:    in.PrecipitationType,
+	}
+	switch strings.ToLower(in.Units) {
+	case "", "metric":
+	case "imperial":
+		obs.AirTemperature = (obs.AirTemperature - 32) * 5 / 9
+		obs.StationPressure *= mbPerInHg
+		obs.WindAvg *= 0.44704
+		obs.WindGust *= 0.44704
+		obs.WindLull *= 0.44704
+		obs.RainAccumulated *= 25.4
+		obs.RainDailyTotal *= 25.4
+		obs.LightningStrikeAvg *= kmPerMile
+	default:
+		return Observation{}, fmt.Errorf("units must be metric or imperial")
+	}
+	if obs.PrecipitationType == 0 && obs.RainAccumulated > 0 {
+		obs.PrecipitationType = 1 // rain
+	}
+	return obs, nil
+}
+
+// parseIngestTimestamp accepts unix seconds (or milliseconds) and RFC 3339
+// strings; a missing timestamp is now
+func parseIngestTimestamp(raw json.RawMessage, now time.Time) (int64, error) {
+	value := strings.TrimSpace(string(raw))
+	if value == "" || value == "null" {
+		return now.Unix(), nil
+	}
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		if t, err := time.Parse(time.RFC3339, unquoted); err == nil {
+			return t.Unix(), nil
+		}
+		value = unquoted
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("timestamp must be unix seconds or RFC 3339")
+	}
+	if seconds > 1e12 {
+		seconds /= 1000 // milliseconds
+	}
+	return int64(seconds), nil
+}
@@ -0,0 +1,101 @@
+package weather
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseIngestPayload(t *testing.T) {
+	now := time.Unix(1767225600, 0)
+
+	t.Run("obs_st", func(t *testing.T) {
+		body := `{"serial_number":"ST-00000512","type":"obs_st","obs":[[1767225540,0.18,0.22,0.27,144,6,1017.57,22.37,50.26,328,0.03,3,0.1,1,0,0,2.41,1]]}`
+		obs, err := ParseIngestPayload([]byte(body), now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(obs) != 1 || obs[0].Timestamp != 1767225540 || obs[0].AirTemperature != 22.37 || obs[0].StationPressure != 1017.57 || obs[0].RainAccumulated != 0.1 {
+			t.Errorf("got %+v", obs)
+		}
+	})
+
+	t.Run("station API", func(t *testing.T) {
+		body := `{"station_id":1,"obs":[{"timestamp":1767225480,"air_temperature":18.5,"relative_humidity":70,"brightness":1200,"precip_accum_local_day":2.5}]}`
+		obs, err := ParseIngestPayload([]byte(body), now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(obs) != 1 || obs[0].AirTemperature != 18.5 || obs[0].Illuminance != 1200 || obs[0].RainDailyTotal != 2.5 {
+			t.Errorf("got %+v", obs)
+		}
+	})
+
+	t.Run("generic imperial batch", func(t *testing.T) {
+		body := `[{"timestamp":"2026-01-01T00:00:00Z","units":"imperial","temperature":68,"humidity":40,"wind_avg":10,"rain":0.1},
+			{"timestamp":1767225540,"temperature":19,"humidity":41}]`
+		obs, err := ParseIngestPayload([]byte(body), now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(obs) != 2 || obs[0].Timestamp != 1767225540 || obs[1].Timestamp != 1767225600 {
+			t.Fatalf("not sorted oldest first: %+v", obs)
+		}
+		converted := obs[1]
+		if converted.AirTemperature != 20 || math.Abs(converted.WindAvg-4.47) > 0.01 || math.Abs(converted.RainAccumulated-2.54) > 0.001 || converted.PrecipitationType != 1 {
+			t.Errorf("imperial conversion: %+v", converted)
+		}
+	})
+
+	t.Run("generic defaults to now", func(t *testing.T) {
+		obs, err := ParseIngestPayload([]byte(`{"temperature":21,"humidity":50}`), now)
+		if err != nil || len(obs) != 1 || obs[0].Timestamp != now.Unix() {
+			t.Errorf("got %+v, %v", obs, err)
+		}
+	})
+
+	for name, body := range map[string]string{
+		"empty":              ``,
+		"missing humidity":   `{"temperature":21}`,
+		"bad humidity":       `{"temperature":21,"humidity":140}`,
+		"bad units":          `{"temperature":21,"humidity":50,"units":"kelvin"}`,
+		"bad timestamp":      `{"timestamp":"yesterday","temperature":21,"humidity":50}`,
+		"future":             `{"timestamp":1767236400,"temperature":21,"humidity":50}`,
+		"short obs_st":       `{"type":"obs_st","obs":[[1767225540,0.18]]}`,
+		"other message":      `{"type":"rapid_wind","obs":[[1767225540,0.18,0.22]]}`,
+		"empty obs":          `{"obs":[]}`,
+		"not an observation": `"hello"`,
+	} {
+		if _, err := ParseIngestPayload([]byte(body), now); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestIngestDataSource(t *testing.T) {
+	s := NewIngestDataSource("Garden")
+	if err := s.Push(Observation{Timestamp: 100}); err == nil {
+		t.Error("push before Start should fail")
+	}
+	ch, err := s.Start()
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := s.Push(Observation{Timestamp: 100, AirTemperature: 20}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if err := s.Push(Observation{Timestamp: 100, AirTemperature: 21}); err == nil {
+		t.Error("a repeated timestamp should be rejected")
+	}
+	if got := <-ch; got.AirTemperature != 20 {
+		t.Errorf("received %+v", got)
+	}
+	status := s.GetStatus()
+	if status.Type != DataSourceIngest || status.ObservationCount != 1 || status.ErrorCount != 1 || s.GetLatestObservation().Timestamp != 100 {
+		t.Errorf("status = %+v", status)
+	}
+	_ = s.Stop()
+	if err := s.Push(Observation{Timestamp: 200}); err == nil {
+		t.Error("push after Stop should fail")
+	}
+}
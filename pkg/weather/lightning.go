@@ -0,0 +1,126 @@
+package weather
+
+import (
+	"sync"
+	"time"
+)
+
+// LightningStormGap is how long without strikes ends a storm; the next
+// strike starts a new one
+const LightningStormGap = 30 * time.Minute
+
+// LightningRateWindow is the span the strike rate is averaged over
+const LightningRateWindow = 10 * time.Minute
+
+// LightningStrike is a single strike between observations, such as the UDP
+// evt_strike message a Tempest sends for each strike it detects
+type LightningStrike struct {
+	Timestamp int64   // Unix seconds
+	Distance  float64 // km
+	Energy    float64
+}
+
+// LightningStorm summarises the strikes of the current or most recent storm
+type LightningStorm struct {
+	Active     bool      // a strike was detected within LightningStormGap
+	Start      time.Time // first strike of the storm
+	LastStrike time.Time
+	Strikes    int       // strikes counted by the observations of the storm
+	Nearest    float64   // km, the closest strike or observation average
+	NearestAt  time.Time // when Nearest was detected
+	Rate       float64   // strikes per minute over LightningRateWindow
+}
+
+// LightningTracker follows lightning storms from observations and strike
+// events and fills LightningNearest and LightningRate. Observations count the
+// strikes; strike events, which are included in the next observation's count,
+// only refine the nearest distance because observations report the average
+// distance of their interval.
+type LightningTracker struct {
+	mu     sync.Mutex
+	storm  LightningStorm
+	counts []strikeCount // strike counts within LightningRateWindow
+	last   int64         // newest observation timestamp counted
+}
+
+type strikeCount struct {
+	at    time.Time
+	count int
+}
+
+// NewLightningTracker creates a tracker with no storm
+func NewLightningTracker() *LightningTracker {
+	return &LightningTracker{}
+}
+
+// Apply records the strikes of observations newer than the last one applied
+// and sets obs.LightningNearest (0 without an active storm) and
+// obs.LightningRate. A repeat of the last timestamp, as the UDP + API merger
+// sends, is not counted again.
+func (l *LightningTracker) Apply(obs *Observation) {
+	if obs == nil {
+		return
+	}
+	l.mu.Lock()
+	at := time.Unix(obs.Timestamp, 0)
+	if obs.Timestamp > l.last {
+		l.last = obs.Timestamp
+		if obs.LightningStrikeCount > 0 {
+			l.strike(at, obs.LightningStrikeAvg)
+			l.storm.Strikes += obs.LightningStrikeCount
+			l.counts = append(l.counts, strikeCount{at: at, count: obs.LightningStrikeCount})
+		}
+	}
+	l.mu.Unlock()
+
+	storm := l.Storm(at)
+	obs.LightningNearest, obs.LightningRate = 0, storm.Rate
+	if storm.Active {
+		obs.LightningNearest = storm.Nearest
+	}
+}
+
+// AddStrike records the distance of a single strike
+func (l *LightningTracker) AddStrike(s LightningStrike) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.strike(time.Unix(s.Timestamp, 0), s.Distance)
+}
+
+// strike starts a new storm when the previous one has ended and keeps the
+// nearest distance. A distance of 0 means unknown and is ignored.
+func (l *LightningTracker) strike(at time.Time, distance float64) {
+	if l.storm.LastStrike.IsZero() || at.Sub(l.storm.LastStrike) >= LightningStormGap {
+		l.storm = LightningStorm{Start: at}
+	}
+	if at.After(l.storm.LastStrike) {
+		l.storm.LastStrike = at
+	}
+	if distance > 0 && (l.storm.Nearest == 0 || distance < l.storm.Nearest) {
+		l.storm.Nearest, l.storm.NearestAt = distance, at
+	}
+}
+
+// Storm returns the current storm, or the most recent one with Active false,
+// and the strike rate ending at now
+func (l *LightningTracker) Storm(now time.Time) LightningStorm {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	from := now.Add(-LightningRateWindow)
+	strikes := 0
+	kept := l.counts[:0]
+	for _, c := range l.counts {
+		if c.at.After(from) {
+			kept = append(kept, c)
+			if !c.at.After(now) {
+				strikes += c.count
+			}
+		}
+	}
+	l.counts = kept
+
+	storm := l.storm
+	storm.Active = !storm.LastStrike.IsZero() && now.Sub(storm.LastStrike) < LightningStormGap
+	storm.Rate = float64(strikes) / LightningRateWindow.Minutes()
+	return storm
+}
@@ -0,0 +1,67 @@
+package weather
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLightningTrackerNearestAndRate(t *testing.T) {
+	start := time.Date(2026, 7, 4, 18, 0, 0, 0, time.UTC)
+	l := NewLightningTracker()
+	if storm := l.Storm(start); storm.Active || storm.Rate != 0 {
+		t.Fatalf("empty tracker = %+v, want no storm", storm)
+	}
+
+	// A strike event between observations refines the nearest distance the
+	// next observation averages away
+	l.Apply(&Observation{Timestamp: start.Unix(), LightningStrikeCount: 4, LightningStrikeAvg: 18})
+	l.AddStrike(LightningStrike{Timestamp: start.Add(30 * time.Second).Unix(), Distance: 6})
+	l.Apply(&Observation{Timestamp: start.Add(time.Minute).Unix(), LightningStrikeCount: 6, LightningStrikeAvg: 12})
+	obs := Observation{Timestamp: start.Add(2 * time.Minute).Unix()}
+	l.Apply(&obs)
+	if obs.LightningNearest != 6 || obs.LightningRate != 1 {
+		t.Errorf("observation nearest/rate = %v/%v, want 6/1", obs.LightningNearest, obs.LightningRate)
+	}
+	// A repeated observation is not counted twice
+	l.Apply(&Observation{Timestamp: start.Add(time.Minute).Unix(), LightningStrikeCount: 6, LightningStrikeAvg: 12})
+
+	storm := l.Storm(start.Add(2 * time.Minute))
+	if !storm.Active || storm.Strikes != 10 || !storm.Start.Equal(start) {
+		t.Errorf("storm = %+v, want active with 10 strikes from %v", storm, start)
+	}
+	if storm.Nearest != 6 || !storm.NearestAt.Equal(start.Add(30*time.Second)) {
+		t.Errorf("nearest = %v at %v, want 6 at the strike event", storm.Nearest, storm.NearestAt)
+	}
+	if storm.Rate != 1 {
+		t.Errorf("rate = %v, want 1 strike/min", storm.Rate)
+	}
+
+	// The rate only counts the last LightningRateWindow
+	if rate := l.Storm(start.Add(10*time.Minute + 30*time.Second)).Rate; rate != 0.6 {
+		t.Errorf("rate after the first observation left the window = %v, want 0.6", rate)
+	}
+}
+
+func TestLightningTrackerStormGap(t *testing.T) {
+	start := time.Date(2026, 7, 4, 18, 0, 0, 0, time.UTC)
+	l := NewLightningTracker()
+	l.Apply(&Observation{Timestamp: start.Unix(), LightningStrikeCount: 3, LightningStrikeAvg: 5})
+
+	ended := l.Storm(start.Add(LightningStormGap))
+	if ended.Active || ended.Nearest != 5 {
+		t.Errorf("storm after the gap = %+v, want the ended storm", ended)
+	}
+	quiet := Observation{Timestamp: start.Add(LightningStormGap).Unix()}
+	l.Apply(&quiet)
+	if quiet.LightningNearest != 0 {
+		t.Errorf("nearest after the storm = %v, want 0", quiet.LightningNearest)
+	}
+
+	// The next strike starts a new storm with its own nearest distance
+	next := start.Add(LightningStormGap + time.Minute)
+	l.Apply(&Observation{Timestamp: next.Unix(), LightningStrikeCount: 1, LightningStrikeAvg: 20})
+	storm := l.Storm(next)
+	if !storm.Active || storm.Strikes != 1 || storm.Nearest != 20 || !storm.Start.Equal(next) {
+		t.Errorf("new storm = %+v, want 1 strike at 20 km from %v", storm, next)
+	}
+}
@@ -0,0 +1,207 @@
+// Package weather provides the observation merger used when UDP and the REST
+// API both report the same station.
+package weather
+
+import (
+	"sync"
+	"time"
+)
+
+// ObservationSource identifies where an observation handed to the merger came from
+type ObservationSource string
+
+const (
+	// SourceUDP is a local UDP broadcast (obs_st)
+	SourceUDP ObservationSource = "udp"
+
+	// SourceAPI is a WeatherFlow REST API observation
+	SourceAPI ObservationSource = "api"
+)
+
+const (
+	// DefaultMergeTolerance is how far apart UDP and API timestamps may be
+	// while still describing the same one-minute report
+	DefaultMergeTolerance = 30 * time.Second
+
+	// DefaultUDPStaleAfter is how long UDP may be silent before API
+	// observations are passed through on their own
+	DefaultUDPStaleAfter = 3 * time.Minute
+
+	// mergeWindow is how many recent observations per source are kept for matching
+	mergeWindow = 10
+)
+
+// MergeStats counts the merger's decisions since it was created
+type MergeStats struct {
+	UDP         int64 `json:"udp"`         // UDP observations emitted
+	Filled      int64 `json:"filled"`      // Emitted observations that had fields filled from the API
+	Duplicates  int64 `json:"duplicates"`  // Observations dropped as already reported
+	APIFallback int64 `json:"apiFallback"` // API observations emitted while UDP was silent
+}
+
+// ObservationMerger combines observations of one station arriving over UDP and
+// the REST API instead of letting whichever arrives last win:
+//
+//   - Two observations whose timestamps are within the tolerance describe the
+//     same report. The merged observation keeps the UDP timestamp.
+//   - UDP fields win. Fields the UDP observation leaves at zero are filled from
+//     the matching API observation; in particular RainDailyTotal, which the
+//     obs_st packet does not carry.
+//   - A UDP observation is emitted as soon as it arrives. An API observation
+//     matching one already emitted is emitted again, as an update with the
+//     same timestamp, only when it fills a field; otherwise it is a duplicate.
+//   - Repeated observations from the same source are duplicates.
+//   - An API observation with no UDP match is held for a later UDP
+//     observation while UDP is live, and emitted on its own once UDP has been
+//     silent for longer than the stale period.
+//   - Nothing older than the newest emitted observation is emitted, except
+//     the fill-in updates above.
+type ObservationMerger struct {
+	tolerance  time.Duration
+	staleAfter time.Duration
+	now        func() time.Time
+
+	mu        sync.Mutex
+	udp       []Observation // recent UDP observations as emitted, oldest first
+	api       []Observation // recent API observations, oldest first
+	lastUDPAt time.Time     // arrival time of the newest UDP observation
+	newest    int64         // newest emitted timestamp
+	fallback  int64         // timestamp of the newest API observation emitted on its own
+	stats     MergeStats
+}
+
+// NewObservationMerger creates a merger with the default tolerance and stale period
+func NewObservationMerger() *ObservationMerger {
+	return &ObservationMerger{
+		tolerance:  DefaultMergeTolerance,
+		staleAfter: DefaultUDPStaleAfter,
+		now:        time.Now,
+	}
+}
+
+// Add offers an observation from src and returns the observation to publish,
+// if any
+func (m *ObservationMerger) Add(src ObservationSource, obs Observation) (Observation, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if src == SourceUDP {
+		return m.addUDP(obs)
+	}
+	return m.addAPI(obs)
+}
+
+// Stats returns the merge counters
+func (m *ObservationMerger) Stats() MergeStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+func (m *ObservationMerger) addUDP(obs Observation) (Observation, bool) {
+	m.lastUDPAt = m.now()
+	if _, ok := m.match(m.udp, obs.Timestamp); ok || obs.Timestamp <= m.newest {
+		m.stats.Duplicates++
+		return Observation{}, false
+	}
+	if i, ok := m.match(m.api, obs.Timestamp); ok {
+		if m.fallback != 0 && m.api[i].Timestamp == m.fallback {
+			// Already published from the API while UDP was silent
+			m.stats.Duplicates++
+			return Observation{}, false
+		}
+		if fillMissing(&obs, m.api[i]) {
+			m.stats.Filled++
+		}
+	}
+	m.udp = appendRecent(m.udp, obs)
+	m.newest = obs.Timestamp
+	m.stats.UDP++
+	return obs, true
+}
+
+func (m *ObservationMerger) addAPI(obs Observation) (Observation, bool) {
+	if _, ok := m.match(m.api, obs.Timestamp); ok {
+		m.stats.Duplicates++
+		return Observation{}, false
+	}
+	m.api = appendRecent(m.api, obs)
+
+	if i, ok := m.match(m.udp, obs.Timestamp); ok {
+		merged := m.udp[i]
+		if !fillMissing(&merged, obs) {
+			m.stats.Duplicates++
+			return Observation{}, false
+		}
+		m.udp[i] = merged
+		m.stats.Filled++
+		return merged, true
+	}
+
+	udpSilent := m.lastUDPAt.IsZero() || m.now().Sub(m.lastUDPAt) > m.staleAfter
+	if !udpSilent || obs.Timestamp <= m.newest {
+		return Observation{}, false
+	}
+	m.newest, m.fallback = obs.Timestamp, obs.Timestamp
+	m.stats.APIFallback++
+	return obs, true
+}
+
+// match returns the index of the observation in recent closest to ts within
+// the tolerance
+func (m *ObservationMerger) match(recent []Observation, ts int64) (int, bool) {
+	best, bestDiff := -1, int64(m.tolerance/time.Second)+1
+	for i, o := range recent {
+		diff := o.Timestamp - ts
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff < bestDiff {
+			best, bestDiff = i, diff
+		}
+	}
+	return best, best >= 0
+}
+
+func appendRecent(recent []Observation, obs Observation) []Observation {
+	recent = append(recent, obs)
+	if len(recent) > mergeWindow {
+		recent = recent[len(recent)-mergeWindow:]
+	}
+	return recent
+}
+
+// fillMissing copies fields that are zero in dst but set in src, reporting
+// whether anything was copied. The timestamp is never changed.
+func fillMissing(dst *Observation, src Observation) bool {
+	filled := false
+	f := func(d *float64, s float64) {
+		if *d == 0 && s != 0 {
+			*d, filled = s, true
+		}
+	}
+	i := func(d *int, s int) {
+		if *d == 0 && s != 0 {
+			*d, filled = s, true
+		}
+	}
+	f(&dst.WindLull, src.WindLull)
+	f(&dst.WindAvg, src.WindAvg)
+	f(&dst.WindGust, src.WindGust)
+	f(&dst.WindDirection, src.WindDirection)
+	f(&dst.StationPressure, src.StationPressure)
+	f(&dst.AirTemperature, src.AirTemperature)
+	f(&dst.RelativeHumidity, src.RelativeHumidity)
+	f(&dst.Illuminance, src.Illuminance)
+	i(&dst.UV, src.UV)
+	f(&dst.SolarRadiation, src.SolarRadiation)
+	f(&dst.RainAccumulated, src.RainAccumulated)
+	f(&dst.RainDailyTotal, src.RainDailyTotal)
+	f(&dst.NCRainAccumulated, src.NCRainAccumulated)
+	f(&dst.NCRainDailyTotal, src.NCRainDailyTotal)
+	i(&dst.PrecipitationType, src.PrecipitationType)
+	f(&dst.LightningStrikeAvg, src.LightningStrikeAvg)
+	i(&dst.LightningStrikeCount, src.LightningStrikeCount)
+	f(&dst.Battery, src.Battery)
+	i(&dst.ReportInterval, src.ReportInterval)
+	return filled
+}
@@ -0,0 +1,161 @@
+package weather
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestMerger() (*ObservationMerger, *time.Time) {
+	m := NewObservationMerger()
+	now := time.Unix(1_700_000_000, 0)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func TestMergerPrefersUDPAndFillsFromAPI(t *testing.T) {
+	m, _ := newTestMerger()
+
+	udp := Observation{Timestamp: 1000, AirTemperature: 20, WindAvg: 3}
+	got, ok := m.Add(SourceUDP, udp)
+	if !ok || got != udp {
+		t.Fatalf("UDP observation = %+v, %v; want it emitted unchanged", got, ok)
+	}
+
+	// Same report from the API a few seconds off, with a different temperature
+	// and the daily rain total UDP lacks
+	got, ok = m.Add(SourceAPI, Observation{Timestamp: 1004, AirTemperature: 21, WindAvg: 3, RainDailyTotal: 2.5})
+	if !ok {
+		t.Fatal("API observation that fills a field should be emitted as an update")
+	}
+	want := Observation{Timestamp: 1000, AirTemperature: 20, WindAvg: 3, RainDailyTotal: 2.5}
+	if got != want {
+		t.Errorf("merged = %+v, want %+v", got, want)
+	}
+
+	// Nothing left to fill: a second API copy is a duplicate
+	if _, ok := m.Add(SourceAPI, Observation{Timestamp: 998, AirTemperature: 21, RainDailyTotal: 2.5}); ok {
+		t.Error("API observation adding nothing should be dropped")
+	}
+
+	if s := m.Stats(); s.UDP != 1 || s.Filled != 1 || s.Duplicates != 1 || s.APIFallback != 0 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestMergerHoldsAPIWhileUDPIsLive(t *testing.T) {
+	m, now := newTestMerger()
+	m.Add(SourceUDP, Observation{Timestamp: 940, AirTemperature: 19})
+
+	// API report for the next minute arrives before its UDP packet
+	*now = now.Add(50 * time.Second)
+	if _, ok := m.Add(SourceAPI, Observation{Timestamp: 1000, AirTemperature: 20, RainDailyTotal: 1}); ok {
+		t.Fatal("unmatched API observation should be held while UDP is live")
+	}
+	got, ok := m.Add(SourceUDP, Observation{Timestamp: 1001, AirTemperature: 20.5})
+	if !ok || got.Timestamp != 1001 || got.AirTemperature != 20.5 || got.RainDailyTotal != 1 {
+		t.Errorf("UDP observation = %+v, %v; want UDP values and timestamp plus the held rain total", got, ok)
+	}
+}
+
+func TestMergerFallsBackToAPIWhenUDPIsSilent(t *testing.T) {
+	m, now := newTestMerger()
+
+	// No UDP yet: API observations pass through
+	if got, ok := m.Add(SourceAPI, Observation{Timestamp: 1000, AirTemperature: 20}); !ok || got.Timestamp != 1000 {
+		t.Fatalf("API observation without UDP = %+v, %v", got, ok)
+	}
+
+	m.Add(SourceUDP, Observation{Timestamp: 1060, AirTemperature: 20})
+	*now = now.Add(DefaultUDPStaleAfter + time.Second)
+	got, ok := m.Add(SourceAPI, Observation{Timestamp: 1300, AirTemperature: 22})
+	if !ok || got.AirTemperature != 22 {
+		t.Fatalf("API observation after UDP went silent = %+v, %v", got, ok)
+	}
+
+	// The late UDP copy of a report already published from the API is a duplicate
+	if _, ok := m.Add(SourceUDP, Observation{Timestamp: 1302, AirTemperature: 22.1}); ok {
+		t.Error("UDP copy of an API fallback observation should be dropped")
+	}
+	if _, ok := m.Add(SourceUDP, Observation{Timestamp: 1360, AirTemperature: 22.3}); !ok {
+		t.Error("new UDP observation should be emitted once UDP is back")
+	}
+	if s := m.Stats(); s.APIFallback != 2 || s.UDP != 2 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestMergerDropsRepeatedAndOutOfOrderUDP(t *testing.T) {
+	m, _ := newTestMerger()
+	m.Add(SourceUDP, Observation{Timestamp: 1060, AirTemperature: 20})
+
+	if _, ok := m.Add(SourceUDP, Observation{Timestamp: 1060, AirTemperature: 20}); ok {
+		t.Error("repeated UDP packet should be dropped")
+	}
+	if _, ok := m.Add(SourceUDP, Observation{Timestamp: 900, AirTemperature: 18}); ok {
+		t.Error("UDP observation older than the newest emitted should be dropped")
+	}
+	if _, ok := m.Add(SourceUDP, Observation{Timestamp: 1120, AirTemperature: 21}); !ok {
+		t.Error("next UDP observation should be emitted")
+	}
+}
+
+func TestMergedDataSource(t *testing.T) {
+	udp, api := newChanSource(DataSourceUDP), newChanSource(DataSourceAPI)
+	ds := NewMergedDataSource(udp, api)
+	out, err := ds.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	udp.ch <- Observation{Timestamp: 1000, AirTemperature: 20}
+	api.ch <- Observation{Timestamp: 1000, AirTemperature: 21, RainDailyTotal: 3}
+	for _, want := range []Observation{
+		{Timestamp: 1000, AirTemperature: 20},
+		{Timestamp: 1000, AirTemperature: 20, RainDailyTotal: 3},
+	} {
+		select {
+		case got := <-out:
+			if got != want {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for a merged observation")
+		}
+	}
+
+	status := ds.GetStatus()
+	if status.Type != DataSourceUDP || status.Merge == nil || status.Merge.Filled != 1 {
+		t.Errorf("status = %+v", status)
+	}
+	if ds.GetForecast() != api.forecast {
+		t.Error("forecast should come from the API source")
+	}
+	if err := ds.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if !udp.stopped || !api.stopped {
+		t.Error("Stop should stop both sources")
+	}
+	if _, open := <-out; open {
+		t.Error("observation channel should be closed after Stop")
+	}
+}
+
+// chanSource is a DataSource fed by the test
+type chanSource struct {
+	typ      DataSourceType
+	ch       chan Observation
+	forecast *ForecastResponse
+	stopped  bool
+}
+
+func newChanSource(typ DataSourceType) *chanSource {
+	return &chanSource{typ: typ, ch: make(chan Observation), forecast: &ForecastResponse{}}
+}
+
+func (c *chanSource) Start() (<-chan Observation, error) { return c.ch, nil }
+func (c *chanSource) Stop() error                        { c.stopped = true; return nil }
+func (c *chanSource) GetLatestObservation() *Observation { return nil }
+func (c *chanSource) GetForecast() *ForecastResponse     { return c.forecast }
+func (c *chanSource) GetStatus() DataSourceStatus        { return DataSourceStatus{Type: c.typ, Active: true} }
+func (c *chanSource) GetType() DataSourceType            { return c.typ }
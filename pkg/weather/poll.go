@@ -0,0 +1,49 @@
+package weather
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Default polling cadences of the WeatherFlow endpoints
+const (
+	DefaultObservationInterval = 60 * time.Second
+	DefaultForecastInterval    = 30 * time.Minute
+	DefaultStatusInterval      = 15 * time.Minute
+)
+
+// pollJitter is the fraction of an interval by which each wait is randomly
+// lengthened or shortened, so many bridges started at the same moment do not
+// keep hitting the API in lockstep
+const pollJitter = 0.1
+
+// PollIntervals sets how often each endpoint is polled. Zero fields use the
+// defaults.
+type PollIntervals struct {
+	Observation time.Duration
+	Forecast    time.Duration
+	Status      time.Duration
+}
+
+// withDefaults fills unset intervals with the default cadences
+func (p PollIntervals) withDefaults() PollIntervals {
+	if p.Observation <= 0 {
+		p.Observation = DefaultObservationInterval
+	}
+	if p.Forecast <= 0 {
+		p.Forecast = DefaultForecastInterval
+	}
+	if p.Status <= 0 {
+		p.Status = DefaultStatusInterval
+	}
+	return p
+}
+
+// jittered returns d shifted by a random amount of up to pollJitter of d
+func jittered(d time.Duration) time.Duration {
+	spread := int64(float64(d) * pollJitter)
+	if spread <= 0 {
+		return d
+	}
+	return d - time.Duration(spread) + time.Duration(rand.Int63n(2*spread+1))
+}
@@ -0,0 +1,58 @@
+package weather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJittered(t *testing.T) {
+	d := time.Minute
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 200; i++ {
+		got := jittered(d)
+		if got < 54*time.Second || got > 66*time.Second {
+			t.Fatalf("jittered(%v) = %v, outside ±10%%", d, got)
+		}
+		seen[got] = true
+	}
+	if len(seen) < 2 {
+		t.Error("jittered returned the same value every time")
+	}
+	if got := jittered(5); got != 5 {
+		t.Errorf("jittered(5ns) = %v, want unchanged", got)
+	}
+}
+
+func TestPollIntervalsWithDefaults(t *testing.T) {
+	got := PollIntervals{Forecast: time.Hour}.withDefaults()
+	want := PollIntervals{Observation: DefaultObservationInterval, Forecast: time.Hour, Status: DefaultStatusInterval}
+	if got != want {
+		t.Errorf("withDefaults = %+v, want %+v", got, want)
+	}
+}
+
+func TestAPIDataSource_ObservationInterval(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"obs":[{"timestamp": 1696761600, "air_temperature": 20}]}`))
+	}))
+	defer srv.Close()
+
+	ds := NewAPIDataSource(1, "", "Test", APIDataSourceOptions{
+		CustomURL: srv.URL + "/weather",
+		Intervals: PollIntervals{Observation: 20 * time.Millisecond},
+	})
+	if _, err := ds.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	_ = ds.Stop()
+
+	if n := calls.Load(); n < 3 {
+		t.Errorf("observation endpoint polled %d times in 150ms at a 20ms interval, want at least 3", n)
+	}
+}
@@ -0,0 +1,29 @@
+package weather
+
+// ApplyRainCorrection scales the gauge's rain values by factor (a check-gauge
+// calibration set with --rain-correction), keeping the uncorrected values in
+// RainAccumulatedRaw and RainDailyTotalRaw. The raw fields are filled even for
+// a factor of 1 so the API always exposes both; WeatherFlow's Rain Check (NC)
+// values are left as reported. Observations that already carry raw values are
+// not scaled again.
+func ApplyRainCorrection(obs *Observation, factor float64) {
+	if obs == nil || obs.RainAccumulatedRaw != 0 || obs.RainDailyTotalRaw != 0 {
+		return
+	}
+	if factor <= 0 {
+		factor = 1
+	}
+	obs.RainAccumulatedRaw = obs.RainAccumulated
+	obs.RainDailyTotalRaw = obs.RainDailyTotal
+	obs.RainAccumulated *= factor
+	obs.RainDailyTotal *= factor
+}
+
+// RawRain returns the gauge's uncorrected rain since the last observation and
+// since midnight, whether or not ApplyRainCorrection has run
+func RawRain(obs *Observation) (accumulated, daily float64) {
+	if obs.RainAccumulatedRaw == 0 && obs.RainDailyTotalRaw == 0 {
+		return obs.RainAccumulated, obs.RainDailyTotal
+	}
+	return obs.RainAccumulatedRaw, obs.RainDailyTotalRaw
+}
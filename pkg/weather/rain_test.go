@@ -0,0 +1,34 @@
+package weather
+
+import "testing"
+
+func TestApplyRainCorrection(t *testing.T) {
+	obs := &Observation{RainAccumulated: 0.5, RainDailyTotal: 4, NCRainDailyTotal: 5}
+	ApplyRainCorrection(obs, 1.2)
+	if obs.RainAccumulated != 0.6 || obs.RainDailyTotal != 4.8 {
+		t.Errorf("corrected = %v, %v; want 0.6, 4.8", obs.RainAccumulated, obs.RainDailyTotal)
+	}
+	if acc, daily := RawRain(obs); acc != 0.5 || daily != 4 {
+		t.Errorf("raw = %v, %v; want 0.5, 4", acc, daily)
+	}
+	if obs.NCRainDailyTotal != 5 {
+		t.Errorf("Rain Check total changed to %v", obs.NCRainDailyTotal)
+	}
+
+	// Applying again must not compound the correction
+	ApplyRainCorrection(obs, 1.2)
+	if obs.RainDailyTotal != 4.8 {
+		t.Errorf("second correction changed the total to %v", obs.RainDailyTotal)
+	}
+
+	dry := &Observation{}
+	ApplyRainCorrection(dry, 1.2)
+	if acc, daily := RawRain(dry); acc != 0 || daily != 0 || dry.RainDailyTotal != 0 {
+		t.Errorf("dry observation = %+v", dry)
+	}
+
+	uncorrected := &Observation{RainDailyTotal: 3}
+	if _, daily := RawRain(uncorrected); daily != 3 {
+		t.Errorf("RawRain without a correction = %v, want 3", daily)
+	}
+}
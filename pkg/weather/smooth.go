@@ -0,0 +1,47 @@
+package weather
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Smoother is an exponential moving average for irregularly timed readings,
+// such as the illuminance reported to HomeKit: a reading's weight depends on
+// how long after the previous one it arrives, so the window is a time
+// constant rather than a count of readings. Passing clouds then no longer
+// swing the value enough to toggle automations on and off.
+type Smoother struct {
+	mu     sync.Mutex
+	window time.Duration
+	value  float64
+	last   time.Time
+}
+
+// NewSmoother creates a smoother with the given time constant (0 passes
+// readings through unchanged)
+func NewSmoother(window time.Duration) *Smoother {
+	return &Smoother{window: window}
+}
+
+// Add folds in v measured at t and returns the smoothed value. The first
+// reading starts the average; a reading not newer than the previous one
+// returns the average unchanged.
+func (s *Smoother) Add(t time.Time, v float64) float64 {
+	if s.window <= 0 {
+		return v
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.last.IsZero():
+		s.value = v
+	case !t.After(s.last):
+		return s.value
+	default:
+		alpha := 1 - math.Exp(-t.Sub(s.last).Seconds()/s.window.Seconds())
+		s.value += alpha * (v - s.value)
+	}
+	s.last = t
+	return s.value
+}
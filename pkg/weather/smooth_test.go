@@ -0,0 +1,38 @@
+package weather
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSmootherDampsSwings(t *testing.T) {
+	start := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := NewSmoother(5 * time.Minute)
+	if got := s.Add(start, 80000); got != 80000 {
+		t.Fatalf("first reading = %v, want it unchanged", got)
+	}
+
+	// A one-minute cloud moves the average by 1 - e^(-1/5), about 18%
+	got := s.Add(start.Add(time.Minute), 20000)
+	if want := 80000 - 60000*(1-math.Exp(-0.2)); math.Abs(got-want) > 0.01 {
+		t.Errorf("after a cloud = %v, want %v", got, want)
+	}
+	// A repeated timestamp leaves the average alone
+	if again := s.Add(start.Add(time.Minute), 0); again != got {
+		t.Errorf("repeat = %v, want %v", again, got)
+	}
+	// After many time constants the average has caught up
+	if caught := s.Add(start.Add(time.Hour), 20000); math.Abs(caught-20000) > 1 {
+		t.Errorf("after an hour = %v, want about 20000", caught)
+	}
+}
+
+func TestSmootherDisabled(t *testing.T) {
+	s := NewSmoother(0)
+	start := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.Add(start, 80000)
+	if got := s.Add(start.Add(time.Minute), 100); got != 100 {
+		t.Errorf("without a window = %v, want the raw reading", got)
+	}
+}
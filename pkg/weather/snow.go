@@ -0,0 +1,120 @@
+package weather
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// SnowAllBelow is the air temperature (°C) at or below which liquid
+	// precipitation is counted entirely as snow
+	SnowAllBelow = 0.0
+
+	// RainAllAbove is the air temperature (°C) at or above which it is counted
+	// entirely as rain. In between, the snow fraction falls off linearly.
+	RainAllAbove = 2.0
+
+	// DefaultSnowRatio is the snow-to-liquid ratio used when none is configured
+	// (10 mm of snow per mm of water)
+	DefaultSnowRatio = 10.0
+
+	// Tempest precipitation types that rule out snow
+	precipHail        = 2
+	precipRainAndHail = 3
+)
+
+// SnowFraction classifies an observation's precipitation: 1 for snow, 0 for
+// rain or no precipitation, and a linear mix between SnowAllBelow and
+// RainAllAbove. The Tempest reports rain and hail but not snow, so the type
+// only rules out hail and the temperature decides the rest.
+func SnowFraction(obs *Observation) float64 {
+	if obs.RainAccumulated <= 0 || obs.PrecipitationType == precipHail || obs.PrecipitationType == precipRainAndHail {
+		return 0
+	}
+	switch {
+	case obs.AirTemperature <= SnowAllBelow:
+		return 1
+	case obs.AirTemperature >= RainAllAbove:
+		return 0
+	default:
+		return (RainAllAbove - obs.AirTemperature) / (RainAllAbove - SnowAllBelow)
+	}
+}
+
+// SnowEstimator fills SnowRate and SnowDailyTotal from the liquid
+// precipitation, a snow-to-liquid ratio and the temperature. The daily total
+// resets at midnight in the station's time zone.
+type SnowEstimator struct {
+	ratio float64
+
+	mu       sync.Mutex
+	location *time.Location
+	day      string // date the daily total belongs to
+	daily    float64
+	last     int64 // newest timestamp counted in daily
+}
+
+// NewSnowEstimator creates an estimator with the given snow-to-liquid ratio
+// (DefaultSnowRatio when ratio <= 0) and day boundaries in loc (server local
+// time when nil)
+func NewSnowEstimator(ratio float64, loc *time.Location) *SnowEstimator {
+	if ratio <= 0 {
+		ratio = DefaultSnowRatio
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return &SnowEstimator{ratio: ratio, location: loc}
+}
+
+// SetLocation changes the time zone the daily total resets in
+func (s *SnowEstimator) SetLocation(loc *time.Location) {
+	if loc == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.location = loc
+}
+
+// Apply sets obs.SnowRate and, for observations newer than the last one
+// applied, adds to and reports the daily total. A repeat of the last
+// timestamp reports the total without adding to it; older observations
+// (history) only get their rate.
+func (s *SnowEstimator) Apply(obs *Observation) {
+	if obs == nil {
+		return
+	}
+	snow := SetSnowRate(obs, s.ratio)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if obs.Timestamp == s.last {
+		// An update of the last report (the UDP + API merger re-sends them)
+		obs.SnowDailyTotal = s.daily
+		return
+	}
+	if obs.Timestamp < s.last {
+		return
+	}
+	day := time.Unix(obs.Timestamp, 0).In(s.location).Format("2006-01-02")
+	if day != s.day {
+		s.day, s.daily = day, 0
+	}
+	s.daily += snow
+	s.last = obs.Timestamp
+	obs.SnowDailyTotal = s.daily
+}
+
+// SetSnowRate sets obs.SnowRate (mm/hr) for the given snow-to-liquid ratio and
+// returns the mm of snow in this one report. The Tempest reports once a minute
+// unless ReportInterval says otherwise.
+func SetSnowRate(obs *Observation, ratio float64) float64 {
+	snow := obs.RainAccumulated * SnowFraction(obs) * ratio
+	minutes := obs.ReportInterval
+	if minutes <= 0 {
+		minutes = 1
+	}
+	obs.SnowRate = snow * 60 / float64(minutes)
+	return snow
+}
@@ -0,0 +1,66 @@
+package weather
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSnowFraction(t *testing.T) {
+	tests := []struct {
+		name string
+		obs  Observation
+		want float64
+	}{
+		{"dry and cold", Observation{AirTemperature: -5}, 0},
+		{"freezing", Observation{RainAccumulated: 0.2, AirTemperature: -3}, 1},
+		{"at zero", Observation{RainAccumulated: 0.2, AirTemperature: 0}, 1},
+		{"mixed", Observation{RainAccumulated: 0.2, AirTemperature: 0.5}, 0.75},
+		{"warm", Observation{RainAccumulated: 0.2, AirTemperature: 2}, 0},
+		{"hail", Observation{RainAccumulated: 0.2, AirTemperature: -1, PrecipitationType: 2}, 0},
+	}
+	for _, tt := range tests {
+		if got := SnowFraction(&tt.obs); got != tt.want {
+			t.Errorf("%s: SnowFraction = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSnowEstimator(t *testing.T) {
+	utc := time.UTC
+	est := NewSnowEstimator(12, utc)
+	base := time.Date(2026, 1, 10, 23, 58, 0, 0, utc).Unix()
+
+	obs := &Observation{Timestamp: base, RainAccumulated: 0.1, AirTemperature: -2}
+	est.Apply(obs)
+	if math.Abs(obs.SnowRate-72) > 1e-9 || math.Abs(obs.SnowDailyTotal-1.2) > 1e-9 {
+		t.Errorf("first report: rate %v, daily %v; want 72 mm/hr and 1.2 mm", obs.SnowRate, obs.SnowDailyTotal)
+	}
+
+	obs = &Observation{Timestamp: base + 60, RainAccumulated: 0.1, AirTemperature: -2}
+	est.Apply(obs)
+	if math.Abs(obs.SnowDailyTotal-2.4) > 1e-9 {
+		t.Errorf("second report: daily %v, want 2.4", obs.SnowDailyTotal)
+	}
+
+	// A re-sent report keeps the total without counting it twice
+	again := &Observation{Timestamp: base + 60, RainAccumulated: 0.1, AirTemperature: -2}
+	est.Apply(again)
+	if math.Abs(again.SnowDailyTotal-2.4) > 1e-9 {
+		t.Errorf("repeated report: daily %v, want 2.4", again.SnowDailyTotal)
+	}
+
+	// Midnight resets the total
+	obs = &Observation{Timestamp: base + 120, RainAccumulated: 0.05, AirTemperature: -2, ReportInterval: 3}
+	est.Apply(obs)
+	if math.Abs(obs.SnowDailyTotal-0.6) > 1e-9 || math.Abs(obs.SnowRate-12) > 1e-9 {
+		t.Errorf("after midnight: daily %v, rate %v; want 0.6 mm and 12 mm/hr", obs.SnowDailyTotal, obs.SnowRate)
+	}
+
+	// Older observations only get a rate
+	old := &Observation{Timestamp: base - 600, RainAccumulated: 0.1, AirTemperature: -2}
+	est.Apply(old)
+	if old.SnowDailyTotal != 0 || old.SnowRate == 0 {
+		t.Errorf("old observation: daily %v, rate %v", old.SnowDailyTotal, old.SnowRate)
+	}
+}
@@ -14,6 +14,7 @@ type StatusManager struct {
 	stationID      int
 	logLevel       string
 	useWebScraping bool
+	interval       time.Duration
 	cachedStatus   *StationStatus
 	mutex          sync.RWMutex
 	stopChan       chan bool
@@ -26,6 +27,7 @@ func NewStatusManager(stationID int, logLevel string, useWebScraping bool) *Stat
 		stationID:      stationID,
 		logLevel:       logLevel,
 		useWebScraping: useWebScraping,
+		interval:       DefaultStatusInterval,
 		stopChan:       make(chan bool),
 	}
 
@@ -35,6 +37,12 @@ func NewStatusManager(stationID int, logLevel string, useWebScraping bool) *Stat
 	return manager
 }
 
+// SetInterval changes how often the status page is scraped; call it before
+// Start. Zero keeps the default.
+func (sm *StatusManager) SetInterval(d time.Duration) {
+	sm.interval = PollIntervals{Status: d}.withDefaults().Status
+}
+
 // Start begins the periodic status scraping if web scraping is enabled
 func (sm *StatusManager) Start() {
 	if !sm.useWebScraping {
@@ -45,7 +53,7 @@ func (sm *StatusManager) Start() {
 	}
 
 	if sm.logLevel == "debug" {
-		logger.Debug("Starting status manager with 15-minute web scraping interval")
+		logger.Debug("Starting status manager with %v web scraping interval", sm.interval)
 	}
 
 	sm.scrapingActive = true
@@ -78,15 +86,29 @@ func (sm *StatusManager) GetStatus() *StationStatus {
 	return &statusCopy
 }
 
-// periodicScraping runs the scraping loop every 15 minutes
+// SetStationID points the manager at another station, dropping the cached
+// status of the previous one
+func (sm *StatusManager) SetStationID(stationID int) {
+	sm.mutex.Lock()
+	sm.stationID = stationID
+	sm.cachedStatus = sm.createFallbackStatus()
+	sm.mutex.Unlock()
+
+	if sm.scrapingActive {
+		go sm.performScrape()
+	}
+}
+
+// periodicScraping runs the scraping loop on the jittered interval
 func (sm *StatusManager) periodicScraping() {
-	ticker := time.NewTicker(15 * time.Minute)
-	defer ticker.Stop()
+	timer := time.NewTimer(jittered(sm.interval))
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C:
 			go sm.performScrape()
+			timer.Reset(jittered(sm.interval))
 		case <-sm.stopChan:
 			return
 		}
@@ -95,8 +117,12 @@ func (sm *StatusManager) periodicScraping() {
 
 // performScrape attempts to scrape status data
 func (sm *StatusManager) performScrape() {
+	sm.mutex.RLock()
+	stationID := sm.stationID
+	sm.mutex.RUnlock()
+
 	if sm.logLevel == "debug" {
-		logger.Debug("Performing status scrape for station %d", sm.stationID)
+		logger.Debug("Performing status scrape for station %d", stationID)
 	}
 
 	var status *StationStatus
@@ -104,13 +130,13 @@ func (sm *StatusManager) performScrape() {
 
 	if sm.useWebScraping {
 		// Try headless browser scraping first
-		status, err = GetStationStatusWithBrowser(sm.stationID, sm.logLevel)
+		status, err = GetStationStatusWithBrowser(stationID, sm.logLevel)
 		if err != nil {
 			if sm.logLevel == "debug" {
 				logger.Debug("Browser scraping failed: %v", err)
 			}
 			// Fall back to regular HTTP scraping
-			status, err = GetStationStatus(sm.stationID, sm.logLevel)
+			status, err = GetStationStatus(stationID, sm.logLevel)
 			if err == nil && sm.hasUsefulData(status) {
 				status.DataSource = "api, web status page"
 				status.LastScraped = time.Now().UTC().Format(time.RFC3339)
@@ -147,8 +173,12 @@ func (sm *StatusManager) performScrape() {
 		}
 	}
 
-	// Update cached status
+	// Update cached status, unless the station changed while scraping
 	sm.mutex.Lock()
+	if sm.stationID != stationID {
+		sm.mutex.Unlock()
+		return
+	}
 	sm.cachedStatus = status
 	sm.mutex.Unlock()
 
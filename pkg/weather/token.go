@@ -0,0 +1,66 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// TokenState is the outcome of checking a WeatherFlow API token
+type TokenState string
+
+const (
+	TokenValid       TokenState = "valid"
+	TokenInvalid     TokenState = "invalid"      // rejected by the API: mistyped, expired or revoked
+	TokenRateLimited TokenState = "rate_limited" // the API asked us to slow down; the token itself may be fine
+	TokenUnreachable TokenState = "unreachable"  // network or server error; the token could not be checked
+)
+
+// TokenCheck is the result of CheckToken
+type TokenCheck struct {
+	State      TokenState
+	StatusCode int           // HTTP status, 0 for network errors
+	Message    string        // human readable detail, never contains the token
+	RetryAfter time.Duration // from Retry-After when rate limited
+	CheckedAt  time.Time
+}
+
+// CheckToken asks the WeatherFlow API for the token's stations and classifies
+// the answer, so callers can tell a bad token from rate limiting or an outage.
+func CheckToken(token string) TokenCheck {
+	check := TokenCheck{CheckedAt: time.Now()}
+	// Neither cached nor retried: the answer must reflect the token right now
+	resp, err := sharedClient.get(fmt.Sprintf("%s/stations?token=%s", BaseURL, url.QueryEscape(token)), requestOptions{noCache: true, noRetry: true})
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		check.State = TokenUnreachable
+		check.Message = fmt.Sprintf("WeatherFlow API unreachable: %v", err)
+		return check
+	}
+	check.StatusCode = resp.StatusCode
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		check.State = TokenValid
+		check.Message = "Token accepted"
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		check.State = TokenInvalid
+		check.Message = fmt.Sprintf("Token rejected (HTTP %d): it may be mistyped, expired or revoked", resp.StatusCode)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		check.State = TokenRateLimited
+		check.Message = "WeatherFlow API rate limit reached (HTTP 429)"
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			check.RetryAfter = time.Duration(secs) * time.Second
+			check.Message += fmt.Sprintf(", retry after %v", check.RetryAfter)
+		}
+	default:
+		check.State = TokenUnreachable
+		check.Message = fmt.Sprintf("WeatherFlow API error (HTTP %d)", resp.StatusCode)
+	}
+	return check
+}
@@ -0,0 +1,60 @@
+package weather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header string
+		want   TokenState
+	}{
+		{"valid", http.StatusOK, "", TokenValid},
+		{"unauthorized", http.StatusUnauthorized, "", TokenInvalid},
+		{"forbidden", http.StatusForbidden, "", TokenInvalid},
+		{"rate limited", http.StatusTooManyRequests, "30", TokenRateLimited},
+		{"server error", http.StatusBadGateway, "", TokenUnreachable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.header != "" {
+					w.Header().Set("Retry-After", tt.header)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"stations":[]}`))
+			}))
+			defer srv.Close()
+			defer overrideTransportToTestServer(srv)()
+
+			check := CheckToken("secret-token")
+			if check.State != tt.want || check.StatusCode != tt.status {
+				t.Errorf("CheckToken = %+v, want state %s", check, tt.want)
+			}
+			if tt.header != "" && check.RetryAfter != 30*time.Second {
+				t.Errorf("RetryAfter = %v, want 30s", check.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestCheckTokenNetworkErrorHidesToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	restore := overrideTransportToTestServer(srv)
+	defer restore()
+	srv.Close() // connections are refused from now on
+
+	check := CheckToken("secret-token")
+	if check.State != TokenUnreachable || check.StatusCode != 0 {
+		t.Errorf("CheckToken = %+v, want unreachable", check)
+	}
+	if strings.Contains(check.Message, "secret-token") {
+		t.Errorf("message leaks the token: %q", check.Message)
+	}
+}
@@ -0,0 +1,82 @@
+package weather
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Observations are stored in metric units (°C, mb, m/s, mm, km). The helpers
+// here convert them for display, so every display context (dashboard,
+// HomeKit, notifications, exports) agrees on the conversions.
+
+// kmPerMile converts statute miles to kilometres
+const kmPerMile = 1.609344
+
+// mbPerInHg converts inches of mercury to millibars
+const mbPerInHg = 33.8639
+
+// Pressure units
+const (
+	PressureMB   = "mb"
+	PressureInHg = "inHg"
+)
+
+// PressureUnits are the pressure units a display context can use
+var PressureUnits = []string{PressureInHg, PressureMB}
+
+// DistanceUnit returns the distance unit of a unit system (--units): "mi"
+// for imperial and sae, "km" for metric
+func DistanceUnit(units string) string {
+	if units == "metric" {
+		return "km"
+	}
+	return "mi"
+}
+
+// ConvertDistance converts a distance in km to the unit of a unit system
+func ConvertDistance(km float64, units string) float64 {
+	if DistanceUnit(units) == "mi" {
+		return km / kmPerMile
+	}
+	return km
+}
+
+// ConvertPressure converts a pressure in mb to unit (inHg or mb); any other
+// unit is treated as mb
+func ConvertPressure(mb float64, unit string) float64 {
+	if unit == PressureInHg {
+		return mb / mbPerInHg
+	}
+	return mb
+}
+
+// PressureToMB converts a pressure in unit (inHg, mb or hPa, case
+// insensitive) to mb
+func PressureToMB(value float64, unit string) (float64, error) {
+	switch strings.ToLower(unit) {
+	case "inhg":
+		return value * mbPerInHg, nil
+	case "mb", "hpa":
+		return value, nil
+	}
+	return 0, fmt.Errorf("unknown pressure unit %q", unit)
+}
+
+// FormatPressure formats a pressure in mb in unit with its unit, to the
+// precision each unit is read at: "29.92 inHg" or "1013.2 mb"
+func FormatPressure(mb float64, unit string) string {
+	if unit == PressureInHg {
+		return fmt.Sprintf("%.2f inHg", ConvertPressure(mb, unit))
+	}
+	return fmt.Sprintf("%.1f mb", mb)
+}
+
+// PressureRange is the span and step a pressure gauge in unit covers, for
+// consumers such as HomeKit that need fixed characteristic ranges: 700 to
+// 1200 mb, or the same span in inHg
+func PressureRange(unit string) (min, max, step float64) {
+	if unit == PressureInHg {
+		return ConvertPressure(700, unit), ConvertPressure(1200, unit), 0.01
+	}
+	return 700, 1200, 0.1
+}
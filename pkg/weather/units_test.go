@@ -0,0 +1,44 @@
+package weather
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvertDistance(t *testing.T) {
+	for units, want := range map[string]float64{"imperial": 6.21, "sae": 6.21, "metric": 10} {
+		if got := ConvertDistance(10, units); math.Abs(got-want) > 0.01 {
+			t.Errorf("ConvertDistance(10, %q) = %v, want %v", units, got, want)
+		}
+	}
+	if DistanceUnit("metric") != "km" || DistanceUnit("imperial") != "mi" {
+		t.Errorf("DistanceUnit = %q/%q, want km/mi", DistanceUnit("metric"), DistanceUnit("imperial"))
+	}
+}
+
+func TestPressureUnits(t *testing.T) {
+	if got := ConvertPressure(1013.25, PressureInHg); math.Abs(got-29.92) > 0.005 {
+		t.Errorf("ConvertPressure(1013.25, inHg) = %v, want 29.92", got)
+	}
+	if got := FormatPressure(1013.25, PressureInHg); got != "29.92 inHg" {
+		t.Errorf("FormatPressure(inHg) = %q", got)
+	}
+	if got := FormatPressure(1013.25, PressureMB); got != "1013.2 mb" {
+		t.Errorf("FormatPressure(mb) = %q", got)
+	}
+	for unit, want := range map[string]float64{"inHg": 1013.25, "INHG": 1013.25, "mb": 1013.25, "hPa": 1013.25} {
+		value := 1013.25
+		if unit == "inHg" || unit == "INHG" {
+			value = 1013.25 / mbPerInHg
+		}
+		if got, err := PressureToMB(value, unit); err != nil || math.Abs(got-want) > 1e-9 {
+			t.Errorf("PressureToMB(%v, %q) = %v, %v; want %v", value, unit, got, err, want)
+		}
+	}
+	if _, err := PressureToMB(30, "psi"); err == nil {
+		t.Error("PressureToMB(psi) should fail")
+	}
+	if min, max, step := PressureRange(PressureInHg); math.Abs(min-20.67) > 0.01 || math.Abs(max-35.44) > 0.01 || step != 0.01 {
+		t.Errorf("PressureRange(inHg) = %v..%v step %v", min, max, step)
+	}
+}
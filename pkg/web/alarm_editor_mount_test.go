@@ -0,0 +1,53 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMountAlarmEditorRequiresPassword(t *testing.T) {
+	ws := testNewWebServer(t)
+
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("editor"))
+	})
+	ws.MountAlarmEditor(inner, "s3cret")
+
+	ts := httptest.NewServer(ws.server.Handler)
+	defer ts.Close()
+
+	cases := []struct {
+		name     string
+		password string
+		auth     bool
+		want     int
+	}{
+		{"no credentials", "", false, http.StatusUnauthorized},
+		{"wrong password", "nope", true, http.StatusUnauthorized},
+		{"correct password", "s3cret", true, http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, ts.URL+"/alarm-editor/", nil)
+			if tc.auth {
+				req.SetBasicAuth("admin", tc.password)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+			if resp.StatusCode != tc.want {
+				t.Errorf("expected %d, got %d", tc.want, resp.StatusCode)
+			}
+		})
+	}
+
+	ws.mu.RLock()
+	url := ws.alarmEditorURL
+	ws.mu.RUnlock()
+	if url != "/alarm-editor/" {
+		t.Errorf("expected editor URL to be recorded, got %q", url)
+	}
+}
@@ -0,0 +1,260 @@
+package web
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"html/template"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AlarmAcknowledger accepts acknowledgements of alarm notifications from
+// their links and SMS replies; *alarm.Manager implements it
+type AlarmAcknowledger interface {
+	AcknowledgeToken(token, by string) (string, error)
+	AcknowledgeReply(from, body string, trusted bool) (string, error)
+	VerifySMSReply(replyURL string, params url.Values, signature string) bool
+}
+
+// ackTokenHeader carries the acknowledgement token of POST /api/alarms/ack
+// for clients that do not send it in the body
+const ackTokenHeader = "X-Ack-Token"
+
+// AlarmAckRequest is the JSON body of POST /api/alarms/ack and the JSON form
+// of an SMS reply to /api/alarms/ack/sms
+type AlarmAckRequest struct {
+	Token string `json:"token,omitempty"` // link: the token after # in the {{ack_url}} link
+	By    string `json:"by,omitempty"`    // link: who saw the alarm, shown on the dashboard
+	From  string `json:"from,omitempty"`  // sms: the sender's phone number
+	Body  string `json:"body,omitempty"`  // sms: the reply, "ACK <code>"
+}
+
+// AlarmAckResponse reports which alarm an acknowledgement was for
+type AlarmAckResponse struct {
+	Name         string `json:"name"`
+	Acknowledged bool   `json:"acknowledged"`
+}
+
+// ackPage is the page acknowledgement links open. Links are not
+// acknowledged on GET, since mail scanners follow them; the button posts back
+// to the same URL. The token is in the link's fragment, which browsers never
+// send, so it stays out of request and proxy logs; the script moves it into
+// the form and drops it from the address bar and history.
+var ackPage = template.Must(template.New("ack").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Acknowledge alarm</title>
+</head>
+<body style="font-family: Arial, Helvetica, sans-serif; max-width: 480px; margin: 40px auto; padding: 0 16px;">
+{{if .Name}}<h1>✅ {{.Name}} acknowledged</h1>
+<p>Everyone watching the dashboard can see the alert was seen. The alarm stays quiet until its condition clears.</p>
+{{else if .Error}}<h1>Acknowledgement failed</h1>
+<p>{{.Error}}</p>
+{{else}}<h1>Acknowledge alarm</h1>
+<form method="post" id="ack-form">
+<input type="hidden" name="token" id="ack-token">
+<p><label>Your name (optional)<br><input type="text" name="by" maxlength="60" autocomplete="name"></label></p>
+<p><button type="submit" id="ack-button">I have seen this alert</button></p>
+<p id="ack-missing" hidden>This page was opened without the token from the alert. Open the link in the notification again.</p>
+</form>
+<script>
+(function () {
+    var token = decodeURIComponent(window.location.hash.slice(1));
+    if (token) {
+        document.getElementById('ack-token').value = token;
+        history.replaceState(null, '', window.location.pathname + window.location.search);
+    } else {
+        document.getElementById('ack-button').disabled = true;
+        document.getElementById('ack-missing').hidden = false;
+    }
+})();
+</script>
+{{end}}</body>
+</html>
+`))
+
+// alarmAcknowledger returns the alarm manager's acknowledgements, or writes
+// the error and returns nil when alarms are off
+func (ws *WebServer) alarmAcknowledger(w http.ResponseWriter) AlarmAcknowledger {
+	ws.mu.RLock()
+	acknowledger, ok := ws.alarmManager.(AlarmAcknowledger)
+	ws.mu.RUnlock()
+	if !ok {
+		http.Error(w, "Alarms are not enabled", http.StatusServiceUnavailable)
+		return nil
+	}
+	return acknowledger
+}
+
+// wantsJSON reports whether a request sent or asks for JSON
+func wantsJSON(r *http.Request) bool {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return contentType == "application/json" || strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// handleAlarmAckAPI acknowledges the notification an {{ack_url}} link
+// belongs to. The token is the credential, so no password is needed; it comes
+// in the body or the X-Ack-Token header, never in the URL.
+func (ws *WebServer) handleAlarmAckAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = ackPage.Execute(w, struct{ Name, Error string }{})
+		return
+	}
+	acknowledger := ws.alarmAcknowledger(w)
+	if acknowledger == nil {
+		return
+	}
+
+	var req AlarmAckRequest
+	r.Body = http.MaxBytesReader(w, r.Body, 4<<10)
+	if wantsJSON(r) && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Request body must be {\"token\": \"<token>\", \"by\": \"<name>\"}", http.StatusBadRequest)
+			return
+		}
+	} else {
+		req.Token, req.By = r.PostFormValue("token"), r.PostFormValue("by")
+	}
+	token := strings.TrimSpace(req.Token)
+	if header := r.Header.Get(ackTokenHeader); header != "" {
+		token = strings.TrimSpace(header)
+	}
+	by := strings.TrimSpace(req.By)
+	if runes := []rune(by); len(runes) > 60 {
+		by = string(runes[:60])
+	}
+	if by == "" {
+		by = "web link"
+	}
+
+	name, err := "", errors.New("no acknowledgement token. Open the link in the notification again")
+	status := http.StatusBadRequest
+	if token != "" {
+		name, err = acknowledger.AcknowledgeToken(token, by)
+		status = http.StatusNotFound
+	}
+	if wantsJSON(r) {
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(AlarmAckResponse{Name: name, Acknowledged: true})
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	data := struct{ Name, Error string }{Name: name}
+	if err != nil {
+		w.WriteHeader(status)
+		data.Error = err.Error()
+		if status == http.StatusNotFound {
+			data.Error += ". A newer alert may have replaced this one; check the dashboard."
+		}
+	}
+	_ = ackPage.Execute(w, data)
+}
+
+// handleAlarmAckRedirect sends links that carry the token in the path, as
+// notifications from earlier versions do, to the acknowledgement page with
+// the token in the fragment
+func (ws *WebServer) handleAlarmAckRedirect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	http.Redirect(w, r, ws.BasePath()+"/api/alarms/ack#"+url.PathEscape(r.PathValue("token")), http.StatusFound)
+}
+
+// twimlResponse is the reply Twilio texts back to the sender
+type twimlResponse struct {
+	XMLName xml.Name `xml:"Response"`
+	Message string   `xml:"Message"`
+}
+
+// handleAlarmAckSMSAPI acknowledges a notification from an SMS reply. Twilio
+// posts replies as a form signed with X-Twilio-Signature and gets a TwiML
+// answer texted back; other gateways can post JSON. Replies without a valid
+// signature must quote the alert's code.
+func (ws *WebServer) handleAlarmAckSMSAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	acknowledger := ws.alarmAcknowledger(w)
+	if acknowledger == nil {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 16<<10)
+	var req AlarmAckRequest
+	trusted := false
+	isJSON := wantsJSON(r)
+	if isJSON {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Request body must be {\"from\": \"+15551234567\", \"body\": \"ACK <code>\"}", http.StatusBadRequest)
+			return
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form", http.StatusBadRequest)
+			return
+		}
+		req.From, req.Body = r.PostForm.Get("From"), r.PostForm.Get("Body")
+		if signature := r.Header.Get("X-Twilio-Signature"); signature != "" {
+			if !acknowledger.VerifySMSReply(requestURL(r, ws.BasePath()), r.PostForm, signature) {
+				http.Error(w, "Invalid signature", http.StatusForbidden)
+				return
+			}
+			trusted = true
+		}
+	}
+
+	name, err := acknowledger.AcknowledgeReply(req.From, req.Body, trusted)
+	if isJSON {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(AlarmAckResponse{Name: name, Acknowledged: true})
+		return
+	}
+	reply := twimlResponse{Message: "Acknowledged: " + name}
+	if err != nil {
+		reply.Message = "Not acknowledged: " + err.Error()
+	}
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(reply)
+}
+
+// requestURL is the URL a client requested, as Twilio signs it, honouring
+// the scheme and host a reverse proxy forwards and the basePath it strips
+func requestURL(r *http.Request, basePath string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = strings.TrimSpace(strings.Split(proto, ",")[0])
+	}
+	host := r.Host
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		host = strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	return scheme + "://" + host + basePath + r.URL.RequestURI()
+}
@@ -0,0 +1,135 @@
+package web
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestAlarmAckAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.SetAdminPassword("secret")
+	do := func(method, path, contentType, body string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/api/alarms/ack", "application/x-www-form-urlencoded", "token=abc", nil); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without alarms, got %d", rec.Code)
+	}
+
+	bodies := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- string(b)
+	}))
+	defer srv.Close()
+	manager, err := alarm.NewManager(`{"alarms": [{"name": "Hot", "condition": "temperature > 30", "enabled": true,
+		"channels": [{"type": "webhook", "webhook": {"url": "`+srv.URL+`", "body": "{{ack_url}}", "content_type": "text/plain"}}]}]}`, "Test")
+	if err != nil {
+		t.Fatalf("Failed to create alarm manager: %v", err)
+	}
+	defer manager.Stop()
+	ws.SetAlarmManager(manager)
+	manager.ProcessObservation(&weather.Observation{Timestamp: 1700000000, AirTemperature: 35})
+	_, token, ok := strings.Cut(<-bodies, "/api/alarms/ack#")
+	if !ok {
+		t.Fatal("the notification has no acknowledgement link")
+	}
+
+	status := func() AlarmStatus {
+		rec := httptest.NewRecorder()
+		ws.handleAlarmStatusAPI(rec, httptest.NewRequest(http.MethodGet, "/api/alarm-status", nil))
+		var resp AlarmStatusResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || len(resp.Alarms) != 1 {
+			t.Fatalf("alarm status: %v %+v", err, resp)
+		}
+		return resp.Alarms[0]
+	}
+	if s := status(); !s.AwaitingAck || s.Acknowledged {
+		t.Errorf("before acknowledging: %+v", s)
+	}
+
+	// Opening the link only shows the confirmation form, which posts the
+	// token from the fragment
+	rec := do(http.MethodGet, "/api/alarms/ack", "", "", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `<input type="hidden" name="token"`) || !status().AwaitingAck {
+		t.Errorf("GET: got %d, awaiting %v", rec.Code, status().AwaitingAck)
+	}
+	// Links from earlier versions carry the token in the path
+	if rec := do(http.MethodGet, "/api/alarms/ack/"+token, "", "", nil); rec.Code != http.StatusFound || rec.Header().Get("Location") != "/api/alarms/ack#"+token {
+		t.Errorf("expected a redirect to the fragment link, got %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = do(http.MethodPost, "/api/alarms/ack", "application/json", `{"token": "wrong", "by": "Sam"}`, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown token, got %d", rec.Code)
+	}
+	rec = do(http.MethodPost, "/api/alarms/ack", "application/json", `{"by": "Sam"}`, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a token, got %d", rec.Code)
+	}
+	rec = do(http.MethodPost, "/api/alarms/ack", "application/x-www-form-urlencoded", "by=Sam&token="+token, nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Hot acknowledged") {
+		t.Errorf("POST: got %d: %s", rec.Code, rec.Body.String())
+	}
+	if s := status(); s.AwaitingAck || !s.Acknowledged || s.AcknowledgedBy != "Sam" || s.AcknowledgedAt == "" {
+		t.Errorf("after acknowledging: %+v", s)
+	}
+
+	// SMS replies: JSON from a gateway, and Twilio forms with a signature
+	code := strings.ToUpper(token[:6])
+	rec = do(http.MethodPost, "/api/alarms/ack/sms", "application/json", `{"from": "+15551234567", "body": "ACK `+code+`"}`, nil)
+	var resp AlarmAckResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Name != "Hot" {
+		t.Errorf("SMS JSON: got %d %+v (%v)", rec.Code, resp, err)
+	}
+	form := url.Values{"From": {"+15551234567"}, "Body": {"ACK"}}.Encode()
+	rec = do(http.MethodPost, "/api/alarms/ack/sms", "application/x-www-form-urlencoded", form, http.Header{"X-Twilio-Signature": {"bad"}})
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a bad signature, got %d", rec.Code)
+	}
+	rec = do(http.MethodPost, "/api/alarms/ack/sms", "application/x-www-form-urlencoded", form, nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<Response><Message>Not acknowledged") {
+		t.Errorf("unsigned bare ACK: got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The token may come in a header; the request log never shows it
+	rec = do(http.MethodPost, "/api/alarms/ack", "", "", http.Header{"X-Ack-Token": {token}, "Accept": {"application/json"}})
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Name != "Hot" {
+		t.Errorf("header token: got %d %+v (%v)", rec.Code, resp, err)
+	}
+	rec = do(http.MethodGet, "/api/requests", "", "", http.Header{"Authorization": {"Basic " + base64.StdEncoding.EncodeToString([]byte("admin:secret"))}})
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), token) {
+		t.Errorf("/api/requests: got %d, exposes the token: %v", rec.Code, strings.Contains(rec.Body.String(), token))
+	}
+}
+
+func TestRequestURL(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/alarms/ack/sms?x=1", nil)
+	req.Host = "internal:8080"
+	if got := requestURL(req, ""); got != "http://internal:8080/api/alarms/ack/sms?x=1" {
+		t.Errorf("requestURL = %q", got)
+	}
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "weather.example.com")
+	if got := requestURL(req, "/tempest"); got != "https://weather.example.com/tempest/api/alarms/ack/sms?x=1" {
+		t.Errorf("requestURL behind a proxy = %q", got)
+	}
+}
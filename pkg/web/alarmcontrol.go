@@ -0,0 +1,153 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// AlarmController changes the runtime state of alarms; *alarm.Manager
+// implements it
+type AlarmController interface {
+	Acknowledge(name string) error
+	Snooze(name string, d time.Duration) (time.Time, error)
+	SetEnabled(name string, enabled bool) error
+	TriggerTest(name string, obs *weather.Observation) (int, []error, error)
+	TriggerChannelTest(name string, index int, obs *weather.Observation) error
+}
+
+// AlarmControlRequest is the body of the /api/alarms/{acknowledge,snooze,
+// enable,send-test} endpoints. Only name is needed by every one.
+type AlarmControlRequest struct {
+	Name     string `json:"name"`
+	Duration string `json:"duration,omitempty"` // snooze: Go duration such as "1h"; "0" ends a snooze
+	Enabled  *bool  `json:"enabled,omitempty"`  // enable: the new state
+	Channel  *int   `json:"channel,omitempty"`  // send-test: channel index; all channels when left out
+}
+
+// AlarmControlResponse reports the outcome of an alarm control request
+type AlarmControlResponse struct {
+	Name         string   `json:"name"`
+	SnoozedUntil string   `json:"snoozedUntil,omitempty"` // snooze: RFC 3339; empty when a snooze was ended
+	Channels     int      `json:"channels,omitempty"`     // send-test: channels attempted
+	Errors       []string `json:"errors,omitempty"`       // send-test: delivery errors
+}
+
+// alarmControl decodes an alarm control request, or writes the error and
+// returns nil when alarms are off or the body is unusable
+func (ws *WebServer) alarmControl(w http.ResponseWriter, r *http.Request) (AlarmController, *AlarmControlRequest) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, nil
+	}
+	ws.mu.RLock()
+	controller, ok := ws.alarmManager.(AlarmController)
+	ws.mu.RUnlock()
+	if !ok {
+		http.Error(w, "Alarms are not enabled", http.StatusServiceUnavailable)
+		return nil, nil
+	}
+	var req AlarmControlRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10)).Decode(&req); err != nil || req.Name == "" {
+		http.Error(w, "Request body must be {\"name\": \"<alarm name>\", ...}", http.StatusBadRequest)
+		return nil, nil
+	}
+	return controller, &req
+}
+
+func writeAlarmControl(w http.ResponseWriter, resp AlarmControlResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// handleAlarmAcknowledgeAPI silences an alarm until its condition clears
+func (ws *WebServer) handleAlarmAcknowledgeAPI(w http.ResponseWriter, r *http.Request) {
+	controller, req := ws.alarmControl(w, r)
+	if req == nil {
+		return
+	}
+	if err := controller.Acknowledge(req.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeAlarmControl(w, AlarmControlResponse{Name: req.Name})
+}
+
+// handleAlarmSnoozeAPI silences an alarm for a duration
+func (ws *WebServer) handleAlarmSnoozeAPI(w http.ResponseWriter, r *http.Request) {
+	controller, req := ws.alarmControl(w, r)
+	if req == nil {
+		return
+	}
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil || d > 7*24*time.Hour {
+		http.Error(w, "duration must be a duration up to 168h, such as \"30m\" or \"2h\" (\"0\" ends a snooze)", http.StatusBadRequest)
+		return
+	}
+	until, err := controller.Snooze(req.Name, d)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	resp := AlarmControlResponse{Name: req.Name}
+	if !until.IsZero() {
+		resp.SnoozedUntil = until.Format(time.RFC3339)
+	}
+	writeAlarmControl(w, resp)
+}
+
+// handleAlarmEnableAPI enables or disables an alarm
+func (ws *WebServer) handleAlarmEnableAPI(w http.ResponseWriter, r *http.Request) {
+	controller, req := ws.alarmControl(w, r)
+	if req == nil {
+		return
+	}
+	if req.Enabled == nil {
+		http.Error(w, "Request body must include \"enabled\": true or false", http.StatusBadRequest)
+		return
+	}
+	if err := controller.SetEnabled(req.Name, *req.Enabled); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeAlarmControl(w, AlarmControlResponse{Name: req.Name})
+}
+
+// handleAlarmSendTestAPI sends a test notification for an alarm with the
+// latest observation, through one channel or all of them
+func (ws *WebServer) handleAlarmSendTestAPI(w http.ResponseWriter, r *http.Request) {
+	controller, req := ws.alarmControl(w, r)
+	if req == nil {
+		return
+	}
+	ws.mu.RLock()
+	obs := ws.weatherData
+	ws.mu.RUnlock()
+	if obs == nil {
+		http.Error(w, "No observation received yet", http.StatusServiceUnavailable)
+		return
+	}
+	current := *obs
+
+	resp := AlarmControlResponse{Name: req.Name}
+	if req.Channel != nil {
+		resp.Channels = 1
+		if err := controller.TriggerChannelTest(req.Name, *req.Channel, &current); err != nil {
+			resp.Errors = append(resp.Errors, err.Error())
+		}
+	} else {
+		attempted, errs, err := controller.TriggerTest(req.Name, &current)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		resp.Channels = attempted
+		for _, e := range errs {
+			resp.Errors = append(resp.Errors, e.Error())
+		}
+	}
+	writeAlarmControl(w, resp)
+}
@@ -0,0 +1,94 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestAlarmControlAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.SetAdminPassword("secret")
+	do := func(action, body string, auth bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/alarms/"+action, strings.NewReader(body))
+		if auth {
+			req.SetBasicAuth("admin", "secret")
+		}
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("acknowledge", `{"name": "Hot"}`, true); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without alarms, got %d", rec.Code)
+	}
+
+	manager, err := alarm.NewManager(`{"alarms": [{"name": "Hot", "condition": "temperature > 30", "enabled": true,
+		"channels": [{"type": "console", "template": "Hot"}]}]}`, "Test")
+	if err != nil {
+		t.Fatalf("Failed to create alarm manager: %v", err)
+	}
+	defer manager.Stop()
+	ws.SetAlarmManager(manager)
+
+	if rec := do("acknowledge", `{"name": "Hot"}`, false); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the admin password, got %d", rec.Code)
+	}
+	if rec := do("acknowledge", `{}`, true); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a name, got %d", rec.Code)
+	}
+	if rec := do("acknowledge", `{"name": "Cold"}`, true); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown alarm, got %d", rec.Code)
+	}
+	if rec := do("acknowledge", `{"name": "Hot"}`, true); rec.Code != http.StatusOK {
+		t.Errorf("acknowledge: got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := do("snooze", `{"name": "Hot", "duration": "200h"}`, true); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a snooze over 168h, got %d", rec.Code)
+	}
+	rec := do("snooze", `{"name": "Hot", "duration": "2h"}`, true)
+	var resp AlarmControlResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.SnoozedUntil == "" {
+		t.Errorf("snooze: got %d %+v (%v)", rec.Code, resp, err)
+	}
+
+	if rec := do("enable", `{"name": "Hot"}`, true); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without enabled, got %d", rec.Code)
+	}
+	if rec := do("enable", `{"name": "Hot", "enabled": false}`, true); rec.Code != http.StatusOK || manager.GetConfig().Alarms[0].Enabled {
+		t.Errorf("enable: got %d, alarm enabled %v", rec.Code, manager.GetConfig().Alarms[0].Enabled)
+	}
+
+	// The status API reports what the console shows
+	statusRec := httptest.NewRecorder()
+	ws.handleAlarmStatusAPI(statusRec, httptest.NewRequest(http.MethodGet, "/api/alarm-status", nil))
+	var status AlarmStatusResponse
+	if err := json.NewDecoder(statusRec.Body).Decode(&status); err != nil || len(status.Alarms) != 1 {
+		t.Fatalf("alarm status: %v %+v", err, status)
+	}
+	if a := status.Alarms[0]; !a.Acknowledged || a.SnoozedUntil == "" || a.Enabled {
+		t.Errorf("unexpected alarm status: %+v", a)
+	}
+
+	if rec := do("send-test", `{"name": "Hot"}`, true); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 before any observation, got %d", rec.Code)
+	}
+	ws.UpdateWeather(&weather.Observation{Timestamp: time.Now().Unix(), AirTemperature: 35})
+	rec = do("send-test", `{"name": "Hot", "channel": 0}`, true)
+	resp = AlarmControlResponse{}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Channels != 1 || len(resp.Errors) != 0 {
+		t.Errorf("send-test: got %d %+v (%v)", rec.Code, resp, err)
+	}
+	rec = do("send-test", `{"name": "Hot", "channel": 3}`, true)
+	resp = AlarmControlResponse{}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || len(resp.Errors) != 1 {
+		t.Errorf("expected a channel error, got %d %+v (%v)", rec.Code, resp, err)
+	}
+}
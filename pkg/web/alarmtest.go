@@ -0,0 +1,62 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// AlarmSimulator evaluates alarms against an observation without firing
+// them; *alarm.Manager implements it
+type AlarmSimulator interface {
+	Simulate(obs *weather.Observation) []alarm.SimulatedAlarm
+}
+
+// AlarmTestResponse is returned by /api/alarms/test
+type AlarmTestResponse struct {
+	Observation weather.Observation    `json:"observation"`
+	Alarms      []alarm.SimulatedAlarm `json:"alarms"`
+	Firing      int                    `json:"firing"` // alarms that would fire
+}
+
+// handleAlarmTestAPI runs the observation in the request body through the
+// live alarm manager and reports which alarms would fire and what each
+// channel would send. The observation is not added to the history or the
+// dashboard, and no notification is sent.
+func (ws *WebServer) handleAlarmTestAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ws.mu.RLock()
+	simulator, ok := ws.alarmManager.(AlarmSimulator)
+	ws.mu.RUnlock()
+	if !ok {
+		http.Error(w, "Alarms are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var obs weather.Observation
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&obs); err != nil {
+		http.Error(w, "Request body must be an observation, e.g. {\"air_temperature\": 35.2}: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if obs.Timestamp == 0 {
+		obs.Timestamp = time.Now().Unix()
+	}
+
+	resp := AlarmTestResponse{Observation: obs, Alarms: simulator.Simulate(&obs)}
+	for _, a := range resp.Alarms {
+		if a.Fires {
+			resp.Firing++
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,54 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/alarm"
+)
+
+func TestAlarmTestAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.SetAdminPassword("secret")
+	do := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/alarms/test", strings.NewReader(body))
+		req.SetBasicAuth("admin", "secret")
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(`{"air_temperature": 35}`); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without alarms, got %d", rec.Code)
+	}
+
+	manager, err := alarm.NewManager(`{"alarms": [{"name": "Hot", "condition": "temperature > 30", "enabled": true,
+		"channels": [{"type": "console", "template": "Hot: {{temperature_c}}°C"}]}]}`, "Test")
+	if err != nil {
+		t.Fatalf("Failed to create alarm manager: %v", err)
+	}
+	defer manager.Stop()
+	ws.SetAlarmManager(manager)
+
+	if rec := do(`{"air_temp": 35}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown field, got %d", rec.Code)
+	}
+
+	rec := do(`{"air_temperature": 35}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp AlarmTestResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode /api/alarms/test response: %v", err)
+	}
+	if resp.Firing != 1 || resp.Observation.Timestamp == 0 || len(resp.Alarms) != 1 || !strings.HasPrefix(resp.Alarms[0].Messages[0].Message, "Hot: 35") {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if ws.weatherData != nil || ws.history.Len() != 0 {
+		t.Error("simulated observation reached the dashboard or history")
+	}
+}
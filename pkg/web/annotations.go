@@ -0,0 +1,166 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tempest-homekit-go/pkg/annotations"
+)
+
+// AnnotationRequest is the body of POST /api/annotations and PUT
+// /api/annotations/{id}. Times are RFC 3339 or Unix seconds; without end the
+// note marks the moment start.
+type AnnotationRequest struct {
+	Start string `json:"start"`
+	End   string `json:"end,omitempty"`
+	Text  string `json:"text"`
+}
+
+// SetAnnotations sets the notes /api/annotations serves and the charts draw
+func (ws *WebServer) SetAnnotations(s *annotations.Store) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.annotations = s
+}
+
+// annotationStore returns the annotations, or writes the error and returns
+// nil when there are none
+func (ws *WebServer) annotationStore(w http.ResponseWriter) *annotations.Store {
+	ws.mu.RLock()
+	s := ws.annotations
+	ws.mu.RUnlock()
+	if s == nil {
+		http.Error(w, "Annotations are not available", http.StatusServiceUnavailable)
+	}
+	return s
+}
+
+// parseTimeParam reads an RFC 3339 or Unix seconds time; empty is zero
+func parseTimeParam(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s '%s'. Use Unix seconds or an RFC 3339 time", name, value)
+	}
+	return t, nil
+}
+
+// decodeAnnotation reads an annotation request body, or writes the error and
+// returns false
+func decodeAnnotation(w http.ResponseWriter, r *http.Request) (start, end time.Time, text string, ok bool) {
+	var req AnnotationRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 8<<10)).Decode(&req); err != nil {
+		http.Error(w, "Request body must be {\"start\": \"<RFC 3339>\", \"end\": \"<RFC 3339>\", \"text\": \"<note>\"}", http.StatusBadRequest)
+		return
+	}
+	var err error
+	if start, err = parseTimeParam("start", req.Start); err == nil {
+		end, err = parseTimeParam("end", req.End)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	return start, end, req.Text, true
+}
+
+func writeAnnotations(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// handleAnnotationsAPI lists the annotations on GET and adds one on POST,
+// which requires the admin password
+func (ws *WebServer) handleAnnotationsAPI(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s := ws.annotationStore(w)
+		if s == nil {
+			return
+		}
+		from, err := parseTimeParam("from", r.URL.Query().Get("from"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := parseTimeParam("to", r.URL.Query().Get("to"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeAnnotations(w, s.List(from, to))
+	case http.MethodPost:
+		ws.requireAdmin(ws.handleAnnotationAddAPI)(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAnnotationAddAPI stores a new annotation
+func (ws *WebServer) handleAnnotationAddAPI(w http.ResponseWriter, r *http.Request) {
+	s := ws.annotationStore(w)
+	if s == nil {
+		return
+	}
+	start, end, text, ok := decodeAnnotation(w, r)
+	if !ok {
+		return
+	}
+	a, err := s.Add(start, end, text)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	writeAnnotations(w, a)
+}
+
+// handleAnnotationAPI changes an annotation on PUT and removes it on DELETE
+func (ws *WebServer) handleAnnotationAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s := ws.annotationStore(w)
+	if s == nil {
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid annotation id '%s'", r.PathValue("id")), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if err := s.Delete(id); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	start, end, text, ok := decodeAnnotation(w, r)
+	if !ok {
+		return
+	}
+	a, err := s.Update(id, start, end, text)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, annotations.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeAnnotations(w, a)
+}
@@ -0,0 +1,95 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/annotations"
+)
+
+func TestAnnotationsAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.SetAdminPassword("secret")
+	do := func(method, path, body string, auth bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if auth {
+			req.SetBasicAuth("admin", "secret")
+		}
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/api/annotations", "", false); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without annotations, got %d", rec.Code)
+	}
+	ws.SetAnnotations(annotations.New())
+
+	body := `{"start": "2025-06-01T14:00:00Z", "end": "2025-06-01T15:30:00Z", "text": "sprinkler hit sensor"}`
+	if rec := do(http.MethodPost, "/api/annotations", body, false); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the admin password, got %d", rec.Code)
+	}
+	for _, bad := range []string{`{}`, `{"start": "noon", "text": "x"}`, `{"start": "2025-06-01T14:00:00Z"}`, `{"start": "2025-06-01T14:00:00Z", "end": "2025-06-01T13:00:00Z", "text": "x"}`} {
+		if rec := do(http.MethodPost, "/api/annotations", bad, true); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", bad, rec.Code)
+		}
+	}
+
+	rec := do(http.MethodPost, "/api/annotations", body, true)
+	var added annotations.Annotation
+	if err := json.NewDecoder(rec.Body).Decode(&added); err != nil || rec.Code != http.StatusCreated || added.Text != "sprinkler hit sensor" {
+		t.Fatalf("add: got %d %+v (%v)", rec.Code, added, err)
+	}
+	// Unix seconds and a moment without end
+	if rec := do(http.MethodPost, "/api/annotations", `{"start": "1748880000", "text": "moved station"}`, true); rec.Code != http.StatusCreated {
+		t.Errorf("add moment: got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	list := func(query string) []annotations.Annotation {
+		t.Helper()
+		var got []annotations.Annotation
+		rec := do(http.MethodGet, "/api/annotations"+query, "", false)
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("list %s: %d (%v)", query, rec.Code, err)
+		}
+		return got
+	}
+	if got := list(""); len(got) != 2 {
+		t.Errorf("list = %+v, want both", got)
+	}
+	if got := list("?from=2025-06-01T15:00:00Z&to=2025-06-01T16:00:00Z"); len(got) != 1 || got[0].ID != added.ID {
+		t.Errorf("list in range = %+v, want the sprinkler note", got)
+	}
+	if rec := do(http.MethodGet, "/api/annotations?from=yesterday", "", false); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad from: expected 400, got %d", rec.Code)
+	}
+
+	path := "/api/annotations/" + strconv.Itoa(added.ID)
+	if rec := do(http.MethodPut, path, `{"start": "2025-06-01T14:00:00Z", "text": "sprinkler"}`, false); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the admin password, got %d", rec.Code)
+	}
+	rec = do(http.MethodPut, path, `{"start": "2025-06-01T14:00:00Z", "text": "sprinkler"}`, true)
+	var updated annotations.Annotation
+	if err := json.NewDecoder(rec.Body).Decode(&updated); err != nil || updated.Text != "sprinkler" || !updated.End.Equal(updated.Start) || updated.Updated.IsZero() {
+		t.Errorf("update: got %d %+v (%v)", rec.Code, updated, err)
+	}
+	if rec := do(http.MethodPut, "/api/annotations/99", `{"start": "2025-06-01T14:00:00Z", "text": "x"}`, true); rec.Code != http.StatusNotFound {
+		t.Errorf("update unknown: expected 404, got %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/api/annotations/abc", "", true); rec.Code != http.StatusBadRequest {
+		t.Errorf("delete bad id: expected 400, got %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, path, "", true); rec.Code != http.StatusNoContent {
+		t.Errorf("delete: got %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, path, "", true); rec.Code != http.StatusNotFound {
+		t.Errorf("delete again: expected 404, got %d", rec.Code)
+	}
+	if got := list(""); len(got) != 1 {
+		t.Errorf("list after delete = %+v", got)
+	}
+}
@@ -0,0 +1,34 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"tempest-homekit-go/pkg/stats"
+)
+
+// SetAnomalyDetector enables /api/anomalies
+func (ws *WebServer) SetAnomalyDetector(detector *stats.AnomalyDetector) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.anomalyDetector = detector
+}
+
+// handleAnomaliesAPI returns the latest anomaly scores and the flagged
+// readings the dashboard highlights on its charts
+func (ws *WebServer) handleAnomaliesAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ws.mu.RLock()
+	detector := ws.anomalyDetector
+	ws.mu.RUnlock()
+	if detector == nil {
+		http.Error(w, "Anomaly detection is not running", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(detector.Report())
+}
@@ -0,0 +1,43 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/stats"
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestAnomaliesAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/anomalies", nil))
+		return rec
+	}
+	if rec := get(); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status without a detector = %d, want 503", rec.Code)
+	}
+
+	detector := stats.NewAnomalyDetector(4)
+	start := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	for m := 0; m <= 40; m++ {
+		temp := 20.0
+		if m == 40 {
+			temp = 35
+		}
+		detector.Add(&weather.Observation{Timestamp: start.Add(time.Duration(m) * time.Minute).Unix(), AirTemperature: temp})
+	}
+	ws.SetAnomalyDetector(detector)
+
+	var resp stats.AnomalyReport
+	if err := json.NewDecoder(get().Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode /api/anomalies response: %v", err)
+	}
+	if resp.Threshold != 4 || len(resp.Recent) != 1 || resp.Recent[0].Field != "temperature" || resp.Scores["temperature"] < 4 {
+		t.Errorf("report = %+v", resp)
+	}
+}
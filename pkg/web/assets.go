@@ -0,0 +1,32 @@
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+)
+
+// embeddedStatic is pkg/web/static built into the binary, so the dashboard
+// works wherever the binary is installed. Tests, notes and Node fixtures are
+// left out.
+//
+//go:embed static/*.html static/*.css static/*.js static/*.map
+var embeddedStatic embed.FS
+
+// staticFiles serves /pkg/web/static/ and the chart popout page: the embedded
+// copy unless SetStaticDir pointed it at a directory
+var staticFiles fs.FS = mustSub(embeddedStatic, "static")
+
+// SetStaticDir serves the static assets from dir instead of the embedded
+// copy, so edits show up without a rebuild. Call it before starting servers.
+func SetStaticDir(dir string) {
+	staticFiles = os.DirFS(dir)
+}
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
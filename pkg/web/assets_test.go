@@ -0,0 +1,86 @@
+package web
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStaticAssetsEmbedded(t *testing.T) {
+	// The test runs in pkg/web, where ./pkg/web/static does not exist
+	ws := testNewWebServer(t)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	for _, path := range []string{"/pkg/web/static/script.js", "/static/styles.css", "/pkg/web/static/chart.umd.js", "/chart/rain"} {
+		if rec := get(path); rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Errorf("%s: status %d, %d bytes", path, rec.Code, rec.Body.Len())
+		}
+	}
+	for _, path := range []string{"/pkg/web/static/README.md", "/pkg/web/static/__tests__/alarm-utils.test.js", "/static/../server.go"} {
+		if rec := get(path); rec.Code == http.StatusOK {
+			t.Errorf("%s served", path)
+		}
+	}
+}
+
+func TestSetStaticDir(t *testing.T) {
+	saved := staticFiles
+	defer func() { staticFiles = saved }()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "styles.css"), []byte("body { color: red }"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	SetStaticDir(dir)
+	ws := testNewWebServer(t)
+	rec := httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/styles.css", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "color: red") {
+		t.Errorf("styles.css from the static dir: status %d, body %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestVendoredAssetsMatchChecksums(t *testing.T) {
+	f, err := os.Open(filepath.Join("static", "vendor.sha256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	checked := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		data, err := fs.ReadFile(embeddedStatic, "static/"+fields[1])
+		if err != nil {
+			t.Errorf("%s is not embedded: %v", fields[1], err)
+			continue
+		}
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != fields[0] {
+			t.Errorf("%s does not match vendor.sha256; run scripts/vendor-static.sh", fields[1])
+		}
+		checked++
+	}
+	if checked == 0 {
+		t.Fatal("vendor.sha256 lists no files")
+	}
+
+	// The dashboard loads them from the server, not a CDN
+	var page strings.Builder
+	if err := testNewWebServer(t).renderDashboard(&page); err != nil || strings.Contains(page.String(), "unpkg.com") || !strings.Contains(page.String(), "/pkg/web/static/chart.umd.js") {
+		t.Error("dashboard does not load the vendored Chart.js")
+	}
+}
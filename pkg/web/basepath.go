@@ -0,0 +1,56 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// basePathPattern is what SetBasePath accepts: one or more path segments
+// without a trailing slash, e.g. /station/alice
+var basePathPattern = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// ValidateBasePath reports whether path can be served as a URL prefix
+func ValidateBasePath(path string) error {
+	if path == "" || basePathPattern.MatchString(path) {
+		return nil
+	}
+	return fmt.Errorf("base path %q must look like /station/name (letters, digits, . _ ~ -, no trailing slash)", path)
+}
+
+// SetBasePath sets the URL prefix a reverse proxy serves the console under,
+// e.g. /station/alice. Routes stay unprefixed because the proxy strips the
+// prefix; the pages prefix their asset, API and editor URLs with it. Must be
+// called before MountAlarmEditor.
+func (ws *WebServer) SetBasePath(path string) error {
+	if err := ValidateBasePath(path); err != nil {
+		return err
+	}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.basePath = path
+	return nil
+}
+
+// BasePath returns the URL prefix set by SetBasePath
+func (ws *WebServer) BasePath() string {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	return ws.basePath
+}
+
+// withPathPrefix restores prefix on request paths before handing them to a
+// handler that routes on public URLs, such as the alarm editor
+func withPathPrefix(prefix string, next http.Handler) http.Handler {
+	if prefix == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = prefix + r.URL.Path
+		if r.URL.RawPath != "" {
+			r2.URL.RawPath = prefix + r.URL.RawPath
+		}
+		next.ServeHTTP(w, r2)
+	})
+}
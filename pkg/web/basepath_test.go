@@ -0,0 +1,66 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateBasePath(t *testing.T) {
+	for _, ok := range []string{"", "/station/mom", "/a", "/x.y/z_1~"} {
+		if err := ValidateBasePath(ok); err != nil {
+			t.Errorf("ValidateBasePath(%q) = %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"/", "station/mom", "/station/mom/", "/a//b", `/a"b`, "/a b"} {
+		if err := ValidateBasePath(bad); err == nil {
+			t.Errorf("ValidateBasePath(%q) accepted", bad)
+		}
+	}
+}
+
+func TestBasePathPages(t *testing.T) {
+	ws := testNewWebServer(t)
+	if err := ws.SetBasePath("/station/mom"); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string][]string{
+		"/":                  {`<meta name="base-path" content="/station/mom">`, `href="/station/mom/pkg/web/static/styles.css"`, `src="/station/mom/pkg/web/static/script.js?v=`},
+		"/chart/temperature": {`<meta name="base-path" content="/station/mom">`, `href="/station/mom/pkg/web/static/chart.css"`, `src="/station/mom/pkg/web/static/script.js"`},
+	} {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		page := rec.Body.String()
+		for _, fragment := range want {
+			if !strings.Contains(page, fragment) {
+				t.Errorf("%s: missing %s", path, fragment)
+			}
+		}
+		if strings.Contains(page, `="/pkg/web/static/`) {
+			t.Errorf("%s: asset URL without the base path", path)
+		}
+	}
+}
+
+func TestBasePathAlarmEditor(t *testing.T) {
+	ws := testNewWebServer(t)
+	if err := ws.SetBasePath("/station/mom"); err != nil {
+		t.Fatal(err)
+	}
+	var seen string
+	ws.MountAlarmEditor(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.URL.Path
+	}), "s3cret")
+
+	req := httptest.NewRequest(http.MethodGet, "/alarm-editor/api/config", nil)
+	req.SetBasicAuth("admin", "s3cret")
+	ws.server.Handler.ServeHTTP(httptest.NewRecorder(), req)
+	if seen != "/station/mom/alarm-editor/api/config" {
+		t.Errorf("editor saw %q, want the public path", seen)
+	}
+	if got := ws.alarmEditorURL; got != "/station/mom/alarm-editor/" {
+		t.Errorf("editor URL = %q", got)
+	}
+}
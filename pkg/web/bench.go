@@ -0,0 +1,285 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// BenchOptions configures a web server load test
+type BenchOptions struct {
+	HistoryPoints int            // synthetic observations loaded into the history
+	Requests      int            // total requests replayed
+	Concurrency   int            // parallel clients
+	Mix           map[string]int // request path -> relative weight
+}
+
+// BenchEndpoint is the outcome for one path of the request mix
+type BenchEndpoint struct {
+	Path     string
+	Requests int
+	Errors   int
+	Bytes    int64 // response body size of the last successful request
+	P50      time.Duration
+	P95      time.Duration
+	P99      time.Duration
+	Max      time.Duration
+	// Measured separately by calling the handler in-process, so client and
+	// transport allocations are not counted
+	AllocsPerOp int64
+	BytesPerOp  int64
+}
+
+// BenchReport summarizes a web server load test
+type BenchReport struct {
+	HistoryPoints int
+	Requests      int
+	Concurrency   int
+	Elapsed       time.Duration
+	Endpoints     []BenchEndpoint
+	// Whole process during the load phase, including the HTTP clients
+	TotalAlloc uint64
+	Mallocs    uint64
+	NumGC      uint32
+	HeapInuse  uint64 // after the load phase
+}
+
+// RequestsPerSecond is the throughput of the load phase
+func (r *BenchReport) RequestsPerSecond() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Elapsed.Seconds()
+}
+
+// benchAllocRuns is how many in-process calls are averaged per endpoint
+const benchAllocRuns = 20
+
+// Benchmark starts a web server on a loopback port with a history of
+// synthetic observations and replays the request mix against it
+func Benchmark(opts BenchOptions) (*BenchReport, error) {
+	if opts.Requests <= 0 || opts.Concurrency <= 0 || len(opts.Mix) == 0 {
+		return nil, fmt.Errorf("benchmark needs requests, concurrency and a request mix")
+	}
+
+	ws := NewWebServer("0", 0, "error", 0, false, "bench", "", &GeneratedWeatherInfo{}, nil, "metric", "mb", opts.HistoryPoints, 0, "", true)
+	ws.SetStationName("Benchmark Station")
+	for _, obs := range syntheticHistory(ws.maxHistorySize, time.Now()) {
+		ws.UpdateWeather(obs)
+	}
+	points := ws.history.Len()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{Handler: ws.mux}
+	go func() { _ = srv.Serve(ln) }()
+	defer func() { _ = srv.Close() }()
+	base := "http://" + ln.Addr().String()
+
+	paths := make([]string, 0, len(opts.Mix))
+	for path := range opts.Mix {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	schedule := benchSchedule(paths, opts.Mix, opts.Requests)
+
+	client := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{MaxIdleConnsPerHost: opts.Concurrency},
+	}
+	// Warm up connections and lazily built state before measuring
+	for _, path := range paths {
+		if _, _, err := benchGet(client, base+path); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	type sample struct {
+		path    string
+		latency time.Duration
+		bytes   int64
+		err     error
+	}
+	samples := make([]sample, len(schedule))
+	next := make(chan int)
+	var wg sync.WaitGroup
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range next {
+				path := schedule[idx]
+				t0 := time.Now()
+				n, status, err := benchGet(client, base+path)
+				if err == nil && status != http.StatusOK {
+					err = fmt.Errorf("HTTP %d", status)
+				}
+				samples[idx] = sample{path: path, latency: time.Since(t0), bytes: n, err: err}
+			}
+		}()
+	}
+	for i := range schedule {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	report := &BenchReport{
+		HistoryPoints: points,
+		Requests:      len(schedule),
+		Concurrency:   opts.Concurrency,
+		Elapsed:       elapsed,
+		TotalAlloc:    after.TotalAlloc - before.TotalAlloc,
+		Mallocs:       after.Mallocs - before.Mallocs,
+		NumGC:         after.NumGC - before.NumGC,
+		HeapInuse:     after.HeapInuse,
+	}
+	for _, path := range paths {
+		ep := BenchEndpoint{Path: path}
+		var latencies []time.Duration
+		for _, s := range samples {
+			if s.path != path {
+				continue
+			}
+			ep.Requests++
+			if s.err != nil {
+				ep.Errors++
+				continue
+			}
+			ep.Bytes = s.bytes
+			latencies = append(latencies, s.latency)
+		}
+		if len(latencies) > 0 {
+			sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+			ep.P50 = percentile(latencies, 0.50)
+			ep.P95 = percentile(latencies, 0.95)
+			ep.P99 = percentile(latencies, 0.99)
+			ep.Max = latencies[len(latencies)-1]
+		}
+		ep.AllocsPerOp, ep.BytesPerOp = handlerAllocs(ws.mux, path)
+		report.Endpoints = append(report.Endpoints, ep)
+	}
+	return report, nil
+}
+
+// benchSchedule spreads requests over the paths in proportion to their
+// weights, interleaved so each client sees the whole mix
+func benchSchedule(paths []string, mix map[string]int, requests int) []string {
+	total := 0
+	for _, path := range paths {
+		total += mix[path]
+	}
+	schedule := make([]string, 0, requests)
+	credit := make([]int, len(paths))
+	for len(schedule) < requests {
+		// Smooth weighted round robin
+		best := 0
+		for i, path := range paths {
+			credit[i] += mix[path]
+			if credit[i] > credit[best] {
+				best = i
+			}
+		}
+		credit[best] -= total
+		schedule = append(schedule, paths[best])
+	}
+	return schedule
+}
+
+// benchGet fetches url and returns the body size and status
+func benchGet(client *http.Client, url string) (int64, int, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	n, err := io.Copy(io.Discard, resp.Body)
+	return n, resp.StatusCode, err
+}
+
+// percentile picks the nearest-rank value from sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+// handlerAllocs averages the allocations of serving path in-process
+func handlerAllocs(h http.Handler, path string) (allocs, bytes int64) {
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return 0, 0
+	}
+	w := &discardResponse{header: make(http.Header)}
+	h.ServeHTTP(w, req)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := 0; i < benchAllocRuns; i++ {
+		h.ServeHTTP(w, req)
+	}
+	runtime.ReadMemStats(&after)
+	return int64(after.Mallocs-before.Mallocs) / benchAllocRuns, int64(after.TotalAlloc-before.TotalAlloc) / benchAllocRuns
+}
+
+// discardResponse is a ResponseWriter that drops the body
+type discardResponse struct {
+	header http.Header
+}
+
+func (d *discardResponse) Header() http.Header         { return d.header }
+func (d *discardResponse) Write(b []byte) (int, error) { return len(b), nil }
+func (d *discardResponse) WriteHeader(int)             {}
+
+// syntheticHistory returns n one-minute observations ending at end, with
+// diurnal temperature, light and wind cycles
+func syntheticHistory(n int, end time.Time) []*weather.Observation {
+	observations := make([]*weather.Observation, n)
+	start := end.Add(-time.Duration(n-1) * time.Minute)
+	rain := 0.0
+	for i := range observations {
+		t := start.Add(time.Duration(i) * time.Minute)
+		day := 2 * math.Pi * float64(t.Hour()*60+t.Minute()) / 1440
+		sun := math.Max(0, -math.Cos(day))
+		if i%900 < 30 {
+			rain += 0.05
+		}
+		observations[i] = &weather.Observation{
+			Timestamp:        t.Unix(),
+			AirTemperature:   15 - 6*math.Cos(day),
+			RelativeHumidity: 60 + 20*math.Cos(day),
+			WindLull:         1 + sun,
+			WindAvg:          2 + 3*sun,
+			WindGust:         4 + 5*sun,
+			WindDirection:    math.Mod(float64(i), 360),
+			StationPressure:  1010 + 3*math.Sin(float64(i)/720),
+			Illuminance:      100000 * sun,
+			UV:               int(8 * sun),
+			SolarRadiation:   900 * sun,
+			RainAccumulated:  rain,
+			Battery:          2.6,
+			ReportInterval:   1,
+		}
+	}
+	return observations
+}
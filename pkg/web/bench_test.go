@@ -0,0 +1,45 @@
+package web
+
+import (
+	"testing"
+)
+
+func TestBenchmark(t *testing.T) {
+	report, err := Benchmark(BenchOptions{
+		HistoryPoints: 500,
+		Requests:      40,
+		Concurrency:   4,
+		Mix:           map[string]int{"/api/status": 3, "/api/history": 1},
+	})
+	if err != nil {
+		t.Fatalf("Benchmark: %v", err)
+	}
+	if report.HistoryPoints != 500 || report.Requests != 40 {
+		t.Errorf("report covers %d points and %d requests, want 500 and 40", report.HistoryPoints, report.Requests)
+	}
+	if len(report.Endpoints) != 2 {
+		t.Fatalf("got %d endpoints, want 2", len(report.Endpoints))
+	}
+	for _, ep := range report.Endpoints {
+		want := 30
+		if ep.Path == "/api/history" {
+			want = 10
+		}
+		if ep.Requests != want || ep.Errors != 0 {
+			t.Errorf("%s: %d requests (%d errors), want %d", ep.Path, ep.Requests, ep.Errors, want)
+		}
+		if ep.P50 <= 0 || ep.P95 < ep.P50 || ep.Max < ep.P95 || ep.Bytes == 0 || ep.AllocsPerOp == 0 {
+			t.Errorf("%s: implausible stats %+v", ep.Path, ep)
+		}
+	}
+}
+
+func TestBenchSchedule(t *testing.T) {
+	got := benchSchedule([]string{"a", "b"}, map[string]int{"a": 2, "b": 1}, 6)
+	want := []string{"a", "b", "a", "a", "b", "a"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("schedule = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/http"
+)
+
+// chartPageName is the popout page template in the static assets that the
+// chart config is embedded in
+const chartPageName = "chart.html"
+
+// ChartDataset is one line of a chart popout. Style fields use Chart.js
+// dataset option names so the page can pass them through unchanged.
+type ChartDataset struct {
+	Role            string  `json:"role"`             // data, average, trend, accumulation, total or overlay
+	Sensor          string  `json:"sensor,omitempty"` // sensor an overlay line plots
+	Label           string  `json:"label"`
+	BorderColor     string  `json:"borderColor"`
+	BackgroundColor string  `json:"backgroundColor"`
+	BorderDash      []int   `json:"borderDash,omitempty"`
+	BorderWidth     float64 `json:"borderWidth"`
+	Fill            bool    `json:"fill"`
+	Tension         float64 `json:"tension"`
+	YAxisID         string  `json:"yAxisID,omitempty"` // y1 is the right-hand axis
+}
+
+// ChartAxis is a Y axis of a chart popout and the unit of the values bound
+// to it through the datasets' yAxisID
+type ChartAxis struct {
+	ID       string            `json:"id"`       // Chart.js scale ID: y or y1
+	Position string            `json:"position"` // left or right
+	Sensor   string            `json:"sensor"`   // sensor whose values the axis shows
+	Title    string            `json:"title"`
+	UnitKey  string            `json:"unitKey,omitempty"` // display unit setting the unit follows
+	Units    map[string]string `json:"units"`             // unit label by display unit setting; "" when fixed
+}
+
+// ChartOverlay is a sensor a popout can plot on its right-hand axis
+type ChartOverlay struct {
+	Sensor string `json:"sensor"`
+	Title  string `json:"title"`
+}
+
+// ChartConfig describes the popout chart of one sensor. Datasets are in
+// drawing order; the page fills them by role.
+type ChartConfig struct {
+	Sensor   string            `json:"sensor"`
+	Title    string            `json:"title"`
+	Field    string            `json:"field"`             // dataHistory field the data line plots
+	Color    string            `json:"color"`             // main line color
+	UnitKey  string            `json:"unitKey,omitempty"` // display unit setting the unit follows
+	Units    map[string]string `json:"units"`             // unit label by display unit setting; "" when fixed
+	Datasets []ChartDataset    `json:"datasets"`
+	Axes     []ChartAxis       `json:"axes"`               // the left axis first
+	Overlays []ChartOverlay    `json:"overlays,omitempty"` // sensors ?overlay= accepts
+	Overlay  string            `json:"overlay,omitempty"`  // the overlaid sensor, if any
+}
+
+// chartAverage is the dashed daily-average line most charts overlay
+var chartAverage = ChartDataset{Role: "average", Label: "Average", BorderColor: "#00cc66", BackgroundColor: "rgba(0, 204, 102, 0.2)", BorderDash: []int{5, 5}, BorderWidth: 2}
+
+// chartLine returns the main data line of a chart
+func chartLine(label, color string) ChartDataset {
+	return ChartDataset{Role: "data", Label: label, BorderColor: color, BackgroundColor: color + "1A", BorderWidth: 3, Tension: 0.4}
+}
+
+// chartConfigs is the popout registry, keyed by the sensor in /chart/{sensor}
+var chartConfigs = map[string]ChartConfig{
+	"temperature": {
+		Title: "Temperature", Field: "temperature", Color: "#ff6384",
+		UnitKey: "temperature", Units: map[string]string{"celsius": "°C", "fahrenheit": "°F"},
+		Datasets: []ChartDataset{chartLine("Temperature", "#ff6384"), chartAverage},
+	},
+	"humidity": {
+		Title: "Humidity", Field: "humidity", Color: "#36a2eb",
+		Units:    map[string]string{"": "%"},
+		Datasets: []ChartDataset{chartLine("Humidity", "#36a2eb"), chartAverage},
+	},
+	"wind": {
+		Title: "Wind Speed", Field: "windSpeed", Color: "#ffce56",
+		UnitKey: "wind", Units: map[string]string{"mph": "mph", "kph": "kph"},
+		Datasets: []ChartDataset{chartLine("Wind Speed", "#ffce56"), chartAverage},
+	},
+	"rain": {
+		Title: "Rain", Field: "rainAccum", Color: "#3b82f6",
+		UnitKey: "rain", Units: map[string]string{"inches": "in", "mm": "mm"},
+		Datasets: []ChartDataset{
+			{Role: "data", Label: "Rain Intensity", BorderColor: "#3b82f6", BackgroundColor: "rgba(59, 130, 246, 0.2)", BorderWidth: 2, Fill: true, Tension: 0.4, YAxisID: "y"},
+			chartAverage,
+			{Role: "accumulation", Label: "Accumulation", BorderColor: "#8b5cf6", BackgroundColor: "transparent", BorderWidth: 2, Tension: 0.4, YAxisID: "y1"},
+			{Role: "total", Label: "Today Total", BorderColor: "#ff6b35", BackgroundColor: "rgba(255, 107, 53, 0.1)", BorderDash: []int{3, 3}, BorderWidth: 3, YAxisID: "y1"},
+		},
+		// Intensity and accumulation already use both axes
+		Axes: []ChartAxis{
+			{ID: "y", Position: "left", Sensor: "rain", Title: "Rain Intensity", UnitKey: "rain", Units: map[string]string{"inches": "in/hr", "mm": "mm/hr"}},
+			{ID: "y1", Position: "right", Sensor: "rain", Title: "Accumulation", UnitKey: "rain", Units: map[string]string{"inches": "in", "mm": "mm"}},
+		},
+	},
+	"pressure": {
+		Title: "Pressure", Field: "pressure", Color: "#9966ff",
+		UnitKey: "pressure", Units: map[string]string{"mb": "mb", "inHg": "inHg"},
+		Datasets: []ChartDataset{
+			chartLine("Pressure", "#9966ff"),
+			chartAverage,
+			{Role: "trend", Label: "Trend", BorderColor: "#ff6384", BackgroundColor: "rgba(255, 99, 132, 0.1)", BorderDash: []int{2, 2}, BorderWidth: 1.5},
+		},
+	},
+	"light": {
+		Title: "Light", Field: "illuminance", Color: "#ff9f40",
+		Units:    map[string]string{"": "lux"},
+		Datasets: []ChartDataset{chartLine("Light", "#ff9f40")},
+	},
+	"uv": {
+		Title: "UV Index", Field: "uv", Color: "#ff6384",
+		Units:    map[string]string{"": "UVI"},
+		Datasets: []ChartDataset{chartLine("UV Index", "#ff6384")},
+	},
+}
+
+// overlaySensors are the sensors a popout can overlay, in selector order
+var overlaySensors = []string{"temperature", "humidity", "wind", "pressure", "light", "uv"}
+
+// chartConfig returns the registry entry for sensor. Sensors plotted on one
+// axis get their axis and the sensors they can overlay on a second one.
+func chartConfig(sensor string) (ChartConfig, bool) {
+	cfg, ok := chartConfigs[sensor]
+	cfg.Sensor = sensor
+	if !ok || len(cfg.Axes) > 0 {
+		return cfg, ok
+	}
+	cfg.Axes = []ChartAxis{{ID: "y", Position: "left", Sensor: sensor, Title: cfg.Title, UnitKey: cfg.UnitKey, Units: cfg.Units}}
+	for _, other := range overlaySensors {
+		if other != sensor {
+			cfg.Overlays = append(cfg.Overlays, ChartOverlay{Sensor: other, Title: chartConfigs[other].Title})
+		}
+	}
+	return cfg, true
+}
+
+// withOverlay adds overlay's line to cfg on a right-hand axis with its own
+// unit
+func withOverlay(cfg ChartConfig, overlay string) (ChartConfig, error) {
+	allowed := false
+	for _, o := range cfg.Overlays {
+		allowed = allowed || o.Sensor == overlay
+	}
+	if !allowed {
+		return cfg, fmt.Errorf("'%s' cannot be overlaid on the %s chart", overlay, cfg.Sensor)
+	}
+	o := chartConfigs[overlay]
+	color := o.Color
+	if color == cfg.Color {
+		color = "#4bc0c0" // temperature and UV share a color
+	}
+	cfg.Datasets = append(append([]ChartDataset{}, cfg.Datasets...), ChartDataset{
+		Role: "overlay", Sensor: overlay, Label: o.Title, BorderColor: color, BackgroundColor: "transparent", BorderWidth: 2, Tension: 0.4, YAxisID: "y1",
+	})
+	cfg.Axes = append(append([]ChartAxis{}, cfg.Axes...), ChartAxis{ID: "y1", Position: "right", Sensor: overlay, Title: o.Title, UnitKey: o.UnitKey, Units: o.Units})
+	cfg.Overlay = overlay
+	return cfg, nil
+}
+
+// requestChartConfig returns the chart config of sensor with the request's
+// ?overlay= applied
+func requestChartConfig(r *http.Request, sensor string) (ChartConfig, int, error) {
+	cfg, ok := chartConfig(sensor)
+	if !ok {
+		return cfg, http.StatusNotFound, fmt.Errorf("unknown sensor '%s'", sensor)
+	}
+	if overlay := r.URL.Query().Get("overlay"); overlay != "" {
+		var err error
+		if cfg, err = withOverlay(cfg, overlay); err != nil {
+			return cfg, http.StatusBadRequest, err
+		}
+	}
+	return cfg, http.StatusOK, nil
+}
+
+// handleChartConfigAPI returns the popout chart configuration of a sensor
+func (ws *WebServer) handleChartConfigAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cfg, status, err := requestChartConfig(r, r.PathValue("sensor"))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(cfg)
+}
+
+// renderChartPage returns the chart page name in fsys with the sensor's chart
+// config embedded as a JSON script block the popout reads on load. Asset URLs
+// are prefixed with basePath, which script.js reads from the base-path meta
+// element for its API calls.
+func renderChartPage(fsys fs.FS, name string, cfg ChartConfig, basePath string) ([]byte, error) {
+	page, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	escaped := html.EscapeString(basePath)
+	page = bytes.ReplaceAll(page, []byte(`="/pkg/web/static/`), []byte(`="`+escaped+`/pkg/web/static/`))
+	var block bytes.Buffer
+	block.WriteString(`<meta name="base-path" content="` + escaped + `">` + "\n    ")
+	block.WriteString(`<script id="chart-config" type="application/json">`)
+	// json.Marshal escapes <, > and &, so no value can close the element
+	block.Write(data)
+	block.WriteString("</script>\n  </head>")
+	return bytes.Replace(page, []byte("</head>"), block.Bytes(), 1), nil
+}
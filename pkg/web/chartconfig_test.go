@@ -0,0 +1,139 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// testChartPageHandler serves chart popouts from the chart.html at path, for
+// test servers that do not run from the repository root
+func testChartPageHandler(t *testing.T, path string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg, ok := chartConfig(strings.TrimPrefix(r.URL.Path, "/chart/"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		page, err := renderChartPage(os.DirFS(filepath.Dir(path)), filepath.Base(path), cfg, "")
+		if err != nil {
+			t.Errorf("failed to render %s: %v", path, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(page)
+	}
+}
+
+func TestChartConfigAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	rec := httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/chart-config/rain", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	var cfg ChartConfig
+	if err := json.NewDecoder(rec.Body).Decode(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	var roles []string
+	for _, ds := range cfg.Datasets {
+		roles = append(roles, ds.Role)
+	}
+	if cfg.Sensor != "rain" || cfg.UnitKey != "rain" || cfg.Units["mm"] != "mm" || strings.Join(roles, ",") != "data,average,accumulation,total" {
+		t.Errorf("rain config = %+v", cfg)
+	}
+
+	rec = httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/chart-config/dewpoint", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown sensor: status %d, want 404", rec.Code)
+	}
+}
+
+func TestChartConfigRegistry(t *testing.T) {
+	for sensor, cfg := range chartConfigs {
+		if len(cfg.Datasets) == 0 || cfg.Datasets[0].Role != "data" {
+			t.Errorf("%s: first dataset must be the data line", sensor)
+		}
+		if cfg.UnitKey == "" && cfg.Units[""] == "" {
+			t.Errorf("%s: neither a unit setting nor a fixed unit", sensor)
+		}
+	}
+}
+
+func TestRenderChartPage(t *testing.T) {
+	cfg, _ := chartConfig("pressure")
+	cfg.Title = "</script><b>"
+	page, err := renderChartPage(staticFiles, chartPageName, cfg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := regexp.MustCompile(`<script id="chart-config" type="application/json">(.*?)</script>\s*</head>`).FindSubmatch(page)
+	if m == nil {
+		t.Fatalf("chart config not embedded before </head>")
+	}
+	var got ChartConfig
+	if err := json.Unmarshal(m[1], &got); err != nil {
+		t.Fatalf("embedded config: %v", err)
+	}
+	if got.Sensor != "pressure" || got.Title != cfg.Title || len(got.Datasets) != 3 {
+		t.Errorf("embedded config = %+v", got)
+	}
+}
+
+func TestChartConfigOverlay(t *testing.T) {
+	ws := testNewWebServer(t)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/api/chart-config/temperature?overlay=humidity")
+	var cfg ChartConfig
+	if err := json.NewDecoder(rec.Body).Decode(&cfg); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("status %d (%v)", rec.Code, err)
+	}
+	overlay := cfg.Datasets[len(cfg.Datasets)-1]
+	if cfg.Overlay != "humidity" || overlay.Role != "overlay" || overlay.Sensor != "humidity" || overlay.YAxisID != "y1" {
+		t.Errorf("overlay dataset = %+v", overlay)
+	}
+	if len(cfg.Axes) != 2 || cfg.Axes[0].Sensor != "temperature" || cfg.Axes[0].UnitKey != "temperature" ||
+		cfg.Axes[1].ID != "y1" || cfg.Axes[1].Position != "right" || cfg.Axes[1].Units[""] != "%" {
+		t.Errorf("axes = %+v", cfg.Axes)
+	}
+	// The registry entry is not changed
+	if plain, _ := chartConfig("temperature"); len(plain.Datasets) != 2 || len(plain.Axes) != 1 || len(plain.Overlays) != 5 {
+		t.Errorf("registry config = %+v", plain)
+	}
+
+	// UV shares temperature's color, so its overlay line gets another
+	rec = get("/api/chart-config/temperature?overlay=uv")
+	if err := json.NewDecoder(rec.Body).Decode(&cfg); err != nil || cfg.Datasets[len(cfg.Datasets)-1].BorderColor == cfg.Color {
+		t.Errorf("uv overlay = %+v (%v)", cfg.Datasets, err)
+	}
+
+	for _, path := range []string{"/api/chart-config/temperature?overlay=temperature", "/api/chart-config/pressure?overlay=rain", "/api/chart-config/rain?overlay=wind"} {
+		if rec := get(path); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", path, rec.Code)
+		}
+	}
+	if rain, _ := chartConfig("rain"); len(rain.Axes) != 2 || len(rain.Overlays) != 0 {
+		t.Errorf("rain axes %+v, overlays %+v", rain.Axes, rain.Overlays)
+	}
+
+	// The popout page embeds the combined config
+	rec = get("/chart/pressure?overlay=wind")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"overlay":"wind"`) {
+		t.Errorf("chart page: status %d", rec.Code)
+	}
+	if rec := get("/chart/pressure?overlay=dewpoint"); rec.Code != http.StatusBadRequest {
+		t.Errorf("chart page with an unknown overlay: status %d, want 400", rec.Code)
+	}
+}
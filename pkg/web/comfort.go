@@ -0,0 +1,79 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// IndoorRequest is the body of POST /api/indoor
+type IndoorRequest struct {
+	Temperature *float64 `json:"temperature"`
+	Humidity    *float64 `json:"humidity"`
+	Unit        string   `json:"unit,omitempty"` // "C" (default) or "F"
+}
+
+// SetComfortAdvisor enables /api/indoor and /api/comfort
+func (ws *WebServer) SetComfortAdvisor(advisor *weather.ComfortAdvisor) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.comfortAdvisor = advisor
+}
+
+func (ws *WebServer) comfort(w http.ResponseWriter) *weather.ComfortAdvisor {
+	ws.mu.RLock()
+	advisor := ws.comfortAdvisor
+	ws.mu.RUnlock()
+	if advisor == nil {
+		http.Error(w, "Comfort advisor is not running", http.StatusServiceUnavailable)
+	}
+	return advisor
+}
+
+// handleIndoorAPI records an indoor temperature and humidity from an
+// external sensor and returns the resulting advice
+func (ws *WebServer) handleIndoorAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req IndoorRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req)
+	unit := strings.ToUpper(strings.TrimSpace(req.Unit))
+	if err != nil || req.Temperature == nil || req.Humidity == nil || *req.Humidity < 0 || *req.Humidity > 100 ||
+		(unit != "" && unit != "C" && unit != "F") {
+		http.Error(w, "Request body must be {\"temperature\": <number>, \"humidity\": <0-100>, \"unit\": \"C\" or \"F\"}", http.StatusBadRequest)
+		return
+	}
+	advisor := ws.comfort(w)
+	if advisor == nil {
+		return
+	}
+	temperature := *req.Temperature
+	if unit == "F" {
+		temperature = (temperature - 32) * 5 / 9
+	}
+	advice := advisor.SetIndoor(weather.IndoorReading{Temperature: temperature, Humidity: *req.Humidity, Time: time.Now()})
+	writeComfortAdvice(w, advice)
+}
+
+// handleComfortAPI returns the advice for the latest indoor and outdoor
+// readings
+func (ws *WebServer) handleComfortAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if advisor := ws.comfort(w); advisor != nil {
+		writeComfortAdvice(w, advisor.Advice())
+	}
+}
+
+func writeComfortAdvice(w http.ResponseWriter, advice weather.ComfortAdvice) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(advice)
+}
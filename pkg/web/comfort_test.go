@@ -0,0 +1,52 @@
+package web
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestComfortAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	if rec := do(http.MethodGet, "/api/comfort", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status without an advisor = %d, want 503", rec.Code)
+	}
+
+	advisor := weather.NewComfortAdvisor(weather.DefaultComfortTargets, nil)
+	advisor.SetOutdoor(weather.Observation{AirTemperature: 18, RelativeHumidity: 60})
+	ws.SetComfortAdvisor(advisor)
+
+	for _, bad := range []string{`{}`, `{"temperature": 25}`, `{"temperature": 25, "humidity": 120}`, `{"temperature": 25, "humidity": 50, "unit": "K"}`, `nope`} {
+		if rec := do(http.MethodPost, "/api/indoor", bad); rec.Code != http.StatusBadRequest {
+			t.Errorf("POST %s: status %d, want 400", bad, rec.Code)
+		}
+	}
+
+	rec := do(http.MethodPost, "/api/indoor", `{"temperature": 81, "humidity": 50, "unit": "F"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /api/indoor status %d: %s", rec.Code, rec.Body.String())
+	}
+	var advice weather.ComfortAdvice
+	if err := json.NewDecoder(rec.Body).Decode(&advice); err != nil {
+		t.Fatalf("failed to decode advice: %v", err)
+	}
+	if !advice.OpenWindows || advice.Indoor == nil || math.Abs(advice.Indoor.Temperature-27.2) > 0.1 {
+		t.Errorf("advice = %+v", advice)
+	}
+
+	rec = do(http.MethodGet, "/api/comfort", "")
+	advice = weather.ComfortAdvice{}
+	if err := json.NewDecoder(rec.Body).Decode(&advice); err != nil || !advice.OpenWindows {
+		t.Errorf("GET /api/comfort = %+v, %v", advice, err)
+	}
+}
@@ -0,0 +1,255 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// HistoryFetcher returns the active station's observations with timestamps
+// between start and end, oldest first
+type HistoryFetcher func(start, end time.Time) ([]*weather.Observation, error)
+
+// compareCacheSize bounds the fetched ranges kept for /api/history/compare.
+// Year-ago weeks come back from the WeatherFlow API in coarse buckets and
+// are small; recent ranges are one-minute data.
+const compareCacheSize = 8
+
+// comparePeriods maps the period parameter to its bucket width
+var comparePeriods = map[string]time.Duration{
+	"day":  15 * time.Minute,
+	"week": time.Hour,
+}
+
+// compareFields maps the field parameter to the observation value it reads.
+// Values are in the same units as /api/history.
+var compareFields = map[string]func(*weather.Observation) float64{
+	"temperature":    func(o *weather.Observation) float64 { return o.AirTemperature },
+	"humidity":       func(o *weather.Observation) float64 { return o.RelativeHumidity },
+	"windSpeed":      func(o *weather.Observation) float64 { return o.WindAvg },
+	"windGust":       func(o *weather.Observation) float64 { return o.WindGust },
+	"pressure":       func(o *weather.Observation) float64 { return o.StationPressure },
+	"illuminance":    func(o *weather.Observation) float64 { return o.Illuminance },
+	"uv":             func(o *weather.Observation) float64 { return float64(o.UV) },
+	"solarRadiation": func(o *weather.Observation) float64 { return o.SolarRadiation },
+	"rain":           func(o *weather.Observation) float64 { return o.RainAccumulated },
+}
+
+// CompareSeries is one period of a comparison, one value per bucket
+type CompareSeries struct {
+	Start  int64      `json:"start"`           // Unix time of the first bucket
+	End    int64      `json:"end"`             // Unix time the period ends
+	Source string     `json:"source"`          // history, api, or history+api
+	Values []*float64 `json:"values"`          // bucket averages; rain is cumulative since start; null without data
+	Error  string     `json:"error,omitempty"` // why older data could not be fetched
+}
+
+// CompareResponse aligns the current period with an earlier one: Values[i]
+// of both series covers Start + i*BucketSeconds of its own period
+type CompareResponse struct {
+	Field         string        `json:"field"`
+	Period        string        `json:"period"`  // day or week
+	Against       string        `json:"against"` // previous or year
+	BucketSeconds int64         `json:"bucketSeconds"`
+	Current       CompareSeries `json:"current"`
+	Previous      CompareSeries `json:"previous"`
+}
+
+// compareCache keeps fetched observation ranges; completed periods never
+// change, so a year-over-year overlay costs one API request per period
+type compareCache struct {
+	mu      sync.Mutex
+	entries map[string][]*weather.Observation
+	order   []string
+}
+
+func (c *compareCache) get(key string) ([]*weather.Observation, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	obs, ok := c.entries[key]
+	return obs, ok
+}
+
+func (c *compareCache) put(key string, obs []*weather.Observation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string][]*weather.Observation)
+	}
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = obs
+	for len(c.order) > compareCacheSize {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// SetHistoryFetcher lets /api/history/compare fetch periods older than the
+// in-memory history
+func (ws *WebServer) SetHistoryFetcher(fetch HistoryFetcher) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.historyFetcher = fetch
+}
+
+// comparePeriodStart returns the start of the day or week (Monday) holding t
+// in loc, the station time zone
+func comparePeriodStart(period string, t time.Time, loc *time.Location) time.Time {
+	start := startOfDay(t, loc)
+	if period == "week" {
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	}
+	return start
+}
+
+// handleHistoryCompareAPI returns the current day or week next to the
+// previous one or the same period a year earlier
+func (ws *WebServer) handleHistoryCompareAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	field := q.Get("field")
+	if field == "" {
+		field = "temperature"
+	}
+	value, ok := compareFields[field]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown field '%s'", field), http.StatusBadRequest)
+		return
+	}
+	period := q.Get("period")
+	if period == "" {
+		period = "week"
+	}
+	bucket, ok := comparePeriods[period]
+	if !ok {
+		http.Error(w, fmt.Sprintf("invalid period '%s'. Use day or week", period), http.StatusBadRequest)
+		return
+	}
+	against := q.Get("against")
+	if against == "" {
+		against = "previous"
+	}
+	if against != "previous" && against != "year" {
+		http.Error(w, fmt.Sprintf("invalid against '%s'. Use previous or year", against), http.StatusBadRequest)
+		return
+	}
+
+	start := comparePeriodStart(period, time.Now(), ws.stationLocation())
+	days := 1
+	if period == "week" {
+		days = 7
+	}
+	prevStart := start.AddDate(0, 0, -days)
+	if against == "year" {
+		// Whole weeks keep weekdays aligned; days compare the same date
+		prevStart = start.AddDate(-1, 0, 0)
+		if period == "week" {
+			prevStart = start.AddDate(0, 0, -364)
+		}
+	}
+	buckets := int(start.AddDate(0, 0, days).Sub(start) / bucket)
+
+	resp := CompareResponse{
+		Field:         field,
+		Period:        period,
+		Against:       against,
+		BucketSeconds: int64(bucket / time.Second),
+		Current:       ws.compareSeries(start, start.AddDate(0, 0, days), bucket, buckets, field, value),
+		Previous:      ws.compareSeries(prevStart, prevStart.AddDate(0, 0, days), bucket, buckets, field, value),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// compareSeries buckets the observations of [start, end), taking what the
+// in-memory history holds and fetching anything older
+func (ws *WebServer) compareSeries(start, end time.Time, bucket time.Duration, buckets int, field string, value func(*weather.Observation) float64) CompareSeries {
+	series := CompareSeries{Start: start.Unix(), End: end.Unix(), Values: make([]*float64, buckets)}
+
+	ws.mu.RLock()
+	var observations []*weather.Observation
+	oldest := int64(math.MaxInt64)
+	history := ws.history.Snapshot()
+	if len(history) > 0 {
+		oldest = history[0].Timestamp
+	}
+	for i := range history {
+		if ts := history[i].Timestamp; ts >= start.Unix() && ts < end.Unix() {
+			obs := history[i]
+			observations = append(observations, &obs)
+		}
+	}
+	fetch, stationID := ws.historyFetcher, ws.stationID
+	ws.mu.RUnlock()
+	if len(observations) > 0 {
+		series.Source = "history"
+	}
+
+	// History starts after the period does: fetch the part before it
+	if oldest > start.Unix() && fetch != nil {
+		fetchEnd, complete := end, true
+		if oldest < end.Unix() {
+			fetchEnd = time.Unix(oldest, 0)
+		} else if now := time.Now(); fetchEnd.After(now) {
+			// No history at all: the running period is still changing
+			fetchEnd, complete = now, false
+		}
+		key := fmt.Sprintf("%d:%d:%d", stationID, start.Unix(), fetchEnd.Unix())
+		fetched, ok := ws.compareCache.get(key)
+		if !ok {
+			var err error
+			fetched, err = fetch(start.Add(-time.Second), fetchEnd)
+			if err != nil {
+				ws.logInfo("Comparison history for %s unavailable: %v", start.Format("2006-01-02"), err)
+				series.Error = err.Error()
+			} else if complete {
+				ws.compareCache.put(key, fetched)
+			}
+		}
+		if len(fetched) > 0 {
+			observations = append(append([]*weather.Observation{}, fetched...), observations...)
+			if series.Source == "" {
+				series.Source = "api"
+			} else {
+				series.Source = "history+api"
+			}
+		}
+	}
+	sort.Slice(observations, func(i, j int) bool { return observations[i].Timestamp < observations[j].Timestamp })
+
+	sums := make([]float64, buckets)
+	counts := make([]int, buckets)
+	for _, obs := range observations {
+		i := int(time.Unix(obs.Timestamp, 0).Sub(start) / bucket)
+		if i < 0 || i >= buckets {
+			continue
+		}
+		sums[i] += value(obs)
+		counts[i]++
+	}
+	total := 0.0
+	for i := range sums {
+		if counts[i] == 0 {
+			continue
+		}
+		v := sums[i] / float64(counts[i])
+		if field == "rain" {
+			total += sums[i]
+			v = total
+		}
+		series.Values[i] = &v
+	}
+	return series
+}
@@ -0,0 +1,99 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func getCompare(t *testing.T, ws *WebServer, query string) (*httptest.ResponseRecorder, CompareResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/history/compare"+query, nil))
+	var resp CompareResponse
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode /api/history/compare response: %v", err)
+		}
+	}
+	return rec, resp
+}
+
+func TestHistoryCompareAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	if err := ws.SetTimezone("UTC"); err != nil {
+		t.Fatal(err)
+	}
+	today := startOfDay(time.Now(), ws.stationLocation())
+	ws.history.Replace([]weather.Observation{{Timestamp: today.Unix(), AirTemperature: 20, RainAccumulated: 0.5}})
+
+	// Without a fetcher only the in-memory history is available
+	_, resp := getCompare(t, ws, "?period=day")
+	if resp.BucketSeconds != 900 || len(resp.Current.Values) != 96 || len(resp.Previous.Values) != 96 {
+		t.Fatalf("response = %+v", resp)
+	}
+	if resp.Current.Source != "history" || resp.Current.Values[0] == nil || *resp.Current.Values[0] != 20 {
+		t.Errorf("current = %+v", resp.Current)
+	}
+	if resp.Previous.Source != "" || resp.Previous.Values[0] != nil {
+		t.Errorf("previous without a fetcher = %+v", resp.Previous)
+	}
+
+	calls := 0
+	ws.SetHistoryFetcher(func(start, end time.Time) ([]*weather.Observation, error) {
+		calls++
+		yesterday := today.AddDate(0, 0, -1)
+		if start.Unix() >= yesterday.Unix() || end.Unix() != today.Unix() {
+			t.Errorf("fetched %v - %v, want yesterday", start, end)
+		}
+		return []*weather.Observation{
+			{Timestamp: yesterday.Unix(), AirTemperature: 10, RainAccumulated: 1},
+			{Timestamp: yesterday.Add(5 * time.Minute).Unix(), AirTemperature: 12, RainAccumulated: 1},
+			{Timestamp: yesterday.Add(time.Hour).Unix(), AirTemperature: 15, RainAccumulated: 2},
+		}, nil
+	})
+	_, resp = getCompare(t, ws, "?period=day&field=temperature")
+	if resp.Previous.Source != "api" || *resp.Previous.Values[0] != 11 || *resp.Previous.Values[4] != 15 || resp.Previous.Values[1] != nil {
+		t.Errorf("previous = %+v", resp.Previous)
+	}
+	_, resp = getCompare(t, ws, "?period=day&field=rain")
+	if *resp.Previous.Values[0] != 2 || *resp.Previous.Values[4] != 4 {
+		t.Errorf("cumulative rain = %v, %v; want 2 and 4", *resp.Previous.Values[0], *resp.Previous.Values[4])
+	}
+	if calls != 1 {
+		t.Errorf("fetched %d times, want completed periods cached", calls)
+	}
+
+	for _, query := range []string{"?field=dewpoint", "?period=month", "?against=decade"} {
+		if rec, _ := getCompare(t, ws, query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestHistoryCompareYearAgoWeek(t *testing.T) {
+	ws := testNewWebServer(t)
+	if err := ws.SetTimezone("UTC"); err != nil {
+		t.Fatal(err)
+	}
+	ws.SetHistoryFetcher(func(start, end time.Time) ([]*weather.Observation, error) {
+		return nil, errors.New("API request failed with status 429")
+	})
+	_, resp := getCompare(t, ws, "?against=year")
+	week := comparePeriodStart("week", time.Now(), ws.stationLocation())
+	if week.Weekday() != time.Monday || resp.Current.Start != week.Unix() {
+		t.Errorf("current week starts %v", time.Unix(resp.Current.Start, 0).UTC())
+	}
+	prev := time.Unix(resp.Previous.Start, 0).UTC()
+	if prev.Weekday() != time.Monday || week.Sub(prev) != 364*24*time.Hour {
+		t.Errorf("year-ago week starts %v", prev)
+	}
+	if len(resp.Previous.Values) != 168 || resp.Previous.Error == "" {
+		t.Errorf("previous = %+v, want 168 empty buckets and the fetch error", resp.Previous)
+	}
+}
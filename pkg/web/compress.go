@@ -0,0 +1,281 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// minCompressSize is the smallest body worth compressing; below it gzip's
+// header and trailer eat most of the savings
+const minCompressSize = 1024
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	},
+}
+
+// compressWriter gzips a response as the handler writes it, for clients that
+// accept gzip, so streamed bodies stay streamed. The first minCompressSize
+// bytes are held back to decide: shorter bodies, statuses other than 200 and
+// types gzip does not shrink go out as written.
+type compressWriter struct {
+	http.ResponseWriter
+	r       *http.Request
+	status  int
+	pending []byte
+	started bool
+	gz      *gzip.Writer
+}
+
+func newCompressWriter(w http.ResponseWriter, r *http.Request) *compressWriter {
+	w.Header().Add("Vary", "Accept-Encoding")
+	return &compressWriter{ResponseWriter: w, r: r}
+}
+
+func (c *compressWriter) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	if !c.started {
+		c.pending = append(c.pending, p...)
+		if len(c.pending) >= minCompressSize {
+			if err := c.start(true); err != nil {
+				return 0, err
+			}
+		}
+		return len(p), nil
+	}
+	if c.gz != nil {
+		return c.gz.Write(p)
+	}
+	if c.r.Method == http.MethodHead {
+		return len(p), nil
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+// start sends the header, choosing gzip when the body may be large enough,
+// and then the bytes held back
+func (c *compressWriter) start(large bool) error {
+	c.started = true
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	h := c.Header()
+	switch {
+	case c.status != http.StatusOK:
+		h.Del("ETag") // the tag names the 200 representation
+	case large && h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type")) && acceptsGzip(c.r):
+		// Each encoding is its own representation with its own tag
+		if tag := h.Get("ETag"); strings.HasSuffix(tag, `"`) {
+			h.Set("ETag", strings.TrimSuffix(tag, `"`)+`-gzip"`)
+		}
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		c.gz = gzipWriters.Get().(*gzip.Writer)
+		if c.r.Method == http.MethodHead {
+			c.gz.Reset(io.Discard)
+		} else {
+			c.gz.Reset(c.ResponseWriter)
+		}
+	case !large:
+		h.Set("Content-Length", strconv.Itoa(len(c.pending)))
+	}
+	c.ResponseWriter.WriteHeader(c.status)
+
+	pending := c.pending
+	c.pending = nil
+	if len(pending) == 0 || c.status == http.StatusNotModified {
+		return nil
+	}
+	_, err := c.Write(pending)
+	return err
+}
+
+// Flush sends what was written so far, compressed or not
+func (c *compressWriter) Flush() {
+	if !c.started {
+		_ = c.start(true)
+	}
+	if c.gz != nil {
+		_ = c.gz.Flush()
+	}
+	_ = http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+// Close sends a body shorter than minCompressSize, or ends the gzip stream
+func (c *compressWriter) Close() error {
+	if !c.started {
+		return c.start(false)
+	}
+	if c.gz == nil {
+		return nil
+	}
+	err := c.gz.Close()
+	gzipWriters.Put(c.gz)
+	c.gz = nil
+	return err
+}
+
+func (c *compressWriter) Unwrap() http.ResponseWriter { return c.ResponseWriter }
+
+// stateETag is a strong ETag naming the state a response is built from
+func stateETag(parts ...any) string {
+	sum := fnv.New64a()
+	for _, part := range parts {
+		_, _ = fmt.Fprintf(sum, "%v\x00", part)
+	}
+	return `"` + hex.EncodeToString(sum.Sum(nil)) + `"`
+}
+
+// withETag serves GET and HEAD responses of next with the ETag tag returns
+// for the request and gzips them as they are written. tag must change
+// whenever the response would; it is computed from the state behind the
+// response rather than the body, so a matching If-None-Match is answered with
+// 304 Not Modified without running next. An empty tag serves next untagged.
+// Brotli is not offered: the standard library has no encoder.
+func withETag(tag func(r *http.Request) string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next(w, r)
+			return
+		}
+		if etag := tag(r); etag != "" {
+			w.Header().Set("ETag", etag)
+			if etagMatches(r.Header.Get("If-None-Match"), strings.Trim(etag, `"`)) {
+				w.Header().Add("Vary", "Accept-Encoding")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		cw := newCompressWriter(w, r)
+		next(cw, r)
+		_ = cw.Close()
+	}
+}
+
+// historyETag tags /api/status and /api/history responses by the history
+// they are built from: its revision, the newest observation, the latest
+// forecast and the query. Status fields that change between observations,
+// such as the uptime, are refreshed with the next observation.
+func (ws *WebServer) historyETag(r *http.Request) string {
+	ws.mu.RLock()
+	revision, forecast := ws.historyRevision, ws.forecastReceived.UnixNano()
+	ws.mu.RUnlock()
+	var newest int64
+	if history := ws.history.Snapshot(); len(history) > 0 {
+		newest = history[len(history)-1].Timestamp
+	}
+	return stateETag(r.URL.Path, revision, newest, forecast, r.URL.RawQuery)
+}
+
+// bufferedResponse collects a handler's response so it can be tagged before
+// anything is sent
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+// withBodyETag is withETag for responses without cheaper state to tag, such
+// as static files: next runs into a buffer and the tag is a hash of the
+// body. Use it only for small bodies.
+func withBodyETag(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next(w, r)
+			return
+		}
+		buf := &bufferedResponse{header: w.Header()}
+		next(buf, r)
+		if buf.status == 0 {
+			buf.status = http.StatusOK
+		}
+		withETag(func(*http.Request) string {
+			if buf.status != http.StatusOK || w.Header().Get("Content-Encoding") != "" {
+				return ""
+			}
+			sum := fnv.New64a()
+			_, _ = sum.Write(buf.body.Bytes())
+			return `"` + hex.EncodeToString(sum.Sum(nil)) + `"`
+		}, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", strconv.Itoa(buf.body.Len()))
+			w.WriteHeader(buf.status)
+			_, _ = w.Write(buf.body.Bytes())
+		})(w, r)
+	}
+}
+
+// etagMatches reports whether an If-None-Match header names tag in any
+// encoding, using the weak comparison RFC 9110 prescribes for it
+func etagMatches(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == `"`+tag+`"` || candidate == `"`+tag+`-gzip"` {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if coding = strings.TrimSpace(coding); coding != "gzip" && coding != "*" {
+			continue
+		}
+		q := strings.TrimSpace(params)
+		if value, ok := strings.CutPrefix(q, "q="); ok {
+			if f, err := strconv.ParseFloat(value, 64); err == nil && f == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// compressible reports whether a content type is text that gzip shrinks;
+// images and fonts are compressed already
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" ||
+		mediaType == "application/javascript" || mediaType == "image/svg+xml" ||
+		strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,177 @@
+package web
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestHistoryAPIETagAndGzip(t *testing.T) {
+	ws := testNewWebServer(t)
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Minute)
+	for i := 0; i < 60; i++ {
+		ws.UpdateWeather(&weather.Observation{Timestamp: start.Add(time.Duration(i) * time.Minute).Unix(), AirTemperature: 20})
+	}
+	get := func(header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/history", nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	plain := get(nil)
+	tag := plain.Header().Get("ETag")
+	if plain.Code != http.StatusOK || tag == "" || plain.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("plain response: %d, ETag %q, encoding %q", plain.Code, tag, plain.Header().Get("Content-Encoding"))
+	}
+
+	zipped := get(map[string]string{"Accept-Encoding": "br, gzip;q=0.8"})
+	if zipped.Header().Get("Content-Encoding") != "gzip" || zipped.Body.Len() >= plain.Body.Len() {
+		t.Fatalf("gzip response: encoding %q, %d bytes vs %d plain", zipped.Header().Get("Content-Encoding"), zipped.Body.Len(), plain.Body.Len())
+	}
+	if zipped.Header().Get("ETag") == tag || !strings.Contains(zipped.Header().Get("Vary"), "Accept-Encoding") {
+		t.Errorf("gzip ETag %q, Vary %q", zipped.Header().Get("ETag"), zipped.Header().Get("Vary"))
+	}
+	zr, err := gzip.NewReader(zipped.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(zr)
+	var points []HistoryResponse
+	if err := json.Unmarshal(body, &points); err != nil || len(points) != 60 {
+		t.Fatalf("decompressed %d points: %v", len(points), err)
+	}
+
+	// Either tag revalidates the unchanged history
+	for _, ifNoneMatch := range []string{tag, `"other", ` + zipped.Header().Get("ETag")} {
+		if rec := get(map[string]string{"If-None-Match": ifNoneMatch}); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: %d with %d bytes", ifNoneMatch, rec.Code, rec.Body.Len())
+		}
+	}
+	if rec := get(map[string]string{"Accept-Encoding": "gzip;q=0"}); rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("gzip sent although refused with q=0")
+	}
+
+	// A new observation changes the tag
+	ws.UpdateWeather(&weather.Observation{Timestamp: start.Add(time.Hour).Unix(), AirTemperature: 21})
+	if rec := get(map[string]string{"If-None-Match": tag}); rec.Code != http.StatusOK || rec.Header().Get("ETag") == tag {
+		t.Errorf("stale tag answered with %d", rec.Code)
+	}
+}
+
+func TestStatusAPIETagStreams(t *testing.T) {
+	ws := testNewWebServer(t)
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	for i := 0; i < 50; i++ {
+		ws.UpdateWeather(&weather.Observation{Timestamp: start.Add(time.Duration(i) * time.Minute).Unix(), AirTemperature: 20})
+	}
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/api/status", "")
+	tag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Encoding") != "gzip" || !strings.HasSuffix(tag, `-gzip"`) {
+		t.Fatalf("status: %d, encoding %q, ETag %q", rec.Code, rec.Header().Get("Content-Encoding"), tag)
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status StatusResponse
+	if err := json.NewDecoder(zr).Decode(&status); err != nil || len(status.DataHistory) != 50 {
+		t.Fatalf("decompressed status with %d points: %v", len(status.DataHistory), err)
+	}
+
+	// The uptime moves on, but the tag follows the history
+	time.Sleep(1100 * time.Millisecond)
+	if rec := get("/api/status", tag); rec.Code != http.StatusNotModified {
+		t.Errorf("unchanged status answered with %d", rec.Code)
+	}
+	if rec := get("/api/status?fields=temperature", tag); rec.Code != http.StatusOK {
+		t.Errorf("another query answered with %d", rec.Code)
+	}
+	ws.UpdateWeather(&weather.Observation{Timestamp: start.Add(time.Hour).Unix(), AirTemperature: 21})
+	if rec := get("/api/status", tag); rec.Code != http.StatusOK {
+		t.Errorf("status after a new observation answered with %d", rec.Code)
+	}
+}
+
+func TestWithETagSkipsHandlerWhenUnchanged(t *testing.T) {
+	calls := 0
+	handler := withETag(func(*http.Request) string { return `"v1"` }, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		// Written in chunks and flushed, as the status stream does
+		for i := 0; i < 8; i++ {
+			_, _ = w.Write([]byte(strings.Repeat(`{"a":1},`, 512)))
+			http.NewResponseController(w).Flush()
+		}
+	})
+	serve := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	rec := serve("")
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(zr)
+	if len(body) != 8*8*512 || rec.Header().Get("ETag") != `"v1-gzip"` || !rec.Flushed {
+		t.Errorf("streamed %d bytes, ETag %q, flushed %v", len(body), rec.Header().Get("ETag"), rec.Flushed)
+	}
+	if rec := serve(`"v1-gzip"`); rec.Code != http.StatusNotModified || calls != 1 {
+		t.Errorf("matching tag: %d after %d handler calls", rec.Code, calls)
+	}
+}
+
+func TestWithBodyETagPassesThrough(t *testing.T) {
+	handler := withBodyETag(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(make([]byte, 4096))
+	})
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	if rec := serve(http.MethodGet, "/image"); rec.Header().Get("Content-Encoding") != "" || rec.Body.Len() != 4096 || rec.Header().Get("ETag") == "" {
+		t.Errorf("image: encoding %q, %d bytes", rec.Header().Get("Content-Encoding"), rec.Body.Len())
+	}
+	if rec := serve(http.MethodHead, "/image"); rec.Body.Len() != 0 || rec.Header().Get("Content-Length") != "4096" {
+		t.Errorf("HEAD: %d bytes, Content-Length %q", rec.Body.Len(), rec.Header().Get("Content-Length"))
+	}
+	if rec := serve(http.MethodGet, "/missing"); rec.Code != http.StatusNotFound || rec.Header().Get("ETag") != "" {
+		t.Errorf("error response: %d, ETag %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
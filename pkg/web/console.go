@@ -0,0 +1,321 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+)
+
+// ConsoleStyle colors one piece of status console text by its role: label,
+// value, success, danger, warning or muted. The terminal console maps roles
+// to tview color tags, /console to ANSI escape codes.
+type ConsoleStyle func(role, text string) string
+
+// ConsolePanel is one titled box of the status console
+type ConsolePanel struct {
+	Title string
+	Text  string
+}
+
+// ConsolePanels renders the sensor, station, alarm and HomeKit panels of the
+// status console from /api/weather, /api/status and /api/alarm-status
+// responses. A nil response (the request failed) leaves its panels empty.
+func ConsolePanels(weatherData, statusData, alarmData map[string]interface{}, style ConsoleStyle) []ConsolePanel {
+	field := func(b *strings.Builder, label, value string) {
+		fmt.Fprintf(b, "%s %s\n", style("label", label+":"), style("value", value))
+	}
+
+	var sensors strings.Builder
+	if weatherData != nil {
+		for _, f := range []struct{ key, label, format string }{
+			{"temperature", "Temperature", "%.1f°C"},
+			{"humidity", "Humidity", "%.0f%%"},
+			{"pressure", "Pressure", "%.1f mb"},
+			{"windSpeed", "Wind Speed", "%.1f mph"},
+			{"windGust", "Wind Gust", "%.1f mph"},
+			{"windDirection", "Wind Direction", "%.0f°"},
+			{"rainAccum", "Rain Accum", "%.3f in"},
+			{"illuminance", "Illuminance", "%.0f lux"},
+			{"uv", "UV Index", "%.0f"},
+			{"battery", "Battery", "%.2fV"},
+		} {
+			if v, ok := weatherData[f.key].(float64); ok {
+				field(&sensors, f.label, fmt.Sprintf(f.format, v))
+			}
+		}
+	}
+
+	var station strings.Builder
+	if statusData != nil {
+		field(&station, "Connected", fmt.Sprint(statusData["connected"]))
+		if s, ok := statusData["station"].(string); ok {
+			field(&station, "Station", s)
+		}
+		if s, ok := statusData["dataSource"].(string); ok {
+			field(&station, "Data Source", s)
+		}
+		if s, ok := statusData["lastUpdate"].(string); ok {
+			field(&station, "Last Update", s)
+		}
+		if history, ok := statusData["dataHistory"].([]interface{}); ok {
+			field(&station, "History", fmt.Sprintf("%d pts", len(history)))
+		}
+	}
+
+	var alarms strings.Builder
+	if alarmData != nil {
+		if enabled, ok := alarmData["enabled"].(bool); ok {
+			field(&alarms, "Enabled", fmt.Sprint(enabled))
+		}
+		if total, ok := alarmData["totalAlarms"].(float64); ok {
+			field(&alarms, "Total", fmt.Sprintf("%.0f", total))
+		}
+		if active, ok := alarmData["enabledAlarms"].(float64); ok {
+			field(&alarms, "Active", fmt.Sprintf("%.0f", active))
+			alarms.WriteByte('\n')
+		}
+		if list, ok := alarmData["alarms"].([]interface{}); ok {
+			var triggered, cooling, silenced []string
+			for _, a := range list {
+				alarm, ok := a.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _ := alarm["name"].(string)
+				lastTriggered, _ := alarm["lastTriggered"].(string)
+				inCooldown, _ := alarm["inCooldown"].(bool)
+				cooldownRemaining, _ := alarm["cooldownRemaining"].(float64)
+				if lastTriggered != "" && lastTriggered != "Never" {
+					triggered = append(triggered, fmt.Sprintf("%s (%s)", name, lastTriggered))
+				}
+				if inCooldown && cooldownRemaining > 0 {
+					cooling = append(cooling, fmt.Sprintf("%s (%.0fs)", name, cooldownRemaining))
+				}
+				if until, _ := alarm["snoozedUntil"].(string); until != "" {
+					if t, err := time.Parse(time.RFC3339, until); err == nil {
+						until = t.Local().Format("15:04")
+					}
+					silenced = append(silenced, fmt.Sprintf("%s (snoozed until %s)", name, until))
+				} else if acked, _ := alarm["acknowledged"].(bool); acked {
+					silenced = append(silenced, name+" (acknowledged)")
+				}
+			}
+			// Only the first three of each list fit the panel
+			list := func(heading, role string, names []string) {
+				fmt.Fprintf(&alarms, "%s\n", style(role, heading+":"))
+				for i, n := range names {
+					if i == 3 {
+						fmt.Fprintf(&alarms, "  %s\n", style("value", fmt.Sprintf("+%d more...", len(names)-3)))
+						break
+					}
+					fmt.Fprintf(&alarms, "  %s\n", style("value", n))
+				}
+			}
+			if len(triggered) > 0 {
+				list("Triggered", "danger", triggered)
+				alarms.WriteByte('\n')
+			}
+			if len(cooling) > 0 {
+				list("Cooling Down", "warning", cooling)
+				alarms.WriteByte('\n')
+			}
+			if len(silenced) > 0 {
+				list("Silenced", "muted", silenced)
+			}
+		}
+	}
+
+	var homekit strings.Builder
+	if hk, ok := statusData["homekit"].(map[string]interface{}); ok {
+		if bridge, ok := hk["bridge"].(bool); ok {
+			if bridge {
+				fmt.Fprintf(&homekit, "%s %s\n", style("label", "Status:"), style("success", "Active"))
+			} else {
+				fmt.Fprintf(&homekit, "%s %s\n", style("label", "Status:"), style("danger", "Disabled"))
+			}
+		}
+		if code, ok := hk["setupCode"].(string); ok && code != "" {
+			field(&homekit, "PIN", code)
+		} else if pin, ok := hk["pin"].(string); ok {
+			field(&homekit, "PIN", pin)
+		}
+		if uri, ok := hk["setupURI"].(string); ok && uri != "" {
+			field(&homekit, "Setup URI", uri)
+		}
+		if accessories, ok := hk["accessories"].(float64); ok {
+			field(&homekit, "Accessories", fmt.Sprintf("%.0f", accessories))
+			homekit.WriteByte('\n')
+		}
+		if names, ok := hk["accessoryNames"].([]interface{}); ok && len(names) > 0 {
+			fmt.Fprintf(&homekit, "%s\n", style("label", "Published Sensors:"))
+			for _, n := range names {
+				if name, ok := n.(string); ok {
+					fmt.Fprintf(&homekit, "  %s\n", style("success", "• "+name))
+				}
+			}
+		} else {
+			fmt.Fprintf(&homekit, "%s\n", style("muted", "No sensors published"))
+		}
+	}
+
+	return []ConsolePanel{
+		{Title: "Tempest Sensors", Text: sensors.String()},
+		{Title: "Station Status", Text: station.String()},
+		{Title: "Alarm Status", Text: alarms.String()},
+		{Title: "HomeKit Status", Text: homekit.String()},
+	}
+}
+
+// consoleANSI are the SGR codes of the /console roles; values keep the
+// terminal's default color so they read on light and dark backgrounds
+var consoleANSI = map[string]string{
+	"title":   "1",
+	"heading": "1;34",
+	"label":   "36",
+	"success": "32",
+	"danger":  "31",
+	"warning": "33",
+	"muted":   "90",
+}
+
+// ansiStyle is the ConsoleStyle of /console
+func ansiStyle(role, text string) string {
+	code, ok := consoleANSI[role]
+	if !ok {
+		return text
+	}
+	return "\x1b[" + code + "m" + text + "\x1b[0m"
+}
+
+// consoleText renders the status console summary as ANSI-colored text
+func (ws *WebServer) consoleText(now time.Time) string {
+	// The same responses the terminal console fetches over HTTP
+	get := func(handler http.HandlerFunc) map[string]interface{} {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		var data map[string]interface{}
+		if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &data) != nil {
+			return nil
+		}
+		return data
+	}
+	panels := ConsolePanels(get(ws.handleWeatherAPI), get(ws.handleStatusAPI), get(ws.handleAlarmStatusAPI), ansiStyle)
+
+	ws.mu.RLock()
+	version := ws.version
+	ws.mu.RUnlock()
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %s\n", ansiStyle("title", "Tempest HomeKit v"+version), ansiStyle("muted", now.Format("2006-01-02 15:04:05")))
+	for _, p := range panels {
+		fmt.Fprintf(&b, "\n%s\n", ansiStyle("heading", "── "+p.Title+" ──"))
+		if p.Text == "" {
+			fmt.Fprintf(&b, "%s\n", ansiStyle("muted", "unavailable"))
+			continue
+		}
+		b.WriteString(p.Text)
+	}
+	return b.String()
+}
+
+// ansiToHTML escapes s for HTML and turns its SGR escape sequences into
+// <span class="ansi-N"> elements; other escape sequences are dropped
+func ansiToHTML(s string) string {
+	var b strings.Builder
+	open := 0
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '\x1b')
+		if i < 0 {
+			b.WriteString(html.EscapeString(s))
+			break
+		}
+		b.WriteString(html.EscapeString(s[:i]))
+		s = s[i+1:]
+		if !strings.HasPrefix(s, "[") {
+			continue
+		}
+		end := strings.IndexFunc(s[1:], func(r rune) bool { return r >= '@' && r <= '~' })
+		if end < 0 {
+			break
+		}
+		params, final := s[1:end+1], s[end+1]
+		s = s[end+2:]
+		if final != 'm' {
+			continue
+		}
+		for _, p := range strings.Split(params, ";") {
+			if p == "" || p == "0" {
+				b.WriteString(strings.Repeat("</span>", open))
+				open = 0
+				continue
+			}
+			fmt.Fprintf(&b, `<span class="ansi-%s">`, html.EscapeString(p))
+			open++
+		}
+	}
+	b.WriteString(strings.Repeat("</span>", open))
+	return b.String()
+}
+
+// consolePage wraps the rendered summary; the script refetches the fragment
+// so the page stays live without reloading
+const consolePage = `<!doctype html>
+<html>
+  <head>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <title>Tempest HomeKit Console</title>
+    <style>
+      body { margin: 0; background: #1e1e1e; color: #d4d4d4; }
+      pre { margin: 0; padding: 1em; font: 14px/1.4 ui-monospace, Menlo, Consolas, monospace; white-space: pre-wrap; }
+      .ansi-1 { font-weight: bold; }
+      .ansi-31 { color: #f14c4c; }
+      .ansi-32 { color: #23d18b; }
+      .ansi-33 { color: #f5f543; }
+      .ansi-34 { color: #3b8eea; }
+      .ansi-36 { color: #29b8db; }
+      .ansi-90 { color: #808080; }
+    </style>
+  </head>
+  <body>
+    <pre id="console">%s</pre>
+    <script>
+      setInterval(function() {
+        fetch('console?fragment=1', { cache: 'no-store' })
+          .then(function(r) { return r.ok ? r.text() : Promise.reject(r.status); })
+          .then(function(html) { document.getElementById('console').innerHTML = html; })
+          .catch(function() {});
+      }, %d);
+    </script>
+  </body>
+</html>
+`
+
+// consoleRefresh is how often the /console page refetches the summary
+const consoleRefresh = 5 * time.Second
+
+// handleConsole serves a read-only live view of the status console summary:
+// an HTML page by default, the bare HTML fragment with ?fragment=1, or the
+// ANSI text with ?format=ansi (for curl in a terminal)
+func (ws *WebServer) handleConsole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	text := ws.consoleText(time.Now())
+	w.Header().Set("Cache-Control", "no-store")
+	switch {
+	case r.URL.Query().Get("format") == "ansi":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = fmt.Fprint(w, text)
+	case r.URL.Query().Get("fragment") != "":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = fmt.Fprint(w, ansiToHTML(text))
+	default:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = fmt.Fprintf(w, consolePage, ansiToHTML(text), consoleRefresh.Milliseconds())
+	}
+}
@@ -0,0 +1,69 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestAnsiToHTML(t *testing.T) {
+	got := ansiToHTML("\x1b[1;34m── <Sensors> ──\x1b[0m\n\x1b[36mA & B:\x1b[0m 5\x1b[2K\x1b[31mopen")
+	want := `<span class="ansi-1"><span class="ansi-34">── &lt;Sensors&gt; ──</span></span>` + "\n" +
+		`<span class="ansi-36">A &amp; B:</span> 5<span class="ansi-31">open</span>`
+	if got != want {
+		t.Errorf("ansiToHTML =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestConsolePanels(t *testing.T) {
+	style := func(role, text string) string { return "<" + role + ">" + text }
+	alarms := []interface{}{}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		alarms = append(alarms, map[string]interface{}{"name": name, "lastTriggered": "12:00"})
+	}
+	panels := ConsolePanels(
+		map[string]interface{}{"temperature": 21.25, "uv": 3.0},
+		nil,
+		map[string]interface{}{"enabled": true, "alarms": alarms},
+		style,
+	)
+	if len(panels) != 4 {
+		t.Fatalf("got %d panels", len(panels))
+	}
+	if want := "<label>Temperature: <value>21.2°C\n<label>UV Index: <value>3\n"; panels[0].Text != want {
+		t.Errorf("sensors = %q, want %q", panels[0].Text, want)
+	}
+	if panels[1].Text != "" || panels[3].Text != "" {
+		t.Errorf("panels without /api/status = %q, %q", panels[1].Text, panels[3].Text)
+	}
+	if !strings.Contains(panels[2].Text, "<danger>Triggered:") || !strings.Contains(panels[2].Text, "<value>+2 more...") || strings.Contains(panels[2].Text, "d (12:00)") {
+		t.Errorf("alarms = %q", panels[2].Text)
+	}
+}
+
+func TestConsoleHandler(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.UpdateWeather(&weather.Observation{Timestamp: time.Now().Unix(), AirTemperature: 18.5, RelativeHumidity: 55})
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/console"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("/console%s: status %d", query, rec.Code)
+		}
+		return rec
+	}
+	if body := get("?format=ansi").Body.String(); !strings.Contains(body, "\x1b[36mTemperature:\x1b[0m 18.5°C") || !strings.Contains(body, "── HomeKit Status ──") {
+		t.Errorf("ANSI console = %q", body)
+	}
+	if body := get("?fragment=1").Body.String(); strings.Contains(body, "\x1b") || !strings.Contains(body, `<span class="ansi-36">Humidity:</span> 55%`) {
+		t.Errorf("fragment = %q", body)
+	}
+	if body := get("").Body.String(); !strings.Contains(body, `<pre id="console">`) || !strings.Contains(body, "fetch('console?fragment=1'") {
+		t.Errorf("page = %q", body)
+	}
+}
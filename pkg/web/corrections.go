@@ -0,0 +1,151 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"tempest-homekit-go/pkg/corrections"
+)
+
+// CorrectionRequest is the body of PUT /api/history/{timestamp}, and
+// optionally of DELETE with just the reason
+type CorrectionRequest struct {
+	Fields map[string]float64 `json:"fields,omitempty"` // observation JSON names, e.g. {"air_temperature": 24.1}
+	Reason string             `json:"reason,omitempty"`
+}
+
+// CorrectionResponse reports a correction and the daily statistics it
+// recomputed
+type CorrectionResponse struct {
+	Correction      corrections.Entry `json:"correction"`
+	StatsDate       string            `json:"statsDate,omitempty"`  // station day the reading belongs to
+	StatsRecomputed bool              `json:"statsRecomputed"`      // the day's statistics were rebuilt
+	StatsError      string            `json:"statsError,omitempty"` // why they were not
+}
+
+// SetCorrections enables /api/history corrections and replays the recorded
+// ones on history loaded later
+func (ws *WebServer) SetCorrections(l *corrections.Log) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.corrections = l
+}
+
+// correctionLog returns the corrections, or writes the error and returns nil
+// when there are none
+func (ws *WebServer) correctionLog(w http.ResponseWriter) *corrections.Log {
+	ws.mu.RLock()
+	l := ws.corrections
+	ws.mu.RUnlock()
+	if l == nil {
+		http.Error(w, "Corrections are not available", http.StatusServiceUnavailable)
+	}
+	return l
+}
+
+// handleCorrectionsAPI lists the audit trail of corrections, newest first
+func (ws *WebServer) handleCorrectionsAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	l := ws.correctionLog(w)
+	if l == nil {
+		return
+	}
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit '"+v+"'", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(l.Entries(limit))
+}
+
+// handleCorrectionAPI deletes (DELETE) or overwrites fields of (PUT) the
+// stored observation at the path's timestamp, records it in the audit trail
+// and recomputes the statistics of its day
+func (ws *WebServer) handleCorrectionAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	l := ws.correctionLog(w)
+	if l == nil {
+		return
+	}
+	at, err := parseTimeParam("timestamp", r.PathValue("timestamp"))
+	if err != nil || at.IsZero() {
+		http.Error(w, fmt.Sprintf("invalid timestamp '%s'. Use Unix seconds or an RFC 3339 time", r.PathValue("timestamp")), http.StatusBadRequest)
+		return
+	}
+	ts := at.Unix()
+
+	var req CorrectionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 8<<10)).Decode(&req); err != nil {
+			http.Error(w, "Request body must be {\"fields\": {\"air_temperature\": 24.1}, \"reason\": \"<why>\"}", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var entry corrections.Entry
+	if r.Method == http.MethodDelete {
+		before, ok := ws.history.Remove(ts)
+		if !ok {
+			http.Error(w, fmt.Sprintf("no stored observation at %d", ts), http.StatusNotFound)
+			return
+		}
+		entry = l.Record(before, nil, nil, req.Reason, r.RemoteAddr)
+	} else {
+		stored, ok := ws.history.Get(ts)
+		if !ok {
+			http.Error(w, fmt.Sprintf("no stored observation at %d", ts), http.StatusNotFound)
+			return
+		}
+		after, err := corrections.Overwrite(stored, req.Fields)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		before, ok := ws.history.Update(after)
+		if !ok {
+			http.Error(w, fmt.Sprintf("no stored observation at %d", ts), http.StatusNotFound)
+			return
+		}
+		entry = l.Record(before, &after, req.Fields, req.Reason, r.RemoteAddr)
+	}
+
+	ws.mu.Lock()
+	ws.historyRevision++
+	if ws.weatherData != nil && ws.weatherData.Timestamp == ts {
+		// The current reading was corrected too
+		if entry.After != nil {
+			current := *entry.After
+			ws.weatherData = &current
+		} else if history := ws.history.Snapshot(); len(history) > 0 {
+			latest := history[len(history)-1]
+			ws.weatherData = &latest
+		}
+	}
+	engine := ws.statsEngine
+	ws.mu.Unlock()
+
+	resp := CorrectionResponse{Correction: entry}
+	if engine != nil {
+		date, err := engine.Recompute(ts, ws.history.Snapshot())
+		resp.StatsDate, resp.StatsRecomputed = date, err == nil
+		if err != nil {
+			resp.StatsError = err.Error()
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
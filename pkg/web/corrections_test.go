@@ -0,0 +1,113 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/corrections"
+	"tempest-homekit-go/pkg/stats"
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestCorrectionsAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.SetAdminPassword("secret")
+	do := func(method, path, body string, auth bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if auth {
+			req.SetBasicAuth("admin", "secret")
+		}
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	start := time.Now().Truncate(time.Minute).Add(-10 * time.Minute)
+	engine := stats.NewEngine(time.UTC, 45, 0)
+	ws.SetStatsEngine(engine)
+	var spike, gust int64
+	for m := 0; m < 5; m++ {
+		obs := &weather.Observation{Timestamp: start.Add(time.Duration(m) * time.Minute).Unix(), AirTemperature: 20, RelativeHumidity: 50, ReportInterval: 1}
+		switch m {
+		case 2:
+			obs.AirTemperature, spike = 60, obs.Timestamp
+		case 3:
+			obs.WindGust, gust = 90, obs.Timestamp
+		}
+		ws.UpdateWeather(obs)
+		engine.Add(obs)
+	}
+	path := func(ts int64) string { return "/api/history/" + strconv.FormatInt(ts, 10) }
+
+	if rec := do(http.MethodDelete, path(spike), "", true); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without corrections, got %d", rec.Code)
+	}
+	ws.SetCorrections(corrections.New())
+
+	if rec := do(http.MethodDelete, path(spike), "", false); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the admin password, got %d", rec.Code)
+	}
+	for name, req := range map[string][2]string{
+		"bad timestamp": {"/api/history/noon", `{"reason": "x"}`},
+		"bad field":     {path(gust), `{"fields": {"temperature": 20}}`},
+		"no fields":     {path(gust), `{"reason": "x"}`},
+		"bad body":      {path(gust), `{`},
+	} {
+		if rec := do(http.MethodPut, req[0], req[1], true); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rec.Code)
+		}
+	}
+	if rec := do(http.MethodPut, path(spike+1), `{"fields": {"air_temperature": 20}}`, true); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown reading: expected 404, got %d", rec.Code)
+	}
+
+	// Delete the spike; today's statistics are rebuilt without it
+	revision := ws.historyRevision
+	rec := do(http.MethodDelete, path(spike), `{"reason": "station sat in a car"}`, true)
+	var resp CorrectionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("delete: got %d (%v)", rec.Code, err)
+	}
+	if resp.Correction.Action != corrections.ActionDelete || resp.Correction.Before.AirTemperature != 60 || !resp.StatsRecomputed {
+		t.Errorf("delete response = %+v", resp)
+	}
+	if s := engine.Summary(); s.Today.TempMax != 20 {
+		t.Errorf("today's max = %v, want 20 after the deletion", s.Today.TempMax)
+	}
+	if len(ws.History()) != 4 || ws.historyRevision == revision {
+		t.Errorf("history = %d readings, revision %d; want 4 and a new revision", len(ws.History()), ws.historyRevision)
+	}
+	if rec := do(http.MethodDelete, path(spike), "", true); rec.Code != http.StatusNotFound {
+		t.Errorf("delete again: expected 404, got %d", rec.Code)
+	}
+
+	// Overwrite the gust, addressed by RFC 3339 time
+	rec = do(http.MethodPut, "/api/history/"+time.Unix(gust, 0).UTC().Format(time.RFC3339), `{"fields": {"wind_gust": 9}}`, true)
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Correction.After == nil || resp.Correction.After.WindGust != 9 {
+		t.Fatalf("overwrite: got %d %+v (%v)", rec.Code, resp, err)
+	}
+	for _, obs := range ws.History() {
+		if obs.Timestamp == gust && obs.WindGust != 9 {
+			t.Errorf("stored gust = %v, want 9", obs.WindGust)
+		}
+	}
+
+	// History loaded again keeps the corrections
+	ws.BackfillHistory([]*weather.Observation{{Timestamp: spike, AirTemperature: 60}, {Timestamp: gust, WindGust: 90}})
+	for _, obs := range ws.History() {
+		if obs.Timestamp == spike || (obs.Timestamp == gust && obs.WindGust != 9) {
+			t.Errorf("corrected reading came back: %+v", obs)
+		}
+	}
+
+	var entries []corrections.Entry
+	rec = do(http.MethodGet, "/api/history/corrections", "", false)
+	if err := json.NewDecoder(rec.Body).Decode(&entries); err != nil || len(entries) != 2 || entries[1].Reason != "station sat in a car" {
+		t.Errorf("audit trail = %+v (%v)", entries, err)
+	}
+}
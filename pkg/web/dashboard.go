@@ -0,0 +1,79 @@
+package web
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dashboardTemplates holds the dashboard layout and one partial per card
+//
+//go:embed templates/*.html templates/cards/*.html
+var dashboardTemplates embed.FS
+
+// Cards in dashboard order. Each name is a partial in templates/cards.
+var (
+	dashboardSensorCards = []string{"temperature", "humidity", "wind", "rain", "pressure", "light", "uv"}
+	dashboardInfoCards   = []string{"forecast", "tempest", "homekit", "alarm"}
+)
+
+// dashboardPage is the data the dashboard templates are executed with. Every
+// value goes through html/template's contextual escaping.
+type dashboardPage struct {
+	Version       string
+	BasePath      string   // URL prefix of every asset and API call, see SetBasePath
+	ScriptVersion string   // cache buster appended to script.js
+	SensorCards   []string // partials of the first grid, in order
+	InfoCards     []string // partials of the information grid, in order
+	HiddenCards   map[string]bool
+}
+
+// dashboardCard is the data a card partial is executed with. Hidden cards
+// are rendered with the hidden class so the script can show them again
+// without a reload.
+type dashboardCard struct {
+	Hidden bool
+}
+
+// parseDashboard parses the embedded templates once. The card function
+// renders the partial of a card by name, so the layout can take the card
+// order and hidden cards from its data.
+var parseDashboard = sync.OnceValues(func() (*template.Template, error) {
+	var tmpl *template.Template
+	card := func(name string, hidden map[string]bool) (template.HTML, error) {
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, name+".html", dashboardCard{Hidden: hidden[name]}); err != nil {
+			return "", fmt.Errorf("card %q: %w", name, err)
+		}
+		// Escaped by html/template while executing the partial
+		return template.HTML(strings.TrimSuffix(buf.String(), "\n")), nil
+	}
+	tmpl, err := template.New("dashboard.html").Funcs(template.FuncMap{"card": card}).
+		ParseFS(dashboardTemplates, "templates/*.html", "templates/cards/*.html")
+	return tmpl, err
+})
+
+// renderDashboard writes the dashboard page to w
+func (ws *WebServer) renderDashboard(w io.Writer) error {
+	tmpl, err := parseDashboard()
+	if err != nil {
+		return err
+	}
+	hidden := make(map[string]bool)
+	for _, card := range ws.HiddenCards() {
+		hidden[card] = true
+	}
+	return tmpl.ExecuteTemplate(w, "dashboard.html", dashboardPage{
+		Version:       ws.version,
+		BasePath:      ws.BasePath(),
+		ScriptVersion: fmt.Sprintf("%d", time.Now().UnixNano()),
+		SensorCards:   dashboardSensorCards,
+		InfoCards:     dashboardInfoCards,
+		HiddenCards:   hidden,
+	})
+}
@@ -0,0 +1,57 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDashboardTemplate(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.version = `1.0"><script>alert(1)</script>`
+	rec := httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	page := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("status %d, type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if strings.Contains(page, "<script>alert(1)") || !strings.Contains(page, "1.0&#34;&gt;&lt;script&gt;") {
+		t.Error("version not escaped")
+	}
+
+	// Every card partial, in order
+	last := -1
+	for _, card := range append(append([]string{}, dashboardSensorCards...), dashboardInfoCards...) {
+		i := strings.Index(page, `id="`+card+`-card"`)
+		if i < last {
+			t.Errorf("%s card missing or out of order", card)
+		}
+		last = i
+	}
+	if !strings.Contains(page, `id="station-tooltip"`) || !strings.Contains(page, `script.js?v=`) {
+		t.Error("layout parts missing")
+	}
+}
+
+func TestDashboardCardOrder(t *testing.T) {
+	defer func(sensors, info []string) {
+		dashboardSensorCards, dashboardInfoCards = sensors, info
+	}(dashboardSensorCards, dashboardInfoCards)
+
+	ws := testNewWebServer(t)
+	dashboardSensorCards, dashboardInfoCards = []string{"uv", "temperature"}, nil
+	var page strings.Builder
+	if err := ws.renderDashboard(&page); err != nil {
+		t.Fatal(err)
+	}
+	uv, temp := strings.Index(page.String(), `id="uv-card"`), strings.Index(page.String(), `id="temperature-card"`)
+	if uv < 0 || temp < uv || strings.Contains(page.String(), `id="wind-card"`) {
+		t.Error("cards not rendered from the configured list")
+	}
+
+	dashboardSensorCards = []string{"dewpoint"}
+	if err := ws.renderDashboard(&page); err == nil || !strings.Contains(err.Error(), "dewpoint") {
+		t.Errorf("unknown card: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"tempest-homekit-go/pkg/federation"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// sitesRefresh is how often the /sites page reloads itself
+const sitesRefresh = 60 * time.Second
+
+// FederationResponse is returned by /api/federation: the local station first,
+// then the federated sites in --federate order
+type FederationResponse struct {
+	Local federation.SiteStatus   `json:"local"`
+	Sites []federation.SiteStatus `json:"sites"`
+}
+
+// SetFederation enables /api/federation and the combined /sites page
+func (ws *WebServer) SetFederation(f *federation.Federation) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.federation = f
+}
+
+// federationResponse collects the local station and the remote sites, or
+// writes 503 when federation is not running
+func (ws *WebServer) federationResponse(w http.ResponseWriter) (FederationResponse, bool) {
+	ws.mu.RLock()
+	f := ws.federation
+	local := federation.SiteStatus{Name: federation.LocalSite, StationName: ws.stationName}
+	if obs := ws.weatherData; obs != nil {
+		local.Online = true
+		local.Reading = &federation.Reading{
+			Temperature:          obs.AirTemperature,
+			Humidity:             obs.RelativeHumidity,
+			WindSpeed:            obs.WindAvg,
+			WindGust:             obs.WindGust,
+			WindDirection:        obs.WindDirection,
+			RainDailyTotal:       obs.RainDailyTotal,
+			PrecipitationType:    obs.PrecipitationType,
+			Pressure:             obs.StationPressure,
+			Illuminance:          obs.Illuminance,
+			UV:                   obs.UV,
+			Battery:              obs.Battery,
+			LightningStrikeAvg:   obs.LightningStrikeAvg,
+			LightningStrikeCount: obs.LightningStrikeCount,
+			LastUpdate:           time.Unix(obs.Timestamp, 0).Format(time.RFC3339),
+		}
+	}
+	ws.mu.RUnlock()
+	if f == nil {
+		http.Error(w, "Federation is not configured (--federate)", http.StatusServiceUnavailable)
+		return FederationResponse{}, false
+	}
+	return FederationResponse{Local: local, Sites: f.Sites()}, true
+}
+
+// handleFederationAPI returns the latest reading of every site
+func (ws *WebServer) handleFederationAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	resp, ok := ws.federationResponse(w)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// siteRow is one line of the /sites table, formatted in the display units
+type siteRow struct {
+	Name, Station, Link, Error                        string
+	Online                                            bool
+	Temperature, Humidity, Wind, Gust, Pressure, Rain string
+	UV, Updated                                       string
+}
+
+// sitesPage is the combined multi-site dashboard. Links are relative so the
+// page also works under --web-base-path.
+var sitesPage = template.Must(template.New("sites").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta http-equiv="refresh" content="{{.Refresh}}">
+    <title>Tempest Sites</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; margin: 1.5em; color: #222; }
+        table { border-collapse: collapse; }
+        th, td { padding: 0.4em 0.8em; border-bottom: 1px solid #ddd; text-align: right; }
+        th:first-child, td:first-child { text-align: left; }
+        .offline { color: #999; }
+        .error { color: #c0392b; font-size: 0.85em; }
+    </style>
+</head>
+<body>
+    <h1>Tempest Sites</h1>
+    <table>
+        <tr><th>Site</th><th>Temperature</th><th>Humidity</th><th>Wind</th><th>Gust</th><th>Pressure</th><th>Rain Today</th><th>UV</th><th>Updated</th></tr>
+        {{- range .Rows}}
+        <tr{{if not .Online}} class="offline"{{end}}>
+            <td><a href="{{.Link}}">{{if .Station}}{{.Station}}{{else}}{{.Name}}{{end}}</a> ({{.Name}}){{if .Error}}<div class="error">{{.Error}}</div>{{end}}</td>
+            <td>{{.Temperature}}</td><td>{{.Humidity}}</td><td>{{.Wind}}</td><td>{{.Gust}}</td><td>{{.Pressure}}</td><td>{{.Rain}}</td><td>{{.UV}}</td><td>{{.Updated}}</td>
+        </tr>
+        {{- end}}
+    </table>
+    <p><a href="api/federation">JSON</a></p>
+</body>
+</html>
+`))
+
+// siteRow formats a site in the console's units
+func (ws *WebServer) siteRow(s federation.SiteStatus, link string) siteRow {
+	row := siteRow{Name: s.Name, Station: s.StationName, Link: link, Error: s.Error, Online: s.Online}
+	r := s.Reading
+	if r == nil {
+		return row
+	}
+	ws.mu.RLock()
+	units, unitsPressure, location := ws.units, ws.unitsPressure, ws.location
+	ws.mu.RUnlock()
+
+	if units == "metric" {
+		row.Temperature = fmt.Sprintf("%.1f°C", r.Temperature)
+		row.Wind = fmt.Sprintf("%.1f m/s", r.WindSpeed)
+		row.Gust = fmt.Sprintf("%.1f m/s", r.WindGust)
+		row.Rain = fmt.Sprintf("%.1f mm", r.RainDailyTotal)
+	} else {
+		row.Temperature = fmt.Sprintf("%.1f°F", r.Temperature*9/5+32)
+		row.Wind = fmt.Sprintf("%.1f mph", r.WindSpeed*2.23694)
+		row.Gust = fmt.Sprintf("%.1f mph", r.WindGust*2.23694)
+		row.Rain = fmt.Sprintf("%.2f in", r.RainDailyTotal/25.4)
+	}
+	row.Pressure = weather.FormatPressure(r.Pressure, unitsPressure)
+	row.Humidity = fmt.Sprintf("%.0f%%", r.Humidity)
+	row.UV = fmt.Sprintf("%d", r.UV)
+	if t, err := time.Parse(time.RFC3339, r.LastUpdate); err == nil {
+		if location != nil {
+			t = t.In(location)
+		}
+		row.Updated = t.Format("15:04")
+	}
+	return row
+}
+
+// handleSitesPage renders the combined dashboard of the local station and
+// every federated site
+func (ws *WebServer) handleSitesPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	resp, ok := ws.federationResponse(w)
+	if !ok {
+		return
+	}
+	rows := []siteRow{ws.siteRow(resp.Local, "./")}
+	for _, s := range resp.Sites {
+		rows = append(rows, ws.siteRow(s, s.URL+"/"))
+	}
+
+	var page bytes.Buffer
+	data := struct {
+		Refresh int
+		Rows    []siteRow
+	}{int(sitesRefresh.Seconds()), rows}
+	if err := sitesPage.Execute(&page, data); err != nil {
+		http.Error(w, "Sites page unavailable", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = page.WriteTo(w)
+}
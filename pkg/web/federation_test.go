@@ -0,0 +1,56 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/federation"
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestFederationAPIAndSitesPage(t *testing.T) {
+	ws := testNewWebServer(t)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	if rec := get("/api/federation"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status without federation = %d, want 503", rec.Code)
+	}
+
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"stationName": "Cabin", "dataHistory": [{"temperature": 10, "windGust": 25, "lastUpdate": "`+
+			time.Now().Format(time.RFC3339)+`"}]}`)
+	}))
+	defer remote.Close()
+	f := federation.New([]federation.Site{{Name: "cabin", URL: remote.URL}}, federation.Options{})
+	f.Poll(context.Background())
+	ws.SetFederation(f)
+	ws.UpdateWeather(&weather.Observation{Timestamp: time.Now().Unix(), AirTemperature: 20})
+
+	rec := get("/api/federation")
+	var resp FederationResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !resp.Local.Online || resp.Local.Reading == nil || resp.Local.Reading.Temperature != 20 {
+		t.Errorf("local = %+v", resp.Local)
+	}
+	if len(resp.Sites) != 1 || !resp.Sites[0].Online || resp.Sites[0].StationName != "Cabin" {
+		t.Errorf("sites = %+v", resp.Sites)
+	}
+
+	page := get("/sites").Body.String()
+	for _, want := range []string{"Cabin</a> (cabin)", `href="` + remote.URL + `/"`, "50.0°F", "55.9 mph", `http-equiv="refresh"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("/sites: missing %s", want)
+		}
+	}
+}
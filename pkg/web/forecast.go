@@ -0,0 +1,145 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// forecastKeep is how long forecast hours are kept to chart against the
+// observations, as long as /api/history/compare reaches back
+const forecastKeep = 7 * 24 * time.Hour
+
+// ForecastHour is the hourly forecast for one hour, as last issued before
+// the hour began. Values are metric, like /api/history.
+type ForecastHour struct {
+	Time              int64   `json:"time"`              // start of the hour, Unix seconds
+	Issued            int64   `json:"issued"`            // when the forecast was received, Unix seconds
+	AirTemperature    float64 `json:"airTemperature"`    // °C
+	PrecipProbability int     `json:"precipProbability"` // %
+}
+
+// ForecastHistoryResponse is the forecast for the hours the observations
+// cover, to chart forecast against actual
+type ForecastHistoryResponse struct {
+	Hours []ForecastHour `json:"hours"` // oldest first, up to the current hour
+}
+
+// maxForecastHours bounds the hours parameter of /api/forecast/hourly; the
+// better_forecast endpoint covers ten days
+const maxForecastHours = 240
+
+// HourlyForecastResponse is the hourly forecast from the current hour on.
+// Values are metric: °C, m/s, mb and mm.
+type HourlyForecastResponse struct {
+	Received int64                    `json:"received"`           // when the forecast arrived, Unix seconds
+	Timezone string                   `json:"timezone,omitempty"` // station IANA time zone
+	Hours    []weather.ForecastPeriod `json:"hours"`
+}
+
+// HourlyForecast returns the hourly periods of the latest forecast that have
+// not ended, for forecast_max() and forecast_min() alarm conditions
+func (ws *WebServer) HourlyForecast() []weather.ForecastPeriod {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	return weather.UpcomingHours(ws.forecastHourly, time.Now(), 0)
+}
+
+// handleForecastHourlyAPI serves the hourly forecast from the current hour
+func (ws *WebServer) handleForecastHourlyAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	hours := 0
+	if v := r.URL.Query().Get("hours"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxForecastHours {
+			http.Error(w, fmt.Sprintf("invalid hours '%s'. Use 1 to %d", v, maxForecastHours), http.StatusBadRequest)
+			return
+		}
+		hours = n
+	}
+	ws.mu.RLock()
+	resp := HourlyForecastResponse{
+		Received: ws.forecastReceived.Unix(),
+		Timezone: ws.timezone,
+		Hours:    weather.UpcomingHours(ws.forecastHourly, time.Now(), 0),
+	}
+	ws.mu.RUnlock()
+	if hours > 0 && hours < len(resp.Hours) {
+		resp.Hours = resp.Hours[:hours]
+	}
+	if len(resp.Hours) == 0 {
+		http.Error(w, "No hourly forecast available", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// recordForecastHoursLocked keeps the hourly periods of a forecast received
+// at now. The better_forecast hours start with the next hour, so a later
+// forecast replaces only hours that have not begun and each past hour keeps
+// the forecast last issued before it. The caller holds ws.mu.
+func (ws *WebServer) recordForecastHoursLocked(hourly []weather.ForecastPeriod, now time.Time) {
+	if ws.forecastHours == nil {
+		ws.forecastHours = map[int64]ForecastHour{}
+	}
+	for _, p := range hourly {
+		ws.forecastHours[p.Time] = ForecastHour{
+			Time:              p.Time,
+			Issued:            now.Unix(),
+			AirTemperature:    p.AirTemperature,
+			PrecipProbability: p.PrecipProbability,
+		}
+	}
+	oldest := now.Add(-forecastKeep).Unix()
+	for t := range ws.forecastHours {
+		if t < oldest {
+			delete(ws.forecastHours, t)
+		}
+	}
+}
+
+// forecastHistory returns the kept forecast hours that began between since
+// and now, oldest first
+func (ws *WebServer) forecastHistory(since, now time.Time) []ForecastHour {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	hours := []ForecastHour{}
+	for t, h := range ws.forecastHours {
+		if t >= since.Truncate(time.Hour).Unix() && t <= now.Unix() {
+			hours = append(hours, h)
+		}
+	}
+	sort.Slice(hours, func(i, j int) bool { return hours[i].Time < hours[j].Time })
+	return hours
+}
+
+// handleForecastHistoryAPI serves the forecast issued for past hours so the
+// charts can draw it over what was observed
+func (ws *WebServer) handleForecastHistoryAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	since, err := parseTimeParam("since", r.URL.Query().Get("since"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	if since.IsZero() || since.Before(now.Add(-forecastKeep)) {
+		since = now.Add(-forecastKeep)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(ForecastHistoryResponse{Hours: ws.forecastHistory(since, now)})
+}
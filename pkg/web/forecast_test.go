@@ -0,0 +1,118 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestForecastHistoryAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	hour := time.Now().Truncate(time.Hour)
+	period := func(offset int, temp float64) weather.ForecastPeriod {
+		return weather.ForecastPeriod{Time: hour.Add(time.Duration(offset) * time.Hour).Unix(), AirTemperature: temp, PrecipProbability: offset + 50}
+	}
+
+	ws.mu.Lock()
+	ws.recordForecastHoursLocked([]weather.ForecastPeriod{period(-8*24, 0), period(-2, 10), period(-1, 11), period(0, 12), period(1, 13)}, hour.Add(-3*time.Hour))
+	// A later forecast replaces the hours that had not begun yet
+	ws.recordForecastHoursLocked([]weather.ForecastPeriod{period(-1, 21), period(0, 22), period(1, 23)}, hour.Add(-90*time.Minute))
+	ws.mu.Unlock()
+
+	rec := get("/api/history/forecast")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp ForecastHistoryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []float64{10, 21, 22}
+	if len(resp.Hours) != len(want) {
+		t.Fatalf("expected %d hours up to the current one, got %+v", len(want), resp.Hours)
+	}
+	for i, h := range resp.Hours {
+		if h.AirTemperature != want[i] || h.Time != hour.Add(time.Duration(i-2)*time.Hour).Unix() || h.PrecipProbability != i+48 {
+			t.Errorf("hour %d: got %+v, want %.0f °C", i, h, want[i])
+		}
+	}
+	if resp.Hours[0].Issued != hour.Add(-3*time.Hour).Unix() || resp.Hours[1].Issued != hour.Add(-90*time.Minute).Unix() {
+		t.Errorf("unexpected issue times: %+v", resp.Hours)
+	}
+
+	if err := json.Unmarshal(get("/api/history/forecast?since="+hour.Add(-time.Hour).Format(time.RFC3339)).Body.Bytes(), &resp); err != nil || len(resp.Hours) != 2 {
+		t.Errorf("expected 2 hours since an hour ago, got %+v (%v)", resp.Hours, err)
+	}
+	if rec := get("/api/history/forecast?since=noon"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad since, got %d", rec.Code)
+	}
+
+	// The status forecast leaves the hours out
+	forecast := &weather.ForecastResponse{}
+	forecast.Forecast.Daily = []weather.ForecastPeriod{period(0, 25)}
+	forecast.Forecast.Hourly = []weather.ForecastPeriod{period(2, 14)}
+	ws.UpdateForecast(forecast)
+	ws.mu.RLock()
+	status, kept := ws.forecastData, ws.forecastHours[period(2, 0).Time]
+	ws.mu.RUnlock()
+	if status.Forecast.Hourly != nil || len(status.Forecast.Daily) != 1 || len(forecast.Forecast.Hourly) != 1 {
+		t.Errorf("expected the status forecast without hours and the received one unchanged, got %+v", status.Forecast)
+	}
+	if kept.AirTemperature != 14 {
+		t.Errorf("expected the hourly forecast to be kept, got %+v", kept)
+	}
+
+	ws.SetStation(2, "Other", "")
+	if err := json.Unmarshal(get("/api/history/forecast").Body.Bytes(), &resp); err != nil || len(resp.Hours) != 0 {
+		t.Errorf("expected no hours after switching stations, got %+v (%v)", resp.Hours, err)
+	}
+}
+
+func TestForecastHourlyAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	if rec := get("/api/forecast/hourly"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without a forecast, got %d", rec.Code)
+	}
+
+	hour := time.Now().Truncate(time.Hour)
+	forecast := &weather.ForecastResponse{Timezone: "UTC"}
+	for h := -1; h < 5; h++ {
+		forecast.Forecast.Hourly = append(forecast.Forecast.Hourly, weather.ForecastPeriod{Time: hour.Add(time.Duration(h) * time.Hour).Unix(), AirTemperature: float64(h), Precip: 0.5})
+	}
+	ws.UpdateForecast(forecast)
+
+	var resp HourlyForecastResponse
+	rec := get("/api/forecast/hourly")
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	// The hour that ended is left out; the current one is included
+	if len(resp.Hours) != 5 || resp.Hours[0].Time != hour.Unix() || resp.Hours[0].Precip != 0.5 || resp.Received == 0 {
+		t.Errorf("unexpected hourly forecast: %+v", resp)
+	}
+	if err := json.Unmarshal(get("/api/forecast/hourly?hours=2").Body.Bytes(), &resp); err != nil || len(resp.Hours) != 2 {
+		t.Errorf("expected 2 hours, got %+v (%v)", resp.Hours, err)
+	}
+	for _, bad := range []string{"0", "241", "soon"} {
+		if rec := get("/api/forecast/hourly?hours=" + bad); rec.Code != http.StatusBadRequest {
+			t.Errorf("hours=%s: expected 400, got %d", bad, rec.Code)
+		}
+	}
+	if got := ws.HourlyForecast(); len(got) != 5 {
+		t.Errorf("expected the alarm provider to return 5 hours, got %d", len(got))
+	}
+}
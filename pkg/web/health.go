@@ -0,0 +1,201 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// ErrSubsystemDisabled may be returned by a health check for a subsystem that
+// is turned off by configuration. It is reported as "disabled" and never fails
+// a probe.
+var ErrSubsystemDisabled = errors.New("disabled")
+
+// Data age thresholds for the built-in data source check. Readiness fails as
+// soon as data is stale; liveness only fails when the data source looks dead,
+// so a container is not restarted over a brief network outage.
+var (
+	readyStaleAfter    = 10 * time.Minute
+	livenessStaleAfter = 30 * time.Minute
+)
+
+// Health check statuses
+const (
+	HealthOK       = "ok"
+	HealthDown     = "down"
+	HealthDisabled = "disabled"
+)
+
+// HealthCheckFunc reports the health of one subsystem: nil when healthy,
+// ErrSubsystemDisabled when turned off, any other error when unhealthy.
+type HealthCheckFunc func() error
+
+type healthCheck struct {
+	name     string
+	critical bool // a failure also fails /healthz, not just /readyz
+	fn       HealthCheckFunc
+}
+
+type healthOutcome struct {
+	name     string
+	critical bool
+	err      error
+}
+
+// HealthCheckResult is the state of one subsystem
+type HealthCheckResult struct {
+	Status   string `json:"status"`
+	Message  string `json:"message,omitempty"`
+	Critical bool   `json:"critical"`
+}
+
+// HealthResponse is returned by /healthz and /readyz
+type HealthResponse struct {
+	Status    string                       `json:"status"` // ok or down
+	Checks    map[string]HealthCheckResult `json:"checks"`
+	Uptime    string                       `json:"uptime"`
+	Timestamp time.Time                    `json:"timestamp"`
+}
+
+// AddHealthCheck registers a subsystem check reported by /healthz and /readyz.
+// Every failing check fails /readyz; critical checks also fail /healthz.
+func (ws *WebServer) AddHealthCheck(name string, critical bool, fn HealthCheckFunc) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.healthChecks = append(ws.healthChecks, healthCheck{name: name, critical: critical, fn: fn})
+}
+
+// Liveness evaluates the /healthz checks without an HTTP request
+func (ws *WebServer) Liveness() HealthResponse {
+	return ws.evaluateHealth(true)
+}
+
+// handleHealthz is the liveness probe: 503 only when a critical subsystem is
+// down and restarting the process is likely to help
+func (ws *WebServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	ws.writeHealth(w, ws.evaluateHealth(true))
+}
+
+// handleReadyz is the readiness probe: 503 until data is flowing and every
+// enabled subsystem is healthy
+func (ws *WebServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ws.writeHealth(w, ws.evaluateHealth(false))
+}
+
+func (ws *WebServer) writeHealth(w http.ResponseWriter, resp HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if resp.Status != HealthOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// evaluateHealth runs all checks. For liveness only critical failures count.
+func (ws *WebServer) evaluateHealth(liveness bool) HealthResponse {
+	ws.mu.RLock()
+	checks := make([]healthCheck, len(ws.healthChecks))
+	copy(checks, ws.healthChecks)
+	var status *weather.DataSourceStatus
+	if ws.dataSourceStatus != nil {
+		s := *ws.dataSourceStatus
+		status = &s
+	}
+	var lastObs time.Time
+	if ws.weatherData != nil && ws.weatherData.Timestamp > 0 {
+		lastObs = time.Unix(ws.weatherData.Timestamp, 0)
+	}
+	udpListener := ws.udpListener
+	startTime := ws.startTime
+	ws.mu.RUnlock()
+
+	now := time.Now()
+	resp := HealthResponse{
+		Status:    HealthOK,
+		Checks:    map[string]HealthCheckResult{},
+		Uptime:    now.Sub(startTime).Round(time.Second).String(),
+		Timestamp: now,
+	}
+
+	staleAfter := readyStaleAfter
+	if liveness {
+		staleAfter = livenessStaleAfter
+	}
+	dataErr := checkDataAge(status, lastObs, startTime, now, staleAfter, liveness)
+	results := []healthOutcome{{"data", true, dataErr}}
+
+	if udpListener != nil {
+		var udpErr error
+		packets, lastPacket, _, _ := udpListener.GetStats()
+		switch {
+		case packets == 0:
+			if !liveness || now.Sub(startTime) > staleAfter {
+				udpErr = fmt.Errorf("no UDP packets received since start")
+			}
+		case now.Sub(lastPacket) > staleAfter:
+			udpErr = fmt.Errorf("no UDP packets for %s", now.Sub(lastPacket).Round(time.Second))
+		}
+		results = append(results, healthOutcome{"udp", true, udpErr})
+	}
+
+	for _, c := range checks {
+		results = append(results, healthOutcome{c.name, c.critical, runHealthCheck(c.fn)})
+	}
+
+	for _, res := range results {
+		r := HealthCheckResult{Status: HealthOK, Critical: res.critical}
+		switch {
+		case errors.Is(res.err, ErrSubsystemDisabled):
+			r.Status = HealthDisabled
+		case res.err != nil:
+			r.Status = HealthDown
+			r.Message = res.err.Error()
+			if !liveness || res.critical {
+				resp.Status = HealthDown
+			}
+		}
+		resp.Checks[res.name] = r
+	}
+	return resp
+}
+
+// checkDataAge reports whether observations are arriving from the data source.
+// During liveness checks a missing first observation is tolerated for the
+// staleness window after startup.
+func checkDataAge(status *weather.DataSourceStatus, lastObs, startTime, now time.Time, staleAfter time.Duration, liveness bool) error {
+	last := lastObs
+	if status != nil && status.LastUpdate.After(last) {
+		last = status.LastUpdate
+	}
+	source := "data source"
+	if status != nil && status.Type != "" {
+		source = string(status.Type)
+	}
+	if last.IsZero() {
+		if liveness && now.Sub(startTime) <= staleAfter {
+			return nil
+		}
+		return fmt.Errorf("no observations received yet from %s", source)
+	}
+	if age := now.Sub(last); age > staleAfter {
+		if status != nil && status.Type == weather.DataSourceAPI {
+			return fmt.Errorf("WeatherFlow API unreachable or not updating: last observation %s ago", age.Round(time.Second))
+		}
+		return fmt.Errorf("last observation from %s was %s ago", source, age.Round(time.Second))
+	}
+	return nil
+}
+
+// runHealthCheck isolates the probe from a panicking check
+func runHealthCheck(fn HealthCheckFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("health check panicked: %v", r)
+		}
+	}()
+	return fn()
+}
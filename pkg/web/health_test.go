@@ -0,0 +1,73 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func probe(t *testing.T, ws *WebServer, path string) (int, HealthResponse) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+	var resp HealthResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s returned invalid JSON: %v", path, err)
+	}
+	return rr.Code, resp
+}
+
+func TestHealthAndReadinessProbes(t *testing.T) {
+	ws := testNewWebServer(t)
+
+	// Freshly started without data: alive but not ready
+	if code, _ := probe(t, ws, "/healthz"); code != http.StatusOK {
+		t.Errorf("expected /healthz 200 during startup, got %d", code)
+	}
+	if code, resp := probe(t, ws, "/readyz"); code != http.StatusServiceUnavailable || resp.Checks["data"].Status != HealthDown {
+		t.Errorf("expected /readyz 503 before first observation, got %d %+v", code, resp)
+	}
+
+	ws.UpdateWeather(&weather.Observation{Timestamp: time.Now().Unix()})
+	ws.UpdateDataSourceStatus(weather.DataSourceStatus{Type: weather.DataSourceAPI, Active: true, LastUpdate: time.Now()})
+	ws.AddHealthCheck("homekit", true, func() error { return ErrSubsystemDisabled })
+	if code, resp := probe(t, ws, "/readyz"); code != http.StatusOK || resp.Checks["homekit"].Status != HealthDisabled {
+		t.Errorf("expected /readyz 200 with data flowing, got %d %+v", code, resp)
+	}
+
+	// A non-critical failure only affects readiness
+	ws.AddHealthCheck("store", false, func() error { return errors.New("read-only filesystem") })
+	if code, _ := probe(t, ws, "/healthz"); code != http.StatusOK {
+		t.Errorf("expected non-critical failure to keep /healthz 200, got %d", code)
+	}
+	if code, resp := probe(t, ws, "/readyz"); code != http.StatusServiceUnavailable || resp.Checks["store"].Message != "read-only filesystem" {
+		t.Errorf("expected /readyz 503 for failing store, got %d %+v", code, resp)
+	}
+
+	// A critical failure (or panic) fails liveness too
+	ws.AddHealthCheck("broken", true, func() error { panic("boom") })
+	if code, resp := probe(t, ws, "/healthz"); code != http.StatusServiceUnavailable || resp.Checks["broken"].Status != HealthDown {
+		t.Errorf("expected /healthz 503 for critical failure, got %d %+v", code, resp)
+	}
+}
+
+func TestCheckDataAge(t *testing.T) {
+	now := time.Now()
+	start := now.Add(-time.Hour)
+	api := &weather.DataSourceStatus{Type: weather.DataSourceAPI, LastUpdate: now.Add(-15 * time.Minute)}
+
+	if err := checkDataAge(api, time.Time{}, start, now, readyStaleAfter, false); err == nil {
+		t.Error("expected stale API data to fail readiness")
+	}
+	if err := checkDataAge(api, time.Time{}, start, now, livenessStaleAfter, true); err != nil {
+		t.Errorf("expected 15 minute old data to pass liveness, got %v", err)
+	}
+	if err := checkDataAge(nil, time.Time{}, start, now, livenessStaleAfter, true); err == nil {
+		t.Error("expected no data an hour after start to fail liveness")
+	}
+}
@@ -0,0 +1,254 @@
+package web
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// historyRing holds the observation history in a fixed-size ring buffer
+// ordered by timestamp. Storage for max entries is allocated once; a new
+// observation overwrites the oldest one when the ring is full, so the
+// common case neither sorts nor copies. Lookups binary search the logical
+// order starting at head. Late observations and removals shift only the
+// entries newer than them, which are few for the usual late arrival.
+//
+// Readers get an ordered copy from Snapshot, built on the first read after a
+// write and shared by later readers until the next write. The zero value is
+// an empty history without a size limit, whose storage grows as needed.
+type historyRing struct {
+	mu   sync.Mutex
+	buf  []weather.Observation                 // ring storage, len(buf) is the capacity
+	head int                                   // index in buf of the oldest entry
+	n    int                                   // entries stored
+	max  int                                   // entries kept, 0 for no limit
+	snap atomic.Pointer[[]weather.Observation] // cached Snapshot, nil after a write
+}
+
+// Snapshot returns the history oldest first. It is shared and must not be
+// modified.
+func (h *historyRing) Snapshot() []weather.Observation {
+	if p := h.snap.Load(); p != nil {
+		return *p
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if p := h.snap.Load(); p != nil {
+		return *p
+	}
+	var out []weather.Observation
+	if h.n > 0 {
+		out = make([]weather.Observation, h.n)
+		if end := h.head + h.n; end <= len(h.buf) {
+			copy(out, h.buf[h.head:end])
+		} else {
+			k := copy(out, h.buf[h.head:])
+			copy(out[k:], h.buf[:end-len(h.buf)])
+		}
+	}
+	h.snap.Store(&out)
+	return out
+}
+
+// Len returns the number of observations stored
+func (h *historyRing) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.n
+}
+
+// slot returns the buf index of the i-th oldest entry; h.mu must be held
+func (h *historyRing) slot(i int) int {
+	return (h.head + i) % len(h.buf)
+}
+
+// search returns the logical index of the first entry at or after ts;
+// h.mu must be held
+func (h *historyRing) search(ts int64) int {
+	return sort.Search(h.n, func(i int) bool { return h.buf[h.slot(i)].Timestamp >= ts })
+}
+
+// changed drops the cached snapshot; h.mu must be held
+func (h *historyRing) changed() {
+	h.snap.Store(nil)
+}
+
+// reserve makes room for one more entry: it allocates the storage on first
+// use, drops the oldest entry of a full ring with a size limit and grows the
+// storage of one without. h.mu must be held.
+func (h *historyRing) reserve() {
+	switch {
+	case h.buf == nil:
+		h.buf = make([]weather.Observation, h.capacity(1))
+	case h.n < len(h.buf):
+	case h.max > 0:
+		h.head = h.slot(1)
+		h.n--
+	default:
+		buf := make([]weather.Observation, 2*len(h.buf))
+		for i := 0; i < h.n; i++ {
+			buf[i] = h.buf[h.slot(i)]
+		}
+		h.buf, h.head = buf, 0
+	}
+}
+
+// capacity is the storage size for n entries
+func (h *historyRing) capacity(n int) int {
+	if h.max > 0 {
+		return h.max
+	}
+	return max(2*n, 16)
+}
+
+// Insert adds obs, or replaces a stored observation with the same timestamp,
+// and keeps the newest max entries. It reports whether obs was new and
+// whether it landed before the newest stored observation.
+func (h *historyRing) Insert(obs *weather.Observation) (added, older bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.changed()
+
+	if h.n == 0 || obs.Timestamp > h.buf[h.slot(h.n-1)].Timestamp {
+		h.reserve()
+		h.buf[h.slot(h.n)] = *obs
+		h.n++
+		return true, false
+	}
+	i := h.search(obs.Timestamp)
+	if h.buf[h.slot(i)].Timestamp == obs.Timestamp {
+		h.buf[h.slot(i)] = *obs
+		return false, false
+	}
+	if h.max > 0 && h.n == h.max {
+		if i == 0 {
+			return true, true // older than everything kept
+		}
+		i--
+	}
+	h.reserve()
+	// Shift the newer entries up one slot to open position i
+	for j := h.n; j > i; j-- {
+		h.buf[h.slot(j)] = h.buf[h.slot(j-1)]
+	}
+	h.buf[h.slot(i)] = *obs
+	h.n++
+	return true, true
+}
+
+// Merge inserts a batch of observations in one pass, replacing stored
+// observations with the same timestamp. It returns how many were new and
+// whether any landed before the newest stored observation.
+func (h *historyRing) Merge(observations []*weather.Observation) (added int, older bool) {
+	batch := make([]weather.Observation, 0, len(observations))
+	for _, obs := range observations {
+		batch = append(batch, *obs)
+	}
+	// Stable, so the last of several readings with one timestamp wins
+	sort.SliceStable(batch, func(i, j int) bool { return batch[i].Timestamp < batch[j].Timestamp })
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.changed()
+
+	next := make([]weather.Observation, 0, h.n+len(batch))
+	i := 0
+	for j := 0; j < len(batch); j++ {
+		if j+1 < len(batch) && batch[j+1].Timestamp == batch[j].Timestamp {
+			continue
+		}
+		obs := batch[j]
+		for i < h.n && h.buf[h.slot(i)].Timestamp < obs.Timestamp {
+			next = append(next, h.buf[h.slot(i)])
+			i++
+		}
+		if i < h.n && h.buf[h.slot(i)].Timestamp == obs.Timestamp {
+			i++ // replaced
+		} else {
+			added++
+			older = older || i < h.n
+		}
+		next = append(next, obs)
+	}
+	for ; i < h.n; i++ {
+		next = append(next, h.buf[h.slot(i)])
+	}
+	h.load(next)
+	return added, older
+}
+
+// load refills the ring from sorted, keeping the newest max entries;
+// h.mu must be held
+func (h *historyRing) load(sorted []weather.Observation) {
+	if h.max > 0 && len(sorted) > h.max {
+		sorted = sorted[len(sorted)-h.max:]
+	}
+	if len(h.buf) < len(sorted) || h.buf == nil {
+		h.buf = make([]weather.Observation, h.capacity(len(sorted)))
+	}
+	h.head, h.n = 0, copy(h.buf, sorted)
+	clear(h.buf[h.n:])
+}
+
+// Get returns the observation with timestamp ts
+func (h *historyRing) Get(ts int64) (weather.Observation, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	i := h.search(ts)
+	if i == h.n || h.buf[h.slot(i)].Timestamp != ts {
+		return weather.Observation{}, false
+	}
+	return h.buf[h.slot(i)], true
+}
+
+// Remove deletes the observation with timestamp ts and returns it
+func (h *historyRing) Remove(ts int64) (weather.Observation, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	i := h.search(ts)
+	if i == h.n || h.buf[h.slot(i)].Timestamp != ts {
+		return weather.Observation{}, false
+	}
+	removed := h.buf[h.slot(i)]
+	for j := i; j < h.n-1; j++ {
+		h.buf[h.slot(j)] = h.buf[h.slot(j+1)]
+	}
+	h.n--
+	h.buf[h.slot(h.n)] = weather.Observation{}
+	h.changed()
+	return removed, true
+}
+
+// Update replaces the stored observation with obs's timestamp and returns
+// the one replaced. Unlike Insert it never adds one.
+func (h *historyRing) Update(obs weather.Observation) (weather.Observation, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	i := h.search(obs.Timestamp)
+	if i == h.n || h.buf[h.slot(i)].Timestamp != obs.Timestamp {
+		return weather.Observation{}, false
+	}
+	before := h.buf[h.slot(i)]
+	h.buf[h.slot(i)] = obs
+	h.changed()
+	return before, true
+}
+
+// Replace swaps in a copy of observations, sorted and trimmed to max
+func (h *historyRing) Replace(observations []weather.Observation) {
+	sorted := append([]weather.Observation(nil), observations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.load(sorted)
+	h.changed()
+}
+
+// Reset empties the history
+func (h *historyRing) Reset() {
+	h.Replace(nil)
+}
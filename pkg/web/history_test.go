@@ -0,0 +1,251 @@
+package web
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestHistoryRingSnapshotsAreImmutable(t *testing.T) {
+	h := historyRing{max: 5}
+	for ts := int64(1); ts <= 5; ts++ {
+		if added, older := h.Insert(&weather.Observation{Timestamp: ts * 10}); !added || older {
+			t.Fatalf("insert %d: added=%v older=%v", ts*10, added, older)
+		}
+	}
+	before := h.Snapshot()
+	want := []int64{10, 20, 30, 40, 50}
+
+	// Append past capacity, trim, replace and insert out of order
+	h.Insert(&weather.Observation{Timestamp: 60})
+	h.Insert(&weather.Observation{Timestamp: 70})
+	if added, _ := h.Insert(&weather.Observation{Timestamp: 60, AirTemperature: 1}); added {
+		t.Error("replacing a timestamp reported a new observation")
+	}
+	if added, older := h.Insert(&weather.Observation{Timestamp: 45}); !added || !older {
+		t.Errorf("out-of-order insert: added=%v older=%v", added, older)
+	}
+
+	for i, obs := range before {
+		if obs.Timestamp != want[i] || obs.AirTemperature != 0 {
+			t.Fatalf("earlier snapshot changed: %v", before)
+		}
+	}
+	var got []int64
+	for _, obs := range h.Snapshot() {
+		got = append(got, obs.Timestamp)
+	}
+	if len(got) != 5 || got[0] != 40 || got[1] != 45 || got[4] != 70 || h.Snapshot()[3].AirTemperature != 1 {
+		t.Errorf("history = %v", got)
+	}
+
+	h.Replace([]weather.Observation{{Timestamp: 3}, {Timestamp: 1}, {Timestamp: 2}})
+	if s := h.Snapshot(); len(s) != 3 || s[0].Timestamp != 1 || s[2].Timestamp != 3 {
+		t.Errorf("Replace did not sort: %v", s)
+	}
+	h.Reset()
+	if h.Len() != 0 {
+		t.Errorf("Reset left %d entries", h.Len())
+	}
+}
+
+func TestHistoryRingConcurrentReaders(t *testing.T) {
+	h := historyRing{max: 200}
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				s := h.Snapshot()
+				for i := 1; i < len(s); i++ {
+					if s[i].Timestamp <= s[i-1].Timestamp {
+						t.Errorf("snapshot out of order at %d", i)
+						return
+					}
+				}
+			}
+		}()
+	}
+	for ts := int64(1); ts <= 2000; ts++ {
+		h.Insert(&weather.Observation{Timestamp: ts * 2})
+		if ts%50 == 0 {
+			h.Insert(&weather.Observation{Timestamp: ts*2 - 1})
+		}
+	}
+	stop.Store(true)
+	wg.Wait()
+	if h.Len() != 200 {
+		t.Errorf("history holds %d entries, want 200", h.Len())
+	}
+}
+
+// lockedHistory is the previous design: one slice behind an RWMutex that
+// readers copy while holding the read lock
+type lockedHistory struct {
+	mu  sync.RWMutex
+	obs []weather.Observation
+	max int
+}
+
+func (l *lockedHistory) insert(obs *weather.Observation) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.obs = append(l.obs, *obs)
+	if len(l.obs) > l.max {
+		l.obs = l.obs[len(l.obs)-l.max:]
+	}
+}
+
+func (l *lockedHistory) read() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	c := make([]weather.Observation, len(l.obs))
+	copy(c, l.obs)
+	return len(c)
+}
+
+// BenchmarkHistoryInsertDuringReads measures the observation write path while
+// API readers continuously read a 20000 point history:
+//
+//	go test ./pkg/web -run '^$' -bench HistoryInsertDuringReads
+func BenchmarkHistoryInsertDuringReads(b *testing.B) {
+	const points, readers = 20000, 4
+	run := func(b *testing.B, insert func(*weather.Observation), read func() int) {
+		for ts := int64(1); ts <= points; ts++ {
+			insert(&weather.Observation{Timestamp: ts})
+		}
+		var stop atomic.Bool
+		var wg sync.WaitGroup
+		for r := 0; r < readers; r++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for !stop.Load() {
+					_ = read()
+					runtime.Gosched()
+				}
+			}()
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			insert(&weather.Observation{Timestamp: int64(points + i + 1)})
+		}
+		b.StopTimer()
+		stop.Store(true)
+		wg.Wait()
+	}
+
+	b.Run("rwmutex-copy", func(b *testing.B) {
+		l := &lockedHistory{max: points}
+		run(b, l.insert, l.read)
+	})
+	b.Run("ring", func(b *testing.B) {
+		h := &historyRing{max: points}
+		run(b, func(obs *weather.Observation) { h.Insert(obs) }, func() int {
+			n := 0
+			for range h.Snapshot() {
+				n++
+			}
+			return n
+		})
+	})
+}
+
+func TestHistoryRingMerge(t *testing.T) {
+	h := historyRing{max: 6}
+	for _, ts := range []int64{10, 30, 50} {
+		h.Insert(&weather.Observation{Timestamp: ts})
+	}
+	before := h.Snapshot()
+
+	added, older := h.Merge([]*weather.Observation{
+		{Timestamp: 60}, {Timestamp: 20}, {Timestamp: 30, AirTemperature: 1},
+		{Timestamp: 40}, {Timestamp: 40, AirTemperature: 2},
+	})
+	if added != 3 || !older {
+		t.Errorf("Merge added=%d older=%v, want 3 and true", added, older)
+	}
+	var got []int64
+	for _, obs := range h.Snapshot() {
+		got = append(got, obs.Timestamp)
+	}
+	s := h.Snapshot()
+	if len(got) != 6 || got[0] != 10 || got[1] != 20 || got[5] != 60 || s[2].AirTemperature != 1 || s[3].AirTemperature != 2 {
+		t.Errorf("history = %v", got)
+	}
+	if len(before) != 3 || before[1].AirTemperature != 0 {
+		t.Errorf("earlier snapshot changed: %v", before)
+	}
+
+	// Only newer points: nothing older, trimmed to max
+	if added, older := h.Merge([]*weather.Observation{{Timestamp: 70}, {Timestamp: 80}}); added != 2 || older {
+		t.Errorf("appending merge added=%d older=%v", added, older)
+	}
+	if s := h.Snapshot(); len(s) != 6 || s[0].Timestamp != 30 {
+		t.Errorf("history not trimmed: %v", s)
+	}
+}
+
+func TestHistoryRingOverwritesOldest(t *testing.T) {
+	h := historyRing{max: 100}
+	h.Reset()
+	ts := int64(0)
+	// Appends go into the preallocated storage and, once it is full,
+	// overwrite the oldest entry: no history is copied or reallocated
+	allocs := testing.AllocsPerRun(150, func() {
+		ts++
+		h.Insert(&weather.Observation{Timestamp: ts * 10})
+	})
+	if allocs != 0 {
+		t.Errorf("%.0f allocations per append, want 0", allocs)
+	}
+	if s := h.Snapshot(); len(s) != 100 || s[0].Timestamp != 520 || s[99].Timestamp != 1510 {
+		t.Fatalf("history after 151 appends = %d entries, %d to %d", len(s), s[0].Timestamp, s[len(s)-1].Timestamp)
+	}
+
+	// The ring has wrapped: late inserts, updates and removals work on the
+	// logical order across the end of the storage
+	if added, older := h.Insert(&weather.Observation{Timestamp: 1505}); !added || !older {
+		t.Errorf("late insert: added=%v older=%v", added, older)
+	}
+	if added, _ := h.Insert(&weather.Observation{Timestamp: 100}); !added {
+		t.Error("insert older than the history should report added")
+	}
+	if _, ok := h.Update(weather.Observation{Timestamp: 1000, AirTemperature: 5}); !ok {
+		t.Error("Update did not find 1000")
+	}
+	if obs, ok := h.Get(1000); !ok || obs.AirTemperature != 5 {
+		t.Errorf("Get(1000) = %v, %v", obs, ok)
+	}
+	if _, ok := h.Remove(800); !ok {
+		t.Error("Remove did not find 800")
+	}
+	if _, ok := h.Remove(800); ok {
+		t.Error("Remove found 800 twice")
+	}
+	s := h.Snapshot()
+	if len(s) != 99 || s[0].Timestamp != 530 || s[97].Timestamp != 1505 || s[98].Timestamp != 1510 {
+		t.Fatalf("history = %d entries, %d to %d", len(s), s[0].Timestamp, s[len(s)-1].Timestamp)
+	}
+	for i := 1; i < len(s); i++ {
+		if s[i].Timestamp <= s[i-1].Timestamp {
+			t.Fatalf("history out of order at %d", i)
+		}
+	}
+}
+
+func TestHistoryRingGrowsWithoutLimit(t *testing.T) {
+	var h historyRing
+	for ts := int64(1); ts <= 100; ts++ {
+		h.Insert(&weather.Observation{Timestamp: ts})
+	}
+	h.Insert(&weather.Observation{Timestamp: 0})
+	if s := h.Snapshot(); len(s) != 101 || s[0].Timestamp != 0 || s[100].Timestamp != 100 {
+		t.Errorf("history = %d entries", len(s))
+	}
+}
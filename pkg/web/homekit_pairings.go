@@ -0,0 +1,146 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"tempest-homekit-go/pkg/logger"
+)
+
+// ErrPairingNotFound is returned by a HomeKitPairingManager for an unknown
+// controller
+var ErrPairingNotFound = errors.New("pairing not found")
+
+// HomeKitPairing is a controller paired with the bridge
+type HomeKitPairing struct {
+	ID    string `json:"id"`
+	Admin bool   `json:"admin"`
+}
+
+// HomeKitPairingsResponse is returned by /api/homekit/pairings
+type HomeKitPairingsResponse struct {
+	Pairings []HomeKitPairing `json:"pairings"`
+	Count    int              `json:"count"`
+}
+
+// HomeKitPairingManager lists and removes HomeKit pairings
+type HomeKitPairingManager interface {
+	Pairings() ([]HomeKitPairing, error)
+	RemovePairing(id string) error
+	Reset() error
+}
+
+// SetHomeKitPairingManager enables the pairing management endpoints
+func (ws *WebServer) SetHomeKitPairingManager(m HomeKitPairingManager) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.pairingManager = m
+}
+
+// SetAdminPassword sets the HTTP basic auth password of the admin endpoints.
+// Without one the admin endpoints are disabled.
+func (ws *WebServer) SetAdminPassword(password string) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.adminPassword = password
+}
+
+// requireAdmin protects an admin endpoint with the admin password
+func (ws *WebServer) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ws.mu.RLock()
+		password := ws.adminPassword
+		ws.mu.RUnlock()
+		if password == "" {
+			http.Error(w, "Admin endpoints disabled: set --admin-password", http.StatusForbidden)
+			return
+		}
+		requireBasicAuth("Tempest Admin", password, next).ServeHTTP(w, r)
+	}
+}
+
+func (ws *WebServer) homekitPairingManager(w http.ResponseWriter) HomeKitPairingManager {
+	ws.mu.RLock()
+	m := ws.pairingManager
+	ws.mu.RUnlock()
+	if m == nil {
+		http.Error(w, "HomeKit is disabled", http.StatusServiceUnavailable)
+	}
+	return m
+}
+
+// handleHomeKitPairingsAPI lists the paired controllers
+func (ws *WebServer) handleHomeKitPairingsAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	m := ws.homekitPairingManager(w)
+	if m == nil {
+		return
+	}
+	pairings, err := m.Pairings()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if pairings == nil {
+		pairings = []HomeKitPairing{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(HomeKitPairingsResponse{Pairings: pairings, Count: len(pairings)})
+}
+
+// handleHomeKitPairingRemoveAPI removes the pairing given as {"id": "..."}
+func (ws *WebServer) handleHomeKitPairingRemoveAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.ID) == "" {
+		http.Error(w, "Request body must be {\"id\": \"<pairing id>\"}", http.StatusBadRequest)
+		return
+	}
+	m := ws.homekitPairingManager(w)
+	if m == nil {
+		return
+	}
+	if err := m.RemovePairing(req.ID); err != nil {
+		if errors.Is(err, ErrPairingNotFound) {
+			http.Error(w, "Pairing not found", http.StatusNotFound)
+			return
+		}
+		logger.Error("Failed to remove HomeKit pairing %s: %v", req.ID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logger.Info("HomeKit pairing %s removed via web console from %s", req.ID, r.RemoteAddr)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "removed", "id": req.ID})
+}
+
+// handleHomeKitResetAPI clears all pairings and the bridge identity
+func (ws *WebServer) handleHomeKitResetAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	m := ws.homekitPairingManager(w)
+	if m == nil {
+		return
+	}
+	if err := m.Reset(); err != nil {
+		logger.Error("Failed to reset HomeKit: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logger.Info("HomeKit state reset via web console from %s", r.RemoteAddr)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "reset"})
+}
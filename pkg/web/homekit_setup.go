@@ -0,0 +1,47 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HomeKitSetupResponse is the pairing information returned by /api/homekit/setup
+type HomeKitSetupResponse struct {
+	PIN          string `json:"pin"`
+	SetupCode    string `json:"setup_code"` // e.g. "031-45-154"
+	SetupID      string `json:"setup_id"`
+	SetupURI     string `json:"setup_uri"` // X-HM:// payload for QR codes
+	PINGenerated bool   `json:"pin_generated"`
+	Paired       bool   `json:"paired"`
+}
+
+// handleHomeKitSetupAPI returns the bridge's setup code and X-HM URI for
+// provisioning tools that print labels or pair the bridge automatically. The
+// values come from the HomeKit status pushed by the service.
+func (ws *WebServer) handleHomeKitSetupAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ws.mu.RLock()
+	bridge, _ := ws.homekitStatus["bridge"].(bool)
+	resp := HomeKitSetupResponse{}
+	resp.PIN, _ = ws.homekitStatus["pin"].(string)
+	resp.SetupCode, _ = ws.homekitStatus["setupCode"].(string)
+	resp.SetupID, _ = ws.homekitStatus["setupId"].(string)
+	resp.SetupURI, _ = ws.homekitStatus["setupURI"].(string)
+	resp.PINGenerated, _ = ws.homekitStatus["pinGenerated"].(bool)
+	paired, _ := ws.homekitStatus["pairedDevices"].(int)
+	ws.mu.RUnlock()
+
+	if !bridge || resp.SetupURI == "" {
+		http.Error(w, "HomeKit bridge not running", http.StatusServiceUnavailable)
+		return
+	}
+	resp.Paired = paired > 0
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,109 @@
+package web
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// ingestMaxBody bounds a /api/ingest request, enough for a day of one-minute
+// WeatherFlow observation arrays
+const ingestMaxBody = 1 << 20
+
+// Ingestor receives observations pushed to /api/ingest
+type Ingestor interface {
+	Push(obs weather.Observation) error
+}
+
+// IngestResponse reports how many pushed observations were queued
+type IngestResponse struct {
+	Accepted int      `json:"accepted"`
+	Rejected int      `json:"rejected"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// SetIngestor enables /api/ingest, feeding pushed observations to ingestor.
+// Requests must send token as a bearer token or the token query parameter;
+// without a token the endpoint refuses every request.
+func (ws *WebServer) SetIngestor(ingestor Ingestor, token string) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.ingestor = ingestor
+	ws.ingestToken = token
+}
+
+// ingestAuthorized checks the bearer token, or the token query parameter for
+// gateways that cannot set headers
+func ingestAuthorized(r *http.Request, token string) bool {
+	supplied := r.URL.Query().Get("token")
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		scheme, value, _ := strings.Cut(auth, " ")
+		if !strings.EqualFold(scheme, "Bearer") {
+			return false
+		}
+		supplied = strings.TrimSpace(value)
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(supplied), []byte(token)) == 1
+}
+
+// handleIngestAPI queues observations pushed by other hardware
+func (ws *WebServer) handleIngestAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ws.mu.RLock()
+	ingestor, token := ws.ingestor, ws.ingestToken
+	ws.mu.RUnlock()
+	if ingestor == nil {
+		http.Error(w, "Ingest disabled: start with --ingest", http.StatusServiceUnavailable)
+		return
+	}
+	if token == "" {
+		http.Error(w, "Ingest endpoint disabled: set --ingest-token", http.StatusForbidden)
+		return
+	}
+	if !ingestAuthorized(r, token) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="Tempest Ingest"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, ingestMaxBody))
+	if err != nil {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	observations, err := weather.ParseIngestPayload(body, time.Now())
+	if err != nil {
+		http.Error(w, "Invalid observation: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resp IngestResponse
+	for _, obs := range observations {
+		if err := ingestor.Push(obs); err != nil {
+			resp.Rejected++
+			if len(resp.Errors) < 10 {
+				resp.Errors = append(resp.Errors, err.Error())
+			}
+			continue
+		}
+		resp.Accepted++
+	}
+	logger.Debug("Ingest: accepted %d, rejected %d observation(s) from %s", resp.Accepted, resp.Rejected, r.RemoteAddr)
+
+	status := http.StatusOK
+	if resp.Accepted == 0 {
+		status = http.StatusConflict
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,58 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestIngestAPI(t *testing.T) {
+	const token = "0123456789abcdef"
+	ws := testNewWebServer(t)
+	post := func(path, auth, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+	const reading = `{"temperature": 21.5, "humidity": 48}`
+	if rec := post("/api/ingest", "Bearer "+token, reading); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status without ingest = %d, want 503", rec.Code)
+	}
+
+	source := weather.NewIngestDataSource("Garden")
+	ch, _ := source.Start()
+	ws.SetIngestor(source, token)
+
+	for _, auth := range []string{"", "Bearer wrong", "Basic " + token} {
+		if rec := post("/api/ingest", auth, reading); rec.Code != http.StatusUnauthorized {
+			t.Errorf("auth %q: status %d, want 401", auth, rec.Code)
+		}
+	}
+	if rec := post("/api/ingest", "Bearer "+token, `{"temperature": 21.5}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid payload: status %d, want 400", rec.Code)
+	}
+
+	rec := post("/api/ingest", "Bearer "+token, `{"timestamp": 1767225540, "temperature": 21.5, "humidity": 48}`)
+	var resp IngestResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK || resp.Accepted != 1 {
+		t.Fatalf("status %d, response %+v, %v", rec.Code, resp, err)
+	}
+	if obs := <-ch; obs.AirTemperature != 21.5 || obs.RelativeHumidity != 48 {
+		t.Errorf("queued %+v", obs)
+	}
+
+	// The token query parameter is for gateways that cannot set headers;
+	// a repeated observation is not queued again
+	rec = post("/api/ingest?token="+token, "", `{"timestamp": 1767225540, "temperature": 21.5, "humidity": 48}`)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("repeated observation: status %d, want 409", rec.Code)
+	}
+}
@@ -0,0 +1,169 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/maintenance"
+)
+
+// MaintenanceRequest is the body of POST /api/maintenance. Without start the
+// window starts now; it ends at end or after duration.
+type MaintenanceRequest struct {
+	Start    string `json:"start,omitempty"`    // RFC 3339
+	End      string `json:"end,omitempty"`      // RFC 3339
+	Duration string `json:"duration,omitempty"` // Go duration such as "2h"
+	Reason   string `json:"reason,omitempty"`   // e.g. "moving the station to the roof"
+}
+
+// MaintenanceEndRequest is the optional body of POST /api/maintenance/end
+type MaintenanceEndRequest struct {
+	ID int `json:"id,omitempty"` // window to end or cancel; the active one when left out
+}
+
+// SetMaintenance sets the maintenance windows /api/maintenance manages and
+// /api/history reports as gaps
+func (ws *WebServer) SetMaintenance(s *maintenance.Scheduler) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.maintenance = s
+}
+
+// maintenanceScheduler returns the maintenance windows, or writes the error
+// and returns nil when there are none
+func (ws *WebServer) maintenanceScheduler(w http.ResponseWriter) *maintenance.Scheduler {
+	ws.mu.RLock()
+	s := ws.maintenance
+	ws.mu.RUnlock()
+	if s == nil {
+		http.Error(w, "Maintenance windows are not available", http.StatusServiceUnavailable)
+	}
+	return s
+}
+
+func writeMaintenance(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// handleMaintenanceAPI reports the maintenance windows on GET and schedules
+// one on POST, which requires the admin password
+func (ws *WebServer) handleMaintenanceAPI(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if s := ws.maintenanceScheduler(w); s != nil {
+			writeMaintenance(w, s.Status())
+		}
+	case http.MethodPost:
+		ws.requireAdmin(ws.handleMaintenanceScheduleAPI)(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleMaintenanceScheduleAPI schedules a maintenance window
+func (ws *WebServer) handleMaintenanceScheduleAPI(w http.ResponseWriter, r *http.Request) {
+	s := ws.maintenanceScheduler(w)
+	if s == nil {
+		return
+	}
+	var req MaintenanceRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10)).Decode(&req); err != nil {
+		http.Error(w, "Request body must be {\"duration\": \"2h\"} or {\"start\": \"<RFC 3339>\", \"end\": \"<RFC 3339>\"}, with an optional \"reason\"", http.StatusBadRequest)
+		return
+	}
+	start := time.Now()
+	if req.Start != "" {
+		t, err := time.Parse(time.RFC3339, req.Start)
+		if err != nil {
+			http.Error(w, "invalid start '"+req.Start+"'. Use RFC 3339, e.g. 2025-06-01T09:00:00-05:00", http.StatusBadRequest)
+			return
+		}
+		start = t
+	}
+	var end time.Time
+	switch {
+	case req.End != "" && req.Duration != "":
+		http.Error(w, "Give end or duration, not both", http.StatusBadRequest)
+		return
+	case req.End != "":
+		t, err := time.Parse(time.RFC3339, req.End)
+		if err != nil {
+			http.Error(w, "invalid end '"+req.End+"'. Use RFC 3339, e.g. 2025-06-01T13:00:00-05:00", http.StatusBadRequest)
+			return
+		}
+		end = t
+	case req.Duration != "":
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			http.Error(w, "invalid duration '"+req.Duration+"'. Use a Go duration such as 2h", http.StatusBadRequest)
+			return
+		}
+		end = start.Add(d)
+	default:
+		http.Error(w, "Give the end or duration of the maintenance", http.StatusBadRequest)
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if runes := []rune(reason); len(runes) > 200 {
+		reason = string(runes[:200])
+	}
+
+	window, err := s.Add(start, end, reason, "api")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeMaintenance(w, window)
+}
+
+// handleMaintenanceEndAPI ends the active window early, or cancels a
+// scheduled one
+func (ws *WebServer) handleMaintenanceEndAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s := ws.maintenanceScheduler(w)
+	if s == nil {
+		return
+	}
+	var req MaintenanceEndRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10)).Decode(&req); err != nil {
+			http.Error(w, "Request body must be {\"id\": <window id>} or empty", http.StatusBadRequest)
+			return
+		}
+	}
+	window, err := s.End(req.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeMaintenance(w, window)
+}
+
+// setMaintenanceGaps sets X-Maintenance-Gaps on a history response to the
+// maintenance windows between the first and last observation returned, as
+// start-end pairs of Unix seconds
+func (ws *WebServer) setMaintenanceGaps(w http.ResponseWriter, first, last int64) {
+	ws.mu.RLock()
+	s := ws.maintenance
+	ws.mu.RUnlock()
+	if s == nil {
+		return
+	}
+	gaps := s.Gaps(time.Unix(first, 0), time.Unix(last+1, 0))
+	if len(gaps) == 0 {
+		return
+	}
+	pairs := make([]string, len(gaps))
+	for i, g := range gaps {
+		pairs[i] = strconv.FormatInt(g.Start.Unix(), 10) + "-" + strconv.FormatInt(g.End.Unix(), 10)
+	}
+	w.Header().Set("X-Maintenance-Gaps", strings.Join(pairs, ","))
+}
@@ -0,0 +1,91 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/maintenance"
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestMaintenanceAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.SetAdminPassword("secret")
+	do := func(method, path, body string, auth bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if auth {
+			req.SetBasicAuth("admin", "secret")
+		}
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/api/maintenance", "", false); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without maintenance windows, got %d", rec.Code)
+	}
+	scheduler := maintenance.New()
+	ws.SetMaintenance(scheduler)
+
+	if rec := do(http.MethodPost, "/api/maintenance", `{"duration": "2h"}`, false); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the admin password, got %d", rec.Code)
+	}
+	for _, body := range []string{`{}`, `{"duration": "soon"}`, `{"start": "tomorrow", "duration": "1h"}`, `{"end": "2020-01-01T00:00:00Z"}`, `{"duration": "1h", "end": "2099-01-01T00:00:00Z"}`} {
+		if rec := do(http.MethodPost, "/api/maintenance", body, true); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rec.Code)
+		}
+	}
+
+	// Start maintenance now and schedule another window
+	rec := do(http.MethodPost, "/api/maintenance", `{"duration": "2h", "reason": "relocating"}`, true)
+	var active maintenance.Window
+	if err := json.NewDecoder(rec.Body).Decode(&active); err != nil || active.Reason != "relocating" || active.Source != "api" {
+		t.Fatalf("schedule: got %d %+v (%v)", rec.Code, active, err)
+	}
+	start := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	end := time.Now().Add(25 * time.Hour).UTC().Format(time.RFC3339)
+	if rec := do(http.MethodPost, "/api/maintenance", `{"start": "`+start+`", "end": "`+end+`"}`, true); rec.Code != http.StatusOK {
+		t.Errorf("schedule ahead: got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var status maintenance.Status
+	rec = do(http.MethodGet, "/api/maintenance", "", false)
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil || status.Active == nil || status.Active.ID != active.ID || len(status.Scheduled) != 1 {
+		t.Errorf("status: %+v (%v)", status, err)
+	}
+
+	// The dashboard status and the history report it
+	ws.UpdateWeather(&weather.Observation{Timestamp: time.Now().Add(-time.Minute).Unix()})
+	ws.UpdateWeather(&weather.Observation{Timestamp: time.Now().Unix()})
+	statusRec := httptest.NewRecorder()
+	ws.handleStatusAPI(statusRec, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	var resp StatusResponse
+	if err := json.NewDecoder(statusRec.Body).Decode(&resp); err != nil || resp.Maintenance == nil || resp.Maintenance.Active == nil {
+		t.Errorf("status maintenance: %+v (%v)", resp.Maintenance, err)
+	}
+	rec = do(http.MethodGet, "/api/history", "", false)
+	if gaps := rec.Header().Get("X-Maintenance-Gaps"); !strings.HasPrefix(gaps, "1") || !strings.Contains(gaps, "-") {
+		t.Errorf("X-Maintenance-Gaps = %q", gaps)
+	}
+
+	// End it early and cancel the scheduled window
+	if rec := do(http.MethodPost, "/api/maintenance/end", "", false); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the admin password, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/maintenance/end", "", true); rec.Code != http.StatusOK {
+		t.Errorf("end: got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/api/maintenance/end", "", true); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without maintenance in progress, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/maintenance/end", `{"id": 2}`, true); rec.Code != http.StatusOK {
+		t.Errorf("cancel: got %d: %s", rec.Code, rec.Body.String())
+	}
+	if s := scheduler.Status(); s.Active != nil || len(s.Scheduled) != 0 || len(s.Gaps) != 1 {
+		t.Errorf("after ending: %+v", s)
+	}
+}
@@ -0,0 +1,705 @@
+package web
+
+import (
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/annotations"
+	"tempest-homekit-go/pkg/corrections"
+	"tempest-homekit-go/pkg/maintenance"
+	"tempest-homekit-go/pkg/stats"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// apiParam documents a query parameter of an API route
+type apiParam struct {
+	Name        string
+	Description string
+	Type        string // OpenAPI scalar type, defaults to "string"
+	Required    bool
+}
+
+// apiRoute describes one REST endpoint. Every API route is registered through
+// handleRoute so the OpenAPI document served at /api/openapi.json always matches
+// the routes the server actually handles.
+type apiRoute struct {
+	Method      string
+	Path        string
+	Tag         string // weather, status, history, alarms, units, admin
+	Summary     string
+	Description string
+	Params      []apiParam
+	Request     interface{} // zero value of the JSON request body type, nil for none
+	Response    interface{} // zero value of the JSON response type, nil for a generic object
+	ContentType string      // response content type, defaults to application/json
+	Auth        bool        // requires HTTP basic auth
+}
+
+// handleRoute registers an API handler on the server mux and records the
+// route for the OpenAPI document
+func (ws *WebServer) handleRoute(route apiRoute, handler http.HandlerFunc) {
+	ws.mux.HandleFunc(route.Path, handler)
+	ws.documentRoute(route)
+}
+
+// documentRoute records a route served by a handler mounted elsewhere (for
+// example the alarm editor's sub-router)
+func (ws *WebServer) documentRoute(route apiRoute) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.routes = append(ws.routes, route)
+}
+
+// registerCoreRoutes registers the built-in REST API on the server mux
+func (ws *WebServer) registerCoreRoutes() {
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/weather", Tag: "weather",
+		Summary:  "Current weather observation with derived values",
+		Response: WeatherResponse{},
+	}, ws.handleWeatherAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/status", Tag: "status",
+		Summary:     "Service, HomeKit, and data source status",
+		Description: "`dataHistory` is streamed point by point, oldest first. Filtering it with `since` and `fields` keeps polling responses small; daily rain totals still count points left out by `since`.",
+		Params: []apiParam{
+			{Name: "since", Description: "Only history points after this time, as Unix seconds or RFC 3339"},
+			{Name: "fields", Description: "Comma-separated history point fields to include, e.g. temperature,lastUpdate (default: all)"},
+		},
+		Response: StatusResponse{},
+	}, withETag(ws.historyETag, ws.handleStatusAPI))
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/alarm-status", Tag: "alarms",
+		Summary:  "Configured alarms with cooldown state",
+		Response: AlarmStatusResponse{},
+	}, ws.handleAlarmStatusAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/alarms/test", Tag: "alarms",
+		Summary:     "Which alarms a synthetic observation would fire, with the message each channel would send",
+		Description: "Body: an observation with the Tempest field names in metric units, e.g. `{\"air_temperature\": 35.2, \"wind_gust\": 18}`; `timestamp` defaults to now. Change detection, sustained_for, cooldowns and schedules use the live alarm state, which is left unchanged. Nothing is sent and the history is not touched. Requires the admin password.",
+		Response:    AlarmTestResponse{},
+	}, ws.requireAdmin(ws.handleAlarmTestAPI))
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/alarms/acknowledge", Tag: "alarms",
+		Summary:     "Silence an alarm until its condition clears",
+		Description: "Body: `{\"name\": \"High Temperature\"}`. The alarm notifies again the next time its condition is met after clearing. Kept across restarts with the alarm state. Requires the admin password.",
+		Request:     AlarmControlRequest{},
+		Response:    AlarmControlResponse{},
+	}, ws.requireAdmin(ws.handleAlarmAcknowledgeAPI))
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/alarms/ack", Tag: "alarms",
+		Summary:     "Acknowledge an alarm notification from its {{ack_url}} link",
+		Description: "Silences the alarm until its condition clears and shows who saw it on the dashboard. Body: `{\"token\": \"<token>\", \"by\": \"Sam\"}`, or the `token` and `by` form fields; the token may instead be sent in the `X-Ack-Token` header. Only the latest notification of an alarm can be acknowledged. The token is the credential, so no password is needed, and it is never part of the URL, which request and proxy logs keep. HTML is returned unless JSON is sent or accepted.",
+		Request:     AlarmAckRequest{},
+		Response:    AlarmAckResponse{},
+	}, ws.handleAlarmAckAPI)
+	ws.documentRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/alarms/ack", Tag: "alarms",
+		Summary:     "Acknowledgement page with a button that posts the link's token",
+		Description: "`{{ack_url}}` links open this page with the token after `#`, which browsers do not send. Opening the link does not acknowledge, since mail scanners follow links.",
+		ContentType: "text/html",
+	})
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/alarms/ack/{token}", Tag: "alarms",
+		Summary:     "Redirect an acknowledgement link with the token in the path to the acknowledgement page",
+		Description: "For links in notifications sent by earlier versions. The token moves to the fragment; the request log records only the route.",
+		Params:      []apiParam{{Name: "token", Description: "Token from the link", Required: true}},
+		ContentType: "text/html",
+	}, ws.handleAlarmAckRedirect)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/alarms/ack/sms", Tag: "alarms",
+		Summary:     "Acknowledge an alarm notification from an SMS reply",
+		Description: "Point the Twilio number's incoming message webhook here. Replies are `ACK <code>` with the alert's {{ack_code}}; with a valid X-Twilio-Signature a bare `ACK` acknowledges the latest alert sent to the sender. Twilio gets a TwiML confirmation texted back. Other gateways can post `{\"from\": \"+15551234567\", \"body\": \"ACK K3QF7A\"}`.",
+		Request:     AlarmAckRequest{},
+		Response:    AlarmAckResponse{},
+	}, ws.handleAlarmAckSMSAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/alarms/snooze", Tag: "alarms",
+		Summary:     "Silence an alarm for a while",
+		Description: "Body: `{\"name\": \"High Temperature\", \"duration\": \"2h\"}` (at most 168h; `\"0\"` ends a snooze). Kept across restarts with the alarm state. Requires the admin password.",
+		Request:     AlarmControlRequest{},
+		Response:    AlarmControlResponse{},
+	}, ws.requireAdmin(ws.handleAlarmSnoozeAPI))
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/alarms/enable", Tag: "alarms",
+		Summary:     "Enable or disable an alarm",
+		Description: "Body: `{\"name\": \"High Temperature\", \"enabled\": false}`. An alarm file is saved with a backup, as the alarm editor does. Requires the admin password.",
+		Request:     AlarmControlRequest{},
+		Response:    AlarmControlResponse{},
+	}, ws.requireAdmin(ws.handleAlarmEnableAPI))
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/alarms/send-test", Tag: "alarms",
+		Summary:     "Send a test notification for an alarm",
+		Description: "Body: `{\"name\": \"High Temperature\", \"channel\": 0}`; without `channel` every channel of the alarm is used. Sends the latest observation, ignoring the condition, schedule, cooldown and snooze. Requires the admin password.",
+		Request:     AlarmControlRequest{},
+		Response:    AlarmControlResponse{},
+	}, ws.requireAdmin(ws.handleAlarmSendTestAPI))
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/maintenance", Tag: "status",
+		Summary:     "Active, scheduled and past maintenance windows",
+		Description: "While a window is active alarms are not evaluated and uploads do not run; both resume when it ends. Completed windows are kept as `gaps`, the 100 most recent.",
+		Response:    maintenance.Status{},
+	}, ws.handleMaintenanceAPI)
+	ws.documentRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/maintenance", Tag: "status",
+		Summary:     "Schedule a maintenance window",
+		Description: "Body: `{\"duration\": \"2h\", \"reason\": \"cleaning the rain gauge\"}` starts one now; `start` and `end` (RFC 3339) schedule one ahead. Windows may not overlap and last at most 7 days. Requires the admin password.",
+		Request:     MaintenanceRequest{},
+		Response:    maintenance.Window{},
+	})
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/maintenance/end", Tag: "status",
+		Summary:     "End maintenance early or cancel a scheduled window",
+		Description: "Body: `{\"id\": 3}`, or empty to end the active window. An ended window is kept as a gap; a cancelled one is dropped. Requires the admin password.",
+		Request:     MaintenanceEndRequest{},
+		Response:    maintenance.Window{},
+	}, ws.requireAdmin(ws.handleMaintenanceEndAPI))
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/annotations", Tag: "history",
+		Summary:     "Notes on time ranges of the history, oldest start first",
+		Description: "The charts draw them as markers and the chart exports include them. A note on a moment has `end` equal to `start`.",
+		Params: []apiParam{
+			{Name: "from", Description: "Only notes ending at or after this time, as Unix seconds or RFC 3339"},
+			{Name: "to", Description: "Only notes starting at or before this time, as Unix seconds or RFC 3339"},
+		},
+		Response: []annotations.Annotation{},
+	}, ws.handleAnnotationsAPI)
+	ws.documentRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/annotations", Tag: "history",
+		Summary:     "Add a note on a time range",
+		Description: "Body: `{\"start\": \"2025-06-01T14:00:00Z\", \"end\": \"2025-06-01T15:30:00Z\", \"text\": \"sprinkler hit sensor\"}`; without `end` the note marks the moment `start`. Text is limited to 500 characters. Requires the admin password.",
+		Request:     AnnotationRequest{},
+		Response:    annotations.Annotation{},
+	})
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPut, Path: "/api/annotations/{id}", Tag: "history",
+		Summary:     "Change a note",
+		Description: "Body as for POST /api/annotations; the range and text are replaced. Requires the admin password.",
+		Request:     AnnotationRequest{},
+		Response:    annotations.Annotation{},
+	}, ws.requireAdmin(ws.handleAnnotationAPI))
+	ws.documentRoute(apiRoute{
+		Method: http.MethodDelete, Path: "/api/annotations/{id}", Tag: "history",
+		Summary:     "Delete a note",
+		Description: "Requires the admin password.",
+	})
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/history", Tag: "history",
+		Summary:     "Observation history used by the charts, oldest first",
+		Description: "With `limit`, the `X-Next-Cursor` response header is set while later points remain; pass it back as `cursor` for the next page. `X-History-Revision` changes when an outage gap is backfilled, which makes earlier pages stale. `X-Maintenance-Gaps` lists the maintenance windows within the returned points as `start-end` Unix seconds, comma-separated.",
+		Params: []apiParam{
+			{Name: "since", Description: "Only points after this time, as Unix seconds or RFC 3339"},
+			{Name: "cursor", Description: "Opaque cursor from a previous X-Next-Cursor header"},
+			{Name: "limit", Type: "integer", Description: "Maximum points to return (default: all)"},
+		},
+		Response: []HistoryResponse{},
+	}, withETag(ws.historyETag, ws.handleHistoryAPI))
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/history/compare", Tag: "history",
+		Summary:     "The current day or week aligned with an earlier period",
+		Description: "Both series are bucketed from the start of their period (15 minutes for a day, an hour for a week) so `values[i]` line up. Periods older than the in-memory history are fetched from the WeatherFlow API, which returns coarser data for older ranges, and cached.",
+		Params: []apiParam{
+			{Name: "field", Description: "temperature (default), humidity, windSpeed, windGust, pressure, illuminance, uv, solarRadiation, or rain (cumulative)"},
+			{Name: "period", Description: "day or week (default, starting Monday) in the station time zone"},
+			{Name: "against", Description: "previous (default) or year; a year-ago week is 52 weeks earlier so weekdays line up"},
+		},
+		Response: CompareResponse{},
+	}, ws.handleHistoryCompareAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/forecast/hourly", Tag: "weather",
+		Summary:     "Hourly forecast from the current hour on",
+		Description: "The hourly periods of the latest WeatherFlow forecast that have not ended, in metric units (°C, m/s, mb, mm). `precip` is the forecast precipitation for the hour. Returns 503 until a forecast with hourly data has arrived; sources without a forecast (generated weather, --ingest) never have one.",
+		Params: []apiParam{
+			{Name: "hours", Type: "integer", Description: "Number of hours to return, starting with the current one (1-240, default: all)"},
+		},
+		Response: HourlyForecastResponse{},
+	}, ws.handleForecastHourlyAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/history/forecast", Tag: "history",
+		Summary:     "Hourly forecast issued for past hours, to chart against the observations",
+		Description: "Each hour keeps the temperature and chance of precipitation of the last forecast received before it began, for up to a week. Hours are kept from the forecasts received while the service runs, so they start when it did.",
+		Params: []apiParam{
+			{Name: "since", Description: "Only hours from this time, as Unix seconds or RFC 3339 (default: the last week)"},
+		},
+		Response: ForecastHistoryResponse{},
+	}, ws.handleForecastHistoryAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/history/corrections", Tag: "history",
+		Summary:     "Audit trail of deleted and overwritten observations, newest first",
+		Description: "Each entry keeps the reading before and after the correction, the reason and the address it came from. Corrections are replayed on history loaded later (preload, outage backfill), so a corrected reading does not come back.",
+		Params: []apiParam{
+			{Name: "limit", Type: "integer", Description: "Maximum entries to return (default: all)"},
+		},
+		Response: []corrections.Entry{},
+	}, ws.handleCorrectionsAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPut, Path: "/api/history/{timestamp}", Tag: "history",
+		Summary:     "Overwrite fields of a stored observation",
+		Description: "The path holds the observation's time as Unix seconds or RFC 3339. Body: `{\"fields\": {\"air_temperature\": 24.1}, \"reason\": \"station sat in a car\"}` with the observation's JSON field names. The correction is added to the audit trail and the daily statistics of the reading's day are recomputed when the history covers that day. Requires the admin password.",
+		Request:     CorrectionRequest{},
+		Response:    CorrectionResponse{},
+	}, ws.requireAdmin(ws.handleCorrectionAPI))
+	ws.documentRoute(apiRoute{
+		Method: http.MethodDelete, Path: "/api/history/{timestamp}", Tag: "history",
+		Summary:     "Delete a stored observation",
+		Description: "Optional body `{\"reason\": \"station sat in a car\"}`. Audited and recomputed as for PUT. Requires the admin password.",
+		Request:     CorrectionRequest{},
+		Response:    CorrectionResponse{},
+	})
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/windrose", Tag: "history",
+		Summary:     "Wind rose of the in-memory history",
+		Description: "Percent of readings per direction sector and speed class (m/s); calm readings below 0.5 m/s are counted separately.",
+		Params: []apiParam{
+			{Name: "hours", Type: "integer", Description: "Window in hours, default 24; 0 uses all history"},
+			{Name: "sectors", Type: "integer", Description: "Direction sectors: 8, 16 (default) or 36"},
+		},
+		Response: WindRoseResponse{},
+	}, ws.handleWindRoseAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/regenerate-weather", Tag: "admin",
+		Summary:     "Pick a new random location and season for generated weather",
+		Description: "Only available when running with generated weather.",
+		Response:    map[string]interface{}{},
+	}, ws.handleRegenerateWeatherAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/generate-weather", Tag: "weather",
+		Summary:     "Generated observation in WeatherFlow API format",
+		Description: "Only available when running with generated weather.",
+		Response:    map[string]interface{}{},
+	}, ws.handleGenerateWeatherAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/stats", Tag: "weather",
+		Summary:     "Daily statistics such as reference evapotranspiration (ET0)",
+		Description: "Days start at midnight in the station's time zone. `today` is a running estimate; completed days are kept for a week.",
+		Response:    stats.Summary{},
+	}, ws.handleStatsAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/anomalies", Tag: "weather",
+		Summary:     "Anomaly scores of the latest observation and recently flagged readings",
+		Description: "Scores are signed z-scores against the previous two hours of each field; readings at or beyond the threshold are kept in `recent`.",
+		Response:    stats.AnomalyReport{},
+	}, ws.handleAnomaliesAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/indoor", Tag: "weather",
+		Summary:     "Push an indoor temperature and humidity to the comfort advisor",
+		Description: "Body: `{\"temperature\": 23.5, \"humidity\": 58, \"unit\": \"C\"}` (`unit` C or F, default C). Returns the resulting advice. Readings older than 30 minutes are ignored.",
+		Request:     IndoorRequest{},
+		Response:    weather.ComfortAdvice{},
+	}, ws.handleIndoorAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/comfort", Tag: "weather",
+		Summary:     "Whether opening the windows would bring indoors toward the comfort range",
+		Description: "Compares the last /api/indoor reading with the latest observation: outdoor air is recommended when it cools, warms or dries the inside toward `--comfort-range` and `--comfort-humidity`, never when it is more humid, raining or gusting above 10 m/s. 503 unless the comfort advisor is running.",
+		Response:    weather.ComfortAdvice{},
+	}, ws.handleComfortAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/ingest", Tag: "weather",
+		Summary:     "Push observations from other hardware",
+		Description: "Requires `Authorization: Bearer <--ingest-token>` (or `?token=`). Accepts a WeatherFlow UDP `obs_st` message, a WeatherFlow device or station API response, or the generic format `{\"timestamp\": 1767225600, \"temperature\": 21.5, \"humidity\": 48, \"pressure\": 1012.3, \"wind_avg\": 2.1, \"wind_gust\": 4.0, \"wind_direction\": 225, \"rain\": 0.2}` (one object or an array; metric unless `\"units\": \"imperial\"`). Observations not newer than the last one are rejected. 503 unless started with --ingest.",
+		Request:     weather.IngestObservation{},
+		Response:    IngestResponse{},
+	}, ws.handleIngestAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/federation", Tag: "weather",
+		Summary:     "Latest reading of this station and every federated site",
+		Description: "Sites are polled through their /api/status every `--federate-interval`; a site is offline when its last poll failed or its newest observation is older than 15 minutes. Values are metric (°C, m/s, mb, mm). The same data is shown on the /sites page. 503 unless started with --federate.",
+		Response:    FederationResponse{},
+	}, ws.handleFederationAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/units", Tag: "units",
+		Summary:  "Display unit preferences",
+		Response: map[string]string{},
+	}, ws.handleUnitsAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/chart-config/{sensor}", Tag: "units",
+		Summary:     "Chart popout configuration of a sensor",
+		Description: "Datasets (role, label, Chart.js style), data field, unit labels and Y axes the /chart/{sensor} popout draws; the popout page embeds the same object. Each axis names the sensor and units of the datasets bound to it by `yAxisID`. `overlays` lists the sensors `?overlay=` can plot on a right-hand axis (y1), which adds an `overlay` dataset and its axis.",
+		Params: []apiParam{
+			{Name: "sensor", Description: "temperature, humidity, wind, rain, pressure, light or uv", Required: true},
+			{Name: "overlay", Description: "Second sensor to plot on the right-hand axis, one of `overlays`"},
+		},
+		Response: ChartConfig{},
+	}, ws.handleChartConfigAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/stream", Tag: "weather",
+		Summary:     "Server-Sent Events stream of observations and fired alarms",
+		Description: "Emits `observation`, `alarm`, and `dropped` events with keep-alive comments.",
+		Params: []apiParam{{
+			Name: "events", Description: "Comma separated event types to receive (observation, alarm)",
+		}},
+		ContentType: "text/event-stream",
+	}, ws.handleStreamAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/homekit/setup", Tag: "status",
+		Summary:     "HomeKit setup code and X-HM setup URI",
+		Description: "Returns 503 when HomeKit is disabled or the bridge is not running.",
+		Response:    HomeKitSetupResponse{},
+	}, ws.handleHomeKitSetupAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/homekit/pairings", Tag: "admin",
+		Summary:     "Controllers paired with the HomeKit bridge",
+		Description: "Requires the admin password (HTTP basic auth).",
+		Response:    HomeKitPairingsResponse{},
+	}, ws.requireAdmin(ws.handleHomeKitPairingsAPI))
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/homekit/pairings/remove", Tag: "admin",
+		Summary:     "Remove a HomeKit pairing",
+		Description: "Body: `{\"id\": \"<pairing id>\"}`. Removing the last admin controller removes all pairings. Requires the admin password.",
+		Response:    map[string]string{},
+	}, ws.requireAdmin(ws.handleHomeKitPairingRemoveAPI))
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/homekit/reset", Tag: "admin",
+		Summary:     "Reset HomeKit pairings and bridge identity (like --cleardb)",
+		Description: "Restarts the bridge without restarting the process. Requires the admin password.",
+		Response:    map[string]string{},
+	}, ws.requireAdmin(ws.handleHomeKitResetAPI))
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/sensors", Tag: "admin",
+		Summary:     "HomeKit sensors and hidden dashboard cards",
+		Description: "Requires the admin password (HTTP basic auth).",
+		Response:    SensorsResponse{},
+	}, ws.requireAdmin(ws.handleSensorsAPI))
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/sensors/update", Tag: "admin",
+		Summary:     "Enable or disable HomeKit sensors and dashboard cards",
+		Description: "Body: `{\"homekit\": [\"temp\", \"humidity\"], \"hidden_cards\": [\"uv\"]}`; either field may be left out. HomeKit is rebuilt with the new sensors, keeping its pairings, and both lists are saved to the env file as SENSORS and HIDDEN_CARDS. Requires the admin password.",
+		Response:    SensorsResponse{},
+	}, ws.requireAdmin(ws.handleSensorsUpdateAPI))
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/stations", Tag: "status",
+		Summary:     "Stations available to the WeatherFlow token",
+		Description: "Cached for 10 minutes; `?refresh=1` fetches the list again. 503 unless the WeatherFlow API is the data source.",
+		Response:    StationsResponse{},
+	}, ws.handleStationsAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodPost, Path: "/api/stations/select", Tag: "admin",
+		Summary:     "Switch the active station",
+		Description: "Body: `{\"id\": <station id>}`. Restarts the data source, clears the history of the previous station and updates HomeKit serials that use {station}. Requires the admin password.",
+		Response:    map[string]interface{}{},
+	}, ws.requireAdmin(ws.handleStationSelectAPI))
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/healthz", Tag: "status",
+		Summary:     "Liveness probe",
+		Description: "Returns 503 when a critical subsystem (data source, UDP listener, HomeKit server) is down.",
+		Response:    HealthResponse{},
+	}, ws.handleHealthz)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/readyz", Tag: "status",
+		Summary:     "Readiness probe",
+		Description: "Returns 503 until observations are arriving and every enabled subsystem is healthy.",
+		Response:    HealthResponse{},
+	}, ws.handleReadyz)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/requests", Tag: "status",
+		Summary:     "Request counters per endpoint and the last 100 requests",
+		Description: "Counts, status codes, bytes and latency per method and route since startup, for troubleshooting. The same counters are exported for Prometheus at `/metrics`. Recent requests list the matched pattern rather than the path when it has wildcards, and their client addresses; requires the admin password.",
+		Response:    RequestsResponse{},
+	}, ws.requireAdmin(ws.handleRequestsAPI))
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/udp/raw", Tag: "status",
+		Summary:     "Last UDP packets exactly as received from the hub",
+		Description: "For reporting parsing problems. Packets are kept only with `--udp-stream` and `--loglevel debug` (`--udp-raw-packets` of them); otherwise 404. `?download=1` returns the list as a file.",
+		Params:      []apiParam{{Name: "download", Description: "Set to save the response as a file"}},
+		Response:    UDPRawResponse{},
+	}, ws.handleUDPRawAPI)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/metrics", Tag: "status",
+		Summary:     "Request counters and UDP link statistics in the Prometheus text format",
+		ContentType: "text/plain",
+	}, ws.handleMetrics)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/openapi.json", Tag: "admin",
+		Summary:  "This OpenAPI document",
+		Response: map[string]interface{}{},
+	}, ws.handleOpenAPISpec)
+	ws.handleRoute(apiRoute{
+		Method: http.MethodGet, Path: "/api/docs", Tag: "admin",
+		Summary:     "Swagger UI for this API",
+		Description: "Served from the Swagger UI files vendored by `scripts/vendor-static.sh`; nothing is loaded from other sites.",
+		ContentType: "text/html",
+	}, ws.handleAPIDocs)
+}
+
+// documentAlarmEditorRoutes records the editor API mounted under prefix
+func (ws *WebServer) documentAlarmEditorRoutes(prefix string) {
+	routes := []struct {
+		method, path, summary string
+	}{
+		{http.MethodGet, "/api/config", "Current alarm configuration"},
+		{http.MethodPost, "/api/config/save", "Replace the alarm configuration"},
+		{http.MethodGet, "/api/alarms", "List alarms"},
+		{http.MethodPost, "/api/alarms/create", "Create an alarm"},
+		{http.MethodPost, "/api/alarms/update", "Update an alarm"},
+		{http.MethodPost, "/api/alarms/delete", "Delete an alarm"},
+		{http.MethodPost, "/api/alarms/validate", "Validate a condition: fields, units and error positions"},
+		{http.MethodPost, "/api/alarms/preview", "Render the message of every channel of an alarm without sending it"},
+		{http.MethodGet, "/api/tags", "List alarm tags"},
+		{http.MethodPost, "/api/tags/save", "Save alarm tags"},
+		{http.MethodPost, "/api/validate", "Validate a condition (alias of /api/alarms/validate)"},
+		{http.MethodPost, "/api/validate-json", "Validate an alarm configuration document"},
+		{http.MethodGet, "/api/fields", "Fields available in conditions"},
+		{http.MethodGet, "/api/env-defaults", "Notification defaults from the environment"},
+		{http.MethodGet, "/api/contacts", "List contacts"},
+		{http.MethodPost, "/api/contacts/save", "Save contacts"},
+		{http.MethodGet, "/api/versions", "List configuration backups"},
+		{http.MethodGet, "/api/versions/diff", "Diff a backup against the current configuration"},
+		{http.MethodPost, "/api/versions/rollback", "Restore a configuration backup"},
+	}
+	for _, r := range routes {
+		route := apiRoute{Method: r.method, Path: prefix + r.path, Tag: "admin", Summary: r.summary, Auth: true}
+		if strings.HasPrefix(r.path, "/api/versions/") {
+			route.Params = []apiParam{{Name: "id", Description: "Backup ID from /api/versions", Required: true}}
+		}
+		ws.documentRoute(route)
+	}
+}
+
+// OpenAPISpec builds the OpenAPI 3 document for the registered routes
+func (ws *WebServer) OpenAPISpec() map[string]interface{} {
+	ws.mu.RLock()
+	routes := make([]apiRoute, len(ws.routes))
+	copy(routes, ws.routes)
+	version := ws.version
+	ws.mu.RUnlock()
+
+	schemas := &schemaBuilder{components: map[string]interface{}{}}
+	paths := map[string]map[string]interface{}{}
+	needsAuth := false
+
+	for _, route := range routes {
+		op := map[string]interface{}{
+			"summary":     route.Summary,
+			"tags":        []string{route.Tag},
+			"operationId": operationID(route),
+		}
+		if route.Description != "" {
+			op["description"] = route.Description
+		}
+		if len(route.Params) > 0 {
+			params := make([]map[string]interface{}, 0, len(route.Params))
+			for _, p := range route.Params {
+				typ := p.Type
+				if typ == "" {
+					typ = "string"
+				}
+				in := "query"
+				if strings.Contains(route.Path, "{"+p.Name+"}") {
+					in = "path"
+				}
+				params = append(params, map[string]interface{}{
+					"name":        p.Name,
+					"in":          in,
+					"description": p.Description,
+					"required":    p.Required,
+					"schema":      map[string]interface{}{"type": typ},
+				})
+			}
+			op["parameters"] = params
+		}
+		if route.Request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemas.schemaFor(reflect.TypeOf(route.Request))},
+				},
+			}
+		}
+
+		contentType := route.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		var schema interface{} = map[string]interface{}{"type": "string"}
+		if contentType == "application/json" {
+			schema = map[string]interface{}{"type": "object"}
+			if route.Response != nil {
+				schema = schemas.schemaFor(reflect.TypeOf(route.Response))
+			}
+		}
+		responses := map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "OK",
+				"content":     map[string]interface{}{contentType: map[string]interface{}{"schema": schema}},
+			},
+		}
+		if route.Auth {
+			needsAuth = true
+			op["security"] = []map[string][]string{{"basicAuth": {}}}
+			responses["401"] = map[string]interface{}{"description": "Missing or wrong password"}
+		}
+		op["responses"] = responses
+
+		if paths[route.Path] == nil {
+			paths[route.Path] = map[string]interface{}{}
+		}
+		paths[route.Path][strings.ToLower(route.Method)] = op
+	}
+
+	components := map[string]interface{}{"schemas": schemas.components}
+	if needsAuth {
+		components["securitySchemes"] = map[string]interface{}{
+			"basicAuth": map[string]interface{}{"type": "http", "scheme": "basic"},
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Tempest HomeKit Bridge API",
+			"version":     version,
+			"description": "REST API of the Tempest HomeKit bridge web console.",
+		},
+		"paths":      paths,
+		"components": components,
+	}
+}
+
+// operationID derives a stable operation ID such as getApiAlarmStatus
+func operationID(route apiRoute) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(route.Method))
+	for _, part := range strings.FieldsFunc(route.Path, func(r rune) bool {
+		return r == '/' || r == '-' || r == '.' || r == '_' || r == '{' || r == '}'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// schemaBuilder converts Go types to OpenAPI schemas, collecting named structs
+// under components/schemas
+type schemaBuilder struct {
+	components map[string]interface{}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (b *schemaBuilder) schemaFor(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := t.Name()
+		if _, ok := b.components[name]; !ok {
+			b.components[name] = map[string]interface{}{} // placeholder guards against recursive types
+			b.components[name] = b.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]interface{}{} // interface{} and anything else: any value
+	}
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]interface{} {
+	props := map[string]interface{}{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = b.schemaFor(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			required = append(required, name)
+		}
+	}
+	schema := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// handleOpenAPISpec serves the OpenAPI document as JSON
+func (ws *WebServer) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(ws.OpenAPISpec())
+}
+
+// swaggerUIAssets are the Swagger UI files scripts/vendor-static.sh vendors
+// into pkg/web/static, checked against vendor.sha256 like Chart.js
+var swaggerUIAssets = []string{"swagger-ui-bundle.js", "swagger-ui.css"}
+
+// handleAPIDocs serves Swagger UI pointed at /api/openapi.json from the
+// vendored assets, so the page loads nothing from other sites. A build
+// without them links to the raw document instead.
+func (ws *WebServer) handleAPIDocs(w http.ResponseWriter, r *http.Request) {
+	vendored := true
+	for _, name := range swaggerUIAssets {
+		if _, err := fs.Stat(staticFiles, name); err != nil {
+			vendored = false
+		}
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := apiDocsPage.Execute(w, struct {
+		BasePath string
+		Vendored bool
+	}{ws.BasePath(), vendored}); err != nil {
+		ws.logError("Failed to render API docs: %v", err)
+	}
+}
+
+var apiDocsPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <title>Tempest HomeKit Bridge API</title>
+{{- if .Vendored}}
+    <link rel="stylesheet" href="{{.BasePath}}/pkg/web/static/swagger-ui.css">
+{{- end}}
+</head>
+<body>
+{{- if .Vendored}}
+    <div id="swagger-ui"></div>
+    <script src="{{.BasePath}}/pkg/web/static/swagger-ui-bundle.js"></script>
+    <script>
+        window.onload = function() {
+            window.ui = SwaggerUIBundle({ url: '{{.BasePath}}/api/openapi.json', dom_id: '#swagger-ui' });
+        };
+    </script>
+{{- else}}
+    <h1>Tempest HomeKit Bridge API</h1>
+    <p>This build does not include Swagger UI. Run <code>make vendor-static</code> and rebuild to add it, or read the OpenAPI document directly:
+    <a href="{{.BasePath}}/api/openapi.json">/api/openapi.json</a>.</p>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,110 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.MountAlarmEditor(http.NotFoundHandler(), "pw")
+
+	ts := httptest.NewServer(ws.server.Handler)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var spec struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas         map[string]interface{} `json:"schemas"`
+			SecuritySchemes map[string]interface{} `json:"securitySchemes"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatalf("invalid spec JSON: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("unexpected openapi version %q", spec.OpenAPI)
+	}
+
+	for path, method := range map[string]string{
+		"/api/weather":                    "get",
+		"/api/status":                     "get",
+		"/api/history":                    "get",
+		"/api/alarm-status":               "get",
+		"/api/units":                      "get",
+		"/api/regenerate-weather":         "post",
+		"/api/stream":                     "get",
+		"/alarm-editor/api/config/save":   "post",
+		"/alarm-editor/api/versions/diff": "get",
+	} {
+		if _, ok := spec.Paths[path][method]; !ok {
+			t.Errorf("spec is missing %s %s", strings.ToUpper(method), path)
+		}
+	}
+	if _, ok := spec.Paths["/alarm-editor/api/config"]["get"]["security"]; !ok {
+		t.Error("expected editor routes to require basic auth")
+	}
+	if spec.Components.SecuritySchemes["basicAuth"] == nil {
+		t.Error("expected basicAuth security scheme")
+	}
+
+	// Every schema reference must resolve
+	raw, _ := json.Marshal(spec.Paths)
+	for _, part := range strings.Split(string(raw), `"$ref":"#/components/schemas/`)[1:] {
+		name := part[:strings.Index(part, `"`)]
+		if spec.Components.Schemas[name] == nil {
+			t.Errorf("unresolved schema reference %s", name)
+		}
+	}
+	if spec.Components.Schemas["WeatherResponse"] == nil || spec.Components.Schemas["HistoryResponse"] == nil {
+		t.Error("expected response types to be generated as schemas")
+	}
+}
+
+func TestAPIDocsServesSwaggerUI(t *testing.T) {
+	saved := staticFiles
+	defer func() { staticFiles = saved }()
+	docs := func() string {
+		ws := testNewWebServer(t)
+		rr := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "/api/openapi.json") {
+			t.Errorf("unexpected docs response %d: %s", rr.Code, rr.Body.String())
+		}
+		if strings.Contains(rr.Body.String(), "https://") {
+			t.Errorf("docs load from another site: %s", rr.Body.String())
+		}
+		return rr.Body.String()
+	}
+
+	dir := t.TempDir()
+	SetStaticDir(dir)
+	if page := docs(); strings.Contains(page, "swagger-ui-bundle.js") {
+		t.Errorf("expected the fallback without vendored assets: %s", page)
+	}
+	for _, name := range swaggerUIAssets {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("/* vendored */"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if page := docs(); !strings.Contains(page, `src="/pkg/web/static/swagger-ui-bundle.js"`) {
+		t.Errorf("expected the vendored Swagger UI: %s", page)
+	}
+}
+
+func TestOperationID(t *testing.T) {
+	if got := operationID(apiRoute{Method: "GET", Path: "/api/alarm-status"}); got != "getApiAlarmStatus" {
+		t.Errorf("unexpected operation id %q", got)
+	}
+}
@@ -89,7 +89,8 @@ func TestPopoutDiagnostics(t *testing.T) {
 	if _, err := os.Stat(chartPath); err != nil {
 		t.Fatalf("chart.html not found: %v", err)
 	}
-	mux.HandleFunc("/chart/", func(w http.ResponseWriter, r *http.Request) { http.ServeFile(w, r, chartPath) })
+	mux.HandleFunc("/chart/", testChartPageHandler(t, chartPath))
+	mux.HandleFunc("/api/chart-config/{sensor}", ws.handleChartConfigAPI)
 
 	ts := httptest.NewServer(mux)
 	defer ts.Close()
@@ -86,11 +86,11 @@ func TestCalculateDailyRainForTimeAndAccumulation_Extra(t *testing.T) {
 	t2 := start.Add(6 * time.Hour)
 
 	ws := &WebServer{}
-	ws.dataHistory = []weather.Observation{
+	ws.history.Replace([]weather.Observation{
 		{Timestamp: start.Unix(), RainAccumulated: 1.0},
 		{Timestamp: t1.Unix(), RainAccumulated: 1.2},
 		{Timestamp: t2.Unix(), RainAccumulated: 1.5},
-	}
+	})
 
 	// Calculate for t2
 	got := ws.calculateDailyRainForTime(t2, start)
@@ -106,7 +106,7 @@ func TestCalculateDailyRainForTimeAndAccumulation_Extra(t *testing.T) {
 
 	// Single observation case: should return the single value if reasonable
 	ws2 := &WebServer{}
-	ws2.dataHistory = []weather.Observation{{Timestamp: start.Unix(), RainAccumulated: 0.8}}
+	ws2.history.Replace([]weather.Observation{{Timestamp: start.Unix(), RainAccumulated: 0.8}})
 	single := ws2.calculateDailyRainForTime(start.Add(1*time.Hour), start)
 	if math.Abs(single-0.8) > 1e-6 {
 		t.Fatalf("expected single-reading result 0.8, got %v", single)
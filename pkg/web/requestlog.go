@@ -0,0 +1,380 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/udp"
+)
+
+// recentRequests is how many requests /api/requests lists
+const recentRequests = 100
+
+// latencyBuckets are the upper bounds, in seconds, of the request duration
+// histogram exported at /metrics
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// RequestRecord is one answered request in /api/requests
+type RequestRecord struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Route      string    `json:"route"`
+	Status     int       `json:"status"`
+	DurationMs float64   `json:"durationMs"`
+	Bytes      int64     `json:"bytes"`
+	Remote     string    `json:"remote"`
+}
+
+// EndpointSummary aggregates the requests of one method and route
+type EndpointSummary struct {
+	Method       string           `json:"method"`
+	Route        string           `json:"route"`
+	Requests     int64            `json:"requests"`
+	Errors       int64            `json:"errors"` // 5xx responses
+	Status       map[string]int64 `json:"status"`
+	Bytes        int64            `json:"bytes"`
+	AvgLatencyMs float64          `json:"avgLatencyMs"`
+	MaxLatencyMs float64          `json:"maxLatencyMs"`
+}
+
+// RequestsResponse is the JSON body of /api/requests
+type RequestsResponse struct {
+	Since     time.Time         `json:"since"`
+	Endpoints []EndpointSummary `json:"endpoints"`
+	Recent    []RequestRecord   `json:"recent"` // newest first
+}
+
+type endpointKey struct {
+	method, route string
+}
+
+type endpointStats struct {
+	requests int64
+	status   map[int]int64
+	bytes    int64
+	latency  time.Duration
+	max      time.Duration
+	buckets  []int64 // cumulative counts per latencyBuckets entry
+}
+
+// requestLog keeps per-endpoint counters and the most recent requests. Routes
+// are the mux patterns requests matched, so the number of series stays fixed
+// however many distinct URLs clients send.
+type requestLog struct {
+	mu        sync.Mutex
+	since     time.Time
+	endpoints map[endpointKey]*endpointStats
+	recent    []RequestRecord // ring buffer, next is the oldest slot once full
+	next      int
+}
+
+func newRequestLog() *requestLog {
+	return &requestLog{since: time.Now(), endpoints: map[endpointKey]*endpointStats{}}
+}
+
+// record adds one answered request
+func (l *requestLog) record(rec RequestRecord, elapsed time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := endpointKey{rec.Method, rec.Route}
+	s := l.endpoints[key]
+	if s == nil {
+		s = &endpointStats{status: map[int]int64{}, buckets: make([]int64, len(latencyBuckets))}
+		l.endpoints[key] = s
+	}
+	s.requests++
+	s.status[rec.Status]++
+	s.bytes += rec.Bytes
+	s.latency += elapsed
+	s.max = max(s.max, elapsed)
+	for i, bound := range latencyBuckets {
+		if elapsed.Seconds() <= bound {
+			s.buckets[i]++
+		}
+	}
+
+	if len(l.recent) < recentRequests {
+		l.recent = append(l.recent, rec)
+		return
+	}
+	l.recent[l.next] = rec
+	l.next = (l.next + 1) % recentRequests
+}
+
+// sortedKeys returns the endpoint keys by route, then method. The caller
+// holds l.mu.
+func (l *requestLog) sortedKeys() []endpointKey {
+	keys := make([]endpointKey, 0, len(l.endpoints))
+	for k := range l.endpoints {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].method < keys[j].method
+	})
+	return keys
+}
+
+// summary returns the counters and recent requests for /api/requests
+func (l *requestLog) summary() RequestsResponse {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	resp := RequestsResponse{Since: l.since, Endpoints: []EndpointSummary{}, Recent: make([]RequestRecord, 0, len(l.recent))}
+	for _, k := range l.sortedKeys() {
+		s := l.endpoints[k]
+		e := EndpointSummary{
+			Method:       k.method,
+			Route:        k.route,
+			Requests:     s.requests,
+			Status:       map[string]int64{},
+			Bytes:        s.bytes,
+			AvgLatencyMs: float64(s.latency.Microseconds()) / 1000 / float64(s.requests),
+			MaxLatencyMs: float64(s.max.Microseconds()) / 1000,
+		}
+		for code, n := range s.status {
+			e.Status[strconv.Itoa(code)] = n
+			if code >= 500 {
+				e.Errors += n
+			}
+		}
+		resp.Endpoints = append(resp.Endpoints, e)
+	}
+	for i := range l.recent {
+		// Walk backwards from the newest entry
+		idx := (l.next - 1 - i + 2*len(l.recent)) % len(l.recent)
+		resp.Recent = append(resp.Recent, l.recent[idx])
+	}
+	return resp
+}
+
+// writeMetrics writes the counters in the Prometheus text exposition format
+func (l *requestLog) writeMetrics(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	keys := l.sortedKeys()
+
+	fmt.Fprintln(w, "# HELP tempest_http_requests_total HTTP requests answered by the web server.")
+	fmt.Fprintln(w, "# TYPE tempest_http_requests_total counter")
+	for _, k := range keys {
+		s := l.endpoints[k]
+		codes := make([]int, 0, len(s.status))
+		for code := range s.status {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Fprintf(w, "tempest_http_requests_total{method=%q,route=%q,code=\"%d\"} %d\n", k.method, k.route, code, s.status[code])
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP tempest_http_request_duration_seconds Time to answer HTTP requests.")
+	fmt.Fprintln(w, "# TYPE tempest_http_request_duration_seconds histogram")
+	for _, k := range keys {
+		s := l.endpoints[k]
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(w, "tempest_http_request_duration_seconds_bucket{method=%q,route=%q,le=\"%s\"} %d\n",
+				k.method, k.route, strconv.FormatFloat(bound, 'f', -1, 64), s.buckets[i])
+		}
+		fmt.Fprintf(w, "tempest_http_request_duration_seconds_bucket{method=%q,route=%q,le=\"+Inf\"} %d\n", k.method, k.route, s.requests)
+		fmt.Fprintf(w, "tempest_http_request_duration_seconds_sum{method=%q,route=%q} %g\n", k.method, k.route, s.latency.Seconds())
+		fmt.Fprintf(w, "tempest_http_request_duration_seconds_count{method=%q,route=%q} %d\n", k.method, k.route, s.requests)
+	}
+
+	fmt.Fprintln(w, "# HELP tempest_http_response_bytes_total Response body bytes sent by the web server.")
+	fmt.Fprintln(w, "# TYPE tempest_http_response_bytes_total counter")
+	for _, k := range keys {
+		fmt.Fprintf(w, "tempest_http_response_bytes_total{method=%q,route=%q} %d\n", k.method, k.route, l.endpoints[k].bytes)
+	}
+}
+
+// statusRecorder captures the status code and body size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(p)
+	s.bytes += int64(n)
+	return n, err
+}
+
+// Flush keeps /api/stream working through the recorder
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter { return s.ResponseWriter }
+
+// knownMethods bounds the method label; anything else is counted as OTHER
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// logRequests records the method, route, status, latency and size of every
+// request next answers and logs it at debug level
+func (ws *WebServer) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		elapsed := time.Since(start)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		// The mux stores the matched pattern on the request; requests it never
+		// saw (CORS preflights) or could not match are grouped together
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		method := r.Method
+		if !knownMethods[method] {
+			method = "OTHER"
+		}
+		ws.requests.record(RequestRecord{
+			Time:       start,
+			Method:     method,
+			Path:       loggedPath(r),
+			Route:      route,
+			Status:     rec.status,
+			DurationMs: float64(elapsed.Microseconds()) / 1000,
+			Bytes:      rec.bytes,
+			Remote:     r.RemoteAddr,
+		}, elapsed)
+		ws.logDebug("%s %s%s %d %s %dB from %s", r.Method, loggedPath(r), loggedQuery(r), rec.status, elapsed.Round(time.Microsecond), rec.bytes, r.RemoteAddr)
+	})
+}
+
+// loggedPath is the path recorded for r. Paths that matched a pattern with
+// wildcards are recorded as the pattern, since the values may be
+// credentials; anyone who can read the log must not learn them.
+func loggedPath(r *http.Request) string {
+	if !strings.Contains(r.Pattern, "{") {
+		return r.URL.Path
+	}
+	if i := strings.Index(r.Pattern, "/"); i > 0 {
+		return r.Pattern[i:] // drop a method or host
+	}
+	return r.Pattern
+}
+
+// loggedQuery is the query string of r for the debug log, with the values of
+// parameters that look like credentials (?token= of /api/ingest) redacted
+func loggedQuery(r *http.Request) string {
+	if r.URL.RawQuery == "" {
+		return ""
+	}
+	query := r.URL.Query()
+	for name := range query {
+		lower := strings.ToLower(name)
+		for _, secret := range []string{"token", "key", "pass", "secret", "signature"} {
+			if strings.Contains(lower, secret) {
+				query[name] = []string{"REDACTED"}
+				break
+			}
+		}
+	}
+	return "?" + query.Encode()
+}
+
+// handleRequestsAPI serves the per-endpoint request counters and the most
+// recent requests
+func (ws *WebServer) handleRequestsAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(ws.requests.summary()); err != nil {
+		ws.logError("Failed to encode request summary: %v", err)
+	}
+}
+
+// handleMetrics serves the request counters, and the UDP link statistics
+// when listening for UDP broadcasts, for Prometheus
+func (ws *WebServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	var b strings.Builder
+	ws.requests.writeMetrics(&b)
+	ws.mu.RLock()
+	listener := ws.udpListener
+	ws.mu.RUnlock()
+	if listener != nil {
+		writeUDPMetrics(&b, listener)
+	}
+	_, _ = io.WriteString(w, b.String())
+}
+
+// writeUDPMetrics writes the packet count, the observation loss and jitter
+// and the decoder counters of the UDP stream
+func writeUDPMetrics(w io.Writer, l *udp.UDPListener) {
+	packets, _, _, _ := l.GetStats()
+	link := l.GetLinkStats()
+	metrics := []struct {
+		name, help, kind string
+		value            float64
+	}{
+		{"tempest_udp_packets_total", "UDP packets received.", "counter", float64(packets)},
+		{"tempest_udp_observations_expected_total", "Observations expected from the report interval.", "counter", float64(link.Expected)},
+		{"tempest_udp_observations_received_total", "Observations received.", "counter", float64(link.Received)},
+		{"tempest_udp_observations_lost_total", "Observations missing between received ones.", "counter", float64(link.Lost)},
+		{"tempest_udp_observations_duplicate_total", "Observations received more than once or out of order.", "counter", float64(link.Duplicates)},
+		{"tempest_udp_loss_ratio", "Share of expected observations that were lost.", "gauge", link.LossPercent / 100},
+		{"tempest_udp_jitter_seconds", "Smoothed variation in delay between observations.", "gauge", link.JitterSeconds},
+	}
+	for _, m := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.kind)
+		fmt.Fprintf(w, "%s %g\n", m.name, m.value)
+	}
+
+	parser := l.GetParserStats()
+	fmt.Fprintln(w, "# HELP tempest_udp_decode_errors_total UDP messages that could not be decoded.")
+	fmt.Fprintln(w, "# TYPE tempest_udp_decode_errors_total counter")
+	fmt.Fprintf(w, "tempest_udp_decode_errors_total %d\n", parser.Errors)
+	for _, c := range []struct {
+		name, help, label string
+		counts            map[string]int64
+	}{
+		{"tempest_udp_messages_total", "UDP messages decoded, by type.", "type", parser.Messages},
+		{"tempest_udp_unknown_messages_total", "UDP messages of types the decoders do not know.", "type", parser.UnknownTypes},
+		{"tempest_udp_unknown_fields_total", "UDP message fields the decoders do not know.", "field", parser.UnknownFields},
+		{"tempest_udp_invalid_values_total", "Known UDP message fields that were not numbers.", "field", parser.InvalidValues},
+	} {
+		fmt.Fprintf(w, "# HELP %s %s\n", c.name, c.help)
+		fmt.Fprintf(w, "# TYPE %s counter\n", c.name)
+		keys := make([]string, 0, len(c.counts))
+		for k := range c.counts {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "%s{%s=%q} %d\n", c.name, c.label, k, c.counts[k])
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/udp"
+)
+
+func TestRequestLogAndMetrics(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.SetAdminPassword("secret")
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		if path == "/api/requests" {
+			req.SetBasicAuth("admin", "secret")
+		}
+		ws.server.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+	serve(http.MethodGet, "/api/units")
+	serve(http.MethodGet, "/api/units?x=1")
+	serve(http.MethodGet, "/api/chart-config/temperature")
+	serve(http.MethodGet, "/api/chart-config/nope")
+	serve("BREW", "/api/units")
+
+	rec := serve(http.MethodGet, "/api/requests")
+	var summary RequestsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Recent) != 5 || summary.Recent[0].Path != "/api/units" || summary.Recent[0].Method != "OTHER" {
+		t.Fatalf("recent requests, newest first: %+v", summary.Recent)
+	}
+	// Wildcard values are not recorded
+	if path := summary.Recent[1].Path; path != "/api/chart-config/{sensor}" {
+		t.Errorf("expected the pattern for a wildcard path, got %s", path)
+	}
+	anonymous := httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(anonymous, httptest.NewRequest(http.MethodGet, "/api/requests", nil))
+	if anonymous.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the admin password, got %d", anonymous.Code)
+	}
+	find := func(method, route string) EndpointSummary {
+		for _, e := range summary.Endpoints {
+			if e.Method == method && e.Route == route {
+				return e
+			}
+		}
+		t.Fatalf("no endpoint %s %s in %+v", method, route, summary.Endpoints)
+		return EndpointSummary{}
+	}
+	if e := find(http.MethodGet, "/api/units"); e.Requests != 2 || e.Status["200"] != 2 || e.Bytes == 0 {
+		t.Errorf("units: %+v", e)
+	}
+	// Both sensors share the pattern they matched
+	if e := find(http.MethodGet, "/api/chart-config/{sensor}"); e.Requests != 2 || e.Status["404"] != 1 {
+		t.Errorf("chart config: %+v", e)
+	}
+
+	metrics := serve(http.MethodGet, "/metrics").Body.String()
+	for _, want := range []string{
+		`tempest_http_requests_total{method="GET",route="/api/units",code="200"} 2`,
+		`tempest_http_request_duration_seconds_bucket{method="GET",route="/api/units",le="+Inf"} 2`,
+		`tempest_http_requests_total{method="GET",route="/api/requests",code="200"} 1`,
+		"# TYPE tempest_http_response_bytes_total counter",
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("metrics missing %s:\n%s", want, metrics)
+		}
+	}
+}
+
+func TestLoggedQueryRedactsCredentials(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/ingest?token=abcdef&station=Home&PASSKEY=1", nil)
+	if got := loggedQuery(r); got != "?PASSKEY=REDACTED&station=Home&token=REDACTED" {
+		t.Errorf("loggedQuery = %q", got)
+	}
+}
+
+func TestRequestLogRing(t *testing.T) {
+	l := newRequestLog()
+	for i := 0; i < recentRequests+5; i++ {
+		l.record(RequestRecord{Method: http.MethodGet, Route: "/", Status: 200, Bytes: int64(i)}, 0)
+	}
+	s := l.summary()
+	if len(s.Recent) != recentRequests || s.Recent[0].Bytes != recentRequests+4 || s.Recent[recentRequests-1].Bytes != 5 {
+		t.Errorf("ring: %d records, newest %d, oldest %d", len(s.Recent), s.Recent[0].Bytes, s.Recent[len(s.Recent)-1].Bytes)
+	}
+}
+
+func TestMetricsIncludeUDPListener(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.SetUDPListener(udp.NewUDPListener(10))
+	rec := httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		"# TYPE tempest_udp_observations_lost_total counter",
+		"tempest_udp_loss_ratio 0",
+		"tempest_udp_jitter_seconds 0",
+		"tempest_udp_decode_errors_total 0",
+		"# TYPE tempest_udp_unknown_fields_total counter",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics missing %s:\n%s", want, rec.Body.String())
+		}
+	}
+}
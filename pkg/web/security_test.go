@@ -0,0 +1,38 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/websec"
+)
+
+func TestSecurityHeadersAndCORS(t *testing.T) {
+	ws := testNewWebServer(t)
+	get := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/history", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("https://ha.local:8123")
+	if rec.Header().Get("Content-Security-Policy") == "" || rec.Header().Get("X-Frame-Options") != "DENY" {
+		t.Errorf("missing security headers: %v", rec.Header())
+	}
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("CORS allowed without configured origins")
+	}
+
+	ws.SetSecurityPolicy(websec.Policy{CORSOrigins: []string{"https://ha.local:8123"}})
+	rec = get("https://ha.local:8123")
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://ha.local:8123" || !strings.Contains(rec.Header().Get("Access-Control-Expose-Headers"), "ETag") {
+		t.Errorf("configured origin: %v", rec.Header())
+	}
+	if rec := get("https://other.example"); rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("unlisted origin allowed")
+	}
+}
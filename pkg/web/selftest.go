@@ -0,0 +1,55 @@
+package web
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// SelfTestPaths are the pages and endpoints SelfTest requests by default
+var SelfTestPaths = []string{
+	"/", "/api/weather", "/api/status", "/api/history", "/api/units", "/api/alarm-status",
+	"/api/windrose", "/api/chart-config/temperature", "/api/openapi.json", "/healthz", "/metrics",
+	"/pkg/web/static/script.js",
+}
+
+// EndpointCheck is the outcome of one SelfTest request
+type EndpointCheck struct {
+	Path    string
+	Status  int
+	Bytes   int64
+	Latency time.Duration
+	Err     error // transport error or a status other than 200
+}
+
+// SelfTest serves the dashboard and API on a loopback port, with a history of
+// synthetic observations like Benchmark, and requests each path once
+func SelfTest(paths []string) ([]EndpointCheck, error) {
+	ws := NewWebServer("0", 0, "error", 0, false, "selftest", "", &GeneratedWeatherInfo{}, nil, "metric", "mb", 100, 0, "", true)
+	ws.SetStationName("Self Test Station")
+	for _, obs := range syntheticHistory(ws.maxHistorySize, time.Now()) {
+		ws.UpdateWeather(obs)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{Handler: ws.server.Handler}
+	go func() { _ = srv.Serve(ln) }()
+	defer func() { _ = srv.Close() }()
+	base := "http://" + ln.Addr().String()
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	checks := make([]EndpointCheck, 0, len(paths))
+	for _, path := range paths {
+		start := time.Now()
+		n, status, err := benchGet(client, base+path)
+		if err == nil && status != http.StatusOK {
+			err = fmt.Errorf("HTTP %d", status)
+		}
+		checks = append(checks, EndpointCheck{Path: path, Status: status, Bytes: n, Latency: time.Since(start), Err: err})
+	}
+	return checks, nil
+}
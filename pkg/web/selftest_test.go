@@ -0,0 +1,18 @@
+package web
+
+import "testing"
+
+func TestSelfTest(t *testing.T) {
+	checks, err := SelfTest(append(append([]string{}, SelfTestPaths...), "/api/missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range checks[:len(checks)-1] {
+		if c.Err != nil {
+			t.Errorf("%s: %v", c.Path, c.Err)
+		}
+	}
+	if last := checks[len(checks)-1]; last.Err == nil || last.Status != 404 {
+		t.Errorf("missing endpoint reported as %+v", last)
+	}
+}
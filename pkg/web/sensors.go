@@ -0,0 +1,147 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"tempest-homekit-go/pkg/config"
+	"tempest-homekit-go/pkg/logger"
+)
+
+// SensorsResponse is returned by /api/sensors
+type SensorsResponse struct {
+	HomeKit          []string `json:"homekit"`           // enabled --sensors names
+	HomeKitAvailable []string `json:"homekit_available"` // every --sensors name
+	HiddenCards      []string `json:"hidden_cards"`
+	Cards            []string `json:"cards"` // dashboard cards that can be hidden
+}
+
+// SensorsRequest changes the HomeKit sensors, the hidden dashboard cards or
+// both; a missing field is left as it is
+type SensorsRequest struct {
+	HomeKit     *[]string `json:"homekit,omitempty"`
+	HiddenCards *[]string `json:"hidden_cards,omitempty"`
+}
+
+// SensorManager changes the published HomeKit sensors and saves sensor and
+// card choices to the configuration, so they survive a restart
+type SensorManager interface {
+	// HomeKitSensors returns the enabled --sensors names
+	HomeKitSensors() []string
+	SetHomeKitSensors(names []string) error
+	SaveHiddenCards(cards []string) error
+}
+
+// SetSensorManager enables /api/sensors and the dashboard sensor toggles
+func (ws *WebServer) SetSensorManager(m SensorManager) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.sensorManager = m
+}
+
+// SetHiddenCards sets the dashboard cards rendered hidden
+func (ws *WebServer) SetHiddenCards(cards []string) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.hiddenCards = append([]string{}, cards...)
+}
+
+// HiddenCards returns the dashboard cards rendered hidden
+func (ws *WebServer) HiddenCards() []string {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	return append([]string{}, ws.hiddenCards...)
+}
+
+func (ws *WebServer) sensorSettings(w http.ResponseWriter) SensorManager {
+	ws.mu.RLock()
+	m := ws.sensorManager
+	ws.mu.RUnlock()
+	if m == nil {
+		http.Error(w, "Sensor settings are not available", http.StatusServiceUnavailable)
+	}
+	return m
+}
+
+func (ws *WebServer) writeSensors(w http.ResponseWriter, m SensorManager) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(SensorsResponse{
+		HomeKit:          m.HomeKitSensors(),
+		HomeKitAvailable: config.SensorNames,
+		HiddenCards:      ws.HiddenCards(),
+		Cards:            config.HideableCards,
+	})
+}
+
+// handleSensorsAPI returns the HomeKit sensors and hidden dashboard cards
+func (ws *WebServer) handleSensorsAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	m := ws.sensorSettings(w)
+	if m == nil {
+		return
+	}
+	ws.writeSensors(w, m)
+}
+
+// handleSensorsUpdateAPI applies and saves a SensorsRequest
+func (ws *WebServer) handleSensorsUpdateAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req SensorsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.HomeKit == nil && req.HiddenCards == nil) {
+		http.Error(w, "Request body must be {\"homekit\": [...], \"hidden_cards\": [...]}", http.StatusBadRequest)
+		return
+	}
+	var cards []string
+	if req.HomeKit != nil {
+		if len(*req.HomeKit) == 0 {
+			http.Error(w, "Enable at least one HomeKit sensor", http.StatusBadRequest)
+			return
+		}
+		for _, name := range *req.HomeKit {
+			if !slices.Contains(config.SensorNames, name) {
+				http.Error(w, "Unknown sensor "+name, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if req.HiddenCards != nil {
+		for _, card := range *req.HiddenCards {
+			if !slices.Contains(config.HideableCards, card) {
+				http.Error(w, "Unknown dashboard card "+card, http.StatusBadRequest)
+				return
+			}
+		}
+		cards = slices.Compact(slices.Sorted(slices.Values(*req.HiddenCards)))
+	}
+	m := ws.sensorSettings(w)
+	if m == nil {
+		return
+	}
+
+	if req.HomeKit != nil {
+		if err := m.SetHomeKitSensors(*req.HomeKit); err != nil {
+			logger.Error("Failed to change HomeKit sensors: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logger.Info("HomeKit sensors changed via web console from %s", r.RemoteAddr)
+	}
+	if req.HiddenCards != nil {
+		ws.SetHiddenCards(cards)
+		if err := m.SaveHiddenCards(cards); err != nil {
+			logger.Error("Failed to save hidden dashboard cards: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logger.Info("Hidden dashboard cards changed via web console from %s", r.RemoteAddr)
+	}
+	ws.writeSensors(w, m)
+}
@@ -0,0 +1,86 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type fakeSensorManager struct {
+	sensors []string
+	cards   []string
+}
+
+func (m *fakeSensorManager) HomeKitSensors() []string { return m.sensors }
+
+func (m *fakeSensorManager) SetHomeKitSensors(names []string) error {
+	m.sensors = names
+	return nil
+}
+
+func (m *fakeSensorManager) SaveHiddenCards(cards []string) error {
+	m.cards = cards
+	return nil
+}
+
+func TestSensorsAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.SetAdminPassword("secret")
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.SetBasicAuth("admin", "secret")
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/api/sensors", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without a sensor manager, got %d", rec.Code)
+	}
+	manager := &fakeSensorManager{sensors: []string{"temp"}}
+	ws.SetSensorManager(manager)
+
+	for _, body := range []string{`{}`, `{"homekit":[]}`, `{"homekit":["dewpoint"]}`, `{"hidden_cards":["homekit"]}`} {
+		if rec := do(http.MethodPost, "/api/sensors/update", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rec.Code)
+		}
+	}
+
+	rec := do(http.MethodPost, "/api/sensors/update", `{"homekit":["temp","uv"],"hidden_cards":["uv","forecast","uv"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp SensorsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode /api/sensors response: %v", err)
+	}
+	if !slices.Equal(resp.HomeKit, []string{"temp", "uv"}) || !slices.Equal(resp.HiddenCards, []string{"forecast", "uv"}) {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if !slices.Equal(manager.cards, []string{"forecast", "uv"}) {
+		t.Errorf("hidden cards saved = %v", manager.cards)
+	}
+
+	// Only the hidden cards change; the sensors stay
+	if rec := do(http.MethodPost, "/api/sensors/update", `{"hidden_cards":[]}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if len(ws.HiddenCards()) != 0 || !slices.Equal(manager.sensors, []string{"temp", "uv"}) {
+		t.Errorf("hidden cards %v, sensors %v", ws.HiddenCards(), manager.sensors)
+	}
+}
+
+func TestDashboardHiddenCards(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.SetHiddenCards([]string{"uv"})
+	var page strings.Builder
+	if err := ws.renderDashboard(&page); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(page.String(), `class="card hidden" id="uv-card"`) || !strings.Contains(page.String(), `class="card" id="temperature-card"`) {
+		t.Error("hidden card not rendered with the hidden class")
+	}
+}
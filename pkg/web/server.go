@@ -3,21 +3,30 @@
 package web
 
 import (
+	"bytes"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"math"
+	"net"
 	"net/http"
-	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/annotations"
+	"tempest-homekit-go/pkg/corrections"
+	"tempest-homekit-go/pkg/federation"
 	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/maintenance"
+	"tempest-homekit-go/pkg/stats"
 	"time"
 
 	"tempest-homekit-go/pkg/generator"
 	"tempest-homekit-go/pkg/udp"
 	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/websec"
 )
 
 // AlarmManagerInterface defines the methods we need from the alarm manager
@@ -37,14 +46,20 @@ type WebServer struct {
 	server                 *http.Server
 	weatherData            *weather.Observation
 	forecastData           *weather.ForecastResponse
+	forecastHours          map[int64]ForecastHour   // hourly forecasts by hour start, see recordForecastHoursLocked
+	forecastHourly         []weather.ForecastPeriod // hourly periods of the latest forecast, for /api/forecast/hourly
+	forecastReceived       time.Time                // when the latest hourly forecast arrived
 	homekitStatus          map[string]interface{}
-	dataHistory            []weather.Observation
+	history                historyRing // observation history ring, read without ws.mu
 	maxHistorySize         int
 	chartHistoryHours      int // hours of data to show in charts (0 = all)
 	stationName            string
 	stationURL             string                // station URL for weather data
 	stationID              int                   // station ID for TempestWX status scraping
 	elevation              float64               // elevation in meters
+	timezone               string                // station IANA time zone, empty until known
+	rainCorrection         float64               // multiplier the service applied to rain values (0 or 1: none)
+	location               *time.Location        // day boundaries for daily totals; nil means server local time
 	units                  string                // units system: imperial, metric, or sae
 	unitsPressure          string                // pressure units: inHg or mb
 	logLevel               string                // log level for filtering debug messages
@@ -66,6 +81,33 @@ type WebServer struct {
 	version          string                    // application version
 	udpListener      *udp.UDPListener          // UDP listener for local station monitoring
 	dataSourceStatus *weather.DataSourceStatus // Unified data source status
+	mux              *http.ServeMux            // route table, kept so optional handlers can be mounted later
+	alarmEditorURL   string                    // path of the integrated alarm editor, empty when not mounted
+	basePath         string                    // URL prefix a reverse proxy serves the console under, empty at the root
+	stream           *streamHub                // live event fan-out for /api/stream
+	routes           []apiRoute                // registered API routes, the source of the OpenAPI document
+	healthChecks     []healthCheck             // subsystem checks registered for /healthz and /readyz
+	watchdog         map[string]WatchdogInfo   // restart state of supervised components, by component
+	tokenStatus      *TokenStatus              // last WeatherFlow token check, nil when not checked
+	historyRevision  int                       // bumped when backfilled observations change past history
+	pairingManager   HomeKitPairingManager     // nil when HomeKit is disabled
+	stationManager   StationManager            // nil unless the WeatherFlow API is the data source
+	sensorManager    SensorManager             // /api/sensors, nil until the service sets it
+	hiddenCards      []string                  // dashboard cards rendered hidden, see SetHiddenCards
+	statsEngine      *stats.Engine             // daily statistics for /api/stats, nil until the service sets it
+	anomalyDetector  *stats.AnomalyDetector    // flagged readings for /api/anomalies, nil until the service sets it
+	comfortAdvisor   *weather.ComfortAdvisor   // /api/indoor and /api/comfort, nil until the service sets it
+	ingestor         Ingestor                  // /api/ingest, nil unless started with --ingest
+	federation       *federation.Federation    // /api/federation and /sites, nil unless started with --federate
+	ingestToken      string                    // bearer token /api/ingest requires
+	historyFetcher   HistoryFetcher            // older periods for /api/history/compare, nil without the WeatherFlow API
+	maintenance      *maintenance.Scheduler    // maintenance windows, nil when not managed
+	annotations      *annotations.Store        // notes on time ranges for /api/annotations, nil until the service sets it
+	corrections      *corrections.Log          // deleted and overwritten readings, replayed on history loaded later
+	compareCache     compareCache              // ranges fetched by historyFetcher
+	adminPassword    string                    // admin endpoints are disabled when empty
+	requests         *requestLog               // per-endpoint counters for /metrics and /api/requests
+	limits           websec.Limits             // timeouts and connection cap applied by Start
 	mu               sync.RWMutex
 }
 
@@ -111,7 +153,12 @@ type WeatherResponse struct {
 	RainAccum            float64           `json:"rainAccum"`
 	RainRate             float64           `json:"rainRate"` // Rain intensity in mm/hr
 	RainDailyTotal       float64           `json:"rainDailyTotal"`
+	RainDailyTotalRaw    float64           `json:"rainDailyTotalRaw"`          // Daily total before --rain-correction
+	RainDailyTotalNC     float64           `json:"rainDailyTotalNC,omitempty"` // WeatherFlow Rain Check daily total
+	RainCorrection       float64           `json:"rainCorrection,omitempty"`   // Multiplier applied to the rain values, when not 1
 	PrecipitationType    int               `json:"precipitationType"`
+	SnowRate             float64           `json:"snowRate"`  // Estimated snowfall in mm/hr
+	SnowDaily            float64           `json:"snowDaily"` // Estimated snowfall since 00:00 (mm)
 	Pressure             float64           `json:"pressure"`
 	SeaLevelPressure     float64           `json:"seaLevelPressure"`
 	PressureCondition    string            `json:"pressure_condition"`
@@ -122,6 +169,12 @@ type WeatherResponse struct {
 	Battery              float64           `json:"battery"`
 	LightningStrikeAvg   float64           `json:"lightningStrikeAvg"`
 	LightningStrikeCount int               `json:"lightningStrikeCount"`
+	LightningNearest     float64           `json:"lightningNearest"`           // Nearest strike of the current storm (km), 0 without a storm
+	LightningRate        float64           `json:"lightningRate"`              // Strikes per minute over the last 10 minutes
+	PeakGustToday        float64           `json:"peakGustToday,omitempty"`    // Strongest gust since midnight, including rapid_wind samples
+	PeakGustTime         string            `json:"peakGustTime,omitempty"`     // When PeakGustToday was measured, RFC 3339
+	SolarEnergyToday     float64           `json:"solarEnergyToday,omitempty"` // Solar energy received since midnight, Wh/m²
+	UVDoseToday          float64           `json:"uvDoseToday,omitempty"`      // UV dose received since midnight, SED
 	LastUpdate           string            `json:"lastUpdate"`
 	UnitHints            map[string]string `json:"unitHints,omitempty"`
 	ObservationCount     int               `json:"observationCount,omitempty"`
@@ -153,7 +206,32 @@ type StatusResponse struct {
 	UDPStatus         *UDPStatusInfo            `json:"udpStatus,omitempty"`
 	DataSource        *weather.DataSourceStatus `json:"dataSource,omitempty"` // Unified data source status
 	UnitHints         map[string]string         `json:"unitHints,omitempty"`
-	ChartHistoryHours int                       `json:"chartHistoryHours"` // Hours of data to display in charts (0=all)
+	ChartHistoryHours int                       `json:"chartHistoryHours"`     // Hours of data to display in charts (0=all)
+	Watchdog          []WatchdogInfo            `json:"watchdog,omitempty"`    // Components restarted by the service watchdog
+	TokenStatus       *TokenStatus              `json:"tokenStatus,omitempty"` // Last WeatherFlow token check
+	APICalls          []weather.EndpointStats   `json:"apiCalls,omitempty"`    // WeatherFlow requests per endpoint
+	HistoryRevision   int                       `json:"historyRevision"`       // Changes when past dataHistory changes (backfill, preload, station switch)
+	Timezone          string                    `json:"timezone,omitempty"`    // Station time zone that daily totals reset in
+	Maintenance       *maintenance.Status       `json:"maintenance,omitempty"` // Active, scheduled and past maintenance windows
+}
+
+// TokenStatus reports the last check of the WeatherFlow API token
+type TokenStatus struct {
+	State      string `json:"state"` // valid, invalid, rate_limited or unreachable
+	Message    string `json:"message,omitempty"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	CheckedAt  string `json:"checkedAt"`
+	LastValid  string `json:"lastValid,omitempty"` // when the token was last accepted
+}
+
+// WatchdogInfo reports automatic restarts of a supervised component
+type WatchdogInfo struct {
+	Component   string `json:"component"`
+	Restarts    int    `json:"restarts"`
+	LastRestart string `json:"lastRestart,omitempty"`
+	LastReason  string `json:"lastReason,omitempty"`
+	LastError   string `json:"lastError,omitempty"`
+	Stalled     bool   `json:"stalled"`
 }
 
 // UDPStatusInfo contains information about UDP stream status
@@ -196,26 +274,26 @@ func (ws *WebServer) calculateDailyRainAccumulation() float64 {
 		return dailyTotal
 	}
 
-	if len(ws.dataHistory) == 0 {
+	history := ws.history.Snapshot()
+	if len(history) == 0 {
 		ws.logDebug("No data history available for daily rain calculation")
 		return 0.0
 	}
 
-	// Get the start of the current local day
-	now := time.Now()
-	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	// Get the start of the current day in the station's time zone
+	todayStart := startOfDay(time.Now(), ws.location)
 
 	// Find observations from today
 	var dailyObservations []weather.Observation
-	for _, obs := range ws.dataHistory {
+	for _, obs := range history {
 		obsTime := time.Unix(obs.Timestamp, 0)
-		if obsTime.After(startOfDay) || obsTime.Equal(startOfDay) {
+		if obsTime.After(todayStart) || obsTime.Equal(todayStart) {
 			dailyObservations = append(dailyObservations, obs)
 		}
 	}
 
 	ws.logDebug("Daily rain calculation - Total history: %d, Today's observations: %d, Start of day: %s",
-		len(ws.dataHistory), len(dailyObservations), startOfDay.Format("2006-01-02 15:04:05"))
+		len(history), len(dailyObservations), todayStart.Format("2006-01-02 15:04:05"))
 
 	if len(dailyObservations) == 0 {
 		ws.logDebug("No observations found for today")
@@ -268,7 +346,7 @@ func (ws *WebServer) calculateDailyRainAccumulation() float64 {
 func (ws *WebServer) calculateDailyRainForTime(targetTime time.Time, startOfDay time.Time) float64 {
 	// Find observations from the start of the day up to the target time
 	var dayObservations []weather.Observation
-	for _, obs := range ws.dataHistory {
+	for _, obs := range ws.history.Snapshot() {
 		obsTime := time.Unix(obs.Timestamp, 0)
 		if (obsTime.After(startOfDay) || obsTime.Equal(startOfDay)) && !obsTime.After(targetTime) {
 			dayObservations = append(dayObservations, obs)
@@ -402,7 +480,7 @@ func NewWebServer(port string, elevation float64, logLevel string, stationID int
 		stationID:         stationID,
 		maxHistorySize:    historyPoints,
 		chartHistoryHours: chartHistoryHours,
-		dataHistory:       make([]weather.Observation, 0, historyPoints),
+		history:           historyRing{max: historyPoints},
 		startTime:         time.Now(),
 		version:           version,
 		stationURL:        stationURL,
@@ -412,30 +490,32 @@ func NewWebServer(port string, elevation float64, logLevel string, stationID int
 		unitsPressure:     unitsPressure,
 		alarmConfig:       alarmConfig,
 		disableAlarms:     disableAlarms,
+		stream:            newStreamHub(),
 		homekitStatus: map[string]interface{}{
 			"bridge":      false,
 			"accessories": 0,
-			"pin":         "00102003",
 		},
 	}
 
+	// Preallocate the first history segment
+	ws.history.Reset()
+
 	// Initialize status manager
 	ws.statusManager = weather.NewStatusManager(stationID, logLevel, useWebStatus)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", ws.handleDashboard)
-	mux.HandleFunc("/api/weather", ws.handleWeatherAPI)
-	mux.HandleFunc("/api/status", ws.handleStatusAPI)
-	mux.HandleFunc("/api/alarm-status", ws.handleAlarmStatusAPI)
-	mux.HandleFunc("/api/history", ws.handleHistoryAPI)
 	mux.HandleFunc("/chart/", ws.handleChartPage)
-	mux.HandleFunc("/api/regenerate-weather", ws.handleRegenerateWeatherAPI)
-	mux.HandleFunc("/api/generate-weather", ws.handleGenerateWeatherAPI)
-	mux.HandleFunc("/api/units", ws.handleUnitsAPI)
+	mux.HandleFunc("/console", ws.handleConsole)
+	mux.HandleFunc("/sites", ws.handleSitesPage)
+	ws.mux = mux
+	ws.requests = newRequestLog()
+	ws.limits = websec.DefaultLimits
+	ws.registerCoreRoutes()
 
 	ws.server = &http.Server{
 		Addr:    ":" + port,
-		Handler: mux,
+		Handler: ws.logRequests(websec.Wrap(websec.Policy{ExposeHeaders: exposedHeaders}, mux)),
 	}
 
 	// ensure logError is considered used by analyzers: take method value here
@@ -451,8 +531,11 @@ func (ws *WebServer) Start() error {
 	// Start status manager for periodic scraping
 	ws.statusManager.Start()
 
-	ws.logInfo("Web server calling ListenAndServe on :%s", ws.port)
-	err := ws.server.ListenAndServe()
+	ws.logInfo("Web server calling ListenAndServe on %s", ws.server.Addr)
+	ws.mu.RLock()
+	limits := ws.limits
+	ws.mu.RUnlock()
+	err := websec.ListenAndServe(ws.server, limits)
 	if err != nil {
 		ws.logError("Web server ListenAndServe failed: %v", err)
 		fmt.Printf("WEB SERVER ERROR: ListenAndServe failed: %v\n", err)
@@ -465,47 +548,56 @@ func (ws *WebServer) UpdateWeather(obs *weather.Observation) {
 	ws.mu.Lock()
 	defer ws.mu.Unlock()
 
+	// A corrected reading stays corrected when history is loaded again
+	if obs = ws.corrections.Apply(obs); obs == nil {
+		return
+	}
 	ws.weatherData = obs
+	// An older reading (such as a history preload after live data) changes
+	// past history, which clients fetching only newer points would miss
+	if _, older := ws.history.Insert(obs); older {
+		ws.historyRevision++
+	}
+}
 
-	// Insert observation into dataHistory while keeping it sorted by Timestamp (ascending).
-	// Use binary search to find insertion index. If a reading with the same timestamp exists,
-	// replace it. After insertion, trim the slice to retain the most recent maxHistorySize entries.
-	ts := obs.Timestamp
-	n := len(ws.dataHistory)
+// BackfillHistory merges observations recovered after an outage into the
+// history without changing the current reading, and returns how many were
+// not already stored. Dashboards redraw their charts when historyRevision in
+// /api/status changes.
+func (ws *WebServer) BackfillHistory(observations []*weather.Observation) int {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
 
-	if n == 0 {
-		ws.dataHistory = append(ws.dataHistory, *obs)
-	} else {
-		lo, hi := 0, n
-		for lo < hi {
-			mid := (lo + hi) / 2
-			if ws.dataHistory[mid].Timestamp < ts {
-				lo = mid + 1
-			} else {
-				hi = mid
+	if ws.corrections != nil {
+		corrected := make([]*weather.Observation, 0, len(observations))
+		for _, obs := range observations {
+			if obs = ws.corrections.Apply(obs); obs != nil {
+				corrected = append(corrected, obs)
 			}
 		}
+		observations = corrected
+	}
+	added, _ := ws.history.Merge(observations)
+	if added > 0 {
+		ws.historyRevision++
+	}
+	return added
+}
 
-		// lo is the insertion index
-		if lo > 0 && ws.dataHistory[lo-1].Timestamp == ts {
-			// Replace existing at lo-1
-			ws.dataHistory[lo-1] = *obs
-		} else if lo < n && ws.dataHistory[lo].Timestamp == ts {
-			// Replace existing at lo
-			ws.dataHistory[lo] = *obs
-		} else {
-			// Insert at position lo
-			ws.dataHistory = append(ws.dataHistory, weather.Observation{})
-			copy(ws.dataHistory[lo+1:], ws.dataHistory[lo:])
-			ws.dataHistory[lo] = *obs
-		}
+// History returns the stored observations, oldest first. The slice is shared
+// and must not be modified.
+func (ws *WebServer) History() []weather.Observation {
+	return ws.history.Snapshot()
+}
 
-		// Trim to most recent maxHistorySize entries (keep the latest entries)
-		if len(ws.dataHistory) > ws.maxHistorySize {
-			start := len(ws.dataHistory) - ws.maxHistorySize
-			ws.dataHistory = ws.dataHistory[start:]
-		}
+// LatestHistoryTimestamp returns the Unix time of the newest stored
+// observation, or 0 when the history is empty
+func (ws *WebServer) LatestHistoryTimestamp() int64 {
+	history := ws.history.Snapshot()
+	if len(history) == 0 {
+		return 0
 	}
+	return history[len(history)-1].Timestamp
 }
 
 func (ws *WebServer) UpdateHomeKitStatus(status map[string]interface{}) {
@@ -517,6 +609,34 @@ func (ws *WebServer) UpdateHomeKitStatus(status map[string]interface{}) {
 	}
 }
 
+// SetListenAddress binds the web server to one IP instead of all addresses.
+// It must be called before Start.
+func (ws *WebServer) SetListenAddress(ip string) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.server.Addr = net.JoinHostPort(ip, ws.port)
+}
+
+// exposedHeaders are the API response headers cross-origin clients may read
+var exposedHeaders = []string{"ETag", "X-History-Revision", "X-Next-Cursor"}
+
+// SetSecurityPolicy sets the CORS origins, Content-Security-Policy and
+// framing rules applied to every response. Call it before Start.
+func (ws *WebServer) SetSecurityPolicy(policy websec.Policy) {
+	policy.ExposeHeaders = append(policy.ExposeHeaders, exposedHeaders...)
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.server.Handler = ws.logRequests(websec.Wrap(policy, ws.mux))
+}
+
+// SetLimits replaces the default timeouts and connection cap. It must be
+// called before Start.
+func (ws *WebServer) SetLimits(limits websec.Limits) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.limits = limits
+}
+
 func (ws *WebServer) SetStationName(name string) {
 	ws.mu.Lock()
 	defer ws.mu.Unlock()
@@ -564,10 +684,48 @@ func (ws *WebServer) UpdateDataSourceStatus(status weather.DataSourceStatus) {
 		status.Type, status.Active, status.ObservationCount)
 }
 
+// UpdateWatchdogStatus records the restart state of a supervised component
+func (ws *WebServer) UpdateWatchdogStatus(info WatchdogInfo) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.watchdog == nil {
+		ws.watchdog = make(map[string]WatchdogInfo)
+	}
+	ws.watchdog[info.Component] = info
+}
+
+// UpdateTokenStatus records the result of the latest token check
+func (ws *WebServer) UpdateTokenStatus(status TokenStatus) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.tokenStatus = &status
+}
+
 func (ws *WebServer) UpdateForecast(forecast *weather.ForecastResponse) {
 	ws.mu.Lock()
 	defer ws.mu.Unlock()
+	ws.forecastReceived = time.Now()
+	if forecast != nil && len(forecast.Forecast.Hourly) > 0 {
+		// The hours go to /api/forecast/hourly and /api/history/forecast;
+		// /api/status stays small
+		ws.forecastHourly = forecast.Forecast.Hourly
+		ws.recordForecastHoursLocked(forecast.Forecast.Hourly, ws.forecastReceived)
+		daily := *forecast
+		daily.Forecast.Hourly = nil
+		forecast = &daily
+	}
 	ws.forecastData = forecast
+	if forecast != nil && forecast.Timezone != "" && forecast.Timezone != ws.timezone {
+		_ = ws.setTimezoneLocked(forecast.Timezone)
+	}
+}
+
+// SetRainCorrection records the multiplier the service applies to rain
+// values so /api/weather can report it next to the raw total
+func (ws *WebServer) SetRainCorrection(factor float64) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.rainCorrection = factor
 }
 
 // SetAlarmManager sets the alarm manager for status display
@@ -578,6 +736,37 @@ func (ws *WebServer) SetAlarmManager(manager AlarmManagerInterface) {
 	logger.Info("Alarm manager connected to web server")
 }
 
+// MountAlarmEditor serves the alarm editor handler at /alarm-editor. Every request
+// must supply the given password via HTTP basic auth (any username is accepted).
+// Behind a base path the handler must route on BasePath()+"/alarm-editor".
+// Must be called before Start.
+func (ws *WebServer) MountAlarmEditor(handler http.Handler, password string) {
+	const path = "/alarm-editor"
+	basePath := ws.BasePath()
+	protected := requireBasicAuth("Tempest Alarm Editor", password, withPathPrefix(basePath, handler))
+	ws.mux.Handle(path+"/", protected)
+	ws.mux.Handle(path, http.RedirectHandler(path+"/", http.StatusMovedPermanently))
+
+	ws.mu.Lock()
+	ws.alarmEditorURL = basePath + path + "/"
+	ws.mu.Unlock()
+	ws.documentAlarmEditorRoutes(path)
+	logger.Info("Alarm editor available at %s", path+"/")
+}
+
+// requireBasicAuth rejects requests whose basic auth password does not match.
+func requireBasicAuth(realm, password string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pass, ok := r.BasicAuth()
+		if !ok || password == "" || subtle.ConstantTimeCompare([]byte(pass), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", realm))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // GetStatusManager returns the status manager for external use
 func (ws *WebServer) GetStatusManager() *weather.StatusManager {
 	ws.mu.RLock()
@@ -593,8 +782,6 @@ func (ws *WebServer) UpdateBatteryFromObservation(obs *weather.Observation) {
 }
 
 func (ws *WebServer) handleDashboard(w http.ResponseWriter, r *http.Request) {
-	ws.logDebug("Request: %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
-
 	// Handle test-api.html specifically
 	if r.URL.Path == "/test-api.html" {
 		http.ServeFile(w, r, "./test-api.html")
@@ -610,31 +797,21 @@ func (ws *WebServer) handleDashboard(w http.ResponseWriter, r *http.Request) {
 			filename = strings.TrimPrefix(r.URL.Path, "/static/")
 		}
 
-		ws.logDebug("Static file request: %s (path: %s)", filename, r.URL.Path)
-
-		// Serve the file from the physical directory
-		filePath := "./pkg/web/static/" + filename
-
-		// Set appropriate content type and cache-busting headers
+		// Set appropriate content type and revalidation headers; an unchanged
+		// file is answered with 304 through its ETag
 		switch filename {
 		case "styles.css":
 			w.Header().Set("Content-Type", "text/css")
-			w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
-			w.Header().Set("Pragma", "no-cache")
-			w.Header().Set("Expires", "0")
+			w.Header().Set("Cache-Control", "no-cache")
 		case "script.js":
 			w.Header().Set("Content-Type", "application/javascript")
-			w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
-			w.Header().Set("Pragma", "no-cache")
-			w.Header().Set("Expires", "0")
-		case "date-fns.min.js":
-			w.Header().Set("Content-Type", "application/javascript")
+			w.Header().Set("Cache-Control", "no-cache")
 		}
 
-		ws.logDebug("Serving static file: %s", filePath)
-
-		// Try to serve the file
-		http.ServeFile(w, r, filePath)
+		// Serve from the embedded assets (or --static-dir)
+		withBodyETag(func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFileFS(w, r, staticFiles, filename)
+		})(w, r)
 		return
 	}
 
@@ -644,16 +821,18 @@ func (ws *WebServer) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "text/html")
-	tmpl := ws.getDashboardHTML()
-	_, _ = w.Write([]byte(tmpl))
+	var page bytes.Buffer
+	if err := ws.renderDashboard(&page); err != nil {
+		ws.logError("Failed to render dashboard: %v", err)
+		http.Error(w, "Dashboard unavailable", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = page.WriteTo(w)
 }
 
 func (ws *WebServer) handleWeatherAPI(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	ws.logDebug("Weather endpoint called from %s", r.RemoteAddr)
 
 	ws.mu.RLock()
 	defer ws.mu.RUnlock()
@@ -669,7 +848,8 @@ func (ws *WebServer) handleWeatherAPI(w http.ResponseWriter, r *http.Request) {
 
 	// Calculate pressure analysis with debug logging (using sea level pressure for accurate forecasting)
 	pressureCondition := getPressureDescription(seaLevelPressure)
-	pressureTrend := getPressureTrend(ws.dataHistory, ws.elevation)
+	history := ws.history.Snapshot()
+	pressureTrend := getPressureTrend(history, ws.elevation)
 	weatherForecast := getPressureWeatherForecast(seaLevelPressure, pressureTrend)
 
 	// Use the precip_accum_local_day field from the WeatherFlow API as the daily total
@@ -681,17 +861,13 @@ func (ws *WebServer) handleWeatherAPI(w http.ResponseWriter, r *http.Request) {
 	// The RainAccumulated field is cumulative rain in mm
 	var incrementalRainMm float64
 	var rainRate float64 // Rain intensity in mm/hr
-	if len(ws.dataHistory) > 1 {
-		// Use a sorted copy of history to ensure we get the chronologically latest reading
-		historyCopy := make([]weather.Observation, len(ws.dataHistory))
-		copy(historyCopy, ws.dataHistory)
-		sort.Slice(historyCopy, func(i, j int) bool { return historyCopy[i].Timestamp < historyCopy[j].Timestamp })
+	if len(history) > 1 {
 		// Use the SECOND-to-last reading to compare with current (weatherData is the same as last history item)
-		lastReading := historyCopy[len(historyCopy)-2].RainAccumulated
+		lastReading := history[len(history)-2].RainAccumulated
 		incrementalRainMm = math.Max(0, ws.weatherData.RainAccumulated-lastReading)
 
 		// Calculate rain rate in mm/hr
-		timeDiffSeconds := ws.weatherData.Timestamp - historyCopy[len(historyCopy)-2].Timestamp
+		timeDiffSeconds := ws.weatherData.Timestamp - history[len(history)-2].Timestamp
 		if timeDiffSeconds > 0 {
 			rainRate = (incrementalRainMm / float64(timeDiffSeconds)) * 3600 // mm/hr
 		}
@@ -714,6 +890,8 @@ func (ws *WebServer) handleWeatherAPI(w http.ResponseWriter, r *http.Request) {
 		RainRate:             rainRate,          // Rain intensity in mm/hr
 		RainDailyTotal:       dailyRainTotal,    // Total rain since 00:00 (mm)
 		PrecipitationType:    ws.weatherData.PrecipitationType,
+		SnowRate:             ws.weatherData.SnowRate,
+		SnowDaily:            ws.weatherData.SnowDailyTotal,
 		Pressure:             ws.weatherData.StationPressure,
 		SeaLevelPressure:     seaLevelPressure,
 		PressureCondition:    pressureCondition,
@@ -724,9 +902,23 @@ func (ws *WebServer) handleWeatherAPI(w http.ResponseWriter, r *http.Request) {
 		Battery:              ws.weatherData.Battery,
 		LightningStrikeAvg:   ws.weatherData.LightningStrikeAvg,
 		LightningStrikeCount: ws.weatherData.LightningStrikeCount,
+		LightningNearest:     ws.weatherData.LightningNearest,
+		LightningRate:        ws.weatherData.LightningRate,
 		LastUpdate:           time.Unix(ws.weatherData.Timestamp, 0).Format(time.RFC3339),
 	}
 
+	_, response.RainDailyTotalRaw = weather.RawRain(ws.weatherData)
+	response.RainDailyTotalNC = ws.weatherData.NCRainDailyTotal
+	if ws.statsEngine != nil {
+		if peak, at := ws.statsEngine.PeakGust(); !at.IsZero() {
+			response.PeakGustToday, response.PeakGustTime = peak, at.Format(time.RFC3339)
+		}
+		response.SolarEnergyToday, response.UVDoseToday = ws.statsEngine.LightDose()
+	}
+	if ws.rainCorrection != 1 {
+		response.RainCorrection = ws.rainCorrection
+	}
+
 	// Provide explicit unit hints for the client. These describe the units used in the numeric
 	// fields returned by this API so clients (like the popout) can perform deterministic
 	// conversions when necessary. These are the units used internally by the server/data.
@@ -735,10 +927,11 @@ func (ws *WebServer) handleWeatherAPI(w http.ResponseWriter, r *http.Request) {
 		"pressure":    "mb",
 		"wind":        "mph",
 		"rain":        "inches",
+		"distance":    "km",
 	}
 
 	// Add observation count and max history size for real-time updates in UI
-	response.ObservationCount = len(ws.dataHistory)
+	response.ObservationCount = len(history)
 	response.MaxHistorySize = ws.maxHistorySize
 
 	ws.logDebug("Weather API response prepared - Temperature: %.1f°C, Humidity: %.1f%%, UV: %d, Illuminance: %.0f lux, Observations: %d/%d",
@@ -756,12 +949,19 @@ func (ws *WebServer) handleWeatherAPI(w http.ResponseWriter, r *http.Request) {
 
 func (ws *WebServer) handleStatusAPI(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	ws.logDebug("Status endpoint called from %s", r.RemoteAddr)
+	since, err := parseSince(r.URL.Query().Get("since"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fields, err := parseHistoryFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	ws.mu.RLock()
-	defer ws.mu.RUnlock()
 
 	connected := ws.weatherData != nil
 	ws.logDebug("Status check - weatherData exists: %t", connected)
@@ -774,54 +974,16 @@ func (ws *WebServer) handleStatusAPI(w http.ResponseWriter, r *http.Request) {
 	uptime := time.Since(ws.startTime)
 	uptimeStr := fmt.Sprintf("%dh%dm%ds", int(uptime.Hours()), int(uptime.Minutes())%60, int(uptime.Seconds())%60)
 
-	// Convert data history to response format with incremental rain calculation
-	history := make([]WeatherResponse, 0, len(ws.dataHistory))
-
-	// Work from a time-sorted copy to guarantee chronological ordering (oldest -> newest)
-	historyCopy := make([]weather.Observation, len(ws.dataHistory))
-	copy(historyCopy, ws.dataHistory)
-	sort.Slice(historyCopy, func(i, j int) bool { return historyCopy[i].Timestamp < historyCopy[j].Timestamp })
-
-	for i, obs := range historyCopy {
-		// Calculate incremental rain since last observation (in mm)
-		var incrementalRainMm float64
-		var rainRate float64
-		if i > 0 {
-			// obs.RainAccumulated is cumulative rain in mm
-			incrementalRainMm = math.Max(0, obs.RainAccumulated-historyCopy[i-1].RainAccumulated)
-			// Calculate rain rate (mm/hr) from incremental rain and time difference
-			timeDiffSeconds := obs.Timestamp - historyCopy[i-1].Timestamp
-			if timeDiffSeconds > 0 {
-				rainRate = (incrementalRainMm / float64(timeDiffSeconds)) * 3600 // mm/hr
-			}
-		} else {
-			incrementalRainMm = 0 // First observation, no previous data
-			rainRate = 0
-		}
-
-		// Calculate daily total for this observation (in mm)
-		obsTime := time.Unix(obs.Timestamp, 0)
-		startOfDay := time.Date(obsTime.Year(), obsTime.Month(), obsTime.Day(), 0, 0, 0, 0, obsTime.Location())
-		dailyTotal := ws.calculateDailyRainForTime(obsTime, startOfDay)
-
-		history = append(history, WeatherResponse{
-			Temperature:          obs.AirTemperature,
-			Humidity:             obs.RelativeHumidity,
-			WindSpeed:            obs.WindAvg,
-			WindGust:             obs.WindGust,
-			WindDirection:        obs.WindDirection,
-			RainAccum:            incrementalRainMm, // Incremental rain since last sample (mm)
-			RainRate:             rainRate,          // Rain intensity in mm/hr
-			RainDailyTotal:       dailyTotal,        // Total rain since 00:00 (mm)
-			PrecipitationType:    obs.PrecipitationType,
-			Pressure:             obs.StationPressure,
-			Illuminance:          obs.Illuminance,
-			UV:                   obs.UV,
-			Battery:              obs.Battery,
-			LightningStrikeAvg:   obs.LightningStrikeAvg,
-			LightningStrikeCount: obs.LightningStrikeCount,
-			LastUpdate:           time.Unix(obs.Timestamp, 0).Format(time.RFC3339),
-		})
+	// Copy what is updated in place; the response is encoded after the lock is released
+	history := ws.history.Snapshot()
+	homekitStatus := make(map[string]interface{}, len(ws.homekitStatus))
+	for k, v := range ws.homekitStatus {
+		homekitStatus[k] = v
+	}
+	var generatedWeather *GeneratedWeatherInfo
+	if ws.generatedWeather != nil {
+		info := *ws.generatedWeather
+		generatedWeather = &info
 	}
 
 	response := StatusResponse{
@@ -829,13 +991,12 @@ func (ws *WebServer) handleStatusAPI(w http.ResponseWriter, r *http.Request) {
 		LastUpdate:           lastUpdate,
 		Uptime:               uptimeStr,
 		Elevation:            ws.elevation,
-		HomeKit:              ws.homekitStatus,
-		DataHistory:          history,
-		ObservationCount:     len(ws.dataHistory),
+		HomeKit:              homekitStatus,
+		ObservationCount:     len(history),
 		MaxHistorySize:       ws.maxHistorySize,
 		HistoricalDataLoaded: ws.historicalDataLoaded,
 		HistoricalDataCount:  ws.historicalDataCount,
-		GeneratedWeather:     ws.generatedWeather,
+		GeneratedWeather:     generatedWeather,
 	}
 
 	// Provide explicit unit hints for the client to indicate the units used in the
@@ -863,9 +1024,6 @@ func (ws *WebServer) handleStatusAPI(w http.ResponseWriter, r *http.Request) {
 	// Add station URL if available
 	response.StationURL = ws.stationURL
 
-	// Add generated weather information if available
-	response.GeneratedWeather = ws.generatedWeather
-
 	// Add UDP status if UDP listener is active
 	if ws.udpListener != nil {
 		packetCount, lastPacket, stationIP, serialNumber := ws.udpListener.GetStats()
@@ -894,6 +1052,27 @@ func (ws *WebServer) handleStatusAPI(w http.ResponseWriter, r *http.Request) {
 	// Add chart history hours configuration
 	response.ChartHistoryHours = ws.chartHistoryHours
 
+	for _, info := range ws.watchdog {
+		response.Watchdog = append(response.Watchdog, info)
+	}
+	sort.Slice(response.Watchdog, func(i, j int) bool { return response.Watchdog[i].Component < response.Watchdog[j].Component })
+	if ws.tokenStatus != nil {
+		status := *ws.tokenStatus
+		response.TokenStatus = &status
+	}
+	response.HistoryRevision = ws.historyRevision
+	response.Timezone = ws.timezone
+	if ws.maintenance != nil {
+		status := ws.maintenance.Status()
+		response.Maintenance = &status
+	}
+	if calls := weather.APIStats(); len(calls) > 0 {
+		response.APICalls = calls
+	}
+
+	location := ws.location
+	ws.mu.RUnlock()
+
 	// Fetch station status from TempestWX (async, don't block on errors)
 	// Get station status from status manager (handles both scraping and fallback)
 	ws.logDebug("Retrieving station status from status manager")
@@ -903,15 +1082,9 @@ func (ws *WebServer) handleStatusAPI(w http.ResponseWriter, r *http.Request) {
 	ws.logDebug("Station status retrieved - Source: %s, Battery: %s, LastScraped: %s",
 		stationStatus.DataSource, stationStatus.BatteryVoltage, stationStatus.LastScraped)
 
-	// Marshal to JSON first so tests can inspect the exact payload and to provide
-	// clearer debugging output when headless tests observe unexpected/missing fields.
-	if b, err := json.Marshal(response); err == nil {
-		ws.logDebug("Status API JSON payload: %s", string(b))
-		_, _ = w.Write(b)
-		return
+	if err := ws.writeStatusStream(w, response, history, since, fields, location); err != nil {
+		ws.logError("Status API response failed: %v", err)
 	}
-	// Fallback
-	_ = json.NewEncoder(w).Encode(response)
 }
 
 // AlarmStatusResponse represents the alarm status API response
@@ -923,6 +1096,7 @@ type AlarmStatusResponse struct {
 	TotalAlarms   int           `json:"totalAlarms"`
 	EnabledAlarms int           `json:"enabledAlarms"`
 	Alarms        []AlarmStatus `json:"alarms"`
+	EditorURL     string        `json:"editorUrl,omitempty"` // Set when the alarm editor is mounted on this server
 }
 
 // AlarmStatus represents individual alarm information
@@ -938,18 +1112,23 @@ type AlarmStatus struct {
 	CooldownRemaining int      `json:"cooldownRemaining"` // Seconds remaining in cooldown (0 if ready)
 	InCooldown        bool     `json:"inCooldown"`        // True if currently in cooldown
 	TriggeredCount    int      `json:"triggeredCount"`
-	HasSchedule       bool     `json:"hasSchedule"`    // True if alarm has a schedule defined
-	ScheduleActive    bool     `json:"scheduleActive"` // True if schedule allows alarm to be active now
+	HasSchedule       bool     `json:"hasSchedule"`              // True if alarm has a schedule defined
+	ScheduleActive    bool     `json:"scheduleActive"`           // True if schedule allows alarm to be active now
+	SnoozedUntil      string   `json:"snoozedUntil,omitempty"`   // When a snooze ends (RFC 3339); empty when not snoozed
+	Acknowledged      bool     `json:"acknowledged"`             // Silenced until the condition clears
+	AwaitingAck       bool     `json:"awaitingAck"`              // The latest notification has not been acknowledged
+	AcknowledgedBy    string   `json:"acknowledgedBy,omitempty"` // Who acknowledged the latest notification
+	AcknowledgedAt    string   `json:"acknowledgedAt,omitempty"` // When, RFC 3339
 }
 
 func (ws *WebServer) handleAlarmStatusAPI(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	ws.mu.RLock()
 	alarmMgr := ws.alarmManager
 	alarmConfig := ws.alarmConfig
 	disableAlarms := ws.disableAlarms
+	editorURL := ws.alarmEditorURL
 	ws.mu.RUnlock()
 
 	// Determine if alarms are enabled (configured, manager exists, and not disabled via flag)
@@ -1001,6 +1180,17 @@ func (ws *WebServer) handleAlarmStatusAPI(w http.ResponseWriter, r *http.Request
 			scheduleActive = alm.Schedule.IsActive(time.Now(), lat, lon)
 		}
 
+		snoozedUntil := ""
+		if until := alm.GetSnoozedUntil(); !until.IsZero() {
+			snoozedUntil = until.Format(time.RFC3339)
+		}
+
+		awaitingAck, ackedBy, ackedAt := alm.AckStatus()
+		acknowledgedAt := ""
+		if !ackedAt.IsZero() {
+			acknowledgedAt = ackedAt.Format(time.RFC3339)
+		}
+
 		alarmStatuses = append(alarmStatuses, AlarmStatus{
 			Name:              alm.Name,
 			Description:       alm.Description,
@@ -1015,6 +1205,11 @@ func (ws *WebServer) handleAlarmStatusAPI(w http.ResponseWriter, r *http.Request
 			TriggeredCount:    alm.TriggeredCount,
 			HasSchedule:       hasSchedule,
 			ScheduleActive:    scheduleActive,
+			SnoozedUntil:      snoozedUntil,
+			Acknowledged:      alm.IsAcknowledged(),
+			AwaitingAck:       awaitingAck,
+			AcknowledgedBy:    ackedBy,
+			AcknowledgedAt:    acknowledgedAt,
 		})
 	}
 
@@ -1034,24 +1229,31 @@ func (ws *WebServer) handleAlarmStatusAPI(w http.ResponseWriter, r *http.Request
 		TotalAlarms:   totalAlarms,
 		EnabledAlarms: enabledAlarms,
 		Alarms:        alarmStatuses,
+		EditorURL:     editorURL,
 	}
 
 	_ = json.NewEncoder(w).Encode(response)
 }
 
-// handleChartPage serves a dedicated chart page for a given weather type.
-// URL format: /chart/<type>?config=<urlencoded-json>
+// handleChartPage serves the chart popout page for a sensor, with the
+// sensor's chart config embedded. URL format: /chart/<sensor>
 func (ws *WebServer) handleChartPage(w http.ResponseWriter, r *http.Request) {
-	// Expected path: /chart/<type>
-	ws.logDebug("Chart page requested: %s", r.URL.Path)
-
-	// Serve the static chart.html template (script will read query params)
-	if strings.HasPrefix(r.URL.Path, "/chart/") {
-		http.ServeFile(w, r, "./pkg/web/static/chart.html")
+	cfg, status, err := requestChartConfig(r, strings.TrimPrefix(r.URL.Path, "/chart/"))
+	if status == http.StatusNotFound {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), status)
 		return
 	}
-
-	http.NotFound(w, r)
+	page, err := renderChartPage(staticFiles, chartPageName, cfg, ws.BasePath())
+	if err != nil {
+		ws.logError("Failed to render chart page: %v", err)
+		http.Error(w, "Chart page unavailable", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(page)
 }
 
 // handleRegenerateWeatherAPI handles requests to regenerate weather data for testing
@@ -1097,13 +1299,11 @@ func (ws *WebServer) handleRegenerateWeatherAPI(w http.ResponseWriter, r *http.R
 // handleUnitsAPI returns the current units configuration
 func (ws *WebServer) handleUnitsAPI(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	ws.logDebug("Units endpoint called from %s", r.RemoteAddr)
 
 	response := map[string]string{
 		"units":         ws.units,
 		"unitsPressure": ws.unitsPressure,
+		"unitsDistance": weather.DistanceUnit(ws.units),
 	}
 
 	_ = json.NewEncoder(w).Encode(response)
@@ -1133,20 +1333,50 @@ type HistoryResponse struct {
 }
 
 // handleHistoryAPI returns historical weather observations for popout charts
-// with calculated incremental rain values
+// with calculated incremental rain values. since, cursor and limit select a
+// page; X-Next-Cursor is set while later points remain.
 func (ws *WebServer) handleHistoryAPI(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	ws.logDebug("History endpoint called from %s", r.RemoteAddr)
+	query := r.URL.Query()
+	after, err := parseSince(query.Get("since"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if c := query.Get("cursor"); c != "" {
+		cursor, err := strconv.ParseInt(c, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid cursor '%s'", c), http.StatusBadRequest)
+			return
+		}
+		after = max(after, cursor)
+	}
+	limit := 0
+	if l := query.Get("limit"); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil || limit < 1 {
+			http.Error(w, fmt.Sprintf("invalid limit '%s'. Use a positive number of points", l), http.StatusBadRequest)
+			return
+		}
+	}
 
 	ws.mu.RLock()
-	history := make([]weather.Observation, len(ws.dataHistory))
-	copy(history, ws.dataHistory)
+	revision := ws.historyRevision
 	ws.mu.RUnlock()
-
-	// Sort history by timestamp to ensure chronological order for rate calculations
-	sort.Slice(history, func(i, j int) bool { return history[i].Timestamp < history[j].Timestamp })
+	history := ws.history.Snapshot()
+
+	// The page runs from the first point after the cursor; rates still use
+	// the point before it
+	first := sort.Search(len(history), func(i int) bool { return history[i].Timestamp > after })
+	end := len(history)
+	if limit > 0 && first+limit < end {
+		end = first + limit
+		w.Header().Set("X-Next-Cursor", strconv.FormatInt(history[end-1].Timestamp, 10))
+	}
+	w.Header().Set("X-History-Revision", strconv.Itoa(revision))
+	if first < end {
+		ws.setMaintenanceGaps(w, history[first].Timestamp, history[end-1].Timestamp)
+	}
 
 	// Convert to response format
 	// NOTE: We set rainAccum=0 for all historical observations because the WeatherFlow
@@ -1155,9 +1385,10 @@ func (ws *WebServer) handleHistoryAPI(w http.ResponseWriter, r *http.Request) {
 	// calculate reliable incremental rain from historical data.
 	// The "Window Total" line will only show meaningful data for observations collected
 	// live going forward, not for preloaded historical data.
-	response := make([]HistoryResponse, 0, len(history))
+	response := make([]HistoryResponse, 0, end-first)
 
-	for i, obs := range history {
+	for i := first; i < end; i++ {
+		obs := history[i]
 		// Keep rain in mm (native units), convert to user's preferred units in frontend
 		rainInMm := obs.RainAccumulated
 
@@ -1202,715 +1433,8 @@ func (ws *WebServer) handleHistoryAPI(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(response)
 }
 
-func (ws *WebServer) getDashboardHTML() string {
-	return `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Tempest Weather Dashboard</title>
-    <link rel="stylesheet" href="pkg/web/static/styles.css">
-    <link rel="stylesheet" href="pkg/web/static/themes.css">
-
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>🌤️ Tempest Weather Dashboard</h1>
-            <div class="status" id="status">
-                Connecting to weather station...
-            </div>
-        </div>
-
-        <div class="grid">
-            <div class="card" id="temperature-card">
-                <div class="card-header">
-                    <span class="card-icon">🌡️</span>
-                    <span class="card-title">Temperature</span>
-                </div>
-                <div class="card-value" id="temperature">--</div>
-                <div class="card-unit" id="temperature-unit" onclick="toggleUnit('temperature')">°C</div>
-                <div class="chart-container">
-                    <canvas id="temperature-chart"></canvas>
-                </div>
-            </div>
-
-			<!-- Tempest Station Tooltip (hidden, toggled by JS) -->
-			<div class="tempest-tooltip hidden" id="station-tooltip" role="dialog" aria-hidden="true">
-				<div class="tempest-tooltip-header">
-					<div>Tempest Station Details</div>
-					<div class="tempest-tooltip-close" id="station-tooltip-close" title="Close">✕</div>
-				</div>
-				<div class="tempest-tooltip-body" style="padding:12px; font-size:0.9rem;">
-					<p style="margin:0 0 8px 0;">Detailed device and hub status (battery, uptime, signal strength, firmware, serial numbers) are only available when the service is receiving UDP broadcasts from the Tempest station (<code>--udp-stream</code>) or when headless web-status scraping is enabled (<code>--use-web-status</code>).</p>
-					<p style="margin:0;">If neither is enabled the API does not provide these details and the dashboard will show summary status only.</p>
-				</div>
-			</div>
-
-            <div class="card" id="humidity-card">
-                <div class="card-header">
-                    <span class="card-icon">💧</span>
-                    <span class="card-title">Humidity</span>
-                </div>
-                <div class="card-value" id="humidity">--</div>
-                <div class="card-unit">% <span class="info-icon" id="humidity-info-icon" title="Click for humidity reference information">ℹ️</span></div>
-                <div class="humidity-description" id="humidity-description">--</div>
-                <div class="humidity-context" id="humidity-context">
-                    <div class="humidity-tooltip" id="humidity-tooltip">
-                        <div class="humidity-tooltip-header">
-                            <strong>Humidity Comfort Levels:</strong>
-                            <span class="humidity-tooltip-close" id="humidity-tooltip-close" title="Close">×</span>
-                        </div>
-                        <table class="humidity-table">
-                            <thead>
-                                <tr>
-                                    <th>Humidity Range</th>
-                                    <th>Comfort Level</th>
-                                    <th>Effects</th>
-                                </tr>
-                            </thead>
-                            <tbody>
-                                <tr><td>0-30%</td><td>Very Dry</td><td>Static electricity, dry skin, respiratory discomfort</td></tr>
-                                <tr><td>30-40%</td><td>Dry</td><td>Comfortable for most people, minimal static</td></tr>
-                                <tr><td>40-60%</td><td>Ideal</td><td>Most comfortable range for humans</td></tr>
-                                <tr><td>60-70%</td><td>Humid</td><td>Slightly sticky feeling, still comfortable</td></tr>
-                                <tr><td>70-80%</td><td>Very Humid</td><td>Sticky, harder to cool down, mold risk</td></tr>
-                                <tr><td>80%+</td><td>Extremely Humid</td><td>Very uncomfortable, high mold/mildew risk</td></tr>
-                            </tbody>
-                        </table>
-                    </div>
-                </div>
-                <div class="feels-like-info">
-                    <div class="flex-row">
-                        <span>Heat Index (feels like):</span>
-                        <span id="heat-index" class="heat-index-value">--</span>
-                        <span class="info-icon" id="heat-index-info-icon" title="Click for heat index information">ℹ️</span>
-                    </div>
-                    <div class="heat-index-context" id="heat-index-context">
-                        <div class="heat-index-tooltip" id="heat-index-tooltip">
-                            <div class="heat-index-tooltip-header">
-                                <strong>Heat Index Calculation:</strong>
-                                <span class="heat-index-tooltip-close" id="heat-index-tooltip-close" title="Close">×</span>
-                            </div>
-                            <div class="heat-index-details">
-                                <p><strong>What is Heat Index?</strong><br>
-                                The heat index combines air temperature and relative humidity to determine the human-perceived equivalent temperature.</p>
-                                
-                                <p><strong>Calculation:</strong><br>
-                                Uses the official NOAA formula with temperature ≥80°F (26.7°C) and humidity ≥40%.</p>
-                                
-                                <p><strong>Heat Index Categories:</strong></p>
-                                <table class="heat-index-table">
-                                    <tr><td>80-90°F (27-32°C)</td><td>Caution - Fatigue possible</td></tr>
-                                    <tr><td>90-105°F (32-41°C)</td><td>Extreme caution - Heat cramps possible</td></tr>
-                                    <tr><td>105-130°F (41-54°C)</td><td>Danger - Heat exhaustion likely</td></tr>
-                                    <tr><td>130°F+ (54°C+)</td><td>Extreme danger - Heat stroke imminent</td></tr>
-                                </table>
-                                
-                                <p class="heat-index-note">
-                                Note: If conditions don't meet the threshold, actual temperature is displayed.
-                                </p>
-                            </div>
-                        </div>
-                    </div>
-                </div>
-                <div class="chart-container">
-                    <canvas id="humidity-chart"></canvas>
-                </div>
-            </div>
-
-            <div class="card" id="wind-card">
-                <div class="card-header">
-                    <span class="card-icon">🌬️</span>
-                    <span class="card-title">Wind</span>
-                </div>
-                <div class="card-value" id="wind-speed">--</div>
-                <div class="card-unit" id="wind-unit" onclick="toggleUnit('wind')">mph</div>
-                <div class="wind-direction">
-                    <span class="direction-arrow" id="wind-arrow">↑</span>
-                    <span id="wind-direction">--</span>
-                </div>
-                <div class="wind-gust">
-                    <span id="wind-gust-info">--</span>
-                </div>
-                <div class="chart-container">
-                    <canvas id="wind-chart"></canvas>
-                </div>
-            </div>
-
-            <div class="card" id="rain-card">
-                <div class="card-header">
-                    <span class="card-icon">🌧️</span>
-                    <span class="card-title">Rain & Lightning</span>
-                </div>
-                <div class="card-value" id="rain">--</div>
-                <div class="card-unit" id="rain-unit" onclick="toggleUnit('rain')">in <span class="info-icon" id="rain-info-icon" title="Click for rain intensity reference table">ℹ️</span></div>
-                <div class="rain-context" id="rain-context">
-                    <div class="rain-tooltip" id="rain-tooltip">
-                        <div class="rain-tooltip-header">
-                            <strong>Rain Intensity Reference Table:</strong>
-                            <span class="rain-tooltip-close" id="rain-tooltip-close" title="Close">×</span>
-                        </div>
-                        <table class="rain-table">
-                            <thead>
-                                <tr>
-                                    <th>Amount (mm)</th>
-                                    <th>Amount (in)</th>
-                                    <th>Description</th>
-                                    <th>Intensity</th>
-                                </tr>
-                            </thead>
-                            <tbody>
-                                <tr><td>0.0 - 0.25</td><td>0.00 - 0.01</td><td>No rain to very light drizzle</td><td>None/Trace</td></tr>
-                                <tr><td>0.25 - 1.0</td><td>0.01 - 0.04</td><td>Light drizzle</td><td>Very Light</td></tr>
-                                <tr><td>1.0 - 2.5</td><td>0.04 - 0.10</td><td>Light rain</td><td>Light</td></tr>
-                                <tr><td>2.5 - 7.5</td><td>0.10 - 0.30</td><td>Moderate rain</td><td>Moderate</td></tr>
-                                <tr><td>7.5 - 20</td><td>0.30 - 0.79</td><td>Heavy rain</td><td>Heavy</td></tr>
-                                <tr><td>20 - 50</td><td>0.79 - 1.97</td><td>Very heavy rain</td><td>Very Heavy</td></tr>
-                                <tr><td>50+</td><td>1.97+</td><td>Extreme rainfall</td><td>Extreme</td></tr>
-                            </tbody>
-                        </table>
-                    </div>
-                </div>
-                <div class="rain-description">
-                    <span id="rain-description">--</span>
-                </div>
-                <div class="daily-rain-info">
-                    <div class="daily-rain-content">
-                        <span class="daily-rain-label">Today Total:</span>
-                        <span id="daily-rain-total" class="daily-rain-value">--</span>
-                    </div>
-                </div>
-                <div class="precipitation-type">
-                    <div class="precipitation-info">💧 Type: <span id="precipitation-type">--</span></div>
-                </div>
-                <div class="lightning-info">
-                    <div class="lightning-strikes">⚡ <span id="lightning-count">--</span> strikes</div>
-                    <div class="lightning-distance">📏 <span id="lightning-distance">--</span> <span id="lightning-distance-unit">km</span></div>
-                </div>
-                <div class="chart-container">
-                    <canvas id="rain-chart"></canvas>
-                </div>
-            </div>
-
-            <div class="card" id="pressure-card">
-                <div class="card-header">
-                    <span class="card-icon">📊</span>
-                    <span class="card-title">Pressure</span>
-                </div>
-                <div class="card-value" id="pressure">--</div>
-                <div class="card-unit" id="pressure-unit" onclick="toggleUnit('pressure')">mb <span class="info-icon" id="pressure-info-icon" title="Click for pressure interpretation table">ℹ️</span></div>
-                <div class="pressure-info-box">
-                    <div class="pressure-info-row">
-                        <span class="pressure-label">Condition:</span>
-                        <span id="pressure-condition" class="pressure-value">--</span>
-                    </div>
-                    <div class="pressure-info-row-spaced">
-                        <span class="pressure-label">Trend:</span>
-                        <span id="pressure-trend" class="pressure-value">--</span>
-                    </div>
-                    <div class="pressure-info-row-spaced">
-                        <span class="pressure-label">Forecast:</span>
-                        <span id="pressure-forecast" class="pressure-value">--</span>
-                    </div>
-                    <div class="pressure-info-row-spaced">
-                        <span class="pressure-label">Sea Level:</span>
-                        <span id="pressure-sea-level" class="pressure-value">--</span>
-                    </div>
-                </div>
-                <div class="pressure-context" id="pressure-context">
-                    <div class="pressure-tooltip" id="pressure-tooltip">
-                        <div class="pressure-tooltip-header">
-                            <strong>Barometric Pressure Interpretation:</strong>
-                            <span class="pressure-tooltip-close" id="pressure-tooltip-close" title="Close">×</span>
-                        </div>
-                        <table class="pressure-table">
-                            <thead>
-                                <tr>
-                                    <th>Pressure (mb)</th>
-                                    <th>Pressure (inHg)</th>
-                                    <th>Condition</th>
-                                    <th>Weather Trend</th>
-                                </tr>
-                            </thead>
-                            <tbody>
-                                <tr class="pressure-row-very-low"><td>&lt; 980</td><td>&lt; 28.94</td><td><strong>Very Low</strong></td><td>Stormy weather likely</td></tr>
-                                <tr class="pressure-row-low"><td>980-1000</td><td>28.94-29.53</td><td><strong>Low</strong></td><td>Unsettled weather, possible storms</td></tr>
-                                <tr class="pressure-row-normal"><td>1000-1020</td><td>29.53-30.12</td><td><strong>Normal</strong></td><td>Fair weather conditions</td></tr>
-                                <tr class="pressure-row-high"><td>1020-1040</td><td>30.12-30.71</td><td><strong>High</strong></td><td>Generally clear and stable</td></tr>
-                                <tr class="pressure-row-very-high"><td>&gt; 1040</td><td>&gt; 30.71</td><td><strong>Very High</strong></td><td>Very stable, clear conditions</td></tr>
-                            </tbody>
-                        </table>
-                        <div class="pressure-trends-box">
-                            <strong>Pressure Trend Analysis & Weather Forecast:</strong>
-                            <ul class="pressure-trends-list">
-                                <li><strong>Rising Rapidly:</strong> Quick improvement, clearing skies</li>
-                                <li><strong>Rising:</strong> Improving weather, fair conditions ahead</li>
-                                <li><strong>Steady:</strong> Current weather conditions will continue</li>
-                                <li><strong>Falling:</strong> Weather deteriorating, clouds/rain possible</li>
-                                <li><strong>Falling Rapidly:</strong> Storm approaching quickly, take precautions</li>
-                            </ul>
-                            <div class="pressure-wind-note-text">
-                                <strong>Combined Forecast:</strong> The condition shown combines current pressure with trend analysis for more accurate weather prediction.
-                            </div>
-                        </div>
-                        <p class="pressure-note">
-                        Note: Weather predictions based on pressure require considering local conditions and trends over time.
-                        </p>
-                    </div>
-                </div>
-                <div class="chart-container">
-                    <canvas id="pressure-chart"></canvas>
-                </div>
-            </div>
-
-            <div class="card" id="light-card">
-                <div class="card-header">
-                    <span class="card-icon">☀️</span>
-                    <span class="card-title">Light</span>
-                </div>
-                <div class="card-value" id="illuminance">--</div>
-                <div class="card-unit">lux <span class="info-icon" id="lux-info-icon" title="Click for lux reference table">ℹ️</span></div>
-                <div class="lux-description" id="lux-description">--</div>
-                <div class="lux-context" id="lux-context">
-                    <div class="lux-tooltip" id="lux-tooltip">
-                        <div class="lux-tooltip-header">
-                            <strong>Lux Reference Table:</strong>
-                            <span class="lux-tooltip-close" id="lux-tooltip-close" title="Close">×</span>
-                        </div>
-                        <table class="lux-table">
-                            <thead>
-                                <tr>
-                                    <th>Lux</th>
-                                    <th>Condition</th>
-                                </tr>
-                            </thead>
-                            <tbody>
-                                <tr><td>0.0001</td><td>Moonless, overcast night sky (starlight)</td></tr>
-                                <tr><td>0.002</td><td>Moonless clear night sky with airglow</td></tr>
-                                <tr><td>0.01</td><td>Quarter moon on a clear night</td></tr>
-                                <tr><td>0.05–0.3</td><td>Full moon on a clear night</td></tr>
-                                <tr><td>3.4</td><td>Dark limit of civil twilight under a clear sky</td></tr>
-                                <tr><td>20–50</td><td>Public areas with dark surroundings</td></tr>
-                                <tr><td>50</td><td>Family living room lights</td></tr>
-                                <tr><td>80</td><td>Office building hallway/toilet lighting</td></tr>
-                                <tr><td>100</td><td>Very dark overcast day</td></tr>
-                                <tr><td>150</td><td>Train station platforms</td></tr>
-                                <tr><td>320–500</td><td>Office lighting</td></tr>
-                                <tr><td>400</td><td>Sunrise or sunset on a clear day</td></tr>
-                                <tr><td>1000</td><td>Overcast day; typical TV studio lighting</td></tr>
-                                <tr><td>10,000–25,000</td><td>Full daylight (not direct sun)</td></tr>
-                                <tr><td>32,000–100,000</td><td>Direct sunlight</td></tr>
-                            </tbody>
-                        </table>
-                    </div>
-                </div>
-                <div class="chart-container">
-                    <canvas id="light-chart"></canvas>
-                </div>
-            </div>
-
-            <div class="card" id="uv-card">
-                <div class="card-header">
-                    <span class="card-icon">🌞</span>
-                    <span class="card-title">UV Index</span>
-                </div>
-                <div class="card-value" id="uv-index">--</div>
-                <div class="card-unit">UVI <span class="info-icon" id="uv-info-icon" title="Click for UV Index exposure categories">ℹ️</span></div>
-                <div class="uv-description" id="uv-description">--</div>
-                <div class="uv-context" id="uv-context">
-                    <div class="uv-tooltip" id="uv-tooltip">
-                        <div class="uv-tooltip-header">
-                            <strong>UV Index Exposure Categories:</strong>
-                            <span class="uv-tooltip-close" id="uv-tooltip-close" title="Close">×</span>
-                        </div>
-                        <table class="uv-table">
-                            <thead>
-                                <tr>
-                                    <th>UV Index</th>
-                                    <th>Category</th>
-                                    <th>Protection Advice</th>
-                                </tr>
-                            </thead>
-                            <tbody>
-                                <tr class="uv-row-low"><td>0–2</td><td><strong>Low</strong></td><td>Low danger from the sun's UV rays for the average person.</td></tr>
-                                <tr class="uv-row-moderate"><td>3–5</td><td><strong>Moderate</strong></td><td>Moderate risk of harm from unprotected sun exposure.</td></tr>
-                                <tr class="uv-row-high"><td>6–7</td><td><strong>High</strong></td><td>High risk of harm from unprotected sun exposure. Protection against skin and eye damage is needed.</td></tr>
-                                <tr class="uv-row-very-high"><td>8–10</td><td><strong>Very High</strong></td><td>Very high risk of harm from unprotected sun exposure. Take extra precautions because unprotected skin and eyes will be damaged and can burn quickly.</td></tr>
-                                <tr class="uv-row-extreme"><td>11+</td><td><strong>Extreme</strong></td><td>Extreme risk of harm from unprotected sun exposure. Take all precautions because unprotected skin and eyes can burn in minutes.</td></tr>
-                            </tbody>
-                        </table>
-                        <p class="uv-note">
-                        Source: U.S. Environmental Protection Agency UV Index scale
-                        </p>
-                    </div>
-                </div>
-                <div class="chart-container">
-                    <canvas id="uv-chart"></canvas>
-                </div>
-            </div>
-        </div>
-
-        <!-- Information Cards -->
-        <div class="grid">
-            <!-- Tempest Forecast Card -->
-            <div class="card" id="forecast-card">
-                <div class="card-header">
-                    <span class="card-icon">📅</span>
-                    <span class="card-title">Tempest Forecast</span>
-                </div>
-                <div class="card-content">
-                    <div class="forecast-current">
-                        <div class="forecast-current-main">
-                            <div class="forecast-icon" id="forecast-current-icon">--</div>
-                            <div class="forecast-temp-container">
-                                <div class="forecast-temp" id="forecast-current-temp">--°</div>
-                                <div class="forecast-feels-like">Feels like <span id="forecast-current-feels-like">--°</span></div>
-                            </div>
-                            <div class="forecast-conditions" id="forecast-current-conditions">--</div>
-                        </div>
-                        <div class="forecast-current-details">
-                            <div class="forecast-detail">
-                                <span class="forecast-label">Humidity:</span>
-                                <span class="forecast-value" id="forecast-current-humidity">--%</span>
-                            </div>
-                            <div class="forecast-detail">
-                                <span class="forecast-label">Wind:</span>
-                                <span class="forecast-value" id="forecast-current-wind">-- mph</span>
-                            </div>
-                            <div class="forecast-detail">
-                                <span class="forecast-label">Pressure:</span>
-                                <span class="forecast-value" id="forecast-current-pressure">-- mb</span>
-                            </div>
-                            <div class="forecast-detail">
-                                <span class="forecast-label">Precip:</span>
-                                <span class="forecast-value" id="forecast-current-precip">--%</span>
-                            </div>
-                        </div>
-                    </div>
-                    <div class="forecast-daily" id="forecast-daily-container">
-                        <!-- Daily forecast items will be populated by JavaScript -->
-                    </div>
-                </div>
-            </div>
-
-            <div class="card" id="tempest-card">
-                <div class="card-header">
-                    <span class="card-icon">🌤️</span>
-                    <span class="card-title">Tempest Station</span>
-                    <button class="compact-toggle" id="tempest-compact-toggle" title="Toggle compact/detailed view">⚙️</button>
-                </div>
-                <div class="card-content">
-                    <!-- General Status -->
-                    <div class="info-row">
-                        <span class="info-label">Status:</span>
-                        <span class="info-value" id="tempest-status">Disconnected</span>
-                    </div>
-					<div class="info-row">
-						<span class="info-label">Data Source:</span>
-						<span class="info-value" id="tempest-data-source">--</span>
-						<span class="info-icon" id="station-info-icon" role="button" aria-label="More info about Tempest Station status" title="More info about Tempest Station status">ℹ️</span>
-					</div>
-                    <div class="info-row">
-                        <span class="info-label">Station:</span>
-                        <span class="info-value" id="tempest-station">--</span>
-                    </div>
-                    <div class="info-row" id="tempest-station-url-row">
-                        <span class="info-label" id="tempest-station-url-label">Station URL:</span>
-                        <span class="info-value" id="tempest-station-url">--</span>
-                    </div>
-                    <div class="info-row">
-                        <span class="info-label">Elevation:</span>
-                        <span class="info-value" id="tempest-elevation">--</span>
-                    </div>
-                    <div class="info-row">
-                        <span class="info-label">Last Update:</span>
-                        <span class="info-value" id="tempest-last-update">--</span>
-                    </div>
-                    <div class="info-row">
-                        <span class="info-label">Data Points:</span>
-                        <span class="info-value" id="tempest-data-count">--</span>
-                    </div>
-                    <div class="info-row hidden" id="tempest-historical-row">
-                        <span class="info-label">Historical:</span>
-                        <span class="info-value" id="tempest-historical-count">--</span>
-                    </div>
-                    
-                    <!-- Device Status -->
-                    <div class="status-section">
-                        <div class="info-row clickable" id="device-status-row">
-                            <span class="info-label section-header">📡 Device Status</span>
-                            <span class="expand-icon" id="device-status-expand-icon">▶</span>
-                        </div>
-                        <div class="status-expanded hidden" id="device-status-expanded">
-                            <div class="info-row">
-                                <span class="info-label">Battery Level:</span>
-                                <span class="info-value">
-                                    <span class="battery-indicator" id="tempest-battery-indicator"></span>
-                                    <span id="tempest-battery">--</span>
-                                </span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Device Uptime:</span>
-                                <span class="info-value" id="tempest-device-uptime">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Network Status:</span>
-                                <span class="info-value" id="tempest-device-network">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Signal Strength:</span>
-                                <span class="info-value">
-                                    <span class="signal-bars" id="tempest-device-signal-bars"></span>
-                                    <span id="tempest-device-signal">--</span>
-                                </span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Last Observation:</span>
-                                <span class="info-value" id="tempest-device-last-obs">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Serial Number:</span>
-                                <span class="info-value" id="tempest-device-serial">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Firmware:</span>
-                                <span class="info-value" id="tempest-device-firmware">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Sensor Status:</span>
-                                <span class="info-value" id="tempest-sensor-status">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Battery Status:</span>
-                                <span class="info-value" id="tempest-battery-status">--</span>
-                            </div>
-                        </div>
-                    </div>
-                    
-                    <!-- Hub Status -->
-                    <div class="status-section">
-                        <div class="info-row clickable" id="hub-status-row">
-                            <span class="info-label section-header">🏠 Hub Status</span>
-                            <span class="expand-icon" id="hub-status-expand-icon">▶</span>
-                        </div>
-                        <div class="status-expanded hidden" id="hub-status-expanded">
-                            <div class="info-row">
-                                <span class="info-label">Hub Uptime:</span>
-                                <span class="info-value" id="tempest-hub-uptime">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Network Status:</span>
-                                <span class="info-value" id="tempest-hub-network">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">WiFi Signal:</span>
-                                <span class="info-value">
-                                    <span class="signal-bars" id="tempest-hub-signal-bars"></span>
-                                    <span id="tempest-hub-wifi">--</span>
-                                </span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Last Status:</span>
-                                <span class="info-value" id="tempest-hub-last-status">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Serial Number:</span>
-                                <span class="info-value" id="tempest-hub-serial">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Firmware:</span>
-                                <span class="info-value" id="tempest-hub-firmware">--</span>
-                            </div>
-                        </div>
-                    </div>
-                </div>
-            </div>
-
-            <div class="card" id="homekit-card">
-                <div class="card-header">
-                    <span class="card-icon">🏠</span>
-                    <span class="card-title">HomeKit Bridge</span>
-                </div>
-                <div class="card-content">
-                    <!-- General Status -->
-                    <div class="info-row">
-                        <span class="info-label">Status:</span>
-                        <span class="info-value" id="homekit-status">Inactive</span>
-                    </div>
-                    <div class="info-row">
-                        <span class="info-label">Bridge Name:</span>
-                        <span class="info-value" id="homekit-bridge">--</span>
-                    </div>
-                    <div class="info-row clickable" id="accessories-row">
-                        <span class="info-label">Accessories:</span>
-                        <span class="info-value" id="homekit-accessories">--</span>
-                        <span class="expand-icon" id="accessories-expand-icon">▶</span>
-                    </div>
-                    <div class="accessories-expanded hidden" id="accessories-expanded">
-                        <div id="accessories-list">
-                            <!-- Accessories will be populated here -->
-                        </div>
-                    </div>
-                    
-                    <!-- Connection Info -->
-                    <div class="status-section">
-                        <div class="info-row clickable" id="homekit-connection-row">
-                            <span class="info-label section-header">🔗 Connection Info</span>
-                            <span class="expand-icon" id="homekit-connection-expand-icon">▶</span>
-                        </div>
-                        <div class="status-expanded hidden" id="homekit-connection-expanded">
-                            <div class="info-row">
-                                <span class="info-label">Setup PIN:</span>
-                                <span class="info-value" id="homekit-pin">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Setup Code:</span>
-                                <span class="info-value" id="homekit-setup-code">--</span>
-                            </div>
-                            <div class="info-row" style="flex-direction: column; align-items: center; padding: 10px 0;">
-                                <span class="info-label" style="margin-bottom: 10px;">Setup QR Code:</span>
-                                <canvas id="homekit-qr-code" style="border: 2px solid #ddd; border-radius: 8px; padding: 10px; background: white;"></canvas>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Paired Devices:</span>
-                                <span class="info-value" id="homekit-paired-devices">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Reachability:</span>
-                                <span class="info-value" id="homekit-reachability">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Last Request:</span>
-                                <span class="info-value" id="homekit-last-request">--</span>
-                            </div>
-                        </div>
-                    </div>
-                    
-                    <!-- Technical Details -->
-                    <div class="status-section">
-                        <div class="info-row clickable" id="homekit-technical-row">
-                            <span class="info-label section-header">⚙️ Technical Details</span>
-                            <span class="expand-icon" id="homekit-technical-expand-icon">▶</span>
-                        </div>
-                        <div class="status-expanded hidden" id="homekit-technical-expanded">
-                            <div class="info-row">
-                                <span class="info-label">Bridge ID:</span>
-                                <span class="info-value" id="homekit-bridge-id">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Manufacturer:</span>
-                                <span class="info-value" id="homekit-manufacturer">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Model:</span>
-                                <span class="info-value" id="homekit-model">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Firmware:</span>
-                                <span class="info-value" id="homekit-firmware">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Bridge Port:</span>
-                                <span class="info-value" id="homekit-port">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">HAP Version:</span>
-                                <span class="info-value" id="homekit-hap-version">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Configuration #:</span>
-                                <span class="info-value" id="homekit-config-number">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Category:</span>
-                                <span class="info-value" id="homekit-category">--</span>
-                            </div>
-                            <div class="info-row">
-                                <span class="info-label">Uptime (Paired):</span>
-                                <span class="info-value" id="homekit-paired-uptime">--</span>
-                            </div>
-                        </div>
-                    </div>
-                </div>
-            </div>
-
-            <div class="card" id="alarm-card">
-                <div class="card-header">
-                    <span class="card-icon">🚨</span>
-                    <span class="card-title">Alarm Status</span>
-                    <button class="alarm-compact-toggle" id="alarm-compact-toggle" title="Toggle compact/detailed view">⚙️</button>
-                </div>
-                <div class="alarm-status-content">
-                    <div class="alarm-info-row">
-                        <span class="alarm-label">Status:</span>
-                        <span class="alarm-value" id="alarm-status">Loading...</span>
-                    </div>
-                    <div class="alarm-info-row">
-                        <span class="alarm-label">Config:</span>
-                        <span class="alarm-value" id="alarm-config-path">--</span>
-                    </div>
-                    <div class="alarm-info-row">
-                        <span class="alarm-label">Last Read:</span>
-                        <span class="alarm-value" id="alarm-last-read">--</span>
-                    </div>
-                    <div class="alarm-info-row">
-                        <span class="alarm-label">Total Alarms:</span>
-                        <span class="alarm-value"><span id="alarm-enabled-count">--</span> / <span id="alarm-total-count">--</span> enabled</span>
-                    </div>
-                    <div class="alarm-list" id="alarm-list">
-                        <div class="alarm-list-header">Active Alarms:</div>
-                        <!-- Alarm items will be inserted here by JavaScript -->
-                    </div>
-                </div>
-            </div>
-        </div>
-
-        <div class="footer">
-            <p>Last updated: <span id="last-update">--</span></p>
-            <p>Tempest HomeKit Service v` + ws.version + `</p>
-            <div class="theme-selector">
-                <label for="theme-select">🎨 Theme:</label>
-                <select id="theme-select">
-                    <option value="default">Default (Purple)</option>
-                    <option value="ocean">Ocean Blue</option>
-                    <option value="sunset">Sunset Orange</option>
-                    <option value="forest">Forest Green</option>
-                    <option value="midnight">Midnight Dark</option>
-                    <option value="arctic">Arctic Light</option>
-                    <option value="autumn">Autumn Earth</option>
-                </select>
-            </div>
-        </div>
-    <!-- External JavaScript Libraries -->
-    ` + func() string {
-		// If running under CI or explicit flag, prefer local static copies to avoid CDN/network flakiness
-		if os.Getenv("CI") != "" || os.Getenv("USE_LOCAL_CHARTJS") != "" {
-			return `
-    <script src="/pkg/web/static/chart.umd.js"></script>
-    <script src="/pkg/web/static/chartjs-adapter-date-fns.bundle.min.js"></script>
-    `
-		}
-		return `
-    <script src="https://unpkg.com/chart.js@4.4.4/dist/chart.umd.js"></script>
-    <script src="https://unpkg.com/chartjs-adapter-date-fns@3.0.0/dist/chartjs-adapter-date-fns.bundle.min.js"></script>
-    `
-	}() + `
-    
-	<!-- QR Code Library -->
-	<script src="/pkg/web/static/qrcode.min.js"></script>
-	
-	<!-- Main Application Script -->
-	<script src="/pkg/web/static/alarm-utils.js"></script>
-	<script src="pkg/web/static/script.js?v=` + fmt.Sprintf("%d", time.Now().UnixNano()) + `"></script>
-</body>
-</html>`
-}
-
 // handleGenerateWeatherAPI returns Tempest API-compatible JSON format for generated weather data
 func (ws *WebServer) handleGenerateWeatherAPI(w http.ResponseWriter, r *http.Request) {
-	ws.logDebug("Generate weather API endpoint called from %s", r.RemoteAddr)
-
 	// Only allow GET requests
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
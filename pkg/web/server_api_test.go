@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -101,3 +103,412 @@ func TestWeatherAndStatusEndpoints(t *testing.T) {
 		}
 	}
 }
+
+func TestBackfillHistory(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.UpdateWeather(&weather.Observation{Timestamp: 1000, AirTemperature: 10})
+	ws.UpdateWeather(&weather.Observation{Timestamp: 1600, AirTemperature: 16})
+
+	added := ws.BackfillHistory([]*weather.Observation{
+		{Timestamp: 1200, AirTemperature: 12},
+		{Timestamp: 1400, AirTemperature: 14},
+		{Timestamp: 1600, AirTemperature: 16},
+	})
+	if added != 2 {
+		t.Errorf("BackfillHistory added %d, want 2 (1600 was already stored)", added)
+	}
+	if ws.weatherData.Timestamp != 1600 {
+		t.Errorf("backfill replaced the current reading with %d", ws.weatherData.Timestamp)
+	}
+	if ws.LatestHistoryTimestamp() != 1600 {
+		t.Errorf("LatestHistoryTimestamp = %d, want 1600", ws.LatestHistoryTimestamp())
+	}
+	var got []int64
+	for _, obs := range ws.history.Snapshot() {
+		got = append(got, obs.Timestamp)
+	}
+	if len(got) != 4 || got[0] != 1000 || got[1] != 1200 || got[2] != 1400 || got[3] != 1600 {
+		t.Errorf("history timestamps = %v", got)
+	}
+	if ws.historyRevision != 1 {
+		t.Errorf("historyRevision = %d, want 1", ws.historyRevision)
+	}
+	if ws.BackfillHistory(nil) != 0 || ws.historyRevision != 1 {
+		t.Error("an empty backfill should not change the revision")
+	}
+
+	// Live readings append; an older one changes past history
+	ws.UpdateWeather(&weather.Observation{Timestamp: 1800})
+	if ws.historyRevision != 1 {
+		t.Errorf("a newer reading changed the revision to %d", ws.historyRevision)
+	}
+	ws.UpdateWeather(&weather.Observation{Timestamp: 800})
+	if ws.historyRevision != 2 {
+		t.Errorf("historyRevision after an older reading = %d, want 2", ws.historyRevision)
+	}
+}
+
+func TestWeatherAPIReportsRawAndCorrectedRain(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.SetRainCorrection(1.25)
+	obs := &weather.Observation{Timestamp: time.Now().Unix(), RainDailyTotal: 8, NCRainDailyTotal: 9}
+	weather.ApplyRainCorrection(obs, 1.25)
+	ws.UpdateWeather(obs)
+
+	rec := httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/weather", nil))
+	var resp WeatherResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode /api/weather response: %v", err)
+	}
+	if resp.RainDailyTotal != 10 || resp.RainDailyTotalRaw != 8 || resp.RainDailyTotalNC != 9 || resp.RainCorrection != 1.25 {
+		t.Errorf("rain fields: total %v, raw %v, nc %v, correction %v", resp.RainDailyTotal, resp.RainDailyTotalRaw, resp.RainDailyTotalNC, resp.RainCorrection)
+	}
+}
+
+func TestWeatherAPIReportsSnow(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.UpdateWeather(&weather.Observation{Timestamp: time.Now().Unix(), RainAccumulated: 0.1, AirTemperature: -4, SnowRate: 60, SnowDailyTotal: 14})
+
+	rec := httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/weather", nil))
+	var resp WeatherResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode /api/weather response: %v", err)
+	}
+	if resp.SnowRate != 60 || resp.SnowDaily != 14 {
+		t.Errorf("snow fields: rate %v, daily %v; want 60 and 14", resp.SnowRate, resp.SnowDaily)
+	}
+}
+
+func TestWeatherAPIReportsLightningStorm(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.UpdateWeather(&weather.Observation{Timestamp: time.Now().Unix(), LightningStrikeCount: 3, LightningStrikeAvg: 14, LightningNearest: 6, LightningRate: 1.2})
+
+	rec := httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/weather", nil))
+	var resp WeatherResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode /api/weather response: %v", err)
+	}
+	if resp.LightningNearest != 6 || resp.LightningRate != 1.2 || resp.UnitHints["distance"] != "km" {
+		t.Errorf("lightning fields: nearest %v, rate %v, unit %q; want 6, 1.2 and km", resp.LightningNearest, resp.LightningRate, resp.UnitHints["distance"])
+	}
+
+	rec = httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/units", nil))
+	var units map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&units); err != nil {
+		t.Fatalf("failed to decode /api/units response: %v", err)
+	}
+	if want := weather.DistanceUnit(ws.units); units["unitsDistance"] != want {
+		t.Errorf("unitsDistance = %q, want %q for %s units", units["unitsDistance"], want, ws.units)
+	}
+}
+
+func TestStatusIncludesTokenStatus(t *testing.T) {
+	ws := testNewWebServer(t)
+	get := func() StatusResponse {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+		var resp StatusResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode /api/status response: %v", err)
+		}
+		return resp
+	}
+
+	if resp := get(); resp.TokenStatus != nil {
+		t.Fatalf("expected no token status before a check, got %+v", resp.TokenStatus)
+	}
+	ws.UpdateTokenStatus(TokenStatus{State: "invalid", StatusCode: 401, CheckedAt: "2026-01-02T03:04:05Z"})
+	if resp := get(); resp.TokenStatus == nil || resp.TokenStatus.State != "invalid" || resp.TokenStatus.StatusCode != 401 {
+		t.Errorf("unexpected token status: %+v", resp.TokenStatus)
+	}
+}
+
+func TestStatusIncludesWatchdog(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.UpdateWatchdogStatus(WatchdogInfo{Component: "udp", Restarts: 2, LastReason: "no observations for 5m0s", Stalled: true})
+	ws.UpdateWatchdogStatus(WatchdogInfo{Component: "udp", Restarts: 2})
+	ws.UpdateWatchdogStatus(WatchdogInfo{Component: "api"})
+
+	rec := httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+
+	var resp StatusResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode /api/status response: %v", err)
+	}
+	if len(resp.Watchdog) != 2 || resp.Watchdog[0].Component != "api" || resp.Watchdog[1].Component != "udp" {
+		t.Fatalf("unexpected watchdog entries: %+v", resp.Watchdog)
+	}
+	if resp.Watchdog[1].Restarts != 2 || resp.Watchdog[1].Stalled {
+		t.Errorf("expected latest udp state, got %+v", resp.Watchdog[1])
+	}
+}
+
+func TestHomeKitSetupAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+
+	rec := httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/homekit/setup", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 before the bridge is running, got %d", rec.Code)
+	}
+
+	ws.UpdateHomeKitStatus(map[string]interface{}{
+		"bridge":        true,
+		"pin":           "03145154",
+		"setupCode":     "031-45-154",
+		"setupId":       "1QJ8",
+		"setupURI":      "X-HM://0023ISYWY1QJ8",
+		"pinGenerated":  true,
+		"pairedDevices": 1,
+	})
+	rec = httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/homekit/setup", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp HomeKitSetupResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode /api/homekit/setup response: %v", err)
+	}
+	want := HomeKitSetupResponse{PIN: "03145154", SetupCode: "031-45-154", SetupID: "1QJ8", SetupURI: "X-HM://0023ISYWY1QJ8", PINGenerated: true, Paired: true}
+	if resp != want {
+		t.Errorf("got %+v, want %+v", resp, want)
+	}
+}
+
+type fakePairingManager struct {
+	pairings []HomeKitPairing
+	resets   int
+}
+
+func (f *fakePairingManager) Pairings() ([]HomeKitPairing, error) { return f.pairings, nil }
+
+func (f *fakePairingManager) RemovePairing(id string) error {
+	for i, p := range f.pairings {
+		if p.ID == id {
+			f.pairings = append(f.pairings[:i], f.pairings[i+1:]...)
+			return nil
+		}
+	}
+	return ErrPairingNotFound
+}
+
+func (f *fakePairingManager) Reset() error {
+	f.resets++
+	f.pairings = nil
+	return nil
+}
+
+func TestHomeKitPairingsAPIRequiresAdmin(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.SetHomeKitPairingManager(&fakePairingManager{})
+
+	rec := httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/homekit/pairings", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without an admin password, got %d", rec.Code)
+	}
+
+	ws.SetAdminPassword("secret")
+	req := httptest.NewRequest(http.MethodGet, "/api/homekit/pairings", nil)
+	req.SetBasicAuth("admin", "wrong")
+	rec = httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with a wrong password, got %d", rec.Code)
+	}
+}
+
+func TestHomeKitPairingsAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.SetAdminPassword("secret")
+	manager := &fakePairingManager{pairings: []HomeKitPairing{{ID: "hub"}, {ID: "phone", Admin: true}}}
+	ws.SetHomeKitPairingManager(manager)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.SetBasicAuth("admin", "secret")
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/api/homekit/pairings", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp HomeKitPairingsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode /api/homekit/pairings response: %v", err)
+	}
+	if resp.Count != 2 || resp.Pairings[1] != (HomeKitPairing{ID: "phone", Admin: true}) {
+		t.Errorf("unexpected pairings response: %+v", resp)
+	}
+
+	if rec := do(http.MethodPost, "/api/homekit/pairings/remove", `{"id":"nope"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown pairing, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/homekit/pairings/remove", `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without an id, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/homekit/pairings/remove", `{"id":"hub"}`); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 removing a pairing, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(manager.pairings) != 1 {
+		t.Errorf("expected 1 pairing left, got %+v", manager.pairings)
+	}
+
+	if rec := do(http.MethodPost, "/api/homekit/reset", ""); rec.Code != http.StatusOK || manager.resets != 1 {
+		t.Errorf("expected reset to succeed once, got %d (resets %d)", rec.Code, manager.resets)
+	}
+}
+
+type fakeStationManager struct {
+	stations []StationInfo
+	switched []int
+	ws       *WebServer
+}
+
+func (f *fakeStationManager) Stations(refresh bool) ([]StationInfo, time.Time, error) {
+	return f.stations, time.Unix(1700000000, 0), nil
+}
+
+func (f *fakeStationManager) SwitchStation(id int) error {
+	for _, s := range f.stations {
+		if s.ID == id {
+			f.switched = append(f.switched, id)
+			f.ws.SetStation(id, s.Name, "")
+			return nil
+		}
+	}
+	return ErrStationNotFound
+}
+
+func TestStationsAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	rec := httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/stations", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without a station manager, got %d", rec.Code)
+	}
+
+	ws.SetAdminPassword("secret")
+	manager := &fakeStationManager{stations: []StationInfo{{ID: 1, Name: "Home"}, {ID: 2, Name: "Cabin"}}, ws: ws}
+	ws.SetStationManager(manager)
+	ws.SetStation(1, "Home", "")
+	ws.UpdateWeather(&weather.Observation{Timestamp: time.Now().Unix(), AirTemperature: 20})
+
+	rec = httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/stations", nil))
+	var resp StationsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode /api/stations response: %v", err)
+	}
+	if resp.ActiveID != 1 || len(resp.Stations) != 2 || !resp.Stations[0].Active || resp.Stations[1].Active {
+		t.Errorf("unexpected stations response: %+v", resp)
+	}
+
+	do := func(body string, auth bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/stations/select", strings.NewReader(body))
+		if auth {
+			req.SetBasicAuth("admin", "secret")
+		}
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := do(`{"id":2}`, false); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the admin password, got %d", rec.Code)
+	}
+	if rec := do(`{"id":9}`, true); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown station, got %d", rec.Code)
+	}
+	if rec := do(`{"id":2}`, true); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 switching stations, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(manager.switched) != 1 || manager.switched[0] != 2 {
+		t.Errorf("unexpected switches: %v", manager.switched)
+	}
+
+	ws.mu.RLock()
+	name, history := ws.stationName, ws.history.Len()
+	ws.mu.RUnlock()
+	if name != "Cabin" || history != 0 {
+		t.Errorf("expected history of the previous station to be cleared, got name %q and %d points", name, history)
+	}
+}
+
+func TestHistoryAPIPagination(t *testing.T) {
+	ws := testNewWebServer(t)
+	start := time.Now().Add(-10 * time.Hour).Truncate(time.Minute)
+	for i := 0; i < 25; i++ {
+		ws.UpdateWeather(&weather.Observation{
+			Timestamp:       start.Add(time.Duration(i) * time.Minute).Unix(),
+			RainAccumulated: float64(i) * 0.1,
+		})
+	}
+	ws.historyRevision = 3
+
+	get := func(query string) (*httptest.ResponseRecorder, []HistoryResponse) {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/history"+query, nil))
+		var points []HistoryResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &points); err != nil {
+				t.Fatalf("%s: invalid JSON: %v", query, err)
+			}
+		}
+		return rec, points
+	}
+
+	// Page through everything 10 points at a time
+	var all []HistoryResponse
+	query := "?limit=10"
+	for pages := 0; ; pages++ {
+		rec, points := get(query)
+		if rec.Code != http.StatusOK || pages > 3 {
+			t.Fatalf("%s: status %d after %d pages", query, rec.Code, pages)
+		}
+		if rec.Header().Get("X-History-Revision") != "3" {
+			t.Errorf("X-History-Revision = %q", rec.Header().Get("X-History-Revision"))
+		}
+		all = append(all, points...)
+		next := rec.Header().Get("X-Next-Cursor")
+		if next == "" {
+			break
+		}
+		query = "?limit=10&cursor=" + next
+	}
+	if len(all) != 25 {
+		t.Fatalf("paged %d points, want 25", len(all))
+	}
+	for i, p := range all {
+		if p.Timestamp != ws.history.Snapshot()[i].Timestamp {
+			t.Fatalf("point %d has timestamp %d, want %d", i, p.Timestamp, ws.history.Snapshot()[i].Timestamp)
+		}
+	}
+	// The rain rate of a page's first point still uses the point before it
+	if all[10].RainRate <= 0 {
+		t.Errorf("rain rate at a page boundary = %v", all[10].RainRate)
+	}
+
+	// A delta since the 20th point, and an exact final page without a cursor
+	rec, delta := get("?since=" + strconv.FormatInt(ws.history.Snapshot()[19].Timestamp, 10))
+	if len(delta) != 5 || rec.Header().Get("X-Next-Cursor") != "" {
+		t.Errorf("since: %d points, cursor %q", len(delta), rec.Header().Get("X-Next-Cursor"))
+	}
+	if rec, _ := get("?limit=5&since=" + strconv.FormatInt(ws.history.Snapshot()[19].Timestamp, 10)); rec.Header().Get("X-Next-Cursor") != "" {
+		t.Errorf("cursor set on the last page")
+	}
+
+	for _, query := range []string{"?limit=0", "?limit=x", "?cursor=abc", "?since=later"} {
+		if rec, _ := get(query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, rec.Code)
+		}
+	}
+}
@@ -57,11 +57,11 @@ func TestCalculateDailyRainForTime_Basic(t *testing.T) {
 	now := time.Now()
 	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 
-	ws.dataHistory = []weather.Observation{
+	ws.history.Replace([]weather.Observation{
 		{Timestamp: start.Unix(), RainAccumulated: 1.0},
 		{Timestamp: start.Add(1 * time.Hour).Unix(), RainAccumulated: 1.5},
 		{Timestamp: start.Add(2 * time.Hour).Unix(), RainAccumulated: 2.0},
-	}
+	})
 
 	target := start.Add(90 * time.Minute)
 	got := ws.calculateDailyRainForTime(target, start)
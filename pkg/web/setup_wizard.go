@@ -0,0 +1,343 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+	"tempest-homekit-go/pkg/websec"
+)
+
+// SetupChoices are the settings collected by the first-run setup wizard
+type SetupChoices struct {
+	Token         string `json:"token"`
+	StationName   string `json:"station"`
+	Sensors       string `json:"sensors"`
+	Units         string `json:"units"`
+	UnitsPressure string `json:"units_pressure"`
+}
+
+// SetupStation is a station offered by the setup wizard
+type SetupStation struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// SetupWizard serves a single page at / that collects a WeatherFlow token,
+// station, sensors and units before the service has enough configuration to
+// start. Run returns once the choices have been saved.
+type SetupWizard struct {
+	server       *http.Server
+	defaults     SetupChoices
+	save         func(SetupChoices) error
+	listStations func(token string) ([]weather.Station, error)
+	done         chan SetupChoices
+
+	mu        sync.Mutex // serializes save
+	completed bool
+}
+
+// NewSetupWizard creates a wizard listening on addr. defaults pre-fill the
+// form; save validates and persists the choices and its error is shown to
+// the user.
+func NewSetupWizard(addr string, defaults SetupChoices, save func(SetupChoices) error) *SetupWizard {
+	sw := &SetupWizard{
+		defaults:     defaults,
+		save:         save,
+		listStations: weather.GetStations,
+		done:         make(chan SetupChoices, 1),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", sw.handlePage)
+	mux.Handle("/pkg/web/static/", http.StripPrefix("/pkg/web/static/", http.FileServerFS(staticFiles)))
+	mux.HandleFunc("/api/setup/stations", sw.handleStations)
+	mux.HandleFunc("/api/setup/complete", sw.handleComplete)
+	sw.server = &http.Server{
+		Addr:              addr,
+		Handler:           websec.Wrap(websec.Policy{}, mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return sw
+}
+
+// Handler returns the wizard's HTTP handler
+func (sw *SetupWizard) Handler() http.Handler {
+	return sw.server.Handler
+}
+
+// Run serves the wizard until the setup is saved, then shuts the listener
+// down so the web console can take over the port
+func (sw *SetupWizard) Run() (SetupChoices, error) {
+	errCh := make(chan error, 1)
+	go func() {
+		if err := sw.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return SetupChoices{}, fmt.Errorf("setup wizard failed: %w", err)
+	case choices := <-sw.done:
+		// Let the browser receive the final response before closing
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := sw.server.Shutdown(ctx); err != nil {
+			logger.Warn("Setup wizard shutdown: %v", err)
+		}
+		return choices, nil
+	}
+}
+
+func (sw *SetupWizard) handlePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+	// Never echo a configured token back; the page only learns that one exists
+	page := struct {
+		SetupChoices
+		HasToken bool `json:"has_token"`
+	}{SetupChoices: sw.defaults, HasToken: sw.defaults.Token != ""}
+	page.Token = ""
+	defaults, err := json.Marshal(page)
+	if err != nil {
+		http.Error(w, "Failed to encode defaults", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write([]byte(strings.Replace(setupWizardHTML, "{{DEFAULTS}}", string(defaults), 1)))
+}
+
+// handleStations lists the stations a token can access so the user can pick
+// one instead of typing its name
+func (sw *SetupWizard) handleStations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	token := sw.token(req.Token)
+	if token == "" {
+		http.Error(w, "A WeatherFlow token is required", http.StatusBadRequest)
+		return
+	}
+
+	stations, err := sw.listStations(token)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Could not list stations for this token: %v", err), http.StatusBadGateway)
+		return
+	}
+	if len(stations) == 0 {
+		http.Error(w, "This token has no stations", http.StatusNotFound)
+		return
+	}
+
+	resp := make([]SetupStation, 0, len(stations))
+	for _, s := range stations {
+		name := s.Name
+		if name == "" {
+			name = s.StationName
+		}
+		resp = append(resp, SetupStation{ID: s.StationID, Name: name})
+	}
+	sort.Slice(resp, func(i, j int) bool { return resp[i].Name < resp[j].Name })
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (sw *SetupWizard) handleComplete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var choices SetupChoices
+	if err := json.NewDecoder(r.Body).Decode(&choices); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	choices.Token = sw.token(choices.Token)
+	if choices.Token == "" || choices.StationName == "" {
+		http.Error(w, "Token and station are required", http.StatusBadRequest)
+		return
+	}
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if sw.completed {
+		http.Error(w, "Setup already completed", http.StatusConflict)
+		return
+	}
+	if err := sw.save(choices); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sw.completed = true
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	sw.done <- choices
+}
+
+// token returns the token typed into the form, or the configured one when
+// the field was left empty
+func (sw *SetupWizard) token(entered string) string {
+	if t := strings.TrimSpace(entered); t != "" {
+		return t
+	}
+	return sw.defaults.Token
+}
+
+// setupWizardHTML is the wizard page; {{DEFAULTS}} is replaced with the
+// pre-filled choices as JSON
+const setupWizardHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Tempest HomeKit Setup</title>
+    <link rel="stylesheet" href="/pkg/web/static/styles.css">
+    <style>
+        .setup-card { max-width: 560px; margin: 40px auto; padding: 24px; }
+        .setup-step { display: none; }
+        .setup-step.active { display: block; }
+        .setup-card label { display: block; margin: 12px 0 4px; font-weight: 600; }
+        .setup-card input[type=text], .setup-card input[type=password], .setup-card select { width: 100%; padding: 8px; box-sizing: border-box; }
+        .setup-sensors label { display: inline-block; font-weight: normal; margin-right: 12px; }
+        .setup-actions { margin-top: 20px; display: flex; gap: 8px; }
+        .setup-error { color: #c0392b; margin-top: 12px; min-height: 1em; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="card setup-card">
+            <h1>🌤️ Tempest HomeKit Setup</h1>
+
+            <div class="setup-step active" id="step-token">
+                <p>Enter your WeatherFlow personal access token. Create one at
+                <a href="https://tempestwx.com/settings/tokens" target="_blank" rel="noopener">tempestwx.com/settings/tokens</a>.</p>
+                <label for="token">Token</label>
+                <input type="password" id="token" autocomplete="off">
+                <div class="setup-actions"><button id="token-next">Find stations</button></div>
+            </div>
+
+            <div class="setup-step" id="step-station">
+                <label for="station">Station</label>
+                <select id="station"></select>
+                <label>Sensors</label>
+                <div class="setup-sensors" id="sensors"></div>
+                <label for="units">Units</label>
+                <select id="units">
+                    <option value="imperial">Imperial (°F, mph, in)</option>
+                    <option value="metric">Metric (°C, kph, mm)</option>
+                    <option value="sae">SAE (°F, mph, in)</option>
+                </select>
+                <label for="units-pressure">Pressure units</label>
+                <select id="units-pressure">
+                    <option value="inHg">inHg</option>
+                    <option value="mb">mb</option>
+                </select>
+                <div class="setup-actions">
+                    <button id="station-back">Back</button>
+                    <button id="finish">Save and start</button>
+                </div>
+            </div>
+
+            <div class="setup-step" id="step-done">
+                <p>✅ Settings saved. The bridge is starting; this page will open the dashboard shortly.</p>
+            </div>
+
+            <div class="setup-error" id="error"></div>
+        </div>
+    </div>
+    <script>
+    (function () {
+        const defaults = {{DEFAULTS}};
+        const sensorNames = ['temp', 'humidity', 'lux', 'uv', 'wind', 'rain', 'pressure', 'lightning'];
+        const $ = (id) => document.getElementById(id);
+
+        function show(step) {
+            document.querySelectorAll('.setup-step').forEach((el) => el.classList.toggle('active', el.id === step));
+            $('error').textContent = '';
+        }
+
+        async function post(url, body) {
+            const resp = await fetch(url, { method: 'POST', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify(body) });
+            if (!resp.ok) throw new Error((await resp.text()).trim() || resp.statusText);
+            return resp.json();
+        }
+
+        let enabled = (defaults.sensors || 'temp,lux,humidity,uv').split(',').map((s) => s.trim());
+        if (defaults.sensors === 'all') enabled = sensorNames;
+        sensorNames.forEach((name) => {
+            const label = document.createElement('label');
+            const box = document.createElement('input');
+            box.type = 'checkbox';
+            box.value = name;
+            box.checked = enabled.includes(name);
+            label.append(box, ' ' + name);
+            $('sensors').append(label);
+        });
+        if (defaults.has_token) $('token').placeholder = 'Leave empty to keep the configured token';
+        $('units').value = defaults.units || 'imperial';
+        $('units-pressure').value = defaults.units_pressure || 'inHg';
+
+        $('token-next').addEventListener('click', async () => {
+            try {
+                const stations = await post('/api/setup/stations', { token: $('token').value });
+                $('station').innerHTML = '';
+                stations.forEach((s) => {
+                    const opt = document.createElement('option');
+                    opt.value = s.name;
+                    opt.textContent = s.name + ' (' + s.id + ')';
+                    opt.selected = s.name === defaults.station;
+                    $('station').append(opt);
+                });
+                show('step-station');
+            } catch (err) {
+                $('error').textContent = err.message;
+            }
+        });
+
+        $('station-back').addEventListener('click', () => show('step-token'));
+
+        $('finish').addEventListener('click', async () => {
+            const sensors = Array.from(document.querySelectorAll('#sensors input:checked')).map((b) => b.value);
+            try {
+                await post('/api/setup/complete', {
+                    token: $('token').value,
+                    station: $('station').value,
+                    sensors: sensors.join(','),
+                    units: $('units').value,
+                    units_pressure: $('units-pressure').value
+                });
+                show('step-done');
+                // The dashboard replaces the wizard on the same port once the service is up
+                setTimeout(function poll() {
+                    fetch('/api/status').then((r) => { if (r.ok) location.reload(); else setTimeout(poll, 2000); })
+                        .catch(() => setTimeout(poll, 2000));
+                }, 2000);
+            } catch (err) {
+                $('error').textContent = err.message;
+            }
+        });
+    })();
+    </script>
+</body>
+</html>
+`
@@ -0,0 +1,99 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func newTestSetupWizard(defaults SetupChoices, save func(SetupChoices) error) *SetupWizard {
+	sw := NewSetupWizard("127.0.0.1:0", defaults, save)
+	sw.listStations = func(token string) ([]weather.Station, error) {
+		if token != "good-token" {
+			return nil, errors.New("API request failed with status 401")
+		}
+		return []weather.Station{{StationID: 2, Name: "Home"}, {StationID: 1, StationName: "Cabin"}}, nil
+	}
+	return sw
+}
+
+func setupRequest(sw *SetupWizard, method, path, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	sw.Handler().ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rr
+}
+
+func TestSetupWizardPageHidesToken(t *testing.T) {
+	sw := newTestSetupWizard(SetupChoices{Token: "secret-token", Units: "metric"}, nil)
+	rr := setupRequest(sw, http.MethodGet, "/", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET / = %d", rr.Code)
+	}
+	body := rr.Body.String()
+	if strings.Contains(body, "secret-token") {
+		t.Error("setup page leaked the configured token")
+	}
+	if !strings.Contains(body, `"has_token":true`) || !strings.Contains(body, `"units":"metric"`) {
+		t.Error("setup page missing defaults")
+	}
+}
+
+func TestSetupWizardStations(t *testing.T) {
+	sw := newTestSetupWizard(SetupChoices{}, nil)
+
+	rr := setupRequest(sw, http.MethodPost, "/api/setup/stations", `{"token":"good-token"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("stations = %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := strings.TrimSpace(rr.Body.String()); got != `[{"id":1,"name":"Cabin"},{"id":2,"name":"Home"}]` {
+		t.Errorf("stations = %s", got)
+	}
+
+	if rr := setupRequest(sw, http.MethodPost, "/api/setup/stations", `{"token":"bad"}`); rr.Code != http.StatusBadGateway {
+		t.Errorf("bad token = %d, want 502", rr.Code)
+	}
+	if rr := setupRequest(sw, http.MethodPost, "/api/setup/stations", `{}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("missing token = %d, want 400", rr.Code)
+	}
+}
+
+func TestSetupWizardComplete(t *testing.T) {
+	var saved []SetupChoices
+	save := func(c SetupChoices) error {
+		if c.Units == "kelvin" {
+			return errors.New("invalid units 'kelvin'")
+		}
+		saved = append(saved, c)
+		return nil
+	}
+	sw := newTestSetupWizard(SetupChoices{Token: "good-token"}, save)
+
+	rr := setupRequest(sw, http.MethodPost, "/api/setup/complete", `{"station":"Home","units":"kelvin"}`)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "kelvin") {
+		t.Errorf("invalid choices = %d %q", rr.Code, rr.Body.String())
+	}
+
+	rr = setupRequest(sw, http.MethodPost, "/api/setup/complete", `{"station":"Home","sensors":"temp","units":"metric"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("complete = %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(saved) != 1 || saved[0].Token != "good-token" || saved[0].StationName != "Home" {
+		t.Errorf("saved = %+v", saved)
+	}
+	select {
+	case c := <-sw.done:
+		if c.StationName != "Home" {
+			t.Errorf("done = %+v", c)
+		}
+	default:
+		t.Error("wizard not signalled as done")
+	}
+
+	if rr := setupRequest(sw, http.MethodPost, "/api/setup/complete", `{"station":"Home"}`); rr.Code != http.StatusConflict {
+		t.Errorf("second completion = %d, want 409", rr.Code)
+	}
+}
@@ -1 +0,0 @@
-Not found: /date-fns@2.29.3/dist/date-fns.min.js
\ No newline at end of file
@@ -17,6 +17,10 @@ if (urlParams.get('loglevel') === 'debug' || localStorage.getItem('loglevel') ==
 // Enhanced debug logger
 const debugLog = (typeof global !== 'undefined' && global.__JEST__) ? (() => {}) : console.log;
 
+// URL prefix when the dashboard is served under /station/<name>/ by a
+// multi-station host; empty at the root
+const BASE_PATH = (typeof document !== 'undefined' && document.querySelector('meta[name="base-path"]')?.content) || '';
+
 // Global variable to track data source type for better error messaging
 let currentDataSourceType = null;
 
@@ -24,7 +28,9 @@ let units = {
     temperature: localStorage.getItem('temperature-unit') || 'celsius',
     wind: localStorage.getItem('wind-unit') || 'mph',
     rain: localStorage.getItem('rain-unit') || 'inches',
-    pressure: localStorage.getItem('pressure-unit') || 'mb'
+    pressure: localStorage.getItem('pressure-unit') || 'mb',
+    // Lightning distances follow the server's --units, not the rain toggle
+    distance: localStorage.getItem('distance-unit') || 'mi'
 };
 
 // Friendly unit label helper (keeps formatting consistent with chart.html)
@@ -45,7 +51,7 @@ function prettyUnitLabel(key, val) {
 // Load units configuration from server
 async function loadUnitsConfig() {
     try {
-        const response = await fetch('/api/units');
+        const response = await fetch(BASE_PATH + '/api/units');
         const serverUnits = await response.json();
         
         // Map server units to client units
@@ -65,6 +71,7 @@ async function loadUnitsConfig() {
         
         // Set pressure units
         units.pressure = serverUnits.unitsPressure;
+        units.distance = serverUnits.unitsDistance || (serverUnits.units === 'metric' ? 'km' : 'mi');
         
         debugLog(logLevels.DEBUG, 'Loaded units config from server', serverUnits);
         debugLog(logLevels.DEBUG, 'Mapped client units', units);
@@ -74,6 +81,7 @@ async function loadUnitsConfig() {
         localStorage.setItem('wind-unit', units.wind);
         localStorage.setItem('rain-unit', units.rain);
         localStorage.setItem('pressure-unit', units.pressure);
+        localStorage.setItem('distance-unit', units.distance);
         
         return true;
     } catch (error) {
@@ -85,45 +93,18 @@ async function loadUnitsConfig() {
 let weatherData = null;
 let forecastData = null; // Store current forecast data for unit conversions
 let statusData = null; // Store current status data for unit conversions
+let lastHistoryRevision = null; // historyRevision from the last /api/status response
+let statusHistory = []; // dataHistory cached across /api/status polls; later polls fetch only newer points
+let statusHistoryRevision = null; // historyRevision statusHistory was built at
+let stationTimeZone = null; // IANA time zone daily totals reset in; null uses the browser's
 const charts = {};
 
 // Provide a global openChartPopout so click handlers can call it even if
-// forceChartColors() or other initialization hasn't finished. This mirrors
-// the per-dataset metadata encoding used by the internal helper.
-window.openChartPopout = window.openChartPopout || function(type, field, title, color) {
-    try {
-        const chartObj = charts[type];
-        const datasetsMeta = [];
-        if (chartObj && chartObj.data && Array.isArray(chartObj.data.datasets)) {
-            chartObj.data.datasets.forEach(ds => {
-                const meta = {};
-                if (ds.label) meta.label = ds.label;
-                if (ds.borderColor) meta.borderColor = ds.borderColor;
-                if (ds.backgroundColor) meta.backgroundColor = ds.backgroundColor;
-                if (ds.borderDash) meta.borderDash = ds.borderDash;
-                if (ds.borderWidth !== undefined) meta.borderWidth = ds.borderWidth;
-                if (ds.fill !== undefined) meta.fill = ds.fill;
-                if (ds.pointRadius !== undefined) meta.pointRadius = ds.pointRadius;
-                if (ds.tension !== undefined) meta.tension = ds.tension;
-                if (ds.pointStyle !== undefined) meta.pointStyle = ds.pointStyle;
-                if (ds.showLine !== undefined) meta.showLine = ds.showLine;
-                if (ds.stepped !== undefined) meta.stepped = ds.stepped;
-                if (ds.order !== undefined) meta.order = ds.order;
-                if (ds.spanGaps !== undefined) meta.spanGaps = ds.spanGaps;
-                if (String(ds.label).toLowerCase().includes('average')) meta.role = 'average';
-                if (String(ds.label).toLowerCase().includes('trend')) meta.role = 'trend';
-                if (String(ds.label).toLowerCase().includes('today') || String(ds.label).toLowerCase().includes('total')) meta.role = 'total';
-                datasetsMeta.push(meta);
-            });
-        }
-
-        const cfg = { type: type, field: field, title: title, color: color, units: units, datasets: datasetsMeta };
-        const encoded = encodeURIComponent(JSON.stringify(cfg));
-        const url = '/chart/' + type + '?config=' + encoded;
-        window.open(url, '_blank');
-    } catch (e) {
-        debugLog(logLevels.ERROR, 'Global openChartPopout failed', e);
-    }
+// forceChartColors() or other initialization hasn't finished. The popout page
+// gets its datasets, colors and unit labels from the server's chart config
+// registry (/api/chart-config/{sensor}).
+window.openChartPopout = window.openChartPopout || function(type) {
+    window.open(BASE_PATH + '/chart/' + type, '_blank');
 };
 
 // Expose minimal debug hooks for automated tests / headless browsers.
@@ -270,6 +251,7 @@ function initCharts() {
 
     // Set Chart.js default locale to ensure 24-hour format
     Chart.defaults.font.family = '-apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif';
+    Chart.register(annotationPlugin);
     
     // If this page is a chart popout, it contains a single canvas with id
     // `chart-canvas`. In that case build a minimal single chart and return.
@@ -278,152 +260,61 @@ function initCharts() {
         try {
             debugLog(logLevels.INFO, 'Detected chart popout canvas - creating minimal popout chart');
             
-            // Read configuration from URL parameters
-            const urlParams = new URLSearchParams(window.location.search);
-            const configParam = urlParams.get('config');
-            let popoutConfig = {};
-            if (configParam) {
+            // The server embeds the sensor's chart config (datasets, colors,
+            // unit labels) in the page; see /api/chart-config/{sensor}
+            let chartConfig = null;
+            const chartConfigEl = document.getElementById('chart-config');
+            if (chartConfigEl) {
                 try {
-                    popoutConfig = JSON.parse(decodeURIComponent(configParam));
-                    debugLog(logLevels.DEBUG, 'Popout config loaded from URL', popoutConfig);
+                    chartConfig = JSON.parse(chartConfigEl.textContent);
+                    debugLog(logLevels.DEBUG, 'Popout chart config loaded', chartConfig);
                 } catch (e) {
-                    debugLog(logLevels.WARN, 'Failed to parse popout config from URL', e);
-                }
-            }
-            
-            // Apply theme from config if available
-            if (popoutConfig.theme) {
-                document.body.setAttribute('data-theme', popoutConfig.theme);
-                debugLog(logLevels.INFO, 'Applied theme from popout config', { theme: popoutConfig.theme });
-                
-                // Update chart colors for dark themes
-                if (popoutConfig.theme === 'midnight') {
-                    updateChartsForDarkMode(true);
-                } else {
-                    updateChartsForDarkMode(false);
+                    debugLog(logLevels.WARN, 'Failed to parse popout chart config', e);
                 }
             }
             
-            // Detect chart type from URL path (/chart/temperature, /chart/humidity, etc.)
-            const urlPath = window.location.pathname;
-            const chartType = urlPath.split('/').pop(); // Gets 'temperature', 'humidity', etc.
+            // Fall back to the URL path (/chart/temperature, /chart/humidity, etc.)
+            const chartType = chartConfig ? chartConfig.sensor : window.location.pathname.split('/').pop();
             
-            // Prefer units passed in the popout config (from the dashboard) so the
-            // popout follows the same display units as the main console. Fall back
-            // to the locally-determined `units` if no config units are provided.
-            const popUnits = (popoutConfig && popoutConfig.units) ? popoutConfig.units : units;
-
-            // Map chart types to colors and labels, using `popUnits` for unit labels
-            const chartConfigs = {
-                'temperature': { color: '#ff6384', label: 'Temperature', unit: popUnits.temperature === 'celsius' ? '°C' : '°F' },
-                'humidity': { color: '#36a2eb', label: 'Humidity', unit: '%' },
-                'wind': { color: '#ffce56', label: 'Wind Speed', unit: popUnits.wind === 'mph' ? 'mph' : 'kph' },
-                'rain': { color: '#4bc0c0', label: 'Rain', unit: popUnits.rain === 'inches' ? 'in' : 'mm' },
-                'pressure': { color: '#9966ff', label: 'Pressure', unit: popUnits.pressure === 'mb' ? 'mb' : 'inHg' },
-                'light': { color: '#ff9f40', label: 'Light', unit: 'lux' },
-                'uv': { color: '#ff6384', label: 'UV Index', unit: 'UVI' }
-            };
+            // Popouts share localStorage with the dashboard, so `units` (and the
+            // theme, applied on DOMContentLoaded) already match the main console
+            const popUnits = units;
+            const unitLabels = (chartConfig && chartConfig.units) || {};
+            const unitSetting = chartConfig && chartConfig.unitKey ? popUnits[chartConfig.unitKey] : '';
+            const config = chartConfig
+                ? { color: chartConfig.color, label: chartConfig.title, unit: unitLabels[unitSetting] || unitSetting || '' }
+                : { color: '#666', label: 'Data', unit: '' };
             
-            const config = chartConfigs[chartType] || { color: '#666', label: 'Data', unit: '' };
+            // A second sensor (?overlay=) is bound to the right-hand axis
+            // with its own unit, as the chart config's axes describe
+            const overlayAxis = chartConfig && chartConfig.overlay ? (chartConfig.axes || []).find(a => a.id === 'y1') : null;
+            const overlayMeta = overlayAxis ? (chartConfig.datasets || []).find(d => d.role === 'overlay') : null;
+            const overlayUnitSetting = overlayAxis && overlayAxis.unitKey ? popUnits[overlayAxis.unitKey] : '';
+            const overlayUnit = overlayAxis ? ((overlayAxis.units || {})[overlayUnitSetting] || overlayUnitSetting || '') : '';
+            const overlayColor = overlayMeta ? overlayMeta.borderColor : '#333';
             
             const popCtx = popoutCanvas.getContext('2d');
             
-            // Build datasets array - data line first (underneath), then average/trend lines on top
-            // Light and UV charts don't need average lines
-            const datasets = [];
-            
-            // Main data line is always first (drawn underneath)
-            // For popout charts we want a solid data line with no point markers
-            datasets.push({ 
-                data: [], 
-                label: config.label,
-                borderColor: config.color,
-                backgroundColor: config.color + '1A',
-                fill: false,
-                tension: 0.4,
-                borderWidth: 3,
-                // Hide point markers on the main data series for clarity in popouts
-                pointRadius: 0,
-                pointHoverRadius: 6,
-                pointBackgroundColor: config.color,
-                pointBorderColor: '#fff',
-                pointBorderWidth: 0,
-                pointHoverBackgroundColor: config.color,
-                pointHoverBorderColor: '#fff',
-                pointHoverBorderWidth: 2
+            // Datasets come in drawing order: the data line first (underneath),
+            // then average/trend/accumulation lines on top
+            const datasetsMeta = (chartConfig && chartConfig.datasets) || [{ role: 'data', label: config.label, borderColor: config.color, backgroundColor: config.color + '1A', borderWidth: 3, tension: 0.4 }];
+            const datasets = datasetsMeta.map(meta => {
+                const ds = Object.assign({ data: [], fill: false, pointRadius: 0, tension: 0 }, meta);
+                if (meta.role === 'data') {
+                    // Hide point markers on the main data series; hover still highlights
+                    Object.assign(ds, {
+                        pointHoverRadius: 6,
+                        pointBackgroundColor: meta.borderColor,
+                        pointBorderColor: '#fff',
+                        pointBorderWidth: 0,
+                        pointHoverBackgroundColor: meta.borderColor,
+                        pointHoverBorderColor: '#fff',
+                        pointHoverBorderWidth: 2
+                    });
+                }
+                return ds;
             });
             
-            if (chartType !== 'light' && chartType !== 'uv') {
-                datasets.push({
-                    data: [],
-                    borderColor: '#00cc66',
-                    backgroundColor: 'rgba(0, 204, 102, 0.2)',
-                    borderDash: [5, 5],
-                    borderWidth: 2,
-                    fill: false,
-                    pointRadius: 0,
-                    tension: 0,
-                    label: 'Average'
-                });
-            }
-            
-            // Pressure chart needs a trend line (on top of average)
-            if (chartType === 'pressure') {
-                datasets.push({
-                    data: [],
-                    borderColor: '#ff6384',
-                    backgroundColor: 'rgba(255, 99, 132, 0.1)',
-                    borderDash: [2, 2],
-                    borderWidth: 1.5,
-                    fill: false,
-                    pointRadius: 0,
-                    tension: 0,
-                    label: 'Trend'
-                });
-            }
-            
-            // Rain chart needs Rain Intensity and Accumulation with dual Y-axes
-            if (chartType === 'rain') {
-                // Replace first dataset with Rain Intensity (rate in mm/hr)
-                datasets[0] = {
-                    data: [],
-                    label: 'Rain Intensity',
-                    borderColor: '#3b82f6',
-                    backgroundColor: 'rgba(59, 130, 246, 0.2)',
-                    fill: true,
-                    tension: 0.4,
-                    borderWidth: 2,
-                    pointRadius: 0,
-                    pointHoverRadius: 6,
-                    yAxisID: 'y'
-                };
-                // Add Accumulation dataset on right Y-axis
-                datasets.push({
-                    data: [],
-                    borderColor: '#8b5cf6',
-                    backgroundColor: 'transparent',
-                    borderWidth: 2,
-                    fill: false,
-                    pointRadius: 0,
-                    tension: 0.4,
-                    label: 'Accumulation',
-                    yAxisID: 'y1'
-                });
-                // Add Today Total reference line
-                datasets.push({
-                    data: [],
-                    borderColor: '#ff6b35',
-                    backgroundColor: 'rgba(255, 107, 53, 0.1)',
-                    borderDash: [3, 3],
-                    borderWidth: 3,
-                    fill: false,
-                    pointRadius: 0,
-                    tension: 0,
-                    label: 'Today Total',
-                    yAxisID: 'y1'
-                });
-            }
-            
             const popChart = new Chart(popCtx, {
                 type: 'line',
                 data: { datasets: datasets },
@@ -472,7 +363,7 @@ function initCharts() {
                                         }
                                     } else {
                                         formattedValue = value.toFixed(1);
-                                        unit = config.unit;
+                                        unit = context.dataset.role === 'overlay' ? overlayUnit : config.unit;
                                     }
                                     return label + ': ' + formattedValue + ' ' + unit;
                                 }
@@ -557,7 +448,27 @@ function initCharts() {
                                 font: { size: 16, weight: 'bold' },
                                 padding: { top: 10, bottom: 10 }
                             }
-                        } : undefined
+                        } : (overlayAxis ? {
+                            type: 'linear',
+                            display: true,
+                            position: 'right',
+                            beginAtZero: ['humidity', 'uv', 'light'].includes(overlayAxis.sensor),
+                            grid: { display: false },
+                            ticks: {
+                                maxTicksLimit: 8,
+                                color: overlayColor,
+                                font: { size: 14, weight: '500' },
+                                padding: 8,
+                                callback: function(value) { return value.toFixed(1); }
+                            },
+                            title: {
+                                display: true,
+                                text: overlayAxis.title + (overlayUnit ? ' (' + overlayUnit + ')' : ''),
+                                color: overlayColor,
+                                font: { size: 16, weight: 'bold' },
+                                padding: { top: 10, bottom: 10 }
+                            }
+                        } : undefined)
                     },
                     elements: {
                         // Default to no visible points on popout data lines; hover still highlights
@@ -583,7 +494,7 @@ function initCharts() {
             charts.popout = popChart;
             
             // Update the page title to reflect the chart type
-            const chartTitle = config.label + ' Chart';
+            const chartTitle = config.label + (overlayAxis ? ' + ' + overlayAxis.title : '') + ' Chart';
             document.title = chartTitle + ' - Tempest';
             
             const h1Element = document.querySelector('#chart-root h1');
@@ -604,6 +515,26 @@ function initCharts() {
                 });
             }
             
+            // Overlay selector: the server builds the combined config, so a
+            // change reloads the popout with ?overlay=
+            const overlaySelect = document.getElementById('overlay-sensor');
+            const overlayControl = document.getElementById('overlay-control');
+            if (overlaySelect && chartConfig && chartConfig.overlays && chartConfig.overlays.length > 0) {
+                chartConfig.overlays.forEach(o => overlaySelect.add(new Option(o.title, o.sensor)));
+                overlaySelect.value = chartConfig.overlay || '';
+                if (overlayControl) overlayControl.hidden = false;
+                overlaySelect.addEventListener('change', function() {
+                    const params = new URLSearchParams(window.location.search);
+                    if (this.value) {
+                        params.set('overlay', this.value);
+                    } else {
+                        params.delete('overlay');
+                    }
+                    const query = params.toString();
+                    window.location.href = window.location.pathname + (query ? '?' + query : '');
+                });
+            }
+            
             // Set up export buttons
             const exportCsvBtn = document.getElementById('export-csv');
             if (exportCsvBtn) {
@@ -619,6 +550,15 @@ function initCharts() {
                 });
             }
             
+            // The wind popout adds a wind rose of the same time range
+            if (chartType === 'wind') {
+                const roseHours = () => timeRangeSelect ? parseInt(timeRangeSelect.value) : 24;
+                loadWindRose(roseHours(), popUnits);
+                if (timeRangeSelect) {
+                    timeRangeSelect.addEventListener('change', () => loadWindRose(roseHours(), popUnits));
+                }
+            }
+
             debugLog(logLevels.INFO, 'Popout chart created successfully', { type: chartType, config: config });
             
             // Add window resize handler for popout charts
@@ -734,7 +674,7 @@ function initCharts() {
             csv += dataset.label || `Dataset ${index}`;
             if (index < chart.data.datasets.length - 1) csv += ',';
         });
-        csv += '\n';
+        csv += ',Annotation\n';
 
         // Get all unique timestamps
         const timestamps = new Set();
@@ -759,6 +699,9 @@ function initCharts() {
                 csv += point ? (point.y !== undefined ? point.y : '') : '';
                 if (index < chart.data.datasets.length - 1) csv += ',';
             });
+            // Notes covering the reading, quoted as they are free text
+            const notes = annotationsBetween(date.getTime(), date.getTime()).map(a => a.text).join('; ');
+            csv += ',' + (notes ? '"' + notes.replace(/"/g, '""') + '"' : '');
             csv += '\n';
         });
 
@@ -782,6 +725,13 @@ function initCharts() {
                 }))
             }))
         };
+        // Notes overlapping the exported readings
+        const times = chart.data.datasets.flatMap(dataset => (dataset.data || []).map(point => new Date(point.x).getTime()));
+        exportData.annotations = times.length === 0 ? [] : annotationsBetween(Math.min(...times), Math.max(...times)).map(a => ({
+            start: new Date(a.start).toISOString(),
+            end: new Date(a.end).toISOString(),
+            text: a.text
+        }));
 
         const json = JSON.stringify(exportData, null, 2);
         downloadFile(json, filename, 'application/json');
@@ -905,7 +855,7 @@ function initCharts() {
     });
         // clicking on a chart should open a pop-out detailed chart page
         document.getElementById('temperature-chart').addEventListener('click', function(){
-            openChartPopout('temperature', 'temperature', 'Temperature', charts.temperature.data.datasets[0].borderColor);
+            openChartPopout('temperature');
         });
     }
     
@@ -961,7 +911,7 @@ function initCharts() {
         }
     });
         document.getElementById('humidity-chart').addEventListener('click', function(){
-            openChartPopout('humidity', 'humidity', 'Humidity', charts.humidity.data.datasets[0].borderColor);
+            openChartPopout('humidity');
         });
     }
     
@@ -1017,7 +967,7 @@ function initCharts() {
         }
     });
         document.getElementById('wind-chart').addEventListener('click', function(){
-            openChartPopout('wind', 'windSpeed', 'Wind Speed', charts.wind.data.datasets[0].borderColor);
+            openChartPopout('wind');
         });
     }
 
@@ -1122,7 +1072,10 @@ function initCharts() {
                                 label += ': ';
                             }
                             if (context.parsed.y !== null) {
-                                if (context.datasetIndex === 0) {
+                                if (context.dataset.yAxisID === 'yForecast') {
+                                    // Forecast chance of rain
+                                    label += context.parsed.y.toFixed(0) + '%';
+                                } else if (context.datasetIndex === 0) {
                                     // Rain Intensity
                                     const unit = units.rain === 'inches' ? 'in/hr' : 'mm/hr';
                                     label += context.parsed.y.toFixed(3) + ' ' + unit;
@@ -1140,7 +1093,7 @@ function initCharts() {
         }
     });
         document.getElementById('rain-chart').addEventListener('click', function(){
-            openChartPopout('rain', 'rainAccum', 'Rain', charts.rain.data.datasets[0].borderColor);
+            openChartPopout('rain');
         });
     }
 
@@ -1197,7 +1150,7 @@ function initCharts() {
         }
     });
         document.getElementById('pressure-chart').addEventListener('click', function(){
-            openChartPopout('pressure', 'pressure', 'Pressure', charts.pressure.data.datasets[0].borderColor);
+            openChartPopout('pressure');
         });
     }
 
@@ -1234,7 +1187,7 @@ function initCharts() {
         }
     });
         document.getElementById('light-chart').addEventListener('click', function(){
-            openChartPopout('light', 'illuminance', 'Illuminance', charts.light.data.datasets[0].borderColor);
+            openChartPopout('light');
         });
     }
 
@@ -1341,65 +1294,14 @@ function forceChartColors() {
         const uvCanvasEl = document.getElementById('uv-chart');
         if (uvCanvasEl) {
             uvCanvasEl.addEventListener('click', function(){
-                openChartPopout('uv', 'uv', 'UV Index', charts.uv.data.datasets[0].borderColor);
+                openChartPopout('uv');
             });
         }
     }
 
-    // helper to open popout chart page with encoded configuration
-    function openChartPopout(type, field, title, color) {
-        try {
-            // Build a compact per-dataset metadata payload so the popout can mirror
-            // the small-card chart visuals exactly (colors, dashes, widths, fill, etc).
-            const chartObj = charts[type];
-            const datasetsMeta = [];
-            if (chartObj && chartObj.data && Array.isArray(chartObj.data.datasets)) {
-                chartObj.data.datasets.forEach(ds => {
-                    const meta = {};
-                    if (ds.label) meta.label = ds.label;
-                    if (ds.borderColor) meta.borderColor = ds.borderColor;
-                    if (ds.backgroundColor) meta.backgroundColor = ds.backgroundColor;
-                    if (ds.borderDash) meta.borderDash = ds.borderDash;
-                    if (ds.borderWidth !== undefined) meta.borderWidth = ds.borderWidth;
-                    if (ds.fill !== undefined) meta.fill = ds.fill;
-                    if (ds.pointRadius !== undefined) meta.pointRadius = ds.pointRadius;
-                    if (ds.tension !== undefined) meta.tension = ds.tension;
-                    if (ds.pointStyle !== undefined) meta.pointStyle = ds.pointStyle;
-                    if (ds.showLine !== undefined) meta.showLine = ds.showLine;
-                    if (ds.stepped !== undefined) meta.stepped = ds.stepped;
-                    if (ds.order !== undefined) meta.order = ds.order;
-                    if (ds.spanGaps !== undefined) meta.spanGaps = ds.spanGaps;
-                    // mark a simple role hint for common lines (Average/Trend/Today Total)
-                    if (String(ds.label).toLowerCase().includes('average')) meta.role = 'average';
-                    if (String(ds.label).toLowerCase().includes('trend')) meta.role = 'trend';
-                    if (String(ds.label).toLowerCase().includes('today') || String(ds.label).toLowerCase().includes('total')) meta.role = 'total';
-                    datasetsMeta.push(meta);
-                });
-            }
-
-            // Build an incomingUnits hint to inform the popout about the units the server/raw data used.
-            // Best-effort: if statusData or weatherData expose unit hints, prefer those; otherwise fall back to current `units` (safe default).
-            let incomingUnits = null;
-            try {
-                if (statusData && statusData.unitHints) {
-                    incomingUnits = statusData.unitHints;
-                } else if (weatherData && weatherData.unitHints) {
-                    incomingUnits = weatherData.unitHints;
-                } else {
-                    // fallback: assume incoming units match the current UI units
-                    incomingUnits = Object.assign({}, units);
-                }
-            } catch (e) {
-                incomingUnits = Object.assign({}, units);
-            }
-
-            const cfg = { type: type, field: field, title: title, color: color, units: units, incomingUnits: incomingUnits, datasets: datasetsMeta, theme: localStorage.getItem('theme') || 'default' };
-            const encoded = encodeURIComponent(JSON.stringify(cfg));
-            const url = '/chart/' + type + '?config=' + encoded;
-            window.open(url, '_blank');
-        } catch(e) {
-            debugLog(logLevels.ERROR, 'Failed to open chart popout', e);
-        }
+    // helper to open the popout chart page; the server embeds the chart config
+    function openChartPopout(type) {
+        window.open(BASE_PATH + '/chart/' + type, '_blank');
     }
     
     // Rain: Purple data → Yellow-green average → Orange 24h total → Cyan window total
@@ -1700,6 +1602,49 @@ function mmToInches(mm) {
     return mm / 25.4;
 }
 
+// Calendar date (YYYY-MM-DD) of a timestamp in the station time zone
+function stationDateKey(ts) {
+    if (stationTimeZone) {
+        try {
+            return new Intl.DateTimeFormat('en-CA', { timeZone: stationTimeZone, year: 'numeric', month: '2-digit', day: '2-digit' }).format(new Date(ts));
+        } catch (e) {
+            // Unknown zone in this browser; fall back to local time
+        }
+    }
+    const d = new Date(ts);
+    return `${d.getFullYear()}-${String(d.getMonth() + 1).padStart(2, '0')}-${String(d.getDate()).padStart(2, '0')}`;
+}
+
+// Timestamp of the first station-time midnight after ts. DST days are 23 or
+// 25 hours long, so the estimate from the wall clock is corrected once.
+function nextStationMidnight(ts) {
+    const localMidnight = () => {
+        const d = new Date(ts);
+        d.setHours(24, 0, 0, 0);
+        return d.getTime();
+    };
+    if (!stationTimeZone) {
+        return localMidnight();
+    }
+    const secondsIntoDay = t => {
+        const parts = {};
+        new Intl.DateTimeFormat('en-GB', { timeZone: stationTimeZone, hourCycle: 'h23', hour: '2-digit', minute: '2-digit', second: '2-digit' })
+            .formatToParts(new Date(t)).forEach(p => { parts[p.type] = Number(p.value); });
+        return parts.hour * 3600 + parts.minute * 60 + parts.second;
+    };
+    try {
+        const base = Math.floor(ts / 1000) * 1000;
+        let midnight = base + (86400 - secondsIntoDay(base)) * 1000;
+        const off = secondsIntoDay(midnight);
+        if (off !== 0) {
+            midnight += (off < 43200 ? -off : 86400 - off) * 1000;
+        }
+        return midnight > ts ? midnight : midnight + 86400 * 1000;
+    } catch (e) {
+        return localMidnight();
+    }
+}
+
 function mbToInHg(mb) {
     return mb * 0.02953;
 }
@@ -1760,6 +1705,11 @@ function kmToMiles(km) {
     return km / 1.60934;
 }
 
+// formatDistance converts a distance in km to the configured distance unit
+function formatDistance(km) {
+    return (units.distance === 'mi' ? kmToMiles(km) : km).toFixed(1);
+}
+
 function milesToKm(miles) {
     return miles * 1.60934;
 }
@@ -2483,6 +2433,10 @@ function updateDisplay() {
     } else {
         document.getElementById('wind-gust-info').textContent = 'No gusts detected';
     }
+    if (weatherData.peakGustToday > 0) {
+        const peakTime = weatherData.peakGustTime ? ' at ' + new Date(weatherData.peakGustTime).toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' }) : '';
+        document.getElementById('wind-gust-info').textContent += ` · peak today ${formatWindSpeed(weatherData.peakGustToday)}${peakTime}`;
+    }
 
     const direction = degreesToDirection(weatherData.windDirection);
     document.getElementById('wind-direction').textContent = direction + ' (' + weatherData.windDirection.toFixed(0) + '°)';
@@ -2534,6 +2488,16 @@ function updateDisplay() {
         });
     }
     
+    // Estimated snowfall, shown only while there is some
+    const snowInfoElement = document.getElementById('snow-info');
+    if (snowInfoElement) {
+        const snowRate = weatherData.snowRate || 0;
+        const snowDaily = weatherData.snowDaily || 0;
+        snowInfoElement.style.display = (snowRate > 0 || snowDaily > 0) ? '' : 'none';
+        document.getElementById('snow-rate').textContent = formatSnow(snowRate) + '/hr';
+        document.getElementById('snow-daily').textContent = formatSnow(snowDaily);
+    }
+
     // Lightning data
     const lightningCountElement = document.getElementById('lightning-count');
     const lightningDistanceElement = document.getElementById('lightning-distance');
@@ -2544,14 +2508,19 @@ function updateDisplay() {
     }
     
     if (lightningDistanceElement && lightningDistanceUnitElement) {
-        let lightningDistance = weatherData.lightningStrikeAvg || 0;
-        if (units.rain === 'inches') {
-            lightningDistance = kmToMiles(lightningDistance);
-            lightningDistanceUnitElement.textContent = 'mi';
-        } else {
-            lightningDistanceUnitElement.textContent = 'km';
+        lightningDistanceElement.textContent = formatDistance(weatherData.lightningStrikeAvg || 0);
+        lightningDistanceUnitElement.textContent = units.distance;
+    }
+
+    // Storm summary: nearest strike since the storm began and the strike rate
+    const lightningStormElement = document.getElementById('lightning-storm');
+    if (lightningStormElement) {
+        const nearest = weatherData.lightningNearest || 0;
+        lightningStormElement.style.display = nearest > 0 ? '' : 'none';
+        if (nearest > 0) {
+            document.getElementById('lightning-nearest').textContent = formatDistance(nearest) + ' ' + units.distance;
+            document.getElementById('lightning-rate').textContent = (weatherData.lightningRate || 0).toFixed(1);
         }
-        lightningDistanceElement.textContent = lightningDistance.toFixed(1);
     }
     
     debugLog(logLevels.DEBUG, 'Rain and lightning data updated', {
@@ -2562,7 +2531,9 @@ function updateDisplay() {
         rainUnit: units.rain,
         rainDescription: getRainDescription(rainMm),
         lightningCount: weatherData.lightningStrikeCount,
-        lightningDistance: weatherData.lightningStrikeAvg
+        lightningDistance: weatherData.lightningStrikeAvg,
+        lightningNearest: weatherData.lightningNearest,
+        lightningRate: weatherData.lightningRate
     });
 
     let pressure = weatherData.pressure;
@@ -2624,6 +2595,18 @@ function updateDisplay() {
     const uvDescElement = document.getElementById('uv-description');
     if (uvElement) uvElement.textContent = Math.round(weatherData.uv);
     if (uvDescElement) uvDescElement.textContent = getUVDescription(weatherData.uv);
+
+    // Daily doses from the statistics engine
+    const lightDoseElement = document.getElementById('light-dose');
+    if (lightDoseElement) {
+        const solar = weatherData.solarEnergyToday || 0;
+        const uvDose = weatherData.uvDoseToday || 0;
+        lightDoseElement.style.display = (solar > 0 || uvDose > 0) ? '' : 'none';
+        document.getElementById('solar-energy-today').textContent = solar >= 1000
+            ? (solar / 1000).toFixed(2) + ' kWh/m²'
+            : solar.toFixed(0) + ' Wh/m²';
+        document.getElementById('uv-dose-today').textContent = uvDose.toFixed(1) + ' SED';
+    }
     
     debugLog(logLevels.DEBUG, 'Light and UV data updated', {
         illuminance: weatherData.illuminance,
@@ -2824,47 +2807,48 @@ function updateCharts() {
     // Update popout chart if present (for expanded chart views)
     if (charts.popout && charts.popout.data && charts.popout.data.datasets && charts.popout.data.datasets.length > 0) {
         // Determine which data to show based on the chart type stored during initialization
-        let popoutValue = 0;
         const chartType = charts.popoutType || 'temperature';
         // Main data is the first dataset in popout charts (drawn underneath)
         const datasetIndex = 0;
         
-        switch(chartType) {
-            case 'temperature':
-                popoutValue = tempValue;
-                break;
-            case 'humidity':
-                popoutValue = humidityValue;
-                break;
-            case 'wind':
-                popoutValue = windValue;
-                break;
-            case 'rain':
-                // Use rain rate (intensity) for rain popup charts
-                // Backend sends in mm/hr, convert to inches/hr if user prefers inches
-                popoutValue = (typeof weatherData.rainRate === 'number' && Number.isFinite(weatherData.rainRate)) ? weatherData.rainRate : 0;
-                if (units.rain === 'inches') {
-                    popoutValue = mmToInches(popoutValue); // Convert mm/hr to in/hr
+        const liveValue = sensor => {
+            switch(sensor) {
+                case 'temperature':
+                    return tempValue;
+                case 'humidity':
+                    return humidityValue;
+                case 'wind':
+                    return windValue;
+                case 'rain': {
+                    // Use rain rate (intensity) for rain popup charts
+                    // Backend sends in mm/hr, convert to inches/hr if user prefers inches
+                    const rate = (typeof weatherData.rainRate === 'number' && Number.isFinite(weatherData.rainRate)) ? weatherData.rainRate : 0;
+                    return units.rain === 'inches' ? mmToInches(rate) : rate; // Convert mm/hr to in/hr
                 }
-                break;
-            case 'pressure':
-                popoutValue = pressureValue;
-                break;
-            case 'light':
-                popoutValue = illuminanceValue;
-                break;
-            case 'uv':
-                const uvVal = (typeof weatherData.uv === 'number' && Number.isFinite(weatherData.uv)) ? weatherData.uv : 0;
-                popoutValue = uvVal;
-                break;
-            default:
-                popoutValue = tempValue;
-        }
+                case 'pressure':
+                    return pressureValue;
+                case 'light':
+                    return illuminanceValue;
+                case 'uv':
+                    return (typeof weatherData.uv === 'number' && Number.isFinite(weatherData.uv)) ? weatherData.uv : 0;
+                default:
+                    return tempValue;
+            }
+        };
+        const popoutValue = liveValue(chartType);
         
         charts.popout.data.datasets[datasetIndex].data.push({ x: now, y: popoutValue });
         if (charts.popout.data.datasets[datasetIndex].data.length > maxDataPoints) {
             charts.popout.data.datasets[datasetIndex].data.shift();
         }
+        // The overlaid sensor, on the right-hand axis
+        const overlayDs = charts.popout.data.datasets.find(ds => ds.role === 'overlay');
+        if (overlayDs) {
+            overlayDs.data.push({ x: now, y: liveValue(overlayDs.sensor) });
+            if (overlayDs.data.length > maxDataPoints) {
+                overlayDs.data.shift();
+            }
+        }
         
         // Update average line (dataset[1]) - skip for light and UV
         const mainData = charts.popout.data.datasets[datasetIndex].data;
@@ -2905,14 +2889,8 @@ function updateCharts() {
                 // per-point "today total" (step) so previous days are shown as a flat
                 // total and the current day increases from midnight to now.
                 if (mainData.length > 0) {
-                    // Helper: local date key (YYYY-MM-DD) for grouping by day
-                    const localDateKey = ts => {
-                        const d = new Date(ts);
-                        const y = d.getFullYear();
-                        const m = String(d.getMonth() + 1).padStart(2, '0');
-                        const day = String(d.getDate()).padStart(2, '0');
-                        return `${y}-${m}-${day}`;
-                    };
+                    // Group by the station's calendar day
+                    const localDateKey = stationDateKey;
 
                     // Running totals per day and cumulative since midnight per point
                     const runningByDay = {};
@@ -2937,10 +2915,7 @@ function updateCharts() {
                         // Walk the interval and split across midnights
                         let segStart = t0;
                         while (segStart < t1) {
-                            const segStartDate = new Date(segStart);
-                            const nextMidnight = new Date(segStartDate);
-                            nextMidnight.setHours(24, 0, 0, 0);
-                            const segEnd = Math.min(t1, nextMidnight.getTime());
+                            const segEnd = Math.min(t1, nextStationMidnight(segStart));
                             const segHours = (segEnd - segStart) / (1000 * 60 * 60);
                             const delta = intensityMm * segHours; // mm
                             const dayKey = localDateKey(segStart);
@@ -3145,6 +3120,14 @@ function formatRain(mm) {
     return `${mm.toFixed(1)} mm`;
 }
 
+// Snow depths follow the rain unit: inches, or centimetres for metric
+function formatSnow(mm) {
+    if (units.rain === 'inches') {
+        return `${(mm * 0.0393701).toFixed(1)} in`;
+    }
+    return `${(mm / 10).toFixed(1)} cm`;
+}
+
 function formatRainRate(mmPerHour) {
     if (units.rain === 'inches') {
         return `${(mmPerHour * 0.0393701).toFixed(2)} in/hr`;
@@ -3152,12 +3135,372 @@ function formatRainRate(mmPerHour) {
     return `${mmPerHour.toFixed(1)} mm/hr`;
 }
 
+// Comparison overlays: each chart card gets a selector that draws the same
+// day or week of an earlier period from /api/history/compare as a dashed line.
+// Charts with a forecast entry can also show the hourly forecast issued for
+// each past hour (/api/history/forecast): temperature in the chart's unit,
+// the chance of precipitation on its own 0-100% axis.
+const compareCharts = {
+    temperature: {
+        field: 'temperature',
+        convert: v => units.temperature === 'fahrenheit' ? celsiusToFahrenheit(v) : v,
+        forecast: { label: 'Forecast', value: h => h.airTemperature }
+    },
+    humidity: { field: 'humidity', convert: v => v },
+    wind: { field: 'windSpeed', convert: v => units.wind === 'kph' ? mphToKph(v) : v },
+    rain: {
+        field: 'rain',
+        convert: v => units.rain === 'inches' ? mmToInches(v) : v,
+        yAxisID: 'y1',
+        forecast: { label: 'Chance of rain', value: h => h.precipProbability, percent: true }
+    },
+    pressure: { field: 'pressure', convert: v => units.pressure === 'inHg' ? mbToInHg(v) : v },
+    light: { field: 'illuminance', convert: v => v },
+    uv: { field: 'uv', convert: v => v }
+};
+const compareModes = {};
+const compareOptions = [
+    { value: '', label: 'Compare: off' },
+    { value: 'day:previous', label: 'vs yesterday' },
+    { value: 'week:previous', label: 'vs last week' },
+    { value: 'week:year', label: 'vs last year' },
+    { value: 'forecast', label: 'vs forecast' }
+];
+
+function initCompareControls() {
+    Object.keys(compareCharts).forEach(name => {
+        const canvas = document.getElementById(`${name}-chart`);
+        const container = canvas ? canvas.closest('.chart-container') : null;
+        if (!container || container.parentElement.querySelector('.compare-select')) return;
+        const select = document.createElement('select');
+        select.className = 'compare-select';
+        select.setAttribute('aria-label', 'Compare with an earlier period');
+        compareOptions.forEach(opt => {
+            if (opt.value === 'forecast' && !compareCharts[name].forecast) return;
+            const option = document.createElement('option');
+            option.value = opt.value;
+            option.textContent = opt.label;
+            select.appendChild(option);
+        });
+        select.addEventListener('change', () => {
+            compareModes[name] = select.value;
+            updateComparison(name);
+        });
+        container.parentElement.insertBefore(select, container);
+    });
+    // Refresh active overlays as the current period fills in
+    setInterval(() => Object.keys(compareModes).forEach(name => { if (compareModes[name]) updateComparison(name); }), 15 * 60 * 1000);
+}
+
+async function updateComparison(name) {
+    const chart = charts[name];
+    if (!chart || !chart.data || !chart.data.datasets) return;
+    const existing = chart.data.datasets.findIndex(ds => ds.isComparison);
+    if (existing >= 0) chart.data.datasets.splice(existing, 1);
+    const mode = compareModes[name];
+    if (mode !== 'forecast' && chart.options.scales) delete chart.options.scales.yForecast;
+    if (!mode) {
+        chart.update('none');
+        return;
+    }
+    if (mode === 'forecast') {
+        await updateForecastComparison(name);
+        return;
+    }
+    const [period, against] = mode.split(':');
+    const cfg = compareCharts[name];
+    try {
+        const response = await fetch(BASE_PATH + `/api/history/compare?field=${cfg.field}&period=${period}&against=${against}`);
+        if (!response.ok) throw new Error(`HTTP ${response.status}`);
+        const data = await response.json();
+        // Align the earlier period's buckets with the current period's time axis
+        const points = [];
+        data.previous.values.forEach((v, i) => {
+            if (v === null) return;
+            points.push({ x: new Date((data.current.start + i * data.bucketSeconds) * 1000), y: cfg.convert(v) });
+        });
+        if (compareModes[name] !== mode) return; // changed while loading
+        const dataset = {
+            data: points,
+            label: compareOptions.find(opt => opt.value === mode).label.replace('vs ', ''),
+            borderColor: '#888',
+            backgroundColor: 'rgba(136, 136, 136, 0.1)',
+            borderDash: [2, 3],
+            borderWidth: 1.5,
+            fill: false,
+            pointRadius: 0,
+            tension: 0.2,
+            spanGaps: true,
+            isComparison: true
+        };
+        if (cfg.yAxisID && chart.options.scales && chart.options.scales[cfg.yAxisID]) dataset.yAxisID = cfg.yAxisID;
+        const again = chart.data.datasets.findIndex(ds => ds.isComparison);
+        if (again >= 0) chart.data.datasets.splice(again, 1);
+        chart.data.datasets.push(dataset);
+        chart.update('none');
+        if (data.previous.error) debugLog(logLevels.WARN, `Comparison data for ${name} incomplete`, { error: data.previous.error });
+    } catch (error) {
+        debugLog(logLevels.ERROR, `Failed to load comparison for ${name}`, { error: error.message });
+    }
+}
+
+async function updateForecastComparison(name) {
+    const chart = charts[name];
+    const cfg = compareCharts[name];
+    // Only the hours the chart already shows
+    const line = chart.data.datasets[0] ? chart.data.datasets[0].data : [];
+    const since = line.length > 0 ? Math.floor(new Date(line[0].x).getTime() / 1000) : 0;
+    try {
+        const response = await fetch(BASE_PATH + `/api/history/forecast?since=${since}`);
+        if (!response.ok) throw new Error(`HTTP ${response.status}`);
+        const data = await response.json();
+        if (compareModes[name] !== 'forecast') return; // changed while loading
+        const points = data.hours.map(h => {
+            const v = cfg.forecast.value(h);
+            return { x: new Date(h.time * 1000), y: cfg.forecast.percent ? v : cfg.convert(v) };
+        });
+        const dataset = {
+            data: points,
+            label: cfg.forecast.label,
+            borderColor: '#d97706',
+            backgroundColor: 'rgba(217, 119, 6, 0.1)',
+            borderDash: [6, 4],
+            borderWidth: 1.5,
+            fill: false,
+            pointRadius: 2,
+            stepped: true, // each forecast holds for its hour
+            spanGaps: true,
+            isComparison: true
+        };
+        if (cfg.forecast.percent) {
+            chart.options.scales.yForecast = {
+                type: 'linear',
+                display: window.innerWidth >= 600,
+                position: 'right',
+                min: 0,
+                max: 100,
+                title: { display: true, text: `${cfg.forecast.label} (%)`, color: '#d97706', font: { size: 11, weight: 'bold' } },
+                grid: { display: false },
+                ticks: { color: '#d97706', font: { size: 8 }, padding: 2, maxTicksLimit: 3 }
+            };
+            dataset.yAxisID = 'yForecast';
+        }
+        const again = chart.data.datasets.findIndex(ds => ds.isComparison);
+        if (again >= 0) chart.data.datasets.splice(again, 1);
+        chart.data.datasets.push(dataset);
+        chart.update('none');
+    } catch (error) {
+        debugLog(logLevels.ERROR, `Failed to load the forecast for ${name}`, { error: error.message });
+    }
+}
+
+// Wind rose: /api/windrose bins the wind history by direction and speed
+// class; drawn as stacked wedges, light to dark with increasing speed
+const windRoseColors = ['#cde8f6', '#8ecae6', '#219ebc', '#2a9d8f', '#f4a261', '#e76f51'];
+
+async function loadWindRose(hours, displayUnits) {
+    const container = document.getElementById('windrose-container');
+    const canvas = document.getElementById('windrose-canvas');
+    if (!container || !canvas) return;
+    try {
+        const response = await fetch(BASE_PATH + `/api/windrose?hours=${hours}`);
+        if (!response.ok) throw new Error(`HTTP ${response.status}`);
+        const rose = await response.json();
+        container.hidden = false;
+        drawWindRose(canvas, rose);
+        renderWindRoseLegend(rose, (displayUnits || units).wind);
+    } catch (error) {
+        debugLog(logLevels.ERROR, 'Failed to load wind rose', { error: error.message });
+    }
+}
+
+function drawWindRose(canvas, rose) {
+    const ctx = canvas.getContext('2d');
+    const size = Math.min(canvas.width, canvas.height);
+    const cx = canvas.width / 2, cy = canvas.height / 2;
+    const radius = size / 2 - 30;
+    const styles = getComputedStyle(document.body);
+    const textColor = styles.getPropertyValue('--card-text').trim() || '#333';
+    ctx.clearRect(0, 0, canvas.width, canvas.height);
+
+    const maxTotal = Math.max(...rose.sectors.map(s => s.total), 1);
+    // Rings at quarters of the busiest sector
+    ctx.strokeStyle = 'rgba(128,128,128,0.35)';
+    ctx.fillStyle = textColor;
+    ctx.font = '11px sans-serif';
+    for (let ring = 1; ring <= 4; ring++) {
+        const r = radius * ring / 4;
+        ctx.beginPath();
+        ctx.arc(cx, cy, r, 0, 2 * Math.PI);
+        ctx.stroke();
+        ctx.fillText(`${(maxTotal * ring / 4).toFixed(1)}%`, cx + 3, cy - r - 2);
+    }
+
+    const width = 2 * Math.PI / rose.sectors.length;
+    rose.sectors.forEach(sector => {
+        // 0° is north at the top, clockwise
+        const center = sector.direction * Math.PI / 180 - Math.PI / 2;
+        const start = center - width * 0.45, end = center + width * 0.45;
+        let inner = 0;
+        sector.frequency.forEach((pct, c) => {
+            if (pct <= 0) return;
+            const outer = inner + pct;
+            ctx.beginPath();
+            ctx.arc(cx, cy, radius * outer / maxTotal, start, end);
+            ctx.arc(cx, cy, radius * inner / maxTotal, end, start, true);
+            ctx.closePath();
+            ctx.fillStyle = windRoseColors[c % windRoseColors.length];
+            ctx.fill();
+            inner = outer;
+        });
+    });
+
+    ctx.fillStyle = textColor;
+    ctx.font = 'bold 14px sans-serif';
+    ctx.textAlign = 'center';
+    ctx.textBaseline = 'middle';
+    [['N', 0], ['E', 90], ['S', 180], ['W', 270]].forEach(([label, deg]) => {
+        const a = deg * Math.PI / 180 - Math.PI / 2;
+        ctx.fillText(label, cx + (radius + 15) * Math.cos(a), cy + (radius + 15) * Math.sin(a));
+    });
+    ctx.textAlign = 'start';
+    ctx.textBaseline = 'alphabetic';
+}
+
+function renderWindRoseLegend(rose, windUnit) {
+    // Speed classes are in m/s; label them in the dashboard's wind unit
+    const factor = windUnit === 'kph' ? 3.6 : 2.23694;
+    const unit = windUnit === 'kph' ? 'kph' : 'mph';
+    const legend = document.getElementById('windrose-legend');
+    if (legend) {
+        legend.innerHTML = rose.speedClasses.map((c, i) => {
+            const range = c.max === null ? `${(c.min * factor).toFixed(0)}+` : `${(c.min * factor).toFixed(0)}–${(c.max * factor).toFixed(0)}`;
+            return `<span class="windrose-swatch" style="background:${windRoseColors[i % windRoseColors.length]}"></span>${range} ${unit}`;
+        }).join(' ');
+    }
+    const summary = document.getElementById('windrose-summary');
+    if (summary) {
+        summary.textContent = rose.samples === 0 ? '(no data)' :
+            `(${rose.samples} readings, calm ${rose.calm.toFixed(0)}%${rose.prevailing ? ', prevailing ' + rose.prevailing : ''})`;
+    }
+}
+
+// Anomaly markers: readings the server's detector flagged are drawn as red
+// points on the chart that plots the field (wind gusts have no chart line)
+const anomalyCharts = { temperature: 'temperature', humidity: 'humidity', pressure: 'pressure', wind_speed: 'wind' };
+
+async function updateAnomalyMarkers() {
+    let report;
+    try {
+        const response = await fetch(BASE_PATH + '/api/anomalies');
+        if (!response.ok) return; // detection not running
+        report = await response.json();
+    } catch (error) {
+        debugLog(logLevels.DEBUG, 'Failed to load anomalies', { error: error.message });
+        return;
+    }
+    Object.values(anomalyCharts).forEach(name => {
+        const chart = charts[name];
+        if (!chart || !chart.data || !chart.data.datasets || !chart.data.datasets[0]) return;
+        // Only mark readings within the time range the chart already shows
+        const line = chart.data.datasets[0].data;
+        const oldest = line.length > 0 ? new Date(line[0].x).getTime() : Date.now();
+        const points = (report.recent || [])
+            .filter(a => anomalyCharts[a.field] === name && a.timestamp * 1000 >= oldest)
+            .map(a => ({ x: new Date(a.timestamp * 1000), y: compareCharts[name].convert(a.value), score: a.score }));
+        let dataset = chart.data.datasets.find(ds => ds.isAnomaly);
+        if (!dataset) {
+            if (points.length === 0) return;
+            dataset = {
+                data: [],
+                label: 'Anomaly',
+                borderColor: '#e53935',
+                backgroundColor: '#e53935',
+                showLine: false,
+                pointRadius: 5,
+                pointHoverRadius: 7,
+                pointStyle: 'triangle',
+                isAnomaly: true
+            };
+            chart.data.datasets.push(dataset);
+        }
+        dataset.data = points;
+        chart.update('none');
+    });
+}
+
+// Annotations: notes users attach to time ranges (/api/annotations) are drawn
+// on every time-axis chart as a shaded band, or a line for a moment, with the
+// note on top so readings like a sprinkler spike explain themselves
+let chartAnnotations = [];
+
+async function updateAnnotations() {
+    try {
+        const response = await fetch(BASE_PATH + '/api/annotations');
+        if (!response.ok) return; // annotations not available
+        chartAnnotations = (await response.json()).map(a => ({
+            id: a.id,
+            start: new Date(a.start).getTime(),
+            end: new Date(a.end).getTime(),
+            text: a.text
+        }));
+    } catch (error) {
+        debugLog(logLevels.DEBUG, 'Failed to load annotations', { error: error.message });
+        return;
+    }
+    Object.values(charts).forEach(chart => {
+        if (chart && typeof chart.update === 'function') chart.update('none');
+    });
+}
+
+// annotationsBetween returns the annotations overlapping [from, to] (ms)
+function annotationsBetween(from, to) {
+    return chartAnnotations.filter(a => a.end >= from && a.start <= to);
+}
+
+const annotationPlugin = {
+    id: 'tempestAnnotations',
+    afterDatasetsDraw(chart) {
+        const x = chart.scales && chart.scales.x;
+        if (!x || x.type !== 'time' || chartAnnotations.length === 0) return;
+        const area = chart.chartArea;
+        const ctx = chart.ctx;
+        ctx.save();
+        ctx.beginPath();
+        ctx.rect(area.left, area.top, area.right - area.left, area.bottom - area.top);
+        ctx.clip();
+        ctx.font = '11px ' + Chart.defaults.font.family;
+        ctx.textBaseline = 'top';
+        annotationsBetween(x.min, x.max).forEach(a => {
+            const left = Math.max(x.getPixelForValue(a.start), area.left);
+            const right = Math.min(x.getPixelForValue(a.end), area.right);
+            if (right - left >= 2) {
+                ctx.fillStyle = 'rgba(121, 85, 72, 0.15)';
+                ctx.fillRect(left, area.top, right - left, area.bottom - area.top);
+            } else {
+                ctx.strokeStyle = 'rgba(121, 85, 72, 0.8)';
+                ctx.setLineDash([4, 3]);
+                ctx.beginPath();
+                ctx.moveTo(left, area.top);
+                ctx.lineTo(left, area.bottom);
+                ctx.stroke();
+                ctx.setLineDash([]);
+            }
+            // Keep the label within the chart; long notes are cut short
+            const text = a.text.length > 40 ? a.text.substring(0, 39) + '…' : a.text;
+            ctx.fillStyle = 'rgba(93, 64, 55, 0.9)';
+            ctx.fillText(text, left + 3, area.top + 2, Math.max(area.right - left - 6, 0));
+        });
+        ctx.restore();
+    }
+};
+
 async function fetchWeather() {
     const startTime = performance.now();
     debugLog(logLevels.DEBUG, 'Starting weather API call');
     
     try {
-        const response = await fetch('/api/weather');
+        const response = await fetch(BASE_PATH + '/api/weather');
         const endTime = performance.now();
         const responseTime = endTime - startTime;
         
@@ -3246,6 +3589,52 @@ async function fetchWeather() {
     }
 }
 
+const HISTORY_PAGE_SIZE = 5000; // points per /api/history request on popout pages
+
+// popoutHistoryValue returns a sensor's value of a history point in the
+// display units
+function popoutHistoryValue(sensor, obs) {
+    let value = 0;
+    switch(sensor) {
+        case 'temperature':
+            value = obs.air_temperature || 0;
+            if (units.temperature === 'fahrenheit') {
+                value = celsiusToFahrenheit(value);
+            }
+            break;
+        case 'humidity':
+            value = obs.relative_humidity || 0;
+            break;
+        case 'wind':
+            value = obs.wind_avg || 0;
+            if (units.wind === 'kph') {
+                value = mphToKph(value);
+            }
+            break;
+        case 'rain':
+            // Use rainRate (rain intensity in mm/hr) for the intensity chart
+            // Convert to inches/hr if user prefers inches
+            value = obs.rainRate || 0;
+            if (units.rain === 'inches') {
+                value = mmToInches(value); // Convert mm/hr to in/hr
+            }
+            break;
+        case 'pressure':
+            value = obs.station_pressure || 0;
+            if (units.pressure === 'inHg') {
+                value = mbToInHg(value);
+            }
+            break;
+        case 'light':
+            value = obs.illuminance || 0;
+            break;
+        case 'uv':
+            value = obs.uv || 0;
+            break;
+    }
+    return value;
+}
+
 // Load historical data for popout chart pages
 async function loadHistoricalDataForPopout() {
     if (!charts.popout || !charts.popoutType) {
@@ -3255,13 +3644,19 @@ async function loadHistoricalDataForPopout() {
     
     try {
         debugLog(logLevels.INFO, 'Loading historical data for popout chart', { type: charts.popoutType });
-        const response = await fetch('/api/history');
-        if (!response.ok) {
-            throw new Error(`History API returned ${response.status}`);
-        }
-        
-        const history = await response.json();
-        if (!history || !Array.isArray(history)) {
+        // Page through the history so no single response carries the whole buffer
+        const history = [];
+        let cursor = '';
+        do {
+            const response = await fetch(BASE_PATH + `/api/history?limit=${HISTORY_PAGE_SIZE}` + (cursor ? `&cursor=${cursor}` : ''));
+            if (!response.ok) {
+                throw new Error(`History API returned ${response.status}`);
+            }
+            const page = await response.json();
+            if (Array.isArray(page)) history.push(...page);
+            cursor = response.headers.get('X-Next-Cursor') || '';
+        } while (cursor);
+        if (history.length === 0) {
             debugLog(logLevels.WARN, 'No historical data available');
             return;
         }
@@ -3274,51 +3669,15 @@ async function loadHistoricalDataForPopout() {
     // average/trend/accumulated lines are appended after it.
     const datasetIndex = 0;
         
+        const overlayDs = charts.popout.data.datasets.find(ds => ds.role === 'overlay');
         history.forEach(obs => {
             if (!obs || !obs.timestamp) return;
             
             const timestamp = new Date(obs.timestamp * 1000);
-            let value = 0;
-            
-            switch(chartType) {
-                case 'temperature':
-                    value = obs.air_temperature || 0;
-                    if (units.temperature === 'fahrenheit') {
-                        value = celsiusToFahrenheit(value);
-                    }
-                    break;
-                case 'humidity':
-                    value = obs.relative_humidity || 0;
-                    break;
-                case 'wind':
-                    value = obs.wind_avg || 0;
-                    if (units.wind === 'kph') {
-                        value = mphToKph(value);
-                    }
-                    break;
-                case 'rain':
-                    // Use rainRate (rain intensity in mm/hr) for the intensity chart
-                    // Convert to inches/hr if user prefers inches
-                    value = obs.rainRate || 0;
-                    if (units.rain === 'inches') {
-                        value = mmToInches(value); // Convert mm/hr to in/hr
-                    }
-                    break;
-                case 'pressure':
-                    value = obs.station_pressure || 0;
-                    if (units.pressure === 'inHg') {
-                        value = mbToInHg(value);
-                    }
-                    break;
-                case 'light':
-                    value = obs.illuminance || 0;
-                    break;
-                case 'uv':
-                    value = obs.uv || 0;
-                    break;
+            charts.popout.data.datasets[datasetIndex].data.push({ x: timestamp, y: popoutHistoryValue(chartType, obs) });
+            if (overlayDs) {
+                overlayDs.data.push({ x: timestamp, y: popoutHistoryValue(overlayDs.sensor, obs) });
             }
-            
-            charts.popout.data.datasets[datasetIndex].data.push({ x: timestamp, y: value });
         });
         
         // Calculate and populate average line (dataset 1) - skip for light and UV
@@ -3358,13 +3717,8 @@ async function loadHistoricalDataForPopout() {
             if (chartType === 'rain') {
                 // Build midnight-aware accumulated line and per-point today-total step.
                 if (mainData.length > 0) {
-                    const localDateKey = ts => {
-                        const d = new Date(ts);
-                        const y = d.getFullYear();
-                        const m = String(d.getMonth() + 1).padStart(2, '0');
-                        const day = String(d.getDate()).padStart(2, '0');
-                        return `${y}-${m}-${day}`;
-                    };
+                    // Group by the station's calendar day
+                    const localDateKey = stationDateKey;
 
                     const runningByDay = {};
                     const cumulativeSinceMidnight = new Array(mainData.length).fill(0);
@@ -3384,10 +3738,7 @@ async function loadHistoricalDataForPopout() {
                         const intensityMm = (units.rain === 'inches') ? inchesToMm(intensity) : intensity;
                         let segStart = t0;
                         while (segStart < t1) {
-                            const segStartDate = new Date(segStart);
-                            const nextMidnight = new Date(segStartDate);
-                            nextMidnight.setHours(24, 0, 0, 0);
-                            const segEnd = Math.min(t1, nextMidnight.getTime());
+                            const segEnd = Math.min(t1, nextStationMidnight(segStart));
                             const segHours = (segEnd - segStart) / (1000 * 60 * 60);
                             const delta = intensity * segHours;
                             const dayKey = localDateKey(segStart);
@@ -3466,14 +3817,45 @@ async function loadHistoricalDataForPopout() {
     }
 }
 
+// Unix time of the newest cached status history point, 0 when there is none
+function statusHistorySince() {
+    const newest = statusHistory[statusHistory.length - 1];
+    return newest && newest.lastUpdate ? Math.floor(new Date(newest.lastUpdate).getTime() / 1000) : 0;
+}
+
+// Appends a status delta to the cached history (or replaces it after a full
+// fetch) and hands the complete history on as status.dataHistory
+function mergeStatusHistory(status, isDelta) {
+    const points = status.dataHistory || [];
+    statusHistory = isDelta ? statusHistory.concat(points) : points;
+    statusHistoryRevision = status.historyRevision;
+    // The server keeps observationCount points; drop the ones it has trimmed
+    if (typeof status.observationCount === 'number' && statusHistory.length > status.observationCount) {
+        statusHistory = statusHistory.slice(statusHistory.length - status.observationCount);
+    }
+    status.dataHistory = statusHistory;
+}
+
 async function fetchStatus() {
     const startTime = performance.now();
     const responseTime = (performance.now() - startTime).toFixed(2);
     
     try {
-        const response = await fetch('/api/status');
+        // After the first load only points newer than the cached history are fetched
+        const since = statusHistorySince();
+        let response = await fetch(BASE_PATH + (since ? `/api/status?since=${since}` : '/api/status'));
+        let isDelta = since > 0;
+        if (response.ok && isDelta) {
+            const delta = await response.clone().json();
+            // Past history changed (backfill, preload or station switch): refetch all of it
+            if (delta.historyRevision !== statusHistoryRevision) {
+                response = await fetch(BASE_PATH + '/api/status');
+                isDelta = false;
+            }
+        }
         if (response.ok) {
             const status = await response.json();
+            mergeStatusHistory(status, isDelta);
             // expose raw status JSON string for headless tests to inspect exact payload
             try {
                 window.__lastStatusRaw = JSON.stringify(status);
@@ -3512,6 +3894,9 @@ async function fetchStatus() {
 function updateStatusDisplay(status) {
     debugLog(logLevels.DEBUG, 'Updating status display', status);
     debugLog(logLevels.DEBUG, '🔍 STATUS DEBUG - Full status object:', JSON.stringify(status, null, 2));
+
+    // Popout charts split days at the station's midnight too
+    stationTimeZone = status.timezone || null;
     
     // Skip status display updates on chart-only popout pages
     if (!document.getElementById('tempest-status')) {
@@ -3605,6 +3990,66 @@ function updateStatusDisplay(status) {
         }
     }
     
+    // Update UDP observation loss and jitter (only reported in UDP mode)
+    const linkRow = document.getElementById('tempest-udp-link-row');
+    const linkStatus = document.getElementById('tempest-udp-link');
+    if (linkRow && linkStatus) {
+        const link = status.dataSource && status.dataSource.type === 'udp' ? status.dataSource.link : null;
+        linkRow.classList.toggle('hidden', !link || !link.received);
+        if (link && link.received) {
+            linkStatus.textContent = `${link.lossPercent.toFixed(1)}% lost, ${link.jitterSeconds.toFixed(2)}s jitter`;
+            linkStatus.style.color = link.lossPercent >= 5 ? '#dc3545' : link.lossPercent >= 1 ? '#ffc107' : '#28a745';
+            let title = `${link.received} of ${link.expected} observations received, ${link.lost} lost`;
+            if (link.duplicates) title += `, ${link.duplicates} duplicate`;
+            if (link.meanIntervalSeconds) title += `\nMean interval ${link.meanIntervalSeconds.toFixed(1)}s, longest gap ${link.maxGapSeconds.toFixed(0)}s`;
+            if (link.lastLoss && !link.lastLoss.startsWith('0001')) title += '\nLast loss ' + new Date(link.lastLoss).toLocaleString();
+            linkStatus.title = title;
+        }
+    }
+
+    // Update API token check result (only reported in WeatherFlow API mode)
+    const tokenRow = document.getElementById('tempest-token-row');
+    const tokenStatus = document.getElementById('tempest-token-status');
+    if (tokenRow && tokenStatus) {
+        const token = status.tokenStatus;
+        tokenRow.classList.toggle('hidden', !token);
+        if (token) {
+            const states = {
+                valid: ['✅ Valid', '#28a745'],
+                invalid: ['❌ Invalid or expired', '#dc3545'],
+                rate_limited: ['⏳ Rate limited', '#ffc107'],
+                unreachable: ['⚠️ Not checked (API unreachable)', '#ffc107']
+            };
+            const [text, color] = states[token.state] || [token.state, ''];
+            tokenStatus.textContent = text;
+            tokenStatus.style.color = color;
+            let title = token.message || '';
+            if (token.checkedAt) title += (title ? '\n' : '') + 'Checked ' + new Date(token.checkedAt).toLocaleString();
+            if (token.lastValid && token.state !== 'valid') title += '\nLast valid ' + new Date(token.lastValid).toLocaleString();
+            tokenStatus.title = title;
+        }
+    }
+
+    // Update maintenance: the window in progress, else the next scheduled one
+    const maintenanceRow = document.getElementById('tempest-maintenance-row');
+    const maintenanceStatus = document.getElementById('tempest-maintenance-status');
+    if (maintenanceRow && maintenanceStatus) {
+        const maintenance = status.maintenance;
+        const current = maintenance && (maintenance.active || (maintenance.scheduled || [])[0]);
+        maintenanceRow.classList.toggle('hidden', !current);
+        if (current) {
+            const end = new Date(current.end).toLocaleString();
+            if (maintenance.active) {
+                maintenanceStatus.textContent = `🛠️ Until ${end}: alarms and uploads paused`;
+                maintenanceStatus.style.color = '#ffc107';
+            } else {
+                maintenanceStatus.textContent = `Scheduled ${new Date(current.start).toLocaleString()}`;
+                maintenanceStatus.style.color = '';
+            }
+            maintenanceStatus.title = current.reason || '';
+        }
+    }
+
     // Update elevation display with unit conversion
     updateElevationDisplay();
     
@@ -3668,9 +4113,12 @@ function updateStatusDisplay(status) {
         }
     }
 
-    // Populate charts with historical data if available
+    // Populate charts with historical data if available. A new historyRevision
+    // means the server filled a gap after an outage, so redraw from history.
+    const historyChanged = lastHistoryRevision !== null && status.historyRevision !== lastHistoryRevision;
+    lastHistoryRevision = status.historyRevision ?? lastHistoryRevision;
     if (status.dataHistory && status.dataHistory.length > 0) {
-        populateChartsWithHistoricalData(status.dataHistory);
+        populateChartsWithHistoricalData(status.dataHistory, historyChanged);
     }
 
     // Update detailed station status from stationStatus data
@@ -3741,6 +4189,8 @@ function updateDataSourceDisplay(status, dataSourceElement) {
             sources.push('Generated');
         } else if (dsType === 'custom-url') {
             sources.push('Custom URL');
+        } else if (dsType === 'ingest') {
+            sources.push('Pushed (/api/ingest)');
         } else if (dsType === 'api') {
             sources.push('WeatherFlow API');
         }
@@ -3938,6 +4388,47 @@ function refreshForecastDisplay() {
     
     // Update daily forecast with current units  
     updateDailyForecast(forecastData.forecast.daily);
+    renderHourlyForecast();
+}
+
+// Hourly forecast: the next hours from /api/forecast/hourly as a scrollable
+// strip above the daily forecast; hidden while the source has no forecast
+let hourlyForecast = null;
+
+async function updateHourlyForecast() {
+    try {
+        const response = await fetch(BASE_PATH + '/api/forecast/hourly?hours=12');
+        hourlyForecast = response.ok ? await response.json() : null;
+    } catch (error) {
+        debugLog(logLevels.DEBUG, 'Failed to load the hourly forecast', { error: error.message });
+        return;
+    }
+    renderHourlyForecast();
+}
+
+function renderHourlyForecast() {
+    const container = document.getElementById('forecast-hourly-container');
+    if (!container) return;
+    const hours = hourlyForecast ? hourlyForecast.hours : [];
+    container.hidden = hours.length === 0;
+    container.replaceChildren(...hours.map(hour => {
+        const temp = units.temperature === 'fahrenheit' ? celsiusToFahrenheit(hour.air_temperature) : hour.air_temperature;
+        const item = document.createElement('div');
+        item.className = 'forecast-hour';
+        item.title = hour.conditions || '';
+        [
+            ['forecast-hour-time', new Date(hour.time * 1000).toLocaleTimeString('en-US', { hour: 'numeric', timeZone: hourlyForecast.timezone || undefined })],
+            ['forecast-day-icon', getWeatherIcon(hour.icon)],
+            ['forecast-hour-temp', `${Math.round(temp)}°`],
+            ['forecast-hour-precip', `${hour.precip_probability}%`]
+        ].forEach(([className, text]) => {
+            const line = document.createElement('div');
+            line.className = className;
+            line.textContent = text;
+            item.appendChild(line);
+        });
+        return item;
+    }));
 }
 
 function updateCurrentConditions(current) {
@@ -4101,7 +4592,7 @@ function getWeatherIcon(iconCode) {
     return iconMap[iconCode] || '🌤️';
 }
 
-function populateChartsWithHistoricalData(dataHistory) {
+function populateChartsWithHistoricalData(dataHistory, force = false) {
     debugLog(logLevels.DEBUG, 'Populating charts with historical data', {
         dataPoints: dataHistory.length
     });
@@ -4147,11 +4638,12 @@ function populateChartsWithHistoricalData(dataHistory) {
     // 1. Charts are empty (initial load)
     // 2. We have MORE historical data points than current chart data (new historical data loaded)
     // This prevents clearing charts on every status update when dataHistory has fewer points
-    const shouldPopulate = currentDataLength === 0 || (hasActualTimestamps && dataHistory.length > currentDataLength + 5);
+    // 3. The server backfilled a gap (force)
+    const shouldPopulate = force || currentDataLength === 0 || (hasActualTimestamps && dataHistory.length > currentDataLength + 5);
     
     if (shouldPopulate) {
         debugLog(logLevels.INFO, 'Processing historical data', {
-            reason: currentDataLength === 0 ? 'charts empty' : 'new historical data loaded',
+            reason: force ? 'history backfilled' : currentDataLength === 0 ? 'charts empty' : 'new historical data loaded',
             currentDataPoints: currentDataLength,
             historicalDataPoints: dataHistory.length
         });
@@ -4411,12 +4903,14 @@ function updateHomekitStatus(status) {
     const reachability = document.getElementById('homekit-reachability');
     const lastRequest = document.getElementById('homekit-last-request');
     
+    const setupURI = document.getElementById('homekit-setup-uri');
     if (setupCode) setupCode.textContent = hk.bridge ? (hk.setupCode || '--') : 'N/A';
+    if (setupURI) setupURI.textContent = hk.bridge ? (hk.setupURI || '--') : 'N/A';
     if (pairedDevices) pairedDevices.textContent = hk.bridge ? (hk.pairedDevices || 'Unknown') : 'N/A';
     
     // Generate QR code for HomeKit pairing
     if (hk.bridge && hk.pin) {
-        generateHomekitQRCode(hk.pin);
+        generateHomekitQRCode(hk.pin, hk.setupURI);
     }
     if (reachability) {
         if (hk.bridge) {
@@ -4461,8 +4955,9 @@ function updateHomekitStatus(status) {
     });
 }
 
-// Generate HomeKit QR code on canvas
-function generateHomekitQRCode(setupCode) {
+// Generate HomeKit QR code on canvas. setupURI is the X-HM:// payload from the
+// server, which includes the bridge's setup ID; older servers only send the PIN.
+function generateHomekitQRCode(setupCode, setupURI) {
     const canvas = document.getElementById('homekit-qr-code');
     if (!canvas) return;
 
@@ -4476,9 +4971,7 @@ function generateHomekitQRCode(setupCode) {
     ctx.fillRect(0, 0, size, size);
 
     try {
-        // Calculate HomeKit setup payload
-        const payload = calculateHomekitPayload(setupCode);
-        const qrContent = `X-HM://${payload}`;
+        const qrContent = setupURI || `X-HM://${calculateHomekitPayload(setupCode)}`;
 
         debugLog(logLevels.DEBUG, 'Generating QR code for:', qrContent);
 
@@ -4768,6 +5261,303 @@ function toggleHomekitTechnicalExpansion() {
     }
 }
 
+function toggleHomekitPairingsExpansion() {
+    const expandedDiv = document.getElementById('homekit-pairings-expanded');
+    const expandIcon = document.getElementById('homekit-pairings-expand-icon');
+
+    if (expandedDiv && expandIcon) {
+        const isExpanded = expandedDiv.style.display !== 'none' && expandedDiv.style.display !== '';
+
+        if (!isExpanded) {
+            expandedDiv.style.display = 'block';
+            expandIcon.textContent = '▼';
+            loadHomekitPairings();
+        } else {
+            expandedDiv.style.display = 'none';
+            expandIcon.textContent = '▶';
+        }
+
+        debugLog(logLevels.DEBUG, 'HomeKit pairings expansion toggled', { expanded: !isExpanded });
+    }
+}
+
+// Describe an admin API failure; 403 means no --admin-password is configured
+async function adminErrorMessage(response) {
+    const text = (await response.text()).trim();
+    return `${response.status}: ${text || response.statusText}`;
+}
+
+// List paired controllers with a remove button each (admin endpoints, the
+// browser asks for the admin password)
+async function loadHomekitPairings() {
+    const list = document.getElementById('homekit-pairings-list');
+    if (!list) return;
+
+    try {
+        const response = await fetch(BASE_PATH + '/api/homekit/pairings', { credentials: 'same-origin' });
+        if (!response.ok) {
+            list.textContent = await adminErrorMessage(response);
+            return;
+        }
+        const data = await response.json();
+        list.textContent = '';
+        if (!data.pairings || data.pairings.length === 0) {
+            list.textContent = 'No paired controllers';
+            return;
+        }
+        const ul = document.createElement('ul');
+        ul.style.margin = '0';
+        ul.style.paddingLeft = '1.2em';
+        data.pairings.forEach(pairing => {
+            const li = document.createElement('li');
+            const label = document.createElement('span');
+            label.textContent = pairing.id + (pairing.admin ? ' (admin)' : '');
+            const remove = document.createElement('button');
+            remove.type = 'button';
+            remove.className = 'pairing-button pairing-button-danger';
+            remove.style.marginLeft = '6px';
+            remove.textContent = 'Remove';
+            remove.addEventListener('click', () => removeHomekitPairing(pairing.id, pairing.admin));
+            li.appendChild(label);
+            li.appendChild(remove);
+            ul.appendChild(li);
+        });
+        list.appendChild(ul);
+    } catch (error) {
+        debugLog(logLevels.ERROR, 'Failed to load HomeKit pairings:', error);
+        list.textContent = 'Failed to load pairings';
+    }
+}
+
+async function removeHomekitPairing(id, admin) {
+    const warning = admin ? '\n\nIf this is the last admin controller, all pairings are removed.' : '';
+    if (!confirm(`Remove HomeKit pairing ${id}?${warning}`)) return;
+
+    const response = await fetch(BASE_PATH + '/api/homekit/pairings/remove', {
+        method: 'POST',
+        credentials: 'same-origin',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ id })
+    });
+    if (!response.ok) {
+        alert('Failed to remove pairing: ' + await adminErrorMessage(response));
+    }
+    loadHomekitPairings();
+}
+
+async function resetHomekit() {
+    if (!confirm('Reset HomeKit? All pairings are removed and the bridge must be added to the Home app again.')) return;
+
+    const response = await fetch(BASE_PATH + '/api/homekit/reset', { method: 'POST', credentials: 'same-origin' });
+    if (!response.ok) {
+        alert('Failed to reset HomeKit: ' + await adminErrorMessage(response));
+        return;
+    }
+    loadHomekitPairings();
+}
+
+function toggleSensorSettingsExpansion() {
+    const expandedDiv = document.getElementById('sensor-settings-expanded');
+    const expandIcon = document.getElementById('sensor-settings-expand-icon');
+
+    if (expandedDiv && expandIcon) {
+        const isExpanded = expandedDiv.style.display !== 'none' && expandedDiv.style.display !== '';
+
+        if (!isExpanded) {
+            expandedDiv.style.display = 'block';
+            expandIcon.textContent = '▼';
+            loadSensorSettings();
+        } else {
+            expandedDiv.style.display = 'none';
+            expandIcon.textContent = '▶';
+        }
+
+        debugLog(logLevels.DEBUG, 'Sensor settings expansion toggled', { expanded: !isExpanded });
+    }
+}
+
+// One checkbox per value, checked when checked(value) is true
+function sensorCheckboxGroup(title, name, values, checked) {
+    const group = document.createElement('div');
+    const heading = document.createElement('div');
+    heading.className = 'info-label';
+    heading.textContent = title;
+    group.appendChild(heading);
+    values.forEach(value => {
+        const label = document.createElement('label');
+        label.style.marginRight = '10px';
+        label.style.display = 'inline-block';
+        const box = document.createElement('input');
+        box.type = 'checkbox';
+        box.name = name;
+        box.value = value;
+        box.checked = checked(value);
+        label.appendChild(box);
+        label.appendChild(document.createTextNode(' ' + value));
+        group.appendChild(label);
+    });
+    return group;
+}
+
+// Show the HomeKit sensors and visible dashboard cards as checkboxes (admin
+// endpoint, the browser asks for the admin password)
+async function loadSensorSettings() {
+    const list = document.getElementById('sensor-settings-list');
+    if (!list) return;
+
+    try {
+        const response = await fetch(BASE_PATH + '/api/sensors', { credentials: 'same-origin' });
+        if (!response.ok) {
+            list.textContent = await adminErrorMessage(response);
+            return;
+        }
+        renderSensorSettings(list, await response.json());
+    } catch (error) {
+        debugLog(logLevels.ERROR, 'Failed to load sensor settings:', error);
+        list.textContent = 'Failed to load sensor settings';
+    }
+}
+
+function renderSensorSettings(list, data) {
+    list.textContent = '';
+    const wrapper = document.createElement('div');
+    wrapper.appendChild(sensorCheckboxGroup('HomeKit sensors', 'homekit-sensor', data.homekit_available || [], name => (data.homekit || []).includes(name)));
+    wrapper.appendChild(sensorCheckboxGroup('Dashboard cards', 'dashboard-card', data.cards || [], card => !(data.hidden_cards || []).includes(card)));
+    list.appendChild(wrapper);
+}
+
+// Save the checked sensors and cards. HomeKit is rebuilt, keeping its
+// pairings; unchecked cards are hidden right away.
+async function saveSensorSettings() {
+    const list = document.getElementById('sensor-settings-list');
+    if (!list) return;
+    const values = (name, checked) => Array.from(list.querySelectorAll(`input[name="${name}"]`))
+        .filter(box => box.checked === checked)
+        .map(box => box.value);
+
+    const response = await fetch(BASE_PATH + '/api/sensors/update', {
+        method: 'POST',
+        credentials: 'same-origin',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ homekit: values('homekit-sensor', true), hidden_cards: values('dashboard-card', false) })
+    });
+    if (!response.ok) {
+        alert('Failed to save sensor settings: ' + await adminErrorMessage(response));
+        return;
+    }
+    const data = await response.json();
+    (data.cards || []).forEach(card => {
+        const element = document.getElementById(card + '-card');
+        if (element) element.classList.toggle('hidden', (data.hidden_cards || []).includes(card));
+    });
+    renderSensorSettings(list, data);
+}
+
+function toggleUDPRawExpansion() {
+    const expandedDiv = document.getElementById('udp-raw-expanded');
+    const expandIcon = document.getElementById('udp-raw-expand-icon');
+
+    if (expandedDiv && expandIcon) {
+        const isExpanded = expandedDiv.style.display !== 'none' && expandedDiv.style.display !== '';
+
+        if (!isExpanded) {
+            expandedDiv.style.display = 'block';
+            expandIcon.textContent = '▼';
+            loadUDPRawPackets();
+        } else {
+            expandedDiv.style.display = 'none';
+            expandIcon.textContent = '▶';
+        }
+
+        debugLog(logLevels.DEBUG, 'Raw UDP packets expansion toggled', { expanded: !isExpanded });
+    }
+}
+
+// Show the last UDP packets exactly as the hub sent them. /api/udp/raw
+// answers 404 unless the bridge runs with --loglevel debug, and the panel
+// stays hidden then.
+async function loadUDPRawPackets() {
+    const section = document.getElementById('udp-raw-section');
+    const list = document.getElementById('udp-raw-list');
+    if (!section || !list) return;
+
+    try {
+        const response = await fetch(BASE_PATH + '/api/udp/raw');
+        if (!response.ok) {
+            section.classList.add('hidden');
+            return;
+        }
+        const data = await response.json();
+        section.classList.remove('hidden');
+        const download = document.getElementById('udp-raw-download');
+        if (download) download.href = BASE_PATH + '/api/udp/raw?download=1';
+        if (!data.packets || data.packets.length === 0) {
+            list.textContent = `No packets yet (keeping the last ${data.capacity})`;
+            return;
+        }
+        list.textContent = data.packets.map(p => {
+            const from = p.from ? ` from ${p.from}` : '';
+            return `${new Date(p.time).toLocaleTimeString()}${from} ${p.type || '?'} ${p.serial || ''}\n${p.data}`;
+        }).join('\n\n');
+    } catch (error) {
+        debugLog(logLevels.DEBUG, 'Raw UDP packets unavailable:', error);
+    }
+}
+
+// Fill the station switcher from /api/stations; it stays hidden unless the
+// WeatherFlow API is the data source and the token has several stations
+async function loadStations() {
+    const row = document.getElementById('tempest-station-switch-row');
+    const select = document.getElementById('tempest-station-select');
+    if (!row || !select) return;
+
+    try {
+        const response = await fetch(BASE_PATH + '/api/stations');
+        if (!response.ok) return;
+        const data = await response.json();
+        if (!data.stations || data.stations.length < 2) return;
+
+        select.textContent = '';
+        data.stations.forEach(station => {
+            const option = document.createElement('option');
+            option.value = station.id;
+            option.textContent = `${station.name} (${station.id})`;
+            option.selected = station.active;
+            select.appendChild(option);
+        });
+        select.dataset.active = data.active_id;
+        row.classList.remove('hidden');
+    } catch (error) {
+        debugLog(logLevels.DEBUG, 'Station list unavailable:', error);
+    }
+}
+
+// Switch the active station (admin endpoint, the browser asks for the admin
+// password). Charts start over with the new station's data.
+async function switchStation() {
+    const select = document.getElementById('tempest-station-select');
+    if (!select) return;
+    const id = parseInt(select.value, 10);
+    const label = select.options[select.selectedIndex].textContent;
+    if (!confirm(`Switch to station ${label}? History and charts are reloaded for the new station.`)) {
+        select.value = select.dataset.active;
+        return;
+    }
+
+    const response = await fetch(BASE_PATH + '/api/stations/select', {
+        method: 'POST',
+        credentials: 'same-origin',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ id })
+    });
+    if (!response.ok) {
+        alert('Failed to switch station: ' + await adminErrorMessage(response));
+        select.value = select.dataset.active;
+        return;
+    }
+    location.reload();
+}
+
 // Toggle compact mode for Tempest card
 function toggleCompactMode() {
     const tempestCard = document.getElementById('tempest-card');
@@ -5054,6 +5844,7 @@ document.addEventListener('DOMContentLoaded', function() {
             debugLog(logLevels.ERROR, 'Chart.js not loaded - cannot initialize popout chart');
         }
     } else {
+        initCompareControls();
         // Only attempt the full chart initialization flow on the dashboard page.
         (function initChartsResilient() {
         if (__chartsInitialized) return; // already initialized successfully
@@ -5181,6 +5972,14 @@ document.addEventListener('DOMContentLoaded', function() {
         attachEventListener('hub-status-row', 'click', toggleHubStatusExpansion, 'Toggle hub status expansion');
         attachEventListener('homekit-connection-row', 'click', toggleHomekitConnectionExpansion, 'Toggle HomeKit connection info');
         attachEventListener('homekit-technical-row', 'click', toggleHomekitTechnicalExpansion, 'Toggle HomeKit technical details');
+        attachEventListener('homekit-pairings-row', 'click', toggleHomekitPairingsExpansion, 'Toggle HomeKit pairing management');
+        attachEventListener('homekit-pairings-refresh', 'click', loadHomekitPairings, 'Reload HomeKit pairings');
+        attachEventListener('homekit-reset', 'click', resetHomekit, 'Reset HomeKit pairings');
+        attachEventListener('sensor-settings-row', 'click', toggleSensorSettingsExpansion, 'Toggle sensor and card settings');
+        attachEventListener('sensor-settings-save', 'click', saveSensorSettings, 'Save sensor and card settings');
+        attachEventListener('tempest-station-select', 'change', switchStation, 'Switch the active station');
+        attachEventListener('udp-raw-row', 'click', toggleUDPRawExpansion, 'Toggle raw UDP packets');
+        attachEventListener('udp-raw-refresh', 'click', loadUDPRawPackets, 'Reload raw UDP packets');
         attachEventListener('tempest-compact-toggle', 'click', toggleCompactMode, 'Toggle compact/detailed view mode');
         attachEventListener('alarm-compact-toggle', 'click', toggleAlarmCompactMode, 'Toggle alarm compact/detailed view mode');
         attachEventListener('lux-info-icon', 'click', toggleLuxTooltip, 'Show/hide lux information tooltip');
@@ -5238,6 +6037,10 @@ document.addEventListener('DOMContentLoaded', function() {
     console.log('🚀 DEBUG: Starting initial data fetch');
     // Fetch status first to set currentDataSourceType before weather fetch attempts
     fetchStatus().then(() => fetchWeather());
+    if (!isChartOnly) {
+        loadStations();
+        loadUDPRawPackets();
+    }
     
     // Weather data updates every 5 seconds for real-time chart updates
     setInterval(() => {
@@ -5253,6 +6056,17 @@ document.addEventListener('DOMContentLoaded', function() {
         fetchStatus();
         fetchAlarmStatus();
     }, 10000);
+
+    if (!isChartOnly) {
+        updateAnomalyMarkers();
+        setInterval(updateAnomalyMarkers, 60000);
+        // The forecast is fetched every 30 minutes by default
+        updateHourlyForecast();
+        setInterval(updateHourlyForecast, 10 * 60 * 1000);
+    }
+    // Popouts draw the notes too and include them in their exports
+    updateAnnotations();
+    setInterval(updateAnnotations, 60000);
     
     debugLog(logLevels.INFO, 'Dashboard initialization completed');
 });
@@ -5262,7 +6076,7 @@ async function regenerateWeather() {
     try {
         debugLog(logLevels.INFO, 'Regenerating weather data...');
         
-        const response = await fetch('/api/regenerate-weather', {
+        const response = await fetch(BASE_PATH + '/api/regenerate-weather', {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json',
@@ -5669,7 +6483,7 @@ function updateChartsForDarkMode(isDark) {
 async function fetchAlarmStatus() {
     try {
         debugLog(logLevels.DEBUG, 'Fetching alarm status...');
-        const response = await fetch('/api/alarm-status');
+        const response = await fetch(BASE_PATH + '/api/alarm-status');
         const data = await response.json();
         
         debugLog(logLevels.DEBUG, 'Alarm status received', data);
@@ -5721,6 +6535,17 @@ function updateAlarmStatus(data, opts) {
     if (configPathEl) {
         configPathEl.textContent = data.configPath || 'N/A';
     }
+
+    // Show the editor link when the alarm editor is mounted on this server
+    const editorLinkEl = doc.getElementById('alarm-editor-link');
+    if (editorLinkEl) {
+        if (data.editorUrl) {
+            editorLinkEl.href = data.editorUrl;
+            editorLinkEl.style.display = '';
+        } else {
+            editorLinkEl.style.display = 'none';
+        }
+    }
     
     // Update last read time
     const lastReadEl = doc.getElementById('alarm-last-read');
@@ -5994,6 +6819,21 @@ function updateAlarmStatus(data, opts) {
             alarmDetails.appendChild(channels);
             alarmDetails.appendChild(tagsEl);
             alarmDetails.appendChild(cooldown);
+
+            // Whether someone has seen the latest notification
+            if (alarm.awaitingAck || alarm.acknowledgedBy) {
+                const ack = doc.createElement('div');
+                ack.className = 'alarm-item-ack';
+                if (alarm.awaitingAck) {
+                    ack.textContent = '🔔 Not acknowledged yet';
+                    ack.style.color = 'var(--warning-color, #ff9800)';
+                } else {
+                    const at = alarm.acknowledgedAt ? new Date(alarm.acknowledgedAt).toLocaleString() : '';
+                    ack.textContent = `👀 Acknowledged by ${alarm.acknowledgedBy}${at ? ' at ' + at : ''}`;
+                    ack.style.color = 'var(--success-color, #4caf50)';
+                }
+                alarmDetails.appendChild(ack);
+            }
             
             alarmItem.appendChild(alarmName);
             alarmItem.appendChild(alarmDetails);
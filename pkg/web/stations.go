@@ -0,0 +1,143 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+)
+
+// ErrStationNotFound is returned by a StationManager for a station the token
+// cannot access
+var ErrStationNotFound = errors.New("station not found")
+
+// StationInfo is a station available to the WeatherFlow token
+type StationInfo struct {
+	ID        int     `json:"id"`
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+	Active    bool    `json:"active"`
+}
+
+// StationsResponse is returned by /api/stations
+type StationsResponse struct {
+	Stations  []StationInfo `json:"stations"`
+	ActiveID  int           `json:"active_id"`
+	FetchedAt string        `json:"fetched_at,omitempty"` // when the list was fetched from WeatherFlow
+}
+
+// StationManager lists the stations of the WeatherFlow token and switches the
+// station the bridge reports
+type StationManager interface {
+	// Stations returns the cached station list, fetching it again when
+	// refresh is set or the cache expired
+	Stations(refresh bool) ([]StationInfo, time.Time, error)
+	SwitchStation(id int) error
+}
+
+// SetStationManager enables /api/stations and the dashboard station switcher
+func (ws *WebServer) SetStationManager(m StationManager) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.stationManager = m
+}
+
+// SetStation points the dashboard at another station. History, the current
+// observation and the forecast belong to the previous station and are cleared.
+func (ws *WebServer) SetStation(id int, name, stationURL string) {
+	ws.mu.Lock()
+	ws.stationID = id
+	ws.stationName = name
+	ws.stationURL = stationURL
+	ws.weatherData = nil
+	ws.forecastData = nil
+	ws.forecastHours = nil
+	ws.forecastHourly = nil
+	ws.history.Reset()
+	ws.historicalDataLoaded = false
+	ws.historicalDataCount = 0
+	ws.historyRevision++
+	statusManager := ws.statusManager
+	ws.mu.Unlock()
+
+	if statusManager != nil {
+		statusManager.SetStationID(id)
+	}
+}
+
+func (ws *WebServer) stationSwitcher(w http.ResponseWriter) StationManager {
+	ws.mu.RLock()
+	m := ws.stationManager
+	ws.mu.RUnlock()
+	if m == nil {
+		http.Error(w, "Station selection requires the WeatherFlow API data source", http.StatusServiceUnavailable)
+	}
+	return m
+}
+
+// handleStationsAPI lists the stations of the configured token. ?refresh=1
+// bypasses the cache.
+func (ws *WebServer) handleStationsAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	m := ws.stationSwitcher(w)
+	if m == nil {
+		return
+	}
+	refresh := r.URL.Query().Get("refresh")
+	stations, fetched, err := m.Stations(refresh == "1" || refresh == "true")
+	if err != nil {
+		http.Error(w, "Failed to list stations: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	ws.mu.RLock()
+	resp := StationsResponse{Stations: make([]StationInfo, 0, len(stations)), ActiveID: ws.stationID}
+	ws.mu.RUnlock()
+	for _, s := range stations {
+		s.Active = s.ID == resp.ActiveID
+		resp.Stations = append(resp.Stations, s)
+	}
+	if !fetched.IsZero() {
+		resp.FetchedAt = fetched.UTC().Format(time.RFC3339)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// handleStationSelectAPI switches to the station given as {"id": 12345}
+func (ws *WebServer) handleStationSelectAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		ID int `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID <= 0 {
+		http.Error(w, "Request body must be {\"id\": <station id>}", http.StatusBadRequest)
+		return
+	}
+	m := ws.stationSwitcher(w)
+	if m == nil {
+		return
+	}
+	if err := m.SwitchStation(req.ID); err != nil {
+		if errors.Is(err, ErrStationNotFound) {
+			http.Error(w, "Station not found", http.StatusNotFound)
+			return
+		}
+		logger.Error("Failed to switch to station %d: %v", req.ID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logger.Info("Active station changed to %d via web console from %s", req.ID, r.RemoteAddr)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": "switched", "id": req.ID})
+}
@@ -0,0 +1,33 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"tempest-homekit-go/pkg/stats"
+)
+
+// SetStatsEngine enables /api/stats
+func (ws *WebServer) SetStatsEngine(engine *stats.Engine) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.statsEngine = engine
+}
+
+// handleStatsAPI returns the daily statistics summary
+func (ws *WebServer) handleStatsAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ws.mu.RLock()
+	engine := ws.statsEngine
+	ws.mu.RUnlock()
+	if engine == nil {
+		http.Error(w, "Statistics are not available yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(engine.Summary())
+}
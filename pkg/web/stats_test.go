@@ -0,0 +1,59 @@
+package web
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/stats"
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestStatsAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+		return rec
+	}
+	if rec := get(); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status without an engine = %d, want 503", rec.Code)
+	}
+
+	engine := stats.NewEngine(time.UTC, 40, 100)
+	start := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	engine.Add(&weather.Observation{Timestamp: start.Unix(), AirTemperature: 25, RelativeHumidity: 40, WindAvg: 3, SolarRadiation: 900})
+	ws.SetStatsEngine(engine)
+
+	rec := get()
+	var resp stats.Summary
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode /api/stats response: %v", err)
+	}
+	if resp.Today == nil || resp.Today.Date != "2026-07-01" || resp.ET0.Today <= 0 {
+		t.Errorf("summary = %+v", resp)
+	}
+}
+
+func TestWeatherAPIReportsLightDose(t *testing.T) {
+	ws := testNewWebServer(t)
+	engine := stats.NewEngine(time.UTC, 40, 100)
+	obs := &weather.Observation{Timestamp: time.Now().Unix(), SolarRadiation: 600, UV: 6}
+	engine.Add(obs)
+	ws.SetStatsEngine(engine)
+	ws.UpdateWeather(obs)
+
+	rec := httptest.NewRecorder()
+	ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/weather", nil))
+	var resp WeatherResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode /api/weather response: %v", err)
+	}
+	// One minute at 600 W/m² and UV index 6
+	if math.Abs(resp.SolarEnergyToday-10) > 1e-9 || math.Abs(resp.UVDoseToday-0.09) > 1e-9 {
+		t.Errorf("light dose = %v Wh/m², %v SED; want 10 and 0.09", resp.SolarEnergyToday, resp.UVDoseToday)
+	}
+}
@@ -0,0 +1,189 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+// statusChunkSize is the write buffer of a streamed /api/status response;
+// each full buffer goes out as one HTTP chunk
+const statusChunkSize = 32 << 10
+
+// dataHistoryKey is where the streamed points are spliced into the status JSON
+var dataHistoryKey = []byte(`"dataHistory":[]`)
+
+// historyFieldIndex maps WeatherResponse JSON names to struct field indexes
+// for ?fields=
+var historyFieldIndex = func() map[string]int {
+	index := make(map[string]int)
+	t := reflect.TypeOf(WeatherResponse{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			index[name] = i
+		}
+	}
+	return index
+}()
+
+// historyField is one selected field of a streamed history point
+type historyField struct {
+	key   []byte // `"name":`
+	index int
+}
+
+// parseHistoryFields parses ?fields=, a comma-separated list of
+// WeatherResponse field names. Empty selects every field.
+func parseHistoryFields(value string) ([]historyField, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var fields []historyField
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		index, ok := historyFieldIndex[name]
+		if !ok {
+			names := make([]string, 0, len(historyFieldIndex))
+			for n := range historyFieldIndex {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown field '%s'. Use any of %s", name, strings.Join(names, ", "))
+		}
+		seen[name] = true
+		fields = append(fields, historyField{key: []byte(strconv.Quote(name) + ":"), index: index})
+	}
+	return fields, nil
+}
+
+// parseSince parses ?since= as Unix seconds or RFC 3339; empty means no limit
+func parseSince(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return secs, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid since '%s'. Use Unix seconds or an RFC 3339 time", value)
+	}
+	return t.Unix(), nil
+}
+
+// writeStatusStream writes the status response with its dataHistory array
+// encoded point by point from the sorted history, so the full array is never
+// held in memory. Points at or before since are left out but still count
+// toward the daily rain totals, whose days start at midnight in loc.
+func (ws *WebServer) writeStatusStream(w io.Writer, response StatusResponse, history []weather.Observation, since int64, fields []historyField, loc *time.Location) error {
+	response.DataHistory = []WeatherResponse{}
+	envelope, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	split := bytes.Index(envelope, dataHistoryKey)
+	if split < 0 {
+		return fmt.Errorf("status response has no dataHistory")
+	}
+	ws.logDebug("Status API JSON payload (dataHistory streamed): %s", envelope)
+
+	bw := bufio.NewWriterSize(w, statusChunkSize)
+	enc := json.NewEncoder(bw)
+	_, _ = bw.Write(envelope[:split+len(dataHistoryKey)-1])
+
+	var day time.Time
+	var dayStartRain float64
+	dayCount := 0
+	first := true
+	for i, obs := range history {
+		// Daily totals in one pass: rain since the first reading of the day
+		obsTime := time.Unix(obs.Timestamp, 0)
+		if start := startOfDay(obsTime, loc); !start.Equal(day) {
+			day, dayStartRain, dayCount = start, obs.RainAccumulated, 0
+		}
+		dayCount++
+		if obs.Timestamp <= since {
+			continue
+		}
+		dailyTotal := math.Max(0, obs.RainAccumulated)
+		if dayCount > 1 {
+			dailyTotal = math.Max(0, obs.RainAccumulated-dayStartRain)
+		}
+
+		// Incremental rain and rain rate (mm/hr) since the previous observation
+		var incrementalRainMm, rainRate float64
+		if i > 0 {
+			incrementalRainMm = math.Max(0, obs.RainAccumulated-history[i-1].RainAccumulated)
+			if timeDiffSeconds := obs.Timestamp - history[i-1].Timestamp; timeDiffSeconds > 0 {
+				rainRate = (incrementalRainMm / float64(timeDiffSeconds)) * 3600
+			}
+		}
+
+		point := WeatherResponse{
+			Temperature:          obs.AirTemperature,
+			Humidity:             obs.RelativeHumidity,
+			WindSpeed:            obs.WindAvg,
+			WindGust:             obs.WindGust,
+			WindDirection:        obs.WindDirection,
+			RainAccum:            incrementalRainMm, // Incremental rain since last sample (mm)
+			RainRate:             rainRate,          // Rain intensity in mm/hr
+			RainDailyTotal:       dailyTotal,        // Total rain since 00:00 (mm)
+			PrecipitationType:    obs.PrecipitationType,
+			Pressure:             obs.StationPressure,
+			Illuminance:          obs.Illuminance,
+			UV:                   obs.UV,
+			Battery:              obs.Battery,
+			LightningStrikeAvg:   obs.LightningStrikeAvg,
+			LightningStrikeCount: obs.LightningStrikeCount,
+			LastUpdate:           obsTime.Format(time.RFC3339),
+		}
+		if !first {
+			_ = bw.WriteByte(',')
+		}
+		first = false
+		if fields == nil {
+			err = enc.Encode(point)
+		} else {
+			err = writeHistoryFields(bw, &point, fields)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, _ = bw.Write(envelope[split+len(dataHistoryKey)-1:])
+	return bw.Flush()
+}
+
+// writeHistoryFields writes a JSON object with only the selected fields
+func writeHistoryFields(bw *bufio.Writer, point *WeatherResponse, fields []historyField) error {
+	v := reflect.ValueOf(point).Elem()
+	_ = bw.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			_ = bw.WriteByte(',')
+		}
+		value, err := json.Marshal(v.Field(f.index).Interface())
+		if err != nil {
+			return err
+		}
+		_, _ = bw.Write(f.key)
+		_, _ = bw.Write(value)
+	}
+	return bw.WriteByte('}')
+}
@@ -0,0 +1,98 @@
+package web
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestStatusAPIStreamedHistory(t *testing.T) {
+	ws := testNewWebServer(t)
+	start := time.Now().Add(-30 * time.Hour).Truncate(time.Hour)
+	var history []weather.Observation
+	rain := 0.0
+	for i := 0; i < 120; i++ {
+		if i%7 == 0 {
+			rain += 0.4
+		}
+		history = append(history, weather.Observation{
+			Timestamp:       start.Add(time.Duration(i) * 15 * time.Minute).Unix(),
+			AirTemperature:  float64(i),
+			RainAccumulated: rain,
+		})
+	}
+	// Out of order on purpose: the history buffer sorts what it is given
+	history[3], history[40] = history[40], history[3]
+	ws.history.Replace(history)
+
+	get := func(query string) (*httptest.ResponseRecorder, map[string]json.RawMessage, []map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/status"+query, nil))
+		if rec.Code != http.StatusOK {
+			return rec, nil, nil
+		}
+		var body map[string]json.RawMessage
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", query, err)
+		}
+		var points []map[string]interface{}
+		if err := json.Unmarshal(body["dataHistory"], &points); err != nil {
+			t.Fatalf("%s: invalid dataHistory: %v", query, err)
+		}
+		return rec, body, points
+	}
+
+	_, body, points := get("")
+	if len(points) != 120 || string(body["observationCount"]) != "120" {
+		t.Fatalf("got %d points, observationCount %s", len(points), body["observationCount"])
+	}
+	sorted := make([]weather.Observation, len(history))
+	copy(sorted, history)
+	sorted[3], sorted[40] = sorted[40], sorted[3]
+	for i, p := range points {
+		obs := sorted[i]
+		if p["temperature"].(float64) != obs.AirTemperature {
+			t.Fatalf("point %d out of order: %v", i, p["temperature"])
+		}
+		obsTime := time.Unix(obs.Timestamp, 0)
+		want := ws.calculateDailyRainForTime(obsTime, startOfDay(obsTime, ws.stationLocation()))
+		if got := p["rainDailyTotal"].(float64); math.Abs(got-want) > 1e-9 {
+			t.Errorf("point %d: rainDailyTotal %v, want %v", i, got, want)
+		}
+	}
+
+	// since filters the points but not the daily totals
+	cut := sorted[99].Timestamp
+	_, _, recent := get("?since=" + time.Unix(cut, 0).Format(time.RFC3339))
+	if len(recent) != 20 {
+		t.Fatalf("since: got %d points, want 20", len(recent))
+	}
+	if recent[0]["rainDailyTotal"] != points[100]["rainDailyTotal"] || recent[0]["rainAccum"] != points[100]["rainAccum"] {
+		t.Errorf("since changed derived values: %v vs %v", recent[0], points[100])
+	}
+	if _, _, unix := get("?since=" + strconv.FormatInt(cut, 10)); len(unix) != 20 {
+		t.Errorf("since as Unix seconds: got %d points, want 20", len(unix))
+	}
+
+	// fields keeps only the named keys, in order
+	rec, _, slim := get("?fields=temperature,lastUpdate&since=" + time.Unix(cut, 0).Format(time.RFC3339))
+	if len(slim) != 20 || len(slim[0]) != 2 || slim[0]["lastUpdate"] == nil {
+		t.Fatalf("fields: got %v", slim[0])
+	}
+	if !strings.Contains(rec.Body.String(), `{"temperature":100,"lastUpdate":`) {
+		t.Errorf("fields not written in request order")
+	}
+
+	for _, query := range []string{"?fields=temperature,bogus", "?since=yesterday"} {
+		if rec, _, _ := get(query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/weather"
+)
+
+const (
+	// streamClientBuffer is the number of events queued per /api/stream client
+	// before new events are dropped for that client
+	streamClientBuffer = 64
+	// streamRetryMillis is the reconnect delay suggested to EventSource clients
+	streamRetryMillis = 5000
+)
+
+// streamKeepAlive is the interval between SSE comment lines sent to keep idle
+// connections (and intermediate proxies) open. A variable so tests can shorten it.
+var streamKeepAlive = 15 * time.Second
+
+// streamEvent is one server-sent event, pre-encoded so each client does not
+// marshal the same payload again
+type streamEvent struct {
+	id   uint64
+	name string
+	data []byte
+}
+
+type streamClient struct {
+	events  chan streamEvent
+	filter  map[string]bool // nil means all event types
+	dropped atomic.Int64    // events dropped since the last notice sent to the client
+}
+
+// streamHub fans observations and alarm events out to /api/stream clients.
+// Publishing never blocks: a client whose buffer is full misses events and is
+// told how many it missed once it catches up.
+type streamHub struct {
+	mu      sync.RWMutex
+	clients map[*streamClient]struct{}
+	nextID  atomic.Uint64
+}
+
+func newStreamHub() *streamHub {
+	return &streamHub{clients: make(map[*streamClient]struct{})}
+}
+
+func (h *streamHub) add(filter map[string]bool) *streamClient {
+	c := &streamClient{events: make(chan streamEvent, streamClientBuffer), filter: filter}
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+	return c
+}
+
+func (h *streamHub) remove(c *streamClient) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+}
+
+func (h *streamHub) clientCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+func (h *streamHub) publish(name string, v interface{}) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if len(h.clients) == 0 {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		logger.Error("Stream: failed to encode %s event: %v", name, err)
+		return
+	}
+	ev := streamEvent{id: h.nextID.Add(1), name: name, data: data}
+	for c := range h.clients {
+		if c.filter != nil && !c.filter[name] {
+			continue
+		}
+		select {
+		case c.events <- ev:
+		default:
+			c.dropped.Add(1)
+		}
+	}
+}
+
+// PublishObservationEvent sends an observation to /api/stream clients
+func (ws *WebServer) PublishObservationEvent(obs weather.Observation) {
+	ws.stream.publish("observation", obs)
+}
+
+// PublishAlarmEvent sends a fired alarm to /api/stream clients
+func (ws *WebServer) PublishAlarmEvent(ev alarm.FiredEvent) {
+	ws.stream.publish("alarm", ev)
+}
+
+// handleStreamAPI serves a Server-Sent Events stream of observations and
+// alarm events. Clients may restrict event types with ?events=observation,alarm.
+//
+//	curl -N http://localhost:8080/api/stream
+func (ws *WebServer) handleStreamAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	// The stream outlives the server's write timeout by design
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	var filter map[string]bool
+	if q := r.URL.Query().Get("events"); q != "" {
+		filter = make(map[string]bool)
+		for _, name := range strings.Split(q, ",") {
+			switch name = strings.TrimSpace(name); name {
+			case "observation", "alarm":
+				filter[name] = true
+			case "":
+			default:
+				http.Error(w, fmt.Sprintf("unknown event type %q (use observation or alarm)", name), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	client := ws.stream.add(filter)
+	defer ws.stream.remove(client)
+	ws.logDebug("Stream client connected from %s (%d active)", r.RemoteAddr, ws.stream.clientCount())
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no") // disable nginx response buffering
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetryMillis); err != nil {
+		return
+	}
+	// Start with the latest observation so clients have data immediately
+	if filter == nil || filter["observation"] {
+		ws.mu.RLock()
+		latest := ws.weatherData
+		ws.mu.RUnlock()
+		if latest != nil {
+			if data, err := json.Marshal(latest); err == nil {
+				if err := writeStreamEvent(w, streamEvent{name: "observation", data: data}); err != nil {
+					return
+				}
+			}
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			ws.logDebug("Stream client %s disconnected", r.RemoteAddr)
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case ev := <-client.events:
+			if n := client.dropped.Swap(0); n > 0 {
+				notice := streamEvent{name: "dropped", data: []byte(fmt.Sprintf(`{"count":%d}`, n))}
+				if err := writeStreamEvent(w, notice); err != nil {
+					return
+				}
+			}
+			if err := writeStreamEvent(w, ev); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, ev streamEvent) error {
+	var err error
+	if ev.id > 0 {
+		_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.id, ev.name, ev.data)
+	} else {
+		_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.name, ev.data)
+	}
+	return err
+}
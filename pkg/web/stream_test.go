@@ -0,0 +1,129 @@
+package web
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// readStreamEvent reads lines until a complete event (blank line) and returns
+// the event name and data, skipping comments and retry hints
+func readStreamEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var name, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream read failed: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if name != "" || data != "" {
+				return name, data
+			}
+		case strings.HasPrefix(line, ":"):
+			return "comment", line
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func waitForStreamClients(t *testing.T, ws *WebServer, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for ws.stream.clientCount() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d stream clients, have %d", n, ws.stream.clientCount())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStreamAPIDeliversObservationsAndAlarms(t *testing.T) {
+	ws := testNewWebServer(t)
+	ws.UpdateWeather(&weather.Observation{Timestamp: 100, AirTemperature: 20})
+
+	ts := httptest.NewServer(ws.server.Handler)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	r := bufio.NewReader(resp.Body)
+
+	// The latest observation is sent on connect
+	if name, data := readStreamEvent(t, r); name != "observation" || !strings.Contains(data, `"timestamp":100`) {
+		t.Fatalf("unexpected initial event %s: %s", name, data)
+	}
+
+	waitForStreamClients(t, ws, 1)
+	ws.PublishObservationEvent(weather.Observation{Timestamp: 200})
+	if name, data := readStreamEvent(t, r); name != "observation" || !strings.Contains(data, `"timestamp":200`) {
+		t.Fatalf("unexpected event %s: %s", name, data)
+	}
+
+	ws.PublishAlarmEvent(alarm.FiredEvent{Name: "hot"})
+	if name, data := readStreamEvent(t, r); name != "alarm" || !strings.Contains(data, `"name":"hot"`) {
+		t.Fatalf("unexpected event %s: %s", name, data)
+	}
+}
+
+func TestStreamAPIFilterKeepAliveAndBackpressure(t *testing.T) {
+	old := streamKeepAlive
+	streamKeepAlive = 20 * time.Millisecond
+	defer func() { streamKeepAlive = old }()
+
+	ws := testNewWebServer(t)
+	ts := httptest.NewServer(ws.server.Handler)
+	defer ts.Close()
+
+	if resp, err := http.Get(ts.URL + "/api/stream?events=bogus"); err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown event type, got %v %v", resp, err)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/stream?events=alarm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	r := bufio.NewReader(resp.Body)
+	waitForStreamClients(t, ws, 1)
+
+	if name, _ := readStreamEvent(t, r); name != "comment" {
+		t.Fatalf("expected keep-alive comment, got %s", name)
+	}
+
+	// Observations are filtered out; flooding alarms overflows the client buffer
+	for i := 0; i < streamClientBuffer*3; i++ {
+		ws.PublishObservationEvent(weather.Observation{})
+		ws.PublishAlarmEvent(alarm.FiredEvent{Name: "flood"})
+	}
+	sawDropped := false
+	for i := 0; i < streamClientBuffer*2 && !sawDropped; i++ {
+		name, data := readStreamEvent(t, r)
+		switch name {
+		case "observation":
+			t.Fatal("filtered event type was delivered")
+		case "dropped":
+			sawDropped = strings.Contains(data, `"count":`)
+		}
+	}
+	if !sawDropped {
+		t.Error("expected a dropped notice after the client buffer overflowed")
+	}
+}
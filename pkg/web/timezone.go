@@ -0,0 +1,55 @@
+package web
+
+import (
+	"fmt"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+)
+
+// SetTimezone makes daily totals reset at midnight in the named IANA time
+// zone (the station's, as reported by the WeatherFlow API) instead of the
+// server's. An empty name reverts to server local time; an unknown one is
+// rejected and the current zone kept.
+func (ws *WebServer) SetTimezone(name string) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.setTimezoneLocked(name)
+}
+
+// setTimezoneLocked applies SetTimezone; ws.mu must be held
+func (ws *WebServer) setTimezoneLocked(name string) error {
+	if name == "" {
+		ws.timezone, ws.location = "", nil
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		logger.Warn("Unknown station time zone %q, keeping the current day boundaries: %v", name, err)
+		return fmt.Errorf("invalid time zone '%s': %w", name, err)
+	}
+	if ws.timezone != name {
+		logger.Info("Daily totals reset at midnight %s", name)
+	}
+	ws.timezone, ws.location = name, loc
+	return nil
+}
+
+// stationLocation returns the station time zone, or nil for server local
+// time. Read it once per request and pass it on: SetTimezone may change it.
+func (ws *WebServer) stationLocation() *time.Location {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	return ws.location
+}
+
+// startOfDay returns midnight of t's day in loc, or in server local time when
+// loc is nil. Days around DST changes are 23 or 25 hours long, so boundaries
+// always come from time.Date rather than adding 24h.
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,97 @@
+package web
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestStartOfDayUsesStationTimezone(t *testing.T) {
+	ws := &WebServer{}
+	if err := ws.SetTimezone("America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		at   string // UTC
+		want string // midnight in New York
+	}{
+		{"2024-06-01T03:30:00Z", "2024-05-31T00:00:00-04:00"}, // still the previous day in New York
+		{"2024-03-10T12:00:00Z", "2024-03-10T00:00:00-05:00"}, // 23-hour day, midnight was EST
+		{"2024-03-11T04:30:00Z", "2024-03-11T00:00:00-04:00"}, // first EDT midnight
+		{"2024-11-03T23:00:00Z", "2024-11-03T00:00:00-04:00"}, // 25-hour day, midnight was EDT
+	}
+	for _, tt := range tests {
+		at, _ := time.Parse(time.RFC3339, tt.at)
+		want, _ := time.Parse(time.RFC3339, tt.want)
+		got := startOfDay(at, ws.stationLocation())
+		if !got.Equal(want) || got.Location().String() != "America/New_York" {
+			t.Errorf("startOfDay(%s) = %s, want %s", tt.at, got, want)
+		}
+	}
+
+	if err := ws.SetTimezone("Mars/Olympus_Mons"); err == nil {
+		t.Error("expected an error for an unknown time zone")
+	}
+	if ws.timezone != "America/New_York" {
+		t.Errorf("invalid zone replaced the previous one: %q", ws.timezone)
+	}
+}
+
+func TestDailyRainResetsAtStationMidnight(t *testing.T) {
+	ws := &WebServer{}
+	// A zone whose midnight is never the test machine's midnight and has no DST
+	if err := ws.SetTimezone("Pacific/Kiritimati"); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	midnight := startOfDay(now, ws.stationLocation())
+	if midnight.After(now) || now.Sub(midnight) >= 24*time.Hour {
+		t.Fatalf("startOfDay(now) = %s is not today's midnight in the station zone", midnight)
+	}
+
+	ws.history.Replace([]weather.Observation{
+		{Timestamp: midnight.Add(-time.Minute).Unix(), RainAccumulated: 4.0}, // yesterday for the station
+		{Timestamp: midnight.Unix(), RainAccumulated: 5.0},
+		{Timestamp: now.Unix(), RainAccumulated: 5.5},
+	})
+	if got := ws.calculateDailyRainAccumulation(); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("daily rain = %v, want 0.5 counted from the station's midnight", got)
+	}
+}
+
+func TestUpdateForecastSetsTimezone(t *testing.T) {
+	ws := &WebServer{}
+	ws.UpdateForecast(&weather.ForecastResponse{Timezone: "Europe/Berlin"})
+	if ws.timezone != "Europe/Berlin" || ws.location == nil {
+		t.Errorf("timezone = %q, location = %v", ws.timezone, ws.location)
+	}
+}
+
+// TestStatusWhileTimezoneChanges catches unlocked reads of the station
+// location when run with -race
+func TestStatusWhileTimezoneChanges(t *testing.T) {
+	ws := testNewWebServer(t)
+	for i := 0; i < 10; i++ {
+		ws.UpdateWeather(&weather.Observation{Timestamp: time.Now().Add(time.Duration(i-10) * time.Minute).Unix()})
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, zone := range []string{"Europe/Berlin", "America/New_York", ""} {
+			_ = ws.SetTimezone(zone)
+		}
+	}()
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("status: %d", rec.Code)
+		}
+	}
+	<-done
+}
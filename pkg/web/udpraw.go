@@ -0,0 +1,42 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"tempest-homekit-go/pkg/udp"
+)
+
+// UDPRawResponse is returned by /api/udp/raw
+type UDPRawResponse struct {
+	Capacity int             `json:"capacity"` // packets kept, --udp-raw-packets
+	Packets  []udp.RawPacket `json:"packets"`  // newest first
+}
+
+// handleUDPRawAPI serves the last UDP packets exactly as received. Packets
+// are only kept with --loglevel debug; otherwise the route answers 404.
+// ?download=1 saves the response as a file to attach to a bug report.
+func (ws *WebServer) handleUDPRawAPI(w http.ResponseWriter, r *http.Request) {
+	ws.mu.RLock()
+	listener := ws.udpListener
+	ws.mu.RUnlock()
+	if listener == nil || listener.RawBufferSize() == 0 {
+		http.Error(w, "Raw UDP packets are kept only with --udp-stream and --loglevel debug", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if r.URL.Query().Get("download") != "" {
+		name := fmt.Sprintf("udp-packets-%s.json", time.Now().Format("20060102-150405"))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	}
+	resp := UDPRawResponse{Capacity: listener.RawBufferSize(), Packets: listener.GetRawPackets()}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(resp); err != nil {
+		ws.logError("Failed to encode raw UDP packets: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tempest-homekit-go/pkg/udp"
+)
+
+func TestUDPRawAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	if rec := serve("/api/udp/raw"); rec.Code != http.StatusNotFound {
+		t.Errorf("without a listener: %d", rec.Code)
+	}
+
+	listener := udp.NewUDPListener(10)
+	ws.SetUDPListener(listener)
+	if rec := serve("/api/udp/raw"); rec.Code != http.StatusNotFound {
+		t.Errorf("without --loglevel debug: %d", rec.Code)
+	}
+
+	listener.SetRawBufferSize(5)
+	rec := serve("/api/udp/raw?download=1")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Disposition"), "attachment") {
+		t.Fatalf("status %d, headers %v", rec.Code, rec.Header())
+	}
+	var resp UDPRawResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Capacity != 5 || len(resp.Packets) != 0 {
+		t.Errorf("response = %+v, %v", resp, err)
+	}
+}
@@ -87,9 +87,8 @@ func TestHeadlessDashboard(t *testing.T) {
 	} else {
 		t.Logf("serving chartPath: %s (size=%d)", chartPath, fi.Size())
 	}
-	mux.HandleFunc("/chart/", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, chartPath)
-	})
+	mux.HandleFunc("/chart/", testChartPageHandler(t, chartPath))
+	mux.HandleFunc("/api/chart-config/{sensor}", ws.handleChartConfigAPI)
 
 	ts := httptest.NewServer(mux)
 	defer ts.Close()
@@ -562,7 +561,7 @@ func TestChartPopoutOpensAndRenders(t *testing.T) {
 
 		// Intercept _blank opens by overriding window.open early so any click
 		// handlers installed by the injected script will call our override.
-		overrideOpen := `(function(){ window.__lastOpened = null; window.__lastPopoutConfig = null; var _open = window.open; window.open = function(url,name){ try { window.__lastOpened = url; var m = url.match(/[?&]config=([^&]+)/); if (m && m[1]) { try { window.__lastPopoutConfig = JSON.parse(decodeURIComponent(m[1])); } catch(e) { window.__lastPopoutConfig = {err: e.toString()}; } } } catch(e) {} return _open.apply(window, arguments); }; })()`
+		overrideOpen := `(function(){ window.__lastOpened = null; var _open = window.open; window.open = function(url,name){ window.__lastOpened = url; return _open.apply(window, arguments); }; })()`
 		if err := chromedp.Run(browserCtx, chromedp.EvaluateAsDevTools(overrideOpen, nil)); err != nil {
 			t.Fatalf("failed to override window.open: %v", err)
 		}
@@ -696,52 +695,27 @@ func TestChartPopoutOpensAndRenders(t *testing.T) {
 		t.Fatalf("failed to click temperature chart: %v", err)
 	}
 
-	// Read captured config
-	var popoutConfigJSON string
-	if err := chromedp.Run(browserCtx, chromedp.EvaluateAsDevTools(`JSON.stringify(window.__lastPopoutConfig || {})`, &popoutConfigJSON)); err != nil {
-		t.Fatalf("failed to read captured popout config: %v", err)
-	}
-	if len(popoutConfigJSON) <= 2 {
-		// retry click once in case of timing flake
-		_ = chromedp.Run(browserCtx, chromedp.Click(`#temperature-chart`, chromedp.NodeVisible))
-		_ = chromedp.Run(browserCtx, chromedp.EvaluateAsDevTools(`JSON.stringify(window.__lastPopoutConfig || {})`, &popoutConfigJSON))
-		if len(popoutConfigJSON) <= 2 {
-			// Fallback: build the same compact cfg object in-page from charts.temperature
-			fallbackBuild := `(function(){ try {
-				var chartObj = charts && charts.temperature;
-				var datasetsMeta = [];
-				if (chartObj && chartObj.data && Array.isArray(chartObj.data.datasets)) {
-					chartObj.data.datasets.forEach(function(ds){
-						var meta = {};
-						if (ds.label) meta.label = ds.label;
-						if (ds.borderColor) meta.borderColor = ds.borderColor;
-						if (ds.backgroundColor) meta.backgroundColor = ds.backgroundColor;
-						if (ds.borderDash) meta.borderDash = ds.borderDash;
-						if (ds.borderWidth !== undefined) meta.borderWidth = ds.borderWidth;
-						if (ds.fill !== undefined) meta.fill = ds.fill;
-						if (ds.pointRadius !== undefined) meta.pointRadius = ds.pointRadius;
-						if (ds.tension !== undefined) meta.tension = ds.tension;
-						if (ds.pointStyle !== undefined) meta.pointStyle = ds.pointStyle;
-						if (ds.showLine !== undefined) meta.showLine = ds.showLine;
-						if (ds.stepped !== undefined) meta.stepped = ds.stepped;
-						if (ds.order !== undefined) meta.order = ds.order;
-						if (ds.spanGaps !== undefined) meta.spanGaps = ds.spanGaps;
-						if (String(ds.label).toLowerCase().indexOf('average')>=0) meta.role='average';
-						if (String(ds.label).toLowerCase().indexOf('trend')>=0) meta.role='trend';
-						if (String(ds.label).toLowerCase().indexOf('today')>=0 || String(ds.label).toLowerCase().indexOf('total')>=0) meta.role='total';
-						datasetsMeta.push(meta);
-					});
-				}
-				var cfg = { type: 'temperature', field: 'temperature', title: 'Temperature', color: (chartObj && chartObj.data && chartObj.data.datasets && chartObj.data.datasets[0] && chartObj.data.datasets[0].borderColor) || '#007bff', units: window.units || {}, datasets: datasetsMeta };
-				window.__lastPopoutConfig = cfg;
-				return JSON.stringify(cfg);
-			} catch(e) { return 'err:'+e.toString(); } })()`
-			_ = chromedp.Run(browserCtx, chromedp.EvaluateAsDevTools(fallbackBuild, &popoutConfigJSON))
-			if len(popoutConfigJSON) <= 2 {
-				t.Fatalf("no popout config was captured on window.open and fallback builder failed")
-			}
+	// The popout URL carries only the sensor; its chart config comes from the server
+	var opened string
+	for i := 0; i < 20 && opened == ""; i++ {
+		_ = chromedp.Run(browserCtx, chromedp.Evaluate(`window.__lastOpened || ''`, &opened))
+		if opened == "" {
+			time.Sleep(100 * time.Millisecond)
 		}
 	}
+	if opened != "/chart/temperature" {
+		t.Fatalf("popout opened %q, want /chart/temperature", opened)
+	}
+	cfgResp, err := http.Get(ts.URL + "/api/chart-config/temperature")
+	if err != nil {
+		t.Fatalf("failed to fetch chart config: %v", err)
+	}
+	cfgBody, _ := io.ReadAll(cfgResp.Body)
+	_ = cfgResp.Body.Close()
+	popoutConfigJSON := string(cfgBody)
+	if err := chromedp.Run(browserCtx, chromedp.EvaluateAsDevTools(fmt.Sprintf(`window.__lastPopoutConfig = %s;`, popoutConfigJSON), nil)); err != nil {
+		t.Fatalf("failed to set in-page chart config: %v", err)
+	}
 
 	// Prefer the in-page raw status payload (window.__lastStatusRaw) if available
 	// This allows us to inspect the exact object the page used. Fall back to a
@@ -1225,9 +1199,8 @@ func TestPopoutDatasetOrdering(t *testing.T) {
 	// serve chart.html
 	_, thisFile, _, _ := gruntime.Caller(0)
 	chartPath := filepath.Join(filepath.Dir(thisFile), "static", "chart.html")
-	mux.HandleFunc("/chart/", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, chartPath)
-	})
+	mux.HandleFunc("/chart/", testChartPageHandler(t, chartPath))
+	mux.HandleFunc("/api/chart-config/{sensor}", ws.handleChartConfigAPI)
 
 	ts := httptest.NewServer(mux)
 	defer ts.Close()
@@ -0,0 +1,163 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// windCalm is the speed (m/s) below which a reading has no meaningful direction
+const windCalm = 0.5
+
+// windSpeedClasses are the lower bounds (m/s) of the wind rose speed classes,
+// roughly Beaufort 1-2, 3, 4, 5, 6 and 6+
+var windSpeedClasses = []float64{0.5, 2, 4, 6, 8, 11}
+
+// compassPoints names 16 directions, clockwise from north
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// WindSpeedClass is one speed band of the wind rose, in m/s
+type WindSpeedClass struct {
+	Min float64  `json:"min"`
+	Max *float64 `json:"max"` // null for the open-ended top class
+}
+
+// WindRoseSector holds the share of readings blowing from one direction
+type WindRoseSector struct {
+	Direction float64   `json:"direction"` // center, degrees from north
+	Label     string    `json:"label"`
+	Total     float64   `json:"total"`     // percent of all readings
+	Frequency []float64 `json:"frequency"` // percent of all readings, one per speed class
+}
+
+// WindRoseResponse bins the wind history by direction sector and speed class.
+// Percentages are of all readings, so calm plus every sector's total is 100.
+type WindRoseResponse struct {
+	Hours        int              `json:"hours"` // 0 means all history
+	From         int64            `json:"from,omitempty"`
+	To           int64            `json:"to,omitempty"`
+	Samples      int              `json:"samples"`
+	Calm         float64          `json:"calm"`      // percent of readings below 0.5 m/s
+	MeanSpeed    float64          `json:"meanSpeed"` // m/s
+	Prevailing   string           `json:"prevailing,omitempty"`
+	SpeedClasses []WindSpeedClass `json:"speedClasses"`
+	Sectors      []WindRoseSector `json:"sectors"`
+}
+
+// windRose bins (speed m/s, direction degrees) readings into sectors
+func windRose(speeds, directions []float64, sectors int) WindRoseResponse {
+	resp := WindRoseResponse{Samples: len(speeds)}
+	for i, min := range windSpeedClasses {
+		class := WindSpeedClass{Min: min}
+		if i+1 < len(windSpeedClasses) {
+			max := windSpeedClasses[i+1]
+			class.Max = &max
+		}
+		resp.SpeedClasses = append(resp.SpeedClasses, class)
+	}
+	width := 360 / float64(sectors)
+	resp.Sectors = make([]WindRoseSector, sectors)
+	for s := range resp.Sectors {
+		resp.Sectors[s] = WindRoseSector{
+			Direction: float64(s) * width,
+			Frequency: make([]float64, len(windSpeedClasses)),
+		}
+		if sectors <= len(compassPoints) && len(compassPoints)%sectors == 0 {
+			resp.Sectors[s].Label = compassPoints[s*len(compassPoints)/sectors]
+		} else {
+			resp.Sectors[s].Label = fmt.Sprintf("%.0f°", resp.Sectors[s].Direction)
+		}
+	}
+	if len(speeds) == 0 {
+		return resp
+	}
+
+	share := 100 / float64(len(speeds))
+	sum := 0.0
+	for i, speed := range speeds {
+		sum += speed
+		if speed < windCalm {
+			resp.Calm += share
+			continue
+		}
+		// Sectors are centered on their direction: N covers 348.75-11.25° with 16
+		s := int(math.Floor(math.Mod(directions[i]+width/2, 360)/width)) % sectors
+		class := 0
+		for c, min := range windSpeedClasses {
+			if speed >= min {
+				class = c
+			}
+		}
+		resp.Sectors[s].Frequency[class] += share
+		resp.Sectors[s].Total += share
+	}
+	resp.MeanSpeed = sum / float64(len(speeds))
+	best := -1
+	for s := range resp.Sectors {
+		if resp.Sectors[s].Total > 0 && (best < 0 || resp.Sectors[s].Total > resp.Sectors[best].Total) {
+			best = s
+		}
+	}
+	if best >= 0 {
+		resp.Prevailing = resp.Sectors[best].Label
+	}
+	return resp
+}
+
+// handleWindRoseAPI returns a wind rose of the last ?hours (default 24, 0 for
+// all history) in ?sectors (8, 16 or 36) direction sectors
+func (ws *WebServer) handleWindRoseAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	hours := 24
+	if v := r.URL.Query().Get("hours"); v != "" {
+		h, err := strconv.Atoi(v)
+		if err != nil || h < 0 || h > 24*31 {
+			http.Error(w, fmt.Sprintf("invalid hours '%s'. Use 0 (all history) to 744", v), http.StatusBadRequest)
+			return
+		}
+		hours = h
+	}
+	sectors := 16
+	if v := r.URL.Query().Get("sectors"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || (n != 8 && n != 16 && n != 36) {
+			http.Error(w, fmt.Sprintf("invalid sectors '%s'. Use 8, 16 or 36", v), http.StatusBadRequest)
+			return
+		}
+		sectors = n
+	}
+
+	var since int64
+	if hours > 0 {
+		since = time.Now().Add(-time.Duration(hours) * time.Hour).Unix()
+	}
+	var speeds, directions []float64
+	var from, to int64
+	ws.mu.RLock()
+	for _, obs := range ws.history.Snapshot() {
+		if obs.Timestamp < since {
+			continue
+		}
+		if from == 0 || obs.Timestamp < from {
+			from = obs.Timestamp
+		}
+		if obs.Timestamp > to {
+			to = obs.Timestamp
+		}
+		speeds = append(speeds, obs.WindAvg)
+		directions = append(directions, obs.WindDirection)
+	}
+	ws.mu.RUnlock()
+
+	resp := windRose(speeds, directions, sectors)
+	resp.Hours, resp.From, resp.To = hours, from, to
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(resp)
+}
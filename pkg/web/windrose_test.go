@@ -0,0 +1,74 @@
+package web
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/weather"
+)
+
+func TestWindRose(t *testing.T) {
+	// Two calm readings, three northerlies (one at 355° and one strong), one
+	// easterly
+	rose := windRose([]float64{0, 0.3, 1, 3, 12, 5}, []float64{0, 90, 355, 5, 10, 92}, 16)
+	if rose.Samples != 6 || math.Abs(rose.Calm-100.0/3) > 1e-9 || rose.Prevailing != "N" {
+		t.Fatalf("rose = %+v", rose)
+	}
+	n := rose.Sectors[0]
+	if math.Abs(n.Total-50) > 1e-9 || n.Frequency[0] == 0 || n.Frequency[1] == 0 || n.Frequency[5] == 0 {
+		t.Errorf("N sector = %+v", n)
+	}
+	if e := rose.Sectors[4]; e.Label != "E" || math.Abs(e.Frequency[2]-100.0/6) > 1e-9 {
+		t.Errorf("E sector = %+v", e)
+	}
+	total := rose.Calm
+	for _, s := range rose.Sectors {
+		total += s.Total
+	}
+	if math.Abs(total-100) > 1e-9 {
+		t.Errorf("percentages add up to %v", total)
+	}
+	if top := rose.SpeedClasses[len(rose.SpeedClasses)-1]; top.Max != nil || top.Min != 11 {
+		t.Errorf("top speed class = %+v", top)
+	}
+	if rose36 := windRose(nil, nil, 36); rose36.Sectors[1].Label != "10°" || rose36.Prevailing != "" {
+		t.Errorf("36 sectors = %+v", rose36.Sectors[1])
+	}
+}
+
+func TestWindRoseAPI(t *testing.T) {
+	ws := testNewWebServer(t)
+	now := time.Now()
+	ws.history.Replace([]weather.Observation{
+		{Timestamp: now.Add(-30 * time.Hour).Unix(), WindAvg: 5, WindDirection: 270},
+		{Timestamp: now.Add(-2 * time.Hour).Unix(), WindAvg: 3, WindDirection: 180},
+		{Timestamp: now.Add(-1 * time.Hour).Unix(), WindAvg: 4, WindDirection: 185},
+	})
+	get := func(query string) (*httptest.ResponseRecorder, WindRoseResponse) {
+		rec := httptest.NewRecorder()
+		ws.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/windrose"+query, nil))
+		var resp WindRoseResponse
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode /api/windrose response: %v", err)
+			}
+		}
+		return rec, resp
+	}
+
+	if _, resp := get(""); resp.Samples != 2 || resp.Prevailing != "S" || len(resp.Sectors) != 16 {
+		t.Errorf("last 24h = %+v", resp)
+	}
+	if _, resp := get("?hours=0&sectors=8"); resp.Samples != 3 || len(resp.Sectors) != 8 || resp.Sectors[6].Label != "W" {
+		t.Errorf("all history = %+v", resp)
+	}
+	for _, query := range []string{"?hours=-1", "?hours=x", "?sectors=12"} {
+		if rec, _ := get(query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package webhooklistener
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"tempest-homekit-go/pkg/alarm"
+	"tempest-homekit-go/pkg/weather"
+)
+
+// AlarmPayload is the body of the default webhook channel template
+type AlarmPayload struct {
+	Alarm struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Condition   string `json:"condition"`
+		Tags        string `json:"tags"`
+	} `json:"alarm"`
+	Station   string                 `json:"station"`
+	Timestamp string                 `json:"timestamp"`
+	Sensors   map[string]interface{} `json:"sensors"`
+	AppInfo   string                 `json:"app_info"`
+}
+
+// parseAlarmPayload decodes body and reports whether it looks like an alarm
+// webhook (has alarm and sensors fields)
+func parseAlarmPayload(body []byte) (AlarmPayload, bool) {
+	var payload AlarmPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return payload, false
+	}
+	return payload, payload.Alarm.Name != "" && len(payload.Sensors) > 0
+}
+
+// formatAlarmMessage formats an alarm payload like console notifications
+func formatAlarmMessage(payload AlarmPayload) string {
+	a := &alarm.Alarm{
+		Name:        payload.Alarm.Name,
+		Description: payload.Alarm.Description,
+		Condition:   payload.Alarm.Condition,
+		Enabled:     true, // Assume enabled if we're receiving it
+	}
+
+	// Parse tags if present
+	if payload.Alarm.Tags != "" {
+		a.Tags = strings.Split(payload.Alarm.Tags, ",")
+		for i, tag := range a.Tags {
+			a.Tags[i] = strings.TrimSpace(tag)
+		}
+	}
+
+	// Create observation from sensors data
+	obs := &weather.Observation{Timestamp: time.Now().Unix()}
+	if t, err := time.Parse("2006-01-02 15:04:05 MST", payload.Timestamp); err == nil {
+		obs.Timestamp = t.Unix()
+	} else if t, err := time.Parse(time.RFC3339, payload.Timestamp); err == nil {
+		obs.Timestamp = t.Unix()
+	}
+
+	sensor := func(key string) (float64, bool) {
+		val, ok := payload.Sensors[key].(float64)
+		return val, ok
+	}
+	for key, field := range map[string]*float64{
+		"temperature_c":         &obs.AirTemperature,
+		"humidity":              &obs.RelativeHumidity,
+		"pressure_mb":           &obs.StationPressure,
+		"wind_speed_ms":         &obs.WindAvg,
+		"wind_gust_ms":          &obs.WindGust,
+		"wind_direction_deg":    &obs.WindDirection,
+		"illuminance_lux":       &obs.Illuminance,
+		"rain_rate_mmh":         &obs.RainAccumulated,
+		"rain_daily_mm":         &obs.RainDailyTotal,
+		"lightning_distance_km": &obs.LightningStrikeAvg,
+	} {
+		if val, ok := sensor(key); ok {
+			*field = val
+		}
+	}
+	if val, ok := sensor("uv_index"); ok {
+		obs.UV = int(val)
+	}
+	if val, ok := sensor("lightning_count"); ok {
+		obs.LightningStrikeCount = int(val)
+	}
+
+	return fmt.Sprintf("WEBHOOK ALARM: %s\n%s", payload.Alarm.Name, alarm.FormatAlarmText(a, obs))
+}
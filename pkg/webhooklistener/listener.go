@@ -0,0 +1,441 @@
+// Package webhooklistener receives alarm webhooks, for example from other
+// bridges' webhook channels. It checks the sender's address and HMAC
+// signature, logs alarms like console notifications, keeps them in a JSON
+// lines file and can forward them to another URL, which makes it a small
+// alarm aggregator across several stations.
+package webhooklistener
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tempest-homekit-go/pkg/logger"
+	"tempest-homekit-go/pkg/websec"
+)
+
+const (
+	// maxBodySize caps a webhook body; alarm payloads are a few KiB
+	maxBodySize = 1 << 20
+	// maxStored is how many received webhooks the store file and /alarms keep
+	maxStored = 1000
+	// forwardTimeout bounds one forwarding request
+	forwardTimeout = 10 * time.Second
+)
+
+// Config configures the listener
+type Config struct {
+	Port string
+	// Secret verifies the X-Tempest-Signature header of every webhook; empty
+	// accepts unsigned webhooks
+	Secret string
+	// AllowedSources are the networks webhooks are accepted from; empty
+	// accepts any address
+	AllowedSources []*net.IPNet
+	// StoreFile keeps received webhooks across restarts; empty keeps them in
+	// memory only
+	StoreFile string
+	// ForwardURL receives a copy of every accepted webhook, re-signed with
+	// Secret when one is set
+	ForwardURL string
+	Limits     websec.Limits
+}
+
+// Received is one accepted webhook
+type Received struct {
+	Time    time.Time       `json:"time"`
+	Remote  string          `json:"remote"`
+	Alarm   string          `json:"alarm,omitempty"`
+	Station string          `json:"station,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"` // JSON bodies
+	Body    string          `json:"body,omitempty"`    // other bodies
+}
+
+// Server is a webhook listener
+type Server struct {
+	cfg     Config
+	started time.Time
+	client  *http.Client
+	now     func() time.Time
+
+	mu       sync.Mutex
+	received []Received // oldest first, at most maxStored
+	store    *os.File
+	forwards sync.WaitGroup
+}
+
+// New creates a listener and loads the webhooks kept in cfg.StoreFile
+func New(cfg Config) (*Server, error) {
+	s := &Server{
+		cfg:     cfg,
+		started: time.Now(),
+		client:  &http.Client{Timeout: forwardTimeout},
+		now:     time.Now,
+	}
+	if cfg.StoreFile != "" {
+		if err := s.openStore(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// openStore loads the newest maxStored webhooks and opens the file for
+// appending, rewriting it first when it holds more than that
+func (s *Server) openStore() error {
+	data, err := os.ReadFile(s.cfg.StoreFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read webhook store: %w", err)
+	}
+	lines := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), maxBodySize*2)
+	for scanner.Scan() {
+		lines++
+		var rec Received
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			logger.Warn("Skipping unreadable line %d of %s: %v", lines, s.cfg.StoreFile, err)
+			continue
+		}
+		s.received = append(s.received, rec)
+	}
+	if len(s.received) > maxStored {
+		s.received = append([]Received(nil), s.received[len(s.received)-maxStored:]...)
+	}
+
+	if lines > len(s.received) {
+		var buf bytes.Buffer
+		for _, rec := range s.received {
+			line, _ := marshalLine(rec)
+			buf.Write(line)
+		}
+		if err := os.WriteFile(s.cfg.StoreFile, buf.Bytes(), 0600); err != nil {
+			return fmt.Errorf("failed to compact webhook store: %w", err)
+		}
+	}
+	f, err := os.OpenFile(s.cfg.StoreFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open webhook store: %w", err)
+	}
+	s.store = f
+	return nil
+}
+
+// Close waits for pending forwards and closes the store file
+func (s *Server) Close() error {
+	s.forwards.Wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.store == nil {
+		return nil
+	}
+	err := s.store.Close()
+	s.store = nil
+	return err
+}
+
+// Received returns the kept webhooks, newest first
+func (s *Server) Received() []Received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Received, len(s.received))
+	for i, rec := range s.received {
+		out[len(out)-1-i] = rec
+	}
+	return out
+}
+
+// ListenAndServe serves the listener until ctx is cancelled, then shuts
+// down gracefully
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{Addr: ":" + s.cfg.Port, Handler: s.Handler()}
+	errc := make(chan error, 1)
+	go func() { errc <- websec.ListenAndServe(srv, s.cfg.Limits) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Handler returns the listener's routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhook", s.handleWebhook)
+	mux.HandleFunc("/alarms", s.handleAlarms)
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/", s.handleRoot)
+	return mux
+}
+
+// allowed reports whether a request comes from an allowed source address
+func (s *Server) allowed(r *http.Request) bool {
+	if len(s.cfg.AllowedSources) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.cfg.AllowedSources {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		logger.Error("Webhook endpoint received invalid method: %s from %s", r.Method, r.RemoteAddr)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.allowed(r) {
+		logger.Warn("Rejected webhook from %s: source address not allowed", r.RemoteAddr)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		logger.Error("Failed to read webhook request body from %s: %v", r.RemoteAddr, err)
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if s.cfg.Secret != "" {
+		err := websec.VerifySignature(s.cfg.Secret, r.Header.Get(websec.SignatureHeader), r.Header.Get(websec.TimestampHeader), body, s.now())
+		if err != nil {
+			logger.Warn("Rejected webhook from %s: %v", r.RemoteAddr, err)
+			http.Error(w, "Invalid signature", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	// Log webhook reception at INFO level
+	logger.Info("Webhook received from %s (%d bytes)", r.RemoteAddr, len(body))
+
+	// Try to parse and format alarm data like console notifications
+	payload, isAlarm := parseAlarmPayload(body)
+	if isAlarm {
+		logger.Alarm("%s", formatAlarmMessage(payload))
+	}
+	logDetails(r, body)
+
+	rec := Received{Time: s.now().UTC(), Remote: r.RemoteAddr}
+	if json.Valid(body) {
+		rec.Payload = json.RawMessage(body)
+	} else {
+		rec.Body = string(body)
+	}
+	if isAlarm {
+		rec.Alarm = payload.Alarm.Name
+		rec.Station = payload.Station
+	}
+	s.keep(rec)
+
+	if s.cfg.ForwardURL != "" {
+		s.forwards.Add(1)
+		go func() {
+			defer s.forwards.Done()
+			s.forward(body, r.Header.Get("Content-Type"), r.RemoteAddr)
+		}()
+	}
+
+	// Send success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(`{"status":"ok","message":"Webhook received successfully"}`))
+}
+
+// logDetails logs the request and body at debug level
+func logDetails(r *http.Request, body []byte) {
+	logger.Debug("Webhook details - Method: %s, URL: %s, Content-Type: %s",
+		r.Method, r.URL.String(), r.Header.Get("Content-Type"))
+
+	if len(r.Header) > 0 {
+		headers := make([]string, 0, len(r.Header))
+		for key, values := range r.Header {
+			headers = append(headers, fmt.Sprintf("%s=%s", key, strings.Join(values, ",")))
+		}
+		logger.Debug("Webhook headers: %s", strings.Join(headers, "; "))
+	}
+
+	if len(body) == 0 {
+		logger.Debug("Webhook body: (empty)")
+		return
+	}
+	var jsonData interface{}
+	if err := json.Unmarshal(body, &jsonData); err == nil {
+		prettyJSON, _ := json.MarshalIndent(jsonData, "", "  ")
+		logger.Debug("Webhook body (JSON):\n%s", string(prettyJSON))
+	} else {
+		logger.Debug("Webhook body (text):\n%s", string(body))
+	}
+}
+
+// keep records an accepted webhook in memory and in the store file
+func (s *Server) keep(rec Received) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received = append(s.received, rec)
+	if len(s.received) > maxStored {
+		s.received = s.received[len(s.received)-maxStored:]
+	}
+	if s.store == nil {
+		return
+	}
+	line, err := marshalLine(rec)
+	if err == nil {
+		_, err = s.store.Write(line)
+	}
+	if err != nil {
+		logger.Error("Failed to store webhook from %s: %v", rec.Remote, err)
+	}
+}
+
+// marshalLine encodes rec as one store line, keeping the payload as sent
+// (json.Marshal would escape <, > and & in it)
+func marshalLine(rec Received) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(rec)
+	return buf.Bytes(), err
+}
+
+// forward posts a copy of an accepted webhook to cfg.ForwardURL
+func (s *Server) forward(body []byte, contentType, remote string) {
+	req, err := http.NewRequest(http.MethodPost, s.cfg.ForwardURL, bytes.NewReader(body))
+	if err != nil {
+		logger.Error("Failed to create webhook forward request: %v", err)
+		return
+	}
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		req.Header.Set("X-Forwarded-For", host)
+	}
+	if s.cfg.Secret != "" {
+		ts := s.now().Unix()
+		req.Header.Set(websec.TimestampHeader, strconv.FormatInt(ts, 10))
+		req.Header.Set(websec.SignatureHeader, websec.SignBody(s.cfg.Secret, ts, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		logger.Error("Failed to forward webhook to %s: %v", s.cfg.ForwardURL, err)
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		logger.Error("Forwarding webhook to %s failed with status %d", s.cfg.ForwardURL, resp.StatusCode)
+		return
+	}
+	logger.Debug("Webhook forwarded to %s", s.cfg.ForwardURL)
+}
+
+// handleAlarms lists the kept webhooks, newest first; ?limit=N returns the
+// newest N
+func (s *Server) handleAlarms(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	received := s.Received()
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit >= 0 && limit < len(received) {
+		received = received[:limit]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(received)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Health check request from %s", r.RemoteAddr)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(`{"status":"ok","service":"webhook-listener"}`))
+}
+
+func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Root endpoint request from %s", r.RemoteAddr)
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	signed := "not required"
+	if s.cfg.Secret != "" {
+		signed = "required (" + websec.SignatureHeader + ", " + websec.TimestampHeader + ")"
+	}
+	response := fmt.Sprintf(`Webhook Listener Server
+
+This server listens for incoming webhook requests.
+
+Endpoints:
+  POST /webhook  - Receive webhook payloads (logs to console)
+  GET  /alarms   - Received webhooks, newest first (?limit=N)
+  GET  /health   - Health check endpoint
+
+Send webhooks to: http://localhost:%s/webhook
+Signature: %s
+
+Server started at: %s
+`, s.cfg.Port, signed, s.started.Format("2006-01-02 15:04:05"))
+	_, _ = w.Write([]byte(response))
+}
+
+// ParseSources parses a comma-separated list of IP addresses and CIDR
+// networks
+func ParseSources(list string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q", entry)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
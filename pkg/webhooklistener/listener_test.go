@@ -0,0 +1,144 @@
+package webhooklistener
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"tempest-homekit-go/pkg/websec"
+)
+
+const alarmBody = `{"alarm":{"name":"Hot","condition":"temperature > 30","tags":"heat, summer"},"station":"Backyard","timestamp":"2025-07-01T12:00:00Z","sensors":{"temperature_c":31.5,"humidity":40}}`
+
+func post(t *testing.T, h http.Handler, remote, body string, header map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	req.RemoteAddr = remote
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func signed(secret, body string, at time.Time) map[string]string {
+	return map[string]string{
+		websec.TimestampHeader: strconv.FormatInt(at.Unix(), 10),
+		websec.SignatureHeader: websec.SignBody(secret, at.Unix(), []byte(body)),
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	s, err := New(Config{Secret: "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := s.Handler()
+	now := time.Now()
+
+	if rec := post(t, h, "192.0.2.1:5000", alarmBody, signed("s3cret", alarmBody, now)); rec.Code != http.StatusOK {
+		t.Fatalf("signed webhook: %d %s", rec.Code, rec.Body)
+	}
+	for name, header := range map[string]map[string]string{
+		"unsigned":     nil,
+		"wrong secret": signed("other", alarmBody, now),
+		"stale":        signed("s3cret", alarmBody, now.Add(-time.Hour)),
+		"other body":   signed("s3cret", `{"alarm":{}}`, now),
+	} {
+		if rec := post(t, h, "192.0.2.1:5000", alarmBody, header); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: %d", name, rec.Code)
+		}
+	}
+	if got := s.Received(); len(got) != 1 || got[0].Alarm != "Hot" || got[0].Station != "Backyard" {
+		t.Errorf("received: %+v", got)
+	}
+}
+
+func TestWebhookAllowedSources(t *testing.T) {
+	sources, err := ParseSources("10.0.0.0/8, 192.0.2.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := New(Config{AllowedSources: sources})
+	h := s.Handler()
+	for remote, want := range map[string]int{
+		"10.1.2.3:4000":   http.StatusOK,
+		"192.0.2.7:4000":  http.StatusOK,
+		"192.0.2.8:4000":  http.StatusForbidden,
+		"[2001:db8::1]:1": http.StatusForbidden,
+	} {
+		if rec := post(t, h, remote, alarmBody, nil); rec.Code != want {
+			t.Errorf("%s: %d, want %d", remote, rec.Code, want)
+		}
+	}
+	if _, err := ParseSources("10.0.0.0/33"); err == nil {
+		t.Error("invalid network accepted")
+	}
+}
+
+func TestWebhookStoreAndForward(t *testing.T) {
+	forwarded := make(chan *http.Request, 1)
+	var forwardedBody []byte
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwardedBody, _ = io.ReadAll(r.Body)
+		forwarded <- r
+	}))
+	defer target.Close()
+
+	store := filepath.Join(t.TempDir(), "webhook-alarms.jsonl")
+	s, err := New(Config{Secret: "s3cret", StoreFile: store, ForwardURL: target.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	post(t, s.Handler(), "192.0.2.1:5000", alarmBody, signed("s3cret", alarmBody, time.Now()))
+
+	select {
+	case r := <-forwarded:
+		err := websec.VerifySignature("s3cret", r.Header.Get(websec.SignatureHeader), r.Header.Get(websec.TimestampHeader), forwardedBody, time.Now())
+		if err != nil || string(forwardedBody) != alarmBody || r.Header.Get("X-Forwarded-For") != "192.0.2.1" {
+			t.Errorf("forwarded %q (%v), X-Forwarded-For %q", forwardedBody, err, r.Header.Get("X-Forwarded-For"))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not forwarded")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The store survives a restart and /alarms lists it
+	s, err = New(Config{StoreFile: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/alarms", nil))
+	var got []Received
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got) != 1 || got[0].Alarm != "Hot" || string(got[0].Payload) != alarmBody {
+		t.Errorf("stored alarms: %+v (%v)", got, err)
+	}
+}
+
+func TestFormatAlarmMessage(t *testing.T) {
+	payload, ok := parseAlarmPayload([]byte(alarmBody))
+	if !ok {
+		t.Fatal("alarm payload not recognized")
+	}
+	msg := formatAlarmMessage(payload)
+	for _, want := range []string{"WEBHOOK ALARM: Hot", "Condition: temperature > 30", "Tags: heat, summer", "Current Conditions:"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+	if _, ok := parseAlarmPayload([]byte(`{"hello":"world"}`)); ok {
+		t.Error("plain JSON taken for an alarm")
+	}
+}
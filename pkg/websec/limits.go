@@ -0,0 +1,67 @@
+package websec
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/netutil"
+)
+
+// Limits bounds how long a client may take and how many may be connected
+// at once, so slow or runaway clients cannot exhaust the server. Zero
+// values disable the corresponding limit.
+type Limits struct {
+	// ReadHeaderTimeout is how long a client may take to send the request
+	// headers; it is the slow-loris guard
+	ReadHeaderTimeout time.Duration
+	// WriteTimeout bounds writing a response. Streaming handlers such as
+	// /api/stream clear it for their connection.
+	WriteTimeout time.Duration
+	// IdleTimeout closes keep-alive connections without a new request
+	IdleTimeout time.Duration
+	// MaxConns caps concurrent connections; further clients wait to be
+	// accepted until one closes
+	MaxConns int
+}
+
+// DefaultLimits are the limits used unless configured otherwise
+var DefaultLimits = Limits{
+	ReadHeaderTimeout: 10 * time.Second,
+	WriteTimeout:      60 * time.Second,
+	IdleTimeout:       120 * time.Second,
+	MaxConns:          256,
+}
+
+// Apply sets the timeouts on srv
+func (l Limits) Apply(srv *http.Server) {
+	srv.ReadHeaderTimeout = l.ReadHeaderTimeout
+	srv.WriteTimeout = l.WriteTimeout
+	srv.IdleTimeout = l.IdleTimeout
+}
+
+// Listen opens a TCP listener on addr that accepts at most l.MaxConns
+// connections at a time
+func (l Limits) Listen(addr string) (net.Listener, error) {
+	if addr == "" {
+		addr = ":http"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if l.MaxConns > 0 {
+		ln = netutil.LimitListener(ln, l.MaxConns)
+	}
+	return ln, nil
+}
+
+// ListenAndServe is http.Server.ListenAndServe with the limits applied
+func ListenAndServe(srv *http.Server, l Limits) error {
+	l.Apply(srv)
+	ln, err := l.Listen(srv.Addr)
+	if err != nil {
+		return err
+	}
+	return srv.Serve(ln)
+}
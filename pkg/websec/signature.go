@@ -0,0 +1,52 @@
+package websec
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook signature headers. The signature is "sha256=" followed by the hex
+// HMAC-SHA256 of "<timestamp>.<body>" under a shared secret; the timestamp
+// (Unix seconds) is signed too so a captured request cannot be replayed
+// later.
+const (
+	SignatureHeader = "X-Tempest-Signature"
+	TimestampHeader = "X-Tempest-Timestamp"
+)
+
+// SignatureTolerance is how far a signed timestamp may be from the
+// receiver's clock
+const SignatureTolerance = 5 * time.Minute
+
+// SignBody returns the signature header value for body sent at timestamp
+func SignBody(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks the signature and timestamp headers of a received
+// body against secret
+func VerifySignature(secret, signature, timestamp string, body []byte, now time.Time) error {
+	if signature == "" || timestamp == "" {
+		return errors.New("missing " + SignatureHeader + " or " + TimestampHeader + " header")
+	}
+	ts, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return errors.New("invalid " + TimestampHeader + " header")
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > SignatureTolerance || skew < -SignatureTolerance {
+		return errors.New("signature timestamp outside the allowed window")
+	}
+	if !hmac.Equal([]byte(strings.TrimSpace(signature)), []byte(SignBody(secret, ts, body))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
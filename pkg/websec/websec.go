@@ -0,0 +1,118 @@
+// Package websec adds CORS and browser security headers to the web console
+// and alarm editor servers.
+package websec
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultContentSecurityPolicy allows the pages' own scripts, styles and
+// API calls. Inline scripts and styles stay allowed because the dashboard
+// and alarm editor use inline handlers and style attributes. Third-party
+// libraries, Swagger UI included, are vendored, so no other site is allowed.
+// frame-ancestors is added from Policy.FrameAncestors.
+const DefaultContentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' 'unsafe-inline'; " +
+	"style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; " +
+	"connect-src 'self'; " +
+	"base-uri 'self'; " +
+	"form-action 'self'; " +
+	"object-src 'none'"
+
+// corsMaxAge is how long browsers may cache a preflight answer, in seconds
+const corsMaxAge = 600
+
+// Policy configures the headers Wrap adds
+type Policy struct {
+	// CORSOrigins are the origins (scheme://host[:port]) allowed to call the
+	// API from another site. "*" allows any origin; none allows only
+	// same-origin requests, which need no CORS headers.
+	CORSOrigins []string
+	// ContentSecurityPolicy replaces DefaultContentSecurityPolicy when set
+	ContentSecurityPolicy string
+	// FrameAncestors are the CSP sources allowed to embed the pages in a
+	// frame, e.g. a Home Assistant dashboard. Empty forbids framing.
+	FrameAncestors string
+	// ExposeHeaders are response headers cross-origin scripts may read
+	ExposeHeaders []string
+}
+
+// ParseOrigins splits a comma-separated origin list, dropping empty entries
+// and trailing slashes
+func ParseOrigins(list string) []string {
+	var origins []string
+	for _, origin := range strings.Split(list, ",") {
+		if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// allows reports whether a request from origin may read responses
+func (p Policy) allows(origin string) bool {
+	for _, allowed := range p.CORSOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Wrap returns next with the policy's security headers on every response.
+// Cross-origin requests from an allowed origin get CORS headers, and their
+// preflight OPTIONS requests are answered without reaching next.
+func Wrap(p Policy, next http.Handler) http.Handler {
+	csp := p.ContentSecurityPolicy
+	if csp == "" {
+		csp = DefaultContentSecurityPolicy
+	}
+	frameAncestors := strings.TrimSpace(p.FrameAncestors)
+	if frameAncestors == "" {
+		frameAncestors = "'none'"
+	}
+	if !strings.Contains(csp, "frame-ancestors") {
+		csp += "; frame-ancestors " + frameAncestors
+	}
+	expose := strings.Join(p.ExposeHeaders, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Content-Security-Policy", csp)
+		if frameAncestors == "'none'" {
+			// For browsers without CSP frame-ancestors support
+			h.Set("X-Frame-Options", "DENY")
+		}
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("Referrer-Policy", "same-origin")
+		h.Set("Permissions-Policy", "camera=(), microphone=(), geolocation=()")
+		h.Set("Cross-Origin-Opener-Policy", "same-origin")
+
+		origin := r.Header.Get("Origin")
+		if origin == "" || len(p.CORSOrigins) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h.Add("Vary", "Origin")
+		if !p.allows(origin) {
+			// No CORS headers: the browser keeps the response from the page
+			next.ServeHTTP(w, r)
+			return
+		}
+		h.Set("Access-Control-Allow-Origin", origin)
+		if expose != "" {
+			h.Set("Access-Control-Expose-Headers", expose)
+		}
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-None-Match")
+			h.Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,72 @@
+package websec
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serve(p Policy, method, origin string, header map[string]string) (*httptest.ResponseRecorder, bool) {
+	reached := false
+	h := Wrap(p, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(method, "/api/weather", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec, reached
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	rec, _ := serve(Policy{}, http.MethodGet, "", nil)
+	h := rec.Header()
+	if csp := h.Get("Content-Security-Policy"); !strings.HasPrefix(csp, DefaultContentSecurityPolicy) || !strings.HasSuffix(csp, "frame-ancestors 'none'") || strings.Contains(csp, "https:") {
+		t.Errorf("CSP = %q", csp)
+	}
+	if h.Get("X-Frame-Options") != "DENY" || h.Get("X-Content-Type-Options") != "nosniff" || h.Get("Referrer-Policy") == "" {
+		t.Errorf("headers = %v", h)
+	}
+
+	// Framing by a dashboard host, and a custom policy that sets its own ancestors
+	rec, _ = serve(Policy{FrameAncestors: "https://ha.local:8123"}, http.MethodGet, "", nil)
+	if rec.Header().Get("X-Frame-Options") != "" || !strings.HasSuffix(rec.Header().Get("Content-Security-Policy"), "frame-ancestors https://ha.local:8123") {
+		t.Errorf("frame ancestors: %v", rec.Header())
+	}
+	rec, _ = serve(Policy{ContentSecurityPolicy: "default-src *; frame-ancestors *"}, http.MethodGet, "", nil)
+	if rec.Header().Get("Content-Security-Policy") != "default-src *; frame-ancestors *" {
+		t.Errorf("custom CSP = %q", rec.Header().Get("Content-Security-Policy"))
+	}
+}
+
+func TestCORS(t *testing.T) {
+	p := Policy{CORSOrigins: ParseOrigins(" https://ha.local:8123/ ,"), ExposeHeaders: []string{"ETag"}}
+
+	rec, _ := serve(p, http.MethodGet, "https://ha.local:8123", nil)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://ha.local:8123" || rec.Header().Get("Access-Control-Expose-Headers") != "ETag" {
+		t.Errorf("allowed origin: %v", rec.Header())
+	}
+	rec, reached := serve(p, http.MethodGet, "https://evil.example", nil)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" || !reached || rec.Header().Get("Vary") != "Origin" {
+		t.Errorf("other origin: %v", rec.Header())
+	}
+	if rec, _ := serve(Policy{}, http.MethodGet, "https://ha.local:8123", nil); rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("CORS headers sent without configured origins")
+	}
+	if rec, _ := serve(Policy{CORSOrigins: []string{"*"}}, http.MethodGet, "http://anything", nil); rec.Header().Get("Access-Control-Allow-Origin") != "http://anything" {
+		t.Error("* did not allow any origin")
+	}
+
+	// Preflight is answered by the middleware
+	rec, reached = serve(p, http.MethodOptions, "https://ha.local:8123", map[string]string{"Access-Control-Request-Method": "POST"})
+	if reached || rec.Code != http.StatusNoContent || !strings.Contains(rec.Header().Get("Access-Control-Allow-Methods"), "POST") {
+		t.Errorf("preflight: %d, reached %v, %v", rec.Code, reached, rec.Header())
+	}
+}
@@ -95,7 +95,9 @@ After=network.target
 Wants=network.target
 
 [Service]
-Type=simple
+Type=notify
+NotifyAccess=main
+WatchdogSec=60
 User=tempest
 Group=tempest
 ExecStart=$INSTALL_DIR/tempest-homekit-go
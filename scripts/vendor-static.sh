@@ -0,0 +1,112 @@
+#!/bin/bash
+
+# Tempest HomeKit Go Vendored Asset Updater
+# Downloads the third-party JavaScript the dashboard serves (Chart.js, its
+# date-fns adapter, the QR code generator and Swagger UI for /api/docs) into
+# pkg/web/static and checks every file against pkg/web/static/vendor.sha256
+# before replacing anything.
+#
+# Usage:
+#   ./scripts/vendor-static.sh            # download pinned versions, verify, install
+#   ./scripts/vendor-static.sh --verify   # only check the committed files
+#   ./scripts/vendor-static.sh --update   # after bumping a version: install and record new checksums
+
+set -e
+
+CHART_VERSION="4.4.4"
+ADAPTER_VERSION="3.0.0"
+QRCODE_VERSION="1.4.4"
+SWAGGER_UI_VERSION="5.17.14"
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+VENDOR_DIR="$SCRIPT_DIR/../pkg/web/static"
+CHECKSUMS="vendor.sha256"
+
+# file name, then source URL
+ASSETS=(
+    "chart.umd.js" "https://unpkg.com/chart.js@${CHART_VERSION}/dist/chart.umd.js"
+    "chartjs-adapter-date-fns.bundle.min.js" "https://unpkg.com/chartjs-adapter-date-fns@${ADAPTER_VERSION}/dist/chartjs-adapter-date-fns.bundle.min.js"
+    "qrcode.min.js" "https://unpkg.com/qrcode-generator@${QRCODE_VERSION}/qrcode.js"
+    "swagger-ui-bundle.js" "https://unpkg.com/swagger-ui-dist@${SWAGGER_UI_VERSION}/swagger-ui-bundle.js"
+    "swagger-ui.css" "https://unpkg.com/swagger-ui-dist@${SWAGGER_UI_VERSION}/swagger-ui.css"
+)
+
+RED='\033[0;31m'
+GREEN='\033[0;32m'
+BLUE='\033[0;34m'
+NC='\033[0m' # No Color
+
+print_status() {
+    echo -e "${BLUE}[INFO]${NC} $1"
+}
+
+print_success() {
+    echo -e "${GREEN}[SUCCESS]${NC} $1"
+}
+
+print_error() {
+    echo -e "${RED}[ERROR]${NC} $1"
+}
+
+# sha256sum on Linux, shasum on macOS
+sha256() {
+    if command -v sha256sum >/dev/null 2>&1; then
+        sha256sum "$@"
+    else
+        shasum -a 256 "$@"
+    fi
+}
+
+# verify_dir checks every asset in $1 against its recorded checksum; an asset
+# without one fails rather than being installed unchecked
+verify_dir() {
+    for ((i = 0; i < ${#ASSETS[@]}; i += 2)); do
+        if ! grep -q "  ${ASSETS[i]}\$" "$VENDOR_DIR/$CHECKSUMS"; then
+            print_error "No checksum for ${ASSETS[i]} in $CHECKSUMS; run with --update and review it"
+            return 1
+        fi
+    done
+    (cd "$1" && sha256 -c "$VENDOR_DIR/$CHECKSUMS" >/dev/null)
+}
+
+MODE="${1:-install}"
+case "$MODE" in
+    --verify)
+        if verify_dir "$VENDOR_DIR"; then
+            print_success "Vendored assets match $CHECKSUMS"
+            exit 0
+        fi
+        print_error "Vendored assets do not match $CHECKSUMS"
+        exit 1
+        ;;
+    install|--update)
+        ;;
+    *)
+        print_error "Unknown option: $MODE"
+        exit 2
+        ;;
+esac
+
+TMP_DIR="$(mktemp -d)"
+trap 'rm -rf "$TMP_DIR"' EXIT
+
+for ((i = 0; i < ${#ASSETS[@]}; i += 2)); do
+    name="${ASSETS[i]}"
+    url="${ASSETS[i+1]}"
+    print_status "Downloading $url"
+    curl -fsSL "$url" -o "$TMP_DIR/$name"
+done
+
+if [ "$MODE" = "--update" ]; then
+    (cd "$TMP_DIR" && for ((i = 0; i < ${#ASSETS[@]}; i += 2)); do sha256 "${ASSETS[i]}"; done) > "$TMP_DIR/$CHECKSUMS"
+    cp "$TMP_DIR/$CHECKSUMS" "$VENDOR_DIR/$CHECKSUMS"
+    print_status "Recorded new checksums in $CHECKSUMS; review the diff before committing"
+elif ! verify_dir "$TMP_DIR"; then
+    print_error "Downloaded files do not match $CHECKSUMS; nothing was replaced"
+    exit 1
+fi
+
+for ((i = 0; i < ${#ASSETS[@]}; i += 2)); do
+    cp "$TMP_DIR/${ASSETS[i]}" "$VENDOR_DIR/${ASSETS[i]}"
+done
+print_success "Vendored Chart.js v$CHART_VERSION, adapter v$ADAPTER_VERSION, qrcode-generator v$QRCODE_VERSION and Swagger UI v$SWAGGER_UI_VERSION"